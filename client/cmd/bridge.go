@@ -0,0 +1,108 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/burrow/client/methods"
+	"github.com/hyperledger/burrow/util"
+)
+
+func buildBridgeCommand() *cobra.Command {
+	bridgeCmd := &cobra.Command{
+		Use:   "bridge",
+		Short: "burrow-client bridge attests events designated for the bridge to Ethereum",
+		Long: `burrow-client bridge attests events designated for the bridge to Ethereum.
+
+Governance designates the events of contracts that are bridged. Each validator
+runs burrow-client bridge attest, which watches its node for the logs of those
+events and attests each of them with an AttestTx, signed by the validator key
+and by the secp256k1 bridge key of the validator. An attestation is complete
+once validators with more than two thirds of the power have attested, and its
+signatures can then be submitted to the contract on Ethereum, which must hold
+the Ethereum addresses of the bridge keys of the validators.
+`,
+		Example: `$ burrow-client bridge address --bridge-key-file bridge.key
+$ burrow-client bridge attest --addr $VALIDATOR_ADDR --bridge-key-file bridge.key
+$ burrow-client bridge attestation --id $ID`,
+		Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+	}
+	nodeFlags := func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+	}
+	bridgeKeyFlags := func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&clientDo.BridgeKeyFileFlag, "bridge-key-file", "", "", "specify the file holding the hex secp256k1 secret key the validator attests with")
+	}
+
+	addressCmd := &cobra.Command{
+		Use:   "address",
+		Short: "burrow-client bridge address --bridge-key-file <file>",
+		Long: "burrow-client bridge address --bridge-key-file <file>\n" +
+			"writes the Ethereum address of the bridge key.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.BridgeAddress(clientDo); err != nil {
+				util.Fatalf("Could not read bridge key: %s", err)
+			}
+		},
+	}
+	bridgeKeyFlags(addressCmd)
+
+	attestCmd := &cobra.Command{
+		Use:   "attest",
+		Short: "burrow-client bridge attest --addr <validator addr> --bridge-key-file <file>",
+		Long: "burrow-client bridge attest --addr <validator addr> --bridge-key-file <file>\n" +
+			"watches the node for the events designated for the bridge and attests\n" +
+			"each of them until the node stops. Events designated after it starts\n" +
+			"are watched once it is restarted.",
+		Run: func(cmd *cobra.Command, args []string) {
+			// attestations are always broadcast as soon as they are signed
+			clientDo.BroadcastFlag = true
+			if err := methods.BridgeAttest(clientDo); err != nil {
+				util.Fatalf("Stopped attesting events: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	nodeFlags(attestCmd)
+	bridgeKeyFlags(attestCmd)
+	addKeyDaemonTLSFlags(attestCmd)
+	attestCmd.Flags().StringVarP(&clientDo.SignAddrFlag, "sign-addr", "", defaultKeyDaemonAddress(), "set monax-keys daemon address (default respects $BURROW_CLIENT_SIGN_ADDRESS)")
+	attestCmd.Flags().StringVarP(&clientDo.PubkeyFlag, "pubkey", "", defaultPublicKey(), "specify the validator public key to sign with (defaults to $BURROW_CLIENT_PUBLIC_KEY)")
+	attestCmd.Flags().StringVarP(&clientDo.AddrFlag, "addr", "", defaultAddress(), "specify the validator address (for which the public key can be found at monax-keys) (default respects $BURROW_CLIENT_ADDRESS)")
+	attestCmd.Flags().StringVarP(&clientDo.ChainidFlag, "chain-id", "", defaultChainId(), "specify the chainID (default respects $CHAIN_ID)")
+	attestCmd.Flags().StringVarP(&clientDo.KeyFileFlag, "key-file", "", "", "sign with the validator key in a plain JSON key file or keystore rather than monax-keys")
+	attestCmd.Flags().StringVarP(&clientDo.PassphraseFileFlag, "passphrase-file", "", "", "file holding the passphrase of the --key-file keystore on its first line (else $BURROW_KEYS_PASSPHRASE, else stdin)")
+	attestCmd.Flags().BoolVarP(&clientDo.WaitFlag, "wait", "w", false, "wait for each attestation to be committed in a block")
+
+	attestationCmd := &cobra.Command{
+		Use:   "attestation",
+		Short: "burrow-client bridge attestation --id <id>",
+		Long: "burrow-client bridge attestation --id <id>\n" +
+			"writes the attestation with the hex ID as JSON, with the signatures\n" +
+			"of the validators that have attested its event.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.BridgeAttestation(clientDo); err != nil {
+				util.Fatalf("Could not get attestation: %s", err)
+			}
+		},
+		PreRun: assertAddresses,
+	}
+	nodeFlags(attestationCmd)
+	attestationCmd.Flags().StringVarP(&clientDo.AttestationIDFlag, "id", "", "", "specify the hex ID of the attestation")
+
+	bridgeCmd.AddCommand(addressCmd, attestCmd, attestationCmd)
+	return bridgeCmd
+}
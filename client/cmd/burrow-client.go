@@ -59,6 +59,12 @@ func AddGlobalFlags() {
 func AddClientCommands() {
 	BurrowClientCmd.AddCommand(buildTransactionCommand())
 	BurrowClientCmd.AddCommand(buildStatusCommand())
+	BurrowClientCmd.AddCommand(buildVerifyCommand())
+	BurrowClientCmd.AddCommand(buildVerifyContractCommand())
+	BurrowClientCmd.AddCommand(buildCallCommand())
+	BurrowClientCmd.AddCommand(buildMultisigCommand())
+	BurrowClientCmd.AddCommand(buildBridgeCommand())
+	BurrowClientCmd.AddCommand(buildConfidentialCommand())
 
 	buildGenesisGenCommand()
 	BurrowClientCmd.AddCommand(GenesisGenCmd)
@@ -0,0 +1,63 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/burrow/client/methods"
+	"github.com/hyperledger/burrow/util"
+)
+
+func buildCallCommand() *cobra.Command {
+	callCmd := &cobra.Command{
+		Use:   "call <address> <method> [args...]",
+		Short: "burrow-client call calls a function of a contract by its ABI.",
+		Long: `burrow-client call encodes a call to a function of the contract at an address
+with the ABI registered for it, or the ABI in --abi-file, and decodes the
+values the function returns and the events it emits. The method is a function
+name, or its full signature such as transfer(address,uint256) when it is
+overloaded by inputs of the same number.
+
+Arguments of elementary types are given as they are, with numbers in decimal or
+0x prefixed hex and addresses and bytes in hex. Arrays and structs are given as
+JSON arrays, or objects keyed by field name for structs.
+
+Constant functions are run as a simulated call on the node, as is any function
+with --simulate. Other functions are called by a CallTx signed as with
+burrow-client tx.
+`,
+		Example: `$ burrow-client call <address> balanceOf <owner>
+$ burrow-client call <address> transfer <to> 100 --chain-id <chain> --addr <sender> --gas 100000
+$ burrow-client call <address> addPeople '[{"name": "bob", "age": 99}]' --chain-id <chain> --addr <sender> --gas 100000`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) < 2 {
+				util.Fatalf("Please give the address of the contract and the method to call.")
+			}
+			err := methods.ContractCall(clientDo, args[0], args[1], args[2:])
+			if err != nil {
+				util.Fatalf("Could not call contract: %s", err)
+			}
+		},
+		PreRun: assertAddresses,
+	}
+	addTransactionPersistentFlags(callCmd)
+	callCmd.Flags().StringVarP(&clientDo.ABIFileFlag, "abi-file", "", "", "specify a file with the JSON ABI, rather than the ABI registered for the contract")
+	callCmd.Flags().BoolVarP(&clientDo.SimulateFlag, "simulate", "", false, "run the function as a simulated call even if it is not constant")
+	callCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "0", "specify an amount to send with a CallTx")
+	callCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "0", "specify the fee of a CallTx")
+	callCmd.Flags().StringVarP(&clientDo.GasFlag, "gas", "g", "1000000", "specify the gas limit of a CallTx")
+	return callCmd
+}
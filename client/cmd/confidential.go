@@ -0,0 +1,87 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/burrow/client/methods"
+	"github.com/hyperledger/burrow/util"
+)
+
+func buildConfidentialCommand() *cobra.Command {
+	confidentialCmd := &cobra.Command{
+		Use:   "confidential",
+		Short: "burrow-client confidential seals and reads blobs of the confidential store",
+		Long: `burrow-client confidential seals and reads blobs of the confidential store.
+
+The Confidential native contract stores blobs sealed by the nodes holding the
+key of the confidential store, with the accounts each owner grants to read
+them. An owner has a node seal its data with seal and puts the blob with a call
+to the contract. An account granted by the owner reads the data with read,
+which signs the read with the key of the account so the node only opens the
+blob for it.
+`,
+		Example: `$ burrow-client confidential seal --owner $OWNER --blob-key 01 --data $DATA
+$ burrow-client confidential read --addr $READER --owner $OWNER --blob-key 01`,
+		Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+	}
+	blobFlags := func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+		cmd.Flags().StringVarP(&clientDo.ConfidentialOwnerFlag, "owner", "", "", "specify the hex address of the owner of the blob")
+		cmd.Flags().StringVarP(&clientDo.ConfidentialKeyFlag, "blob-key", "", "", "specify the hex key the blob is put at, left padded to 32 bytes")
+	}
+
+	sealCmd := &cobra.Command{
+		Use:   "seal",
+		Short: "burrow-client confidential seal --owner <addr> --blob-key <key> --data <hex>",
+		Long: "burrow-client confidential seal --owner <addr> --blob-key <key> --data <hex>\n" +
+			"writes the hex blob the node seals the data into, for the owner to put\n" +
+			"at the key.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.ConfidentialSeal(clientDo); err != nil {
+				util.Fatalf("Could not seal blob: %s", err)
+			}
+		},
+		PreRun: assertAddresses,
+	}
+	blobFlags(sealCmd)
+	sealCmd.Flags().StringVarP(&clientDo.DataFlag, "data", "", "", "specify the hex data to seal")
+
+	readCmd := &cobra.Command{
+		Use:   "read",
+		Short: "burrow-client confidential read --addr <reader addr> --owner <addr> --blob-key <key>",
+		Long: "burrow-client confidential read --addr <reader addr> --owner <addr> --blob-key <key>\n" +
+			"signs a read of the blob with the key of the reader and writes the hex\n" +
+			"data the node opens it to, if the owner has granted the reader.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.ConfidentialRead(clientDo); err != nil {
+				util.Fatalf("Could not read blob: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	blobFlags(readCmd)
+	addKeyDaemonTLSFlags(readCmd)
+	readCmd.Flags().StringVarP(&clientDo.SignAddrFlag, "sign-addr", "", defaultKeyDaemonAddress(), "set monax-keys daemon address (default respects $BURROW_CLIENT_SIGN_ADDRESS)")
+	readCmd.Flags().StringVarP(&clientDo.PubkeyFlag, "pubkey", "", defaultPublicKey(), "specify the public key of the reader to sign with (defaults to $BURROW_CLIENT_PUBLIC_KEY)")
+	readCmd.Flags().StringVarP(&clientDo.AddrFlag, "addr", "", defaultAddress(), "specify the address of the reader (for which the public key can be found at monax-keys) (default respects $BURROW_CLIENT_ADDRESS)")
+	readCmd.Flags().StringVarP(&clientDo.ChainidFlag, "chain-id", "", defaultChainId(), "specify the chainID (default respects $CHAIN_ID)")
+	readCmd.Flags().StringVarP(&clientDo.KeyFileFlag, "key-file", "", "", "sign with the key of the reader in a plain JSON key file or keystore rather than monax-keys")
+	readCmd.Flags().StringVarP(&clientDo.PassphraseFileFlag, "passphrase-file", "", "", "file holding the passphrase of the --key-file keystore on its first line (else $BURROW_KEYS_PASSPHRASE, else stdin)")
+
+	confidentialCmd.AddCommand(sealCmd, readCmd)
+	return confidentialCmd
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/burrow/client/methods"
+	"github.com/hyperledger/burrow/util"
+)
+
+func buildMultisigCommand() *cobra.Command {
+	multisigCmd := &cobra.Command{
+		Use:   "multisig",
+		Short: "burrow-client multisig forms, signs and combines transactions from multisig accounts",
+		Long: `burrow-client multisig forms, signs and combines transactions from multisig accounts.
+
+The address of a multisig account is the hash of a threshold and the public
+keys of its signatories, and a transaction from it must be signed by at least
+threshold of them. An unsigned transaction is written as JSON, passed to each
+signatory to sign offline, and the signed copies combined and broadcast.
+`,
+		Example: `$ burrow-client multisig send --threshold 2 --signatories $ALICE,$BOB,$CAROL --amt 10 --to $ADDR > tx.json
+$ burrow-client multisig sign --addr $ALICE_ADDR tx.json > alice.json
+$ burrow-client multisig sign --addr $BOB_ADDR tx.json > bob.json
+$ burrow-client multisig combine alice.json bob.json > signed.json
+$ burrow-client multisig broadcast signed.json`,
+		Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+	}
+	multisigCmd.PersistentFlags().StringVarP(&clientDo.ChainidFlag, "chain-id", "", defaultChainId(), "specify the chainID (default respects $CHAIN_ID)")
+
+	signatoryFlags := func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&clientDo.ThresholdFlag, "threshold", "", "", "specify the number of signatories that must sign")
+		cmd.Flags().StringVarP(&clientDo.SignatoriesFlag, "signatories", "", "", "specify the comma separated public keys of the signatories")
+	}
+	nodeFlags := func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+		cmd.Flags().StringVarP(&clientDo.NonceFlag, "nonce", "", "", "specify the nonce to use for the transaction (should equal the multisig account's nonce + 1)")
+	}
+
+	addressCmd := &cobra.Command{
+		Use:   "address",
+		Short: "burrow-client multisig address --threshold <n> --signatories <pubkeys>",
+		Long:  "burrow-client multisig address --threshold <n> --signatories <pubkeys>",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.MultisigAddress(clientDo); err != nil {
+				util.Fatalf("Could not make multisig address: %s", err)
+			}
+		},
+	}
+	signatoryFlags(addressCmd)
+
+	sendCmd := &cobra.Command{
+		Use:   "send",
+		Short: "burrow-client multisig send --threshold <n> --signatories <pubkeys> --amt <amt> --to <addr>",
+		Long:  "burrow-client multisig send --threshold <n> --signatories <pubkeys> --amt <amt> --to <addr>",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.MultisigSend(clientDo); err != nil {
+				util.Fatalf("Could not form send: %s", err)
+			}
+		},
+	}
+	signatoryFlags(sendCmd)
+	nodeFlags(sendCmd)
+	sendCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount")
+	sendCmd.Flags().StringVarP(&clientDo.ToFlag, "to", "t", "", "specify an address to send to")
+
+	callCmd := &cobra.Command{
+		Use:   "call",
+		Short: "burrow-client multisig call --threshold <n> --signatories <pubkeys> --amt <amt> --fee <fee> --gas <gas> --to <contract addr> --data <data>",
+		Long:  "burrow-client multisig call --threshold <n> --signatories <pubkeys> --amt <amt> --fee <fee> --gas <gas> --to <contract addr> --data <data>",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.MultisigCall(clientDo); err != nil {
+				util.Fatalf("Could not form call: %s", err)
+			}
+		},
+	}
+	signatoryFlags(callCmd)
+	nodeFlags(callCmd)
+	callCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount")
+	callCmd.Flags().StringVarP(&clientDo.ToFlag, "to", "t", "", "specify an address to send to")
+	callCmd.Flags().StringVarP(&clientDo.DataFlag, "data", "", "", "specify some data")
+	callCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "", "specify the fee to send")
+	callCmd.Flags().StringVarP(&clientDo.GasFlag, "gas", "g", "", "specify the gas limit for a CallTx")
+
+	signCmd := &cobra.Command{
+		Use:   "sign <file>",
+		Short: "burrow-client multisig sign --addr <addr> <file>",
+		Long:  "burrow-client multisig sign --addr <addr> <file> signs the transaction in file with monax-keys as one of its signatories",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.Fatalf("Give the file of the transaction to sign")
+			}
+			if err := methods.MultisigSign(clientDo, args[0]); err != nil {
+				util.Fatalf("Could not sign: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	signCmd.Flags().StringVarP(&clientDo.SignAddrFlag, "sign-addr", "", defaultKeyDaemonAddress(), "set monax-keys daemon address (default respects $BURROW_CLIENT_SIGN_ADDRESS)")
+	addKeyDaemonTLSFlags(signCmd)
+	signCmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+	signCmd.Flags().StringVarP(&clientDo.PubkeyFlag, "pubkey", "", defaultPublicKey(), "specify the public key to sign with (defaults to $BURROW_CLIENT_PUBLIC_KEY)")
+	signCmd.Flags().StringVarP(&clientDo.AddrFlag, "addr", "", defaultAddress(), "specify the account address (for which the public key can be found at monax-keys) (default respects $BURROW_CLIENT_ADDRESS)")
+
+	combineCmd := &cobra.Command{
+		Use:   "combine <file> ...",
+		Short: "burrow-client multisig combine <file> ...",
+		Long:  "burrow-client multisig combine <file> ... combines the signatures of the signed copies of a transaction",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := methods.MultisigCombine(clientDo, args); err != nil {
+				util.Fatalf("Could not combine: %s", err)
+			}
+		},
+	}
+
+	broadcastCmd := &cobra.Command{
+		Use:   "broadcast <file>",
+		Short: "burrow-client multisig broadcast <file>",
+		Long:  "burrow-client multisig broadcast <file> broadcasts a transaction signed by enough of its signatories",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.Fatalf("Give the file of the transaction to broadcast")
+			}
+			if err := methods.MultisigBroadcast(clientDo, args[0]); err != nil {
+				util.Fatalf("Could not broadcast: %s", err)
+			}
+		},
+	}
+	broadcastCmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+	broadcastCmd.Flags().BoolVarP(&clientDo.WaitFlag, "wait", "w", true, "wait for the transaction to be committed in a block")
+
+	multisigCmd.AddCommand(addressCmd, sendCmd, callCmd, signCmd, combineCmd, broadcastCmd)
+	return multisigCmd
+}
@@ -24,8 +24,9 @@ import (
 )
 
 func buildTransactionCommand() *cobra.Command {
-	// Transaction command has subcommands send, name, call, bond,
-	// unbond, rebond, permissions. Dupeout transaction is not accessible through the command line.
+	// Transaction command has subcommands send, name, renew-name, call, bond,
+	// unbond, rebond, rotate, permissions, propose, vote, gov, and sign and
+	// broadcast for txs in JSON. Dupeout transaction is not accessible through the command line.
 	transactionCmd := &cobra.Command{
 		Use:   "tx",
 		Short: "burrow-client tx formulates and signs a transaction to a chain",
@@ -57,7 +58,10 @@ func buildTransactionCommand() *cobra.Command {
 		Short: "burrow-client tx name --amt <amt> --name <name> --data <data>",
 		Long:  "burrow-client tx name --amt <amt> --name <name> --data <data>",
 		Run: func(cmd *cobra.Command, args []string) {
-			// transaction.Name(clientDo)
+			err := methods.Name(clientDo)
+			if err != nil {
+				util.Fatalf("Could not register name: %s", err)
+			}
 		},
 		PreRun: assertParameters,
 	}
@@ -65,7 +69,26 @@ func buildTransactionCommand() *cobra.Command {
 	nameCmd.Flags().StringVarP(&clientDo.NameFlag, "name", "n", "", "specify a name")
 	nameCmd.Flags().StringVarP(&clientDo.DataFlag, "data", "", "", "specify some data")
 	nameCmd.Flags().StringVarP(&clientDo.DataFileFlag, "data-file", "", "", "specify a file with some data")
-	nameCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "", "specify the fee to send")
+	nameCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "0", "specify the fee to send")
+
+	// NameTx extending an entry
+	renewNameCmd := &cobra.Command{
+		Use:   "renew-name",
+		Short: "burrow-client tx renew-name --amt <amt> --name <name>",
+		Long: "burrow-client tx renew-name --amt <amt> --name <name>\n" +
+			"extends a name registry entry you own with the credit of the amount,\n" +
+			"keeping the data already registered.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.RenewName(clientDo)
+			if err != nil {
+				util.Fatalf("Could not renew name: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	renewNameCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount")
+	renewNameCmd.Flags().StringVarP(&clientDo.NameFlag, "name", "n", "", "specify a name")
+	renewNameCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "0", "specify the fee to send")
 
 	// CallTx
 	callCmd := &cobra.Command{
@@ -86,26 +109,81 @@ func buildTransactionCommand() *cobra.Command {
 	callCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "", "specify the fee to send")
 	callCmd.Flags().StringVarP(&clientDo.GasFlag, "gas", "g", "", "specify the gas limit for a CallTx")
 
+	// PrivateTx
+	privateCmd := &cobra.Command{
+		Use:   "private",
+		Short: "burrow-client tx private --amt <amt> --gas <gas> --to <contract addr> --data <data> --group-key-file <file>",
+		Long: "burrow-client tx private --amt <amt> --gas <gas> --to <contract addr> --data <data> --group-key-file <file>\n" +
+			"calls a contract, or creates one when --to is left out, privately to a group:\n" +
+			"the call is encrypted with the key the group shares and only the nodes of\n" +
+			"the group execute it, against the private state they keep for the group",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Private(clientDo)
+			if err != nil {
+				util.Fatalf("Could not complete private call: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	privateCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount, all of which is paid as the fee")
+	privateCmd.Flags().StringVarP(&clientDo.ToFlag, "to", "t", "", "specify the address of the private contract")
+	privateCmd.Flags().StringVarP(&clientDo.DataFlag, "data", "", "", "specify some data")
+	privateCmd.Flags().StringVarP(&clientDo.GasFlag, "gas", "g", "", "specify the gas limit of the private call")
+	privateCmd.Flags().StringVarP(&clientDo.GroupKeyFileFlag, "group-key-file", "", "", "specify the file holding the hex key of the private group")
+
+	// ABITx
+	abiCmd := &cobra.Command{
+		Use:   "abi",
+		Short: "burrow-client tx abi --amt <amt> --to <contract addr> --abi-file <file>",
+		Long: "burrow-client tx abi --amt <amt> --to <contract addr> --abi-file <file>\n" +
+			"registers the ABI of the code of a contract, or with --abi-hash <hash>\n" +
+			"the sha3 hash of an ABI kept off-chain, so it can be fetched for any\n" +
+			"contract deployed with the same code",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.ABI(clientDo)
+			if err != nil {
+				util.Fatalf("Could not register ABI: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	abiCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount, all of which is paid as the fee")
+	abiCmd.Flags().StringVarP(&clientDo.ToFlag, "to", "t", "", "specify the address of the contract")
+	abiCmd.Flags().StringVarP(&clientDo.ABIFileFlag, "abi-file", "", "", "specify a file with the JSON ABI")
+	abiCmd.Flags().StringVarP(&clientDo.ABIHashFlag, "abi-hash", "", "", "specify the hash of an ABI kept off-chain")
+
 	// BondTx
 	bondCmd := &cobra.Command{
 		Use:   "bond",
-		Short: "burrow-client tx bond --pubkey <pubkey> --amt <amt> --unbond-to <address>",
-		Long:  "burrow-client tx bond --pubkey <pubkey> --amt <amt> --unbond-to <address>",
+		Short: "burrow-client tx bond --pubkey <validator pubkey> --amt <amt> --to <address>",
+		Long: "burrow-client tx bond --pubkey <validator pubkey> --amt <amt> --to <address>\n" +
+			"bonds coins from the validator's own account to add to its voting power.\n" +
+			"The coins are returned to the --to address, or the validator's account,\n" +
+			"after unbonding. A key that is not yet a validator joins the validator set.",
 		Run: func(cmd *cobra.Command, args []string) {
-			// transaction.Bond(clientDo)
+			err := methods.Bond(clientDo)
+			if err != nil {
+				util.Fatalf("Could not bond: %s", err)
+			}
 		},
 		PreRun: assertParameters,
 	}
 	bondCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount")
 	bondCmd.Flags().StringVarP(&clientDo.UnbondtoFlag, "to", "t", "", "specify an address to unbond to")
+	bondCmd.Flags().StringVarP(&clientDo.FeeFlag, "fee", "f", "", "specify the fee to send")
 
 	// UnbondTx
 	unbondCmd := &cobra.Command{
 		Use:   "unbond",
 		Short: "burrow-client tx unbond --addr <address> --height <block_height>",
-		Long:  "burrow-client tx unbond --addr <address> --height <block_height>",
+		Long: "burrow-client tx unbond --addr <address> --height <block_height>\n" +
+			"removes the validator from the validator set. Its bonded coins are\n" +
+			"released to the accounts it bonded them to after the unbonding period.",
 		Run: func(cmd *cobra.Command, args []string) {
-			// transaction.Unbond(clientDo)
+			err := methods.Unbond(clientDo)
+			if err != nil {
+				util.Fatalf("Could not unbond: %s", err)
+			}
 		},
 		PreRun: assertParameters,
 	}
@@ -125,32 +203,189 @@ func buildTransactionCommand() *cobra.Command {
 	rebondCmd.Flags().StringVarP(&clientDo.AddrFlag, "addr", "a", "", "specify an address")
 	rebondCmd.Flags().StringVarP(&clientDo.HeightFlag, "height", "n", "", "specify a height to unbond at")
 
+	// RotateTx
+	rotateCmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "burrow-client tx rotate --pubkey <validator pubkey> --new-pubkey <pubkey> --height <block_height>",
+		Long: "burrow-client tx rotate --pubkey <validator pubkey> --new-pubkey <pubkey> --height <block_height>\n" +
+			"hands the voting power of a validator over to a new consensus key from\n" +
+			"the given height on. Monax-keys must hold both keys to sign with. The node\n" +
+			"must be restarted with the new key as its priv_validator.json at that height.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Rotate(clientDo)
+			if err != nil {
+				util.Fatalf("Could not rotate validator key: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	rotateCmd.Flags().StringVarP(&clientDo.NewPubkeyFlag, "new-pubkey", "", "", "specify the public key to rotate to")
+	rotateCmd.Flags().StringVarP(&clientDo.HeightFlag, "height", "n", "", "specify the first height the new key validates at")
+
+	// IdentifyTx
+	identifyCmd := &cobra.Command{
+		Use:   "identify",
+		Short: "burrow-client tx identify --pubkey <validator pubkey> --node-id <node id> --net-address <host:port>",
+		Long: "burrow-client tx identify --pubkey <validator pubkey> --node-id <node id> --net-address <host:port>\n" +
+			"registers the network identity of a validator's node in the node registry,\n" +
+			"signed by the validator key, so that peers can find and authenticate it.\n" +
+			"The node ID is the hex public key the node identifies itself to peers with.\n" +
+			"It replaces the identity the validator registered before.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Identify(clientDo)
+			if err != nil {
+				util.Fatalf("Could not register validator node: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	identifyCmd.Flags().StringVarP(&clientDo.NodeIDFlag, "node-id", "", "", "specify the hex public key of the node")
+	identifyCmd.Flags().StringVarP(&clientDo.NetAddressFlag, "net-address", "", "", "specify the host:port peers can dial the node at")
+	identifyCmd.Flags().StringVarP(&clientDo.TLSCertFingerprintFlag, "tls-cert-fingerprint", "", "", "specify the hex sha256 hash of the certificate the node serves TLS with")
+	identifyCmd.Flags().StringVarP(&clientDo.MonikerFlag, "moniker", "", "", "specify a name for the node")
+
 	// PermissionsTx
 	permissionsCmd := &cobra.Command{
 		Use:   "permission",
-		Short: "burrow-client tx perm <function name> <args ...>",
-		Long:  "burrow-client tx perm <function name> <args ...>",
+		Short: "burrow-client tx permission <function name> <args ...>",
+		Long: "burrow-client tx permission <function name> <args ...>\n" +
+			"calls a permission function, one of setBase, unsetBase, setGlobal,\n" +
+			"addRole, removeRole, setGroup, unsetGroup, addGroupMembers or\n" +
+			"removeGroupMembers, such as: tx permission setBase <addr> call true",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Permissions(clientDo, args)
+			if err != nil {
+				util.Fatalf("Could not change permissions: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+
+	// ProposalTx making a proposal
+	proposeCmd := &cobra.Command{
+		Use:   "propose",
+		Short: "burrow-client tx propose --amt <amt> --name <name> --txs-file <file>",
+		Long: "burrow-client tx propose --amt <amt> --name <name> --txs-file <file>\n" +
+			"proposes the batch of txs in the file, a JSON array of txs in the form\n" +
+			"[type, {tx}], and votes for it. The batch is executed once enough accounts\n" +
+			"with the vote permission, including every input of the batch, have voted.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Propose(clientDo)
+			if err != nil {
+				util.Fatalf("Could not make proposal: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	proposeCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount, all of which is paid as the fee")
+	proposeCmd.Flags().StringVarP(&clientDo.NameFlag, "name", "n", "", "specify a name for the proposal")
+	proposeCmd.Flags().StringVarP(&clientDo.DescriptionFlag, "description", "", "", "specify a description of the proposal")
+	proposeCmd.Flags().StringVarP(&clientDo.TxsFileFlag, "txs-file", "", "", "specify a file with the JSON txs to batch")
+
+	// ProposalTx voting for a proposal
+	voteCmd := &cobra.Command{
+		Use:   "vote",
+		Short: "burrow-client tx vote --amt <amt> --proposal-hash <hash>",
+		Long:  "burrow-client tx vote --amt <amt> --proposal-hash <hash>",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Vote(clientDo)
+			if err != nil {
+				util.Fatalf("Could not vote: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+	voteCmd.Flags().StringVarP(&clientDo.AmtFlag, "amt", "a", "", "specify an amount, all of which is paid as the fee")
+	voteCmd.Flags().StringVarP(&clientDo.ProposalHashFlag, "proposal-hash", "", "", "specify the hash of the proposal to vote for")
+
+	// GovTx to batch in a proposal
+	govCmd := &cobra.Command{
+		Use:   "gov",
+		Short: "burrow-client tx gov --params-file <file> --unjail <addr,...>",
+		Long: "burrow-client tx gov --params-file <file> --unjail <addr,...>\n" +
+			"writes a GovTx changing the chain parameters in the JSON file and\n" +
+			"unjailing the validators with the addresses given. A GovTx is not signed,\n" +
+			"it is put in the --txs-file of a proposal to be executed once it passes.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Gov(clientDo)
+			if err != nil {
+				util.Fatalf("Could not form governance transaction: %s", err)
+			}
+		},
+	}
+	govCmd.Flags().StringVarP(&clientDo.ParamsFileFlag, "params-file", "", "", "specify a JSON file of the chain parameters to change")
+	govCmd.Flags().StringVarP(&clientDo.UnjailFlag, "unjail", "", "", "specify the comma separated addresses of the validators to unjail")
+
+	// Any tx from JSON
+	signCmd := &cobra.Command{
+		Use:   "sign <file>",
+		Short: "burrow-client tx sign <file>",
+		Long: "burrow-client tx sign <file>\n" +
+			"signs the transaction of any type in the JSON file, in the form [type, {tx}],\n" +
+			"and broadcasts it, or with --broadcast=false or --offline writes it signed\n" +
+			"to stdout. The file - is read from stdin.",
 		Run: func(cmd *cobra.Command, args []string) {
-			// transaction.Permsissions(clientDo)
+			if len(args) != 1 {
+				util.Fatalf("Give the file of the transaction to sign")
+			}
+			err := methods.SignTx(clientDo, args[0])
+			if err != nil {
+				util.Fatalf("Could not sign transaction: %s", err)
+			}
+		},
+		PreRun: assertParameters,
+	}
+
+	broadcastCmd := &cobra.Command{
+		Use:   "broadcast <file>",
+		Short: "burrow-client tx broadcast <file>",
+		Long: "burrow-client tx broadcast <file>\n" +
+			"broadcasts the signed transaction of any type in the JSON file, as written\n" +
+			"by the other tx commands with --broadcast=false or --offline. The file - is\n" +
+			"read from stdin.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.Fatalf("Give the file of the transaction to broadcast")
+			}
+			err := methods.BroadcastTx(clientDo, args[0])
+			if err != nil {
+				util.Fatalf("Could not broadcast transaction: %s", err)
+			}
 		},
 		PreRun: assertParameters,
 	}
 
-	transactionCmd.AddCommand(sendCmd, nameCmd, callCmd, bondCmd, unbondCmd, rebondCmd, permissionsCmd)
+	transactionCmd.AddCommand(sendCmd, nameCmd, renewNameCmd, callCmd, privateCmd, abiCmd, bondCmd, unbondCmd, rebondCmd, rotateCmd, identifyCmd,
+		permissionsCmd,
+		proposeCmd, voteCmd, govCmd,
+		signCmd, broadcastCmd)
 	return transactionCmd
 }
 
 func addTransactionPersistentFlags(transactionCmd *cobra.Command) {
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.SignAddrFlag, "sign-addr", "", defaultKeyDaemonAddress(), "set monax-keys daemon address (default respects $BURROW_CLIENT_SIGN_ADDRESS)")
+	addKeyDaemonTLSFlags(transactionCmd)
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.PubkeyFlag, "pubkey", "", defaultPublicKey(), "specify the public key to sign with (defaults to $BURROW_CLIENT_PUBLIC_KEY)")
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.AddrFlag, "addr", "", defaultAddress(), "specify the account address (for which the public key can be found at monax-keys) (default respects $BURROW_CLIENT_ADDRESS)")
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.ChainidFlag, "chain-id", "", defaultChainId(), "specify the chainID (default respects $CHAIN_ID)")
 	transactionCmd.PersistentFlags().StringVarP(&clientDo.NonceFlag, "nonce", "", "", "specify the nonce to use for the transaction (should equal the sender account's nonce + 1)")
+	transactionCmd.PersistentFlags().StringVarP(&clientDo.KeyFileFlag, "key-file", "", "", "sign with the key in a plain JSON key file or keystore rather than monax-keys")
+	transactionCmd.PersistentFlags().StringVarP(&clientDo.PassphraseFileFlag, "passphrase-file", "", "", "file holding the passphrase of the --key-file keystore on its first line (else $BURROW_KEYS_PASSPHRASE, else stdin)")
 
 	// transactionCmd.PersistentFlags().BoolVarP(&clientDo.SignFlag, "sign", "s", false, "sign the transaction using the monax-keys daemon")
 	transactionCmd.PersistentFlags().BoolVarP(&clientDo.BroadcastFlag, "broadcast", "b", true, "broadcast the transaction to the blockchain")
 	transactionCmd.PersistentFlags().BoolVarP(&clientDo.WaitFlag, "wait", "w", true, "wait for the transaction to be committed in a block")
+	transactionCmd.PersistentFlags().BoolVarP(&clientDo.OfflineFlag, "offline", "", false, "never contact the node, writing the signed transaction to stdout to broadcast later (requires --nonce)")
+	transactionCmd.PersistentFlags().BoolVarP(&clientDo.UnsignedFlag, "unsigned", "", false, "write the transaction to stdout without signing it, to sign elsewhere with burrow keys sign-tx (requires --pubkey)")
+}
+
+// Flags for reaching monax-keys on another machine over https, authenticated
+// by a client certificate
+func addKeyDaemonTLSFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&clientDo.SignCertFlag, "sign-cert", "", defaultKeyDaemonCert(), "set the client certificate to authenticate to monax-keys over https with (default respects $BURROW_CLIENT_SIGN_CERT)")
+	cmd.PersistentFlags().StringVarP(&clientDo.SignKeyFlag, "sign-key", "", defaultKeyDaemonKey(), "set the key of the client certificate (default respects $BURROW_CLIENT_SIGN_KEY)")
+	cmd.PersistentFlags().StringVarP(&clientDo.SignCAFlag, "sign-ca", "", defaultKeyDaemonCA(), "set the CA certificates to check the certificate of monax-keys against, rather than the system's (default respects $BURROW_CLIENT_SIGN_CA)")
 }
 
 //------------------------------------------------------------------------------
@@ -164,6 +399,18 @@ func defaultKeyDaemonAddress() string {
 	return setDefaultString("BURROW_CLIENT_SIGN_ADDRESS", "http://127.0.0.1:4767")
 }
 
+func defaultKeyDaemonCert() string {
+	return setDefaultString("BURROW_CLIENT_SIGN_CERT", "")
+}
+
+func defaultKeyDaemonKey() string {
+	return setDefaultString("BURROW_CLIENT_SIGN_KEY", "")
+}
+
+func defaultKeyDaemonCA() string {
+	return setDefaultString("BURROW_CLIENT_SIGN_CA", "")
+}
+
 func defaultNodeRpcAddress() string {
 	return setDefaultString("BURROW_CLIENT_NODE_ADDRESS", "tcp://127.0.0.1:46657")
 }
@@ -183,7 +430,12 @@ func assertParameters(cmd *cobra.Command, args []string) {
 	if clientDo.ChainidFlag == "" {
 		util.Fatalf(`Please provide a chain id either through the flag --chain-id or environment variable $CHAIN_ID.`)
 	}
+	assertAddresses(cmd, args)
+}
 
+// Checks the addresses of the node and monax-keys, for commands that may not
+// need a chain id
+func assertAddresses(cmd *cobra.Command, args []string) {
 	if !strings.HasPrefix(clientDo.NodeAddrFlag, "tcp://") &&
 		!strings.HasPrefix(clientDo.NodeAddrFlag, "unix://") {
 		// TODO: [ben] go-rpc will deprecate reformatting; also it is bad practice to auto-correct for this;
@@ -192,10 +444,15 @@ func assertParameters(cmd *cobra.Command, args []string) {
 		util.Fatalf(`Please use fully formed listening address for the node, including the tcp:// or unix:// prefix.`)
 	}
 
-	if !strings.HasPrefix(clientDo.SignAddrFlag, "http://") {
+	if !strings.HasPrefix(clientDo.SignAddrFlag, "http://") &&
+		!strings.HasPrefix(clientDo.SignAddrFlag, "https://") {
 		// NOTE: [ben] we preserve the auto-correction here as it is a simple http request-response to the key server.
 		// TODO: [Silas] we don't have logging here to log that we've done this. I'm inclined to either urls without a scheme
 		// and be quiet about it, or to make non-compliance fatal
-		clientDo.SignAddrFlag = "http://" + clientDo.SignAddrFlag
+		if clientDo.SignCertFlag != "" || clientDo.SignCAFlag != "" {
+			clientDo.SignAddrFlag = "https://" + clientDo.SignAddrFlag
+		} else {
+			clientDo.SignAddrFlag = "http://" + clientDo.SignAddrFlag
+		}
 	}
 }
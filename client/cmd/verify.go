@@ -0,0 +1,81 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/burrow/client/methods"
+	"github.com/hyperledger/burrow/util"
+)
+
+func buildVerifyCommand() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "burrow-client verify checks a block header from a node against the chain's validators.",
+		Long: `burrow-client verify fetches the header at a height from a node, which need
+not be trusted, and verifies that it was signed by more than two thirds of the
+voting power of the validators in the genesis file, following any changes to the
+validators since. The verified header and its AppHash are printed.
+`,
+		Example: `$ burrow-client verify --genesis genesis.json --height 42`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.Verify(clientDo)
+			if err != nil {
+				util.Fatalf("Could not verify header: %s", err)
+			}
+		},
+	}
+	verifyCmd.PersistentFlags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+	verifyCmd.PersistentFlags().StringVarP(&clientDo.GenesisFileFlag, "genesis", "", "genesis.json", "genesis file of the chain, whose validators are trusted")
+	verifyCmd.PersistentFlags().StringVarP(&clientDo.HeightFlag, "height", "", "", "height of the header to verify, which must be below the latest height")
+	return verifyCmd
+}
+
+func buildVerifyContractCommand() *cobra.Command {
+	verifyContractCmd := &cobra.Command{
+		Use:   "verify-contract",
+		Short: "burrow-client verify-contract checks the code deployed at an address was compiled from a Solidity source.",
+		Long: `burrow-client verify-contract compiles a Solidity source with solc and the
+settings given, which must be those the contract was compiled with, and checks
+that the runtime bytecode of the contract is the code deployed at the address.
+The metadata hash solc appends to the code is ignored, since it changes with
+the comments and file name of the source, but is reported as an exact match
+when it matches too.
+
+A record of the verification is printed as JSON for explorers to keep, and with
+--abi-output the ABI of the contract is written to a file to register on chain
+with burrow-client tx abi --to <address> --abi-file <file>.
+`,
+		Example: `$ burrow-client verify-contract --address <address> --source store.sol --contract Store --solc-version 0.4.24 --optimize`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := methods.VerifyContract(clientDo)
+			if err != nil {
+				util.Fatalf("Could not verify contract: %s", err)
+			}
+		},
+	}
+	verifyContractCmd.Flags().StringVarP(&clientDo.NodeAddrFlag, "node-addr", "", defaultNodeRpcAddress(), "set the burrow node rpc server address (default respects $BURROW_CLIENT_NODE_ADDRESS)")
+	verifyContractCmd.Flags().StringVarP(&clientDo.ContractAddrFlag, "address", "", "", "address of the contract to verify")
+	verifyContractCmd.Flags().StringVarP(&clientDo.SourceFileFlag, "source", "", "", "Solidity source file of the contract")
+	verifyContractCmd.Flags().StringVarP(&clientDo.ContractFlag, "contract", "", "", "name of the contract in the source, needed if it has more than one")
+	verifyContractCmd.Flags().StringVarP(&clientDo.SolcFlag, "solc", "", "solc", "solc binary to compile with")
+	verifyContractCmd.Flags().StringVarP(&clientDo.SolcVersionFlag, "solc-version", "", "", "version solc must be, such as 0.4.24")
+	verifyContractCmd.Flags().BoolVarP(&clientDo.OptimizeFlag, "optimize", "", false, "compile with the optimizer")
+	verifyContractCmd.Flags().IntVarP(&clientDo.OptimizeRunsFlag, "optimize-runs", "", 200, "runs the optimizer was tuned for")
+	verifyContractCmd.Flags().StringSliceVarP(&clientDo.LibrariesFlag, "libraries", "", nil, "addresses of the libraries to link, as <name>:<address>")
+	verifyContractCmd.Flags().StringVarP(&clientDo.ABIOutputFlag, "abi-output", "", "", "file to write the ABI of the verified contract to")
+	return verifyContractCmd
+}
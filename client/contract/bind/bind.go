@@ -0,0 +1,433 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bind generates Go bindings for contracts from their ABIs, with a
+// typed method for each function and a watcher for each event, built on
+// package contract, as well as the equivalent JavaScript and TypeScript
+// bindings for front-ends
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+)
+
+// A contract to generate bindings for, by its Go type name and JSON ABI
+type ContractABI struct {
+	Name string
+	ABI  string
+}
+
+// Generates the source of Go package pkg binding to the contracts
+func Bind(pkg string, contracts []ContractABI) ([]byte, error) {
+	file := &fileData{Package: pkg, imports: make(map[string]bool)}
+	for _, contractABI := range contracts {
+		contract, err := file.bindContract(contractABI)
+		if err != nil {
+			return nil, err
+		}
+		file.Contracts = append(file.Contracts, contract)
+	}
+	for path := range file.imports {
+		if !strings.Contains(path, ".") {
+			file.StdImports = append(file.StdImports, path)
+		} else {
+			file.Imports = append(file.Imports, path)
+		}
+	}
+	sort.Strings(file.StdImports)
+	sort.Strings(file.Imports)
+	buf := new(bytes.Buffer)
+	if err := bindTemplate.Execute(buf, file); err != nil {
+		return nil, err
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Could not format generated bindings: %v", err)
+	}
+	return source, nil
+}
+
+type fileData struct {
+	Package    string
+	StdImports []string
+	Imports    []string
+	Contracts  []*contractData
+	imports    map[string]bool
+}
+
+type contractData struct {
+	Name      string
+	ABI       string
+	Structs   []*structData
+	Functions []*functionData
+	Events    []*eventData
+	structs   map[string]bool
+}
+
+type structData struct {
+	Name   string
+	Fields []*fieldData
+}
+
+type fieldData struct {
+	Name string
+	Type string
+}
+
+type functionData struct {
+	GoName    string
+	Signature string
+	Constant  bool
+	Payable   bool
+	Inputs    []*fieldData
+	Outputs   []*fieldData
+}
+
+type eventData struct {
+	GoName string
+	Name   string
+	Type   string
+	Fields []*fieldData
+	// Why a watcher cannot be generated, if it cannot
+	Unsupported string
+}
+
+func (file *fileData) bindContract(contractABI ContractABI) (*contractData, error) {
+	if !isIdentifier(contractABI.Name) || !unicode.IsUpper([]rune(contractABI.Name)[0]) {
+		return nil, fmt.Errorf("Contract name %s is not an exported Go identifier",
+			contractABI.Name)
+	}
+	parsedABI, err := abi.ReadABI([]byte(contractABI.ABI))
+	if err != nil {
+		return nil, err
+	}
+	file.imports["github.com/hyperledger/burrow/client/contract"] = true
+	contract := &contractData{
+		Name:    contractABI.Name,
+		ABI:     strings.Replace(contractABI.ABI, "`", "`+\"`\"+`", -1),
+		structs: make(map[string]bool),
+	}
+	overloads := make(map[string]int)
+	for _, function := range parsedABI.Functions {
+		signature, err := function.Signature()
+		if err != nil {
+			return nil, err
+		}
+		goName := capitalise(function.Name)
+		if n := overloads[goName]; n > 0 {
+			goName = fmt.Sprintf("%s%v", goName, n)
+		}
+		overloads[capitalise(function.Name)]++
+		data := &functionData{
+			GoName:    goName,
+			Signature: signature,
+			Constant:  function.IsConstant(),
+			Payable:   function.IsPayable() && !function.IsConstant(),
+		}
+		params := map[string]bool{"amount": true, "err": true, "result": true}
+		for i, input := range function.Inputs {
+			goType, err := file.argumentType(contract, input)
+			if err != nil {
+				return nil, fmt.Errorf("Could not bind input %s of %s: %v",
+					input.Name, function.Name, err)
+			}
+			data.Inputs = append(data.Inputs, &fieldData{
+				Name: paramName(input.Name, i, params),
+				Type: goType,
+			})
+		}
+		for i, output := range function.Outputs {
+			goType, err := file.argumentType(contract, output)
+			if err != nil {
+				return nil, fmt.Errorf("Could not bind output %s of %s: %v",
+					output.Name, function.Name, err)
+			}
+			data.Outputs = append(data.Outputs, &fieldData{
+				Name: fmt.Sprintf("out%v", i),
+				Type: goType,
+			})
+		}
+		if !data.Constant {
+			file.imports["github.com/hyperledger/burrow/client/rpc"] = true
+		}
+		contract.Functions = append(contract.Functions, data)
+	}
+	for _, event := range parsedABI.Events {
+		data := &eventData{
+			GoName: capitalise(event.Name),
+			Name:   event.Name,
+			Type:   contract.Name + capitalise(event.Name),
+		}
+		if event.Anonymous {
+			data.Unsupported = "it is anonymous"
+		}
+		fields := map[string]bool{"BlockHeight": true}
+		for i, input := range event.Inputs {
+			goType, err := file.argumentType(contract, eventArgument(input))
+			if err != nil {
+				data.Unsupported = fmt.Sprintf("its input %s is of type %s", input.Name,
+					input.TypeName)
+				break
+			}
+			if typ, _ := input.Type(); input.Indexed && typ.Kind != abi.ElementaryKind {
+				// Only the hash of the value is logged
+				goType = "[32]byte"
+			}
+			data.Fields = append(data.Fields, &fieldData{
+				Name: fieldName(input.Name, i, fields),
+				Type: goType,
+			})
+		}
+		if data.Unsupported == "" {
+			file.imports["github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"] = true
+		}
+		contract.Events = append(contract.Events, data)
+	}
+	return contract, nil
+}
+
+func eventArgument(input *abi.EventInput) *abi.Argument {
+	return &abi.Argument{
+		Name:         input.Name,
+		TypeName:     input.TypeName,
+		Components:   input.Components,
+		InternalType: input.InternalType,
+	}
+}
+
+func (file *fileData) argumentType(contract *contractData, arg *abi.Argument) (string, error) {
+	typ, err := abi.ParseType(arg.TypeName, arg.Components)
+	if err != nil {
+		return "", err
+	}
+	return file.goType(contract, typ, arg.Components, structName(contract, arg)), nil
+}
+
+// The Go type of an ABI type. Tuples are bound to structs named tupleName,
+// with the components of the tuple as fields.
+func (file *fileData) goType(contract *contractData, typ *abi.Type,
+	components []*abi.Argument, tupleName string) string {
+	switch typ.Kind {
+	case abi.ArrayKind:
+		elem := file.goType(contract, typ.Elem, components, tupleName)
+		if typ.Length < 0 {
+			return "[]" + elem
+		}
+		return fmt.Sprintf("[%v]%s", typ.Length, elem)
+	case abi.TupleKind:
+		if !contract.structs[tupleName] {
+			contract.structs[tupleName] = true
+			data := &structData{Name: tupleName}
+			fields := make(map[string]bool)
+			for i, component := range components {
+				data.Fields = append(data.Fields, &fieldData{
+					Name: fieldName(component.Name, i, fields),
+					Type: file.goType(contract, typ.Components[i], component.Components,
+						structName(contract, component)),
+				})
+			}
+			contract.Structs = append(contract.Structs, data)
+		}
+		return tupleName
+	case abi.BytesKind:
+		if typ.Name == abi.StringTypeName {
+			return "string"
+		}
+		return "[]byte"
+	}
+	name := string(typ.Name)
+	switch {
+	case typ.Name == abi.AddressTypeName:
+		file.imports["github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"] = true
+		return "abi.Address"
+	case typ.Name == abi.BoolTypeName:
+		return "bool"
+	case strings.HasPrefix(name, "bytes"):
+		return "[" + strings.TrimPrefix(name, "bytes") + "]byte"
+	}
+	switch strings.TrimPrefix(strings.TrimPrefix(name, "u"), "int") {
+	case "8", "16", "32", "64":
+		return name
+	}
+	file.imports["math/big"] = true
+	return "*big.Int"
+}
+
+// Names the struct a tuple is bound to after its Solidity struct when solc
+// gives it, such as struct Token.Holder, or else after the argument
+func structName(contract *contractData, arg *abi.Argument) string {
+	if strings.HasPrefix(arg.InternalType, "struct ") {
+		name := strings.TrimPrefix(arg.InternalType, "struct ")
+		if i := strings.Index(name, "["); i >= 0 {
+			name = name[:i]
+		}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		return contract.Name + capitalise(name)
+	}
+	return contract.Name + capitalise(strings.TrimLeft(arg.Name, "_"))
+}
+
+func capitalise(name string) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// A Go parameter name for an input that is an identifier and not a keyword or
+// a name already taken
+func paramName(name string, i int, taken map[string]bool) string {
+	if name != "" {
+		runes := []rune(strings.TrimLeft(name, "_"))
+		if len(runes) > 0 {
+			runes[0] = unicode.ToLower(runes[0])
+			name = string(runes)
+		}
+	}
+	if !isIdentifier(name) || token.Lookup(name).IsKeyword() || taken[name] {
+		name = fmt.Sprintf("arg%v", i)
+	}
+	taken[name] = true
+	return name
+}
+
+func fieldName(name string, i int, taken map[string]bool) string {
+	name = capitalise(name)
+	if !isIdentifier(name) || taken[name] {
+		name = fmt.Sprintf("Arg%v", i)
+	}
+	taken[name] = true
+	return name
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || name == "_" {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+var bindTemplate = template.Must(template.New("bind").Parse(`// Code generated by burrow abi bind. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	"{{.}}"
+{{- end}}
+{{if .StdImports}}
+{{end}}
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range $contract := .Contracts}}
+const {{.Name}}ABI = ` + "`{{.ABI}}`" + `
+
+// Binds the {{.Name}} contract at an address
+type {{.Name}} struct {
+	*contract.Contract
+}
+
+// Binds the {{.Name}} contract at address, calling it through session
+func New{{.Name}}(address []byte, session *contract.Session) (*{{.Name}}, error) {
+	c, err := contract.New(address, {{.Name}}ABI, session)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Name}}{c}, nil
+}
+{{range .Structs}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+{{- range .Functions}}
+{{- if .Constant}}
+// {{.GoName}} runs {{.Signature}} as a simulated call
+func (_{{$contract.Name}} *{{$contract.Name}}) {{.GoName}}(
+{{- range $i, $input := .Inputs}}{{if $i}}, {{end}}{{.Name}} {{.Type}}{{end -}}
+) ({{range .Outputs}}{{.Type}}, {{end}}error) {
+	{{- range .Outputs}}
+	var {{.Name}} {{.Type}}
+	{{- end}}
+	err := _{{$contract.Name}}.Contract.Call("{{.Signature}}", []interface{}{
+		{{- range $i, $input := .Inputs}}{{if $i}}, {{end}}{{.Name}}{{end -}}
+	}{{range .Outputs}}, &{{.Name}}{{end}})
+	return {{range .Outputs}}{{.Name}}, {{end}}err
+}
+{{else}}
+// {{.GoName}} calls {{.Signature}} with a CallTx and waits for it to be
+// committed
+func (_{{$contract.Name}} *{{$contract.Name}}) {{.GoName}}(
+{{- if .Payable}}amount int64{{if .Inputs}}, {{end}}{{end}}
+{{- range $i, $input := .Inputs}}{{if $i}}, {{end}}{{.Name}} {{.Type}}{{end -}}
+) ({{range .Outputs}}{{.Type}}, {{end}}*rpc.TxResult, error) {
+	{{- range .Outputs}}
+	var {{.Name}} {{.Type}}
+	{{- end}}
+	result, err := _{{$contract.Name}}.Contract.Transact("{{.Signature}}", {{if .Payable}}amount{{else}}0{{end}}, []interface{}{
+		{{- range $i, $input := .Inputs}}{{if $i}}, {{end}}{{.Name}}{{end -}}
+	}{{range .Outputs}}, &{{.Name}}{{end}})
+	return {{range .Outputs}}{{.Name}}, {{end}}result, err
+}
+{{end}}
+{{- end}}
+{{- range .Events}}
+{{- if .Unsupported}}
+// No watcher is generated for the {{.Name}} event since {{.Unsupported}}
+{{else}}
+// A {{.Name}} event and the height of the block it was emitted in
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	BlockHeight int64
+}
+
+// Watch{{.GoName}} calls handler with each {{.Name}} event the contract emits
+// until stop is called
+func (_{{$contract.Name}} *{{$contract.Name}}) Watch{{.GoName}}(handler func(event *{{.Type}}, err error)) (stop func(), err error) {
+	return _{{$contract.Name}}.Contract.WatchEvent("{{.Name}}", func(values []interface{}, height int64, err error) {
+		event := &{{.Type}}{BlockHeight: height}
+		if err == nil {
+			err = abi.Assign(values{{range .Fields}}, &event.{{.Name}}{{end}})
+		}
+		handler(event, err)
+	})
+}
+{{end}}
+{{- end}}
+{{- end}}`))
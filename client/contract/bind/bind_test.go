@@ -0,0 +1,104 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bind
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tokenABI = `[
+	{"type": "function", "name": "balanceOf", "constant": true,
+		"inputs": [{"name": "_owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "transfer", "stateMutability": "nonpayable",
+		"inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint64"}],
+		"outputs": [{"name": "ok", "type": "bool"}]},
+	{"type": "function", "name": "transfer", "stateMutability": "payable",
+		"inputs": [{"name": "to", "type": "address"}],
+		"outputs": []},
+	{"type": "function", "name": "holders", "stateMutability": "view",
+		"inputs": [{"name": "type", "type": "bytes4[2]"}],
+		"outputs": [{"name": "", "type": "tuple[]", "internalType": "struct Token.Holder[]",
+			"components": [
+				{"name": "holder", "type": "address"},
+				{"name": "tags", "type": "string[]"},
+				{"name": "since", "type": "tuple", "internalType": "struct Token.Date",
+					"components": [{"name": "year", "type": "uint16"}]}
+			]}]},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "memo", "type": "string", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}
+	]},
+	{"type": "event", "name": "Moved", "anonymous": false, "inputs": [
+		{"name": "ids", "type": "uint256[]", "indexed": true},
+		{"name": "to", "type": "tuple", "internalType": "struct Token.Date",
+			"components": [{"name": "year", "type": "uint16"}]}
+	]},
+	{"type": "event", "name": "Anonymous", "anonymous": true, "inputs": []}
+]`
+
+func TestBind(t *testing.T) {
+	source, err := Bind("token", []ContractABI{{Name: "Token", ABI: tokenABI}})
+	require.NoError(t, err)
+	file, err := parser.ParseFile(token.NewFileSet(), "token.go", source, parser.ParseComments)
+	require.NoError(t, err, string(source))
+	assert.Equal(t, "token", file.Name.Name)
+
+	decls := make(map[string]string)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			decls[d.Name.Name] = string(source[d.Pos()-1 : d.Type.End()-1])
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					decls[typeSpec.Name.Name] = string(source[typeSpec.Type.Pos()-1 : typeSpec.Type.End()-1])
+				}
+			}
+		}
+	}
+	assert.Equal(t, "func (_Token *Token) BalanceOf(owner abi.Address) (*big.Int, error)", decls["BalanceOf"])
+	assert.Equal(t, "func (_Token *Token) Transfer(to abi.Address, value uint64) (bool, *rpc.TxResult, error)",
+		decls["Transfer"])
+	assert.Equal(t, "func (_Token *Token) Transfer1(amount int64, to abi.Address) (*rpc.TxResult, error)",
+		decls["Transfer1"])
+	assert.Equal(t, "func (_Token *Token) Holders(arg0 [2][4]byte) ([]TokenHolder, error)", decls["Holders"])
+	assert.Contains(t, decls["TokenHolder"], "Tags   []string")
+	assert.Contains(t, decls["TokenHolder"], "Since  TokenDate")
+	assert.Contains(t, decls["TokenDate"], "Year uint16")
+	assert.Contains(t, decls["TokenTransfer"], "Memo        [32]byte")
+	assert.Equal(t, "func (_Token *Token) WatchTransfer(handler func(event *TokenTransfer, err error)) (stop func(), err error)",
+		decls["WatchTransfer"])
+	assert.Contains(t, decls["TokenMoved"], "Ids         [32]byte")
+	assert.Contains(t, decls["TokenMoved"], "To          TokenDate")
+	assert.Contains(t, decls, "WatchMoved")
+	assert.NotContains(t, decls, "WatchAnonymous")
+	assert.True(t, strings.Contains(string(source),
+		"No watcher is generated for the Anonymous event since it is anonymous"))
+	assert.Contains(t, string(source), `Contract.Call("holders(bytes4[2])"`)
+
+	_, err = Bind("token", []ContractABI{{Name: "token", ABI: tokenABI}})
+	assert.Error(t, err)
+	_, err = Bind("token", []ContractABI{{Name: "Token", ABI: "{"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,869 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+)
+
+// Generates a JavaScript module binding to the contracts, with a class for
+// each contract, and a TypeScript declaration file typing it so it can be
+// checked at compile time. The module calls a burrow node over its Ethereum
+// JSON-RPC endpoint, so it runs in browsers as well as node.
+func BindTypeScript(contracts []ContractABI) (js, dts []byte, err error) {
+	var bound []*tsContractData
+	for _, contractABI := range contracts {
+		contract, err := bindTypeScriptContract(contractABI)
+		if err != nil {
+			return nil, nil, err
+		}
+		bound = append(bound, contract)
+	}
+	jsBuf := new(bytes.Buffer)
+	if err := jsTemplate.Execute(jsBuf, bound); err != nil {
+		return nil, nil, err
+	}
+	dtsBuf := new(bytes.Buffer)
+	if err := dtsTemplate.Execute(dtsBuf, bound); err != nil {
+		return nil, nil, err
+	}
+	return jsBuf.Bytes(), dtsBuf.Bytes(), nil
+}
+
+type tsContractData struct {
+	Name       string
+	Interfaces []*structData
+	Functions  []*tsFunctionData
+	Events     []*tsEventData
+	interfaces map[string]bool
+}
+
+type tsFunctionData struct {
+	Name      string
+	Signature string
+	Selector  string
+	Constant  bool
+	Payable   bool
+	Params    []*fieldData
+	Inputs    string
+	Outputs   string
+	Returns   string
+}
+
+type tsEventData struct {
+	Name        string
+	Signature   string
+	Topic       string
+	Type        string
+	Fields      []*fieldData
+	Inputs      string
+	Unsupported string
+}
+
+// The members of the runtime's Contract class, which bindings must not shadow
+var contractMembers = map[string]bool{
+	"constructor":   true,
+	"_id":           true,
+	"_session":      true,
+	"_rpc":          true,
+	"_pollInterval": true,
+	"_call":         true,
+	"_transact":     true,
+	"_watch":        true,
+}
+
+// The JSON the runtime reads the arguments of functions and events from
+type jsArgument struct {
+	Name       string        `json:"name"`
+	Type       abi.TypeName  `json:"type"`
+	Components []*jsArgument `json:"components,omitempty"`
+	Indexed    bool          `json:"indexed,omitempty"`
+}
+
+func bindTypeScriptContract(contractABI ContractABI) (*tsContractData, error) {
+	if !isIdentifier(contractABI.Name) || jsReserved[contractABI.Name] ||
+		contractABI.Name == "Contract" {
+		return nil, fmt.Errorf("Contract name %s is not a JavaScript class name",
+			contractABI.Name)
+	}
+	parsedABI, err := abi.ReadABI([]byte(contractABI.ABI))
+	if err != nil {
+		return nil, err
+	}
+	contract := &tsContractData{
+		Name:       contractABI.Name,
+		interfaces: make(map[string]bool),
+	}
+	// Interfaces are named like the structs of the Go bindings
+	goContract := &contractData{Name: contractABI.Name}
+	overloads := make(map[string]int)
+	for _, function := range parsedABI.Functions {
+		signature, err := function.Signature()
+		if err != nil {
+			return nil, err
+		}
+		selector, err := function.Selector()
+		if err != nil {
+			return nil, err
+		}
+		name := function.Name
+		if n := overloads[name]; n > 0 {
+			name = fmt.Sprintf("%s%v", name, n)
+		}
+		overloads[function.Name]++
+		if contractMembers[name] {
+			name += "_"
+		}
+		data := &tsFunctionData{
+			Name:      name,
+			Signature: signature,
+			Selector:  fmt.Sprintf("0x%x", selector[:]),
+			Constant:  function.IsConstant(),
+			Payable:   function.IsPayable() && !function.IsConstant(),
+		}
+		params := map[string]bool{"options": true}
+		for i, input := range function.Inputs {
+			tsType, err := contract.argumentType(goContract, input, true)
+			if err != nil {
+				return nil, fmt.Errorf("Could not bind input %s of %s: %v",
+					input.Name, function.Name, err)
+			}
+			data.Params = append(data.Params, &fieldData{
+				Name: jsParamName(input.Name, i, params),
+				Type: tsType,
+			})
+		}
+		var returns []string
+		for _, output := range function.Outputs {
+			tsType, err := contract.argumentType(goContract, output, false)
+			if err != nil {
+				return nil, fmt.Errorf("Could not bind output %s of %s: %v",
+					output.Name, function.Name, err)
+			}
+			returns = append(returns, tsType)
+		}
+		switch len(returns) {
+		case 0:
+			data.Returns = "void"
+		case 1:
+			data.Returns = returns[0]
+		default:
+			data.Returns = "[" + strings.Join(returns, ", ") + "]"
+		}
+		if data.Inputs, err = argumentsJSON(function.Inputs); err != nil {
+			return nil, err
+		}
+		if data.Outputs, err = argumentsJSON(function.Outputs); err != nil {
+			return nil, err
+		}
+		contract.Functions = append(contract.Functions, data)
+	}
+	for _, event := range parsedABI.Events {
+		data := &tsEventData{
+			Name:      "watch" + capitalise(event.Name),
+			Signature: event.Signature(),
+			Topic:     fmt.Sprintf("0x%x", event.ID().Bytes()),
+			Type:      contract.Name + capitalise(event.Name) + "Event",
+		}
+		if event.Anonymous {
+			data.Unsupported = "it is anonymous"
+		}
+		inputs := make([]*jsArgument, len(event.Inputs))
+		for i, input := range event.Inputs {
+			arg := eventArgument(input)
+			tsType, err := contract.argumentType(goContract, arg, false)
+			if err != nil {
+				data.Unsupported = fmt.Sprintf("its input %s is of type %s", input.Name,
+					input.TypeName)
+				break
+			}
+			if typ, _ := input.Type(); input.Indexed && typ.Kind != abi.ElementaryKind {
+				// Only the hash of the value is logged
+				tsType = "string"
+			}
+			data.Fields = append(data.Fields, &fieldData{
+				Name: jsFieldName(input.Name, i),
+				Type: tsType,
+			})
+			inputs[i] = jsArguments([]*abi.Argument{arg})[0]
+			inputs[i].Indexed = input.Indexed
+		}
+		if data.Unsupported == "" {
+			bs, err := json.Marshal(inputs)
+			if err != nil {
+				return nil, err
+			}
+			data.Inputs = string(bs)
+		}
+		contract.Events = append(contract.Events, data)
+	}
+	return contract, nil
+}
+
+func (contract *tsContractData) argumentType(goContract *contractData, arg *abi.Argument,
+	input bool) (string, error) {
+	typ, err := abi.ParseType(arg.TypeName, arg.Components)
+	if err != nil {
+		return "", err
+	}
+	return contract.tsType(goContract, typ, arg.Components, structName(goContract, arg),
+		input), nil
+}
+
+// The TypeScript type of an ABI type. Integers are bigints, although inputs
+// may also be given as numbers or strings, addresses and bytes are hex strings
+// and tuples are interfaces named tupleName.
+func (contract *tsContractData) tsType(goContract *contractData, typ *abi.Type,
+	components []*abi.Argument, tupleName string, input bool) string {
+	switch typ.Kind {
+	case abi.ArrayKind:
+		elem := contract.tsType(goContract, typ.Elem, components, tupleName, input)
+		return elem + "[]"
+	case abi.TupleKind:
+		if !contract.interfaces[tupleName] {
+			contract.interfaces[tupleName] = true
+			data := &structData{Name: tupleName}
+			for i, component := range components {
+				data.Fields = append(data.Fields, &fieldData{
+					Name: jsFieldName(component.Name, i),
+					Type: contract.tsType(goContract, typ.Components[i], component.Components,
+						structName(goContract, component), false),
+				})
+			}
+			contract.Interfaces = append(contract.Interfaces, data)
+		}
+		return tupleName
+	case abi.BytesKind:
+		return "string"
+	}
+	switch name := string(typ.Name); {
+	case typ.Name == abi.BoolTypeName:
+		return "boolean"
+	case strings.HasPrefix(name, "int") || strings.HasPrefix(name, "uint"):
+		if input {
+			return "Numeric"
+		}
+		return "bigint"
+	}
+	return "string"
+}
+
+func argumentsJSON(args []*abi.Argument) (string, error) {
+	bs, err := json.Marshal(jsArguments(args))
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+func jsArguments(args []*abi.Argument) []*jsArgument {
+	jsArgs := make([]*jsArgument, len(args))
+	for i, arg := range args {
+		jsArgs[i] = &jsArgument{
+			Name:       arg.Name,
+			Type:       arg.TypeName,
+			Components: jsArguments(arg.Components),
+		}
+	}
+	return jsArgs
+}
+
+// A JavaScript parameter name for an input that is not reserved or a name
+// already taken
+func jsParamName(name string, i int, taken map[string]bool) string {
+	name = strings.TrimLeft(name, "_")
+	if !isIdentifier(name) || jsReserved[name] || taken[name] {
+		name = fmt.Sprintf("arg%v", i)
+	}
+	taken[name] = true
+	return name
+}
+
+// The property the runtime decodes a tuple component or event input to
+func jsFieldName(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%v", i)
+	}
+	return name
+}
+
+var jsReserved = make(map[string]bool)
+
+func init() {
+	for _, word := range strings.Fields(`arguments await break case catch class const
+		continue debugger default delete do else enum eval export extends false
+		finally for function if implements import in instanceof interface let new
+		null package private protected public return static super switch this throw
+		true try typeof var void while with yield`) {
+		jsReserved[word] = true
+	}
+}
+
+var tsTemplateFuncs = template.FuncMap{
+	"params": func(params []*fieldData) string {
+		names := make([]string, len(params))
+		for i, param := range params {
+			names[i] = param.Name
+		}
+		return strings.Join(names, ", ")
+	},
+	"typedParams": func(params []*fieldData) string {
+		typed := make([]string, len(params))
+		for i, param := range params {
+			typed[i] = param.Name + ": " + param.Type
+		}
+		return strings.Join(typed, ", ")
+	},
+	"comma": func(params []*fieldData) string {
+		if len(params) > 0 {
+			return ", "
+		}
+		return ""
+	},
+}
+
+var jsTemplate = template.Must(template.New("js").Funcs(tsTemplateFuncs).Parse(
+	"// Code generated by burrow abi bind. DO NOT EDIT.\n\n" + jsRuntime + `{{range .}}
+export class {{.Name}} extends Contract {
+{{- range .Functions}}
+
+  // {{.Signature}}
+  {{.Name}}({{params .Params}}{{if not .Constant}}{{comma .Params}}options{{end}}) {
+{{- if .Constant}}
+    return this._call("{{.Selector}}", {{.Inputs}}, [{{params .Params}}], {{.Outputs}});
+{{- else}}
+    return this._transact("{{.Selector}}", {{.Inputs}}, [{{params .Params}}], options);
+{{- end}}
+  }
+{{- end}}
+{{- range .Events}}
+{{- if .Unsupported}}
+
+  // No watcher is generated for the {{.Signature}} event since {{.Unsupported}}
+{{- else}}
+
+  // {{.Signature}}
+  {{.Name}}(handler, options) {
+    return this._watch("{{.Topic}}", {{.Inputs}}, handler, options);
+  }
+{{- end}}
+{{- end}}
+}
+{{end}}`))
+
+var dtsTemplate = template.Must(template.New("dts").Funcs(tsTemplateFuncs).Parse(
+	"// Code generated by burrow abi bind. DO NOT EDIT.\n\n" + dtsRuntime + `{{range .}}
+{{- range .Interfaces}}
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}: {{.Type}};
+{{- end}}
+}
+{{end}}
+{{- range .Events}}{{if not .Unsupported}}
+export interface {{.Type}} {
+{{- range .Fields}}
+  {{.Name}}: {{.Type}};
+{{- end}}
+}
+{{end}}{{end}}
+export declare class {{.Name}} extends Contract {
+{{- range .Functions}}
+{{- if .Constant}}
+  {{.Name}}({{typedParams .Params}}): Promise<{{.Returns}}>;
+{{- else}}
+  {{.Name}}({{typedParams .Params}}{{comma .Params}}options?: {{if .Payable}}PayableOptions{{else}}TransactOptions{{end}}): Promise<Receipt>;
+{{- end}}
+{{- end}}
+{{- range .Events}}{{if not .Unsupported}}
+  {{.Name}}(handler: (event: {{.Type}}, log: Log) => void, options?: WatchOptions): () => void;
+{{- end}}{{end}}
+}
+{{end}}`))
+
+const dtsRuntime = `// Integers may be given as bigints, numbers or decimal or 0x hex strings
+export type Numeric = bigint | number | string;
+
+export interface UnsignedTx {
+  from: string;
+  to: string;
+  data: string;
+  value: bigint;
+  nonce: bigint;
+  chainId: bigint;
+  gas?: bigint;
+}
+
+// Signs a transaction, returning it as hex for eth_sendRawTransaction
+export type Signer = (tx: UnsignedTx) => Promise<string>;
+
+export interface Session {
+  // The Ethereum JSON-RPC endpoint of a burrow node
+  url: string;
+  // The account calls are made from, which along with a signer is needed to
+  // send transactions
+  from?: string;
+  signer?: Signer;
+  // How often to poll for receipts and events in milliseconds, 1000 by default
+  pollInterval?: number;
+}
+
+export interface TransactOptions {
+  gas?: Numeric;
+}
+
+export interface PayableOptions extends TransactOptions {
+  value?: Numeric;
+}
+
+export interface WatchOptions {
+  // The block to watch from rather than the next one
+  fromBlock?: Numeric;
+  onError?: (err: Error) => void;
+}
+
+export interface Log {
+  address: string;
+  topics: string[];
+  data: string;
+  blockNumber: string;
+  transactionHash: string;
+  logIndex: string;
+}
+
+export interface Receipt {
+  transactionHash: string;
+  blockNumber: string;
+  status: string;
+  logs: Log[];
+}
+
+export declare function encodeArgs(inputs: object[], args: unknown[]): string;
+export declare function decodeOutputs(outputs: object[], ret: string): unknown;
+export declare function decodeEvent(inputs: object[], log: Log): Record<string, unknown>;
+
+export declare class Contract {
+  readonly address: string;
+  constructor(address: string, session: Session);
+}
+`
+
+// The runtime the bindings are built on, which encodes calls with the ABI and
+// sends them to the Ethereum JSON-RPC endpoint of a burrow node
+const jsRuntime = `function parseType(arg) {
+  const array = /^(.*)\[(\d*)\]$/.exec(arg.type);
+  if (array) {
+    return {
+      kind: "array",
+      length: array[2] === "" ? -1 : Number(array[2]),
+      elem: parseType({type: array[1], components: arg.components}),
+    };
+  }
+  if (arg.type === "tuple") {
+    return {
+      kind: "tuple",
+      components: arg.components.map(parseType),
+      names: arg.components.map(fieldName),
+    };
+  }
+  if (arg.type === "string" || arg.type === "bytes") {
+    return {kind: arg.type};
+  }
+  const name = arg.type === "uint" ? "uint256" : arg.type === "int" ? "int256" : arg.type;
+  return {kind: "elementary", name: name};
+}
+
+function fieldName(arg, i) {
+  return arg.name || "arg" + i;
+}
+
+function isDynamic(type) {
+  switch (type.kind) {
+    case "string":
+    case "bytes":
+      return true;
+    case "array":
+      return type.length < 0 || isDynamic(type.elem);
+    case "tuple":
+      return type.components.some(isDynamic);
+  }
+  return false;
+}
+
+function headSize(type) {
+  if (isDynamic(type)) {
+    return 32;
+  }
+  switch (type.kind) {
+    case "array":
+      return type.length * headSize(type.elem);
+    case "tuple":
+      return type.components.reduce((size, component) => size + headSize(component), 0);
+  }
+  return 32;
+}
+
+function fromHex(hex) {
+  hex = hex.startsWith("0x") || hex.startsWith("0X") ? hex.slice(2) : hex;
+  if (hex.length % 2 !== 0 || !/^[0-9a-fA-F]*$/.test(hex)) {
+    throw new Error(hex + " is not hex");
+  }
+  return hex.toLowerCase();
+}
+
+function padLeft(hex) {
+  return hex.padStart(64, "0");
+}
+
+function padRight(hex) {
+  return hex.padEnd(Math.ceil(hex.length / 64) * 64, "0");
+}
+
+function intWord(value) {
+  return padLeft(BigInt(value).toString(16));
+}
+
+const TWO_256 = 2n ** 256n;
+
+function encodeWord(name, value) {
+  if (name === "address") {
+    const hex = fromHex(value);
+    if (hex.length !== 40) {
+      throw new Error(value + " is not an address");
+    }
+    return padLeft(hex);
+  }
+  if (name === "bool") {
+    return intWord(value ? 1 : 0);
+  }
+  const int = /^(u?)int(\d+)$/.exec(name);
+  if (int) {
+    const size = BigInt(int[2]);
+    let i = BigInt(value);
+    const min = int[1] ? 0n : -(2n ** (size - 1n));
+    const max = int[1] ? 2n ** size : 2n ** (size - 1n);
+    if (i < min || i >= max) {
+      throw new Error(value + " is out of range for " + name);
+    }
+    if (i < 0n) {
+      i += TWO_256;
+    }
+    return intWord(i);
+  }
+  const bytes = /^bytes(\d+)$/.exec(name);
+  if (bytes) {
+    const hex = fromHex(value);
+    if (hex.length > Number(bytes[1]) * 2) {
+      throw new Error(value + " is not a " + name);
+    }
+    return padRight(hex || "0");
+  }
+  throw new Error("ABI type " + name + " is not supported");
+}
+
+function utf8Hex(s) {
+  return Array.from(new TextEncoder().encode(s),
+    (b) => b.toString(16).padStart(2, "0")).join("");
+}
+
+function encode(type, value) {
+  switch (type.kind) {
+    case "string":
+    case "bytes": {
+      const hex = type.kind === "string" ? utf8Hex(value) : fromHex(value);
+      return intWord(hex.length / 2) + (hex.length ? padRight(hex) : "");
+    }
+    case "array": {
+      if (!Array.isArray(value)) {
+        throw new Error("Expected an array but got " + value);
+      }
+      if (type.length >= 0 && value.length !== type.length) {
+        throw new Error("Expected " + type.length + " elements but got " + value.length);
+      }
+      const encoded = encodeSequence(value.map(() => type.elem), value);
+      return type.length < 0 ? intWord(value.length) + encoded : encoded;
+    }
+    case "tuple": {
+      const values = Array.isArray(value) ? value : type.names.map((name) => value[name]);
+      return encodeSequence(type.components, values);
+    }
+  }
+  return encodeWord(type.name, value);
+}
+
+function encodeSequence(types, values) {
+  if (types.length !== values.length) {
+    throw new Error("Expected " + types.length + " values but got " + values.length);
+  }
+  let offset = types.reduce((size, type) => size + headSize(type), 0);
+  const heads = [];
+  const tails = [];
+  types.forEach((type, i) => {
+    const encoded = encode(type, values[i]);
+    if (isDynamic(type)) {
+      heads.push(intWord(offset));
+      tails.push(encoded);
+      offset += encoded.length / 2;
+    } else {
+      heads.push(encoded);
+    }
+  });
+  return heads.join("") + tails.join("");
+}
+
+function readWord(data, offset) {
+  if (data.length < (offset + 32) * 2) {
+    throw new Error("Data of length " + data.length / 2 + " ends before the word at " + offset);
+  }
+  return data.slice(offset * 2, (offset + 32) * 2);
+}
+
+function readInt(data, offset) {
+  const i = BigInt("0x" + readWord(data, offset));
+  if (i > BigInt(data.length / 2)) {
+    throw new Error("Offset or length " + i + " overruns the data");
+  }
+  return Number(i);
+}
+
+function decodeWord(name, word) {
+  if (name === "address") {
+    return "0x" + word.slice(24);
+  }
+  if (name === "bool") {
+    return BigInt("0x" + word) !== 0n;
+  }
+  const int = /^(u?)int(\d+)$/.exec(name);
+  if (int) {
+    const i = BigInt("0x" + word);
+    return !int[1] && i >= TWO_256 / 2n ? i - TWO_256 : i;
+  }
+  const bytes = /^bytes(\d+)$/.exec(name);
+  if (bytes) {
+    return "0x" + word.slice(0, Number(bytes[1]) * 2);
+  }
+  throw new Error("ABI type " + name + " is not supported");
+}
+
+function decode(type, data, offset) {
+  switch (type.kind) {
+    case "string":
+    case "bytes": {
+      const length = readInt(data, offset);
+      if (data.length < (offset + 32 + length) * 2) {
+        throw new Error(type.kind + " of length " + length + " overruns the data");
+      }
+      const hex = data.slice((offset + 32) * 2, (offset + 32 + length) * 2);
+      if (type.kind === "bytes") {
+        return "0x" + hex;
+      }
+      const bytes = new Uint8Array(length);
+      for (let i = 0; i < length; i++) {
+        bytes[i] = parseInt(hex.slice(i * 2, i * 2 + 2), 16);
+      }
+      return new TextDecoder().decode(bytes);
+    }
+    case "array": {
+      let length = type.length;
+      if (length < 0) {
+        length = readInt(data, offset);
+        offset += 32;
+      }
+      return decodeSequence(new Array(length).fill(type.elem), data, offset);
+    }
+    case "tuple": {
+      const values = decodeSequence(type.components, data, offset);
+      const tuple = {};
+      type.names.forEach((name, i) => {
+        tuple[name] = values[i];
+      });
+      return tuple;
+    }
+  }
+  return decodeWord(type.name, readWord(data, offset));
+}
+
+function decodeSequence(types, data, offset) {
+  let head = offset;
+  return types.map((type) => {
+    const start = isDynamic(type) ? offset + readInt(data, head) : head;
+    head += headSize(type);
+    return decode(type, data, start);
+  });
+}
+
+// Encodes the inputs of a function given as ABI arguments
+export function encodeArgs(inputs, args) {
+  return encodeSequence(inputs.map(parseType), args);
+}
+
+// Decodes the outputs of a function, as undefined if it has none, the value
+// if it has one and otherwise an array of them
+export function decodeOutputs(outputs, ret) {
+  const values = decodeSequence(outputs.map(parseType), fromHex(ret), 0);
+  if (values.length <= 1) {
+    return values[0];
+  }
+  return values;
+}
+
+// Decodes the inputs of an event from a log, keyed by name. Indexed inputs of
+// types other than elementary ones are logged only as their hash, which is
+// given in their place.
+export function decodeEvent(inputs, log) {
+  const topics = log.topics.slice(1);
+  const data = fromHex(log.data);
+  const types = inputs.map(parseType);
+  const indexed = [];
+  const unindexed = [];
+  inputs.forEach((input, i) => (input.indexed ? indexed : unindexed).push(i));
+  const values = new Array(inputs.length);
+  indexed.forEach((i, j) => {
+    if (j >= topics.length) {
+      throw new Error("Log is missing the topic of input " + fieldName(inputs[i], i));
+    }
+    const word = fromHex(topics[j]);
+    values[i] = types[i].kind === "elementary" ? decodeWord(types[i].name, word) : "0x" + word;
+  });
+  const decoded = decodeSequence(unindexed.map((i) => types[i]), data, 0);
+  unindexed.forEach((i, j) => {
+    values[i] = decoded[j];
+  });
+  const event = {};
+  inputs.forEach((input, i) => {
+    event[fieldName(input, i)] = values[i];
+  });
+  return event;
+}
+
+function sleep(ms) {
+  return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+export class Contract {
+  constructor(address, session) {
+    this.address = address;
+    this._session = session;
+    this._id = 0;
+  }
+
+  async _rpc(method, params) {
+    const response = await fetch(this._session.url, {
+      method: "POST",
+      headers: {"Content-Type": "application/json"},
+      body: JSON.stringify({jsonrpc: "2.0", id: ++this._id, method: method, params: params}),
+    });
+    const body = await response.json();
+    if (body.error) {
+      throw new Error(method + " failed: " + body.error.message);
+    }
+    return body.result;
+  }
+
+  _pollInterval() {
+    return this._session.pollInterval || 1000;
+  }
+
+  async _call(selector, inputs, args, outputs) {
+    const call = {to: this.address, data: selector + encodeArgs(inputs, args)};
+    if (this._session.from) {
+      call.from = this._session.from;
+    }
+    return decodeOutputs(outputs, await this._rpc("eth_call", [call, "latest"]));
+  }
+
+  async _transact(selector, inputs, args, options) {
+    options = options || {};
+    const from = this._session.from;
+    const signer = this._session.signer;
+    if (!from || !signer) {
+      throw new Error("Sending a transaction needs a session with from and signer");
+    }
+    const nonce = await this._rpc("eth_getTransactionCount", [from, "latest"]);
+    const chainId = await this._rpc("eth_chainId", []);
+    const tx = {
+      from: from,
+      to: this.address,
+      data: selector + encodeArgs(inputs, args),
+      value: BigInt(options.value || 0),
+      nonce: BigInt(nonce),
+      chainId: BigInt(chainId),
+    };
+    if (options.gas !== undefined) {
+      tx.gas = BigInt(options.gas);
+    }
+    const hash = await this._rpc("eth_sendRawTransaction", [await signer(tx)]);
+    for (;;) {
+      const receipt = await this._rpc("eth_getTransactionReceipt", [hash]);
+      if (receipt) {
+        if (receipt.status === "0x0") {
+          throw new Error("Transaction " + hash + " failed");
+        }
+        return receipt;
+      }
+      await sleep(this._pollInterval());
+    }
+  }
+
+  _watch(topic, inputs, handler, options) {
+    options = options || {};
+    let next = options.fromBlock === undefined ? undefined : BigInt(options.fromBlock);
+    let stopped = false;
+    const poll = async () => {
+      const latest = BigInt(await this._rpc("eth_blockNumber", []));
+      if (next === undefined) {
+        next = latest + 1n;
+      }
+      if (next > latest) {
+        return;
+      }
+      const logs = await this._rpc("eth_getLogs", [{
+        address: this.address,
+        topics: [topic],
+        fromBlock: "0x" + next.toString(16),
+        toBlock: "0x" + latest.toString(16),
+      }]);
+      next = latest + 1n;
+      for (const log of logs) {
+        if (!stopped) {
+          handler(decodeEvent(inputs, log), log);
+        }
+      }
+    };
+    const loop = async () => {
+      while (!stopped) {
+        try {
+          await poll();
+        } catch (err) {
+          if (options.onError) {
+            options.onError(err);
+          }
+        }
+        await sleep(this._pollInterval());
+      }
+    };
+    loop();
+    return () => {
+      stopped = true;
+    };
+  }
+}
+`
@@ -0,0 +1,72 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bind
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindTypeScript(t *testing.T) {
+	js, dts, err := BindTypeScript([]ContractABI{{Name: "Token", ABI: tokenABI}})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(js), "export class Token extends Contract {")
+	// Selectors and topics are computed at generation
+	assert.Contains(t, string(js), `balanceOf(owner) {
+    return this._call("0x70a08231", [{"name":"_owner","type":"address"}], [owner], [{"name":"","type":"uint256"}]);`)
+	assert.Contains(t, string(js), `transfer1(to, options) {
+    return this._transact("0x1a695230", [{"name":"to","type":"address"}], [to], options);`)
+	assert.Contains(t, string(js), `watchTransfer(handler, options) {
+    return this._watch("0x0844b14fe102ea307aadd2235f4dbb2e0c33cc0466085bd36b25e54ddf9c4a94"`)
+	assert.Contains(t, string(js), "No watcher is generated for the Anonymous() event since it is anonymous")
+
+	assert.Contains(t, string(dts), "balanceOf(owner: string): Promise<bigint>;")
+	assert.Contains(t, string(dts),
+		"transfer(to: string, value: Numeric, options?: TransactOptions): Promise<Receipt>;")
+	assert.Contains(t, string(dts), "transfer1(to: string, options?: PayableOptions): Promise<Receipt>;")
+	assert.Contains(t, string(dts), "holders(type: string[]): Promise<TokenHolder[]>;")
+	assert.Contains(t, string(dts), `export interface TokenHolder {
+  holder: string;
+  tags: string[];
+  since: TokenDate;
+}`)
+	assert.Contains(t, string(dts), `export interface TokenTransferEvent {
+  from: string;
+  memo: string;
+  value: bigint;
+}`)
+	assert.Contains(t, string(dts), "watchTransfer(handler: (event: TokenTransferEvent, log: Log) => void, options?: WatchOptions): () => void;")
+	assert.Contains(t, string(dts), `export interface TokenMovedEvent {
+  ids: string;
+  to: TokenDate;
+}`)
+	assert.NotContains(t, string(dts), "watchAnonymous")
+
+	_, _, err = BindTypeScript([]ContractABI{{Name: "class", ABI: tokenABI}})
+	assert.Error(t, err)
+}
+
+func TestJSNames(t *testing.T) {
+	js, _, err := BindTypeScript([]ContractABI{{Name: "Names", ABI: `[
+		{"type": "function", "name": "_call", "constant": true,
+			"inputs": [{"name": "new", "type": "uint8"}, {"name": "options", "type": "bool"}],
+			"outputs": []}
+	]`}})
+	require.NoError(t, err)
+	assert.Contains(t, string(js), "_call_(arg0, arg1) {")
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contract calls the functions of contracts and watches their events
+// by their ABI. It is what the Go bindings generated by burrow abi bind are
+// built on.
+package contract
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/keys"
+	vm "github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/txs"
+)
+
+// The node and key a contract is called through
+type Session struct {
+	NodeClient client.NodeClient
+	// Needed only to send CallTxs
+	KeyClient keys.KeyClient
+	ChainID   string
+	// The address of the key CallTxs are signed with, which is also the caller
+	// of simulated calls
+	From []byte
+	// The gas limit and fee of CallTxs
+	Gas int64
+	Fee int64
+}
+
+// A contract at an address
+type Contract struct {
+	Address []byte
+	ABI     *abi.ABI
+	session *Session
+}
+
+func New(address []byte, abiJSON string, session *Session) (*Contract, error) {
+	contractABI, err := abi.ReadABI([]byte(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.NodeClient == nil {
+		return nil, fmt.Errorf("Contract at %X needs a session with a node client",
+			address)
+	}
+	return &Contract{
+		Address: address,
+		ABI:     contractABI,
+		session: session,
+	}, nil
+}
+
+// Runs method, a function name or signature, as a simulated call and assigns
+// its outputs to the values outputs point to
+func (contract *Contract) Call(method string, inputs []interface{},
+	outputs ...interface{}) error {
+	function, data, err := contract.pack(method, inputs)
+	if err != nil {
+		return err
+	}
+	ret, _, err := contract.session.NodeClient.QueryContract(contract.session.From,
+		contract.Address, data)
+	if err != nil {
+		return vm.DecodeRevertError(err, contract.ABI)
+	}
+	return contract.unpack(function, ret, outputs)
+}
+
+// Calls method, a function name or signature, with a CallTx sending amount
+// and waits for it to be committed. Its outputs are assigned to the values
+// outputs point to.
+func (contract *Contract) Transact(method string, amount int64, inputs []interface{},
+	outputs ...interface{}) (*rpc.TxResult, error) {
+	function, data, err := contract.pack(method, inputs)
+	if err != nil {
+		return nil, err
+	}
+	session := contract.session
+	if session.KeyClient == nil {
+		return nil, fmt.Errorf("A key client is needed to call %s with a CallTx",
+			method)
+	}
+	tx, err := rpc.Call(session.NodeClient, session.KeyClient, "",
+		hex.EncodeToString(session.From), hex.EncodeToString(contract.Address),
+		strconv.FormatInt(amount, 10), "", strconv.FormatInt(session.Gas, 10),
+		strconv.FormatInt(session.Fee, 10), hex.EncodeToString(data))
+	if err != nil {
+		return nil, err
+	}
+	result, err := rpc.SignAndBroadcast(session.ChainID, session.NodeClient,
+		session.KeyClient, tx, true, true, true)
+	if err != nil {
+		return nil, vm.DecodeRevertError(err, contract.ABI)
+	}
+	return result, contract.unpack(function, result.Return, outputs)
+}
+
+// Calls handler with the inputs of each event called name the contract emits,
+// decoded as by abi.Event.Unpack, and the height of its block, until stop is
+// called. A log that cannot be decoded is passed to handler as an error.
+func (contract *Contract) WatchEvent(name string,
+	handler func(values []interface{}, height int64, err error)) (stop func(), err error) {
+	var event *abi.Event
+	for _, e := range contract.ABI.Events {
+		if e.Name == name {
+			event = e
+		}
+	}
+	if event == nil {
+		return nil, fmt.Errorf("ABI of contract at %X has no event %s",
+			contract.Address, name)
+	}
+	if event.Anonymous {
+		return nil, fmt.Errorf("Event %s is anonymous so cannot be told apart "+
+			"from other logs", name)
+	}
+	wsClient, err := contract.session.NodeClient.DeriveWebsocketClient()
+	if err != nil {
+		return nil, err
+	}
+	logs, err := wsClient.SubscribeLogs(contract.Address)
+	if err != nil {
+		wsClient.Close()
+		return nil, err
+	}
+	go func() {
+		for log := range logs {
+			if len(log.Topics) == 0 || log.Topics[0] != event.ID() {
+				continue
+			}
+			handler(decodeLog(event, log))
+		}
+	}()
+	return wsClient.Close, nil
+}
+
+func decodeLog(event *abi.Event, log txs.EventDataLog) ([]interface{}, int64, error) {
+	values, err := event.Unpack(log.Topics, log.Data)
+	return values, log.Height, err
+}
+
+func (contract *Contract) pack(method string, inputs []interface{}) (*abi.Function, []byte, error) {
+	function, err := contract.ABI.FunctionByName(method, len(inputs))
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := function.Pack(inputs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return function, data, nil
+}
+
+func (contract *Contract) unpack(function *abi.Function, ret []byte,
+	outputs []interface{}) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	values, err := function.Unpack(ret)
+	if err != nil {
+		return err
+	}
+	return abi.Assign(values, outputs...)
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+func ABI(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "ABI")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	var abiJSON string
+	if do.ABIFileFlag != "" {
+		abiBytes, err := ioutil.ReadFile(do.ABIFileFlag)
+		if err != nil {
+			return fmt.Errorf("Could not read ABI file %s: %s", do.ABIFileFlag, err)
+		}
+		abiJSON = string(abiBytes)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	abiTransaction, err := rpc.ABI(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.ToFlag, do.AmtFlag, do.NonceFlag,
+		abiJSON, do.ABIHashFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming ABI Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, abiTransaction, logger)
+}
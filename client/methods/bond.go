@@ -0,0 +1,57 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+func Bond(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Bond")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	bondTransaction, err := rpc.Bond(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.UnbondtoFlag, do.AmtFlag, do.FeeFlag, do.NonceFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Bond Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, bondTransaction, logger)
+}
+
+func Unbond(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Unbond")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	unbondTransaction, err := rpc.Unbond(do.AddrFlag, do.HeightFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Unbond Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, unbondTransaction, logger)
+}
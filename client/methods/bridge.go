@@ -0,0 +1,176 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/tendermint/go-wire"
+)
+
+// Writes the Ethereum address of the bridge key, which the contract on
+// Ethereum must hold to accept the attestations of the validator
+func BridgeAddress(do *definitions.ClientDo) error {
+	bridgeKey, err := readBridgeKey(do.BridgeKeyFileFlag)
+	if err != nil {
+		return err
+	}
+	pubkey, err := secp256k1.PubkeyFromSeckey(bridgeKey)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%X\n", sha3.Sha3(pubkey[1:])[12:])
+	return nil
+}
+
+// Watches the node for the logs of the events designated for the bridge, and
+// attests each of them with an AttestTx signed by the validator key and the
+// bridge key, until the node closes the connection. Events emitted while it is
+// not watching are not attested, and events designated after it starts are
+// only watched once it is restarted.
+func BridgeAttest(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "BridgeAttest")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	if do.OfflineFlag {
+		return fmt.Errorf("Events can only be attested by watching a node")
+	}
+	bridgeKey, err := readBridgeKey(do.BridgeKeyFileFlag)
+	if err != nil {
+		return err
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	designated, err := burrowNodeClient.ListDesignatedEvents()
+	if err != nil {
+		return err
+	}
+	topics := make(map[string]map[Word256]bool)
+	for _, event := range designated {
+		if event.RevokedAt != 0 {
+			continue
+		}
+		if topics[string(event.Address)] == nil {
+			topics[string(event.Address)] = make(map[Word256]bool)
+		}
+		topics[string(event.Address)][event.Topic] = true
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("No events are designated for the bridge")
+	}
+
+	// A websocket client reads all the events it is sent, so each contract is
+	// watched with its own
+	logs := make(chan txs.EventDataLog)
+	var watching sync.WaitGroup
+	for address := range topics {
+		wsClient, err := burrowNodeClient.DeriveWebsocketClient()
+		if err != nil {
+			return err
+		}
+		defer wsClient.Close()
+		contractLogs, err := wsClient.SubscribeLogs([]byte(address))
+		if err != nil {
+			return err
+		}
+		watching.Add(1)
+		go func() {
+			defer watching.Done()
+			for log := range contractLogs {
+				logs <- log
+			}
+		}()
+		logging.InfoMsg(logger, "Watching contract for designated events",
+			"address", fmt.Sprintf("%X", address))
+	}
+	go func() {
+		watching.Wait()
+		close(logs)
+	}()
+
+	for log := range logs {
+		event := log.BridgeEvent()
+		if len(event.Topics) == 0 || !topics[string(event.Address)][event.Topics[0]] {
+			continue
+		}
+		attestLogger := logger.With("id",
+			fmt.Sprintf("%X", txs.AttestationID(do.ChainidFlag, event)))
+		tx, err := rpc.Attest(burrowNodeClient, burrowKeyClient, do.ChainidFlag,
+			do.PubkeyFlag, do.AddrFlag, event, bridgeKey)
+		if err == nil {
+			_, err = signAndBroadcastResult(do, burrowNodeClient, burrowKeyClient, tx)
+		}
+		// One event failing to be attested does not stop the others
+		if err != nil {
+			logging.InfoMsg(attestLogger, "Could not attest event", "error", err)
+			continue
+		}
+		logging.InfoMsg(attestLogger, "Attested event", "height", event.Height)
+	}
+	return fmt.Errorf("The node stopped sending events")
+}
+
+// Writes the attestation with the ID of --id as JSON, with the signatures of
+// the validators that have attested its event so far
+func BridgeAttestation(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "BridgeAttestation")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	id, err := hex.DecodeString(do.AttestationIDFlag)
+	if err != nil {
+		return fmt.Errorf("Attestation ID (%s) is bad hex: %s", do.AttestationIDFlag, err)
+	}
+	attestation, err := nodeClientFromClientDo(do, logger).GetAttestation(id)
+	if err != nil {
+		return err
+	}
+	if attestation == nil {
+		return fmt.Errorf("No validator has attested %X", id)
+	}
+	fmt.Println(string(wire.JSONBytes(attestation)))
+	return nil
+}
+
+func readBridgeKey(file string) ([]byte, error) {
+	if file == "" {
+		return nil, fmt.Errorf("The bridge key must be given with --bridge-key-file")
+	}
+	keyHex, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read bridge key: %s", err)
+	}
+	bridgeKey, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil || len(bridgeKey) != 32 {
+		return nil, fmt.Errorf("Bridge key must be a hex 32 byte secp256k1 secret key")
+	}
+	return bridgeKey, nil
+}
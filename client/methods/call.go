@@ -17,10 +17,8 @@ package methods
 import (
 	"fmt"
 
-	"github.com/hyperledger/burrow/client"
 	"github.com/hyperledger/burrow/client/rpc"
 	"github.com/hyperledger/burrow/definitions"
-	"github.com/hyperledger/burrow/keys"
 )
 
 func Call(do *definitions.ClientDo) error {
@@ -30,8 +28,11 @@ func Call(do *definitions.ClientDo) error {
 	if err != nil {
 		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
 	}
-	burrowKeyClient := keys.NewBurrowKeyClient(do.SignAddrFlag, logger)
-	burrowNodeClient := client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
 	// form the call transaction
 	callTransaction, err := rpc.Call(burrowNodeClient, burrowKeyClient,
 		do.PubkeyFlag, do.AddrFlag, do.ToFlag, do.AmtFlag, do.NonceFlag,
@@ -39,15 +40,5 @@ func Call(do *definitions.ClientDo) error {
 	if err != nil {
 		return fmt.Errorf("Failed on forming Call Transaction: %s", err)
 	}
-	// TODO: [ben] we carry over the sign bool, but always set it to true,
-	// as we move away from and deprecate the api that allows sending unsigned
-	// transactions and relying on (our) receiving node to sign it.
-	txResult, err := rpc.SignAndBroadcast(do.ChainidFlag, burrowNodeClient, burrowKeyClient,
-		callTransaction, true, do.BroadcastFlag, do.WaitFlag)
-
-	if err != nil {
-		return fmt.Errorf("Failed on signing (and broadcasting) transaction: %s", err)
-	}
-	unpackSignAndBroadcast(txResult, logger)
-	return nil
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, callTransaction, logger)
 }
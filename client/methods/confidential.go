@@ -0,0 +1,88 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+// Writes the hex blob the node seals --data into for --owner to put at
+// --blob-key in the Confidential native contract
+func ConfidentialSeal(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "ConfidentialSeal")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	if do.OfflineFlag {
+		return fmt.Errorf("Blobs can only be sealed by a node")
+	}
+	owner, key, err := confidentialOwnerAndKey(do)
+	if err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(do.DataFlag)
+	if err != nil {
+		return fmt.Errorf("Data (%s) is bad hex: %s", do.DataFlag, err)
+	}
+	blob, err := nodeClientFromClientDo(do, logger).SealConfidential(owner, key, data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%X\n", blob)
+	return nil
+}
+
+// Writes the hex data of the blob --owner put at --blob-key, which the node
+// opens once the read signed with the key of the account is granted
+func ConfidentialRead(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "ConfidentialRead")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	owner, key, err := confidentialOwnerAndKey(do)
+	if err != nil {
+		return err
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	data, err := rpc.ReadConfidential(burrowNodeClient, burrowKeyClient, do.PubkeyFlag,
+		do.AddrFlag, do.ChainidFlag, owner, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%X\n", data)
+	return nil
+}
+
+func confidentialOwnerAndKey(do *definitions.ClientDo) ([]byte, []byte, error) {
+	owner, err := hex.DecodeString(do.ConfidentialOwnerFlag)
+	if err != nil || len(owner) != 20 {
+		return nil, nil, fmt.Errorf("Owner (%s) must be a hex 20 byte address",
+			do.ConfidentialOwnerFlag)
+	}
+	key, err := hex.DecodeString(do.ConfidentialKeyFlag)
+	if err != nil || len(key) > 32 {
+		return nil, nil, fmt.Errorf("Key (%s) must be at most 32 bytes of hex",
+			do.ConfidentialKeyFlag)
+	}
+	return owner, key, nil
+}
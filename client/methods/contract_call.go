@@ -0,0 +1,204 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/definitions"
+	vm "github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+)
+
+// How many times to look for the receipt of a CallTx, which is only stored
+// once its block is committed, just after it is confirmed
+const receiptAttempts = 10
+
+// Calls the function method of the contract at address with args encoded by
+// the registered ABI of the contract, or the ABI in --abi-file. Constant
+// functions, or any with --simulate, are run as a simulated call; otherwise
+// a CallTx is signed and broadcast. The values returned and the events the
+// call emitted are decoded and printed.
+func ContractCall(do *definitions.ClientDo, address, method string, args []string) error {
+	logger, err := loggerFromClientDo(do, "ContractCall")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	contractAddress, err := hex.DecodeString(address)
+	if err != nil {
+		return fmt.Errorf("Contract address is bad hex: %s", err)
+	}
+	nodeClient := nodeClientFromClientDo(do, logger)
+	abiJSON, err := contractABI(do, nodeClient, contractAddress)
+	if err != nil {
+		return err
+	}
+	parsedABI, err := abi.ReadABI([]byte(abiJSON))
+	if err != nil {
+		return err
+	}
+	function, err := parsedABI.FunctionByName(method, len(args))
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	data, err := function.Pack(values...)
+	if err != nil {
+		return err
+	}
+
+	if do.SimulateFlag || function.IsConstant() {
+		if nodeClient == nil {
+			return fmt.Errorf("Cannot simulate a call offline")
+		}
+		var caller []byte
+		if do.AddrFlag != "" {
+			if caller, err = hex.DecodeString(do.AddrFlag); err != nil {
+				return fmt.Errorf("Caller address is bad hex: %s", err)
+			}
+		}
+		ret, gasUsed, err := nodeClient.QueryContract(caller, contractAddress, data)
+		if err != nil {
+			return vm.DecodeRevertError(err, parsedABI)
+		}
+		fmt.Printf("Simulated call to %s used %v gas\n", method, gasUsed)
+		return printOutputs(function, ret)
+	}
+
+	if do.ChainidFlag == "" {
+		return fmt.Errorf("Please provide a chain id either through the flag " +
+			"--chain-id or environment variable $CHAIN_ID")
+	}
+	keyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	callTx, err := rpc.Call(nodeClient, keyClient, do.PubkeyFlag, do.AddrFlag,
+		address, do.AmtFlag, do.NonceFlag, do.GasFlag, do.FeeFlag,
+		hex.EncodeToString(data))
+	if err != nil {
+		return fmt.Errorf("Failed on forming Call Transaction: %s", err)
+	}
+	result, err := signAndBroadcastResult(do, nodeClient, keyClient, callTx)
+	if err != nil || result == nil {
+		return err
+	}
+	fmt.Printf("Tx %X calling %s\n", result.Hash, method)
+	if !do.WaitFlag {
+		return nil
+	}
+	if result.Exception != "" {
+		return vm.DecodeRevertError(fmt.Errorf("Call to %s failed: %s", method,
+			result.Exception), parsedABI)
+	}
+	if err := printOutputs(function, result.Return); err != nil {
+		return err
+	}
+	receipt, err := txReceipt(nodeClient, result.Hash, do.ABIFileFlag, abiJSON)
+	if err != nil {
+		return fmt.Errorf("Could not get the events emitted by the call: %s", err)
+	}
+	printEvents(receipt)
+	return nil
+}
+
+// Reads the ABI from --abi-file, or else gets the ABI registered for the
+// contract
+func contractABI(do *definitions.ClientDo, nodeClient client.NodeClient,
+	address []byte) (string, error) {
+	if do.ABIFileFlag != "" {
+		abiJSON, err := ioutil.ReadFile(do.ABIFileFlag)
+		if err != nil {
+			return "", fmt.Errorf("Could not read ABI file: %s", err)
+		}
+		return string(abiJSON), nil
+	}
+	if nodeClient == nil {
+		return "", fmt.Errorf("The ABI must be given with --abi-file offline")
+	}
+	entry, err := nodeClient.GetABI(address)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", fmt.Errorf("No ABI is registered for the contract at %X, "+
+			"give it with --abi-file", address)
+	}
+	if entry.ABI == "" {
+		return "", fmt.Errorf("Only the hash of the ABI of the contract at %X "+
+			"is registered, give the ABI with --abi-file", address)
+	}
+	return entry.ABI, nil
+}
+
+// Gets the receipt of a committed CallTx, waiting for its block to be
+// committed. Logs are decoded against the ABI from --abi-file if there is one,
+// and otherwise against the ABIs registered for the contracts that emitted
+// them.
+func txReceipt(nodeClient client.NodeClient, txHash []byte, abiFile,
+	abiJSON string) (receipt *core_types.TxReceipt, err error) {
+	if abiFile == "" {
+		abiJSON = ""
+	}
+	for i := 0; i < receiptAttempts; i++ {
+		if receipt, err = nodeClient.GetTxReceipt(txHash, abiJSON); err == nil {
+			return receipt, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return nil, err
+}
+
+func printOutputs(function *abi.Function, ret []byte) error {
+	values, err := function.Unpack(ret)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		output := function.Outputs[i]
+		name := output.Name
+		if name == "" {
+			name = fmt.Sprintf("%v", i)
+		}
+		fmt.Printf("%s (%s): %s\n", name, output.TypeName, abi.FormatValue(value))
+	}
+	return nil
+}
+
+func printEvents(receipt *core_types.TxReceipt) {
+	for _, log := range receipt.Logs {
+		if log.Event == nil {
+			fmt.Printf("Log from %X with topics %X and data %X\n",
+				log.Address.Postfix(20), log.Topics, log.Data)
+			continue
+		}
+		args := make([]string, len(log.Event.Args))
+		for i, arg := range log.Event.Args {
+			args[i] = fmt.Sprintf("%s: %s", arg.Name, arg.Value)
+		}
+		fmt.Printf("Event %s(%s) from %X\n", log.Event.Name, strings.Join(args, ", "),
+			log.Address.Postfix(20))
+	}
+}
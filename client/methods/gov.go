@@ -0,0 +1,42 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+// Writes a GovTx to stdout as JSON to be batched by a proposal, since a GovTx
+// is not signed and cannot be broadcast on its own
+func Gov(do *definitions.ClientDo) error {
+	var paramsJSON []byte
+	if do.ParamsFileFlag != "" {
+		var err error
+		paramsJSON, err = ioutil.ReadFile(do.ParamsFileFlag)
+		if err != nil {
+			return fmt.Errorf("Could not read chain parameters file %s: %s",
+				do.ParamsFileFlag, err)
+		}
+	}
+	govTransaction, err := rpc.Gov(string(paramsJSON), do.UnjailFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Gov Transaction: %s", err)
+	}
+	return writeTx(govTransaction)
+}
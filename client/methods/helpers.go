@@ -15,12 +15,19 @@
 package methods
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/burrow/client"
 	"github.com/hyperledger/burrow/client/rpc"
 	"github.com/hyperledger/burrow/core"
 	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/keys/keystore"
 	"github.com/hyperledger/burrow/logging"
 	"github.com/hyperledger/burrow/logging/lifecycle"
 	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/txs"
 )
 
 func unpackSignAndBroadcast(result *rpc.TxResult, logger logging_types.InfoTraceLogger) {
@@ -59,3 +66,81 @@ func loggerFromClientDo(do *definitions.ClientDo, scope string) (logging_types.I
 	lifecycle.CaptureTendermintLog15Output(logger)
 	return logger, nil
 }
+
+// Signs tx and broadcasts it, or in offline mode or with --broadcast=false
+// writes the signed tx to stdout as JSON to be broadcast later. With
+// --unsigned the tx is written without being signed, to be signed elsewhere
+// such as by burrow keys sign-tx on an air-gapped machine.
+func signAndBroadcast(do *definitions.ClientDo, nodeClient client.NodeClient,
+	keyClient keys.KeyClient, tx txs.Tx, logger logging_types.InfoTraceLogger) error {
+	txResult, err := signAndBroadcastResult(do, nodeClient, keyClient, tx)
+	if err != nil {
+		return err
+	}
+	unpackSignAndBroadcast(txResult, logger)
+	return nil
+}
+
+// As signAndBroadcast but returns the result of broadcasting the tx, which is
+// nil when the tx was written to stdout instead
+func signAndBroadcastResult(do *definitions.ClientDo, nodeClient client.NodeClient,
+	keyClient keys.KeyClient, tx txs.Tx) (*rpc.TxResult, error) {
+	if do.UnsignedFlag {
+		return nil, writeTx(tx)
+	}
+	broadcast := do.BroadcastFlag && !do.OfflineFlag
+	// TODO: [ben] we carry over the sign bool, but always set it to true,
+	// as we move away from and deprecate the api that allows sending unsigned
+	// transactions and relying on (our) receiving node to sign it.
+	txResult, err := rpc.SignAndBroadcast(do.ChainidFlag, nodeClient, keyClient,
+		tx, true, broadcast, do.WaitFlag)
+	if err != nil {
+		return nil, fmt.Errorf("Failed on signing (and broadcasting) transaction: %s", err)
+	}
+	if !broadcast {
+		return nil, writeTx(tx)
+	}
+	return txResult, nil
+}
+
+func writeTx(tx txs.Tx) error {
+	_, err := fmt.Println(string(txs.TxJSON(tx)))
+	return err
+}
+
+// Makes the client for the node, or none in offline mode so that the network
+// is never touched and the nonce must be given with --nonce
+func nodeClientFromClientDo(do *definitions.ClientDo, logger logging_types.InfoTraceLogger) client.NodeClient {
+	if do.OfflineFlag {
+		return nil
+	}
+	return client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+}
+
+// Makes the client that signs with the key in --key-file, or else the client
+// for monax-keys, connecting over https when its address is an https one
+func keyClientFromClientDo(do *definitions.ClientDo, logger logging_types.InfoTraceLogger) (keys.KeyClient, error) {
+	if do.KeyFileFlag != "" {
+		privAccount, err := keystore.ReadKeyFile(do.KeyFileFlag, do.PassphraseFileFlag)
+		if err != nil {
+			return nil, err
+		}
+		// sign with the key of the file unless told otherwise
+		if do.PubkeyFlag == "" && do.AddrFlag == "" {
+			do.AddrFlag = fmt.Sprintf("%X", privAccount.Address)
+		}
+		return keys.NewLocalKeyClient(privAccount), nil
+	}
+	if !strings.HasPrefix(do.SignAddrFlag, "https://") {
+		if do.SignCertFlag != "" || do.SignCAFlag != "" {
+			return nil, fmt.Errorf("monax-keys must be reached over https to use " +
+				"certificates, so its address must start with https://")
+		}
+		return keys.NewBurrowKeyClient(do.SignAddrFlag, logger), nil
+	}
+	tlsConfig, err := keys.LoadClientTLSConfig(do.SignCertFlag, do.SignKeyFlag, do.SignCAFlag)
+	if err != nil {
+		return nil, err
+	}
+	return keys.NewBurrowKeyClientTLS(do.SignAddrFlag, tlsConfig, logger), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+func Identify(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Identify")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	// form the identify transaction, signed by monax-keys with the validator key
+	identifyTransaction, err := rpc.Identify(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.NodeIDFlag, do.NetAddressFlag,
+		do.TLSCertFingerprintFlag, do.MonikerFlag, do.NonceFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Identify Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, identifyTransaction, logger)
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/txs"
+)
+
+func MultisigAddress(do *definitions.ClientDo) error {
+	threshold, pubKeys, err := rpc.MultisigSignatories(do.ThresholdFlag, do.SignatoriesFlag)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%X\n", txs.MultisigAddress(threshold, pubKeys))
+	return nil
+}
+
+// Writes an unsigned SendTx from a multisig account to stdout
+func MultisigSend(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "MultisigSend")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowNodeClient := client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+	tx, err := rpc.MultisigSend(burrowNodeClient, do.ThresholdFlag, do.SignatoriesFlag,
+		do.ToFlag, do.AmtFlag, do.NonceFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Send Transaction: %s", err)
+	}
+	return writeMultisigTx(tx)
+}
+
+// Writes an unsigned CallTx from a multisig account to stdout
+func MultisigCall(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "MultisigCall")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowNodeClient := client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+	tx, err := rpc.MultisigCall(burrowNodeClient, do.ThresholdFlag, do.SignatoriesFlag,
+		do.ToFlag, do.AmtFlag, do.NonceFlag, do.GasFlag, do.FeeFlag, do.DataFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Call Transaction: %s", err)
+	}
+	return writeMultisigTx(tx)
+}
+
+// Signs the multisig tx in file as one of its signatories and writes it with
+// the signature added to stdout
+func MultisigSign(do *definitions.ClientDo, file string) error {
+	logger, err := loggerFromClientDo(do, "MultisigSign")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	tx, err := readMultisigTx(file)
+	if err != nil {
+		return err
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	if err := rpc.SignMultisig(burrowKeyClient, do.ChainidFlag, tx, do.PubkeyFlag, do.AddrFlag); err != nil {
+		return fmt.Errorf("Failed on signing transaction: %s", err)
+	}
+	return writeMultisigTx(tx)
+}
+
+// Combines the signatures of the copies of a multisig tx in files and writes
+// the result to stdout
+func MultisigCombine(do *definitions.ClientDo, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("Give the files of the signed copies of the transaction to combine")
+	}
+	combined, err := readMultisigTx(files[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range files[1:] {
+		tx, err := readMultisigTx(file)
+		if err != nil {
+			return err
+		}
+		if err := combined.Combine(tx); err != nil {
+			return fmt.Errorf("Could not combine %s: %s", file, err)
+		}
+	}
+	if err := combined.Verify(do.ChainidFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Transaction cannot be broadcast yet: %s\n", err)
+	}
+	return writeMultisigTx(combined)
+}
+
+// Broadcasts the multisig tx in file once it has been signed by enough of its
+// signatories
+func MultisigBroadcast(do *definitions.ClientDo, file string) error {
+	logger, err := loggerFromClientDo(do, "MultisigBroadcast")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	tx, err := readMultisigTx(file)
+	if err != nil {
+		return err
+	}
+	if err := tx.Verify(do.ChainidFlag); err != nil {
+		return fmt.Errorf("Transaction is not signed by its signatories: %s", err)
+	}
+	burrowNodeClient := client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+	txResult, err := rpc.SignAndBroadcast(do.ChainidFlag, burrowNodeClient, nil,
+		tx, false, true, do.WaitFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on broadcasting transaction: %s", err)
+	}
+	unpackSignAndBroadcast(txResult, logger)
+	return nil
+}
+
+func readMultisigTx(file string) (*txs.MultisigTx, error) {
+	txJSON, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read transaction file (%s): %s", file, err)
+	}
+	tx, err := txs.DecodeMultisigTxJSON(txJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode transaction file (%s): %s", file, err)
+	}
+	return tx, nil
+}
+
+func writeMultisigTx(tx *txs.MultisigTx) error {
+	_, err := fmt.Println(string(txs.MultisigTxJSON(tx)))
+	return err
+}
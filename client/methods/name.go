@@ -0,0 +1,52 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+func Name(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Name")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	data := do.DataFlag
+	if do.DataFileFlag != "" {
+		if data != "" {
+			return fmt.Errorf("Give the data of the name with only one of --data or --data-file")
+		}
+		dataBytes, err := ioutil.ReadFile(do.DataFileFlag)
+		if err != nil {
+			return fmt.Errorf("Could not read data file %s: %s", do.DataFileFlag, err)
+		}
+		data = string(dataBytes)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	nameTransaction, err := rpc.Name(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.AmtFlag, do.NonceFlag, do.FeeFlag, do.NameFlag, data)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Name Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, nameTransaction, logger)
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+// Forms a PermissionsTx calling the permission function args[0] with the rest
+// of args as its arguments
+func Permissions(do *definitions.ClientDo, args []string) error {
+	logger, err := loggerFromClientDo(do, "Permissions")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("Give the permission function and its arguments")
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	permissionsTransaction, err := rpc.Permissions(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.NonceFlag, args[0], args[1:])
+	if err != nil {
+		return fmt.Errorf("Failed on forming Permissions Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, permissionsTransaction, logger)
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/txs"
+)
+
+// Forms, signs and broadcasts a PrivateTx encrypted with the key of the
+// private group in --group-key-file
+func Private(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Private")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	groupKey, err := readGroupKey(do.GroupKeyFileFlag)
+	if err != nil {
+		return err
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	privateTransaction, err := rpc.Private(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.ToFlag, do.AmtFlag, do.NonceFlag,
+		do.GasFlag, do.DataFlag, groupKey)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Private Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, privateTransaction, logger)
+}
+
+func readGroupKey(file string) ([]byte, error) {
+	if file == "" {
+		return nil, fmt.Errorf("The key of the private group must be given with " +
+			"--group-key-file")
+	}
+	keyHex, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read key of private group: %s", err)
+	}
+	groupKey, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil || len(groupKey) != txs.PrivateGroupKeyLength {
+		return nil, fmt.Errorf("Key of private group must be a hex %v byte key",
+			txs.PrivateGroupKeyLength)
+	}
+	return groupKey, nil
+}
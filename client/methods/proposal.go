@@ -0,0 +1,69 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+func Propose(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Propose")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	if do.TxsFileFlag == "" {
+		return fmt.Errorf("A proposal must give the txs it batches with --txs-file")
+	}
+	txsJSON, err := ioutil.ReadFile(do.TxsFileFlag)
+	if err != nil {
+		return fmt.Errorf("Could not read txs file %s: %s", do.TxsFileFlag, err)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	proposalTransaction, err := rpc.Propose(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.AmtFlag, do.NonceFlag, do.NameFlag,
+		do.DescriptionFlag, string(txsJSON))
+	if err != nil {
+		return fmt.Errorf("Failed on forming Proposal Transaction: %s", err)
+	}
+	// the proposal is voted for by its hash
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, proposalTransaction,
+		logger.With("proposal hash", proposalTransaction.GetProposalHash(do.ChainidFlag)))
+}
+
+func Vote(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Vote")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	voteTransaction, err := rpc.Vote(burrowNodeClient, burrowKeyClient,
+		do.PubkeyFlag, do.AddrFlag, do.AmtFlag, do.NonceFlag, do.ProposalHashFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on forming Proposal Transaction: %s", err)
+	}
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, voteTransaction, logger)
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/txs"
+)
+
+// Signs the tx of any type in file, as written by a tx command with
+// --broadcast=false or --offline, and broadcasts it or writes it to stdout
+// signed
+func SignTx(do *definitions.ClientDo, file string) error {
+	logger, err := loggerFromClientDo(do, "SignTx")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	tx, err := readTx(file)
+	if err != nil {
+		return err
+	}
+	burrowKeyClient, err := keyClientFromClientDo(do, logger)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	return signAndBroadcast(do, burrowNodeClient, burrowKeyClient, tx, logger)
+}
+
+// Broadcasts the signed tx of any type in file
+func BroadcastTx(do *definitions.ClientDo, file string) error {
+	logger, err := loggerFromClientDo(do, "BroadcastTx")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	if do.OfflineFlag {
+		return fmt.Errorf("A transaction cannot be broadcast offline")
+	}
+	tx, err := readTx(file)
+	if err != nil {
+		return err
+	}
+	burrowNodeClient := nodeClientFromClientDo(do, logger)
+	txResult, err := rpc.SignAndBroadcast(do.ChainidFlag, burrowNodeClient, nil,
+		tx, false, true, do.WaitFlag)
+	if err != nil {
+		return fmt.Errorf("Failed on broadcasting transaction: %s", err)
+	}
+	unpackSignAndBroadcast(txResult, logger)
+	return nil
+}
+
+// Reads the JSON tx in file, or from stdin when file is -
+func readTx(file string) (txs.Tx, error) {
+	var txJSON []byte
+	var err error
+	if file == "-" {
+		txJSON, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		txJSON, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not read transaction file (%s): %s", file, err)
+	}
+	tx, err := txs.DecodeTxJSON(txJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode transaction file (%s): %s", file, err)
+	}
+	return tx, nil
+}
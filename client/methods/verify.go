@@ -0,0 +1,57 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/lite"
+
+	rpcclient "github.com/tendermint/go-rpc/client"
+)
+
+func Verify(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "Verify")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	height, err := strconv.Atoi(do.HeightFlag)
+	if err != nil || height < 1 {
+		return fmt.Errorf("Height (%s) must be a positive integer", do.HeightFlag)
+	}
+	jsonBlob, err := ioutil.ReadFile(do.GenesisFileFlag)
+	if err != nil {
+		return fmt.Errorf("Could not read genesis file (%s): %s", do.GenesisFileFlag, err)
+	}
+	genDoc := genesis.GenesisDocFromJSON(jsonBlob)
+	client := lite.NewClient(genDoc.ChainID, genDoc.TendermintValidators(),
+		lite.NewNodeSource(rpcclient.NewJSONRPCClient(do.NodeAddrFlag)))
+	header, err := client.VerifyHeader(height)
+	if err != nil {
+		return err
+	}
+	logger.Info("chain", do.NodeAddrFlag,
+		"chainId", header.ChainID,
+		"verified height", header.Height,
+		"block hash", fmt.Sprintf("%X", header.Hash()),
+		"app hash", fmt.Sprintf("%X", header.AppHash),
+		"validators hash", fmt.Sprintf("%X", header.ValidatorsHash),
+	)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package methods
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/solidity"
+	"github.com/hyperledger/burrow/definitions"
+)
+
+// Compiles the Solidity source and checks that the runtime bytecode of the
+// contract matches the code deployed at the address, writing a record of the
+// verification to stdout
+func VerifyContract(do *definitions.ClientDo) error {
+	logger, err := loggerFromClientDo(do, "VerifyContract")
+	if err != nil {
+		return fmt.Errorf("Could not generate logging config from ClientDo: %s", err)
+	}
+	address, err := hex.DecodeString(do.ContractAddrFlag)
+	if err != nil || len(address) != 20 {
+		return fmt.Errorf("Give the address of the contract to verify with --address")
+	}
+	source, err := ioutil.ReadFile(do.SourceFileFlag)
+	if err != nil {
+		return fmt.Errorf("Could not read source file (%s): %s", do.SourceFileFlag, err)
+	}
+	burrowNodeClient := client.NewBurrowNodeClient(do.NodeAddrFlag, logger)
+	account, err := burrowNodeClient.GetAccount(address)
+	if err != nil {
+		return fmt.Errorf("Could not get account %X: %s", address, err)
+	}
+	if account == nil {
+		return fmt.Errorf("No account at %X", address)
+	}
+	settings := solidity.Settings{
+		Solc:         do.SolcFlag,
+		Version:      do.SolcVersionFlag,
+		Optimize:     do.OptimizeFlag,
+		OptimizeRuns: do.OptimizeRunsFlag,
+		Libraries:    do.LibrariesFlag,
+	}
+	contracts, version, err := solidity.Compile(settings, do.SourceFileFlag)
+	if err != nil {
+		return err
+	}
+	contract, err := solidity.FindContract(contracts, do.ContractFlag)
+	if err != nil {
+		return err
+	}
+	record, err := solidity.Verify(address, account.Code, source, contract, settings, version)
+	if err != nil {
+		return err
+	}
+	logger.Info("contract", record.Contract,
+		"verified address", record.Address,
+		"code hash", record.CodeHash,
+		"compiler version", record.CompilerVersion,
+		"exact match", record.ExactMatch,
+	)
+	if do.ABIOutputFlag != "" {
+		if err := ioutil.WriteFile(do.ABIOutputFlag, record.ABI, 0644); err != nil {
+			return fmt.Errorf("Could not write ABI file (%s): %s", do.ABIOutputFlag, err)
+		}
+	}
+	recordJSON, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(recordJSON))
+	return err
+}
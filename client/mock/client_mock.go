@@ -116,6 +116,36 @@ func (mock *MockNodeClient) ListValidators() (blockHeight int, bondedValidators,
 	return 0, nil, nil, nil
 }
 
+func (mock *MockNodeClient) GetNode(address []byte) (*core_types.NodeRegEntry, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) ListDesignatedEvents() ([]*core_types.DesignatedEvent, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) GetAttestation(id []byte) (*core_types.Attestation, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) SealConfidential(owner, key, data []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) ReadConfidential(read *txs.ConfidentialRead,
+	pubKey, signature []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) GetABI(address []byte) (*core_types.ABIEntry, error) {
+	return nil, nil
+}
+
+func (mock *MockNodeClient) GetTxReceipt(txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	return nil, nil
+}
+
 func (mock *MockNodeClient) Logger() logging_types.InfoTraceLogger {
 	return loggers.NewNoopInfoTraceLogger()
 }
@@ -44,6 +44,22 @@ type NodeClient interface {
 	DumpStorage(address []byte) (storage *core_types.Storage, err error)
 	GetName(name string) (owner []byte, data string, expirationBlock int, err error)
 	ListValidators() (blockHeight int, bondedValidators, unbondingValidators []consensus_types.Validator, err error)
+	// Get the node the validator with address registered, nil if it has not
+	GetNode(address []byte) (*core_types.NodeRegEntry, error)
+	// Get the events designated for the bridge to Ethereum, and the
+	// attestation with id, nil if no validator has attested its event
+	ListDesignatedEvents() ([]*core_types.DesignatedEvent, error)
+	GetAttestation(id []byte) (*core_types.Attestation, error)
+	// Have the node seal data to be put by owner at key in the confidential
+	// store, and open the blob of a signed read
+	SealConfidential(owner, key, data []byte) ([]byte, error)
+	ReadConfidential(read *txs.ConfidentialRead, pubKey, signature []byte) ([]byte, error)
+	// Get the ABI registered for the contract at address, nil if
+	// there is none
+	GetABI(address []byte) (*core_types.ABIEntry, error)
+	// Get the receipt of a committed CallTx with its logs decoded against
+	// abiJSON, or the registered ABIs if it is empty
+	GetTxReceipt(txHash []byte, abiJSON string) (*core_types.TxReceipt, error)
 
 	// Logging context for this NodeClient
 	Logger() logging_types.InfoTraceLogger
@@ -54,6 +70,10 @@ type NodeWebsocketClient interface {
 	Unsubscribe(eventId string) error
 
 	WaitForConfirmation(tx txs.Tx, chainId string, inputAddr []byte) (chan Confirmation, error)
+	// Subscribe to the logs emitted by the contract at address, which are sent
+	// on the channel until the client is closed. The client reads all the
+	// events it is sent, so it should not also wait for confirmations.
+	SubscribeLogs(address []byte) (chan txs.EventDataLog, error)
 	Close()
 }
 
@@ -263,6 +283,91 @@ func (burrowNodeClient *burrowNodeClient) ListValidators() (blockHeight int,
 	return
 }
 
+//--------------------------------------------------------------------------------------------
+// Node registry
+
+func (burrowNodeClient *burrowNodeClient) GetNode(address []byte) (*core_types.NodeRegEntry, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	entry, err := tendermint_client.GetNode(client, address)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to node (%s) to get node registry "+
+			"entry for validator (%X)", burrowNodeClient.broadcastRPC, address)
+	}
+	return entry, nil
+}
+
+//--------------------------------------------------------------------------------------------
+// Bridge
+
+func (burrowNodeClient *burrowNodeClient) ListDesignatedEvents() ([]*core_types.DesignatedEvent, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	eventsResult, err := tendermint_client.ListDesignatedEvents(client)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to node (%s) to get the events "+
+			"designated for the bridge: %s", burrowNodeClient.broadcastRPC, err)
+	}
+	return eventsResult.Events, nil
+}
+
+func (burrowNodeClient *burrowNodeClient) GetAttestation(id []byte) (*core_types.Attestation, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	attestation, err := tendermint_client.GetAttestation(client, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to node (%s) to get attestation "+
+			"(%X): %s", burrowNodeClient.broadcastRPC, id, err)
+	}
+	return attestation, nil
+}
+
+//--------------------------------------------------------------------------------------------
+// Confidential store
+
+func (burrowNodeClient *burrowNodeClient) SealConfidential(owner, key,
+	data []byte) ([]byte, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	blob, err := tendermint_client.SealConfidential(client, owner, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to node (%s) to seal blob: %s",
+			burrowNodeClient.broadcastRPC, err)
+	}
+	return blob, nil
+}
+
+func (burrowNodeClient *burrowNodeClient) ReadConfidential(read *txs.ConfidentialRead,
+	pubKey, signature []byte) ([]byte, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	data, err := tendermint_client.ReadConfidential(client, read, pubKey, signature)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading blob of (%X) at key (%X) from node "+
+			"(%s): %s", read.Owner, read.Key, burrowNodeClient.broadcastRPC, err)
+	}
+	return data, nil
+}
+
+//--------------------------------------------------------------------------------------------
+// Contracts
+
+func (burrowNodeClient *burrowNodeClient) GetABI(address []byte) (*core_types.ABIEntry, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	entry, err := tendermint_client.GetABI(client, address)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to node (%s) to get the ABI "+
+			"of contract (%X): %s", burrowNodeClient.broadcastRPC, address, err)
+	}
+	return entry, nil
+}
+
+func (burrowNodeClient *burrowNodeClient) GetTxReceipt(txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	client := rpcclient.NewJSONRPCClient(burrowNodeClient.broadcastRPC)
+	receipt, err := tendermint_client.GetTxReceipt(client, txHash, abiJSON)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the receipt of tx (%X) from "+
+			"node (%s): %s", txHash, burrowNodeClient.broadcastRPC, err)
+	}
+	return receipt, nil
+}
+
 func (burrowNodeClient *burrowNodeClient) Logger() logging_types.InfoTraceLogger {
 	return burrowNodeClient.logger
 }
@@ -15,15 +15,22 @@
 package rpc
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 
 	ptypes "github.com/hyperledger/burrow/permission/types"
 
+	acc "github.com/hyperledger/burrow/account"
 	"github.com/hyperledger/burrow/client"
+	core_types "github.com/hyperledger/burrow/core/types"
 	"github.com/hyperledger/burrow/keys"
 	"github.com/hyperledger/burrow/txs"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
 )
 
 //------------------------------------------------------------------------------------
@@ -82,6 +89,73 @@ func Call(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr,
 	return tx, nil
 }
 
+// Forms a PrivateTx that calls toAddr, or creates a contract when it is
+// empty, with data, encrypted with the key of the private group groupKey.
+// All of the amount is paid as the fee.
+func Private(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, toAddr, amtS, nonceS, gasS, data string,
+	groupKey []byte) (*txs.PrivateTx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+
+	toAddrBytes, err := hex.DecodeString(toAddr)
+	if err != nil {
+		return nil, fmt.Errorf("toAddr is bad hex: %v", err)
+	}
+
+	gas, err := strconv.ParseInt(gasS, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("gas is misformatted: %v", err)
+	}
+
+	dataBytes, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("data is bad hex: %v", err)
+	}
+
+	input := &txs.TxInput{
+		Address:  pub.Address(),
+		Amount:   amt,
+		Sequence: int(nonce),
+		PubKey:   pub,
+	}
+	return txs.NewPrivateTx(input, groupKey, &txs.PrivatePayload{
+		Address:  toAddrBytes,
+		GasLimit: gas,
+		Data:     dataBytes,
+	})
+}
+
+// Signs a read of the blob owner put at key in the confidential store, at the
+// latest height of the node, with the key of pubkey or addr and has the node
+// open the blob
+func ReadConfidential(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, chainID string,
+	owner, key []byte) ([]byte, error) {
+	if nodeClient == nil {
+		return nil, fmt.Errorf("The confidential store can only be read from a node")
+	}
+	pub, err := pubKeyFromFlags(nodeClient, keyClient, pubkey, addr)
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, height, _, err := nodeClient.Status()
+	if err != nil {
+		return nil, err
+	}
+	read := &txs.ConfidentialRead{
+		Owner:  owner,
+		Key:    key,
+		Height: height,
+	}
+	signature, err := keyClient.Sign(fmt.Sprintf("%X", acc.SignBytes(chainID, read)),
+		pub.Address())
+	if err != nil {
+		return nil, err
+	}
+	return nodeClient.ReadConfidential(read, pub[:], signature)
+}
+
 func Name(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, amtS, nonceS, feeS, name, data string) (*txs.NameTx, error) {
 	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
 	if err != nil {
@@ -97,6 +171,62 @@ func Name(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr,
 	return tx, nil
 }
 
+// Forms a NameTx that extends the name registry entry owned by the signer with
+// the credit of amtS, keeping the data of the entry
+func RenewName(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, amtS, nonceS, feeS, name string) (*txs.NameTx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+	if amt <= 0 {
+		return nil, fmt.Errorf("renewing a name must add some credit to it with --amt")
+	}
+
+	fee, err := strconv.ParseInt(feeS, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("fee is misformatted: %v", err)
+	}
+
+	if nodeClient == nil {
+		return nil, fmt.Errorf("renewing a name keeps the data of its entry, " +
+			"which must be fetched from a node with --node-addr")
+	}
+	owner, data, _, err := nodeClient.GetName(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not get name registry entry %s: %v", name, err)
+	}
+	if !bytes.Equal(owner, pub.Address()) {
+		return nil, fmt.Errorf("name registry entry %s is owned by %X, not %X",
+			name, owner, pub.Address())
+	}
+
+	tx := txs.NewNameTxWithNonce(pub, name, data, amt, fee, int(nonce))
+	return tx, nil
+}
+
+func ABI(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, contractAddr, amtS, nonceS, abiJSON, abiHashS string) (*txs.ABITx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+
+	contractAddrBytes, err := hex.DecodeString(contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("contract address is bad hex: %v", err)
+	}
+
+	abiHash, err := hex.DecodeString(abiHashS)
+	if err != nil {
+		return nil, fmt.Errorf("ABI hash is bad hex: %v", err)
+	}
+
+	tx := txs.NewABITxWithNonce(pub, contractAddrBytes, abiJSON, abiHash, amt, int(nonce))
+	if err := tx.ValidateABI(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 func Permissions(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addrS, nonceS, permFunc string, argsS []string) (*txs.PermissionsTx, error) {
 	pub, _, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addrS, "0", nonceS)
 	if err != nil {
@@ -153,6 +283,47 @@ func Permissions(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey,
 			return nil, err
 		}
 		args = &ptypes.RmRoleArgs{addr, argsS[1]}
+	case "setGroup":
+		if len(argsS) != 3 {
+			return nil, fmt.Errorf("setGroup takes a group, a permission and a value (true or false)")
+		}
+		pF, err := ptypes.PermStringToFlag(argsS[1])
+		if err != nil {
+			return nil, err
+		}
+		var value bool
+		if argsS[2] == "true" {
+			value = true
+		} else if argsS[2] == "false" {
+			value = false
+		} else {
+			return nil, fmt.Errorf("Unknown value %s", argsS[2])
+		}
+		args = &ptypes.SetGroupArgs{argsS[0], pF, value}
+	case "unsetGroup":
+		if len(argsS) != 2 {
+			return nil, fmt.Errorf("unsetGroup takes a group and a permission")
+		}
+		pF, err := ptypes.PermStringToFlag(argsS[1])
+		if err != nil {
+			return nil, err
+		}
+		args = &ptypes.UnsetGroupArgs{argsS[0], pF}
+	case "addGroupMembers", "removeGroupMembers":
+		if len(argsS) < 2 {
+			return nil, fmt.Errorf("%s takes a group and the addresses of its members", permFunc)
+		}
+		addrs := make([][]byte, len(argsS)-1)
+		for i, addrS := range argsS[1:] {
+			if addrs[i], err = hex.DecodeString(addrS); err != nil {
+				return nil, err
+			}
+		}
+		if permFunc == "addGroupMembers" {
+			args = &ptypes.AddGroupMembersArgs{argsS[0], addrs}
+		} else {
+			args = &ptypes.RmGroupMembersArgs{argsS[0], addrs}
+		}
 	default:
 		return nil, fmt.Errorf("Invalid permission function for use in PermissionsTx: %s", permFunc)
 	}
@@ -161,57 +332,111 @@ func Permissions(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey,
 	return tx, nil
 }
 
-func Bond(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, unbondAddr, amtS, nonceS string) (*txs.BondTx, error) {
-	return nil, fmt.Errorf("Bond Transaction formation to be implemented on 0.12.0")
-	// pub, amt, nonce, err := checkCommon(nodeAddr, signAddr, pubkey, "", amtS, nonceS)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// var pubKey crypto.PubKeyEd25519
-	// var unbondAddrBytes []byte
-
-	// if unbondAddr == "" {
-	// 	pkb, _ := hex.DecodeString(pubkey)
-	// 	copy(pubKey[:], pkb)
-	// 	unbondAddrBytes = pubKey.Address()
-	// } else {
-	// 	unbondAddrBytes, err = hex.DecodeString(unbondAddr)
-	// 	if err != nil {
-	// 		return nil, fmt.Errorf("unbondAddr is bad hex: %v", err)
-	// 	}
+func Propose(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, amtS, nonceS, name, description,
+	txsJSON string) (*txs.ProposalTx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+
+	// the batch is a JSON array of txs in the form [type, {tx}]
+	var batch []txs.Tx
+	wire.ReadJSON(&batch, []byte(txsJSON), &err)
+	if err != nil {
+		return nil, fmt.Errorf("txs of the proposal are misformatted: %v", err)
+	}
+
+	tx := txs.NewProposalTx(&txs.TxInput{
+		Address:  pub.Address(),
+		Amount:   amt,
+		Sequence: int(nonce),
+		PubKey:   pub,
+	}, &txs.Proposal{
+		Name:        name,
+		Description: description,
+		Txs:         batch,
+	})
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func Vote(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, amtS, nonceS,
+	proposalHashS string) (*txs.ProposalTx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
 
-	// }
+	proposalHash, err := hex.DecodeString(proposalHashS)
+	if err != nil {
+		return nil, fmt.Errorf("proposal hash is bad hex: %v", err)
+	}
+
+	tx := txs.NewVoteTx(&txs.TxInput{
+		Address:  pub.Address(),
+		Amount:   amt,
+		Sequence: int(nonce),
+		PubKey:   pub,
+	}, proposalHash)
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
 
-	// tx, err := types.NewBondTx(pub)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// tx.AddInputWithNonce(pub, amt, int(nonce))
-	// tx.AddOutput(unbondAddrBytes, amt)
+// Bonds amtS of the coins of the validator key pubkey, which is also the input,
+// to itself with feeS on top as the fee. The coins are unbonded to
+// unbondAddr, or to the validator's own account when it is empty.
+func Bond(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr, unbondAddr, amtS, feeS, nonceS string) (*txs.BondTx, error) {
+	pub, amt, nonce, err := checkCommon(nodeClient, keyClient, pubkey, addr, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+	fee := int64(0)
+	if feeS != "" {
+		fee, err = strconv.ParseInt(feeS, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("fee is misformatted: %v", err)
+		}
+	}
+	unbondAddrBytes := pub.Address()
+	if unbondAddr != "" {
+		unbondAddrBytes, err = hex.DecodeString(unbondAddr)
+		if err != nil {
+			return nil, fmt.Errorf("unbondAddr is bad hex: %v", err)
+		}
+	}
 
-	// return tx, nil
+	tx, err := txs.NewBondTx(pub)
+	if err != nil {
+		return nil, err
+	}
+	tx.AddInputWithNonce(pub, amt+fee, int(nonce))
+	tx.AddOutput(unbondAddrBytes, amt)
+	return tx, nil
 }
 
 func Unbond(addrS, heightS string) (*txs.UnbondTx, error) {
-	return nil, fmt.Errorf("Unbond Transaction formation to be implemented on 0.12.0")
-	// if addrS == "" {
-	// 	return nil, fmt.Errorf("Validator address must be given with --addr flag")
-	// }
-
-	// addrBytes, err := hex.DecodeString(addrS)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("addr is bad hex: %v", err)
-	// }
-
-	// height, err := strconv.ParseInt(heightS, 10, 32)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("height is misformatted: %v", err)
-	// }
-
-	// return &types.UnbondTx{
-	// 	Address: addrBytes,
-	// 	Height:  int(height),
-	// }, nil
+	if addrS == "" {
+		return nil, fmt.Errorf("Validator address must be given with --addr flag")
+	}
+
+	addrBytes, err := hex.DecodeString(addrS)
+	if err != nil {
+		return nil, fmt.Errorf("addr is bad hex: %v", err)
+	}
+
+	height, err := strconv.ParseInt(heightS, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("height is misformatted: %v", err)
+	}
+
+	return &txs.UnbondTx{
+		Address: addrBytes,
+		Height:  int(height),
+	}, nil
 }
 
 func Rebond(addrS, heightS string) (*txs.RebondTx, error) {
@@ -236,6 +461,129 @@ func Rebond(addrS, heightS string) (*txs.RebondTx, error) {
 	// 	}, nil
 }
 
+// Forms a RotateTx handing the voting power of the validator with public key
+// pubkey over to newPubkey from height on. It must be signed by both keys.
+func Rotate(pubkey, newPubkey, heightS string) (*txs.RotateTx, error) {
+	tx := new(txs.RotateTx)
+	for _, key := range []struct {
+		flag, hex string
+		pubKey    *crypto.PubKeyEd25519
+	}{
+		{"--pubkey", pubkey, &tx.PubKey},
+		{"--new-pubkey", newPubkey, &tx.NewPubKey},
+	} {
+		pubKeyBytes, err := hex.DecodeString(key.hex)
+		if err != nil || len(pubKeyBytes) != 32 {
+			return nil, fmt.Errorf("%s must be given as a hex ed25519 public key", key.flag)
+		}
+		copy(key.pubKey[:], pubKeyBytes)
+	}
+	height, err := strconv.ParseInt(heightS, 10, 32)
+	if err != nil || height < 1 {
+		return nil, fmt.Errorf("height is misformatted: %s", heightS)
+	}
+	tx.Height = int(height)
+	return tx, nil
+}
+
+// Forms an IdentifyTx registering the node of the validator with public key
+// pubkey, or the key monax-keys holds for addr. The node ID and the TLS
+// certificate fingerprint are hex, and the fingerprint may be left empty. When
+// nonceS is empty the sequence follows that of the node the validator last
+// registered.
+func Identify(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey, addr,
+	nodeIDS, netAddress, tlsCertFingerprintS, moniker, nonceS string) (*txs.IdentifyTx, error) {
+	pub, err := pubKeyFromFlags(nodeClient, keyClient, pubkey, addr)
+	if err != nil {
+		return nil, err
+	}
+	tx := &txs.IdentifyTx{
+		PubKey:     pub,
+		NetAddress: netAddress,
+		Moniker:    moniker,
+	}
+	if tx.NodeID, err = hex.DecodeString(nodeIDS); err != nil {
+		return nil, fmt.Errorf("node ID is bad hex: %v", err)
+	}
+	if tx.TLSCertFingerprint, err = hex.DecodeString(tlsCertFingerprintS); err != nil {
+		return nil, fmt.Errorf("TLS certificate fingerprint is bad hex: %v", err)
+	}
+	if nonceS == "" {
+		if nodeClient == nil {
+			return nil, fmt.Errorf("input must specify a nonce with the --nonce flag or use --node-addr (or BURROW_CLIENT_NODE_ADDR) to fetch the nonce from a node")
+		}
+		entry, err := nodeClient.GetNode(pub.Address())
+		if err != nil {
+			return nil, err
+		}
+		tx.Sequence = 1
+		if entry != nil {
+			tx.Sequence = entry.Sequence + 1
+		}
+	} else {
+		sequence, err := strconv.ParseInt(nonceS, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("nonce is misformatted: %v", err)
+		}
+		tx.Sequence = int(sequence)
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Forms an AttestTx of the validator with public key pubkey, or the key
+// monax-keys holds for addr, that event was emitted on chainID, with the
+// Ethereum signature of bridgeKey, the secp256k1 secret key the validator
+// bridges with
+func Attest(nodeClient client.NodeClient, keyClient keys.KeyClient, chainID, pubkey,
+	addr string, event core_types.BridgeEvent, bridgeKey []byte) (*txs.AttestTx, error) {
+	pub, err := pubKeyFromFlags(nodeClient, keyClient, pubkey, addr)
+	if err != nil {
+		return nil, err
+	}
+	tx := &txs.AttestTx{
+		PubKey: pub,
+		Event:  event,
+	}
+	if tx.EthSignature, err = txs.SignAttestation(chainID, event, bridgeKey); err != nil {
+		return nil, fmt.Errorf("Could not sign attestation with the bridge key: %v", err)
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Forms a GovTx changing the chain parameters in paramsJSON, if any, and
+// unjailing the validators with the comma separated addresses of unjailS. A
+// GovTx is not signed, it is executed as part of the batch of a proposal.
+func Gov(paramsJSON, unjailS string) (*txs.GovTx, error) {
+	tx := new(txs.GovTx)
+	if paramsJSON != "" {
+		var err error
+		tx.Params = new(txs.ChainParams)
+		wire.ReadJSON(tx.Params, []byte(paramsJSON), &err)
+		if err != nil {
+			return nil, fmt.Errorf("chain parameters are misformatted: %v", err)
+		}
+	}
+	if unjailS != "" {
+		for _, addrS := range strings.Split(unjailS, ",") {
+			addr, err := hex.DecodeString(strings.TrimSpace(addrS))
+			if err != nil {
+				return nil, fmt.Errorf("address to unjail is bad hex: %v", err)
+			}
+			tx.Unjail = append(tx.Unjail, addr)
+		}
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 type TxResult struct {
 	BlockHash []byte // all txs get in a block
 	Hash      []byte // all txs get a hash
@@ -258,9 +606,16 @@ func SignAndBroadcast(chainID string, nodeClient client.NodeClient, keyClient ke
 		if err != nil {
 			return nil, err
 		}
+	} else {
+		// already signed, such as by the signatories of a multisig tx
+		inputAddr = inputAddress(tx)
 	}
 
 	if broadcast {
+		if nodeClient == nil {
+			return nil, fmt.Errorf("a tx can only be broadcast to a node given " +
+				"with --node-addr")
+		}
 		if wait {
 			wsClient, err := nodeClient.DeriveWebsocketClient()
 			if err != nil {
@@ -308,7 +663,11 @@ func SignAndBroadcast(chainID string, nodeClient client.NodeClient, keyClient ke
 		// NOTE: [ben] is this consistent with the Ethereum protocol?  It should seem
 		// reasonable to get this returned from the chain directly.  Alternatively,
 		// the benefit is that the we don't need to trust the chain node
-		if tx_, ok := tx.(*txs.CallTx); ok {
+		callTx := tx
+		if multisigTx, ok := tx.(*txs.MultisigTx); ok {
+			callTx = multisigTx.Tx
+		}
+		if tx_, ok := callTx.(*txs.CallTx); ok {
 			if len(tx_.Address) == 0 {
 				txResult.Address = txs.NewContractAddress(tx_.Input.Address, tx_.Input.Sequence)
 			}
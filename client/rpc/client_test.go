@@ -18,10 +18,16 @@ import (
 	"fmt"
 	"testing"
 
-	// "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/tendermint/go-crypto"
+
+	acm "github.com/hyperledger/burrow/account"
 	mockclient "github.com/hyperledger/burrow/client/mock"
+	"github.com/hyperledger/burrow/keys"
 	mockkeys "github.com/hyperledger/burrow/keys/mock"
+	"github.com/hyperledger/burrow/txs"
 )
 
 func Test(t *testing.T) {
@@ -152,3 +158,57 @@ func testPermissions(t *testing.T,
 	}
 	// TODO: test content of Transaction
 }
+
+func TestOffline(t *testing.T) {
+	privAccount := acm.GenPrivAccount()
+	keyClient := keys.NewLocalKeyClient(privAccount)
+	addressString := fmt.Sprintf("%X", privAccount.Address)
+	toAddressString := fmt.Sprintf("%X", acm.GenPrivAccount().Address)
+
+	// Without a node the nonce must be given
+	_, err := Send(nil, keyClient, "", addressString, toAddressString, "1000", "")
+	assert.Error(t, err)
+	tx, err := Send(nil, keyClient, "", addressString, toAddressString, "1000", "7")
+	require.NoError(t, err)
+
+	txResult, err := SignAndBroadcast("chain", nil, keyClient, tx, true, false, false)
+	require.NoError(t, err)
+	assert.Nil(t, txResult)
+	assert.Equal(t, 7, tx.Inputs[0].Sequence)
+	assert.True(t, privAccount.PubKey.VerifyBytes(acm.SignBytes("chain", tx),
+		tx.Inputs[0].Signature))
+
+	// The signed tx survives the trip through JSON to be broadcast later
+	decoded, err := txs.DecodeTxJSON(txs.TxJSON(tx))
+	require.NoError(t, err)
+	assert.Equal(t, privAccount.Address, inputAddress(decoded))
+	_, err = SignAndBroadcast("chain", nil, nil, decoded, false, true, false)
+	assert.Error(t, err)
+}
+
+func TestSignTx(t *testing.T) {
+	privAccount := acm.GenPrivAccount()
+	pubKey := privAccount.PubKey.(crypto.PubKeyEd25519)
+	pubKeyString := fmt.Sprintf("%X", pubKey[:])
+	toAddressString := fmt.Sprintf("%X", acm.GenPrivAccount().Address)
+
+	// Formed without any key, then signed on another machine
+	tx, err := Call(nil, nil, pubKeyString, "", toAddressString, "10", "1", "1000",
+		"10", "")
+	require.NoError(t, err)
+	unsigned, err := txs.DecodeTxJSON(txs.TxJSON(tx))
+	require.NoError(t, err)
+	signed, err := SignTx(keys.NewLocalKeyClient(privAccount), "chain", unsigned)
+	require.NoError(t, err)
+	callTx := signed.(*txs.CallTx)
+	assert.True(t, privAccount.PubKey.VerifyBytes(acm.SignBytes("chain", callTx),
+		callTx.Input.Signature))
+
+	// Only the key of the input can sign
+	_, err = SignTx(keys.NewLocalKeyClient(acm.GenPrivAccount()), "chain", tx)
+	assert.Error(t, err)
+	// A GovTx is never signed
+	_, err = SignTx(keys.NewLocalKeyClient(privAccount), "chain",
+		&txs.GovTx{Unjail: [][]byte{privAccount.Address}})
+	assert.Error(t, err)
+}
@@ -32,10 +32,34 @@ import (
 //------------------------------------------------------------------------------------
 // sign and broadcast convenience
 
+// Signs tx formed elsewhere, such as an unsigned tx moved to an air-gapped
+// machine, with the key keyClient holds for its input
+func SignTx(keyClient keys.KeyClient, chainID string, tx txs.Tx) (txs.Tx, error) {
+	_, tx, err := signTx(keyClient, chainID, tx)
+	return tx, err
+}
+
 // tx has either one input or we default to the first one (ie for send/bond)
 // TODO: better support for multisig and bonding
 func signTx(keyClient keys.KeyClient, chainID string, tx_ txs.Tx) ([]byte, txs.Tx, error) {
 	signBytesString := fmt.Sprintf("%X", acc.SignBytes(chainID, tx_))
+	if rotateTx, ok := tx_.(*txs.RotateTx); ok {
+		// signed by both the validator key and the new key
+		for _, sig := range []struct {
+			address   []byte
+			signature *crypto.SignatureEd25519
+		}{
+			{rotateTx.PubKey.Address(), &rotateTx.Signature},
+			{rotateTx.NewPubKey.Address(), &rotateTx.NewSignature},
+		} {
+			sigBytes, err := keyClient.Sign(signBytesString, sig.address)
+			if err != nil {
+				return nil, nil, err
+			}
+			copy(sig.signature[:], sigBytes)
+		}
+		return rotateTx.PubKey.Address(), rotateTx, nil
+	}
 	var inputAddr []byte
 	var sigED crypto.SignatureEd25519
 	switch tx := tx_.(type) {
@@ -48,9 +72,21 @@ func signTx(keyClient keys.KeyClient, chainID string, tx_ txs.Tx) ([]byte, txs.T
 	case *txs.CallTx:
 		inputAddr = tx.Input.Address
 		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
+	case *txs.ABITx:
+		inputAddr = tx.Input.Address
+		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
+	case *txs.RelayTx:
+		inputAddr = tx.Input.Address
+		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
+	case *txs.PrivateTx:
+		inputAddr = tx.Input.Address
+		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
 	case *txs.PermissionsTx:
 		inputAddr = tx.Input.Address
 		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
+	case *txs.ProposalTx:
+		inputAddr = tx.Input.Address
+		defer func(s *crypto.SignatureEd25519) { tx.Input.Signature = *s }(&sigED)
 	case *txs.BondTx:
 		inputAddr = tx.Inputs[0].Address
 		defer func(s *crypto.SignatureEd25519) {
@@ -63,6 +99,14 @@ func signTx(keyClient keys.KeyClient, chainID string, tx_ txs.Tx) ([]byte, txs.T
 	case *txs.RebondTx:
 		inputAddr = tx.Address
 		defer func(s *crypto.SignatureEd25519) { tx.Signature = *s }(&sigED)
+	case *txs.IdentifyTx:
+		inputAddr = tx.PubKey.Address()
+		defer func(s *crypto.SignatureEd25519) { tx.Signature = *s }(&sigED)
+	case *txs.AttestTx:
+		inputAddr = tx.PubKey.Address()
+		defer func(s *crypto.SignatureEd25519) { tx.Signature = *s }(&sigED)
+	default:
+		return nil, nil, fmt.Errorf("%T cannot be signed with a single key", tx_)
 	}
 	sig, err := keyClient.Sign(signBytesString, inputAddr)
 	if err != nil {
@@ -76,6 +120,48 @@ func signTx(keyClient keys.KeyClient, chainID string, tx_ txs.Tx) ([]byte, txs.T
 	return inputAddr, tx_, nil
 }
 
+// The address of the account that signs tx, or of the validator that signs it
+// for validator txs
+func inputAddress(tx_ txs.Tx) []byte {
+	switch tx := tx_.(type) {
+	case *txs.SendTx:
+		if len(tx.Inputs) > 0 {
+			return tx.Inputs[0].Address
+		}
+	case *txs.NameTx:
+		return tx.Input.Address
+	case *txs.CallTx:
+		return tx.Input.Address
+	case *txs.ABITx:
+		return tx.Input.Address
+	case *txs.RelayTx:
+		return tx.Input.Address
+	case *txs.PrivateTx:
+		return tx.Input.Address
+	case *txs.PermissionsTx:
+		return tx.Input.Address
+	case *txs.ProposalTx:
+		return tx.Input.Address
+	case *txs.MultisigTx:
+		return tx.Address()
+	case *txs.BondTx:
+		if len(tx.Inputs) > 0 {
+			return tx.Inputs[0].Address
+		}
+	case *txs.UnbondTx:
+		return tx.Address
+	case *txs.RebondTx:
+		return tx.Address
+	case *txs.RotateTx:
+		return tx.PubKey.Address()
+	case *txs.IdentifyTx:
+		return tx.PubKey.Address()
+	case *txs.AttestTx:
+		return tx.PubKey.Address()
+	}
+	return nil
+}
+
 func decodeAddressPermFlag(addrS, permFlagS string) (addr []byte, pFlag ptypes.PermFlag, err error) {
 	if addr, err = hex.DecodeString(addrS); err != nil {
 		return
@@ -92,12 +178,54 @@ func checkCommon(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey,
 		return
 	}
 
+	pub, err = pubKeyFromFlags(nodeClient, keyClient, pubkey, addr)
+	if err != nil {
+		return
+	}
+
+	amt, err = strconv.ParseInt(amtS, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("amt is misformatted: %v", err)
+	}
+
+	addrBytes := pub.Address()
+
+	if nonceS == "" {
+		if nodeClient == nil {
+			err = fmt.Errorf("input must specify a nonce with the --nonce flag or use --node-addr (or BURROW_CLIENT_NODE_ADDR) to fetch the nonce from a node")
+			return
+		}
+		// fetch nonce from node
+		account, err2 := nodeClient.GetAccount(addrBytes)
+		if err2 != nil {
+			return pub, amt, nonce, err2
+		}
+		nonce = int64(account.Sequence) + 1
+		logging.TraceMsg(nodeClient.Logger(), "Fetch nonce from node",
+			"nonce", nonce,
+			"account address", addrBytes,
+		)
+	} else {
+		nonce, err = strconv.ParseInt(nonceS, 10, 64)
+		if err != nil {
+			err = fmt.Errorf("nonce is misformatted: %v", err)
+			return
+		}
+	}
+
+	return
+}
+
+// Gets the public key given by the --pubkey flag, or failing that the one
+// monax-keys holds for the --addr flag
+func pubKeyFromFlags(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey,
+	addr string) (pub crypto.PubKeyEd25519, err error) {
 	var pubKeyBytes []byte
 	if pubkey == "" && addr == "" {
 		err = fmt.Errorf("at least one of --pubkey or --addr must be given")
 		return
 	} else if pubkey != "" {
-		if addr != "" {
+		if addr != "" && nodeClient != nil {
 			logging.InfoMsg(nodeClient.Logger(), "Both a public key and an address have been specified. The public key takes precedent.",
 				"public_key", pubkey,
 				"address", addr,
@@ -127,38 +255,6 @@ func checkCommon(nodeClient client.NodeClient, keyClient keys.KeyClient, pubkey,
 		return
 	}
 
-	amt, err = strconv.ParseInt(amtS, 10, 64)
-	if err != nil {
-		err = fmt.Errorf("amt is misformatted: %v", err)
-	}
-
-	var pubArray [32]byte
-	copy(pubArray[:], pubKeyBytes)
-	pub = crypto.PubKeyEd25519(pubArray)
-	addrBytes := pub.Address()
-
-	if nonceS == "" {
-		if nodeClient == nil {
-			err = fmt.Errorf("input must specify a nonce with the --nonce flag or use --node-addr (or BURROW_CLIENT_NODE_ADDR) to fetch the nonce from a node")
-			return
-		}
-		// fetch nonce from node
-		account, err2 := nodeClient.GetAccount(addrBytes)
-		if err2 != nil {
-			return pub, amt, nonce, err2
-		}
-		nonce = int64(account.Sequence) + 1
-		logging.TraceMsg(nodeClient.Logger(), "Fetch nonce from node",
-			"nonce", nonce,
-			"account address", addrBytes,
-		)
-	} else {
-		nonce, err = strconv.ParseInt(nonceS, 10, 64)
-		if err != nil {
-			err = fmt.Errorf("nonce is misformatted: %v", err)
-			return
-		}
-	}
-
+	copy(pub[:], pubKeyBytes)
 	return
 }
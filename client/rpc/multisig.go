@@ -0,0 +1,196 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tendermint/go-crypto"
+
+	acc "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/txs"
+)
+
+//------------------------------------------------------------------------------------
+// multisig txs, formed unsigned and signed by each signatory in turn
+
+// Parses the threshold and comma separated public keys of the signatories of
+// a multisig account
+func MultisigSignatories(thresholdS, pubkeysS string) (int, []crypto.PubKey, error) {
+	threshold, err := strconv.Atoi(thresholdS)
+	if err != nil {
+		return 0, nil, fmt.Errorf("threshold is misformatted: %v", err)
+	}
+	if pubkeysS == "" {
+		return 0, nil, fmt.Errorf("the signatories must be given with the --signatories flag")
+	}
+	var pubKeys []crypto.PubKey
+	for _, pubkey := range strings.Split(pubkeysS, ",") {
+		pubKeyBytes, err := hex.DecodeString(strings.TrimSpace(pubkey))
+		if err != nil || len(pubKeyBytes) != 32 {
+			return 0, nil, fmt.Errorf("signatory %s is not a hex ed25519 public key", pubkey)
+		}
+		var pubArray [32]byte
+		copy(pubArray[:], pubKeyBytes)
+		pubKeys = append(pubKeys, crypto.PubKeyEd25519(pubArray))
+	}
+	return threshold, pubKeys, nil
+}
+
+func MultisigSend(nodeClient client.NodeClient, thresholdS, pubkeysS, toAddr, amtS, nonceS string) (*txs.MultisigTx, error) {
+	threshold, pubKeys, amt, nonce, err := checkMultisig(nodeClient, thresholdS, pubkeysS, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+
+	if toAddr == "" {
+		return nil, fmt.Errorf("destination address must be given with --to flag")
+	}
+
+	toAddrBytes, err := hex.DecodeString(toAddr)
+	if err != nil {
+		return nil, fmt.Errorf("toAddr is bad hex: %v", err)
+	}
+
+	tx := txs.NewSendTx()
+	tx.Inputs = append(tx.Inputs, &txs.TxInput{
+		Address:  txs.MultisigAddress(threshold, pubKeys),
+		Amount:   amt,
+		Sequence: int(nonce),
+	})
+	tx.AddOutput(toAddrBytes, amt)
+
+	return txs.NewMultisigTx(threshold, pubKeys, tx)
+}
+
+func MultisigCall(nodeClient client.NodeClient, thresholdS, pubkeysS, toAddr, amtS, nonceS, gasS, feeS, data string) (*txs.MultisigTx, error) {
+	threshold, pubKeys, amt, nonce, err := checkMultisig(nodeClient, thresholdS, pubkeysS, amtS, nonceS)
+	if err != nil {
+		return nil, err
+	}
+
+	toAddrBytes, err := hex.DecodeString(toAddr)
+	if err != nil {
+		return nil, fmt.Errorf("toAddr is bad hex: %v", err)
+	}
+
+	fee, err := strconv.ParseInt(feeS, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("fee is misformatted: %v", err)
+	}
+
+	gas, err := strconv.ParseInt(gasS, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("gas is misformatted: %v", err)
+	}
+
+	dataBytes, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("data is bad hex: %v", err)
+	}
+
+	tx := &txs.CallTx{
+		Input: &txs.TxInput{
+			Address:  txs.MultisigAddress(threshold, pubKeys),
+			Amount:   amt,
+			Sequence: int(nonce),
+		},
+		Address:  toAddrBytes,
+		GasLimit: gas,
+		Fee:      fee,
+		Data:     dataBytes,
+	}
+	return txs.NewMultisigTx(threshold, pubKeys, tx)
+}
+
+// Adds the signature of the signatory whose key is held by monax-keys at
+// addr, or whose public key is pubkey
+func SignMultisig(keyClient keys.KeyClient, chainID string, tx *txs.MultisigTx, pubkey, addr string) error {
+	var pubKeyBytes []byte
+	var err error
+	if pubkey != "" {
+		if pubKeyBytes, err = hex.DecodeString(pubkey); err != nil {
+			return fmt.Errorf("pubkey is bad hex: %v", err)
+		}
+	} else {
+		addressBytes, err := hex.DecodeString(addr)
+		if err != nil || addr == "" {
+			return fmt.Errorf("at least one of --pubkey or --addr must be given")
+		}
+		if pubKeyBytes, err = keyClient.PublicKey(addressBytes); err != nil {
+			return fmt.Errorf("Failed to fetch pubkey for address (%s): %v", addr, err)
+		}
+	}
+	var pubArray [32]byte
+	copy(pubArray[:], pubKeyBytes)
+	pub := crypto.PubKeyEd25519(pubArray)
+
+	sig, err := keyClient.Sign(fmt.Sprintf("%X", acc.SignBytes(chainID, tx)), pub.Address())
+	if err != nil {
+		return err
+	}
+	var sig64 [64]byte
+	copy(sig64[:], sig)
+	return tx.AddSignature(chainID, pub, crypto.SignatureEd25519(sig64))
+}
+
+func checkMultisig(nodeClient client.NodeClient, thresholdS, pubkeysS, amtS, nonceS string) (threshold int,
+	pubKeys []crypto.PubKey, amt int64, nonce int64, err error) {
+	if amtS == "" {
+		err = fmt.Errorf("input must specify an amount with the --amt flag")
+		return
+	}
+	threshold, pubKeys, err = MultisigSignatories(thresholdS, pubkeysS)
+	if err != nil {
+		return
+	}
+	amt, err = strconv.ParseInt(amtS, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("amt is misformatted: %v", err)
+		return
+	}
+
+	addrBytes := txs.MultisigAddress(threshold, pubKeys)
+	if nonceS == "" {
+		if nodeClient == nil {
+			err = fmt.Errorf("input must specify a nonce with the --nonce flag or use --node-addr (or BURROW_CLIENT_NODE_ADDR) to fetch the nonce from a node")
+			return
+		}
+		// fetch nonce from node
+		account, err2 := nodeClient.GetAccount(addrBytes)
+		if err2 != nil {
+			err = err2
+			return
+		}
+		nonce = int64(account.Sequence) + 1
+		logging.TraceMsg(nodeClient.Logger(), "Fetch nonce from node",
+			"nonce", nonce,
+			"account address", addrBytes,
+		)
+	} else {
+		nonce, err = strconv.ParseInt(nonceS, 10, 64)
+		if err != nil {
+			err = fmt.Errorf("nonce is misformatted: %v", err)
+			return
+		}
+	}
+	return
+}
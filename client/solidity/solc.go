@@ -0,0 +1,180 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package solidity compiles Solidity source with solc to verify that the code
+// deployed at an address was built from it.
+package solidity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The solc binary and the settings a contract was compiled with, which must
+// be those it was deployed with for its code to match
+type Settings struct {
+	// The solc binary to run, solc from the path if empty
+	Solc string
+	// The version solc must report, such as 0.4.24, or any if empty
+	Version      string
+	Optimize     bool
+	OptimizeRuns int
+	// The addresses of the libraries to link, each as <name>:<hex address>
+	Libraries []string
+}
+
+type Contract struct {
+	// The name solc gives the contract, <source file>:<contract>
+	Name            string
+	ABI             json.RawMessage
+	RuntimeBytecode []byte
+	// Whether the code has placeholders for libraries that were not linked
+	Unlinked bool
+}
+
+var versionRegexp = regexp.MustCompile(`Version: (\S+)`)
+
+// Gets the full version of solc, such as 0.4.24+commit.e67f0147.Linux.g++
+func (settings Settings) SolcVersion() (string, error) {
+	output, err := exec.Command(settings.solc(), "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("Could not run %s: %v", settings.solc(), err)
+	}
+	match := versionRegexp.FindSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("Could not find the version of %s in: %s",
+			settings.solc(), output)
+	}
+	return string(match[1]), nil
+}
+
+// Compiles the contracts of sourceFile and returns them by name along with the
+// full version of solc
+func Compile(settings Settings, sourceFile string) (map[string]*Contract, string, error) {
+	version, err := settings.SolcVersion()
+	if err != nil {
+		return nil, "", err
+	}
+	if !VersionMatches(settings.Version, version) {
+		return nil, "", fmt.Errorf("%s is version %s rather than %s",
+			settings.solc(), version, settings.Version)
+	}
+	args := []string{"--combined-json", "abi,bin-runtime"}
+	if settings.Optimize {
+		args = append(args, "--optimize", "--optimize-runs",
+			strconv.Itoa(settings.OptimizeRuns))
+	}
+	if len(settings.Libraries) > 0 {
+		args = append(args, "--libraries", strings.Join(settings.Libraries, ","))
+	}
+	cmd := exec.Command(settings.solc(), append(args, sourceFile)...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not compile %s: %v: %s", sourceFile,
+			err, stderr)
+	}
+	contracts, err := parseCombinedJSON(output)
+	if err != nil {
+		return nil, "", err
+	}
+	return contracts, version, nil
+}
+
+// Whether the full version of solc is the version wanted, which may leave off
+// the commit and platform
+func VersionMatches(wanted, version string) bool {
+	return wanted == "" || version == wanted ||
+		strings.HasPrefix(version, wanted+"+") ||
+		strings.HasPrefix(version, wanted+"-")
+}
+
+// Finds the contract called name, which may leave off the source file, or the
+// only contract with code when name is empty
+func FindContract(contracts map[string]*Contract, name string) (*Contract, error) {
+	var found []*Contract
+	for fullName, contract := range contracts {
+		hasCode := len(contract.RuntimeBytecode) > 0 || contract.Unlinked
+		if name == "" && hasCode ||
+			name != "" && (fullName == name || strings.HasSuffix(fullName, ":"+name)) {
+			found = append(found, contract)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("Source has no contract %s", name)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, contract := range found {
+		names[i] = contract.Name
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("Source has more than one contract to choose from, "+
+		"give one of %s", strings.Join(names, ", "))
+}
+
+func (settings Settings) solc() string {
+	if settings.Solc == "" {
+		return "solc"
+	}
+	return settings.Solc
+}
+
+// The output of solc --combined-json, whose ABIs are JSON encoded as strings
+// before solc 0.8
+type combinedJSON struct {
+	Contracts map[string]struct {
+		ABI        json.RawMessage `json:"abi"`
+		BinRuntime string          `json:"bin-runtime"`
+	} `json:"contracts"`
+}
+
+func parseCombinedJSON(output []byte) (map[string]*Contract, error) {
+	combined := new(combinedJSON)
+	if err := json.Unmarshal(output, combined); err != nil {
+		return nil, fmt.Errorf("Could not decode output of solc: %v", err)
+	}
+	contracts := make(map[string]*Contract, len(combined.Contracts))
+	for name, compiled := range combined.Contracts {
+		contract := &Contract{
+			Name: name,
+			ABI:  compiled.ABI,
+		}
+		var abiString string
+		if err := json.Unmarshal(compiled.ABI, &abiString); err == nil {
+			contract.ABI = json.RawMessage(abiString)
+		}
+		contracts[name] = contract
+		if strings.Contains(compiled.BinRuntime, "__") {
+			contract.Unlinked = true
+			continue
+		}
+		var err error
+		contract.RuntimeBytecode, err = hex.DecodeString(compiled.BinRuntime)
+		if err != nil {
+			return nil, fmt.Errorf("Runtime bytecode of %s is bad hex: %v", name, err)
+		}
+	}
+	return contracts, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solidity
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	abiJSON = `[{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`
+	code    = "6060604052600080fd00"
+	// bzzr0 metadata as appended by solc 0.4
+	metadata      = "a165627a7a72305820" + "1111111111111111111111111111111111111111111111111111111111111111" + "0029"
+	otherMetadata = "a165627a7a72305820" + "2222222222222222222222222222222222222222222222222222222222222222" + "0029"
+)
+
+func combined(abi string) string {
+	return `{"contracts":{"store.sol:Store":{"abi":` + abi + `,"bin-runtime":"` +
+		code + metadata + `"},"store.sol:Lib":{"abi":"[]","bin-runtime":"73__store.sol:Lib______________________________"},` +
+		`"store.sol:Iface":{"abi":"[]","bin-runtime":""}},"version":"0.4.24+commit.e67f0147.Linux.g++"}`
+}
+
+func TestParseCombinedJSON(t *testing.T) {
+	// ABIs are strings before solc 0.8, and JSON after
+	for _, abi := range []string{`"` + `[{\"constant\":true,\"inputs\":[],\"name\":\"get\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"type\":\"function\"}]` + `"`,
+		abiJSON} {
+		contracts, err := parseCombinedJSON([]byte(combined(abi)))
+		require.NoError(t, err)
+		assert.Len(t, contracts, 3)
+		store := contracts["store.sol:Store"]
+		assert.Equal(t, abiJSON, string(store.ABI))
+		assert.Equal(t, code+metadata, hex.EncodeToString(store.RuntimeBytecode))
+		assert.True(t, contracts["store.sol:Lib"].Unlinked)
+
+		_, err = FindContract(contracts, "")
+		assert.Error(t, err, "both Store and Lib have code")
+		contract, err := FindContract(contracts, "Store")
+		require.NoError(t, err)
+		assert.Equal(t, store, contract)
+		_, err = FindContract(contracts, "Missing")
+		assert.Error(t, err)
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	withMetadata, err := hex.DecodeString(code + metadata)
+	require.NoError(t, err)
+	stripped, md := StripMetadata(withMetadata)
+	assert.Equal(t, code, hex.EncodeToString(stripped))
+	assert.Equal(t, metadata, hex.EncodeToString(md))
+
+	// Code without metadata is left alone
+	plain, err := hex.DecodeString(code)
+	require.NoError(t, err)
+	stripped, md = StripMetadata(plain)
+	assert.Equal(t, plain, stripped)
+	assert.Nil(t, md)
+}
+
+func TestVerifyCode(t *testing.T) {
+	compiled, err := hex.DecodeString(code + metadata)
+	require.NoError(t, err)
+	contract := &Contract{Name: "store.sol:Store", RuntimeBytecode: compiled}
+
+	exactMatch, err := VerifyCode(compiled, contract)
+	require.NoError(t, err)
+	assert.True(t, exactMatch)
+
+	// Compiled from a source that differs only in its comments
+	deployed, err := hex.DecodeString(code + otherMetadata)
+	require.NoError(t, err)
+	exactMatch, err = VerifyCode(deployed, contract)
+	require.NoError(t, err)
+	assert.False(t, exactMatch)
+
+	deployed, err = hex.DecodeString("6060604052600180fd00" + metadata)
+	require.NoError(t, err)
+	_, err = VerifyCode(deployed, contract)
+	assert.Error(t, err)
+
+	_, err = VerifyCode(nil, contract)
+	assert.Error(t, err)
+	_, err = VerifyCode(compiled, &Contract{Name: "Lib", Unlinked: true})
+	assert.Error(t, err)
+}
+
+func TestCompile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solidity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// Stands in for solc
+	solc := path.Join(dir, "solc")
+	require.NoError(t, ioutil.WriteFile(solc, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "solc, the solidity compiler commandline interface"
+	echo "Version: 0.4.24+commit.e67f0147.Linux.g++"
+	exit 0
+fi
+cat <<'JSON'
+`+combined(`"[]"`)+`
+JSON
+`), 0700))
+
+	settings := Settings{Solc: solc, Version: "0.4.24"}
+	contracts, version, err := Compile(settings, "store.sol")
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.24+commit.e67f0147.Linux.g++", version)
+	contract, err := FindContract(contracts, "store.sol:Store")
+	require.NoError(t, err)
+
+	record, err := Verify([]byte{1, 2, 3}, contract.RuntimeBytecode, []byte("source"),
+		contract, settings, version)
+	require.NoError(t, err)
+	assert.Equal(t, "010203", record.Address)
+	assert.True(t, record.ExactMatch)
+	assert.Equal(t, "store.sol:Store", record.Contract)
+
+	settings.Version = "0.4.25"
+	_, _, err = Compile(settings, "store.sol")
+	assert.Error(t, err)
+}
+
+func TestVersionMatches(t *testing.T) {
+	assert.True(t, VersionMatches("", "0.4.24+commit.e67f0147"))
+	assert.True(t, VersionMatches("0.4.24", "0.4.24+commit.e67f0147"))
+	assert.True(t, VersionMatches("0.4.24+commit.e67f0147", "0.4.24+commit.e67f0147"))
+	assert.False(t, VersionMatches("0.4.2", "0.4.24+commit.e67f0147"))
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solidity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+)
+
+// A record that the code deployed at an address was compiled from a source,
+// for explorers to show the source of verified contracts
+type VerificationRecord struct {
+	Address string `json:"address"`
+	// The sha3 hash of the code, by which its ABI is registered on chain
+	CodeHash        string   `json:"code_hash"`
+	Contract        string   `json:"contract"`
+	SourceHash      string   `json:"source_hash"`
+	CompilerVersion string   `json:"compiler_version"`
+	Optimize        bool     `json:"optimize"`
+	OptimizeRuns    int      `json:"optimize_runs,omitempty"`
+	Libraries       []string `json:"libraries,omitempty"`
+	// Whether the metadata hash solc appends to the code matches too, which
+	// means the source is byte for byte the one compiled
+	ExactMatch bool            `json:"exact_match"`
+	ABI        json.RawMessage `json:"abi"`
+}
+
+// Strips the CBOR encoded metadata solc appends to the runtime bytecode, whose
+// length is given by the last two bytes, and returns the code before it and
+// the metadata. The metadata holds the hash of the source and settings, so it
+// differs for a source that only differs in its comments.
+func StripMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - length
+	// The metadata is a CBOR map
+	if length == 0 || start < 0 || code[start]&0xe0 != 0xa0 {
+		return code, nil
+	}
+	return code[:start], code[start:]
+}
+
+// Checks that the deployed code is the runtime bytecode of contract, ignoring
+// its metadata, and returns whether the metadata matches too
+func VerifyCode(deployed []byte, contract *Contract) (exactMatch bool, err error) {
+	if contract.Unlinked {
+		return false, fmt.Errorf("Contract %s uses libraries, give their "+
+			"addresses to link with --libraries", contract.Name)
+	}
+	if len(deployed) == 0 {
+		return false, fmt.Errorf("No code is deployed at the address")
+	}
+	if bytes.Equal(deployed, contract.RuntimeBytecode) {
+		return true, nil
+	}
+	deployedCode, _ := StripMetadata(deployed)
+	compiledCode, _ := StripMetadata(contract.RuntimeBytecode)
+	if !bytes.Equal(deployedCode, compiledCode) {
+		return false, fmt.Errorf("Code deployed is not the code of %s as compiled",
+			contract.Name)
+	}
+	return false, nil
+}
+
+// Verifies the code deployed at address against contract, compiled from source
+// with settings by solc of version
+func Verify(address, deployed, source []byte, contract *Contract, settings Settings,
+	version string) (*VerificationRecord, error) {
+	exactMatch, err := VerifyCode(deployed, contract)
+	if err != nil {
+		return nil, err
+	}
+	record := &VerificationRecord{
+		Address:         fmt.Sprintf("%X", address),
+		CodeHash:        fmt.Sprintf("%X", sha3.Sha3(deployed)),
+		Contract:        contract.Name,
+		SourceHash:      fmt.Sprintf("%X", sha3.Sha3(source)),
+		CompilerVersion: version,
+		Optimize:        settings.Optimize,
+		Libraries:       settings.Libraries,
+		ExactMatch:      exactMatch,
+		ABI:             contract.ABI,
+	}
+	if settings.Optimize {
+		record.OptimizeRuns = settings.OptimizeRuns
+	}
+	return record, nil
+}
@@ -199,6 +199,41 @@ func (burrowNodeWebsocketClient *burrowNodeWebsocketClient) WaitForConfirmation(
 	return confirmationChannel, nil
 }
 
+func (burrowNodeWebsocketClient *burrowNodeWebsocketClient) SubscribeLogs(address []byte) (chan txs.EventDataLog, error) {
+	if err := burrowNodeWebsocketClient.assertNoErrors(); err != nil {
+		return nil, err
+	}
+	eid := txs.EventStringLogEvent(address)
+	if err := burrowNodeWebsocketClient.Subscribe(eid); err != nil {
+		return nil, fmt.Errorf("Error subscribing to Log event (%s): %v", eid, err)
+	}
+	logChannel := make(chan txs.EventDataLog, 100)
+	go func() {
+		defer close(logChannel)
+		for resultBytes := range burrowNodeWebsocketClient.tendermintWebsocket.ResultsCh {
+			var err error
+			result := new(ctypes.BurrowResult)
+			if wire.ReadJSONPtr(result, resultBytes, &err); err != nil {
+				logging.InfoMsg(burrowNodeWebsocketClient.logger, "Failed to unmarshal json bytes for websocket event",
+					"error", err)
+				continue
+			}
+			event, ok := (*result).(*ctypes.ResultEvent)
+			if !ok || event.Event != eid {
+				continue
+			}
+			switch data := event.Data.(type) {
+			case txs.EventDataLog:
+				logChannel <- data
+			case txs.EventDataDecodedLog:
+				// Logs the node could decode carry their event as well
+				logChannel <- data.Log()
+			}
+		}
+	}()
+	return logChannel, nil
+}
+
 func (burrowNodeWebsocketClient *burrowNodeWebsocketClient) Close() {
 	if burrowNodeWebsocketClient.tendermintWebsocket != nil {
 		burrowNodeWebsocketClient.tendermintWebsocket.Stop()
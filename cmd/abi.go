@@ -0,0 +1,161 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/burrow/client/contract/bind"
+	"github.com/hyperledger/burrow/client/solidity"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/util"
+	"github.com/spf13/cobra"
+)
+
+func buildABICommand(do *definitions.Do) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "abi",
+		Short: "burrow abi works with the ABIs of contracts.",
+		Run:   func(cmd *cobra.Command, args []string) { cmd.Help() },
+	}
+	cmd.AddCommand(buildABIBindCommand(do))
+	return cmd
+}
+
+func buildABIBindCommand(do *definitions.Do) *cobra.Command {
+	var abiFiles []string
+	var sourceFile, solc, pkg, lang, output string
+	cmd := &cobra.Command{
+		Use:   "bind",
+		Short: "burrow abi bind generates Go or TypeScript bindings for contracts from their ABIs.",
+		Long: `burrow abi bind generates a Go package with a type for each contract that has
+a typed method for each function of its ABI and a watcher for each event. The
+methods of constant functions run simulated calls, and the rest send CallTxs
+and wait for them to be committed. Structs are bound to Go structs. The
+bindings use the node and key clients of burrow-client, set in a
+contract.Session.
+
+With --lang ts it instead generates a JavaScript module with a class for each
+contract, and a TypeScript declaration file beside it typing the classes for
+front-ends. They call the node over its Ethereum JSON-RPC endpoint and poll it
+for events, and send transactions signed by a signer given in the session.
+
+The ABIs are read from files given as <name>=<file>, or just <file> to name the
+contract after the file, or compiled from a Solidity source with solc.`,
+		Example: `$ burrow abi bind --abi Token=token.abi --pkg token --out token/token.go
+$ burrow abi bind --sol token.sol --pkg token --out token/token.go
+$ burrow abi bind --sol token.sol --lang ts --out src/token.js`,
+		Run: func(cmd *cobra.Command, args []string) {
+			switch lang {
+			case "go":
+				if pkg == "" {
+					util.Fatalf("The package of the bindings must be given with --pkg")
+				}
+			case "ts":
+				if output == "" {
+					util.Fatalf("The JavaScript file to write must be given with --out")
+				}
+			default:
+				util.Fatalf("Bindings cannot be generated for language %s", lang)
+			}
+			contracts, err := readContractABIs(abiFiles, sourceFile, solc)
+			if err != nil {
+				util.Fatalf("Could not read ABIs: %s", err)
+			}
+			if len(contracts) == 0 {
+				util.Fatalf("Please give ABIs with --abi or a Solidity source with --sol")
+			}
+			if lang == "ts" {
+				js, dts, err := bind.BindTypeScript(contracts)
+				if err != nil {
+					util.Fatalf("Could not generate bindings: %s", err)
+				}
+				declarations := strings.TrimSuffix(output, filepath.Ext(output)) + ".d.ts"
+				if err := ioutil.WriteFile(output, js, 0644); err != nil {
+					util.Fatalf("Could not write bindings: %s", err)
+				}
+				if err := ioutil.WriteFile(declarations, dts, 0644); err != nil {
+					util.Fatalf("Could not write bindings: %s", err)
+				}
+				return
+			}
+			source, err := bind.Bind(pkg, contracts)
+			if err != nil {
+				util.Fatalf("Could not generate bindings: %s", err)
+			}
+			if output == "" {
+				os.Stdout.Write(source)
+				return
+			}
+			if err := ioutil.WriteFile(output, source, 0644); err != nil {
+				util.Fatalf("Could not write bindings: %s", err)
+			}
+		},
+	}
+	cmd.Flags().StringSliceVarP(&abiFiles, "abi", "", nil,
+		"JSON ABI files of the contracts, as <name>=<file> or <file>")
+	cmd.Flags().StringVarP(&sourceFile, "sol", "", "",
+		"Solidity source to compile with solc and bind all the contracts of")
+	cmd.Flags().StringVarP(&solc, "solc", "", "solc", "the solc binary to compile with")
+	cmd.Flags().StringVarP(&pkg, "pkg", "", "", "the Go package of the bindings")
+	cmd.Flags().StringVarP(&lang, "lang", "", "go", "the language of the bindings, go or ts")
+	cmd.Flags().StringVarP(&output, "out", "o", "",
+		"the file to write the bindings to, else stdout")
+	return cmd
+}
+
+func readContractABIs(abiFiles []string, sourceFile, solc string) ([]bind.ContractABI, error) {
+	var contracts []bind.ContractABI
+	for _, abiFile := range abiFiles {
+		name := strings.TrimSuffix(filepath.Base(abiFile), filepath.Ext(abiFile))
+		if i := strings.Index(abiFile, "="); i >= 0 {
+			name, abiFile = abiFile[:i], abiFile[i+1:]
+		} else if name != "" {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		abiJSON, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, bind.ContractABI{Name: name, ABI: string(abiJSON)})
+	}
+	if sourceFile != "" {
+		compiled, _, err := solidity.Compile(solidity.Settings{Solc: solc}, sourceFile)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for name := range compiled {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			contractName := name[strings.LastIndex(name, ":")+1:]
+			if contractName == "" {
+				return nil, fmt.Errorf("solc gave a contract no name")
+			}
+			contracts = append(contracts, bind.ContractABI{
+				Name: contractName,
+				ABI:  string(compiled[name].ABI),
+			})
+		}
+	}
+	return contracts, nil
+}
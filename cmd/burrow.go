@@ -55,6 +55,14 @@ func AddGlobalFlags(do *definitions.Do) {
 
 func AddCommands(do *definitions.Do) {
 	BurrowCmd.AddCommand(buildServeCommand(do))
+	BurrowCmd.AddCommand(buildDumpCommand(do))
+	BurrowCmd.AddCommand(buildRestoreCommand(do))
+	BurrowCmd.AddCommand(buildVentCommand(do))
+	BurrowCmd.AddCommand(buildKeysCommand(do))
+	BurrowCmd.AddCommand(buildABICommand(do))
+	BurrowCmd.AddCommand(buildTestnetCommand(do))
+	BurrowCmd.AddCommand(buildSpecCommand(do))
+	BurrowCmd.AddCommand(buildConfigureCommand(do))
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,118 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/files"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/spf13/cobra"
+)
+
+// build the configure subcommand
+func buildConfigureCommand(do *definitions.Do) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "configure",
+		Short: "burrow configure manages the configuration of a burrow node.",
+		Run:   func(cmd *cobra.Command, args []string) { cmd.Help() },
+	}
+	cmd.AddCommand(buildMigrateCommand(do))
+	return cmd
+}
+
+// build the configure migrate subcommand
+func buildMigrateCommand(do *definitions.Do) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "burrow configure migrate upgrades a configuration and genesis file to the current version.",
+		Long: fmt.Sprintf(`burrow configure migrate rewrites the configuration file %s in the
+working directory, and the genesis file it names, as this version of burrow
+writes them. The values still read are kept, those no longer read are dropped
+and reported as deprecated, and those missing are filled with their defaults
+and reported. The originals are kept alongside with a .bak extension.`,
+			DefaultConfigFilename),
+		Example: `$ burrow configure migrate --work-dir my_node --dry-run`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			setWorkDir(do)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			configFile := path.Join(do.WorkDir, DefaultConfigFilename)
+			configBytes, err := ioutil.ReadFile(configFile)
+			if err != nil {
+				util.Fatalf("Could not read configuration: %s", err)
+			}
+			migratedConfig, report, err := config.MigrateConfig(configBytes)
+			if err != nil {
+				util.Fatalf("Could not migrate %s: %s", configFile, err)
+			}
+			printMigrationReport(configFile, report)
+
+			conf, err := config.ReadViperConfig(migratedConfig)
+			if err != nil {
+				util.Fatalf("Could not read migrated configuration: %s", err)
+			}
+			genesisFile := path.Join(do.WorkDir, conf.GetString("chain.genesis_file"))
+			genesisBytes, err := ioutil.ReadFile(genesisFile)
+			if err != nil {
+				util.Fatalf("Could not read genesis file: %s", err)
+			}
+			migratedGenesis, report, err := config.MigrateGenesis(genesisBytes)
+			if err != nil {
+				util.Fatalf("Could not migrate %s: %s", genesisFile, err)
+			}
+			printMigrationReport(genesisFile, report)
+
+			if dryRun {
+				return
+			}
+			for file, migrated := range map[string][][]byte{
+				configFile:  {configBytes, migratedConfig},
+				genesisFile: {genesisBytes, migratedGenesis},
+			} {
+				if err := files.WriteFileRW(file+".bak", migrated[0]); err != nil {
+					util.Fatalf("Could not back up %s: %s", file, err)
+				}
+				if err := files.WriteFileRW(file, migrated[1]); err != nil {
+					util.Fatalf("Could not write %s: %s", file, err)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&do.WorkDir, "work-dir", "w", defaultWorkDir(),
+		"specify the working directory of the node.  If omitted, and no path set in $BURROW_WORKDIR, the current working directory is taken.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"report what would be migrated without writing the files")
+	return cmd
+}
+
+func printMigrationReport(file string, report *config.MigrationReport) {
+	fmt.Printf("%s:\n", file)
+	for _, key := range report.Deprecated {
+		fmt.Printf("  deprecated %s\n", key)
+	}
+	for _, key := range report.Filled {
+		fmt.Printf("  filled %s\n", key)
+	}
+	if len(report.Deprecated) == 0 && len(report.Filled) == 0 {
+		fmt.Printf("  up to date\n")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/files"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/keys/hd"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The mnemonic the keys of a development chain are derived from unless
+// --dev-mnemonic is given. Everyone knows it, so its keys must never hold
+// anything of value on a real chain.
+const DefaultDevMnemonic = "test test test test test test test test test test test junk"
+
+const (
+	devChainId       = "burrow_dev"
+	devMoniker       = "dev_marmot"
+	devBalance       = int64(1000000000000)
+	devBonded        = int64(1000000000)
+	devAccountPrefix = "dev_account_"
+)
+
+// setDevWorkDir prepares the working directory of a development chain, which
+// is a new temporary directory unless one is given. The configuration, genesis
+// file and validator key are written unless the directory already holds a
+// chain, and the keys of the accounts are printed.
+func setDevWorkDir(do *definitions.Do) {
+	if do.DevAccounts < 1 {
+		util.Fatalf("A development chain needs at least one account")
+	}
+	if do.WorkDir == "" {
+		workDir, err := ioutil.TempDir("", "burrow_dev_")
+		if err != nil {
+			util.Fatalf("Could not create a working directory: %s", err)
+		}
+		do.WorkDir = workDir
+	} else if err := util.EnsureDir(do.WorkDir, os.ModePerm); err != nil {
+		util.Fatalf("Could not create working directory %s: %s", do.WorkDir, err)
+	}
+	if do.ChainId == "" {
+		do.ChainId = devChainId
+	}
+
+	privAccounts, err := devPrivAccounts(do.DevMnemonic, do.DevAccounts)
+	if err != nil {
+		util.Fatalf("Could not derive the development keys: %s", err)
+	}
+	if _, err := os.Stat(path.Join(do.WorkDir, DefaultConfigFilename)); err == nil {
+		fmt.Printf("Resuming the development chain in %s\n", do.WorkDir)
+	} else if err := writeDevChain(do.WorkDir, do.ChainId, privAccounts); err != nil {
+		util.Fatalf("Could not write the development chain to %s: %s",
+			do.WorkDir, err)
+	} else {
+		fmt.Printf("Created the development chain %s in %s\n", do.ChainId,
+			do.WorkDir)
+	}
+	printDevAccounts(do.DevMnemonic, privAccounts)
+}
+
+// Derives the ed25519 keys of the development accounts from the mnemonic as
+// burrow keys derive does, so they can be recreated with it
+func devPrivAccounts(mnemonic string, count int) ([]*acm.PrivAccount, error) {
+	p, err := hd.ParsePath(defaultDerivationPath("ed25519"))
+	if err != nil {
+		return nil, err
+	}
+	seed := hd.Seed(mnemonic, "")
+	privAccounts := make([]*acm.PrivAccount, count)
+	for i := range privAccounts {
+		privAccounts[i], err = derivePrivAccount(seed, p.Sibling(uint32(i)), "ed25519")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return privAccounts, nil
+}
+
+// The first account is also the only validator, so the chain needs no other
+// key. Every account has all the permissions.
+func writeDevChain(workDir, chainId string, privAccounts []*acm.PrivAccount) error {
+	permissions := ptypes.AccountPermissions{
+		Base: ptypes.BasePermissions{
+			Perms:  ptypes.AllPermFlags,
+			SetBit: ptypes.AllPermFlags,
+		},
+		Roles: []string{},
+	}
+	accounts := make([]*genesis.GenesisAccount, len(privAccounts))
+	for i, privAccount := range privAccounts {
+		accounts[i] = genesis.NewGenesisAccount(privAccount.Address, devBalance,
+			fmt.Sprintf("%s%d", devAccountPrefix, i), &permissions)
+	}
+	validatorKey := privAccounts[0].PubKey.(crypto.PubKeyEd25519)
+	validator, err := genesis.NewGenesisValidator(devBonded, devMoniker,
+		privAccounts[0].Address, devBonded, "ed25519", validatorKey[:])
+	if err != nil {
+		return err
+	}
+	genesisDoc, err := genesis.MakeGenesisDocFromAccounts(chainId, accounts,
+		[]*genesis.GenesisValidator{validator})
+	if err != nil {
+		return err
+	}
+	genesisBytes, err := genesis.GetGenesisFileBytes(&genesisDoc)
+	if err != nil {
+		return err
+	}
+	configBytes, err := config.GetDevConfigurationFileBytes(chainId, devMoniker)
+	if err != nil {
+		return err
+	}
+	privValidator := &tm_types.PrivValidator{
+		Address: privAccounts[0].Address,
+		PubKey:  validatorKey,
+		PrivKey: privAccounts[0].PrivKey.(crypto.PrivKeyEd25519),
+	}
+	if err := files.WriteFileRW(path.Join(workDir, DefaultConfigFilename),
+		configBytes); err != nil {
+		return err
+	}
+	if err := files.WriteFileRW(path.Join(workDir, "genesis.json"),
+		genesisBytes); err != nil {
+		return err
+	}
+	return files.WriteFile(path.Join(workDir, "priv_validator.json"),
+		wire.JSONBytesPretty(privValidator), 0600)
+}
+
+func printDevAccounts(mnemonic string, privAccounts []*acm.PrivAccount) {
+	fmt.Printf("\nMnemonic: %s\n\n", mnemonic)
+	fmt.Printf("Each account has %d and all the permissions, and the first "+
+		"is the validator.\nThese keys are for development only, never use "+
+		"them on a real chain.\n\n", devBalance)
+	for i, privAccount := range privAccounts {
+		privKey := privAccount.PrivKey.(crypto.PrivKeyEd25519)
+		fmt.Printf("(%d) Address:     %X\n    Private key: %X\n", i,
+			privAccount.Address, privKey[:])
+	}
+	fmt.Println()
+}
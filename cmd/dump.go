@@ -0,0 +1,143 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/core"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/manager"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/spf13/cobra"
+)
+
+// build the dump subcommand
+func buildDumpCommand(do *definitions.Do) *cobra.Command {
+	var output string
+	var height int
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "burrow dump exports the state of a stopped burrow node.",
+		Long: `burrow dump exports the accounts, contract storage, and name registry of
+the last committed state of a burrow node, or of the state at a past height
+that has not been pruned. The node must be stopped; the state of a running node
+can be dumped over RPC from /state/dump. The dump can be restored as the
+starting state of a new chain with burrow restore.`,
+		Example: `$ burrow dump --work-dir <path-to-working-directory> --height 1000 --output state.dump`,
+		PreRun:  func(cmd *cobra.Command, args []string) { setWorkDir(do) },
+		Run: func(cmd *cobra.Command, args []string) {
+			managerConfig, err := loadManagerConfigFromDo(do)
+			if err != nil {
+				util.Fatalf("Failed to load application manager configuration: %s", err)
+			}
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					util.Fatalf("Failed to create dump file: %s", err)
+				}
+				defer file.Close()
+				w = file
+			}
+			if height < 0 {
+				util.Fatalf("Negative height %v to dump state at", height)
+			}
+			if err := manager.DumpState(managerConfig, height, w); err != nil {
+				util.Fatalf("Failed to dump state: %s", err)
+			}
+		},
+	}
+	addStateFlags(do, cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", "",
+		"file to write the dump to. If omitted the dump is written to stdout.")
+	cmd.Flags().IntVarP(&height, "height", "", 0,
+		"height of the state to dump. If omitted the last committed state is dumped.")
+	return cmd
+}
+
+// build the restore subcommand
+func buildRestoreCommand(do *definitions.Do) *cobra.Command {
+	var input string
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "burrow restore initialises a new chain from a dump of state.",
+		Long: `burrow restore initialises the state of a new chain from a dump made with
+burrow dump. The chain id and validators are taken from the genesis file of the
+new chain, and its data directory must not already hold any state. Once
+restored the chain is started with burrow serve.`,
+		Example: `$ burrow restore --work-dir <path-to-working-directory> --input state.dump`,
+		PreRun:  func(cmd *cobra.Command, args []string) { setWorkDir(do) },
+		Run: func(cmd *cobra.Command, args []string) {
+			managerConfig, err := loadManagerConfigFromDo(do)
+			if err != nil {
+				util.Fatalf("Failed to load application manager configuration: %s", err)
+			}
+			var r io.Reader = os.Stdin
+			if input != "" {
+				file, err := os.Open(input)
+				if err != nil {
+					util.Fatalf("Failed to open dump file: %s", err)
+				}
+				defer file.Close()
+				r = file
+			}
+			if err := manager.RestoreState(managerConfig, r); err != nil {
+				util.Fatalf("Failed to restore state: %s", err)
+			}
+		},
+	}
+	addStateFlags(do, cmd)
+	cmd.Flags().StringVarP(&input, "input", "i", "",
+		"file to read the dump from. If omitted the dump is read from stdin.")
+	return cmd
+}
+
+func addStateFlags(do *definitions.Do, cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&do.ChainId, "chain-id", "c",
+		defaultChainId(), "specify the chain id to use for assertion against the genesis file. If omitted, and no id is set in $CHAIN_ID, then assert_chain_id is used from the configuration file.")
+	cmd.PersistentFlags().StringVarP(&do.WorkDir, "work-dir", "w",
+		defaultWorkDir(), "specify the working directory of the chain.  If omitted, and no path set in $BURROW_WORKDIR, the current working directory is taken.")
+	cmd.PersistentFlags().StringVarP(&do.DataDir, "data-dir", "",
+		defaultDataDir(), "specify the data directory.  If omitted and not set in $BURROW_DATADIR, <working_directory>/data is taken.")
+}
+
+// Reads the configuration in the working directory and loads the application
+// manager's module configuration from it, as serve does
+func loadManagerConfigFromDo(do *definitions.Do) (*config.ModuleConfig, error) {
+	err := do.ReadConfig(do.WorkDir, DefaultConfigBasename, DefaultConfigType)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read configuration from %s/%s: %v",
+			do.WorkDir, DefaultConfigFilename, err)
+	}
+	do.GenesisFile = path.Join(do.WorkDir,
+		do.Config.GetString("chain.genesis_file"))
+	if err := do.InitialiseDataDirectory(); err != nil {
+		return nil, fmt.Errorf("Failed to initialise data directory (%s): %v",
+			do.DataDir, err)
+	}
+	if do.ChainId == "" {
+		if do.ChainId = do.Config.GetString("chain.assert_chain_id"); do.ChainId == "" {
+			return nil, fmt.Errorf("The config chain.assert_chain_id is empty, " +
+				"but should be set to the chain_id of the chain.")
+		}
+	}
+	return core.LoadApplicationManagerModuleConfig(do)
+}
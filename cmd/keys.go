@@ -0,0 +1,388 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/keys/hd"
+	"github.com/hyperledger/burrow/keys/keystore"
+	"github.com/hyperledger/burrow/txs"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/spf13/cobra"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+)
+
+// build the keys subcommand
+func buildKeysCommand(do *definitions.Do) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "burrow keys converts key files to and from encrypted keystores.",
+		Long: `burrow keys converts plain JSON key files, such as priv_validator.json, to
+keystores in which the private key is encrypted with a passphrase, and back
+again. The passphrase is read from --passphrase-file, else from
+$BURROW_KEYS_PASSPHRASE, else from the first line of stdin.
+
+burrow keys can also generate a BIP39 mnemonic and derive keys from it, so that
+all the keys of a node or a test network can be backed up as a single seed
+phrase and recreated from it, and sign txs with a key file on a machine that is
+never connected to the network.`,
+	}
+	cmd.AddCommand(buildKeysExportCommand(), buildKeysImportCommand(),
+		buildKeysMnemonicCommand(), buildKeysDeriveCommand(),
+		buildKeysSignTxCommand())
+	return cmd
+}
+
+func buildKeysExportCommand() *cobra.Command {
+	var keyFile, kdf, output, passphraseFile string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "burrow keys export encrypts a plain key file as a keystore.",
+		Example: `$ burrow keys export --key priv_validator.json --output validator.keystore
+$ burrow keys export --key priv_validator.json --kdf pbkdf2 < passphrase.txt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			keyJSON, err := ioutil.ReadFile(keyFile)
+			if err != nil {
+				util.Fatalf("Could not read key file: %s", err)
+			}
+			privAccount := new(acm.PrivAccount)
+			wire.ReadJSON(privAccount, keyJSON, &err)
+			if err != nil {
+				util.Fatalf("Could not decode key file %s: %s", keyFile, err)
+			}
+			var kdfParams keystore.KDFParams
+			switch kdf {
+			case keystore.KDFScrypt:
+				kdf, kdfParams = keystore.ScryptParams()
+			case keystore.KDFPBKDF2:
+				kdf, kdfParams = keystore.PBKDF2Params()
+			default:
+				util.Fatalf("Unknown KDF %s, must be %s or %s", kdf,
+					keystore.KDFScrypt, keystore.KDFPBKDF2)
+			}
+			passphrase, err := keystore.ReadPassphrase(passphraseFile)
+			if err != nil {
+				util.Fatalf("Could not read passphrase: %s", err)
+			}
+			ks, err := keystore.Encrypt(privAccount, passphrase, kdf, kdfParams)
+			if err != nil {
+				util.Fatalf("Could not encrypt key: %s", err)
+			}
+			ksJSON, err := keystore.Marshal(ks)
+			if err != nil {
+				util.Fatalf("Could not encode keystore: %s", err)
+			}
+			writeKeyOutput(output, ksJSON)
+		},
+	}
+	cmd.Flags().StringVarP(&keyFile, "key", "k", "priv_validator.json",
+		"plain JSON key file to encrypt")
+	cmd.Flags().StringVar(&kdf, "kdf", keystore.KDFScrypt,
+		"key derivation function, scrypt or pbkdf2")
+	addKeysFlags(cmd, &output, &passphraseFile)
+	return cmd
+}
+
+func buildKeysImportCommand() *cobra.Command {
+	var keystoreFile, output, passphraseFile string
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "burrow keys import decrypts a keystore to a plain key file.",
+		Long: `burrow keys import decrypts a keystore made with burrow keys export to a
+plain JSON key file, which can also be used as a new priv_validator.json.`,
+		Example: `$ burrow keys import --keystore validator.keystore --output priv_validator.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ksJSON, err := ioutil.ReadFile(keystoreFile)
+			if err != nil {
+				util.Fatalf("Could not read keystore: %s", err)
+			}
+			ks, err := keystore.Unmarshal(ksJSON)
+			if err != nil {
+				util.Fatalf("%s", err)
+			}
+			passphrase, err := keystore.ReadPassphrase(passphraseFile)
+			if err != nil {
+				util.Fatalf("Could not read passphrase: %s", err)
+			}
+			privAccount, err := keystore.Decrypt(ks, passphrase)
+			if err != nil {
+				util.Fatalf("%s", err)
+			}
+			writeKeyOutput(output, wire.JSONBytesPretty(privAccount))
+		},
+	}
+	cmd.Flags().StringVar(&keystoreFile, "keystore", "",
+		"keystore to decrypt")
+	addKeysFlags(cmd, &output, &passphraseFile)
+	return cmd
+}
+
+func buildKeysMnemonicCommand() *cobra.Command {
+	var wordlistFile string
+	var bits int
+	cmd := &cobra.Command{
+		Use:   "mnemonic",
+		Short: "burrow keys mnemonic generates a BIP39 mnemonic.",
+		Long: `burrow keys mnemonic generates a random BIP39 mnemonic from the words of a
+wordlist, such as the english.txt published with BIP39.`,
+		Example: `$ burrow keys mnemonic --wordlist english.txt --bits 256 > seed.txt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			wordlist, err := hd.LoadWordlist(wordlistFile)
+			if err != nil {
+				util.Fatalf("Could not load wordlist: %s", err)
+			}
+			mnemonic, err := wordlist.NewMnemonic(bits)
+			if err != nil {
+				util.Fatalf("Could not generate mnemonic: %s", err)
+			}
+			fmt.Println(mnemonic)
+		},
+	}
+	cmd.Flags().StringVar(&wordlistFile, "wordlist", "english.txt",
+		"BIP39 wordlist with one word on each line")
+	cmd.Flags().IntVar(&bits, "bits", 256,
+		"bits of entropy, a multiple of 32 from 128 to 256")
+	return cmd
+}
+
+func buildKeysDeriveCommand() *cobra.Command {
+	var mnemonicFile, wordlistFile, keyType, derivationPath, outputDir string
+	var count int
+	cmd := &cobra.Command{
+		Use:   "derive",
+		Short: "burrow keys derive derives plain key files from a BIP39 mnemonic.",
+		Long: `burrow keys derive derives count keys from a BIP39 mnemonic, at the
+derivation path and its siblings found by increasing its last index. Ed25519
+keys are derived as SLIP-0010 specifies, which allows only hardened indices, and
+secp256k1 keys as BIP32 specifies. The mnemonic is read from --mnemonic-file,
+else from the first line of stdin, and its optional BIP39 passphrase from
+$BURROW_KEYS_SEED_PASSPHRASE. If a wordlist is given the mnemonic is checked
+against it.
+
+The keys are written as plain JSON key files named by address to --output-dir,
+or as a JSON array to stdout. The path of each key is written to stderr.`,
+		Example: `$ burrow keys derive --mnemonic-file seed.txt --count 4 --output-dir keys
+$ burrow keys derive --key-type secp256k1 --path "m/44'/60'/0'/0/0" < seed.txt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			mnemonic, err := readMnemonic(mnemonicFile)
+			if err != nil {
+				util.Fatalf("Could not read mnemonic: %s", err)
+			}
+			if wordlistFile != "" {
+				wordlist, err := hd.LoadWordlist(wordlistFile)
+				if err != nil {
+					util.Fatalf("Could not load wordlist: %s", err)
+				}
+				if _, err := wordlist.MnemonicToEntropy(mnemonic); err != nil {
+					util.Fatalf("%s", err)
+				}
+			}
+			if derivationPath == "" {
+				derivationPath = defaultDerivationPath(keyType)
+			}
+			p, err := hd.ParsePath(derivationPath)
+			if err != nil {
+				util.Fatalf("%s", err)
+			}
+			if len(p) == 0 && count > 1 {
+				util.Fatalf("Cannot derive more than one key at the master path")
+			}
+			seed := hd.Seed(mnemonic, os.Getenv("BURROW_KEYS_SEED_PASSPHRASE"))
+			privAccounts := make([]*acm.PrivAccount, count)
+			for i := range privAccounts {
+				sibling := p.Sibling(uint32(i))
+				privAccounts[i], err = derivePrivAccount(seed, sibling, keyType)
+				if err != nil {
+					util.Fatalf("Could not derive key at %s: %s", sibling, err)
+				}
+				fmt.Fprintf(os.Stderr, "%s %X\n", sibling, privAccounts[i].Address)
+			}
+			if outputDir == "" {
+				fmt.Println(string(wire.JSONBytesPretty(privAccounts)))
+				return
+			}
+			if err := os.MkdirAll(outputDir, 0700); err != nil {
+				util.Fatalf("Could not create %s: %s", outputDir, err)
+			}
+			for _, privAccount := range privAccounts {
+				writeKeyOutput(path.Join(outputDir, fmt.Sprintf("%X.json",
+					privAccount.Address)), wire.JSONBytesPretty(privAccount))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&mnemonicFile, "mnemonic-file", "",
+		"file holding the mnemonic on its first line")
+	cmd.Flags().StringVar(&wordlistFile, "wordlist", "",
+		"BIP39 wordlist to check the mnemonic against")
+	cmd.Flags().StringVar(&keyType, "key-type", "ed25519",
+		"type of key to derive, ed25519 or secp256k1")
+	cmd.Flags().StringVar(&derivationPath, "path", "",
+		"derivation path of the first key. If omitted it is m/44'/60'/0'/0'/0' "+
+			"for ed25519 and m/44'/60'/0'/0/0 for secp256k1.")
+	cmd.Flags().IntVar(&count, "count", 1, "number of keys to derive")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "",
+		"directory to write the key files to")
+	return cmd
+}
+
+func buildKeysSignTxCommand() *cobra.Command {
+	var keyFile, chainID, output, passphraseFile string
+	cmd := &cobra.Command{
+		Use:   "sign-tx <file>",
+		Short: "burrow keys sign-tx signs a tx with a key file, without a network.",
+		Long: `burrow keys sign-tx signs the unsigned tx in the JSON file, as written by
+burrow-client tx with --unsigned, with the key in a plain key file or keystore.
+It never touches the network, so the key can be kept on an air-gapped machine.
+The tx and its hash are written to stderr to be checked before the signed tx is
+carried back and broadcast with burrow-client tx broadcast. The file - is read
+from stdin, in which case the passphrase of a keystore cannot be.`,
+		Example: `$ burrow-client tx send --unsigned --pubkey <pubkey> --amt 10 --to <addr> > send.json
+$ burrow keys sign-tx --key cold.keystore --chain-id my-chain --output signed.json send.json
+$ burrow-client tx broadcast signed.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				util.Fatalf("Give the file of the tx to sign")
+			}
+			if chainID == "" {
+				util.Fatalf("Give the chain ID the tx is signed for with --chain-id")
+			}
+			var txJSON []byte
+			var err error
+			if args[0] == "-" {
+				txJSON, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				txJSON, err = ioutil.ReadFile(args[0])
+			}
+			if err != nil {
+				util.Fatalf("Could not read tx file: %s", err)
+			}
+			tx, err := txs.DecodeTxJSON(txJSON)
+			if err != nil {
+				util.Fatalf("Could not decode tx file %s: %s", args[0], err)
+			}
+			privAccount, err := keystore.ReadKeyFile(keyFile, passphraseFile)
+			if err != nil {
+				util.Fatalf("%s", err)
+			}
+			fmt.Fprintf(os.Stderr, "Signing %v\n", tx)
+			tx, err = rpc.SignTx(keys.NewLocalKeyClient(privAccount), chainID, tx)
+			if err != nil {
+				util.Fatalf("Could not sign tx: %s", err)
+			}
+			fmt.Fprintf(os.Stderr, "Signed tx with hash %X\n", txs.TxHash(chainID, tx))
+			writeKeyOutput(output, txs.TxJSON(tx))
+		},
+	}
+	cmd.Flags().StringVarP(&keyFile, "key", "k", "",
+		"plain JSON key file or keystore to sign with")
+	cmd.Flags().StringVar(&chainID, "chain-id", "",
+		"ID of the chain the tx is signed for")
+	addKeysFlags(cmd, &output, &passphraseFile)
+	return cmd
+}
+
+func defaultDerivationPath(keyType string) string {
+	if keyType == "secp256k1" {
+		return "m/44'/60'/0'/0/0"
+	}
+	return "m/44'/60'/0'/0'/0'"
+}
+
+func derivePrivAccount(seed []byte, p hd.Path, keyType string) (*acm.PrivAccount, error) {
+	switch keyType {
+	case "ed25519":
+		key, _, err := hd.DeriveEd25519(seed, p)
+		if err != nil {
+			return nil, err
+		}
+		// The public key half is filled in from the seed half
+		privKeyBytes := make([]byte, 64)
+		copy(privKeyBytes, key)
+		return acm.GenPrivAccountFromPrivKeyBytes(privKeyBytes), nil
+	case "secp256k1":
+		key, _, err := hd.DeriveSecp256k1(seed, p)
+		if err != nil {
+			return nil, err
+		}
+		var privKey crypto.PrivKeySecp256k1
+		copy(privKey[:], key)
+		pubKey := privKey.PubKey()
+		return &acm.PrivAccount{
+			Address: pubKey.Address(),
+			PubKey:  pubKey,
+			PrivKey: privKey,
+		}, nil
+	}
+	return nil, fmt.Errorf("Unknown key type %s, must be ed25519 or secp256k1",
+		keyType)
+}
+
+func readMnemonic(mnemonicFile string) (string, error) {
+	var bs []byte
+	var err error
+	if mnemonicFile != "" {
+		bs, err = ioutil.ReadFile(mnemonicFile)
+	} else {
+		var line string
+		line, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if line != "" {
+			err = nil
+		}
+		bs = []byte(line)
+	}
+	if err != nil {
+		return "", err
+	}
+	mnemonic := strings.TrimSpace(strings.SplitN(string(bs), "\n", 2)[0])
+	if mnemonic == "" {
+		return "", fmt.Errorf("The mnemonic is empty")
+	}
+	return mnemonic, nil
+}
+
+func addKeysFlags(cmd *cobra.Command, output, passphraseFile *string) {
+	cmd.Flags().StringVarP(output, "output", "o", "",
+		"file to write to, which must not exist. If omitted it is written to stdout.")
+	cmd.Flags().StringVar(passphraseFile, "passphrase-file", "",
+		"file holding the passphrase on its first line")
+}
+
+// Key files are written readable only by their owner and never overwritten
+func writeKeyOutput(output string, bs []byte) {
+	if output == "" {
+		fmt.Println(string(bs))
+		return
+	}
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		util.Fatalf("Could not create %s: %s", output, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(bs, '\n')); err != nil {
+		util.Fatalf("Could not write %s: %s", output, err)
+	}
+}
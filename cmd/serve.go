@@ -20,12 +20,15 @@ import (
 	"os/signal"
 	"path"
 	"syscall"
+	"time"
 
 	"github.com/hyperledger/burrow/core"
 	"github.com/hyperledger/burrow/definitions"
 	"github.com/hyperledger/burrow/logging"
+	lconfig "github.com/hyperledger/burrow/logging/config"
 	"github.com/hyperledger/burrow/logging/lifecycle"
 	vm "github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/server"
 	"github.com/hyperledger/burrow/util"
 
 	"github.com/hyperledger/burrow/config"
@@ -35,6 +38,9 @@ import (
 const (
 	DefaultConfigBasename = "config"
 	DefaultConfigType     = "toml"
+	// How long the servers are given to finish their requests once the node
+	// has halted
+	haltTimeout = 10 * time.Second
 )
 
 var DefaultConfigFilename = fmt.Sprintf("%s.%s",
@@ -44,32 +50,22 @@ var DefaultConfigFilename = fmt.Sprintf("%s.%s",
 // build the serve subcommand
 func buildServeCommand(do *definitions.Do) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "serve",
-		Short: "burrow serve starts a burrow node with client API enabled by default.",
+		Use:     "serve",
+		Aliases: []string{"start"},
+		Short:   "burrow serve starts a burrow node with client API enabled by default.",
 		Long: `burrow serve starts a burrow node with client API enabled by default.
 The burrow node is modularly configured for the consensus engine and application
 manager.  The client API can be disabled.`,
 		Example: fmt.Sprintf(`$ burrow serve -- will start the burrow node based on the configuration file "%s" in the current working directory
 $ burrow serve --work-dir <path-to-working-directory> -- will start the burrow node based on the configuration file "%s" in the provided working directory
-$ burrow serve --chain-id <CHAIN_ID> -- will overrule the configuration entry assert_chain_id`,
+$ burrow serve --chain-id <CHAIN_ID> -- will overrule the configuration entry assert_chain_id
+$ burrow start --dev -- will start a single node development chain with pre-funded accounts in a new temporary directory`,
 			DefaultConfigFilename, DefaultConfigFilename),
 		PreRun: func(cmd *cobra.Command, args []string) {
-			// if WorkDir was not set by a flag or by $BURROW_WORKDIR
-			// NOTE [ben]: we can consider an `Explicit` flag that eliminates
-			// the use of any assumptions while starting burrow
-			if do.WorkDir == "" {
-				if currentDirectory, err := os.Getwd(); err != nil {
-					panic(fmt.Sprintf("No directory provided and failed to get current "+
-						"working directory: %v", err))
-					os.Exit(1)
-				} else {
-					do.WorkDir = currentDirectory
-				}
-			}
-			if !util.IsDir(do.WorkDir) {
-				panic(fmt.Sprintf("Provided working directory %s is not a directory",
-					do.WorkDir))
-				os.Exit(1)
+			if do.Dev {
+				setDevWorkDir(do)
+			} else {
+				setWorkDir(do)
 			}
 		},
 		Run: ServeRunner(do),
@@ -78,6 +74,26 @@ $ burrow serve --chain-id <CHAIN_ID> -- will overrule the configuration entry as
 	return cmd
 }
 
+func setWorkDir(do *definitions.Do) {
+	// if WorkDir was not set by a flag or by $BURROW_WORKDIR
+	// NOTE [ben]: we can consider an `Explicit` flag that eliminates
+	// the use of any assumptions while starting burrow
+	if do.WorkDir == "" {
+		if currentDirectory, err := os.Getwd(); err != nil {
+			panic(fmt.Sprintf("No directory provided and failed to get current "+
+				"working directory: %v", err))
+			os.Exit(1)
+		} else {
+			do.WorkDir = currentDirectory
+		}
+	}
+	if !util.IsDir(do.WorkDir) {
+		panic(fmt.Sprintf("Provided working directory %s is not a directory",
+			do.WorkDir))
+		os.Exit(1)
+	}
+}
+
 func addServeFlags(do *definitions.Do, serveCmd *cobra.Command) {
 	serveCmd.PersistentFlags().StringVarP(&do.ChainId, "chain-id", "c",
 		defaultChainId(), "specify the chain id to use for assertion against the genesis file or the existing state. If omitted, and no id is set in $CHAIN_ID, then assert_chain_id is used from the configuration file.")
@@ -87,6 +103,12 @@ func addServeFlags(do *definitions.Do, serveCmd *cobra.Command) {
 		defaultDataDir(), "specify the data directory.  If omitted and not set in $BURROW_DATADIR, <working_directory>/data is taken.")
 	serveCmd.PersistentFlags().BoolVarP(&do.DisableRpc, "disable-rpc", "",
 		defaultDisableRpc(), "indicate for the RPC to be disabled. If omitted the RPC is enabled by default, unless (deprecated) $BURROW_API is set to false.")
+	serveCmd.PersistentFlags().BoolVarP(&do.Dev, "dev", "",
+		false, "start a single node development chain with instant blocks and pre-funded accounts, whose keys are printed at startup. The chain is created in a new temporary directory unless --work-dir is given, in which an existing development chain is resumed.")
+	serveCmd.PersistentFlags().IntVarP(&do.DevAccounts, "dev-accounts", "",
+		10, "number of pre-funded accounts of the development chain")
+	serveCmd.PersistentFlags().StringVarP(&do.DevMnemonic, "dev-mnemonic", "",
+		DefaultDevMnemonic, "BIP39 mnemonic the keys of the development chain are derived from")
 }
 
 //------------------------------------------------------------------------------
@@ -119,6 +141,11 @@ func NewCoreFromDo(do *definitions.Do) (*core.Core, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Failed to build logger from logging config: %s", err)
 	}
+	// Rebuild the logger's outputs from the config file on SIGHUP or when
+	// requested over RPC
+	lifecycle.ReloadLoggingOnSignal(logger, func() (*lconfig.LoggingConfig, error) {
+		return core.LoadLoggingConfigFromFile(path.Join(do.WorkDir, DefaultConfigFilename))
+	})
 	// Create a root logger to pass through to dependencies
 	logger = logging.WithScope(logger, "Serve")
 	// Capture all logging from tendermint/tendermint and tendermint/go-*
@@ -161,7 +188,8 @@ func NewCoreFromDo(do *definitions.Do) (*core.Core, error) {
 
 // ServeRunner() returns a command runner that prepares the environment and sets
 // up the core for burrow to run. After the setup succeeds, it starts the core
-// and waits for the core to terminate.
+// and waits for the core to terminate. The additional chains of the
+// configuration are run alongside in the same process, each until it stops.
 func ServeRunner(do *definitions.Do) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
 		// load configuration from a single location to avoid a wrong configuration
@@ -174,38 +202,140 @@ func ServeRunner(do *definitions.Do) func(*cobra.Command, []string) {
 
 		vm.SetDebug(do.Debug)
 
-		newCore, err := NewCoreFromDo(do)
-
+		additionalDos, err := additionalChainDos(do)
 		if err != nil {
-			util.Fatalf("Failed to load core: %s", err)
+			util.Fatalf("Failed to load additional chains: %s", err)
 		}
-
-		if !do.DisableRpc {
-			serverConfig, err := core.LoadServerConfigFromDo(do)
-			if err != nil {
-				util.Fatalf("Failed to load server configuration: %s.", err)
-			}
-			serverProcess, err := newCore.NewGatewayV0(serverConfig)
-			if err != nil {
-				util.Fatalf("Failed to load servers: %s.", err)
-			}
-			err = serverProcess.Start()
+		// The chain of the working directory is served last so that its logger
+		// is the one that captures the logging of tendermint and of the stdlib
+		var chains []*servedChain
+		for _, chainDo := range append(additionalDos, do) {
+			chain, err := serveChain(chainDo)
 			if err != nil {
-				util.Fatalf("Failed to start servers: %s.", err)
+				util.Fatalf("Failed to serve chain of %s: %s", chainDo.WorkDir, err)
 			}
-			_, err = newCore.NewGatewayTendermint(serverConfig)
-			if err != nil {
-				util.Fatalf("Failed to start Tendermint gateway")
-			}
-			<-serverProcess.StopEventChannel()
-			// Attempt graceful shutdown
-			newCore.Stop()
-		} else {
-			signals := make(chan os.Signal, 1)
+			chains = append(chains, chain)
+		}
+
+		stopped := make(chan *servedChain)
+		for _, chain := range chains {
+			go chain.waitForStop(stopped)
+		}
+		signals := make(chan os.Signal, 1)
+		if do.DisableRpc {
 			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
-			fmt.Fprintf(os.Stderr, "Received %s signal. Marmots out.", <-signals)
 		}
+		for running := len(chains); running > 0; running-- {
+			select {
+			case received := <-signals:
+				fmt.Fprintf(os.Stderr, "Received %s signal. Marmots out.", received)
+				return
+			case chain := <-stopped:
+				// Attempt graceful shutdown
+				chain.core.Stop()
+			}
+		}
+	}
+}
+
+// A chain served by this process, with its servers unless the RPC is disabled
+type servedChain struct {
+	chainId       string
+	core          *core.Core
+	serverProcess *server.ServeProcess
+}
+
+func serveChain(do *definitions.Do) (*servedChain, error) {
+	newCore, err := NewCoreFromDo(do)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load core: %s", err)
+	}
+	chain := &servedChain{
+		chainId: do.ChainId,
+		core:    newCore,
+	}
+	if do.DisableRpc {
+		return chain, nil
+	}
+	serverConfig, err := core.LoadServerConfigFromDo(do)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load server configuration: %s.", err)
+	}
+	chain.serverProcess, err = newCore.NewGatewayV0(serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load servers: %s.", err)
+	}
+	err = chain.serverProcess.Start()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start servers: %s.", err)
+	}
+	_, err = newCore.NewGatewayTendermint(serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start Tendermint gateway")
+	}
+	return chain, nil
+}
+
+// Sends chain on stopped once its servers have stopped, or once it has halted
+// for an upgrade, stopping its servers
+func (chain *servedChain) waitForStop(stopped chan<- *servedChain) {
+	var serversStopped <-chan struct{}
+	if chain.serverProcess != nil {
+		serversStopped = chain.serverProcess.StopEventChannel()
+	}
+	select {
+	case <-serversStopped:
+	case <-chain.core.Halted():
+		fmt.Fprintf(os.Stderr, "Chain %s halted for its upgrade.\n",
+			chain.chainId)
+		if chain.serverProcess != nil {
+			chain.serverProcess.Stop(haltTimeout)
+		}
+	}
+	stopped <- chain
+}
+
+// The dos of the additional chains the configuration of do names, which are
+// served from their own working directories with their own configurations
+func additionalChainDos(do *definitions.Do) ([]*definitions.Do, error) {
+	chainId := do.ChainId
+	if chainId == "" {
+		chainId = do.Config.GetString("chain.assert_chain_id")
+	}
+	chainIds := map[string]string{chainId: do.WorkDir}
+	var dos []*definitions.Do
+	for _, workDir := range do.Config.GetStringSlice("chain.additional_chains") {
+		chainDo := definitions.NewDo()
+		chainDo.Debug = do.Debug
+		chainDo.Verbose = do.Verbose
+		chainDo.DisableRpc = do.DisableRpc
+		chainDo.WorkDir = workDir
+		if !path.IsAbs(workDir) {
+			chainDo.WorkDir = path.Join(do.WorkDir, workDir)
+		}
+		if !util.IsDir(chainDo.WorkDir) {
+			return nil, fmt.Errorf("Working directory %s of additional chain is "+
+				"not a directory", chainDo.WorkDir)
+		}
+		err := chainDo.ReadConfig(chainDo.WorkDir, DefaultConfigBasename,
+			DefaultConfigType)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read configuration from %s/%s: %v",
+				chainDo.WorkDir, DefaultConfigFilename, err)
+		}
+		if len(chainDo.Config.GetStringSlice("chain.additional_chains")) > 0 {
+			return nil, fmt.Errorf("Additional chain of %s cannot have "+
+				"additional chains of its own", chainDo.WorkDir)
+		}
+		chainId = chainDo.Config.GetString("chain.assert_chain_id")
+		if other, ok := chainIds[chainId]; ok {
+			return nil, fmt.Errorf("Chains of %s and %s have the same chain id %s",
+				other, chainDo.WorkDir, chainId)
+		}
+		chainIds[chainId] = chainDo.WorkDir
+		dos = append(dos, chainDo)
 	}
+	return dos, nil
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,118 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/files"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/spf13/cobra"
+)
+
+// build the spec subcommand
+func buildSpecCommand(do *definitions.Do) *cobra.Command {
+	var chainID, accountsFile, format, genesisTime, output string
+	cmd := &cobra.Command{
+		Use:   "spec",
+		Short: "burrow spec generates a genesis file from a list of accounts.",
+		Long: `burrow spec generates a genesis file from a list of accounts, with their
+balances, permissions and roles, produced elsewhere, such as by an onboarding
+pipeline. The list is either a JSON array of accounts or a CSV whose header row
+names its columns, of:
+
+  address      the address of the account in hex
+  amount       the balance of the account
+  name         the name of the account
+  permissions  the names of the permissions granted, separated by spaces
+  roles        the roles of the account, separated by spaces
+  pub_key      the ed25519 public key in hex of an account that is a validator,
+               from which its address is taken if address is omitted
+  bonded       the amount the validator bonds
+
+Every account and permission is validated. The accounts and validators are
+ordered by address, so the same list always generates the same genesis file.`,
+		Example: `$ burrow spec --chain-id my_chain --accounts accounts.csv --output genesis.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if chainID == "" {
+				util.Fatalf("A genesis file needs a chain id")
+			}
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(accountsFile), ".")
+			}
+			var read func(io.Reader) ([]*genesis.AccountSpec, error)
+			switch strings.ToLower(format) {
+			case "csv":
+				read = genesis.ReadAccountSpecsCSV
+			case "json":
+				read = genesis.ReadAccountSpecsJSON
+			default:
+				util.Fatalf("Unknown format '%s' of accounts, expected 'csv' or "+
+					"'json'", format)
+			}
+			var input io.Reader = os.Stdin
+			if accountsFile != "" {
+				file, err := os.Open(accountsFile)
+				if err != nil {
+					util.Fatalf("Could not open accounts: %s", err)
+				}
+				defer file.Close()
+				input = file
+			}
+			specs, err := read(input)
+			if err != nil {
+				util.Fatalf("Could not read accounts: %s", err)
+			}
+			timestamp := genesis.DefaultGenesisTime
+			if genesisTime != "" {
+				if timestamp, err = time.Parse(time.RFC3339, genesisTime); err != nil {
+					util.Fatalf("Invalid genesis time: %s", err)
+				}
+			}
+			genesisDoc, err := genesis.MakeGenesisDocFromAccountSpecs(chainID,
+				timestamp.UTC(), specs)
+			if err != nil {
+				util.Fatalf("Could not generate genesis file: %s", err)
+			}
+			genesisBytes, err := genesis.GetGenesisFileBytes(genesisDoc)
+			if err != nil {
+				util.Fatalf("Could not generate genesis file: %s", err)
+			}
+			if output == "" {
+				os.Stdout.Write(append(genesisBytes, '\n'))
+			} else if err := files.WriteFileRW(output, genesisBytes); err != nil {
+				util.Fatalf("Could not write %s: %s", output, err)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&chainID, "chain-id", "c", "",
+		"chain id of the genesis file")
+	cmd.Flags().StringVarP(&accountsFile, "accounts", "a", "",
+		"file to read the accounts from. If omitted the accounts are read from stdin.")
+	cmd.Flags().StringVar(&format, "format", "",
+		"format of the accounts, csv or json. If omitted it is taken from the extension of --accounts.")
+	cmd.Flags().StringVar(&genesisTime, "genesis-time", "",
+		"genesis time of the chain in RFC3339. If omitted a fixed default is taken.")
+	cmd.Flags().StringVarP(&output, "output", "o", "",
+		"file to write the genesis file to. If omitted it is written to stdout.")
+	return cmd
+}
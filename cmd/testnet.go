@@ -0,0 +1,98 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/testnet"
+	"github.com/hyperledger/burrow/util"
+
+	"github.com/spf13/cobra"
+)
+
+// build the testnet subcommand
+func buildTestnetCommand(do *definitions.Do) *cobra.Command {
+	var spec testnet.Spec
+	var outputDir string
+	var local bool
+	cmd := &cobra.Command{
+		Use:   "testnet",
+		Short: "burrow testnet generates the nodes of a test network of validators.",
+		Long: `burrow testnet generates new keys for a number of validators and writes a
+working directory for each, named node0, node1 and so on, holding a genesis
+file naming all the validators, the key of the validator and a configuration
+whose seeds are the other nodes. The nodes listen on their own ports, those of
+the first plus 10 for each next node, so they can share a host. Plain key files
+of the accounts, the first of which has all the permissions, are written to
+accounts, and a docker-compose.yaml and a kubernetes.yaml running each node in
+its own container are written alongside.
+
+The nodes dial each other by the names of their services in the manifests
+unless --hosts or --local is given.`,
+		Example: `$ burrow testnet --chain-id my_testnet --validators 4 --output-dir my_testnet
+$ burrow testnet --chain-id my_testnet --local && cd my_testnet && burrow serve --work-dir node0`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if local {
+				spec.Hosts = make([]string, spec.Validators)
+				for i := range spec.Hosts {
+					spec.Hosts[i] = "127.0.0.1"
+				}
+			}
+			if outputDir == "" {
+				outputDir = spec.ChainId
+			}
+			if entries, err := ioutil.ReadDir(outputDir); err == nil && len(entries) > 0 {
+				util.Fatalf("Output directory %s is not empty", outputDir)
+			}
+			tn, err := testnet.Generate(spec)
+			if err != nil {
+				util.Fatalf("Could not generate testnet: %s", err)
+			}
+			if err := os.MkdirAll(outputDir, 0700); err != nil {
+				util.Fatalf("Could not create %s: %s", outputDir, err)
+			}
+			if err := tn.Write(outputDir); err != nil {
+				util.Fatalf("Could not write testnet to %s: %s", outputDir, err)
+			}
+			for i, node := range tn.Nodes {
+				fmt.Printf("%s %X api:%d peer:%d rpc:%d seeds:%s\n", node.Name,
+					node.PrivValidator.Address, node.APIPort, node.PeerPort,
+					node.RPCPort, tn.Seeds(i))
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&spec.ChainId, "chain-id", "c", "",
+		"chain id of the testnet")
+	cmd.Flags().IntVar(&spec.Validators, "validators", 4, "number of validators")
+	cmd.Flags().IntVar(&spec.Accounts, "accounts", 1,
+		"number of accounts in addition to those of the validators")
+	cmd.Flags().Int64Var(&spec.Balance, "balance", 1000000000000,
+		"balance of each account")
+	cmd.Flags().Int64Var(&spec.Bonded, "bonded", 1000000000,
+		"amount each validator bonds from the balance of its account")
+	cmd.Flags().StringSliceVar(&spec.Hosts, "hosts", nil,
+		"comma separated hosts the nodes dial each other at, one for each validator")
+	cmd.Flags().BoolVar(&local, "local", false,
+		"run all the nodes on this host, which they dial each other at 127.0.0.1")
+	cmd.Flags().StringVar(&spec.DockerImage, "image", testnet.DefaultDockerImage,
+		"docker image of burrow the manifests run")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "",
+		"directory to write the testnet to. If omitted it is named by the chain id.")
+	return cmd
+}
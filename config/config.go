@@ -17,6 +17,9 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"text/template"
 
 	lconfig "github.com/hyperledger/burrow/logging/config"
@@ -45,16 +48,44 @@ type ConfigChainModule struct {
 }
 
 type ConfigTendermint struct {
-	Moniker  string
-	Seeds    string
-	FastSync bool
+	Moniker     string
+	Seeds       string
+	FastSync    bool
+	NodeAddress string
+	DBBackend   string
+	// Shortens the timeouts for a single node development chain
+	InstantBlocks bool
 }
 
+// The addresses the servers listen on, which must differ between the nodes run
+// on one host
+type ConfigServers struct {
+	BindPort             uint16
+	TendermintRPCAddress string
+}
+
+type ConfigBurrowMint struct {
+	DBBackend string
+	// Must be the TendermintRPCAddress of the servers
+	TendermintHost string
+}
+
+// The listen addresses for a node run on its own. The Tendermint RPC, which
+// serves the unsafe and private routes, only listens locally.
+const (
+	DefaultNodeAddress          = "0.0.0.0:46656"
+	DefaultBindPort             = 1337
+	DefaultTendermintRPCAddress = "127.0.0.1:46657"
+	DefaultDBBackend            = "leveldb"
+)
+
 var serviceGeneralTemplate *template.Template
 var chainGeneralTemplate *template.Template
 var chainConsensusTemplate *template.Template
 var chainApplicationManagerTemplate *template.Template
 var tendermintTemplate *template.Template
+var serversTemplate *template.Template
+var burrowMintTemplate *template.Template
 
 func init() {
 	var err error
@@ -73,6 +104,12 @@ func init() {
 	if tendermintTemplate, err = template.New("tendermint").Parse(sectionTendermint); err != nil {
 		panic(err)
 	}
+	if serversTemplate, err = template.New("servers").Parse(sectionServers); err != nil {
+		panic(err)
+	}
+	if burrowMintTemplate, err = template.New("burrowMint").Parse(sectionBurrowMint); err != nil {
+		panic(err)
+	}
 }
 
 // NOTE: [ben] for 0.12.0-rc3 we only have a single configuration path
@@ -90,6 +127,69 @@ func GetConfigurationFileBytes(chainId, moniker, seeds string, chainImageName st
 		ContainerEntrypoint: containerEntrypoint,
 	}
 
+	tendermintModule := &ConfigTendermint{
+		Moniker:     moniker,
+		Seeds:       seeds,
+		FastSync:    false,
+		NodeAddress: DefaultNodeAddress,
+		DBBackend:   DefaultDBBackend,
+	}
+	return configurationFileBytes(chainId, burrowService, tendermintModule,
+		defaultServers(), defaultBurrowMint())
+}
+
+// GetDevConfigurationFileBytes returns the configuration of a single node
+// development chain, which commits blocks as soon as it can
+func GetDevConfigurationFileBytes(chainId, moniker string) ([]byte, error) {
+	tendermintModule := &ConfigTendermint{
+		Moniker:       moniker,
+		FastSync:      false,
+		NodeAddress:   DefaultNodeAddress,
+		DBBackend:     DefaultDBBackend,
+		InstantBlocks: true,
+	}
+	return GetNodeConfigurationFileBytes(chainId, tendermintModule,
+		defaultServers(), defaultBurrowMint())
+}
+
+// GetNodeConfigurationFileBytes returns the configuration of one node of a
+// chain whose nodes may share a host, so listen on their own addresses
+func GetNodeConfigurationFileBytes(chainId string, tendermintModule *ConfigTendermint,
+	servers *ConfigServers, burrowMint *ConfigBurrowMint) ([]byte, error) {
+	exportedPorts := []string{strconv.Itoa(int(servers.BindPort))}
+	for _, address := range []string{tendermintModule.NodeAddress,
+		servers.TendermintRPCAddress} {
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("Bad listen address for %s: %s", chainId, err)
+		}
+		exportedPorts = append(exportedPorts, port)
+	}
+	burrowService := &ConfigServiceGeneral{
+		ChainImageName: "db:latest",
+		ExportedPorts:  fmt.Sprintf("[ %s ]", strings.Join(exportedPorts, ", ")),
+	}
+	return configurationFileBytes(chainId, burrowService, tendermintModule,
+		servers, burrowMint)
+}
+
+func defaultServers() *ConfigServers {
+	return &ConfigServers{
+		BindPort:             DefaultBindPort,
+		TendermintRPCAddress: DefaultTendermintRPCAddress,
+	}
+}
+
+func defaultBurrowMint() *ConfigBurrowMint {
+	return &ConfigBurrowMint{
+		DBBackend:      DefaultDBBackend,
+		TendermintHost: DefaultTendermintRPCAddress,
+	}
+}
+
+func configurationFileBytes(chainId string, burrowService *ConfigServiceGeneral,
+	tendermintModule *ConfigTendermint, servers *ConfigServers,
+	burrowMint *ConfigBurrowMint) ([]byte, error) {
 	// We want to encode in the config file which Burrow version generated the config
 	burrowVersion := version.GetBurrowVersion()
 	burrowChain := &ConfigChainGeneral{
@@ -108,11 +208,6 @@ func GetConfigurationFileBytes(chainId, moniker, seeds string, chainImageName st
 		Name:               "burrowmint",
 		ModuleRelativeRoot: "burrowmint",
 	}
-	tendermintModule := &ConfigTendermint{
-		Moniker:  moniker,
-		Seeds:    seeds,
-		FastSync: false,
-	}
 
 	// NOTE: [ben] according to StackOverflow appending strings with copy is
 	// more efficient than bytes.WriteString, but for readability and because
@@ -157,9 +252,11 @@ func GetConfigurationFileBytes(chainId, moniker, seeds string, chainImageName st
 
 	// write separator servers
 	buffer.WriteString(separatorServerConfiguration)
-	// TODO: [ben] upon necessity replace this with template too
-	// write static section servers
-	buffer.WriteString(sectionServers)
+	// write section [servers]
+	if err := serversTemplate.Execute(&buffer, servers); err != nil {
+		return nil, fmt.Errorf("Failed to write template servers for %s: %s",
+			chainId, err)
+	}
 
 	// write separator modules
 	buffer.WriteString(separatorModules)
@@ -167,11 +264,14 @@ func GetConfigurationFileBytes(chainId, moniker, seeds string, chainImageName st
 	// write section module Tendermint
 	if err := tendermintTemplate.Execute(&buffer, tendermintModule); err != nil {
 		return nil, fmt.Errorf("Failed to write template tendermint for %s, moniker %s: %s",
-			chainId, moniker, err)
+			chainId, tendermintModule.Moniker, err)
 	}
 
-	// write static section burrowmint
-	buffer.WriteString(sectionBurrowMint)
+	// write section burrowmint
+	if err := burrowMintTemplate.Execute(&buffer, burrowMint); err != nil {
+		return nil, fmt.Errorf("Failed to write template burrowmint for %s: %s",
+			chainId, err)
+	}
 
 	buffer.WriteString(sectionLoggingHeader)
 	buffer.WriteString(lconfig.DefaultNodeLoggingConfig().RootTOMLString())
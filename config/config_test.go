@@ -26,3 +26,17 @@ func TestGeneratedConfigIsUsable(t *testing.T) {
 	_, err = ReadViperConfig(bs)
 	assert.NoError(t, err, "Should be able to read example config into Viper")
 }
+
+func TestDevConfigHasInstantBlocks(t *testing.T) {
+	bs, err := GetDevConfigurationFileBytes("burrow_dev", "dev_marmot")
+	assert.NoError(t, err, "Should be able to create development config")
+	conf, err := ReadViperConfig(bs)
+	assert.NoError(t, err, "Should be able to read development config into Viper")
+	assert.Equal(t, 100, conf.GetInt("tendermint.configuration.timeout_commit"))
+
+	bs, err = GetExampleConfigFileBytes()
+	assert.NoError(t, err)
+	conf, err = ReadViperConfig(bs)
+	assert.NoError(t, err)
+	assert.False(t, conf.IsSet("tendermint.configuration.timeout_commit"))
+}
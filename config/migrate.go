@@ -0,0 +1,445 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/burrow/genesis"
+	lconfig "github.com/hyperledger/burrow/logging/config"
+
+	"github.com/BurntSushi/toml"
+	wire "github.com/tendermint/go-wire"
+)
+
+// The tables of the configuration that keep whatever keys they are given,
+// since they are passed on to Tendermint or are keyed by names operators
+// choose, along with the tables inside them
+var openTables = []string{
+	"tendermint.configuration",
+	"servers.tls.certificates",
+	"servers.auth.roles",
+	"servers.auth.identities",
+}
+
+// The keys that hold the version of burrow that wrote a configuration, which
+// are always migrated to the current version
+var versionKeys = map[string]bool{
+	"chain." + majorVersionKey: true,
+	"chain." + minorVersionKey: true,
+}
+
+var (
+	tableHeaderRegexp = regexp.MustCompile(`^(\s*)\[([^\[\]]+)\]\s*(#.*)?$`)
+	keyLineRegexp     = regexp.MustCompile(`^(\s*)([A-Za-z0-9_-]+)\s*=\s*(.*)$`)
+	bareKeyRegexp     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// What migrating a configuration or genesis file changed
+type MigrationReport struct {
+	// The keys that are no longer read, which have been dropped
+	Deprecated []string
+	// The keys that were missing, which have been given their defaults
+	Filled []string
+}
+
+// Migrates the configuration file configBytes to the configuration burrow now
+// writes, keeping the values of the keys it set that are still read and giving
+// the keys it lacked their defaults. The comments are those of the current
+// configuration, and the logging configuration is kept as it was.
+func MigrateConfig(configBytes []byte) ([]byte, *MigrationReport, error) {
+	old := make(map[string]interface{})
+	if _, err := toml.Decode(string(configBytes), &old); err != nil {
+		return nil, nil, fmt.Errorf("Failed to read configuration: %v", err)
+	}
+	// every option the configuration is written with is also a key, whose value
+	// is taken from the old configuration, other than the optional entry point
+	entryPoint, _ := lookupTable(old, "service")["entry_point"].(string)
+	currentBytes, err := GetConfigurationFileBytes("", "", "", "", false, "[]",
+		entryPoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := currentBytes[:bytes.Index(currentBytes, []byte(sectionLoggingHeader))]
+	defaults := make(map[string]interface{})
+	if _, err := toml.Decode(string(template), &defaults); err != nil {
+		return nil, nil, fmt.Errorf("Failed to read current configuration: %v", err)
+	}
+
+	loggingConfig := lconfig.DefaultNodeLoggingConfig()
+	if loggingMap, ok := old["logging"].(map[string]interface{}); ok {
+		loggingConfig, err = lconfig.LoggingConfigFromMap(loggingMap)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to read logging configuration: %v",
+				err)
+		}
+	}
+	delete(old, "logging")
+
+	migrated, report := mergeConfig(template, old, defaults)
+	migrated = append(migrated, sectionLoggingHeader...)
+	migrated = append(migrated, loggingConfig.RootTOMLString()...)
+	return migrated, report, nil
+}
+
+// Takes the values of old into template, a configuration whose values are
+// defaults, adding the keys of old in open tables that template does not have
+func mergeConfig(template []byte, old, defaults map[string]interface{}) ([]byte,
+	*MigrationReport) {
+	oldValues := flattenTable(old, "")
+	defaultValues := flattenTable(defaults, "")
+	report := new(MigrationReport)
+	for key := range oldValues {
+		if _, ok := defaultValues[key]; !ok && !isOpen(key) {
+			report.Deprecated = append(report.Deprecated, key)
+		}
+	}
+	for key := range defaultValues {
+		if _, ok := oldValues[key]; !ok && !versionKeys[key] {
+			report.Filled = append(report.Filled, key)
+		}
+	}
+	sort.Strings(report.Deprecated)
+	sort.Strings(report.Filled)
+
+	buf := new(bytes.Buffer)
+	table, tableIndent := "", ""
+	// the comments and blank lines after the last key of a table, which
+	// belong to the next table, so go after the keys added to the table
+	var pending []string
+	lines := strings.SplitAfter(string(template), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if match := tableHeaderRegexp.FindStringSubmatch(line); match != nil {
+			writeOpenKeys(buf, table, tableIndent, old, defaults)
+			for _, line := range pending {
+				buf.WriteString(line)
+			}
+			pending = nil
+			table, tableIndent = strings.TrimSpace(match[2]), match[1]
+			buf.WriteString(line)
+			continue
+		}
+		match := keyLineRegexp.FindStringSubmatch(line)
+		if match == nil {
+			pending = append(pending, line)
+			continue
+		}
+		for _, line := range pending {
+			buf.WriteString(line)
+		}
+		pending = nil
+		// arrays may run over several lines
+		value, comment := splitValue(match[3])
+		for strings.Count(value, "[") > strings.Count(value, "]") && i+1 < len(lines) {
+			i++
+			line += lines[i]
+			value, comment = splitValue(value + " " + strings.TrimSpace(lines[i]))
+		}
+		key := joinKey(table, match[2])
+		if oldValue, ok := oldValues[key]; ok && !versionKeys[key] &&
+			!reflect.DeepEqual(oldValue, defaultValues[key]) {
+			line = match[1] + match[2] + " = " + tomlValue(oldValue)
+			if comment != "" {
+				line += " " + comment
+			}
+			line += "\n"
+		}
+		buf.WriteString(line)
+	}
+	writeOpenKeys(buf, table, tableIndent, old, defaults)
+	for _, line := range pending {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), report
+}
+
+// Writes the keys and tables old has in table, if it is open, that defaults
+// does not
+func writeOpenKeys(buf *bytes.Buffer, table, indent string,
+	old, defaults map[string]interface{}) {
+	if !isOpen(table) {
+		return
+	}
+	defaultTable := lookupTable(defaults, table)
+	added := make(map[string]interface{})
+	for key, value := range lookupTable(old, table) {
+		if _, ok := defaultTable[key]; !ok {
+			added[key] = value
+		}
+	}
+	writeKeys(buf, table, indent, added)
+}
+
+// Writes the keys of table, which is at path, and then the tables inside it
+func writeKeys(buf *bytes.Buffer, path, indent string, table map[string]interface{}) {
+	var keys, tables []string
+	for key, value := range table {
+		if _, ok := value.(map[string]interface{}); ok {
+			tables = append(tables, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	sort.Strings(tables)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s%s = %s\n", indent, tomlKey(key), tomlValue(table[key]))
+	}
+	for _, key := range tables {
+		subPath := joinKey(path, tomlKey(key))
+		fmt.Fprintf(buf, "\n%s[%s]\n", indent, subPath)
+		writeKeys(buf, subPath, indent, table[key].(map[string]interface{}))
+	}
+}
+
+// Whether key is in one of the open tables
+func isOpen(key string) bool {
+	for _, table := range openTables {
+		if key == table || strings.HasPrefix(key, table+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// The values of table and of the tables inside it by their dotted keys
+func flattenTable(table map[string]interface{}, prefix string) map[string]interface{} {
+	values := make(map[string]interface{})
+	for key, value := range table {
+		if subTable, ok := value.(map[string]interface{}); ok {
+			for subKey, subValue := range flattenTable(subTable, joinKey(prefix, key)) {
+				values[subKey] = subValue
+			}
+		} else {
+			values[joinKey(prefix, key)] = value
+		}
+	}
+	return values
+}
+
+// The table at the dotted path in table, nil if there is none
+func lookupTable(table map[string]interface{}, path string) map[string]interface{} {
+	for _, key := range strings.Split(path, ".") {
+		table, _ = table[strings.Trim(key, `"`)].(map[string]interface{})
+	}
+	return table
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// Splits the value of a key line from the comment that follows it
+func splitValue(value string) (string, string) {
+	inString := false
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i:])
+			}
+		}
+	}
+	return strings.TrimSpace(value), ""
+}
+
+func tomlKey(key string) string {
+	if bareKeyRegexp.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// Writes value, as decoded from TOML, back as TOML
+func tomlValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return tomlString(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		float := strconv.FormatFloat(value, 'g', -1, 64)
+		if !strings.ContainsAny(float, ".eIN") {
+			float += ".0"
+		}
+		return float
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case []interface{}:
+		elements := make([]string, len(value))
+		for i, element := range value {
+			elements[i] = tomlValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case []map[string]interface{}:
+		elements := make([]string, len(value))
+		for i, element := range value {
+			elements[i] = tomlValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = tomlKey(key) + " = " + tomlValue(value[key])
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	return tomlString(fmt.Sprint(value))
+}
+
+// Quotes s as a TOML basic string
+func tomlString(s string) string {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(buf, `\u%04X`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// Migrates the genesis file genesisBytes to the genesis doc burrow now reads,
+// dropping the fields that are no longer read and filling in the defaults of
+// the parameters it left unset, with which the chain starts in the same state
+func MigrateGenesis(genesisBytes []byte) ([]byte, *MigrationReport, error) {
+	var fields interface{}
+	if err := json.Unmarshal(genesisBytes, &fields); err != nil {
+		return nil, nil, fmt.Errorf("Failed to read genesis file: %v", err)
+	}
+	report := &MigrationReport{
+		Deprecated: unknownFields(fields, reflect.TypeOf(genesis.GenesisDoc{}), ""),
+	}
+	var genDoc *genesis.GenesisDoc
+	var err error
+	wire.ReadJSONPtr(&genDoc, genesisBytes, &err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read genesis file: %v", err)
+	}
+	if genDoc.GenesisTime.IsZero() {
+		genDoc.GenesisTime = genesis.DefaultGenesisTime
+		report.Filled = append(report.Filled, "genesis_time")
+	}
+	if genDoc.Params == nil {
+		genDoc.Params = new(genesis.GenesisParams)
+	}
+	if genDoc.Params.GasLimit == 0 {
+		genDoc.Params.GasLimit = genesis.DefaultGasLimit
+		report.Filled = append(report.Filled, "params.gas_limit")
+	}
+	if genDoc.Params.Slashing == nil {
+		slashingParams := genesis.DefaultSlashingParams
+		genDoc.Params.Slashing = &slashingParams
+		report.Filled = append(report.Filled, "params.slashing")
+	}
+
+	buf, indented, n := new(bytes.Buffer), new(bytes.Buffer), new(int)
+	wire.WriteJSON(genDoc, buf, n, &err)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Indent(indented, buf.Bytes(), "", "\t"); err != nil {
+		return nil, nil, err
+	}
+	return indented.Bytes(), report, nil
+}
+
+// The dotted paths of the fields of value, decoded from JSON, that the type t
+// it is read into does not have, with any indices of arrays left out
+func unknownFields(value interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	unknown := make(map[string]bool)
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok || t == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = t.Field(i).Name
+			}
+			fields[name] = t.Field(i).Type
+		}
+		for name, fieldValue := range object {
+			fieldType, ok := fields[name]
+			if !ok {
+				unknown[joinKey(path, name)] = true
+				continue
+			}
+			for _, field := range unknownFields(fieldValue, fieldType, joinKey(path, name)) {
+				unknown[field] = true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for _, element := range array {
+			for _, field := range unknownFields(element, t.Elem(), path) {
+				unknown[field] = true
+			}
+		}
+	default:
+		return nil
+	}
+	fields := make([]string, 0, len(unknown))
+	for field := range unknown {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	bs, err := GetConfigurationFileBytes("my_chain", "my_node", "", "", false,
+		"[]", "")
+	require.NoError(t, err)
+	old := strings.Replace(string(bs), "[tendermint]",
+		"[tendermint]\ndropped_key = \"some value\"", 1)
+	old = strings.Replace(old, "[tendermint.configuration]",
+		"[tendermint.configuration]\ntimeout_commit = 100", 1)
+	old = strings.Replace(old, "[burrowmint.mempool]", "[burrowmint.old_mempool]", 1)
+
+	migrated, report, err := MigrateConfig([]byte(old))
+	require.NoError(t, err)
+	assert.Contains(t, report.Deprecated, "tendermint.dropped_key")
+	assert.Contains(t, report.Filled, "burrowmint.mempool.priority")
+	assert.NotContains(t, report.Deprecated,
+		"tendermint.configuration.timeout_commit")
+
+	conf, err := ReadViperConfig(migrated)
+	require.NoError(t, err)
+	assert.Equal(t, "my_chain", conf.GetString("chain.assert_chain_id"))
+	assert.Equal(t, 100, conf.GetInt("tendermint.configuration.timeout_commit"))
+	assert.False(t, conf.IsSet("tendermint.dropped_key"))
+	assert.True(t, conf.IsSet("burrowmint.mempool.priority"))
+	assert.True(t, conf.IsSet("logging"))
+
+	// Migrating is idempotent
+	again, report, err := MigrateConfig(migrated)
+	require.NoError(t, err)
+	assert.Empty(t, report.Deprecated)
+	assert.Empty(t, report.Filled)
+	assert.Equal(t, string(migrated), string(again))
+}
+
+func TestTOMLValue(t *testing.T) {
+	assert.Equal(t, `"a\"b"`, tomlValue("a\"b"))
+	assert.Equal(t, `[1, 2]`, tomlValue([]interface{}{int64(1), int64(2)}))
+	assert.Equal(t, "true", tomlValue(true))
+	assert.Equal(t, `"my key"`, tomlKey("my key"))
+	assert.Equal(t, "my_key", tomlKey("my_key"))
+}
@@ -72,6 +72,12 @@ assert_chain_id = "{{.AssertChainId}}"
 %s = {{.BurrowMinorVersion}}
 # genesis file, relative path is to burrow working directory
 genesis_file = "{{.GenesisRelativePath}}"
+# the working directories of further chains this process runs alongside this
+# one, relative to this working directory. Each holds its own configuration
+# and genesis file, so has its own state, consensus and servers, which must
+# listen on their own ports. The logging of tendermint goes to that of this
+# chain, and the metrics are those of all the chains.
+additional_chains = []
 
 `, majorVersionKey, minorVersionKey)
 
@@ -128,34 +134,149 @@ const sectionServers = `[servers]
 
   [servers.bind]
   address = ""
-  port = 1337
+  port = {{.BindPort}}
 
   [servers.tls]
   tls = false
   cert_path = ""
   key_path = ""
+  # the PEM file of the CAs that client certificates are verified against;
+  # clients with a verified certificate are identified by its common name
+  client_ca_path = ""
+  # how often to check whether the certificate and key files have changed and
+  # reload them, so renewed certificates are served without a restart; "0s"
+  # to never reload
+  reload_interval = "1m"
+
+    # further certificates, each served in place of the one above to clients
+    # asking for a server name (SNI) the certificate is valid for, for example
+    # [servers.tls.certificates.example]
+    # cert_path = "/etc/burrow/tls/example.com.crt"
+    # key_path = "/etc/burrow/tls/example.com.key"
+    [servers.tls.certificates]
 
   [servers.cors]
+  # whether browser pages from other origins may read the responses of the
+  # RPC services, so that dApps can talk to the node directly
   enable = false
+  # "*" for any origin, or origins such as "https://dapp.example.com", or
+  # with a wildcard subdomain such as "https://*.example.com"
   allow_origins = []
   allow_credentials = false
+  # empty to allow GET, POST and DELETE
   allow_methods = []
+  # empty to allow Content-Type, Authorization and traceparent
   allow_headers = []
   expose_headers = []
+  # how many seconds browsers may cache the answer to a preflight request
   max_age = 0
 
   [servers.http]
   json_rpc_endpoint = "/rpc"
+  # the endpoint of the Ethereum compatible (eth_ namespace) JSON-RPC service
+  # for web3 clients and wallets; leave empty to not serve it
+  eth_json_rpc_endpoint = "/eth"
+  # the endpoint of the GraphQL service for querying accounts, blocks,
+  # transactions and events; leave empty to not serve it
+  graphql_endpoint = ""
+  # the endpoint Prometheus scrapes the metrics of the node from; leave empty
+  # to not serve them
+  metrics_endpoint = "/metrics"
+  # the endpoints of the liveness and readiness probes, which respond 200 when
+  # the node is healthy or ready to serve and 503 when not; leave empty to not
+  # serve them
+  health_endpoint = "/healthz"
+  readiness_endpoint = "/readyz"
 
   [servers.websocket]
   endpoint = "/socketrpc"
   max_sessions = 50
   read_buffer_size = 4096
   write_buffer_size = 4096
+  # number of events buffered for each subscription so that slow clients do
+  # not hold up block processing; set to 0 to deliver events synchronously
+  event_buffer_size = 100
+  # what to do with an event when a subscription's buffer is full, one of
+  # "drop" (discard the new event), "latest_wins" (discard the oldest event)
+  # or "block" (wait for the client). Clients may choose their own policy but
+  # may only ask for "block", or a buffer size of 0, when this is "block" or
+  # event_buffer_size is 0, as they can then hold up block processing
+  event_overflow_policy = "latest_wins"
+  # the origins of browser pages that may open websockets, in the same form
+  # as allow_origins of [servers.cors], which are used when this is empty;
+  # with neither any page may. Clients that are not browsers send no origin,
+  # and pages served by the node itself may always connect
+  allowed_origins = []
+
+  [servers.tracing]
+  # where to send the spans that trace RPC requests and the execution of the
+  # txs they send, in the Zipkin v2 JSON format that Zipkin and Jaeger accept,
+  # for example "http://localhost:9411/api/v2/spans"; leave empty to not trace
+  zipkin_endpoint = ""
+  service_name = "burrow"
+
+  [servers.health]
+  # the node is not ready while it is catching up, which is taken to be while
+  # its latest block is older than max_block_age, or while it has fewer than
+  # min_peers peers
+  max_block_age = "1m"
+  min_peers = 0
+
+  [servers.auth]
+  # whether to identify clients by their bearer token (an "Authorization:
+  # Bearer <token>" header) or client certificate and only let them call the
+  # RPC methods their roles allow; when disabled anyone may call any method
+  enable = false
+  # the roles of clients that present no credentials
+  anonymous_roles = ["read"]
+
+    # roles are patterns of the names of the methods they allow, matching the
+    # JSON-RPC methods, the eth_ methods, "graphql" for GraphQL queries and
+    # the routes of [servers.tendermint] as tendermint.<route>; the REST routes
+    # are authorized as their equivalent JSON-RPC methods
+    [servers.auth.roles]
+    read = ["burrow.get*", "burrow.list*", "burrow.is*", "burrow.call",
+      "burrow.callCode", "burrow.traceTx", "burrow.event*", "burrow.stream*",
+      "eth_chainId", "eth_blockNumber", "eth_get*", "eth_call", "graphql",
+      "tendermint.status", "tendermint.net_info", "tendermint.genesis",
+      "tendermint.chain_id", "tendermint.blockchain", "tendermint.list_*",
+      "tendermint.call*", "tendermint.dump_*", "tendermint.*subscribe",
+      "tendermint.get_[^p]*"]
+    transact = ["burrow.broadcastTx*", "eth_sendRawTransaction",
+      "tendermint.broadcast_tx"]
+    # includes the unsafe methods that sign with private keys sent to the node
+    admin = ["*"]
+
+    # clients that may identify themselves, for example
+    # [servers.auth.identities.deployer]
+    # token = "<a long random secret>"
+    # client_cert_name = "deployer.example.com"
+    # roles = ["read", "transact"]
+    [servers.auth.identities]
+
+  [servers.rate_limit]
+  # limits of each client, which is each identity when authentication is
+  # enabled and otherwise each IP address; 0 means no limit. Clients over a
+  # limit get a 429 response, or the JSON-RPC error code -32005 for messages
+  # and subscriptions over websockets
+  # the sustained rate of HTTP requests and websocket messages
+  requests_per_second = 0
+  # how many requests may be made at once above that rate; 0 to allow a
+  # second's worth
+  burst = 0
+  # websocket connections held at once
+  max_connections = 0
+  # event subscriptions and streams held at once over websockets
+  max_subscriptions = 0
+  # take the IP of clients from the X-Forwarded-For or X-Real-IP headers set by
+  # a reverse proxy; only enable behind a proxy that sets them
+  trust_proxy_headers = false
 
 	[servers.tendermint]
-	# Multiple listeners can be separated with a comma
-	rpc_local_address = "0.0.0.0:46657"
+	# Multiple listeners can be separated with a comma. Its routes include the
+	# unsafe and private ones, so listen on other than a local address only
+	# with [servers.auth] enabled
+	rpc_local_address = "{{.TendermintRPCAddress}}"
 	endpoint = "/websocket"
 
   `
@@ -188,6 +309,12 @@ const sectionTendermint = `
 # out and directly managed by monax-keys
 # This file needs to be in the root directory
 private_validator_file = "priv_validator.json"
+# When the node restarts with blocks in its block store that the application
+# has not executed, they are replayed before consensus starts. Replay reads
+# replay_read_ahead blocks ahead of the block executing, and only saves the
+# application state every replay_save_interval blocks.
+replay_read_ahead = 64
+replay_save_interval = 100
 
   # Tendermint requires additional configuration parameters.
   # burrow's tendermint consensus module will load [tendermint.configuration]
@@ -204,12 +331,19 @@ private_validator_file = "priv_validator.json"
   # NOTE: Tendermint has reported potential issues with fast_sync enabled.
   # The recommended setting is for keeping it disabled.
   fast_sync = {{.FastSync}}
+  {{- if .InstantBlocks}}
+  # a single node development chain proposes at once and waits only briefly
+  # between blocks, so transactions are committed almost as soon as they are
+  # broadcast
+  timeout_propose = 100
+  timeout_commit = 100
+  {{- end}}
   # database backend to use for Tendermint. Supported "leveldb" and "memdb".
-  db_backend = "leveldb"
+  db_backend = "{{.DBBackend}}"
   # logging level. Supported "error" < "warn" < "notice" < "info" < "debug"
   log_level = "info"
   # node local address
-  node_laddr = "0.0.0.0:46656"
+  node_laddr = "{{.NodeAddress}}"
   # rpc local address
 	# NOTE: value is ignored when run in-process as RPC is
 	# handled by [servers.tendermint]
@@ -284,11 +418,121 @@ const sectionBurrowMint = `
 
 [burrowmint]
 # Database backend to use for BurrowMint state database.
-# Supported "leveldb" and "memdb".
-db_backend = "leveldb"
+# Supported "leveldb" (or "goleveldb"), "badger", "boltdb", and "memdb".
+# badger writes state fastest, boltdb never needs compacting or recovering
+# after a crash, and memdb keeps nothing once the node stops so suits tests.
+# A chain's state stays in the backend it was started with.
+db_backend = "{{.DBBackend}}"
 # tendermint host address needs to correspond to tendermints configuration
 # of the rpc local address
-tendermint_host = "0.0.0.0:46657"
+tendermint_host = "{{.TendermintHost}}"
+# Go plugins (.so files) of native contracts to register with the virtual
+# machine. Each must export Precompiles, a []vm.Precompile. Every node of the
+# chain must load the same plugins.
+precompile_plugins = []
+# Files of event signatures, such as Transfer(address,address,uint256), to
+# decode the logs streamed by the events RPC as alongside the events of the
+# ABIs registered on chain. Each may be a JSON array of signatures, an export
+# of 4byte.directory's event signatures, or text with one signature a line.
+event_signatures = []
+# The number of the most recent CallTxs to keep EVM traces of for TraceTx,
+# 0 disables tracing. Traces are kept in memory and are lost on restart.
+trace_txs = 0
+# How long the EVM may run a call simulated for an RPC query, such as "10s",
+# when that is shorter than the timeout of [burrowmint.call_pool], with no
+# limit when "0s". It is never applied to the txs of blocks, which could then
+# fail on some nodes and not others: the gas ceiling max_tx_gas of the genesis
+# params is what stops every node running the call of a CallTx at the same
+# point. CheckTx does not run calls, so it only checks their gas limit.
+tx_timeout = "0s"
+
+[burrowmint.pruning]
+# Every version of the state is kept on disk unless pruning is enabled by
+# setting keep_recent. Pruning deletes old versions in the background.
+# The number of most recent versions of the state to keep, 0 keeps all.
+keep_recent = 0
+# Also keep the versions at heights that are a multiple of keep_every, for
+# example to take snapshots or serve proofs from, 0 keeps none.
+keep_every = 0
+# The number of blocks between pruning runs.
+interval = 10
+
+[burrowmint.read_cache]
+# Cache the accounts and storage slots most recently read from the state, for
+# the execution of txs and RPC queries. The hits and misses of the cache are
+# exported as burrow_state_cache_lookups_total.
+# The number of accounts to cache, 0 caches none.
+accounts = 10000
+# The number of storage slots to cache, 0 caches none.
+storage = 100000
+
+[burrowmint.mempool]
+# Limit the gas the txs waiting in the mempool may be given in all, and once it
+# is full give the places of txs to those of a higher priority, evicting the
+# rest when they are rechecked after the next block. Tendermint reaps the txs
+# for a block in the order they arrived, and those beyond the max_block_gas of
+# the genesis params fail without being charged, so must be sent again.
+# The gas of the mempool, such as a few blocks' worth, no limit when 0.
+max_gas = 0
+# How txs are prioritised once the mempool is full: "fifo" keeps the places of
+# the txs that arrived first, "gas_price" gives them to txs paying the highest
+# priority fee for each unit of gas, and "whitelist" to the txs of the accounts
+# in whitelist.
+priority = "fifo"
+# The addresses, in hex, of the accounts whose txs come first by "whitelist".
+whitelist = []
+
+[burrowmint.call_pool]
+# Simulate the calls made by RPC queries (call and call_code) a few at a time
+# against a copy of the committed state, so they cannot hold up the execution
+# of blocks. The calls simulated are exported as burrow_simulated_calls_total.
+# The most calls simulated at once, the number of CPUs when 0.
+concurrency = 0
+# The gas each call is given, the chain's gas limit when 0.
+gas_limit = 0
+# How long a call may queue and run before it is stopped, such as "5s", with no
+# limit when "0s".
+timeout = "5s"
+
+[burrowmint.write_buffer]
+# Buffer the writes to the state database of each block and write them in one
+# batch sorted by key once the block is committed, rather than key by key.
+enabled = true
+# Write each block's batch in the background while the next block executes.
+pipeline = true
+# When to sync the batches written to disk: "always", "never" (leaving it to
+# the database backend and operating system), or every sync_interval batches
+# with "interval". Should the node stop before a block's batch is written or
+# synced the block is replayed from the block store on restart.
+sync = "interval"
+sync_interval = 10
+
+[burrowmint.snapshots]
+# Snapshots of the state let new nodes sync state from this node rather than
+# replaying every block. They are stored alongside the state and taken in the
+# background. When pruning, interval should be a multiple of keep_every.
+# Take a snapshot at every height that is a multiple of interval, 0 takes none.
+interval = 0
+# The number of most recent snapshots to keep, 0 keeps all.
+keep_recent = 2
+
+[burrowmint.private]
+# Files each holding the key, in hex, of a private group this node belongs to.
+# The node decrypts and executes the PrivateTxs sent to these groups against a
+# private state it keeps for each group, in a database of its own, while for
+# other groups it only records the hash of their payloads. Keep these files,
+# and this list, private. A node must be in a group from the start of the
+# chain, as it cannot catch up on payloads it has not executed.
+key_files = []
+
+[burrowmint.confidential]
+# The address of the key, held by the monax-keys server at keys_server, that
+# the blobs of the Confidential native contract are sealed under. Every node
+# given the same key seals and opens the same blobs, and opens a blob only for
+# the accounts its owner has granted. Leave key_address empty for a node that
+# does not seal or open blobs.
+keys_server = "http://localhost:4767"
+key_address = ""
 
 `
 
@@ -0,0 +1,206 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tendermint
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	dbm "github.com/tendermint/go-db"
+	"github.com/tendermint/tendermint/blockchain"
+	tendermint_types "github.com/tendermint/tendermint/types"
+
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	config "github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	manager_types "github.com/hyperledger/burrow/manager/types"
+)
+
+const (
+	defaultReplayReadAhead    = 64
+	defaultReplaySaveInterval = 100
+	// How often the progress of a replay is logged
+	replayProgressInterval = 10 * time.Second
+)
+
+type ReplayOptions struct {
+	// How many blocks to read from the block store and decode ahead of the
+	// block being executed
+	ReadAhead int
+	// Save state every SaveInterval blocks rather than every block
+	SaveInterval int
+}
+
+// Reads the replay options of the [tendermint] section of the configuration,
+// which default when they are not set
+func loadReplayOptions(moduleConfig *config.ModuleConfig) ReplayOptions {
+	options := ReplayOptions{
+		ReadAhead:    defaultReplayReadAhead,
+		SaveInterval: defaultReplaySaveInterval,
+	}
+	if moduleConfig.Config.IsSet("replay_read_ahead") {
+		options.ReadAhead = moduleConfig.Config.GetInt("replay_read_ahead")
+	}
+	if moduleConfig.Config.IsSet("replay_save_interval") {
+		options.SaveInterval = moduleConfig.Config.GetInt("replay_save_interval")
+	}
+	return options
+}
+
+// Replays the blocks of the Tendermint block store that application has not
+// executed through it, before the Tendermint node is started and opens the
+// block store itself
+func replayStoredBlocks(tmintConfig *TendermintConfig,
+	application manager_types.ReplayApplication, options ReplayOptions,
+	logger logging_types.InfoTraceLogger) error {
+	blockStoreDB := dbm.NewDB("blockstore", tmintConfig.GetString("db_backend"),
+		tmintConfig.GetString("db_dir"))
+	defer blockStoreDB.Close()
+	blockStore := blockchain.NewBlockStore(blockStoreDB)
+	return ReplayBlocks(&tendermintBlockStore{blockStore}, application, options,
+		logger)
+}
+
+// Executes the blocks of blockStore after the last block application
+// committed, reading and decoding blocks ahead of the one executing and saving
+// state only every options.SaveInterval blocks. Each block is checked to have
+// been proposed with the app hash of the block before it as replayed, and the
+// progress of the replay is logged as it goes. State is saved when the last
+// block has been replayed or replaying fails, and replaying stops at the block
+// after which application halts, if it does.
+func ReplayBlocks(blockStore blockchain_types.BlockStore,
+	application manager_types.ReplayApplication, options ReplayOptions,
+	logger logging_types.InfoTraceLogger) error {
+	from := application.LastBlockHeight() + 1
+	to := blockStore.Height()
+	if from > to {
+		return nil
+	}
+	logging.InfoMsg(logger, "Replaying blocks",
+		"from_height", from,
+		"to_height", to)
+	application.SetBlockStore(blockStore)
+	application.SetSaveInterval(options.SaveInterval)
+	defer func() {
+		application.SetSaveInterval(1)
+		// The block store may be closed once replayed, the node gives the
+		// application its own before executing blocks
+		application.SetBlockStore(nil)
+	}()
+
+	readAhead := options.ReadAhead
+	if readAhead < 1 {
+		readAhead = 1
+	}
+	blocks := make(chan *tendermint_types.Block, readAhead)
+	stop := make(chan struct{})
+	defer func() {
+		// Wait for the reader to stop so the block store can be closed
+		close(stop)
+		for range blocks {
+		}
+	}()
+	go func() {
+		defer close(blocks)
+		for height := from; height <= to; height++ {
+			block := blockStore.Block(height)
+			select {
+			case blocks <- block:
+			case <-stop:
+				return
+			}
+			if block == nil {
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	lastProgress := start
+	var appHash []byte
+	height := from
+	for block := range blocks {
+		if block == nil {
+			return fmt.Errorf("Block %v is missing from the block store", height)
+		}
+		if appHash != nil && !bytes.Equal(block.AppHash, appHash) {
+			return fmt.Errorf("Block %v was proposed with app hash %X but "+
+				"replaying block %v gave %X", height, block.AppHash, height-1,
+				appHash)
+		}
+		var err error
+		appHash, err = replayBlock(application, block)
+		if err != nil {
+			return err
+		}
+		if halting, ok := application.(manager_types.HaltingApplication); ok {
+			select {
+			case <-halting.Halted():
+				logging.InfoMsg(logger, "Stopped replaying blocks at halt",
+					"height", height)
+				return nil
+			default:
+			}
+		}
+		if now := time.Now(); now.Sub(lastProgress) >= replayProgressInterval || height == to {
+			lastProgress = now
+			replayed := height - from + 1
+			rate := float64(replayed) / now.Sub(start).Seconds()
+			logging.InfoMsg(logger, "Replayed blocks",
+				"height", height,
+				"to_height", to,
+				"blocks_per_second", fmt.Sprintf("%.1f", rate),
+				"remaining", time.Duration(float64(to-height)/rate)*time.Second)
+		}
+		height++
+	}
+	return nil
+}
+
+// Executes block as Tendermint does, returning the app hash it commits
+func replayBlock(application manager_types.Application,
+	block *tendermint_types.Block) ([]byte, error) {
+	application.BeginBlock(block.Hash(), tendermint_types.TM2PB.Header(block.Header))
+	// Txs that fail are in blocks all the same
+	for _, tx := range block.Data.Txs {
+		application.DeliverTx(tx)
+	}
+	application.EndBlock(uint64(block.Height))
+	result := application.Commit()
+	if result.IsErr() {
+		return nil, fmt.Errorf("Could not commit block %v: %v", block.Height,
+			result.Log)
+	}
+	return result.Data, nil
+}
+
+// Adapts the Tendermint block store to a BlockStore
+type tendermintBlockStore struct {
+	blockStore *blockchain.BlockStore
+}
+
+func (tbs *tendermintBlockStore) Height() int {
+	return tbs.blockStore.Height()
+}
+
+func (tbs *tendermintBlockStore) BlockMeta(height int) *tendermint_types.BlockMeta {
+	return tbs.blockStore.LoadBlockMeta(height)
+}
+
+func (tbs *tendermintBlockStore) Block(height int) *tendermint_types.Block {
+	return tbs.blockStore.LoadBlock(height)
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tendermint
+
+import (
+	"testing"
+
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	consensus_types "github.com/hyperledger/burrow/consensus/types"
+	"github.com/hyperledger/burrow/logging/loggers"
+	abci "github.com/tendermint/abci/types"
+	tendermint_types "github.com/tendermint/tendermint/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Stores blocks whose app hash is the height of the block before
+type testBlockStore []*tendermint_types.Block
+
+func newTestBlockStore(height int) testBlockStore {
+	var blocks testBlockStore
+	for h := 1; h <= height; h++ {
+		blocks = append(blocks, &tendermint_types.Block{
+			Header: &tendermint_types.Header{Height: h, AppHash: []byte{byte(h - 1)}},
+			Data:   &tendermint_types.Data{Txs: tendermint_types.Txs{[]byte{byte(h)}}},
+		})
+	}
+	return blocks
+}
+
+func (blocks testBlockStore) Height() int {
+	return len(blocks)
+}
+
+func (blocks testBlockStore) BlockMeta(height int) *tendermint_types.BlockMeta {
+	return nil
+}
+
+func (blocks testBlockStore) Block(height int) *tendermint_types.Block {
+	return blocks[height-1]
+}
+
+// Commits the height of the last block as its app hash
+type testApplication struct {
+	height        int
+	txs           []byte
+	saveIntervals []int
+}
+
+func (app *testApplication) Info() abci.ResponseInfo                { return abci.ResponseInfo{} }
+func (app *testApplication) SetOption(key, value string) string     { return "" }
+func (app *testApplication) CheckTx(tx []byte) abci.Result          { return abci.OK }
+func (app *testApplication) InitChain(validators []*abci.Validator) {}
+func (app *testApplication) BeginBlock(hash []byte, header *abci.Header) {
+	app.height = int(header.Height)
+}
+func (app *testApplication) EndBlock(height uint64) abci.ResponseEndBlock {
+	return abci.ResponseEndBlock{}
+}
+func (app *testApplication) Query(reqQuery abci.RequestQuery) abci.ResponseQuery {
+	return abci.ResponseQuery{}
+}
+func (app *testApplication) CompatibleConsensus(consensusEngine consensus_types.ConsensusEngine) bool {
+	return true
+}
+func (app *testApplication) LastBlockHeight() int                                 { return app.height }
+func (app *testApplication) SetBlockStore(blockStore blockchain_types.BlockStore) {}
+
+func (app *testApplication) DeliverTx(tx []byte) abci.Result {
+	app.txs = append(app.txs, tx...)
+	return abci.OK
+}
+
+func (app *testApplication) Commit() abci.Result {
+	return abci.NewResultOK([]byte{byte(app.height)}, "")
+}
+
+func (app *testApplication) SetSaveInterval(interval int) {
+	app.saveIntervals = append(app.saveIntervals, interval)
+}
+
+func TestReplayBlocks(t *testing.T) {
+	logger := loggers.NewNoopInfoTraceLogger()
+	app := &testApplication{height: 2}
+	options := ReplayOptions{ReadAhead: 2, SaveInterval: 10}
+	assert.NoError(t, ReplayBlocks(newTestBlockStore(7), app, options, logger))
+	assert.Equal(t, 7, app.height)
+	assert.Equal(t, []byte{3, 4, 5, 6, 7}, app.txs)
+	// State is saved every block again once replayed
+	assert.Equal(t, []int{10, 1}, app.saveIntervals)
+
+	// Nothing to replay
+	assert.NoError(t, ReplayBlocks(newTestBlockStore(7), app, options, logger))
+	assert.Equal(t, []int{10, 1}, app.saveIntervals)
+
+	// A block proposed with a different app hash stops the replay
+	app = &testApplication{}
+	blocks := newTestBlockStore(5)
+	blocks[3].AppHash = []byte{0xFF}
+	assert.Error(t, ReplayBlocks(blocks, app, options, logger))
+	assert.Equal(t, 3, app.height)
+	assert.Equal(t, []int{10, 1}, app.saveIntervals)
+}
@@ -15,6 +15,7 @@
 package tendermint
 
 import (
+	"bytes"
 	"fmt"
 	"path"
 	"strings"
@@ -37,7 +38,9 @@ import (
 	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
 	consensus_types "github.com/hyperledger/burrow/consensus/types"
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
 	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/metrics"
 	"github.com/hyperledger/burrow/txs"
 	"github.com/tendermint/go-wire"
 )
@@ -46,9 +49,13 @@ type Tendermint struct {
 	tmintNode   *node.Node
 	tmintConfig *TendermintConfig
 	chainId     string
+	application manager_types.Application
 	logger      logging_types.InfoTraceLogger
 }
 
+var mempoolTxs = metrics.NewGaugeFunc("burrow_mempool_txs",
+	"The txs in the mempool waiting to be included in a block")
+
 // Compiler checks to ensure Tendermint successfully implements
 // burrow/definitions Consensus and Blockchain
 var _ consensus_types.ConsensusEngine = (*Tendermint)(nil)
@@ -57,6 +64,7 @@ var _ blockchain_types.Blockchain = (*Tendermint)(nil)
 func NewTendermint(moduleConfig *config.ModuleConfig,
 	application manager_types.Application,
 	logger logging_types.InfoTraceLogger) (*Tendermint, error) {
+	logger = logging.WithSubsystem(logger, structure.ConsensusSubsystem)
 	// loading the module has ensured the working and data directory
 	// for tendermint have been created, but the config files needs
 	// to be written in tendermint's root directory.
@@ -116,8 +124,23 @@ func NewTendermint(moduleConfig *config.ModuleConfig,
 		tmintConfig.Set("rpc_laddr", "")
 	}
 
+	// Replay the blocks stored but not executed before Tendermint takes the
+	// block store
+	if replayApplication, ok := application.(manager_types.ReplayApplication); ok {
+		err := replayStoredBlocks(tmintConfig, replayApplication,
+			loadReplayOptions(moduleConfig), logger)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to replay blocks: %v", err)
+		}
+	}
+
 	newNode := node.NewNode(tmintConfig, privateValidator,
 		proxy.NewLocalClientCreator(application))
+	// The application reads the commits of past blocks as it executes blocks,
+	// so it takes the block store before the node starts executing any
+	if replayApplication, ok := application.(manager_types.ReplayApplication); ok {
+		replayApplication.SetBlockStore(&tendermintBlockStore{newNode.BlockStore()})
+	}
 
 	// TODO: [ben] delay starting the node to a different function, to hand
 	// control over events to Core
@@ -144,10 +167,15 @@ func NewTendermint(moduleConfig *config.ModuleConfig,
 			"seeds", seeds)
 	}
 
+	mempoolTxs.SetFunc(func() float64 {
+		return float64(newNode.MempoolReactor().Mempool.Size())
+	})
+
 	return &Tendermint{
 		tmintNode:   newNode,
 		tmintConfig: tmintConfig,
 		chainId:     chainId,
+		application: application,
 		logger:      logger,
 	}, nil
 }
@@ -187,10 +215,15 @@ func (tendermint *Tendermint) Peers() []*consensus_types.Peer {
 	p2pPeers := tendermint.tmintNode.Switch().Peers().List()
 	peers := make([]*consensus_types.Peer, 0)
 	for _, peer := range p2pPeers {
-		peers = append(peers, &consensus_types.Peer{
+		consensusPeer := &consensus_types.Peer{
 			NodeInfo:   peer.NodeInfo,
 			IsOutbound: peer.IsOutbound(),
-		})
+		}
+		// Peers still being added have no state yet
+		if peerState, ok := peer.Data.Get(tendermint_types.PeerStateKey).(*tendermint_consensus.PeerState); ok {
+			consensusPeer.RoundState = consensus_types.FromPeerRoundState(peerState.GetRoundState())
+		}
+		peers = append(peers, consensusPeer)
 	}
 	return peers
 }
@@ -243,14 +276,77 @@ func (tendermint *Tendermint) ListUnconfirmedTxs(
 	return transactions, nil
 }
 
+func (tendermint *Tendermint) RemoveUnconfirmedTx(txHash []byte) bool {
+	return tendermint.removeUnconfirmedTxs(func(tx txs.Tx) bool {
+		return bytes.Equal(txs.TxHash(tendermint.chainId, tx), txHash)
+	}) > 0
+}
+
+func (tendermint *Tendermint) FlushUnconfirmedTxs() int {
+	return tendermint.removeUnconfirmedTxs(func(tx txs.Tx) bool {
+		return true
+	})
+}
+
+// Removes the txs in the mempool that remove returns true for, returning how
+// many it removed. They stay in the cache of the mempool, so the same txs are
+// not let in again until they have left it.
+func (tendermint *Tendermint) removeUnconfirmedTxs(remove func(tx txs.Tx) bool) int {
+	mempool := tendermint.tmintNode.MempoolReactor().Mempool
+	var removed tendermint_types.Txs
+	var txHashes [][]byte
+	for _, txBytes := range mempool.Reap(-1) {
+		tx, err := txs.DecodeTx(txBytes)
+		if err != nil || !remove(tx) {
+			continue
+		}
+		removed = append(removed, txBytes)
+		txHashes = append(txHashes, txs.TxHash(tendermint.chainId, tx))
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+	mempool.Lock()
+	defer mempool.Unlock()
+	if application, ok := tendermint.application.(manager_types.MempoolApplication); ok {
+		application.RemoveMempoolTxs(txHashes)
+	}
+	// Removing a tx can leave later txs of its sender with sequence numbers
+	// that are out of order, so the rest are rechecked against the committed
+	// state by updating the mempool as if the txs removed were in a block
+	mempool.Update(tendermint.Height(), removed)
+	logging.InfoMsg(tendermint.logger, "Removed txs from mempool",
+		"txs", len(removed))
+	return len(removed)
+}
+
 func (tendermint *Tendermint) ListValidators() []consensus_types.Validator {
 	return consensus_types.FromTendermintValidators(tendermint.tmintNode.
 		ConsensusState().Validators.Validators)
 }
 
 func (tendermint *Tendermint) ConsensusState() *consensus_types.ConsensusState {
-	return consensus_types.FromRoundState(tendermint.tmintNode.ConsensusState().
-		GetRoundState())
+	roundState := tendermint.tmintNode.ConsensusState().GetRoundState()
+	consensusState := consensus_types.FromRoundState(roundState)
+	consensusState.ValidatorSigning = tendermint.validatorSigning(roundState.Validators.Validators)
+	return consensusState
+}
+
+// Reads the commits of the blocks in the signing window to see which of
+// validators signed them
+func (tendermint *Tendermint) validatorSigning(validators []*tendermint_types.Validator) []*consensus_types.ValidatorSigning {
+	blockStore := tendermint.tmintNode.BlockStore()
+	height := blockStore.Height()
+	commits := make([]*tendermint_types.Commit, 0, consensus_types.SigningWindow)
+	if height > 0 {
+		// The commit of the latest block is only stored as seen by this node
+		// until the next block includes it
+		commits = append(commits, blockStore.LoadSeenCommit(height))
+	}
+	for h := height - 1; h > 0 && len(commits) < consensus_types.SigningWindow; h-- {
+		commits = append(commits, blockStore.LoadBlockCommit(h))
+	}
+	return consensus_types.ValidatorSigningFromCommits(validators, height, commits)
 }
 
 func (tendermint *Tendermint) PeerConsensusStates() map[string]string {
@@ -43,6 +43,12 @@ type ConsensusEngine interface {
 	// List pending transactions in the mempool, passing 0 for maxTxs gets an
 	// unbounded number of transactions
 	ListUnconfirmedTxs(maxTxs int) ([]txs.Tx, error)
+	// Removes the transaction with txHash from the mempool of this node, without
+	// it being broadcast to other nodes, returning whether it was there
+	RemoveUnconfirmedTx(txHash []byte) bool
+	// Removes every transaction from the mempool of this node, returning how
+	// many there were
+	FlushUnconfirmedTxs() int
 	ListValidators() []Validator
 	ConsensusState() *ConsensusState
 	// TODO: Consider creating a real type for PeerRoundState, but at the looks
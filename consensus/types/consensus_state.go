@@ -30,6 +30,8 @@ type ConsensusState struct {
 	CommitTime time.Time                  `json:"commit_time"`
 	Validators []Validator                `json:"validators"`
 	Proposal   *tendermint_types.Proposal `json:"proposal"`
+	// How the current validators have signed the most recent blocks
+	ValidatorSigning []*ValidatorSigning `json:"validator_signing,omitempty"`
 }
 
 func FromRoundState(rs *tendermint_consensus.RoundState) *ConsensusState {
@@ -14,9 +14,36 @@
 
 package types
 
-import "github.com/tendermint/go-p2p"
+import (
+	"time"
+
+	"github.com/tendermint/go-p2p"
+	tendermint_consensus "github.com/tendermint/tendermint/consensus"
+)
 
 type Peer struct {
 	NodeInfo   *p2p.NodeInfo `json:"node_info"`
 	IsOutbound bool          `json:"is_outbound"`
+	// Where the peer is in consensus, as far as we have heard from it
+	RoundState *PeerRoundState `json:"round_state,omitempty"`
+}
+
+type PeerRoundState struct {
+	Height          int       `json:"height"`
+	Round           int       `json:"round"`
+	Step            uint8     `json:"step"`
+	StartTime       time.Time `json:"start_time"`
+	Proposal        bool      `json:"proposal"`
+	LastCommitRound int       `json:"last_commit_round"`
+}
+
+func FromPeerRoundState(prs *tendermint_consensus.PeerRoundState) *PeerRoundState {
+	return &PeerRoundState{
+		Height:          prs.Height,
+		Round:           prs.Round,
+		Step:            uint8(prs.Step),
+		StartTime:       prs.StartTime,
+		Proposal:        prs.Proposal,
+		LastCommitRound: prs.LastCommitRound,
+	}
 }
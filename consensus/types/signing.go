@@ -0,0 +1,82 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+
+	tendermint_types "github.com/tendermint/tendermint/types"
+)
+
+// The number of most recent blocks whose commits the signing of validators is
+// counted over
+const SigningWindow = 100
+
+// Whether a validator has been signing the blocks it should
+type ValidatorSigning struct {
+	Address     []byte `json:"address"`
+	VotingPower int64  `json:"voting_power"`
+	// Whether the validator's precommit is in the commit of the latest block
+	SignedLastBlock bool `json:"signed_last_block"`
+	// The greatest height within the window the validator signed, or 0
+	LastSignedHeight int `json:"last_signed_height"`
+	// The number of blocks within the window the validator did not sign
+	MissedBlocks int `json:"missed_blocks"`
+	// The number of blocks in the window
+	WindowBlocks int `json:"window_blocks"`
+}
+
+// Works out the signing of validators from commits, the commit of lastHeight
+// first and then of each height before it. Commits that could not be loaded may
+// be nil and are left out of the window.
+func ValidatorSigningFromCommits(validators []*tendermint_types.Validator,
+	lastHeight int, commits []*tendermint_types.Commit) []*ValidatorSigning {
+	signings := make([]*ValidatorSigning, len(validators))
+	for i, validator := range validators {
+		signings[i] = &ValidatorSigning{
+			Address:     validator.Address,
+			VotingPower: validator.VotingPower,
+		}
+	}
+	for i, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		height := lastHeight - i
+		for _, signing := range signings {
+			signing.WindowBlocks++
+			if signed(commit, signing.Address) {
+				if signing.LastSignedHeight < height {
+					signing.LastSignedHeight = height
+				}
+				if i == 0 {
+					signing.SignedLastBlock = true
+				}
+			} else {
+				signing.MissedBlocks++
+			}
+		}
+	}
+	return signings
+}
+
+func signed(commit *tendermint_types.Commit, address []byte) bool {
+	for _, precommit := range commit.Precommits {
+		if precommit != nil && bytes.Equal(precommit.ValidatorAddress, address) {
+			return true
+		}
+	}
+	return false
+}
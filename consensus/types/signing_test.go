@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tendermint_types "github.com/tendermint/tendermint/types"
+)
+
+func TestValidatorSigningFromCommits(t *testing.T) {
+	a, b := []byte{1}, []byte{2}
+	validators := []*tendermint_types.Validator{
+		{Address: a, VotingPower: 10},
+		{Address: b, VotingPower: 5},
+	}
+	commit := func(addresses ...[]byte) *tendermint_types.Commit {
+		// Absent validators leave nil precommits
+		commit := &tendermint_types.Commit{Precommits: []*tendermint_types.Vote{nil}}
+		for _, address := range addresses {
+			commit.Precommits = append(commit.Precommits,
+				&tendermint_types.Vote{ValidatorAddress: address})
+		}
+		return commit
+	}
+	signings := ValidatorSigningFromCommits(validators, 10,
+		[]*tendermint_types.Commit{commit(a), commit(a, b), nil, commit(a)})
+	assert.Equal(t, []*ValidatorSigning{
+		{Address: a, VotingPower: 10, SignedLastBlock: true, LastSignedHeight: 10,
+			MissedBlocks: 0, WindowBlocks: 3},
+		{Address: b, VotingPower: 5, SignedLastBlock: false, LastSignedHeight: 9,
+			MissedBlocks: 2, WindowBlocks: 3},
+	}, signings)
+}
@@ -107,10 +107,25 @@ func LoadServerConfig(chainId string, rootConfig *viper.Viper) (*server.ServerCo
 }
 
 func LoadLoggingConfigFromDo(do *definitions.Do) (*lconfig.LoggingConfig, error) {
-	if !do.Config.IsSet("logging") {
+	return loadLoggingConfig(do.Config)
+}
+
+// Reads the logging config afresh from configFile, so that it can be reloaded
+// without disturbing the rest of the configuration of a running node
+func LoadLoggingConfigFromFile(configFile string) (*lconfig.LoggingConfig, error) {
+	conf := viper.New()
+	conf.SetConfigFile(configFile)
+	if err := conf.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return loadLoggingConfig(conf)
+}
+
+func loadLoggingConfig(conf *viper.Viper) (*lconfig.LoggingConfig, error) {
+	if !conf.IsSet("logging") {
 		return nil, nil
 	}
-	loggingConfigMap := do.Config.GetStringMap("logging")
+	loggingConfigMap := conf.GetStringMap("logging")
 	return lconfig.LoggingConfigFromMap(loggingConfigMap)
 }
 
@@ -25,14 +25,23 @@ import (
 	"github.com/hyperledger/burrow/definitions"
 	"github.com/hyperledger/burrow/event"
 	"github.com/hyperledger/burrow/manager"
+	manager_types "github.com/hyperledger/burrow/manager/types"
+	"github.com/hyperledger/burrow/metrics"
 	// rpc_v0 is carried over from burrowv0.11 and before on port 1337
 	rpc_v0 "github.com/hyperledger/burrow/rpc/v0"
+	// rpc_eth serves web3 clients on the same port under its own endpoint
+	rpc_eth "github.com/hyperledger/burrow/rpc/eth"
+	// rpc_graphql serves GraphQL queries on the same port under its own endpoint
+	rpc_graphql "github.com/hyperledger/burrow/rpc/graphql"
+	// rpc_health serves liveness and readiness probes on the same port
+	rpc_health "github.com/hyperledger/burrow/rpc/health"
 	// rpc_tendermint is carried over from burrowv0.11 and before on port 46657
 
 	"github.com/hyperledger/burrow/logging"
 	logging_types "github.com/hyperledger/burrow/logging/types"
 	rpc_tendermint "github.com/hyperledger/burrow/rpc/tendermint/core"
 	"github.com/hyperledger/burrow/server"
+	"github.com/hyperledger/burrow/tracing"
 )
 
 // Core is the high-level structure
@@ -93,16 +102,31 @@ func (core *Core) NewGatewayV0(config *server.ServerConfig) (*server.ServeProces
 	error) {
 	codec := &rpc_v0.TCodec{}
 	eventSubscriptions := event.NewEventSubscriptions(core.pipe.Events())
+	eventBufferConfig, err := event.NewBufferConfig(
+		int(config.WebSocket.EventBufferSize), config.WebSocket.EventOverflowPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load gateway: %v", err)
+	}
 	// The services.
-	tmwss := rpc_v0.NewBurrowWsService(codec, core.pipe)
+	tmwss := rpc_v0.NewBurrowWsService(codec, core.pipe, eventBufferConfig)
 	tmjs := rpc_v0.NewBurrowJsonService(codec, core.pipe, eventSubscriptions)
 	// The servers.
 	jsonServer := rpc_v0.NewJsonRpcServer(tmjs)
 	restServer := rpc_v0.NewRestServer(codec, core.pipe, eventSubscriptions)
+	ethServer := rpc_eth.NewEthJsonRpcServer(rpc_eth.NewEthService(core.pipe))
+	graphQLServer := rpc_graphql.NewGraphQLServer(rpc_graphql.NewGraphQLService(core.pipe))
+	metricsServer := server.NewMetricsServer(metrics.DefaultRegistry)
+	healthServer := rpc_health.NewHealthServer(rpc_health.NewHealthService(core.pipe,
+		config.Health.MaxBlockAge, config.Health.MinPeers))
+	if config.Tracing.ZipkinEndpoint != "" {
+		tracing.DefaultTracer.SetExporter(tracing.NewZipkinExporter(
+			config.Tracing.ZipkinEndpoint, config.Tracing.ServiceName))
+	}
 	wsServer := server.NewWebSocketServer(config.WebSocket.MaxWebSocketSessions,
 		tmwss, core.logger)
 	// Create a server process.
-	proc, err := server.NewServeProcess(config, core.logger, jsonServer, restServer, wsServer)
+	proc, err := server.NewServeProcess(config, core.logger, jsonServer, restServer, wsServer,
+		ethServer, graphQLServer, metricsServer, healthServer)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to load gateway: %v", err)
 	}
@@ -123,3 +147,12 @@ func (core *Core) NewGatewayTendermint(config *server.ServerConfig) (
 func (core *Core) Stop() bool {
 	return core.pipe.GetConsensusEngine().Stop()
 }
+
+// Closed once the application has halted, as it does for an upgrade of the
+// chain, or nil if it never halts
+func (core *Core) Halted() <-chan struct{} {
+	if halting, ok := core.pipe.GetApplication().(manager_types.HaltingApplication); ok {
+		return halting.Halted()
+	}
+	return nil
+}
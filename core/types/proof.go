@@ -0,0 +1,104 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+
+	account "github.com/hyperledger/burrow/account"
+
+	"github.com/tendermint/go-merkle"
+)
+
+type (
+	// Proves that a key and value are held in one of the trees of state
+	// committed to by AppHash. TreeProof is a go-wire encoded go-merkle
+	// IAVLProof of the key and value against TreeRoot, and Aunts proves the
+	// entry for TreeName and TreeRoot in the simple merkle map hashed to give
+	// AppHash, in which it is entry Index of Total.
+	StateProof struct {
+		AppHash   []byte   `json:"app_hash"`
+		TreeName  string   `json:"tree_name"`
+		TreeRoot  []byte   `json:"tree_root"`
+		TreeProof []byte   `json:"tree_proof"`
+		Index     int      `json:"index"`
+		Total     int      `json:"total"`
+		Aunts     [][]byte `json:"aunts"`
+	}
+
+	// An account with a proof of it against the AppHash of the state at
+	// Height. The AppHash for a height is found in the header of the next
+	// block.
+	AccountWithProof struct {
+		Height  int              `json:"height"`
+		Account *account.Account `json:"account"`
+		Proof   *StateProof      `json:"proof"`
+	}
+
+	// A storage item with its account, for the storage root, and a proof of
+	// each. StorageProof is a go-wire encoded go-merkle IAVLProof of the item
+	// against the account's StorageRoot.
+	StorageItemWithProof struct {
+		Height       int              `json:"height"`
+		StorageItem  StorageItem      `json:"storage_item"`
+		StorageProof []byte           `json:"storage_proof"`
+		Account      *account.Account `json:"account"`
+		AccountProof *StateProof      `json:"account_proof"`
+	}
+)
+
+// Checks that the proof holds for key and value, and that it is against
+// appHash
+func (proof *StateProof) Verify(key, value, appHash []byte) bool {
+	if proof == nil || !bytes.Equal(proof.AppHash, appHash) {
+		return false
+	}
+	if !verifyTreeProof(proof.TreeProof, key, value, proof.TreeRoot) {
+		return false
+	}
+	leaf := merkle.KVPair{Key: proof.TreeName, Value: treeRoot(proof.TreeRoot)}
+	simpleProof := &merkle.SimpleProof{Aunts: proof.Aunts}
+	return simpleProof.Verify(proof.Index, proof.Total, leaf.Hash(), appHash)
+}
+
+// Checks that the proof of the account holds against appHash. The account is
+// stored against its address, encoded with account.EncodeAccount.
+func (accountWithProof *AccountWithProof) Verify(appHash []byte) bool {
+	acc := accountWithProof.Account
+	return acc != nil &&
+		accountWithProof.Proof.Verify(acc.Address, account.EncodeAccount(acc), appHash)
+}
+
+// Checks that the proofs of the storage item and its account hold against
+// appHash.
+func (itemWithProof *StorageItemWithProof) Verify(appHash []byte) bool {
+	acc := itemWithProof.Account
+	return acc != nil &&
+		itemWithProof.AccountProof.Verify(acc.Address, account.EncodeAccount(acc), appHash) &&
+		verifyTreeProof(itemWithProof.StorageProof, itemWithProof.StorageItem.Key,
+			itemWithProof.StorageItem.Value, acc.StorageRoot)
+}
+
+func verifyTreeProof(proofBytes, key, value, root []byte) bool {
+	proof, err := merkle.ReadProof(proofBytes)
+	return err == nil && proof.Verify(key, value, root)
+}
+
+// The root hash of a tree as it is hashed into the AppHash
+type treeRoot []byte
+
+func (root treeRoot) Hash() []byte {
+	return root
+}
@@ -19,10 +19,13 @@ package types
 // for a discussion around the proper defintion of the needed types.
 
 import (
+	"time"
+
 	// NodeInfo (drop this!)
 	"github.com/tendermint/tendermint/types"
 
 	account "github.com/hyperledger/burrow/account"
+	. "github.com/hyperledger/burrow/word256"
 )
 
 type (
@@ -45,6 +48,24 @@ type (
 		StorageItems []StorageItem `json:"storage_items"`
 	}
 
+	// A page of accounts in order of address, as committed at Height, with
+	// NextPageToken as for StoragePage
+	AccountPage struct {
+		Height        int                `json:"height"`
+		Accounts      []*account.Account `json:"accounts"`
+		NextPageToken []byte             `json:"next_page_token"`
+	}
+
+	// A page of account storage in order of key, as committed at Height.
+	// NextPageToken is empty on the last page, otherwise it is passed to get
+	// the page that follows, which is read at the same height.
+	StoragePage struct {
+		Height        int           `json:"height"`
+		StorageRoot   []byte        `json:"storage_root"`
+		StorageItems  []StorageItem `json:"storage_items"`
+		NextPageToken []byte        `json:"next_page_token"`
+	}
+
 	// *********************************** Blockchain ***********************************
 
 	// BlockchainInfo
@@ -69,6 +90,21 @@ type (
 		ChainId string `json:"chain_id"`
 	}
 
+	// GetBaseFee
+	BaseFee struct {
+		BaseFee int64 `json:"base_fee"`
+	}
+
+	// RemoveUnconfirmedTx
+	MempoolRemoval struct {
+		Removed bool `json:"removed"`
+	}
+
+	// FlushUnconfirmedTxs
+	MempoolFlush struct {
+		Removed int `json:"removed"`
+	}
+
 	// GetBlocks
 	Blocks struct {
 		MinHeight  int                `json:"min_height"`
@@ -97,9 +133,15 @@ type (
 		Result bool `json:"result"`
 	}
 
+	// ReloadLogging
+	LoggingReload struct {
+		Result bool `json:"result"`
+	}
+
 	// EventPoll
 	PollResponse struct {
 		Events []interface{} `json:"events"`
+		Cursor uint64        `json:"cursor"`
 	}
 
 	// *********************************** Network ***********************************
@@ -126,8 +168,110 @@ type (
 	Call struct {
 		Return  string `json:"return"`
 		GasUsed int64  `json:"gas_used"`
+		// Only when a trace was asked for
+		Trace *Trace `json:"trace"`
 		// TODO ...
 	}
+
+	// Changes to an account applied before a simulated call, leaving what is
+	// not given as it is in state. An account that does not exist is created.
+	AccountOverride struct {
+		Address  []byte `json:"address"`
+		Balance  *int64 `json:"balance"`
+		Sequence *int   `json:"sequence"`
+		// Replaces the code of the account when not empty
+		Code []byte `json:"code"`
+		// Sets these storage slots, leaving the others as they are
+		Storage []*StorageItem `json:"storage"`
+	}
+
+	// The steps of EVM execution
+	Trace struct {
+		Steps []*TraceStep `json:"steps"`
+		// Whether steps beyond the maximum were left out
+		Truncated bool `json:"truncated"`
+	}
+
+	// An op executed by the EVM at call depth Depth with Gas left before it
+	// ran, the stack as it was then, and the memory and storage it wrote
+	TraceStep struct {
+		Depth   int                  `json:"depth"`
+		PC      int64                `json:"pc"`
+		Op      string               `json:"op"`
+		Gas     int64                `json:"gas"`
+		Stack   [][]byte             `json:"stack"`
+		Memory  []*TraceMemoryWrite  `json:"memory"`
+		Storage []*TraceStorageWrite `json:"storage"`
+		// The error the call ended with if this was its last step
+		Error string `json:"error"`
+	}
+
+	TraceMemoryWrite struct {
+		Offset int64  `json:"offset"`
+		Data   []byte `json:"data"`
+	}
+
+	TraceStorageWrite struct {
+		Address []byte `json:"address"`
+		Key     []byte `json:"key"`
+		Value   []byte `json:"value"`
+	}
+
+	// The outcome of a CallTx in a committed block
+	TxReceipt struct {
+		TxHash []byte `json:"tx_hash"`
+		Height int    `json:"height"`
+		// False if the call failed with Exception
+		Success   bool   `json:"success"`
+		Exception string `json:"exception"`
+		GasUsed   int64  `json:"gas_used"`
+		// Only when the tx created a contract that was successfully deployed
+		ContractAddress []byte `json:"contract_address"`
+		// The logs emitted by the tx, or none if it failed
+		Logs []*ReceiptLog `json:"logs"`
+	}
+
+	// A committed tx with an input from an account
+	SenderTx struct {
+		TxHash []byte `json:"tx_hash"`
+		Height int    `json:"height"`
+		// The position of the tx in its block
+		Index int `json:"index"`
+		// The sequence number of the account's input
+		Sequence int `json:"sequence"`
+	}
+
+	// The most recent committed txs of an account, newest first
+	SenderTxs struct {
+		Address []byte `json:"address"`
+		// The sequence number of the account's last committed tx, which its next
+		// tx must exceed by one
+		Sequence int         `json:"sequence"`
+		Txs      []*SenderTx `json:"txs"`
+	}
+
+	ReceiptLog struct {
+		Address Word256   `json:"address"`
+		Topics  []Word256 `json:"topics"`
+		Data    []byte    `json:"data"`
+		// Only when the log was decoded against an ABI
+		Event *DecodedEvent `json:"event"`
+	}
+
+	// A log decoded as an event of a contract's ABI
+	DecodedEvent struct {
+		Name string        `json:"name"`
+		Args []*DecodedArg `json:"args"`
+	}
+
+	// An input of an event with its value formatted as a string: numbers are in
+	// decimal and addresses and bytes in hex
+	DecodedArg struct {
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		Indexed bool   `json:"indexed"`
+		Value   string `json:"value"`
+	}
 )
 
 //------------------------------------------------------------------------------
@@ -146,3 +290,117 @@ type (
 		Names       []*NameRegEntry `json:"names"`
 	}
 )
+
+//------------------------------------------------------------------------------
+// ABI registry
+
+// The ABI registered for the contract at Address by Owner, the account that
+// created it. Either ABI holds the JSON ABI or ABIHash holds the sha3 of a JSON
+// ABI kept off-chain; with neither the entry only records the creator.
+type ABIEntry struct {
+	Address []byte `json:"address"`
+	Owner   []byte `json:"owner"` // address that created the contract
+	ABI     string `json:"abi"`
+	ABIHash []byte `json:"abi_hash"`
+}
+
+//------------------------------------------------------------------------------
+// Node registry
+
+// The network identity of its node that the validator with Address last
+// registered, with the IdentifyTx of Sequence in the block at Height
+type NodeRegEntry struct {
+	Address            []byte `json:"address"`
+	NodeID             []byte `json:"node_id"`
+	NetAddress         string `json:"net_address"`
+	TLSCertFingerprint []byte `json:"tls_cert_fingerprint"`
+	Moniker            string `json:"moniker"`
+	Sequence           int    `json:"sequence"`
+	Height             int    `json:"height"`
+}
+
+//------------------------------------------------------------------------------
+// Relay
+
+// Another chain whose headers are relayed, with the validators trusted to sign
+// its next header. Registrar is the account that relayed its first header, at
+// RegisteredAt on this chain.
+type RelayedChain struct {
+	ChainID      string             `json:"chain_id"`
+	Validators   []*types.Validator `json:"validators"`
+	Height       int                `json:"height"`
+	Registrar    []byte             `json:"registrar"`
+	RegisteredAt int                `json:"registered_at"`
+}
+
+// A header of another chain verified against the commit of its validators
+// and relayed at RelayedAt on this chain. AppHash is the root of the state
+// of the chain after the block before Height.
+type RelayedHeader struct {
+	ChainID        string    `json:"chain_id"`
+	Height         int       `json:"height"`
+	Time           time.Time `json:"time"`
+	BlockHash      []byte    `json:"block_hash"`
+	AppHash        []byte    `json:"app_hash"`
+	ValidatorsHash []byte    `json:"validators_hash"`
+	RelayedAt      int       `json:"relayed_at"`
+}
+
+//------------------------------------------------------------------------------
+// Bridge
+
+// A log of a contract whose event is designated for the bridge to Ethereum, as
+// emitted in the block at Height
+type BridgeEvent struct {
+	Height  int64     `json:"height"`
+	Address []byte    `json:"address"`
+	Topics  []Word256 `json:"topics"`
+	Data    []byte    `json:"data"`
+}
+
+// The signature of the ID of an attestation by the Ethereum key a validator
+// has bound for the bridge, as r || s || v with v 27 or 28 as ecrecover takes
+// it, with the power of the validator when it attested
+type AttestationSignature struct {
+	Validator  []byte `json:"validator"`
+	EthAddress []byte `json:"eth_address"`
+	Signature  []byte `json:"signature"`
+	Power      int64  `json:"power"`
+}
+
+// The attestation by validators that Event was emitted, which is complete once
+// validators with more than two thirds of TotalPower have signed it, at
+// CompletedAt. A contract on Ethereum accepts it with the signatures of
+// validators it holds the Ethereum addresses of.
+type Attestation struct {
+	ID          []byte                  `json:"id"`
+	Event       BridgeEvent             `json:"event"`
+	Signatures  []*AttestationSignature `json:"signatures"`
+	Power       int64                   `json:"power"`
+	TotalPower  int64                   `json:"total_power"`
+	CompletedAt int                     `json:"completed_at"`
+}
+
+// An event of the contract at Address designated for the bridge, by the topic
+// of its signature, from DesignatedAt until RevokedAt, which is 0 until it is
+// revoked
+type DesignatedEvent struct {
+	Address      []byte  `json:"address"`
+	Topic        Word256 `json:"topic"`
+	DesignatedAt int     `json:"designated_at"`
+	RevokedAt    int     `json:"revoked_at"`
+}
+
+//------------------------------------------------------------------------------
+// Private transactions
+
+// The record every node keeps of the PrivateTx with TxHash that Sender sent to
+// Group in the block at Height, whose encrypted payload hashes to PayloadHash.
+// Only the nodes of the group execute the payload, against their private state.
+type PrivateTxRecord struct {
+	TxHash      []byte `json:"tx_hash"`
+	Group       []byte `json:"group"`
+	Sender      []byte `json:"sender"`
+	PayloadHash []byte `json:"payload_hash"`
+	Height      int    `json:"height"`
+}
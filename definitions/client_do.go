@@ -22,14 +22,28 @@ type ClientDo struct {
 
 	// Following parameters are global flags for burrow-client tx
 	SignAddrFlag string
+	// Client certificate and key, and CA certificates, for connecting to
+	// monax-keys over https
+	SignCertFlag string
+	SignKeyFlag  string
+	SignCAFlag   string
 	NodeAddrFlag string
 	PubkeyFlag   string
 	AddrFlag     string
 	ChainidFlag  string
 
+	// A plain key file or keystore to sign with instead of monax-keys, and
+	// the file holding the passphrase of a keystore
+	KeyFileFlag        string
+	PassphraseFileFlag string
+
 	// signFlag      bool // TODO: remove; unsafe signing without monax-keys
 	BroadcastFlag bool
 	WaitFlag      bool
+	// Never contact the node, writing the signed tx to stdout instead
+	OfflineFlag bool
+	// Write the tx to stdout without signing it, to be signed elsewhere
+	UnsignedFlag bool
 
 	// Following parameters are vary for different Transaction subcommands
 	// some of these are strings rather than flags because the `core`
@@ -44,6 +58,68 @@ type ClientDo struct {
 	GasFlag      string
 	UnbondtoFlag string
 	HeightFlag   string
+	ABIFileFlag  string
+	ABIHashFlag  string
+	// The key a validator's key is rotated to
+	NewPubkeyFlag string
+
+	// The signatories of a multisig account, as a threshold and comma
+	// separated public keys
+	ThresholdFlag   string
+	SignatoriesFlag string
+
+	// Genesis file of the chain whose validators are trusted by verify
+	GenesisFileFlag string
+
+	// The description of a proposal and a JSON file of the txs it batches,
+	// or the hash of the proposal voted for
+	DescriptionFlag  string
+	TxsFileFlag      string
+	ProposalHashFlag string
+
+	// The network identity a validator registers for its node
+	NodeIDFlag             string
+	NetAddressFlag         string
+	TLSCertFingerprintFlag string
+	MonikerFlag            string
+
+	// A JSON file of the chain parameters a GovTx changes, and the comma
+	// separated addresses of the validators it unjails
+	ParamsFileFlag string
+	UnjailFlag     string
+
+	// The file holding the hex secp256k1 secret key a validator signs
+	// attestations for the bridge to Ethereum with, and the hex ID of an
+	// attestation
+	BridgeKeyFileFlag string
+	AttestationIDFlag string
+
+	// The file holding the hex key of the private group a PrivateTx is
+	// encrypted to
+	GroupKeyFileFlag string
+
+	// The hex address of the owner of a blob in the confidential store and the
+	// hex key it is put at
+	ConfidentialOwnerFlag string
+	ConfidentialKeyFlag   string
+
+	// The Solidity source and solc settings the contract at an address is
+	// verified against
+	ContractAddrFlag string
+	SourceFileFlag   string
+	ContractFlag     string
+	SolcFlag         string
+	SolcVersionFlag  string
+	OptimizeFlag     bool
+	OptimizeRunsFlag int
+	LibrariesFlag    []string
+	// The file the ABI of a verified contract is written to, for burrow-client
+	// tx abi to register
+	ABIOutputFlag string
+
+	// Whether burrow-client call runs a function as a simulated call rather
+	// than a CallTx, which it does anyway for constant functions
+	SimulateFlag bool
 }
 
 func NewClientDo() *ClientDo {
@@ -52,14 +128,22 @@ func NewClientDo() *ClientDo {
 	clientDo.Verbose = false
 
 	clientDo.SignAddrFlag = ""
+	clientDo.SignCertFlag = ""
+	clientDo.SignKeyFlag = ""
+	clientDo.SignCAFlag = ""
 	clientDo.NodeAddrFlag = ""
 	clientDo.PubkeyFlag = ""
 	clientDo.AddrFlag = ""
 	clientDo.ChainidFlag = ""
 
+	clientDo.KeyFileFlag = ""
+	clientDo.PassphraseFileFlag = ""
+
 	// clientDo.signFlag = false
 	clientDo.BroadcastFlag = false
 	clientDo.WaitFlag = false
+	clientDo.OfflineFlag = false
+	clientDo.UnsignedFlag = false
 
 	clientDo.AmtFlag = ""
 	clientDo.NonceFlag = ""
@@ -71,6 +155,14 @@ func NewClientDo() *ClientDo {
 	clientDo.GasFlag = ""
 	clientDo.UnbondtoFlag = ""
 	clientDo.HeightFlag = ""
+	clientDo.ABIFileFlag = ""
+	clientDo.ABIHashFlag = ""
+	clientDo.NewPubkeyFlag = ""
+
+	clientDo.ThresholdFlag = ""
+	clientDo.SignatoriesFlag = ""
+
+	clientDo.GenesisFileFlag = ""
 
 	return clientDo
 }
@@ -46,7 +46,12 @@ type Do struct {
 	// Zip          bool
 	// Tarball      bool
 	DisableRpc bool
-	Config     *viper.Viper
+	// Dev runs a single node development chain with pre-funded accounts
+	// derived from DevMnemonic
+	Dev         bool
+	DevAccounts int
+	DevMnemonic string
+	Config      *viper.Viper
 	// Accounts     []*Account
 	// Result       string
 }
@@ -23,6 +23,8 @@ package definitions
 // these interfaces into an Engine, Communicator, NameReg, Permissions (suggestion)
 
 import (
+	"io"
+
 	account "github.com/hyperledger/burrow/account"
 	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
 	consensus_types "github.com/hyperledger/burrow/consensus/types"
@@ -38,6 +40,9 @@ type Pipe interface {
 	Accounts() Accounts
 	Blockchain() blockchain_types.Blockchain
 	Events() event.EventEmitter
+	Logs() Logs
+	Receipts() Receipts
+	TxIndex() TxIndex
 	NameReg() NameReg
 	Transactor() Transactor
 	// Hash of Genesis state
@@ -57,22 +62,79 @@ type Accounts interface {
 	GenPrivAccount() (*account.PrivAccount, error)
 	GenPrivAccountFromKey(privKey []byte) (*account.PrivAccount, error)
 	Accounts([]*event.FilterData) (*types.AccountList, error)
+	// Get a page of at most pageSize (0 for the default) of the accounts that
+	// match the filters, starting after pageToken, or at the first account
+	// when it is empty
+	ListAccounts(filters []*event.FilterData, pageToken []byte, pageSize int) (*types.AccountPage, error)
 	Account(address []byte) (*account.Account, error)
 	Storage(address []byte) (*types.Storage, error)
 	StorageAt(address, key []byte) (*types.StorageItem, error)
+	// Get the account, its storage or an item of it as committed at height (0
+	// for the latest height), which only past heights whose version of state
+	// has not been pruned can be read at
+	HistoricalAccount(address []byte, height int) (*account.Account, error)
+	HistoricalStorage(address []byte, height int) (*types.Storage, error)
+	HistoricalStorageAt(address, key []byte, height int) (*types.StorageItem, error)
+	// Get the account, or an item of its storage, as committed at height (0 for
+	// the latest height) with a merkle proof of it against the AppHash
+	AccountWithProof(address []byte, height int) (*types.AccountWithProof, error)
+	StorageAtWithProof(address, key []byte, height int) (*types.StorageItemWithProof, error)
+	// Get a page of at most pageSize items (0 for the default) of the storage
+	// of the account, starting after pageToken, or at the first key when it is
+	// empty
+	ListStorage(address, pageToken []byte, pageSize int) (*types.StoragePage, error)
+	// Get the ABI registered for the contract at address
+	ABI(address []byte) (*types.ABIEntry, error)
+	// Write a dump (as burrow dump does) of the state committed at height (0
+	// for the latest height) to w as it is read, so that the state is never
+	// held in memory. Nothing is written if the state at height is unavailable.
+	Dump(height int, w io.Writer) error
 }
 
 type NameReg interface {
 	Entry(key string) (*core_types.NameRegEntry, error)
+	// Get the entry as committed at height (0 for the latest height)
+	HistoricalEntry(key string, height int) (*core_types.NameRegEntry, error)
 	Entries([]*event.FilterData) (*types.ResultListNames, error)
 }
 
+// Logs looks up the EVM logs emitted by committed blocks
+type Logs interface {
+	// Get the logs emitted by address, having topic among their topics, between
+	// minHeight and maxHeight inclusive. One of address and topic may be nil to
+	// match any. A maxHeight of zero means up to the latest block.
+	Logs(address, topic []byte, minHeight, maxHeight int64) ([]txs.EventDataLog, error)
+}
+
+// Receipts looks up the receipts of CallTxs in committed blocks
+type Receipts interface {
+	// Get the receipt of the tx with txHash, decoding its logs against abiJSON,
+	// a contract's JSON ABI, or if it is empty against the ABIs registered for
+	// the contracts that emitted them
+	TxReceipt(txHash []byte, abiJSON string) (*types.TxReceipt, error)
+}
+
+// TxIndex looks up the txs of committed blocks by the accounts that signed them
+type TxIndex interface {
+	// Get up to limit of the most recent txs with an input from address, newest
+	// first, with the sequence number of its last committed tx
+	SenderTxs(address []byte, limit int) (*types.SenderTxs, error)
+}
+
 type Transactor interface {
-	Call(fromAddress, toAddress, data []byte) (*types.Call, error)
-	CallCode(fromAddress, code, data []byte) (*types.Call, error)
+	// Calls record a trace of execution when trace is true
+	Call(fromAddress, toAddress, data []byte, trace bool) (*types.Call, error)
+	CallCode(fromAddress, code, data []byte, trace bool) (*types.Call, error)
+	// Calls toAddress, or runs code if toAddress is empty, against the state as
+	// committed at height (0 for the latest height) with overrides applied
+	CallWithOverrides(fromAddress, toAddress, code, data []byte, height int,
+		overrides []*types.AccountOverride, trace bool) (*types.Call, error)
 	// Send(privKey, toAddress []byte, amount int64) (*types.Receipt, error)
 	// SendAndHold(privKey, toAddress []byte, amount int64) (*types.Receipt, error)
 	BroadcastTx(tx txs.Tx) (*txs.Receipt, error)
+	// Broadcasts signed txs in order, checking before any is broadcast that
+	// they would all get into the mempool
+	BroadcastTxBatch(batch []txs.Tx) ([]*txs.Receipt, error)
 	Transact(privKey, address, data []byte, gasLimit,
 		fee int64) (*txs.Receipt, error)
 	TransactAndHold(privKey, address, data []byte, gasLimit,
@@ -81,5 +143,12 @@ type Transactor interface {
 	SendAndHold(privKey, toAddress []byte, amount int64) (*txs.Receipt, error)
 	TransactNameReg(privKey []byte, name, data string, amount,
 		fee int64) (*txs.Receipt, error)
+	// Extends the name registry entry owned by privKey with the credit of
+	// amount, keeping its data
+	RenewName(privKey []byte, name string, amount, fee int64) (*txs.Receipt, error)
 	SignTx(tx txs.Tx, privAccounts []*account.PrivAccount) (txs.Tx, error)
+	// The least fee txs in the next block must pay before any priority fee
+	BaseFee() int64
+	// The trace of a recent CallTx kept by this node
+	TraceTx(txHash []byte) (*types.Trace, error)
 }
@@ -62,6 +62,34 @@ type TendermintPipe interface {
 	GetName(name string) (*rpc_tm_types.ResultGetName, error)
 	ListNames() (*rpc_tm_types.ResultListNames, error)
 
+	// Node registry
+	GetNode(address []byte) (*rpc_tm_types.ResultGetNode, error)
+	ListNodes() (*rpc_tm_types.ResultListNodes, error)
+
+	// Relayed chains
+	GetRelayedChain(chainID string) (*rpc_tm_types.ResultGetRelayedChain, error)
+	GetRelayedHeader(chainID string, height int) (*rpc_tm_types.ResultGetRelayedHeader, error)
+	ListRelayedChains() (*rpc_tm_types.ResultListRelayedChains, error)
+
+	// Bridge
+	GetAttestation(id []byte) (*rpc_tm_types.ResultGetAttestation, error)
+	ListAttestations(minHeight int64) (*rpc_tm_types.ResultListAttestations, error)
+	ListDesignatedEvents() (*rpc_tm_types.ResultListDesignatedEvents, error)
+
+	// Private transactions
+	GetPrivateTx(txHash []byte, abiJSON string) (*rpc_tm_types.ResultGetPrivateTx, error)
+	GetPrivateAccount(group, address []byte) (*rpc_tm_types.ResultGetAccount, error)
+	GetPrivateStorage(group, address, key []byte) (*rpc_tm_types.ResultGetStorage, error)
+
+	// Confidential store
+	SealConfidential(owner, key, data []byte) (*rpc_tm_types.ResultSealConfidential, error)
+	ReadConfidential(read *txs.ConfidentialRead, pubKey,
+		signature []byte) (*rpc_tm_types.ResultReadConfidential, error)
+
+	// Contracts
+	GetABI(address []byte) (*rpc_tm_types.ResultGetABI, error)
+	GetTxReceipt(txHash []byte, abiJSON string) (*rpc_tm_types.ResultGetTxReceipt, error)
+
 	// Memory pool
 	BroadcastTxAsync(transaction txs.Tx) (*rpc_tm_types.ResultBroadcastTx, error)
 	BroadcastTxSync(transaction txs.Tx) (*rpc_tm_types.ResultBroadcastTx, error)
@@ -0,0 +1,157 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/burrow/txs"
+)
+
+// Determines what a buffered subscription does with an event that arrives
+// while its buffer is full.
+type OverflowPolicy string
+
+const (
+	// Block the publisher until the subscriber has made room in the buffer
+	OverflowBlock OverflowPolicy = "block"
+	// Discard the incoming event
+	OverflowDrop OverflowPolicy = "drop"
+	// Discard the oldest buffered event to make room for the incoming event
+	OverflowLatestWins OverflowPolicy = "latest_wins"
+)
+
+// The largest buffer a client may ask for on a single subscription
+const MaxBufferSize = 10000
+
+type BufferConfig struct {
+	// Number of events held for a subscriber before Policy applies, zero means
+	// the callback is invoked synchronously by the publisher
+	Size   int
+	Policy OverflowPolicy
+}
+
+// Whether a subscriber with this config can hold up the publisher, as its
+// events are delivered synchronously or wait for room in its buffer
+func (bufferConfig BufferConfig) Blocks() bool {
+	return bufferConfig.Size == 0 || bufferConfig.Policy == OverflowBlock
+}
+
+func ParseOverflowPolicy(policy string) (OverflowPolicy, error) {
+	switch OverflowPolicy(policy) {
+	case OverflowBlock, OverflowDrop, OverflowLatestWins:
+		return OverflowPolicy(policy), nil
+	case "":
+		return OverflowLatestWins, nil
+	default:
+		return "", fmt.Errorf("Overflow policy '%s' is not one of '%s', '%s', "+
+			"or '%s'", policy, OverflowBlock, OverflowDrop, OverflowLatestWins)
+	}
+}
+
+func NewBufferConfig(size int, policy string) (BufferConfig, error) {
+	if size < 0 || size > MaxBufferSize {
+		return BufferConfig{}, fmt.Errorf("Event buffer size must be between 0 "+
+			"and %v but was %v", MaxBufferSize, size)
+	}
+	overflowPolicy, err := ParseOverflowPolicy(policy)
+	if err != nil {
+		return BufferConfig{}, err
+	}
+	return BufferConfig{Size: size, Policy: overflowPolicy}, nil
+}
+
+// The BufferConfig of a subscription whose client asks for size and policy,
+// either of which it may omit with a nil size or an empty policy. An omitted
+// size or policy is that of serverConfig. As
+// clients are not trusted to keep up, they may only ask for a config that can
+// hold up the publisher, such as a size of zero for synchronous delivery or
+// the block policy, when the operator has configured serverConfig to.
+func ClientBufferConfig(serverConfig BufferConfig, size *int,
+	policy string) (BufferConfig, error) {
+	clientSize := serverConfig.Size
+	if size != nil {
+		clientSize = *size
+	}
+	if policy == "" {
+		policy = string(serverConfig.Policy)
+	}
+	bufferConfig, err := NewBufferConfig(clientSize, policy)
+	if err != nil {
+		return BufferConfig{}, err
+	}
+	if bufferConfig.Blocks() && !serverConfig.Blocks() {
+		return BufferConfig{}, fmt.Errorf("Event buffer of size %v with overflow "+
+			"policy '%s' may hold up the server, which only allows subscriptions "+
+			"that do not block", bufferConfig.Size, bufferConfig.Policy)
+	}
+	return bufferConfig, nil
+}
+
+// Wraps callback so that the publisher hands events to a buffer rather than
+// calling into the subscriber directly. A goroutine drains the buffer into
+// callback until ctx is done, so ctx should be the same context the
+// subscription is made with. A zero size BufferConfig returns callback as is.
+func BufferedCallback(ctx context.Context, bufferConfig BufferConfig,
+	callback func(txs.EventData)) func(txs.EventData) {
+	if bufferConfig.Size <= 0 {
+		return callback
+	}
+	buffer := make(chan txs.EventData, bufferConfig.Size)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case eventData := <-buffer:
+				callback(eventData)
+			}
+		}
+	}()
+
+	switch bufferConfig.Policy {
+	case OverflowBlock:
+		return func(eventData txs.EventData) {
+			select {
+			case buffer <- eventData:
+			case <-ctx.Done():
+			}
+		}
+	case OverflowLatestWins:
+		return func(eventData txs.EventData) {
+			for {
+				select {
+				case buffer <- eventData:
+					return
+				default:
+					// Make room by discarding the oldest event, the subscriber may
+					// beat us to it, in which case we just try again
+					select {
+					case <-buffer:
+					default:
+					}
+				}
+			}
+		}
+	default:
+		return func(eventData txs.EventData) {
+			select {
+			case buffer <- eventData:
+			default:
+			}
+		}
+	}
+}
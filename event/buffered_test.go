@@ -0,0 +1,136 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/burrow/txs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferedCallbackDrop(t *testing.T) {
+	assert.Equal(t, []txs.EventData{mockEvent(0), mockEvent(1), mockEvent(2)},
+		floodBufferedCallback(t, OverflowDrop))
+}
+
+func TestBufferedCallbackLatestWins(t *testing.T) {
+	assert.Equal(t, []txs.EventData{mockEvent(0), mockEvent(2), mockEvent(3)},
+		floodBufferedCallback(t, OverflowLatestWins))
+}
+
+func TestBufferedCallbackBlock(t *testing.T) {
+	assert.Equal(t, []txs.EventData{mockEvent(0), mockEvent(1), mockEvent(2),
+		mockEvent(3)}, floodBufferedCallback(t, OverflowBlock))
+}
+
+func TestBufferedCallbackUnbuffered(t *testing.T) {
+	var received []txs.EventData
+	callback := BufferedCallback(context.Background(), BufferConfig{},
+		func(eventData txs.EventData) {
+			received = append(received, eventData)
+		})
+	callback(mockEvent(0))
+	assert.Equal(t, []txs.EventData{mockEvent(0)}, received)
+}
+
+func TestNewBufferConfig(t *testing.T) {
+	bufferConfig, err := NewBufferConfig(10, "")
+	assert.NoError(t, err)
+	assert.Equal(t, BufferConfig{Size: 10, Policy: OverflowLatestWins}, bufferConfig)
+	_, err = NewBufferConfig(10, "sometimes")
+	assert.Error(t, err)
+	_, err = NewBufferConfig(MaxBufferSize+1, "block")
+	assert.Error(t, err)
+}
+
+func TestClientBufferConfig(t *testing.T) {
+	size := func(size int) *int {
+		return &size
+	}
+	serverConfig := BufferConfig{Size: 100, Policy: OverflowDrop}
+	bufferConfig, err := ClientBufferConfig(serverConfig, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, BufferConfig{Size: 100, Policy: OverflowDrop}, bufferConfig)
+	bufferConfig, err = ClientBufferConfig(serverConfig, size(10), "latest_wins")
+	assert.NoError(t, err)
+	assert.Equal(t, BufferConfig{Size: 10, Policy: OverflowLatestWins}, bufferConfig)
+	// Clients may not hold up a server that does not block
+	_, err = ClientBufferConfig(serverConfig, nil, "block")
+	assert.Error(t, err)
+	_, err = ClientBufferConfig(serverConfig, size(0), "")
+	assert.Error(t, err)
+
+	serverConfig = BufferConfig{Size: 100, Policy: OverflowBlock}
+	bufferConfig, err = ClientBufferConfig(serverConfig, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, BufferConfig{Size: 100, Policy: OverflowBlock}, bufferConfig)
+	// A size of zero asks for synchronous delivery
+	bufferConfig, err = ClientBufferConfig(serverConfig, size(0), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, bufferConfig.Size)
+	_, err = ClientBufferConfig(serverConfig, size(-1), "")
+	assert.Error(t, err)
+}
+
+func mockEvent(i int) txs.EventData {
+	return mockEventData{eventId: string(rune('0' + i))}
+}
+
+// Publishes 4 events to a buffered callback of size 2 whose subscriber is
+// stalled on the first event and returns the events the subscriber received
+// once it is released
+func floodBufferedCallback(t *testing.T, policy OverflowPolicy) []txs.EventData {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := make(chan struct{})
+	gate := make(chan struct{})
+	receivedCh := make(chan txs.EventData, 4)
+	callback := BufferedCallback(ctx, BufferConfig{Size: 2, Policy: policy},
+		func(eventData txs.EventData) {
+			if eventData == mockEvent(0) {
+				close(started)
+				<-gate
+			}
+			receivedCh <- eventData
+		})
+	callback(mockEvent(0))
+	<-started
+	published := make(chan struct{})
+	go func() {
+		for i := 1; i < 4; i++ {
+			callback(mockEvent(i))
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+		assert.NotEqual(t, OverflowBlock, policy, "publisher should block")
+	case <-time.After(100 * time.Millisecond):
+		assert.Equal(t, OverflowBlock, policy, "publisher should not block")
+	}
+	close(gate)
+	var received []txs.EventData
+	for {
+		select {
+		case eventData := <-receivedCh:
+			received = append(received, eventData)
+		case <-time.After(100 * time.Millisecond):
+			return received
+		}
+	}
+}
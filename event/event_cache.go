@@ -15,6 +15,7 @@
 package event
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -27,34 +28,70 @@ var (
 	reaperThreshold = 10 * time.Second
 )
 
+// The number of events retained by a subscription for clients resuming from a
+// cursor, unless set otherwise with SetHistoryLength
+const DefaultEventHistoryLength = 1000
+
+// Holds the events received by a subscription in a ring buffer. Each event is
+// given a cursor, counting up from 1, so that clients can poll for the events
+// after the last one they saw.
 type EventCache struct {
 	mtx    *sync.Mutex
 	events []interface{}
+	// Number of events currently retained, at most len(events)
+	held int
+	// Cursor of the most recent event, zero if no event has been received
+	cursor uint64
+	// Cursor of the most recent event returned by a poll
+	polled uint64
 	ts     time.Time
 	subId  string
+	cancel context.CancelFunc
 }
 
-func newEventCache() *EventCache {
+func newEventCache(historyLength int) *EventCache {
+	if historyLength <= 0 {
+		historyLength = DefaultEventHistoryLength
+	}
 	return &EventCache{
-		&sync.Mutex{},
-		make([]interface{}, 0),
-		time.Now(),
-		"",
+		mtx:    &sync.Mutex{},
+		events: make([]interface{}, historyLength),
+		ts:     time.Now(),
+		cancel: func() {},
 	}
 }
 
-func (this *EventCache) poll() []interface{} {
+func (this *EventCache) add(evt interface{}) {
 	this.mtx.Lock()
 	defer this.mtx.Unlock()
-	var evts []interface{}
-	if len(this.events) > 0 {
-		evts = this.events
-		this.events = []interface{}{}
-	} else {
-		evts = []interface{}{}
+	this.cursor++
+	this.events[(this.cursor-1)%uint64(len(this.events))] = evt
+	if this.held < len(this.events) {
+		this.held++
 	}
+}
+
+// Returns the retained events with a cursor greater than afterCursor along
+// with the cursor of the most recent event. A nil afterCursor returns the
+// events received since the last poll and one of zero every retained event.
+func (this *EventCache) poll(afterCursor *uint64) ([]interface{}, uint64) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	after := this.polled
+	if afterCursor != nil {
+		after = *afterCursor
+	}
+	first := this.cursor - uint64(this.held) + 1
+	if after >= first {
+		first = after + 1
+	}
+	evts := []interface{}{}
+	for c := first; c <= this.cursor; c++ {
+		evts = append(evts, this.events[(c-1)%uint64(len(this.events))])
+	}
+	this.polled = this.cursor
 	this.ts = time.Now()
-	return evts
+	return evts, this.cursor
 }
 
 // Catches events that callers subscribe to and adds them to an array ready to be polled.
@@ -63,19 +100,31 @@ type EventSubscriptions struct {
 	eventEmitter EventEmitter
 	subs         map[string]*EventCache
 	reap         bool
+	// Events retained per subscription for polling from a cursor
+	historyLength int
 }
 
 func NewEventSubscriptions(eventEmitter EventEmitter) *EventSubscriptions {
 	es := &EventSubscriptions{
-		mtx:          &sync.RWMutex{},
-		eventEmitter: eventEmitter,
-		subs:         make(map[string]*EventCache),
-		reap:         true,
+		mtx:           &sync.RWMutex{},
+		eventEmitter:  eventEmitter,
+		subs:          make(map[string]*EventCache),
+		reap:          true,
+		historyLength: DefaultEventHistoryLength,
 	}
 	go reap(es)
 	return es
 }
 
+// Sets the number of events retained by subsequently added subscriptions. A
+// client polling from a cursor older than this many events will miss the
+// events that have been overwritten.
+func (this *EventSubscriptions) SetHistoryLength(historyLength int) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.historyLength = historyLength
+}
+
 func reap(es *EventSubscriptions) {
 	if !es.reap {
 		return
@@ -87,7 +136,7 @@ func reap(es *EventSubscriptions) {
 		if time.Since(sub.ts) > reaperThreshold {
 			// Seems like Go is ok with this..
 			delete(es.subs, id)
-			es.eventEmitter.Unsubscribe(id)
+			sub.cancel()
 		}
 	}
 	go reap(es)
@@ -98,46 +147,65 @@ func reap(es *EventSubscriptions) {
 // a delay - though a conflict is practically impossible, and if it does
 // happen it's for an insignificant amount of time (the time it takes to
 // carry out EventCache.poll() ).
-func (this *EventSubscriptions) Add(eventId string) (string, error) {
+// The eventId may be a query (see Query) to have only matching events cached.
+// The subscription is removed when ctx is done, when Remove is called, or
+// when it has not been polled for reaperThreshold, whichever comes first.
+func (this *EventSubscriptions) Add(ctx context.Context, eventId string) (string, error) {
 	subId, errSID := GenerateSubId()
 	if errSID != nil {
 		return "", errSID
 	}
-	cache := newEventCache()
-	errC := this.eventEmitter.Subscribe(subId, eventId,
+	this.mtx.RLock()
+	cache := newEventCache(this.historyLength)
+	this.mtx.RUnlock()
+	ctx, cache.cancel = context.WithCancel(ctx)
+	errC := SubscribeQuery(ctx, this.eventEmitter, subId, eventId,
 		func(evt txs.EventData) {
 			cache.mtx.Lock()
 			defer cache.mtx.Unlock()
 			cache.events = append(cache.events, evt)
 		})
 	cache.subId = subId
-	this.mtx.Lock()
-	defer this.mtx.Unlock()
-	this.subs[subId] = cache
 	if errC != nil {
+		cache.cancel()
 		return "", errC
 	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.subs[subId] = cache
+	go func() {
+		<-ctx.Done()
+		this.mtx.Lock()
+		defer this.mtx.Unlock()
+		delete(this.subs, subId)
+	}()
 	return subId, nil
 }
 
-func (this *EventSubscriptions) Poll(subId string) ([]interface{}, error) {
+// Poll returns the events of a subscription with a cursor greater than
+// afterCursor together with the cursor of the most recent event, which the
+// client can pass back to resume from where it left off after a disconnect.
+// A nil afterCursor returns the events received since the last poll, and as
+// cursors count up from 1 one of zero returns every event still retained.
+func (this *EventSubscriptions) Poll(subId string, afterCursor *uint64) ([]interface{}, uint64, error) {
 	this.mtx.RLock()
 	defer this.mtx.RUnlock()
 	sub, ok := this.subs[subId]
 	if !ok {
-		return nil, fmt.Errorf("Subscription not active. ID: " + subId)
+		return nil, 0, fmt.Errorf("Subscription not active. ID: " + subId)
 	}
-	return sub.poll(), nil
+	evts, cursor := sub.poll(afterCursor)
+	return evts, cursor, nil
 }
 
 func (this *EventSubscriptions) Remove(subId string) error {
 	this.mtx.Lock()
 	defer this.mtx.Unlock()
-	// TODO Check this.
-	_, ok := this.subs[subId]
+	sub, ok := this.subs[subId]
 	if !ok {
 		return fmt.Errorf("Subscription not active. ID: " + subId)
 	}
 	delete(this.subs, subId)
+	sub.cancel()
 	return nil
 }
@@ -15,6 +15,7 @@
 package event
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"runtime"
@@ -57,7 +58,8 @@ func newMockEventEmitter() *mockEventEmitter {
 	return &mockEventEmitter{make(map[string]mockSub), &sync.Mutex{}}
 }
 
-func (this *mockEventEmitter) Subscribe(subId, eventId string, callback func(txs.EventData)) error {
+func (this *mockEventEmitter) Subscribe(ctx context.Context, subId, eventId string,
+	callback func(txs.EventData)) error {
 	if _, ok := this.subs[subId]; ok {
 		return nil
 	}
@@ -79,6 +81,7 @@ func (this *mockEventEmitter) Subscribe(subId, eventId string, callback func(txs
 			}
 		}
 	}()
+	UnsubscribeOnDone(ctx, this, subId)
 	return nil
 }
 
@@ -107,7 +110,7 @@ func TestSubReaping(t *testing.T) {
 		for i := 0; i < NUM_SUBS; i++ {
 			time.Sleep(2 * time.Millisecond)
 			go func() {
-				id, err := eSubs.Add("WeirdEvent")
+				id, err := eSubs.Add(context.Background(), "WeirdEvent")
 				if err != nil {
 					doneChan <- err
 					return
@@ -138,6 +141,36 @@ func TestSubReaping(t *testing.T) {
 	t.Logf("Added %d subs that were all automatically reaped.", NUM_SUBS)
 }
 
+// Test that event subscriptions are torn down when their context is cancelled.
+func TestSubContextCancel(t *testing.T) {
+	NUM_SUBS := 100
+	// Keep the reaper out of this.
+	reaperThreshold = 10000 * time.Millisecond
+	reaperTimeout = 10000 * time.Millisecond
+
+	mee := newMockEventEmitter()
+	eSubs := NewEventSubscriptions(mee)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < NUM_SUBS; i++ {
+		_, err := eSubs.Add(ctx, "WeirdEvent")
+		assert.NoError(t, err)
+	}
+	eSubs.mtx.RLock()
+	assert.Len(t, eSubs.subs, NUM_SUBS)
+	eSubs.mtx.RUnlock()
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	mee.mutex.Lock()
+	assert.Len(t, mee.subs, 0)
+	mee.mutex.Unlock()
+	eSubs.mtx.RLock()
+	assert.Len(t, eSubs.subs, 0)
+	eSubs.mtx.RUnlock()
+	t.Logf("Added %d subs that were all closed down by cancelling their context.", NUM_SUBS)
+}
+
 // Test that event subscriptions can be added and removed manually.
 func TestSubManualClose(t *testing.T) {
 	NUM_SUBS := 100
@@ -152,7 +185,7 @@ func TestSubManualClose(t *testing.T) {
 		for i := 0; i < NUM_SUBS; i++ {
 			time.Sleep(2 * time.Millisecond)
 			go func() {
-				id, err := eSubs.Add("WeirdEvent")
+				id, err := eSubs.Add(context.Background(), "WeirdEvent")
 				if err != nil {
 					doneChan <- err
 					return
@@ -200,7 +233,7 @@ func TestSubFlooding(t *testing.T) {
 		for i := 0; i < NUM_SUBS; i++ {
 			time.Sleep(1 * time.Millisecond)
 			go func() {
-				id, err := eSubs.Add("WeirdEvent")
+				id, err := eSubs.Add(context.Background(), "WeirdEvent")
 				if err != nil {
 					doneChan <- err
 					return
@@ -232,3 +265,48 @@ func TestSubFlooding(t *testing.T) {
 	assert.Len(t, eSubs.subs, 0)
 	t.Logf("Added %d subs that all received 1000 events each. They were all closed down by unsubscribing.", NUM_SUBS)
 }
+
+// Test that polling from a cursor returns the retained events after it.
+func TestEventCachePollCursor(t *testing.T) {
+	cursorOf := func(cursor uint64) *uint64 {
+		return &cursor
+	}
+	cache := newEventCache(3)
+	evts, cursor := cache.poll(nil)
+	assert.Len(t, evts, 0)
+	assert.Equal(t, uint64(0), cursor)
+
+	for i := 0; i < 2; i++ {
+		cache.add(mockEvent(i))
+	}
+	evts, cursor = cache.poll(nil)
+	assert.Equal(t, []interface{}{mockEvent(0), mockEvent(1)}, evts)
+	assert.Equal(t, uint64(2), cursor)
+
+	// Nothing new since the last poll
+	evts, cursor = cache.poll(nil)
+	assert.Len(t, evts, 0)
+	assert.Equal(t, uint64(2), cursor)
+
+	// A client that lost the first response replays from the start
+	evts, cursor = cache.poll(cursorOf(0))
+	assert.Equal(t, []interface{}{mockEvent(0), mockEvent(1)}, evts)
+	assert.Equal(t, uint64(2), cursor)
+
+	// A client that missed the last response can resume from its own cursor
+	cache.add(mockEvent(2))
+	evts, cursor = cache.poll(cursorOf(1))
+	assert.Equal(t, []interface{}{mockEvent(1), mockEvent(2)}, evts)
+	assert.Equal(t, uint64(3), cursor)
+
+	// Overwritten events are lost
+	for i := 3; i < 5; i++ {
+		cache.add(mockEvent(i))
+	}
+	evts, cursor = cache.poll(cursorOf(1))
+	assert.Equal(t, []interface{}{mockEvent(2), mockEvent(3), mockEvent(4)}, evts)
+	assert.Equal(t, uint64(5), cursor)
+	evts, cursor = cache.poll(cursorOf(0))
+	assert.Equal(t, []interface{}{mockEvent(2), mockEvent(3), mockEvent(4)}, evts)
+	assert.Equal(t, uint64(5), cursor)
+}
@@ -15,13 +15,16 @@
 package event
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"strings"
 
 	"fmt"
 
+	core_types "github.com/hyperledger/burrow/core/types"
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
 	logging_types "github.com/hyperledger/burrow/logging/types"
 	"github.com/hyperledger/burrow/txs"
 	go_events "github.com/tendermint/go-events"
@@ -37,13 +40,17 @@ import (
 // We are using this as a marker interface for the
 type anyEventData interface{}
 
+// An EventEmitter delivers events to subscribers. A subscription lives until
+// the context passed to Subscribe is done or until Unsubscribe is called with
+// its subId, whichever happens first.
 type EventEmitter interface {
-	Subscribe(subId, event string, callback func(txs.EventData)) error
+	Subscribe(ctx context.Context, subId, event string, callback func(txs.EventData)) error
 	Unsubscribe(subId string) error
 }
 
 func NewEvents(eventSwitch go_events.EventSwitch, logger logging_types.InfoTraceLogger) *events {
-	return &events{eventSwitch: eventSwitch, logger: logging.WithScope(logger, "Events")}
+	return &events{eventSwitch: eventSwitch, logger: logging.WithScope(logging.WithSubsystem(logger,
+		structure.EventsSubsystem), "Events")}
 }
 
 // Provides an EventEmitter that wraps many underlying EventEmitters as a
@@ -60,7 +67,7 @@ type events struct {
 }
 
 // Subscribe to an event.
-func (evts *events) Subscribe(subId, event string,
+func (evts *events) Subscribe(ctx context.Context, subId, event string,
 	callback func(txs.EventData)) error {
 	cb := func(evt go_events.EventData) {
 		eventData, err := mapToOurEventData(evt)
@@ -72,6 +79,7 @@ func (evts *events) Subscribe(subId, event string,
 		callback(eventData)
 	}
 	evts.eventSwitch.AddListenerForEvent(subId, event, cb)
+	UnsubscribeOnDone(ctx, evts, subId)
 	return nil
 }
 
@@ -81,15 +89,53 @@ func (evts *events) Unsubscribe(subId string) error {
 	return nil
 }
 
+// Decodes a log as the event of a contract it is, returning nil if the event
+// is not known
+type LogDecoder func(log txs.EventDataLog) *core_types.DecodedEvent
+
+// Provides an EventEmitter that delivers the events of eventEmitter with the
+// logs among them that decoder knows the event of as EventDataDecodedLogs
+func DecodeLogs(eventEmitter EventEmitter, decoder LogDecoder) *decodingEvents {
+	return &decodingEvents{eventEmitter: eventEmitter, decoder: decoder}
+}
+
+type decodingEvents struct {
+	eventEmitter EventEmitter
+	decoder      LogDecoder
+}
+
+func (decEvents *decodingEvents) Subscribe(ctx context.Context, subId, event string,
+	callback func(txs.EventData)) error {
+	return decEvents.eventEmitter.Subscribe(ctx, subId, event, func(eventData txs.EventData) {
+		if log, ok := eventData.(txs.EventDataLog); ok {
+			if decoded := decEvents.decoder(log); decoded != nil {
+				eventData = txs.EventDataDecodedLog{
+					Address: log.Address,
+					Topics:  log.Topics,
+					Data:    log.Data,
+					Height:  log.Height,
+					Event:   decoded,
+				}
+			}
+		}
+		callback(eventData)
+	})
+}
+
+func (decEvents *decodingEvents) Unsubscribe(subId string) error {
+	return decEvents.eventEmitter.Unsubscribe(subId)
+}
+
 type multiplexedEvents struct {
 	eventEmitters []EventEmitter
 }
 
-// Subscribe to an event.
-func (multiEvents *multiplexedEvents) Subscribe(subId, event string,
+// Subscribe to an event. Each underlying EventEmitter is responsible for
+// tearing down its own part of the subscription when ctx is done.
+func (multiEvents *multiplexedEvents) Subscribe(ctx context.Context, subId, event string,
 	callback func(txs.EventData)) error {
 	for _, eventEmitter := range multiEvents.eventEmitters {
-		err := eventEmitter.Subscribe(subId, event, callback)
+		err := eventEmitter.Subscribe(ctx, subId, event, callback)
 		if err != nil {
 			return err
 		}
@@ -122,14 +168,35 @@ type EventUnsub struct {
 	Result bool `json:"result"`
 }
 
+// GetLogs
+type LogList struct {
+	Logs []txs.EventDataLog `json:"logs"`
+}
+
 // EventPoll
 type PollResponse struct {
 	Events []interface{} `json:"events"`
+	// Cursor of the most recent event, to be passed as after_cursor to resume
+	Cursor uint64 `json:"cursor"`
 }
 
 // **************************************************************************************
 // Helper function
 
+// Unsubscribes subId from eventEmitter once ctx is done. Intended to be called
+// by EventEmitter implementations from Subscribe. A context that can never be
+// cancelled (such as context.Background()) spawns nothing and leaves the
+// subscription to be removed by an explicit call to Unsubscribe.
+func UnsubscribeOnDone(ctx context.Context, eventEmitter EventEmitter, subId string) {
+	if ctx.Done() == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		eventEmitter.Unsubscribe(subId)
+	}()
+}
+
 func GenerateSubId() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
@@ -15,12 +15,15 @@
 package event
 
 import (
+	"context"
 	"testing"
 
 	"sync"
 	"time"
 
+	core_types "github.com/hyperledger/burrow/core/types"
 	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,17 +40,17 @@ func TestMultiplexedEvents(t *testing.T) {
 	mutex2 := &sync.Mutex{}
 	mutex12 := &sync.Mutex{}
 
-	emitter12.Subscribe("Sub12", "Event12", func(eventData txs.EventData) {
+	emitter12.Subscribe(context.Background(), "Sub12", "Event12", func(eventData txs.EventData) {
 		mutex12.Lock()
 		eventData12[eventData] = 1
 		mutex12.Unlock()
 	})
-	emitter1.Subscribe("Sub1", "Event1", func(eventData txs.EventData) {
+	emitter1.Subscribe(context.Background(), "Sub1", "Event1", func(eventData txs.EventData) {
 		mutex1.Lock()
 		eventData1[eventData] = 1
 		mutex1.Unlock()
 	})
-	emitter2.Subscribe("Sub2", "Event2", func(eventData txs.EventData) {
+	emitter2.Subscribe(context.Background(), "Sub2", "Event2", func(eventData txs.EventData) {
 		mutex2.Lock()
 		eventData2[eventData] = 1
 		mutex2.Unlock()
@@ -76,3 +79,44 @@ func TestMultiplexedEvents(t *testing.T) {
 		eventData12)
 
 }
+
+// Delivers its events synchronously to each subscriber
+type sliceEventEmitter []txs.EventData
+
+func (events sliceEventEmitter) Subscribe(ctx context.Context, subId, event string,
+	callback func(txs.EventData)) error {
+	for _, eventData := range events {
+		callback(eventData)
+	}
+	return nil
+}
+
+func (events sliceEventEmitter) Unsubscribe(subId string) error {
+	return nil
+}
+
+func TestDecodeLogs(t *testing.T) {
+	transfer := RightPadWord256([]byte{0xDD, 0xF2})
+	known := txs.EventDataLog{Topics: []Word256{transfer}, Height: 3}
+	unknown := txs.EventDataLog{Topics: []Word256{One256}, Height: 4}
+	call := txs.EventDataCall{Exception: "none"}
+	decoded := &core_types.DecodedEvent{Name: "Transfer"}
+	emitter := DecodeLogs(sliceEventEmitter{known, unknown, call},
+		func(log txs.EventDataLog) *core_types.DecodedEvent {
+			if log.Topics[0] == transfer {
+				return decoded
+			}
+			return nil
+		})
+	var received []txs.EventData
+	err := emitter.Subscribe(context.Background(), "Sub", "Log", func(eventData txs.EventData) {
+		received = append(received, eventData)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []txs.EventData{
+		txs.EventDataDecodedLog{Topics: known.Topics, Height: 3, Event: decoded},
+		unknown,
+		call,
+	}, received)
+	assert.Equal(t, known, received[0].(txs.EventDataDecodedLog).Log())
+}
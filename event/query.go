@@ -0,0 +1,407 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+)
+
+// A Query selects the events delivered to a subscription. Queries take the form
+// of a conjunction of conditions on tags such as:
+//
+//	EventID = 'Log/1A2B...' AND Height > 100 AND Topic0 = '0xDDF2...'
+//
+// Every query must contain exactly one EventID condition using '=' since this
+// determines the event the underlying subscription is made to. The remaining
+// conditions are evaluated against the data of each event. String values are
+// single-quoted and compared case insensitively (a leading 0x on hex values is
+// ignored), numeric values are unquoted integers. A bare event id containing
+// no operators, such as 'NewBlock', is itself a query for every event with
+// that id.
+type Query struct {
+	EventId    string
+	conditions []*condition
+}
+
+type tagKind int
+
+const (
+	stringTag tagKind = iota
+	hexTag
+	numberTag
+)
+
+// The tags that can appear in a query other than EventID. Tags not present in
+// the event data being matched (for example Height on an EventDataTx) cause
+// the condition to fail.
+var queryTags = map[string]tagKind{
+	"height":     numberTag,
+	"round":      numberTag,
+	"value":      numberTag,
+	"gas":        numberTag,
+	"step":       stringTag,
+	"exception":  stringTag,
+	"address":    hexTag,
+	"caller":     hexTag,
+	"callee":     hexTag,
+	"origin":     hexTag,
+	"txid":       hexTag,
+	"txhash":     hexTag,
+	"granter":    hexTag,
+	"function":   stringTag,
+	"event":      stringTag,
+	"permission": stringTag,
+	"role":       stringTag,
+	"name":       stringTag,
+	"owner":      hexTag,
+	"topic0":     hexTag,
+	"topic1":     hexTag,
+	"topic2":     hexTag,
+	"topic3":     hexTag,
+}
+
+const eventIdTag = "eventid"
+
+type condition struct {
+	tag           string
+	op            string
+	stringValue   string
+	numberValue   int64
+	matchesString func(s0, s1 string) bool
+	matchesNumber func(a, b int64) bool
+}
+
+func ParseQuery(query string) (*Query, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("Query is empty")
+	}
+	if !strings.ContainsAny(query, "'=<>!") {
+		return &Query{EventId: query}, nil
+	}
+	tokens, err := tokeniseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	// Conditions are triples of tag, operator, and value separated by AND
+	if len(tokens)%4 != 3 {
+		return nil, fmt.Errorf("Could not parse query '%s' as conditions "+
+			"joined by AND", query)
+	}
+	q := &Query{}
+	eventIdFound := false
+	for i := 0; i < len(tokens); i += 4 {
+		if i > 0 && !strings.EqualFold(tokens[i-1], "AND") {
+			return nil, fmt.Errorf("Expected AND in query but found '%s'",
+				tokens[i-1])
+		}
+		tag, op, value := strings.ToLower(tokens[i]), tokens[i+1], tokens[i+2]
+		if tag == eventIdTag {
+			if op != "=" || !isQuoted(value) {
+				return nil, fmt.Errorf("EventID may only be compared with '=' " +
+					"against a quoted string")
+			}
+			if eventIdFound {
+				return nil, fmt.Errorf("Query may only contain one EventID condition")
+			}
+			eventIdFound = true
+			q.EventId = unquote(value)
+			continue
+		}
+		cond, err := newCondition(tag, op, value)
+		if err != nil {
+			return nil, err
+		}
+		q.conditions = append(q.conditions, cond)
+	}
+	if !eventIdFound {
+		return nil, fmt.Errorf("Query must select an event with an " +
+			"EventID = '...' condition")
+	}
+	return q, nil
+}
+
+// Returns true if eventData satisfies all of the query's conditions. The
+// EventID is not checked since it is the job of the EventEmitter to only
+// deliver events of that id.
+func (q *Query) Matches(eventData txs.EventData) bool {
+	for _, cond := range q.conditions {
+		if !cond.matches(eventData) {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscribes to the events selected by query (see Query), only passing on to
+// callback those events that satisfy its conditions.
+func SubscribeQuery(ctx context.Context, eventEmitter EventEmitter, subId,
+	query string, callback func(txs.EventData)) error {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	if len(q.conditions) == 0 {
+		return eventEmitter.Subscribe(ctx, subId, q.EventId, callback)
+	}
+	return eventEmitter.Subscribe(ctx, subId, q.EventId,
+		func(eventData txs.EventData) {
+			if q.Matches(eventData) {
+				callback(eventData)
+			}
+		})
+}
+
+func newCondition(tag, op, value string) (*condition, error) {
+	kind, ok := queryTags[tag]
+	if !ok {
+		return nil, fmt.Errorf("Tag '%s' is not supported in queries", tag)
+	}
+	// Reuse the filter operators which use '==' for equality
+	filterOp := op
+	if op == "=" {
+		filterOp = "=="
+	}
+	cond := &condition{tag: tag, op: op}
+	var err error
+	switch kind {
+	case numberTag:
+		if isQuoted(value) {
+			return nil, fmt.Errorf("Tag '%s' must be compared with a number "+
+				"but got %s", tag, value)
+		}
+		cond.numberValue, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse number '%s' for tag '%s'",
+				value, tag)
+		}
+		cond.matchesNumber, err = GetRangeFilter(filterOp, tag)
+	default:
+		if !isQuoted(value) {
+			return nil, fmt.Errorf("Tag '%s' must be compared with a quoted "+
+				"string but got %s", tag, value)
+		}
+		cond.stringValue = unquote(value)
+		if kind == hexTag {
+			cond.stringValue = trimHexPrefix(cond.stringValue)
+		}
+		cond.matchesString, err = GetStringFilter(filterOp, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+func (cond *condition) matches(eventData txs.EventData) bool {
+	value, ok := eventTag(eventData, cond.tag)
+	if !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case int64:
+		return cond.matchesNumber != nil && cond.matchesNumber(v, cond.numberValue)
+	case string:
+		return cond.matchesString != nil && cond.matchesString(v, cond.stringValue)
+	}
+	return false
+}
+
+// Gets the value of tag from eventData as either an int64 or a string
+func eventTag(eventData txs.EventData, tag string) (interface{}, bool) {
+	switch ed := eventData.(type) {
+	case txs.EventDataDecodedLog:
+		if tag == "event" {
+			return ed.Event.Name, true
+		}
+		return eventTag(ed.Log(), tag)
+	case txs.EventDataLog:
+		switch tag {
+		case "address":
+			return fmt.Sprintf("%X", ed.Address.Postfix(20)), true
+		case "height":
+			return ed.Height, true
+		case "topic0", "topic1", "topic2", "topic3":
+			i := int(tag[len(tag)-1] - '0')
+			if i < len(ed.Topics) {
+				return fmt.Sprintf("%X", ed.Topics[i].Bytes()), true
+			}
+		}
+	case txs.EventDataCall:
+		switch tag {
+		case "origin":
+			return fmt.Sprintf("%X", ed.Origin), true
+		case "txid":
+			return fmt.Sprintf("%X", ed.TxID), true
+		case "exception":
+			return ed.Exception, true
+		}
+		if ed.CallData != nil {
+			switch tag {
+			case "caller":
+				return fmt.Sprintf("%X", ed.CallData.Caller), true
+			case "callee":
+				return fmt.Sprintf("%X", ed.CallData.Callee), true
+			case "value":
+				return ed.CallData.Value, true
+			case "gas":
+				return ed.CallData.Gas, true
+			}
+		}
+	case txs.EventDataTx:
+		if tag == "exception" {
+			return ed.Exception, true
+		}
+	case txs.EventDataPendingTx:
+		switch tag {
+		case "txhash":
+			return fmt.Sprintf("%X", ed.TxHash), true
+		case "exception":
+			return ed.Exception, true
+		}
+	case txs.EventDataPermission:
+		switch tag {
+		case "height":
+			return ed.Height, true
+		case "txhash":
+			return fmt.Sprintf("%X", ed.TxHash), true
+		case "granter":
+			return fmt.Sprintf("%X", ed.Granter), true
+		case "address":
+			return fmt.Sprintf("%X", ed.Address), true
+		case "function":
+			return ed.Function, true
+		case "permission":
+			if ed.Permission != 0 {
+				return strings.Join(ptypes.PermFlagToStrings(ed.Permission), ","), true
+			}
+		case "role":
+			if ed.Role != "" {
+				return ed.Role, true
+			}
+		}
+	case txs.EventDataNameRegExpiry:
+		switch tag {
+		case "height":
+			return ed.Height, true
+		case "name":
+			return ed.Name, true
+		case "owner":
+			return fmt.Sprintf("%X", ed.Owner), true
+		}
+	case txs.EventDataProposal:
+		switch tag {
+		case "height":
+			return ed.Height, true
+		case "name":
+			return ed.Name, true
+		}
+	case txs.EventDataSlash:
+		switch tag {
+		case "height":
+			return ed.Height, true
+		case "address":
+			return fmt.Sprintf("%X", ed.Address), true
+		case "reason":
+			return ed.Reason, true
+		}
+	case txs.EventDataRoundState:
+		switch tag {
+		case "height":
+			return int64(ed.Height), true
+		case "round":
+			return int64(ed.Round), true
+		case "step":
+			return ed.Step, true
+		}
+	case txs.EventDataNewBlock:
+		if tag == "height" && ed.Block != nil && ed.Block.Header != nil {
+			return int64(ed.Block.Header.Height), true
+		}
+	case txs.EventDataNewBlockHeader:
+		if tag == "height" && ed.Header != nil {
+			return int64(ed.Header.Height), true
+		}
+	}
+	return nil, false
+}
+
+// Splits a query into identifiers, operators, quoted strings, and numbers
+func tokeniseQuery(query string) ([]string, error) {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("Unterminated string in query '%s'", query)
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		case strings.ContainsRune("=<>!", r):
+			j := i + 1
+			if j < len(runes) && runes[j] == '=' {
+				j++
+			}
+			op := string(runes[i:j])
+			if op == "!" {
+				return nil, fmt.Errorf("Unknown operator '!' in query '%s'", query)
+			}
+			tokens = append(tokens, op)
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) ||
+				unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("Unexpected character '%c' in query '%s'",
+				r, query)
+		}
+	}
+	return tokens, nil
+}
+
+func isQuoted(token string) bool {
+	return len(token) >= 2 && token[0] == '\'' && token[len(token)-1] == '\''
+}
+
+func unquote(token string) string {
+	return token[1 : len(token)-1]
+}
+
+func trimHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s[2:]
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQueryBareEventId(t *testing.T) {
+	q, err := ParseQuery(" NewBlock ")
+	assert.NoError(t, err)
+	assert.Equal(t, "NewBlock", q.EventId)
+	assert.True(t, q.Matches(txs.EventDataTx{}))
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"Height > 100",
+		"EventID = 'Log' AND",
+		"EventID = 'Log' OR Height > 100",
+		"EventID > 'Log'",
+		"EventID = 'Log' AND EventID = 'NewBlock'",
+		"EventID = 'Log' AND Height > '100'",
+		"EventID = 'Log' AND Address = 4",
+		"EventID = 'Log' AND Address > '0x01'",
+		"EventID = 'Log' AND Colour = 'red'",
+		"EventID = 'Log",
+		"EventID = 'Log' AND Height ! 100",
+	} {
+		_, err := ParseQuery(query)
+		assert.Error(t, err, "Query '%s' should not parse", query)
+	}
+}
+
+func TestQueryMatchesLog(t *testing.T) {
+	address := LeftPadWord256([]byte{0x1A, 0x2B})
+	topic := RightPadWord256([]byte{0xDD, 0xF2})
+	eventDataLog := txs.EventDataLog{
+		Address: address,
+		Topics:  []Word256{topic},
+		Height:  101,
+	}
+	q, err := ParseQuery("EventID = 'Log/0000000000000000000000000000000000001A2B' " +
+		"AND Height > 100 and address = '0x0000000000000000000000000000000000001a2b' " +
+		"AND Topic0 = 'DDF2000000000000000000000000000000000000000000000000000000000000'")
+	assert.NoError(t, err)
+	assert.Equal(t, "Log/0000000000000000000000000000000000001A2B", q.EventId)
+	assert.True(t, q.Matches(eventDataLog))
+
+	eventDataLog.Height = 100
+	assert.False(t, q.Matches(eventDataLog))
+
+	q, err = ParseQuery("EventID = 'Log' AND Topic1 = '00'")
+	assert.NoError(t, err)
+	assert.False(t, q.Matches(eventDataLog), "missing topic should not match")
+}
+
+func TestQueryMatchesDecodedLog(t *testing.T) {
+	eventDataLog := txs.EventDataDecodedLog{
+		Address: LeftPadWord256([]byte{0x1A, 0x2B}),
+		Height:  101,
+		Event:   &core_types.DecodedEvent{Name: "Transfer"},
+	}
+	q, err := ParseQuery("EventID = 'Log/0000000000000000000000000000000000001A2B' " +
+		"AND Event = 'transfer' AND Height > 100")
+	assert.NoError(t, err)
+	assert.True(t, q.Matches(eventDataLog))
+
+	q, err = ParseQuery("EventID = 'Log' AND Event = 'Approval'")
+	assert.NoError(t, err)
+	assert.False(t, q.Matches(eventDataLog))
+	assert.False(t, q.Matches(eventDataLog.Log()), "undecoded log has no event")
+}
+
+func TestQueryMatchesCall(t *testing.T) {
+	eventDataCall := txs.EventDataCall{
+		CallData: &txs.CallData{
+			Caller: []byte{0x01},
+			Callee: []byte{0x02},
+			Value:  10,
+		},
+		Exception: "",
+	}
+	q, err := ParseQuery("EventID = 'Acc/02/Call' AND Caller = '01' AND " +
+		"Value >= 10 AND Exception = ''")
+	assert.NoError(t, err)
+	assert.True(t, q.Matches(eventDataCall))
+	assert.False(t, q.Matches(txs.EventDataTx{}), "wrong event data type should not match")
+
+	eventDataCall.Exception = "out of gas"
+	assert.False(t, q.Matches(eventDataCall))
+}
+
+func TestQueryMatchesPendingTx(t *testing.T) {
+	eventDataPendingTx := txs.EventDataPendingTx{TxHash: []byte{0xAB, 0xCD}}
+	q, err := ParseQuery("EventID = 'PendingTx' AND TxHash = '0xabcd' AND " +
+		"Exception = ''")
+	assert.NoError(t, err)
+	assert.True(t, q.Matches(eventDataPendingTx))
+
+	eventDataPendingTx.Exception = "Invalid sequence"
+	assert.False(t, q.Matches(eventDataPendingTx))
+}
+
+func TestQueryMatchesPermission(t *testing.T) {
+	eventDataPermission := txs.EventDataPermission{
+		Height:     3,
+		Granter:    []byte{0x01, 0x02},
+		Address:    []byte{0x03, 0x04},
+		Function:   "setBase",
+		Permission: ptypes.CreateContract,
+		Value:      true,
+	}
+	q, err := ParseQuery("EventID = 'PermissionChange' AND Granter = '0102' AND " +
+		"Function = 'setBase' AND Permission = 'CREATE_CONTRACT' AND Height >= 3")
+	assert.NoError(t, err)
+	assert.True(t, q.Matches(eventDataPermission))
+
+	q, err = ParseQuery("EventID = 'PermissionChange' AND Role = 'deployers'")
+	assert.NoError(t, err)
+	assert.False(t, q.Matches(eventDataPermission))
+	eventDataPermission.Role = "deployers"
+	assert.True(t, q.Matches(eventDataPermission))
+}
+
+func TestSubscribeQuery(t *testing.T) {
+	mee := newMockEventEmitter()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mtx := &sync.Mutex{}
+	var received []txs.EventData
+	err := SubscribeQuery(ctx, mee, "Sub", "EventID = 'WeirdEvent' AND Exception != ''",
+		func(eventData txs.EventData) {
+			mtx.Lock()
+			received = append(received, eventData)
+			mtx.Unlock()
+		})
+	assert.NoError(t, err)
+	time.Sleep(4 * mockInterval)
+	mtx.Lock()
+	defer mtx.Unlock()
+	// mockEventData does not have an exception tag so nothing should get through
+	assert.Len(t, received, 0)
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genesis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ptypes "github.com/hyperledger/burrow/permission/types"
+
+	"github.com/tendermint/go-crypto"
+)
+
+// The columns of a CSV list of accounts, named by its header row. Only
+// address, or pub_key, is required, and the columns may come in any order.
+var accountSpecColumns = []string{"address", "amount", "name", "permissions",
+	"roles", "pub_key", "bonded"}
+
+// An account of an externally produced list of accounts, such as those of an
+// onboarding pipeline, to make a genesis doc from
+type AccountSpec struct {
+	// The address in hex, which may be left out when PubKey is given
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+	Name    string `json:"name"`
+	// The names of the permissions the account is granted, such as "send" or
+	// "create_contract", which otherwise fall back to the global permissions
+	Permissions []string `json:"permissions"`
+	Roles       []string `json:"roles"`
+	// The ed25519 public key in hex of an account that is a validator bonding
+	// Bonded, which is unbonded to the account
+	PubKey string `json:"pub_key"`
+	Bonded int64  `json:"bonded"`
+}
+
+// Reads a list of accounts as a JSON array of AccountSpecs
+func ReadAccountSpecsJSON(r io.Reader) ([]*AccountSpec, error) {
+	var specs []*AccountSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return nil, fmt.Errorf("Failed to read accounts: %v", err)
+	}
+	return specs, nil
+}
+
+// Reads a list of accounts as a CSV with a header row naming its columns of
+// AccountSpec, by their JSON names. Permissions and roles are separated by
+// spaces.
+func ReadAccountSpecsCSV(r io.Reader) ([]*AccountSpec, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read accounts: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("Accounts have no header row")
+	}
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !isAccountSpecColumn(column) {
+			return nil, fmt.Errorf("Unknown column '%s' of accounts, expected "+
+				"%s", column, strings.Join(accountSpecColumns, ", "))
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("Column '%s' of accounts is repeated", column)
+		}
+		columns[column] = i
+	}
+	specs := make([]*AccountSpec, len(records)-1)
+	for i, record := range records[1:] {
+		field := func(column string) string {
+			if index, ok := columns[column]; ok {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		spec := &AccountSpec{
+			Address:     field("address"),
+			Name:        field("name"),
+			Permissions: strings.Fields(field("permissions")),
+			Roles:       strings.Fields(field("roles")),
+			PubKey:      field("pub_key"),
+		}
+		for column, amount := range map[string]*int64{
+			"amount": &spec.Amount,
+			"bonded": &spec.Bonded,
+		} {
+			if value := field(column); value != "" {
+				if *amount, err = strconv.ParseInt(value, 10, 64); err != nil {
+					// the header is row 1
+					return nil, fmt.Errorf("Invalid %s of account on row %d: %v",
+						column, i+2, err)
+				}
+			}
+		}
+		specs[i] = spec
+	}
+	return specs, nil
+}
+
+func isAccountSpecColumn(column string) bool {
+	for _, specColumn := range accountSpecColumns {
+		if column == specColumn {
+			return true
+		}
+	}
+	return false
+}
+
+// Validates the account, returning its address, its permissions and its
+// public key if it is a validator
+func (spec *AccountSpec) resolve() ([]byte, *ptypes.AccountPermissions,
+	*crypto.PubKeyEd25519, error) {
+	var pubKey *crypto.PubKeyEd25519
+	if spec.PubKey != "" {
+		pubKeyBytes, err := hex.DecodeString(spec.PubKey)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("Invalid public key: %v", err)
+		}
+		if len(pubKeyBytes) != PublicKeyEd25519ByteLength {
+			return nil, nil, nil, fmt.Errorf("Public key is %v bytes but an "+
+				"ed25519 public key is %v bytes", len(pubKeyBytes),
+				PublicKeyEd25519ByteLength)
+		}
+		pubKey = new(crypto.PubKeyEd25519)
+		copy(pubKey[:], pubKeyBytes)
+	}
+	var address []byte
+	switch {
+	case spec.Address != "":
+		var err error
+		if address, err = hex.DecodeString(spec.Address); err != nil {
+			return nil, nil, nil, fmt.Errorf("Invalid address: %v", err)
+		}
+		if len(address) != 20 {
+			return nil, nil, nil, fmt.Errorf("Address is %v bytes but addresses "+
+				"are 20 bytes", len(address))
+		}
+		if pubKey != nil && !bytes.Equal(address, pubKey.Address()) {
+			return nil, nil, nil, fmt.Errorf("Address is not that of the public "+
+				"key, %X", pubKey.Address())
+		}
+	case pubKey != nil:
+		address = pubKey.Address()
+	default:
+		return nil, nil, nil, fmt.Errorf("Account has neither an address nor " +
+			"a public key")
+	}
+	if spec.Amount < 0 {
+		return nil, nil, nil, fmt.Errorf("Amount %v is negative", spec.Amount)
+	}
+	if spec.Bonded < 0 || (spec.Bonded > 0 && pubKey == nil) {
+		return nil, nil, nil, fmt.Errorf("Bonded %v must be positive, and "+
+			"needs the public key of a validator", spec.Bonded)
+	}
+	if pubKey != nil && spec.Bonded == 0 {
+		return nil, nil, nil, fmt.Errorf("Validator with public key %s bonds "+
+			"nothing", spec.PubKey)
+	}
+	permissions := make(map[string]bool, len(spec.Permissions))
+	for _, permission := range spec.Permissions {
+		if _, err := ptypes.PermStringToFlag(permission); err != nil {
+			return nil, nil, nil, err
+		}
+		permissions[permission] = true
+	}
+	roles := make([]string, 0, len(spec.Roles))
+	for _, role := range spec.Roles {
+		if role == "" {
+			return nil, nil, nil, fmt.Errorf("Account has an empty role")
+		}
+		roles = append(roles, role)
+	}
+	accountPermissions, err := ptypes.ConvertPermissionsMapAndRolesToAccountPermissions(
+		permissions, roles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return address, accountPermissions, pubKey, nil
+}
+
+// Makes a genesis doc from a list of accounts, validating each of them. The
+// accounts and validators are ordered by address so that the same accounts,
+// in whatever order, and genesisTime always make the same genesis doc.
+func MakeGenesisDocFromAccountSpecs(chainID string, genesisTime time.Time,
+	specs []*AccountSpec) (*GenesisDoc, error) {
+	var accounts []*GenesisAccount
+	var validators []*GenesisValidator
+	addresses := make(map[string]int)
+	for i, spec := range specs {
+		address, permissions, pubKey, err := spec.resolve()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid account %d (%s): %v", i+1, spec.Name,
+				err)
+		}
+		if other, ok := addresses[string(address)]; ok {
+			return nil, fmt.Errorf("Accounts %d and %d have the same address %X",
+				other+1, i+1, address)
+		}
+		addresses[string(address)] = i
+		accounts = append(accounts, NewGenesisAccount(address, spec.Amount,
+			spec.Name, permissions))
+		if pubKey != nil {
+			validator, err := NewGenesisValidator(spec.Bonded, spec.Name, address,
+				spec.Bonded, "ed25519", pubKey[:])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid account %d (%s): %v", i+1,
+					spec.Name, err)
+			}
+			validators = append(validators, validator)
+		}
+	}
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("None of the accounts is a validator")
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].Address, accounts[j].Address) < 0
+	})
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].UnbondTo[0].Address,
+			validators[j].UnbondTo[0].Address) < 0
+	})
+	genesisDoc, err := MakeGenesisDocFromAccounts(chainID, accounts, validators)
+	if err != nil {
+		return nil, err
+	}
+	genesisDoc.GenesisTime = genesisTime
+	return &genesisDoc, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genesis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	ptypes "github.com/hyperledger/burrow/permission/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var accountSpecsCSV = `name,address,amount,permissions,roles,pub_key,bonded
+participant,E1BD50A1B90A15861F5CF0F182D291F556B21A86,100,send call,,,
+validator,,9999,send,validators,238E1A77CC7CDCD13F4D77841F1FE4A46A77DB691EC89718CD0D4CB3409F61D2,1000`
+
+var accountSpecsJSON = `[
+	{
+		"name": "validator",
+		"amount": 9999,
+		"permissions": ["send"],
+		"roles": ["validators"],
+		"pub_key": "238E1A77CC7CDCD13F4D77841F1FE4A46A77DB691EC89718CD0D4CB3409F61D2",
+		"bonded": 1000
+	},
+	{
+		"name": "participant",
+		"address": "E1BD50A1B90A15861F5CF0F182D291F556B21A86",
+		"amount": 100,
+		"permissions": ["send", "call"]
+	}
+]`
+
+func TestGenesisFromAccountSpecs(t *testing.T) {
+	csvSpecs, err := ReadAccountSpecsCSV(strings.NewReader(accountSpecsCSV))
+	require.NoError(t, err)
+	jsonSpecs, err := ReadAccountSpecsJSON(strings.NewReader(accountSpecsJSON))
+	require.NoError(t, err)
+
+	csvGenesisDoc, err := MakeGenesisDocFromAccountSpecs(chainID,
+		DefaultGenesisTime, csvSpecs)
+	require.NoError(t, err)
+	jsonGenesisDoc, err := MakeGenesisDocFromAccountSpecs(chainID,
+		DefaultGenesisTime, jsonSpecs)
+	require.NoError(t, err)
+	csvBytes, err := GetGenesisFileBytes(csvGenesisDoc)
+	require.NoError(t, err)
+	jsonBytes, err := GetGenesisFileBytes(jsonGenesisDoc)
+	require.NoError(t, err)
+	// The same accounts in another order make the same genesis file
+	assert.Equal(t, string(csvBytes), string(jsonBytes))
+
+	require.Len(t, csvGenesisDoc.Accounts, 2)
+	require.Len(t, csvGenesisDoc.Validators, 1)
+	validatorAccount := csvGenesisDoc.Accounts[0]
+	assert.Equal(t, "validator", validatorAccount.Name)
+	assert.Equal(t, "0C9DAEA4046491A661FCE0B41B0CAA2AD3415268",
+		fmt.Sprintf("%X", validatorAccount.Address))
+	assert.Equal(t, []string{"validators"}, validatorAccount.Permissions.Roles)
+	assert.Equal(t, int64(1000), csvGenesisDoc.Validators[0].Amount)
+	assert.Equal(t, validatorAccount.Address,
+		csvGenesisDoc.Validators[0].UnbondTo[0].Address)
+
+	participant := csvGenesisDoc.Accounts[1]
+	assert.Equal(t, int64(100), participant.Amount)
+	assert.Equal(t, ptypes.Send|ptypes.Call, participant.Permissions.Base.Perms)
+	assert.Equal(t, ptypes.Send|ptypes.Call, participant.Permissions.Base.SetBit)
+}
+
+func TestInvalidAccountSpecs(t *testing.T) {
+	validator := AccountSpec{
+		PubKey: "238E1A77CC7CDCD13F4D77841F1FE4A46A77DB691EC89718CD0D4CB3409F61D2",
+		Bonded: 1,
+	}
+	for name, spec := range map[string]AccountSpec{
+		"short address":        {Address: "E1BD50A1B90A15861F5CF0F182D291F556B2"},
+		"no address":           {Amount: 1},
+		"unknown permission":   {Address: "E1BD50A1B90A15861F5CF0F182D291F556B21A86", Permissions: []string{"fly"}},
+		"negative amount":      {Address: "E1BD50A1B90A15861F5CF0F182D291F556B21A86", Amount: -1},
+		"bonded without key":   {Address: "E1BD50A1B90A15861F5CF0F182D291F556B21A86", Bonded: 1},
+		"address of other key": {Address: "E1BD50A1B90A15861F5CF0F182D291F556B21A86", PubKey: validator.PubKey, Bonded: 1},
+	} {
+		spec := spec
+		_, err := MakeGenesisDocFromAccountSpecs(chainID, DefaultGenesisTime,
+			[]*AccountSpec{&validator, &spec})
+		assert.Error(t, err, name)
+	}
+	// the same account twice
+	_, err := MakeGenesisDocFromAccountSpecs(chainID, DefaultGenesisTime,
+		[]*AccountSpec{&validator, &validator})
+	assert.Error(t, err)
+
+	_, err = ReadAccountSpecsCSV(strings.NewReader("address,balance\n"))
+	assert.Error(t, err)
+	_, err = ReadAccountSpecsCSV(strings.NewReader("address,amount\nE1BD50A1B90A15861F5CF0F182D291F556B21A86,lots\n"))
+	assert.Error(t, err)
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/tendermint/go-crypto"
 	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
 )
 
 //------------------------------------------------------------
@@ -30,6 +31,21 @@ import (
 
 var GenDocKey = []byte("GenDocKey")
 
+// What a genesis doc that leaves them unset is taken to give
+var (
+	// 11/18/2016 @ 4:09am (UTC)
+	DefaultGenesisTime = time.Unix(1479442162, 0)
+	// The gas each CallTx is given
+	DefaultGasLimit       = int64(1000000)
+	DefaultSlashingParams = SlashingParams{
+		SlashPercent:       5,
+		JailBlocks:         60 * 24,
+		DowntimeWindow:     100,
+		MaxMissedBlocks:    50,
+		DowntimeJailBlocks: 60,
+	}
+)
+
 //------------------------------------------------------------
 // core types for a genesis definition
 
@@ -67,6 +83,75 @@ type GenesisPrivateValidator struct {
 
 type GenesisParams struct {
 	GlobalPermissions *ptypes.AccountPermissions `json:"global_permissions"`
+	// The dynamic base fee is only charged when Fees is set
+	Fees *FeeParams `json:"fees"`
+	// The gas the EVM charges, Burrow's own schedule when not set
+	GasSchedule *GasSchedule `json:"gas_schedule"`
+	// The votes of accounts with the Vote permission a proposal needs before
+	// its batch of txs is executed. Proposals cannot be made when it is 0.
+	ProposalThreshold int `json:"proposal_threshold"`
+	// The gas each CallTx is given, 1000000 when 0
+	GasLimit int64 `json:"gas_limit"`
+	// The longest tx in bytes the chain accepts, no limit when 0
+	MaxTxSize int `json:"max_tx_size"`
+	// The most gas a CallTx may be given, no limit when 0
+	MaxTxGas int64 `json:"max_tx_gas"`
+	// The most gas the CallTxs of a block may be given in all, no limit when 0
+	MaxBlockGas int64 `json:"max_block_gas"`
+	// The punishment of validators that double-sign, Burrow's defaults when
+	// not set
+	Slashing *SlashingParams `json:"slashing"`
+	// The rewards of validators, none when not set
+	Rewards *RewardParams `json:"rewards"`
+}
+
+// Parameters of the base fee that CallTxs and SendTxs must pay on top of any
+// priority fee. The base fee is burnt, and is adjusted after each block
+// towards the fee at which blocks hold TargetTxsPerBlock txs.
+type FeeParams struct {
+	InitialBaseFee    int64 `json:"initial_base_fee"`
+	TargetTxsPerBlock int   `json:"target_txs_per_block"`
+	// Damps the change, a block twice the target raises the fee by 1/denominator
+	BaseFeeChangeDenominator int64 `json:"base_fee_change_denominator"`
+}
+
+// A validator that signs conflicting votes at a height loses SlashPercent of
+// the coins it has bonded, which are burnt, and is jailed, out of the
+// validator set, for JailBlocks blocks. A validator that misses signing more
+// than MaxMissedBlocks of a window of DowntimeWindow blocks is jailed for
+// DowntimeJailBlocks blocks, and is not slashed. Validators are not jailed
+// for downtime when DowntimeWindow is 0.
+type SlashingParams struct {
+	SlashPercent       int `json:"slash_percent"`
+	JailBlocks         int `json:"jail_blocks"`
+	DowntimeWindow     int `json:"downtime_window"`
+	MaxMissedBlocks    int `json:"max_missed_blocks"`
+	DowntimeJailBlocks int `json:"downtime_jail_blocks"`
+}
+
+// Each block mints BlockReward coins and adds to them FeePercent of the fees
+// paid by its txs, other than priority fees, which are otherwise burnt. The
+// proposer of the block is credited with ProposerPercent of the reward and the
+// validators that signed the last commit share the rest in proportion to their
+// power.
+type RewardParams struct {
+	BlockReward     int64 `json:"block_reward"`
+	FeePercent      int   `json:"fee_percent"`
+	ProposerPercent int   `json:"proposer_percent"`
+}
+
+// A named base gas schedule, "burrow" or "ethereum", with some of its costs
+// overridden
+type GasSchedule struct {
+	Base  string    `json:"base"`
+	Costs []GasCost `json:"costs"`
+}
+
+// A cost named by opcode, such as SLOAD, or by the dynamic cost it sets, such
+// as memory_word or sstore_set
+type GasCost struct {
+	Name string `json:"name"`
+	Gas  int64  `json:"gas"`
 }
 
 //------------------------------------------------------------
@@ -80,6 +165,16 @@ type GenesisDoc struct {
 	Validators  []GenesisValidator `json:"validators"`
 }
 
+// Get the validators as tendermint makes them from the genesis file
+func (genDoc *GenesisDoc) TendermintValidators() []*tm_types.Validator {
+	validators := make([]*tm_types.Validator, len(genDoc.Validators))
+	for i, genesisValidator := range genDoc.Validators {
+		validators[i] = tm_types.NewValidator(genesisValidator.PubKey,
+			genesisValidator.Amount)
+	}
+	return validators
+}
+
 //------------------------------------------------------------
 // Make genesis state from file
 
@@ -0,0 +1,303 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integration boots a single validator burrow node in process, keeping
+// its state in memory, so that Go projects can test against a real chain
+// without building binaries or running containers.
+package integration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/core"
+	"github.com/hyperledger/burrow/files"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/logging/lifecycle"
+	"github.com/hyperledger/burrow/logging/loggers"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	rpc_client "github.com/hyperledger/burrow/rpc/tendermint/client"
+	rpc_core "github.com/hyperledger/burrow/rpc/tendermint/core"
+
+	"github.com/tendermint/go-crypto"
+	rpcclient "github.com/tendermint/go-rpc/client"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+const (
+	DefaultChainId  = "integration_test_chain"
+	DefaultAccounts = 5
+	DefaultBalance  = int64(1000000000000)
+	// How long to wait for the next block before giving up
+	BlockTimeout = 10 * time.Second
+)
+
+type Config struct {
+	ChainId string
+	// The number of accounts, each with Balance and all the permissions
+	Accounts int
+	Balance  int64
+	// Spills the logs of the node on the floor if nil
+	Logger logging_types.InfoTraceLogger
+}
+
+type Node struct {
+	ChainId string
+	Genesis *genesis.GenesisDoc
+	// The accounts of the genesis file, the first of which is the validator.
+	// Their keys are derived from the chain id so are the same on every run.
+	Accounts []*acm.PrivAccount
+	// The host:port of the Tendermint RPC of the node
+	RPCAddress string
+
+	core    *core.Core
+	server  *rpc_core.TendermintWebsocketServer
+	workDir string
+	logger  logging_types.InfoTraceLogger
+}
+
+// Starts a node whose blocks follow each other as quickly as consensus allows,
+// and waits for its first block. The node listens on free ports of the loopback
+// interface, so many can run at once.
+func Start(conf Config) (*Node, error) {
+	if conf.ChainId == "" {
+		conf.ChainId = DefaultChainId
+	}
+	if conf.Accounts == 0 {
+		conf.Accounts = DefaultAccounts
+	}
+	if conf.Balance == 0 {
+		conf.Balance = DefaultBalance
+	}
+	if conf.Logger == nil {
+		conf.Logger = loggers.NewNoopInfoTraceLogger()
+	}
+	workDir, err := ioutil.TempDir("", "burrow_integration_")
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{
+		ChainId: conf.ChainId,
+		workDir: workDir,
+		logger:  conf.Logger,
+	}
+	if err := node.start(conf); err != nil {
+		node.Stop()
+		return nil, err
+	}
+	return node, nil
+}
+
+func (node *Node) start(conf Config) error {
+	node.Accounts = make([]*acm.PrivAccount, conf.Accounts)
+	genesisAccounts := make([]*genesis.GenesisAccount, conf.Accounts)
+	permissions := ptypes.AccountPermissions{
+		Base: ptypes.BasePermissions{
+			Perms:  ptypes.AllPermFlags,
+			SetBit: ptypes.AllPermFlags,
+		},
+		Roles: []string{},
+	}
+	for i := range node.Accounts {
+		node.Accounts[i] = acm.GenPrivAccountFromSecret(fmt.Sprintf("%s_%d",
+			conf.ChainId, i))
+		genesisAccounts[i] = genesis.NewGenesisAccount(node.Accounts[i].Address,
+			conf.Balance, fmt.Sprintf("account_%d", i), &permissions)
+	}
+	validatorKey := node.Accounts[0].PubKey.(crypto.PubKeyEd25519)
+	validator, err := genesis.NewGenesisValidator(conf.Balance, "validator",
+		node.Accounts[0].Address, conf.Balance, "ed25519", validatorKey[:])
+	if err != nil {
+		return err
+	}
+	genesisDoc, err := genesis.MakeGenesisDocFromAccounts(conf.ChainId,
+		genesisAccounts, []*genesis.GenesisValidator{validator})
+	if err != nil {
+		return err
+	}
+	node.Genesis = &genesisDoc
+	genesisBytes, err := genesis.GetGenesisFileBytes(node.Genesis)
+	if err != nil {
+		return err
+	}
+	genesisFile := path.Join(node.workDir, "genesis.json")
+	if err := files.WriteFileRW(genesisFile, genesisBytes); err != nil {
+		return err
+	}
+	privValidator := &tm_types.PrivValidator{
+		Address: node.Accounts[0].Address,
+		PubKey:  validatorKey,
+		PrivKey: node.Accounts[0].PrivKey,
+	}
+	if err := files.WriteFileRW(path.Join(node.workDir, "priv_validator.json"),
+		wire.JSONBytesPretty(privValidator)); err != nil {
+		return err
+	}
+
+	ports, err := freePorts(3)
+	if err != nil {
+		return err
+	}
+	node.RPCAddress = loopbackAddress(ports[1])
+	configBytes, err := config.GetNodeConfigurationFileBytes(conf.ChainId,
+		&config.ConfigTendermint{
+			Moniker:       "integration",
+			NodeAddress:   loopbackAddress(ports[0]),
+			DBBackend:     "memdb",
+			InstantBlocks: true,
+		},
+		&config.ConfigServers{
+			BindPort:             uint16(ports[2]),
+			TendermintRPCAddress: node.RPCAddress,
+		},
+		&config.ConfigBurrowMint{
+			DBBackend:      "memdb",
+			TendermintHost: node.RPCAddress,
+		})
+	if err != nil {
+		return err
+	}
+	rootConfig, err := config.ReadViperConfig(configBytes)
+	if err != nil {
+		return err
+	}
+	dataDir := path.Join(node.workDir, "data")
+	consensusConfig, err := core.LoadModuleConfig(rootConfig, node.workDir,
+		dataDir, genesisFile, conf.ChainId, "consensus")
+	if err != nil {
+		return err
+	}
+	managerConfig, err := core.LoadModuleConfig(rootConfig, node.workDir,
+		dataDir, genesisFile, conf.ChainId, "manager")
+	if err != nil {
+		return err
+	}
+	serverConfig, err := core.LoadServerConfig(conf.ChainId, rootConfig)
+	if err != nil {
+		return err
+	}
+	lifecycle.CaptureTendermintLog15Output(node.logger)
+
+	if node.core, err = core.NewCore(conf.ChainId, consensusConfig,
+		managerConfig, node.logger); err != nil {
+		return err
+	}
+	if node.server, err = node.core.NewGatewayTendermint(serverConfig); err != nil {
+		return err
+	}
+	return node.WaitForHeight(1)
+}
+
+// Stops the node and deletes its files
+func (node *Node) Stop() {
+	if node.server != nil {
+		node.server.Shutdown()
+	}
+	if node.core != nil {
+		node.core.Stop()
+		// Tendermint may still be saving the validator's last signature
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.RemoveAll(node.workDir)
+}
+
+// A client of the Tendermint RPC of the node speaking JSON-RPC
+func (node *Node) JSONRPCClient() rpc_client.RPCClient {
+	return rpcclient.NewJSONRPCClient(node.RPCAddress)
+}
+
+// A client of the Tendermint RPC of the node passing parameters in URIs
+func (node *Node) URIClient() rpc_client.RPCClient {
+	return rpcclient.NewURIClient(node.RPCAddress)
+}
+
+// The client burrow-client uses, which broadcasts txs and waits for them
+// to be committed
+func (node *Node) NodeClient() client.NodeClient {
+	return client.NewBurrowNodeClient("tcp://"+node.RPCAddress, node.logger)
+}
+
+func (node *Node) Height() (int, error) {
+	status, err := rpc_client.Status(node.JSONRPCClient())
+	if err != nil {
+		return 0, err
+	}
+	return status.LatestBlockHeight, nil
+}
+
+// Waits for the node to commit the given number of blocks, so that the txs
+// already broadcast are committed, or time passes for the txs and contracts
+// that wait for a height
+func (node *Node) FastForward(blocks int) error {
+	height, err := node.Height()
+	if err != nil {
+		return err
+	}
+	return node.WaitForHeight(height + blocks)
+}
+
+// Waits for the node to commit the block at height, failing if it commits no
+// block for BlockTimeout
+func (node *Node) WaitForHeight(height int) error {
+	latest := -1
+	deadline := time.Now().Add(BlockTimeout)
+	for {
+		current, err := node.Height()
+		if err == nil {
+			if current >= height {
+				return nil
+			}
+			if current > latest {
+				latest = current
+				deadline = time.Now().Add(BlockTimeout)
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("Timed out waiting for block %d: %v", height, err)
+			}
+			return fmt.Errorf("Timed out waiting for block %d, the latest is %d",
+				height, latest)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func freePorts(n int) ([]int, error) {
+	ports := make([]int, n)
+	for i := range ports {
+		// Keep each listener open until all are found so the ports differ
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+		ports[i] = listener.Addr().(*net.TCPAddr).Port
+	}
+	return ports, nil
+}
+
+func loopbackAddress(port int) string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	rpc_client "github.com/hyperledger/burrow/rpc/tendermint/client"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode(t *testing.T) {
+	node, err := Start(Config{Accounts: 2})
+	require.NoError(t, err)
+	defer node.Stop()
+	require.Len(t, node.Accounts, 2)
+	assert.Equal(t, DefaultChainId, node.Genesis.ChainID)
+
+	from, to := node.Accounts[0], node.Accounts[1]
+	tx := txs.NewSendTx()
+	require.NoError(t, tx.AddInputWithNonce(from.PubKey, 100, 1))
+	require.NoError(t, tx.AddOutput(to.Address, 100))
+	require.NoError(t, tx.SignInput(node.ChainId, 0, from))
+	_, err = rpc_client.BroadcastTx(node.JSONRPCClient(), tx)
+	require.NoError(t, err)
+
+	height, err := node.Height()
+	require.NoError(t, err)
+	require.NoError(t, node.FastForward(2))
+	newHeight, err := node.Height()
+	require.NoError(t, err)
+	assert.True(t, newHeight >= height+2)
+
+	account, err := node.NodeClient().GetAccount(to.Address)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBalance+100, account.Balance)
+}
@@ -0,0 +1,160 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+)
+
+// Child indices from this one up are hardened
+const HardenedOffset uint32 = 0x80000000
+
+// A derivation path such as m/44'/60'/0'/0/0, where hardened indices are
+// marked with ' or h
+type Path []uint32
+
+func ParsePath(path string) (Path, error) {
+	components := strings.Split(strings.TrimSpace(path), "/")
+	if components[0] != "m" {
+		return nil, fmt.Errorf("Derivation path %s must start with m", path)
+	}
+	var p Path
+	for _, component := range components[1:] {
+		offset := uint32(0)
+		if strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h") {
+			offset = HardenedOffset
+			component = component[:len(component)-1]
+		}
+		index, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("Derivation path %s has bad index %s", path,
+				component)
+		}
+		p = append(p, uint32(index)+offset)
+	}
+	return p, nil
+}
+
+func (p Path) String() string {
+	components := []string{"m"}
+	for _, index := range p {
+		if index >= HardenedOffset {
+			components = append(components, fmt.Sprintf("%v'", index-HardenedOffset))
+		} else {
+			components = append(components, fmt.Sprintf("%v", index))
+		}
+	}
+	return strings.Join(components, "/")
+}
+
+// Gets the path with its last index increased by n, which keeps it hardened
+// if it was, for deriving a sequence of sibling keys
+func (p Path) Sibling(n uint32) Path {
+	sibling := append(Path{}, p...)
+	if len(sibling) > 0 {
+		sibling[len(sibling)-1] += n
+	}
+	return sibling
+}
+
+// Derives the 32 byte ed25519 private key seed at path from the BIP39 seed
+// as SLIP-0010 specifies, which allows only hardened derivation
+func DeriveEd25519(seed []byte, path Path) ([]byte, []byte, error) {
+	key, chainCode := hmacSHA512([]byte("ed25519 seed"), seed)
+	for _, index := range path {
+		if index < HardenedOffset {
+			return nil, nil, fmt.Errorf("Ed25519 keys can only be derived at "+
+				"hardened indices, not %v in %s", index, path)
+		}
+		key, chainCode = hmacSHA512(chainCode, []byte{0}, key, ser32(index))
+	}
+	return key, chainCode, nil
+}
+
+// Derives the 32 byte secp256k1 private key at path from the BIP39 seed as
+// BIP32 specifies
+func DeriveSecp256k1(seed []byte, path Path) ([]byte, []byte, error) {
+	key, chainCode := hmacSHA512([]byte("Bitcoin seed"), seed)
+	if err := checkSecp256k1Key(key); err != nil {
+		return nil, nil, err
+	}
+	for _, index := range path {
+		var childKey []byte
+		if index >= HardenedOffset {
+			childKey, chainCode = hmacSHA512(chainCode, []byte{0}, key, ser32(index))
+		} else {
+			pubkey, err := secp256k1.PubkeyFromSeckey(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			childKey, chainCode = hmacSHA512(chainCode, compress(pubkey), ser32(index))
+		}
+		if err := checkSecp256k1Key(childKey); err != nil {
+			return nil, nil, err
+		}
+		k := new(big.Int).SetBytes(childKey)
+		k.Add(k, new(big.Int).SetBytes(key))
+		k.Mod(k, secp256k1.N)
+		if k.Sign() == 0 {
+			return nil, nil, fmt.Errorf("Derived an invalid secp256k1 key at %s, "+
+				"use the next index", path)
+		}
+		key = make([]byte, 32)
+		kBytes := k.Bytes()
+		copy(key[32-len(kBytes):], kBytes)
+	}
+	return key, chainCode, nil
+}
+
+// The chance of this failing is below 1 in 2^127, and BIP32 says to move on
+// to the next index when it does
+func checkSecp256k1Key(key []byte) error {
+	if new(big.Int).SetBytes(key).Cmp(secp256k1.N) >= 0 {
+		return fmt.Errorf("Derived an invalid secp256k1 key, use the next index")
+	}
+	return nil
+}
+
+// Compresses an uncompressed 0x04 || x || y public key to 0x02 or 0x03 || x
+func compress(pubkey []byte) []byte {
+	compressed := make([]byte, 33)
+	compressed[0] = 2 + pubkey[64]&1
+	copy(compressed[1:], pubkey[1:33])
+	return compressed
+}
+
+func ser32(index uint32) []byte {
+	bs := make([]byte, 4)
+	binary.BigEndian.PutUint32(bs, index)
+	return bs
+}
+
+// Splits the HMAC-SHA512 of data into its left and right halves
+func hmacSHA512(key []byte, data ...[]byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
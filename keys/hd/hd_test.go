@@ -0,0 +1,120 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWordlist(t *testing.T) Wordlist {
+	var words []string
+	for i := 0; i < WordlistLength; i++ {
+		words = append(words, fmt.Sprintf("w%04d", i))
+	}
+	wordlist, err := ReadWordlist(strings.NewReader(strings.Join(words, "\n")))
+	require.NoError(t, err)
+	return wordlist
+}
+
+func TestMnemonic(t *testing.T) {
+	wordlist := testWordlist(t)
+	// The first BIP39 test vector, whose last word has index 3
+	mnemonic, err := wordlist.EntropyToMnemonic(make([]byte, 16))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("w0000 ", 11)+"w0003", mnemonic)
+
+	for _, entropyBits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := wordlist.NewMnemonic(entropyBits)
+		require.NoError(t, err)
+		assert.Len(t, strings.Fields(mnemonic), entropyBits*3/32)
+		entropy, err := wordlist.MnemonicToEntropy(mnemonic)
+		require.NoError(t, err)
+		assert.Len(t, entropy, entropyBits/8)
+		again, err := wordlist.EntropyToMnemonic(entropy)
+		require.NoError(t, err)
+		assert.Equal(t, mnemonic, again)
+	}
+
+	_, err = wordlist.MnemonicToEntropy(strings.Repeat("w0000 ", 12))
+	assert.Error(t, err, "bad checksum")
+	_, err = wordlist.MnemonicToEntropy(strings.Repeat("w0000 ", 11) + "nope")
+	assert.Error(t, err, "unknown word")
+	_, err = wordlist.NewMnemonic(100)
+	assert.Error(t, err)
+	_, err = ReadWordlist(strings.NewReader("abandon\nability"))
+	assert.Error(t, err)
+}
+
+func TestSeed(t *testing.T) {
+	seed := Seed(strings.Repeat("abandon ", 11)+"about", "TREZOR")
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e5349553"+
+		"1f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		hex.EncodeToString(seed))
+}
+
+func TestPath(t *testing.T) {
+	path, err := ParsePath("m/44'/60'/0h/0/7")
+	require.NoError(t, err)
+	assert.Equal(t, Path{HardenedOffset + 44, HardenedOffset + 60, HardenedOffset, 0, 7},
+		path)
+	assert.Equal(t, "m/44'/60'/0'/0/7", path.String())
+	assert.Equal(t, "m/44'/60'/0'/0/9", path.Sibling(2).String())
+	assert.Equal(t, "m/44'/60'/0'/0/7", path.String())
+
+	for _, bad := range []string{"44'/0", "m/x", "m/2147483648", "m//1"} {
+		_, err = ParsePath(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+var testSeed, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+// The first test vectors of BIP32 and SLIP-0010
+func TestDeriveSecp256k1(t *testing.T) {
+	for path, expected := range map[string]string{
+		"m":      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1": "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+	} {
+		p, err := ParsePath(path)
+		require.NoError(t, err)
+		key, _, err := DeriveSecp256k1(testSeed, p)
+		require.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(key), path)
+	}
+}
+
+func TestDeriveEd25519(t *testing.T) {
+	key, chainCode, err := DeriveEd25519(testSeed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		hex.EncodeToString(key))
+	assert.Equal(t, "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+		hex.EncodeToString(chainCode))
+
+	key, _, err = DeriveEd25519(testSeed, Path{HardenedOffset})
+	require.NoError(t, err)
+	assert.Equal(t, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		hex.EncodeToString(key))
+
+	_, _, err = DeriveEd25519(testSeed, Path{0})
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hd derives hierarchical deterministic keys from a BIP39 mnemonic,
+// following BIP32 for secp256k1 keys and SLIP-0010 for ed25519 keys, so that
+// a set of keys can be backed up and recreated from a single seed phrase.
+package hd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const WordlistLength = 2048
+
+// A BIP39 wordlist of 2048 words. Only wordlists whose words need no Unicode
+// normalisation, such as the English one, are supported.
+type Wordlist []string
+
+// Reads a wordlist with one word on each line, such as the english.txt
+// published with BIP39
+func LoadWordlist(path string) (Wordlist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadWordlist(file)
+}
+
+func ReadWordlist(r io.Reader) (Wordlist, error) {
+	var wordlist Wordlist
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word != "" {
+			wordlist = append(wordlist, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(wordlist) != WordlistLength {
+		return nil, fmt.Errorf("Wordlist has %v words rather than %v",
+			len(wordlist), WordlistLength)
+	}
+	return wordlist, nil
+}
+
+// Generates a mnemonic from entropyBits of random entropy, which must be a
+// multiple of 32 from 128 to 256
+func (wordlist Wordlist) NewMnemonic(entropyBits int) (string, error) {
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", fmt.Errorf("Mnemonic entropy must be a multiple of 32 bits "+
+			"from 128 to 256, not %v", entropyBits)
+	}
+	entropy := make([]byte, entropyBits/8)
+	if _, err := io.ReadFull(rand.Reader, entropy); err != nil {
+		return "", err
+	}
+	return wordlist.EntropyToMnemonic(entropy)
+}
+
+// Encodes entropy and its checksum as words, 11 bits to a word
+func (wordlist Wordlist) EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", fmt.Errorf("Mnemonic entropy must be a multiple of 4 bytes "+
+			"from 16 to 32, not %v", len(entropy))
+	}
+	checksum := sha256.Sum256(entropy)
+	bits := append(append([]byte{}, entropy...), checksum[0])
+	words := make([]string, (len(entropy)*8+len(entropy)/4)/11)
+	for i := range words {
+		words[i] = wordlist[readBits(bits, i*11, 11)]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// Decodes a mnemonic to its entropy, checking its words and checksum
+func (wordlist Wordlist) MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("Mnemonic must have 12, 15, 18, 21 or 24 words, "+
+			"not %v", len(words))
+	}
+	indices := make(map[string]int, len(wordlist))
+	for i, word := range wordlist {
+		indices[word] = i
+	}
+	checksumBits := len(words) / 3
+	bits := make([]byte, (len(words)*11+7)/8)
+	for i, word := range words {
+		index, ok := indices[word]
+		if !ok {
+			return nil, fmt.Errorf("Mnemonic word %v, %s, is not in the wordlist",
+				i+1, word)
+		}
+		writeBits(bits, i*11, 11, index)
+	}
+	entropy := bits[:checksumBits*4]
+	checksum := sha256.Sum256(entropy)
+	if readBits(bits, checksumBits*32, checksumBits) !=
+		int(checksum[0]>>uint(8-checksumBits)) {
+		return nil, fmt.Errorf("Mnemonic checksum is wrong")
+	}
+	return entropy, nil
+}
+
+// Gets the 64 byte BIP39 seed of mnemonic and an optional passphrase
+func Seed(mnemonic, passphrase string) []byte {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64,
+		sha512.New)
+}
+
+// Reads length bits big-endian from bs starting at bit offset
+func readBits(bs []byte, offset, length int) int {
+	n := 0
+	for i := offset; i < offset+length; i++ {
+		n = n<<1 | int(bs[i/8]>>uint(7-i%8)&1)
+	}
+	return n
+}
+
+func writeBits(bs []byte, offset, length, n int) {
+	for i := 0; i < length; i++ {
+		if n>>uint(length-1-i)&1 == 1 {
+			bit := offset + i
+			bs[bit/8] |= 1 << uint(7-bit%8)
+		}
+	}
+}
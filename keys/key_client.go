@@ -15,8 +15,10 @@
 package keys
 
 import (
+	"crypto/tls"
 	"encoding/hex"
 	"fmt"
+	"net/http"
 
 	"github.com/hyperledger/burrow/logging"
 	logging_types "github.com/hyperledger/burrow/logging/types"
@@ -35,19 +37,33 @@ type KeyClient interface {
 var _ KeyClient = (*monaxKeyClient)(nil)
 
 type monaxKeyClient struct {
-	rpcString string
-	logger    logging_types.InfoTraceLogger
+	rpcString  string
+	httpClient *http.Client
+	logger     logging_types.InfoTraceLogger
 }
 
 // monaxKeyClient.New returns a new monax-keys client for provided rpc location
 // Monax-keys connects over http request-responses
 func NewBurrowKeyClient(rpcString string, logger logging_types.InfoTraceLogger) *monaxKeyClient {
 	return &monaxKeyClient{
-		rpcString: rpcString,
-		logger:    logging.WithScope(logger, "BurrowKeyClient"),
+		rpcString:  rpcString,
+		httpClient: new(http.Client),
+		logger:     logging.WithScope(logger, "BurrowKeyClient"),
 	}
 }
 
+// Returns a monax-keys client that connects to an https rpc location with
+// tlsConfig, as loaded by LoadClientTLSConfig, so that a key server on
+// another machine can authenticate the client by its certificate
+func NewBurrowKeyClientTLS(rpcString string, tlsConfig *tls.Config,
+	logger logging_types.InfoTraceLogger) *monaxKeyClient {
+	keyClient := NewBurrowKeyClient(rpcString, logger)
+	keyClient.httpClient = &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	return keyClient
+}
+
 // Monax-keys client Sign requests the signature from BurrowKeysClient over rpc for the given
 // bytes to be signed and the address to sign them with.
 func (monaxKeys *monaxKeyClient) Sign(signBytesString string, signAddress []byte) (signature []byte, err error) {
@@ -56,7 +72,8 @@ func (monaxKeys *monaxKeyClient) Sign(signBytesString string, signAddress []byte
 		"hash": signBytesString, // TODO:[ben] backwards compatibility
 		"addr": fmt.Sprintf("%X", signAddress),
 	}
-	sigS, err := RequestResponse(monaxKeys.rpcString, "sign", args, monaxKeys.logger)
+	sigS, err := requestResponseWithClient(monaxKeys.httpClient, monaxKeys.rpcString, "sign",
+		args, monaxKeys.logger)
 	if err != nil {
 		return
 	}
@@ -73,7 +90,8 @@ func (monaxKeys *monaxKeyClient) PublicKey(address []byte) (publicKey []byte, er
 	args := map[string]string{
 		"addr": fmt.Sprintf("%X", address),
 	}
-	pubS, err := RequestResponse(monaxKeys.rpcString, "pub", args, monaxKeys.logger)
+	pubS, err := requestResponseWithClient(monaxKeys.httpClient, monaxKeys.rpcString, "pub",
+		args, monaxKeys.logger)
 	if err != nil {
 		return
 	}
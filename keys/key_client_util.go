@@ -16,6 +16,8 @@ package keys
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -33,6 +35,11 @@ type HTTPResponse struct {
 }
 
 func RequestResponse(addr, method string, args map[string]string, logger logging_types.InfoTraceLogger) (string, error) {
+	return requestResponseWithClient(new(http.Client), addr, method, args, logger)
+}
+
+func requestResponseWithClient(client *http.Client, addr, method string, args map[string]string,
+	logger logging_types.InfoTraceLogger) (string, error) {
 	body, err := json.Marshal(args)
 	if err != nil {
 		return "", err
@@ -47,7 +54,7 @@ func RequestResponse(addr, method string, args map[string]string, logger logging
 		return "", err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	res, errS, err := requestResponse(req)
+	res, errS, err := requestResponse(client, req)
 	if err != nil {
 		return "", fmt.Errorf("Error calling monax-keys at %s: %s", endpoint, err.Error())
 	}
@@ -62,8 +69,7 @@ func RequestResponse(addr, method string, args map[string]string, logger logging
 	return res, nil
 }
 
-func requestResponse(req *http.Request) (string, string, error) {
-	client := new(http.Client)
+func requestResponse(client *http.Client, req *http.Request) (string, string, error) {
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", "", err
@@ -74,6 +80,37 @@ func requestResponse(req *http.Request) (string, string, error) {
 	return unpackResponse(resp)
 }
 
+// Loads the TLS config for connecting to a key server over https that
+// authenticates its clients. The client certificate and its key are given
+// together or not at all, and the server certificate is checked against the
+// CA certificates in caPath, or the system's when it is empty.
+func LoadClientTLSConfig(certPath, keyPath, caPath string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if (certPath == "") != (keyPath == "") {
+		return nil, fmt.Errorf("Both a client certificate and its key must be given " +
+			"to authenticate to the key server")
+	}
+	if certPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load client certificate %s: %v",
+				certPath, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caPath != "" {
+		caPEM, err := ioutil.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read CA certificates %s: %v", caPath, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("No CA certificates found in %s", caPath)
+		}
+	}
+	return tlsConfig, nil
+}
+
 func unpackResponse(resp *http.Response) (string, string, error) {
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
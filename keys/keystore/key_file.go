@@ -0,0 +1,81 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/tendermint/go-wire"
+)
+
+// Reads the passphrase from the first line of passphraseFile if given, else
+// from $BURROW_KEYS_PASSPHRASE, else from the first line of stdin
+func ReadPassphrase(passphraseFile string) ([]byte, error) {
+	var passphrase string
+	if passphraseFile != "" {
+		bs, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		passphrase = strings.SplitN(string(bs), "\n", 2)[0]
+	} else if env, ok := os.LookupEnv("BURROW_KEYS_PASSPHRASE"); ok {
+		passphrase = env
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, err
+		}
+		passphrase = line
+	}
+	passphrase = strings.TrimRight(passphrase, "\r\n")
+	if passphrase == "" {
+		return nil, fmt.Errorf("The passphrase is empty")
+	}
+	return []byte(passphrase), nil
+}
+
+// Reads the private account in keyFile, which is either a plain JSON key file
+// such as a priv_validator.json or a keystore. The passphrase of a keystore is
+// read as by ReadPassphrase.
+func ReadKeyFile(keyFile, passphraseFile string) (*acm.PrivAccount, error) {
+	keyJSON, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read key file: %v", err)
+	}
+	if ks, err := Unmarshal(keyJSON); err == nil && ks.Crypto.CipherText != "" {
+		passphrase, err := ReadPassphrase(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read passphrase: %v", err)
+		}
+		return Decrypt(ks, passphrase)
+	}
+	privAccount := new(acm.PrivAccount)
+	wire.ReadJSON(privAccount, keyJSON, &err)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode key file %s: %v", keyFile, err)
+	}
+	if privAccount.PrivKey == nil ||
+		!bytes.Equal(privAccount.PrivKey.PubKey().Address(), privAccount.Address) {
+		return nil, fmt.Errorf("Key file %s does not hold the private key of "+
+			"its address", keyFile)
+	}
+	return privAccount, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/go-wire"
+)
+
+func TestReadKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	privAccount := acm.GenPrivAccount()
+
+	plainFile := path.Join(dir, "key.json")
+	require.NoError(t, ioutil.WriteFile(plainFile, wire.JSONBytesPretty(privAccount), 0600))
+	read, err := ReadKeyFile(plainFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, privAccount.Address, read.Address)
+	assert.Equal(t, privAccount.PrivKey, read.PrivKey)
+
+	ks, err := Encrypt(privAccount, []byte("passphrase"), KDFPBKDF2,
+		KDFParams{C: 1, PRF: "hmac-sha256"})
+	require.NoError(t, err)
+	ksJSON, err := Marshal(ks)
+	require.NoError(t, err)
+	keystoreFile := path.Join(dir, "key.keystore")
+	require.NoError(t, ioutil.WriteFile(keystoreFile, ksJSON, 0600))
+	passphraseFile := path.Join(dir, "passphrase")
+	require.NoError(t, ioutil.WriteFile(passphraseFile, []byte("passphrase\n"), 0600))
+	read, err = ReadKeyFile(keystoreFile, passphraseFile)
+	require.NoError(t, err)
+	assert.Equal(t, privAccount.PrivKey, read.PrivKey)
+
+	require.NoError(t, ioutil.WriteFile(passphraseFile, []byte("wrong\n"), 0600))
+	_, err = ReadKeyFile(keystoreFile, passphraseFile)
+	assert.Error(t, err)
+
+	// A key file whose private key is not the key of its address
+	other := *privAccount
+	other.Address = acm.GenPrivAccount().Address
+	require.NoError(t, ioutil.WriteFile(plainFile, wire.JSONBytesPretty(&other), 0600))
+	_, err = ReadKeyFile(plainFile, "")
+	assert.Error(t, err)
+}
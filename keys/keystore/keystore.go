@@ -0,0 +1,260 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keystore encrypts private keys at rest with a passphrase, in a
+// format that follows the layout of version 3 of the Ethereum keystore. The
+// key derived from the passphrase with scrypt or PBKDF2 encrypts the private
+// key with AES-256-GCM, whose tag stands in for the MAC of the Ethereum
+// format.
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/tendermint/go-crypto"
+)
+
+const (
+	Version = 3
+
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+
+	CipherAES256GCM = "aes-256-gcm"
+
+	KeyTypeEd25519 = "ed25519"
+
+	// The derived key is an AES-256 key
+	derivedKeyLength = 32
+)
+
+type (
+	Keystore struct {
+		Version int    `json:"version"`
+		ID      string `json:"id"`
+		Address string `json:"address"`
+		KeyType string `json:"key_type"`
+		Crypto  Crypto `json:"crypto"`
+	}
+
+	Crypto struct {
+		Cipher       string       `json:"cipher"`
+		CipherText   string       `json:"ciphertext"`
+		CipherParams CipherParams `json:"cipherparams"`
+		KDF          string       `json:"kdf"`
+		KDFParams    KDFParams    `json:"kdfparams"`
+	}
+
+	CipherParams struct {
+		// The GCM nonce
+		IV string `json:"iv"`
+	}
+
+	KDFParams struct {
+		DKLen int    `json:"dklen"`
+		Salt  string `json:"salt"`
+		// scrypt
+		N int `json:"n,omitempty"`
+		R int `json:"r,omitempty"`
+		P int `json:"p,omitempty"`
+		// PBKDF2
+		C   int    `json:"c,omitempty"`
+		PRF string `json:"prf,omitempty"`
+	}
+)
+
+// The scrypt parameters geth uses by default, which take about a second to
+// derive a key
+func ScryptParams() (string, KDFParams) {
+	return KDFScrypt, KDFParams{N: 1 << 18, R: 8, P: 1}
+}
+
+func PBKDF2Params() (string, KDFParams) {
+	return KDFPBKDF2, KDFParams{C: 262144, PRF: "hmac-sha256"}
+}
+
+// Encrypts the private key of privAccount with a key derived from passphrase
+// by kdf with kdfParams, whose salt is chosen at random
+func Encrypt(privAccount *acm.PrivAccount, passphrase []byte, kdf string,
+	kdfParams KDFParams) (*Keystore, error) {
+	privKeyBytes, keyType, err := privKey(privAccount)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 32)
+	nonce := make([]byte, 12)
+	id := make([]byte, 16)
+	for _, bs := range [][]byte{salt, nonce, id} {
+		if _, err := io.ReadFull(rand.Reader, bs); err != nil {
+			return nil, err
+		}
+	}
+	kdfParams.DKLen = derivedKeyLength
+	kdfParams.Salt = hex.EncodeToString(salt)
+	derivedKey, err := deriveKey(passphrase, kdf, kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := gcm(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	address := hex.EncodeToString(privAccount.Address)
+	// The address and key type are authenticated so they cannot be swapped
+	cipherText := aead.Seal(nil, nonce, privKeyBytes, []byte(address+keyType))
+	// A random (version 4) UUID
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return &Keystore{
+		Version: Version,
+		ID: fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10],
+			id[10:]),
+		Address: address,
+		KeyType: keyType,
+		Crypto: Crypto{
+			Cipher:       CipherAES256GCM,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: CipherParams{IV: hex.EncodeToString(nonce)},
+			KDF:          kdf,
+			KDFParams:    kdfParams,
+		},
+	}, nil
+}
+
+// Decrypts the private account in keystore with passphrase
+func Decrypt(keystore *Keystore, passphrase []byte) (*acm.PrivAccount, error) {
+	if keystore.Version != Version {
+		return nil, fmt.Errorf("Keystore version %v is not supported", keystore.Version)
+	}
+	if keystore.Crypto.Cipher != CipherAES256GCM {
+		return nil, fmt.Errorf("Keystore cipher %s is not supported, only %s",
+			keystore.Crypto.Cipher, CipherAES256GCM)
+	}
+	if keystore.KeyType != KeyTypeEd25519 {
+		return nil, fmt.Errorf("Keystore key type %s is not supported",
+			keystore.KeyType)
+	}
+	if keystore.Crypto.KDFParams.DKLen != derivedKeyLength {
+		return nil, fmt.Errorf("Keystore derived key length must be %v",
+			derivedKeyLength)
+	}
+	cipherText, err := hex.DecodeString(keystore.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("Keystore ciphertext is bad hex: %v", err)
+	}
+	nonce, err := hex.DecodeString(keystore.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("Keystore iv is bad hex: %v", err)
+	}
+	derivedKey, err := deriveKey(passphrase, keystore.Crypto.KDF,
+		keystore.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := gcm(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("Keystore iv must be %v bytes", aead.NonceSize())
+	}
+	address := strings.ToLower(keystore.Address)
+	privKeyBytes, err := aead.Open(nil, nonce, cipherText,
+		[]byte(address+keystore.KeyType))
+	if err != nil {
+		return nil, fmt.Errorf("Could not decrypt keystore, the passphrase may " +
+			"be wrong")
+	}
+	if len(privKeyBytes) != 64 {
+		return nil, fmt.Errorf("Keystore holds a private key of %v bytes rather "+
+			"than 64", len(privKeyBytes))
+	}
+	privAccount := acm.GenPrivAccountFromPrivKeyBytes(privKeyBytes)
+	if hex.EncodeToString(privAccount.Address) != address {
+		return nil, fmt.Errorf("Keystore holds the key of %X rather than %s",
+			privAccount.Address, keystore.Address)
+	}
+	return privAccount, nil
+}
+
+func Marshal(keystore *Keystore) ([]byte, error) {
+	return json.MarshalIndent(keystore, "", "  ")
+}
+
+func Unmarshal(keystoreJSON []byte) (*Keystore, error) {
+	keystore := new(Keystore)
+	if err := json.Unmarshal(keystoreJSON, keystore); err != nil {
+		return nil, fmt.Errorf("Could not decode keystore: %v", err)
+	}
+	return keystore, nil
+}
+
+func privKey(privAccount *acm.PrivAccount) ([]byte, string, error) {
+	if privAccount == nil || privAccount.PrivKey == nil {
+		return nil, "", fmt.Errorf("No private key to encrypt")
+	}
+	privKey, ok := privAccount.PrivKey.(crypto.PrivKeyEd25519)
+	if !ok {
+		return nil, "", fmt.Errorf("Only ed25519 private keys can be encrypted")
+	}
+	if !bytes.Equal(privKey.PubKey().Address(), privAccount.Address) {
+		return nil, "", fmt.Errorf("Private key is not the key of %X",
+			privAccount.Address)
+	}
+	return privKey[:], KeyTypeEd25519, nil
+}
+
+func deriveKey(passphrase []byte, kdf string, kdfParams KDFParams) ([]byte, error) {
+	salt, err := hex.DecodeString(kdfParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("Keystore salt is bad hex: %v", err)
+	}
+	switch kdf {
+	case KDFScrypt:
+		return scrypt.Key(passphrase, salt, kdfParams.N, kdfParams.R, kdfParams.P,
+			kdfParams.DKLen)
+	case KDFPBKDF2:
+		if kdfParams.PRF != "hmac-sha256" {
+			return nil, fmt.Errorf("Keystore PBKDF2 PRF %s is not supported",
+				kdfParams.PRF)
+		}
+		if kdfParams.C < 1 {
+			return nil, fmt.Errorf("Keystore PBKDF2 iteration count must be positive")
+		}
+		return pbkdf2.Key(passphrase, salt, kdfParams.C, kdfParams.DKLen,
+			sha256.New), nil
+	}
+	return nil, fmt.Errorf("Keystore KDF %s is not supported", kdf)
+}
+
+func gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
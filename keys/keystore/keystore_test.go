@@ -0,0 +1,70 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	privAccount := acm.GenPrivAccount()
+	passphrase := []byte("correct horse battery staple")
+	// Cheap parameters so the test runs quickly
+	for kdf, kdfParams := range map[string]KDFParams{
+		KDFScrypt: {N: 1 << 10, R: 8, P: 1},
+		KDFPBKDF2: {C: 1000, PRF: "hmac-sha256"},
+	} {
+		ks, err := Encrypt(privAccount, passphrase, kdf, kdfParams)
+		require.NoError(t, err)
+		ksJSON, err := Marshal(ks)
+		require.NoError(t, err)
+		ks, err = Unmarshal(ksJSON)
+		require.NoError(t, err)
+		assert.Equal(t, Version, ks.Version)
+		assert.Len(t, ks.ID, 36)
+
+		decrypted, err := Decrypt(ks, passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, privAccount.Address, decrypted.Address)
+		assert.Equal(t, privAccount.PrivKey, decrypted.PrivKey)
+		assert.Equal(t, privAccount.PubKey, decrypted.PubKey)
+
+		_, err = Decrypt(ks, []byte("wrong"))
+		assert.Error(t, err)
+
+		// The address is authenticated
+		other := *ks
+		other.Address = "0000000000000000000000000000000000000000"
+		_, err = Decrypt(&other, passphrase)
+		assert.Error(t, err)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	kdf, kdfParams := ScryptParams()
+	_, err := Encrypt(nil, []byte("passphrase"), kdf, kdfParams)
+	assert.Error(t, err)
+
+	ks, err := Encrypt(acm.GenPrivAccount(), []byte("passphrase"), KDFPBKDF2,
+		KDFParams{C: 1, PRF: "hmac-sha256"})
+	require.NoError(t, err)
+	ks.Crypto.KDF = "argon2"
+	_, err = Decrypt(ks, []byte("passphrase"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/tendermint/go-crypto"
+)
+
+var _ KeyClient = (*localKeyClient)(nil)
+
+// Signs with private keys held in memory, such as those read from key files,
+// so that txs can be signed without monax-keys and without touching the
+// network
+type localKeyClient struct {
+	privAccounts map[string]*acm.PrivAccount
+}
+
+func NewLocalKeyClient(privAccounts ...*acm.PrivAccount) *localKeyClient {
+	keyClient := &localKeyClient{
+		privAccounts: make(map[string]*acm.PrivAccount, len(privAccounts)),
+	}
+	for _, privAccount := range privAccounts {
+		keyClient.privAccounts[fmt.Sprintf("%X", privAccount.Address)] = privAccount
+	}
+	return keyClient
+}
+
+func (local *localKeyClient) Sign(signBytesString string, signAddress []byte) ([]byte, error) {
+	privAccount := local.privAccounts[fmt.Sprintf("%X", signAddress)]
+	if privAccount == nil {
+		return nil, fmt.Errorf("No key file holds the key of %X", signAddress)
+	}
+	signBytes, err := hex.DecodeString(signBytesString)
+	if err != nil {
+		return nil, fmt.Errorf("Sign bytes string is invalid hex string: %s", err.Error())
+	}
+	signature, ok := privAccount.PrivKey.Sign(signBytes).(crypto.SignatureEd25519)
+	if !ok {
+		return nil, fmt.Errorf("Only ed25519 keys can sign txs")
+	}
+	return signature[:], nil
+}
+
+func (local *localKeyClient) PublicKey(address []byte) ([]byte, error) {
+	privAccount := local.privAccounts[fmt.Sprintf("%X", address)]
+	if privAccount == nil {
+		return nil, fmt.Errorf("No key file holds the key of %X", address)
+	}
+	pubKey, ok := privAccount.PrivKey.PubKey().(crypto.PubKeyEd25519)
+	if !ok {
+		return nil, fmt.Errorf("Only ed25519 keys can sign txs")
+	}
+	return pubKey[:], nil
+}
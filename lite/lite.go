@@ -0,0 +1,212 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The lite package verifies the block headers served by an untrusted node
+// against the commit signatures of a validator set the client trusts, so
+// that state read from the node can be checked against a verified AppHash.
+package lite
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// A Source provides the blocks and validators of a chain. It need not be
+// trusted.
+type Source interface {
+	// Get the block at height
+	Block(height int) (*tm_types.Block, error)
+	// Get the current validators of the chain
+	Validators() ([]*tm_types.Validator, error)
+}
+
+// A Client verifies headers from a Source starting from a trusted set of
+// validators, such as those in the genesis of the chain. It follows changes
+// to the validator set as it verifies headers. A Client is safe for
+// concurrent use.
+type Client struct {
+	mtx        sync.Mutex
+	chainID    string
+	source     Source
+	validators *tm_types.ValidatorSet
+	header     *tm_types.Header
+}
+
+func NewClient(chainID string, trustedValidators []*tm_types.Validator,
+	source Source) *Client {
+	return &Client{
+		chainID:    chainID,
+		source:     source,
+		validators: tm_types.NewValidatorSet(trustedValidators),
+	}
+}
+
+// Get the most recent header verified, nil if none has been
+func (client *Client) LastVerifiedHeader() *tm_types.Header {
+	client.mtx.Lock()
+	defer client.mtx.Unlock()
+	return client.header
+}
+
+// Fetches the header at height and verifies that more than two thirds of the
+// trusted validators' voting power signed it. The signatures for a block are
+// carried by the LastCommit of its successor, so only heights below the
+// latest can be verified.
+//
+// When the header names a validator set other than the trusted one, the
+// current validators of the source are fetched and are trusted from then on
+// if they are the set named by the header and more than two thirds of the
+// previously trusted voting power also signed it. Headers should therefore
+// be verified in order (or at least at each change of validators), since the
+// validators at past heights cannot be fetched from the source.
+func (client *Client) VerifyHeader(height int) (*tm_types.Header, error) {
+	client.mtx.Lock()
+	defer client.mtx.Unlock()
+	block, err := client.source.Block(height)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get block at height %v: %v", height, err)
+	}
+	next, err := client.source.Block(height + 1)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the commit for height %v from the "+
+			"block at height %v: %v", height, height+1, err)
+	}
+	header := block.Header
+	if header == nil || next.Header == nil || next.LastCommit == nil {
+		return nil, fmt.Errorf("Block at height %v or %v is incomplete", height,
+			height+1)
+	}
+	if header.ChainID != client.chainID {
+		return nil, fmt.Errorf("Header at height %v is for chain %s not %s",
+			height, header.ChainID, client.chainID)
+	}
+	if header.Height != height {
+		return nil, fmt.Errorf("Asked for header at height %v but got height %v",
+			height, header.Height)
+	}
+	// The commit is for the block ID its successor builds on
+	blockID := next.LastBlockID
+	if !bytes.Equal(blockID.Hash, header.Hash()) {
+		return nil, fmt.Errorf("Block at height %v does not build on the header "+
+			"at height %v", height+1, height)
+	}
+	validators := client.validators
+	if !bytes.Equal(header.ValidatorsHash, validators.Hash()) {
+		if validators, err = client.nextValidators(header, blockID, next.LastCommit); err != nil {
+			return nil, err
+		}
+	}
+	err = validators.VerifyCommit(client.chainID, blockID, height, next.LastCommit)
+	if err != nil {
+		return nil, fmt.Errorf("Could not verify commit for height %v: %v", height, err)
+	}
+	client.validators = validators
+	if client.header == nil || header.Height > client.header.Height {
+		client.header = header
+	}
+	return header, nil
+}
+
+// Verifies an account fetched with its proof from a node (for example with
+// GetAccountWithProof) against the AppHash of the state at its height. The
+// AppHash is taken from the verified header of the next height.
+func (client *Client) VerifyAccount(accountWithProof *core_types.AccountWithProof) error {
+	header, err := client.VerifyHeader(accountWithProof.Height + 1)
+	if err != nil {
+		return err
+	}
+	if !accountWithProof.Verify(header.AppHash) {
+		return fmt.Errorf("Proof of account does not match AppHash %X at "+
+			"height %v", header.AppHash, header.Height)
+	}
+	return nil
+}
+
+// Verifies a storage item fetched with its proof from a node (for example
+// with GetStorageAtWithProof) against the AppHash of the state at its height.
+func (client *Client) VerifyStorageItem(itemWithProof *core_types.StorageItemWithProof) error {
+	header, err := client.VerifyHeader(itemWithProof.Height + 1)
+	if err != nil {
+		return err
+	}
+	if !itemWithProof.Verify(header.AppHash) {
+		return fmt.Errorf("Proof of storage item does not match AppHash %X at "+
+			"height %v", header.AppHash, header.Height)
+	}
+	return nil
+}
+
+// Fetches the validators named by header from the source and checks that the
+// trusted validators hand over to them by signing the commit for header
+func (client *Client) nextValidators(header *tm_types.Header,
+	blockID tm_types.BlockID, commit *tm_types.Commit) (*tm_types.ValidatorSet, error) {
+	currentValidators, err := client.source.Validators()
+	if err != nil {
+		return nil, fmt.Errorf("Could not get validators: %v", err)
+	}
+	validators := tm_types.NewValidatorSet(currentValidators)
+	if !bytes.Equal(header.ValidatorsHash, validators.Hash()) {
+		return nil, fmt.Errorf("Validators changed at height %v but the validators "+
+			"for that height are not available from the source", header.Height)
+	}
+	err = VerifyHandover(client.chainID, client.validators, blockID,
+		header.Height, commit)
+	if err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// Checks that more than two thirds of the voting power of the trusted
+// validators signed commit for blockID at height, so that they hand over to
+// the validators named by the header of blockID
+func VerifyHandover(chainID string, trusted *tm_types.ValidatorSet,
+	blockID tm_types.BlockID, height int, commit *tm_types.Commit) error {
+	power := signedPower(chainID, trusted, blockID, height, commit)
+	if power*3 <= trusted.TotalVotingPower()*2 {
+		return fmt.Errorf("Validators changed at height %v but only %v of "+
+			"the %v voting power of the trusted validators signed the change",
+			height, power, trusted.TotalVotingPower())
+	}
+	return nil
+}
+
+// Returns the voting power of the members of validators with valid precommits
+// for blockID at height in commit
+func signedPower(chainID string, validators *tm_types.ValidatorSet,
+	blockID tm_types.BlockID, height int, commit *tm_types.Commit) int64 {
+	power := int64(0)
+	signed := make(map[string]bool)
+	for _, precommit := range commit.Precommits {
+		if precommit == nil || precommit.Height != height ||
+			precommit.Type != tm_types.VoteTypePrecommit ||
+			!blockID.Equals(precommit.BlockID) ||
+			signed[string(precommit.ValidatorAddress)] {
+			continue
+		}
+		_, validator := validators.GetByAddress(precommit.ValidatorAddress)
+		if validator == nil || !validator.PubKey.VerifyBytes(
+			tm_types.SignBytes(chainID, precommit), precommit.Signature) {
+			continue
+		}
+		signed[string(precommit.ValidatorAddress)] = true
+		power += validator.VotingPower
+	}
+	return power
+}
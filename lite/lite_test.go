@@ -0,0 +1,192 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lite
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+const chainID = "lite-test"
+
+type testValidators struct {
+	privKeys map[string]crypto.PrivKey
+	set      *tm_types.ValidatorSet
+}
+
+func newTestValidators(n int) *testValidators {
+	tvs := &testValidators{privKeys: make(map[string]crypto.PrivKey)}
+	validators := make([]*tm_types.Validator, n)
+	for i := range validators {
+		privKey := crypto.GenPrivKeyEd25519()
+		validators[i] = tm_types.NewValidator(privKey.PubKey(), 10)
+		tvs.privKeys[string(validators[i].Address)] = privKey
+	}
+	tvs.set = tm_types.NewValidatorSet(validators)
+	return tvs
+}
+
+// Signs a commit of blockID at height by all the validators, except those at
+// the indices in absent
+func (tvs *testValidators) commit(blockID tm_types.BlockID, height int,
+	absent ...int) *tm_types.Commit {
+	commit := &tm_types.Commit{
+		BlockID:    blockID,
+		Precommits: make([]*tm_types.Vote, tvs.set.Size()),
+	}
+	for i, validator := range tvs.set.Validators {
+		if contains(absent, i) {
+			continue
+		}
+		vote := &tm_types.Vote{
+			ValidatorAddress: validator.Address,
+			ValidatorIndex:   i,
+			Height:           height,
+			Type:             tm_types.VoteTypePrecommit,
+			BlockID:          blockID,
+		}
+		vote.Signature = tvs.privKeys[string(validator.Address)].Sign(
+			tm_types.SignBytes(chainID, vote))
+		commit.Precommits[i] = vote
+	}
+	return commit
+}
+
+type testSource struct {
+	blocks     map[int]*tm_types.Block
+	validators []*tm_types.Validator
+}
+
+func (source *testSource) Block(height int) (*tm_types.Block, error) {
+	block, ok := source.blocks[height]
+	if !ok {
+		return nil, fmt.Errorf("No block at height %v", height)
+	}
+	return block, nil
+}
+
+func (source *testSource) Validators() ([]*tm_types.Validator, error) {
+	return source.validators, nil
+}
+
+// Makes a chain of blocks up to height signed by the validators given for
+// each height
+func makeChain(validatorsAt func(height int) *testValidators, height int) *testSource {
+	source := &testSource{blocks: make(map[int]*tm_types.Block)}
+	var lastBlockID tm_types.BlockID
+	for h := 1; h <= height+1; h++ {
+		block := &tm_types.Block{
+			Header: &tm_types.Header{
+				ChainID:        chainID,
+				Height:         h,
+				LastBlockID:    lastBlockID,
+				ValidatorsHash: validatorsAt(h).set.Hash(),
+				AppHash:        []byte{byte(h)},
+			},
+			Data:       &tm_types.Data{},
+			LastCommit: &tm_types.Commit{},
+		}
+		if h > 1 {
+			block.LastCommit = validatorsAt(h-1).commit(lastBlockID, h-1)
+		}
+		lastBlockID = tm_types.BlockID{Hash: block.Hash()}
+		source.blocks[h] = block
+	}
+	source.validators = validatorsAt(height).set.Validators
+	return source
+}
+
+func TestVerifyHeader(t *testing.T) {
+	tvs := newTestValidators(4)
+	source := makeChain(func(int) *testValidators { return tvs }, 3)
+	client := NewClient(chainID, tvs.set.Validators, source)
+	for height := 1; height <= 3; height++ {
+		header, err := client.VerifyHeader(height)
+		assert.NoError(t, err)
+		assert.Equal(t, height, header.Height)
+	}
+	assert.Equal(t, 3, client.LastVerifiedHeader().Height)
+
+	// The latest block has no commit yet
+	_, err := client.VerifyHeader(4)
+	assert.Error(t, err)
+
+	// Too few signatures
+	source.blocks[3].LastCommit = tvs.commit(source.blocks[3].LastBlockID, 2, 0, 1)
+	_, err = client.VerifyHeader(2)
+	assert.Error(t, err)
+
+	// Untrusted validators
+	_, err = NewClient(chainID, newTestValidators(4).set.Validators,
+		source).VerifyHeader(1)
+	assert.Error(t, err)
+
+	// A tampered header no longer matches the hash its successor signed
+	source = makeChain(func(int) *testValidators { return tvs }, 3)
+	source.blocks[2].AppHash = []byte("forged")
+	_, err = NewClient(chainID, tvs.set.Validators, source).VerifyHeader(2)
+	assert.Error(t, err)
+}
+
+func TestVerifyHeaderValidatorChange(t *testing.T) {
+	oldValidators := newTestValidators(3)
+	// The new set shares enough of the old set to sign over the change
+	newValidators := newTestValidators(1)
+	for address, privKey := range oldValidators.privKeys {
+		newValidators.privKeys[address] = privKey
+	}
+	newValidators.set = tm_types.NewValidatorSet(append(
+		oldValidators.set.Copy().Validators, newValidators.set.Validators...))
+
+	validatorsAt := func(height int) *testValidators {
+		if height >= 3 {
+			return newValidators
+		}
+		return oldValidators
+	}
+	source := makeChain(validatorsAt, 4)
+	client := NewClient(chainID, oldValidators.set.Validators, source)
+	for height := 1; height <= 4; height++ {
+		_, err := client.VerifyHeader(height)
+		assert.NoError(t, err)
+	}
+
+	// A wholly new set cannot be handed over to
+	strangers := newTestValidators(4)
+	source = makeChain(func(height int) *testValidators {
+		if height >= 3 {
+			return strangers
+		}
+		return oldValidators
+	}, 4)
+	client = NewClient(chainID, oldValidators.set.Validators, source)
+	_, err := client.VerifyHeader(2)
+	assert.NoError(t, err)
+	_, err = client.VerifyHeader(3)
+	assert.Error(t, err)
+}
+
+func contains(is []int, i int) bool {
+	for _, j := range is {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
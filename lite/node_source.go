@@ -0,0 +1,62 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lite
+
+import (
+	"fmt"
+
+	consensus_types "github.com/hyperledger/burrow/consensus/types"
+	tendermint_client "github.com/hyperledger/burrow/rpc/tendermint/client"
+
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// A Source reading from the tendermint RPC of a burrow node
+type nodeSource struct {
+	client tendermint_client.RPCClient
+}
+
+var _ Source = (*nodeSource)(nil)
+
+func NewNodeSource(client tendermint_client.RPCClient) *nodeSource {
+	return &nodeSource{client}
+}
+
+func (source *nodeSource) Block(height int) (*tm_types.Block, error) {
+	result, err := tendermint_client.GetBlock(source.client, height)
+	if err != nil {
+		return nil, err
+	}
+	if result.Block == nil {
+		return nil, fmt.Errorf("No block at height %v", height)
+	}
+	return result.Block, nil
+}
+
+func (source *nodeSource) Validators() ([]*tm_types.Validator, error) {
+	result, err := tendermint_client.ListValidators(source.client)
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]*tm_types.Validator, 0, len(result.BondedValidators))
+	for _, validator := range result.BondedValidators {
+		tendermintValidator, ok := validator.(*consensus_types.TendermintValidator)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported validator type %T", validator)
+		}
+		validators = append(validators, tendermintValidator.Validator)
+	}
+	return validators, nil
+}
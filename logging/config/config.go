@@ -10,7 +10,12 @@ import (
 )
 
 type LoggingConfig struct {
-	RootSink *SinkConfig `toml:"root_sink,omitempty"`
+	// The level of lines from subsystems not listed in Subsystems and from no
+	// subsystem at all, which are passed to RootSink unfiltered if empty
+	DefaultLevel string `toml:"default_level,omitempty"`
+	// The level of each named subsystem (see structure.Subsystems)
+	Subsystems map[string]string `toml:"subsystems,omitempty"`
+	RootSink   *SinkConfig       `toml:"root_sink,omitempty"`
 }
 
 // For encoding a top-level '[logging]' TOML table
@@ -0,0 +1,146 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/hyperledger/burrow/logging/types"
+)
+
+// The levels of a subsystem, from quietest to noisiest
+const (
+	// Drop all lines
+	OffLevel = "off"
+	// Keep lines on the Info channel
+	InfoLevel = "info"
+	// Keep lines on both the Info and Trace channels
+	TraceLevel = "trace"
+)
+
+// Build the root logger, dropping the lines of each subsystem that are beneath
+// its configured level before they reach RootSink
+func (lc *LoggingConfig) BuildLogger() (kitlog.Logger, map[string]*loggers.CaptureLogger, error) {
+	rootSink := lc.RootSink
+	if rootSink == nil {
+		rootSink = DefaultNodeLoggingConfig().RootSink
+	}
+	outputLogger, captures, err := rootSink.BuildLogger()
+	if err != nil {
+		return nil, nil, err
+	}
+	predicate, err := lc.levelsPredicate()
+	if err != nil {
+		return nil, nil, err
+	}
+	if predicate == nil {
+		return outputLogger, captures, nil
+	}
+	return loggers.NewFilterLogger(outputLogger, predicate), captures, nil
+}
+
+// Returns a predicate that is true for the lines to drop or nil if no levels
+// are configured
+func (lc *LoggingConfig) levelsPredicate() (func([]interface{}) bool, error) {
+	if lc.DefaultLevel == "" && len(lc.Subsystems) == 0 {
+		return nil, nil
+	}
+	defaultLevel, err := parseLevel(lc.DefaultLevel)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid default_level: %v", err)
+	}
+	levels := make(map[string]string, len(lc.Subsystems))
+	for subsystem, level := range lc.Subsystems {
+		if !isSubsystem(subsystem) {
+			return nil, fmt.Errorf("Unknown logging subsystem '%s', subsystems are: %s",
+				subsystem, strings.Join(structure.Subsystems, ", "))
+		}
+		levels[subsystem], err = parseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid level for subsystem %s: %v", subsystem, err)
+		}
+	}
+	return func(keyvals []interface{}) bool {
+		level := defaultLevel
+		if subsystemLevel, ok := levels[subsystemOf(keyvals)]; ok {
+			level = subsystemLevel
+		}
+		switch level {
+		case OffLevel:
+			return true
+		case InfoLevel:
+			return channelOf(keyvals) != types.InfoChannelName
+		default:
+			return false
+		}
+	}, nil
+}
+
+// An empty level keeps every line
+func parseLevel(level string) (string, error) {
+	switch strings.ToLower(level) {
+	case OffLevel:
+		return OffLevel, nil
+	case InfoLevel:
+		return InfoLevel, nil
+	case TraceLevel, "":
+		return TraceLevel, nil
+	default:
+		return "", fmt.Errorf("unknown level '%s', levels are: %s, %s, %s",
+			level, OffLevel, InfoLevel, TraceLevel)
+	}
+}
+
+func isSubsystem(subsystem string) bool {
+	for _, s := range structure.Subsystems {
+		if s == subsystem {
+			return true
+		}
+	}
+	return false
+}
+
+// The innermost subsystem of a (vectorised) log line
+func subsystemOf(keyvals []interface{}) string {
+	for i := 0; i < 2*(len(keyvals)/2); i += 2 {
+		if keyvals[i] == structure.SubsystemKey {
+			switch v := keyvals[i+1].(type) {
+			case string:
+				return v
+			case []interface{}:
+				if len(v) > 0 {
+					s, _ := v[len(v)-1].(string)
+					return s
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+func channelOf(keyvals []interface{}) string {
+	for i := 0; i < 2*(len(keyvals)/2); i += 2 {
+		if keyvals[i] == structure.ChannelKey {
+			s, _ := keyvals[i+1].(string)
+			return s
+		}
+	}
+	return ""
+}
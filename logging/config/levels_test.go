@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/hyperledger/burrow/logging/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingConfig_BuildLogger(t *testing.T) {
+	lc := &LoggingConfig{
+		DefaultLevel: "info",
+		Subsystems: map[string]string{
+			structure.EVMSubsystem: "trace",
+			structure.RPCSubsystem: "off",
+		},
+		RootSink: Sink().SetTransform(CaptureTransform("cap", 100, false)),
+	}
+	logger, captures, err := lc.BuildLogger()
+	require.NoError(t, err)
+
+	info := structure.ChannelKey
+	logger.Log(info, types.InfoChannelName, structure.SubsystemKey, structure.EVMSubsystem, "line", "1")
+	logger.Log(info, types.TraceChannelName, structure.SubsystemKey, structure.EVMSubsystem, "line", "2")
+	logger.Log(info, types.InfoChannelName, structure.SubsystemKey, structure.RPCSubsystem, "line", "3")
+	logger.Log(info, types.InfoChannelName, structure.SubsystemKey, structure.StateSubsystem, "line", "4")
+	logger.Log(info, types.TraceChannelName, structure.SubsystemKey, structure.StateSubsystem, "line", "5")
+	logger.Log(info, types.TraceChannelName, "line", "6")
+	// The innermost subsystem applies
+	logger.Log(info, types.TraceChannelName, structure.SubsystemKey,
+		[]interface{}{structure.StateSubsystem, structure.EVMSubsystem}, "line", "7")
+
+	var lines []interface{}
+	for _, line := range captures["cap"].BufferLogger().FlushLogLines() {
+		lines = append(lines, line[len(line)-1])
+	}
+	assert.Equal(t, []interface{}{"1", "2", "4", "7"}, lines)
+}
+
+func TestLoggingConfig_BuildLoggerInvalid(t *testing.T) {
+	_, _, err := (&LoggingConfig{DefaultLevel: "debug"}).BuildLogger()
+	assert.Error(t, err)
+	_, _, err = (&LoggingConfig{Subsystems: map[string]string{"vm": "info"}}).BuildLogger()
+	assert.Error(t, err)
+	// No levels leaves lines unfiltered
+	_, _, err = (&LoggingConfig{}).BuildLogger()
+	assert.NoError(t, err)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"net/url"
 
@@ -23,6 +24,7 @@ const (
 	// OutputType
 	NoOutput outputType = ""
 	Graylog  outputType = "graylog"
+	Loki     outputType = "loki"
 	Syslog   outputType = "syslog"
 	File     outputType = "file"
 	Stdout   outputType = "stdout"
@@ -80,22 +82,42 @@ func (mode filterMode) MatchAny() bool {
 // Sink configuration types
 type (
 	// Outputs
+	// GELF over udp or tcp, for example udp://graylog:12201. Host is the source
+	// of the messages, the hostname by default.
 	GraylogConfig struct {
+		Address string `toml:"address"`
+		Host    string `toml:"host,omitempty"`
 	}
 
+	// Pushes to a URL like http://loki:3100/loki/api/v1/push with a stream
+	// labelled by Labels, job = "burrow" by default
+	LokiConfig struct {
+		PushUrl string            `toml:"push_url"`
+		Labels  map[string]string `toml:"labels,omitempty"`
+	}
+
+	// The facility is local0 by default
 	SyslogConfig struct {
-		Url string `toml:"url"`
-		Tag string `toml:"tag"`
+		Url      string `toml:"url"`
+		Tag      string `toml:"tag"`
+		Facility string `toml:"facility,omitempty"`
 	}
 
+	// The file is rotated once it would grow beyond MaxSizeMB megabytes or has
+	// been open for MaxAge (a duration like "24h"), keeping at most MaxBackups
+	// rotated files. Zero or empty is no limit.
 	FileConfig struct {
-		Path string `toml:"path"`
+		Path       string `toml:"path"`
+		MaxSizeMB  int64  `toml:"max_size_mb,omitempty"`
+		MaxAge     string `toml:"max_age,omitempty"`
+		MaxBackups int    `toml:"max_backups,omitempty"`
 	}
 
 	OutputConfig struct {
 		OutputType outputType `toml:"output_type"`
 		Format     string     `toml:"format,omitempty"`
 		*GraylogConfig
+		*LokiConfig
 		*FileConfig
 		*SyslogConfig
 	}
@@ -200,6 +222,43 @@ func FileOutput(path string) *OutputConfig {
 	}
 }
 
+func RotatingFileOutput(path string, maxSizeMB int64, maxAge string,
+	maxBackups int) *OutputConfig {
+	return &OutputConfig{
+		OutputType: File,
+		FileConfig: &FileConfig{
+			Path:       path,
+			MaxSizeMB:  maxSizeMB,
+			MaxAge:     maxAge,
+			MaxBackups: maxBackups,
+		},
+	}
+}
+
+func GraylogOutput(address string) *OutputConfig {
+	return &OutputConfig{
+		OutputType: Graylog,
+		GraylogConfig: &GraylogConfig{
+			Address: address,
+		},
+	}
+}
+
+func LokiOutput(pushUrl string, labelKeyvals ...string) *OutputConfig {
+	length := len(labelKeyvals) / 2
+	labels := make(map[string]string, length)
+	for i := 0; i < 2*length; i += 2 {
+		labels[labelKeyvals[i]] = labelKeyvals[i+1]
+	}
+	return &OutputConfig{
+		OutputType: Loki,
+		LokiConfig: &LokiConfig{
+			PushUrl: pushUrl,
+			Labels:  labels,
+		},
+	}
+}
+
 func RemoteSyslogOutput(tag, remoteUrl string) *OutputConfig {
 	return &OutputConfig{
 		OutputType: Syslog,
@@ -307,7 +366,22 @@ func BuildOutputLogger(outputConfig *OutputConfig) (kitlog.Logger, error) {
 	switch outputConfig.OutputType {
 	case NoOutput:
 		return kitlog.NewNopLogger(), nil
-	//case Graylog:
+	case Graylog:
+		if outputConfig.GraylogConfig == nil || outputConfig.GraylogConfig.Address == "" {
+			return nil, fmt.Errorf("Graylog output needs an address")
+		}
+		address, err := url.Parse(outputConfig.GraylogConfig.Address)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing Graylog address: %s, error: %s",
+				outputConfig.GraylogConfig.Address, err)
+		}
+		return loggers.NewGELFLogger(address, outputConfig.GraylogConfig.Host)
+	case Loki:
+		if outputConfig.LokiConfig == nil || outputConfig.LokiConfig.PushUrl == "" {
+			return nil, fmt.Errorf("Loki output needs a push_url")
+		}
+		return loggers.NewLokiLogger(outputConfig.LokiConfig.PushUrl,
+			outputConfig.LokiConfig.Labels, outputConfig.Format), nil
 	case Syslog:
 		urlString := outputConfig.SyslogConfig.Url
 		if urlString != "" {
@@ -318,16 +392,31 @@ func BuildOutputLogger(outputConfig *OutputConfig) (kitlog.Logger, error) {
 					urlString, err)
 			}
 			return loggers.NewRemoteSyslogLogger(remoteUrl,
-				outputConfig.SyslogConfig.Tag, outputConfig.Format)
+				outputConfig.SyslogConfig.Tag, outputConfig.SyslogConfig.Facility,
+				outputConfig.Format)
 		}
 		return loggers.NewSyslogLogger(outputConfig.SyslogConfig.Tag,
-			outputConfig.Format)
+			outputConfig.SyslogConfig.Facility, outputConfig.Format)
 	case Stdout:
 		return loggers.NewStreamLogger(os.Stdout, outputConfig.Format), nil
 	case Stderr:
 		return loggers.NewStreamLogger(os.Stderr, outputConfig.Format), nil
 	case File:
-		return loggers.NewFileLogger(outputConfig.FileConfig.Path, outputConfig.Format)
+		fileConfig := outputConfig.FileConfig
+		if fileConfig.MaxSizeMB == 0 && fileConfig.MaxAge == "" {
+			return loggers.NewFileLogger(fileConfig.Path, outputConfig.Format)
+		}
+		var maxAge time.Duration
+		if fileConfig.MaxAge != "" {
+			var err error
+			maxAge, err = time.ParseDuration(fileConfig.MaxAge)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing max_age of log file %s: %s",
+					fileConfig.Path, err)
+			}
+		}
+		return loggers.NewRotatingFileLogger(fileConfig.Path, fileConfig.MaxSizeMB<<20,
+			maxAge, fileConfig.MaxBackups, outputConfig.Format)
 	default:
 		return nil, fmt.Errorf("Could not build logger for output: '%s'",
 			outputConfig.OutputType)
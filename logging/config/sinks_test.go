@@ -3,6 +3,7 @@ package config
 import (
 	"testing"
 
+	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -115,3 +116,25 @@ func logLines(keyvals ...string) [][]interface{} {
 	}
 	return llines
 }
+
+func TestNetworkAndRotatingOutputsTOML(t *testing.T) {
+	lc := &LoggingConfig{
+		RootSink: Sink().
+			AddSinks(
+				Sink().SetOutput(RotatingFileOutput("/var/log/burrow.log", 100, "24h", 7)),
+				Sink().SetOutput(GraylogOutput("udp://graylog:12201")),
+				Sink().SetOutput(LokiOutput("http://loki:3100/loki/api/v1/push",
+					"chain", "test").SetFormat("json")),
+			),
+	}
+	decoded := new(LoggingConfig)
+	_, err := toml.Decode(lc.TOMLString(), decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, lc, decoded)
+
+	_, _, err = Sink().SetOutput(RotatingFileOutput("/var/log/burrow.log", 0, "a day", 0)).
+		BuildLogger()
+	assert.Error(t, err)
+	_, _, err = Sink().SetOutput(GraylogOutput("")).BuildLogger()
+	assert.Error(t, err)
+}
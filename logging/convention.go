@@ -44,6 +44,13 @@ func WithScope(logger types.InfoTraceLogger, scopeName string) types.InfoTraceLo
 	return logger.With(structure.ScopeKey, scopeName)
 }
 
+// Mark the log lines of this logger as belonging to a subsystem so that they are
+// filtered by the level configured for that subsystem. When subsystems are
+// nested the innermost one applies.
+func WithSubsystem(logger types.InfoTraceLogger, subsystem string) types.InfoTraceLogger {
+	return logger.With(structure.SubsystemKey, subsystem)
+}
+
 // Record a structured log line with a message
 func Msg(logger kitlog.Logger, message string, keyvals ...interface{}) error {
 	prepended := slice.CopyPrepend(keyvals, structure.MessageKey, message)
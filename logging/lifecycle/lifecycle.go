@@ -96,7 +96,8 @@ func NewLogger(outputLogger kitlog.Logger) (types.InfoTraceLogger, channels.Chan
 func CaptureTendermintLog15Output(infoTraceLogger types.InfoTraceLogger) {
 	tmLog15.Root().SetHandler(
 		tmLog15adapter.InfoTraceLoggerAsLog15Handler(infoTraceLogger.
-			With(structure.CapturedLoggingSourceKey, "tendermint_log15")))
+			With(structure.CapturedLoggingSourceKey, "tendermint_log15",
+				structure.SubsystemKey, structure.ConsensusSubsystem)))
 }
 
 func CaptureStdlibLogOutput(infoTraceLogger types.InfoTraceLogger) {
@@ -106,7 +107,7 @@ func CaptureStdlibLogOutput(infoTraceLogger types.InfoTraceLogger) {
 
 // Helpers
 func infoTraceLoggerFromLoggingConfig(loggingConfig *config.LoggingConfig) (kitlog.Logger, error) {
-	outputLogger, _, err := loggingConfig.RootSink.BuildLogger()
+	outputLogger, _, err := loggingConfig.BuildLogger()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,81 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/config"
+	"github.com/hyperledger/burrow/logging/types"
+)
+
+var reloader struct {
+	sync.Mutex
+	logger     types.InfoTraceLogger
+	loadConfig func() (*config.LoggingConfig, error)
+}
+
+// Swap the output loggers of logger for those built from the LoggingConfig
+// returned by loadConfig whenever the process receives SIGHUP or ReloadLogging
+// is called, so that subsystem levels and sinks can be changed without a
+// restart. A nil LoggingConfig reverts to the default node logging config.
+func ReloadLoggingOnSignal(logger types.InfoTraceLogger,
+	loadConfig func() (*config.LoggingConfig, error)) {
+	reloader.Lock()
+	reloader.logger = logger
+	reloader.loadConfig = loadConfig
+	reloader.Unlock()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			err := ReloadLogging()
+			if err != nil {
+				logging.InfoMsg(logger, "Failed to reload logging config on SIGHUP",
+					"error", err)
+			}
+		}
+	}()
+}
+
+// Reload the logging config registered with ReloadLoggingOnSignal
+func ReloadLogging() error {
+	reloader.Lock()
+	defer reloader.Unlock()
+	if reloader.loadConfig == nil {
+		return fmt.Errorf("Logging cannot be reloaded since it was not " +
+			"configured from a reloadable source")
+	}
+	loggingConfig, err := reloader.loadConfig()
+	if err != nil {
+		return err
+	}
+	if loggingConfig == nil {
+		loggingConfig = config.DefaultNodeLoggingConfig()
+	}
+	err = SwapOutputLoggersFromLoggingConfig(reloader.logger, loggingConfig)
+	if err != nil {
+		return err
+	}
+	logging.InfoMsg(reloader.logger, "Reloaded logging config",
+		"logging_config", loggingConfig.RootTOMLString())
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loggers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/hyperledger/burrow/logging/types"
+)
+
+const (
+	// Graylog's recommended UDP chunk size, which fits the MTU of most networks
+	gelfChunkSize = 1420
+	// Graylog drops messages of more chunks than this
+	gelfMaxChunks = 128
+	// Syslog severities
+	gelfInfoLevel  = 6
+	gelfDebugLevel = 7
+)
+
+var (
+	gelfChunkMagic  = []byte{0x1e, 0x0f}
+	gelfFieldRegexp = regexp.MustCompile(`[^\w\.\-]`)
+)
+
+// Sends log lines to Graylog as GELF 1.1 messages over udp (chunked when large)
+// or tcp (null delimited). Lines on the Info channel have level informational
+// and all others debug. The remaining key-values become additional fields.
+type gelfLogger struct {
+	sync.Mutex
+	network string
+	address string
+	host    string
+	conn    net.Conn
+}
+
+var _ kitlog.Logger = (*gelfLogger)(nil)
+
+// Creates a GELF logger for an address like udp://graylog:12201 or
+// tcp://graylog:12201. If host is empty the hostname is used as the source.
+func NewGELFLogger(address *url.URL, host string) (kitlog.Logger, error) {
+	switch address.Scheme {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("GELF address %s should have scheme udp or tcp", address)
+	}
+	if host == "" {
+		var err error
+		host, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &gelfLogger{
+		network: address.Scheme,
+		address: address.Host,
+		host:    host,
+	}, nil
+}
+
+func (gl *gelfLogger) Log(keyvals ...interface{}) error {
+	message, err := json.Marshal(gelfMessage(gl.host, keyvals))
+	if err != nil {
+		return err
+	}
+	gl.Lock()
+	defer gl.Unlock()
+	if gl.conn == nil {
+		gl.conn, err = net.Dial(gl.network, gl.address)
+		if err != nil {
+			gl.conn = nil
+			return err
+		}
+	}
+	if gl.network == "tcp" {
+		_, err = gl.conn.Write(append(message, 0))
+	} else {
+		err = gl.writeChunked(message)
+	}
+	if err != nil {
+		// Redial on the next line
+		gl.conn.Close()
+		gl.conn = nil
+	}
+	return err
+}
+
+func (gl *gelfLogger) writeChunked(message []byte) error {
+	if len(message) <= gelfChunkSize {
+		_, err := gl.conn.Write(message)
+		return err
+	}
+	numChunks := (len(message) + gelfChunkSize - 1) / gelfChunkSize
+	if numChunks > gelfMaxChunks {
+		return fmt.Errorf("GELF message of %v bytes is too long to send over udp",
+			len(message))
+	}
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return err
+	}
+	chunk := new(bytes.Buffer)
+	for i := 0; i < numChunks; i++ {
+		chunk.Reset()
+		chunk.Write(gelfChunkMagic)
+		chunk.Write(id)
+		chunk.WriteByte(byte(i))
+		chunk.WriteByte(byte(numChunks))
+		end := (i + 1) * gelfChunkSize
+		if end > len(message) {
+			end = len(message)
+		}
+		chunk.Write(message[i*gelfChunkSize : end])
+		_, err = gl.conn.Write(chunk.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gelfMessage(host string, keyvals []interface{}) map[string]interface{} {
+	message := map[string]interface{}{
+		"version": "1.1",
+		"host":    host,
+		"level":   gelfDebugLevel,
+	}
+	timestamp := time.Now()
+	for i := 0; i < 2*(len(keyvals)/2); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		value := keyvals[i+1]
+		switch key {
+		case structure.MessageKey:
+			message["short_message"] = fmt.Sprint(value)
+			continue
+		case structure.TimeKey:
+			if t, ok := value.(time.Time); ok {
+				timestamp = t
+				continue
+			}
+		case structure.ChannelKey:
+			if value == types.InfoChannelName {
+				message["level"] = gelfInfoLevel
+			}
+		}
+		key = gelfFieldRegexp.ReplaceAllString(key, "_")
+		// _id is reserved
+		if key == "id" {
+			key = "id_"
+		}
+		switch v := value.(type) {
+		case string, int, int64, uint64, float64:
+			message["_"+key] = v
+		default:
+			message["_"+key] = fmt.Sprint(v)
+		}
+	}
+	if _, ok := message["short_message"]; !ok {
+		line := new(bytes.Buffer)
+		kitlog.NewLogfmtLogger(line).Log(keyvals...)
+		message["short_message"] = string(bytes.TrimSpace(line.Bytes()))
+	}
+	message["timestamp"] = float64(timestamp.UnixNano()) / float64(time.Second)
+	return message
+}
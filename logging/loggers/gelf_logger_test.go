@@ -0,0 +1,113 @@
+package loggers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/hyperledger/burrow/logging/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGELFLoggerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	address, err := url.Parse("udp://" + conn.LocalAddr().String())
+	require.NoError(t, err)
+	logger, err := NewGELFLogger(address, "validator-0")
+	require.NoError(t, err)
+
+	timestamp := time.Unix(1500000000, 500000000)
+	err = logger.Log(structure.TimeKey, timestamp, structure.ChannelKey, types.InfoChannelName,
+		structure.MessageKey, "Committed block", "height", 12, "id", "x", "tx hash", "AB")
+	require.NoError(t, err)
+
+	message := readGELF(t, conn)
+	assert.Equal(t, "1.1", message["version"])
+	assert.Equal(t, "validator-0", message["host"])
+	assert.Equal(t, "Committed block", message["short_message"])
+	assert.Equal(t, 1500000000.5, message["timestamp"])
+	assert.Equal(t, float64(gelfInfoLevel), message["level"])
+	assert.Equal(t, float64(12), message["_height"])
+	assert.Equal(t, "x", message["_id_"])
+	assert.Equal(t, "AB", message["_tx_hash"])
+
+	// Lines without a message are summarised and large ones chunked
+	long := strings.Repeat("a", 3*gelfChunkSize)
+	err = logger.Log(structure.ChannelKey, types.TraceChannelName, "long", long)
+	require.NoError(t, err)
+	message = readGELF(t, conn)
+	assert.Equal(t, float64(gelfDebugLevel), message["level"])
+	assert.Equal(t, long, message["_long"])
+	assert.Contains(t, message["short_message"], "long=aaa")
+}
+
+func TestGELFLoggerTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	address, err := url.Parse("tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+	logger, err := NewGELFLogger(address, "")
+	require.NoError(t, err)
+
+	require.NoError(t, logger.Log(structure.MessageKey, "one"))
+	require.NoError(t, logger.Log(structure.MessageKey, "two"))
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := new(bytes.Buffer)
+	for bytes.Count(buf.Bytes(), []byte{0}) < 2 {
+		chunk := make([]byte, 1024)
+		n, err := conn.Read(chunk)
+		require.NoError(t, err)
+		buf.Write(chunk[:n])
+	}
+	messages := bytes.Split(bytes.TrimRight(buf.Bytes(), "\x00"), []byte{0})
+	require.Len(t, messages, 2)
+	message := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(messages[1], &message))
+	assert.Equal(t, "two", message["short_message"])
+}
+
+func TestNewGELFLogger(t *testing.T) {
+	address, err := url.Parse("http://graylog:12201")
+	require.NoError(t, err)
+	_, err = NewGELFLogger(address, "")
+	assert.Error(t, err)
+}
+
+// Reads a GELF message from conn, reassembling it if chunked
+func readGELF(t *testing.T, conn net.PacketConn) map[string]interface{} {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var chunks [][]byte
+	for {
+		packet := make([]byte, 65536)
+		n, _, err := conn.ReadFrom(packet)
+		require.NoError(t, err)
+		packet = packet[:n]
+		if !bytes.HasPrefix(packet, gelfChunkMagic) {
+			chunks = [][]byte{packet}
+			break
+		}
+		seq, count := packet[10], packet[11]
+		if chunks == nil {
+			chunks = make([][]byte, count)
+		}
+		chunks[seq] = packet[12:]
+		if int(seq) == len(chunks)-1 {
+			break
+		}
+	}
+	message := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(bytes.Join(chunks, nil), &message))
+	return message
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loggers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/hyperledger/burrow/logging/structure"
+)
+
+const (
+	// Lines are pushed in batches of at most this many, or after this interval
+	lokiBatchSize     = 1000
+	lokiFlushInterval = time.Second
+	// Lines logged while this many are waiting to be pushed are dropped
+	lokiQueueSize = 10000
+)
+
+// Loki needs at least one label for a stream
+var defaultLokiLabels = map[string]string{"job": "burrow"}
+
+// Pushes log lines to Loki as a single stream with labels, formatted as JSON or
+// logfmt, to a push URL like http://loki:3100/loki/api/v1/push. Lines are sent
+// from a goroutine and dropped if Loki cannot keep up.
+type lokiLogger struct {
+	pushURL    string
+	labels     map[string]string
+	formatName string
+	client     *http.Client
+	entries    chan [2]string
+}
+
+var _ kitlog.Logger = (*lokiLogger)(nil)
+
+func NewLokiLogger(pushURL string, labels map[string]string, formatName string) kitlog.Logger {
+	if len(labels) == 0 {
+		labels = defaultLokiLabels
+	}
+	ll := &lokiLogger{
+		pushURL:    pushURL,
+		labels:     labels,
+		formatName: formatName,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		entries:    make(chan [2]string, lokiQueueSize),
+	}
+	go ll.run()
+	return ll
+}
+
+func (ll *lokiLogger) Log(keyvals ...interface{}) error {
+	timestamp := time.Now()
+	for i := 0; i < 2*(len(keyvals)/2); i += 2 {
+		if keyvals[i] == structure.TimeKey {
+			if t, ok := keyvals[i+1].(time.Time); ok {
+				timestamp = t
+			}
+			break
+		}
+	}
+	line := new(bytes.Buffer)
+	var err error
+	if ll.formatName == JSONFormat {
+		err = kitlog.NewJSONLogger(line).Log(keyvals...)
+	} else {
+		err = kitlog.NewLogfmtLogger(line).Log(keyvals...)
+	}
+	if err != nil {
+		return err
+	}
+	entry := [2]string{strconv.FormatInt(timestamp.UnixNano(), 10),
+		string(bytes.TrimSpace(line.Bytes()))}
+	select {
+	case ll.entries <- entry:
+		return nil
+	default:
+		return fmt.Errorf("Dropped log line since %v lines are waiting to be "+
+			"pushed to Loki", lokiQueueSize)
+	}
+}
+
+func (ll *lokiLogger) run() {
+	ticker := time.NewTicker(lokiFlushInterval)
+	defer ticker.Stop()
+	var batch [][2]string
+	for {
+		select {
+		case entry := <-ll.entries:
+			batch = append(batch, entry)
+			if len(batch) < lokiBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		// Lines that cannot be pushed are dropped rather than backing up
+		ll.push(batch)
+		batch = nil
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+func (ll *lokiLogger) push(batch [][2]string) error {
+	body, err := json.Marshal(lokiPush{
+		Streams: []lokiStream{{Stream: ll.labels, Values: batch}},
+	})
+	if err != nil {
+		return err
+	}
+	response, err := ll.client.Post(ll.pushURL, "application/json",
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("Loki push URL %s returned status %v", ll.pushURL,
+			response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package loggers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLokiLogger(t *testing.T) {
+	pushes := make(chan lokiPush, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		var push lokiPush
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+		w.WriteHeader(http.StatusNoContent)
+		pushes <- push
+	}))
+	defer server.Close()
+
+	logger := NewLokiLogger(server.URL+"/loki/api/v1/push", map[string]string{"chain": "test"},
+		JSONFormat)
+	timestamp := time.Unix(1500000000, 0)
+	require.NoError(t, logger.Log(structure.TimeKey, timestamp, structure.MessageKey, "one"))
+	require.NoError(t, logger.Log(structure.MessageKey, "two"))
+
+	select {
+	case push := <-pushes:
+		require.Len(t, push.Streams, 1)
+		stream := push.Streams[0]
+		assert.Equal(t, map[string]string{"chain": "test"}, stream.Stream)
+		require.Len(t, stream.Values, 2)
+		assert.Equal(t, "1500000000000000000", stream.Values[0][0])
+		line := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(stream.Values[1][1]), &line))
+		assert.Equal(t, "two", line[structure.MessageKey])
+	case <-time.After(5 * lokiFlushInterval):
+		t.Fatal("Timed out waiting for push to Loki")
+	}
+}
@@ -1,7 +1,9 @@
 package loggers
 
 import (
+	"fmt"
 	"io"
+	"time"
 
 	"log/syslog"
 	"net/url"
@@ -36,8 +38,22 @@ func NewFileLogger(path string, formatName string) (kitlog.Logger, error) {
 	return log15a.Log15HandlerAsKitLogger(handler), err
 }
 
-func NewRemoteSyslogLogger(url *url.URL, tag, formatName string) (kitlog.Logger, error) {
-	handler, err := log15.SyslogNetHandler(url.Scheme, url.Host, syslogPriority,
+// Logs to a file that is rotated by size and age (see RotatingFile)
+func NewRotatingFileLogger(path string, maxSize int64, maxAge time.Duration,
+	maxBackups int, formatName string) (kitlog.Logger, error) {
+	file, err := NewRotatingFile(path, maxSize, maxAge, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamLogger(file, formatName), nil
+}
+
+func NewRemoteSyslogLogger(url *url.URL, tag, facility, formatName string) (kitlog.Logger, error) {
+	priority, err := syslogFacility(facility)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := log15.SyslogNetHandler(url.Scheme, url.Host, priority,
 		tag, format(formatName))
 	if err != nil {
 		return nil, err
@@ -45,14 +61,44 @@ func NewRemoteSyslogLogger(url *url.URL, tag, formatName string) (kitlog.Logger,
 	return log15a.Log15HandlerAsKitLogger(handler), nil
 }
 
-func NewSyslogLogger(tag, formatName string) (kitlog.Logger, error) {
-	handler, err := log15.SyslogHandler(syslogPriority, tag, format(formatName))
+func NewSyslogLogger(tag, facility, formatName string) (kitlog.Logger, error) {
+	priority, err := syslogFacility(facility)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := log15.SyslogHandler(priority, tag, format(formatName))
 	if err != nil {
 		return nil, err
 	}
 	return log15a.Log15HandlerAsKitLogger(handler), nil
 }
 
+var syslogFacilities = map[string]syslog.Priority{
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// The default facility is local0
+func syslogFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslogPriority, nil
+	}
+	priority, ok := syslogFacilities[name]
+	if !ok {
+		return 0, fmt.Errorf("Unknown syslog facility '%s', facilities are user, "+
+			"daemon and local0 to local7", name)
+	}
+	return priority, nil
+}
+
 func format(name string) log15.Format {
 	switch name {
 	case JSONFormat:
@@ -0,0 +1,166 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loggers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The suffix of rotated files sorts in the order they were rotated
+const rotatedFileTimeLayout = "20060102T150405.000000000"
+
+// A file that is moved aside to path.<time> and reopened empty once writing to it
+// would take it over maxSize bytes or it has been open for longer than maxAge.
+// At most maxBackups rotated files are kept. A zero limit is no limit.
+type RotatingFile struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	file       *os.File
+	size       int64
+	openedAt   time.Time
+}
+
+func NewRotatingFile(path string, maxSize int64, maxAge time.Duration,
+	maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.Lock()
+	defer rf.Unlock()
+	if rf.file == nil {
+		return 0, fmt.Errorf("Rotating file %s is closed", rf.path)
+	}
+	var rotateErr error
+	if rf.due(len(p)) {
+		// Lines are still written to whichever file is open if rotation fails
+		rotateErr = rf.rotate()
+		if rf.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.Lock()
+	defer rf.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// The rotated files of this file, oldest first
+func (rf *RotatingFile) Backups() ([]string, error) {
+	matches, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0, len(matches))
+	for _, match := range matches {
+		_, err := time.Parse(rotatedFileTimeLayout, match[len(rf.path)+1:])
+		if err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Whether the file should be rotated before writing n more bytes to it. An
+// empty file is never rotated so that a single oversized line is still written.
+func (rf *RotatingFile) due(n int) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.maxSize > 0 && rf.size+int64(n) > rf.maxSize {
+		return true
+	}
+	return rf.maxAge > 0 && time.Since(rf.openedAt) >= rf.maxAge
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	err := rf.file.Close()
+	if err != nil {
+		return err
+	}
+	rf.file = nil
+	backup := rf.path + "." + time.Now().UTC().Format(rotatedFileTimeLayout)
+	renameErr := os.Rename(rf.path, backup)
+	// Reopen the same file if it could not be moved aside
+	err = rf.open()
+	if err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	if rf.maxBackups > 0 {
+		backups, err := rf.Backups()
+		if err != nil {
+			return err
+		}
+		for len(backups) > rf.maxBackups {
+			err = os.Remove(backups[0])
+			if err != nil {
+				return err
+			}
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package loggers
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating_file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "burrow.log")
+
+	rf, err := NewRotatingFile(logPath, 10, 0, 2)
+	require.NoError(t, err)
+	defer rf.Close()
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		_, err = rf.Write([]byte(line))
+		require.NoError(t, err)
+		// Distinct rotation times
+		time.Sleep(time.Millisecond)
+	}
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	// The oldest backup was pruned
+	require.Len(t, backups, 2)
+	assertFile(t, "bbbbbb\n", backups[0])
+	assertFile(t, "cccccc\n", backups[1])
+	assertFile(t, "dddddd\n", logPath)
+
+	// A line larger than the limit is still written to an empty file
+	rf.Close()
+	os.Remove(logPath)
+	rf, err = NewRotatingFile(logPath, 2, 0, 0)
+	require.NoError(t, err)
+	_, err = rf.Write([]byte("eeeeee\n"))
+	require.NoError(t, err)
+	assertFile(t, "eeeeee\n", logPath)
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating_file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "burrow.log")
+
+	rf, err := NewRotatingFile(logPath, 0, 10*time.Millisecond, 0)
+	require.NoError(t, err)
+	defer rf.Close()
+	_, err = rf.Write([]byte("old\n"))
+	require.NoError(t, err)
+	_, err = rf.Write([]byte("still young\n"))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = rf.Write([]byte("new\n"))
+	require.NoError(t, err)
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assertFile(t, "old\nstill young\n", backups[0])
+	assertFile(t, "new\n", logPath)
+}
+
+func assertFile(t *testing.T, expected, filePath string) {
+	bs, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(bs))
+}
@@ -35,11 +35,26 @@ const (
 	ComponentKey = "component"
 	// Vector-valued scope
 	ScopeKey = "scope"
+	// Subsystem name (one of the subsystems below), the innermost applies when
+	// vector-valued
+	SubsystemKey = "subsystem"
 	// Globally unique identifier persisting while a single instance (root process)
 	// of this program/service is running
 	RunId = "run_id"
 )
 
+// Subsystems whose log levels can be set independently
+const (
+	ConsensusSubsystem = "consensus"
+	EVMSubsystem       = "evm"
+	RPCSubsystem       = "rpc"
+	EventsSubsystem    = "events"
+	StateSubsystem     = "state"
+)
+
+var Subsystems = []string{ConsensusSubsystem, EVMSubsystem, RPCSubsystem,
+	EventsSubsystem, StateSubsystem}
+
 // Pull the specified values from a structured log line into a map.
 // Assumes keys are single-valued.
 // Returns a map of the key-values from the requested keys and
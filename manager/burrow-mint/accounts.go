@@ -19,17 +19,26 @@ package burrowmint
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"sync"
 
 	account "github.com/hyperledger/burrow/account"
 	core_types "github.com/hyperledger/burrow/core/types"
 	definitions "github.com/hyperledger/burrow/definitions"
 	event "github.com/hyperledger/burrow/event"
+	sm "github.com/hyperledger/burrow/manager/burrow-mint/state"
+	ptypes "github.com/hyperledger/burrow/permission/types"
 	word256 "github.com/hyperledger/burrow/word256"
 )
 
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
 // NOTE [ben] Compiler check to ensure Accounts successfully implements
 // burrow/definitions.Accounts
 var _ definitions.Accounts = (*accounts)(nil)
@@ -55,6 +64,18 @@ func newAccounts(burrowMint *BurrowMint) *accounts {
 		},
 	})
 
+	ff.RegisterFilterPool("address", &sync.Pool{
+		New: func() interface{} {
+			return &AccountAddressFilter{}
+		},
+	})
+
+	ff.RegisterFilterPool("permission", &sync.Pool{
+		New: func() interface{} {
+			return &AccountPermissionFilter{}
+		},
+	})
+
 	return &accounts{burrowMint, ff}
 
 }
@@ -95,10 +116,65 @@ func (this *accounts) Accounts(fda []*event.FilterData) (
 	return &core_types.AccountList{accounts}, nil
 }
 
+// Get a page of at most pageSize of the accounts that match the filters, in
+// order of address. Page tokens are as for ListStorage.
+func (this *accounts) ListAccounts(fda []*event.FilterData, pageToken []byte,
+	pageSize int) (*core_types.AccountPage, error) {
+	pageSize, err := checkPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	height, after, err := parsePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := this.filterFactory.NewFilter(fda)
+	if err != nil {
+		return nil, fmt.Errorf("Error in query: " + err.Error())
+	}
+	var page *core_types.AccountPage
+	err = this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		page = &core_types.AccountPage{
+			Height:   state.LastBlockHeight,
+			Accounts: make([]*account.Account, 0),
+		}
+		state.GetAccounts().Iterate(func(key, value []byte) bool {
+			if after != nil && bytes.Compare(key, after) <= 0 {
+				return false
+			}
+			acc := account.DecodeAccount(value)
+			if !filter.Match(acc) {
+				return false
+			}
+			if len(page.Accounts) == pageSize {
+				page.NextPageToken = pageTokenAfter(page.Height,
+					page.Accounts[pageSize-1].Address)
+				return true
+			}
+			page.Accounts = append(page.Accounts, acc)
+			return false
+		})
+		return nil
+	})
+	return page, err
+}
+
 // Get an account.
 func (this *accounts) Account(address []byte) (*account.Account, error) {
-	cache := this.burrowMint.GetState() // NOTE: we want to read from mempool!
-	acc := cache.GetAccount(address)
+	return this.HistoricalAccount(address, 0)
+}
+
+// Get an account as committed at height.
+func (this *accounts) HistoricalAccount(address []byte, height int) (
+	*account.Account, error) {
+	var acc *account.Account
+	err := this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		acc = state.GetAccount(address)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	if acc == nil {
 		acc = this.newAcc(address)
 	}
@@ -109,41 +185,177 @@ func (this *accounts) Account(address []byte) (*account.Account, error) {
 // Both the key and value is returned.
 func (this *accounts) StorageAt(address, key []byte) (*core_types.StorageItem,
 	error) {
-	state := this.burrowMint.GetState()
-	account := state.GetAccount(address)
-	if account == nil {
-		return &core_types.StorageItem{key, []byte{}}, nil
-	}
-	storageRoot := account.StorageRoot
-	storageTree := state.LoadStorage(storageRoot)
+	return this.HistoricalStorageAt(address, key, 0)
+}
 
-	_, value, _ := storageTree.Get(word256.LeftPadWord256(key).Bytes())
+// Get the value stored at 'key' in the account with address 'address' as
+// committed at height.
+func (this *accounts) HistoricalStorageAt(address, key []byte, height int) (
+	*core_types.StorageItem, error) {
+	var value []byte
+	err := this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		if account := state.GetAccount(address); account != nil {
+			value = state.GetStorage(account.StorageRoot,
+				word256.LeftPadWord256(key).Bytes())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	if value == nil {
 		return &core_types.StorageItem{key, []byte{}}, nil
 	}
 	return &core_types.StorageItem{key, value}, nil
 }
 
+// Get the account with address 'address' at height, with a proof of it
+// against the AppHash of the state at that height.
+func (this *accounts) AccountWithProof(address []byte, height int) (
+	*core_types.AccountWithProof, error) {
+	var accountWithProof *core_types.AccountWithProof
+	err := this.burrowMint.ReadStateAt(height, func(state *sm.State) (err error) {
+		accountWithProof, err = state.GetAccountWithProof(address)
+		return err
+	})
+	return accountWithProof, err
+}
+
+// Get the value stored at 'key' in the account with address 'address' at
+// height, with proofs of it and the account against the AppHash of the state
+// at that height.
+func (this *accounts) StorageAtWithProof(address, key []byte, height int) (
+	*core_types.StorageItemWithProof, error) {
+	var itemWithProof *core_types.StorageItemWithProof
+	err := this.burrowMint.ReadStateAt(height, func(state *sm.State) (err error) {
+		itemWithProof, err = state.GetStorageWithProof(address,
+			word256.LeftPadWord256(key))
+		return err
+	})
+	return itemWithProof, err
+}
+
+// Get the ABI registered for the contract with address 'address'.
+func (this *accounts) ABI(address []byte) (*core_types.ABIEntry, error) {
+	entry := sm.GetContractABIEntry(this.burrowMint.GetState(), address)
+	if entry == nil {
+		return nil, fmt.Errorf("No ABI is registered for the contract at %X", address)
+	}
+	return entry, nil
+}
+
+// Write a dump of the state committed at height to w. The dump is of a copy
+// of state so blocks go on being committed while it is written.
+func (this *accounts) Dump(height int, w io.Writer) error {
+	return this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		return state.Dump(w)
+	})
+}
+
 // Get the storage of the account with address 'address'.
 func (this *accounts) Storage(address []byte) (*core_types.Storage, error) {
+	return this.HistoricalStorage(address, 0)
+}
 
-	state := this.burrowMint.GetState()
-	account := state.GetAccount(address)
+// Get the storage of the account with address 'address' as committed at
+// height.
+func (this *accounts) HistoricalStorage(address []byte, height int) (
+	*core_types.Storage, error) {
+	var storageRoot []byte
 	storageItems := make([]core_types.StorageItem, 0)
-	if account == nil {
-		return &core_types.Storage{nil, storageItems}, nil
-	}
-	storageRoot := account.StorageRoot
-	storageTree := state.LoadStorage(storageRoot)
+	err := this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		account := state.GetAccount(address)
+		if account == nil {
+			return nil
+		}
+		storageRoot = account.StorageRoot
+		storageTree := state.LoadStorage(storageRoot)
 
-	storageTree.Iterate(func(key, value []byte) bool {
-		storageItems = append(storageItems, core_types.StorageItem{
-			key, value})
-		return false
+		storageTree.Iterate(func(key, value []byte) bool {
+			storageItems = append(storageItems, core_types.StorageItem{
+				key, value})
+			return false
+		})
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	return &core_types.Storage{storageRoot, storageItems}, nil
 }
 
+// Get a page of the storage of the account with address 'address'. The page
+// token holds the height the first page was read at followed by the last key
+// of the page before, so that paging through storage sees a single version of
+// it and neither repeats nor skips items.
+func (this *accounts) ListStorage(address, pageToken []byte, pageSize int) (
+	*core_types.StoragePage, error) {
+	pageSize, err := checkPageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	height, after, err := parsePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	var page *core_types.StoragePage
+	err = this.burrowMint.ReadStateAt(height, func(state *sm.State) error {
+		page = &core_types.StoragePage{
+			Height:       state.LastBlockHeight,
+			StorageItems: make([]core_types.StorageItem, 0),
+		}
+		account := state.GetAccount(address)
+		if account == nil {
+			return nil
+		}
+		page.StorageRoot = account.StorageRoot
+		state.LoadStorage(account.StorageRoot).Iterate(func(key, value []byte) bool {
+			if after != nil && bytes.Compare(key, after) <= 0 {
+				return false
+			}
+			if len(page.StorageItems) == pageSize {
+				page.NextPageToken = pageTokenAfter(page.Height,
+					page.StorageItems[pageSize-1].Key)
+				return true
+			}
+			page.StorageItems = append(page.StorageItems,
+				core_types.StorageItem{key, value})
+			return false
+		})
+		return nil
+	})
+	return page, err
+}
+
+func checkPageSize(pageSize int) (int, error) {
+	if pageSize == 0 {
+		return DefaultPageSize, nil
+	}
+	if pageSize < 0 || pageSize > MaxPageSize {
+		return 0, fmt.Errorf("Page size must be between 1 and %v", MaxPageSize)
+	}
+	return pageSize, nil
+}
+
+// The token of the page following the one whose last key is key
+func pageTokenAfter(height int, key []byte) []byte {
+	pageToken := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(pageToken, uint64(height))
+	return append(pageToken, key...)
+}
+
+// Gets the height and the key to start after from a page token, or height 0
+// and a nil key for the first page
+func parsePageToken(pageToken []byte) (int, []byte, error) {
+	if len(pageToken) == 0 {
+		return 0, nil, nil
+	}
+	if len(pageToken) <= 8 {
+		return 0, nil, fmt.Errorf("Malformed page token %X", pageToken)
+	}
+	return int(binary.BigEndian.Uint64(pageToken[:8])), pageToken[8:], nil
+}
+
 // Create a new account.
 func (this *accounts) newAcc(address []byte) *account.Account {
 	return &account.Account{
@@ -226,3 +438,74 @@ func (this *AccountBalanceFilter) Match(v interface{}) bool {
 	}
 	return this.match(int64(acc.Balance), this.value)
 }
+
+// Filter for account address by prefix, so a full address matches only itself.
+// Ops: == or !=
+type AccountAddressFilter struct {
+	op    string
+	value []byte
+	match func([]byte, []byte) bool
+}
+
+func (this *AccountAddressFilter) Configure(fd *event.FilterData) error {
+	val, err := hex.DecodeString(fd.Value)
+	if err != nil {
+		return fmt.Errorf("Wrong value type.")
+	}
+	if fd.Op == "==" {
+		this.match = bytes.HasPrefix
+	} else if fd.Op == "!=" {
+		this.match = func(a, b []byte) bool {
+			return !bytes.HasPrefix(a, b)
+		}
+	} else {
+		return fmt.Errorf("Op: " + fd.Op + " is not supported for 'address' filtering")
+	}
+	this.op = fd.Op
+	this.value = val
+	return nil
+}
+
+func (this *AccountAddressFilter) Match(v interface{}) bool {
+	acc, ok := v.(*account.Account)
+	if !ok {
+		return false
+	}
+	return this.match(acc.Address, this.value)
+}
+
+// Filter for the permissions set on an account itself, not those it falls
+// back on from the global permissions. Value is a permission name and '=='
+// matches accounts granted it.
+// Ops: == or !=
+type AccountPermissionFilter struct {
+	op    string
+	value ptypes.PermFlag
+	match func(bool) bool
+}
+
+func (this *AccountPermissionFilter) Configure(fd *event.FilterData) error {
+	val, err := ptypes.PermStringToFlag(fd.Value)
+	if err != nil {
+		return err
+	}
+	if fd.Op == "==" {
+		this.match = func(granted bool) bool { return granted }
+	} else if fd.Op == "!=" {
+		this.match = func(granted bool) bool { return !granted }
+	} else {
+		return fmt.Errorf("Op: " + fd.Op + " is not supported for 'permission' filtering")
+	}
+	this.op = fd.Op
+	this.value = val
+	return nil
+}
+
+func (this *AccountPermissionFilter) Match(v interface{}) bool {
+	acc, ok := v.(*account.Account)
+	if !ok {
+		return false
+	}
+	granted, err := acc.Permissions.Base.Get(this.value)
+	return this.match(err == nil && granted)
+}
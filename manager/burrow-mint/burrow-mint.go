@@ -15,7 +15,6 @@
 package burrowmint
 
 import (
-	"bytes"
 	"fmt"
 	"sync"
 	"time"
@@ -24,11 +23,16 @@ import (
 	tendermint_events "github.com/tendermint/go-events"
 	wire "github.com/tendermint/go-wire"
 
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	"github.com/hyperledger/burrow/common/sanity"
 	consensus_types "github.com/hyperledger/burrow/consensus/types"
+	core_types "github.com/hyperledger/burrow/core/types"
 	"github.com/hyperledger/burrow/logging"
 	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
 	sm "github.com/hyperledger/burrow/manager/burrow-mint/state"
 	manager_types "github.com/hyperledger/burrow/manager/types"
+	"github.com/hyperledger/burrow/tracing"
 	"github.com/hyperledger/burrow/txs"
 )
 
@@ -47,6 +51,58 @@ type BurrowMint struct {
 	evc  *tendermint_events.EventCache
 	evsw tendermint_events.EventSwitch
 
+	logIndex   *sm.LogIndex
+	txReceipts *sm.TxReceipts
+	// The events of registered ABIs and imported signatures, to decode logs
+	eventSignatures *sm.EventSignatures
+	// The txs of committed blocks by the accounts that signed them
+	senderIndex *sm.SenderIndex
+	// When name registry entries expire, for their expiry events
+	nameRegExpiries *sm.NameRegExpiries
+	// Keeps the traces of recent CallTxs when enabled, otherwise nil
+	txTraces *sm.TxTraces
+	// Limits the gas of the txs in the mempool when enabled, otherwise nil
+	txPool *txPool
+	// The gas given to the CallTxs delivered in the current block
+	blockGas int64
+	// Closed once the node has halted for the upgrade of the chain, after
+	// which no more blocks are executed, and where the upgrade marker is
+	// written then, if anywhere
+	halted      chan struct{}
+	upgradeFile string
+	// Saves state when pruning is enabled, otherwise nil
+	pruner *sm.Pruner
+	// Takes snapshots of state when enabled, otherwise nil, and the restorer
+	// of the snapshot offered for state sync, if any
+	snapshots *sm.Snapshots
+	restorer  *sm.SnapshotRestorer
+	// State is only saved every saveInterval blocks while replaying blocks,
+	// and unsaved is set while there are blocks committed but not saved
+	saveInterval int
+	unsaved      bool
+	// Buffers the writes of each block to the state database until the block
+	// is committed when enabled, otherwise nil
+	writeBuffer *sm.WriteBuffer
+	// The blocks of the chain, whose commits show the validators that missed
+	// signing, or nil until it is set. Blocks after the second cannot be
+	// executed without it.
+	blockStore blockchain_types.BlockStore
+	// The proposer of the block at commitHeight and the validators that signed
+	// its commit, read from the block store at the beginning of the block for
+	// its rewards
+	commitHeight   int
+	commitProposer []byte
+	commitSigners  [][]byte
+
+	// Read from the genesis doc in state when first needed
+	genesisLoaded bool
+	proposers     *sm.ProposerSchedule
+
+	// When the last block was committed and the gas used by blocks then, for
+	// its metrics
+	lastCommitTime time.Time
+	lastGasUsed    int64
+
 	nTxs   int // count txs in a block
 	logger logging_types.InfoTraceLogger
 }
@@ -62,6 +118,8 @@ type BurrowMintCompatibleConsensusEngine interface {
 // NOTE [ben] Compiler check to ensure BurrowMint successfully implements
 // burrow/manager/types.Application
 var _ manager_types.Application = (*BurrowMint)(nil)
+var _ manager_types.StateSyncApplication = (*BurrowMint)(nil)
+var _ manager_types.ReplayApplication = (*BurrowMint)(nil)
 
 func (app *BurrowMint) CompatibleConsensus(consensusEngine consensus_types.ConsensusEngine) bool {
 	_, ok := consensusEngine.(BurrowMintCompatibleConsensusEngine)
@@ -74,6 +132,38 @@ func (app *BurrowMint) GetState() *sm.State {
 	return app.state.Copy()
 }
 
+// Calls read with a copy of the state as committed at height, or of the
+// latest state if height is 0. Past heights can only be read if their version
+// of state was recorded and has not been pruned. The version read is kept from
+// pruning until read returns, so read must not hold on to the state.
+func (app *BurrowMint) ReadStateAt(height int, read func(*sm.State) error) error {
+	st, release, err := app.stateAt(height)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return read(st)
+}
+
+func (app *BurrowMint) stateAt(height int) (*sm.State, func(), error) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if height == 0 || height == app.state.LastBlockHeight {
+		if app.pruner == nil {
+			return app.state.Copy(), func() {}, nil
+		}
+		return app.state.Copy(), app.pruner.HoldLatest(), nil
+	}
+	if height > app.state.LastBlockHeight {
+		return nil, nil, fmt.Errorf("Height %v is greater than the last block "+
+			"height %v", height, app.state.LastBlockHeight)
+	}
+	if app.pruner == nil {
+		return nil, nil, fmt.Errorf("The state at height %v is not available", height)
+	}
+	return app.pruner.StateAt(app.state, height)
+}
+
 // TODO: this is used for call/callcode and to get nonces during mempool.
 // the former should work on last committed state only and the later should
 // be handled by the client, or a separate wallet-like nonce tracker thats not part of the app
@@ -81,15 +171,28 @@ func (app *BurrowMint) GetCheckCache() *sm.BlockCache {
 	return app.checkCache
 }
 
-func NewBurrowMint(s *sm.State, evsw tendermint_events.EventSwitch,
+// Creates the application on top of state s. pruner may be nil, in which case
+// every version of state is kept.
+func NewBurrowMint(s *sm.State, pruner *sm.Pruner, evsw tendermint_events.EventSwitch,
 	logger logging_types.InfoTraceLogger) *BurrowMint {
+	txReceipts := sm.NewTxReceipts(s.DB)
+	s.SetTxReceipts(txReceipts)
+	blockHeight.Set(float64(s.LastBlockHeight))
 	return &BurrowMint{
-		state:      s,
-		cache:      sm.NewBlockCache(s),
-		checkCache: sm.NewBlockCache(s),
-		evc:        tendermint_events.NewEventCache(evsw),
-		evsw:       evsw,
-		logger:     logging.WithScope(logger, "BurrowMint"),
+		state:           s,
+		cache:           sm.NewBlockCache(s),
+		checkCache:      sm.NewBlockCache(s),
+		evc:             tendermint_events.NewEventCache(evsw),
+		evsw:            evsw,
+		logIndex:        sm.NewLogIndex(s.DB),
+		txReceipts:      txReceipts,
+		eventSignatures: sm.NewEventSignatures(s.DB),
+		senderIndex:     sm.NewSenderIndex(s.DB),
+		nameRegExpiries: sm.NewNameRegExpiries(s),
+		pruner:          pruner,
+		halted:          make(chan struct{}),
+		lastGasUsed:     sm.GasUsed(),
+		logger:          logging.WithScope(logger, "BurrowMint"),
 	}
 }
 
@@ -105,73 +208,246 @@ func (app *BurrowMint) SetOption(key string, value string) (log string) {
 
 // Implements manager/types.Application
 func (app *BurrowMint) DeliverTx(txBytes []byte) abci.Result {
+	if app.isHalted() {
+		return abci.NewError(abci.CodeType_InternalError, errHalted.Error())
+	}
 	app.nTxs += 1
 
+	if err := app.checkTxSize(txBytes); err != nil {
+		return abci.NewError(abci.CodeType_EncodingError, err.Error())
+	}
 	// XXX: if we had tx ids we could cache the decoded txs on CheckTx
-	var n int
-	var err error
-	tx := new(txs.Tx)
-	buf := bytes.NewBuffer(txBytes)
-	wire.ReadBinaryPtr(tx, buf, len(txBytes), &n, &err)
+	tx, err := txs.DecodeTx(txBytes)
 	if err != nil {
 		return abci.NewError(abci.CodeType_EncodingError, fmt.Sprintf("Encoding error: %v", err))
 	}
 
-	err = sm.ExecTx(app.cache, *tx, true, app.evc, app.logger)
+	if app.txPool != nil {
+		app.txPool.remove(txs.TxHash(app.state.ChainID, tx))
+	}
+	// Txs are reaped for blocks in the order they arrived, so those beyond the
+	// gas limit of the block fail without being charged
+	gas := txGas(tx)
+	if maxBlockGas := app.state.MaxBlockGas; maxBlockGas > 0 &&
+		app.blockGas+gas > maxBlockGas {
+		txsDelivered.Inc("false")
+		return abci.NewError(abci.CodeType_InternalError,
+			fmt.Sprintf("Internal error: %v", txs.ErrTxBlockGasExceeded))
+	}
+
+	span := app.startTxSpan(tx, "DeliverTx")
+	err = sm.ExecTx(app.cache, tx, true, &logIndexingFireable{app.evc, app.logIndex},
+		app.logger)
+	app.finishTxSpan(span, tx, err, true)
 	if err != nil {
+		txsDelivered.Inc("false")
 		return abci.NewError(abci.CodeType_InternalError, fmt.Sprintf("Internal error: %v", err))
 	}
+	txsDelivered.Inc("true")
+	app.blockGas += gas
+	app.senderIndex.Add(app.state.ChainID, tx, app.state.LastBlockHeight+1,
+		app.nTxs-1)
+	app.nameRegExpiries.Add(tx)
+	if abiTx, ok := tx.(*txs.ABITx); ok && abiTx.ABI != "" {
+		if contractABI, err := abi.ReadABI([]byte(abiTx.ABI)); err == nil {
+			app.eventSignatures.AddABI(contractABI)
+		}
+	}
 
-	receipt := txs.GenerateReceipt(app.state.ChainID, *tx)
+	receipt := txs.GenerateReceipt(app.state.ChainID, tx)
 	receiptBytes := wire.BinaryBytes(receipt)
 	return abci.NewResultOK(receiptBytes, "Success")
 }
 
 // Implements manager/types.Application
 func (app *BurrowMint) CheckTx(txBytes []byte) abci.Result {
-	var n int
-	var err error
-	tx := new(txs.Tx)
-	buf := bytes.NewBuffer(txBytes)
-	wire.ReadBinaryPtr(tx, buf, len(txBytes), &n, &err)
+	if app.isHalted() {
+		return abci.NewError(abci.CodeType_InternalError, errHalted.Error())
+	}
+	if err := app.checkTxSize(txBytes); err != nil {
+		return abci.NewError(abci.CodeType_EncodingError, err.Error())
+	}
+	tx, err := txs.DecodeTx(txBytes)
 	if err != nil {
 		return abci.NewError(abci.CodeType_EncodingError, fmt.Sprintf("Encoding error: %v", err))
 	}
 
 	// TODO: map ExecTx errors to sensible abci error codes
-	err = sm.ExecTx(app.checkCache, *tx, false, nil, app.logger)
+	span := app.startTxSpan(tx, "CheckTx")
+	err = app.checkTx(tx)
+	app.finishTxSpan(span, tx, err, false)
+	app.firePendingTx(tx, err)
 	if err != nil {
 		return abci.NewError(abci.CodeType_InternalError, fmt.Sprintf("Internal error: %v", err))
 	}
-	receipt := txs.GenerateReceipt(app.state.ChainID, *tx)
+	receipt := txs.GenerateReceipt(app.state.ChainID, tx)
 	receiptBytes := wire.BinaryBytes(receipt)
 	return abci.NewResultOK(receiptBytes, "Success")
 }
 
+// Checks tx against the check cache, and when the mempool is limited that there
+// is room for it, or when it is being rechecked after a commit that it has not
+// been evicted
+func (app *BurrowMint) checkTx(tx txs.Tx) error {
+	if app.txPool == nil {
+		return sm.ExecTx(app.checkCache, tx, false, nil, app.logger)
+	}
+	hash := txs.TxHash(app.state.ChainID, tx)
+	rechecking, err := app.txPool.recheck(hash)
+	if err != nil {
+		return err
+	}
+	if !rechecking {
+		if err := app.txPool.checkRoom(tx); err != nil {
+			return err
+		}
+	}
+	if err := sm.ExecTx(app.checkCache, tx, false, nil, app.logger); err != nil {
+		app.txPool.remove(hash)
+		return err
+	}
+	if !rechecking {
+		app.txPool.add(hash, tx)
+	}
+	return nil
+}
+
+// Starts a span of tx if its trace is continued from the RPC request that sent
+// it, otherwise returns nil
+func (app *BurrowMint) startTxSpan(tx txs.Tx, name string) *tracing.Span {
+	if !tracing.DefaultTracer.Enabled() {
+		return nil
+	}
+	return tracing.DefaultTracer.StartTxSpan(txs.TxHash(app.state.ChainID, tx), name)
+}
+
+// Finishes the span of tx that it ran with err, forgetting its trace once it
+// has been executed in a block
+func (app *BurrowMint) finishTxSpan(span *tracing.Span, tx txs.Tx, err error,
+	executed bool) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.SetTag("error", err.Error())
+	}
+	span.SetTag("height", fmt.Sprintf("%v", app.state.LastBlockHeight+1))
+	span.Finish()
+	if executed {
+		tracing.DefaultTracer.ForgetTx(txs.TxHash(app.state.ChainID, tx))
+	}
+}
+
+// Checks that txBytes is no longer than the max tx size of the chain
+func (app *BurrowMint) checkTxSize(txBytes []byte) error {
+	maxTxSize := app.state.MaxTxSize
+	if maxTxSize > 0 && len(txBytes) > maxTxSize {
+		return fmt.Errorf("Tx of %v bytes is longer than the max tx size %v",
+			len(txBytes), maxTxSize)
+	}
+	return nil
+}
+
+// Checks that txs would all pass CheckTx if they were checked one after the
+// other, without changing the check cache, returning the error of the first
+// that would not
+func (app *BurrowMint) CheckTxBatch(batch []txs.Tx) error {
+	cache := app.checkCache.Copy()
+	for i, tx := range batch {
+		if err := sm.ExecTx(cache, tx, false, nil, app.logger); err != nil {
+			return fmt.Errorf("Tx %v of batch would fail: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Tells subscribers to PendingTx whether tx, which failed with err if it is
+// not nil, is in the mempool
+func (app *BurrowMint) firePendingTx(tx txs.Tx, err error) {
+	receipt := txs.GenerateReceipt(app.state.ChainID, tx)
+	pendingTx := txs.EventDataPendingTx{
+		TxHash:       receipt.TxHash,
+		Tx:           tx,
+		ContractAddr: receipt.ContractAddr,
+	}
+	if err != nil {
+		pendingTx.Exception = err.Error()
+	}
+	app.evsw.FireEvent(txs.EventStringPendingTx(), pendingTx)
+}
+
 // Implements manager/types.Application
 // Commit the state (called at end of block)
 // NOTE: CheckTx/AppendTx must not run concurrently with Commit -
-//  the mempool should run during AppendTxs, but lock for Commit and Update
+//
+//	the mempool should run during AppendTxs, but lock for Commit and Update
 func (app *BurrowMint) Commit() (res abci.Result) {
 	app.mtx.Lock() // the lock protects app.state
 	defer app.mtx.Unlock()
 
+	// the blocks the node goes on storing until it stops are executed when the
+	// binary of the upgrade replays them, so none is committed here: the app
+	// hash of the state halted at would be claimed for blocks never executed
+	if app.isHalted() {
+		return abci.NewError(abci.CodeType_InternalError, errHalted.Error())
+	}
+
 	app.state.LastBlockHeight += 1
 	logging.InfoMsg(app.logger, "Committing block",
 		"last_block_height", app.state.LastBlockHeight)
 
+	// adjust the base fee for the next block to how full this one was, before
+	// any new fee parameters are synced
+	app.state.BaseFee = sm.NextBaseFee(app.state.BaseFee, app.nTxs,
+		app.state.FeeParams)
+
 	// sync the AppendTx cache
 	app.cache.Sync()
 
+	// pay out the coins of unbonded validators whose unbonding period is over
+	for _, unbonding := range app.state.ReleaseUnbondings(app.state.LastBlockHeight) {
+		logging.InfoMsg(app.logger, "Released unbonded validator",
+			"address", unbonding.Address,
+			"unbond_to", unbonding.UnbondTo)
+	}
+
 	// Refresh the checkCache with the latest commited state
 	logging.InfoMsg(app.logger, "Resetting checkCache",
 		"txs", app.nTxs)
 	app.checkCache = sm.NewBlockCache(app.state)
 
 	app.nTxs = 0
+	app.blockGas = 0
 
 	// save state to disk
-	app.state.Save()
+	app.unsaved = true
+	if app.saveInterval <= 1 || app.state.LastBlockHeight%app.saveInterval == 0 {
+		app.saveState()
+	}
+
+	// index the block's logs alongside the state, which go out in the same
+	// flush, failing rather than committing state the index does not cover
+	if err := app.logIndex.Commit(); err != nil {
+		sanity.PanicCrisis(fmt.Sprintf("Failed to index logs at height %v: %v",
+			app.state.LastBlockHeight, err))
+	}
+	app.txReceipts.Commit()
+	app.eventSignatures.Commit()
+	if err := app.senderIndex.Commit(); err != nil {
+		logging.InfoMsg(app.logger, "Failed to index txs by sender", "error", err)
+	}
+
+	for _, expiry := range app.nameRegExpiries.Commit(app.state) {
+		if expiry.Expired {
+			app.evc.FireEvent(txs.EventStringNameRegExpired(expiry.Name), expiry)
+		} else {
+			app.evc.FireEvent(txs.EventStringNameRegExpiring(expiry.Name), expiry)
+		}
+		app.evc.FireEvent(txs.EventStringNameRegExpiry(), expiry)
+	}
+
+	// write the block's writes to the state database
+	app.flushWrites()
 
 	// flush events to listeners (XXX: note issue with blocking)
 	app.evc.Flush()
@@ -183,9 +459,296 @@ func (app *BurrowMint) Commit() (res abci.Result) {
 	// NOTE: set internal time as two seconds per block
 	app.state.LastBlockTime = app.state.LastBlockTime.Add(time.Duration(2) * time.Second)
 	appHash := app.state.Hash()
+
+	app.recordBlockMetrics()
+	if app.state.UpgradeDue() {
+		app.haltForUpgrade(appHash)
+	}
 	return abci.NewResultOK(appHash, "Success")
 }
 
+// Saves state and takes any snapshot due, app.mtx must be held
+func (app *BurrowMint) saveState() {
+	if app.pruner != nil {
+		app.pruner.Save(app.state)
+	} else {
+		app.state.Save()
+	}
+	if privateStates := app.state.PrivateStates(); privateStates != nil {
+		privateStates.Save()
+	}
+	app.unsaved = false
+	if app.snapshots != nil {
+		app.snapshots.Take(app.state)
+	}
+}
+
+// Updates the metrics of blocks after a commit, app.mtx must be held
+func (app *BurrowMint) recordBlockMetrics() {
+	now := time.Now()
+	if !app.lastCommitTime.IsZero() {
+		blockInterval.Observe(now.Sub(app.lastCommitTime).Seconds())
+	}
+	app.lastCommitTime = now
+	blockHeight.Set(float64(app.state.LastBlockHeight))
+	totalGasUsed := sm.GasUsed()
+	blockGasUsed.Observe(float64(totalGasUsed - app.lastGasUsed))
+	app.lastGasUsed = totalGasUsed
+}
+
+// Get the index of logs emitted by committed blocks
+func (app *BurrowMint) LogIndex() *sm.LogIndex {
+	return app.logIndex
+}
+
+// Get the receipts of CallTxs in committed blocks
+func (app *BurrowMint) TxReceipts() *sm.TxReceipts {
+	return app.txReceipts
+}
+
+// Get the events that logs are decoded as in the events RPC
+func (app *BurrowMint) EventSignatures() *sm.EventSignatures {
+	return app.eventSignatures
+}
+
+// Decodes a log against the ABI registered for its contract or the event
+// signatures known, or returns nil if it cannot be. Logs are fired from
+// Commit while app.mtx is held, so this reads the committed state directly
+// rather than through GetState.
+func (app *BurrowMint) DecodeLog(log txs.EventDataLog) *core_types.DecodedEvent {
+	return app.eventSignatures.DecodeLog(log, app.state)
+}
+
+// Get the receipt of a CallTx in a committed block with its logs decoded
+// against abiJSON, or against the ABIs registered on chain if it is empty.
+// Implements definitions.Receipts.
+func (app *BurrowMint) TxReceipt(txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	return app.txReceipts.TxReceipt(txHash, abiJSON, app.GetState())
+}
+
+// Get up to limit of the most recent txs signed by address in committed
+// blocks. Implements definitions.TxIndex.
+func (app *BurrowMint) SenderTxs(address []byte, limit int) (*core_types.SenderTxs, error) {
+	senderTxs, err := app.senderIndex.SenderTxs(address, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := &core_types.SenderTxs{Address: address, Txs: senderTxs}
+	if account := app.GetState().GetAccount(address); account != nil {
+		result.Sequence = account.Sequence
+	}
+	return result, nil
+}
+
+// Keeps the EVM traces of the capacity most recent CallTxs for TraceTx
+func (app *BurrowMint) EnableTxTraces(capacity int) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.txTraces = sm.NewTxTraces(capacity)
+	app.state.SetTxTraces(app.txTraces)
+}
+
+// Limits the gas of the txs in the mempool to the MaxGas of options, giving
+// their places to txs by the priority of options once it is full
+func (app *BurrowMint) LimitMempool(options MempoolOptions) error {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	txPool, err := newTxPool(app.state.ChainID, options)
+	if err != nil {
+		return err
+	}
+	app.txPool = txPool
+	return nil
+}
+
+// Implements manager/types.MempoolApplication
+func (app *BurrowMint) RemoveMempoolTxs(txHashes [][]byte) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if app.txPool != nil {
+		for _, txHash := range txHashes {
+			app.txPool.remove(txHash)
+		}
+	}
+	logging.InfoMsg(app.logger, "Resetting checkCache to recheck mempool",
+		"removed_txs", len(txHashes))
+	app.checkCache = sm.NewBlockCache(app.state)
+}
+
+// Implements manager/types.ReplayApplication
+func (app *BurrowMint) LastBlockHeight() int {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	return app.state.LastBlockHeight
+}
+
+// Implements manager/types.ReplayApplication. Only the heights state is
+// saved at can be read with ReadStateAt or snapshotted.
+func (app *BurrowMint) SetSaveInterval(interval int) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.saveInterval = interval
+	if app.unsaved {
+		app.saveState()
+		app.flushWrites()
+	}
+}
+
+// Buffers the writes to the state database of each block until it has been
+// committed, when they are written in one batch
+func (app *BurrowMint) EnableWriteBuffer(writeBuffer *sm.WriteBuffer) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.writeBuffer = writeBuffer
+}
+
+// Flushes the writes buffered if write buffering is enabled, app.mtx must be
+// held
+func (app *BurrowMint) flushWrites() {
+	if app.writeBuffer != nil {
+		app.writeBuffer.Flush()
+	}
+}
+
+// Takes snapshots of state with snapshots for other nodes to sync state from
+func (app *BurrowMint) EnableSnapshots(snapshots *sm.Snapshots) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.snapshots = snapshots
+}
+
+// Implements manager/types.StateSyncApplication
+func (app *BurrowMint) ListSnapshots() []*manager_types.Snapshot {
+	if app.snapshots == nil {
+		return nil
+	}
+	return app.snapshots.List()
+}
+
+// Implements manager/types.StateSyncApplication
+func (app *BurrowMint) LoadSnapshotChunk(height uint64, format uint32,
+	chunk uint32) []byte {
+	if app.snapshots == nil {
+		return nil
+	}
+	return app.snapshots.LoadChunk(height, format, chunk)
+}
+
+// Implements manager/types.StateSyncApplication
+func (app *BurrowMint) OfferSnapshot(snapshot *manager_types.Snapshot,
+	appHash []byte) manager_types.OfferSnapshotResult {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if app.state.LastBlockHeight > 0 {
+		logging.InfoMsg(app.logger, "Aborting state sync since blocks have been executed",
+			"last_block_height", app.state.LastBlockHeight)
+		return manager_types.OfferSnapshotAbort
+	}
+	if snapshot.Format != sm.SnapshotFormat {
+		return manager_types.OfferSnapshotRejectFormat
+	}
+	restorer, err := sm.NewSnapshotRestorer(app.state, snapshot, appHash)
+	if err != nil {
+		logging.InfoMsg(app.logger, "Rejected snapshot",
+			"height", snapshot.Height,
+			"error", err)
+		return manager_types.OfferSnapshotReject
+	}
+	logging.InfoMsg(app.logger, "Restoring snapshot",
+		"height", snapshot.Height,
+		"chunks", snapshot.Chunks)
+	app.restorer = restorer
+	return manager_types.OfferSnapshotAccept
+}
+
+// Implements manager/types.StateSyncApplication. Once the last chunk is
+// applied the restored state replaces the state of the application.
+func (app *BurrowMint) ApplySnapshotChunk(index uint32, chunk []byte,
+	sender string) manager_types.ResponseApplySnapshotChunk {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if app.restorer == nil {
+		return manager_types.ResponseApplySnapshotChunk{
+			Result: manager_types.ApplySnapshotChunkAbort,
+		}
+	}
+	if err := app.restorer.Apply(index, chunk); err != nil {
+		logging.InfoMsg(app.logger, "Rejected snapshot chunk",
+			"index", index,
+			"sender", sender,
+			"error", err)
+		return manager_types.ResponseApplySnapshotChunk{
+			Result:        manager_types.ApplySnapshotChunkRetry,
+			RefetchChunks: []uint32{index},
+			RejectSenders: []string{sender},
+		}
+	}
+	app.flushWrites()
+	if !app.restorer.Complete() {
+		return manager_types.ResponseApplySnapshotChunk{
+			Result: manager_types.ApplySnapshotChunkAccept,
+		}
+	}
+	restored, err := app.restorer.Finish()
+	app.restorer = nil
+	if err != nil {
+		logging.InfoMsg(app.logger, "Rejected snapshot", "error", err)
+		return manager_types.ResponseApplySnapshotChunk{
+			Result: manager_types.ApplySnapshotChunkRejectSnapshot,
+		}
+	}
+	if app.pruner != nil {
+		app.pruner.Save(restored)
+	} else {
+		restored.Save()
+	}
+	app.flushWrites()
+	restored.SetTxReceipts(app.txReceipts)
+	restored.SetPrivateStates(app.state.PrivateStates())
+	if app.txTraces != nil {
+		restored.SetTxTraces(app.txTraces)
+	}
+	app.state = restored
+	app.cache = sm.NewBlockCache(restored)
+	app.checkCache = sm.NewBlockCache(restored)
+	app.nameRegExpiries = sm.NewNameRegExpiries(restored)
+	blockHeight.Set(float64(restored.LastBlockHeight))
+	logging.InfoMsg(app.logger, "Restored state from snapshot",
+		"last_block_height", restored.LastBlockHeight)
+	return manager_types.ResponseApplySnapshotChunk{
+		Result: manager_types.ApplySnapshotChunkAccept,
+	}
+}
+
+// Counts the blocks validators miss signing from the commits in blockStore,
+// jailing those that are down for too long
+func (app *BurrowMint) SetBlockStore(blockStore blockchain_types.BlockStore) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.blockStore = blockStore
+}
+
+// Get the traces of recent CallTxs, or nil if they are not kept
+func (app *BurrowMint) TxTraces() *sm.TxTraces {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	return app.txTraces
+}
+
+// Passes events on to fireable, adding any logs to logIndex on the way
+type logIndexingFireable struct {
+	fireable tendermint_events.Fireable
+	logIndex *sm.LogIndex
+}
+
+func (lif *logIndexingFireable) FireEvent(event string, data tendermint_events.EventData) {
+	if log, ok := data.(txs.EventDataLog); ok {
+		lif.logIndex.Add(log)
+	}
+	lif.fireable.FireEvent(event, data)
+}
+
 func (app *BurrowMint) Query(query abci.RequestQuery) (res abci.ResponseQuery) {
 	return abci.ResponseQuery{
 		Code: abci.CodeType_OK,
@@ -203,13 +766,103 @@ func (app *BurrowMint) InitChain(validators []*abci.Validator) {
 
 // Signals the beginning of a block
 func (app *BurrowMint) BeginBlock(hash []byte, header *abci.Header) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if app.isHalted() {
+		return
+	}
+	app.recordCommitSigners(int(header.Height))
+	// the proposer of the block of the commit is credited with the priority
+	// fees of the block
+	app.state.BlockProposer = app.commitProposer
+}
+
+// Counts the validators that did not sign the commit of the block two before
+// height, and keeps the signers and the proposer of that block, found from
+// the round of its commit, for the rewards of the block, app.mtx must be
+// held. That commit is the LastCommit of the previous block, which is already
+// in the block store and is the same on every node, unlike the commit this
+// node saw for the last block. Every node must count the same signers, so the
+// block fails if the commit cannot be loaded.
+func (app *BurrowMint) recordCommitSigners(height int) {
+	app.commitHeight = height - 2
+	app.commitProposer = nil
+	app.commitSigners = nil
+	if height <= 2 {
+		return
+	}
+	if app.blockStore == nil {
+		sanity.PanicCrisis(fmt.Sprintf("No block store to load the commit of "+
+			"height %v from", height-2))
+	}
+	block := app.blockStore.Block(height - 1)
+	if block == nil || block.LastCommit == nil {
+		sanity.PanicCrisis(fmt.Sprintf("Could not load the commit of height %v "+
+			"from block %v", height-2, height-1))
+	}
+	var signers [][]byte
+	round := 0
+	for _, precommit := range block.LastCommit.Precommits {
+		if precommit != nil {
+			signers = append(signers, precommit.ValidatorAddress)
+			round = precommit.Round
+		}
+	}
+	if err := app.loadGenesis(); err != nil {
+		sanity.PanicCrisis(fmt.Sprintf("Could not find the proposer of "+
+			"height %v: %v", height-2, err))
+	}
+	// The schedule is read before the changes this block makes
+	proposer, err := app.proposers.ProposerAt(height-2, round,
+		app.state.GetValidatorRotations(), app.state.GetValidatorPowerChanges())
+	if err != nil {
+		sanity.PanicCrisis(fmt.Sprintf("Could not find the proposer of "+
+			"height %v: %v", height-2, err))
+	}
+	sm.RecordCommitSigners(app.cache, height-2, signers, app.evc, app.logger)
+	app.commitProposer = proposer
+	app.commitSigners = signers
+}
 
+// Reads the validators from the genesis doc in state, app.mtx must be held
+func (app *BurrowMint) loadGenesis() error {
+	if app.genesisLoaded {
+		return nil
+	}
+	genDoc, err := app.state.GetGenesisDoc()
+	if err != nil {
+		return err
+	}
+	app.proposers = sm.NewProposerSchedule(genDoc)
+	app.genesisLoaded = true
+	return nil
 }
 
 // Signals the end of a blockchain, return value can be used to modify validator
 // set and voting power distribution see our BlockchainAware interface
 func (app *BurrowMint) EndBlock(height uint64) (respEndblock abci.ResponseEndBlock) {
-	// TODO: [Silas] Bondage
+	if app.isHalted() {
+		return
+	}
+	// The rewards take the fees collected by the block's txs
+	sm.DistributeRewards(app.cache, app.commitHeight, app.commitProposer,
+		app.commitSigners, app.logger)
+	// Tendermint changes the validators of the next block, so the keys rotated
+	// and the power changed from the next height are changed now. The changes
+	// made by this block's txs are still in the cache.
+	changes := sm.ValidatorChangesAfter(app.state.GenesisValidators(),
+		app.cache.GetValidatorRotations(), app.cache.GetValidatorPowerChanges(),
+		int(height))
+	for _, change := range changes {
+		logging.InfoMsg(app.logger, "Changing validator",
+			"address", change.Address,
+			"power", change.VotingPower,
+			"height", height+1)
+		respEndblock.Diffs = append(respEndblock.Diffs, &abci.Validator{
+			PubKey: change.PubKey.Bytes(),
+			Power:  uint64(change.VotingPower),
+		})
+	}
 	// TODO: [Silas] this might be a better place for us to dispatch new block
 	// events particularly if we want to separate ourselves from go-events
 	return
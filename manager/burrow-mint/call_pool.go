@@ -0,0 +1,101 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// The zero value of CallPoolOptions simulates as many calls at once as there
+// are CPUs, each with the chain's gas limit and no time limit
+type CallPoolOptions struct {
+	// The most calls simulated at once, further calls wait for one to finish
+	Concurrency int
+	// The gas each call is given, the chain's gas limit when 0
+	GasLimit int64
+	// How long a call may wait for its turn and run before it is stopped,
+	// no limit when 0
+	Timeout time.Duration
+}
+
+// Simulates calls (Call, CallCode, and the like) for RPC queries against a
+// copy of committed state, a few at a time, so that however many queries are
+// made they neither hold up the execution of blocks nor use every CPU
+type callPool struct {
+	options CallPoolOptions
+	// Holds a token for each call simulating
+	tokens chan struct{}
+}
+
+func newCallPool(options CallPoolOptions) *callPool {
+	if options.Concurrency <= 0 {
+		options.Concurrency = runtime.NumCPU()
+	}
+	return &callPool{
+		options: options,
+		tokens:  make(chan struct{}, options.Concurrency),
+	}
+}
+
+// The gas a call is given on a chain whose gas limit is chainGasLimit
+func (pool *callPool) gasLimit(chainGasLimit int64) int64 {
+	if pool.options.GasLimit > 0 {
+		return pool.options.GasLimit
+	}
+	return chainGasLimit
+}
+
+// Runs simulate once there is room in the pool, closing the interrupt it is
+// passed (for its VM) once the call has taken longer than the timeout
+func (pool *callPool) simulate(simulate func(interrupt <-chan struct{}) error) error {
+	deadline := time.Now().Add(pool.options.Timeout)
+	var expired <-chan time.Time
+	if pool.options.Timeout > 0 {
+		timer := time.NewTimer(pool.options.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case pool.tokens <- struct{}{}:
+	case <-expired:
+		simulatedCalls.Inc("timeout")
+		return fmt.Errorf("Timed out after %v waiting to simulate call",
+			pool.options.Timeout)
+	}
+	defer func() { <-pool.tokens }()
+
+	interrupt := make(chan struct{})
+	if pool.options.Timeout > 0 {
+		timer := time.AfterFunc(deadline.Sub(time.Now()), func() {
+			close(interrupt)
+		})
+		defer timer.Stop()
+	}
+	err := simulate(interrupt)
+	if err != nil {
+		select {
+		case <-interrupt:
+			simulatedCalls.Inc("timeout")
+			return fmt.Errorf("Call did not finish within %v", pool.options.Timeout)
+		default:
+		}
+		simulatedCalls.Inc("error")
+		return err
+	}
+	simulatedCalls.Inc("success")
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallPoolConcurrency(t *testing.T) {
+	pool := newCallPool(CallPoolOptions{Concurrency: 2})
+	running := make(chan struct{}, 3)
+	release := make(chan struct{})
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			done <- pool.simulate(func(interrupt <-chan struct{}) error {
+				running <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	<-running
+	<-running
+	select {
+	case <-running:
+		t.Fatal("More calls simulated at once than the concurrency")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestCallPoolTimeout(t *testing.T) {
+	pool := newCallPool(CallPoolOptions{Concurrency: 1, Timeout: 50 * time.Millisecond})
+	failed := errors.New("failed")
+	assert.Equal(t, failed, pool.simulate(func(interrupt <-chan struct{}) error {
+		return failed
+	}))
+
+	timeouts := simulatedCalls.Value("timeout")
+	started := make(chan struct{})
+	interrupted := make(chan error)
+	go func() {
+		interrupted <- pool.simulate(func(interrupt <-chan struct{}) error {
+			close(started)
+			// As the VM does once interrupted
+			<-interrupt
+			return failed
+		})
+	}()
+	<-started
+	// Times out waiting for the interrupted call to finish
+	assert.Error(t, pool.simulate(func(interrupt <-chan struct{}) error {
+		t.Fatal("Call simulated while the pool was full")
+		return nil
+	}))
+	err := <-interrupted
+	assert.Error(t, err)
+	assert.NotEqual(t, failed, err)
+	assert.Equal(t, timeouts+2, simulatedCalls.Value("timeout"))
+}
+
+func TestCallPoolGasLimit(t *testing.T) {
+	assert.Equal(t, int64(1000), newCallPool(CallPoolOptions{}).gasLimit(1000))
+	assert.Equal(t, int64(10), newCallPool(CallPoolOptions{GasLimit: 10}).gasLimit(1000))
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	crypto "github.com/tendermint/go-crypto"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/hyperledger/burrow/manager/burrow-mint/state"
+	"github.com/hyperledger/burrow/txs"
+	"github.com/hyperledger/burrow/word256"
+)
+
+// The length of the nonce a blob is sealed under with AES-GCM
+const confidentialNonceLength = 12
+
+// Seals and opens the blobs of the confidential store. The key of each blob is
+// derived from the signature, by a key held by the keys service, of the owner
+// and key of the blob, so every node whose keys service holds that key opens
+// the blobs any of them sealed. The key must sign deterministically, as
+// ed25519 keys do.
+type confidentialStore struct {
+	keyClient keys.KeyClient
+	address   []byte
+	chainID   string
+}
+
+// Loads the confidential store from confidential.keys_server and
+// confidential.key_address, nil if no key address is given
+func loadConfidentialStore(moduleConfig *config.ModuleConfig,
+	logger logging_types.InfoTraceLogger) (*confidentialStore, error) {
+	addressHex := moduleConfig.Config.GetString("confidential.key_address")
+	if addressHex == "" {
+		return nil, nil
+	}
+	address, err := hex.DecodeString(addressHex)
+	if err != nil || len(address) != 20 {
+		return nil, fmt.Errorf("Invalid key address of confidential store %s",
+			addressHex)
+	}
+	keysServer := moduleConfig.Config.GetString("confidential.keys_server")
+	logging.InfoMsg(logger, "Opening the confidential store",
+		"keysServer", keysServer,
+		"keyAddress", addressHex)
+	return newConfidentialStore(keys.NewBurrowKeyClient(keysServer, logger),
+		address, moduleConfig.ChainId), nil
+}
+
+func newConfidentialStore(keyClient keys.KeyClient, address []byte,
+	chainID string) *confidentialStore {
+	return &confidentialStore{
+		keyClient: keyClient,
+		address:   address,
+		chainID:   chainID,
+	}
+}
+
+// Seals data to be put by owner at key, under a random nonce. The owner and
+// key are authenticated so the blob cannot be put elsewhere and read there.
+func (store *confidentialStore) seal(owner []byte, key word256.Word256,
+	data []byte) ([]byte, error) {
+	aead, err := store.aead(owner, key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, confidentialNonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("Could not make nonce: %v", err)
+	}
+	return aead.Seal(nonce, nonce, data, confidentialAdditionalData(owner, key)), nil
+}
+
+// Opens the blob owner put at key
+func (store *confidentialStore) open(owner []byte, key word256.Word256,
+	blob []byte) ([]byte, error) {
+	if len(blob) < confidentialNonceLength {
+		return nil, fmt.Errorf("Blob of %X at key %X was not sealed by a node",
+			owner, key)
+	}
+	aead, err := store.aead(owner, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := aead.Open(nil, blob[:confidentialNonceLength],
+		blob[confidentialNonceLength:], confidentialAdditionalData(owner, key))
+	if err != nil {
+		return nil, fmt.Errorf("Could not open blob of %X at key %X: %v", owner,
+			key, err)
+	}
+	return data, nil
+}
+
+// Opens the blob of read for the account with pubKey that signed it, once
+// checking against currentState that the account may read the blob and that
+// read has not expired
+func (store *confidentialStore) read(currentState *state.State,
+	read *txs.ConfidentialRead, pubKey crypto.PubKeyEd25519,
+	signature crypto.SignatureEd25519) ([]byte, error) {
+	if !pubKey.VerifyBytes(acm.SignBytes(store.chainID, read), signature) {
+		return nil, fmt.Errorf("Read of the confidential store is not signed by "+
+			"public key %X", pubKey[:])
+	}
+	height := currentState.LastBlockHeight
+	if read.Height > height || read.Height+txs.ConfidentialReadWindow < height {
+		return nil, fmt.Errorf("Read of the confidential store at height %v has "+
+			"expired or is ahead of the chain at height %v", read.Height, height)
+	}
+	key := word256.LeftPadWord256(read.Key)
+	reader := pubKey.Address()
+	if !currentState.CanReadConfidential(read.Owner, key, reader) {
+		return nil, fmt.Errorf("%X is not granted to read the blob of %X at key %X",
+			reader, read.Owner, read.Key)
+	}
+	blob := currentState.GetConfidentialBlob(read.Owner, key)
+	if blob == nil {
+		return nil, fmt.Errorf("%X has not put a blob at key %X", read.Owner,
+			read.Key)
+	}
+	return store.open(read.Owner, key, blob)
+}
+
+func (store *confidentialStore) aead(owner []byte,
+	key word256.Word256) (cipher.AEAD, error) {
+	msg := sha3.Sha3(append([]byte(store.chainID), confidentialAdditionalData(owner, key)...))
+	signature, err := store.keyClient.Sign(hex.EncodeToString(msg), store.address)
+	if err != nil {
+		return nil, fmt.Errorf("Could not derive key of blob: %v", err)
+	}
+	block, err := aes.NewCipher(sha3.Sha3(signature))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func confidentialAdditionalData(owner []byte, key word256.Word256) []byte {
+	return bytes.Join([][]byte{[]byte("Confidential"),
+		word256.LeftPadBytes(owner, 20), key.Bytes()}, nil)
+}
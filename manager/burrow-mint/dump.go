@@ -0,0 +1,85 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/config"
+	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/manager/burrow-mint/state"
+)
+
+// Writes a dump (see state.DumpRecord) of the state committed at height in the
+// module's data directory to w, or of the last committed state if height is 0.
+// Past heights can only be dumped if their version of state was recorded and
+// has not been pruned. The state database is opened directly so the node must
+// not be running.
+func DumpState(moduleConfig *config.ModuleConfig, height int, w io.Writer) error {
+	stateDB, err := newStateDB(moduleConfig.DataDir,
+		moduleConfig.Config.GetString("db_backend"))
+	if err != nil {
+		return err
+	}
+	defer stateDB.Close()
+	st := state.LoadState(stateDB)
+	if st == nil {
+		return fmt.Errorf("No state to dump in %s", moduleConfig.DataDir)
+	}
+	if height > st.LastBlockHeight {
+		return fmt.Errorf("Height %v is greater than the last block height %v",
+			height, st.LastBlockHeight)
+	}
+	if height != 0 && height != st.LastBlockHeight {
+		if st, err = state.LoadStateAt(st, height); err != nil {
+			return err
+		}
+	}
+	return st.Dump(w)
+}
+
+// Initialises the state of a new chain in the module's data directory from
+// its genesis file (which provides the chain id and validators) and a dump
+// read from r, so that the chain starts from the dumped accounts, storage,
+// and name registry.
+func RestoreState(moduleConfig *config.ModuleConfig, r io.Reader) error {
+	stateDB, err := newStateDB(moduleConfig.DataDir,
+		moduleConfig.Config.GetString("db_backend"))
+	if err != nil {
+		return err
+	}
+	defer stateDB.Close()
+	if state.LoadState(stateDB) != nil {
+		return fmt.Errorf("State already exists in %s, a dump can only be "+
+			"restored to a new chain", moduleConfig.DataDir)
+	}
+	jsonBlob, err := ioutil.ReadFile(moduleConfig.GenesisFile)
+	if err != nil {
+		return fmt.Errorf("Could not read genesis file: %v", err)
+	}
+	genesisDoc := genesis.GenesisDocFromJSON(jsonBlob)
+	if genesisDoc.ChainID != moduleConfig.ChainId {
+		return fmt.Errorf("ChainId (%s) of genesis file does not match "+
+			"configuration chainId (%s).", genesisDoc.ChainID, moduleConfig.ChainId)
+	}
+	st, err := state.RestoreState(stateDB, genesisDoc, r)
+	if err != nil {
+		return err
+	}
+	st.Save()
+	return saveGenesisDoc(stateDB, genesisDoc)
+}
@@ -0,0 +1,363 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The functions, events and custom errors of a contract's JSON ABI, as output
+// by solc. Other entries, such as the constructor and fallback function, are
+// ignored.
+type ABI struct {
+	Functions []*Function
+	Events    []*Event
+	Errors    []*Error
+}
+
+type Event struct {
+	Name      string        `json:"name"`
+	Inputs    []*EventInput `json:"inputs"`
+	Anonymous bool          `json:"anonymous"`
+}
+
+// An input of an event, which like an Argument may be a struct with
+// Components
+type EventInput struct {
+	Name         string      `json:"name"`
+	TypeName     TypeName    `json:"type"`
+	Components   []*Argument `json:"components"`
+	InternalType string      `json:"internalType"`
+	Indexed      bool        `json:"indexed"`
+}
+
+type abiEntry struct {
+	Type string `json:"type"`
+}
+
+// Reads a contract's JSON ABI
+func ReadABI(abiJSON []byte) (*ABI, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(abiJSON, &entries); err != nil {
+		return nil, fmt.Errorf("Could not read ABI: %v", err)
+	}
+	abi := new(ABI)
+	for _, entryJSON := range entries {
+		entry := new(abiEntry)
+		if err := json.Unmarshal(entryJSON, entry); err != nil {
+			return nil, fmt.Errorf("Could not read ABI: %v", err)
+		}
+		switch entry.Type {
+		case "event":
+			event := new(Event)
+			if err := json.Unmarshal(entryJSON, event); err != nil {
+				return nil, fmt.Errorf("Could not read ABI: %v", err)
+			}
+			abi.Events = append(abi.Events, event)
+		case "error":
+			customError := new(Error)
+			if err := json.Unmarshal(entryJSON, customError); err != nil {
+				return nil, fmt.Errorf("Could not read ABI: %v", err)
+			}
+			abi.Errors = append(abi.Errors, customError)
+		case "function", "":
+			// solc used to leave out the type of functions
+			function := new(Function)
+			if err := json.Unmarshal(entryJSON, function); err != nil {
+				return nil, fmt.Errorf("Could not read ABI: %v", err)
+			}
+			abi.Functions = append(abi.Functions, function)
+		}
+	}
+	return abi, nil
+}
+
+// Get the event that the first topic of a log identifies, or nil if there is
+// none. Anonymous events cannot be identified.
+func (abi *ABI) EventByID(id Word256) *Event {
+	for _, event := range abi.Events {
+		if !event.Anonymous && event.ID() == id {
+			return event
+		}
+	}
+	return nil
+}
+
+// The canonical signature of the event, such as Transfer(address,uint256)
+func (event *Event) Signature() string {
+	typeNames := make([]string, len(event.Inputs))
+	for i, input := range event.Inputs {
+		typ, err := input.Type()
+		if err != nil {
+			// Such an event cannot be decoded anyway
+			typeNames[i] = string(input.TypeName)
+			continue
+		}
+		typeNames[i] = string(typ.Name)
+	}
+	return fmt.Sprintf("%s(%s)", event.Name, strings.Join(typeNames, ","))
+}
+
+// The hash of the signature, which is the first topic of the event's logs
+// unless it is anonymous
+func (event *Event) ID() Word256 {
+	return LeftPadWord256(sha3.Sha3([]byte(event.Signature())))
+}
+
+// Parses the event of a canonical signature, such as those of lists of
+// signatures like 4byte.directory's. The inputs of the event have no names and
+// are not indexed, since a signature does not say.
+func ParseEventSignature(signature string) (*Event, error) {
+	signature = strings.TrimSpace(signature)
+	i := strings.Index(signature, "(")
+	if i <= 0 || !strings.HasSuffix(signature, ")") {
+		return nil, fmt.Errorf("Event signature %s is not of the form name(types)", signature)
+	}
+	args, err := parseSignatureTypes(signature[i+1 : len(signature)-1])
+	if err != nil {
+		return nil, fmt.Errorf("Could not read event signature %s: %v", signature, err)
+	}
+	event := &Event{Name: signature[:i]}
+	for _, arg := range args {
+		event.Inputs = append(event.Inputs, &EventInput{
+			TypeName:   arg.TypeName,
+			Components: arg.Components,
+		})
+	}
+	return event, nil
+}
+
+// Parses a comma separated list of types, in which tuples are written as their
+// component types in brackets, into arguments
+func parseSignatureTypes(typeList string) ([]*Argument, error) {
+	if typeList == "" {
+		return nil, nil
+	}
+	var args []*Argument
+	depth, start := 0, 0
+	for i := 0; i <= len(typeList); i++ {
+		if i < len(typeList) {
+			switch typeList[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				if depth < 0 {
+					return nil, fmt.Errorf("unbalanced brackets in %s", typeList)
+				}
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		} else if depth != 0 {
+			return nil, fmt.Errorf("unbalanced brackets in %s", typeList)
+		}
+		arg, err := parseSignatureType(strings.TrimSpace(typeList[start:i]))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		start = i + 1
+	}
+	return args, nil
+}
+
+func parseSignatureType(typeName string) (*Argument, error) {
+	arg := &Argument{TypeName: TypeName(typeName)}
+	if strings.HasPrefix(typeName, "(") {
+		end := strings.LastIndex(typeName, ")")
+		components, err := parseSignatureTypes(typeName[1:end])
+		if err != nil {
+			return nil, err
+		}
+		arg.TypeName = TypeName("tuple" + typeName[end+1:])
+		arg.Components = components
+	}
+	if _, err := ParseType(arg.TypeName, arg.Components); err != nil {
+		return nil, err
+	}
+	return arg, nil
+}
+
+func (input *EventInput) Type() (*Type, error) {
+	return ParseType(input.TypeName, input.Components)
+}
+
+// Decodes the inputs of the event from the topics and data of a log, returning
+// them formatted as strings in the order of the inputs. Numbers are in
+// decimal, addresses and bytes in hex, and arrays and structs in JSON. Indexed
+// inputs of types other than elementary ones are only logged as a hash, which
+// is returned in their place.
+func (event *Event) Decode(topics []Word256, data []byte) ([]string, error) {
+	values, err := event.Unpack(topics, data)
+	if err != nil {
+		return nil, err
+	}
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = FormatValue(value)
+	}
+	return formatted, nil
+}
+
+// Decodes the inputs of the event from the topics and data of a log like
+// Function.Unpack, with arrays and structs as slices
+func (event *Event) Unpack(topics []Word256, data []byte) ([]interface{}, error) {
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID() {
+			return nil, fmt.Errorf("Log is not a %s event", event.Name)
+		}
+		topics = topics[1:]
+	}
+	values := make([]interface{}, len(event.Inputs))
+	var unindexed []int
+	var unindexedTypes []*Type
+	for i, input := range event.Inputs {
+		typ, err := input.Type()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read input %s of %s event: %v",
+				input.Name, event.Name, err)
+		}
+		if !input.Indexed {
+			unindexed = append(unindexed, i)
+			unindexedTypes = append(unindexedTypes, typ)
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("Log of %s event is missing the topic "+
+				"for input %s", event.Name, input.Name)
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if typ.Kind != ElementaryKind {
+			values[i] = fmt.Sprintf("%X", topic.Bytes())
+			continue
+		}
+		value, err := decodeWord(typ.Name, topic)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	decoded, err := decodeSequence(unindexedTypes, data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode %s event: %v", event.Name, err)
+	}
+	for j, i := range unindexed {
+		values[i] = decoded[j]
+	}
+	return values, nil
+}
+
+func canonicalTypeName(typeName TypeName) TypeName {
+	switch typeName {
+	case "uint":
+		return "uint256"
+	case IntTypeName:
+		return "int256"
+	}
+	return typeName
+}
+
+// Decodes a value of a static type from its word
+func decodeWord(typeName TypeName, word Word256) (string, error) {
+	name := string(typeName)
+	switch {
+	case typeName == AddressTypeName:
+		return fmt.Sprintf("%X", word.Postfix(AddressLength)), nil
+	case typeName == BoolTypeName:
+		return strconv.FormatBool(word != Zero256), nil
+	case strings.HasPrefix(name, "uint"):
+		if _, err := typeSize(name, "uint", 8, 256); err != nil {
+			return "", err
+		}
+		return new(big.Int).SetBytes(word.Bytes()).String(), nil
+	case strings.HasPrefix(name, "int"):
+		if _, err := typeSize(name, "int", 8, 256); err != nil {
+			return "", err
+		}
+		i := new(big.Int).SetBytes(word.Bytes())
+		if word[0]&0x80 != 0 {
+			i.Sub(i, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return i.String(), nil
+	case strings.HasPrefix(name, "bytes"):
+		size, err := typeSize(name, "bytes", 1, 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%X", word[:size]), nil
+	}
+	return "", fmt.Errorf("ABI type %s is not supported", typeName)
+}
+
+// Decodes a string or bytes that starts at offset in data
+func decodeBytes(typeName TypeName, data []byte, offset int) (string, error) {
+	lengthWord, err := readWord(data, offset)
+	if err != nil {
+		return "", err
+	}
+	length, err := wordInt(lengthWord, len(data))
+	if err != nil {
+		return "", err
+	}
+	if offset+32+length > len(data) {
+		return "", fmt.Errorf("%s of length %v overruns the data", typeName, length)
+	}
+	bs := data[offset+32 : offset+32+length]
+	if typeName == StringTypeName {
+		return string(bs), nil
+	}
+	return fmt.Sprintf("%X", bs), nil
+}
+
+// Gets the size in the name of a type, such as 8 in uint8, which must be
+// between min and max and divide by min
+func typeSize(name, prefix string, min, max int) (int, error) {
+	size, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	if err != nil || size < min || size > max || size%min != 0 {
+		return 0, fmt.Errorf("ABI type %s is not supported", name)
+	}
+	return size, nil
+}
+
+func readWord(data []byte, offset int) (Word256, error) {
+	if offset+32 > len(data) {
+		return Zero256, fmt.Errorf("data of length %v ends before the word "+
+			"at %v", len(data), offset)
+	}
+	return LeftPadWord256(data[offset : offset+32]), nil
+}
+
+// Reads a word as an int that must be no greater than max
+func wordInt(word Word256, max int) (int, error) {
+	n := new(big.Int).SetBytes(word.Bytes())
+	if n.Cmp(big.NewInt(int64(max))) > 0 {
+		return 0, fmt.Errorf("offset or length %v overruns the data", n)
+	}
+	return int(n.Int64()), nil
+}
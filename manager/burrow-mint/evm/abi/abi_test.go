@@ -0,0 +1,155 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tokenABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}]},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}
+	]},
+	{"type": "event", "name": "Note", "anonymous": false, "inputs": [
+		{"name": "tag", "type": "string", "indexed": true},
+		{"name": "delta", "type": "int"},
+		{"name": "text", "type": "string"},
+		{"name": "ok", "type": "bool"},
+		{"name": "id", "type": "bytes4"}
+	]}
+]`
+
+func TestEventByID(t *testing.T) {
+	abi, err := ReadABI([]byte(tokenABI))
+	require.NoError(t, err)
+	require.Len(t, abi.Events, 2)
+	transfer := abi.Events[0]
+	assert.Equal(t, "Transfer(address,address,uint256)", transfer.Signature())
+	id, _ := hex.DecodeString("ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	assert.Equal(t, LeftPadWord256(id), transfer.ID())
+	assert.Equal(t, transfer, abi.EventByID(transfer.ID()))
+	assert.Equal(t, "Note(string,int256,string,bool,bytes4)", abi.Events[1].Signature())
+	assert.Nil(t, abi.EventByID(One256))
+
+	_, err = ReadABI([]byte(`{"type": "event"}`))
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	abi, err := ReadABI([]byte(tokenABI))
+	require.NoError(t, err)
+	from := LeftPadWord256([]byte{0xAB, 0xCD})
+	to := LeftPadWord256([]byte{0x12})
+	transfer := abi.Events[0]
+	values, err := transfer.Decode([]Word256{transfer.ID(), from, to},
+		Int64ToWord256(1000).Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"000000000000000000000000000000000000ABCD",
+		"0000000000000000000000000000000000000012", "1000"}, values)
+
+	_, err = transfer.Decode([]Word256{One256, from, to}, Int64ToWord256(1000).Bytes())
+	assert.Error(t, err)
+	_, err = transfer.Decode([]Word256{transfer.ID(), from}, Int64ToWord256(1000).Bytes())
+	assert.Error(t, err)
+	_, err = transfer.Decode([]Word256{transfer.ID(), from, to}, nil)
+	assert.Error(t, err)
+
+	note := abi.Events[1]
+	tag := LeftPadWord256([]byte("hash of the tag"))
+	minusTwo := RightPadWord256(nil)
+	for i := range minusTwo {
+		minusTwo[i] = 0xFF
+	}
+	minusTwo[31] = 0xFE
+	var data []byte
+	for _, word := range []Word256{minusTwo, Int64ToWord256(4 * 32), One256,
+		RightPadWord256([]byte{1, 2, 3, 4}), Int64ToWord256(5),
+		RightPadWord256([]byte("hello"))} {
+		data = append(data, word.Bytes()...)
+	}
+	values, err = note.Decode([]Word256{note.ID(), tag}, data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{fmt.Sprintf("%X", tag.Bytes()), "-2", "hello", "true",
+		"01020304"}, values)
+
+	// The string runs past the end of the data
+	_, err = note.Decode([]Word256{note.ID(), tag}, data[:len(data)-30])
+	assert.Error(t, err)
+}
+
+func TestEventTuples(t *testing.T) {
+	abi, err := ReadABI([]byte(`[
+		{"type": "event", "name": "Moved", "anonymous": false, "inputs": [
+			{"name": "ids", "type": "uint[]", "indexed": true},
+			{"name": "to", "type": "tuple", "indexed": false, "components": [
+				{"name": "x", "type": "int8"},
+				{"name": "tags", "type": "string[]"}
+			]},
+			{"name": "path", "type": "uint16[2][]", "indexed": false}
+		]}
+	]`))
+	require.NoError(t, err)
+	moved := abi.Events[0]
+	assert.Equal(t, "Moved(uint256[],(int8,string[]),uint16[2][])", moved.Signature())
+
+	function := &Function{Name: "f", Inputs: []*Argument{
+		{TypeName: "tuple", Components: moved.Inputs[1].Components},
+		{TypeName: "uint16[2][]"},
+	}}
+	data, err := function.Pack(`{"x": -1, "tags": ["a", "b"]}`, "[[1, 2], [3, 4]]")
+	require.NoError(t, err)
+	ids := LeftPadWord256([]byte("hash of the ids"))
+	values, err := moved.Unpack([]Word256{moved.ID(), ids}, data[4:])
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{fmt.Sprintf("%X", ids.Bytes()),
+		[]interface{}{"-1", []interface{}{"a", "b"}},
+		[]interface{}{[]interface{}{"1", "2"}, []interface{}{"3", "4"}}}, values)
+
+	formatted, err := moved.Decode([]Word256{moved.ID(), ids}, data[4:])
+	require.NoError(t, err)
+	assert.Equal(t, `["-1",["a","b"]]`, formatted[1])
+	assert.Equal(t, `[["1","2"],["3","4"]]`, formatted[2])
+}
+
+func TestParseEventSignature(t *testing.T) {
+	for _, signature := range []string{
+		"Transfer(address,address,uint256)",
+		"Moved(uint256[],(int8,string[]),uint16[2][])",
+		"Nested(((bool,bytes32)[2],address)[])",
+		"Nothing()",
+	} {
+		event, err := ParseEventSignature(signature)
+		require.NoError(t, err, signature)
+		assert.Equal(t, signature, event.Signature())
+	}
+	event, err := ParseEventSignature(" Transfer(address, address, uint)")
+	require.NoError(t, err)
+	assert.Equal(t, "Transfer(address,address,uint256)", event.Signature())
+
+	for _, signature := range []string{"", "Transfer", "(address)", "Bad(address", "Bad((address)",
+		"Bad(address))", "Bad(strin)", "Bad(address,)"} {
+		_, err := ParseEventSignature(signature)
+		assert.Error(t, err, signature)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// A custom error a contract can revert with, which is ABI encoded like a call
+// to a function of the same signature
+type Error struct {
+	Name   string      `json:"name"`
+	Inputs []*Argument `json:"inputs"`
+}
+
+// The errors Solidity reverts with for revert and require with a reason, and
+// for failed asserts, arithmetic overflow and the like
+var (
+	ReasonError = &Error{Name: "Error", Inputs: []*Argument{{Name: "reason", TypeName: StringTypeName}}}
+	PanicError  = &Error{Name: "Panic", Inputs: []*Argument{{Name: "code", TypeName: "uint256"}}}
+)
+
+// The reasons for the codes of Panic errors
+var panicReasons = map[int64]string{
+	0x00: "generic panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion to an invalid enum value",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop from an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an uninitialised internal function",
+}
+
+// The canonical signature of the error, such as InsufficientBalance(uint256)
+func (customError *Error) Signature() (string, error) {
+	function := &Function{Name: customError.Name, Inputs: customError.Inputs}
+	return function.Signature()
+}
+
+// The first 4 bytes of the hash of the signature, which the data the error is
+// reverted with starts with
+func (customError *Error) Selector() (FunctionSelector, error) {
+	function := &Function{Name: customError.Name, Inputs: customError.Inputs}
+	return function.Selector()
+}
+
+// Decodes the inputs of the error from the data a call reverted with, like
+// Function.Unpack
+func (customError *Error) Unpack(data []byte) ([]interface{}, error) {
+	selector, err := customError.Selector()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(selector) || !bytes.Equal(data[:len(selector)], selector[:]) {
+		return nil, fmt.Errorf("Data is not a %s error", customError.Name)
+	}
+	types, err := parseArguments(customError.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read inputs of error %s: %v",
+			customError.Name, err)
+	}
+	values, err := decodeSequence(types, data[len(selector):], 0)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode %s error: %v", customError.Name, err)
+	}
+	return values, nil
+}
+
+// Gets the error of the ABI that the data a call reverted with is an encoding
+// of, which may be Error(string) or Panic(uint256) even when abi is nil, or
+// nil if there is none
+func (abi *ABI) ErrorBySelector(data []byte) *Error {
+	var customErrors []*Error
+	if abi != nil {
+		customErrors = abi.Errors
+	}
+	for _, customError := range append(customErrors, ReasonError, PanicError) {
+		selector, err := customError.Selector()
+		if err == nil && bytes.HasPrefix(data, selector[:]) {
+			return customError
+		}
+	}
+	return nil
+}
+
+// Describes the data a call reverted with, as the reason for Error(string),
+// the code and its meaning for Panic(uint256) and the error and its inputs for
+// custom errors of the ABI, such as InsufficientBalance(needed: 10). Data that
+// is none of them is given in hex.
+func (abi *ABI) DecodeRevert(data []byte) string {
+	customError := abi.ErrorBySelector(data)
+	if customError == nil {
+		return fmt.Sprintf("%X", data)
+	}
+	values, err := customError.Unpack(data)
+	if err != nil {
+		return fmt.Sprintf("%X", data)
+	}
+	switch customError {
+	case ReasonError:
+		return values[0].(string)
+	case PanicError:
+		code, _ := new(big.Int).SetString(values[0].(string), 10)
+		description := fmt.Sprintf("Panic 0x%x", code)
+		if reason, ok := panicReasons[code.Int64()]; ok && code.IsInt64() {
+			description += ": " + reason
+		}
+		return description
+	}
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = FormatValue(value)
+		if name := customError.Inputs[i].Name; name != "" {
+			formatted[i] = name + ": " + formatted[i]
+		}
+	}
+	return fmt.Sprintf("%s(%s)", customError.Name, strings.Join(formatted, ", "))
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	abi, err := ReadABI([]byte(`[
+		{"type": "error", "name": "InsufficientBalance", "inputs": [
+			{"name": "available", "type": "uint256"},
+			{"name": "required", "type": "uint256"}
+		]},
+		{"type": "error", "name": "Unauthorised", "inputs": [{"name": "", "type": "address"}]}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, abi.Errors, 2)
+	signature, err := abi.Errors[0].Signature()
+	require.NoError(t, err)
+	assert.Equal(t, "InsufficientBalance(uint256,uint256)", signature)
+	selector, err := abi.Errors[0].Selector()
+	require.NoError(t, err)
+	assert.Equal(t, sha3.Sha3([]byte(signature))[:4], selector[:])
+
+	data := append(selector[:], Int64ToWord256(10).Bytes()...)
+	data = append(data, Int64ToWord256(20).Bytes()...)
+	assert.Equal(t, abi.Errors[0], abi.ErrorBySelector(data))
+	assert.Equal(t, "InsufficientBalance(available: 10, required: 20)", abi.DecodeRevert(data))
+	// Truncated
+	assert.Equal(t, "CF479181", abi.DecodeRevert(data[:4]))
+
+	selector, err = abi.Errors[1].Selector()
+	require.NoError(t, err)
+	data = append(selector[:], LeftPadWord256([]byte{0xAB}).Bytes()...)
+	assert.Equal(t, "Unauthorised(00000000000000000000000000000000000000AB)",
+		abi.DecodeRevert(data))
+
+	// Errors Solidity reverts with are known without an ABI
+	var noABI *ABI
+	reason, err := (&Function{Name: "Error", Inputs: ReasonError.Inputs}).Pack("Not enough")
+	require.NoError(t, err)
+	assert.Equal(t, "Not enough", noABI.DecodeRevert(reason))
+	panicCode, err := (&Function{Name: "Panic", Inputs: PanicError.Inputs}).Pack("0x11")
+	require.NoError(t, err)
+	assert.Equal(t, "Panic 0x11: arithmetic overflow or underflow", noABI.DecodeRevert(panicCode))
+	assert.Equal(t, "010203", noABI.DecodeRevert([]byte{1, 2, 3}))
+}
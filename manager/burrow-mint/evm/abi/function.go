@@ -0,0 +1,774 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+type Function struct {
+	Name    string      `json:"name"`
+	Inputs  []*Argument `json:"inputs"`
+	Outputs []*Argument `json:"outputs"`
+	// Older versions of solc only set Constant, newer ones StateMutability
+	Constant        bool   `json:"constant"`
+	Payable         bool   `json:"payable"`
+	StateMutability string `json:"stateMutability"`
+}
+
+// An input or output of a function. Structs have the type tuple, or tuple[]
+// and so on for arrays of them, with their fields as Components.
+type Argument struct {
+	Name       string      `json:"name"`
+	TypeName   TypeName    `json:"type"`
+	Components []*Argument `json:"components"`
+	// The Solidity type, such as struct Token.Holder, output by newer solc
+	InternalType string `json:"internalType"`
+}
+
+// Gets the function called name, which may be a full signature such as
+// transfer(address,uint256) to pick one of several overloaded functions. If
+// it is not a signature, overloads are told apart by their number of inputs.
+func (abi *ABI) FunctionByName(name string, numInputs int) (*Function, error) {
+	var matches []*Function
+	for _, function := range abi.Functions {
+		if strings.Contains(name, "(") {
+			signature, err := function.Signature()
+			if err == nil && signature == name {
+				return function, nil
+			}
+		} else if function.Name == name {
+			matches = append(matches, function)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("ABI has no function %s", name)
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	var found *Function
+	for _, function := range matches {
+		if len(function.Inputs) == numInputs {
+			if found != nil {
+				return nil, fmt.Errorf("Function %s is overloaded so must be "+
+					"given by its signature", name)
+			}
+			found = function
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("No function %s takes %v inputs", name, numInputs)
+	}
+	return found, nil
+}
+
+// The canonical signature of the function, such as transfer(address,uint256)
+func (function *Function) Signature() (string, error) {
+	types, err := parseArguments(function.Inputs)
+	if err != nil {
+		return "", fmt.Errorf("Could not read inputs of function %s: %v",
+			function.Name, err)
+	}
+	typeNames := make([]string, len(types))
+	for i, typ := range types {
+		typeNames[i] = string(typ.Name)
+	}
+	return fmt.Sprintf("%s(%s)", function.Name, strings.Join(typeNames, ",")), nil
+}
+
+// The first 4 bytes of the hash of the signature, which a call to the function
+// starts with
+func (function *Function) Selector() (FunctionSelector, error) {
+	var selector FunctionSelector
+	signature, err := function.Signature()
+	if err != nil {
+		return selector, err
+	}
+	copy(selector[:], sha3.Sha3([]byte(signature)))
+	return selector, nil
+}
+
+// Whether the function accepts an amount sent with a call to it
+func (function *Function) IsPayable() bool {
+	return function.Payable || function.StateMutability == "payable"
+}
+
+// Whether the function leaves state as it is, so can be run as a simulated
+// call rather than a transaction
+func (function *Function) IsConstant() bool {
+	return function.Constant || function.StateMutability == "view" ||
+		function.StateMutability == "pure"
+}
+
+// Encodes a call to the function with the values of its inputs. Values of
+// elementary types are given as strings, with numbers in decimal or 0x
+// prefixed hex and addresses and bytes in hex. Arrays and structs are given
+// as JSON arrays, or objects keyed by field name for structs, either decoded
+// or as a string. Values can also be given as Go values: integers, *big.Int,
+// []byte and byte arrays, slices and arrays, and structs with their fields in
+// the order of the components.
+func (function *Function) Pack(values ...interface{}) ([]byte, error) {
+	if len(values) != len(function.Inputs) {
+		return nil, fmt.Errorf("Function %s takes %v inputs but %v were given",
+			function.Name, len(function.Inputs), len(values))
+	}
+	types, err := parseArguments(function.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read inputs of function %s: %v",
+			function.Name, err)
+	}
+	selector, err := function.Selector()
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeSequence(types, values)
+	if err != nil {
+		return nil, fmt.Errorf("Could not encode call to %s: %v", function.Name, err)
+	}
+	return append(selector[:], data...), nil
+}
+
+// Decodes the outputs of the function from the data it returns, in the order
+// of the outputs. Values of elementary types are strings formatted as for
+// events, and arrays and structs are slices of their elements or fields.
+func (function *Function) Unpack(data []byte) ([]interface{}, error) {
+	types, err := parseArguments(function.Outputs)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read outputs of function %s: %v",
+			function.Name, err)
+	}
+	values, err := decodeSequence(types, data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode output of %s: %v", function.Name, err)
+	}
+	return values, nil
+}
+
+// Formats a value decoded by Unpack, as itself if it is a string or else as
+// JSON
+func FormatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	bs, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(bs)
+}
+
+// The kind of an ABI type: elementary types fit in a word, and bytes include
+// strings
+type Kind int
+
+const (
+	ElementaryKind Kind = iota
+	BytesKind
+	ArrayKind
+	TupleKind
+)
+
+// A parsed ABI type
+type Type struct {
+	// The canonical name, with tuples written as their components in brackets
+	Name TypeName
+	Kind Kind
+	// The length of an array, or -1 if it is dynamic
+	Length int
+	Elem   *Type
+	// The types and names of the fields of a tuple
+	Components []*Type
+	FieldNames []string
+}
+
+func parseArguments(args []*Argument) ([]*Type, error) {
+	types := make([]*Type, len(args))
+	for i, arg := range args {
+		typ, err := ParseType(arg.TypeName, arg.Components)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = typ
+	}
+	return types, nil
+}
+
+// Parses a type name, such as uint256[2][] or tuple[], with the components of
+// a tuple
+func ParseType(typeName TypeName, components []*Argument) (*Type, error) {
+	name := string(typeName)
+	if strings.HasSuffix(name, "]") {
+		i := strings.LastIndex(name, "[")
+		if i < 0 {
+			return nil, fmt.Errorf("ABI type %s is not supported", name)
+		}
+		elem, err := ParseType(TypeName(name[:i]), components)
+		if err != nil {
+			return nil, err
+		}
+		length := -1
+		if i < len(name)-2 {
+			length, err = strconv.Atoi(name[i+1 : len(name)-1])
+			if err != nil || length <= 0 {
+				return nil, fmt.Errorf("ABI type %s is not supported", name)
+			}
+		}
+		return &Type{
+			Name:   elem.Name + TypeName(name[i:]),
+			Kind:   ArrayKind,
+			Length: length,
+			Elem:   elem,
+		}, nil
+	}
+	if name == "tuple" {
+		typ := &Type{Kind: TupleKind}
+		typeNames := make([]string, len(components))
+		for i, component := range components {
+			componentType, err := ParseType(component.TypeName, component.Components)
+			if err != nil {
+				return nil, err
+			}
+			typ.Components = append(typ.Components, componentType)
+			typ.FieldNames = append(typ.FieldNames, component.Name)
+			typeNames[i] = string(componentType.Name)
+		}
+		typ.Name = TypeName("(" + strings.Join(typeNames, ",") + ")")
+		return typ, nil
+	}
+	typeName = canonicalTypeName(typeName)
+	if typeName == StringTypeName || typeName == "bytes" {
+		return &Type{Name: typeName, Kind: BytesKind}, nil
+	}
+	// Check the type is one decodeWord supports
+	if _, err := decodeWord(typeName, Zero256); err != nil {
+		return nil, err
+	}
+	return &Type{Name: typeName, Kind: ElementaryKind}, nil
+}
+
+// Whether the encoding of the type has no fixed size, so it is placed after
+// the values of static types in a sequence
+func (typ *Type) Dynamic() bool {
+	switch typ.Kind {
+	case BytesKind:
+		return true
+	case ArrayKind:
+		return typ.Length < 0 || typ.Elem.Dynamic()
+	case TupleKind:
+		for _, component := range typ.Components {
+			if component.Dynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// The size of the type's encoding in the head of a sequence holding it
+func (typ *Type) HeadSize() int {
+	if typ.Dynamic() {
+		return 32
+	}
+	switch typ.Kind {
+	case ArrayKind:
+		return typ.Length * typ.Elem.HeadSize()
+	case TupleKind:
+		size := 0
+		for _, component := range typ.Components {
+			size += component.HeadSize()
+		}
+		return size
+	}
+	return 32
+}
+
+// Encodes the values of a tuple or the elements of an array, with values of
+// static types in place and those of dynamic types after them at the offset
+// left in their place
+func encodeSequence(types []*Type, values []interface{}) ([]byte, error) {
+	headSize := 0
+	for _, typ := range types {
+		headSize += typ.HeadSize()
+	}
+	head := new(bytes.Buffer)
+	tail := new(bytes.Buffer)
+	for i, typ := range types {
+		encoded, err := encodeValue(typ, values[i])
+		if err != nil {
+			return nil, err
+		}
+		if typ.Dynamic() {
+			head.Write(Int64ToWord256(int64(headSize + tail.Len())).Bytes())
+			tail.Write(encoded)
+		} else {
+			head.Write(encoded)
+		}
+	}
+	return append(head.Bytes(), tail.Bytes()...), nil
+}
+
+func encodeValue(typ *Type, value interface{}) ([]byte, error) {
+	switch typ.Kind {
+	case BytesKind:
+		s, err := valueString(typ, value)
+		if err != nil {
+			return nil, err
+		}
+		bs := []byte(s)
+		if typ.Name != StringTypeName {
+			if bs, err = decodeHex(s); err != nil {
+				return nil, fmt.Errorf("Could not encode %s as bytes: %v", s, err)
+			}
+		}
+		encoded := Int64ToWord256(int64(len(bs))).Bytes()
+		encoded = append(encoded, bs...)
+		if len(bs)%32 != 0 {
+			encoded = append(encoded, make([]byte, 32-len(bs)%32)...)
+		}
+		return encoded, nil
+	case ArrayKind:
+		elements, err := valueSlice(typ, value)
+		if err != nil {
+			return nil, err
+		}
+		if typ.Length >= 0 && len(elements) != typ.Length {
+			return nil, fmt.Errorf("%s must have %v elements but has %v",
+				typ.Name, typ.Length, len(elements))
+		}
+		types := make([]*Type, len(elements))
+		for i := range types {
+			types[i] = typ.Elem
+		}
+		encoded, err := encodeSequence(types, elements)
+		if err != nil {
+			return nil, err
+		}
+		if typ.Length < 0 {
+			encoded = append(Int64ToWord256(int64(len(elements))).Bytes(), encoded...)
+		}
+		return encoded, nil
+	case TupleKind:
+		fields, err := valueFields(typ, value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSequence(typ.Components, fields)
+	}
+	s, err := valueString(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	word, err := encodeWord(typ.Name, s)
+	if err != nil {
+		return nil, err
+	}
+	return word.Bytes(), nil
+}
+
+// Encodes a value of a static elementary type as a word
+func encodeWord(typeName TypeName, s string) (Word256, error) {
+	name := string(typeName)
+	switch {
+	case typeName == AddressTypeName:
+		bs, err := decodeHex(s)
+		if err != nil || len(bs) != AddressLength {
+			return Zero256, fmt.Errorf("%s is not an address", s)
+		}
+		return LeftPadWord256(bs), nil
+	case typeName == BoolTypeName:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return Zero256, fmt.Errorf("%s is not a bool", s)
+		}
+		if b {
+			return Int64ToWord256(1), nil
+		}
+		return Zero256, nil
+	case strings.HasPrefix(name, "uint"), strings.HasPrefix(name, "int"):
+		signed := strings.HasPrefix(name, "int")
+		prefix := "uint"
+		if signed {
+			prefix = "int"
+		}
+		size, err := typeSize(name, prefix, 8, 256)
+		if err != nil {
+			return Zero256, err
+		}
+		i, ok := parseInt(s)
+		if !ok {
+			return Zero256, fmt.Errorf("%s is not an integer", s)
+		}
+		min, max := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(size))
+		if signed {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+			return Zero256, fmt.Errorf("%s is out of range for %s", s, typeName)
+		}
+		if i.Sign() < 0 {
+			i.Add(i, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return LeftPadWord256(i.Bytes()), nil
+	case strings.HasPrefix(name, "bytes"):
+		size, err := typeSize(name, "bytes", 1, 32)
+		if err != nil {
+			return Zero256, err
+		}
+		bs, err := decodeHex(s)
+		if err != nil || len(bs) > size {
+			return Zero256, fmt.Errorf("%s is not a %s", s, typeName)
+		}
+		return RightPadWord256(bs), nil
+	}
+	return Zero256, fmt.Errorf("ABI type %s is not supported", typeName)
+}
+
+// Parses an integer in decimal or 0x prefixed hex
+func parseInt(s string) (*big.Int, bool) {
+	base := 10
+	digits := s
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		base = 16
+		digits = digits[2:]
+	}
+	i, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, false
+	}
+	if negative {
+		i.Neg(i)
+	}
+	return i, true
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	return hex.DecodeString(s)
+}
+
+// Gets a value of an elementary type, string or bytes as a string
+func valueString(typ *Type, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case *big.Int:
+		return v.String(), nil
+	case []byte:
+		return hex.EncodeToString(v), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bs := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bs), rv)
+			return hex.EncodeToString(bs), nil
+		}
+	}
+	return "", fmt.Errorf("Cannot encode %v as %s", value, typ.Name)
+}
+
+// Gets the elements of an array from a slice or a JSON array
+func valueSlice(typ *Type, value interface{}) ([]interface{}, error) {
+	value, err := decodeJSONValue(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	if elements, ok := value.([]interface{}); ok {
+		return elements, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("Cannot encode %v as %s", value, typ.Name)
+	}
+	elements := make([]interface{}, rv.Len())
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+	return elements, nil
+}
+
+// Gets the fields of a struct in order from a slice or a map by field name,
+// or JSON for either
+func valueFields(typ *Type, value interface{}) ([]interface{}, error) {
+	value, err := decodeJSONValue(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) != len(typ.Components) {
+			return nil, fmt.Errorf("%s must have %v fields but has %v",
+				typ.Name, len(typ.Components), len(v))
+		}
+		return v, nil
+	case map[string]interface{}:
+		fields := make([]interface{}, len(typ.Components))
+		for i, fieldName := range typ.FieldNames {
+			field, ok := v[fieldName]
+			if !ok {
+				return nil, fmt.Errorf("%s is missing field %s", typ.Name, fieldName)
+			}
+			fields[i] = field
+		}
+		if len(v) != len(fields) {
+			return nil, fmt.Errorf("%v has fields that %s does not", value, typ.Name)
+		}
+		return fields, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		fields := exportedFields(rv)
+		if len(fields) != len(typ.Components) {
+			return nil, fmt.Errorf("%s must have %v fields but %s has %v",
+				typ.Name, len(typ.Components), rv.Type(), len(fields))
+		}
+		values := make([]interface{}, len(fields))
+		for i, field := range fields {
+			values[i] = field.Interface()
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("Cannot encode %v as %s", value, typ.Name)
+}
+
+func exportedFields(rv reflect.Value) []reflect.Value {
+	var fields []reflect.Value
+	for i := 0; i < rv.NumField(); i++ {
+		if rv.Type().Field(i).PkgPath == "" {
+			fields = append(fields, rv.Field(i))
+		}
+	}
+	return fields
+}
+
+// Decodes a value given as a string of JSON, keeping numbers as they are
+// written
+func decodeJSONValue(typ *Type, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("Could not read %s as JSON for %s: %v", s, typ.Name, err)
+	}
+	return decoded, nil
+}
+
+// Decodes the sequence of values of the types that starts at offset in data
+func decodeSequence(types []*Type, data []byte, offset int) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	head := offset
+	for i, typ := range types {
+		start := head
+		if typ.Dynamic() {
+			word, err := readWord(data, head)
+			if err != nil {
+				return nil, err
+			}
+			relative, err := wordInt(word, len(data)-offset)
+			if err != nil {
+				return nil, err
+			}
+			start = offset + relative
+		}
+		value, err := decodeValue(typ, data, start)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		head += typ.HeadSize()
+	}
+	return values, nil
+}
+
+// Decodes the value of the type whose encoding starts at offset in data
+func decodeValue(typ *Type, data []byte, offset int) (interface{}, error) {
+	switch typ.Kind {
+	case BytesKind:
+		return decodeBytes(typ.Name, data, offset)
+	case ArrayKind:
+		length := typ.Length
+		if length < 0 {
+			word, err := readWord(data, offset)
+			if err != nil {
+				return nil, err
+			}
+			// Each element takes at least a word
+			length, err = wordInt(word, (len(data)-offset)/32)
+			if err != nil {
+				return nil, err
+			}
+			offset += 32
+		}
+		types := make([]*Type, length)
+		for i := range types {
+			types[i] = typ.Elem
+		}
+		return decodeSequence(types, data, offset)
+	case TupleKind:
+		return decodeSequence(typ.Components, data, offset)
+	}
+	word, err := readWord(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	return decodeWord(typ.Name, word)
+}
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+// Assigns values decoded by Unpack or Event.Decode to the Go values targets
+// point to, which may be strings, bools, integers, *big.Int, []byte and byte
+// arrays for elementary types or slices, arrays and structs of them
+func Assign(values []interface{}, targets ...interface{}) error {
+	if len(values) != len(targets) {
+		return fmt.Errorf("Cannot assign %v values to %v targets", len(values),
+			len(targets))
+	}
+	for i, target := range targets {
+		rv := reflect.ValueOf(target)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("Cannot assign to %T, which is not a pointer", target)
+		}
+		if err := assignValue(values[i], rv.Elem()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignValue(value interface{}, target reflect.Value) error {
+	if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+		target.Set(reflect.ValueOf(value))
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		return assignString(v, target)
+	case []interface{}:
+		switch target.Kind() {
+		case reflect.Slice:
+			target.Set(reflect.MakeSlice(target.Type(), len(v), len(v)))
+		case reflect.Array:
+			if target.Len() != len(v) {
+				return fmt.Errorf("Cannot assign %v elements to %s", len(v), target.Type())
+			}
+		case reflect.Struct:
+			fields := exportedFields(target)
+			if len(fields) != len(v) {
+				return fmt.Errorf("Cannot assign %v fields to %s", len(v), target.Type())
+			}
+			for i, field := range fields {
+				if err := assignValue(v[i], field); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("Cannot assign %v to %s", value, target.Type())
+		}
+		for i, element := range v {
+			if err := assignValue(element, target.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("Cannot assign %v to %s", value, target.Type())
+}
+
+func assignString(s string, target reflect.Value) error {
+	if target.Type() == bigIntType {
+		i, ok := parseInt(s)
+		if !ok {
+			return fmt.Errorf("Cannot assign %s to %s", s, target.Type())
+		}
+		target.Set(reflect.ValueOf(i))
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err == nil {
+			target.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err == nil && !target.OverflowInt(i) {
+			target.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, 64)
+		if err == nil && !target.OverflowUint(i) {
+			target.SetUint(i)
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if target.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		bs, err := decodeHex(s)
+		if err != nil {
+			break
+		}
+		if target.Kind() == reflect.Slice {
+			target.SetBytes(bs)
+			return nil
+		}
+		if len(bs) <= target.Len() {
+			reflect.Copy(target, reflect.ValueOf(bs))
+			return nil
+		}
+	}
+	return fmt.Errorf("Cannot assign %s to %s", s, target.Type())
+}
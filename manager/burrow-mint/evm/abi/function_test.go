@@ -0,0 +1,234 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package abi
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const examplesABI = `[
+	{"type": "function", "name": "baz", "constant": true, "inputs": [
+		{"name": "x", "type": "uint32"}, {"name": "y", "type": "bool"}
+	], "outputs": [{"name": "r", "type": "bool"}]},
+	{"type": "function", "name": "sam", "inputs": [
+		{"name": "name", "type": "bytes"}, {"name": "z", "type": "bool"},
+		{"name": "data", "type": "uint[]"}
+	]},
+	{"type": "function", "name": "f", "stateMutability": "nonpayable", "inputs": [
+		{"name": "a", "type": "uint"}, {"name": "b", "type": "uint32[]"},
+		{"name": "c", "type": "bytes10"}, {"name": "d", "type": "bytes"}
+	]},
+	{"type": "function", "name": "f", "stateMutability": "view", "inputs": [
+		{"name": "people", "type": "tuple[]", "components": [
+			{"name": "name", "type": "string"}, {"name": "age", "type": "uint8"}
+		]}
+	], "outputs": [
+		{"name": "oldest", "type": "tuple", "components": [
+			{"name": "name", "type": "string"}, {"name": "age", "type": "uint8"}
+		]},
+		{"name": "ages", "type": "int16[2][]"}
+	]}
+]`
+
+func words(hexWords ...string) []byte {
+	bs, err := hex.DecodeString(strings.Join(hexWords, ""))
+	if err != nil {
+		panic(err)
+	}
+	return bs
+}
+
+func TestFunctionByName(t *testing.T) {
+	abi, err := ReadABI([]byte(examplesABI))
+	require.NoError(t, err)
+	require.Len(t, abi.Functions, 4)
+	assert.Empty(t, abi.Events)
+
+	baz, err := abi.FunctionByName("baz", 0)
+	require.NoError(t, err)
+	assert.True(t, baz.IsConstant())
+	selector, err := baz.Selector()
+	require.NoError(t, err)
+	assert.Equal(t, "CDCD77C0", strings.ToUpper(hex.EncodeToString(selector[:])))
+
+	f, err := abi.FunctionByName("f", 4)
+	require.NoError(t, err)
+	assert.False(t, f.IsConstant())
+	signature, err := f.Signature()
+	require.NoError(t, err)
+	assert.Equal(t, "f(uint256,uint32[],bytes10,bytes)", signature)
+
+	f, err = abi.FunctionByName("f((string,uint8)[])", 0)
+	require.NoError(t, err)
+	assert.True(t, f.IsConstant())
+
+	_, err = abi.FunctionByName("f", 2)
+	assert.Error(t, err)
+	_, err = abi.FunctionByName("g", 0)
+	assert.Error(t, err)
+}
+
+func TestPack(t *testing.T) {
+	abi, err := ReadABI([]byte(examplesABI))
+	require.NoError(t, err)
+	baz := abi.Functions[0]
+	data, err := baz.Pack("69", "true")
+	require.NoError(t, err)
+	assert.Equal(t, words("cdcd77c0",
+		"0000000000000000000000000000000000000000000000000000000000000045",
+		"0000000000000000000000000000000000000000000000000000000000000001"), data)
+
+	sam := abi.Functions[1]
+	data, err = sam.Pack(hex.EncodeToString([]byte("dave")), "true", "[1, 2, 3]")
+	require.NoError(t, err)
+	assert.Equal(t, words("a5643bf2",
+		"0000000000000000000000000000000000000000000000000000000000000060",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"00000000000000000000000000000000000000000000000000000000000000a0",
+		"0000000000000000000000000000000000000000000000000000000000000004",
+		"6461766500000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000003"), data)
+
+	f := abi.Functions[2]
+	data, err = f.Pack("0x123", `["0x456", "0x789"]`,
+		hex.EncodeToString([]byte("1234567890")),
+		hex.EncodeToString([]byte("Hello, world!")))
+	require.NoError(t, err)
+	assert.Equal(t, words("8be65246",
+		"0000000000000000000000000000000000000000000000000000000000000123",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"3132333435363738393000000000000000000000000000000000000000000000",
+		"00000000000000000000000000000000000000000000000000000000000000e0",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000456",
+		"0000000000000000000000000000000000000000000000000000000000000789",
+		"000000000000000000000000000000000000000000000000000000000000000d",
+		"48656c6c6f2c20776f726c642100000000000000000000000000000000000000"), data)
+
+	_, err = baz.Pack("69")
+	assert.Error(t, err)
+	_, err = baz.Pack("4294967296", "true")
+	assert.Error(t, err)
+	_, err = f.Pack("-1", "[]", "", "")
+	assert.Error(t, err)
+	_, err = f.Pack("1", "[1]", "0102030405060708090A0B", "")
+	assert.Error(t, err)
+}
+
+func TestUnpack(t *testing.T) {
+	abi, err := ReadABI([]byte(examplesABI))
+	require.NoError(t, err)
+	baz := abi.Functions[0]
+	values, err := baz.Unpack(words(
+		"0000000000000000000000000000000000000000000000000000000000000001"))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"true"}, values)
+
+	// A struct with a dynamic field and an array of static arrays
+	f := abi.Functions[3]
+	data := words(
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"00000000000000000000000000000000000000000000000000000000000000c0",
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"0000000000000000000000000000000000000000000000000000000000000063",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"626f620000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000007",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff9")
+	values, err = f.Unpack(data)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"bob", "99"},
+		[]interface{}{[]interface{}{"7", "-7"}},
+	}, values)
+	assert.Equal(t, `["bob","99"]`, FormatValue(values[0]))
+
+	_, err = f.Unpack(data[:len(data)-32])
+	assert.Error(t, err)
+
+	// Structs can be given as objects
+	data, err = f.Pack(`[{"name": "bob", "age": 99}, ["alice", "0x20"]]`)
+	require.NoError(t, err)
+	_, err = f.Pack(`[{"name": "bob"}]`)
+	assert.Error(t, err)
+	modified := *f
+	modified.Outputs = f.Inputs
+	values, err = modified.Unpack(data[4:])
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{
+		[]interface{}{"bob", "99"}, []interface{}{"alice", "32"},
+	}}, values)
+}
+
+type person struct {
+	Name string
+	Age  uint8
+}
+
+func TestGoValues(t *testing.T) {
+	abi, err := ReadABI([]byte(examplesABI))
+	require.NoError(t, err)
+	f := abi.Functions[2]
+	expected, err := f.Pack("0x123", `["0x456", "0x789"]`,
+		hex.EncodeToString([]byte("1234567890")),
+		hex.EncodeToString([]byte("Hello, world!")))
+	require.NoError(t, err)
+	var c [10]byte
+	copy(c[:], "1234567890")
+	data, err := f.Pack(big.NewInt(0x123), []uint32{0x456, 0x789}, c,
+		[]byte("Hello, world!"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	f = abi.Functions[3]
+	expected, err = f.Pack(`[["bob", 99], ["alice", 32]]`)
+	require.NoError(t, err)
+	data, err = f.Pack([]person{{"bob", 99}, {"alice", 32}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	var oldest person
+	var ages [][2]int16
+	err = Assign([]interface{}{
+		[]interface{}{"bob", "99"},
+		[]interface{}{[]interface{}{"7", "-7"}},
+	}, &oldest, &ages)
+	require.NoError(t, err)
+	assert.Equal(t, person{"bob", 99}, oldest)
+	assert.Equal(t, [][2]int16{{7, -7}}, ages)
+
+	var value *big.Int
+	var id [4]byte
+	var ok bool
+	require.NoError(t, Assign([]interface{}{"1000", "01020304", "true"},
+		&value, &id, &ok))
+	assert.Equal(t, big.NewInt(1000), value)
+	assert.Equal(t, [4]byte{1, 2, 3, 4}, id)
+	assert.True(t, ok)
+
+	var small uint8
+	assert.Error(t, Assign([]interface{}{"256"}, &small))
+	assert.Error(t, Assign([]interface{}{"1"}, small))
+}
@@ -38,6 +38,7 @@ const (
 	IntTypeName     TypeName = "int"
 	Uint64TypeName  TypeName = "uint64"
 	Bytes32TypeName TypeName = "bytes32"
+	BytesTypeName   TypeName = "bytes"
 	StringTypeName  TypeName = "string"
 	BoolTypeName    TypeName = "bool"
 )
@@ -0,0 +1,344 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A pure Go implementation of the BLS12-381 curve with the BLS signatures of
+// the proof of possession scheme of the IETF BLS signature draft, as used by
+// Ethereum 2, with public keys in G1 and signatures in G2, so that the EVM
+// can verify them without a C dependency. It favours simplicity over speed
+// and is not constant time, so must not be used to sign with keys that need
+// protecting from timing attacks.
+package bls12381
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+const (
+	// The lengths of the compressed encodings of points of G1 and G2 in the
+	// format of ZCash, x with flags in the top three bits
+	G1CompressedLength = 48
+	G2CompressedLength = 96
+	// The lengths of the uncompressed encodings, x || y
+	G1Length = 2 * G1CompressedLength
+	G2Length = 2 * G2CompressedLength
+
+	compressedFlag = 0x80
+	infinityFlag   = 0x40
+	signFlag       = 0x20
+)
+
+var (
+	ErrInvalidPoint  = errors.New("bls12-381 point is not on the curve")
+	ErrNotInSubgroup = errors.New("bls12-381 point is not in the subgroup of order Order")
+)
+
+var (
+	// The parameter of the curve, from which P and Order are derived
+	x = new(big.Int).Neg(pairing.FromHex("D201000000010000"))
+	// The field prime, (x - 1)²(x⁴ - x² + 1) / 3 + x
+	P = pairing.FromHex("1A0111EA397FE69A4B1BA7B6434BACD764774B84F38512BF6730D2A0F6B0F624" +
+		"1EABFFFEB153FFFFB9FEFFFFFFFFAAAB")
+	// The order of G1 and G2, x⁴ - x² + 1
+	Order = pairing.FromHex("73EDA753299D7D483339D80809A1D80553BDA402FFFE5BFEFFFFFFFF00000001")
+
+	halfP = new(big.Int).Rsh(P, 1)
+
+	// ξ = 1 + i, where w⁶ = ξ in GF(P¹²)
+	field = pairing.NewField(P, 1)
+	// y² = x³ + 4 and its twist y² = x³ + 4ξ
+	curve = pairing.NewCurve(field, big.NewInt(4), field.NewGFp2(4, 0).Mul(field.Xi()), Order)
+
+	// |x|, the loop count of the optimal ate pairing
+	ateLoopCount = new(big.Int).Neg(x)
+
+	// The Frobenius endomorphism maps w to w^P = ξ^((P - 1) / 6)·w, so ψ
+	// maps the twist coordinates x, y to conj(x)/ξ^((P - 1) / 3),
+	// conj(y)/ξ^((P - 1) / 2)
+	psiX = field.FrobeniusGamma().Mul(field.FrobeniusGamma()).Invert()
+	psiY = psiX.Mul(field.FrobeniusGamma().Invert())
+
+	g1Generator = &pairing.CurvePoint{
+		X: pairing.FromHex("17F1D3A73197D7942695638C4FA9AC0FC3688C4F9774B905A14E3A3F171BAC58" +
+			"6C55E83FF97A1AEFFB3AF00ADB22C6BB"),
+		Y: pairing.FromHex("08B3F481E3AAA0F1A09E30ED741D8AE4FCF5E095D5D00AF600DB18CB2C04B3ED" +
+			"D03CC744A2888AE40CAA232946C5E7E1"),
+	}
+	g2Generator = &pairing.TwistPoint{
+		X: field.GFp2(
+			pairing.FromHex("024AA2B2F08F0A91260805272DC51051C6E47AD4FA403B02B4510B647AE3D177"+
+				"0BAC0326A805BBEFD48056C8C121BDB8"),
+			pairing.FromHex("13E02B6052719F607DACD3A088274F65596BD0D09920B61AB5DA61BBDC7F5049"+
+				"334CF11213945D57E5AC7D055D042B7E"),
+		),
+		Y: field.GFp2(
+			pairing.FromHex("0CE5D527727D6E118CC9CDC6DA2E351AADFD9BAA8CBDD3A76D429A695160D12C"+
+				"923AC9CC3BACA289E193548608B82801"),
+			pairing.FromHex("0606C4A02EA734CC32ACD2B02BC28B99CB3E287E85A763AF267492AB572E99AB"+
+				"3F370D275CEC1DA1AAA9075FF05F79BE"),
+		),
+	}
+)
+
+// A point of G1, the points of order Order of y² = x³ + 4 over GF(P). The
+// zero value is the point at infinity.
+type G1 struct {
+	p *pairing.CurvePoint
+}
+
+// Sets e to the point of the compressed or uncompressed encoding data,
+// returning ErrInvalidPoint if it is not on the curve and ErrNotInSubgroup if
+// it is not of order Order
+func (e *G1) Unmarshal(data []byte) error {
+	compressed, infinity, largest, err := flags(data, G1CompressedLength)
+	if err != nil {
+		return err
+	}
+	if infinity {
+		e.p = nil
+		return nil
+	}
+	x, err := coordinate(data[:G1CompressedLength], true)
+	if err != nil {
+		return err
+	}
+	var c *pairing.CurvePoint
+	if compressed {
+		rhs := new(big.Int).Mul(x, x)
+		rhs.Mul(rhs, x)
+		y := field.Sqrt(rhs.Add(rhs, curve.B))
+		if y == nil {
+			return ErrInvalidPoint
+		}
+		if (y.Cmp(halfP) > 0) != largest {
+			y.Sub(P, y)
+		}
+		c = &pairing.CurvePoint{X: x, Y: y}
+	} else {
+		y, err := coordinate(data[G1CompressedLength:], false)
+		if err != nil {
+			return err
+		}
+		c = &pairing.CurvePoint{X: x, Y: y}
+		if !curve.OnCurve(c) {
+			return ErrInvalidPoint
+		}
+	}
+	// The curve has points outside the subgroup
+	if curve.MultiplyCurvePoint(c, Order) != nil {
+		return ErrNotInSubgroup
+	}
+	e.p = c
+	return nil
+}
+
+// The compressed encoding of e
+func (e *G1) Marshal() []byte {
+	data := make([]byte, G1CompressedLength)
+	if e.p == nil {
+		data[0] = compressedFlag | infinityFlag
+		return data
+	}
+	putCoordinate(data, e.p.X)
+	data[0] |= compressedFlag
+	if e.p.Y.Cmp(halfP) > 0 {
+		data[0] |= signFlag
+	}
+	return data
+}
+
+// Sets e to a + b and returns e
+func (e *G1) Add(a, b *G1) *G1 {
+	e.p = curve.AddCurvePoints(a.p, b.p)
+	return e
+}
+
+// Sets e to k·a and returns e
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	e.p = curve.MultiplyCurvePoint(a.p, k)
+	return e
+}
+
+// Sets e to -a and returns e
+func (e *G1) Neg(a *G1) *G1 {
+	e.p = curve.NegCurvePoint(a.p)
+	return e
+}
+
+func (e *G1) IsInfinity() bool {
+	return e.p == nil
+}
+
+// A point of G2, the points of order Order of the twist y² = x³ + 4ξ over
+// GF(P²). The zero value is the point at infinity.
+type G2 struct {
+	t *pairing.TwistPoint
+}
+
+// Sets e to the point of the compressed or uncompressed encoding data, of
+// x as b || a for x = a + bi, returning ErrInvalidPoint if it is not on the
+// twist and ErrNotInSubgroup if it is not of order Order
+func (e *G2) Unmarshal(data []byte) error {
+	compressed, infinity, largest, err := flags(data, G2CompressedLength)
+	if err != nil {
+		return err
+	}
+	if infinity {
+		e.t = nil
+		return nil
+	}
+	x, err := gfP2Coordinate(data[:G2CompressedLength], true)
+	if err != nil {
+		return err
+	}
+	var t *pairing.TwistPoint
+	if compressed {
+		y := x.Mul(x).Mul(x).Add(curve.TwistB).Sqrt()
+		if y == nil {
+			return ErrInvalidPoint
+		}
+		if lexicographicallyLargest(y) != largest {
+			y = y.Neg()
+		}
+		t = &pairing.TwistPoint{X: x, Y: y}
+	} else {
+		y, err := gfP2Coordinate(data[G2CompressedLength:], false)
+		if err != nil {
+			return err
+		}
+		t = &pairing.TwistPoint{X: x, Y: y}
+		if !curve.OnTwist(t) {
+			return ErrInvalidPoint
+		}
+	}
+	if pairing.MultiplyTwistPoint(t, Order) != nil {
+		return ErrNotInSubgroup
+	}
+	e.t = t
+	return nil
+}
+
+// The compressed encoding of e
+func (e *G2) Marshal() []byte {
+	data := make([]byte, G2CompressedLength)
+	if e.t == nil {
+		data[0] = compressedFlag | infinityFlag
+		return data
+	}
+	putCoordinate(data[:48], e.t.X.B)
+	putCoordinate(data[48:], e.t.X.A)
+	data[0] |= compressedFlag
+	if lexicographicallyLargest(e.t.Y) {
+		data[0] |= signFlag
+	}
+	return data
+}
+
+// Sets e to a + b and returns e
+func (e *G2) Add(a, b *G2) *G2 {
+	e.t = pairing.AddTwistPoints(a.t, b.t)
+	return e
+}
+
+// Sets e to k·a and returns e
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	e.t = pairing.MultiplyTwistPoint(a.t, k)
+	return e
+}
+
+// Whether the product of the pairings e(a[i], b[i]) is one, where a and b are
+// the same length. True when they are empty.
+func PairingCheck(a []*G1, b []*G2) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	cs := make([]*pairing.CurvePoint, len(a))
+	ts := make([]*pairing.TwistPoint, len(b))
+	for i := range a {
+		cs[i], ts[i] = a[i].p, b[i].t
+	}
+	return pairingCheck(cs, ts)
+}
+
+// Reads the flags of the encoding data of a point whose compressed encoding
+// is compressedLength bytes, checking its length
+func flags(data []byte, compressedLength int) (compressed, infinity, largest bool, err error) {
+	if len(data) == 0 {
+		return false, false, false, fmt.Errorf("bls12-381 point has no encoding")
+	}
+	compressed = data[0]&compressedFlag != 0
+	infinity = data[0]&infinityFlag != 0
+	largest = data[0]&signFlag != 0
+	length := 2 * compressedLength
+	if compressed {
+		length = compressedLength
+	}
+	if len(data) != length {
+		return false, false, false, fmt.Errorf("bls12-381 point is %v bytes but "+
+			"its flags give %v bytes", len(data), length)
+	}
+	if infinity {
+		if largest || data[0]&^(compressedFlag|infinityFlag) != 0 || !zeros(data[1:]) {
+			return false, false, false, fmt.Errorf("bls12-381 point at infinity " +
+				"has a non-zero encoding")
+		}
+	} else if largest && !compressed {
+		return false, false, false, fmt.Errorf("bls12-381 uncompressed point " +
+			"has the sign flag set")
+	}
+	return compressed, infinity, largest, nil
+}
+
+// Reads a coordinate, clearing the flags when it is first
+func coordinate(data []byte, first bool) (*big.Int, error) {
+	n := new(big.Int).SetBytes(data)
+	if first {
+		n.SetBytes(append([]byte{data[0] &^ (compressedFlag | infinityFlag | signFlag)},
+			data[1:]...))
+	}
+	if n.Cmp(P) >= 0 {
+		return nil, fmt.Errorf("bls12-381 coordinate %X is not below the field "+
+			"prime", data)
+	}
+	return n, nil
+}
+
+func gfP2Coordinate(data []byte, first bool) (*pairing.GFp2, error) {
+	b, err := coordinate(data[:48], first)
+	if err != nil {
+		return nil, err
+	}
+	a, err := coordinate(data[48:], false)
+	if err != nil {
+		return nil, err
+	}
+	return field.GFp2(a, b), nil
+}
+
+func putCoordinate(data []byte, n *big.Int) {
+	nBytes := n.Bytes()
+	copy(data[len(data)-len(nBytes):], nBytes)
+}
+
+func zeros(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,391 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	bs, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestParameters(t *testing.T) {
+	x2 := new(big.Int).Mul(x, x)
+	order := new(big.Int).Mul(x2, x2)
+	order.Sub(order, x2)
+	order.Add(order, big.NewInt(1))
+	assert.Equal(t, 0, Order.Cmp(order))
+	xMinusOne := new(big.Int).Sub(x, big.NewInt(1))
+	p := new(big.Int).Mul(xMinusOne, xMinusOne)
+	p.Mul(p, order)
+	p.Div(p, big.NewInt(3))
+	assert.Equal(t, 0, P.Cmp(p.Add(p, x)))
+
+	assert.True(t, curve.OnCurve(g1Generator))
+	assert.True(t, curve.OnTwist(g2Generator))
+	assert.Nil(t, curve.MultiplyCurvePoint(g1Generator, Order))
+	assert.Nil(t, pairing.MultiplyTwistPoint(g2Generator, Order))
+}
+
+func TestFrobenius(t *testing.T) {
+	f := field.NewGFp12()
+	for i := 0; i < 12; i++ {
+		f.AddGFp(big.NewInt(int64(i*i+7)), i)
+	}
+	assert.True(t, f.Frobenius().Equal(f.Exp(P)))
+	assert.True(t, f.Conjugate().Equal(f.Exp(new(big.Int).Exp(P, big.NewInt(6), nil))))
+	assert.True(t, f.Mul(f.Invert()).IsOne())
+	// ψ acts on G2 as multiplication by P
+	assert.Equal(t, pairing.MultiplyTwistPoint(g2Generator, P), psi(g2Generator))
+}
+
+func TestBilinearity(t *testing.T) {
+	a, b := big.NewInt(12345), big.NewInt(67890)
+	ab := new(big.Int).Mul(a, b)
+	e := pair(g1Generator, g2Generator)
+	assert.False(t, e.IsOne())
+	assert.True(t, e.Exp(Order).IsOne())
+
+	eab := pair(curve.MultiplyCurvePoint(g1Generator, a), pairing.MultiplyTwistPoint(g2Generator, b))
+	assert.True(t, eab.Equal(e.Exp(ab)))
+	assert.True(t, eab.Equal(pair(curve.MultiplyCurvePoint(g1Generator, ab), g2Generator)))
+	assert.True(t, eab.Equal(pair(g1Generator, pairing.MultiplyTwistPoint(g2Generator, ab))))
+}
+
+// The test vector of RFC 9380 for the empty message
+func TestHashToG2(t *testing.T) {
+	hash, err := HashToG2(nil, []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_"))
+	require.NoError(t, err)
+	assert.Equal(t, field.GFp2(
+		pairing.FromHex("0141EBFBDCA40EB85B87142E130AB689C673CF60F1A3E98D69335266F30D9B8D"+
+			"4AC44C1038E9DCDD5393FAF5C41FB78A"),
+		pairing.FromHex("05CB8437535E20ECFFAEF7752BADDF98034139C38452458BAEEFAB379BA13DFF"+
+			"5BF5DD71B72418717047F5B0F37DA03D"),
+	), hash.t.X)
+	assert.Equal(t, field.GFp2(
+		pairing.FromHex("0503921D7F6A12805E72940B963C0CF3471C7B2A524950CA195D11062EE75EC0"+
+			"76DAF2D4BC358C4B190C0C98064FDD92"),
+		pairing.FromHex("12424AC32561493F3FE3C260708A12B7C620E7BE00099A974E259DDC7D1F6395"+
+			"C3C811CDD19F1E8DBF3E9ECFDCBAB8D6"),
+	), hash.t.Y)
+}
+
+// A test vector of Ethereum 2
+func TestSignAndVerify(t *testing.T) {
+	seckey := decodeHex(t, "263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3")
+	message := make([]byte, 32)
+	signature, err := Sign(seckey, message)
+	require.NoError(t, err)
+	assert.Equal(t, "b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6"+
+		"076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24"+
+		"802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55",
+		hex.EncodeToString(signature))
+
+	pubkey, err := PubkeyFromSeckey(seckey)
+	require.NoError(t, err)
+	valid, err := Verify(pubkey, message, signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = Verify(pubkey, []byte("other message"), signature)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	proof, err := ProvePossession(seckey)
+	require.NoError(t, err)
+	valid, err = VerifyPossession(pubkey, proof)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	// A signature of the public key is not a proof of possession
+	signature, err = Sign(seckey, pubkey)
+	require.NoError(t, err)
+	valid, err = VerifyPossession(pubkey, signature)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAggregateVerify(t *testing.T) {
+	var pubkeys, messages, signatures, sameMessageSignatures [][]byte
+	message := []byte("message")
+	for i := byte(1); i <= 3; i++ {
+		seckey := bytes.Repeat([]byte{i}, SeckeyLength)
+		pubkey, err := PubkeyFromSeckey(seckey)
+		require.NoError(t, err)
+		signature, err := Sign(seckey, []byte{i})
+		require.NoError(t, err)
+		sameMessageSignature, err := Sign(seckey, message)
+		require.NoError(t, err)
+		pubkeys = append(pubkeys, pubkey)
+		messages = append(messages, []byte{i})
+		signatures = append(signatures, signature)
+		sameMessageSignatures = append(sameMessageSignatures, sameMessageSignature)
+	}
+
+	aggregate, err := AggregateSignatures(signatures)
+	require.NoError(t, err)
+	valid, err := AggregateVerify(pubkeys, messages, aggregate)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = AggregateVerify(pubkeys[:2], messages[:2], aggregate)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	_, err = AggregateVerify(pubkeys, messages[:2], aggregate)
+	assert.Error(t, err)
+
+	aggregate, err = AggregateSignatures(sameMessageSignatures)
+	require.NoError(t, err)
+	valid, err = FastAggregateVerify(pubkeys, message, aggregate)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = FastAggregateVerify(pubkeys, messages[0], aggregate)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	_, err = FastAggregateVerify(nil, message, aggregate)
+	assert.Error(t, err)
+}
+
+func TestMarshal(t *testing.T) {
+	g1 := &G1{g1Generator}
+	for k := int64(1); k <= 4; k++ {
+		point := new(G1).ScalarMult(g1, big.NewInt(k))
+		unmarshalled := new(G1)
+		require.NoError(t, unmarshalled.Unmarshal(point.Marshal()))
+		assert.Equal(t, point, unmarshalled)
+	}
+	assert.Equal(t, "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac58"+
+		"6c55e83ff97a1aeffb3af00adb22c6bb", hex.EncodeToString(g1.Marshal()))
+	infinity := new(G1)
+	require.NoError(t, infinity.Unmarshal(new(G1).Marshal()))
+	assert.True(t, infinity.IsInfinity())
+
+	g2 := &G2{g2Generator}
+	for k := int64(1); k <= 4; k++ {
+		point := new(G2).ScalarMult(g2, big.NewInt(k))
+		unmarshalled := new(G2)
+		require.NoError(t, unmarshalled.Unmarshal(point.Marshal()))
+		assert.Equal(t, point, unmarshalled)
+	}
+	// The uncompressed encoding
+	uncompressed := make([]byte, G2Length)
+	putCoordinate(uncompressed[:48], g2Generator.X.B)
+	putCoordinate(uncompressed[48:96], g2Generator.X.A)
+	putCoordinate(uncompressed[96:144], g2Generator.Y.B)
+	putCoordinate(uncompressed[144:], g2Generator.Y.A)
+	unmarshalled := new(G2)
+	require.NoError(t, unmarshalled.Unmarshal(uncompressed))
+	assert.Equal(t, g2, unmarshalled)
+	uncompressed[191]++
+	assert.Equal(t, ErrInvalidPoint, unmarshalled.Unmarshal(uncompressed))
+
+	assert.Error(t, new(G1).Unmarshal(g1.Marshal()[:47]))
+	assert.Error(t, new(G1).Unmarshal(nil))
+	notInfinity := new(G1).Marshal()
+	notInfinity[47] = 1
+	assert.Error(t, new(G1).Unmarshal(notInfinity))
+}
+
+func TestSubgroup(t *testing.T) {
+	// Points of the curve and the twist whose orders are not Order, found by
+	// trying x coordinates until x³ + b is a square
+	for i := int64(1); ; i++ {
+		rhs := new(big.Int).Exp(big.NewInt(i), big.NewInt(3), P)
+		y := field.Sqrt(rhs.Add(rhs, curve.B))
+		if y == nil {
+			continue
+		}
+		c := &pairing.CurvePoint{X: big.NewInt(i), Y: y}
+		if curve.MultiplyCurvePoint(c, Order) == nil {
+			continue
+		}
+		assert.Equal(t, ErrNotInSubgroup, new(G1).Unmarshal((&G1{c}).Marshal()))
+		break
+	}
+	for i := int64(1); ; i++ {
+		tx := field.NewGFp2(i, 0)
+		ty := tx.Mul(tx).Mul(tx).Add(curve.TwistB).Sqrt()
+		if ty == nil {
+			continue
+		}
+		t2 := &pairing.TwistPoint{X: tx, Y: ty}
+		require.True(t, curve.OnTwist(t2))
+		if pairing.MultiplyTwistPoint(t2, Order) == nil {
+			continue
+		}
+		assert.Equal(t, ErrNotInSubgroup, new(G2).Unmarshal((&G2{t2}).Marshal()))
+		break
+	}
+}
+
+// The generators of G1 and G2, their negations and their doubles in the
+// encoding of the precompiles of EIP-2537, of each coordinate in 64 bytes and
+// of a + bi as a || b, with the point at infinity as zeros
+const (
+	eip2537Padding = "00000000000000000000000000000000"
+	eip2537G1      = eip2537Padding +
+		"17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+		eip2537Padding +
+		"08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"
+	eip2537MinusG1 = eip2537Padding +
+		"17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+		eip2537Padding +
+		"114d1d6855d545a8aa7d76c8cf2e21f267816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca"
+	eip2537DoubleG1 = eip2537Padding +
+		"0572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e" +
+		eip2537Padding +
+		"166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28"
+	eip2537G2 = eip2537Padding +
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+		eip2537Padding +
+		"13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+		eip2537Padding +
+		"0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+		eip2537Padding +
+		"0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be"
+	eip2537MinusG2 = eip2537Padding +
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+		eip2537Padding +
+		"13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+		eip2537Padding +
+		"0d1b3cc2c7027888be51d9ef691d77bcb679afda66c73f17f9ee3837a55024f78c71363275a75d75d86bab79f74782aa" +
+		eip2537Padding +
+		"13fa4d4a0ad8b1ce186ed5061789213d993923066dddaf1040bc3ff59f825c78df74f2d75467e25e0f55f8a00fa030ed"
+	eip2537DoubleG2 = eip2537Padding +
+		"1638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053" +
+		eip2537Padding +
+		"0a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+		eip2537Padding +
+		"0468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899" +
+		eip2537Padding +
+		"0f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3"
+)
+
+func eip2537Coordinates(t *testing.T, s string, n int) []*big.Int {
+	data := decodeHex(t, s)
+	require.Len(t, data, 64*n)
+	coordinates := make([]*big.Int, n)
+	for i := range coordinates {
+		require.True(t, zeros(data[64*i:64*i+16]), "EIP-2537 coordinate is not padded")
+		coordinates[i] = new(big.Int).SetBytes(data[64*i+16 : 64*(i+1)])
+		require.Equal(t, -1, coordinates[i].Cmp(P))
+	}
+	return coordinates
+}
+
+func eip2537ToG1(t *testing.T, s string) *G1 {
+	c := eip2537Coordinates(t, s, 2)
+	if c[0].Sign() == 0 && c[1].Sign() == 0 {
+		return new(G1)
+	}
+	p := &pairing.CurvePoint{X: c[0], Y: c[1]}
+	require.True(t, curve.OnCurve(p))
+	return &G1{p}
+}
+
+func eip2537ToG2(t *testing.T, s string) *G2 {
+	c := eip2537Coordinates(t, s, 4)
+	if c[0].Sign() == 0 && c[1].Sign() == 0 && c[2].Sign() == 0 && c[3].Sign() == 0 {
+		return new(G2)
+	}
+	p := &pairing.TwistPoint{X: field.GFp2(c[0], c[1]), Y: field.GFp2(c[2], c[3])}
+	require.True(t, curve.OnTwist(p))
+	return &G2{p}
+}
+
+func eip2537Scalar(t *testing.T, s string) *big.Int {
+	data := decodeHex(t, s)
+	require.Len(t, data, 32)
+	return new(big.Int).SetBytes(data)
+}
+
+func TestEIP2537Vectors(t *testing.T) {
+	infinityG1 := strings.Repeat("00", 128)
+	infinityG2 := strings.Repeat("00", 256)
+	two := strings.Repeat("00", 31) + "02"
+	order := hex.EncodeToString(Order.Bytes())
+
+	g1Add := []struct{ a, b, sum string }{
+		{eip2537G1, eip2537G1, eip2537DoubleG1},
+		{eip2537G1, eip2537MinusG1, infinityG1},
+		{eip2537G1, infinityG1, eip2537G1},
+		{infinityG1, infinityG1, infinityG1},
+	}
+	for _, vector := range g1Add {
+		sum := new(G1).Add(eip2537ToG1(t, vector.a), eip2537ToG1(t, vector.b))
+		assert.Equal(t, eip2537ToG1(t, vector.sum), sum)
+	}
+	g1Mul := []struct{ a, k, product string }{
+		{eip2537G1, two, eip2537DoubleG1},
+		{eip2537G1, order, infinityG1},
+		{eip2537G1, strings.Repeat("00", 32), infinityG1},
+		{infinityG1, two, infinityG1},
+	}
+	for _, vector := range g1Mul {
+		product := new(G1).ScalarMult(eip2537ToG1(t, vector.a), eip2537Scalar(t, vector.k))
+		assert.Equal(t, eip2537ToG1(t, vector.product), product)
+	}
+
+	g2Add := []struct{ a, b, sum string }{
+		{eip2537G2, eip2537G2, eip2537DoubleG2},
+		{eip2537G2, eip2537MinusG2, infinityG2},
+		{eip2537G2, infinityG2, eip2537G2},
+	}
+	for _, vector := range g2Add {
+		sum := new(G2).Add(eip2537ToG2(t, vector.a), eip2537ToG2(t, vector.b))
+		assert.Equal(t, eip2537ToG2(t, vector.sum), sum)
+	}
+	g2Mul := []struct{ a, k, product string }{
+		{eip2537G2, two, eip2537DoubleG2},
+		{eip2537G2, order, infinityG2},
+	}
+	for _, vector := range g2Mul {
+		product := new(G2).ScalarMult(eip2537ToG2(t, vector.a), eip2537Scalar(t, vector.k))
+		assert.Equal(t, eip2537ToG2(t, vector.product), product)
+	}
+
+	// Pairs of a point of G1 and of G2 and whether the product of their
+	// pairings is one
+	pairingVectors := []struct {
+		pairs [][2]string
+		one   bool
+	}{
+		{[][2]string{{eip2537G1, eip2537G2}}, false},
+		{[][2]string{{eip2537G1, infinityG2}}, true},
+		{[][2]string{{infinityG1, eip2537G2}}, true},
+		{[][2]string{{eip2537G1, eip2537G2}, {eip2537MinusG1, eip2537G2}}, true},
+		{[][2]string{{eip2537G1, eip2537G2}, {eip2537G1, eip2537MinusG2}}, true},
+		{[][2]string{{eip2537DoubleG1, eip2537G2}, {eip2537G1, eip2537G2}}, false},
+		{[][2]string{{eip2537DoubleG1, eip2537G2}, {eip2537MinusG1, eip2537DoubleG2}}, true},
+		{[][2]string{{eip2537DoubleG1, eip2537MinusG2}, {eip2537G1, eip2537DoubleG2}}, true},
+	}
+	for _, vector := range pairingVectors {
+		var g1s []*G1
+		var g2s []*G2
+		for _, pair := range vector.pairs {
+			g1s = append(g1s, eip2537ToG1(t, pair[0]))
+			g2s = append(g2s, eip2537ToG2(t, pair[1]))
+		}
+		assert.Equal(t, vector.one, PairingCheck(g1s, g2s), "%v", vector.pairs)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// The twist y² = x³ + 4ξ over GF(P²) of y² = x³ + 4, whose point (x, y)
+// maps to the point (x/w², y/w³) of the curve over GF(P¹²)
+
+// The endomorphism ψ of the twist that maps it to the curve over GF(P¹²),
+// applies the Frobenius endomorphism there and maps back
+func psi(t *pairing.TwistPoint) *pairing.TwistPoint {
+	if t == nil {
+		return nil
+	}
+	return &pairing.TwistPoint{
+		X: t.X.Conjugate().Mul(psiX),
+		Y: t.Y.Conjugate().Mul(psiY),
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// The sign of e that picks between e and -e, as defined for hashing to the
+// curve
+func sgn0(e *pairing.GFp2) bool {
+	return e.A.Bit(0) == 1 || (e.A.Sign() == 0 && e.B.Bit(0) == 1)
+}
+
+// Whether e is above -e, comparing b then a, which picks the y coordinate
+// of a compressed point
+func lexicographicallyLargest(e *pairing.GFp2) bool {
+	if e.B.Sign() != 0 {
+		return e.B.Cmp(halfP) > 0
+	}
+	return e.A.Cmp(halfP) > 0
+}
@@ -0,0 +1,210 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// Hashing to G2 by the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite of RFC 9380,
+// which maps two elements of GF(P²) hashed from the message to the curve
+// isogenous to the twist by the simplified SWU map, then to the twist by the
+// 3-isogeny, adds them and clears the cofactor.
+
+var (
+	// The curve y² = x³ + A·x + B 3-isogenous to the twist and the Z of its
+	// simplified SWU map
+	sswuA = field.NewGFp2(0, 240)
+	sswuB = field.NewGFp2(1012, 1012)
+	sswuZ = field.NewGFp2(-2, -1)
+
+	// The coefficients of the 3-isogeny from lowest to highest power of x, of
+	// x = xNum / xDen and y = y·yNum / yDen, where xDen and yDen are monic
+	isoXNum = []*pairing.GFp2{
+		field.GFp2(
+			pairing.FromHex("05C759507E8E333EBB5B7A9A47D7ED8532C52D39FD3A042A88B58423C50AE15D"+
+				"5C2638E343D9C71C6238AAAAAAAA97D6"),
+			pairing.FromHex("05C759507E8E333EBB5B7A9A47D7ED8532C52D39FD3A042A88B58423C50AE15D"+
+				"5C2638E343D9C71C6238AAAAAAAA97D6")),
+		field.GFp2(new(big.Int),
+			pairing.FromHex("11560BF17BAA99BC32126FCED787C88F984F87ADF7AE0C7F9A208C6B4F20A418"+
+				"1472AAA9CB8D555526A9FFFFFFFFC71A")),
+		field.GFp2(
+			pairing.FromHex("11560BF17BAA99BC32126FCED787C88F984F87ADF7AE0C7F9A208C6B4F20A418"+
+				"1472AAA9CB8D555526A9FFFFFFFFC71E"),
+			pairing.FromHex("08AB05F8BDD54CDE190937E76BC3E447CC27C3D6FBD7063FCD104635A790520C"+
+				"0A395554E5C6AAAA9354FFFFFFFFE38D")),
+		field.GFp2(
+			pairing.FromHex("171D6541FA38CCFAED6DEA691F5FB614CB14B4E7F4E810AA22D6108F142B8575"+
+				"7098E38D0F671C7188E2AAAAAAAA5ED1"),
+			new(big.Int)),
+	}
+	isoXDen = []*pairing.GFp2{
+		field.NewGFp2(0, -72),
+		field.NewGFp2(12, -12),
+		field.NewGFp2(1, 0),
+	}
+	isoYNum = []*pairing.GFp2{
+		field.GFp2(
+			pairing.FromHex("1530477C7AB4113B59A4C18B076D11930F7DA5D4A07F649BF54439D87D27E500"+
+				"FC8C25EBF8C92F6812CFC71C71C6D706"),
+			pairing.FromHex("1530477C7AB4113B59A4C18B076D11930F7DA5D4A07F649BF54439D87D27E500"+
+				"FC8C25EBF8C92F6812CFC71C71C6D706")),
+		field.GFp2(new(big.Int),
+			pairing.FromHex("05C759507E8E333EBB5B7A9A47D7ED8532C52D39FD3A042A88B58423C50AE15D"+
+				"5C2638E343D9C71C6238AAAAAAAA97BE")),
+		field.GFp2(
+			pairing.FromHex("11560BF17BAA99BC32126FCED787C88F984F87ADF7AE0C7F9A208C6B4F20A418"+
+				"1472AAA9CB8D555526A9FFFFFFFFC71C"),
+			pairing.FromHex("08AB05F8BDD54CDE190937E76BC3E447CC27C3D6FBD7063FCD104635A790520C"+
+				"0A395554E5C6AAAA9354FFFFFFFFE38F")),
+		field.GFp2(
+			pairing.FromHex("124C9AD43B6CF79BFBF7043DE3811AD0761B0F37A1E26286B0E977C69AA27452"+
+				"4E79097A56DC4BD9E1B371C71C718B10"),
+			new(big.Int)),
+	}
+	isoYDen = []*pairing.GFp2{
+		field.NewGFp2(-432, -432),
+		field.NewGFp2(0, -216),
+		field.NewGFp2(18, -18),
+		field.NewGFp2(1, 0),
+	}
+)
+
+// Hashes message with the domain separation tag dst to a point of G2
+func HashToG2(message, dst []byte) (*G2, error) {
+	u, err := hashToField(message, dst)
+	if err != nil {
+		return nil, err
+	}
+	t := pairing.AddTwistPoints(isogeny(sswu(u[0])), isogeny(sswu(u[1])))
+	return &G2{clearCofactor(t)}, nil
+}
+
+// expand_message_xmd of RFC 9380 with SHA-256
+func expandMessage(message, dst []byte, length int) ([]byte, error) {
+	blocks := (length + sha256.Size - 1) / sha256.Size
+	if blocks > 255 || len(dst) > 255 {
+		return nil, fmt.Errorf("Cannot expand message to %v bytes with a %v "+
+			"byte domain separation tag", length, len(dst))
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	hash := sha256.New()
+	hash.Write(make([]byte, sha256.BlockSize))
+	hash.Write(message)
+	hash.Write([]byte{byte(length >> 8), byte(length), 0})
+	hash.Write(dstPrime)
+	b0 := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(b0)
+	hash.Write([]byte{1})
+	hash.Write(dstPrime)
+	bi := hash.Sum(nil)
+	uniform := append([]byte{}, bi...)
+	for i := 2; i <= blocks; i++ {
+		xored := make([]byte, sha256.Size)
+		for j := range xored {
+			xored[j] = b0[j] ^ bi[j]
+		}
+		hash.Reset()
+		hash.Write(xored)
+		hash.Write([]byte{byte(i)})
+		hash.Write(dstPrime)
+		bi = hash.Sum(nil)
+		uniform = append(uniform, bi...)
+	}
+	return uniform[:length], nil
+}
+
+// hash_to_field of RFC 9380 for two elements of GF(P²), taking each of their
+// coordinates from 64 bytes for a bias of at most 2⁻¹²⁸
+func hashToField(message, dst []byte) ([2]*pairing.GFp2, error) {
+	var u [2]*pairing.GFp2
+	uniform, err := expandMessage(message, dst, 4*64)
+	if err != nil {
+		return u, err
+	}
+	element := func(i int) *big.Int {
+		return new(big.Int).SetBytes(uniform[64*i : 64*(i+1)])
+	}
+	u[0] = field.GFp2(element(0), element(1))
+	u[1] = field.GFp2(element(2), element(3))
+	return u, nil
+}
+
+// The simplified SWU map of RFC 9380 to the curve isogenous to the twist
+func sswu(u *pairing.GFp2) *pairing.TwistPoint {
+	u2 := u.Mul(u)
+	zu2 := sswuZ.Mul(u2)
+	tv1 := zu2.Mul(zu2).Add(zu2)
+	var x1 *pairing.GFp2
+	if tv1.IsZero() {
+		x1 = sswuB.Mul(sswuZ.Mul(sswuA).Invert())
+	} else {
+		x1 = sswuB.Neg().Mul(sswuA.Invert()).Mul(field.NewGFp2(1, 0).Add(tv1.Invert()))
+	}
+	x := x1
+	y := sswuCurve(x1).Sqrt()
+	if y == nil {
+		x = zu2.Mul(x1)
+		y = sswuCurve(x).Sqrt()
+	}
+	if sgn0(u) != sgn0(y) {
+		y = y.Neg()
+	}
+	return &pairing.TwistPoint{X: x, Y: y}
+}
+
+func sswuCurve(x *pairing.GFp2) *pairing.GFp2 {
+	return x.Mul(x).Mul(x).Add(sswuA.Mul(x)).Add(sswuB)
+}
+
+// Maps a point of the curve isogenous to the twist to the twist
+func isogeny(t *pairing.TwistPoint) *pairing.TwistPoint {
+	polynomial := func(coefficients []*pairing.GFp2) *pairing.GFp2 {
+		result := field.NewGFp2(0, 0)
+		for i := len(coefficients) - 1; i >= 0; i-- {
+			result = result.Mul(t.X).Add(coefficients[i])
+		}
+		return result
+	}
+	xDen := polynomial(isoXDen)
+	yDen := polynomial(isoYDen)
+	if xDen.IsZero() || yDen.IsZero() {
+		// The kernel of the isogeny
+		return nil
+	}
+	return &pairing.TwistPoint{
+		X: polynomial(isoXNum).Mul(xDen.Invert()),
+		Y: t.Y.Mul(polynomial(isoYNum)).Mul(yDen.Invert()),
+	}
+}
+
+// Multiplies t by the effective cofactor of RFC 9380 with the endomorphism
+// ψ, as (x² - x - 1)·t + (x - 1)·ψ(t) + ψ²(2t)
+func clearCofactor(t *pairing.TwistPoint) *pairing.TwistPoint {
+	xt := pairing.MultiplyTwistPoint(t, x)
+	psiT := psi(t)
+	result := psi(psi(pairing.AddTwistPoints(t, t)))
+	result = pairing.AddTwistPoints(result, psiT.Neg())
+	result = pairing.AddTwistPoints(result, pairing.MultiplyTwistPoint(pairing.AddTwistPoints(xt, psiT), x))
+	result = pairing.AddTwistPoints(result, xt.Neg())
+	return pairing.AddTwistPoints(result, t.Neg())
+}
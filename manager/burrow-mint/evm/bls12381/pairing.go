@@ -0,0 +1,63 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"math/big"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// The line through the twist points t1 and t2, or the tangent at t1 when they
+// are equal, evaluated at the point c of G1 in GF(P¹²), up to a factor in a
+// subfield of GF(P¹²) that the final exponentiation takes to one.
+//
+// Over GF(P¹²) the slope of the line through (x1/w², y1/w³) and
+// (x2/w², y2/w³) is lambda/w, for lambda the slope of the line through
+// (x1, y1) and (x2, y2), so w³ times the line at c is
+// y1 - lambda·x1 + lambda·xc·w² - yc·w³. w² times the vertical line is
+// xc·w² - x1.
+func lineFunction(t1, t2 *pairing.TwistPoint, c *pairing.CurvePoint) *pairing.GFp12 {
+	line := field.NewGFp12()
+	lambda, vertical := pairing.TwistSlope(t1, t2)
+	if vertical {
+		line.AddGFp(c.X, 2)
+		return line.AddGFp2(t1.X.Neg(), 0)
+	}
+	line.AddGFp(field.Mod(new(big.Int).Neg(c.Y)), 3)
+	line.AddGFp2(lambda.MulScalar(c.X), 2)
+	return line.AddGFp2(t1.Y.Sub(lambda.Mul(t1.X)), 0)
+}
+
+// The Miller loop of the optimal ate pairing of c and t, which is over the
+// absolute value of the curve parameter and conjugated as it is negative
+func miller(t *pairing.TwistPoint, c *pairing.CurvePoint) *pairing.GFp12 {
+	if t == nil || c == nil {
+		return field.OneGFp12()
+	}
+	f, _ := curve.MillerLoop(t, c, ateLoopCount, lineFunction)
+	return f.Conjugate()
+}
+
+// The optimal ate pairing of c and t, in the subgroup of order Order of
+// GF(P¹²)
+func pair(c *pairing.CurvePoint, t *pairing.TwistPoint) *pairing.GFp12 {
+	return curve.FinalExponentiation(miller(t, c))
+}
+
+// Whether the product of the pairings of the points of cs and ts is one
+func pairingCheck(cs []*pairing.CurvePoint, ts []*pairing.TwistPoint) bool {
+	return curve.PairingCheck(cs, ts, miller)
+}
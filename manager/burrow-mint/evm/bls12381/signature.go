@@ -0,0 +1,186 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls12381
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// The lengths of a secret key, a public key and a signature
+	SeckeyLength    = 32
+	PubkeyLength    = G1CompressedLength
+	SignatureLength = G2CompressedLength
+)
+
+var (
+	// The domain separation tags of the proof of possession scheme, for
+	// signatures and for the proofs of possession of public keys
+	SignatureDST  = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	PossessionDST = []byte("BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+// Gets the public key of the 32 byte secret key seckey
+func PubkeyFromSeckey(seckey []byte) ([]byte, error) {
+	k, err := scalar(seckey)
+	if err != nil {
+		return nil, err
+	}
+	return new(G1).ScalarMult(&G1{g1Generator}, k).Marshal(), nil
+}
+
+// Signs message with seckey
+func Sign(seckey, message []byte) ([]byte, error) {
+	return sign(seckey, message, SignatureDST)
+}
+
+// Proves possession of the secret key of pubkey by signing pubkey, so that
+// aggregates of the public keys that have been proved are safe from rogue
+// key attacks
+func ProvePossession(seckey []byte) ([]byte, error) {
+	pubkey, err := PubkeyFromSeckey(seckey)
+	if err != nil {
+		return nil, err
+	}
+	return sign(seckey, pubkey, PossessionDST)
+}
+
+// Aggregates signatures into one signature
+func AggregateSignatures(signatures [][]byte) ([]byte, error) {
+	aggregate := new(G2)
+	for _, signature := range signatures {
+		sig, err := unmarshalSignature(signature)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.Add(aggregate, sig)
+	}
+	return aggregate.Marshal(), nil
+}
+
+// Whether signature is the signature of message by pubkey. The error is
+// non-nil when pubkey or signature is not a valid encoding.
+func Verify(pubkey, message, signature []byte) (bool, error) {
+	return AggregateVerify([][]byte{pubkey}, [][]byte{message}, signature)
+}
+
+// Whether proof proves possession of the secret key of pubkey
+func VerifyPossession(pubkey, proof []byte) (bool, error) {
+	return aggregateVerify([][]byte{pubkey}, [][]byte{pubkey}, proof, PossessionDST)
+}
+
+// Whether signature aggregates the signatures of message by each of pubkeys,
+// each of whose possession must have been proved
+func FastAggregateVerify(pubkeys [][]byte, message, signature []byte) (bool, error) {
+	if len(pubkeys) == 0 {
+		return false, fmt.Errorf("An aggregate signature must have at least one " +
+			"public key")
+	}
+	aggregate := new(G1)
+	for _, pubkey := range pubkeys {
+		pk, err := unmarshalPubkey(pubkey)
+		if err != nil {
+			return false, err
+		}
+		aggregate.Add(aggregate, pk)
+	}
+	return AggregateVerify([][]byte{aggregate.Marshal()}, [][]byte{message}, signature)
+}
+
+// Whether signature aggregates the signatures of each of messages by the
+// public key of pubkeys at the same index
+func AggregateVerify(pubkeys, messages [][]byte, signature []byte) (bool, error) {
+	return aggregateVerify(pubkeys, messages, signature, SignatureDST)
+}
+
+func aggregateVerify(pubkeys, messages [][]byte, signature, dst []byte) (bool, error) {
+	if len(pubkeys) == 0 || len(pubkeys) != len(messages) {
+		return false, fmt.Errorf("An aggregate signature must have a message for "+
+			"each of at least one public key but has %v public keys and %v "+
+			"messages", len(pubkeys), len(messages))
+	}
+	sig, err := unmarshalSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	// e(pk₁, H(m₁))···e(pkₙ, H(mₙ)) = e(g₁, sig)
+	g1s := []*G1{new(G1).Neg(&G1{g1Generator})}
+	g2s := []*G2{sig}
+	for i, pubkey := range pubkeys {
+		pk, err := unmarshalPubkey(pubkey)
+		if err != nil {
+			return false, err
+		}
+		hash, err := HashToG2(messages[i], dst)
+		if err != nil {
+			return false, err
+		}
+		g1s = append(g1s, pk)
+		g2s = append(g2s, hash)
+	}
+	return PairingCheck(g1s, g2s), nil
+}
+
+func sign(seckey, message, dst []byte) ([]byte, error) {
+	k, err := scalar(seckey)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := HashToG2(message, dst)
+	if err != nil {
+		return nil, err
+	}
+	return new(G2).ScalarMult(hash, k).Marshal(), nil
+}
+
+func unmarshalPubkey(pubkey []byte) (*G1, error) {
+	if len(pubkey) != PubkeyLength {
+		return nil, fmt.Errorf("bls12-381 public key must be %v bytes but was %v",
+			PubkeyLength, len(pubkey))
+	}
+	pk := new(G1)
+	if err := pk.Unmarshal(pubkey); err != nil {
+		return nil, err
+	}
+	if pk.IsInfinity() {
+		return nil, fmt.Errorf("bls12-381 public key is the point at infinity")
+	}
+	return pk, nil
+}
+
+func unmarshalSignature(signature []byte) (*G2, error) {
+	if len(signature) != SignatureLength {
+		return nil, fmt.Errorf("bls12-381 signature must be %v bytes but was %v",
+			SignatureLength, len(signature))
+	}
+	sig := new(G2)
+	if err := sig.Unmarshal(signature); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func scalar(seckey []byte) (*big.Int, error) {
+	if len(seckey) != SeckeyLength {
+		return nil, fmt.Errorf("bls12-381 secret key must be %v bytes but was %v",
+			SeckeyLength, len(seckey))
+	}
+	k := new(big.Int).SetBytes(seckey)
+	if k.Sign() == 0 || k.Cmp(Order) >= 0 {
+		return nil, fmt.Errorf("bls12-381 secret key is not between 1 and Order")
+	}
+	return k, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A pure Go implementation of the alt_bn128 curve of Ethereum's precompiles
+// for point addition, scalar multiplication and pairing checks, so that the
+// pairing based zero-knowledge proofs verified on Ethereum can be verified
+// by the EVM without a C dependency. It favours simplicity over speed and is
+// not constant time, which does not matter for verifying public proofs.
+package bn256
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+const (
+	// x || y of a point of G1, with the point at infinity as zeros
+	G1Length = 64
+	// x || y of a point of G2, each an element a + bi of GF(P²) as b || a,
+	// with the point at infinity as zeros
+	G2Length = 128
+)
+
+var (
+	ErrInvalidPoint  = errors.New("bn256 point is not on the curve")
+	ErrNotInSubgroup = errors.New("bn256 point of G2 is not in the subgroup of order Order")
+)
+
+var (
+	// The parameter of the curve, from which P and Order are derived
+	u = pairing.FromHex("44E992B44A6909F1")
+	// The field prime, 36u⁴ + 36u³ + 24u² + 6u + 1
+	P = pairing.FromHex("30644E72E131A029B85045B68181585D97816A916871CA8D3C208C16D87CFD47")
+	// The order of G1 and G2, 36u⁴ + 36u³ + 18u² + 6u + 1
+	Order = pairing.FromHex("30644E72E131A029B85045B68181585D2833E84879B9709143E1F593F0000001")
+
+	// ξ = 9 + i, where w⁶ = ξ in GF(P¹²)
+	field = pairing.NewField(P, 9)
+	// y² = x³ + 3 and its twist y² = x³ + 3/ξ
+	curve = pairing.NewCurve(field, big.NewInt(3), field.NewGFp2(3, 0).Mul(field.Xi().Invert()),
+		Order)
+
+	// 6u + 2, the loop count of the optimal ate pairing
+	ateLoopCount = new(big.Int).Add(new(big.Int).Mul(u, big.NewInt(6)), big.NewInt(2))
+
+	// The Frobenius endomorphism maps w to w^P = ξ^((P - 1) / 6)·w, so the
+	// twist coordinates x, y to conj(x)·ξ^((P - 1) / 3), conj(y)·ξ^((P - 1) / 2)
+	frobeniusX = field.FrobeniusGamma().Mul(field.FrobeniusGamma())
+	frobeniusY = frobeniusX.Mul(field.FrobeniusGamma())
+
+	g1Generator = &pairing.CurvePoint{X: big.NewInt(1), Y: big.NewInt(2)}
+	g2Generator = &pairing.TwistPoint{
+		X: field.GFp2(
+			pairing.FromHex("1800DEEF121F1E76426A00665E5C4479674322D4F75EDADD46DEBD5CD992F6ED"),
+			pairing.FromHex("198E9393920D483A7260BFB731FB5D25F1AA493335A9E71297E485B7AEF312C2"),
+		),
+		Y: field.GFp2(
+			pairing.FromHex("12C85EA5DB8C6DEB4AAB71808DCB408FE3D1E7690C43D37B4CE6CC0166FA7DAA"),
+			pairing.FromHex("090689D0585FF075EC9E99AD690C3395BC4B313370B38EF355ACDADCD122975B"),
+		),
+	}
+)
+
+// A point of G1, the points of y² = x³ + 3 over GF(P). The zero value is the
+// point at infinity.
+type G1 struct {
+	p *pairing.CurvePoint
+}
+
+// Sets e to the point encoded as x || y, returning ErrInvalidPoint if it is
+// not on the curve
+func (e *G1) Unmarshal(data []byte) error {
+	if len(data) != G1Length {
+		return fmt.Errorf("bn256 point of G1 must be %v bytes but was %v",
+			G1Length, len(data))
+	}
+	x, err := coordinate(data[:32])
+	if err != nil {
+		return err
+	}
+	y, err := coordinate(data[32:])
+	if err != nil {
+		return err
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		e.p = nil
+		return nil
+	}
+	c := &pairing.CurvePoint{X: x, Y: y}
+	if !curve.OnCurve(c) {
+		return ErrInvalidPoint
+	}
+	e.p = c
+	return nil
+}
+
+func (e *G1) Marshal() []byte {
+	data := make([]byte, G1Length)
+	if e.p != nil {
+		putCoordinate(data[:32], e.p.X)
+		putCoordinate(data[32:], e.p.Y)
+	}
+	return data
+}
+
+// Sets e to a + b and returns e
+func (e *G1) Add(a, b *G1) *G1 {
+	e.p = curve.AddCurvePoints(a.p, b.p)
+	return e
+}
+
+// Sets e to k·a and returns e
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	e.p = curve.MultiplyCurvePoint(a.p, k)
+	return e
+}
+
+// Sets e to -a and returns e
+func (e *G1) Neg(a *G1) *G1 {
+	e.p = curve.NegCurvePoint(a.p)
+	return e
+}
+
+// A point of G2, the points of order Order of the twist y² = x³ + 3/ξ over
+// GF(P²). The zero value is the point at infinity.
+type G2 struct {
+	t *pairing.TwistPoint
+}
+
+// Sets e to the point encoded as x || y, returning ErrInvalidPoint if it is
+// not on the twist and ErrNotInSubgroup if it is not of order Order
+func (e *G2) Unmarshal(data []byte) error {
+	if len(data) != G2Length {
+		return fmt.Errorf("bn256 point of G2 must be %v bytes but was %v",
+			G2Length, len(data))
+	}
+	var coordinates [4]*big.Int
+	for i := range coordinates {
+		var err error
+		if coordinates[i], err = coordinate(data[32*i : 32*(i+1)]); err != nil {
+			return err
+		}
+	}
+	t := &pairing.TwistPoint{
+		X: field.GFp2(coordinates[1], coordinates[0]),
+		Y: field.GFp2(coordinates[3], coordinates[2]),
+	}
+	if t.X.IsZero() && t.Y.IsZero() {
+		e.t = nil
+		return nil
+	}
+	if !curve.OnTwist(t) {
+		return ErrInvalidPoint
+	}
+	// Unlike G1, the twist has points outside the subgroup
+	if pairing.MultiplyTwistPoint(t, Order) != nil {
+		return ErrNotInSubgroup
+	}
+	e.t = t
+	return nil
+}
+
+func (e *G2) Marshal() []byte {
+	data := make([]byte, G2Length)
+	if e.t != nil {
+		putCoordinate(data[:32], e.t.X.B)
+		putCoordinate(data[32:64], e.t.X.A)
+		putCoordinate(data[64:96], e.t.Y.B)
+		putCoordinate(data[96:], e.t.Y.A)
+	}
+	return data
+}
+
+// Sets e to k·a and returns e
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	e.t = pairing.MultiplyTwistPoint(a.t, k)
+	return e
+}
+
+// Whether the product of the pairings e(a[i], b[i]) is one, where a and b are
+// the same length. True when they are empty.
+func PairingCheck(a []*G1, b []*G2) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	cs := make([]*pairing.CurvePoint, len(a))
+	ts := make([]*pairing.TwistPoint, len(b))
+	for i := range a {
+		cs[i], ts[i] = a[i].p, b[i].t
+	}
+	return pairingCheck(cs, ts)
+}
+
+func coordinate(data []byte) (*big.Int, error) {
+	n := new(big.Int).SetBytes(data)
+	if n.Cmp(P) >= 0 {
+		return nil, fmt.Errorf("bn256 coordinate %X is not below the field prime",
+			data)
+	}
+	return n, nil
+}
+
+func putCoordinate(data []byte, n *big.Int) {
+	nBytes := n.Bytes()
+	copy(data[len(data)-len(nBytes):], nBytes)
+}
@@ -0,0 +1,142 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bn256
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	bs, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestParameters(t *testing.T) {
+	u2 := new(big.Int).Mul(u, u)
+	u3 := new(big.Int).Mul(u2, u)
+	u4 := new(big.Int).Mul(u3, u)
+	polynomial := func(c2 int64) *big.Int {
+		n := new(big.Int).Mul(u4, big.NewInt(36))
+		n.Add(n, new(big.Int).Mul(u3, big.NewInt(36)))
+		n.Add(n, new(big.Int).Mul(u2, big.NewInt(c2)))
+		n.Add(n, new(big.Int).Mul(u, big.NewInt(6)))
+		return n.Add(n, big.NewInt(1))
+	}
+	assert.Equal(t, 0, P.Cmp(polynomial(24)))
+	assert.Equal(t, 0, Order.Cmp(polynomial(18)))
+
+	assert.True(t, curve.OnCurve(g1Generator))
+	assert.True(t, curve.OnTwist(g2Generator))
+	assert.Nil(t, curve.MultiplyCurvePoint(g1Generator, Order))
+	assert.Nil(t, pairing.MultiplyTwistPoint(g2Generator, Order))
+}
+
+func TestFrobenius(t *testing.T) {
+	f := field.NewGFp12()
+	for i := 0; i < 12; i++ {
+		f.AddGFp(big.NewInt(int64(i*i+7)), i)
+	}
+	assert.True(t, f.Frobenius().Equal(f.Exp(P)))
+	assert.True(t, f.Conjugate().Equal(f.Exp(new(big.Int).Exp(P, big.NewInt(6), nil))))
+	assert.True(t, f.Mul(f.Invert()).IsOne())
+	// The Frobenius endomorphism of the twist acts on G2 as multiplication by P
+	assert.Equal(t, pairing.MultiplyTwistPoint(g2Generator, P), twistFrobenius(g2Generator))
+}
+
+func TestBilinearity(t *testing.T) {
+	a, b := big.NewInt(12345), big.NewInt(67890)
+	ab := new(big.Int).Mul(a, b)
+	e := pair(g1Generator, g2Generator)
+	assert.False(t, e.IsOne())
+	assert.True(t, e.Exp(Order).IsOne())
+
+	eab := pair(curve.MultiplyCurvePoint(g1Generator, a), pairing.MultiplyTwistPoint(g2Generator, b))
+	assert.True(t, eab.Equal(e.Exp(ab)))
+	assert.True(t, eab.Equal(pair(curve.MultiplyCurvePoint(g1Generator, ab), g2Generator)))
+	assert.True(t, eab.Equal(pair(g1Generator, pairing.MultiplyTwistPoint(g2Generator, ab))))
+}
+
+func TestPairingCheck(t *testing.T) {
+	a, b := big.NewInt(3), big.NewInt(5)
+	g1, g2 := &G1{g1Generator}, &G2{g2Generator}
+	aG1 := new(G1).ScalarMult(g1, a)
+	bG2 := new(G2).ScalarMult(g2, b)
+	minusAbG1 := new(G1).Neg(new(G1).ScalarMult(g1, big.NewInt(15)))
+	assert.True(t, PairingCheck([]*G1{aG1, minusAbG1}, []*G2{bG2, g2}))
+	assert.False(t, PairingCheck([]*G1{aG1, aG1}, []*G2{bG2, g2}))
+	assert.False(t, PairingCheck([]*G1{g1}, []*G2{g2}))
+	// Pairings with the point at infinity are one
+	assert.True(t, PairingCheck([]*G1{new(G1), g1}, []*G2{g2, new(G2)}))
+	assert.True(t, PairingCheck(nil, nil))
+	assert.False(t, PairingCheck([]*G1{g1}, nil))
+}
+
+func TestMarshal(t *testing.T) {
+	g1 := new(G1)
+	require.NoError(t, g1.Unmarshal(decodeHex(t,
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+			"0000000000000000000000000000000000000000000000000000000000000002")))
+	assert.Equal(t, "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3"+
+		"15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4",
+		hex.EncodeToString(new(G1).Add(g1, g1).Marshal()))
+	assert.Equal(t, make([]byte, G1Length), new(G1).Add(g1, new(G1).Neg(g1)).Marshal())
+
+	g2Bytes := decodeHex(t,
+		"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2"+
+			"1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed"+
+			"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b"+
+			"12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa")
+	g2 := new(G2)
+	require.NoError(t, g2.Unmarshal(g2Bytes))
+	assert.Equal(t, g2Generator, g2.t)
+	assert.Equal(t, g2Bytes, g2.Marshal())
+	require.NoError(t, g2.Unmarshal(make([]byte, G2Length)))
+	assert.Nil(t, g2.t)
+
+	// (1, 3) is not on the curve
+	invalid := g1.Marshal()
+	invalid[63] = 3
+	assert.Equal(t, ErrInvalidPoint, g1.Unmarshal(invalid))
+	assert.Error(t, g1.Unmarshal(P.Bytes()[:31]))
+	assert.Error(t, g1.Unmarshal(append(P.Bytes(), invalid[32:]...)))
+	g2Bytes[127]++
+	assert.Equal(t, ErrInvalidPoint, g2.Unmarshal(g2Bytes))
+}
+
+func TestSubgroup(t *testing.T) {
+	// A point of the twist whose order is not Order, found by trying x
+	// coordinates until x³ + 3/ξ is a square
+	for x := int64(1); ; x++ {
+		tx := field.NewGFp2(x, 0)
+		t2 := &pairing.TwistPoint{X: tx, Y: tx.Mul(tx).Mul(tx).Add(curve.TwistB).Sqrt()}
+		if t2.Y == nil {
+			continue
+		}
+		require.True(t, curve.OnTwist(t2))
+		if pairing.MultiplyTwistPoint(t2, Order) == nil {
+			continue
+		}
+		g2 := &G2{t2}
+		assert.Equal(t, ErrNotInSubgroup, new(G2).Unmarshal(g2.Marshal()))
+		return
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bn256
+
+import (
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// The twist y² = x³ + 3/ξ over GF(P²) of y² = x³ + 3, whose point (x, y)
+// maps to the point (xw², yw³) of the curve over GF(P¹²)
+
+// The twist point of the image of t under the Frobenius endomorphism of the
+// curve over GF(P¹²), which raises the coordinates of (xw², yw³) to the P
+func twistFrobenius(t *pairing.TwistPoint) *pairing.TwistPoint {
+	return &pairing.TwistPoint{
+		X: t.X.Conjugate().Mul(frobeniusX),
+		Y: t.Y.Conjugate().Mul(frobeniusY),
+	}
+}
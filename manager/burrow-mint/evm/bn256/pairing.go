@@ -0,0 +1,64 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bn256
+
+import (
+	"math/big"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/pairing"
+)
+
+// The line through the twist points t1 and t2, or the tangent at t1 when they
+// are equal, evaluated at the point c of G1 in GF(P¹²).
+//
+// Over GF(P¹²) the slope of the line through (x1w², y1w³) and (x2w², y2w³) is
+// lambda·w, for lambda the slope of the line through (x1, y1) and (x2, y2), so
+// the line at c is (y1 - lambda·x1)w³ + lambda·xc·w - yc. The vertical line
+// is xc - x1w².
+func lineFunction(t1, t2 *pairing.TwistPoint, c *pairing.CurvePoint) *pairing.GFp12 {
+	line := field.NewGFp12()
+	lambda, vertical := pairing.TwistSlope(t1, t2)
+	if vertical {
+		line.AddGFp(c.X, 0)
+		return line.AddGFp2(t1.X.Neg(), 2)
+	}
+	line.AddGFp(field.Mod(new(big.Int).Neg(c.Y)), 0)
+	line.AddGFp2(lambda.MulScalar(c.X), 1)
+	return line.AddGFp2(t1.Y.Sub(lambda.Mul(t1.X)), 3)
+}
+
+// The Miller loop of the optimal ate pairing of c and t
+func miller(t *pairing.TwistPoint, c *pairing.CurvePoint) *pairing.GFp12 {
+	if t == nil || c == nil {
+		return field.OneGFp12()
+	}
+	f, r := curve.MillerLoop(t, c, ateLoopCount, lineFunction)
+	q1 := twistFrobenius(t)
+	minusQ2 := twistFrobenius(q1).Neg()
+	f = f.Mul(lineFunction(r, q1, c))
+	r = pairing.AddTwistPoints(r, q1)
+	return f.Mul(lineFunction(r, minusQ2, c))
+}
+
+// The optimal ate pairing of c and t, in the subgroup of order Order of
+// GF(P¹²)
+func pair(c *pairing.CurvePoint, t *pairing.TwistPoint) *pairing.GFp12 {
+	return curve.FinalExponentiation(miller(t, c))
+}
+
+// Whether the product of the pairings of the points of cs and ts is one
+func pairingCheck(cs []*pairing.CurvePoint, ts []*pairing.TwistPoint) bool {
+	return curve.PairingCheck(cs, ts, miller)
+}
@@ -22,7 +22,14 @@ import (
 )
 
 type FakeAppState struct {
+	accounts  map[string]*Account
+	storage   map[string]Word256
+	snapshots []fakeAppStateSnapshot
+}
+
+type fakeAppStateSnapshot struct {
 	accounts map[string]*Account
+	values   map[*Account]Account
 	storage  map[string]Word256
 }
 
@@ -49,17 +56,31 @@ func (fas *FakeAppState) CreateAccount(creator *Account) *Account {
 	addr := createAddress(creator)
 	account := fas.accounts[addr.String()]
 	if account == nil {
-		return &Account{
+		account = &Account{
 			Address: addr,
 			Balance: 0,
 			Code:    nil,
 			Nonce:   0,
 		}
+		fas.accounts[addr.String()] = account
+		return account
 	} else {
 		panic(fmt.Sprintf("Invalid account addr: %X", addr))
 	}
 }
 
+func (fas *FakeAppState) CreateAccountAt(creator *Account, addr Word256) *Account {
+	if fas.accounts[addr.String()] != nil {
+		return nil
+	}
+	creator.Nonce += 1
+	account := &Account{
+		Address: addr,
+	}
+	fas.accounts[addr.String()] = account
+	return account
+}
+
 func (fas *FakeAppState) GetStorage(addr Word256, key Word256) Word256 {
 	_, ok := fas.accounts[addr.String()]
 	if !ok {
@@ -83,6 +104,35 @@ func (fas *FakeAppState) SetStorage(addr Word256, key Word256, value Word256) {
 	fas.storage[addr.String()+key.String()] = value
 }
 
+func (fas *FakeAppState) Snapshot() int {
+	snapshot := fakeAppStateSnapshot{
+		accounts: make(map[string]*Account, len(fas.accounts)),
+		values:   make(map[*Account]Account, len(fas.accounts)),
+		storage:  make(map[string]Word256, len(fas.storage)),
+	}
+	for addr, account := range fas.accounts {
+		snapshot.accounts[addr] = account
+		snapshot.values[account] = *account
+	}
+	for key, value := range fas.storage {
+		snapshot.storage[key] = value
+	}
+	fas.snapshots = append(fas.snapshots, snapshot)
+	return len(fas.snapshots) - 1
+}
+
+func (fas *FakeAppState) RevertToSnapshot(snapshot int) {
+	if snapshot < 0 || snapshot >= len(fas.snapshots) {
+		panic(fmt.Sprintf("Invalid snapshot: %v", snapshot))
+	}
+	fas.accounts = fas.snapshots[snapshot].accounts
+	for account, value := range fas.snapshots[snapshot].values {
+		*account = value
+	}
+	fas.storage = fas.snapshots[snapshot].storage
+	fas.snapshots = fas.snapshots[:snapshot]
+}
+
 // Creates a 20 byte address and bumps the nonce.
 func createAddress(creator *Account) Word256 {
 	nonce := creator.Nonce
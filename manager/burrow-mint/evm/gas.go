@@ -22,11 +22,33 @@ const (
 	GasBaseOp  int64 = 0 // TODO: make this 1
 	GasStackOp int64 = 1
 
-	GasEcRecover     int64 = 1
 	GasSha256Word    int64 = 1
 	GasSha256Base    int64 = 1
 	GasRipemd160Word int64 = 1
 	GasRipemd160Base int64 = 1
 	GasIdentityWord  int64 = 1
 	GasIdentityBase  int64 = 1
+
+	// Ethereum's cost of ecrecover, as contracts ported from Ethereum may
+	// forward it exactly
+	GasEcRecover int64 = 3000
+
+	// Istanbul's costs for the alt_bn128 precompiles of EIP-196 and EIP-197,
+	// so that contracts verifying zero-knowledge proofs on Ethereum budget the
+	// same gas here
+	GasBn256Add          int64 = 150
+	GasBn256ScalarMul    int64 = 6000
+	GasBn256PairingBase  int64 = 45000
+	GasBn256PairingPoint int64 = 34000
+
+	// Per 32 byte word of a blob put in the confidential store
+	GasConfidentialWord int64 = 1
+
+	// The costs of the BLS12-381 operations of EIP-2537 that verifying
+	// signatures with the BLS12381 SNative takes: a pairing check, hashing a
+	// message to G2 and adding a public key to an aggregate
+	GasBLS12381PairingBase  int64 = 37700
+	GasBLS12381PairingPoint int64 = 32600
+	GasBLS12381HashToG2     int64 = 23800
+	GasBLS12381AddPubkey    int64 = 375
 )
@@ -0,0 +1,222 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
+)
+
+const (
+	// The gas schedule Burrow has always charged, where only stack operations,
+	// account reads, hashing and storage writes cost gas. The default.
+	BurrowGasScheduleName = "burrow"
+	// Ethereum's gas costs as of Petersburg, with Istanbul's costs for CHAINID
+	// and SELFBALANCE, for gas estimates that match Ethereum tooling
+	EthereumGasScheduleName = "ethereum"
+)
+
+// The gas charged for each opcode and for the parts of execution whose cost
+// depends on their arguments
+type GasSchedule struct {
+	// The static cost of each opcode, charged before it runs
+	Ops [256]int64
+
+	// Charged for each word pushed to or popped from the stack
+	StackOp int64
+	// Charged for each account read by BALANCE, EXTCODE*, CALL and SELFDESTRUCT
+	GetAccount int64
+	// Charged for each hash by SHA3, EXTCODEHASH and CREATE2
+	Sha3 int64
+	// Charged for each SSTORE when SStoreSet and SStoreReset are zero
+	StorageUpdate int64
+
+	// Per 32 byte word hashed by SHA3 and CREATE2
+	Sha3Word int64
+	// Per 32 byte word copied by CALLDATACOPY, CODECOPY and EXTCODECOPY
+	CopyWord int64
+	// Per byte of the exponent of EXP
+	ExpByte int64
+	// Per topic and per byte of data of LOG0 to LOG4
+	LogTopic int64
+	LogData  int64
+	// Per byte of the code a CREATE or CREATE2 deploys
+	CreateData int64
+	// Per CALL or CALLCODE that transfers value, of which CallStipend is
+	// passed on to the callee
+	CallValue   int64
+	CallStipend int64
+	// Per CALL that transfers value to an account that does not yet exist
+	CallNewAccount int64
+
+	// Memory of w words costs MemoryWord*w + w*w/MemoryQuadDivisor, charged as
+	// it is first used. No quadratic part when MemoryQuadDivisor is zero.
+	MemoryWord        int64
+	MemoryQuadDivisor int64
+
+	// Per SSTORE setting zero storage to non-zero, and otherwise
+	SStoreSet   int64
+	SStoreReset int64
+	// Refunded for each SSTORE clearing storage and each SELFDESTRUCT
+	SStoreClearRefund  int64
+	SelfDestructRefund int64
+	// Refunds are limited to the gas used divided by MaxRefundQuotient, no
+	// gas is refunded when it is zero
+	MaxRefundQuotient int64
+}
+
+var gasScheduleCostNames = map[string]func(*GasSchedule) *int64{
+	"stack_op":            func(gs *GasSchedule) *int64 { return &gs.StackOp },
+	"get_account":         func(gs *GasSchedule) *int64 { return &gs.GetAccount },
+	"sha3":                func(gs *GasSchedule) *int64 { return &gs.Sha3 },
+	"storage_update":      func(gs *GasSchedule) *int64 { return &gs.StorageUpdate },
+	"sha3_word":           func(gs *GasSchedule) *int64 { return &gs.Sha3Word },
+	"copy_word":           func(gs *GasSchedule) *int64 { return &gs.CopyWord },
+	"exp_byte":            func(gs *GasSchedule) *int64 { return &gs.ExpByte },
+	"log_topic":           func(gs *GasSchedule) *int64 { return &gs.LogTopic },
+	"log_data":            func(gs *GasSchedule) *int64 { return &gs.LogData },
+	"create_data":         func(gs *GasSchedule) *int64 { return &gs.CreateData },
+	"call_value":          func(gs *GasSchedule) *int64 { return &gs.CallValue },
+	"call_stipend":        func(gs *GasSchedule) *int64 { return &gs.CallStipend },
+	"call_new_account":    func(gs *GasSchedule) *int64 { return &gs.CallNewAccount },
+	"memory_word":         func(gs *GasSchedule) *int64 { return &gs.MemoryWord },
+	"memory_quad_divisor": func(gs *GasSchedule) *int64 { return &gs.MemoryQuadDivisor },
+	"sstore_set":          func(gs *GasSchedule) *int64 { return &gs.SStoreSet },
+	"sstore_reset":        func(gs *GasSchedule) *int64 { return &gs.SStoreReset },
+	"sstore_clear_refund": func(gs *GasSchedule) *int64 { return &gs.SStoreClearRefund },
+	"selfdestruct_refund": func(gs *GasSchedule) *int64 { return &gs.SelfDestructRefund },
+	"max_refund_quotient": func(gs *GasSchedule) *int64 { return &gs.MaxRefundQuotient },
+}
+
+// Get the gas schedule Burrow has always charged
+func DefaultGasSchedule() *GasSchedule {
+	gs := &GasSchedule{
+		StackOp:       GasStackOp,
+		GetAccount:    GasGetAccount,
+		Sha3:          GasSha3,
+		StorageUpdate: GasStorageUpdate,
+	}
+	for i := range gs.Ops {
+		gs.Ops[i] = GasBaseOp
+	}
+	return gs
+}
+
+// Get Ethereum's gas schedule
+func EthereumGasSchedule() *GasSchedule {
+	gs := &GasSchedule{
+		Sha3Word:           6,
+		CopyWord:           3,
+		ExpByte:            50,
+		LogTopic:           375,
+		LogData:            8,
+		CreateData:         200,
+		CallValue:          9000,
+		CallStipend:        2300,
+		CallNewAccount:     25000,
+		MemoryWord:         3,
+		MemoryQuadDivisor:  512,
+		SStoreSet:          20000,
+		SStoreReset:        5000,
+		SStoreClearRefund:  15000,
+		SelfDestructRefund: 24000,
+		MaxRefundQuotient:  2,
+	}
+	costs := []struct {
+		gas int64
+		ops []OpCode
+	}{
+		{2, []OpCode{ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE,
+			GASPRICE_DEPRECATED, COINBASE, TIMESTAMP, BLOCKHEIGHT,
+			DIFFICULTY_DEPRECATED, GASLIMIT, CHAINID, POP, PC, MSIZE, GAS}},
+		{3, []OpCode{ADD, SUB, NOT, LT, GT, SLT, SGT, EQ, ISZERO, AND, OR, XOR,
+			BYTE, SHL, SHR, SAR, CALLDATALOAD, CALLDATACOPY, CODECOPY, MLOAD,
+			MSTORE, MSTORE8}},
+		{5, []OpCode{MUL, DIV, SDIV, MOD, SMOD, SIGNEXTEND, SELFBALANCE}},
+		{8, []OpCode{ADDMOD, MULMOD, JUMP}},
+		{10, []OpCode{EXP, JUMPI}},
+		{30, []OpCode{SHA3}},
+		{1, []OpCode{JUMPDEST}},
+		{20, []OpCode{BLOCKHASH}},
+		{200, []OpCode{SLOAD}},
+		{375, []OpCode{LOG0, LOG1, LOG2, LOG3, LOG4}},
+		{400, []OpCode{BALANCE, EXTCODEHASH}},
+		{700, []OpCode{EXTCODESIZE, EXTCODECOPY, CALL, CALLCODE, DELEGATECALL}},
+		{5000, []OpCode{SELFDESTRUCT}},
+		{32000, []OpCode{CREATE, CREATE2}},
+	}
+	for _, cost := range costs {
+		for _, op := range cost.ops {
+			gs.Ops[op] = cost.gas
+		}
+	}
+	for op := PUSH1; op <= SWAP16; op++ {
+		gs.Ops[op] = 3
+	}
+	return gs
+}
+
+// Make a gas schedule from a named base schedule and costs that override it.
+// Costs are named by opcode, such as "SLOAD", or by the snake case name of a
+// GasSchedule field, such as "memory_word".
+func NewGasSchedule(base string, costs map[string]int64) (*GasSchedule, error) {
+	var gs *GasSchedule
+	switch base {
+	case "", BurrowGasScheduleName:
+		gs = DefaultGasSchedule()
+	case EthereumGasScheduleName:
+		gs = EthereumGasSchedule()
+	default:
+		return nil, fmt.Errorf("Unknown gas schedule %s, expected %s or %s",
+			base, BurrowGasScheduleName, EthereumGasScheduleName)
+	}
+	// Sort for a deterministic error
+	names := make([]string, 0, len(costs))
+	for name := range costs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		gas := costs[name]
+		if gas < 0 {
+			return nil, fmt.Errorf("Gas cost %s is negative", name)
+		}
+		if op, ok := GetOpCode(name); ok {
+			gs.Ops[op] = gas
+		} else if field, ok := gasScheduleCostNames[name]; ok {
+			*field(gs) = gas
+		} else {
+			return nil, fmt.Errorf("Unknown gas cost %s, expected an opcode or "+
+				"dynamic cost name", name)
+		}
+	}
+	return gs, nil
+}
+
+// The cost of having the given number of words of memory
+func (gs *GasSchedule) memoryCost(words int64) int64 {
+	cost := gs.MemoryWord * words
+	if gs.MemoryQuadDivisor > 0 {
+		cost += words * words / gs.MemoryQuadDivisor
+	}
+	return cost
+}
+
+// The number of 32 byte words needed to hold size bytes
+func toWords(size int64) int64 {
+	return (size + 31) / 32
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"testing"
+
+	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGasSchedule(t *testing.T) {
+	gs, err := NewGasSchedule("", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultGasSchedule(), gs)
+
+	gs, err = NewGasSchedule(EthereumGasScheduleName, map[string]int64{
+		"SLOAD":       800,
+		"memory_word": 4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(800), gs.Ops[SLOAD])
+	assert.Equal(t, int64(3), gs.Ops[PUSH32])
+	assert.Equal(t, int64(4), gs.MemoryWord)
+	assert.Equal(t, int64(20000), gs.SStoreSet)
+
+	_, err = NewGasSchedule("frontier", nil)
+	assert.Error(t, err)
+	_, err = NewGasSchedule("", map[string]int64{"SLOW": 1})
+	assert.Error(t, err)
+	_, err = NewGasSchedule("", map[string]int64{"SLOAD": -1})
+	assert.Error(t, err)
+}
+
+func TestEthereumGasSchedule(t *testing.T) {
+	appState := newAppState()
+	params := newParams()
+	params.GasSchedule = EthereumGasSchedule()
+	ourVm := NewVM(appState, DefaultDynamicMemoryProvider, params, Zero256, nil)
+	account, _ := makeAccountWithCode(appState, "account", nil)
+
+	gasUsed := func(code []byte) int64 {
+		var gas int64 = 100000
+		_, err := ourVm.Call(account, account, code, nil, 0, &gas)
+		assert.NoError(t, err)
+		return 100000 - gas
+	}
+
+	// Three words of memory
+	assert.Equal(t, int64(3+3+3+3*3), gasUsed(Bytecode(PUSH1, 1, PUSH1, 64, MSTORE, STOP)))
+	// Setting storage
+	assert.Equal(t, int64(3+3+20000), gasUsed(Bytecode(PUSH1, 1, PUSH1, 0, SSTORE, STOP)))
+	// Resetting storage
+	assert.Equal(t, int64(3+3+5000), gasUsed(Bytecode(PUSH1, 2, PUSH1, 0, SSTORE, STOP)))
+	// Clearing storage is refunded up to half the gas used
+	assert.Equal(t, int64((3+3+5000)/2), gasUsed(Bytecode(PUSH1, 0, PUSH1, 0, SSTORE, STOP)))
+	// Hashing a word
+	assert.Equal(t, int64(3+3+30+6+3*1+2), gasUsed(Bytecode(PUSH1, 32, PUSH1, 0, SHA3, POP, STOP)))
+}
@@ -102,3 +102,48 @@ func TestLog4(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type logRecordingFireable struct {
+	logs []txs.EventDataLog
+}
+
+func (lrf *logRecordingFireable) FireEvent(event string, data events.EventData) {
+	if log, ok := data.(txs.EventDataLog); ok {
+		lrf.logs = append(lrf.logs, log)
+	}
+}
+
+// Tests that the logs of calls that fail are not fired
+func TestLogsOfFailedCalls(t *testing.T) {
+	st := newAppState()
+	ourVm := NewVM(st, DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	fireable := new(logRecordingFireable)
+	ourVm.SetFireable(fireable)
+
+	caller, _ := makeAccountWithCode(st, "caller", nil)
+	// Logs the topic 2 and reverts
+	reverter, reverterAddress := makeAccountWithCode(st, "reverter",
+		Bytecode(PUSH1, 2, PUSH1, 0, PUSH1, 0, LOG1, PUSH1, 0, PUSH1, 0, REVERT))
+	// Logs the topic 1 and calls the reverter
+	code := Bytecode(PUSH1, 1, PUSH1, 0, PUSH1, 0, LOG1,
+		PUSH1, 0, PUSH1, 0, PUSH1, 0, PUSH1, 0, PUSH1, 0,
+		PUSH20, reverterAddress, PUSH2, 0x10, 0, CALL, STOP)
+
+	var gas int64 = 100000
+	_, err := ourVm.Call(caller, caller, code, nil, 0, &gas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fireable.logs) != 1 || !reflect.DeepEqual(fireable.logs[0].Topics, []Word256{Int64ToWord256(1)}) {
+		t.Errorf("Expected only the log of the call that succeeded. Got: %v", fireable.logs)
+	}
+
+	fireable.logs = nil
+	_, err = ourVm.Call(caller, reverter, reverter.Code, nil, 0, &gas)
+	if err != ErrExecutionReverted {
+		t.Fatalf("Expected the call to revert. Got: %v", err)
+	}
+	if len(fireable.logs) != 0 {
+		t.Errorf("Expected no logs of a call that reverted. Got: %v", fireable.logs)
+	}
+}
@@ -16,7 +16,12 @@ package vm
 
 import (
 	"crypto/sha256"
+	"fmt"
+	"math/big"
 
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/bn256"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
 	. "github.com/hyperledger/burrow/word256"
 
 	"golang.org/x/crypto/ripemd160"
@@ -44,17 +49,23 @@ func init() {
 }
 
 func registerNativeContracts() {
-	// registeredNativeContracts[Int64ToWord256(1)] = ecrecoverFunc
+	registeredNativeContracts[Int64ToWord256(1)] = ecrecoverFunc
 	registeredNativeContracts[Int64ToWord256(2)] = sha256Func
 	registeredNativeContracts[Int64ToWord256(3)] = ripemd160Func
 	registeredNativeContracts[Int64ToWord256(4)] = identityFunc
+	registeredNativeContracts[Int64ToWord256(6)] = bn256AddFunc
+	registeredNativeContracts[Int64ToWord256(7)] = bn256ScalarMulFunc
+	registeredNativeContracts[Int64ToWord256(8)] = bn256PairingFunc
 }
 
 //-----------------------------------------------------------------------------
 
 type NativeContract func(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error)
 
-/* Removed due to C dependency
+// Recovers the address that signed a hash as Ethereum's ecrecover does. Short
+// input is padded with zeros. v must be 27 or 28, and r and s below the group
+// order but, unlike for transactions, s may be in its upper half. Input that
+// is not a valid signature gives empty output rather than failing the call.
 func ecrecoverFunc(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error) {
 	// Deduct gas
 	gasRequired := GasEcRecover
@@ -63,19 +74,19 @@ func ecrecoverFunc(appState AppState, caller *Account, input []byte, gas *int64)
 	} else {
 		*gas -= gasRequired
 	}
+	input = RightPadBytes(input, 128)
+	v := LeftPadWord256(input[32:64])
+	if v != Int64ToWord256(27) && v != Int64ToWord256(28) {
+		return nil, nil
+	}
 	// Recover
-	hash := input[:32]
-	v := byte(input[32] - 27) // ignore input[33:64], v is small.
-	sig := append(input[64:], v)
-
-	recovered, err := secp256k1.RecoverPubkey(hash, sig)
+	sig := append(append([]byte{}, input[64:128]...), input[63]-27)
+	recovered, err := secp256k1.RecoverPubkey(input[:32], sig)
 	if err != nil {
-		return nil, err
+		return nil, nil
 	}
-	hashed := sha3.Sha3(recovered[1:])
-	return LeftPadBytes(hashed, 32), nil
+	return LeftPadBytes(sha3.Sha3(recovered[1:])[12:], 32), nil
 }
-*/
 
 func sha256Func(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error) {
 	// Deduct gas
@@ -118,3 +129,75 @@ func identityFunc(appState AppState, caller *Account, input []byte, gas *int64)
 	// Return identity
 	return input, nil
 }
+
+// The alt_bn128 precompiles of EIP-196 and EIP-197 at the addresses Ethereum
+// gives them. Short input is padded with zeros and excess input ignored, as on
+// Ethereum, except by the pairing check whose input must be whole pairs.
+
+func bn256AddFunc(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error) {
+	// Deduct gas
+	gasRequired := GasBn256Add
+	if *gas < gasRequired {
+		return nil, ErrInsufficientGas
+	} else {
+		*gas -= gasRequired
+	}
+	input = RightPadBytes(input, 2*bn256.G1Length)
+	a, b := new(bn256.G1), new(bn256.G1)
+	if err := a.Unmarshal(input[:bn256.G1Length]); err != nil {
+		return nil, err
+	}
+	if err := b.Unmarshal(input[bn256.G1Length : 2*bn256.G1Length]); err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).Add(a, b).Marshal(), nil
+}
+
+func bn256ScalarMulFunc(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error) {
+	// Deduct gas
+	gasRequired := GasBn256ScalarMul
+	if *gas < gasRequired {
+		return nil, ErrInsufficientGas
+	} else {
+		*gas -= gasRequired
+	}
+	input = RightPadBytes(input, bn256.G1Length+32)
+	a := new(bn256.G1)
+	if err := a.Unmarshal(input[:bn256.G1Length]); err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[bn256.G1Length : bn256.G1Length+32])
+	return new(bn256.G1).ScalarMult(a, k).Marshal(), nil
+}
+
+func bn256PairingFunc(appState AppState, caller *Account, input []byte, gas *int64) (output []byte, err error) {
+	const pairLength = bn256.G1Length + bn256.G2Length
+	if len(input)%pairLength != 0 {
+		return nil, fmt.Errorf("Input of bn256 pairing check is %v bytes, which "+
+			"is not a whole number of %v byte pairs", len(input), pairLength)
+	}
+	pairs := len(input) / pairLength
+	// Deduct gas
+	gasRequired := GasBn256PairingBase + int64(pairs)*GasBn256PairingPoint
+	if *gas < gasRequired {
+		return nil, ErrInsufficientGas
+	} else {
+		*gas -= gasRequired
+	}
+	g1s := make([]*bn256.G1, pairs)
+	g2s := make([]*bn256.G2, pairs)
+	for i := range g1s {
+		pair := input[i*pairLength : (i+1)*pairLength]
+		g1s[i], g2s[i] = new(bn256.G1), new(bn256.G2)
+		if err := g1s[i].Unmarshal(pair[:bn256.G1Length]); err != nil {
+			return nil, err
+		}
+		if err := g2s[i].Unmarshal(pair[bn256.G1Length:]); err != nil {
+			return nil, err
+		}
+	}
+	if bn256.PairingCheck(g1s, g2s) {
+		return LeftPadBytes([]byte{1}, 32), nil
+	}
+	return LeftPadBytes(nil, 32), nil
+}
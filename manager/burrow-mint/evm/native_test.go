@@ -0,0 +1,147 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/bn256"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEcrecover(t *testing.T) {
+	// The test vector of go-ethereum
+	input, err := hex.DecodeString(
+		"38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" +
+			"000000000000000000000000000000000000000000000000000000000000001b" +
+			"38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" +
+			"789d1dd423d25f0772d2748d60f7e4b81bb14d086eba8e8e8efb6dcff8a4ae02")
+	require.NoError(t, err)
+	signer, err := hex.DecodeString("ceaccac640adf55b2028469bd36ba501f28b699d")
+	require.NoError(t, err)
+
+	appState := newAppState()
+	caller := &Account{Address: Int64ToWord256(100)}
+	call := func(input []byte) ([]byte, int64) {
+		gas := int64(10000)
+		output, err := ecrecoverFunc(appState, caller, input, &gas)
+		require.NoError(t, err)
+		return output, gas
+	}
+	output, gas := call(input)
+	assert.Equal(t, LeftPadBytes(signer, 32), output)
+	assert.Equal(t, 10000-GasEcRecover, gas)
+	// Excess input is ignored
+	output, _ = call(append(append([]byte{}, input...), 1, 2, 3))
+	assert.Equal(t, LeftPadBytes(signer, 32), output)
+
+	// s in the upper half of the group order is accepted with the other v
+	upper := append([]byte{}, input...)
+	copy(upper[96:], LeftPadBytes(new(big.Int).Sub(secp256k1.N,
+		new(big.Int).SetBytes(input[96:])).Bytes(), 32))
+	upper[63] = 28
+	output, _ = call(upper)
+	assert.Equal(t, LeftPadBytes(signer, 32), output)
+
+	// Invalid signatures give no output, but the gas is still taken
+	for _, invalid := range [][]byte{
+		withByte(input, 63, 29),
+		withByte(input, 62, 1),
+		append(append(append([]byte{}, input[:64]...), secp256k1.N.Bytes()...),
+			input[96:]...),
+		make([]byte, 128),
+		// Padded with a zero r and s
+		input[:64],
+	} {
+		output, gas = call(invalid)
+		assert.Empty(t, output)
+		assert.Equal(t, 10000-GasEcRecover, gas)
+	}
+
+	gas = GasEcRecover - 1
+	_, err = ecrecoverFunc(appState, caller, input, &gas)
+	assert.Equal(t, ErrInsufficientGas, err)
+}
+
+func withByte(bs []byte, i int, b byte) []byte {
+	bs = append([]byte{}, bs...)
+	bs[i] = b
+	return bs
+}
+
+func TestBn256Precompiles(t *testing.T) {
+	g1Bytes := append(LeftPadBytes([]byte{1}, 32), LeftPadBytes([]byte{2}, 32)...)
+	g2Bytes, err := hex.DecodeString(
+		"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" +
+			"1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+			"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" +
+			"12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa")
+	require.NoError(t, err)
+	g1 := new(bn256.G1)
+	require.NoError(t, g1.Unmarshal(g1Bytes))
+	doubleG1 := new(bn256.G1).Add(g1, g1).Marshal()
+
+	appState := newAppState()
+	caller := &Account{Address: Int64ToWord256(100)}
+	call := func(address int64, input []byte, gas int64) ([]byte, int64, error) {
+		output, err := registeredNativeContracts[Int64ToWord256(address)](appState,
+			caller, input, &gas)
+		return output, gas, err
+	}
+
+	output, gas, err := call(6, append(g1Bytes, g1Bytes...), 1000)
+	require.NoError(t, err)
+	assert.Equal(t, doubleG1, output)
+	assert.Equal(t, 1000-GasBn256Add, gas)
+	// Missing input is the point at infinity
+	output, _, err = call(6, g1Bytes, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, g1Bytes, output)
+	invalid := append([]byte{}, g1Bytes...)
+	invalid[63] = 3
+	_, _, err = call(6, invalid, 1000)
+	assert.Equal(t, bn256.ErrInvalidPoint, err)
+
+	output, gas, err = call(7, append(g1Bytes, LeftPadBytes([]byte{2}, 32)...), 10000)
+	require.NoError(t, err)
+	assert.Equal(t, doubleG1, output)
+	assert.Equal(t, 10000-GasBn256ScalarMul, gas)
+	_, _, err = call(7, g1Bytes, 100)
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	// e(2·G1, G2)·e(-G1, 2·G2) = 1
+	g2 := new(bn256.G2)
+	require.NoError(t, g2.Unmarshal(g2Bytes))
+	doubleG2 := new(bn256.G2).ScalarMult(g2, big.NewInt(2)).Marshal()
+	minusG1 := new(bn256.G1).Neg(g1).Marshal()
+	input := append(append(append(doubleG1, g2Bytes...), minusG1...), doubleG2...)
+	output, gas, err = call(8, input, 200000)
+	require.NoError(t, err)
+	assert.Equal(t, LeftPadBytes([]byte{1}, 32), output)
+	assert.Equal(t, 200000-GasBn256PairingBase-2*GasBn256PairingPoint, gas)
+	output, _, err = call(8, append(append(g1Bytes, g2Bytes...), input[192:]...), 200000)
+	require.NoError(t, err)
+	assert.Equal(t, LeftPadBytes(nil, 32), output)
+	output, _, err = call(8, nil, 200000)
+	require.NoError(t, err)
+	assert.Equal(t, LeftPadBytes([]byte{1}, 32), output)
+	_, _, err = call(8, input[:100], 200000)
+	assert.Error(t, err)
+}
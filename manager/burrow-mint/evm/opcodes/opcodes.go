@@ -52,6 +52,9 @@ const (
 	XOR
 	NOT
 	BYTE
+	SHL
+	SHR
+	SAR
 
 	SHA3 = 0x20
 )
@@ -71,6 +74,8 @@ const (
 	GASPRICE_DEPRECATED
 	EXTCODESIZE
 	EXTCODECOPY
+
+	EXTCODEHASH = 0x3f
 )
 
 const (
@@ -82,6 +87,8 @@ const (
 	BLOCKHEIGHT
 	DIFFICULTY_DEPRECATED
 	GASLIMIT
+	CHAINID
+	SELFBALANCE
 )
 
 const (
@@ -183,8 +190,10 @@ const (
 	CALLCODE
 	RETURN
 	DELEGATECALL
+	CREATE2
 
 	// 0x70 range - other
+	REVERT       = 0xfd
 	SELFDESTRUCT = 0xff
 )
 
@@ -214,6 +223,9 @@ var opCodeToString = map[OpCode]string{
 	OR:     "OR",
 	XOR:    "XOR",
 	BYTE:   "BYTE",
+	SHL:    "SHL",
+	SHR:    "SHR",
+	SAR:    "SAR",
 	ADDMOD: "ADDMOD",
 	MULMOD: "MULMOD",
 
@@ -240,8 +252,11 @@ var opCodeToString = map[OpCode]string{
 	BLOCKHEIGHT:           "BLOCKHEIGHT",
 	DIFFICULTY_DEPRECATED: "DIFFICULTY_DEPRECATED",
 	GASLIMIT:              "GASLIMIT",
+	CHAINID:               "CHAINID",
+	SELFBALANCE:           "SELFBALANCE",
 	EXTCODESIZE:           "EXTCODESIZE",
 	EXTCODECOPY:           "EXTCODECOPY",
+	EXTCODEHASH:           "EXTCODEHASH",
 
 	// 0x50 range - 'storage' and execution
 	POP:      "POP",
@@ -336,8 +351,10 @@ var opCodeToString = map[OpCode]string{
 	RETURN:       "RETURN",
 	CALLCODE:     "CALLCODE",
 	DELEGATECALL: "DELEGATECALL",
+	CREATE2:      "CREATE2",
 
 	// 0x70 range - other
+	REVERT:       "REVERT",
 	SELFDESTRUCT: "SELFDESTRUCT",
 }
 
@@ -350,6 +367,16 @@ func (o OpCode) String() string {
 	return str
 }
 
+// Get the opcode with the name String() gives it
+func GetOpCode(name string) (OpCode, bool) {
+	for op, str := range opCodeToString {
+		if str == name {
+			return op, true
+		}
+	}
+	return 0, false
+}
+
 //-----------------------------------------------------------------------------
 
 func AnalyzeJumpDests(code []byte) (dests *set.Set) {
@@ -0,0 +1,224 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"math/big"
+)
+
+// The curve y² = x³ + B over GF(P) and its sextic twist y² = x³ + TwistB
+// over GF(P²), whose subgroups G1 and G2 are of order Order
+type Curve struct {
+	*Field
+	B      *big.Int
+	TwistB *GFp2
+	Order  *big.Int
+	// (P⁴ - P² + 1) / Order, the hard part of the final exponentiation
+	hardExponent *big.Int
+}
+
+func NewCurve(field *Field, b *big.Int, twistB *GFp2, order *big.Int) *Curve {
+	p2 := new(big.Int).Mul(field.P, field.P)
+	hardExponent := new(big.Int).Mul(p2, p2)
+	hardExponent.Sub(hardExponent, p2)
+	hardExponent.Add(hardExponent, big.NewInt(1))
+	return &Curve{
+		Field:        field,
+		B:            b,
+		TwistB:       twistB,
+		Order:        order,
+		hardExponent: hardExponent.Div(hardExponent, order),
+	}
+}
+
+// A point of the curve in affine coordinates, where nil is the point at
+// infinity
+type CurvePoint struct {
+	X, Y *big.Int
+}
+
+func (curve *Curve) OnCurve(c *CurvePoint) bool {
+	lhs := curve.Mod(new(big.Int).Mul(c.Y, c.Y))
+	rhs := new(big.Int).Mul(c.X, c.X)
+	rhs.Mul(rhs, c.X)
+	return lhs.Cmp(curve.Mod(rhs.Add(rhs, curve.B))) == 0
+}
+
+func (curve *Curve) NegCurvePoint(c *CurvePoint) *CurvePoint {
+	if c == nil {
+		return nil
+	}
+	return &CurvePoint{c.X, curve.Mod(new(big.Int).Neg(c.Y))}
+}
+
+func (curve *Curve) AddCurvePoints(c1, c2 *CurvePoint) *CurvePoint {
+	if c1 == nil {
+		return c2
+	}
+	if c2 == nil {
+		return c1
+	}
+	var lambda *big.Int
+	if c1.X.Cmp(c2.X) == 0 {
+		if c1.Y.Cmp(c2.Y) != 0 || c1.Y.Sign() == 0 {
+			// c2 = -c1
+			return nil
+		}
+		// Doubling: lambda = 3x² / 2y
+		numerator := new(big.Int).Mul(c1.X, c1.X)
+		numerator.Mul(numerator, big.NewInt(3))
+		denominator := curve.Mod(new(big.Int).Lsh(c1.Y, 1))
+		lambda = numerator.Mul(numerator, denominator.ModInverse(denominator, curve.P))
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		numerator := new(big.Int).Sub(c2.Y, c1.Y)
+		denominator := curve.Mod(new(big.Int).Sub(c2.X, c1.X))
+		lambda = numerator.Mul(numerator, denominator.ModInverse(denominator, curve.P))
+	}
+	curve.Mod(lambda)
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, c1.X)
+	x.Sub(x, c2.X)
+	curve.Mod(x)
+	y := new(big.Int).Sub(c1.X, x)
+	y.Mul(y, lambda)
+	y.Sub(y, c1.Y)
+	return &CurvePoint{x, curve.Mod(y)}
+}
+
+// Multiplies c by k, which may be negative
+func (curve *Curve) MultiplyCurvePoint(c *CurvePoint, k *big.Int) *CurvePoint {
+	var result *CurvePoint
+	// Bit takes negative numbers in two's complement
+	abs := new(big.Int).Abs(k)
+	for i := abs.BitLen() - 1; i >= 0; i-- {
+		result = curve.AddCurvePoints(result, result)
+		if abs.Bit(i) == 1 {
+			result = curve.AddCurvePoints(result, c)
+		}
+	}
+	if k.Sign() < 0 {
+		return curve.NegCurvePoint(result)
+	}
+	return result
+}
+
+// A point of the twist in affine coordinates, where nil is the point at
+// infinity
+type TwistPoint struct {
+	X, Y *GFp2
+}
+
+func (curve *Curve) OnTwist(t *TwistPoint) bool {
+	return t.Y.Mul(t.Y).Equal(t.X.Mul(t.X).Mul(t.X).Add(curve.TwistB))
+}
+
+func (t *TwistPoint) Neg() *TwistPoint {
+	if t == nil {
+		return nil
+	}
+	return &TwistPoint{t.X, t.Y.Neg()}
+}
+
+// The slope of the line through t1 and t2, or of the tangent at t1 when they
+// are equal, and whether the line is vertical, so t2 = -t1
+func TwistSlope(t1, t2 *TwistPoint) (*GFp2, bool) {
+	if t1.X.Equal(t2.X) {
+		if !t1.Y.Equal(t2.Y) || t1.Y.IsZero() {
+			return nil, true
+		}
+		// Doubling: lambda = 3x² / 2y
+		numerator := t1.X.Mul(t1.X).MulScalar(big.NewInt(3))
+		return numerator.Mul(t1.Y.Add(t1.Y).Invert()), false
+	}
+	return t2.Y.Sub(t1.Y).Mul(t2.X.Sub(t1.X).Invert()), false
+}
+
+func AddTwistPoints(t1, t2 *TwistPoint) *TwistPoint {
+	if t1 == nil {
+		return t2
+	}
+	if t2 == nil {
+		return t1
+	}
+	lambda, vertical := TwistSlope(t1, t2)
+	if vertical {
+		return nil
+	}
+	x := lambda.Mul(lambda).Sub(t1.X).Sub(t2.X)
+	y := t1.X.Sub(x).Mul(lambda).Sub(t1.Y)
+	return &TwistPoint{x, y}
+}
+
+// Multiplies t by k, which may be negative
+func MultiplyTwistPoint(t *TwistPoint, k *big.Int) *TwistPoint {
+	var result *TwistPoint
+	abs := new(big.Int).Abs(k)
+	for i := abs.BitLen() - 1; i >= 0; i-- {
+		result = AddTwistPoints(result, result)
+		if abs.Bit(i) == 1 {
+			result = AddTwistPoints(result, t)
+		}
+	}
+	if k.Sign() < 0 {
+		return result.Neg()
+	}
+	return result
+}
+
+// The line through the twist points t1 and t2, or the tangent at t1 when they
+// are equal, evaluated at the point c of the curve in GF(P¹²), which depends
+// on how the twist maps to the curve over GF(P¹²)
+type LineFunction func(t1, t2 *TwistPoint, c *CurvePoint) *GFp12
+
+// The Miller loop over the bits of the positive loopCount, returning the
+// product of the lines through the multiples of t evaluated at c, and
+// loopCount·t. Neither t nor c may be the point at infinity.
+func (curve *Curve) MillerLoop(t *TwistPoint, c *CurvePoint, loopCount *big.Int,
+	line LineFunction) (*GFp12, *TwistPoint) {
+	f := curve.OneGFp12()
+	r := t
+	// The top bit is accounted for by starting from t
+	for i := loopCount.BitLen() - 2; i >= 0; i-- {
+		f = f.Mul(f).Mul(line(r, r, c))
+		r = AddTwistPoints(r, r)
+		if loopCount.Bit(i) == 1 {
+			f = f.Mul(line(r, t, c))
+			r = AddTwistPoints(r, t)
+		}
+	}
+	return f, r
+}
+
+// The Miller loop of a pairing of c and t
+type Miller func(t *TwistPoint, c *CurvePoint) *GFp12
+
+// Raises f to (P¹² - 1) / Order, as f^(P⁶ - 1) then to P² + 1, which the
+// Frobenius endomorphism makes cheap, and then to (P⁴ - P² + 1) / Order
+func (curve *Curve) FinalExponentiation(f *GFp12) *GFp12 {
+	f = f.Conjugate().Mul(f.Invert())
+	f = f.Frobenius().Frobenius().Mul(f)
+	return f.Exp(curve.hardExponent)
+}
+
+// Whether the product of the pairings of the points of cs and ts, by the
+// Miller loop miller, is one
+func (curve *Curve) PairingCheck(cs []*CurvePoint, ts []*TwistPoint, miller Miller) bool {
+	f := curve.OneGFp12()
+	for i := range cs {
+		f = f.Mul(miller(ts[i], cs[i]))
+	}
+	return curve.FinalExponentiation(f).IsOne()
+}
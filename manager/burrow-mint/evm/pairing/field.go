@@ -0,0 +1,335 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The arithmetic that the pairing friendly curves of bn256 and bls12381
+// share: the tower of fields GF(P) ⊂ GF(P²) ⊂ GF(P¹²) and the points of a
+// curve y² = x³ + b over GF(P) and of its sextic twist over GF(P²), with the
+// Miller loop over them. The packages of the curves supply their parameters,
+// their line functions and their final exponentiations, which differ with
+// the type of the twist and the sign of the curve parameter.
+package pairing
+
+import (
+	"fmt"
+	"math/big"
+)
+
+func FromHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic(fmt.Errorf("invalid hex constant %s", s))
+	}
+	return n
+}
+
+// GF(P) for a prime P = 3 mod 4, with GF(P²) = GF(P)(i), where i² = -1, and
+// GF(P¹²) = GF(P²)(w), where w⁶ = ξ = xi + i
+type Field struct {
+	P  *big.Int
+	xi int64
+	// (P + 1) / 4 for square roots in GF(P), and (P - 3) / 4 and (P - 1) / 2
+	// for square roots in GF(P²)
+	sqrtExponentP *big.Int
+	sqrtExponent  *big.Int
+	halfExponent  *big.Int
+	// The Frobenius endomorphism maps w to w^P = ξ^((P - 1) / 6)·w
+	frobeniusGamma *GFp2
+	// The powers of w raised to P
+	frobeniusW [12]*GFp12
+}
+
+func NewField(p *big.Int, xi int64) *Field {
+	if p.Bit(0) != 1 || p.Bit(1) != 1 {
+		panic(fmt.Errorf("field prime %X is not 3 mod 4", p))
+	}
+	one := big.NewInt(1)
+	f := &Field{
+		P:             p,
+		xi:            xi,
+		sqrtExponentP: new(big.Int).Rsh(new(big.Int).Add(p, one), 2),
+		sqrtExponent:  new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(3)), 2),
+		halfExponent:  new(big.Int).Rsh(new(big.Int).Sub(p, one), 1),
+	}
+	f.frobeniusGamma = f.Xi().Exp(new(big.Int).Div(new(big.Int).Sub(p, one), big.NewInt(6)))
+	wp := f.NewGFp12().AddGFp2(f.frobeniusGamma, 1)
+	f.frobeniusW[0] = f.OneGFp12()
+	for i := 1; i < 12; i++ {
+		f.frobeniusW[i] = f.frobeniusW[i-1].Mul(wp)
+	}
+	return f
+}
+
+func (f *Field) Mod(n *big.Int) *big.Int {
+	return n.Mod(n, f.P)
+}
+
+// A square root of n in GF(P), or nil if n is not a square
+func (f *Field) Sqrt(n *big.Int) *big.Int {
+	root := new(big.Int).Exp(n, f.sqrtExponentP, f.P)
+	if f.Mod(new(big.Int).Mul(root, root)).Cmp(f.Mod(new(big.Int).Set(n))) != 0 {
+		return nil
+	}
+	return root
+}
+
+// ξ, where w⁶ = ξ in GF(P¹²)
+func (f *Field) Xi() *GFp2 {
+	return f.NewGFp2(f.xi, 1)
+}
+
+// ξ^((P - 1) / 6), where w^P = ξ^((P - 1) / 6)·w
+func (f *Field) FrobeniusGamma() *GFp2 {
+	return f.frobeniusGamma
+}
+
+// An element a + bi of GF(P²)
+type GFp2 struct {
+	A, B  *big.Int
+	field *Field
+}
+
+func (f *Field) NewGFp2(a, b int64) *GFp2 {
+	return f.GFp2(big.NewInt(a), big.NewInt(b))
+}
+
+// The element a + bi, reducing a and b
+func (f *Field) GFp2(a, b *big.Int) *GFp2 {
+	return &GFp2{f.Mod(new(big.Int).Set(a)), f.Mod(new(big.Int).Set(b)), f}
+}
+
+func (e *GFp2) IsZero() bool {
+	return e.A.Sign() == 0 && e.B.Sign() == 0
+}
+
+func (e *GFp2) Equal(f *GFp2) bool {
+	return e.A.Cmp(f.A) == 0 && e.B.Cmp(f.B) == 0
+}
+
+func (e *GFp2) new(a, b *big.Int) *GFp2 {
+	return &GFp2{e.field.Mod(a), e.field.Mod(b), e.field}
+}
+
+func (e *GFp2) Add(f *GFp2) *GFp2 {
+	return e.new(new(big.Int).Add(e.A, f.A), new(big.Int).Add(e.B, f.B))
+}
+
+func (e *GFp2) Sub(f *GFp2) *GFp2 {
+	return e.new(new(big.Int).Sub(e.A, f.A), new(big.Int).Sub(e.B, f.B))
+}
+
+func (e *GFp2) Neg() *GFp2 {
+	return e.new(new(big.Int).Neg(e.A), new(big.Int).Neg(e.B))
+}
+
+func (e *GFp2) Conjugate() *GFp2 {
+	return e.new(new(big.Int).Set(e.A), new(big.Int).Neg(e.B))
+}
+
+func (e *GFp2) Mul(f *GFp2) *GFp2 {
+	a := new(big.Int).Mul(e.A, f.A)
+	a.Sub(a, new(big.Int).Mul(e.B, f.B))
+	b := new(big.Int).Mul(e.A, f.B)
+	b.Add(b, new(big.Int).Mul(e.B, f.A))
+	return e.new(a, b)
+}
+
+func (e *GFp2) MulScalar(k *big.Int) *GFp2 {
+	return e.new(new(big.Int).Mul(e.A, k), new(big.Int).Mul(e.B, k))
+}
+
+// 1 / (a + bi) = (a - bi) / (a² + b²), which is only defined for non-zero e
+func (e *GFp2) Invert() *GFp2 {
+	norm := new(big.Int).Mul(e.A, e.A)
+	norm.Add(norm, new(big.Int).Mul(e.B, e.B))
+	norm.ModInverse(e.field.Mod(norm), e.field.P)
+	return e.Conjugate().MulScalar(norm)
+}
+
+func (e *GFp2) Exp(k *big.Int) *GFp2 {
+	result := e.field.NewGFp2(1, 0)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.Mul(result)
+		if k.Bit(i) == 1 {
+			result = result.Mul(e)
+		}
+	}
+	return result
+}
+
+// A square root of e, or nil if e is not a square, by Algorithm 9 of Adj and
+// Rodríguez-Henríquez for P = 3 mod 4
+func (e *GFp2) Sqrt() *GFp2 {
+	minusOne := e.field.NewGFp2(-1, 0)
+	a1 := e.Exp(e.field.sqrtExponent)
+	alpha := a1.Mul(a1).Mul(e)
+	if alpha.Conjugate().Mul(alpha).Equal(minusOne) {
+		return nil
+	}
+	x0 := a1.Mul(e)
+	if alpha.Equal(minusOne) {
+		return e.field.NewGFp2(0, 1).Mul(x0)
+	}
+	return alpha.Add(e.field.NewGFp2(1, 0)).Exp(e.field.halfExponent).Mul(x0)
+}
+
+// An element of GF(P¹²) as the coefficients of a polynomial in w. Since
+// (w⁶ - xi)² = i² = -1, w¹² = 2xi·w⁶ - (xi² + 1), and GF(P²) is embedded as
+// a + bi = (a - xi·b) + bw⁶.
+type GFp12 struct {
+	c     [12]*big.Int
+	field *Field
+}
+
+func (f *Field) NewGFp12() *GFp12 {
+	e := &GFp12{field: f}
+	for i := range e.c {
+		e.c[i] = new(big.Int)
+	}
+	return e
+}
+
+func (f *Field) OneGFp12() *GFp12 {
+	e := f.NewGFp12()
+	e.c[0].SetInt64(1)
+	return e
+}
+
+func (e *GFp12) IsOne() bool {
+	if e.c[0].Cmp(big.NewInt(1)) != 0 {
+		return false
+	}
+	for _, c := range e.c[1:] {
+		if c.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *GFp12) Equal(f *GFp12) bool {
+	for i := range e.c {
+		if e.c[i].Cmp(f.c[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds the element k of GF(P) times w^power and returns e
+func (e *GFp12) AddGFp(k *big.Int, power int) *GFp12 {
+	e.field.Mod(e.c[power].Add(e.c[power], k))
+	return e
+}
+
+// Adds the element k of GF(P²) times w^power, for power below 6, and
+// returns e
+func (e *GFp12) AddGFp2(k *GFp2, power int) *GFp12 {
+	c := new(big.Int).Mul(k.B, big.NewInt(e.field.xi))
+	e.AddGFp(c.Sub(k.A, c), power)
+	return e.AddGFp(k.B, power+6)
+}
+
+func (e *GFp12) Mul(f *GFp12) *GFp12 {
+	var product [23]*big.Int
+	for i := range product {
+		product[i] = new(big.Int)
+	}
+	term := new(big.Int)
+	for i, c := range e.c {
+		if c.Sign() == 0 {
+			continue
+		}
+		for j, d := range f.c {
+			product[i+j].Add(product[i+j], term.Mul(c, d))
+		}
+	}
+	// Reduce with w¹² = 2xi·w⁶ - (xi² + 1) from the highest power down
+	xi := e.field.xi
+	w6 := big.NewInt(2 * xi)
+	w0 := big.NewInt(xi*xi + 1)
+	for k := 22; k >= 12; k-- {
+		product[k-6].Add(product[k-6], term.Mul(product[k], w6))
+		product[k-12].Sub(product[k-12], term.Mul(product[k], w0))
+	}
+	result := &GFp12{field: e.field}
+	for i := range result.c {
+		result.c[i] = e.field.Mod(product[i])
+	}
+	return result
+}
+
+func (e *GFp12) MulScalar(k *big.Int) *GFp12 {
+	result := &GFp12{field: e.field}
+	for i, c := range e.c {
+		result.c[i] = e.field.Mod(new(big.Int).Mul(c, k))
+	}
+	return result
+}
+
+func (e *GFp12) Exp(k *big.Int) *GFp12 {
+	result := e.field.OneGFp12()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.Mul(result)
+		if k.Bit(i) == 1 {
+			result = result.Mul(e)
+		}
+	}
+	return result
+}
+
+// e^P, which is linear over GF(P) so is the sum of the coefficients of e
+// times the powers of w raised to P
+func (e *GFp12) Frobenius() *GFp12 {
+	result := e.field.NewGFp12()
+	term := new(big.Int)
+	for i, c := range e.c {
+		if c.Sign() == 0 {
+			continue
+		}
+		for j, d := range e.field.frobeniusW[i].c {
+			result.c[j].Add(result.c[j], term.Mul(c, d))
+		}
+	}
+	for _, c := range result.c {
+		e.field.Mod(c)
+	}
+	return result
+}
+
+// e^(P⁶), the conjugate of e over GF(P⁶) = GF(P)(w²), which takes w to -w
+func (e *GFp12) Conjugate() *GFp12 {
+	result := &GFp12{field: e.field}
+	for i, c := range e.c {
+		if i%2 == 1 {
+			result.c[i] = e.field.Mod(new(big.Int).Neg(c))
+		} else {
+			result.c[i] = new(big.Int).Set(c)
+		}
+	}
+	return result
+}
+
+// The inverse of e is the product of its conjugates e^(P^k), for k from 1 to
+// 11, divided by its norm, the product of e and its conjugates, which lies in
+// GF(P). Only defined for non-zero e.
+func (e *GFp12) Invert() *GFp12 {
+	conjugate := e
+	product := e.field.OneGFp12()
+	for k := 1; k < 12; k++ {
+		conjugate = conjugate.Frobenius()
+		product = product.Mul(conjugate)
+	}
+	norm := e.Mul(product).c[0]
+	return product.MulScalar(new(big.Int).ModInverse(norm, e.field.P))
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGFp2(t *testing.T) {
+	// A small field in which every element can be tried
+	f := NewField(big.NewInt(103), 1)
+	squares := 0
+	for a := int64(0); a < 103; a++ {
+		for b := int64(0); b < 103; b++ {
+			e := f.NewGFp2(a, b)
+			if e.IsZero() {
+				continue
+			}
+			assert.True(t, e.Mul(e.Invert()).Equal(f.NewGFp2(1, 0)))
+			root := e.Mul(e).Sqrt()
+			require.NotNil(t, root)
+			assert.True(t, root.Equal(e) || root.Equal(e.Neg()))
+			if e.Sqrt() != nil {
+				squares++
+			}
+		}
+	}
+	// Half of the non-zero elements are squares
+	assert.Equal(t, (103*103-1)/2, squares)
+}
+
+func TestMultiplyByNegative(t *testing.T) {
+	f := NewField(big.NewInt(103), 1)
+	curve := NewCurve(f, big.NewInt(3), f.NewGFp2(3, 0), big.NewInt(1))
+	three, minusThree := big.NewInt(3), big.NewInt(-3)
+
+	c := &CurvePoint{big.NewInt(1), big.NewInt(2)}
+	require.True(t, curve.OnCurve(c))
+	assert.Equal(t, curve.NegCurvePoint(curve.MultiplyCurvePoint(c, three)),
+		curve.MultiplyCurvePoint(c, minusThree))
+
+	tp := &TwistPoint{f.NewGFp2(1, 0), f.NewGFp2(2, 0)}
+	require.True(t, curve.OnTwist(tp))
+	assert.Equal(t, MultiplyTwistPoint(tp, three).Neg(), MultiplyTwistPoint(tp, minusThree))
+	assert.Nil(t, AddTwistPoints(MultiplyTwistPoint(tp, three), MultiplyTwistPoint(tp, minusThree)))
+}
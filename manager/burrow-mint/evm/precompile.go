@@ -0,0 +1,135 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"plugin"
+	"sort"
+
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The symbol a precompile plugin exports, of type []vm.Precompile
+const PrecompilePluginSymbol = "Precompiles"
+
+// A native contract that a node operator registers at an address of their
+// choosing, either at compile time from an init function or by loading a Go
+// plugin. Registration must happen before the VM first runs, and all the
+// nodes of a chain must register the same precompiles.
+type Precompile struct {
+	Name    string
+	Address Word256
+	// Gas charged before Function runs, GasBase plus GasWord for each 32 byte
+	// word of input. Function may charge more from the gas it is passed.
+	GasBase int64
+	GasWord int64
+	// The permissions the calling account must have, or 0 for none
+	Permissions ptypes.PermFlag
+	Function    NativeContract
+}
+
+var registeredPrecompiles = make(map[Word256]Precompile)
+
+func RegisterPrecompile(precompile Precompile) error {
+	if precompile.Function == nil {
+		return fmt.Errorf("Precompile %s has no function", precompile.Name)
+	}
+	if precompile.Address == Zero256 {
+		return fmt.Errorf("Precompile %s cannot be registered at the zero address",
+			precompile.Name)
+	}
+	if precompile.GasBase < 0 || precompile.GasWord < 0 {
+		return fmt.Errorf("Precompile %s has negative gas", precompile.Name)
+	}
+	if precompile.Permissions&^ptypes.AllPermFlags != 0 {
+		return fmt.Errorf("Precompile %s requires unknown permissions %b",
+			precompile.Name, precompile.Permissions&^ptypes.AllPermFlags)
+	}
+	if !RegisterNativeContract(precompile.Address, precompile.call) {
+		return fmt.Errorf("Cannot register precompile %s since a native "+
+			"contract is already registered at %X", precompile.Name,
+			precompile.Address)
+	}
+	registeredPrecompiles[precompile.Address] = precompile
+	return nil
+}
+
+// Get the precompiles that have been registered in order of address
+func RegisteredPrecompiles() []Precompile {
+	precompiles := make([]Precompile, 0, len(registeredPrecompiles))
+	for _, precompile := range registeredPrecompiles {
+		precompiles = append(precompiles, precompile)
+	}
+	sort.Sort(precompilesByAddress(precompiles))
+	return precompiles
+}
+
+// Opens the Go plugin at path and registers the precompiles it exports
+func LoadPrecompilePlugin(path string) ([]Precompile, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open precompile plugin %s: %v", path, err)
+	}
+	symbol, err := p.Lookup(PrecompilePluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("Precompile plugin %s does not export %s: %v",
+			path, PrecompilePluginSymbol, err)
+	}
+	precompiles, ok := symbol.(*[]Precompile)
+	if !ok {
+		return nil, fmt.Errorf("Precompile plugin %s exports %s of type %T "+
+			"rather than []vm.Precompile", path, PrecompilePluginSymbol, symbol)
+	}
+	for _, precompile := range *precompiles {
+		if err := RegisterPrecompile(precompile); err != nil {
+			return nil, fmt.Errorf("Precompile plugin %s: %v", path, err)
+		}
+	}
+	return *precompiles, nil
+}
+
+func (precompile Precompile) call(appState AppState, caller *Account, input []byte,
+	gas *int64) (output []byte, err error) {
+	for perm := ptypes.PermFlag(1); perm != 0 && perm <= precompile.Permissions; perm <<= 1 {
+		if precompile.Permissions&perm != 0 && !HasPermission(appState, caller, perm) {
+			return nil, ErrPermission{ptypes.PermFlagToString(perm)}
+		}
+	}
+	// Deduct gas
+	gasRequired := int64((len(input)+31)/32)*precompile.GasWord + precompile.GasBase
+	if *gas < gasRequired {
+		return nil, ErrInsufficientGas
+	} else {
+		*gas -= gasRequired
+	}
+	return precompile.Function(appState, caller, input, gas)
+}
+
+type precompilesByAddress []Precompile
+
+func (ps precompilesByAddress) Len() int {
+	return len(ps)
+}
+
+func (ps precompilesByAddress) Less(i, j int) bool {
+	return bytes.Compare(ps[i].Address[:], ps[j].Address[:]) < 0
+}
+
+func (ps precompilesByAddress) Swap(i, j int) {
+	ps[i], ps[j] = ps[j], ps[i]
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"testing"
+
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterPrecompile(t *testing.T) {
+	address := Int64ToWord256(0x1000)
+	precompile := Precompile{
+		Name:        "double",
+		Address:     address,
+		GasBase:     10,
+		GasWord:     2,
+		Permissions: ptypes.Call | ptypes.CreateContract,
+		Function: func(appState AppState, caller *Account, input []byte,
+			gas *int64) ([]byte, error) {
+			return append(input, input...), nil
+		},
+	}
+	assert.NoError(t, RegisterPrecompile(precompile))
+	assert.True(t, RegisteredNativeContract(address))
+	registered := RegisteredPrecompiles()
+	if assert.Len(t, registered, 1) {
+		assert.Equal(t, "double", registered[0].Name)
+		assert.Equal(t, address, registered[0].Address)
+	}
+
+	// Addresses are taken once
+	assert.Error(t, RegisterPrecompile(precompile))
+	precompile.Address = Int64ToWord256(2)
+	assert.Error(t, RegisterPrecompile(precompile))
+	precompile.Address = Int64ToWord256(0x1001)
+	precompile.Function = nil
+	assert.Error(t, RegisterPrecompile(precompile))
+
+	appState := newAppState()
+	caller := &Account{
+		Address:     Int64ToWord256(100),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	caller.Permissions.Base.Set(ptypes.Call, true)
+	caller.Permissions.Base.Set(ptypes.CreateContract, true)
+	var gas int64 = 100
+	output, err := registeredNativeContracts[address](appState, caller,
+		make([]byte, 40), &gas)
+	assert.NoError(t, err)
+	assert.Len(t, output, 80)
+	// Two words of input
+	assert.Equal(t, int64(100-10-2*2), gas)
+
+	gas = 11
+	_, err = registeredNativeContracts[address](appState, caller, make([]byte, 40), &gas)
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	caller.Permissions.Base.Set(ptypes.CreateContract, false)
+	gas = 100
+	_, err = registeredNativeContracts[address](appState, caller, nil, &gas)
+	assert.Equal(t, ErrPermission{"create_contract"}, err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The selector Solidity prefixes the reason given to revert or require with,
+// which is ABI encoded as if it were a call to Error(string)
+var revertReasonSelector = sha3.Sha3([]byte("Error(string)"))[:4]
+
+// Gets the reason from the output of a call that reverted, if it holds an ABI
+// encoded Error(string)
+func RevertReason(output []byte) (string, bool) {
+	if len(output) < 4+2*32 || !bytes.Equal(output[:4], revertReasonSelector) {
+		return "", false
+	}
+	data := output[4:]
+	offset, ok := abiInt(data[:32], len(data))
+	if !ok || offset+32 > len(data) {
+		return "", false
+	}
+	length, ok := abiInt(data[offset:offset+32], len(data))
+	if !ok || offset+32+length > len(data) {
+		return "", false
+	}
+	return string(data[offset+32 : offset+32+length]), true
+}
+
+// Precedes the hex of the output of a call that reverted in the errors of
+// RevertError when it is not a reason, such as a custom error
+const revertDataPrefix = " with data "
+
+// Adds the reason to err when it is ErrExecutionReverted and output holds a
+// reason, or else any other output in hex, which a client can decode as a
+// custom error of the contract's ABI. Otherwise returns err.
+func RevertError(err error, output []byte) error {
+	if err != ErrExecutionReverted {
+		return err
+	}
+	if reason, ok := RevertReason(output); ok {
+		return fmt.Errorf("%s: %s", err, reason)
+	}
+	if len(output) > 0 {
+		return fmt.Errorf("%s%s%X", err, revertDataPrefix, output)
+	}
+	return err
+}
+
+// Decodes the output a call reverted with that err carries, when it is an
+// error of RevertError holding output other than a reason, against the custom
+// errors of the ABI of the contract called. The hex of the output is replaced
+// with the error it encodes, such as InsufficientBalance(needed: 10), or with
+// the panic code of a failed assert. Otherwise returns err.
+func DecodeRevertError(err error, contractABI *abi.ABI) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	prefix := ErrExecutionReverted.Error() + revertDataPrefix
+	i := strings.Index(message, prefix)
+	if i < 0 {
+		return err
+	}
+	start := i + len(prefix)
+	end := start
+	for end < len(message) && strings.IndexByte("0123456789ABCDEFabcdef", message[end]) >= 0 {
+		end++
+	}
+	output, hexErr := hex.DecodeString(message[start:end])
+	if hexErr != nil || len(output) == 0 {
+		return err
+	}
+	return fmt.Errorf("%s: %s%s", message[:i+len(ErrExecutionReverted.Error())],
+		contractABI.DecodeRevert(output), message[end:])
+}
+
+// Reads a word as an int that must be no greater than max
+func abiInt(word []byte, max int) (int, bool) {
+	w := LeftPadWord256(word)
+	for _, b := range w[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	n := Uint64FromWord256(w)
+	if n > uint64(max) {
+		return 0, false
+	}
+	return int(n), true
+}
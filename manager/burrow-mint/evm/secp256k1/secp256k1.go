@@ -0,0 +1,297 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A pure Go implementation of the secp256k1 curve operations needed to sign
+// Ethereum transactions and to recover their senders, so that we avoid the C
+// dependency of libsecp256k1. It is not constant time so must not be used to
+// sign with keys that need protecting from timing attacks.
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// r || s || recovery id
+	SignatureLength = 65
+	// 0x04 || x || y
+	PubkeyLength = 65
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid secp256k1 signature")
+	ErrInvalidSeckey    = errors.New("invalid secp256k1 private key")
+)
+
+func fromHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic(fmt.Errorf("invalid hex constant %s", s))
+	}
+	return n
+}
+
+var (
+	// The field prime
+	P = fromHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F")
+	// The order of the group generated by G
+	N  = fromHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+	Gx = fromHex("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798")
+	Gy = fromHex("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8")
+	// Signatures with s above this are malleable and rejected by Ethereum
+	HalfN = new(big.Int).Rsh(N, 1)
+
+	b = big.NewInt(7)
+	// (P + 1) / 4 for square roots, which works since P = 3 mod 4
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(P, big.NewInt(1)), 2)
+)
+
+// A point in affine coordinates where nil is the point at infinity
+type point struct {
+	x, y *big.Int
+}
+
+var g = &point{Gx, Gy}
+
+func mod(n, m *big.Int) *big.Int {
+	return n.Mod(n, m)
+}
+
+func add(p1, p2 *point) *point {
+	if p1 == nil {
+		return p2
+	}
+	if p2 == nil {
+		return p1
+	}
+	var lambda *big.Int
+	if p1.x.Cmp(p2.x) == 0 {
+		if p1.y.Cmp(p2.y) != 0 || p1.y.Sign() == 0 {
+			// p2 = -p1
+			return nil
+		}
+		// Doubling: lambda = 3x^2 / 2y
+		numerator := new(big.Int).Mul(p1.x, p1.x)
+		numerator.Mul(numerator, big.NewInt(3))
+		denominator := new(big.Int).Lsh(p1.y, 1)
+		lambda = numerator.Mul(numerator, denominator.ModInverse(denominator, P))
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		numerator := new(big.Int).Sub(p2.y, p1.y)
+		denominator := mod(new(big.Int).Sub(p2.x, p1.x), P)
+		lambda = numerator.Mul(numerator, denominator.ModInverse(denominator, P))
+	}
+	mod(lambda, P)
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, p1.x)
+	x.Sub(x, p2.x)
+	mod(x, P)
+	y := new(big.Int).Sub(p1.x, x)
+	y.Mul(y, lambda)
+	y.Sub(y, p1.y)
+	return &point{x, mod(y, P)}
+}
+
+func multiply(p *point, k *big.Int) *point {
+	var result *point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = add(result, result)
+		if k.Bit(i) == 1 {
+			result = add(result, p)
+		}
+	}
+	return result
+}
+
+// Finds the point with x coordinate x whose y coordinate has the parity odd
+func decompress(x *big.Int, odd bool) (*point, error) {
+	if x.Cmp(P) >= 0 {
+		return nil, ErrInvalidSignature
+	}
+	rhs := new(big.Int).Exp(x, big.NewInt(3), P)
+	mod(rhs.Add(rhs, b), P)
+	y := new(big.Int).Exp(rhs, sqrtExp, P)
+	if new(big.Int).Exp(y, big.NewInt(2), P).Cmp(rhs) != 0 {
+		return nil, ErrInvalidSignature
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(P, y)
+	}
+	return &point{x, y}, nil
+}
+
+func marshal(p *point) []byte {
+	pubkey := make([]byte, PubkeyLength)
+	pubkey[0] = 0x04
+	xBytes, yBytes := p.x.Bytes(), p.y.Bytes()
+	copy(pubkey[33-len(xBytes):33], xBytes)
+	copy(pubkey[65-len(yBytes):], yBytes)
+	return pubkey
+}
+
+// Recovers the uncompressed public key that made sig over the 32 byte
+// hash, where sig is r || s || v with a recovery id v of 0 to 3
+func RecoverPubkey(hash, sig []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("secp256k1 hash must be 32 bytes but was %v",
+			len(hash))
+	}
+	if len(sig) != SignatureLength {
+		return nil, fmt.Errorf("secp256k1 signature must be %v bytes but was %v",
+			SignatureLength, len(sig))
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	v := sig[64]
+	if v > 3 || r.Sign() == 0 || r.Cmp(N) >= 0 || s.Sign() == 0 ||
+		s.Cmp(N) >= 0 {
+		return nil, ErrInvalidSignature
+	}
+	x := new(big.Int).Set(r)
+	if v&2 != 0 {
+		x.Add(x, N)
+	}
+	R, err := decompress(x, v&1 == 1)
+	if err != nil {
+		return nil, err
+	}
+	// Q = r^-1 (sR - eG)
+	e := new(big.Int).SetBytes(hash)
+	rInv := new(big.Int).ModInverse(r, N)
+	u1 := mod(new(big.Int).Neg(e), N)
+	u1 = mod(u1.Mul(u1, rInv), N)
+	u2 := mod(new(big.Int).Mul(s, rInv), N)
+	Q := add(multiply(g, u1), multiply(R, u2))
+	if Q == nil {
+		return nil, ErrInvalidSignature
+	}
+	return marshal(Q), nil
+}
+
+// Gets the uncompressed public key of the 32 byte private key seckey
+func PubkeyFromSeckey(seckey []byte) ([]byte, error) {
+	d, err := scalar(seckey)
+	if err != nil {
+		return nil, err
+	}
+	return marshal(multiply(g, d)), nil
+}
+
+// Signs the 32 byte hash with seckey returning r || s || v, with the nonce
+// chosen deterministically as described by RFC 6979 and s in the lower half
+// of the group order
+func Sign(hash, seckey []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("secp256k1 hash must be 32 bytes but was %v",
+			len(hash))
+	}
+	d, err := scalar(seckey)
+	if err != nil {
+		return nil, err
+	}
+	e := new(big.Int).SetBytes(hash)
+	nonces := newRFC6979(d, mod(new(big.Int).Set(e), N))
+	for {
+		k := nonces.next()
+		R := multiply(g, k)
+		r := mod(new(big.Int).Set(R.x), N)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 (e + rd)
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, N))
+		mod(s, N)
+		if s.Sign() == 0 {
+			continue
+		}
+		v := byte(R.y.Bit(0))
+		if R.x.Cmp(N) >= 0 {
+			v |= 2
+		}
+		if s.Cmp(HalfN) > 0 {
+			s.Sub(N, s)
+			v ^= 1
+		}
+		sig := make([]byte, SignatureLength)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rBytes):32], rBytes)
+		copy(sig[64-len(sBytes):64], sBytes)
+		sig[64] = v
+		return sig, nil
+	}
+}
+
+func scalar(seckey []byte) (*big.Int, error) {
+	if len(seckey) != 32 {
+		return nil, ErrInvalidSeckey
+	}
+	d := new(big.Int).SetBytes(seckey)
+	if d.Sign() == 0 || d.Cmp(N) >= 0 {
+		return nil, ErrInvalidSeckey
+	}
+	return d, nil
+}
+
+// The HMAC-SHA256 deterministic nonce generator of RFC 6979
+type rfc6979 struct {
+	k, v []byte
+}
+
+func newRFC6979(d, e *big.Int) *rfc6979 {
+	x := make([]byte, 32)
+	dBytes := d.Bytes()
+	copy(x[32-len(dBytes):], dBytes)
+	h := make([]byte, 32)
+	eBytes := e.Bytes()
+	copy(h[32-len(eBytes):], eBytes)
+	gen := &rfc6979{
+		k: make([]byte, 32),
+		v: make([]byte, 32),
+	}
+	for i := range gen.v {
+		gen.v[i] = 0x01
+	}
+	gen.k = gen.mac(gen.v, []byte{0x00}, x, h)
+	gen.v = gen.mac(gen.v)
+	gen.k = gen.mac(gen.v, []byte{0x01}, x, h)
+	gen.v = gen.mac(gen.v)
+	return gen
+}
+
+func (gen *rfc6979) mac(data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, gen.k)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+func (gen *rfc6979) next() *big.Int {
+	for {
+		gen.v = gen.mac(gen.v)
+		k := new(big.Int).SetBytes(gen.v)
+		// Prepare for the next candidate whether or not this one is used
+		gen.k = gen.mac(gen.v, []byte{0x00})
+		gen.v = gen.mac(gen.v)
+		if k.Sign() > 0 && k.Cmp(N) < 0 {
+			return k
+		}
+	}
+}
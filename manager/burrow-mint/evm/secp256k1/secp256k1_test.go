@@ -0,0 +1,96 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	bs, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return bs
+}
+
+func address(pubkey []byte) []byte {
+	return sha3.Sha3(pubkey[1:])[12:]
+}
+
+// The example transaction signature from EIP-155
+func TestSignAndRecover(t *testing.T) {
+	seckey := bytes.Repeat([]byte{0x46}, 32)
+	hash := decodeHex(t, "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53")
+	expectedSig := decodeHex(t, "28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"+
+		"67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"+"00")
+	expectedAddress := decodeHex(t, "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+
+	sig, err := Sign(hash, seckey)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSig, sig)
+
+	pubkey, err := PubkeyFromSeckey(seckey)
+	require.NoError(t, err)
+	assert.Equal(t, expectedAddress, address(pubkey))
+
+	recovered, err := RecoverPubkey(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, pubkey, recovered)
+
+	// The other recovery id gives a different key
+	sig[64] = 1
+	recovered, err = RecoverPubkey(hash, sig)
+	require.NoError(t, err)
+	assert.NotEqual(t, pubkey, recovered)
+}
+
+func TestSignLowS(t *testing.T) {
+	seckey := sha3.Sha3([]byte("seckey"))
+	pubkey, err := PubkeyFromSeckey(seckey)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		hash := sha3.Sha3([]byte{byte(i)})
+		sig, err := Sign(hash, seckey)
+		require.NoError(t, err)
+		s := sig[32:64]
+		assert.True(t, bytes.Compare(s, HalfN.Bytes()) <= 0)
+		recovered, err := RecoverPubkey(hash, sig)
+		require.NoError(t, err)
+		assert.Equal(t, pubkey, recovered)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	hash := make([]byte, 32)
+	_, err := RecoverPubkey(hash, make([]byte, SignatureLength))
+	assert.Equal(t, ErrInvalidSignature, err)
+	_, err = RecoverPubkey(hash, make([]byte, 64))
+	assert.Error(t, err)
+	sig, err := Sign(hash, bytes.Repeat([]byte{0x46}, 32))
+	require.NoError(t, err)
+	sig[64] = 4
+	_, err = RecoverPubkey(hash, sig)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = PubkeyFromSeckey(make([]byte, 32))
+	assert.Equal(t, ErrInvalidSeckey, err)
+	_, err = PubkeyFromSeckey(N.Bytes())
+	assert.Equal(t, ErrInvalidSeckey, err)
+}
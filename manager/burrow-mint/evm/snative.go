@@ -18,8 +18,10 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/burrow/common/sanity"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/bls12381"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
 	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
 
 	"strings"
@@ -183,6 +185,221 @@ func SNativeContracts() map[string]*SNativeContractDescription {
 				ptypes.SetGlobal,
 				setGlobal},
 		),
+		NewSNativeContract(`
+		* Interface for reading the headers and storage of other chains relayed to this one.
+		* @dev Headers are relayed with RelayTxs, which verify them against the commits of the
+		* @dev validators of their chain, along with storage proved against their AppHash.
+		* @dev Chain IDs are given right padded with zeros, so must be at most 32 bytes.
+		`,
+			"Relay",
+			&SNativeFunctionDescription{`
+			* @notice Gets the height of the latest header relayed of a chain
+			* @param _chainID chain ID
+			* @return result height of the header, 0 if none has been relayed
+			`,
+				"latestHeight",
+				[]abi.Arg{
+					abiArg("_chainID", abi.Bytes32TypeName),
+				},
+				abiReturn("result", abi.Uint64TypeName),
+				ptypes.Call,
+				latestHeight},
+
+			&SNativeFunctionDescription{`
+			* @notice Gets the AppHash of a header relayed of a chain
+			* @param _chainID chain ID
+			* @param _height height of the header
+			* @return result AppHash of the header, 0 if it has not been relayed
+			`,
+				"appHash",
+				[]abi.Arg{
+					abiArg("_chainID", abi.Bytes32TypeName),
+					abiArg("_height", abi.Uint64TypeName),
+				},
+				abiReturn("result", abi.Bytes32TypeName),
+				ptypes.Call,
+				appHash},
+
+			&SNativeFunctionDescription{`
+			* @notice Gets storage of an account on a chain proved against a header relayed of it
+			* @param _chainID chain ID
+			* @param _height height of the header
+			* @param _account account address on the chain
+			* @param _key storage key
+			* @return result storage value, the call failing if it has not been proved
+			`,
+				"relayedStorage",
+				[]abi.Arg{
+					abiArg("_chainID", abi.Bytes32TypeName),
+					abiArg("_height", abi.Uint64TypeName),
+					abiArg("_account", abi.AddressTypeName),
+					abiArg("_key", abi.Bytes32TypeName),
+				},
+				abiReturn("result", abi.Bytes32TypeName),
+				ptypes.Call,
+				relayedStorage},
+		),
+		NewSNativeContract(`
+		* Interface for anchoring encrypted blobs of private data on the chain.
+		* @dev Each account puts blobs under keys of its own and grants other accounts, such as
+		* @dev contracts or users, to read them. The blobs are stored as given, so should be
+		* @dev encrypted, such as by the seal_confidential method of a node whose operator
+		* @dev holds the key of the store, which decrypts them for the accounts granted.
+		`,
+			"Confidential",
+			&SNativeFunctionDescription{`
+			* @notice Puts a blob under a key of the caller, replacing any blob there
+			* @param _key key of the blob
+			* @param _blob the encrypted blob, an empty blob removing the one there
+			* @return result true
+			`,
+				"put",
+				[]abi.Arg{
+					abiArg("_key", abi.Bytes32TypeName),
+					abiArg("_blob", abi.BytesTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				putConfidential},
+
+			&SNativeFunctionDescription{`
+			* @notice Gets a blob, the call failing unless the caller is its owner or granted to read it
+			* @param _owner account that put the blob
+			* @param _key key of the blob
+			* @return result the encrypted blob, empty if there is none
+			`,
+				"get",
+				[]abi.Arg{
+					abiArg("_owner", abi.AddressTypeName),
+					abiArg("_key", abi.Bytes32TypeName),
+				},
+				abiReturn("result", abi.BytesTypeName),
+				ptypes.Call,
+				getConfidential},
+
+			&SNativeFunctionDescription{`
+			* @notice Grants an account to read the blob under a key of the caller
+			* @param _key key of the blob, which need not have been put yet
+			* @param _reader account granted
+			* @return result true
+			`,
+				"grant",
+				[]abi.Arg{
+					abiArg("_key", abi.Bytes32TypeName),
+					abiArg("_reader", abi.AddressTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				grantConfidential},
+
+			&SNativeFunctionDescription{`
+			* @notice Revokes the grant of an account to read the blob under a key of the caller
+			* @param _key key of the blob
+			* @param _reader account revoked
+			* @return result true
+			`,
+				"revoke",
+				[]abi.Arg{
+					abiArg("_key", abi.Bytes32TypeName),
+					abiArg("_reader", abi.AddressTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				revokeConfidential},
+
+			&SNativeFunctionDescription{`
+			* @notice Checks whether an account may read a blob, as its owner or by a grant
+			* @param _owner account that puts the blob
+			* @param _key key of the blob
+			* @param _reader account reading
+			* @return result whether the account may read the blob
+			`,
+				"isGranted",
+				[]abi.Arg{
+					abiArg("_owner", abi.AddressTypeName),
+					abiArg("_key", abi.Bytes32TypeName),
+					abiArg("_reader", abi.AddressTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				isConfidentialGranted},
+		),
+		NewSNativeContract(`
+		* Interface for verifying BLS signatures over the BLS12-381 curve.
+		* @dev Signatures are those of the proof of possession scheme of the BLS signatures draft
+		* @dev of the IETF, as signed by Ethereum 2 validators: public keys are compressed 48 byte
+		* @dev points of G1 and signatures compressed 96 byte points of G2. Public keys aggregated
+		* @dev must have had their possession proved, such as by verifyPossession on registering
+		* @dev them, or an attacker may forge aggregate signatures with rogue keys.
+		* @dev Lists of public keys are given concatenated.
+		`,
+			"BLS12381",
+			&SNativeFunctionDescription{`
+			* @notice Verifies the signature of a message by a public key
+			* @param _pubkey public key
+			* @param _message message signed
+			* @param _signature signature
+			* @return result whether the signature is valid, the call failing if an encoding is not
+			`,
+				"verify",
+				[]abi.Arg{
+					abiArg("_pubkey", abi.BytesTypeName),
+					abiArg("_message", abi.BytesTypeName),
+					abiArg("_signature", abi.BytesTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				blsVerify},
+
+			&SNativeFunctionDescription{`
+			* @notice Verifies a signature aggregating the signatures of one message by public keys
+			* @param _pubkeys public keys whose possession has been proved, concatenated
+			* @param _message message signed
+			* @param _signature aggregate signature
+			* @return result whether the signature is valid, the call failing if an encoding is not
+			`,
+				"fastAggregateVerify",
+				[]abi.Arg{
+					abiArg("_pubkeys", abi.BytesTypeName),
+					abiArg("_message", abi.BytesTypeName),
+					abiArg("_signature", abi.BytesTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				blsFastAggregateVerify},
+
+			&SNativeFunctionDescription{`
+			* @notice Verifies a signature aggregating the signatures of a 32 byte message by each public key
+			* @param _pubkeys public keys, concatenated
+			* @param _messages messages signed, concatenated in the order of the public keys signing them
+			* @param _signature aggregate signature
+			* @return result whether the signature is valid, the call failing if an encoding is not
+			`,
+				"aggregateVerify",
+				[]abi.Arg{
+					abiArg("_pubkeys", abi.BytesTypeName),
+					abiArg("_messages", abi.BytesTypeName),
+					abiArg("_signature", abi.BytesTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				blsAggregateVerify},
+
+			&SNativeFunctionDescription{`
+			* @notice Verifies a proof of possession of the secret key of a public key
+			* @param _pubkey public key
+			* @param _proof proof of possession
+			* @return result whether the proof is valid, the call failing if an encoding is not
+			`,
+				"verifyPossession",
+				[]abi.Arg{
+					abiArg("_pubkey", abi.BytesTypeName),
+					abiArg("_proof", abi.BytesTypeName),
+				},
+				abiReturn("result", abi.BoolTypeName),
+				ptypes.Call,
+				blsVerifyPossession},
+		),
 	}
 
 	contractMap := make(map[string]*SNativeContractDescription, len(contracts))
@@ -243,8 +460,10 @@ func (contract *SNativeContractDescription) Dispatch(appState AppState,
 		return nil, ErrInvalidPermission{caller.Address, function.Name}
 	}
 
-	// ensure there are enough arguments
-	if len(remainingArgs) != function.NArgs()*Word256Length {
+	// ensure there are enough arguments, dynamic arguments following the
+	// words of their offsets
+	if len(remainingArgs) != function.NArgs()*Word256Length &&
+		!(function.dynamic() && len(remainingArgs) > function.NArgs()*Word256Length) {
 		return nil, fmt.Errorf("%s() takes %d arguments", function.Name,
 			function.NArgs())
 	}
@@ -324,6 +543,17 @@ func (function *SNativeFunctionDescription) NArgs() int {
 	return len(function.Args)
 }
 
+// Whether the function takes bytes, which are encoded after the other
+// arguments
+func (function *SNativeFunctionDescription) dynamic() bool {
+	for _, arg := range function.Args {
+		if arg.TypeName == abi.BytesTypeName {
+			return true
+		}
+	}
+	return false
+}
+
 func abiArg(name string, abiTypeName abi.TypeName) abi.Arg {
 	return abi.Arg{
 		Name:     name,
@@ -450,6 +680,284 @@ func removeRole(appState AppState, caller *Account, args []byte, gas *int64) (ou
 	return LeftPadWord256([]byte{permInt}).Bytes(), nil
 }
 
+// Relay function definitions
+
+func latestHeight(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	relayState, err := getRelayState(appState)
+	if err != nil {
+		return nil, err
+	}
+	chainID := relayedChainID(args[:32])
+	return Uint64ToWord256(uint64(relayState.GetRelayedHeight(chainID))).Bytes(), nil
+}
+
+func appHash(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	relayState, err := getRelayState(appState)
+	if err != nil {
+		return nil, err
+	}
+	chainIDWord, heightWord := returnTwoArgs(args)
+	chainID := relayedChainID(chainIDWord.Bytes())
+	hash := relayState.GetRelayedAppHash(chainID, int(Uint64FromWord256(heightWord)))
+	return LeftPadWord256(hash).Bytes(), nil
+}
+
+func relayedStorage(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	relayState, err := getRelayState(appState)
+	if err != nil {
+		return nil, err
+	}
+	chainIDWord, heightWord := returnTwoArgs(args)
+	addr, key := returnTwoArgs(args[64:])
+	chainID := relayedChainID(chainIDWord.Bytes())
+	height := int(Uint64FromWord256(heightWord))
+	value, ok := relayState.GetRelayedStorage(chainID, height, addr, key)
+	if !ok {
+		return nil, fmt.Errorf("Storage of %X at key %X on chain %s has not been "+
+			"proved at height %v", addr.Postfix(20), key, chainID, height)
+	}
+	return value.Bytes(), nil
+}
+
+func getRelayState(appState AppState) (RelayState, error) {
+	relayState, ok := appState.(RelayState)
+	if !ok {
+		return nil, fmt.Errorf("Relayed chains are not available to this VM")
+	}
+	return relayState, nil
+}
+
+func relayedChainID(chainID []byte) string {
+	return strings.TrimRight(string(chainID), "\x00")
+}
+
+// Confidential function definitions
+
+// The longest blob that can be put in the confidential store
+const MaxConfidentialBlobLength = 1 << 16
+
+func putConfidential(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	confidentialState, err := getConfidentialState(appState)
+	if err != nil {
+		return nil, err
+	}
+	key := LeftPadWord256(args[:32])
+	blob, err := bytesArg(args, 32)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) > MaxConfidentialBlobLength {
+		return nil, fmt.Errorf("Blob is %v bytes but at most %v bytes can be put",
+			len(blob), MaxConfidentialBlobLength)
+	}
+	gasRequired := int64((len(blob)+31)/32) * GasConfidentialWord
+	if *gas < gasRequired {
+		return nil, ErrInsufficientGas
+	}
+	*gas -= gasRequired
+	confidentialState.SetConfidentialBlob(caller.Address, key, blob)
+	return LeftPadWord256([]byte{1}).Bytes(), nil
+}
+
+func getConfidential(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	confidentialState, err := getConfidentialState(appState)
+	if err != nil {
+		return nil, err
+	}
+	owner, key := returnTwoArgs(args)
+	if owner != caller.Address &&
+		!confidentialState.IsConfidentialGranted(owner, key, caller.Address) {
+		return nil, fmt.Errorf("%X is not granted to read the blob of %X at key %X",
+			caller.Address.Postfix(20), owner.Postfix(20), key)
+	}
+	return returnBytes(confidentialState.GetConfidentialBlob(owner, key)), nil
+}
+
+func grantConfidential(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	return setConfidentialGrant(appState, caller, args, true)
+}
+
+func revokeConfidential(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	return setConfidentialGrant(appState, caller, args, false)
+}
+
+func setConfidentialGrant(appState AppState, caller *Account, args []byte,
+	granted bool) (output []byte, err error) {
+	confidentialState, err := getConfidentialState(appState)
+	if err != nil {
+		return nil, err
+	}
+	key, reader := returnTwoArgs(args)
+	confidentialState.SetConfidentialGrant(caller.Address, key, reader, granted)
+	return LeftPadWord256([]byte{1}).Bytes(), nil
+}
+
+func isConfidentialGranted(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	confidentialState, err := getConfidentialState(appState)
+	if err != nil {
+		return nil, err
+	}
+	owner, key, reader := returnThreeArgs(args)
+	granted := owner == reader ||
+		confidentialState.IsConfidentialGranted(owner, key, reader)
+	return LeftPadWord256([]byte{byteFromBool(granted)}).Bytes(), nil
+}
+
+func getConfidentialState(appState AppState) (ConfidentialState, error) {
+	confidentialState, ok := appState.(ConfidentialState)
+	if !ok {
+		return nil, fmt.Errorf("The confidential store is not available to this VM")
+	}
+	return confidentialState, nil
+}
+
+// BLS12381 function definitions
+
+// The length of each message signed in an aggregate verified by aggregateVerify
+const BLSAggregateMessageLength = 32
+
+func blsVerify(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	pubkey, message, signature, err := threeBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := useBLSGas(gas, 1, 0, 2); err != nil {
+		return nil, err
+	}
+	return returnBLSResult(bls12381.Verify(pubkey, message, signature))
+}
+
+func blsFastAggregateVerify(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	pubkeysBytes, message, signature, err := threeBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	pubkeys, err := splitBLSPubkeys(pubkeysBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := useBLSGas(gas, 1, len(pubkeys), 2); err != nil {
+		return nil, err
+	}
+	return returnBLSResult(bls12381.FastAggregateVerify(pubkeys, message, signature))
+}
+
+func blsAggregateVerify(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	pubkeysBytes, messagesBytes, signature, err := threeBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	pubkeys, err := splitBLSPubkeys(pubkeysBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(messagesBytes) != len(pubkeys)*BLSAggregateMessageLength {
+		return nil, fmt.Errorf("Messages are %v bytes but %v public keys sign "+
+			"%v bytes", len(messagesBytes), len(pubkeys),
+			len(pubkeys)*BLSAggregateMessageLength)
+	}
+	messages := make([][]byte, len(pubkeys))
+	for i := range messages {
+		messages[i] = messagesBytes[i*BLSAggregateMessageLength : (i+1)*BLSAggregateMessageLength]
+	}
+	if err := useBLSGas(gas, len(messages), 0, len(messages)+1); err != nil {
+		return nil, err
+	}
+	return returnBLSResult(bls12381.AggregateVerify(pubkeys, messages, signature))
+}
+
+func blsVerifyPossession(appState AppState, caller *Account, args []byte, gas *int64) (output []byte, err error) {
+	pubkey, err := bytesArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := bytesArg(args, 32)
+	if err != nil {
+		return nil, err
+	}
+	if err := useBLSGas(gas, 1, 0, 2); err != nil {
+		return nil, err
+	}
+	return returnBLSResult(bls12381.VerifyPossession(pubkey, proof))
+}
+
+// Takes the gas of hashing messages to G2, aggregating pubkeys public keys
+// and checking a pairing of pairs pairs
+func useBLSGas(gas *int64, messages, pubkeys, pairs int) error {
+	gasRequired := int64(messages)*GasBLS12381HashToG2 +
+		int64(pubkeys)*GasBLS12381AddPubkey + GasBLS12381PairingBase +
+		int64(pairs)*GasBLS12381PairingPoint
+	if *gas < gasRequired {
+		return ErrInsufficientGas
+	}
+	*gas -= gasRequired
+	return nil
+}
+
+func splitBLSPubkeys(pubkeysBytes []byte) ([][]byte, error) {
+	if len(pubkeysBytes) == 0 ||
+		len(pubkeysBytes)%bls12381.PubkeyLength != 0 {
+		return nil, fmt.Errorf("Public keys are %v bytes, which is not a "+
+			"positive multiple of %v bytes", len(pubkeysBytes),
+			bls12381.PubkeyLength)
+	}
+	pubkeys := make([][]byte, len(pubkeysBytes)/bls12381.PubkeyLength)
+	for i := range pubkeys {
+		pubkeys[i] = pubkeysBytes[i*bls12381.PubkeyLength : (i+1)*bls12381.PubkeyLength]
+	}
+	return pubkeys, nil
+}
+
+func returnBLSResult(valid bool, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return LeftPadWord256([]byte{byteFromBool(valid)}).Bytes(), nil
+}
+
+var permissionsContract = SNativeContracts()["Permissions"]
+
+// Gets the changes made by a successful call from granter to the Permissions
+// SNative with args that returned ret, for its audit events
+func permissionChanges(granter Word256, args, ret []byte) []txs.EventDataPermission {
+	function, err := permissionsContract.FunctionByID(firstFourBytes(args))
+	if err != nil {
+		return nil
+	}
+	args = args[abi.FunctionSelectorLength:]
+	change := txs.EventDataPermission{
+		Granter:  granter.Postfix(20),
+		Function: function.Name,
+	}
+	switch function.Name {
+	case "setBase":
+		addr, permNum, permVal := returnThreeArgs(args)
+		change.Address = addr.Postfix(20)
+		change.Permission = ptypes.PermFlag(Uint64FromWord256(permNum))
+		change.Value = !permVal.IsZero()
+	case "unsetBase":
+		addr, permNum := returnTwoArgs(args)
+		change.Address = addr.Postfix(20)
+		change.Permission = ptypes.PermFlag(Uint64FromWord256(permNum))
+	case "setGlobal":
+		permNum, permVal := returnTwoArgs(args)
+		change.Address = ptypes.GlobalPermissionsAddress
+		change.Permission = ptypes.PermFlag(Uint64FromWord256(permNum))
+		change.Value = !permVal.IsZero()
+	case "addRole", "removeRole":
+		// Only changes if the role was added or removed
+		if len(ret) == 0 || ret[len(ret)-1] == 0 {
+			return nil
+		}
+		addr, role := returnTwoArgs(args)
+		change.Address = addr.Postfix(20)
+		change.Role = strings.TrimRight(string(role.Bytes()), "\x00")
+	default:
+		return nil
+	}
+	return []txs.EventDataPermission{change}
+}
+
 //------------------------------------------------------------------------------------------------
 // Errors and utility funcs
 
@@ -502,6 +1010,38 @@ func returnThreeArgs(args []byte) (a Word256, b Word256, c Word256) {
 	return
 }
 
+// Reads the bytes argument whose offset is at head of args
+func bytesArg(args []byte, head int) ([]byte, error) {
+	offset := Uint64FromWord256(LeftPadWord256(args[head : head+32]))
+	if offset > uint64(len(args)-32) {
+		return nil, fmt.Errorf("Offset %v of bytes argument is out of range", offset)
+	}
+	length := Uint64FromWord256(LeftPadWord256(args[offset : offset+32]))
+	if length > uint64(len(args))-offset-32 {
+		return nil, fmt.Errorf("Length %v of bytes argument is out of range", length)
+	}
+	start := offset + 32
+	return args[start : start+length], nil
+}
+
+// Reads the three bytes arguments that are the only arguments
+func threeBytesArgs(args []byte) (a, b, c []byte, err error) {
+	if a, err = bytesArg(args, 0); err != nil {
+		return
+	}
+	if b, err = bytesArg(args, 32); err != nil {
+		return
+	}
+	c, err = bytesArg(args, 64)
+	return
+}
+
+// Encodes bytes returned as the only output
+func returnBytes(data []byte) []byte {
+	output := append(Uint64ToWord256(32).Bytes(), Uint64ToWord256(uint64(len(data))).Bytes()...)
+	return append(output, RightPadBytes(data, (len(data)+31)/32*32)...)
+}
+
 func byteFromBool(b bool) byte {
 	if b {
 		return 0x1
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/bls12381"
 	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
 	ptypes "github.com/hyperledger/burrow/permission/types"
@@ -94,6 +95,180 @@ func TestSNativeContractDescription_Dispatch(t *testing.T) {
 	assert.Equal(t, retValue, LeftPadBytes([]byte{1}, 32))
 }
 
+func TestConfidentialContract(t *testing.T) {
+	contract := SNativeContracts()["Confidential"]
+	state := &fakeConfidentialState{
+		FakeAppState: newAppState(),
+		blobs:        make(map[string][]byte),
+		grants:       make(map[string]bool),
+	}
+	owner := &Account{Address: addr(1, 1, 1), Permissions: allAccountPermissions()}
+	reader := &Account{Address: addr(2, 2, 2), Permissions: allAccountPermissions()}
+	key := LeftPadWord256([]byte("record"))
+	blob := []byte("an encrypted blob longer than a single word of 32 bytes")
+	dispatch := func(caller *Account, name string, inputs []*abi.Argument,
+		values ...interface{}) ([]byte, error) {
+		function := &abi.Function{Name: name, Inputs: inputs}
+		data, err := function.Pack(values...)
+		if err != nil {
+			t.Fatalf("Could not pack call to %s: %s", name, err)
+		}
+		gas := int64(1000)
+		return contract.Dispatch(state, caller, data, &gas)
+	}
+	get := func(caller *Account) ([]byte, error) {
+		ret, err := dispatch(caller, "get", []*abi.Argument{
+			{TypeName: abi.AddressTypeName}, {TypeName: abi.Bytes32TypeName}},
+			owner.Address.Postfix(20), key.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		var got []byte
+		function := &abi.Function{Name: "get",
+			Outputs: []*abi.Argument{{TypeName: abi.BytesTypeName}}}
+		values, err := function.Unpack(ret)
+		assert.NoError(t, err)
+		assert.NoError(t, abi.Assign(values, &got))
+		return got, nil
+	}
+	grantArgs := []*abi.Argument{{TypeName: abi.Bytes32TypeName}, {TypeName: abi.AddressTypeName}}
+
+	_, err := dispatch(owner, "put", []*abi.Argument{
+		{TypeName: abi.Bytes32TypeName}, {TypeName: abi.BytesTypeName}},
+		key.Bytes(), blob)
+	assert.NoError(t, err)
+	got, err := get(owner)
+	assert.NoError(t, err)
+	assert.Equal(t, blob, got)
+
+	// The reader may only get the blob once granted
+	_, err = get(reader)
+	assert.Error(t, err)
+	_, err = dispatch(owner, "grant", grantArgs, key.Bytes(), reader.Address.Postfix(20))
+	assert.NoError(t, err)
+	got, err = get(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, blob, got)
+	ret, err := dispatch(reader, "isGranted", []*abi.Argument{
+		{TypeName: abi.AddressTypeName}, {TypeName: abi.Bytes32TypeName},
+		{TypeName: abi.AddressTypeName}},
+		owner.Address.Postfix(20), key.Bytes(), reader.Address.Postfix(20))
+	assert.NoError(t, err)
+	assert.Equal(t, LeftPadBytes([]byte{1}, 32), ret)
+
+	// The reader cannot put a blob as the owner, only under keys of its own
+	_, err = dispatch(reader, "put", []*abi.Argument{
+		{TypeName: abi.Bytes32TypeName}, {TypeName: abi.BytesTypeName}},
+		key.Bytes(), []byte("forged"))
+	assert.NoError(t, err)
+	got, err = get(owner)
+	assert.NoError(t, err)
+	assert.Equal(t, blob, got)
+
+	_, err = dispatch(owner, "revoke", grantArgs, key.Bytes(), reader.Address.Postfix(20))
+	assert.NoError(t, err)
+	_, err = get(reader)
+	assert.Error(t, err)
+
+	// A blob is removed by putting an empty one
+	_, err = dispatch(owner, "put", []*abi.Argument{
+		{TypeName: abi.Bytes32TypeName}, {TypeName: abi.BytesTypeName}},
+		key.Bytes(), []byte{})
+	assert.NoError(t, err)
+	got, err = get(owner)
+	assert.NoError(t, err)
+	assert.Len(t, got, 0)
+
+	// Offsets outside the arguments are rejected
+	function, err := contract.FunctionByName("put")
+	assert.NoError(t, err)
+	funcID := function.ID()
+	gas := int64(1000)
+	_, err = contract.Dispatch(state, owner, Bytecode(funcID[:], key,
+		Uint64ToWord256(1<<20), Uint64ToWord256(1)), &gas)
+	assert.Error(t, err)
+}
+
+func TestBLS12381Contract(t *testing.T) {
+	contract := SNativeContracts()["BLS12381"]
+	caller := &Account{Address: addr(1, 1, 1), Permissions: allAccountPermissions()}
+	bytesArgs := func(n int) []*abi.Argument {
+		args := make([]*abi.Argument, n)
+		for i := range args {
+			args[i] = &abi.Argument{TypeName: abi.BytesTypeName}
+		}
+		return args
+	}
+	dispatch := func(name string, gas int64, values ...interface{}) ([]byte, error) {
+		function := &abi.Function{Name: name, Inputs: bytesArgs(len(values))}
+		data, err := function.Pack(values...)
+		if err != nil {
+			t.Fatalf("Could not pack call to %s: %s", name, err)
+		}
+		return contract.Dispatch(newAppState(), caller, data, &gas)
+	}
+	valid := LeftPadBytes([]byte{1}, 32)
+	invalid := LeftPadBytes([]byte{0}, 32)
+
+	var pubkeys, messages, signatures []byte
+	var sameMessageSignatures [][]byte
+	message := []byte("message")
+	for i := byte(1); i <= 2; i++ {
+		seckey := LeftPadBytes([]byte{i}, 32)
+		pubkey, err := bls12381.PubkeyFromSeckey(seckey)
+		assert.NoError(t, err)
+		aggregateMessage := LeftPadBytes([]byte{i}, BLSAggregateMessageLength)
+		signature, err := bls12381.Sign(seckey, aggregateMessage)
+		assert.NoError(t, err)
+		sameMessageSignature, err := bls12381.Sign(seckey, message)
+		assert.NoError(t, err)
+		pubkeys = append(pubkeys, pubkey...)
+		messages = append(messages, aggregateMessage...)
+		signatures = append(signatures, signature...)
+		sameMessageSignatures = append(sameMessageSignatures, sameMessageSignature)
+	}
+	pubkey := pubkeys[:bls12381.PubkeyLength]
+
+	ret, err := dispatch("verify", 1e6, pubkey, message, sameMessageSignatures[0])
+	assert.NoError(t, err)
+	assert.Equal(t, valid, ret)
+	ret, err = dispatch("verify", 1e6, pubkey, []byte("other"), sameMessageSignatures[0])
+	assert.NoError(t, err)
+	assert.Equal(t, invalid, ret)
+	_, err = dispatch("verify", 1e6, pubkey[1:], message, sameMessageSignatures[0])
+	assert.Error(t, err)
+	_, err = dispatch("verify", GasBLS12381PairingBase, pubkey, message,
+		sameMessageSignatures[0])
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	aggregate, err := bls12381.AggregateSignatures(sameMessageSignatures)
+	assert.NoError(t, err)
+	ret, err = dispatch("fastAggregateVerify", 1e6, pubkeys, message, aggregate)
+	assert.NoError(t, err)
+	assert.Equal(t, valid, ret)
+	ret, err = dispatch("fastAggregateVerify", 1e6, pubkey, message, aggregate)
+	assert.NoError(t, err)
+	assert.Equal(t, invalid, ret)
+
+	aggregate, err = bls12381.AggregateSignatures([][]byte{
+		signatures[:bls12381.SignatureLength], signatures[bls12381.SignatureLength:]})
+	assert.NoError(t, err)
+	ret, err = dispatch("aggregateVerify", 1e6, pubkeys, messages, aggregate)
+	assert.NoError(t, err)
+	assert.Equal(t, valid, ret)
+	_, err = dispatch("aggregateVerify", 1e6, pubkeys, messages[1:], aggregate)
+	assert.Error(t, err)
+
+	proof, err := bls12381.ProvePossession(LeftPadBytes([]byte{1}, 32))
+	assert.NoError(t, err)
+	ret, err = dispatch("verifyPossession", 1e6, pubkey, proof)
+	assert.NoError(t, err)
+	assert.Equal(t, valid, ret)
+	ret, err = dispatch("verifyPossession", 1e6, pubkey, sameMessageSignatures[0])
+	assert.NoError(t, err)
+	assert.Equal(t, invalid, ret)
+}
+
 func TestSNativeContractDescription_Address(t *testing.T) {
 	contract := NewSNativeContract("A comment",
 		"CoolButVeryLongNamedContractOfDoom")
@@ -155,3 +330,25 @@ func idToSignatureMap() map[string]string {
 	}
 	return sigMap
 }
+
+type fakeConfidentialState struct {
+	*FakeAppState
+	blobs  map[string][]byte
+	grants map[string]bool
+}
+
+func (fcs *fakeConfidentialState) GetConfidentialBlob(owner, key Word256) []byte {
+	return fcs.blobs[owner.String()+key.String()]
+}
+
+func (fcs *fakeConfidentialState) SetConfidentialBlob(owner, key Word256, blob []byte) {
+	fcs.blobs[owner.String()+key.String()] = blob
+}
+
+func (fcs *fakeConfidentialState) IsConfidentialGranted(owner, key, reader Word256) bool {
+	return fcs.grants[owner.String()+key.String()+reader.String()]
+}
+
+func (fcs *fakeConfidentialState) SetConfidentialGrant(owner, key, reader Word256, granted bool) {
+	fcs.grants[owner.String()+key.String()+reader.String()] = granted
+}
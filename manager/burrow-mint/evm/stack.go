@@ -27,20 +27,25 @@ type Stack struct {
 	data []Word256
 	ptr  int
 
-	gas *int64
-	err *error
+	gasPerOp int64
+	gas      *int64
+	err      *error
 }
 
-func NewStack(capacity int, gas *int64, err *error) *Stack {
+func NewStack(capacity int, gasPerOp int64, gas *int64, err *error) *Stack {
 	return &Stack{
-		data: make([]Word256, capacity),
-		ptr:  0,
-		gas:  gas,
-		err:  err,
+		data:     make([]Word256, capacity),
+		ptr:      0,
+		gasPerOp: gasPerOp,
+		gas:      gas,
+		err:      err,
 	}
 }
 
 func (st *Stack) useGas(gasToUse int64) {
+	if gasToUse == 0 {
+		return
+	}
 	if *st.gas > gasToUse {
 		*st.gas -= gasToUse
 	} else {
@@ -55,7 +60,7 @@ func (st *Stack) setErr(err error) {
 }
 
 func (st *Stack) Push(d Word256) {
-	st.useGas(GasStackOp)
+	st.useGas(st.gasPerOp)
 	if st.ptr == cap(st.data) {
 		st.setErr(ErrDataStackOverflow)
 		return
@@ -77,7 +82,7 @@ func (st *Stack) Push64(i int64) {
 }
 
 func (st *Stack) Pop() Word256 {
-	st.useGas(GasStackOp)
+	st.useGas(st.gasPerOp)
 	if st.ptr == 0 {
 		st.setErr(ErrDataStackUnderflow)
 		return Zero256
@@ -100,7 +105,7 @@ func (st *Stack) Len() int {
 }
 
 func (st *Stack) Swap(n int) {
-	st.useGas(GasStackOp)
+	st.useGas(st.gasPerOp)
 	if st.ptr < n {
 		st.setErr(ErrDataStackUnderflow)
 		return
@@ -109,7 +114,7 @@ func (st *Stack) Swap(n int) {
 }
 
 func (st *Stack) Dup(n int) {
-	st.useGas(GasStackOp)
+	st.useGas(st.gasPerOp)
 	if st.ptr < n {
 		st.setErr(ErrDataStackUnderflow)
 		return
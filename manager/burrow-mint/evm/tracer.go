@@ -0,0 +1,116 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	core_types "github.com/hyperledger/burrow/core/types"
+	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The most steps a tracer records by default
+const DefaultMaxTraceSteps = 100000
+
+// Records each step of EVM execution when set on a VM with SetTracer. Steps
+// beyond maxSteps are dropped so that a long running call cannot exhaust
+// memory.
+type Tracer struct {
+	trace    core_types.Trace
+	maxSteps int
+	// The step running at each call depth
+	current []*core_types.TraceStep
+}
+
+func NewTracer(maxSteps int) *Tracer {
+	return &Tracer{maxSteps: maxSteps}
+}
+
+func (tracer *Tracer) Trace() *core_types.Trace {
+	return &tracer.trace
+}
+
+func (tracer *Tracer) step(depth int, pc int64, op OpCode, gas int64, stack *Stack) {
+	for len(tracer.current) <= depth {
+		tracer.current = append(tracer.current, nil)
+	}
+	tracer.current[depth] = nil
+	if len(tracer.trace.Steps) >= tracer.maxSteps {
+		tracer.trace.Truncated = true
+		return
+	}
+	words := stack.data[:stack.ptr]
+	stackBytes := make([][]byte, len(words))
+	for i, word := range words {
+		stackBytes[i] = word.Bytes()
+	}
+	step := &core_types.TraceStep{
+		Depth: depth,
+		PC:    pc,
+		Op:    op.String(),
+		Gas:   gas,
+		Stack: stackBytes,
+	}
+	tracer.trace.Steps = append(tracer.trace.Steps, step)
+	tracer.current[depth] = step
+}
+
+// Get the step running at depth, or nil if it was not recorded
+func (tracer *Tracer) currentStep(depth int) *core_types.TraceStep {
+	if depth < len(tracer.current) {
+		return tracer.current[depth]
+	}
+	return nil
+}
+
+func (tracer *Tracer) memoryWrite(depth int, offset int64, data []byte) {
+	if step := tracer.currentStep(depth); step != nil {
+		step.Memory = append(step.Memory, &core_types.TraceMemoryWrite{
+			Offset: offset,
+			Data:   append([]byte{}, data...),
+		})
+	}
+}
+
+func (tracer *Tracer) storageWrite(depth int, address, key, value Word256) {
+	if step := tracer.currentStep(depth); step != nil {
+		step.Storage = append(step.Storage, &core_types.TraceStorageWrite{
+			Address: address.Postfix(20),
+			Key:     key.Bytes(),
+			Value:   value.Bytes(),
+		})
+	}
+}
+
+// Records the error the call at depth ended with against its last step
+func (tracer *Tracer) fail(depth int, err error) {
+	if step := tracer.currentStep(depth); step != nil && step.Error == "" {
+		step.Error = err.Error()
+	}
+}
+
+// Passes writes on to the memory of a call, recording them with the tracer
+type tracingMemory struct {
+	Memory
+	tracer *Tracer
+	depth  int
+}
+
+func (mem *tracingMemory) Write(offset int64, value []byte) error {
+	err := mem.Memory.Write(offset, value)
+	if err == nil {
+		mem.tracer.memoryWrite(mem.depth, offset, value)
+	}
+	return err
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
 )
 
@@ -50,11 +51,46 @@ type AppState interface {
 	UpdateAccount(*Account)
 	RemoveAccount(*Account)
 	CreateAccount(*Account) *Account
+	// Creates an account at a given address, as for CREATE2, returning nil if
+	// an account already exists there
+	CreateAccountAt(creator *Account, addr Word256) *Account
 
 	// Storage
 	GetStorage(Word256, Word256) Word256
 	SetStorage(Word256, Word256, Word256) // Setting to Zero is deleting.
 
+	// Snapshots, by which the changes of a call that fails are undone
+	// Returns an identifier for the current state of the accounts and storage
+	Snapshot() int
+	// Undoes the changes made since snapshot, including those made in place to
+	// the accounts returned, and those of the snapshots taken after it
+	RevertToSnapshot(snapshot int)
+}
+
+// The headers and storage of other chains relayed to this one, read by the
+// Relay SNative when the AppState provides them
+type RelayState interface {
+	// The height of the latest header of chainID relayed, 0 if none has been
+	GetRelayedHeight(chainID string) int
+	// The AppHash of the header of chainID relayed at height, nil if it has not
+	// been relayed
+	GetRelayedAppHash(chainID string, height int) []byte
+	// The storage of the account at addr on chainID proved against the header
+	// at height, and whether it has been proved
+	GetRelayedStorage(chainID string, height int, addr Word256, key Word256) (Word256, bool)
+}
+
+// The encrypted blobs of the confidential store, kept by the account that put
+// them and a key, and the accounts granted to read them, used by the
+// Confidential SNative when the AppState provides them
+type ConfidentialState interface {
+	// The blob owner put at key, nil if there is none
+	GetConfidentialBlob(owner, key Word256) []byte
+	// Puts blob at key of owner, removing the blob there when it is empty
+	SetConfidentialBlob(owner, key Word256, blob []byte)
+	// Whether owner granted reader to read the blob at key
+	IsConfidentialGranted(owner, key, reader Word256) bool
+	SetConfidentialGrant(owner, key, reader Word256, granted bool)
 }
 
 type Params struct {
@@ -62,4 +98,13 @@ type Params struct {
 	BlockHash   Word256
 	BlockTime   int64
 	GasLimit    int64
+	ChainID     Word256
+	// The DefaultGasSchedule when nil
+	GasSchedule *GasSchedule
+}
+
+// The value of the CHAINID opcode for a chain, which is the chain ID itself
+// when it is a number and the hash of it otherwise
+func ChainIDWord256(chainID string) Word256 {
+	return LeftPadWord256(txs.EthChainID(chainID).Bytes())
 }
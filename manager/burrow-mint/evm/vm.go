@@ -18,11 +18,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/hyperledger/burrow/common/sanity"
 	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/wasm"
 	ptypes "github.com/hyperledger/burrow/permission/types"
 	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
@@ -44,6 +46,8 @@ var (
 	ErrDataStackUnderflow     = errors.New("Data stack underflow")
 	ErrInvalidContract        = errors.New("Invalid contract")
 	ErrNativeContractCodeCopy = errors.New("Tried to copy native contract code")
+	ErrExecutionReverted      = errors.New("Execution reverted")
+	ErrExecutionInterrupted   = errors.New("Execution interrupted")
 )
 
 type ErrPermission struct {
@@ -77,20 +81,37 @@ type VM struct {
 	appState       AppState
 	memoryProvider func() Memory
 	params         Params
+	gasSchedule    *GasSchedule
 	origin         Word256
 	txid           []byte
+	// Gas refunded at the end of the outermost call
+	refund int64
+	// Permission changes fired at the end of the outermost call
+	pendingPermissionChanges []txs.EventDataPermission
+	// Logs fired at the end of the outermost call, so those of calls that fail
+	// never are
+	pendingLogs []pendingLog
 
 	callDepth int
 
 	evc events.Fireable
+	// Records execution when not nil
+	tracer *Tracer
+	// Stops execution when closed, if not nil
+	interrupt <-chan struct{}
 }
 
 func NewVM(appState AppState, memoryProvider func() Memory, params Params,
 	origin Word256, txid []byte) *VM {
+	gasSchedule := params.GasSchedule
+	if gasSchedule == nil {
+		gasSchedule = DefaultGasSchedule()
+	}
 	return &VM{
 		appState:       appState,
 		memoryProvider: memoryProvider,
 		params:         params,
+		gasSchedule:    gasSchedule,
 		origin:         origin,
 		callDepth:      0,
 		txid:           txid,
@@ -102,12 +123,23 @@ func (vm *VM) SetFireable(evc events.Fireable) {
 	vm.evc = evc
 }
 
+// Records each step of execution with tracer, or stops recording if it is nil
+func (vm *VM) SetTracer(tracer *Tracer) {
+	vm.tracer = tracer
+}
+
+// Stops execution with ErrExecutionInterrupted once interrupt is closed, such
+// as when a simulated call runs for too long
+func (vm *VM) SetInterrupt(interrupt <-chan struct{}) {
+	vm.interrupt = interrupt
+}
+
 // CONTRACT: it is the duty of the contract writer to call known permissions
 // we do not convey if a permission is not set
 // (unlike in state/execution, where we guarantee HasPermission is called
 // on known permissions and panics else)
-// If the perm is not defined in the acc nor set by default in GlobalPermissions,
-// this function returns false.
+// If the perm is not defined in the acc, its groups nor set by default in
+// GlobalPermissions, this function returns false.
 func HasPermission(appState AppState, acc *Account, perm ptypes.PermFlag) bool {
 	v, err := acc.Permissions.Base.Get(perm)
 	if _, ok := err.(ptypes.ErrValueNotSet); ok {
@@ -115,6 +147,16 @@ func HasPermission(appState AppState, acc *Account, perm ptypes.PermFlag) bool {
 			// In this case the permission is unknown
 			return false
 		}
+		v, err = acc.Permissions.GroupGet(func(group string) *ptypes.BasePermissions {
+			groupAcc := appState.GetAccount(LeftPadWord256(ptypes.GroupAddress(group)))
+			if groupAcc == nil {
+				return nil
+			}
+			return &groupAcc.Permissions.Base
+		}, perm)
+		if _, ok := err.(ptypes.ErrValueNotSet); !ok {
+			return v
+		}
 		return HasPermission(nil, appState.GetAccount(ptypes.GlobalPermissionsAddress256), perm)
 	}
 	return v
@@ -133,6 +175,32 @@ func (vm *VM) fireCallEvent(exception *string, output *[]byte, caller, callee *A
 	}
 }
 
+type pendingLog struct {
+	eventID string
+	log     txs.EventDataLog
+}
+
+func (vm *VM) fireLogs() {
+	if vm.evc != nil {
+		for _, pending := range vm.pendingLogs {
+			vm.evc.FireEvent(pending.eventID, pending.log)
+		}
+	}
+	vm.pendingLogs = nil
+}
+
+func (vm *VM) firePermissionChanges() {
+	if vm.evc != nil {
+		for _, change := range vm.pendingPermissionChanges {
+			change.Height = vm.params.BlockHeight
+			change.TxHash = vm.txid
+			vm.evc.FireEvent(txs.EventStringPermissionChange(), change)
+			vm.evc.FireEvent(txs.EventStringAccPermissionChange(change.Address), change)
+		}
+	}
+	vm.pendingPermissionChanges = nil
+}
+
 // CONTRACT appState is aware of caller and callee, so we can just mutate them.
 // CONTRACT code and input are not mutated.
 // CONTRACT returned 'ret' is a new compact slice.
@@ -151,16 +219,29 @@ func (vm *VM) Call(caller, callee *Account, code, input []byte, value int64, gas
 	}
 
 	if len(code) > 0 {
+		startGas, refund := *gas, vm.refund
+		changes, logs := len(vm.pendingPermissionChanges), len(vm.pendingLogs)
+		snapshot := vm.appState.Snapshot()
 		vm.callDepth += 1
 		output, err = vm.call(caller, callee, code, input, value, gas)
 		vm.callDepth -= 1
 		if err != nil {
-			*exception = err.Error()
+			// The changes of a failed call are undone, reverted or not, and
+			// its refunds and logs are lost with them
+			vm.appState.RevertToSnapshot(snapshot)
+			vm.refund = refund
+			vm.pendingPermissionChanges = vm.pendingPermissionChanges[:changes]
+			vm.pendingLogs = vm.pendingLogs[:logs]
+			*exception = RevertError(err, output).Error()
 			err := transfer(callee, caller, value)
 			if err != nil {
 				// data has been corrupted in ram
 				sanity.PanicCrisis("Could not return value to caller")
 			}
+		} else if vm.callDepth == 0 {
+			vm.useRefund(startGas, gas)
+			vm.firePermissionChanges()
+			vm.fireLogs()
 		}
 	}
 
@@ -182,11 +263,18 @@ func (vm *VM) DelegateCall(caller, callee *Account, code, input []byte, value in
 	// DelegateCall does not transfer the value to the callee.
 
 	if len(code) > 0 {
+		refund := vm.refund
+		changes, logs := len(vm.pendingPermissionChanges), len(vm.pendingLogs)
+		snapshot := vm.appState.Snapshot()
 		vm.callDepth += 1
 		output, err = vm.call(caller, callee, code, input, value, gas)
 		vm.callDepth -= 1
 		if err != nil {
-			*exception = err.Error()
+			vm.appState.RevertToSnapshot(snapshot)
+			vm.refund = refund
+			vm.pendingPermissionChanges = vm.pendingPermissionChanges[:changes]
+			vm.pendingLogs = vm.pendingLogs[:logs]
+			*exception = RevertError(err, output).Error()
 		}
 	}
 
@@ -205,23 +293,80 @@ func useGasNegative(gasLeft *int64, gasToUse int64, err *error) bool {
 	return true
 }
 
+// Charges for any memory the size bytes at offset are the first to use. If ok
+// return false, otherwise set err and return true.
+func (vm *VM) useMemoryGasNegative(memoryWords *int64, offset, size int64,
+	gasLeft *int64, err *error) bool {
+	gs := vm.gasSchedule
+	if size == 0 || (gs.MemoryWord == 0 && gs.MemoryQuadDivisor == 0) {
+		return false
+	}
+	// Leave out of bounds ranges to be rejected by the memory
+	if offset < 0 || size < 0 || offset > math.MaxInt32 || size > math.MaxInt32 {
+		return false
+	}
+	words := toWords(offset + size)
+	if words <= *memoryWords {
+		return false
+	}
+	gasToUse := gs.memoryCost(words) - gs.memoryCost(*memoryWords)
+	*memoryWords = words
+	return useGasNegative(gasLeft, gasToUse, err)
+}
+
+// Returns the refund accumulated by a call that started with startGas to gas,
+// up to the limit of the schedule
+func (vm *VM) useRefund(startGas int64, gas *int64) {
+	if vm.gasSchedule.MaxRefundQuotient > 0 {
+		refund := vm.refund
+		if maxRefund := (startGas - *gas) / vm.gasSchedule.MaxRefundQuotient; refund > maxRefund {
+			refund = maxRefund
+		}
+		*gas += refund
+	}
+	vm.refund = 0
+}
+
 // Just like Call() but does not transfer 'value' or modify the callDepth.
 func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas *int64) (output []byte, err error) {
+	if wasm.IsWASM(code) {
+		return vm.callWASM(caller, callee, code, input, value, gas)
+	}
 	dbg.Printf("(%d) (%X) %X (code=%d) gas: %v (d) %X\n", vm.callDepth, caller.Address[:4], callee.Address, len(callee.Code), *gas, input)
 
 	var (
 		pc     int64 = 0
-		stack        = NewStack(dataStackCapacity, gas, &err)
+		stack        = NewStack(dataStackCapacity, vm.gasSchedule.StackOp, gas, &err)
 		memory       = vm.memoryProvider()
+		// The words of memory paid for
+		memoryWords int64
 	)
+	if vm.tracer != nil {
+		memory = &tracingMemory{Memory: memory, tracer: vm.tracer, depth: vm.callDepth}
+		defer func() {
+			if err != nil {
+				vm.tracer.fail(vm.callDepth, RevertError(err, output))
+			}
+		}()
+	}
 
 	for {
-		// Use BaseOp gas.
-		if useGasNegative(gas, GasBaseOp, &err) {
+		if vm.interrupt != nil {
+			select {
+			case <-vm.interrupt:
+				return nil, ErrExecutionInterrupted
+			default:
+			}
+		}
+		var op = codeGetOp(code, pc)
+		if vm.tracer != nil {
+			vm.tracer.step(vm.callDepth, pc, op, *gas, stack)
+		}
+		// Use the static gas of the op
+		if useGasNegative(gas, vm.gasSchedule.Ops[op], &err) {
 			return nil, err
 		}
 
-		var op = codeGetOp(code, pc)
 		dbg.Printf("(pc) %-3d (op) %-14s (st) %-4d ", pc, op.String(), stack.Len())
 
 		switch op {
@@ -347,6 +492,9 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			x, y := stack.Pop(), stack.Pop()
 			xb := new(big.Int).SetBytes(x[:])
 			yb := new(big.Int).SetBytes(y[:])
+			if useGasNegative(gas, vm.gasSchedule.ExpByte*int64(len(yb.Bytes())), &err) {
+				return nil, err
+			}
 			pow := new(big.Int).Exp(xb, yb, big.NewInt(0))
 			res := LeftPadWord256(U256(pow).Bytes())
 			stack.Push(res)
@@ -484,11 +632,49 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			stack.Push64(int64(res))
 			dbg.Printf(" => 0x%X\n", res)
 
+		case SHL: // 0x1B
+			shift, x := stack.Pop(), stack.Pop()
+			res := Zero256
+			if shiftb := new(big.Int).SetBytes(shift[:]); shiftb.Cmp(big.NewInt(256)) < 0 {
+				xb := new(big.Int).SetBytes(x[:])
+				res = LeftPadWord256(U256(xb.Lsh(xb, uint(shiftb.Uint64()))).Bytes())
+			}
+			stack.Push(res)
+			dbg.Printf(" %X << %X = %X\n", x, shift, res)
+
+		case SHR: // 0x1C
+			shift, x := stack.Pop(), stack.Pop()
+			res := Zero256
+			if shiftb := new(big.Int).SetBytes(shift[:]); shiftb.Cmp(big.NewInt(256)) < 0 {
+				xb := new(big.Int).SetBytes(x[:])
+				res = LeftPadWord256(xb.Rsh(xb, uint(shiftb.Uint64())).Bytes())
+			}
+			stack.Push(res)
+			dbg.Printf(" %X >> %X = %X\n", x, shift, res)
+
+		case SAR: // 0x1D
+			shift, x := stack.Pop(), stack.Pop()
+			xb := S256(new(big.Int).SetBytes(x[:]))
+			// Shifting by 255 or more leaves just the sign
+			n := uint(255)
+			if shiftb := new(big.Int).SetBytes(shift[:]); shiftb.Cmp(big.NewInt(255)) < 0 {
+				n = uint(shiftb.Uint64())
+			}
+			res := LeftPadWord256(U256(xb.Rsh(xb, n)).Bytes())
+			stack.Push(res)
+			dbg.Printf(" %X >> %X = %X\n", x, shift, res)
+
 		case SHA3: // 0x20
-			if useGasNegative(gas, GasSha3, &err) {
+			if useGasNegative(gas, vm.gasSchedule.Sha3, &err) {
 				return nil, err
 			}
 			offset, size := stack.Pop64(), stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, offset, size, gas, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.Sha3Word*toWords(size), &err) {
+				return nil, err
+			}
 			data, memErr := memory.Read(offset, size)
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -504,7 +690,7 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case BALANCE: // 0x31
 			addr := stack.Pop()
-			if useGasNegative(gas, GasGetAccount, &err) {
+			if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
 				return nil, err
 			}
 			acc := vm.appState.GetAccount(addr)
@@ -545,6 +731,12 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			memOff := stack.Pop64()
 			inputOff := stack.Pop64()
 			length := stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, memOff, length, gas, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.CopyWord*toWords(length), &err) {
+				return nil, err
+			}
 			data, ok := subslice(input, inputOff, length)
 			if !ok {
 				return nil, firstErr(err, ErrInputOutOfBounds)
@@ -565,6 +757,12 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			memOff := stack.Pop64()
 			codeOff := stack.Pop64()
 			length := stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, memOff, length, gas, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.CopyWord*toWords(length), &err) {
+				return nil, err
+			}
 			data, ok := subslice(code, codeOff, length)
 			if !ok {
 				return nil, firstErr(err, ErrCodeOutOfBounds)
@@ -582,7 +780,7 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case EXTCODESIZE: // 0x3B
 			addr := stack.Pop()
-			if useGasNegative(gas, GasGetAccount, &err) {
+			if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
 				return nil, err
 			}
 			acc := vm.appState.GetAccount(addr)
@@ -600,7 +798,7 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			}
 		case EXTCODECOPY: // 0x3C
 			addr := stack.Pop()
-			if useGasNegative(gas, GasGetAccount, &err) {
+			if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
 				return nil, err
 			}
 			acc := vm.appState.GetAccount(addr)
@@ -615,6 +813,12 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			memOff := stack.Pop64()
 			codeOff := stack.Pop64()
 			length := stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, memOff, length, gas, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.CopyWord*toWords(length), &err) {
+				return nil, err
+			}
 			data, ok := subslice(code, codeOff, length)
 			if !ok {
 				return nil, firstErr(err, ErrCodeOutOfBounds)
@@ -626,6 +830,23 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			}
 			dbg.Printf(" => [%v, %v, %v] %X\n", memOff, codeOff, length, data)
 
+		case EXTCODEHASH: // 0x3F
+			addr := stack.Pop()
+			if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.Sha3, &err) {
+				return nil, err
+			}
+			// The hash of a non-existent account is zero, and that of an
+			// account without code is the hash of empty input
+			res := Zero256
+			if acc := vm.appState.GetAccount(addr); acc != nil {
+				res = LeftPadWord256(sha3.Sha3(acc.Code))
+			}
+			stack.Push(res)
+			dbg.Printf(" => %X (%X)\n", res, addr)
+
 		case BLOCKHASH: // 0x40
 			stack.Push(Zero256)
 			dbg.Printf(" => 0x%X (NOT SUPPORTED)\n", stack.Peek().Bytes())
@@ -648,12 +869,23 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			stack.Push64(vm.params.GasLimit)
 			dbg.Printf(" => %v\n", vm.params.GasLimit)
 
+		case CHAINID: // 0x46
+			stack.Push(vm.params.ChainID)
+			dbg.Printf(" => %X\n", vm.params.ChainID)
+
+		case SELFBALANCE: // 0x47
+			stack.Push64(callee.Balance)
+			dbg.Printf(" => %v\n", callee.Balance)
+
 		case POP: // 0x50
 			popped := stack.Pop()
 			dbg.Printf(" => 0x%X\n", popped)
 
 		case MLOAD: // 0x51
 			offset := stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, offset, 32, gas, &err) {
+				return nil, err
+			}
 			data, memErr := memory.Read(offset, 32)
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -664,6 +896,9 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case MSTORE: // 0x52
 			offset, data := stack.Pop64(), stack.Pop()
+			if vm.useMemoryGasNegative(&memoryWords, offset, 32, gas, &err) {
+				return nil, err
+			}
 			memErr := memory.Write(offset, data.Bytes())
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -673,6 +908,9 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case MSTORE8: // 0x53
 			offset, val := stack.Pop64(), byte(stack.Pop64()&0xFF)
+			if vm.useMemoryGasNegative(&memoryWords, offset, 1, gas, &err) {
+				return nil, err
+			}
 			memErr := memory.Write(offset, []byte{val})
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -688,10 +926,13 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case SSTORE: // 0x55
 			loc, data := stack.Pop(), stack.Pop()
-			if useGasNegative(gas, GasStorageUpdate, &err) {
+			if useGasNegative(gas, vm.storeGas(callee.Address, loc, data), &err) {
 				return nil, err
 			}
 			vm.appState.SetStorage(callee.Address, loc, data)
+			if vm.tracer != nil {
+				vm.tracer.storageWrite(vm.callDepth, callee.Address, loc, data)
+			}
 			dbg.Printf(" {0x%X : 0x%X}\n", loc, data)
 
 		case JUMP: // 0x56
@@ -759,6 +1000,12 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			for i := 0; i < n; i++ {
 				topics[i] = stack.Pop()
 			}
+			if vm.useMemoryGasNegative(&memoryWords, offset, size, gas, &err) {
+				return nil, err
+			}
+			if useGasNegative(gas, vm.gasSchedule.LogTopic*int64(n)+vm.gasSchedule.LogData*size, &err) {
+				return nil, err
+			}
 			data, memErr := memory.Read(offset, size)
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -773,16 +1020,19 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 					data,
 					vm.params.BlockHeight,
 				}
-				vm.evc.FireEvent(eventID, log)
+				vm.pendingLogs = append(vm.pendingLogs, pendingLog{eventID, log})
 			}
 			dbg.Printf(" => T:%X D:%X\n", topics, data)
 
-		case CREATE: // 0xF0
+		case CREATE, CREATE2: // 0xF0, 0xF5
 			if !HasPermission(vm.appState, callee, ptypes.CreateContract) {
 				return nil, ErrPermission{"create_contract"}
 			}
 			contractValue := stack.Pop64()
 			offset, size := stack.Pop64(), stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, offset, size, gas, &err) {
+				return nil, err
+			}
 			input, memErr := memory.Read(offset, size)
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -795,16 +1045,39 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			}
 
 			// TODO charge for gas to create account _ the code length * GasCreateByte
-			newAccount := vm.appState.CreateAccount(callee)
+			var newAccount *Account
+			if op == CREATE {
+				newAccount = vm.appState.CreateAccount(callee)
+			} else {
+				salt := stack.Pop()
+				if useGasNegative(gas, vm.gasSchedule.Sha3+vm.gasSchedule.Sha3Word*toWords(size), &err) {
+					return nil, err
+				}
+				// Nil if an account already exists at the address
+				newAccount = vm.appState.CreateAccountAt(callee,
+					Create2Address(callee.Address, salt, input))
+			}
 
-			// Run the input to get the contract code.
-			// NOTE: no need to copy 'input' as per Call contract.
-			ret, err_ := vm.Call(callee, newAccount, input, input, contractValue, gas)
-			if err_ != nil {
+			if newAccount == nil {
+				dbg.Printf(" => account already exists\n")
 				stack.Push(Zero256)
 			} else {
-				newAccount.Code = ret // Set the code (ret need not be copied as per Call contract)
-				stack.Push(newAccount.Address)
+				// Run the input to get the contract code.
+				// NOTE: no need to copy 'input' as per Call contract.
+				ret, err_ := vm.Call(callee, newAccount, input, input, contractValue, gas)
+				if err_ == nil {
+					// Pay to deploy the code, without which creation fails
+					var createErr error
+					if useGasNegative(gas, vm.gasSchedule.CreateData*int64(len(ret)), &createErr) {
+						err_ = createErr
+					}
+				}
+				if err_ != nil {
+					stack.Push(Zero256)
+				} else {
+					newAccount.Code = ret // Set the code (ret need not be copied as per Call contract)
+					stack.Push(newAccount.Address)
+				}
 			}
 
 		case CALL, CALLCODE, DELEGATECALL: // 0xF1, 0xF2, 0xF4
@@ -824,6 +1097,17 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			inOffset, inSize := stack.Pop64(), stack.Pop64()   // inputs
 			retOffset, retSize := stack.Pop64(), stack.Pop64() // outputs
 			dbg.Printf(" => %X\n", addr)
+			if vm.useMemoryGasNegative(&memoryWords, inOffset, inSize, gas, &err) {
+				return nil, err
+			}
+			if vm.useMemoryGasNegative(&memoryWords, retOffset, retSize, gas, &err) {
+				return nil, err
+			}
+			if op != DELEGATECALL && value > 0 {
+				if useGasNegative(gas, vm.gasSchedule.CallValue, &err) {
+					return nil, err
+				}
+			}
 
 			// Get the arguments from the memory
 			args, memErr := memory.Read(inOffset, inSize)
@@ -839,6 +1123,10 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 				*gas -= gasLimit
 				// NOTE: we will return any used gas later.
 			}
+			// The callee gets the stipend on top of the gas paid for
+			if op != DELEGATECALL && value > 0 {
+				gasLimit += vm.gasSchedule.CallStipend
+			}
 
 			// Begin execution
 			var ret []byte
@@ -846,6 +1134,10 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			if nativeContract := registeredNativeContracts[addr]; nativeContract != nil {
 				// Native contract
 				ret, err = nativeContract(vm.appState, callee, args, &gasLimit)
+				if err == nil && addr == permissionsContract.AddressWord256() {
+					vm.pendingPermissionChanges = append(vm.pendingPermissionChanges,
+						permissionChanges(callee.Address, args, ret)...)
+				}
 
 				// for now we fire the Call event. maybe later we'll fire more particulars
 				var exception string
@@ -856,7 +1148,7 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 				vm.fireCallEvent(&exception, &ret, callee, &Account{Address: addr}, args, value, &gasLimit)
 			} else {
 				// EVM contract
-				if useGasNegative(gas, GasGetAccount, &err) {
+				if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
 					return nil, err
 				}
 				acc := vm.appState.GetAccount(addr)
@@ -880,6 +1172,9 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 						if !HasPermission(vm.appState, caller, ptypes.CreateAccount) {
 							return nil, ErrPermission{"create_account"}
 						}
+						if value > 0 && useGasNegative(gas, vm.gasSchedule.CallNewAccount, &err) {
+							return nil, err
+						}
 						acc = &Account{Address: addr}
 					}
 					// add account to the tx cache
@@ -889,7 +1184,16 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			}
 
 			// Push result
-			if err != nil {
+			if err == ErrExecutionReverted {
+				dbg.Printf("call reverted\n")
+				stack.Push(Zero256)
+				// The output of REVERT is returned like that of RETURN
+				memErr := memory.Write(retOffset, RightPadBytes(ret, int(retSize)))
+				if memErr != nil {
+					dbg.Printf(" => Memory err: %s", memErr)
+					return nil, ErrMemoryOutOfBounds
+				}
+			} else if err != nil {
 				dbg.Printf("error on call: %s\n", err.Error())
 				stack.Push(Zero256)
 			} else {
@@ -912,6 +1216,9 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 
 		case RETURN: // 0xF3
 			offset, size := stack.Pop64(), stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, offset, size, gas, &err) {
+				return nil, err
+			}
 			output, memErr := memory.Read(offset, size)
 			if memErr != nil {
 				dbg.Printf(" => Memory err: %s", memErr)
@@ -920,9 +1227,22 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			dbg.Printf(" => [%v, %v] (%d) 0x%X\n", offset, size, len(output), output)
 			return output, nil
 
+		case REVERT: // 0xFD
+			offset, size := stack.Pop64(), stack.Pop64()
+			if vm.useMemoryGasNegative(&memoryWords, offset, size, gas, &err) {
+				return nil, err
+			}
+			output, memErr := memory.Read(offset, size)
+			if memErr != nil {
+				dbg.Printf(" => Memory err: %s", memErr)
+				return nil, firstErr(err, ErrMemoryOutOfBounds)
+			}
+			dbg.Printf(" => [%v, %v] (%d) 0x%X\n", offset, size, len(output), output)
+			return output, ErrExecutionReverted
+
 		case SELFDESTRUCT: // 0xFF
 			addr := stack.Pop()
-			if useGasNegative(gas, GasGetAccount, &err) {
+			if useGasNegative(gas, vm.gasSchedule.GetAccount, &err) {
 				return nil, err
 			}
 			// TODO if the receiver is , then make it the fee. (?)
@@ -935,6 +1255,7 @@ func (vm *VM) call(caller, callee *Account, code, input []byte, value int64, gas
 			receiver.Balance += balance
 			vm.appState.UpdateAccount(receiver)
 			vm.appState.RemoveAccount(callee)
+			vm.refund += vm.gasSchedule.SelfDestructRefund
 			dbg.Printf(" => (%X) %v\n", addr[:4], balance)
 			fallthrough
 
@@ -992,6 +1313,36 @@ func jump(code []byte, to int64, pc *int64) (err error) {
 	return nil
 }
 
+// The address of a contract created by CREATE2, which depends only on its
+// creator, the salt and the init code rather than the creator's nonce
+func Create2Address(creator, salt Word256, initCode []byte) Word256 {
+	temp := make([]byte, 0, 1+20+32+32)
+	temp = append(temp, 0xff)
+	temp = append(temp, creator.Postfix(20)...)
+	temp = append(temp, salt[:]...)
+	temp = append(temp, sha3.Sha3(initCode)...)
+	return LeftPadWord256(sha3.Sha3(temp)[12:])
+}
+
+// The gas for an SSTORE, adding to the refund when it clears storage
+func (vm *VM) storeGas(address, key, value Word256) int64 {
+	gs := vm.gasSchedule
+	if gs.SStoreSet == 0 && gs.SStoreReset == 0 {
+		return gs.StorageUpdate
+	}
+	current := vm.appState.GetStorage(address, key)
+	if current == Zero256 {
+		if value == Zero256 {
+			return gs.SStoreReset
+		}
+		return gs.SStoreSet
+	}
+	if value == Zero256 {
+		vm.refund += gs.SStoreClearRefund
+	}
+	return gs.SStoreReset
+}
+
 func firstErr(errA, errB error) error {
 	if errA != nil {
 		return errA
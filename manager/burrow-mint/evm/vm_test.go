@@ -18,17 +18,22 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"strings"
 	"testing"
 	"time"
 
 	"errors"
 
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
 	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
 	ptypes "github.com/hyperledger/burrow/permission/types"
 	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tendermint/go-events"
 )
 
@@ -120,6 +125,31 @@ func TestJumpErr(t *testing.T) {
 	}
 }
 
+func TestInterrupt(t *testing.T) {
+	ourVm := NewVM(newAppState(), DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	interrupt := make(chan struct{})
+	ourVm.SetInterrupt(interrupt)
+	account1 := &Account{Address: Int64ToWord256(100)}
+	account2 := &Account{Address: Int64ToWord256(101)}
+
+	var gas int64 = 1 << 62
+	// Loops until it runs out of gas, which would take a long time
+	code := Bytecode(JUMPDEST, PUSH1, 0, JUMP)
+	var err error
+	ch := make(chan struct{})
+	go func() {
+		_, err = ourVm.Call(account1, account2, code, []byte{}, 0, &gas)
+		ch <- struct{}{}
+	}()
+	close(interrupt)
+	select {
+	case <-time.After(time.Second * 2):
+		t.Fatal("VM was not interrupted")
+	case <-ch:
+		assert.Equal(t, ErrExecutionInterrupted, err)
+	}
+}
+
 // Tests the code for a subcurrency contract compiled by serpent
 func TestSubcurrency(t *testing.T) {
 	st := newAppState()
@@ -470,3 +500,245 @@ func TestConcat(t *testing.T) {
 		[]byte{0x01, 0x02, 0x03, 0x04},
 		Concat([]byte{0x01, 0x02}, []byte{0x03, 0x04}))
 }
+
+func TestShifts(t *testing.T) {
+	ourVm := NewVM(newAppState(), DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	account := &Account{Address: LeftPadWord256([]byte("shifter"))}
+	minusTwo := LeftPadWord256(U256(big.NewInt(-2)).Bytes())
+	minusOne := LeftPadWord256(U256(big.NewInt(-1)).Bytes())
+	tests := []struct {
+		op       OpCode
+		value    Word256
+		shift    int64
+		expected Word256
+	}{
+		{SHL, One256, 1, Int64ToWord256(2)},
+		{SHL, One256, 255, LeftPadWord256(Bytecode(0x80, make([]byte, 31)))},
+		{SHL, One256, 256, Zero256},
+		{SHL, minusOne, 1, minusTwo},
+		{SHR, Int64ToWord256(4), 2, One256},
+		{SHR, minusOne, 255, One256},
+		{SHR, minusOne, 256, Zero256},
+		{SAR, Int64ToWord256(4), 1, Int64ToWord256(2)},
+		{SAR, minusTwo, 1, minusOne},
+		{SAR, minusTwo, 300, minusOne},
+		{SAR, Int64ToWord256(4), 300, Zero256},
+	}
+	for _, test := range tests {
+		var gas int64 = 1000
+		code := Bytecode(PUSH32, test.value, pushInt64(test.shift), test.op, return1())
+		output, err := ourVm.Call(account, account, code, nil, 0, &gas)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected.Bytes(), output, "%v %X by %v", test.op,
+			test.value, test.shift)
+	}
+}
+
+func TestChainIDAndSelfBalance(t *testing.T) {
+	params := newParams()
+	params.ChainID = ChainIDWord256("1234")
+	assert.Equal(t, Int64ToWord256(1234), params.ChainID)
+	assert.Equal(t, LeftPadWord256(sha3.Sha3([]byte("burrow-chain"))),
+		ChainIDWord256("burrow-chain"))
+
+	ourVm := NewVM(newAppState(), DefaultDynamicMemoryProvider, params, Zero256, nil)
+	account := &Account{Address: LeftPadWord256([]byte("account")), Balance: 42}
+	var gas int64 = 1000
+	output, err := ourVm.Call(account, account, Bytecode(CHAINID, return1()), nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Int64ToWord256(1234).Bytes(), output)
+
+	output, err = ourVm.Call(account, account, Bytecode(SELFBALANCE, return1()), nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Int64ToWord256(42).Bytes(), output)
+}
+
+func TestExtCodeHash(t *testing.T) {
+	appState := newAppState()
+	ourVm := NewVM(appState, DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	account, _ := makeAccountWithCode(appState, "account", Bytecode(STOP))
+	emptyAccount, emptyAddress := makeAccountWithCode(appState, "empty", nil)
+
+	var gas int64 = 1000
+	code := Bytecode(PUSH32, account.Address, EXTCODEHASH, return1())
+	output, err := ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, sha3.Sha3(account.Code), output)
+
+	code = Bytecode(PUSH20, emptyAddress, EXTCODEHASH, return1())
+	output, err = ourVm.Call(account, emptyAccount, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, sha3.Sha3(nil), output)
+
+	code = Bytecode(PUSH20, makeBytes(20), EXTCODEHASH, return1())
+	output, err = ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Zero256.Bytes(), output)
+}
+
+func TestCreate2(t *testing.T) {
+	// Examples from EIP-1014
+	assert.Equal(t, "4D1A2E2BB4F88F0250F26FFFF098B0B30B26BF38",
+		fmt.Sprintf("%X", Create2Address(Zero256, Zero256, []byte{0}).Postfix(20)))
+	deadbeef, _ := hex.DecodeString("deadbeef")
+	assert.Equal(t, "60F3F640A8508FC6A86D45DF051962668E1E8AC7",
+		fmt.Sprintf("%X", Create2Address(LeftPadWord256(deadbeef),
+			Int64ToWord256(0xcafebabe), deadbeef).Postfix(20)))
+
+	appState := newAppState()
+	ourVm := NewVM(appState, DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	creator, _ := makeAccountWithCode(appState, "creator", nil)
+	// Returns the one byte contract 0x2a
+	initCode := Bytecode(PUSH1, 0x2a, PUSH1, 0, MSTORE8, PUSH1, 1, PUSH1, 0, RETURN)
+	salt := Int64ToWord256(7)
+	code := Bytecode(PUSH10, initCode, PUSH1, 0, MSTORE,
+		PUSH32, salt, PUSH1, len(initCode), PUSH1, 32-len(initCode), PUSH1, 0,
+		CREATE2, return1())
+	expected := Create2Address(creator.Address, salt, initCode)
+
+	var gas int64 = 1000
+	output, err := ourVm.Call(creator, creator, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Bytes(), output)
+	assert.Equal(t, int64(1), creator.Nonce)
+
+	// The address is taken
+	appState.UpdateAccount(&Account{Address: expected})
+	output, err = ourVm.Call(creator, creator, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Zero256.Bytes(), output)
+}
+
+func TestTracer(t *testing.T) {
+	ourVm := NewVM(newAppState(), DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	account := &Account{Address: LeftPadWord256([]byte("traced"))}
+	tracer := NewTracer(DefaultMaxTraceSteps)
+	ourVm.SetTracer(tracer)
+
+	var gas int64 = 100000
+	code := Bytecode(PUSH1, 5, PUSH1, 0, MSTORE, PUSH1, 7, PUSH1, 1, SSTORE, PUSH1, 0x20, JUMP)
+	_, err := ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.Equal(t, ErrInvalidJumpDest, err)
+
+	trace := tracer.Trace()
+	assert.False(t, trace.Truncated)
+	if !assert.Len(t, trace.Steps, 8) {
+		return
+	}
+	mstore := trace.Steps[2]
+	assert.Equal(t, "MSTORE", mstore.Op)
+	assert.Equal(t, int64(4), mstore.PC)
+	assert.Equal(t, [][]byte{Int64ToWord256(5).Bytes(), Zero256.Bytes()}, mstore.Stack)
+	assert.Equal(t, []*core_types.TraceMemoryWrite{{0, Int64ToWord256(5).Bytes()}},
+		mstore.Memory)
+
+	sstore := trace.Steps[5]
+	assert.Equal(t, "SSTORE", sstore.Op)
+	assert.Equal(t, []*core_types.TraceStorageWrite{{account.Address.Postfix(20),
+		One256.Bytes(), Int64ToWord256(7).Bytes()}}, sstore.Storage)
+	assert.True(t, sstore.Gas < mstore.Gas)
+
+	jump := trace.Steps[7]
+	assert.Equal(t, "JUMP", jump.Op)
+	assert.Equal(t, ErrInvalidJumpDest.Error(), jump.Error)
+	for _, step := range trace.Steps[:7] {
+		assert.Equal(t, 1, step.Depth)
+		assert.Empty(t, step.Error)
+	}
+
+	// Steps beyond the maximum are dropped
+	tracer = NewTracer(3)
+	ourVm.SetTracer(tracer)
+	gas = 100000
+	_, err = ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.Len(t, tracer.Trace().Steps, 3)
+	assert.True(t, tracer.Trace().Truncated)
+}
+
+func TestRevert(t *testing.T) {
+	appState := newAppState()
+	ourVm := NewVM(appState, DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	account, _ := makeAccountWithCode(appState, "account", nil)
+
+	reason := "Not enough"
+	output := Bytecode(revertReasonSelector, Int64ToWord256(32),
+		Int64ToWord256(int64(len(reason))), RightPadWord256([]byte(reason)))
+	code := Bytecode(PUSH32, RightPadWord256(revertReasonSelector), PUSH1, 0, MSTORE,
+		PUSH1, 32, PUSH1, 4, MSTORE,
+		PUSH1, len(reason), PUSH1, 36, MSTORE,
+		PUSH32, RightPadWord256([]byte(reason)), PUSH1, 68, MSTORE,
+		PUSH1, len(output), PUSH1, 0, REVERT)
+	reverter, reverterAddress := makeAccountWithCode(appState, "reverter", code)
+
+	var gas int64 = 100000
+	ret, err := ourVm.Call(account, reverter, reverter.Code, nil, 0, &gas)
+	assert.Equal(t, ErrExecutionReverted, err)
+	assert.Equal(t, output, ret)
+	assert.EqualError(t, RevertError(err, ret), "Execution reverted: Not enough")
+	assert.Equal(t, ErrInsufficientGas, RevertError(ErrInsufficientGas, ret))
+
+	// A contract calling the reverter gets its output and a 0 result
+	code = Bytecode(PUSH1, len(output), PUSH1, 0, PUSH1, 0, PUSH1, 0, PUSH1, 0,
+		PUSH20, reverterAddress, PUSH2, 0x10, 0, CALL,
+		PUSH1, len(output), MSTORE, PUSH1, len(output)+32, PUSH1, 0, RETURN)
+	ret, err = ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Bytecode(output, Zero256), ret)
+
+	// The storage, accounts and balances a reverted call changed are restored
+	inner, innerAddress := makeAccountWithCode(appState, "inner",
+		Bytecode(PUSH1, 1, PUSH1, 0, SSTORE,
+			PUSH1, 0, PUSH1, 0, PUSH1, 3, CREATE,
+			PUSH1, 0, PUSH1, 0, REVERT))
+	created := createAddress(&Account{Address: inner.Address})
+	code = Bytecode(PUSH1, 0, PUSH1, 0, PUSH1, 0, PUSH1, 0, PUSH1, 0,
+		PUSH20, innerAddress, PUSH3, 1, 0, 0, CALL, return1())
+	gas = 100000
+	ret, err = ourVm.Call(account, account, code, nil, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, Zero256.Bytes(), ret)
+	assert.Equal(t, Zero256, appState.GetStorage(inner.Address, Zero256))
+	assert.Nil(t, appState.GetAccount(created))
+	assert.Equal(t, int64(0), inner.Nonce)
+	assert.Equal(t, int64(9999999), inner.Balance)
+}
+
+func TestRevertReason(t *testing.T) {
+	output := Bytecode(revertReasonSelector, Int64ToWord256(32), Int64ToWord256(3),
+		RightPadWord256([]byte("bad")))
+	reason, ok := RevertReason(output)
+	assert.True(t, ok)
+	assert.Equal(t, "bad", reason)
+
+	for _, output := range [][]byte{
+		nil,
+		output[:len(output)-30],
+		Bytecode(Int64ToWord256(1), Int64ToWord256(32), Int64ToWord256(3)),
+		Bytecode(revertReasonSelector, Int64ToWord256(1<<40), Int64ToWord256(3)),
+		Bytecode(revertReasonSelector, Int64ToWord256(32), LeftPadWord256([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})),
+	} {
+		_, ok := RevertReason(output)
+		assert.False(t, ok, "%X", output)
+	}
+}
+
+func TestDecodeRevertError(t *testing.T) {
+	contractABI, err := abi.ReadABI([]byte(`[{"type": "error", "name": "InsufficientBalance",
+		"inputs": [{"name": "needed", "type": "uint256"}]}]`))
+	require.NoError(t, err)
+	output := Bytecode(sha3.Sha3([]byte("InsufficientBalance(uint256)"))[:4], Int64ToWord256(10))
+	err = RevertError(ErrExecutionReverted, output)
+	assert.EqualError(t, err, fmt.Sprintf("Execution reverted with data %X", output))
+	assert.EqualError(t, DecodeRevertError(fmt.Errorf("Call failed: %s!", err), contractABI),
+		"Call failed: Execution reverted: InsufficientBalance(needed: 10)!")
+
+	// A failed assert
+	output = Bytecode(sha3.Sha3([]byte("Panic(uint256)"))[:4], Int64ToWord256(1))
+	assert.EqualError(t, DecodeRevertError(RevertError(ErrExecutionReverted, output), nil),
+		"Execution reverted: Panic 0x1: assertion failed")
+
+	assert.Equal(t, ErrExecutionReverted, RevertError(ErrExecutionReverted, nil))
+	reason := fmt.Errorf("Execution reverted: Not enough")
+	assert.Equal(t, reason, DecodeRevertError(reason, contractABI))
+	assert.Nil(t, DecodeRevertError(nil, contractABI))
+}
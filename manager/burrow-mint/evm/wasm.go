@@ -0,0 +1,576 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/wasm"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// WASM contracts are run through the ewasm Ethereum Environment Interface
+// (EEI), which they import from this module. A contract exports its memory
+// and a main function that ends by calling finish or revert, or by returning.
+// As with EVM code, running the code sent to create a contract returns the
+// code of the contract.
+const (
+	ewasmModule       = "ethereum"
+	ewasmMainFunction = "main"
+	ewasmMemory       = "memory"
+
+	// Values are 128 bit little endian integers in the EEI
+	ewasmValueLength   = 16
+	ewasmAddressLength = 20
+)
+
+var (
+	// Returned by the EEI functions that halt the contract
+	errWASMFinish = errors.New("WASM contract finished")
+	errWASMRevert = errors.New("WASM contract reverted")
+)
+
+// The outcome of an EEI call, as returned by call, callCode, callDelegate
+// and create
+const (
+	ewasmCallSuccess uint64 = 0
+	ewasmCallFailure uint64 = 1
+	ewasmCallRevert  uint64 = 2
+)
+
+// The state of the execution of one WASM contract
+type wasmContext struct {
+	vm             *VM
+	caller, callee *Account
+	code, input    []byte
+	value          int64
+	gas            *int64
+	output         []byte
+	// The output of the last call made by the contract
+	returnData []byte
+}
+
+type eeiFunction struct {
+	typ  wasm.FunctionType
+	call func(ctx *wasmContext, inst *wasm.Instance, args []uint64) ([]uint64, error)
+}
+
+func valueTypes(types ...wasm.ValueType) []wasm.ValueType {
+	return types
+}
+
+// The EEI functions by name, which are set in init since they can call
+// contracts that use them in turn
+var eeiFunctions map[string]eeiFunction
+
+func init() {
+	i32, i64 := wasm.I32, wasm.I64
+	eeiFunctions = map[string]eeiFunction{
+		"useGas":             {wasm.FunctionType{Params: valueTypes(i64)}, (*wasmContext).useGas},
+		"getGasLeft":         {wasm.FunctionType{Results: valueTypes(i64)}, (*wasmContext).getGasLeft},
+		"getAddress":         {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getAddress},
+		"getExternalBalance": {wasm.FunctionType{Params: valueTypes(i32, i32)}, (*wasmContext).getExternalBalance},
+		"getBlockHash":       {wasm.FunctionType{Params: valueTypes(i64, i32), Results: valueTypes(i32)}, (*wasmContext).getBlockHash},
+		"getBlockCoinbase":   {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getBlockCoinbase},
+		"getBlockGasLimit":   {wasm.FunctionType{Results: valueTypes(i64)}, (*wasmContext).getBlockGasLimit},
+		"getBlockNumber":     {wasm.FunctionType{Results: valueTypes(i64)}, (*wasmContext).getBlockNumber},
+		"getBlockTimestamp":  {wasm.FunctionType{Results: valueTypes(i64)}, (*wasmContext).getBlockTimestamp},
+		"getTxGasPrice":      {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getTxGasPrice},
+		"getTxOrigin":        {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getTxOrigin},
+		"getCaller":          {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getCaller},
+		"getCallValue":       {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).getCallValue},
+		"getCallDataSize":    {wasm.FunctionType{Results: valueTypes(i32)}, (*wasmContext).getCallDataSize},
+		"callDataCopy":       {wasm.FunctionType{Params: valueTypes(i32, i32, i32)}, (*wasmContext).callDataCopy},
+		"getCodeSize":        {wasm.FunctionType{Results: valueTypes(i32)}, (*wasmContext).getCodeSize},
+		"codeCopy":           {wasm.FunctionType{Params: valueTypes(i32, i32, i32)}, (*wasmContext).codeCopy},
+		"getExternalCodeSize": {wasm.FunctionType{Params: valueTypes(i32), Results: valueTypes(i32)},
+			(*wasmContext).getExternalCodeSize},
+		"externalCodeCopy":  {wasm.FunctionType{Params: valueTypes(i32, i32, i32, i32)}, (*wasmContext).externalCodeCopy},
+		"storageStore":      {wasm.FunctionType{Params: valueTypes(i32, i32)}, (*wasmContext).storageStore},
+		"storageLoad":       {wasm.FunctionType{Params: valueTypes(i32, i32)}, (*wasmContext).storageLoad},
+		"log":               {wasm.FunctionType{Params: valueTypes(i32, i32, i32, i32, i32, i32, i32)}, (*wasmContext).log},
+		"getReturnDataSize": {wasm.FunctionType{Results: valueTypes(i32)}, (*wasmContext).getReturnDataSize},
+		"returnDataCopy":    {wasm.FunctionType{Params: valueTypes(i32, i32, i32)}, (*wasmContext).returnDataCopy},
+		"call": {wasm.FunctionType{Params: valueTypes(i64, i32, i32, i32, i32), Results: valueTypes(i32)},
+			(*wasmContext).callContract},
+		"callCode": {wasm.FunctionType{Params: valueTypes(i64, i32, i32, i32, i32), Results: valueTypes(i32)},
+			(*wasmContext).callCode},
+		"callDelegate": {wasm.FunctionType{Params: valueTypes(i64, i32, i32, i32), Results: valueTypes(i32)},
+			(*wasmContext).callDelegate},
+		"create": {wasm.FunctionType{Params: valueTypes(i32, i32, i32, i32), Results: valueTypes(i32)},
+			(*wasmContext).create},
+		"finish":       {wasm.FunctionType{Params: valueTypes(i32, i32)}, (*wasmContext).finish},
+		"revert":       {wasm.FunctionType{Params: valueTypes(i32, i32)}, (*wasmContext).revert},
+		"selfDestruct": {wasm.FunctionType{Params: valueTypes(i32)}, (*wasmContext).selfDestruct},
+	}
+}
+
+// Runs WASM code as callee, as call does EVM code
+func (vm *VM) callWASM(caller, callee *Account, code, input []byte, value int64, gas *int64) (output []byte, err error) {
+	dbg.Printf("(%d) (%X) %X (WASM code=%d) gas: %v (d) %X\n", vm.callDepth, caller.Address[:4], callee.Address, len(code), *gas, input)
+	module, err := wasm.DecodeModule(code)
+	if err != nil {
+		return nil, err
+	}
+	if export, ok := module.Exports[ewasmMemory]; !ok || export.Kind != wasm.ExternalMemory {
+		return nil, fmt.Errorf("WASM contract must export its memory as %s", ewasmMemory)
+	}
+	ctx := &wasmContext{
+		vm:     vm,
+		caller: caller,
+		callee: callee,
+		code:   code,
+		input:  input,
+		value:  value,
+		gas:    gas,
+	}
+	instance, err := wasm.Instantiate(module, ctx.resolve, gas)
+	if err != nil {
+		return nil, err
+	}
+	instance.SetInterrupt(vm.interrupt)
+	_, err = instance.Invoke(ewasmMainFunction)
+	switch err {
+	case nil, errWASMFinish:
+		dbg.Printf(" => WASM finished (%d) 0x%X\n", len(ctx.output), ctx.output)
+		return ctx.output, nil
+	case errWASMRevert:
+		dbg.Printf(" => WASM reverted (%d) 0x%X\n", len(ctx.output), ctx.output)
+		return ctx.output, ErrExecutionReverted
+	case wasm.ErrInsufficientGas:
+		return nil, ErrInsufficientGas
+	case wasm.ErrInterrupted:
+		return nil, ErrExecutionInterrupted
+	default:
+		dbg.Printf(" => WASM error: %s\n", err)
+		return nil, err
+	}
+}
+
+func (ctx *wasmContext) resolve(module, name string, typ wasm.FunctionType) (wasm.HostFunction, error) {
+	if module != ewasmModule {
+		return nil, fmt.Errorf("WASM contracts can only import from %s, not %s",
+			ewasmModule, module)
+	}
+	function, ok := eeiFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported EEI function %s", name)
+	}
+	if !typ.Equal(function.typ) {
+		return nil, fmt.Errorf("EEI function %s has type %v, not %v", name,
+			function.typ, typ)
+	}
+	return func(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+		return function.call(ctx, inst, args)
+	}, nil
+}
+
+func (ctx *wasmContext) useGasNegative(amount int64) error {
+	var err error
+	useGasNegative(ctx.gas, amount, &err)
+	return err
+}
+
+func (ctx *wasmContext) useGas(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, ctx.useGasNegative(int64(args[0]))
+}
+
+func (ctx *wasmContext) getGasLeft(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(*ctx.gas)}, nil
+}
+
+func (ctx *wasmContext) getAddress(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), ctx.callee.Address.Postfix(ewasmAddressLength))
+}
+
+func (ctx *wasmContext) getExternalBalance(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.useGasNegative(ctx.vm.gasSchedule.GetAccount); err != nil {
+		return nil, err
+	}
+	address, err := readAddress(inst, args[0])
+	if err != nil {
+		return nil, err
+	}
+	var balance int64
+	if acc := ctx.vm.appState.GetAccount(address); acc != nil {
+		balance = acc.Balance
+	}
+	return nil, inst.WriteMemory(uint32(args[1]), encodeValue(balance))
+}
+
+func (ctx *wasmContext) getBlockHash(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	// Block hashes are not available to the EVM either
+	return []uint64{ewasmCallFailure}, nil
+}
+
+func (ctx *wasmContext) getBlockCoinbase(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), make([]byte, ewasmAddressLength))
+}
+
+func (ctx *wasmContext) getBlockGasLimit(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(ctx.vm.params.GasLimit)}, nil
+}
+
+func (ctx *wasmContext) getBlockNumber(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(ctx.vm.params.BlockHeight)}, nil
+}
+
+func (ctx *wasmContext) getBlockTimestamp(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(ctx.vm.params.BlockTime)}, nil
+}
+
+func (ctx *wasmContext) getTxGasPrice(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), encodeValue(0))
+}
+
+func (ctx *wasmContext) getTxOrigin(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), ctx.vm.origin.Postfix(ewasmAddressLength))
+}
+
+func (ctx *wasmContext) getCaller(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), ctx.caller.Address.Postfix(ewasmAddressLength))
+}
+
+func (ctx *wasmContext) getCallValue(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, inst.WriteMemory(uint32(args[0]), encodeValue(ctx.value))
+}
+
+func (ctx *wasmContext) getCallDataSize(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(len(ctx.input))}, nil
+}
+
+func (ctx *wasmContext) callDataCopy(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, copyToMemory(inst, args[0], ctx.input, args[1], args[2], ErrInputOutOfBounds)
+}
+
+func (ctx *wasmContext) getCodeSize(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(len(ctx.code))}, nil
+}
+
+func (ctx *wasmContext) codeCopy(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, copyToMemory(inst, args[0], ctx.code, args[1], args[2], ErrCodeOutOfBounds)
+}
+
+func (ctx *wasmContext) getExternalCodeSize(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	acc, err := ctx.getExternalAccount(inst, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return []uint64{uint64(len(acc.Code))}, nil
+}
+
+func (ctx *wasmContext) externalCodeCopy(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	acc, err := ctx.getExternalAccount(inst, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, copyToMemory(inst, args[1], acc.Code, args[2], args[3], ErrCodeOutOfBounds)
+}
+
+func (ctx *wasmContext) getExternalAccount(inst *wasm.Instance, addressOffset uint64) (*Account, error) {
+	if err := ctx.useGasNegative(ctx.vm.gasSchedule.GetAccount); err != nil {
+		return nil, err
+	}
+	address, err := readAddress(inst, addressOffset)
+	if err != nil {
+		return nil, err
+	}
+	acc := ctx.vm.appState.GetAccount(address)
+	if acc == nil {
+		return nil, ErrUnknownAddress
+	}
+	return acc, nil
+}
+
+func (ctx *wasmContext) storageStore(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := inst.ReadMemory(uint32(args[0]), 32)
+	if err != nil {
+		return nil, err
+	}
+	value, err := inst.ReadMemory(uint32(args[1]), 32)
+	if err != nil {
+		return nil, err
+	}
+	gasToUse := ctx.vm.storeGas(ctx.callee.Address, LeftPadWord256(key), LeftPadWord256(value))
+	if err := ctx.useGasNegative(gasToUse); err != nil {
+		return nil, err
+	}
+	ctx.vm.appState.SetStorage(ctx.callee.Address, LeftPadWord256(key), LeftPadWord256(value))
+	dbg.Printf(" WASM storageStore {0x%X : 0x%X}\n", key, value)
+	return nil, nil
+}
+
+func (ctx *wasmContext) storageLoad(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := inst.ReadMemory(uint32(args[0]), 32)
+	if err != nil {
+		return nil, err
+	}
+	value := ctx.vm.appState.GetStorage(ctx.callee.Address, LeftPadWord256(key))
+	dbg.Printf(" WASM storageLoad {0x%X : 0x%X}\n", key, value)
+	return nil, inst.WriteMemory(uint32(args[1]), value.Bytes())
+}
+
+func (ctx *wasmContext) log(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	data, err := inst.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	n := uint32(args[2])
+	if n > 4 {
+		return nil, fmt.Errorf("A log has at most 4 topics, not %v", n)
+	}
+	topics := make([]Word256, n)
+	for i := range topics {
+		topic, err := inst.ReadMemory(uint32(args[3+i]), 32)
+		if err != nil {
+			return nil, err
+		}
+		topics[i] = LeftPadWord256(topic)
+	}
+	if ctx.vm.evc != nil {
+		eventID := txs.EventStringLogEvent(ctx.callee.Address.Postfix(20))
+		ctx.vm.evc.FireEvent(eventID, txs.EventDataLog{
+			ctx.callee.Address,
+			topics,
+			data,
+			ctx.vm.params.BlockHeight,
+		})
+	}
+	dbg.Printf(" WASM log => T:%X D:%X\n", topics, data)
+	return nil, nil
+}
+
+func (ctx *wasmContext) getReturnDataSize(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(len(ctx.returnData))}, nil
+}
+
+func (ctx *wasmContext) returnDataCopy(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, copyToMemory(inst, args[0], ctx.returnData, args[1], args[2], ErrInputOutOfBounds)
+}
+
+func (ctx *wasmContext) callContract(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	value, err := readValue(inst, args[2])
+	if err != nil {
+		return nil, err
+	}
+	return ctx.callAccount(inst, "call", args[0], args[1], value, args[3], args[4])
+}
+
+func (ctx *wasmContext) callCode(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	value, err := readValue(inst, args[2])
+	if err != nil {
+		return nil, err
+	}
+	return ctx.callAccount(inst, "callCode", args[0], args[1], value, args[3], args[4])
+}
+
+func (ctx *wasmContext) callDelegate(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	// As for DELEGATECALL the value is kept from the original call
+	return ctx.callAccount(inst, "callDelegate", args[0], args[1], ctx.value, args[2], args[3])
+}
+
+// Calls an account like the EVM CALL, CALLCODE and DELEGATECALL which
+// call, callCode and callDelegate correspond to
+func (ctx *wasmContext) callAccount(inst *wasm.Instance, kind string, gasLimit, addressOffset uint64,
+	value int64, dataOffset, dataLength uint64) ([]uint64, error) {
+	vm, callee := ctx.vm, ctx.callee
+	if !HasPermission(vm.appState, callee, ptypes.Call) {
+		return nil, ErrPermission{"call"}
+	}
+	address, err := readAddress(inst, addressOffset)
+	if err != nil {
+		return nil, err
+	}
+	args, err := inst.ReadMemory(uint32(dataOffset), uint32(dataLength))
+	if err != nil {
+		return nil, err
+	}
+	callGas := int64(gasLimit)
+	if gasLimit > math.MaxInt64 || *ctx.gas < callGas {
+		return nil, ErrInsufficientGas
+	}
+	*ctx.gas -= callGas
+	// Any gas left is returned however the call ends
+	defer func() { *ctx.gas += callGas }()
+
+	var ret []byte
+	if nativeContract := registeredNativeContracts[address]; nativeContract != nil {
+		ret, err = nativeContract(vm.appState, callee, args, &callGas)
+		var exception string
+		if err != nil {
+			exception = err.Error()
+		}
+		vm.fireCallEvent(&exception, &ret, callee, &Account{Address: address}, args, value, &callGas)
+	} else {
+		if err := ctx.useGasNegative(ctx.vm.gasSchedule.GetAccount); err != nil {
+			return nil, err
+		}
+		acc := vm.appState.GetAccount(address)
+		switch kind {
+		case "callCode":
+			if acc == nil {
+				return nil, ErrUnknownAddress
+			}
+			ret, err = vm.Call(callee, callee, acc.Code, args, value, &callGas)
+		case "callDelegate":
+			if acc == nil {
+				return nil, ErrUnknownAddress
+			}
+			ret, err = vm.DelegateCall(ctx.caller, callee, acc.Code, args, value, &callGas)
+		default:
+			// nil account means we're sending funds to a new account
+			if acc == nil {
+				if !HasPermission(vm.appState, ctx.caller, ptypes.CreateAccount) {
+					return nil, ErrPermission{"create_account"}
+				}
+				acc = &Account{Address: address}
+			}
+			vm.appState.UpdateAccount(acc)
+			ret, err = vm.Call(callee, acc, acc.Code, args, value, &callGas)
+		}
+	}
+	ctx.returnData = ret
+	switch err {
+	case nil:
+		return []uint64{ewasmCallSuccess}, nil
+	case ErrExecutionReverted:
+		return []uint64{ewasmCallRevert}, nil
+	default:
+		dbg.Printf("error on WASM %s: %s\n", kind, err)
+		return []uint64{ewasmCallFailure}, nil
+	}
+}
+
+func (ctx *wasmContext) create(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	vm, callee := ctx.vm, ctx.callee
+	if !HasPermission(vm.appState, callee, ptypes.CreateContract) {
+		return nil, ErrPermission{"create_contract"}
+	}
+	value, err := readValue(inst, args[0])
+	if err != nil {
+		return nil, err
+	}
+	input, err := inst.ReadMemory(uint32(args[1]), uint32(args[2]))
+	if err != nil {
+		return nil, err
+	}
+	if callee.Balance < value {
+		return nil, ErrInsufficientBalance
+	}
+	newAccount := vm.appState.CreateAccount(callee)
+	ret, err := vm.Call(callee, newAccount, input, input, value, ctx.gas)
+	if err != nil {
+		ctx.returnData = ret
+		if err == ErrExecutionReverted {
+			return []uint64{ewasmCallRevert}, nil
+		}
+		return []uint64{ewasmCallFailure}, nil
+	}
+	newAccount.Code = ret
+	ctx.returnData = nil
+	err = inst.WriteMemory(uint32(args[3]), newAccount.Address.Postfix(ewasmAddressLength))
+	if err != nil {
+		return nil, err
+	}
+	return []uint64{ewasmCallSuccess}, nil
+}
+
+func (ctx *wasmContext) finish(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	output, err := inst.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	ctx.output = output
+	return nil, errWASMFinish
+}
+
+func (ctx *wasmContext) revert(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	output, err := inst.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	ctx.output = output
+	return nil, errWASMRevert
+}
+
+func (ctx *wasmContext) selfDestruct(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.useGasNegative(ctx.vm.gasSchedule.GetAccount); err != nil {
+		return nil, err
+	}
+	address, err := readAddress(inst, args[0])
+	if err != nil {
+		return nil, err
+	}
+	receiver := ctx.vm.appState.GetAccount(address)
+	if receiver == nil {
+		return nil, ErrUnknownAddress
+	}
+	receiver.Balance += ctx.callee.Balance
+	ctx.vm.appState.UpdateAccount(receiver)
+	ctx.vm.appState.RemoveAccount(ctx.callee)
+	return nil, errWASMFinish
+}
+
+func readAddress(inst *wasm.Instance, offset uint64) (Word256, error) {
+	address, err := inst.ReadMemory(uint32(offset), ewasmAddressLength)
+	if err != nil {
+		return Zero256, err
+	}
+	return LeftPadWord256(address), nil
+}
+
+// Read a 128 bit little endian value, which must fit in the int64 balances
+// of accounts
+func readValue(inst *wasm.Instance, offset uint64) (int64, error) {
+	bs, err := inst.ReadMemory(uint32(offset), ewasmValueLength)
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for i := 7; i >= 0; i-- {
+		value = value<<8 | uint64(bs[i])
+	}
+	for _, b := range bs[8:] {
+		if b != 0 {
+			value = math.MaxUint64
+		}
+	}
+	if value > math.MaxInt64 {
+		return 0, ErrInsufficientBalance
+	}
+	return int64(value), nil
+}
+
+func encodeValue(value int64) []byte {
+	bs := make([]byte, ewasmValueLength)
+	for i := 0; i < 8; i++ {
+		bs[i] = byte(uint64(value) >> uint(8*i))
+	}
+	return bs
+}
+
+// Copy length bytes from offset of data to memory at memoryOffset
+func copyToMemory(inst *wasm.Instance, memoryOffset uint64, data []byte,
+	offset, length uint64, outOfBounds error) error {
+	if uint64(uint32(offset))+uint64(uint32(length)) > uint64(len(data)) {
+		return outOfBounds
+	}
+	offset, length = uint64(uint32(offset)), uint64(uint32(length))
+	return inst.WriteMemory(uint32(memoryOffset), data[offset:offset+length])
+}
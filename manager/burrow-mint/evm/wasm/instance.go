@@ -0,0 +1,845 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+)
+
+const (
+	GasInstruction int64 = 1
+	GasMemoryPage  int64 = 64
+
+	// How deep calls between WASM functions may go
+	maxCallDepth = 1024
+	// The most values a function may have on its stack
+	maxStackHeight = 1 << 16
+	maxLocals      = 1 << 16
+	// How many pages memory may start with and grow to, 16 MiB as for the
+	// EVM, or less when the module gives a lower maximum
+	defaultMaxPages = 256
+	// The most elements a table may have
+	maxTableSize = 1 << 12
+)
+
+var (
+	ErrInsufficientGas       = errors.New("Insufficient gas")
+	ErrUnreachable           = errors.New("Unreachable instruction executed")
+	ErrMemoryOutOfBounds     = errors.New("WASM memory access out of bounds")
+	ErrIntegerDivideByZero   = errors.New("Integer divide by zero")
+	ErrIntegerOverflow       = errors.New("Integer overflow")
+	ErrCallStackExhausted    = errors.New("WASM call stack exhausted")
+	ErrStackOverflow         = errors.New("WASM value stack overflow")
+	ErrStackUnderflow        = errors.New("WASM value stack underflow")
+	ErrUndefinedElement      = errors.New("Undefined table element")
+	ErrIndirectCallSignature = errors.New("Indirect call to a function of the wrong type")
+	ErrInterrupted           = errors.New("WASM execution interrupted")
+)
+
+// A function provided by the host to satisfy an import. A HostFunction can
+// stop execution by returning an error, which Invoke will return.
+type HostFunction func(instance *Instance, args []uint64) ([]uint64, error)
+
+// Finds the HostFunction to satisfy the import of name from module, which
+// must be of type typ
+type ImportResolver func(module, name string, typ FunctionType) (HostFunction, error)
+
+type Instance struct {
+	module   *Module
+	host     []HostFunction
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	table    []*uint32
+	gas      *int64
+	depth    int
+	// Stops execution when closed, if not nil
+	interrupt <-chan struct{}
+}
+
+// Stops execution by panicking with a trap
+type trap struct {
+	err error
+}
+
+func throw(err error) {
+	panic(trap{err})
+}
+
+// Instantiates module resolving its imports with resolver, and runs its start
+// function if it has one
+func Instantiate(module *Module, resolver ImportResolver, gas *int64) (inst *Instance, err error) {
+	inst = &Instance{module: module, gas: gas}
+	for _, imp := range module.Imports {
+		hostFunction, err := resolver(imp.Module, imp.Name, module.Types[imp.TypeIndex])
+		if err != nil {
+			return nil, err
+		}
+		inst.host = append(inst.host, hostFunction)
+	}
+	for _, global := range module.Globals {
+		inst.globals = append(inst.globals, global.Init.value)
+	}
+	if module.Memory != nil {
+		if module.Memory.Min > defaultMaxPages {
+			return nil, fmt.Errorf("WASM memory of %v pages is more than the "+
+				"%v pages allowed", module.Memory.Min, defaultMaxPages)
+		}
+		// The initial pages are paid for before they are allocated, as pages
+		// are when memory grows
+		cost := int64(module.Memory.Min) * GasMemoryPage
+		if *gas < cost {
+			*gas = 0
+			return nil, ErrInsufficientGas
+		}
+		*gas -= cost
+		inst.memory = make([]byte, int(module.Memory.Min)*PageSize)
+		inst.maxPages = defaultMaxPages
+		if module.Memory.HasMax && module.Memory.Max < defaultMaxPages {
+			inst.maxPages = module.Memory.Max
+		}
+	}
+	if module.Table != nil {
+		if module.Table.Min > maxTableSize {
+			return nil, fmt.Errorf("WASM table of %v elements is more than the "+
+				"%v elements allowed", module.Table.Min, maxTableSize)
+		}
+		inst.table = make([]*uint32, module.Table.Min)
+	}
+	for _, segment := range module.Elements {
+		offset := uint64(uint32(segment.Offset.value))
+		if offset+uint64(len(segment.Functions)) > uint64(len(inst.table)) {
+			return nil, errors.New("WASM element segment does not fit in table")
+		}
+		for i := range segment.Functions {
+			inst.table[offset+uint64(i)] = &segment.Functions[i]
+		}
+	}
+	for _, segment := range module.Data {
+		offset := uint64(uint32(segment.Offset.value))
+		if offset+uint64(len(segment.Data)) > uint64(len(inst.memory)) {
+			return nil, errors.New("WASM data segment does not fit in memory")
+		}
+		copy(inst.memory[offset:], segment.Data)
+	}
+	if module.Start != nil {
+		defer inst.recoverTrap(&err)
+		inst.callFunction(*module.Start, nil)
+	}
+	return inst, nil
+}
+
+// Calls the exported function name
+func (inst *Instance) Invoke(name string, args ...uint64) (results []uint64, err error) {
+	export, ok := inst.module.Exports[name]
+	if !ok || export.Kind != ExternalFunction {
+		return nil, fmt.Errorf("WASM module exports no function %s", name)
+	}
+	ft, err := inst.module.functionType(export.Index)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(ft.Params) {
+		return nil, fmt.Errorf("WASM function %s takes %v arguments, not %v",
+			name, len(ft.Params), len(args))
+	}
+	defer inst.recoverTrap(&err)
+	return inst.callFunction(export.Index, args), nil
+}
+
+// The linear memory of the instance, which host functions may read and write
+func (inst *Instance) Memory() []byte {
+	return inst.memory
+}
+
+// Read length bytes at offset of memory, returning a copy
+func (inst *Instance) ReadMemory(offset, length uint32) ([]byte, error) {
+	if uint64(offset)+uint64(length) > uint64(len(inst.memory)) {
+		return nil, ErrMemoryOutOfBounds
+	}
+	value := make([]byte, length)
+	copy(value, inst.memory[offset:])
+	return value, nil
+}
+
+func (inst *Instance) WriteMemory(offset uint32, value []byte) error {
+	if uint64(offset)+uint64(len(value)) > uint64(len(inst.memory)) {
+		return ErrMemoryOutOfBounds
+	}
+	copy(inst.memory[offset:], value)
+	return nil
+}
+
+// The gas remaining, which host functions may use
+func (inst *Instance) Gas() *int64 {
+	return inst.gas
+}
+
+// Stops execution with ErrInterrupted once interrupt is closed
+func (inst *Instance) SetInterrupt(interrupt <-chan struct{}) {
+	inst.interrupt = interrupt
+}
+
+func (inst *Instance) useGas(amount int64) {
+	if *inst.gas < amount {
+		*inst.gas = 0
+		throw(ErrInsufficientGas)
+	}
+	*inst.gas -= amount
+}
+
+func (inst *Instance) recoverTrap(err *error) {
+	if r := recover(); r != nil {
+		switch r := r.(type) {
+		case trap:
+			*err = r.err
+		case runtime.Error:
+			// Malformed code can upset the interpreter, this stops it
+			// bringing down the node
+			*err = fmt.Errorf("WASM execution failed: %v", r)
+		default:
+			panic(r)
+		}
+	}
+}
+
+func (inst *Instance) callFunction(index uint32, args []uint64) []uint64 {
+	ft, err := inst.module.functionType(index)
+	if err != nil {
+		throw(err)
+	}
+	if int(index) < len(inst.host) {
+		results, err := inst.host[index](inst, args)
+		if err != nil {
+			throw(err)
+		}
+		if len(results) != len(ft.Results) {
+			throw(fmt.Errorf("Host function returned %v results, not %v",
+				len(results), len(ft.Results)))
+		}
+		return results
+	}
+	inst.depth++
+	defer func() { inst.depth-- }()
+	if inst.depth > maxCallDepth {
+		throw(ErrCallStackExhausted)
+	}
+	function := inst.module.Functions[int(index)-len(inst.host)]
+	locals := make([]uint64, len(ft.Params)+len(function.Locals))
+	copy(locals, args)
+	return inst.execute(function, locals, len(ft.Results))
+}
+
+// A block, loop or if being executed
+type label struct {
+	// The number of values a branch to the label carries
+	arity int
+	// The height of the stack on entering the label
+	height int
+	// Where a branch to the label continues
+	target int
+	loop   bool
+}
+
+type stack struct {
+	values []uint64
+}
+
+func (s *stack) push(value uint64) {
+	if len(s.values) >= maxStackHeight {
+		throw(ErrStackOverflow)
+	}
+	s.values = append(s.values, value)
+}
+
+func (s *stack) pop() uint64 {
+	if len(s.values) == 0 {
+		throw(ErrStackUnderflow)
+	}
+	value := s.values[len(s.values)-1]
+	s.values = s.values[:len(s.values)-1]
+	return value
+}
+
+func (s *stack) pop32() uint32 {
+	return uint32(s.pop())
+}
+
+func (s *stack) push32(value uint32) {
+	s.push(uint64(value))
+}
+
+func (s *stack) pushBool(b bool) {
+	if b {
+		s.push(1)
+	} else {
+		s.push(0)
+	}
+}
+
+// Keep the top arity values and drop those above height below them
+func (s *stack) unwind(height, arity int) {
+	if len(s.values)-arity < height {
+		throw(ErrStackUnderflow)
+	}
+	copy(s.values[height:], s.values[len(s.values)-arity:])
+	s.values = s.values[:height+arity]
+}
+
+func (s *stack) top(n int) []uint64 {
+	if len(s.values) < n {
+		throw(ErrStackUnderflow)
+	}
+	values := make([]uint64, n)
+	copy(values, s.values[len(s.values)-n:])
+	return values
+}
+
+func blockArity(blockType byte) int {
+	if blockType == emptyBlockType {
+		return 0
+	}
+	return 1
+}
+
+func (inst *Instance) execute(function *Function, locals []uint64, arity int) []uint64 {
+	s := &stack{}
+	var labels []label
+	r := &reader{data: function.Code}
+
+	u32 := func() uint32 {
+		n, err := r.u32()
+		if err != nil {
+			throw(err)
+		}
+		return n
+	}
+	opByte := func() byte {
+		b, err := r.byte()
+		if err != nil {
+			throw(err)
+		}
+		return b
+	}
+	local := func(index uint32) *uint64 {
+		if index >= uint32(len(locals)) {
+			throw(fmt.Errorf("No local %v", index))
+		}
+		return &locals[index]
+	}
+	global := func(index uint32) *uint64 {
+		if index >= uint32(len(inst.globals)) {
+			throw(fmt.Errorf("No global %v", index))
+		}
+		return &inst.globals[index]
+	}
+	// Returns true when the branch leaves the function
+	branch := func(depth uint32) bool {
+		if depth >= uint32(len(labels)) {
+			if depth == uint32(len(labels)) {
+				return true
+			}
+			throw(fmt.Errorf("No label at depth %v", depth))
+		}
+		i := len(labels) - 1 - int(depth)
+		l := labels[i]
+		s.unwind(l.height, l.arity)
+		if l.loop {
+			labels = labels[:i+1]
+		} else {
+			labels = labels[:i]
+		}
+		r.pos = l.target
+		return false
+	}
+	// The address of a memory access of size bytes
+	address := func(size uint64) uint64 {
+		// Skip the alignment hint
+		u32()
+		offset := u32()
+		ea := uint64(s.pop32()) + uint64(offset)
+		if ea+size > uint64(len(inst.memory)) {
+			throw(ErrMemoryOutOfBounds)
+		}
+		return ea
+	}
+
+	for {
+		inst.useGas(GasInstruction)
+		if inst.interrupt != nil {
+			select {
+			case <-inst.interrupt:
+				throw(ErrInterrupted)
+			default:
+			}
+		}
+		pos := r.pos
+		op := opByte()
+		switch op {
+		case opUnreachable:
+			throw(ErrUnreachable)
+
+		case opNop:
+
+		case opBlock, opLoop:
+			blockType := opByte()
+			l := label{height: len(s.values)}
+			if op == opLoop {
+				l.loop = true
+				l.target = r.pos
+			} else {
+				l.arity = blockArity(blockType)
+				l.target = function.blocks[pos].endPos + 1
+			}
+			labels = append(labels, l)
+
+		case opIf:
+			blockType := opByte()
+			b := function.blocks[pos]
+			cond := s.pop32()
+			l := label{
+				arity:  blockArity(blockType),
+				height: len(s.values),
+				target: b.endPos + 1,
+			}
+			if cond != 0 {
+				labels = append(labels, l)
+			} else if b.elsePos != 0 {
+				labels = append(labels, l)
+				r.pos = b.elsePos + 1
+			} else {
+				r.pos = l.target
+			}
+
+		case opElse:
+			// The end of the then branch
+			if branch(0) {
+				return s.top(arity)
+			}
+
+		case opEnd:
+			if len(labels) == 0 {
+				return s.top(arity)
+			}
+			labels = labels[:len(labels)-1]
+
+		case opBr:
+			if branch(u32()) {
+				return s.top(arity)
+			}
+
+		case opBrIf:
+			depth := u32()
+			if s.pop32() != 0 && branch(depth) {
+				return s.top(arity)
+			}
+
+		case opBrTable:
+			table := function.brTables[pos]
+			r.pos = table.next
+			depth := table.defaultDepth
+			if i := s.pop32(); i < uint32(len(table.depths)) {
+				depth = table.depths[i]
+			}
+			if branch(depth) {
+				return s.top(arity)
+			}
+
+		case opReturn:
+			return s.top(arity)
+
+		case opCall:
+			inst.call(s, u32())
+
+		case opCallIndirect:
+			typeIndex := u32()
+			opByte()
+			i := s.pop32()
+			if i >= uint32(len(inst.table)) || inst.table[i] == nil {
+				throw(ErrUndefinedElement)
+			}
+			if typeIndex >= uint32(len(inst.module.Types)) {
+				throw(fmt.Errorf("No WASM type %v", typeIndex))
+			}
+			ft, err := inst.module.functionType(*inst.table[i])
+			if err != nil {
+				throw(err)
+			}
+			if !ft.Equal(inst.module.Types[typeIndex]) {
+				throw(ErrIndirectCallSignature)
+			}
+			inst.call(s, *inst.table[i])
+
+		case opDrop:
+			s.pop()
+
+		case opSelect:
+			cond := s.pop32()
+			b, a := s.pop(), s.pop()
+			if cond != 0 {
+				s.push(a)
+			} else {
+				s.push(b)
+			}
+
+		case opGetLocal:
+			s.push(*local(u32()))
+
+		case opSetLocal:
+			*local(u32()) = s.pop()
+
+		case opTeeLocal:
+			value := s.pop()
+			s.push(value)
+			*local(u32()) = value
+
+		case opGetGlobal:
+			s.push(*global(u32()))
+
+		case opSetGlobal:
+			index := u32()
+			if index < uint32(len(inst.module.Globals)) &&
+				!inst.module.Globals[index].Mutable {
+				throw(fmt.Errorf("Global %v is immutable", index))
+			}
+			*global(index) = s.pop()
+
+		case opI32Load:
+			ea := address(4)
+			s.push32(le32(inst.memory[ea:]))
+		case opI64Load:
+			ea := address(8)
+			s.push(le64(inst.memory[ea:]))
+		case opI32Load8S:
+			ea := address(1)
+			s.push32(uint32(int32(int8(inst.memory[ea]))))
+		case opI32Load8U:
+			ea := address(1)
+			s.push32(uint32(inst.memory[ea]))
+		case opI32Load16S:
+			ea := address(2)
+			s.push32(uint32(int32(int16(le16(inst.memory[ea:])))))
+		case opI32Load16U:
+			ea := address(2)
+			s.push32(uint32(le16(inst.memory[ea:])))
+		case opI64Load8S:
+			ea := address(1)
+			s.push(uint64(int64(int8(inst.memory[ea]))))
+		case opI64Load8U:
+			ea := address(1)
+			s.push(uint64(inst.memory[ea]))
+		case opI64Load16S:
+			ea := address(2)
+			s.push(uint64(int64(int16(le16(inst.memory[ea:])))))
+		case opI64Load16U:
+			ea := address(2)
+			s.push(uint64(le16(inst.memory[ea:])))
+		case opI64Load32S:
+			ea := address(4)
+			s.push(uint64(int64(int32(le32(inst.memory[ea:])))))
+		case opI64Load32U:
+			ea := address(4)
+			s.push(uint64(le32(inst.memory[ea:])))
+
+		case opI32Store, opI64Store, opI32Store8, opI32Store16, opI64Store8,
+			opI64Store16, opI64Store32:
+			value := s.pop()
+			size := storeSize(op)
+			ea := address(size)
+			for i := uint64(0); i < size; i++ {
+				inst.memory[ea+i] = byte(value >> (8 * i))
+			}
+
+		case opMemorySize:
+			opByte()
+			s.push32(uint32(len(inst.memory) / PageSize))
+
+		case opMemoryGrow:
+			opByte()
+			pages := uint32(len(inst.memory) / PageSize)
+			delta := s.pop32()
+			if inst.module.Memory == nil || uint64(pages)+uint64(delta) > uint64(inst.maxPages) {
+				s.push32(math.MaxUint32)
+				break
+			}
+			inst.useGas(int64(delta) * GasMemoryPage)
+			inst.memory = append(inst.memory, make([]byte, int(delta)*PageSize)...)
+			s.push32(pages)
+
+		case opI32Const:
+			value, err := r.signed(32)
+			if err != nil {
+				throw(err)
+			}
+			s.push32(uint32(value))
+
+		case opI64Const:
+			value, err := r.signed(64)
+			if err != nil {
+				throw(err)
+			}
+			s.push(uint64(value))
+
+		case opI32Eqz:
+			s.pushBool(s.pop32() == 0)
+
+		case opI32Eq, opI32Ne, opI32LtS, opI32LtU, opI32GtS, opI32GtU, opI32LeS,
+			opI32LeU, opI32GeS, opI32GeU:
+			b, a := s.pop32(), s.pop32()
+			s.pushBool(compare(op-opI32Eq, int64(int32(a)), int64(int32(b)),
+				uint64(a), uint64(b)))
+
+		case opI64Eqz:
+			s.pushBool(s.pop() == 0)
+
+		case opI64Eq, opI64Ne, opI64LtS, opI64LtU, opI64GtS, opI64GtU, opI64LeS,
+			opI64LeU, opI64GeS, opI64GeU:
+			b, a := s.pop(), s.pop()
+			s.pushBool(compare(op-opI64Eq, int64(a), int64(b), a, b))
+
+		case opI32Clz:
+			s.push32(uint32(leadingZeros(uint64(s.pop32())) - 32))
+		case opI32Ctz:
+			s.push32(uint32(trailingZeros(uint64(s.pop32()), 32)))
+		case opI32Popcnt:
+			s.push32(uint32(popCount(uint64(s.pop32()))))
+
+		case opI32Add, opI32Sub, opI32Mul, opI32DivS, opI32DivU, opI32RemS,
+			opI32RemU, opI32And, opI32Or, opI32Xor, opI32Shl, opI32ShrS,
+			opI32ShrU, opI32Rotl, opI32Rotr:
+			b, a := s.pop32(), s.pop32()
+			s.push32(binary32(op, a, b))
+
+		case opI64Clz:
+			s.push(uint64(leadingZeros(s.pop())))
+		case opI64Ctz:
+			s.push(uint64(trailingZeros(s.pop(), 64)))
+		case opI64Popcnt:
+			s.push(uint64(popCount(s.pop())))
+
+		case opI64Add, opI64Sub, opI64Mul, opI64DivS, opI64DivU, opI64RemS,
+			opI64RemU, opI64And, opI64Or, opI64Xor, opI64Shl, opI64ShrS,
+			opI64ShrU, opI64Rotl, opI64Rotr:
+			b, a := s.pop(), s.pop()
+			s.push(binary64(op, a, b))
+
+		case opI32WrapI64:
+			s.push32(uint32(s.pop()))
+		case opI64ExtendSI32:
+			s.push(uint64(int64(int32(s.pop32()))))
+		case opI64ExtendUI32:
+			s.push(uint64(s.pop32()))
+
+		default:
+			throw(fmt.Errorf("Unsupported WASM instruction 0x%X", op))
+		}
+	}
+}
+
+// Calls function with arguments from the stack and pushes its results
+func (inst *Instance) call(s *stack, function uint32) {
+	ft, err := inst.module.functionType(function)
+	if err != nil {
+		throw(err)
+	}
+	args := s.top(len(ft.Params))
+	s.values = s.values[:len(s.values)-len(args)]
+	for _, result := range inst.callFunction(function, args) {
+		s.push(result)
+	}
+}
+
+// Compares a and b by the comparison of offset from eq in the i32 or i64
+// comparisons, which have the same order
+func compare(offset byte, sa, sb int64, ua, ub uint64) bool {
+	switch offset + opI32Eq {
+	case opI32Eq:
+		return ua == ub
+	case opI32Ne:
+		return ua != ub
+	case opI32LtS:
+		return sa < sb
+	case opI32LtU:
+		return ua < ub
+	case opI32GtS:
+		return sa > sb
+	case opI32GtU:
+		return ua > ub
+	case opI32LeS:
+		return sa <= sb
+	case opI32LeU:
+		return ua <= ub
+	case opI32GeS:
+		return sa >= sb
+	default:
+		return ua >= ub
+	}
+}
+
+func binary32(op byte, a, b uint32) uint32 {
+	switch op {
+	case opI32Add:
+		return a + b
+	case opI32Sub:
+		return a - b
+	case opI32Mul:
+		return a * b
+	case opI32DivS:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			throw(ErrIntegerOverflow)
+		}
+		return uint32(int32(a) / int32(b))
+	case opI32DivU:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return a / b
+	case opI32RemS:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return uint32(int32(a) % int32(b))
+	case opI32RemU:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return a % b
+	case opI32And:
+		return a & b
+	case opI32Or:
+		return a | b
+	case opI32Xor:
+		return a ^ b
+	case opI32Shl:
+		return a << (b & 31)
+	case opI32ShrS:
+		return uint32(int32(a) >> (b & 31))
+	case opI32ShrU:
+		return a >> (b & 31)
+	case opI32Rotl:
+		k := b & 31
+		return a<<k | a>>((32-k)&31)
+	default:
+		k := b & 31
+		return a>>k | a<<((32-k)&31)
+	}
+}
+
+func binary64(op byte, a, b uint64) uint64 {
+	switch op {
+	case opI64Add:
+		return a + b
+	case opI64Sub:
+		return a - b
+	case opI64Mul:
+		return a * b
+	case opI64DivS:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			throw(ErrIntegerOverflow)
+		}
+		return uint64(int64(a) / int64(b))
+	case opI64DivU:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return a / b
+	case opI64RemS:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return uint64(int64(a) % int64(b))
+	case opI64RemU:
+		if b == 0 {
+			throw(ErrIntegerDivideByZero)
+		}
+		return a % b
+	case opI64And:
+		return a & b
+	case opI64Or:
+		return a | b
+	case opI64Xor:
+		return a ^ b
+	case opI64Shl:
+		return a << (b & 63)
+	case opI64ShrS:
+		return uint64(int64(a) >> (b & 63))
+	case opI64ShrU:
+		return a >> (b & 63)
+	case opI64Rotl:
+		k := b & 63
+		return a<<k | a>>((64-k)&63)
+	default:
+		k := b & 63
+		return a>>k | a<<((64-k)&63)
+	}
+}
+
+func storeSize(op byte) uint64 {
+	switch op {
+	case opI32Store8, opI64Store8:
+		return 1
+	case opI32Store16, opI64Store16:
+		return 2
+	case opI32Store, opI64Store32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+func leadingZeros(x uint64) int {
+	n := 0
+	for i := 63; i >= 0 && x&(1<<uint(i)) == 0; i-- {
+		n++
+	}
+	return n
+}
+
+func trailingZeros(x uint64, bits int) int {
+	n := 0
+	for n < bits && x&(1<<uint(n)) == 0 {
+		n++
+	}
+	return n
+}
+
+func popCount(x uint64) int {
+	n := 0
+	for ; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func le16(bs []byte) uint16 {
+	return uint16(bs[0]) | uint16(bs[1])<<8
+}
+
+func le32(bs []byte) uint32 {
+	return uint32(bs[0]) | uint32(bs[1])<<8 | uint32(bs[2])<<16 | uint32(bs[3])<<24
+}
+
+func le64(bs []byte) uint64 {
+	return uint64(le32(bs)) | uint64(le32(bs[4:]))<<32
+}
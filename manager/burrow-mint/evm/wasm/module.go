@@ -0,0 +1,893 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wasm decodes and interprets WebAssembly (MVP) modules. Floating
+// point is not supported since contracts must execute deterministically, as
+// in ewasm. The host environment is provided by resolving the imports of a
+// module to HostFunctions.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	Version  uint32 = 1
+	PageSize        = 65536
+	// The most pages one memory can have
+	MaxPages = 65536
+)
+
+var magic = []byte{0x00, 0x61, 0x73, 0x6D}
+
+// Value types
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7F
+	I64 ValueType = 0x7E
+	F32 ValueType = 0x7D
+	F64 ValueType = 0x7C
+)
+
+func (vt ValueType) String() string {
+	switch vt {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	default:
+		return fmt.Sprintf("ValueType(0x%X)", byte(vt))
+	}
+}
+
+// The block type of a block with no result
+const emptyBlockType = 0x40
+
+// Kinds of imports and exports
+const (
+	ExternalFunction byte = 0x00
+	ExternalTable    byte = 0x01
+	ExternalMemory   byte = 0x02
+	ExternalGlobal   byte = 0x03
+)
+
+// Section ids
+const (
+	sectionCustom   byte = 0
+	sectionType     byte = 1
+	sectionImport   byte = 2
+	sectionFunction byte = 3
+	sectionTable    byte = 4
+	sectionMemory   byte = 5
+	sectionGlobal   byte = 6
+	sectionExport   byte = 7
+	sectionStart    byte = 8
+	sectionElement  byte = 9
+	sectionCode     byte = 10
+	sectionData     byte = 11
+)
+
+var (
+	ErrNotWASM        = errors.New("Code is not a WASM module")
+	ErrUnexpectedEnd  = errors.New("Unexpected end of WASM module")
+	ErrIntegerTooLong = errors.New("LEB128 integer too long")
+)
+
+type FunctionType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (ft FunctionType) Equal(other FunctionType) bool {
+	return bytes.Equal(valueTypeBytes(ft.Params), valueTypeBytes(other.Params)) &&
+		bytes.Equal(valueTypeBytes(ft.Results), valueTypeBytes(other.Results))
+}
+
+func (ft FunctionType) String() string {
+	return fmt.Sprintf("%v -> %v", ft.Params, ft.Results)
+}
+
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Kind   byte
+	// Index into the types of an imported function
+	TypeIndex uint32
+}
+
+type Export struct {
+	Name  string
+	Kind  byte
+	Index uint32
+}
+
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    constantExpression
+}
+
+type ElementSegment struct {
+	Offset    constantExpression
+	Functions []uint32
+}
+
+type DataSegment struct {
+	Offset constantExpression
+	Data   []byte
+}
+
+// The code of a function defined in the module
+type Function struct {
+	TypeIndex uint32
+	Locals    []ValueType
+	Code      []byte
+	// The positions of the else and end of each block, loop and if in Code
+	// by the position of its opcode
+	blocks map[int]block
+	// The decoded br_tables in Code by the position of their opcode, so that
+	// they are not decoded each time they execute
+	brTables map[int]brTable
+}
+
+type block struct {
+	elsePos int // zero if the if has no else
+	endPos  int
+}
+
+type brTable struct {
+	depths       []uint32
+	defaultDepth uint32
+	// The position of the instruction after the br_table
+	next int
+}
+
+type constantExpression struct {
+	opcode byte
+	value  uint64
+}
+
+type Module struct {
+	Types     []FunctionType
+	Imports   []Import
+	Functions []*Function
+	Table     *Limits
+	Memory    *Limits
+	Globals   []Global
+	Exports   map[string]Export
+	Start     *uint32
+	Elements  []ElementSegment
+	Data      []DataSegment
+}
+
+// Whether code is a WASM module rather than EVM bytecode, judging by the
+// WASM magic number, which as EVM code would stop at once
+func IsWASM(code []byte) bool {
+	return bytes.HasPrefix(code, magic)
+}
+
+func DecodeModule(code []byte) (*Module, error) {
+	if !IsWASM(code) {
+		return nil, ErrNotWASM
+	}
+	r := &reader{data: code, pos: len(magic)}
+	version, err := r.uint32LE()
+	if err != nil {
+		return nil, err
+	}
+	if version != Version {
+		return nil, fmt.Errorf("Unsupported WASM version %v", version)
+	}
+	module := &Module{Exports: make(map[string]Export)}
+	var functionTypes []uint32
+	var lastID byte
+	for r.len() > 0 {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		if id == sectionCustom {
+			continue
+		}
+		if id <= lastID {
+			return nil, fmt.Errorf("WASM section %v is out of order", id)
+		}
+		lastID = id
+		sr := &reader{data: content}
+		switch id {
+		case sectionType:
+			err = module.decodeTypes(sr)
+		case sectionImport:
+			err = module.decodeImports(sr)
+		case sectionFunction:
+			functionTypes, err = sr.u32Vector()
+		case sectionTable:
+			module.Table, err = decodeSingleLimits(sr, "table", true)
+		case sectionMemory:
+			module.Memory, err = decodeSingleLimits(sr, "memory", false)
+		case sectionGlobal:
+			err = module.decodeGlobals(sr)
+		case sectionExport:
+			err = module.decodeExports(sr)
+		case sectionStart:
+			var start uint32
+			start, err = sr.u32()
+			module.Start = &start
+		case sectionElement:
+			err = module.decodeElements(sr)
+		case sectionCode:
+			err = module.decodeCode(sr, functionTypes)
+		case sectionData:
+			err = module.decodeData(sr)
+		default:
+			err = fmt.Errorf("Unknown WASM section %v", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sr.len() > 0 {
+			return nil, fmt.Errorf("WASM section %v is longer than its contents", id)
+		}
+	}
+	if len(module.Functions) != len(functionTypes) {
+		return nil, fmt.Errorf("WASM module declares %v functions but has code "+
+			"for %v", len(functionTypes), len(module.Functions))
+	}
+	return module, module.validate()
+}
+
+// The number of imported functions, which come before the functions of the
+// module in the function index space
+func (module *Module) numImportedFunctions() int {
+	n := 0
+	for _, imp := range module.Imports {
+		if imp.Kind == ExternalFunction {
+			n++
+		}
+	}
+	return n
+}
+
+// Get the type of function in the function index space
+func (module *Module) functionType(function uint32) (FunctionType, error) {
+	imported := uint32(module.numImportedFunctions())
+	if function < imported {
+		n := uint32(0)
+		for _, imp := range module.Imports {
+			if imp.Kind == ExternalFunction {
+				if n == function {
+					return module.Types[imp.TypeIndex], nil
+				}
+				n++
+			}
+		}
+	}
+	if function-imported >= uint32(len(module.Functions)) {
+		return FunctionType{}, fmt.Errorf("No WASM function %v", function)
+	}
+	return module.Types[module.Functions[function-imported].TypeIndex], nil
+}
+
+func (module *Module) validate() error {
+	for _, imp := range module.Imports {
+		if imp.TypeIndex >= uint32(len(module.Types)) {
+			return fmt.Errorf("Import %s.%s has no type %v", imp.Module,
+				imp.Name, imp.TypeIndex)
+		}
+	}
+	for i, function := range module.Functions {
+		if function.TypeIndex >= uint32(len(module.Types)) {
+			return fmt.Errorf("WASM function %v has no type %v", i,
+				function.TypeIndex)
+		}
+	}
+	for _, ft := range module.Types {
+		if len(ft.Results) > 1 {
+			return fmt.Errorf("WASM functions have at most one result, not %v",
+				len(ft.Results))
+		}
+	}
+	numFunctions := uint32(module.numImportedFunctions() + len(module.Functions))
+	for _, export := range module.Exports {
+		if export.Kind == ExternalFunction && export.Index >= numFunctions {
+			return fmt.Errorf("Export %s is of no function", export.Name)
+		}
+	}
+	if module.Start != nil {
+		if *module.Start >= numFunctions {
+			return fmt.Errorf("Start function %v does not exist", *module.Start)
+		}
+		ft, _ := module.functionType(*module.Start)
+		if len(ft.Params) > 0 || len(ft.Results) > 0 {
+			return fmt.Errorf("Start function must have type [] -> [], not %v", ft)
+		}
+	}
+	for _, segment := range module.Elements {
+		if module.Table == nil {
+			return errors.New("WASM module has elements but no table")
+		}
+		for _, function := range segment.Functions {
+			if function >= numFunctions {
+				return fmt.Errorf("Element of no function %v", function)
+			}
+		}
+	}
+	if len(module.Data) > 0 && module.Memory == nil {
+		return errors.New("WASM module has data but no memory")
+	}
+	return nil
+}
+
+func (module *Module) decodeTypes(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		form, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if form != 0x60 {
+			return fmt.Errorf("Unknown WASM type form 0x%X", form)
+		}
+		params, err := r.valueTypes()
+		if err != nil {
+			return err
+		}
+		results, err := r.valueTypes()
+		if err != nil {
+			return err
+		}
+		module.Types = append(module.Types, FunctionType{params, results})
+	}
+	return nil
+}
+
+func (module *Module) decodeImports(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		imp := Import{}
+		if imp.Module, err = r.name(); err != nil {
+			return err
+		}
+		if imp.Name, err = r.name(); err != nil {
+			return err
+		}
+		if imp.Kind, err = r.byte(); err != nil {
+			return err
+		}
+		if imp.Kind != ExternalFunction {
+			// The host only provides functions
+			return fmt.Errorf("Import %s.%s is not a function, only functions "+
+				"can be imported", imp.Module, imp.Name)
+		}
+		if imp.TypeIndex, err = r.u32(); err != nil {
+			return err
+		}
+		module.Imports = append(module.Imports, imp)
+	}
+	return nil
+}
+
+func decodeSingleLimits(r *reader, kind string, table bool) (*Limits, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("WASM modules have at most one %s, not %v", kind, n)
+	}
+	if table {
+		elementType, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if elementType != 0x70 {
+			return nil, fmt.Errorf("Unknown table element type 0x%X", elementType)
+		}
+	}
+	limits := &Limits{}
+	flags, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if limits.Min, err = r.u32(); err != nil {
+		return nil, err
+	}
+	switch flags {
+	case 0:
+	case 1:
+		limits.HasMax = true
+		if limits.Max, err = r.u32(); err != nil {
+			return nil, err
+		}
+		if limits.Max < limits.Min {
+			return nil, fmt.Errorf("The %s maximum is less than its minimum", kind)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown limits flags 0x%X", flags)
+	}
+	if !table && (limits.Min > MaxPages || limits.Max > MaxPages) {
+		return nil, fmt.Errorf("Memory of more than %v pages", MaxPages)
+	}
+	return limits, nil
+}
+
+func (module *Module) decodeGlobals(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		global := Global{}
+		typ, err := r.byte()
+		if err != nil {
+			return err
+		}
+		global.Type = ValueType(typ)
+		if global.Type != I32 && global.Type != I64 {
+			return fmt.Errorf("Unsupported global type %v", global.Type)
+		}
+		mutable, err := r.byte()
+		if err != nil {
+			return err
+		}
+		global.Mutable = mutable == 1
+		if global.Init, err = r.constantExpression(); err != nil {
+			return err
+		}
+		module.Globals = append(module.Globals, global)
+	}
+	return nil
+}
+
+func (module *Module) decodeExports(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		export := Export{}
+		if export.Name, err = r.name(); err != nil {
+			return err
+		}
+		if export.Kind, err = r.byte(); err != nil {
+			return err
+		}
+		if export.Index, err = r.u32(); err != nil {
+			return err
+		}
+		if _, ok := module.Exports[export.Name]; ok {
+			return fmt.Errorf("Duplicate export %s", export.Name)
+		}
+		module.Exports[export.Name] = export
+	}
+	return nil
+}
+
+func (module *Module) decodeElements(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		table, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if table != 0 {
+			return fmt.Errorf("No table %v", table)
+		}
+		segment := ElementSegment{}
+		if segment.Offset, err = r.constantExpression(); err != nil {
+			return err
+		}
+		if segment.Functions, err = r.u32Vector(); err != nil {
+			return err
+		}
+		module.Elements = append(module.Elements, segment)
+	}
+	return nil
+}
+
+func (module *Module) decodeCode(r *reader, functionTypes []uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(functionTypes) {
+		return fmt.Errorf("WASM module declares %v functions but has code "+
+			"for %v", len(functionTypes), n)
+	}
+	for i := uint32(0); i < n; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		br := &reader{data: body}
+		function := &Function{TypeIndex: functionTypes[i]}
+		numLocalGroups, err := br.u32()
+		if err != nil {
+			return err
+		}
+		for j := uint32(0); j < numLocalGroups; j++ {
+			count, err := br.u32()
+			if err != nil {
+				return err
+			}
+			typ, err := br.byte()
+			if err != nil {
+				return err
+			}
+			if ValueType(typ) != I32 && ValueType(typ) != I64 {
+				return fmt.Errorf("Unsupported local type %v", ValueType(typ))
+			}
+			if uint64(len(function.Locals))+uint64(count) > maxLocals {
+				return fmt.Errorf("WASM function has more than %v locals", maxLocals)
+			}
+			for k := uint32(0); k < count; k++ {
+				function.Locals = append(function.Locals, ValueType(typ))
+			}
+		}
+		function.Code = body[br.pos:]
+		if function.blocks, function.brTables, err = scanBlocks(function.Code); err != nil {
+			return fmt.Errorf("WASM function %v: %v", i, err)
+		}
+		module.Functions = append(module.Functions, function)
+	}
+	return nil
+}
+
+func (module *Module) decodeData(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		memory, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if memory != 0 {
+			return fmt.Errorf("No memory %v", memory)
+		}
+		segment := DataSegment{}
+		if segment.Offset, err = r.constantExpression(); err != nil {
+			return err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if segment.Data, err = r.bytes(int(size)); err != nil {
+			return err
+		}
+		module.Data = append(module.Data, segment)
+	}
+	return nil
+}
+
+// Finds the else and end of every block in code and decodes its br_tables,
+// and checks that it only has instructions that are supported
+func scanBlocks(code []byte) (map[int]block, map[int]brTable, error) {
+	blocks := make(map[int]block)
+	brTables := make(map[int]brTable)
+	var open []int
+	r := &reader{data: code}
+	for r.len() > 0 {
+		pos := r.pos
+		op, err := r.byte()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch op {
+		case opBlock, opLoop, opIf:
+			open = append(open, pos)
+		case opElse:
+			if len(open) == 0 || code[open[len(open)-1]] != opIf {
+				return nil, nil, errors.New("else outside of an if")
+			}
+			start := open[len(open)-1]
+			b := blocks[start]
+			if b.elsePos != 0 {
+				return nil, nil, errors.New("if with more than one else")
+			}
+			b.elsePos = pos
+			blocks[start] = b
+		case opEnd:
+			if len(open) == 0 {
+				// The end of the function
+				if r.len() > 0 {
+					return nil, nil, errors.New("Instructions after the end of function")
+				}
+				return blocks, brTables, nil
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			b := blocks[start]
+			b.endPos = pos
+			blocks[start] = b
+		case opBrTable:
+			table := brTable{}
+			if table.depths, err = r.u32Vector(); err != nil {
+				return nil, nil, err
+			}
+			if table.defaultDepth, err = r.u32(); err != nil {
+				return nil, nil, err
+			}
+			table.next = r.pos
+			brTables[pos] = table
+			continue
+		}
+		if err := r.skipImmediates(op); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, errors.New("WASM function is missing its end")
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) len() int {
+	return len(r.data) - r.pos
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, ErrUnexpectedEnd
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > r.len() {
+		return nil, ErrUnexpectedEnd
+	}
+	bs := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return bs, nil
+}
+
+func (r *reader) uint32LE() (uint32, error) {
+	bs, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(bs[0]) | uint32(bs[1])<<8 | uint32(bs[2])<<16 | uint32(bs[3])<<24, nil
+}
+
+// An unsigned LEB128 integer of at most bits bits
+func (r *reader) unsigned(bits uint) (uint64, error) {
+	var result uint64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits || (bits-shift < 7 && b&0x7F>>(bits-shift) != 0) {
+			return 0, ErrIntegerTooLong
+		}
+		result |= uint64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+}
+
+// A signed LEB128 integer of at most bits bits
+func (r *reader) signed(bits uint) (int64, error) {
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits {
+			return 0, ErrIntegerTooLong
+		}
+		result |= int64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			if bits < 64 && (result < -(1<<(bits-1)) || result >= 1<<(bits-1)) {
+				return 0, ErrIntegerTooLong
+			}
+			return result, nil
+		}
+	}
+}
+
+func (r *reader) u32() (uint32, error) {
+	n, err := r.unsigned(32)
+	return uint32(n), err
+}
+
+func (r *reader) u32Vector() ([]uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if int(n) > r.len() {
+		return nil, ErrUnexpectedEnd
+	}
+	vector := make([]uint32, n)
+	for i := range vector {
+		if vector[i], err = r.u32(); err != nil {
+			return nil, err
+		}
+	}
+	return vector, nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	bs, err := r.bytes(int(n))
+	return string(bs), err
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	bs, err := r.bytes(int(n))
+	if err != nil {
+		return nil, err
+	}
+	types := make([]ValueType, n)
+	for i, b := range bs {
+		types[i] = ValueType(b)
+		if types[i] != I32 && types[i] != I64 {
+			return nil, fmt.Errorf("Unsupported value type %v", types[i])
+		}
+	}
+	return types, nil
+}
+
+func (r *reader) constantExpression() (constantExpression, error) {
+	expr := constantExpression{}
+	var err error
+	if expr.opcode, err = r.byte(); err != nil {
+		return expr, err
+	}
+	switch expr.opcode {
+	case opI32Const:
+		var value int64
+		value, err = r.signed(32)
+		expr.value = uint64(uint32(value))
+	case opI64Const:
+		var value int64
+		value, err = r.signed(64)
+		expr.value = uint64(value)
+	default:
+		// get_global can only refer to imported globals, and only functions
+		// can be imported
+		return expr, fmt.Errorf("Unsupported constant expression opcode 0x%X",
+			expr.opcode)
+	}
+	if err != nil {
+		return expr, err
+	}
+	end, err := r.byte()
+	if err != nil {
+		return expr, err
+	}
+	if end != opEnd {
+		return expr, errors.New("Constant expression of more than one instruction")
+	}
+	return expr, nil
+}
+
+// Skips the immediate arguments of op, and rejects unsupported opcodes
+func (r *reader) skipImmediates(op byte) error {
+	var err error
+	switch {
+	case op == opBlock || op == opLoop || op == opIf:
+		var blockType byte
+		if blockType, err = r.byte(); err == nil && blockType != emptyBlockType &&
+			ValueType(blockType) != I32 && ValueType(blockType) != I64 {
+			err = fmt.Errorf("Unsupported block type 0x%X", blockType)
+		}
+	case op == opBr || op == opBrIf || op == opCall ||
+		(op >= opGetLocal && op <= opSetGlobal):
+		_, err = r.u32()
+	case op == opBrTable:
+		if _, err = r.u32Vector(); err == nil {
+			_, err = r.u32()
+		}
+	case op == opCallIndirect:
+		if _, err = r.u32(); err == nil {
+			err = r.reservedByte()
+		}
+	case op >= opI32Load && op <= opI64Store32:
+		if isFloatMemoryOp(op) {
+			return fmt.Errorf("Floating point instruction 0x%X is not supported", op)
+		}
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == opMemorySize || op == opMemoryGrow:
+		err = r.reservedByte()
+	case op == opI32Const:
+		_, err = r.signed(32)
+	case op == opI64Const:
+		_, err = r.signed(64)
+	case isFloatOp(op):
+		return fmt.Errorf("Floating point instruction 0x%X is not supported", op)
+	case !knownOp(op):
+		return fmt.Errorf("Unknown WASM instruction 0x%X", op)
+	}
+	return err
+}
+
+func (r *reader) reservedByte() error {
+	b, err := r.byte()
+	if err == nil && b != 0 {
+		err = errors.New("Reserved byte must be zero")
+	}
+	return err
+}
+
+func valueTypeBytes(types []ValueType) []byte {
+	bs := make([]byte, len(types))
+	for i, typ := range types {
+		bs[i] = byte(typ)
+	}
+	return bs
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+const (
+	// Control
+	opUnreachable  byte = 0x00
+	opNop          byte = 0x01
+	opBlock        byte = 0x02
+	opLoop         byte = 0x03
+	opIf           byte = 0x04
+	opElse         byte = 0x05
+	opEnd          byte = 0x0B
+	opBr           byte = 0x0C
+	opBrIf         byte = 0x0D
+	opBrTable      byte = 0x0E
+	opReturn       byte = 0x0F
+	opCall         byte = 0x10
+	opCallIndirect byte = 0x11
+
+	// Parametric
+	opDrop   byte = 0x1A
+	opSelect byte = 0x1B
+
+	// Variables
+	opGetLocal  byte = 0x20
+	opSetLocal  byte = 0x21
+	opTeeLocal  byte = 0x22
+	opGetGlobal byte = 0x23
+	opSetGlobal byte = 0x24
+
+	// Memory
+	opI32Load    byte = 0x28
+	opI64Load    byte = 0x29
+	opF32Load    byte = 0x2A
+	opF64Load    byte = 0x2B
+	opI32Load8S  byte = 0x2C
+	opI32Load8U  byte = 0x2D
+	opI32Load16S byte = 0x2E
+	opI32Load16U byte = 0x2F
+	opI64Load8S  byte = 0x30
+	opI64Load8U  byte = 0x31
+	opI64Load16S byte = 0x32
+	opI64Load16U byte = 0x33
+	opI64Load32S byte = 0x34
+	opI64Load32U byte = 0x35
+	opI32Store   byte = 0x36
+	opI64Store   byte = 0x37
+	opF32Store   byte = 0x38
+	opF64Store   byte = 0x39
+	opI32Store8  byte = 0x3A
+	opI32Store16 byte = 0x3B
+	opI64Store8  byte = 0x3C
+	opI64Store16 byte = 0x3D
+	opI64Store32 byte = 0x3E
+	opMemorySize byte = 0x3F
+	opMemoryGrow byte = 0x40
+
+	// Numeric
+	opI32Const byte = 0x41
+	opI64Const byte = 0x42
+	opF32Const byte = 0x43
+	opF64Const byte = 0x44
+
+	opI32Eqz byte = 0x45
+	opI32Eq  byte = 0x46
+	opI32Ne  byte = 0x47
+	opI32LtS byte = 0x48
+	opI32LtU byte = 0x49
+	opI32GtS byte = 0x4A
+	opI32GtU byte = 0x4B
+	opI32LeS byte = 0x4C
+	opI32LeU byte = 0x4D
+	opI32GeS byte = 0x4E
+	opI32GeU byte = 0x4F
+
+	opI64Eqz byte = 0x50
+	opI64Eq  byte = 0x51
+	opI64Ne  byte = 0x52
+	opI64LtS byte = 0x53
+	opI64LtU byte = 0x54
+	opI64GtS byte = 0x55
+	opI64GtU byte = 0x56
+	opI64LeS byte = 0x57
+	opI64LeU byte = 0x58
+	opI64GeS byte = 0x59
+	opI64GeU byte = 0x5A
+
+	opI32Clz    byte = 0x67
+	opI32Ctz    byte = 0x68
+	opI32Popcnt byte = 0x69
+	opI32Add    byte = 0x6A
+	opI32Sub    byte = 0x6B
+	opI32Mul    byte = 0x6C
+	opI32DivS   byte = 0x6D
+	opI32DivU   byte = 0x6E
+	opI32RemS   byte = 0x6F
+	opI32RemU   byte = 0x70
+	opI32And    byte = 0x71
+	opI32Or     byte = 0x72
+	opI32Xor    byte = 0x73
+	opI32Shl    byte = 0x74
+	opI32ShrS   byte = 0x75
+	opI32ShrU   byte = 0x76
+	opI32Rotl   byte = 0x77
+	opI32Rotr   byte = 0x78
+
+	opI64Clz    byte = 0x79
+	opI64Ctz    byte = 0x7A
+	opI64Popcnt byte = 0x7B
+	opI64Add    byte = 0x7C
+	opI64Sub    byte = 0x7D
+	opI64Mul    byte = 0x7E
+	opI64DivS   byte = 0x7F
+	opI64DivU   byte = 0x80
+	opI64RemS   byte = 0x81
+	opI64RemU   byte = 0x82
+	opI64And    byte = 0x83
+	opI64Or     byte = 0x84
+	opI64Xor    byte = 0x85
+	opI64Shl    byte = 0x86
+	opI64ShrS   byte = 0x87
+	opI64ShrU   byte = 0x88
+	opI64Rotl   byte = 0x89
+	opI64Rotr   byte = 0x8A
+
+	opI32WrapI64    byte = 0xA7
+	opI64ExtendSI32 byte = 0xAC
+	opI64ExtendUI32 byte = 0xAD
+)
+
+// The numeric float instructions and conversions to and from floats
+func isFloatOp(op byte) bool {
+	return op == opF32Const || op == opF64Const ||
+		(op >= 0x5B && op <= 0x66) ||
+		(op >= 0x8B && op <= 0xA6) ||
+		(op >= 0xA8 && op <= 0xAB) ||
+		(op >= 0xAE && op <= 0xBF)
+}
+
+func isFloatMemoryOp(op byte) bool {
+	return op == opF32Load || op == opF64Load || op == opF32Store || op == opF64Store
+}
+
+func knownOp(op byte) bool {
+	switch {
+	case op <= opIf, op == opElse, op >= opEnd && op <= opCallIndirect,
+		op == opDrop, op == opSelect, op >= opGetLocal && op <= opSetGlobal,
+		op >= opI32Load && op <= opI64Const,
+		op >= opI32Eqz && op <= opI64GeU,
+		op >= opI32Clz && op <= opI64Rotr,
+		op == opI32WrapI64, op == opI64ExtendSI32, op == opI64ExtendUI32:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,400 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helpers to assemble WASM binaries
+
+func leb(n uint64) []byte {
+	var bs []byte
+	for {
+		b := byte(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(bs, b)
+		}
+		bs = append(bs, b|0x80)
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func vector(items ...[]byte) []byte {
+	return concat(leb(uint64(len(items))), concat(items...))
+}
+
+func section(id byte, items ...[]byte) []byte {
+	contents := vector(items...)
+	return concat([]byte{id}, leb(uint64(len(contents))), contents)
+}
+
+func wasmModule(sections ...[]byte) []byte {
+	return concat(magic, []byte{1, 0, 0, 0}, concat(sections...))
+}
+
+func funcType(params, results []byte) []byte {
+	return concat([]byte{0x60}, leb(uint64(len(params))), params,
+		leb(uint64(len(results))), results)
+}
+
+func name(s string) []byte {
+	return concat(leb(uint64(len(s))), []byte(s))
+}
+
+func export(exportName string, index byte) []byte {
+	return concat(name(exportName), []byte{ExternalFunction, index})
+}
+
+// A function body with numLocals i32 locals
+func body(numLocals byte, code ...byte) []byte {
+	locals := []byte{0}
+	if numLocals > 0 {
+		locals = []byte{1, numLocals, byte(I32)}
+	}
+	b := concat(locals, code)
+	return concat(leb(uint64(len(b))), b)
+}
+
+func instantiate(t *testing.T, code []byte, resolver ImportResolver) *Instance {
+	module, err := DecodeModule(code)
+	require.NoError(t, err)
+	if resolver == nil {
+		resolver = func(module, name string, typ FunctionType) (HostFunction, error) {
+			return nil, fmt.Errorf("No import %s.%s", module, name)
+		}
+	}
+	gas := int64(1000000)
+	inst, err := Instantiate(module, resolver, &gas)
+	require.NoError(t, err)
+	return inst
+}
+
+var (
+	i32 = byte(I32)
+	i64 = byte(I64)
+)
+
+func TestFactorial(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType([]byte{i64}, []byte{i64})),
+		section(sectionFunction, []byte{0}),
+		section(sectionExport, export("fac", 0)),
+		section(sectionCode, body(0,
+			opGetLocal, 0,
+			opI64Eqz,
+			opIf, i64,
+			opI64Const, 1,
+			opElse,
+			opGetLocal, 0,
+			opGetLocal, 0,
+			opI64Const, 1,
+			opI64Sub,
+			opCall, 0,
+			opI64Mul,
+			opEnd,
+			opEnd)),
+	)
+	inst := instantiate(t, code, nil)
+	results, err := inst.Invoke("fac", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2432902008176640000}, results)
+}
+
+func sumModule() []byte {
+	return wasmModule(
+		section(sectionType, funcType([]byte{i32}, []byte{i32})),
+		section(sectionFunction, []byte{0}),
+		section(sectionExport, export("sum", 0)),
+		section(sectionCode, body(1,
+			opBlock, emptyBlockType,
+			opLoop, emptyBlockType,
+			opGetLocal, 0,
+			opI32Eqz,
+			opBrIf, 1,
+			opGetLocal, 1,
+			opGetLocal, 0,
+			opI32Add,
+			opSetLocal, 1,
+			opGetLocal, 0,
+			opI32Const, 1,
+			opI32Sub,
+			opSetLocal, 0,
+			opBr, 0,
+			opEnd,
+			opEnd,
+			opGetLocal, 1,
+			opEnd)),
+	)
+}
+
+func TestLoop(t *testing.T) {
+	inst := instantiate(t, sumModule(), nil)
+	results, err := inst.Invoke("sum", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5050}, results)
+
+	// Out of gas
+	gas := int64(100)
+	inst.gas = &gas
+	_, err = inst.Invoke("sum", 100)
+	assert.Equal(t, ErrInsufficientGas, err)
+	assert.Equal(t, int64(0), gas)
+}
+
+func TestBrTable(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType([]byte{i32}, []byte{i32})),
+		section(sectionFunction, []byte{0}),
+		section(sectionExport, export("switch", 0)),
+		section(sectionCode, body(0,
+			opBlock, emptyBlockType,
+			opBlock, emptyBlockType,
+			opGetLocal, 0,
+			opBrTable, 2, 0, 1, 1,
+			opEnd,
+			opI32Const, 10,
+			opReturn,
+			opEnd,
+			opI32Const, 20,
+			opEnd)),
+	)
+	inst := instantiate(t, code, nil)
+	for i, expected := range []uint64{10, 20, 20} {
+		results, err := inst.Invoke("switch", uint64(i*i))
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{expected}, results)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType(nil, []byte{i32}), funcType(nil, []byte{i64})),
+		section(sectionFunction, []byte{0}, []byte{1}, []byte{0}, []byte{0}),
+		section(sectionMemory, []byte{1, 1, 2}),
+		section(sectionExport, export("load", 0), export("store", 1),
+			export("grow", 2), export("outOfBounds", 3)),
+		section(sectionCode,
+			body(0,
+				opI32Const, 8,
+				opI32Load, 2, 0,
+				opEnd),
+			body(0,
+				opI32Const, 16,
+				opI64Const, 0x7E, // -2
+				opI64Store, 3, 0,
+				opI32Const, 16,
+				opI64Load32S, 2, 0,
+				opEnd),
+			body(0,
+				opI32Const, 1,
+				opMemoryGrow, 0,
+				opEnd),
+			body(0,
+				opI32Const, 0x7F, // -1
+				opI32Load, 2, 0,
+				opEnd)),
+		section(sectionData, concat([]byte{0, opI32Const, 8, opEnd}, vector(
+			[]byte{1}, []byte{2}, []byte{3}, []byte{4}))),
+	)
+	inst := instantiate(t, code, nil)
+	results, err := inst.Invoke("load")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0x04030201}, results)
+
+	results, err = inst.Invoke("store")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0xFFFFFFFFFFFFFFFE}, results)
+
+	results, err = inst.Invoke("grow")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, results)
+	assert.Len(t, inst.Memory(), 2*PageSize)
+	// At the maximum
+	results, err = inst.Invoke("grow")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0xFFFFFFFF}, results)
+
+	_, err = inst.Invoke("outOfBounds")
+	assert.Equal(t, ErrMemoryOutOfBounds, err)
+}
+
+func TestInstantiateLimits(t *testing.T) {
+	instantiateWith := func(gas int64, sections ...[]byte) (*Instance, int64, error) {
+		module, err := DecodeModule(wasmModule(sections...))
+		require.NoError(t, err)
+		inst, err := Instantiate(module, nil, &gas)
+		return inst, gas, err
+	}
+	grow := func(limits []byte) []byte {
+		return concat(
+			section(sectionType, funcType(nil, []byte{i32})),
+			section(sectionFunction, []byte{0}),
+			section(sectionMemory, limits),
+			section(sectionExport, export("grow", 0)),
+			section(sectionCode, body(0,
+				opI32Const, 1,
+				opMemoryGrow, 0,
+				opEnd)),
+		)
+	}
+
+	// The initial pages are charged for
+	_, gas, err := instantiateWith(1000, grow([]byte{0, 3}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1000-3*GasMemoryPage, gas)
+	_, _, err = instantiateWith(3*GasMemoryPage-1, grow([]byte{0, 3}))
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	// Memory cannot start above the default maximum, nor grow past it
+	// whatever maximum the module gives
+	_, _, err = instantiateWith(1<<20, grow(concat([]byte{0}, leb(defaultMaxPages+1))))
+	assert.Error(t, err)
+	inst, _, err := instantiateWith(1<<20, grow(concat([]byte{1}, leb(defaultMaxPages),
+		leb(MaxPages))))
+	require.NoError(t, err)
+	results, err := inst.Invoke("grow")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0xFFFFFFFF}, results)
+
+	_, _, err = instantiateWith(1000, section(sectionTable,
+		concat([]byte{0x70, 0}, leb(maxTableSize+1))))
+	assert.Error(t, err)
+}
+
+func TestHostFunction(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType([]byte{i32}, []byte{i32}), funcType(nil, []byte{i32})),
+		section(sectionImport, concat(name("env"), name("double"), []byte{ExternalFunction, 0})),
+		section(sectionFunction, []byte{1}),
+		section(sectionExport, export("main", 1)),
+		section(sectionCode, body(0,
+			opI32Const, 21,
+			opCall, 0,
+			opEnd)),
+	)
+	inst := instantiate(t, code, func(module, name string, typ FunctionType) (HostFunction, error) {
+		assert.Equal(t, "env", module)
+		assert.Equal(t, "double", name)
+		return func(instance *Instance, args []uint64) ([]uint64, error) {
+			return []uint64{args[0] * 2}, nil
+		}, nil
+	})
+	results, err := inst.Invoke("main")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{42}, results)
+
+	// Errors from the host stop execution
+	halt := fmt.Errorf("halt")
+	inst = instantiate(t, code, func(module, name string, typ FunctionType) (HostFunction, error) {
+		return func(instance *Instance, args []uint64) ([]uint64, error) {
+			return nil, halt
+		}, nil
+	})
+	_, err = inst.Invoke("main")
+	assert.Equal(t, halt, err)
+}
+
+func TestCallIndirect(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType(nil, []byte{i32}), funcType([]byte{i32}, []byte{i32})),
+		section(sectionFunction, []byte{0}, []byte{0}, []byte{1}),
+		section(sectionTable, []byte{0x70, 0, 3}),
+		section(sectionExport, export("dispatch", 2)),
+		section(sectionElement, concat([]byte{0, opI32Const, 0, opEnd}, vector([]byte{0}, []byte{1}))),
+		section(sectionCode,
+			body(0, opI32Const, 1, opEnd),
+			body(0, opI32Const, 2, opEnd),
+			body(0,
+				opGetLocal, 0,
+				opCallIndirect, 0, 0,
+				opEnd)),
+	)
+	inst := instantiate(t, code, nil)
+	results, err := inst.Invoke("dispatch", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2}, results)
+
+	_, err = inst.Invoke("dispatch", 2)
+	assert.Equal(t, ErrUndefinedElement, err)
+}
+
+func TestTraps(t *testing.T) {
+	code := wasmModule(
+		section(sectionType, funcType(nil, []byte{i32})),
+		section(sectionFunction, []byte{0}, []byte{0}, []byte{0}),
+		section(sectionExport, export("unreachable", 0), export("divideByZero", 1),
+			export("recurse", 2)),
+		section(sectionCode,
+			body(0, opUnreachable, opEnd),
+			body(0, opI32Const, 1, opI32Const, 0, opI32DivU, opEnd),
+			body(0, opCall, 2, opEnd)),
+	)
+	inst := instantiate(t, code, nil)
+	_, err := inst.Invoke("unreachable")
+	assert.Equal(t, ErrUnreachable, err)
+	_, err = inst.Invoke("divideByZero")
+	assert.Equal(t, ErrIntegerDivideByZero, err)
+	_, err = inst.Invoke("recurse")
+	assert.Equal(t, ErrCallStackExhausted, err)
+	// A trap leaves the instance usable
+	_, err = inst.Invoke("divideByZero")
+	assert.Equal(t, ErrIntegerDivideByZero, err)
+}
+
+func TestDecodeModule(t *testing.T) {
+	assert.True(t, IsWASM(sumModule()))
+	assert.False(t, IsWASM([]byte{0x60, 0x60}))
+	_, err := DecodeModule([]byte{0x60, 0x60})
+	assert.Equal(t, ErrNotWASM, err)
+
+	// Floating point
+	_, err = DecodeModule(wasmModule(
+		section(sectionType, funcType(nil, nil)),
+		section(sectionFunction, []byte{0}),
+		section(sectionCode, body(0, opF32Const, 0, 0, 0, 0, opDrop, opEnd)),
+	))
+	assert.Error(t, err)
+
+	// Truncated
+	code := sumModule()
+	_, err = DecodeModule(code[:len(code)-3])
+	assert.Error(t, err)
+
+	// Missing end
+	_, err = DecodeModule(wasmModule(
+		section(sectionType, funcType(nil, nil)),
+		section(sectionFunction, []byte{0}),
+		section(sectionCode, body(0, opBlock, emptyBlockType, opEnd)),
+	))
+	assert.Error(t, err)
+
+	// A br_table with fewer depths than it says
+	_, err = DecodeModule(wasmModule(
+		section(sectionType, funcType(nil, nil)),
+		section(sectionFunction, []byte{0}),
+		section(sectionCode, body(0, opI32Const, 0, opBrTable, 5, 0, opEnd)),
+	))
+	assert.Error(t, err)
+}
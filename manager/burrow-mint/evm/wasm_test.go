@@ -0,0 +1,93 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/hyperledger/burrow/word256"
+	"github.com/stretchr/testify/assert"
+)
+
+func wasmSection(id byte, items ...[]byte) []byte {
+	contents := append([]byte{byte(len(items))}, bytes.Join(items, nil)...)
+	return append([]byte{id, byte(len(contents))}, contents...)
+}
+
+func wasmName(name string) []byte {
+	return append([]byte{byte(len(name))}, name...)
+}
+
+// A WASM contract that stores the first word of its input under the zero key
+// and then calls end with the offset and length of the word
+func storeInputWASM(end string) []byte {
+	code := []byte{
+		0,                                   // no locals
+		0x41, 0, 0x41, 0, 0x41, 32, 0x10, 0, // callDataCopy(0, 0, 32)
+		0x41, 32, 0x41, 0, 0x10, 1, // storageStore(32, 0)
+		0x41, 0, 0x41, 32, 0x10, 2, // end(0, 32)
+		0x0B,
+	}
+	return bytes.Join([][]byte{
+		{0x00, 0x61, 0x73, 0x6D, 1, 0, 0, 0},
+		wasmSection(1,
+			[]byte{0x60, 3, 0x7F, 0x7F, 0x7F, 0},
+			[]byte{0x60, 2, 0x7F, 0x7F, 0},
+			[]byte{0x60, 0, 0}),
+		wasmSection(2,
+			append(append(wasmName("ethereum"), wasmName("callDataCopy")...), 0, 0),
+			append(append(wasmName("ethereum"), wasmName("storageStore")...), 0, 1),
+			append(append(wasmName("ethereum"), wasmName(end)...), 0, 1)),
+		wasmSection(3, []byte{2}),
+		wasmSection(5, []byte{0, 1}),
+		wasmSection(7,
+			append(wasmName("main"), 0, 3),
+			append(wasmName("memory"), 2, 0)),
+		wasmSection(10, append([]byte{byte(len(code))}, code...)),
+	}, nil)
+}
+
+func TestWASMCall(t *testing.T) {
+	appState := newAppState()
+	ourVm := NewVM(appState, DefaultDynamicMemoryProvider, newParams(), Zero256, nil)
+	account1 := &Account{
+		Address: Int64ToWord256(100),
+	}
+	account2 := &Account{
+		Address: Int64ToWord256(101),
+	}
+	input := Int64ToWord256(42).Bytes()
+
+	var gas int64 = 100000
+	output, err := ourVm.Call(account1, account2, storeInputWASM("finish"), input, 0, &gas)
+	assert.NoError(t, err)
+	assert.Equal(t, input, output)
+	assert.Equal(t, Int64ToWord256(42), appState.GetStorage(account2.Address, Zero256))
+	assert.True(t, gas < 100000)
+
+	output, err = ourVm.Call(account1, account2, storeInputWASM("revert"), input, 0, &gas)
+	assert.Equal(t, ErrExecutionReverted, err)
+	assert.Equal(t, input, output)
+
+	gas = 5
+	_, err = ourVm.Call(account1, account2, storeInputWASM("finish"), input, 0, &gas)
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	// Only the EEI can be imported
+	gas = 100000
+	_, err = ourVm.Call(account1, account2, storeInputWASM("exit"), input, 0, &gas)
+	assert.Error(t, err)
+}
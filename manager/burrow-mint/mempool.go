@@ -0,0 +1,268 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/burrow/txs"
+)
+
+// The orders txs can be given places in a full mempool in
+const (
+	// Txs keep their places in the order they arrived
+	FIFOPriority = "fifo"
+	// Txs paying a higher priority fee for each unit of gas come first
+	GasPricePriority = "gas_price"
+	// Txs sent by the whitelisted accounts come first
+	WhitelistPriority = "whitelist"
+)
+
+var errTxEvicted = errors.New("Evicted from the mempool by a tx with a " +
+	"higher priority")
+
+// The zero value of MempoolOptions places no limit on the mempool
+type MempoolOptions struct {
+	// How txs are prioritised once the mempool is full, FIFOPriority when
+	// empty
+	Priority string
+	// The accounts whose txs come first by WhitelistPriority
+	Whitelist [][]byte
+	// The gas the txs waiting in the mempool may be given in all, no limit
+	// when 0
+	MaxGas int64
+}
+
+func (opts MempoolOptions) Enabled() bool {
+	return opts.MaxGas > 0
+}
+
+// Gives txs their priority for a place in the mempool once it is full
+type TxPrioritiser interface {
+	// Txs of a higher priority take the places of those of a lower one
+	Priority(tx txs.Tx) float64
+}
+
+type TxPrioritiserFunc func(tx txs.Tx) float64
+
+func (f TxPrioritiserFunc) Priority(tx txs.Tx) float64 {
+	return f(tx)
+}
+
+// Gets the TxPrioritiser for the priority of options
+func NewTxPrioritiser(chainID string, options MempoolOptions) (TxPrioritiser, error) {
+	switch options.Priority {
+	case "", FIFOPriority:
+		return TxPrioritiserFunc(func(tx txs.Tx) float64 { return 0 }), nil
+	case GasPricePriority:
+		return TxPrioritiserFunc(func(tx txs.Tx) float64 {
+			return gasPrice(chainID, tx)
+		}), nil
+	case WhitelistPriority:
+		return TxPrioritiserFunc(func(tx txs.Tx) float64 {
+			sender := txSender(chainID, tx)
+			for _, address := range options.Whitelist {
+				if bytes.Equal(sender, address) {
+					return 1
+				}
+			}
+			return 0
+		}), nil
+	}
+	return nil, fmt.Errorf("Unknown mempool priority '%s', expected one of "+
+		"'%s', '%s' or '%s'", options.Priority, FIFOPriority, GasPricePriority,
+		WhitelistPriority)
+}
+
+// The gas tx is given, 0 when it is not a call
+func txGas(tx txs.Tx) int64 {
+	switch tx := tx.(type) {
+	case *txs.CallTx:
+		return tx.GasLimit
+	case *txs.EthTx:
+		return int64(tx.GasLimit)
+	case *txs.PriorityFeeTx, *txs.ExpiringTx:
+		return txGas(txs.InnerTx(tx))
+	}
+	return 0
+}
+
+// The account that sends tx, nil when it has none or more than one
+func txSender(chainID string, tx txs.Tx) []byte {
+	switch tx := tx.(type) {
+	case *txs.CallTx:
+		return tx.Input.Address
+	case *txs.SendTx:
+		if len(tx.Inputs) == 1 {
+			return tx.Inputs[0].Address
+		}
+	case *txs.NameTx:
+		return tx.Input.Address
+	case *txs.PrivateTx:
+		return tx.Input.Address
+	case *txs.EthTx:
+		sender, err := tx.Sender(chainID)
+		if err == nil {
+			return sender
+		}
+	case *txs.PriorityFeeTx, *txs.ExpiringTx:
+		return txSender(chainID, txs.InnerTx(tx))
+	}
+	return nil
+}
+
+// The priority fee tx pays for each unit of its gas, taking txs that are not
+// calls to use a unit
+func gasPrice(chainID string, tx txs.Tx) float64 {
+	if tx, ok := tx.(*txs.EthTx); ok {
+		return float64(tx.GasPrice)
+	}
+	gas := txGas(tx)
+	if gas <= 0 {
+		gas = 1
+	}
+	return float64(txs.PriorityFee(tx)) / float64(gas)
+}
+
+// Keeps account of the gas of the txs that CheckTx let into the mempool, by
+// hash, so that once the mempool holds MaxGas a new tx is only let in if it
+// can evict txs of a lower priority to make room. Tendermint only removes txs
+// from its mempool that are included in a block or that fail when they are
+// rechecked after a commit, so those evicted are failed when they are
+// rechecked.
+type txPool struct {
+	sync.Mutex
+	maxGas      int64
+	prioritiser TxPrioritiser
+	pending     map[string]*pendingTx
+	// The gas of the pending txs that have not been evicted
+	gas int64
+	// The number of txs added, to order them by
+	added int
+}
+
+type pendingTx struct {
+	gas      int64
+	priority float64
+	// The order the tx was added in, of those of its priority the last added
+	// are evicted first
+	order   int
+	evicted bool
+}
+
+func newTxPool(chainID string, options MempoolOptions) (*txPool, error) {
+	prioritiser, err := NewTxPrioritiser(chainID, options)
+	if err != nil {
+		return nil, err
+	}
+	return &txPool{
+		maxGas:      options.MaxGas,
+		prioritiser: prioritiser,
+		pending:     make(map[string]*pendingTx),
+	}, nil
+}
+
+// Returns whether the tx with hash is in the mempool, so is being rechecked,
+// or an error if it has been evicted, which removes it
+func (pool *txPool) recheck(hash []byte) (bool, error) {
+	pool.Lock()
+	defer pool.Unlock()
+	pending, ok := pool.pending[string(hash)]
+	if !ok {
+		return false, nil
+	}
+	if pending.evicted {
+		delete(pool.pending, string(hash))
+		return true, errTxEvicted
+	}
+	return true, nil
+}
+
+// Checks that there is room in the mempool for tx, or that there would be once
+// txs of a lower priority were evicted
+func (pool *txPool) checkRoom(tx txs.Tx) error {
+	pool.Lock()
+	defer pool.Unlock()
+	gas := txGas(tx)
+	if gas > pool.maxGas {
+		return fmt.Errorf("Gas %v of tx is above the gas the mempool can hold %v",
+			gas, pool.maxGas)
+	}
+	if len(pool.evictions(gas, pool.prioritiser.Priority(tx))) == 0 &&
+		pool.gas+gas > pool.maxGas {
+		return fmt.Errorf("Mempool is full with txs of at least the priority of tx")
+	}
+	return nil
+}
+
+// Adds the tx with hash that CheckTx let into the mempool, evicting txs of a
+// lower priority to make room for it
+func (pool *txPool) add(hash []byte, tx txs.Tx) {
+	pool.Lock()
+	defer pool.Unlock()
+	gas := txGas(tx)
+	priority := pool.prioritiser.Priority(tx)
+	for _, evicted := range pool.evictions(gas, priority) {
+		evicted.evicted = true
+		pool.gas -= evicted.gas
+	}
+	pool.pending[string(hash)] = &pendingTx{gas: gas, priority: priority,
+		order: pool.added}
+	pool.gas += gas
+	pool.added++
+}
+
+// Removes the tx with hash from the pool, once it is in a block or has failed
+// to be rechecked
+func (pool *txPool) remove(hash []byte) {
+	pool.Lock()
+	defer pool.Unlock()
+	if pending, ok := pool.pending[string(hash)]; ok {
+		delete(pool.pending, string(hash))
+		if !pending.evicted {
+			pool.gas -= pending.gas
+		}
+	}
+}
+
+// The txs of lowest priority below priority to evict to make room for gas, or
+// none if there would not be room even having evicted them all
+func (pool *txPool) evictions(gas int64, priority float64) []*pendingTx {
+	var evictions []*pendingTx
+	evicted := make(map[*pendingTx]bool)
+	for room := pool.maxGas - pool.gas; room < gas; {
+		var lowest *pendingTx
+		for _, pending := range pool.pending {
+			if pending.evicted || evicted[pending] || pending.gas == 0 ||
+				pending.priority >= priority {
+				continue
+			}
+			if lowest == nil || pending.priority < lowest.priority ||
+				(pending.priority == lowest.priority && pending.order > lowest.order) {
+				lowest = pending
+			}
+		}
+		if lowest == nil {
+			return nil
+		}
+		evicted[lowest] = true
+		evictions = append(evictions, lowest)
+		room += lowest.gas
+	}
+	return evictions
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/txs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callTx(sender byte, gas, priorityFee int64) *txs.PriorityFeeTx {
+	return &txs.PriorityFeeTx{
+		PriorityFee: priorityFee,
+		Tx: &txs.CallTx{
+			Input:    &txs.TxInput{Address: []byte{sender}},
+			GasLimit: gas,
+		},
+	}
+}
+
+func TestTxPoolFIFO(t *testing.T) {
+	pool, err := newTxPool("test", MempoolOptions{MaxGas: 100})
+	require.NoError(t, err)
+	require.NoError(t, pool.checkRoom(callTx(1, 60, 0)))
+	pool.add([]byte("a"), callTx(1, 60, 0))
+	// The mempool keeps the places of the txs that arrived first
+	assert.Error(t, pool.checkRoom(callTx(2, 60, 1000)))
+	assert.Error(t, pool.checkRoom(callTx(2, 101, 0)))
+	assert.NoError(t, pool.checkRoom(callTx(2, 40, 0)))
+
+	rechecking, err := pool.recheck([]byte("a"))
+	assert.True(t, rechecking)
+	assert.NoError(t, err)
+	rechecking, err = pool.recheck([]byte("b"))
+	assert.False(t, rechecking)
+
+	// Makes room once delivered
+	pool.remove([]byte("a"))
+	assert.NoError(t, pool.checkRoom(callTx(2, 60, 0)))
+}
+
+func TestTxPoolGasPrice(t *testing.T) {
+	pool, err := newTxPool("test", MempoolOptions{
+		MaxGas:   100,
+		Priority: GasPricePriority,
+	})
+	require.NoError(t, err)
+	pool.add([]byte("a"), callTx(1, 50, 50))
+	pool.add([]byte("b"), callTx(1, 50, 100))
+	pool.add([]byte("c"), &txs.NameTx{Input: &txs.TxInput{Address: []byte{1}}})
+	// Pays less each unit of gas than both
+	assert.Error(t, pool.checkRoom(callTx(2, 10, 5)))
+	// Would need to evict b, which pays as much
+	assert.Error(t, pool.checkRoom(callTx(2, 60, 120)))
+
+	tx := callTx(2, 50, 100)
+	require.NoError(t, pool.checkRoom(tx))
+	pool.add([]byte("d"), tx)
+	_, err = pool.recheck([]byte("a"))
+	assert.Equal(t, errTxEvicted, err)
+	for _, hash := range []string{"b", "c", "d"} {
+		_, err = pool.recheck([]byte(hash))
+		assert.NoError(t, err)
+	}
+	// Evicted txs are removed once rechecked
+	rechecking, err := pool.recheck([]byte("a"))
+	assert.False(t, rechecking)
+	assert.NoError(t, err)
+}
+
+func TestTxPoolWhitelist(t *testing.T) {
+	pool, err := newTxPool("test", MempoolOptions{
+		MaxGas:    100,
+		Priority:  WhitelistPriority,
+		Whitelist: [][]byte{{1}},
+	})
+	require.NoError(t, err)
+	pool.add([]byte("a"), callTx(2, 40, 0))
+	pool.add([]byte("b"), callTx(2, 40, 0))
+	pool.add([]byte("c"), callTx(1, 20, 0))
+	assert.Error(t, pool.checkRoom(callTx(3, 40, 1000)))
+	pool.add([]byte("d"), callTx(1, 40, 0))
+	// The last of the txs that are not whitelisted is evicted first
+	_, err = pool.recheck([]byte("a"))
+	assert.NoError(t, err)
+	_, err = pool.recheck([]byte("b"))
+	assert.Equal(t, errTxEvicted, err)
+
+	_, err = newTxPool("test", MempoolOptions{MaxGas: 100, Priority: "random"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"github.com/hyperledger/burrow/metrics"
+)
+
+var (
+	blockHeight = metrics.NewGauge("burrow_block_height",
+		"The height of the last block committed")
+	blockInterval = metrics.NewHistogram("burrow_block_interval_seconds",
+		"The time between the commits of consecutive blocks",
+		[]float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60})
+	blockGasUsed = metrics.NewHistogram("burrow_block_gas_used",
+		"The gas used by the calls of the CallTxs of each block",
+		metrics.ExponentialBuckets(1000, 10, 7))
+	txsDelivered = metrics.NewCounter("burrow_txs_total",
+		"The txs executed in blocks, by whether they succeeded", "success")
+	simulatedCalls = metrics.NewCounter("burrow_simulated_calls_total",
+		"The calls simulated for queries, by whether they succeeded, failed, "+
+			"or timed out", "result")
+)
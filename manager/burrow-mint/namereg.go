@@ -66,8 +66,19 @@ func newNameReg(burrowMint *BurrowMint) *namereg {
 }
 
 func (this *namereg) Entry(key string) (*core_types.NameRegEntry, error) {
-	st := this.burrowMint.GetState() // performs a copy
-	entry := st.GetNameRegEntry(key)
+	return this.HistoricalEntry(key, 0)
+}
+
+func (this *namereg) HistoricalEntry(key string, height int) (
+	*core_types.NameRegEntry, error) {
+	var entry *core_types.NameRegEntry
+	err := this.burrowMint.ReadStateAt(height, func(st *sm.State) error {
+		entry = st.GetNameRegEntry(key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	if entry == nil {
 		return nil, fmt.Errorf("Entry %s not found", key)
 	}
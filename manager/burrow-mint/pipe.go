@@ -16,7 +16,11 @@ package burrowmint
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"path"
 
 	abci_types "github.com/tendermint/abci/types"
 	crypto "github.com/tendermint/go-crypto"
@@ -35,12 +39,15 @@ import (
 	edb_event "github.com/hyperledger/burrow/event"
 	genesis "github.com/hyperledger/burrow/genesis"
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
 	logging_types "github.com/hyperledger/burrow/logging/types"
 	vm "github.com/hyperledger/burrow/manager/burrow-mint/evm"
 	"github.com/hyperledger/burrow/manager/burrow-mint/state"
 	manager_types "github.com/hyperledger/burrow/manager/types"
 	rpc_tm_types "github.com/hyperledger/burrow/rpc/tendermint/core/types"
+	"github.com/hyperledger/burrow/storage"
 	"github.com/hyperledger/burrow/txs"
+	"github.com/hyperledger/burrow/version"
 	"github.com/hyperledger/burrow/word256"
 )
 
@@ -54,6 +61,10 @@ type burrowMintPipe struct {
 	events          edb_event.EventEmitter
 	namereg         *namereg
 	transactor      *transactor
+	calls           *callPool
+	// Seals and opens the blobs of the confidential store, nil if the node
+	// holds no key for it
+	confidential *confidentialStore
 	// Genesis cache
 	genesisDoc   *genesis.GenesisDoc
 	genesisState *state.State
@@ -69,25 +80,129 @@ func NewBurrowMintPipe(moduleConfig *config.ModuleConfig,
 	eventSwitch go_events.EventSwitch,
 	logger logging_types.InfoTraceLogger) (*burrowMintPipe, error) {
 
-	startedState, genesisDoc, err := startState(moduleConfig.DataDir,
-		moduleConfig.Config.GetString("db_backend"), moduleConfig.GenesisFile,
-		moduleConfig.ChainId)
+	stateDB, err := newStateDB(moduleConfig.DataDir,
+		moduleConfig.Config.GetString("db_backend"))
 	if err != nil {
 		return nil, fmt.Errorf("Failed to start state: %v", err)
 	}
-	logger = logging.WithScope(logger, "BurrowMintPipe")
+	var writeBuffer *state.WriteBuffer
+	if moduleConfig.Config.GetBool("write_buffer.enabled") {
+		writeBuffer, err = state.NewWriteBuffer(stateDB,
+			loadWriteBufferOptions(moduleConfig))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to start write buffer: %v", err)
+		}
+		stateDB = writeBuffer
+	}
+	startedState, genesisDoc, err := startState(stateDB,
+		moduleConfig.GenesisFile, moduleConfig.ChainId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start state: %v", err)
+	}
+	if writeBuffer != nil {
+		// write any genesis state before the first block
+		writeBuffer.Flush()
+	}
+	logger = logging.WithScope(logging.WithSubsystem(logger, structure.StateSubsystem),
+		"BurrowMintPipe")
 	// assert ChainId matches genesis ChainId
 	logging.InfoMsg(logger, "Loaded state",
 		"chainId", startedState.ChainID,
 		"lastBlockHeight", startedState.LastBlockHeight,
 		"lastBlockHash", startedState.LastBlockHash)
+	// only the binary of the upgrade the chain has halted for may go on
+	if err := startedState.CheckUpgrade(version.GetSemanticVersionString()); err != nil {
+		return nil, err
+	}
+	if startedState.UpgradeDue() {
+		logging.InfoMsg(logger, "Taking over the chain for upgrade",
+			"name", startedState.Upgrade.Name,
+			"height", startedState.Upgrade.Height)
+	}
+	if readCacheOptions := loadReadCacheOptions(moduleConfig); readCacheOptions.Enabled() {
+		logging.InfoMsg(logger, "Caching reads of state",
+			"accounts", readCacheOptions.Accounts,
+			"storage", readCacheOptions.Storage)
+		startedState.SetReadCache(state.NewReadCache(readCacheOptions))
+	}
+	pruningOptions := loadPruningOptions(moduleConfig)
+	pruner, err := state.NewPruner(startedState.DB, pruningOptions, logger)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start pruning: %v", err)
+	}
+	if pruningOptions.Enabled() {
+		logging.InfoMsg(logger, "Pruning state",
+			"keepRecent", pruningOptions.KeepRecent,
+			"keepEvery", pruningOptions.KeepEvery,
+			"interval", pruningOptions.Interval)
+	}
+	if err := loadPrecompilePlugins(moduleConfig, logger); err != nil {
+		return nil, err
+	}
+	privateStates, err := loadPrivateStates(moduleConfig, genesisDoc, logger)
+	if err != nil {
+		return nil, err
+	}
+	if privateStates != nil {
+		startedState.SetPrivateStates(privateStates)
+	}
+	confidential, err := loadConfidentialStore(moduleConfig, logger)
+	if err != nil {
+		return nil, err
+	}
 	// start the application
-	burrowMint := NewBurrowMint(startedState, eventSwitch, logger)
+	burrowMint := NewBurrowMint(startedState, pruner, eventSwitch, logger)
+	burrowMint.SetUpgradeFile(path.Join(moduleConfig.DataDir, upgradeMarkerFile))
+	if writeBuffer != nil {
+		writeBufferOptions := loadWriteBufferOptions(moduleConfig)
+		logging.InfoMsg(logger, "Buffering writes to state",
+			"pipeline", writeBufferOptions.Pipeline,
+			"sync", writeBufferOptions.Sync,
+			"syncInterval", writeBufferOptions.SyncInterval)
+		burrowMint.EnableWriteBuffer(writeBuffer)
+	}
+	mempoolOptions, err := loadMempoolOptions(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+	if mempoolOptions.Enabled() {
+		if err := burrowMint.LimitMempool(mempoolOptions); err != nil {
+			return nil, fmt.Errorf("Failed to limit mempool: %v", err)
+		}
+		logging.InfoMsg(logger, "Limiting the gas of the mempool",
+			"maxGas", mempoolOptions.MaxGas,
+			"priority", mempoolOptions.Priority)
+	}
+	if traceTxs := moduleConfig.Config.GetInt("trace_txs"); traceTxs > 0 {
+		logging.InfoMsg(logger, "Tracing CallTxs", "keepRecent", traceTxs)
+		burrowMint.EnableTxTraces(traceTxs)
+	}
+	if snapshotOptions := loadSnapshotOptions(moduleConfig); snapshotOptions.Enabled() {
+		snapshots, err := state.NewSnapshots(startedState.DB, snapshotOptions, logger)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to start snapshots: %v", err)
+		}
+		logging.InfoMsg(logger, "Taking snapshots of state",
+			"interval", snapshotOptions.Interval,
+			"keepRecent", snapshotOptions.KeepRecent)
+		burrowMint.EnableSnapshots(snapshots)
+	}
+	if err := importEventSignatures(moduleConfig, burrowMint.EventSignatures(),
+		logger); err != nil {
+		return nil, err
+	}
 
 	// initialise the components of the pipe
-	events := edb_event.NewEvents(eventSwitch, logger)
+	events := edb_event.DecodeLogs(edb_event.NewEvents(eventSwitch, logger),
+		burrowMint.DecodeLog)
 	accounts := newAccounts(burrowMint)
 	namereg := newNameReg(burrowMint)
+	callPoolOptions := loadCallPoolOptions(moduleConfig)
+	calls := newCallPool(callPoolOptions)
+	logging.InfoMsg(logger, "Simulating calls",
+		"concurrency", calls.options.Concurrency,
+		"gasLimit", callPoolOptions.GasLimit,
+		"timeout", callPoolOptions.Timeout)
 
 	pipe := &burrowMintPipe{
 		burrowMintState: startedState,
@@ -97,7 +212,9 @@ func NewBurrowMintPipe(moduleConfig *config.ModuleConfig,
 		namereg:         namereg,
 		// We need to set transactor later since we are introducing a mutual dependency
 		// NOTE: this will be cleaned up when the RPC is unified
-		transactor: nil,
+		transactor:   nil,
+		calls:        calls,
+		confidential: confidential,
 		// genesis cache
 		genesisDoc:   genesisDoc,
 		genesisState: nil,
@@ -122,7 +239,7 @@ func NewBurrowMintPipe(moduleConfig *config.ModuleConfig,
 		func(tx txs.Tx) error {
 			_, err := pipe.BroadcastTxSync(tx)
 			return err
-		})
+		}, calls)
 
 	pipe.transactor = transactor
 	return pipe, nil
@@ -131,31 +248,20 @@ func NewBurrowMintPipe(moduleConfig *config.ModuleConfig,
 //------------------------------------------------------------------------------
 // Start state
 
-// Start state tries to load the existing state in the data directory;
+// Start state tries to load the existing state in the state database;
 // if an existing database can be loaded, it will validate that the
 // chainId in the genesis of that loaded state matches the asserted chainId.
 // If no state can be loaded, the JSON genesis file will be loaded into the
 // state database as the zero state.
-func startState(dataDir, backend, genesisFile, chainId string) (*state.State,
+func startState(stateDB db.DB, genesisFile, chainId string) (*state.State,
 	*genesis.GenesisDoc, error) {
-	// avoid Tendermints PanicSanity and return a clean error
-	if backend != db.MemDBBackendStr &&
-		backend != db.LevelDBBackendStr {
-		return nil, nil, fmt.Errorf("Database backend %s is not supported "+
-			"by burrowmint", backend)
-	}
-
-	stateDB := db.NewDB("burrowmint", backend, dataDir)
 	newState := state.LoadState(stateDB)
 	var genesisDoc *genesis.GenesisDoc
 	if newState == nil {
 		genesisDoc, newState = state.MakeGenesisStateFromFile(stateDB, genesisFile)
 		newState.Save()
-		buf, n, err := new(bytes.Buffer), new(int), new(error)
-		wire.WriteJSON(genesisDoc, buf, n, err)
-		stateDB.Set(genesis.GenDocKey, buf.Bytes())
-		if *err != nil {
-			return nil, nil, fmt.Errorf("Unable to write genesisDoc to db: %v", err)
+		if err := saveGenesisDoc(stateDB, genesisDoc); err != nil {
+			return nil, nil, err
 		}
 	} else {
 		loadedGenesisDocBytes := stateDB.Get(genesis.GenDocKey)
@@ -174,6 +280,162 @@ func startState(dataDir, backend, genesisFile, chainId string) (*state.State,
 	return newState, genesisDoc, nil
 }
 
+func newStateDB(dataDir, backend string) (db.DB, error) {
+	return storage.NewDB("burrowmint", backend, dataDir)
+}
+
+// Reads the [burrowmint.pruning] section of the configuration
+func loadPruningOptions(moduleConfig *config.ModuleConfig) state.PruningOptions {
+	return state.PruningOptions{
+		KeepRecent: moduleConfig.Config.GetInt("pruning.keep_recent"),
+		KeepEvery:  moduleConfig.Config.GetInt("pruning.keep_every"),
+		Interval:   moduleConfig.Config.GetInt("pruning.interval"),
+	}
+}
+
+// Reads the [burrowmint.write_buffer] section of the configuration
+func loadWriteBufferOptions(moduleConfig *config.ModuleConfig) state.WriteBufferOptions {
+	return state.WriteBufferOptions{
+		Pipeline:     moduleConfig.Config.GetBool("write_buffer.pipeline"),
+		Sync:         state.SyncPolicy(moduleConfig.Config.GetString("write_buffer.sync")),
+		SyncInterval: moduleConfig.Config.GetInt("write_buffer.sync_interval"),
+	}
+}
+
+// Reads the [burrowmint.read_cache] section of the configuration
+func loadReadCacheOptions(moduleConfig *config.ModuleConfig) state.ReadCacheOptions {
+	return state.ReadCacheOptions{
+		Accounts: moduleConfig.Config.GetInt("read_cache.accounts"),
+		Storage:  moduleConfig.Config.GetInt("read_cache.storage"),
+	}
+}
+
+// Reads the [burrowmint.call_pool] section of the configuration, where the
+// timeout is cut to tx_timeout when that is shorter
+func loadCallPoolOptions(moduleConfig *config.ModuleConfig) CallPoolOptions {
+	options := CallPoolOptions{
+		Concurrency: moduleConfig.Config.GetInt("call_pool.concurrency"),
+		GasLimit:    int64(moduleConfig.Config.GetInt("call_pool.gas_limit")),
+		Timeout:     moduleConfig.Config.GetDuration("call_pool.timeout"),
+	}
+	txTimeout := moduleConfig.Config.GetDuration("tx_timeout")
+	if txTimeout > 0 && (options.Timeout == 0 || txTimeout < options.Timeout) {
+		options.Timeout = txTimeout
+	}
+	return options
+}
+
+// Reads the [burrowmint.mempool] section of the configuration
+func loadMempoolOptions(moduleConfig *config.ModuleConfig) (MempoolOptions, error) {
+	options := MempoolOptions{
+		Priority: moduleConfig.Config.GetString("mempool.priority"),
+		MaxGas:   int64(moduleConfig.Config.GetInt("mempool.max_gas")),
+	}
+	for _, address := range moduleConfig.Config.GetStringSlice("mempool.whitelist") {
+		addressBytes, err := hex.DecodeString(address)
+		if err != nil || len(addressBytes) != 20 {
+			return options, fmt.Errorf("Mempool whitelist address %s is not 20 "+
+				"bytes of hex", address)
+		}
+		options.Whitelist = append(options.Whitelist, addressBytes)
+	}
+	return options, nil
+}
+
+// Reads the [burrowmint.snapshots] section of the configuration
+func loadSnapshotOptions(moduleConfig *config.ModuleConfig) state.SnapshotOptions {
+	return state.SnapshotOptions{
+		Interval:   moduleConfig.Config.GetInt("snapshots.interval"),
+		KeepRecent: moduleConfig.Config.GetInt("snapshots.keep_recent"),
+	}
+}
+
+// Registers the native contracts of the plugins in precompile_plugins
+func loadPrecompilePlugins(moduleConfig *config.ModuleConfig,
+	logger logging_types.InfoTraceLogger) error {
+	for _, path := range moduleConfig.Config.GetStringSlice("precompile_plugins") {
+		precompiles, err := vm.LoadPrecompilePlugin(path)
+		if err != nil {
+			return err
+		}
+		for _, precompile := range precompiles {
+			logging.InfoMsg(logger, "Registered precompile",
+				"plugin", path,
+				"name", precompile.Name,
+				"address", precompile.Address)
+		}
+	}
+	return nil
+}
+
+// Loads the private states of the groups whose keys are in the files of
+// private.key_files, nil if there are none
+func loadPrivateStates(moduleConfig *config.ModuleConfig,
+	genesisDoc *genesis.GenesisDoc,
+	logger logging_types.InfoTraceLogger) (*state.PrivateStates, error) {
+	keyFiles := moduleConfig.Config.GetStringSlice("private.key_files")
+	if len(keyFiles) == 0 {
+		return nil, nil
+	}
+	privateStates := state.NewPrivateStates()
+	for _, path := range keyFiles {
+		keyHex, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read key of private group %s: %v",
+				path, err)
+		}
+		key, err := hex.DecodeString(string(bytes.TrimSpace(keyHex)))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid key of private group %s: %v", path, err)
+		}
+		group := txs.PrivateGroupID(key)
+		privateDB, err := storage.NewDB(fmt.Sprintf("burrowmint_private_%X",
+			group[:8]), moduleConfig.Config.GetString("db_backend"),
+			moduleConfig.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to start private state of %s: %v", path,
+				err)
+		}
+		if _, err := privateStates.AddGroup(key, privateDB, genesisDoc); err != nil {
+			return nil, err
+		}
+		logging.InfoMsg(logger, "Executing PrivateTxs for private group",
+			"file", path,
+			"group", group)
+	}
+	return privateStates, nil
+}
+
+// Imports the lists of event signatures in the files of event_signatures
+func importEventSignatures(moduleConfig *config.ModuleConfig,
+	eventSignatures *state.EventSignatures, logger logging_types.InfoTraceLogger) error {
+	for _, path := range moduleConfig.Config.GetStringSlice("event_signatures") {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Could not read event signatures %s: %v", path, err)
+		}
+		count, err := eventSignatures.Import(data)
+		if err != nil {
+			return fmt.Errorf("Could not import event signatures %s: %v", path, err)
+		}
+		logging.InfoMsg(logger, "Imported event signatures",
+			"file", path,
+			"count", count)
+	}
+	eventSignatures.Commit()
+	return nil
+}
+
+func saveGenesisDoc(stateDB db.DB, genesisDoc *genesis.GenesisDoc) error {
+	buf, n, err := new(bytes.Buffer), new(int), new(error)
+	wire.WriteJSON(genesisDoc, buf, n, err)
+	if *err != nil {
+		return fmt.Errorf("Unable to write genesisDoc to db: %v", *err)
+	}
+	stateDB.Set(genesis.GenDocKey, buf.Bytes())
+	return nil
+}
+
 //------------------------------------------------------------------------------
 // Implement definitions.Pipe for burrowMintPipe
 
@@ -193,6 +455,18 @@ func (pipe *burrowMintPipe) Events() edb_event.EventEmitter {
 	return pipe.events
 }
 
+func (pipe *burrowMintPipe) Logs() definitions.Logs {
+	return pipe.burrowMint.LogIndex()
+}
+
+func (pipe *burrowMintPipe) Receipts() definitions.Receipts {
+	return pipe.burrowMint
+}
+
+func (pipe *burrowMintPipe) TxIndex() definitions.TxIndex {
+	return pipe.burrowMint
+}
+
 func (pipe *burrowMintPipe) NameReg() definitions.NameReg {
 	return pipe.namereg
 }
@@ -209,6 +483,7 @@ func (pipe *burrowMintPipe) SetBlockchain(
 	blockchain blockchain_types.Blockchain) error {
 	if pipe.blockchain == nil {
 		pipe.blockchain = blockchain
+		pipe.burrowMint.SetBlockStore(blockchain)
 	} else {
 		return fmt.Errorf("Failed to set Blockchain for pipe; already set")
 	}
@@ -254,7 +529,9 @@ func (pipe *burrowMintPipe) Subscribe(eventId string,
 		logging.InfoMsg(pipe.logger, "Subscribing to event",
 			"eventId", eventId, "subscriptionId", subscriptionId)
 	}
-	pipe.consensusAndManagerEvents().Subscribe(subscriptionId, eventId,
+	// Tendermint websocket subscriptions are removed explicitly by Unsubscribe
+	err = edb_event.SubscribeQuery(context.Background(), pipe.consensusAndManagerEvents(),
+		subscriptionId, eventId,
 		func(eventData txs.EventData) {
 			result := rpc_tm_types.BurrowResult(
 				&rpc_tm_types.ResultEvent{
@@ -263,6 +540,9 @@ func (pipe *burrowMintPipe) Subscribe(eventId string,
 			// NOTE: EventSwitch callbacks must be nonblocking
 			rpcResponseWriter(result)
 		})
+	if err != nil {
+		return nil, err
+	}
 	return &rpc_tm_types.ResultSubscribe{
 		SubscriptionId: subscriptionId,
 		Event:          eventId,
@@ -373,13 +653,9 @@ func (pipe *burrowMintPipe) GetStorage(address, key []byte) (*rpc_tm_types.Resul
 	if account == nil {
 		return nil, fmt.Errorf("UnknownAddress: %X", address)
 	}
-	storageRoot := account.StorageRoot
-	storageTree := state.LoadStorage(storageRoot)
-
-	_, value, exists := storageTree.Get(
+	value := state.GetStorage(account.StorageRoot,
 		word256.LeftPadWord256(key).Bytes())
-	if !exists {
-		// value == nil {
+	if value == nil {
 		return &rpc_tm_types.ResultGetStorage{key, nil}, nil
 	}
 	return &rpc_tm_types.ResultGetStorage{key, value}, nil
@@ -425,52 +701,49 @@ func (pipe *burrowMintPipe) Call(fromAddress, toAddress, data []byte) (*rpc_tm_t
 	}
 	callee := toVMAccount(outAcc)
 	caller := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
-	txCache := state.NewTxCache(cache)
-	gasLimit := st.GetGasLimit()
-	params := vm.Params{
-		BlockHeight: int64(st.LastBlockHeight),
-		BlockHash:   word256.LeftPadWord256(st.LastBlockHash),
-		BlockTime:   st.LastBlockTime.Unix(),
-		GasLimit:    gasLimit,
-	}
-
-	vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
-		caller.Address, nil)
-	gas := gasLimit
-	ret, err := vmach.Call(caller, callee, callee.Code, data, 0, &gas)
-	if err != nil {
-		return nil, err
-	}
-	gasUsed := gasLimit - gas
-	// here return bytes are not hex encoded; on the sibling function
-	// they are
-	return &rpc_tm_types.ResultCall{Return: ret, GasUsed: gasUsed}, nil
+	return pipe.simulateCall(st, cache, caller, callee, callee.Code, data)
 }
 
 func (pipe *burrowMintPipe) CallCode(fromAddress, code, data []byte) (*rpc_tm_types.ResultCall,
 	error) {
 	st := pipe.burrowMint.GetState()
-	cache := pipe.burrowMint.GetCheckCache()
+	// A cache of its own rather than the check cache, which CheckTx mutates
+	cache := state.NewBlockCache(st)
 	callee := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
 	caller := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
+	return pipe.simulateCall(st, cache, caller, callee, code, data)
+}
+
+// Runs code as callee in the call pool against cache, which is left unchanged
+func (pipe *burrowMintPipe) simulateCall(st *state.State, cache *state.BlockCache,
+	caller, callee *vm.Account, code, data []byte) (*rpc_tm_types.ResultCall, error) {
 	txCache := state.NewTxCache(cache)
-	gasLimit := st.GetGasLimit()
+	gasLimit := pipe.calls.gasLimit(st.GetGasLimit())
 	params := vm.Params{
 		BlockHeight: int64(st.LastBlockHeight),
 		BlockHash:   word256.LeftPadWord256(st.LastBlockHash),
 		BlockTime:   st.LastBlockTime.Unix(),
 		GasLimit:    gasLimit,
+		ChainID:     vm.ChainIDWord256(st.ChainID),
+		GasSchedule: st.GetVMGasSchedule(),
 	}
 
-	vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
-		caller.Address, nil)
-	gas := gasLimit
-	ret, err := vmach.Call(caller, callee, code, data, 0, &gas)
-	if err != nil {
-		return nil, err
-	}
-	gasUsed := gasLimit - gas
-	return &rpc_tm_types.ResultCall{Return: ret, GasUsed: gasUsed}, nil
+	var result *rpc_tm_types.ResultCall
+	err := pipe.calls.simulate(func(interrupt <-chan struct{}) error {
+		vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
+			caller.Address, nil)
+		vmach.SetInterrupt(interrupt)
+		gas := gasLimit
+		ret, err := vmach.Call(caller, callee, code, data, 0, &gas)
+		if err != nil {
+			return vm.RevertError(err, ret)
+		}
+		// here return bytes are not hex encoded; on the sibling function
+		// they are
+		result = &rpc_tm_types.ResultCall{Return: ret, GasUsed: gasLimit - gas}
+		return nil
+	})
+	return result, err
 }
 
 // TODO: [ben] deprecate as we should not allow unsafe behaviour
@@ -496,6 +769,26 @@ func (pipe *burrowMintPipe) SignTransaction(tx txs.Tx,
 		callTx := tx.(*txs.CallTx)
 		callTx.Input.PubKey = privAccounts[0].PubKey
 		callTx.Input.Signature = privAccounts[0].Sign(pipe.transactor.chainID, callTx)
+	case *txs.PriorityFeeTx:
+		priorityFeeTx := tx.(*txs.PriorityFeeTx)
+		for i, input := range priorityFeeTx.Inputs() {
+			input.PubKey = privAccounts[i].PubKey
+			input.Signature = privAccounts[i].Sign(pipe.transactor.chainID, priorityFeeTx)
+		}
+	case *txs.ExpiringTx:
+		expiringTx := tx.(*txs.ExpiringTx)
+		for i, input := range expiringTx.Inputs() {
+			input.PubKey = privAccounts[i].PubKey
+			input.Signature = privAccounts[i].Sign(pipe.transactor.chainID, expiringTx)
+		}
+	case *txs.PrivateTx:
+		privateTx := tx.(*txs.PrivateTx)
+		privateTx.Input.PubKey = privAccounts[0].PubKey
+		privateTx.Input.Signature = privAccounts[0].Sign(pipe.transactor.chainID, privateTx)
+	case *txs.ProposalTx:
+		proposalTx := tx.(*txs.ProposalTx)
+		proposalTx.Input.PubKey = privAccounts[0].PubKey
+		proposalTx.Input.Signature = privAccounts[0].Sign(pipe.transactor.chainID, proposalTx)
 	case *txs.BondTx:
 		bondTx := tx.(*txs.BondTx)
 		// the first privaccount corresponds to the BondTx pub key.
@@ -511,6 +804,20 @@ func (pipe *burrowMintPipe) SignTransaction(tx txs.Tx,
 	case *txs.RebondTx:
 		rebondTx := tx.(*txs.RebondTx)
 		rebondTx.Signature = privAccounts[0].Sign(pipe.transactor.chainID, rebondTx).(crypto.SignatureEd25519)
+	case *txs.RotateTx:
+		rotateTx := tx.(*txs.RotateTx)
+		// the first privaccount holds the validator key, the second the new key
+		if len(privAccounts) != 2 {
+			return nil, fmt.Errorf("RotateTx must be signed by the validator key and the new key")
+		}
+		rotateTx.Signature = privAccounts[0].Sign(pipe.transactor.chainID, rotateTx).(crypto.SignatureEd25519)
+		rotateTx.NewSignature = privAccounts[1].Sign(pipe.transactor.chainID, rotateTx).(crypto.SignatureEd25519)
+	case *txs.IdentifyTx:
+		identifyTx := tx.(*txs.IdentifyTx)
+		identifyTx.Signature = privAccounts[0].Sign(pipe.transactor.chainID, identifyTx).(crypto.SignatureEd25519)
+	case *txs.AttestTx:
+		attestTx := tx.(*txs.AttestTx)
+		attestTx.Signature = privAccounts[0].Sign(pipe.transactor.chainID, attestTx).(crypto.SignatureEd25519)
 	}
 	return &rpc_tm_types.ResultSignTx{tx}, nil
 }
@@ -537,6 +844,160 @@ func (pipe *burrowMintPipe) ListNames() (*rpc_tm_types.ResultListNames, error) {
 	return &rpc_tm_types.ResultListNames{blockHeight, names}, nil
 }
 
+// Node registry, the entry is nil when the validator has not registered a node
+func (pipe *burrowMintPipe) GetNode(address []byte) (*rpc_tm_types.ResultGetNode, error) {
+	entry := pipe.burrowMint.GetState().GetNodeRegEntry(address)
+	return &rpc_tm_types.ResultGetNode{entry}, nil
+}
+
+func (pipe *burrowMintPipe) ListNodes() (*rpc_tm_types.ResultListNodes, error) {
+	var nodes []*core_types.NodeRegEntry
+	currentState := pipe.burrowMint.GetState()
+	currentState.GetNodeRegistry().Iterate(func(key []byte, value []byte) bool {
+		nodes = append(nodes, state.DecodeNodeRegEntry(value))
+		return false
+	})
+	return &rpc_tm_types.ResultListNodes{currentState.LastBlockHeight, nodes}, nil
+}
+
+// Relayed chains, the chain or header is nil when it has not been relayed
+func (pipe *burrowMintPipe) GetRelayedChain(chainID string) (*rpc_tm_types.ResultGetRelayedChain, error) {
+	chain := pipe.burrowMint.GetState().GetRelayedChain(chainID)
+	return &rpc_tm_types.ResultGetRelayedChain{chain}, nil
+}
+
+func (pipe *burrowMintPipe) GetRelayedHeader(chainID string,
+	height int) (*rpc_tm_types.ResultGetRelayedHeader, error) {
+	header := pipe.burrowMint.GetState().GetRelayedHeader(chainID, height)
+	return &rpc_tm_types.ResultGetRelayedHeader{header}, nil
+}
+
+func (pipe *burrowMintPipe) ListRelayedChains() (*rpc_tm_types.ResultListRelayedChains, error) {
+	currentState := pipe.burrowMint.GetState()
+	return &rpc_tm_types.ResultListRelayedChains{currentState.LastBlockHeight,
+		currentState.GetRelayedChains()}, nil
+}
+
+// The attestations of events for the bridge, the attestation is nil when no
+// validator has attested its event
+func (pipe *burrowMintPipe) GetAttestation(id []byte) (*rpc_tm_types.ResultGetAttestation, error) {
+	attestation := pipe.burrowMint.GetState().GetAttestation(id)
+	return &rpc_tm_types.ResultGetAttestation{attestation}, nil
+}
+
+func (pipe *burrowMintPipe) ListAttestations(minHeight int64) (*rpc_tm_types.ResultListAttestations, error) {
+	currentState := pipe.burrowMint.GetState()
+	return &rpc_tm_types.ResultListAttestations{currentState.LastBlockHeight,
+		currentState.GetAttestations(minHeight)}, nil
+}
+
+func (pipe *burrowMintPipe) ListDesignatedEvents() (*rpc_tm_types.ResultListDesignatedEvents, error) {
+	currentState := pipe.burrowMint.GetState()
+	return &rpc_tm_types.ResultListDesignatedEvents{currentState.LastBlockHeight,
+		currentState.GetDesignatedEvents()}, nil
+}
+
+// The ABI registered for the contract at address, the entry is nil
+// when none is
+func (pipe *burrowMintPipe) GetABI(address []byte) (*rpc_tm_types.ResultGetABI, error) {
+	entry := state.GetContractABIEntry(pipe.burrowMint.GetState(), address)
+	return &rpc_tm_types.ResultGetABI{entry}, nil
+}
+
+// The record of the PrivateTx with txHash, with the receipt of its payload
+// when the node is in its private group
+func (pipe *burrowMintPipe) GetPrivateTx(txHash []byte,
+	abiJSON string) (*rpc_tm_types.ResultGetPrivateTx, error) {
+	currentState := pipe.burrowMint.GetState()
+	record := currentState.GetPrivateTxRecord(txHash)
+	if record == nil {
+		return nil, fmt.Errorf("PrivateTx %X not found", txHash)
+	}
+	var receipt *core_types.TxReceipt
+	if privateStates := currentState.PrivateStates(); privateStates != nil {
+		// the receipt is absent when the node is outside the group
+		receipt, _ = privateStates.TxReceipt(record.Group, txHash, abiJSON)
+	}
+	return &rpc_tm_types.ResultGetPrivateTx{record, receipt}, nil
+}
+
+// The account at address in the private state of group, which the node must
+// be in
+func (pipe *burrowMintPipe) GetPrivateAccount(group,
+	address []byte) (*rpc_tm_types.ResultGetAccount, error) {
+	privateStates := pipe.burrowMint.GetState().PrivateStates()
+	if privateStates == nil {
+		return nil, fmt.Errorf("Node is not in any private group")
+	}
+	account, err := privateStates.GetAccount(group, address)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc_tm_types.ResultGetAccount{account}, nil
+}
+
+func (pipe *burrowMintPipe) GetPrivateStorage(group, address,
+	key []byte) (*rpc_tm_types.ResultGetStorage, error) {
+	privateStates := pipe.burrowMint.GetState().PrivateStates()
+	if privateStates == nil {
+		return nil, fmt.Errorf("Node is not in any private group")
+	}
+	value, err := privateStates.GetStorage(group, address,
+		word256.LeftPadWord256(key))
+	if err != nil {
+		return nil, err
+	}
+	if value == word256.Zero256 {
+		return &rpc_tm_types.ResultGetStorage{key, nil}, nil
+	}
+	return &rpc_tm_types.ResultGetStorage{key, value.Bytes()}, nil
+}
+
+// Confidential store
+func (pipe *burrowMintPipe) SealConfidential(owner, key,
+	data []byte) (*rpc_tm_types.ResultSealConfidential, error) {
+	if pipe.confidential == nil {
+		return nil, fmt.Errorf("Node holds no key for the confidential store")
+	}
+	blob, err := pipe.confidential.seal(owner, word256.LeftPadWord256(key), data)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc_tm_types.ResultSealConfidential{blob}, nil
+}
+
+// Opens the blob of read for the account with the ed25519 pubKey that signed
+// it, which must be granted to read it
+func (pipe *burrowMintPipe) ReadConfidential(read *txs.ConfidentialRead, pubKey,
+	signature []byte) (*rpc_tm_types.ResultReadConfidential, error) {
+	if pipe.confidential == nil {
+		return nil, fmt.Errorf("Node holds no key for the confidential store")
+	}
+	var edPubKey crypto.PubKeyEd25519
+	var edSignature crypto.SignatureEd25519
+	if len(pubKey) != len(edPubKey) || len(signature) != len(edSignature) {
+		return nil, fmt.Errorf("Read of the confidential store must be signed " +
+			"by an ed25519 key")
+	}
+	copy(edPubKey[:], pubKey)
+	copy(edSignature[:], signature)
+	data, err := pipe.confidential.read(pipe.burrowMint.GetState(), read,
+		edPubKey, edSignature)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc_tm_types.ResultReadConfidential{read.Owner, read.Key, data}, nil
+}
+
+func (pipe *burrowMintPipe) GetTxReceipt(txHash []byte,
+	abiJSON string) (*rpc_tm_types.ResultGetTxReceipt, error) {
+	receipt, err := pipe.burrowMint.TxReceipt(txHash, abiJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc_tm_types.ResultGetTxReceipt{receipt}, nil
+}
+
 func (pipe *burrowMintPipe) broadcastTx(tx txs.Tx,
 	callback func(res *abci_types.Response)) (*rpc_tm_types.ResultBroadcastTx, error) {
 
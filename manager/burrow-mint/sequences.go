@@ -0,0 +1,54 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import "sync"
+
+// sequenceTracker assigns the sequence numbers of the txs the transactor signs
+// for accounts. The check cache only counts a tx once it has passed CheckTx,
+// and is reset to the committed state at each commit until tendermint rechecks
+// the mempool, so reading the next sequence number from it alone can hand two
+// submissions from an account the same number. The tracker remembers the last
+// number reserved for each account and never assigns it again, until a tx it
+// assigned a number to fails, after which it goes back to the number in state.
+type sequenceTracker struct {
+	mtx      sync.Mutex
+	reserved map[string]int
+}
+
+func newSequenceTracker() *sequenceTracker {
+	return &sequenceTracker{reserved: make(map[string]int)}
+}
+
+// Reserves the sequence number of the next tx from address, which has the
+// sequence number sequence in state
+func (st *sequenceTracker) reserve(address []byte, sequence int) int {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	next := sequence + 1
+	if reserved, ok := st.reserved[string(address)]; ok && reserved >= next {
+		next = reserved + 1
+	}
+	st.reserved[string(address)] = next
+	return next
+}
+
+// Forgets the sequence numbers reserved for address so that the next one is
+// taken from state
+func (st *sequenceTracker) resync(address []byte) {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	delete(st.reserved, string(address))
+}
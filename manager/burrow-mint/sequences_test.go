@@ -0,0 +1,38 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceTracker(t *testing.T) {
+	st := newSequenceTracker()
+	addressA := []byte{0x0A}
+	addressB := []byte{0x0B}
+	// State lags behind the reserved sequence numbers
+	assert.Equal(t, 4, st.reserve(addressA, 3))
+	assert.Equal(t, 5, st.reserve(addressA, 3))
+	assert.Equal(t, 6, st.reserve(addressA, 4))
+	assert.Equal(t, 1, st.reserve(addressB, 0))
+	// State overtakes them
+	assert.Equal(t, 10, st.reserve(addressA, 9))
+	// After a failure state is followed again
+	st.resync(addressA)
+	assert.Equal(t, 8, st.reserve(addressA, 7))
+	assert.Equal(t, 2, st.reserve(addressB, 0))
+}
@@ -22,10 +22,12 @@ import (
 	acm "github.com/hyperledger/burrow/account"
 	"github.com/hyperledger/burrow/common/sanity"
 	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
 
 	dbm "github.com/tendermint/go-db"
 	"github.com/tendermint/go-merkle"
+	"github.com/tendermint/go-wire"
 )
 
 func makeStorage(db dbm.DB, root []byte) merkle.Tree {
@@ -41,18 +43,141 @@ type BlockCache struct {
 	accounts map[string]accountInfo
 	storages map[Tuple256]storageInfo
 	names    map[string]nameInfo
+	abis     map[string]abiInfo
+	ballots  map[string]ballotInfo
+	bonds    map[string]bondInfo
+	jails    map[string]jailInfo
+	missed   map[string]missedInfo
+	nodes    map[string]nodeInfo
+	relays   map[string]entryInfo
+	bridge   map[string]entryInfo
+	private  map[string]entryInfo
+	// Confidential entries, removed entries being empty
+	confidential map[string]entryInfo
+	// Validator rotations added since the last sync
+	rotations []*ValidatorRotation
+	// Validator power changes set since the last sync, by key
+	powerChanges map[string]*ValidatorPowerChange
+	// Unbondings added since the last sync
+	unbondings []*Unbonding
+	// Changes to the chain parameters made since the last sync
+	chainParams []*txs.ChainParams
+	// The fees collected for the rewards of the block since they were last
+	// taken
+	collectedFees int64
 }
 
 func NewBlockCache(backend *State) *BlockCache {
 	return &BlockCache{
-		db:       backend.DB,
-		backend:  backend,
-		accounts: make(map[string]accountInfo),
-		storages: make(map[Tuple256]storageInfo),
-		names:    make(map[string]nameInfo),
+		db:           backend.DB,
+		backend:      backend,
+		accounts:     make(map[string]accountInfo),
+		storages:     make(map[Tuple256]storageInfo),
+		names:        make(map[string]nameInfo),
+		abis:         make(map[string]abiInfo),
+		ballots:      make(map[string]ballotInfo),
+		bonds:        make(map[string]bondInfo),
+		jails:        make(map[string]jailInfo),
+		missed:       make(map[string]missedInfo),
+		nodes:        make(map[string]nodeInfo),
+		relays:       make(map[string]entryInfo),
+		bridge:       make(map[string]entryInfo),
+		private:      make(map[string]entryInfo),
+		confidential: make(map[string]entryInfo),
+		powerChanges: make(map[string]*ValidatorPowerChange),
 	}
 }
 
+// Copies the cache so that txs can be executed against the copy without
+// changing the cache. The storage trees of accounts are shared, so the copy
+// must not be used to run code.
+func (cache *BlockCache) Copy() *BlockCache {
+	cacheCopy := NewBlockCache(cache.backend)
+	for addr, accInfo := range cache.accounts {
+		if accInfo.account != nil {
+			accCopy := accInfo.account.Copy()
+			accCopy.Permissions.Roles = append([]string(nil),
+				accInfo.account.Permissions.Roles...)
+			accInfo.account = accCopy
+		}
+		cacheCopy.accounts[addr] = accInfo
+	}
+	for key, stjInfo := range cache.storages {
+		cacheCopy.storages[key] = stjInfo
+	}
+	for name, nInfo := range cache.names {
+		if nInfo.name != nil {
+			entryCopy := *nInfo.name
+			nInfo.name = &entryCopy
+		}
+		cacheCopy.names[name] = nInfo
+	}
+	for addr, aInfo := range cache.abis {
+		if aInfo.entry != nil {
+			entryCopy := *aInfo.entry
+			aInfo.entry = &entryCopy
+		}
+		cacheCopy.abis[addr] = aInfo
+	}
+	for proposalHash, bInfo := range cache.ballots {
+		if bInfo.ballot != nil {
+			bInfo.ballot = bInfo.ballot.Copy()
+		}
+		cacheCopy.ballots[proposalHash] = bInfo
+	}
+	for address, bInfo := range cache.bonds {
+		if bInfo.bond != nil {
+			bondCopy := *bInfo.bond
+			bondCopy.UnbondTo = append([]*txs.TxOutput(nil), bInfo.bond.UnbondTo...)
+			bInfo.bond = &bondCopy
+		}
+		cacheCopy.bonds[address] = bInfo
+	}
+	for address, jInfo := range cache.jails {
+		if jInfo.jail != nil {
+			jailCopy := *jInfo.jail
+			jInfo.jail = &jailCopy
+		}
+		cacheCopy.jails[address] = jInfo
+	}
+	for address, mInfo := range cache.missed {
+		if mInfo.missed != nil {
+			missedCopy := *mInfo.missed
+			mInfo.missed = &missedCopy
+		}
+		cacheCopy.missed[address] = mInfo
+	}
+	for address, nInfo := range cache.nodes {
+		if nInfo.entry != nil {
+			entryCopy := *nInfo.entry
+			nInfo.entry = &entryCopy
+		}
+		cacheCopy.nodes[address] = nInfo
+	}
+	// Relay, bridge, private tx and confidential entries are encoded so they are
+	// not shared with the copy
+	for key, eInfo := range cache.relays {
+		cacheCopy.relays[key] = eInfo
+	}
+	for key, eInfo := range cache.bridge {
+		cacheCopy.bridge[key] = eInfo
+	}
+	for key, eInfo := range cache.private {
+		cacheCopy.private[key] = eInfo
+	}
+	for key, eInfo := range cache.confidential {
+		cacheCopy.confidential[key] = eInfo
+	}
+	cacheCopy.rotations = append(cacheCopy.rotations, cache.rotations...)
+	for key, change := range cache.powerChanges {
+		cacheCopy.powerChanges[key] = change
+	}
+	cacheCopy.unbondings = append(cacheCopy.unbondings, cache.unbondings...)
+	cacheCopy.chainParams = append(cacheCopy.chainParams, cache.chainParams...)
+	cacheCopy.collectedFees = cache.collectedFees
+	return cacheCopy
+}
+
 func (cache *BlockCache) State() *State {
 	return cache.backend
 }
@@ -114,7 +239,7 @@ func (cache *BlockCache) GetStorage(addr Word256, key Word256) (value Word256) {
 	}
 
 	// Load and set cache
-	_, val_, _ := storage.Get(key.Bytes())
+	val_ := cache.backend.readCache.storageValue(storage, key.Bytes())
 	value = Zero256
 	if val_ != nil {
 		value = LeftPadWord256(val_)
@@ -164,6 +289,369 @@ func (cache *BlockCache) RemoveNameRegEntry(name string) {
 
 // BlockCache.names
 //-------------------------------------
+// BlockCache.abis
+
+func (cache *BlockCache) GetABIEntry(addr []byte) *core_types.ABIEntry {
+	entry, _ := cache.abis[string(addr)].unpack()
+	if entry != nil {
+		return entry
+	}
+	entry = cache.backend.GetABIEntry(addr)
+	cache.abis[string(addr)] = abiInfo{entry, false}
+	return entry
+}
+
+func (cache *BlockCache) UpdateABIEntry(entry *core_types.ABIEntry) {
+	cache.abis[string(entry.Address)] = abiInfo{entry, true}
+}
+
+// BlockCache.abis
+//-------------------------------------
+// BlockCache.ballots
+
+func (cache *BlockCache) GetBallot(proposalHash []byte) *Ballot {
+	ballot, _ := cache.ballots[string(proposalHash)].unpack()
+	if ballot != nil {
+		return ballot
+	}
+	ballot = cache.backend.GetBallot(proposalHash)
+	cache.ballots[string(proposalHash)] = ballotInfo{ballot, false}
+	return ballot
+}
+
+func (cache *BlockCache) UpdateBallot(proposalHash []byte, ballot *Ballot) {
+	cache.ballots[string(proposalHash)] = ballotInfo{ballot, true}
+}
+
+// BlockCache.ballots
+//-------------------------------------
+// BlockCache.rotations
+
+// Gets the validator rotations of the backend and those added to the cache,
+// in the order they take effect
+func (cache *BlockCache) GetValidatorRotations() []*ValidatorRotation {
+	rotations := append(cache.backend.GetValidatorRotations(), cache.rotations...)
+	sort.Stable(validatorRotationsByKey(rotations))
+	return rotations
+}
+
+func (cache *BlockCache) AddValidatorRotation(rotation *ValidatorRotation) {
+	cache.rotations = append(cache.rotations, rotation)
+}
+
+// BlockCache.rotations
+//-------------------------------------
+// BlockCache.bonds
+
+func (cache *BlockCache) GetValidatorBond(address []byte) *ValidatorBond {
+	bond, removed, _ := cache.bonds[string(address)].unpack()
+	if removed {
+		return nil
+	} else if bond != nil {
+		return bond
+	}
+	bond = cache.backend.GetValidatorBond(address)
+	cache.bonds[string(address)] = bondInfo{bond, false, false}
+	return bond
+}
+
+func (cache *BlockCache) UpdateValidatorBond(bond *ValidatorBond) {
+	cache.bonds[string(bond.PubKey.Address())] = bondInfo{bond, false, true}
+}
+
+func (cache *BlockCache) RemoveValidatorBond(address []byte) {
+	cache.bonds[string(address)] = bondInfo{nil, true, false}
+}
+
+// BlockCache.bonds
+//-------------------------------------
+// BlockCache.powerChanges
+
+// Gets the validator power changes of the backend and those set in the
+// cache, in the order they take effect
+func (cache *BlockCache) GetValidatorPowerChanges() []*ValidatorPowerChange {
+	var changes []*ValidatorPowerChange
+	for _, change := range cache.backend.GetValidatorPowerChanges() {
+		if cache.powerChanges[string(validatorPowerChangeKey(change))] == nil {
+			changes = append(changes, change)
+		}
+	}
+	for _, change := range cache.powerChanges {
+		changes = append(changes, change)
+	}
+	sort.Sort(validatorPowerChangesByKey(changes))
+	return changes
+}
+
+// Sets the power of a validator from a height, replacing any power change
+// already set for it at that height
+func (cache *BlockCache) SetValidatorPowerChange(change *ValidatorPowerChange) {
+	cache.powerChanges[string(validatorPowerChangeKey(change))] = change
+}
+
+// BlockCache.powerChanges
+//-------------------------------------
+// BlockCache.unbondings
+
+// Adds an unbonding, replacing any with the same release height and address
+func (cache *BlockCache) AddUnbonding(unbonding *Unbonding) {
+	cache.unbondings = append(cache.unbondings, unbonding)
+}
+
+// Gets the unbondings of the validator with address of the backend and those
+// added to the cache
+func (cache *BlockCache) GetUnbondings(address []byte) []*Unbonding {
+	unbondings := cache.backend.GetUnbondings(address)
+	for _, unbonding := range cache.unbondings {
+		if !bytes.Equal(unbonding.Address, address) {
+			continue
+		}
+		replaced := false
+		for i, existing := range unbondings {
+			if existing.ReleaseHeight == unbonding.ReleaseHeight {
+				unbondings[i] = unbonding
+				replaced = true
+			}
+		}
+		if !replaced {
+			unbondings = append(unbondings, unbonding)
+		}
+	}
+	return unbondings
+}
+
+// BlockCache.unbondings
+//-------------------------------------
+// BlockCache.jails
+
+func (cache *BlockCache) GetValidatorJail(address []byte) *ValidatorJail {
+	jail, _ := cache.jails[string(address)].unpack()
+	if jail != nil {
+		return jail
+	}
+	jail = cache.backend.GetValidatorJail(address)
+	cache.jails[string(address)] = jailInfo{jail, false}
+	return jail
+}
+
+func (cache *BlockCache) UpdateValidatorJail(jail *ValidatorJail) {
+	cache.jails[string(jail.PubKey.Address())] = jailInfo{jail, true}
+}
+
+// BlockCache.jails
+//-------------------------------------
+// BlockCache.missed
+
+func (cache *BlockCache) GetMissedBlocks(address []byte) *MissedBlocks {
+	missed, _ := cache.missed[string(address)].unpack()
+	if missed != nil {
+		return missed
+	}
+	missed = cache.backend.GetMissedBlocks(address)
+	cache.missed[string(address)] = missedInfo{missed, false}
+	return missed
+}
+
+func (cache *BlockCache) UpdateMissedBlocks(missed *MissedBlocks) {
+	cache.missed[string(missed.Address)] = missedInfo{missed, true}
+}
+
+// BlockCache.missed
+//-------------------------------------
+// BlockCache.nodes
+
+func (cache *BlockCache) GetNodeRegEntry(address []byte) *core_types.NodeRegEntry {
+	entry, _ := cache.nodes[string(address)].unpack()
+	if entry != nil {
+		return entry
+	}
+	entry = cache.backend.GetNodeRegEntry(address)
+	cache.nodes[string(address)] = nodeInfo{entry, false}
+	return entry
+}
+
+func (cache *BlockCache) UpdateNodeRegEntry(entry *core_types.NodeRegEntry) {
+	cache.nodes[string(entry.Address)] = nodeInfo{entry, true}
+}
+
+// BlockCache.nodes
+//-------------------------------------
+// BlockCache.relays
+
+func (cache *BlockCache) getRelayEntry(key []byte) []byte {
+	value, _ := cache.relays[string(key)].unpack()
+	if value != nil {
+		return value
+	}
+	value = cache.backend.getRelayEntry(key)
+	cache.relays[string(key)] = entryInfo{value, false}
+	return value
+}
+
+func (cache *BlockCache) setRelayEntry(key, value []byte) {
+	cache.relays[string(key)] = entryInfo{value, true}
+}
+
+func (cache *BlockCache) GetRelayedChain(chainID string) *core_types.RelayedChain {
+	return decodeRelayedChain(cache.getRelayEntry(relayedChainKey(chainID)))
+}
+
+func (cache *BlockCache) UpdateRelayedChain(chain *core_types.RelayedChain) {
+	cache.setRelayEntry(relayedChainKey(chain.ChainID), wire.BinaryBytes(chain))
+}
+
+func (cache *BlockCache) GetRelayedHeader(chainID string, height int) *core_types.RelayedHeader {
+	return decodeRelayedHeader(cache.getRelayEntry(relayedHeaderKey(chainID, height)))
+}
+
+func (cache *BlockCache) UpdateRelayedHeader(header *core_types.RelayedHeader) {
+	cache.setRelayEntry(relayedHeaderKey(header.ChainID, header.Height),
+		wire.BinaryBytes(header))
+}
+
+func (cache *BlockCache) GetRelayedStorage(chainID string, height int,
+	address []byte, key Word256) (Word256, bool) {
+	value := cache.getRelayEntry(relayedStorageKey(chainID, height, address, key))
+	return LeftPadWord256(value), value != nil
+}
+
+func (cache *BlockCache) SetRelayedStorage(chainID string, height int,
+	address []byte, key, value Word256) {
+	cache.setRelayEntry(relayedStorageKey(chainID, height, address, key),
+		value.Bytes())
+}
+
+// BlockCache.relays
+//-------------------------------------
+// BlockCache.bridge
+
+func (cache *BlockCache) getBridgeEntry(key []byte) []byte {
+	value, _ := cache.bridge[string(key)].unpack()
+	if value != nil {
+		return value
+	}
+	value = cache.backend.getBridgeEntry(key)
+	cache.bridge[string(key)] = entryInfo{value, false}
+	return value
+}
+
+func (cache *BlockCache) setBridgeEntry(key, value []byte) {
+	cache.bridge[string(key)] = entryInfo{value, true}
+}
+
+func (cache *BlockCache) GetAttestation(id []byte) *core_types.Attestation {
+	return decodeAttestation(cache.getBridgeEntry(attestationKey(id)))
+}
+
+func (cache *BlockCache) UpdateAttestation(attestation *core_types.Attestation) {
+	cache.setBridgeEntry(attestationKey(attestation.ID), wire.BinaryBytes(attestation))
+}
+
+func (cache *BlockCache) GetDesignatedEvent(address []byte, topic Word256) *core_types.DesignatedEvent {
+	return decodeDesignatedEvent(cache.getBridgeEntry(designatedEventKey(address, topic)))
+}
+
+func (cache *BlockCache) UpdateDesignatedEvent(event *core_types.DesignatedEvent) {
+	cache.setBridgeEntry(designatedEventKey(event.Address, event.Topic),
+		wire.BinaryBytes(event))
+}
+
+func (cache *BlockCache) GetBridgeKey(validator []byte) []byte {
+	return cache.getBridgeEntry(bridgeKeyKey(validator))
+}
+
+func (cache *BlockCache) SetBridgeKey(validator, ethAddress []byte) {
+	cache.setBridgeEntry(bridgeKeyKey(validator), ethAddress)
+}
+
+// BlockCache.bridge
+//-------------------------------------
+// BlockCache.privateTxs
+
+func (cache *BlockCache) GetPrivateTxRecord(txHash []byte) *core_types.PrivateTxRecord {
+	value, _ := cache.private[string(txHash)].unpack()
+	if value == nil {
+		value = cache.backend.getPrivateTxEntry(txHash)
+		cache.private[string(txHash)] = entryInfo{value, false}
+	}
+	return decodePrivateTxRecord(value)
+}
+
+func (cache *BlockCache) UpdatePrivateTxRecord(record *core_types.PrivateTxRecord) {
+	cache.private[string(record.TxHash)] = entryInfo{wire.BinaryBytes(record), true}
+}
+
+// BlockCache.privateTxs
+//-------------------------------------
+// BlockCache.confidential
+
+func (cache *BlockCache) getConfidentialEntry(key []byte) []byte {
+	value, _ := cache.confidential[string(key)].unpack()
+	if value != nil {
+		return value
+	}
+	value = cache.backend.getConfidentialEntry(key)
+	cache.confidential[string(key)] = entryInfo{value, false}
+	return value
+}
+
+func (cache *BlockCache) setConfidentialEntry(key, value []byte) {
+	cache.confidential[string(key)] = entryInfo{value, true}
+}
+
+func (cache *BlockCache) GetConfidentialBlob(owner []byte, key Word256) []byte {
+	blob := cache.getConfidentialEntry(confidentialBlobKey(owner, key))
+	if len(blob) == 0 {
+		return nil
+	}
+	return blob
+}
+
+func (cache *BlockCache) SetConfidentialBlob(owner []byte, key Word256, blob []byte) {
+	cache.setConfidentialEntry(confidentialBlobKey(owner, key),
+		append([]byte{}, blob...))
+}
+
+func (cache *BlockCache) IsConfidentialGranted(owner []byte, key Word256, reader []byte) bool {
+	return len(cache.getConfidentialEntry(confidentialGrantKey(owner, key, reader))) > 0
+}
+
+func (cache *BlockCache) SetConfidentialGrant(owner []byte, key Word256,
+	reader []byte, granted bool) {
+	cache.setConfidentialEntry(confidentialGrantKey(owner, key, reader),
+		confidentialGrantValue(granted))
+}
+
+// BlockCache.confidential
+//-------------------------------------
+// BlockCache.chainParams
+
+// Adds changes to the chain parameters, which are set in the backend when the
+// cache is synced so they take effect from the next block. They must have been
+// checked with ValidateChainParams.
+func (cache *BlockCache) AddChainParams(params *txs.ChainParams) {
+	cache.chainParams = append(cache.chainParams, params)
+}
+
+// BlockCache.chainParams
+//-------------------------------------
+// BlockCache.collectedFees
+
+// Collects a fee taken from the inputs of a tx for the rewards of the block
+func (cache *BlockCache) CollectFee(fee int64) {
+	cache.collectedFees += fee
+}
+
+// Gets the fees collected since they were last taken, and starts collecting
+// again from zero
+func (cache *BlockCache) TakeCollectedFees() int64 {
+	fees := cache.collectedFees
+	cache.collectedFees = 0
+	return fees
+}
+
+// BlockCache.collectedFees
+//-------------------------------------
 
 // CONTRACT the updates are in deterministic order.
 func (cache *BlockCache) Sync() {
@@ -269,6 +757,169 @@ func (cache *BlockCache) Sync() {
 		}
 	}
 
+	// Update ABI entries in order of address
+	abiAddrs := []string{}
+	for addr := range cache.abis {
+		abiAddrs = append(abiAddrs, addr)
+	}
+	sort.Strings(abiAddrs)
+	for _, addr := range abiAddrs {
+		entry, dirty := cache.abis[addr].unpack()
+		if entry != nil && dirty {
+			cache.backend.UpdateABIEntry(entry)
+		}
+	}
+
+	// Update ballots in order of proposal hash
+	proposalHashes := []string{}
+	for proposalHash := range cache.ballots {
+		proposalHashes = append(proposalHashes, proposalHash)
+	}
+	sort.Strings(proposalHashes)
+	for _, proposalHash := range proposalHashes {
+		ballot, dirty := cache.ballots[proposalHash].unpack()
+		if ballot != nil && dirty {
+			cache.backend.UpdateBallot([]byte(proposalHash), ballot)
+		}
+	}
+
+	// Add validator rotations in the order they were made
+	for _, rotation := range cache.rotations {
+		cache.backend.AddValidatorRotation(rotation)
+	}
+	cache.rotations = nil
+
+	// Update bonds in order of address
+	bondAddresses := []string{}
+	for address := range cache.bonds {
+		bondAddresses = append(bondAddresses, address)
+	}
+	sort.Strings(bondAddresses)
+	for _, address := range bondAddresses {
+		bond, removed, dirty := cache.bonds[address].unpack()
+		if removed {
+			cache.backend.RemoveValidatorBond([]byte(address))
+		} else if bond != nil && dirty {
+			cache.backend.UpdateValidatorBond(bond)
+		}
+	}
+
+	// Set validator power changes in the order they take effect
+	powerChanges := make([]*ValidatorPowerChange, 0, len(cache.powerChanges))
+	for _, change := range cache.powerChanges {
+		powerChanges = append(powerChanges, change)
+	}
+	sort.Sort(validatorPowerChangesByKey(powerChanges))
+	for _, change := range powerChanges {
+		cache.backend.SetValidatorPowerChange(change)
+	}
+	cache.powerChanges = make(map[string]*ValidatorPowerChange)
+
+	// Add unbondings in the order they were made
+	for _, unbonding := range cache.unbondings {
+		cache.backend.AddUnbonding(unbonding)
+	}
+	cache.unbondings = nil
+
+	// Update jails in order of address
+	jailAddresses := []string{}
+	for address := range cache.jails {
+		jailAddresses = append(jailAddresses, address)
+	}
+	sort.Strings(jailAddresses)
+	for _, address := range jailAddresses {
+		jail, dirty := cache.jails[address].unpack()
+		if jail != nil && dirty {
+			cache.backend.UpdateValidatorJail(jail)
+		}
+	}
+
+	// Update missed blocks in order of address
+	missedAddresses := []string{}
+	for address := range cache.missed {
+		missedAddresses = append(missedAddresses, address)
+	}
+	sort.Strings(missedAddresses)
+	for _, address := range missedAddresses {
+		missed, dirty := cache.missed[address].unpack()
+		if missed != nil && dirty {
+			cache.backend.UpdateMissedBlocks(missed)
+		}
+	}
+
+	// Update node registry entries in order of validator address
+	nodeAddresses := []string{}
+	for address := range cache.nodes {
+		nodeAddresses = append(nodeAddresses, address)
+	}
+	sort.Strings(nodeAddresses)
+	for _, address := range nodeAddresses {
+		entry, dirty := cache.nodes[address].unpack()
+		if entry != nil && dirty {
+			cache.backend.UpdateNodeRegEntry(entry)
+		}
+	}
+
+	// Update relay entries in order of key
+	relayKeys := []string{}
+	for key := range cache.relays {
+		relayKeys = append(relayKeys, key)
+	}
+	sort.Strings(relayKeys)
+	for _, key := range relayKeys {
+		value, dirty := cache.relays[key].unpack()
+		if value != nil && dirty {
+			cache.backend.setRelayEntry([]byte(key), value)
+		}
+	}
+
+	// Update bridge entries in order of key
+	bridgeKeys := []string{}
+	for key := range cache.bridge {
+		bridgeKeys = append(bridgeKeys, key)
+	}
+	sort.Strings(bridgeKeys)
+	for _, key := range bridgeKeys {
+		value, dirty := cache.bridge[key].unpack()
+		if value != nil && dirty {
+			cache.backend.setBridgeEntry([]byte(key), value)
+		}
+	}
+
+	// Update private tx records in order of tx hash
+	privateKeys := []string{}
+	for key := range cache.private {
+		privateKeys = append(privateKeys, key)
+	}
+	sort.Strings(privateKeys)
+	for _, key := range privateKeys {
+		value, dirty := cache.private[key].unpack()
+		if value != nil && dirty {
+			cache.backend.setPrivateTxEntry([]byte(key), value)
+		}
+	}
+
+	// Update or remove confidential entries in order of key
+	confidentialKeys := []string{}
+	for key := range cache.confidential {
+		confidentialKeys = append(confidentialKeys, key)
+	}
+	sort.Strings(confidentialKeys)
+	for _, key := range confidentialKeys {
+		value, dirty := cache.confidential[key].unpack()
+		if value != nil && dirty {
+			cache.backend.setConfidentialEntry([]byte(key), value)
+		}
+	}
+
+	// Change the chain parameters in the order they were changed
+	for _, params := range cache.chainParams {
+		if err := cache.backend.SetChainParams(params); err != nil {
+			sanity.PanicSanity(fmt.Sprintf("Could not set chain parameters: %v", err))
+		}
+	}
+	cache.chainParams = nil
+
 }
 
 //-----------------------------------------------------------------------------
@@ -302,3 +953,97 @@ type nameInfo struct {
 func (nInfo nameInfo) unpack() (*core_types.NameRegEntry, bool, bool) {
 	return nInfo.name, nInfo.removed, nInfo.dirty
 }
+
+type abiInfo struct {
+	entry *core_types.ABIEntry
+	dirty bool
+}
+
+func (aInfo abiInfo) unpack() (*core_types.ABIEntry, bool) {
+	return aInfo.entry, aInfo.dirty
+}
+
+type ballotInfo struct {
+	ballot *Ballot
+	dirty  bool
+}
+
+func (bInfo ballotInfo) unpack() (*Ballot, bool) {
+	return bInfo.ballot, bInfo.dirty
+}
+
+type bondInfo struct {
+	bond    *ValidatorBond
+	removed bool
+	dirty   bool
+}
+
+func (bInfo bondInfo) unpack() (*ValidatorBond, bool, bool) {
+	return bInfo.bond, bInfo.removed, bInfo.dirty
+}
+
+type jailInfo struct {
+	jail  *ValidatorJail
+	dirty bool
+}
+
+func (jInfo jailInfo) unpack() (*ValidatorJail, bool) {
+	return jInfo.jail, jInfo.dirty
+}
+
+type missedInfo struct {
+	missed *MissedBlocks
+	dirty  bool
+}
+
+func (mInfo missedInfo) unpack() (*MissedBlocks, bool) {
+	return mInfo.missed, mInfo.dirty
+}
+
+type nodeInfo struct {
+	entry *core_types.NodeRegEntry
+	dirty bool
+}
+
+func (nInfo nodeInfo) unpack() (*core_types.NodeRegEntry, bool) {
+	return nInfo.entry, nInfo.dirty
+}
+
+// An encoded entry of a tree of state, such as those of the relays and the
+// bridge
+type entryInfo struct {
+	value []byte
+	dirty bool
+}
+
+func (eInfo entryInfo) unpack() ([]byte, bool) {
+	return eInfo.value, eInfo.dirty
+}
+
+type validatorRotationsByKey []*ValidatorRotation
+
+func (rs validatorRotationsByKey) Len() int {
+	return len(rs)
+}
+
+func (rs validatorRotationsByKey) Less(i, j int) bool {
+	return bytes.Compare(validatorRotationKey(rs[i]), validatorRotationKey(rs[j])) < 0
+}
+
+func (rs validatorRotationsByKey) Swap(i, j int) {
+	rs[i], rs[j] = rs[j], rs[i]
+}
+
+type validatorPowerChangesByKey []*ValidatorPowerChange
+
+func (cs validatorPowerChangesByKey) Len() int {
+	return len(cs)
+}
+
+func (cs validatorPowerChangesByKey) Less(i, j int) bool {
+	return bytes.Compare(validatorPowerChangeKey(cs[i]), validatorPowerChangeKey(cs[j])) < 0
+}
+
+func (cs validatorPowerChangesByKey) Swap(i, j int) {
+	cs[i], cs[j] = cs[j], cs[i]
+}
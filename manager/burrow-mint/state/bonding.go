@@ -0,0 +1,430 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The power that changes at a height, added and removed, may be at most
+// 1/maxPowerChangeDenominator of the power of the validators before it, so
+// that the validators that sign one block hold more than two thirds of the
+// power of the validators of the next
+const maxPowerChangeDenominator = int64(3)
+
+// The voting power PubKey has from Height, which removes it from the
+// validators when 0
+type ValidatorPowerChange struct {
+	Height int                  `json:"height"`
+	PubKey crypto.PubKeyEd25519 `json:"pub_key"`
+	Power  int64                `json:"power"`
+}
+
+// Power changes are keyed by height then by address, as rotations are, so a
+// validator has at most one power change at each height
+func validatorPowerChangeKey(change *ValidatorPowerChange) []byte {
+	key := make([]byte, 8, 8+20)
+	binary.BigEndian.PutUint64(key, uint64(change.Height))
+	return append(key, change.PubKey.Address()...)
+}
+
+func DecodeValidatorPowerChange(changeBytes []byte) *ValidatorPowerChange {
+	change := new(ValidatorPowerChange)
+	readBinary(changeBytes, change)
+	return change
+}
+
+// The coins bonded to a validator by BondTxs, which add to any power it has
+// from genesis, and the accounts they are paid to once it unbonds
+type ValidatorBond struct {
+	PubKey   crypto.PubKeyEd25519 `json:"pub_key"`
+	Amount   int64                `json:"amount"`
+	UnbondTo []*txs.TxOutput      `json:"unbond_to"`
+	// The height of the block of the last BondTx, UnbondTxs must be for a
+	// later height
+	Height int `json:"height"`
+}
+
+func DecodeValidatorBond(bondBytes []byte) *ValidatorBond {
+	bond := new(ValidatorBond)
+	readBinary(bondBytes, bond)
+	return bond
+}
+
+// The coins of a validator that has unbonded, which are held until
+// ReleaseHeight in case it is punished for what it did while validating
+type Unbonding struct {
+	ReleaseHeight int             `json:"release_height"`
+	Address       []byte          `json:"address"`
+	UnbondTo      []*txs.TxOutput `json:"unbond_to"`
+}
+
+func unbondingKey(unbonding *Unbonding) []byte {
+	key := make([]byte, 8, 8+len(unbonding.Address))
+	binary.BigEndian.PutUint64(key, uint64(unbonding.ReleaseHeight))
+	return append(key, unbonding.Address...)
+}
+
+func DecodeUnbonding(unbondingBytes []byte) *Unbonding {
+	unbonding := new(Unbonding)
+	readBinary(unbondingBytes, unbonding)
+	return unbonding
+}
+
+//-------------------------------------
+// State.validatorPowers
+
+// Gets every power change ever made, including those yet to take effect, in
+// the order they take effect
+func (s *State) GetValidatorPowerChanges() []*ValidatorPowerChange {
+	var changes []*ValidatorPowerChange
+	s.validatorPowers.Iterate(func(key, value []byte) bool {
+		changes = append(changes, DecodeValidatorPowerChange(value))
+		return false
+	})
+	return changes
+}
+
+func (s *State) SetValidatorPowerChange(change *ValidatorPowerChange) bool {
+	return s.validatorPowers.Set(validatorPowerChangeKey(change),
+		wire.BinaryBytes(change))
+}
+
+// State.validatorPowers
+//-------------------------------------
+// State.bonds
+
+func (s *State) GetValidatorBond(address []byte) *ValidatorBond {
+	_, valueBytes, _ := s.bonds.Get(address)
+	if valueBytes == nil {
+		return nil
+	}
+	return DecodeValidatorBond(valueBytes)
+}
+
+func (s *State) UpdateValidatorBond(bond *ValidatorBond) bool {
+	return s.bonds.Set(bond.PubKey.Address(), wire.BinaryBytes(bond))
+}
+
+func (s *State) RemoveValidatorBond(address []byte) bool {
+	_, removed := s.bonds.Remove(address)
+	return removed
+}
+
+// State.bonds
+//-------------------------------------
+// State.unbondings
+
+func (s *State) AddUnbonding(unbonding *Unbonding) bool {
+	return s.unbondings.Set(unbondingKey(unbonding), wire.BinaryBytes(unbonding))
+}
+
+// Pays out the unbondings released by height to the accounts they unbond to,
+// creating them if need be, and removes them
+func (s *State) ReleaseUnbondings(height int) []*Unbonding {
+	var released []*Unbonding
+	s.unbondings.Iterate(func(key, value []byte) bool {
+		unbonding := DecodeUnbonding(value)
+		if unbonding.ReleaseHeight > height {
+			return true
+		}
+		released = append(released, unbonding)
+		return false
+	})
+	for _, unbonding := range released {
+		for _, out := range unbonding.UnbondTo {
+			acc := s.GetAccount(out.Address)
+			if acc == nil {
+				acc = &acm.Account{
+					Address:     out.Address,
+					Permissions: ptypes.ZeroAccountPermissions,
+				}
+			}
+			acc.Balance += out.Amount
+			s.UpdateAccount(acc)
+		}
+		s.unbondings.Remove(unbondingKey(unbonding))
+	}
+	return released
+}
+
+// State.unbondings
+//-------------------------------------
+
+// Gets the validators from the block after the next, whose power the txs of
+// the next block change
+func nextValidators(blockCache *BlockCache) []*tm_types.Validator {
+	_s := blockCache.State()
+	return ValidatorsAt(_s.GenesisValidators(), blockCache.GetValidatorRotations(),
+		blockCache.GetValidatorPowerChanges(), _s.LastBlockHeight+2)
+}
+
+// Checks that change can be made, given the power changes made so far: the
+// validator must not be in a rotation yet to take effect, the power changed at
+// the height of change must be within the limit, and there must be a
+// validator left.
+func validatePowerChange(blockCache *BlockCache, change *ValidatorPowerChange) error {
+	_s := blockCache.State()
+	genesisValidators := _s.GenesisValidators()
+	if genesisValidators == nil {
+		return fmt.Errorf("The genesis validators are not known so validator " +
+			"power cannot be changed")
+	}
+	rotations := blockCache.GetValidatorRotations()
+	for _, rotation := range rotations {
+		if rotation.Height > _s.LastBlockHeight && (rotation.PubKey == change.PubKey ||
+			rotation.NewPubKey == change.PubKey) {
+			return fmt.Errorf("%X is in a rotation at height %v",
+				change.PubKey.Address(), rotation.Height)
+		}
+	}
+	var earlier, changes []*ValidatorPowerChange
+	for _, pending := range blockCache.GetValidatorPowerChanges() {
+		if pending.Height < change.Height {
+			earlier = append(earlier, pending)
+		} else if pending.Height == change.Height && pending.PubKey != change.PubKey {
+			changes = append(changes, pending)
+		}
+	}
+	changes = append(changes, change)
+
+	var totalPower, changedPower int64
+	for _, validator := range ValidatorsAt(genesisValidators, rotations, earlier,
+		change.Height-1) {
+		totalPower += validator.VotingPower
+	}
+	validators := ValidatorsAt(genesisValidators, rotations, earlier, change.Height)
+	for _, pending := range changes {
+		power := pending.Power
+		if i := findValidator(validators, pending.PubKey); i >= 0 {
+			power -= validators[i].VotingPower
+		}
+		if power < 0 {
+			power = -power
+		}
+		changedPower += power
+	}
+	if changedPower*maxPowerChangeDenominator > totalPower {
+		return fmt.Errorf("Validator power changed at height %v would be %v, "+
+			"more than 1/%v of the power %v of the validators", change.Height,
+			changedPower, maxPowerChangeDenominator, totalPower)
+	}
+	if len(ValidatorsAt(genesisValidators, rotations, append(earlier, changes...),
+		change.Height)) == 0 {
+		return fmt.Errorf("There would be no validators left at height %v",
+			change.Height)
+	}
+	return nil
+}
+
+// Bonds the coins of the inputs of tx that it unbonds to, which must be
+// signed for by the validator key as well as the inputs, adding them to the
+// power of the validator from the block after the next. The rest of the input
+// coins are taken as the fee.
+func execBondTx(blockCache *BlockCache, tx *txs.BondTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	if len(tx.Inputs) == 0 || len(tx.UnbondTo) == 0 {
+		return fmt.Errorf("BondTx must have inputs and accounts to unbond to")
+	}
+	accounts, err := getInputs(blockCache, tx.Inputs, nil)
+	if err != nil {
+		return err
+	}
+	if !hasBondOrSendPermission(blockCache, accounts, logger) {
+		return fmt.Errorf("At least one input lacks permission to bond")
+	}
+	// A validator without an account can bond if bonding is allowed globally
+	bondAcc := blockCache.GetAccount(tx.PubKey.Address())
+	if bondAcc == nil {
+		bondAcc = blockCache.GetAccount(ptypes.GlobalPermissionsAddress)
+	}
+	if !hasBondPermission(blockCache, bondAcc, logger) {
+		return fmt.Errorf("The bonder does not have permission to bond")
+	}
+	// The accounts to unbond to are only created once the bond is released
+	canCreate := hasCreateAccountPermission(blockCache, accounts, logger)
+	for _, out := range tx.UnbondTo {
+		if blockCache.GetAccount(out.Address) == nil && !canCreate {
+			return fmt.Errorf("At least one input does not have permission to create accounts")
+		}
+	}
+
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	inTotal, err := validateInputs(accounts, signBytes, tx.Inputs, nil)
+	if err != nil {
+		return err
+	}
+	if !tx.PubKey.VerifyBytes(signBytes, tx.Signature) {
+		logging.InfoMsg(logger, "Bond is not signed by the validator key",
+			"address", tx.PubKey.Address())
+		return txs.ErrTxInvalidSignature
+	}
+	outTotal, err := validateOutputs(tx.UnbondTo)
+	if err != nil {
+		return err
+	}
+	if outTotal > inTotal {
+		return txs.ErrTxInsufficientFunds
+	}
+	if err := validateFee(_s, inTotal-outTotal, 0); err != nil {
+		return err
+	}
+	// A jailed validator rejoins with the power it had left once released
+	jail := blockCache.GetValidatorJail(tx.PubKey.Address())
+	if jail != nil && !jail.Jailed {
+		jail = nil
+	}
+	if jail != nil && jail.ReleaseHeight > _s.LastBlockHeight+1 {
+		return fmt.Errorf("Validator %X is jailed until height %v",
+			tx.PubKey.Address(), jail.ReleaseHeight)
+	}
+
+	change := &ValidatorPowerChange{
+		Height: _s.LastBlockHeight + 2,
+		PubKey: tx.PubKey,
+		Power:  outTotal,
+	}
+	validators := nextValidators(blockCache)
+	if i := findValidator(validators, tx.PubKey); i >= 0 {
+		change.Power += validators[i].VotingPower
+	} else if jail != nil {
+		change.Power += jail.Power
+	}
+	if err := validatePowerChange(blockCache, change); err != nil {
+		logging.InfoMsg(logger, "Invalid validator power change", "error", err)
+		return err
+	}
+	logging.TraceMsg(logger, "Bonding validator",
+		"address", tx.PubKey.Address(),
+		"power", change.Power,
+		"height", change.Height)
+
+	// Good! Adjust accounts
+	adjustByInputs(accounts, tx.Inputs)
+	for _, acc := range accounts {
+		blockCache.UpdateAccount(acc)
+	}
+	payFee(blockCache, inTotal-outTotal, 0)
+	bond := blockCache.GetValidatorBond(tx.PubKey.Address())
+	if bond == nil {
+		bond = &ValidatorBond{PubKey: tx.PubKey}
+	}
+	bond.Amount += outTotal
+	bond.UnbondTo = append(bond.UnbondTo, tx.UnbondTo...)
+	bond.Height = _s.LastBlockHeight + 1
+	blockCache.UpdateValidatorBond(bond)
+	blockCache.SetValidatorPowerChange(change)
+	if jail != nil {
+		jail.Jailed = false
+		jail.Power = 0
+		blockCache.UpdateValidatorJail(jail)
+	}
+
+	if evc != nil {
+		for _, in := range tx.Inputs {
+			evc.FireEvent(txs.EventStringAccInput(in.Address), txs.EventDataTx{tx, nil, ""})
+		}
+		evc.FireEvent(txs.EventStringBond(), txs.EventDataTx{tx, nil, ""})
+	}
+	return nil
+}
+
+// Removes the validator of tx, signed by its key, from the block after the
+// next. The coins bonded to it are released to the accounts they unbond to
+// once the unbonding period has passed. A jailed validator can unbond too,
+// leaving jail for good.
+func execUnbondTx(blockCache *BlockCache, tx *txs.UnbondTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	var pubKey crypto.PubKeyEd25519
+	validators := nextValidators(blockCache)
+	jail := blockCache.GetValidatorJail(tx.Address)
+	if i := findValidatorByAddress(validators, tx.Address); i >= 0 {
+		var ok bool
+		pubKey, ok = validators[i].PubKey.(crypto.PubKeyEd25519)
+		if !ok {
+			return fmt.Errorf("Validator %X does not have an ed25519 key", tx.Address)
+		}
+		jail = nil
+	} else if jail != nil && jail.Jailed {
+		pubKey = jail.PubKey
+	} else {
+		return txs.ErrTxInvalidAddress
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if !pubKey.VerifyBytes(signBytes, tx.Signature) {
+		return txs.ErrTxInvalidSignature
+	}
+	// The height stops the tx being replayed once the validator bonds again
+	bond := blockCache.GetValidatorBond(tx.Address)
+	bondHeight := 0
+	if bond != nil {
+		bondHeight = bond.Height
+	}
+	if tx.Height <= bondHeight || tx.Height > _s.LastBlockHeight+1 {
+		return fmt.Errorf("Unbond height must be after %v and no later than %v, "+
+			"not %v", bondHeight, _s.LastBlockHeight+1, tx.Height)
+	}
+
+	change := &ValidatorPowerChange{
+		Height: _s.LastBlockHeight + 2,
+		PubKey: pubKey,
+		Power:  0,
+	}
+	if jail == nil {
+		if err := validatePowerChange(blockCache, change); err != nil {
+			logging.InfoMsg(logger, "Invalid validator power change", "error", err)
+			return err
+		}
+	}
+	logging.TraceMsg(logger, "Unbonding validator",
+		"address", tx.Address,
+		"height", change.Height)
+
+	// Good!
+	if jail == nil {
+		blockCache.SetValidatorPowerChange(change)
+	} else {
+		jail.Jailed = false
+		jail.Power = 0
+		blockCache.UpdateValidatorJail(jail)
+	}
+	if bond != nil {
+		blockCache.RemoveValidatorBond(tx.Address)
+		blockCache.AddUnbonding(&Unbonding{
+			ReleaseHeight: _s.LastBlockHeight + 1 + unbondingPeriodBlocks,
+			Address:       tx.Address,
+			UnbondTo:      bond.UnbondTo,
+		})
+	}
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringUnbond(), txs.EventDataTx{tx, nil, ""})
+	}
+	return nil
+}
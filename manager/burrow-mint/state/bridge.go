@@ -0,0 +1,274 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+)
+
+// The bridge tree holds the attestations of events by their ID, the events
+// designated for the bridge and the Ethereum addresses validators have bound,
+// under keys with these prefixes. Attestations sort first, so they can be
+// listed by iterating from the start of the tree.
+const (
+	attestationPrefix     = byte('a')
+	designatedEventPrefix = byte('d')
+	bridgeKeyPrefix       = byte('k')
+)
+
+func attestationKey(id []byte) []byte {
+	return append([]byte{attestationPrefix}, id...)
+}
+
+func designatedEventKey(address []byte, topic Word256) []byte {
+	key := append([]byte{designatedEventPrefix}, LeftPadBytes(address, 20)...)
+	return append(key, topic.Bytes()...)
+}
+
+func bridgeKeyKey(validator []byte) []byte {
+	return append([]byte{bridgeKeyPrefix}, validator...)
+}
+
+//-------------------------------------
+// State.bridge
+
+func (s *State) getBridgeEntry(key []byte) []byte {
+	_, value, _ := s.bridge.Get(key)
+	return value
+}
+
+func (s *State) setBridgeEntry(key, value []byte) bool {
+	return s.bridge.Set(key, value)
+}
+
+// Get the attestation with id, nil if no validator has attested its event
+func (s *State) GetAttestation(id []byte) *core_types.Attestation {
+	return decodeAttestation(s.getBridgeEntry(attestationKey(id)))
+}
+
+func (s *State) UpdateAttestation(attestation *core_types.Attestation) bool {
+	return s.setBridgeEntry(attestationKey(attestation.ID),
+		wire.BinaryBytes(attestation))
+}
+
+// Get the attestations of events emitted from minHeight in order of ID
+func (s *State) GetAttestations(minHeight int64) []*core_types.Attestation {
+	var attestations []*core_types.Attestation
+	s.bridge.Iterate(func(key, value []byte) bool {
+		if key[0] != attestationPrefix {
+			return true
+		}
+		if attestation := decodeAttestation(value); attestation.Event.Height >= minHeight {
+			attestations = append(attestations, attestation)
+		}
+		return false
+	})
+	return attestations
+}
+
+// Get the event of the contract at address with the signature topic, nil if
+// it has never been designated
+func (s *State) GetDesignatedEvent(address []byte, topic Word256) *core_types.DesignatedEvent {
+	return decodeDesignatedEvent(s.getBridgeEntry(designatedEventKey(address, topic)))
+}
+
+func (s *State) UpdateDesignatedEvent(event *core_types.DesignatedEvent) bool {
+	return s.setBridgeEntry(designatedEventKey(event.Address, event.Topic),
+		wire.BinaryBytes(event))
+}
+
+// Get the events designated for the bridge, including those revoked, in order
+// of address and topic
+func (s *State) GetDesignatedEvents() []*core_types.DesignatedEvent {
+	var events []*core_types.DesignatedEvent
+	s.bridge.Iterate(func(key, value []byte) bool {
+		if key[0] < designatedEventPrefix {
+			return false
+		}
+		if key[0] > designatedEventPrefix {
+			return true
+		}
+		events = append(events, decodeDesignatedEvent(value))
+		return false
+	})
+	return events
+}
+
+// Get the Ethereum address the validator with address has bound for the
+// bridge, nil if it has not attested an event yet
+func (s *State) GetBridgeKey(validator []byte) []byte {
+	return s.getBridgeEntry(bridgeKeyKey(validator))
+}
+
+func (s *State) SetBridgeKey(validator, ethAddress []byte) bool {
+	return s.setBridgeEntry(bridgeKeyKey(validator), ethAddress)
+}
+
+func decodeAttestation(attestationBytes []byte) *core_types.Attestation {
+	if attestationBytes == nil {
+		return nil
+	}
+	attestation := new(core_types.Attestation)
+	readBinary(attestationBytes, attestation)
+	return attestation
+}
+
+func decodeDesignatedEvent(eventBytes []byte) *core_types.DesignatedEvent {
+	if eventBytes == nil {
+		return nil
+	}
+	event := new(core_types.DesignatedEvent)
+	readBinary(eventBytes, event)
+	return event
+}
+
+// State.bridge
+//-------------------------------------
+
+// Designates and revokes the events of params for the bridge from the block
+// executing
+func setBridgeParams(blockCache *BlockCache, params *txs.BridgeParams) {
+	height := blockCache.State().LastBlockHeight + 1
+	for _, spec := range params.Designate {
+		blockCache.UpdateDesignatedEvent(&core_types.DesignatedEvent{
+			Address:      spec.Address,
+			Topic:        spec.Topic,
+			DesignatedAt: height,
+		})
+	}
+	for _, spec := range params.Revoke {
+		if event := blockCache.GetDesignatedEvent(spec.Address, spec.Topic); event != nil &&
+			event.RevokedAt == 0 {
+			event.RevokedAt = height
+			blockCache.UpdateDesignatedEvent(event)
+		}
+	}
+}
+
+// Adds the signature of a validator of the next block to the attestation of
+// the designated event of tx, which it completes once validators with more
+// than two thirds of the power of the next block have signed it. The first
+// AttestTx of a validator binds the Ethereum address that signed it to the
+// validator, and the Ethereum signatures of its later AttestTxs must be by the
+// same address.
+func execAttestTx(blockCache *BlockCache, tx *txs.AttestTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	address := tx.PubKey.Address()
+	if err := tx.ValidateBasic(); err != nil {
+		logging.InfoMsg(logger, "Invalid attestation",
+			"address", address,
+			"error", err)
+		return err
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if !tx.PubKey.VerifyBytes(signBytes, tx.Signature) {
+		logging.InfoMsg(logger, "Attestation is not signed by the validator key",
+			"address", address)
+		return txs.ErrTxInvalidSignature
+	}
+	validators := ValidatorsAt(_s.GenesisValidators(), blockCache.GetValidatorRotations(),
+		blockCache.GetValidatorPowerChanges(), _s.LastBlockHeight+1)
+	if findValidator(validators, tx.PubKey) < 0 {
+		return fmt.Errorf("%X is not a validator so cannot attest events", address)
+	}
+	event := tx.Event
+	if event.Height > int64(_s.LastBlockHeight) {
+		return fmt.Errorf("Event at height %v has not been committed, the last "+
+			"block is at height %v", event.Height, _s.LastBlockHeight)
+	}
+	designated := blockCache.GetDesignatedEvent(event.Address, event.Topics[0])
+	if designated == nil || designated.RevokedAt != 0 {
+		return fmt.Errorf("Event %X of %X is not designated for the bridge",
+			event.Topics[0], event.Address)
+	}
+	ethAddress, err := tx.EthAddress(_s.ChainID)
+	if err != nil {
+		return fmt.Errorf("Could not recover the Ethereum address that signed "+
+			"the attestation: %v", err)
+	}
+	if bound := blockCache.GetBridgeKey(address); bound != nil &&
+		!bytes.Equal(bound, ethAddress) {
+		return fmt.Errorf("Attestation is signed by %X but validator %X bridges "+
+			"with %X", ethAddress, address, bound)
+	}
+	id := txs.AttestationID(_s.ChainID, event)
+	attestation := blockCache.GetAttestation(id)
+	if attestation == nil {
+		attestation = &core_types.Attestation{
+			ID:    id,
+			Event: event,
+		}
+	}
+	for _, signature := range attestation.Signatures {
+		if bytes.Equal(signature.Validator, address) {
+			return fmt.Errorf("Validator %X has already attested %X", address, id)
+		}
+	}
+
+	// Good!
+	logging.TraceMsg(logger, "Attesting event",
+		"address", address,
+		"id", id,
+		"eth_address", ethAddress)
+	// The power of earlier signatures is that of the validators at this height,
+	// so those that have stopped being validators count for nothing
+	attestation.Signatures = append(attestation.Signatures,
+		&core_types.AttestationSignature{
+			Validator:  address,
+			EthAddress: ethAddress,
+			Signature:  tx.EthSignature,
+		})
+	attestation.Power = 0
+	for _, signature := range attestation.Signatures {
+		signature.Power = 0
+		if i := findValidatorByAddress(validators, signature.Validator); i >= 0 {
+			signature.Power = validators[i].VotingPower
+		}
+		attestation.Power += signature.Power
+	}
+	attestation.TotalPower = 0
+	for _, validator := range validators {
+		attestation.TotalPower += validator.VotingPower
+	}
+	completed := false
+	if attestation.CompletedAt == 0 &&
+		attestation.Power*3 > attestation.TotalPower*2 {
+		attestation.CompletedAt = _s.LastBlockHeight + 1
+		completed = true
+	}
+	blockCache.UpdateAttestation(attestation)
+	blockCache.SetBridgeKey(address, ethAddress)
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(address), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringAttest(), txs.EventDataTx{tx, nil, ""})
+		if completed {
+			evc.FireEvent(txs.EventStringAttestation(id), txs.EventDataTx{tx, nil, ""})
+		}
+	}
+	return nil
+}
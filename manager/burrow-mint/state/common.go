@@ -16,6 +16,7 @@ package state
 
 import (
 	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm"
 	. "github.com/hyperledger/burrow/word256"
 )
@@ -24,6 +25,25 @@ type AccountGetter interface {
 	GetAccount(addr []byte) *acm.Account
 }
 
+type ABIGetter interface {
+	AccountGetter
+	GetABIEntry(address []byte) *core_types.ABIEntry
+}
+
+// Get the ABI registered for the contract at address, or nil if there is no
+// contract there or its creator has not registered one
+func GetContractABIEntry(abis ABIGetter, address []byte) *core_types.ABIEntry {
+	acc := abis.GetAccount(address)
+	if acc == nil || len(acc.Code) == 0 {
+		return nil
+	}
+	entry := abis.GetABIEntry(address)
+	if entry == nil || (entry.ABI == "" && len(entry.ABIHash) == 0) {
+		return nil
+	}
+	return entry
+}
+
 type VMAccountState interface {
 	GetAccount(addr Word256) *vm.Account
 	UpdateAccount(acc *vm.Account)
@@ -0,0 +1,83 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The confidential tree holds the blobs put by accounts with the Confidential
+// SNative and the grants to read them, under keys with these prefixes. Removed
+// entries are kept in caches as empty values.
+const (
+	confidentialBlobPrefix  = byte('b')
+	confidentialGrantPrefix = byte('g')
+)
+
+func confidentialBlobKey(owner []byte, key Word256) []byte {
+	blobKey := append([]byte{confidentialBlobPrefix}, LeftPadBytes(owner, 20)...)
+	return append(blobKey, key.Bytes()...)
+}
+
+func confidentialGrantKey(owner []byte, key Word256, reader []byte) []byte {
+	grantKey := append([]byte{confidentialGrantPrefix}, LeftPadBytes(owner, 20)...)
+	grantKey = append(grantKey, key.Bytes()...)
+	return append(grantKey, LeftPadBytes(reader, 20)...)
+}
+
+func confidentialGrantValue(granted bool) []byte {
+	if granted {
+		return []byte{1}
+	}
+	return []byte{}
+}
+
+//-------------------------------------
+// State.confidential
+
+func (s *State) getConfidentialEntry(key []byte) []byte {
+	_, value, _ := s.confidential.Get(key)
+	return value
+}
+
+// Sets the entry at key, removing it when value is empty
+func (s *State) setConfidentialEntry(key, value []byte) bool {
+	if len(value) == 0 {
+		_, removed := s.confidential.Remove(key)
+		return removed
+	}
+	return s.confidential.Set(key, value)
+}
+
+// Get the blob owner put at key, nil if there is none
+func (s *State) GetConfidentialBlob(owner []byte, key Word256) []byte {
+	return s.getConfidentialEntry(confidentialBlobKey(owner, key))
+}
+
+// Whether owner granted reader to read the blob at key
+func (s *State) IsConfidentialGranted(owner []byte, key Word256, reader []byte) bool {
+	return len(s.getConfidentialEntry(confidentialGrantKey(owner, key, reader))) > 0
+}
+
+// Whether reader may read the blob owner puts at key, as its owner or by a
+// grant
+func (s *State) CanReadConfidential(owner []byte, key Word256, reader []byte) bool {
+	return bytes.Equal(owner, reader) || s.IsConfidentialGranted(owner, key, reader)
+}
+
+// State.confidential
+//-------------------------------------
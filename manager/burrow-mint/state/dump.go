@@ -0,0 +1,163 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	genesis "github.com/hyperledger/burrow/genesis"
+
+	dbm "github.com/tendermint/go-db"
+	"github.com/tendermint/go-merkle"
+	wire "github.com/tendermint/go-wire"
+)
+
+// The most storage items written in a single dump record
+const maxDumpStorageItems = 1000
+
+// A dump is a stream of newline separated JSON records. The first record
+// carries only the header fields, then each account is followed by records
+// holding its storage, and then come the name registry entries.
+type DumpRecord struct {
+	// Header
+	ChainID string `json:"chain_id,omitempty"`
+	Height  int    `json:"height,omitempty"`
+
+	Account      *acm.Account             `json:"account,omitempty"`
+	Storage      []core_types.StorageItem `json:"storage,omitempty"`
+	NameRegEntry *core_types.NameRegEntry `json:"name_reg_entry,omitempty"`
+	ABIEntry     *core_types.ABIEntry     `json:"abi_entry,omitempty"`
+}
+
+// Writes the accounts, their storage, the name registry, and the ABI registry
+// of s to w as they are read. The dump is of the state at height
+// s.LastBlockHeight, which for state loaded at a past height is that height.
+func (s *State) Dump(w io.Writer) error {
+	err := writeDumpRecord(w, &DumpRecord{ChainID: s.ChainID,
+		Height: s.LastBlockHeight})
+	if err != nil {
+		return err
+	}
+	s.accounts.Iterate(func(key, value []byte) bool {
+		account := acm.DecodeAccount(value)
+		if err = writeDumpRecord(w, &DumpRecord{Account: account}); err != nil {
+			return true
+		}
+		var items []core_types.StorageItem
+		s.LoadStorage(account.StorageRoot).Iterate(func(key, value []byte) bool {
+			items = append(items, core_types.StorageItem{Key: key, Value: value})
+			if len(items) == maxDumpStorageItems {
+				err = writeDumpRecord(w, &DumpRecord{Storage: items})
+				items = nil
+			}
+			return err != nil
+		})
+		if err == nil && len(items) > 0 {
+			err = writeDumpRecord(w, &DumpRecord{Storage: items})
+		}
+		return err != nil
+	})
+	if err != nil {
+		return err
+	}
+	s.nameReg.Iterate(func(key, value []byte) bool {
+		err = writeDumpRecord(w, &DumpRecord{NameRegEntry: DecodeNameRegEntry(value)})
+		return err != nil
+	})
+	if err != nil {
+		return err
+	}
+	s.abiRegistry.Iterate(func(key, value []byte) bool {
+		err = writeDumpRecord(w, &DumpRecord{ABIEntry: DecodeABIEntry(value)})
+		return err != nil
+	})
+	return err
+}
+
+// Makes the genesis state of a new chain from genDoc, as MakeGenesisState
+// does, then loads the accounts, storage, name registry, and ABI registry
+// entries of a dump read from r on top. Accounts in the dump replace those of
+// the same address in genDoc. The caller is responsible for saving the returned state.
+func RestoreState(db dbm.DB, genDoc *genesis.GenesisDoc, r io.Reader) (*State, error) {
+	s := MakeGenesisState(db, genDoc)
+	reader := bufio.NewReader(r)
+	var account *acm.Account
+	var storage merkle.Tree
+	// Storage is restored to a fresh tree so the account's root must be updated
+	flushAccount := func() {
+		if account != nil {
+			account.StorageRoot = storage.Save()
+			s.UpdateAccount(account)
+			account = nil
+		}
+	}
+	for line := 1; ; line++ {
+		recordBytes, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(bytes.TrimSpace(recordBytes)) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Could not read dump: %v", err)
+		}
+		record := new(DumpRecord)
+		errR := new(error)
+		wire.ReadJSONPtr(record, recordBytes, errR)
+		if *errR != nil {
+			return nil, fmt.Errorf("Could not decode dump record on line %v: %v",
+				line, *errR)
+		}
+		switch {
+		case record.Account != nil:
+			flushAccount()
+			account = record.Account
+			storage = s.LoadStorage(nil)
+		case len(record.Storage) > 0:
+			if account == nil {
+				return nil, fmt.Errorf("Storage on line %v of dump does not "+
+					"follow an account", line)
+			}
+			for _, item := range record.Storage {
+				storage.Set(item.Key, item.Value)
+			}
+		case record.NameRegEntry != nil:
+			flushAccount()
+			s.UpdateNameRegEntry(record.NameRegEntry)
+		case record.ABIEntry != nil:
+			flushAccount()
+			s.UpdateABIEntry(record.ABIEntry)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	flushAccount()
+	return s, nil
+}
+
+func writeDumpRecord(w io.Writer, record *DumpRecord) error {
+	buf, n, err := new(bytes.Buffer), new(int), new(error)
+	wire.WriteJSON(record, buf, n, err)
+	if *err != nil {
+		return fmt.Errorf("Could not encode dump record: %v", *err)
+	}
+	buf.WriteByte('\n')
+	_, errW := w.Write(buf.Bytes())
+	return errW
+}
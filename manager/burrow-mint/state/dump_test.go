@@ -0,0 +1,108 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/logging/loggers"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	dbm "github.com/tendermint/go-db"
+)
+
+func TestDumpRestore(t *testing.T) {
+	st, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	cache := NewBlockCache(st)
+	contract := cache.GetAccount(privAccounts[0].Address)
+	contract.Code = []byte{0x60, 0x01}
+	cache.UpdateAccount(contract)
+	// Write enough storage to span more than one dump record
+	for i := int64(0); i < maxDumpStorageItems+10; i++ {
+		cache.SetStorage(LeftPadWord256(contract.Address), Int64ToWord256(i),
+			Int64ToWord256(i+1))
+	}
+	cache.UpdateNameRegEntry(&core_types.NameRegEntry{
+		Name:    "marmot",
+		Owner:   contract.Address,
+		Data:    "burrow",
+		Expires: 100,
+	})
+	cache.Sync()
+	st.Save()
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, st.Dump(buf))
+
+	genDoc, _, _ := RandGenesisDoc(1, true, 1000, 1, true, 1000)
+	restored, err := RestoreState(dbm.NewMemDB(), genDoc, buf)
+	assert.NoError(t, err)
+
+	for _, privAccount := range privAccounts {
+		assert.Equal(t, st.GetAccount(privAccount.Address).Balance,
+			restored.GetAccount(privAccount.Address).Balance)
+	}
+	restoredContract := restored.GetAccount(contract.Address)
+	assert.Equal(t, contract.Code, restoredContract.Code)
+	restoredCache := NewBlockCache(restored)
+	for _, i := range []int64{0, maxDumpStorageItems + 9} {
+		assert.Equal(t, Int64ToWord256(i+1),
+			restoredCache.GetStorage(LeftPadWord256(contract.Address), Int64ToWord256(i)))
+	}
+	restoredEntry := restored.GetNameRegEntry("marmot")
+	if assert.NotNil(t, restoredEntry) {
+		assert.Equal(t, "burrow", restoredEntry.Data)
+	}
+	// The chain id comes from the genesis of the new chain
+	assert.Equal(t, genDoc.ChainID, restored.ChainID)
+}
+
+func TestDumpAtHeight(t *testing.T) {
+	st, privAccounts, _ := RandGenesisState(1, true, 1000, 1, true, 1000)
+	address := privAccounts[0].Address
+	pruner, err := NewPruner(st.DB, PruningOptions{}, loggers.NewNoopInfoTraceLogger())
+	assert.NoError(t, err)
+	for height := 1; height <= 3; height++ {
+		cache := NewBlockCache(st)
+		account := cache.GetAccount(address)
+		account.Balance = int64(height)
+		cache.UpdateAccount(account)
+		cache.Sync()
+		st.LastBlockHeight = height
+		pruner.Save(st)
+	}
+
+	past, err := LoadStateAt(st, 2)
+	assert.NoError(t, err)
+	buf := new(bytes.Buffer)
+	assert.NoError(t, past.Dump(buf))
+	genDoc, _, _ := RandGenesisDoc(1, true, 1000, 1, true, 1000)
+	restored, err := RestoreState(dbm.NewMemDB(), genDoc, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), restored.GetAccount(address).Balance)
+
+	_, err = LoadStateAt(st, 4)
+	assert.Error(t, err)
+}
+
+func TestRestoreStorageWithoutAccount(t *testing.T) {
+	genDoc, _, _ := RandGenesisDoc(1, true, 1000, 1, true, 1000)
+	_, err := RestoreState(dbm.NewMemDB(), genDoc,
+		bytes.NewBufferString(`{"storage":[{"key":"01","value":"02"}]}`+"\n"))
+	assert.Error(t, err)
+}
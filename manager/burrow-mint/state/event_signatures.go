@@ -0,0 +1,293 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	dbm "github.com/tendermint/go-db"
+)
+
+const eventSignaturesKeyPrefix = "eventsignatures/"
+
+// EventSignatures records the events that logs may be decoded as by their
+// IDs, the hashes of their signatures that logs carry as their first topic.
+// Events are learnt from the ABIs registered on chain and from imported lists
+// of signatures, so that the logs of contracts whose own ABI is not registered
+// can still be decoded when they emit a well known event.
+type EventSignatures struct {
+	mtx     sync.Mutex
+	db      dbm.DB
+	pending map[Word256][]*EventSignature
+}
+
+// An event that logs may be decoded as
+type EventSignature struct {
+	Event *abi.Event `json:"event"`
+	// Whether the event was read from a bare signature, in which case the
+	// names of its inputs and which of them are indexed are not known
+	SignatureOnly bool `json:"signatureOnly"`
+}
+
+func NewEventSignatures(db dbm.DB) *EventSignatures {
+	return &EventSignatures{
+		db:      db,
+		pending: make(map[Word256][]*EventSignature),
+	}
+}
+
+// Adds the events of contractABI to be stored on the next call to Commit
+func (es *EventSignatures) AddABI(contractABI *abi.ABI) {
+	for _, event := range contractABI.Events {
+		if !event.Anonymous {
+			es.add(&EventSignature{Event: event})
+		}
+	}
+}
+
+// Adds the event of a canonical signature, such as
+// Transfer(address,address,uint256), to be stored on the next call to Commit
+func (es *EventSignatures) AddSignature(signature string) error {
+	event, err := abi.ParseEventSignature(signature)
+	if err != nil {
+		return err
+	}
+	es.add(&EventSignature{Event: event, SignatureOnly: true})
+	return nil
+}
+
+// Adds the signatures of a list to be stored on the next call to Commit,
+// returning how many were read. The list may be a JSON array of signatures,
+// a JSON array of objects with a text_signature and optionally its
+// hex_signature, as exported by 4byte.directory, either bare or as the
+// results of a page of them, or text with one signature a line in which blank
+// lines and lines starting with # are skipped.
+func (es *EventSignatures) Import(data []byte) (int, error) {
+	signatures, err := readSignatureList(data)
+	if err != nil {
+		return 0, err
+	}
+	for _, signature := range signatures {
+		if err := es.AddSignature(signature); err != nil {
+			return 0, err
+		}
+	}
+	return len(signatures), nil
+}
+
+// Writes the events added since the last commit
+func (es *EventSignatures) Commit() {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	for id, signatures := range es.pending {
+		bs, err := json.Marshal(signatures)
+		if err != nil {
+			continue
+		}
+		es.db.Set(eventSignaturesKey(id), bs)
+	}
+	es.pending = make(map[Word256][]*EventSignature)
+}
+
+// Returns the events with id, those learnt from ABIs first
+func (es *EventSignatures) Events(id Word256) []*EventSignature {
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	return es.events(id)
+}
+
+// Decodes log against the ABI registered for the contract that
+// emitted it if there is one and the event is in it, otherwise as the first
+// event with the log's ID that it can be decoded as. The inputs of events
+// known only by their signature are taken to be indexed in order while there
+// are topics for them. Returns nil if the log cannot be decoded.
+func (es *EventSignatures) DecodeLog(log txs.EventDataLog,
+	registry ABIGetter) *core_types.DecodedEvent {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+	if registry != nil {
+		if contractABI := registeredABI(registry, log.Address.Postfix(20)); contractABI != nil {
+			if event := contractABI.EventByID(log.Topics[0]); event != nil {
+				if decoded := decodeEvent(event, log.Topics, log.Data); decoded != nil {
+					return decoded
+				}
+			}
+		}
+	}
+	for _, signature := range es.Events(log.Topics[0]) {
+		event := signature.Event
+		if signature.SignatureOnly {
+			event = indexInputs(event, len(log.Topics)-1)
+			if event == nil {
+				continue
+			}
+		}
+		if decoded := decodeEvent(event, log.Topics, log.Data); decoded != nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+func (es *EventSignatures) add(signature *EventSignature) {
+	id := signature.Event.ID()
+	es.mtx.Lock()
+	defer es.mtx.Unlock()
+	signatures := es.events(id)
+	for i, existing := range signatures {
+		if existing.Event.Signature() != signature.Event.Signature() {
+			continue
+		}
+		if signature.SignatureOnly || sameEvent(existing, signature) {
+			return
+		}
+		if existing.SignatureOnly {
+			// An event from an ABI says more than its signature
+			signatures = append(signatures[:i], signatures[i+1:]...)
+			break
+		}
+	}
+	if signature.SignatureOnly {
+		signatures = append(signatures, signature)
+	} else {
+		// Keep the events from ABIs ahead of those from signatures
+		i := 0
+		for i < len(signatures) && !signatures[i].SignatureOnly {
+			i++
+		}
+		signatures = append(signatures[:i], append([]*EventSignature{signature},
+			signatures[i:]...)...)
+	}
+	es.pending[id] = signatures
+}
+
+func (es *EventSignatures) events(id Word256) []*EventSignature {
+	if signatures, ok := es.pending[id]; ok {
+		return append([]*EventSignature(nil), signatures...)
+	}
+	bs := es.db.Get(eventSignaturesKey(id))
+	if len(bs) == 0 {
+		return nil
+	}
+	var signatures []*EventSignature
+	if err := json.Unmarshal(bs, &signatures); err != nil {
+		return nil
+	}
+	return signatures
+}
+
+func sameEvent(a, b *EventSignature) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// Returns a copy of event with its first indexed inputs indexed, or nil if it
+// does not have that many inputs
+func indexInputs(event *abi.Event, indexed int) *abi.Event {
+	if indexed > len(event.Inputs) {
+		return nil
+	}
+	indexedEvent := &abi.Event{Name: event.Name}
+	for i, input := range event.Inputs {
+		indexedInput := *input
+		indexedInput.Indexed = i < indexed
+		indexedEvent.Inputs = append(indexedEvent.Inputs, &indexedInput)
+	}
+	return indexedEvent
+}
+
+type textSignature struct {
+	TextSignature string `json:"text_signature"`
+	HexSignature  string `json:"hex_signature"`
+}
+
+func readSignatureList(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '[' && data[0] != '{') {
+		var signatures []string
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				signatures = append(signatures, line)
+			}
+		}
+		return signatures, scanner.Err()
+	}
+	if data[0] == '{' {
+		page := new(struct {
+			Results json.RawMessage `json:"results"`
+		})
+		if err := json.Unmarshal(data, page); err != nil {
+			return nil, fmt.Errorf("Could not read signature list: %v", err)
+		}
+		if len(page.Results) == 0 {
+			return nil, fmt.Errorf("Signature list object has no results")
+		}
+		data = page.Results
+	}
+	var signatures []string
+	if err := json.Unmarshal(data, &signatures); err == nil {
+		return signatures, nil
+	}
+	signatures = nil
+	var textSignatures []textSignature
+	if err := json.Unmarshal(data, &textSignatures); err != nil {
+		return nil, fmt.Errorf("Could not read signature list: %v", err)
+	}
+	for _, ts := range textSignatures {
+		if ts.HexSignature != "" {
+			event, err := abi.ParseEventSignature(ts.TextSignature)
+			if err != nil {
+				return nil, err
+			}
+			hexSignature, err := hex.DecodeString(strings.TrimPrefix(ts.HexSignature, "0x"))
+			if err != nil {
+				return nil, fmt.Errorf("Could not read hex signature of %s: %v",
+					ts.TextSignature, err)
+			}
+			id := event.ID()
+			if !bytes.Equal(hexSignature, id[:]) {
+				return nil, fmt.Errorf("Hex signature %s is not that of %s, which is %X",
+					ts.HexSignature, ts.TextSignature, id[:])
+			}
+		}
+		signatures = append(signatures, ts.TextSignature)
+	}
+	return signatures, nil
+}
+
+func eventSignaturesKey(id Word256) []byte {
+	return []byte(fmt.Sprintf("%s%X", eventSignaturesKeyPrefix, id[:]))
+}
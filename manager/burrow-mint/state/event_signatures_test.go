@@ -0,0 +1,102 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/go-db"
+)
+
+const transferABI = `[{"type": "event", "name": "Transfer", "inputs": [
+	{"name": "from", "type": "address", "indexed": true},
+	{"name": "to", "type": "address", "indexed": true},
+	{"name": "value", "type": "uint256", "indexed": false}]}]`
+
+func transferLog() txs.EventDataLog {
+	event, err := abi.ParseEventSignature("Transfer(address,address,uint256)")
+	if err != nil {
+		panic(err)
+	}
+	return txs.EventDataLog{
+		Topics: []Word256{event.ID(), LeftPadWord256([]byte{1}),
+			LeftPadWord256([]byte{2})},
+		Data: Int64ToWord256(42).Bytes(),
+	}
+}
+
+func TestEventSignatures(t *testing.T) {
+	db := dbm.NewMemDB()
+	eventSignatures := NewEventSignatures(db)
+	assert.Nil(t, eventSignatures.DecodeLog(transferLog(), nil))
+
+	// From a signature the inputs are unnamed and indexed while there are topics
+	require.NoError(t, eventSignatures.AddSignature("Transfer(address,address,uint256)"))
+	decoded := eventSignatures.DecodeLog(transferLog(), nil)
+	require.NotNil(t, decoded)
+	assert.Equal(t, "Transfer", decoded.Name)
+	assert.Equal(t, []*core_types.DecodedArg{
+		{Type: "address", Indexed: true, Value: "0000000000000000000000000000000000000001"},
+		{Type: "address", Indexed: true, Value: "0000000000000000000000000000000000000002"},
+		{Type: "uint256", Value: "42"},
+	}, decoded.Args)
+
+	// An ABI replaces the signature of its event and is kept on commit
+	contractABI, err := abi.ReadABI([]byte(transferABI))
+	require.NoError(t, err)
+	eventSignatures.AddABI(contractABI)
+	require.NoError(t, eventSignatures.AddSignature("Transfer(address,address,uint256)"))
+	eventSignatures.Commit()
+	eventSignatures = NewEventSignatures(db)
+	signatures := eventSignatures.Events(contractABI.Events[0].ID())
+	require.Len(t, signatures, 1)
+	assert.False(t, signatures[0].SignatureOnly)
+	decoded = eventSignatures.DecodeLog(transferLog(), nil)
+	require.NotNil(t, decoded)
+	assert.Equal(t, "from", decoded.Args[0].Name)
+	assert.Equal(t, "value", decoded.Args[2].Name)
+
+	_, err = eventSignatures.Import([]byte("Transfer(address"))
+	assert.Error(t, err)
+}
+
+func TestImportEventSignatures(t *testing.T) {
+	for _, list := range []string{
+		`["Transfer(address,address,uint256)", "Approval(address,address,uint256)"]`,
+		`{"count": 2, "results": [
+			{"id": 1, "text_signature": "Transfer(address,address,uint256)",
+			 "hex_signature": "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+			{"id": 2, "text_signature": "Approval(address,address,uint256)"}]}`,
+		"# ERC20\nTransfer(address,address,uint256)\n\nApproval(address,address,uint256)\n",
+	} {
+		eventSignatures := NewEventSignatures(dbm.NewMemDB())
+		count, err := eventSignatures.Import([]byte(list))
+		require.NoError(t, err, list)
+		assert.Equal(t, 2, count)
+		assert.NotNil(t, eventSignatures.DecodeLog(transferLog(), nil))
+	}
+
+	_, err := NewEventSignatures(dbm.NewMemDB()).Import([]byte(`[{
+		"text_signature": "Transfer(address,address,uint256)",
+		"hex_signature": "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"}]`))
+	assert.Error(t, err)
+}
@@ -17,20 +17,37 @@ package state
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	acm "github.com/hyperledger/burrow/account"
 	"github.com/hyperledger/burrow/common/sanity"
 	core_types "github.com/hyperledger/burrow/core/types"
 	logging_types "github.com/hyperledger/burrow/logging/types"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
 	ptypes "github.com/hyperledger/burrow/permission/types" // for GlobalPermissionAddress ...
 	"github.com/hyperledger/burrow/txs"
 	. "github.com/hyperledger/burrow/word256"
 
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
+	"github.com/hyperledger/burrow/metrics"
+	"github.com/hyperledger/burrow/tracing"
 	"github.com/tendermint/go-events"
 )
 
+var (
+	evmExecutionSeconds = metrics.NewHistogram("burrow_evm_execution_seconds",
+		"The time taken by the EVM to run the calls of CallTxs", metrics.DefBuckets)
+	gasUsed = metrics.NewCounter("burrow_gas_used_total",
+		"The gas used by the calls of CallTxs in blocks")
+)
+
+// The gas used by the calls of CallTxs in blocks since the node started
+func GasUsed() int64 {
+	return int64(gasUsed.Value())
+}
+
 // ExecBlock stuff is now taken care of by the consensus engine.
 // But we leave here for now for reference when we have to do validator updates
 
@@ -164,7 +181,10 @@ func execBlock(s *State, block *txs.Block, blockPartsHeader txs.PartSetHeader) e
 // acm.PubKey.(type) != nil, (it must be known),
 // or it must be specified in the TxInput.  If redeclared,
 // the TxInput is modified and input.PubKey set to nil.
-func getInputs(state AccountGetter, ins []*txs.TxInput) (map[string]*acm.Account, error) {
+// The inputs in signers are signed for by another tx: a multisig account by
+// its MultisigTx, which has no PubKey, or the inputs of a tx batched by a
+// proposal, which have voted for it.
+func getInputs(state AccountGetter, ins []*txs.TxInput, signers map[string]bool) (map[string]*acm.Account, error) {
 	accounts := map[string]*acm.Account{}
 	for _, in := range ins {
 		// Account shouldn't be duplicated
@@ -176,8 +196,10 @@ func getInputs(state AccountGetter, ins []*txs.TxInput) (map[string]*acm.Account
 			return nil, txs.ErrTxInvalidAddress
 		}
 		// PubKey should be present in either "account" or "in"
-		if err := checkInputPubKey(acc, in); err != nil {
-			return nil, err
+		if !signers[string(in.Address)] {
+			if err := checkInputPubKey(acc, in); err != nil {
+				return nil, err
+			}
 		}
 		accounts[string(in.Address)] = acc
 	}
@@ -240,13 +262,22 @@ func checkInputPubKey(acc *acm.Account, in *txs.TxInput) error {
 	return nil
 }
 
-func validateInputs(accounts map[string]*acm.Account, signBytes []byte, ins []*txs.TxInput) (total int64, err error) {
+// Validates the inputs, other than the signatures of those in signers which
+// are signed for by another tx
+func validateInputs(accounts map[string]*acm.Account, signBytes []byte, ins []*txs.TxInput,
+	signers map[string]bool) (total int64, err error) {
 	for _, in := range ins {
 		acc := accounts[string(in.Address)]
 		if acc == nil {
 			sanity.PanicSanity("validateInputs() expects account in accounts")
 		}
-		err = validateInput(acc, signBytes, in)
+		if signers[string(in.Address)] {
+			if err = in.ValidateBasic(); err == nil {
+				err = validateInputState(acc, in)
+			}
+		} else {
+			err = validateInput(acc, signBytes, in)
+		}
 		if err != nil {
 			return
 		}
@@ -265,6 +296,11 @@ func validateInput(acc *acm.Account, signBytes []byte, in *txs.TxInput) (err err
 	if !acc.PubKey.VerifyBytes(signBytes, in.Signature) {
 		return txs.ErrTxInvalidSignature
 	}
+	return validateInputState(acc, in)
+}
+
+// Checks the sequence and amount of an input whose signature has been checked
+func validateInputState(acc *acm.Account, in *txs.TxInput) error {
 	// Check sequences
 	if acc.Sequence+1 != in.Sequence {
 		return txs.ErrTxInvalidSequence{
@@ -322,249 +358,69 @@ func ExecTx(blockCache *BlockCache, tx txs.Tx, runCall bool, evc events.Fireable
 	logger logging_types.InfoTraceLogger) (err error) {
 
 	logger = logging.WithScope(logger, "ExecTx")
-	// TODO: do something with fees
-	fees := int64(0)
 	_s := blockCache.State() // hack to access validators and block height
 
 	// Exec tx
 	switch tx := tx.(type) {
 	case *txs.SendTx:
-		accounts, err := getInputs(blockCache, tx.Inputs)
-		if err != nil {
-			return err
-		}
+		return execSendTx(blockCache, tx, tx, evc, logger)
 
-		// ensure all inputs have send permissions
-		if !hasSendPermission(blockCache, accounts, logger) {
-			return fmt.Errorf("At least one input lacks permission for SendTx")
-		}
+	case *txs.CallTx:
+		return execCallTx(blockCache, tx, tx, runCall, evc, logger)
 
-		// add outputs to accounts map
-		// if any outputs don't exist, all inputs must have CreateAccount perm
-		accounts, err = getOrMakeOutputs(blockCache, accounts, tx.Outputs, logger)
+	case *txs.EthTx:
+		callTx, err := tx.CallTx(_s.ChainID)
 		if err != nil {
+			logging.InfoMsg(logger, "Cannot recover the sender of EthTx",
+				"error", err)
 			return err
 		}
+		return execCallTx(blockCache, callTx, tx, runCall, evc, logger)
 
-		signBytes := acm.SignBytes(_s.ChainID, tx)
-		inTotal, err := validateInputs(accounts, signBytes, tx.Inputs)
-		if err != nil {
+	case *txs.MultisigTx:
+		if err := tx.Verify(_s.ChainID); err != nil {
+			logging.InfoMsg(logger, "Multisig tx is not signed by its signatories",
+				"multisig_address", tx.Address(), "error", err)
 			return err
 		}
-		outTotal, err := validateOutputs(tx.Outputs)
-		if err != nil {
-			return err
+		switch inner := tx.Tx.(type) {
+		case *txs.SendTx:
+			return execSendTx(blockCache, inner, tx, evc, logger)
+		case *txs.CallTx:
+			return execCallTx(blockCache, inner, tx, runCall, evc, logger)
 		}
-		if outTotal > inTotal {
-			return txs.ErrTxInsufficientFunds
-		}
-		fee := inTotal - outTotal
-		fees += fee
-
-		// Good! Adjust accounts
-		adjustByInputs(accounts, tx.Inputs)
-		adjustByOutputs(accounts, tx.Outputs)
-		for _, acc := range accounts {
-			blockCache.UpdateAccount(acc)
-		}
-
-		// if the evc is nil, nothing will happen
-		if evc != nil {
-			for _, i := range tx.Inputs {
-				evc.FireEvent(txs.EventStringAccInput(i.Address), txs.EventDataTx{tx, nil, ""})
-			}
-
-			for _, o := range tx.Outputs {
-				evc.FireEvent(txs.EventStringAccOutput(o.Address), txs.EventDataTx{tx, nil, ""})
-			}
-		}
-		return nil
+		return fmt.Errorf("Multisig tx cannot sign for %T", tx.Tx)
 
-	case *txs.CallTx:
-		var inAcc, outAcc *acm.Account
-
-		// Validate input
-		inAcc = blockCache.GetAccount(tx.Input.Address)
-		if inAcc == nil {
-			logging.InfoMsg(logger, "Cannot find input account",
-				"tx_input", tx.Input)
-			return txs.ErrTxInvalidAddress
+	case *txs.PriorityFeeTx:
+		if err := tx.ValidateBasic(); err != nil {
+			return err
 		}
-
-		createContract := len(tx.Address) == 0
-		if createContract {
-			if !hasCreateContractPermission(blockCache, inAcc, logger) {
-				return fmt.Errorf("Account %X does not have CreateContract permission", tx.Input.Address)
-			}
-		} else {
-			if !hasCallPermission(blockCache, inAcc, logger) {
-				return fmt.Errorf("Account %X does not have Call permission", tx.Input.Address)
-			}
+		switch inner := tx.Tx.(type) {
+		case *txs.SendTx:
+			return execSendTx(blockCache, inner, tx, evc, logger)
+		case *txs.CallTx:
+			return execCallTx(blockCache, inner, tx, runCall, evc, logger)
 		}
+		return fmt.Errorf("Priority fee tx cannot pay for %T", tx.Tx)
 
-		// pubKey should be present in either "inAcc" or "tx.Input"
-		if err := checkInputPubKey(inAcc, tx.Input); err != nil {
-			logging.InfoMsg(logger, "Cannot find public key for input account",
-				"tx_input", tx.Input)
+	case *txs.ExpiringTx:
+		if err := tx.ValidateBasic(); err != nil {
 			return err
 		}
-		signBytes := acm.SignBytes(_s.ChainID, tx)
-		err := validateInput(inAcc, signBytes, tx.Input)
-		if err != nil {
-			logging.InfoMsg(logger, "validateInput failed",
-				"tx_input", tx.Input, "error", err)
-			return err
-		}
-		if tx.Input.Amount < tx.Fee {
-			logging.InfoMsg(logger, "Sender did not send enough to cover the fee",
-				"tx_input", tx.Input)
-			return txs.ErrTxInsufficientFunds
+		// Expired txs cannot be included in the next block
+		if _s.LastBlockHeight+1 > tx.ExpiresAt {
+			logging.InfoMsg(logger, "Tx has expired",
+				"expires_at", tx.ExpiresAt,
+				"height", _s.LastBlockHeight+1)
+			return txs.ErrTxExpired{ExpiresAt: tx.ExpiresAt, Height: _s.LastBlockHeight + 1}
 		}
-
-		if !createContract {
-			// Validate output
-			if len(tx.Address) != 20 {
-				logging.InfoMsg(logger, "Destination address is not 20 bytes",
-					"address", tx.Address)
-				return txs.ErrTxInvalidAddress
-			}
-			// check if its a native contract
-			if vm.RegisteredNativeContract(LeftPadWord256(tx.Address)) {
-				return fmt.Errorf("Attempt to call a native contract at %X, "+
-					"but native contracts cannot be called using CallTx. Use a "+
-					"contract that calls the native contract or the appropriate tx "+
-					"type (eg. PermissionsTx, NameTx).", tx.Address)
-			}
-
-			// Output account may be nil if we are still in mempool and contract was created in same block as this tx
-			// but that's fine, because the account will be created properly when the create tx runs in the block
-			// and then this won't return nil. otherwise, we take their fee
-			outAcc = blockCache.GetAccount(tx.Address)
-		}
-
-		logger.Trace("output_account", outAcc)
-
-		// Good!
-		value := tx.Input.Amount - tx.Fee
-
-		inAcc.Sequence += 1
-		inAcc.Balance -= tx.Fee
-		blockCache.UpdateAccount(inAcc)
-
-		// The logic in runCall MUST NOT return.
-		if runCall {
-
-			// VM call variables
-			var (
-				gas     int64       = tx.GasLimit
-				err     error       = nil
-				caller  *vm.Account = toVMAccount(inAcc)
-				callee  *vm.Account = nil // initialized below
-				code    []byte      = nil
-				ret     []byte      = nil
-				txCache             = NewTxCache(blockCache)
-				params              = vm.Params{
-					BlockHeight: int64(_s.LastBlockHeight),
-					BlockHash:   LeftPadWord256(_s.LastBlockHash),
-					BlockTime:   _s.LastBlockTime.Unix(),
-					GasLimit:    _s.GetGasLimit(),
-				}
-			)
-
-			if !createContract && (outAcc == nil || len(outAcc.Code) == 0) {
-				// if you call an account that doesn't exist
-				// or an account with no code then we take fees (sorry pal)
-				// NOTE: it's fine to create a contract and call it within one
-				// block (nonce will prevent re-ordering of those txs)
-				// but to create with one contract and call with another
-				// you have to wait a block to avoid a re-ordering attack
-				// that will take your fees
-				if outAcc == nil {
-					logging.InfoMsg(logger, "Call to address that does not exist",
-						"caller_address", inAcc.Address,
-						"callee_address", tx.Address)
-				} else {
-					logging.InfoMsg(logger, "Call to address that holds no code",
-						"caller_address", inAcc.Address,
-						"callee_address", tx.Address)
-				}
-				err = txs.ErrTxInvalidAddress
-				goto CALL_COMPLETE
-			}
-
-			// get or create callee
-			if createContract {
-				// We already checked for permission
-				callee = txCache.CreateAccount(caller)
-				logging.TraceMsg(logger, "Created new contract",
-					"contract_address", callee.Address,
-					"contract_code", callee.Code)
-				code = tx.Data
-			} else {
-				callee = toVMAccount(outAcc)
-				logging.TraceMsg(logger, "Calling existing contract",
-					"contract_address", callee.Address,
-					"contract_code", callee.Code)
-				code = callee.Code
-			}
-			logger.Trace("callee_")
-
-			// Run VM call and sync txCache to blockCache.
-			{ // Capture scope for goto.
-				// Write caller/callee to txCache.
-				txCache.UpdateAccount(caller)
-				txCache.UpdateAccount(callee)
-				vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
-					caller.Address, txs.TxHash(_s.ChainID, tx))
-				vmach.SetFireable(evc)
-				// NOTE: Call() transfers the value from caller to callee iff call succeeds.
-				ret, err = vmach.Call(caller, callee, code, tx.Data, value, &gas)
-				if err != nil {
-					// Failure. Charge the gas fee. The 'value' was otherwise not transferred.
-					logging.InfoMsg(logger, "Error on execution",
-						"error", err)
-					goto CALL_COMPLETE
-				}
-
-				logging.TraceMsg(logger, "Successful execution")
-				if createContract {
-					callee.Code = ret
-				}
-				txCache.Sync()
-			}
-
-		CALL_COMPLETE: // err may or may not be nil.
-
-			// Create a receipt from the ret and whether it erred.
-			logging.TraceMsg(logger, "VM call complete",
-				"caller", caller,
-				"callee", callee,
-				"return", ret,
-				"error", err)
-
-			// Fire Events for sender and receiver
-			// a separate event will be fired from vm for each additional call
-			if evc != nil {
-				exception := ""
-				if err != nil {
-					exception = err.Error()
-				}
-				evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, ret, exception})
-				evc.FireEvent(txs.EventStringAccOutput(tx.Address), txs.EventDataTx{tx, ret, exception})
-			}
-		} else {
-			// The mempool does not call txs until
-			// the proposer determines the order of txs.
-			// So mempool will skip the actual .Call(),
-			// and only deduct from the caller's balance.
-			inAcc.Balance -= value
-			if createContract {
-				inAcc.Sequence += 1 // XXX ?!
-			}
-			blockCache.UpdateAccount(inAcc)
+		switch inner := txs.InnerTx(tx).(type) {
+		case *txs.SendTx:
+			return execSendTx(blockCache, inner, tx, evc, logger)
+		case *txs.CallTx:
+			return execCallTx(blockCache, inner, tx, runCall, evc, logger)
 		}
-
-		return nil
+		return fmt.Errorf("Expiring tx cannot expire %T", tx.Tx)
 
 	case *txs.NameTx:
 		var inAcc *acm.Account
@@ -709,184 +565,7 @@ func ExecTx(blockCache *BlockCache, tx txs.Tx, runCall bool, evc events.Fireable
 
 		return nil
 
-		// Consensus related Txs inactivated for now
-		// TODO!
-		/*
-			case *txs.BondTx:
-						valInfo := blockCache.State().GetValidatorInfo(tx.PubKey.Address())
-						if valInfo != nil {
-							// TODO: In the future, check that the validator wasn't destroyed,
-							// add funds, merge UnbondTo outputs, and unbond validator.
-							return errors.New("Adding coins to existing validators not yet supported")
-						}
-
-						accounts, err := getInputs(blockCache, tx.Inputs)
-						if err != nil {
-							return err
-						}
-
-						// add outputs to accounts map
-						// if any outputs don't exist, all inputs must have CreateAccount perm
-						// though outputs aren't created until unbonding/release time
-						canCreate := hasCreateAccountPermission(blockCache, accounts)
-						for _, out := range tx.UnbondTo {
-							acc := blockCache.GetAccount(out.Address)
-							if acc == nil && !canCreate {
-								return fmt.Errorf("At least one input does not have permission to create accounts")
-							}
-						}
-
-						bondAcc := blockCache.GetAccount(tx.PubKey.Address())
-						if !hasBondPermission(blockCache, bondAcc) {
-							return fmt.Errorf("The bonder does not have permission to bond")
-						}
-
-						if !hasBondOrSendPermission(blockCache, accounts) {
-							return fmt.Errorf("At least one input lacks permission to bond")
-						}
-
-						signBytes := acm.SignBytes(_s.ChainID, tx)
-						inTotal, err := validateInputs(accounts, signBytes, tx.Inputs)
-						if err != nil {
-							return err
-						}
-						if !tx.PubKey.VerifyBytes(signBytes, tx.Signature) {
-							return txs.ErrTxInvalidSignature
-						}
-						outTotal, err := validateOutputs(tx.UnbondTo)
-						if err != nil {
-							return err
-						}
-						if outTotal > inTotal {
-							return txs.ErrTxInsufficientFunds
-						}
-						fee := inTotal - outTotal
-						fees += fee
-
-						// Good! Adjust accounts
-						adjustByInputs(accounts, tx.Inputs)
-						for _, acc := range accounts {
-							blockCache.UpdateAccount(acc)
-						}
-						// Add ValidatorInfo
-						_s.SetValidatorInfo(&txs.ValidatorInfo{
-							Address:         tx.PubKey.Address(),
-							PubKey:          tx.PubKey,
-							UnbondTo:        tx.UnbondTo,
-							FirstBondHeight: _s.LastBlockHeight + 1,
-							FirstBondAmount: outTotal,
-						})
-						// Add Validator
-						added := _s.BondedValidators.Add(&txs.Validator{
-							Address:     tx.PubKey.Address(),
-							PubKey:      tx.PubKey,
-							BondHeight:  _s.LastBlockHeight + 1,
-							VotingPower: outTotal,
-							Accum:       0,
-						})
-						if !added {
-							PanicCrisis("Failed to add validator")
-						}
-						if evc != nil {
-							// TODO: fire for all inputs
-							evc.FireEvent(txs.EventStringBond(), txs.EventDataTx{tx, nil, ""})
-						}
-						return nil
-
-					case *txs.UnbondTx:
-						// The validator must be active
-						_, val := _s.BondedValidators.GetByAddress(tx.Address)
-						if val == nil {
-							return txs.ErrTxInvalidAddress
-						}
-
-						// Verify the signature
-						signBytes := acm.SignBytes(_s.ChainID, tx)
-						if !val.PubKey.VerifyBytes(signBytes, tx.Signature) {
-							return txs.ErrTxInvalidSignature
-						}
-
-						// tx.Height must be greater than val.LastCommitHeight
-						if tx.Height <= val.LastCommitHeight {
-							return errors.New("Invalid unbond height")
-						}
-
-						// Good!
-						_s.unbondValidator(val)
-						if evc != nil {
-							evc.FireEvent(txs.EventStringUnbond(), txs.EventDataTx{tx, nil, ""})
-						}
-						return nil
-
-					case *txs.RebondTx:
-						// The validator must be inactive
-						_, val := _s.UnbondingValidators.GetByAddress(tx.Address)
-						if val == nil {
-							return txs.ErrTxInvalidAddress
-						}
-
-						// Verify the signature
-						signBytes := acm.SignBytes(_s.ChainID, tx)
-						if !val.PubKey.VerifyBytes(signBytes, tx.Signature) {
-							return txs.ErrTxInvalidSignature
-						}
-
-						// tx.Height must be in a suitable range
-						minRebondHeight := _s.LastBlockHeight - (validatorTimeoutBlocks / 2)
-						maxRebondHeight := _s.LastBlockHeight + 2
-						if !((minRebondHeight <= tx.Height) && (tx.Height <= maxRebondHeight)) {
-							return errors.New(Fmt("Rebond height not in range.  Expected %v <= %v <= %v",
-								minRebondHeight, tx.Height, maxRebondHeight))
-						}
-
-						// Good!
-						_s.rebondValidator(val)
-						if evc != nil {
-							evc.FireEvent(txs.EventStringRebond(), txs.EventDataTx{tx, nil, ""})
-						}
-						return nil
-
-					case *txs.DupeoutTx:
-						// Verify the signatures
-						_, accused := _s.BondedValidators.GetByAddress(tx.Address)
-						if accused == nil {
-							_, accused = _s.UnbondingValidators.GetByAddress(tx.Address)
-							if accused == nil {
-								return txs.ErrTxInvalidAddress
-							}
-						}
-						voteASignBytes := acm.SignBytes(_s.ChainID, &tx.VoteA)
-						voteBSignBytes := acm.SignBytes(_s.ChainID, &tx.VoteB)
-						if !accused.PubKey.VerifyBytes(voteASignBytes, tx.VoteA.Signature) ||
-							!accused.PubKey.VerifyBytes(voteBSignBytes, tx.VoteB.Signature) {
-							return txs.ErrTxInvalidSignature
-						}
-
-						// Verify equivocation
-						// TODO: in the future, just require one vote from a previous height that
-						// doesn't exist on this chain.
-						if tx.VoteA.Height != tx.VoteB.Height {
-							return errors.New("DupeoutTx heights don't match")
-						}
-						if tx.VoteA.Round != tx.VoteB.Round {
-							return errors.New("DupeoutTx rounds don't match")
-						}
-						if tx.VoteA.Type != tx.VoteB.Type {
-							return errors.New("DupeoutTx types don't match")
-						}
-						if bytes.Equal(tx.VoteA.BlockHash, tx.VoteB.BlockHash) {
-							return errors.New("DupeoutTx blockhashes shouldn't match")
-						}
-
-						// Good! (Bad validator!)
-						_s.destroyValidator(accused)
-						if evc != nil {
-							evc.FireEvent(txs.EventStringDupeout(), txs.EventDataTx{tx, nil, ""})
-						}
-						return nil
-		*/
-
-	case *txs.PermissionsTx:
+	case *txs.ABITx:
 		var inAcc *acm.Account
 
 		// Validate input
@@ -896,13 +575,10 @@ func ExecTx(blockCache *BlockCache, tx txs.Tx, runCall bool, evc events.Fireable
 				"tx_input", tx.Input)
 			return txs.ErrTxInvalidAddress
 		}
-
-		permFlag := tx.PermArgs.PermFlag()
 		// check permission
-		if !HasPermission(blockCache, inAcc, permFlag, logger) {
-			return fmt.Errorf("Account %X does not have moderator permission %s (%b)", tx.Input.Address, ptypes.PermFlagToString(permFlag), permFlag)
+		if !hasCreateContractPermission(blockCache, inAcc, logger) {
+			return fmt.Errorf("Account %X does not have CreateContract permission", tx.Input.Address)
 		}
-
 		// pubKey should be present in either "inAcc" or "tx.Input"
 		if err := checkInputPubKey(inAcc, tx.Input); err != nil {
 			logging.InfoMsg(logger, "Cannot find public key for input account",
@@ -913,82 +589,710 @@ func ExecTx(blockCache *BlockCache, tx txs.Tx, runCall bool, evc events.Fireable
 		err := validateInput(inAcc, signBytes, tx.Input)
 		if err != nil {
 			logging.InfoMsg(logger, "validateInput failed",
-				"tx_input", tx.Input,
-				"error", err)
+				"tx_input", tx.Input, "error", err)
+			return err
+		}
+		if err := tx.ValidateABI(); err != nil {
+			return err
+		}
+		if tx.ABI != "" {
+			if _, err := abi.ReadABI([]byte(tx.ABI)); err != nil {
+				return fmt.Errorf("Invalid ABI: %v", err)
+			}
+		}
+
+		contractAcc := blockCache.GetAccount(tx.Address)
+		if contractAcc == nil || len(contractAcc.Code) == 0 {
+			return fmt.Errorf("There is no contract at %X to register an ABI for", tx.Address)
+		}
+		// Only the account that created the contract, which its entry
+		// records, may register its ABI
+		if entry := blockCache.GetABIEntry(tx.Address); entry == nil ||
+			!bytes.Equal(entry.Owner, tx.Input.Address) {
+			return fmt.Errorf("Only the account that created the contract at %X may register its ABI",
+				tx.Address)
+		}
+
+		logging.TraceMsg(logger, "Registering ABI",
+			"contract_address", tx.Address)
+		blockCache.UpdateABIEntry(&core_types.ABIEntry{
+			Address: tx.Address,
+			Owner:   tx.Input.Address,
+			ABI:     tx.ABI,
+			ABIHash: tx.ABIHash,
+		})
+
+		// Good! The input amount is burnt as the fee
+		inAcc.Sequence += 1
+		inAcc.Balance -= tx.Input.Amount
+		blockCache.UpdateAccount(inAcc)
+		payFee(blockCache, tx.Input.Amount, 0)
+
+		if evc != nil {
+			evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, nil, ""})
+		}
+
+		return nil
+
+	case *txs.BondTx:
+		return execBondTx(blockCache, tx, evc, logger)
+
+	case *txs.UnbondTx:
+		return execUnbondTx(blockCache, tx, evc, logger)
+
+	case *txs.DupeoutTx:
+		return execDupeoutTx(blockCache, tx, evc, logger)
+
+		// Consensus related Txs inactivated for now
+		// TODO!
+		/*
+			case *txs.RebondTx:
+				// The validator must be inactive
+				_, val := _s.UnbondingValidators.GetByAddress(tx.Address)
+				if val == nil {
+					return txs.ErrTxInvalidAddress
+				}
+
+				// Verify the signature
+				signBytes := acm.SignBytes(_s.ChainID, tx)
+				if !val.PubKey.VerifyBytes(signBytes, tx.Signature) {
+					return txs.ErrTxInvalidSignature
+				}
+
+				// tx.Height must be in a suitable range
+				minRebondHeight := _s.LastBlockHeight - (validatorTimeoutBlocks / 2)
+				maxRebondHeight := _s.LastBlockHeight + 2
+				if !((minRebondHeight <= tx.Height) && (tx.Height <= maxRebondHeight)) {
+					return errors.New(Fmt("Rebond height not in range.  Expected %v <= %v <= %v",
+						minRebondHeight, tx.Height, maxRebondHeight))
+				}
+
+				// Good!
+				_s.rebondValidator(val)
+				if evc != nil {
+					evc.FireEvent(txs.EventStringRebond(), txs.EventDataTx{tx, nil, ""})
+				}
+				return nil
+		*/
+
+	case *txs.RotateTx:
+		signBytes := acm.SignBytes(_s.ChainID, tx)
+		if !tx.PubKey.VerifyBytes(signBytes, tx.Signature) {
+			logging.InfoMsg(logger, "Rotation is not signed by the validator key",
+				"address", tx.PubKey.Address())
+			return txs.ErrTxInvalidSignature
+		}
+		if !tx.NewPubKey.VerifyBytes(signBytes, tx.NewSignature) {
+			logging.InfoMsg(logger, "Rotation is not signed by the new key",
+				"new_address", tx.NewPubKey.Address())
+			return txs.ErrTxInvalidSignature
+		}
+		rotation := &ValidatorRotation{
+			Height:    tx.Height,
+			PubKey:    tx.PubKey,
+			NewPubKey: tx.NewPubKey,
+		}
+		// The tx is executed in the block after the last
+		err := validateRotation(_s.GenesisValidators(), blockCache.GetValidatorRotations(),
+			blockCache.GetValidatorPowerChanges(), rotation, _s.LastBlockHeight+1)
+		if err != nil {
+			logging.InfoMsg(logger, "Invalid validator rotation", "error", err)
 			return err
 		}
+		logging.TraceMsg(logger, "Rotating validator key",
+			"address", tx.PubKey.Address(),
+			"new_address", tx.NewPubKey.Address(),
+			"height", tx.Height)
+		blockCache.AddValidatorRotation(rotation)
+
+		if evc != nil {
+			evc.FireEvent(txs.EventStringAccInput(tx.PubKey.Address()), txs.EventDataTx{tx, nil, ""})
+			evc.FireEvent(txs.EventStringRotate(), txs.EventDataTx{tx, nil, ""})
+		}
+		return nil
+
+	case *txs.IdentifyTx:
+		return execIdentifyTx(blockCache, tx, evc, logger)
+
+	case *txs.RelayTx:
+		return execRelayTx(blockCache, tx, evc, logger)
+
+	case *txs.PrivateTx:
+		return execPrivateTx(blockCache, tx, runCall, evc, logger)
 
-		value := tx.Input.Amount
+	case *txs.AttestTx:
+		return execAttestTx(blockCache, tx, evc, logger)
 
-		logging.TraceMsg(logger, "New PermissionsTx",
-			"perm_flag", ptypes.PermFlagToString(permFlag),
-			"perm_args", tx.PermArgs)
+	case *txs.PermissionsTx:
+		return execPermissionsTx(blockCache, tx, tx, evc, logger)
+
+	case *txs.ProposalTx:
+		return execProposalTx(blockCache, tx, runCall, evc, logger)
+
+	case *txs.GovTx:
+		return fmt.Errorf("GovTx can only be executed by a proposal that has passed")
+
+	default:
+		// binary decoding should not let this happen
+		sanity.PanicSanity("Unknown Tx type")
+		return nil
+	}
+}
 
-		var permAcc *acm.Account
-		switch args := tx.PermArgs.(type) {
-		case *ptypes.HasBaseArgs:
-			// this one doesn't make sense from txs
-			return fmt.Errorf("HasBase is for contracts, not humans. Just look at the blockchain")
-		case *ptypes.SetBaseArgs:
-			if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
-				return fmt.Errorf("Trying to update permissions for unknown account %X", args.Address)
+// Executes tx, which is signedTx itself or a tx of the batch of the
+// ProposalTx signedTx, whose input has voted for the proposal, as ExecTx does
+func execPermissionsTx(blockCache *BlockCache, tx *txs.PermissionsTx, signedTx txs.Tx,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	var inAcc *acm.Account
+
+	// Validate input
+	inAcc = blockCache.GetAccount(tx.Input.Address)
+	if inAcc == nil {
+		logging.InfoMsg(logger, "Cannot find input account",
+			"tx_input", tx.Input)
+		return txs.ErrTxInvalidAddress
+	}
+
+	permFlag := tx.PermArgs.PermFlag()
+	// check permission
+	if !HasPermission(blockCache, inAcc, permFlag, logger) {
+		return fmt.Errorf("Account %X does not have moderator permission %s (%b)", tx.Input.Address, ptypes.PermFlagToString(permFlag), permFlag)
+	}
+
+	var err error
+	if signedTx == txs.Tx(tx) {
+		// pubKey should be present in either "inAcc" or "tx.Input"
+		if err := checkInputPubKey(inAcc, tx.Input); err != nil {
+			logging.InfoMsg(logger, "Cannot find public key for input account",
+				"tx_input", tx.Input)
+			return err
+		}
+		signBytes := acm.SignBytes(_s.ChainID, tx)
+		err = validateInput(inAcc, signBytes, tx.Input)
+	} else {
+		// The input has voted for the proposal of signedTx
+		err = validateInputState(inAcc, tx.Input)
+	}
+	if err != nil {
+		logging.InfoMsg(logger, "validateInput failed",
+			"tx_input", tx.Input,
+			"error", err)
+		return err
+	}
+
+	value := tx.Input.Amount
+
+	logging.TraceMsg(logger, "New PermissionsTx",
+		"perm_flag", ptypes.PermFlagToString(permFlag),
+		"perm_args", tx.PermArgs)
+
+	var permAcc *acm.Account
+	// The accounts joining or leaving a group
+	var memberAccs []*acm.Account
+	switch args := tx.PermArgs.(type) {
+	case *ptypes.HasBaseArgs:
+		// this one doesn't make sense from txs
+		return fmt.Errorf("HasBase is for contracts, not humans. Just look at the blockchain")
+	case *ptypes.SetBaseArgs:
+		if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
+			return fmt.Errorf("Trying to update permissions for unknown account %X", args.Address)
+		}
+		err = permAcc.Permissions.Base.Set(args.Permission, args.Value)
+	case *ptypes.UnsetBaseArgs:
+		if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
+			return fmt.Errorf("Trying to update permissions for unknown account %X", args.Address)
+		}
+		err = permAcc.Permissions.Base.Unset(args.Permission)
+	case *ptypes.SetGlobalArgs:
+		if permAcc = blockCache.GetAccount(ptypes.GlobalPermissionsAddress); permAcc == nil {
+			sanity.PanicSanity("can't find global permissions account")
+		}
+		err = permAcc.Permissions.Base.Set(args.Permission, args.Value)
+	case *ptypes.HasRoleArgs:
+		return fmt.Errorf("HasRole is for contracts, not humans. Just look at the blockchain")
+	case *ptypes.AddRoleArgs:
+		if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
+			return fmt.Errorf("Trying to update roles for unknown account %X", args.Address)
+		}
+		if !permAcc.Permissions.AddRole(args.Role) {
+			return fmt.Errorf("Role (%s) already exists for account %X", args.Role, args.Address)
+		}
+	case *ptypes.RmRoleArgs:
+		if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
+			return fmt.Errorf("Trying to update roles for unknown account %X", args.Address)
+		}
+		if !permAcc.Permissions.RmRole(args.Role) {
+			return fmt.Errorf("Role (%s) does not exist for account %X", args.Role, args.Address)
+		}
+	case *ptypes.SetGroupArgs:
+		groupAddress := ptypes.GroupAddress(args.Group)
+		if permAcc = blockCache.GetAccount(groupAddress); permAcc == nil {
+			permAcc = &acm.Account{
+				Address:     groupAddress,
+				Permissions: ptypes.ZeroAccountPermissions,
 			}
-			err = permAcc.Permissions.Base.Set(args.Permission, args.Value)
-		case *ptypes.UnsetBaseArgs:
-			if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
-				return fmt.Errorf("Trying to update permissions for unknown account %X", args.Address)
+		}
+		err = permAcc.Permissions.Base.Set(args.Permission, args.Value)
+	case *ptypes.UnsetGroupArgs:
+		if permAcc = blockCache.GetAccount(ptypes.GroupAddress(args.Group)); permAcc == nil {
+			return fmt.Errorf("Trying to update permissions for unknown group %s", args.Group)
+		}
+		err = permAcc.Permissions.Base.Unset(args.Permission)
+	case *ptypes.AddGroupMembersArgs:
+		memberAccs, err = updateGroupMembers(blockCache, args.Group, args.Addresses, true)
+	case *ptypes.RmGroupMembersArgs:
+		memberAccs, err = updateGroupMembers(blockCache, args.Group, args.Addresses, false)
+	default:
+		sanity.PanicSanity(fmt.Sprintf("invalid permission function: %s", ptypes.PermFlagToString(permFlag)))
+	}
+
+	// TODO: maybe we want to take funds on error and allow txs in that don't do anythingi?
+	if err != nil {
+		return err
+	}
+
+	// Good!
+	inAcc.Sequence += 1
+	inAcc.Balance -= value
+	blockCache.UpdateAccount(inAcc)
+	if permAcc != nil {
+		blockCache.UpdateAccount(permAcc)
+	}
+	for _, memberAcc := range memberAccs {
+		blockCache.UpdateAccount(memberAcc)
+	}
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{signedTx, nil, ""})
+		evc.FireEvent(txs.EventStringPermissions(ptypes.PermFlagToString(permFlag)), txs.EventDataTx{signedTx, nil, ""})
+		txHash := txs.TxHash(_s.ChainID, signedTx)
+		for _, change := range permissionChanges(tx.PermArgs) {
+			change.Height = int64(_s.LastBlockHeight + 1)
+			change.TxHash = txHash
+			change.Granter = tx.Input.Address
+			evc.FireEvent(txs.EventStringPermissionChange(), change)
+			evc.FireEvent(txs.EventStringAccPermissionChange(change.Address), change)
+		}
+	}
+
+	return nil
+}
+
+// The tx whose sign bytes the inputs of tx sign, which is signedTx when it is
+// tx or the PriorityFeeTx or ExpiringTx for it, and tx otherwise
+func inputsSignedTx(tx, signedTx txs.Tx) txs.Tx {
+	if txs.InnerTx(signedTx) == tx {
+		return signedTx
+	}
+	return tx
+}
+
+// Executes tx, which is signedTx itself, the SendTx signed for by a
+// MultisigTx or wrapped by a PriorityFeeTx or ExpiringTx, or a tx of the batch
+// of a ProposalTx, as ExecTx does. Any signatures of signedTx other than
+// those of the inputs of tx have already been checked.
+func execSendTx(blockCache *BlockCache, tx *txs.SendTx, signedTx txs.Tx,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	signers := make(map[string]bool)
+	switch signedTx := signedTx.(type) {
+	case *txs.MultisigTx:
+		signers[string(signedTx.Address())] = true
+	case *txs.ProposalTx:
+		// every input has voted for the proposal
+		for _, in := range tx.Inputs {
+			signers[string(in.Address)] = true
+		}
+	}
+	accounts, err := getInputs(blockCache, tx.Inputs, signers)
+	if err != nil {
+		return err
+	}
+
+	// ensure all inputs have send permissions
+	if !hasSendPermission(blockCache, accounts, logger) {
+		return fmt.Errorf("At least one input lacks permission for SendTx")
+	}
+
+	// add outputs to accounts map
+	// if any outputs don't exist, all inputs must have CreateAccount perm
+	accounts, err = getOrMakeOutputs(blockCache, accounts, tx.Outputs, logger)
+	if err != nil {
+		return err
+	}
+
+	signBytes := acm.SignBytes(_s.ChainID, inputsSignedTx(tx, signedTx))
+	inTotal, err := validateInputs(accounts, signBytes, tx.Inputs, signers)
+	if err != nil {
+		return err
+	}
+	outTotal, err := validateOutputs(tx.Outputs)
+	if err != nil {
+		return err
+	}
+	if outTotal > inTotal {
+		return txs.ErrTxInsufficientFunds
+	}
+	fee := inTotal - outTotal
+	priorityFee := txs.PriorityFee(signedTx)
+	if err := validateFee(_s, fee, priorityFee); err != nil {
+		return err
+	}
+
+	// Good! Adjust accounts
+	adjustByInputs(accounts, tx.Inputs)
+	adjustByOutputs(accounts, tx.Outputs)
+	for _, acc := range accounts {
+		blockCache.UpdateAccount(acc)
+	}
+	payFee(blockCache, fee, priorityFee)
+
+	// if the evc is nil, nothing will happen
+	if evc != nil {
+		for _, i := range tx.Inputs {
+			evc.FireEvent(txs.EventStringAccInput(i.Address), txs.EventDataTx{signedTx, nil, ""})
+		}
+
+		for _, o := range tx.Outputs {
+			evc.FireEvent(txs.EventStringAccOutput(o.Address), txs.EventDataTx{signedTx, nil, ""})
+		}
+	}
+	return nil
+}
+
+// Executes tx, which is signedTx itself or the CallTx it is executed as, as
+// ExecTx does. The input of tx is only checked against signedTx when they are
+// the same or signedTx is the PriorityFeeTx or ExpiringTx for tx, since
+// otherwise the signer has been recovered from signedTx, or signed for by it
+// when it is a MultisigTx or a ProposalTx whose batch holds tx, and signedTx
+// also gives the hash of the tx and is the tx of its events.
+func execCallTx(blockCache *BlockCache, tx *txs.CallTx, signedTx txs.Tx, runCall bool,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	logger = logging.WithSubsystem(logger, structure.EVMSubsystem)
+	_s := blockCache.State()
+	txHash := txs.TxHash(_s.ChainID, signedTx)
+	var inAcc, outAcc *acm.Account
+
+	// Validate input
+	inAcc = blockCache.GetAccount(tx.Input.Address)
+	if inAcc == nil {
+		logging.InfoMsg(logger, "Cannot find input account",
+			"tx_input", tx.Input)
+		return txs.ErrTxInvalidAddress
+	}
+
+	createContract := len(tx.Address) == 0
+	if createContract {
+		if !hasCreateContractPermission(blockCache, inAcc, logger) {
+			return fmt.Errorf("Account %X does not have CreateContract permission", tx.Input.Address)
+		}
+	} else {
+		if !hasCallPermission(blockCache, inAcc, logger) {
+			return fmt.Errorf("Account %X does not have Call permission", tx.Input.Address)
+		}
+	}
+
+	var err error
+	if inputsSignedTx(tx, signedTx) == signedTx {
+		// pubKey should be present in either "inAcc" or "tx.Input"
+		if err := checkInputPubKey(inAcc, tx.Input); err != nil {
+			logging.InfoMsg(logger, "Cannot find public key for input account",
+				"tx_input", tx.Input)
+			return err
+		}
+		signBytes := acm.SignBytes(_s.ChainID, signedTx)
+		err = validateInput(inAcc, signBytes, tx.Input)
+	} else {
+		// The signer was recovered from signedTx
+		err = validateInputState(inAcc, tx.Input)
+	}
+	if err != nil {
+		logging.InfoMsg(logger, "validateInput failed",
+			"tx_input", tx.Input, "error", err)
+		return err
+	}
+	if tx.Input.Amount < tx.Fee {
+		logging.InfoMsg(logger, "Sender did not send enough to cover the fee",
+			"tx_input", tx.Input)
+		return txs.ErrTxInsufficientFunds
+	}
+	priorityFee := txs.PriorityFee(signedTx)
+	if err := validateFee(_s, tx.Fee, priorityFee); err != nil {
+		logging.InfoMsg(logger, "Fee does not cover the base and priority fees",
+			"base_fee", _s.BaseFee, "error", err)
+		return err
+	}
+	if _s.MaxTxGas > 0 && tx.GasLimit > _s.MaxTxGas {
+		logging.InfoMsg(logger, "Gas limit is above the gas ceiling",
+			"gas_limit", tx.GasLimit, "max_tx_gas", _s.MaxTxGas)
+		return txs.ErrTxGasLimitTooHigh
+	}
+	if _s.MaxBlockGas > 0 && tx.GasLimit > _s.MaxBlockGas {
+		logging.InfoMsg(logger, "Gas limit is above the block gas limit",
+			"gas_limit", tx.GasLimit, "max_block_gas", _s.MaxBlockGas)
+		return txs.ErrTxGasLimitTooHigh
+	}
+
+	if !createContract {
+		// Validate output
+		if len(tx.Address) != 20 {
+			logging.InfoMsg(logger, "Destination address is not 20 bytes",
+				"address", tx.Address)
+			return txs.ErrTxInvalidAddress
+		}
+		// check if its a native contract
+		if vm.RegisteredNativeContract(LeftPadWord256(tx.Address)) {
+			return fmt.Errorf("Attempt to call a native contract at %X, "+
+				"but native contracts cannot be called using CallTx. Use a "+
+				"contract that calls the native contract or the appropriate tx "+
+				"type (eg. PermissionsTx, NameTx).", tx.Address)
+		}
+
+		// Output account may be nil if we are still in mempool and contract was created in same block as this tx
+		// but that's fine, because the account will be created properly when the create tx runs in the block
+		// and then this won't return nil. otherwise, we take their fee
+		outAcc = blockCache.GetAccount(tx.Address)
+	}
+
+	logger.Trace("output_account", outAcc)
+
+	// Good!
+	value := tx.Input.Amount - tx.Fee
+
+	inAcc.Sequence += 1
+	inAcc.Balance -= tx.Fee
+	blockCache.UpdateAccount(inAcc)
+
+	// The logic in runCall MUST NOT return.
+	if runCall {
+
+		// Collects the logs of the call for its receipt
+		logs := &logCollectingFireable{fireable: evc}
+		// VM call variables
+		var (
+			gas     int64       = tx.GasLimit
+			err     error       = nil
+			caller  *vm.Account = toVMAccount(inAcc)
+			callee  *vm.Account = nil // initialized below
+			code    []byte      = nil
+			ret     []byte      = nil
+			txCache             = NewTxCache(blockCache)
+			params              = vm.Params{
+				BlockHeight: int64(_s.LastBlockHeight),
+				BlockHash:   LeftPadWord256(_s.LastBlockHash),
+				BlockTime:   _s.LastBlockTime.Unix(),
+				GasLimit:    _s.GetGasLimit(),
+				ChainID:     vm.ChainIDWord256(_s.ChainID),
+				GasSchedule: _s.GetVMGasSchedule(),
 			}
-			err = permAcc.Permissions.Base.Unset(args.Permission)
-		case *ptypes.SetGlobalArgs:
-			if permAcc = blockCache.GetAccount(ptypes.GlobalPermissionsAddress); permAcc == nil {
-				sanity.PanicSanity("can't find global permissions account")
+		)
+
+		if !createContract && (outAcc == nil || len(outAcc.Code) == 0) {
+			// if you call an account that doesn't exist
+			// or an account with no code then we take fees (sorry pal)
+			// NOTE: it's fine to create a contract and call it within one
+			// block (nonce will prevent re-ordering of those txs)
+			// but to create with one contract and call with another
+			// you have to wait a block to avoid a re-ordering attack
+			// that will take your fees
+			if outAcc == nil {
+				logging.InfoMsg(logger, "Call to address that does not exist",
+					"caller_address", inAcc.Address,
+					"callee_address", tx.Address)
+			} else {
+				logging.InfoMsg(logger, "Call to address that holds no code",
+					"caller_address", inAcc.Address,
+					"callee_address", tx.Address)
 			}
-			err = permAcc.Permissions.Base.Set(args.Permission, args.Value)
-		case *ptypes.HasRoleArgs:
-			return fmt.Errorf("HasRole is for contracts, not humans. Just look at the blockchain")
-		case *ptypes.AddRoleArgs:
-			if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
-				return fmt.Errorf("Trying to update roles for unknown account %X", args.Address)
+			err = txs.ErrTxInvalidAddress
+			goto CALL_COMPLETE
+		}
+
+		// get or create callee
+		if createContract {
+			// We already checked for permission
+			callee = txCache.CreateAccount(caller)
+			logging.TraceMsg(logger, "Created new contract",
+				"contract_address", callee.Address,
+				"contract_code", callee.Code)
+			code = tx.Data
+		} else {
+			callee = toVMAccount(outAcc)
+			logging.TraceMsg(logger, "Calling existing contract",
+				"contract_address", callee.Address,
+				"contract_code", callee.Code)
+			code = callee.Code
+		}
+		logger.Trace("callee_")
+
+		// Run VM call and sync txCache to blockCache.
+		{ // Capture scope for goto.
+			// Write caller/callee to txCache.
+			txCache.UpdateAccount(caller)
+			txCache.UpdateAccount(callee)
+			vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
+				caller.Address, txHash)
+			vmach.SetFireable(logs)
+			var tracer *vm.Tracer
+			if _s.txTraces != nil {
+				tracer = vm.NewTracer(vm.DefaultMaxTraceSteps)
+				vmach.SetTracer(tracer)
 			}
-			if !permAcc.Permissions.AddRole(args.Role) {
-				return fmt.Errorf("Role (%s) already exists for account %X", args.Role, args.Address)
+			// NOTE: Call() transfers the value from caller to callee iff call succeeds.
+			span := tracing.DefaultTracer.StartTxSpan(txHash, "EVM call")
+			start := time.Now()
+			ret, err = vmach.Call(caller, callee, code, tx.Data, value, &gas)
+			evmExecutionSeconds.ObserveSince(start)
+			gasUsed.Add(float64(tx.GasLimit - gas))
+			span.SetTag("gas_used", fmt.Sprintf("%v", tx.GasLimit-gas))
+			if err != nil {
+				span.SetTag("error", err.Error())
 			}
-		case *ptypes.RmRoleArgs:
-			if permAcc = blockCache.GetAccount(args.Address); permAcc == nil {
-				return fmt.Errorf("Trying to update roles for unknown account %X", args.Address)
+			span.Finish()
+			if tracer != nil {
+				_s.txTraces.Add(txHash, tracer.Trace())
 			}
-			if !permAcc.Permissions.RmRole(args.Role) {
-				return fmt.Errorf("Role (%s) does not exist for account %X", args.Role, args.Address)
+			if err != nil {
+				// Failure. Charge the gas fee. The 'value' was otherwise not transferred.
+				logging.InfoMsg(logger, "Error on execution",
+					"error", err)
+				goto CALL_COMPLETE
 			}
-		default:
-			sanity.PanicSanity(fmt.Sprintf("invalid permission function: %s", ptypes.PermFlagToString(permFlag)))
-		}
 
-		// TODO: maybe we want to take funds on error and allow txs in that don't do anythingi?
-		if err != nil {
-			return err
+			logging.TraceMsg(logger, "Successful execution")
+			if createContract {
+				callee.Code = ret
+			}
+			txCache.Sync()
+			if createContract {
+				// The creator alone may register an ABI for the contract
+				blockCache.UpdateABIEntry(&core_types.ABIEntry{
+					Address: callee.Address.Postfix(20),
+					Owner:   tx.Input.Address,
+				})
+			}
 		}
 
-		// Good!
-		inAcc.Sequence += 1
-		inAcc.Balance -= value
-		blockCache.UpdateAccount(inAcc)
-		if permAcc != nil {
-			blockCache.UpdateAccount(permAcc)
+	CALL_COMPLETE: // err may or may not be nil.
+
+		if _s.txReceipts != nil {
+			_s.txReceipts.Add(txReceipt(_s, tx, txHash, createContract, callee, gas, logs, ret, err))
 		}
 
+		// Create a receipt from the ret and whether it erred.
+		logging.TraceMsg(logger, "VM call complete",
+			"caller", caller,
+			"callee", callee,
+			"return", ret,
+			"error", err)
+
+		// Fire Events for sender and receiver
+		// a separate event will be fired from vm for each additional call
 		if evc != nil {
-			evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, nil, ""})
-			evc.FireEvent(txs.EventStringPermissions(ptypes.PermFlagToString(permFlag)), txs.EventDataTx{tx, nil, ""})
+			exception := ""
+			if err != nil {
+				exception = vm.RevertError(err, ret).Error()
+			}
+			evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{signedTx, ret, exception})
+			evc.FireEvent(txs.EventStringAccOutput(tx.Address), txs.EventDataTx{signedTx, ret, exception})
+		}
+	} else {
+		// The mempool does not call txs until
+		// the proposer determines the order of txs.
+		// So mempool will skip the actual .Call(),
+		// and only deduct from the caller's balance.
+		inAcc.Balance -= value
+		if createContract {
+			inAcc.Sequence += 1 // XXX ?!
 		}
+		blockCache.UpdateAccount(inAcc)
+	}
+	payFee(blockCache, tx.Fee, priorityFee)
 
-		return nil
+	return nil
+}
 
-	default:
-		// binary decoding should not let this happen
-		sanity.PanicSanity("Unknown Tx type")
-		return nil
+// Gets the changes made by a PermissionsTx with permArgs for its audit events,
+// without the height, tx hash and granter
+func permissionChanges(permArgs ptypes.PermArgs) []txs.EventDataPermission {
+	switch args := permArgs.(type) {
+	case *ptypes.SetBaseArgs:
+		return []txs.EventDataPermission{{Address: args.Address, Function: "setBase",
+			Permission: args.Permission, Value: args.Value}}
+	case *ptypes.UnsetBaseArgs:
+		return []txs.EventDataPermission{{Address: args.Address, Function: "unsetBase",
+			Permission: args.Permission}}
+	case *ptypes.SetGlobalArgs:
+		return []txs.EventDataPermission{{Address: ptypes.GlobalPermissionsAddress,
+			Function: "setGlobal", Permission: args.Permission, Value: args.Value}}
+	case *ptypes.AddRoleArgs:
+		return []txs.EventDataPermission{{Address: args.Address, Function: "addRole",
+			Role: args.Role}}
+	case *ptypes.RmRoleArgs:
+		return []txs.EventDataPermission{{Address: args.Address, Function: "removeRole",
+			Role: args.Role}}
+	case *ptypes.SetGroupArgs:
+		return []txs.EventDataPermission{{Address: ptypes.GroupAddress(args.Group),
+			Function: "setGroup", Permission: args.Permission, Value: args.Value,
+			Role: args.Group}}
+	case *ptypes.UnsetGroupArgs:
+		return []txs.EventDataPermission{{Address: ptypes.GroupAddress(args.Group),
+			Function: "unsetGroup", Permission: args.Permission, Role: args.Group}}
+	case *ptypes.AddGroupMembersArgs:
+		return groupMemberChanges("addGroupMembers", args.Group, args.Addresses)
+	case *ptypes.RmGroupMembersArgs:
+		return groupMemberChanges("removeGroupMembers", args.Group, args.Addresses)
+	}
+	return nil
+}
+
+func groupMemberChanges(function, group string,
+	addresses [][]byte) []txs.EventDataPermission {
+	changes := make([]txs.EventDataPermission, len(addresses))
+	for i, address := range addresses {
+		changes[i] = txs.EventDataPermission{Address: address, Function: function,
+			Role: group}
+	}
+	return changes
+}
+
+// Adds or removes the group role of the accounts at addresses, returning the
+// accounts to update. All the accounts are checked before any is changed so
+// that a failed tx leaves them as they were.
+func updateGroupMembers(blockCache *BlockCache, group string, addresses [][]byte,
+	add bool) ([]*acm.Account, error) {
+	if blockCache.GetAccount(ptypes.GroupAddress(group)) == nil {
+		return nil, fmt.Errorf("Trying to update members of unknown group %s", group)
+	}
+	memberAccs := make([]*acm.Account, len(addresses))
+	seen := make(map[string]bool, len(addresses))
+	for i, address := range addresses {
+		if seen[string(address)] {
+			return nil, fmt.Errorf("Account %X is given more than once", address)
+		}
+		seen[string(address)] = true
+		if memberAccs[i] = blockCache.GetAccount(address); memberAccs[i] == nil {
+			return nil, fmt.Errorf("Trying to update group %s for unknown account %X",
+				group, address)
+		}
+		if memberAccs[i].Permissions.HasRole(group) == add {
+			if add {
+				return nil, fmt.Errorf("Account %X is already a member of group %s",
+					address, group)
+			}
+			return nil, fmt.Errorf("Account %X is not a member of group %s",
+				address, group)
+		}
+	}
+	for _, memberAcc := range memberAccs {
+		if add {
+			memberAcc.Permissions.AddRole(group)
+		} else {
+			memberAcc.Permissions.RmRole(group)
+		}
 	}
+	return memberAccs, nil
 }
 
 //---------------------------------------------------------------
@@ -1010,7 +1314,24 @@ func HasPermission(state AccountGetter, acc *acm.Account, perm ptypes.PermFlag,
 	v, err := acc.Permissions.Base.Get(perm)
 	if _, ok := err.(ptypes.ErrValueNotSet); ok {
 		if state == nil {
-			sanity.PanicSanity("All known global permissions should be set!")
+			// The global permissions of chains made before a permission was
+			// added, such as Vote, do not set it
+			logging.TraceMsg(logger, "Global permission is not set",
+				"perm_flag", permString)
+			return false
+		}
+		v, err = acc.Permissions.GroupGet(func(group string) *ptypes.BasePermissions {
+			if groupAcc := state.GetAccount(ptypes.GroupAddress(group)); groupAcc != nil {
+				return &groupAcc.Permissions.Base
+			}
+			return nil
+		}, perm)
+		if _, ok := err.(ptypes.ErrValueNotSet); !ok {
+			logging.TraceMsg(logger, "Permission for account is set by its groups",
+				"account_address", acc.Address,
+				"perm_flag", permString,
+				"has_permission", v)
+			return v
 		}
 		logging.TraceMsg(logger, "Permission for account is not set. Querying GlobalPermissionsAddres.",
 			"perm_flag", permString)
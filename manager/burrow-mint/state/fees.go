@@ -0,0 +1,198 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// Returns the base fee for the block after one holding numTxs txs at
+// baseFee. As in EIP-1559 the fee moves towards the target in proportion to
+// how far the block was from it. A nil params means there is no base fee.
+func NextBaseFee(baseFee int64, numTxs int, params *genesis.FeeParams) int64 {
+	if params == nil || params.TargetTxsPerBlock < 1 {
+		return 0
+	}
+	denominator := params.BaseFeeChangeDenominator
+	if denominator < 1 {
+		denominator = 1
+	}
+	target := int64(params.TargetTxsPerBlock)
+	delta := baseFee * (int64(numTxs) - target) / target / denominator
+	if numTxs > params.TargetTxsPerBlock && delta < 1 {
+		// Always rise under congestion, otherwise a base fee of zero would stick
+		delta = 1
+	}
+	if baseFee+delta < 0 {
+		return 0
+	}
+	return baseFee + delta
+}
+
+// Checks that a tx paying fee can cover the base fee and its priority fee
+func validateFee(s *State, fee, priorityFee int64) error {
+	if priorityFee < 0 {
+		return txs.ErrTxInvalidAmount
+	}
+	if fee < s.BaseFee+priorityFee {
+		return fmt.Errorf("Fee %v does not cover the base fee %v plus the "+
+			"priority fee %v", fee, s.BaseFee, priorityFee)
+	}
+	return nil
+}
+
+// Credits the priority fee to the proposer of the block, creating its account
+// if need be, and collects the rest of fee for the rewards of the block. The
+// fee has already been taken from the tx inputs, so what is not paid out as a
+// reward is burnt.
+func payFee(blockCache *BlockCache, fee, priorityFee int64) {
+	creditAccount(blockCache, blockCache.State().BlockProposer, priorityFee)
+	blockCache.CollectFee(fee - priorityFee)
+}
+
+// Credits the proposer of the block at height and the validators that signed
+// its commit, the signers, with the reward of the current block, which takes
+// the fees collected in the cache since the last reward. Both are read from
+// the blocks of the chain, so every node credits the same validators. The
+// validators share in proportion to their power at height the reward that is
+// not the proposer's, and what rounding leaves over goes to the proposer, as
+// does all of it when there are no signers. The reward is burnt when there is
+// no proposer, as for the first blocks, which follow no commit.
+func DistributeRewards(blockCache *BlockCache, height int, proposer []byte,
+	signers [][]byte, logger logging_types.InfoTraceLogger) {
+	_s := blockCache.State()
+	fees := blockCache.TakeCollectedFees()
+	params := _s.RewardParams
+	if params == nil {
+		return
+	}
+	reward := params.BlockReward + fees*int64(params.FeePercent)/100
+	if reward <= 0 {
+		return
+	}
+	proposerReward := reward
+	genesisValidators := _s.GenesisValidators()
+	if len(signers) > 0 && genesisValidators != nil {
+		signed := make(map[string]bool, len(signers))
+		for _, signer := range signers {
+			signed[string(signer)] = true
+		}
+		var voters []*tm_types.Validator
+		totalPower := int64(0)
+		for _, validator := range ValidatorsAt(genesisValidators,
+			blockCache.GetValidatorRotations(), blockCache.GetValidatorPowerChanges(),
+			height) {
+			if signed[string(validator.Address)] {
+				voters = append(voters, validator)
+				totalPower += validator.VotingPower
+			}
+		}
+		votersReward := reward - reward*int64(params.ProposerPercent)/100
+		for _, voter := range voters {
+			share := votersReward * voter.VotingPower / totalPower
+			logging.TraceMsg(logger, "Rewarding validator",
+				"address", voter.Address,
+				"reward", share)
+			creditAccount(blockCache, voter.Address, share)
+			proposerReward -= share
+		}
+	}
+	logging.TraceMsg(logger, "Rewarding block proposer",
+		"address", proposer,
+		"reward", proposerReward)
+	creditAccount(blockCache, proposer, proposerReward)
+}
+
+// Adds amount to the balance of the account at address, creating the account
+// if need be. Nothing is credited when the address is empty.
+func creditAccount(blockCache *BlockCache, address []byte, amount int64) {
+	if amount == 0 || len(address) == 0 {
+		return
+	}
+	acc := blockCache.GetAccount(address)
+	if acc == nil {
+		acc = &acm.Account{
+			Address:     address,
+			PubKey:      nil,
+			Sequence:    0,
+			Balance:     0,
+			Permissions: ptypes.ZeroAccountPermissions,
+		}
+	}
+	acc.Balance += amount
+	blockCache.UpdateAccount(acc)
+}
+
+// Follows the validators tendermint schedules to propose at each round of
+// each height, so that the proposer of a block can be found from the round
+// its commit was made in, since the ABCI header does not carry the proposer.
+// The validators are the genesis validators with the rotations of their keys
+// and the changes to their power made as tendermint makes them.
+type ProposerSchedule struct {
+	genesisValidators []genesis.GenesisValidator
+	validators        *tm_types.ValidatorSet
+	height            int
+}
+
+func NewProposerSchedule(genDoc *genesis.GenesisDoc) *ProposerSchedule {
+	return &ProposerSchedule{
+		genesisValidators: genDoc.Validators,
+		// NewValidatorSet schedules height 1, as tendermint's genesis state does
+		validators: tm_types.NewValidatorSet(genDoc.TendermintValidators()),
+		height:     1,
+	}
+}
+
+// Get the address of the validator scheduled to propose at round of height,
+// which must not be lower than the height previously asked for, given the
+// rotations and power changes made before height.
+func (ps *ProposerSchedule) ProposerAt(height, round int, rotations []*ValidatorRotation,
+	powerChanges []*ValidatorPowerChange) ([]byte, error) {
+	if height < ps.height {
+		return nil, fmt.Errorf("Proposer schedule is at height %v so cannot "+
+			"go back to height %v", ps.height, height)
+	}
+	for ; ps.height < height; ps.height++ {
+		// As tendermint updates the validators after each block and then
+		// schedules the next
+		for _, change := range ValidatorChangesAfter(ps.genesisValidators,
+			rotations, powerChanges, ps.height) {
+			if change.VotingPower == 0 {
+				ps.validators.Remove(change.Address)
+			} else if ps.validators.HasAddress(change.Address) {
+				ps.validators.Update(change)
+			} else {
+				ps.validators.Add(change)
+			}
+		}
+		ps.validators.IncrementAccum(1)
+	}
+	if round > 0 {
+		// As tendermint moves on the proposer for each round of a height
+		validators := ps.validators.Copy()
+		validators.IncrementAccum(round)
+		return validators.Proposer().Address, nil
+	}
+	return ps.validators.Proposer().Address, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBaseFee(t *testing.T) {
+	params := &genesis.FeeParams{
+		InitialBaseFee:           100,
+		TargetTxsPerBlock:        10,
+		BaseFeeChangeDenominator: 8,
+	}
+	assert.Equal(t, int64(100), NextBaseFee(100, 10, params))
+	assert.Equal(t, int64(112), NextBaseFee(100, 20, params))
+	assert.Equal(t, int64(88), NextBaseFee(100, 0, params))
+	// A zero base fee still rises under congestion
+	assert.Equal(t, int64(1), NextBaseFee(0, 11, params))
+	assert.Equal(t, int64(0), NextBaseFee(0, 0, params))
+	assert.Equal(t, int64(0), NextBaseFee(100, 20, nil))
+}
+
+func TestSendTxBaseFee(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	state.BaseFee = 5
+	acc0 := state.GetAccount(privAccounts[0].PubKey.Address())
+	acc1 := state.GetAccount(privAccounts[1].PubKey.Address())
+	proposer := privAccounts[2].PubKey.Address()
+	proposerBalance := state.GetAccount(proposer).Balance
+	state.BlockProposer = proposer
+
+	makeTx := func(fee, priorityFee int64) *txs.PriorityFeeTx {
+		sendTx := &txs.SendTx{
+			Inputs: []*txs.TxInput{
+				&txs.TxInput{
+					Address:  acc0.Address,
+					Amount:   1 + fee,
+					Sequence: state.GetAccount(acc0.Address).Sequence + 1,
+					PubKey:   privAccounts[0].PubKey,
+				},
+			},
+			Outputs: []*txs.TxOutput{
+				&txs.TxOutput{
+					Address: acc1.Address,
+					Amount:  1,
+				},
+			},
+		}
+		tx := &txs.PriorityFeeTx{
+			PriorityFee: priorityFee,
+			Tx:          sendTx,
+		}
+		sendTx.Inputs[0].Signature = privAccounts[0].Sign(state.ChainID, tx)
+		return tx
+	}
+
+	// The fee does not cover the base fee and the priority fee
+	assert.Error(t, execTxWithState(state, makeTx(4, 0), true))
+	assert.Error(t, execTxWithState(state, makeTx(6, 2), true))
+	assert.Error(t, execTxWithState(state, makeTx(6, -1), true))
+	// The input signs for the priority fee
+	tx := makeTx(8, 2)
+	tx.PriorityFee = 1
+	assert.Equal(t, txs.ErrTxInvalidSignature, execTxWithState(state, tx, true))
+	assert.Equal(t, txs.ErrTxInvalidSignature, execTxWithState(state, tx.Tx, true))
+
+	err := execTxWithState(state, makeTx(8, 2), true)
+	if assert.NoError(t, err) {
+		// The proposer gets the priority fee and the rest is burnt
+		assert.Equal(t, acc0.Balance-9, state.GetAccount(acc0.Address).Balance)
+		assert.Equal(t, acc1.Balance+1, state.GetAccount(acc1.Address).Balance)
+		assert.Equal(t, proposerBalance+2, state.GetAccount(proposer).Balance)
+	}
+}
+
+func TestDistributeRewards(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, false, 1000, 2, false, 10)
+	genesisValidators := state.GenesisValidators()
+	genesisValidators[0].Amount = 30
+	state.SetGenesisValidators(genesisValidators)
+	validator0 := genesisValidators[0].PubKey.Address()
+	validator1 := genesisValidators[1].PubKey.Address()
+	proposer := privAccounts[2].PubKey.Address()
+	state.BlockProposer = proposer
+	state.RewardParams = &genesis.RewardParams{
+		BlockReward:     100,
+		FeePercent:      50,
+		ProposerPercent: 20,
+	}
+	balance := func(address []byte) int64 {
+		if acc := state.GetAccount(address); acc != nil {
+			return acc.Balance
+		}
+		return 0
+	}
+	proposerBalance := balance(proposer)
+
+	sendTx := &txs.SendTx{
+		Inputs: []*txs.TxInput{
+			&txs.TxInput{
+				Address:  privAccounts[0].PubKey.Address(),
+				Amount:   13,
+				Sequence: 1,
+				PubKey:   privAccounts[0].PubKey,
+			},
+		},
+		Outputs: []*txs.TxOutput{
+			&txs.TxOutput{
+				Address: privAccounts[1].PubKey.Address(),
+				Amount:  1,
+			},
+		},
+	}
+	tx := &txs.PriorityFeeTx{
+		PriorityFee: 2,
+		Tx:          sendTx,
+	}
+	sendTx.Inputs[0].Signature = privAccounts[0].Sign(state.ChainID, tx)
+	blockCache := NewBlockCache(state)
+	if !assert.NoError(t, ExecTx(blockCache, tx, true, nil, logger)) {
+		return
+	}
+	// Half of the fee of 10 is added to the block reward, and the validators
+	// share 80% of it by power
+	DistributeRewards(blockCache, 1, proposer, [][]byte{validator0, validator1},
+		logger)
+	blockCache.Sync()
+	assert.Equal(t, int64(63), balance(validator0))
+	assert.Equal(t, int64(21), balance(validator1))
+	assert.Equal(t, proposerBalance+2+21, balance(proposer))
+
+	// The fees have been taken, and the proposer gets all of the reward when
+	// no validator signed
+	blockCache = NewBlockCache(state)
+	DistributeRewards(blockCache, 1, proposer, nil, logger)
+	blockCache.Sync()
+	assert.Equal(t, int64(63), balance(validator0))
+	assert.Equal(t, proposerBalance+2+21+100, balance(proposer))
+
+	// The reward is burnt when there is no proposer
+	blockCache = NewBlockCache(state)
+	DistributeRewards(blockCache, 0, nil, nil, logger)
+	blockCache.Sync()
+	assert.Equal(t, proposerBalance+2+21+100, balance(proposer))
+
+	// Nothing is minted without reward parameters
+	state.RewardParams = nil
+	blockCache = NewBlockCache(state)
+	DistributeRewards(blockCache, 1, proposer, [][]byte{validator0}, logger)
+	blockCache.Sync()
+	assert.Equal(t, int64(63), balance(validator0))
+}
+
+func TestProposerSchedule(t *testing.T) {
+	genDoc, _, _ := RandGenesisDoc(1, true, 1000, 2, false, 10)
+	proposers := NewProposerSchedule(genDoc)
+	first, err := proposers.ProposerAt(1, 0, nil, nil)
+	assert.NoError(t, err)
+	// The validators have the same power so take turns round by round
+	second, err := proposers.ProposerAt(1, 1, nil, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	next, err := proposers.ProposerAt(2, 0, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, second, next)
+	_, err = proposers.ProposerAt(1, 0, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,287 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	dbm "github.com/tendermint/go-db"
+	wire "github.com/tendermint/go-wire"
+)
+
+const (
+	logIndexPrefix = "logindex/"
+	// The logs emitted at a height
+	logsKeyPrefix = logIndexPrefix + "logs/"
+	// The heights at which an address, a topic, or an address and topic appear
+	heightsKeyPrefix = logIndexPrefix + "heights/"
+	// Suffix of the key holding the number of heights recorded under a prefix
+	heightsCountSuffix = "count"
+)
+
+// LogIndex records the EVM logs emitted by committed blocks so that they can be
+// looked up by contract address and topic over a range of heights without
+// re-executing or scanning blocks. For each height that has logs their
+// contents are stored once, and the height is recorded against the emitting
+// address, each of the log's topics, and each address and topic pair.
+//
+// Each height recorded against one of those is its own key under the prefix
+// for it, numbered in height order alongside a count, so that indexing a block
+// writes a fixed number of small keys however long the history. dbm.Iterator
+// cannot seek so the heights are read back by their number rather than by
+// scanning the database.
+type LogIndex struct {
+	mtx     sync.Mutex
+	db      dbm.DB
+	pending []txs.EventDataLog
+}
+
+func NewLogIndex(db dbm.DB) *LogIndex {
+	return &LogIndex{db: db}
+}
+
+// Adds a log to be indexed on the next call to Commit
+func (li *LogIndex) Add(log txs.EventDataLog) {
+	li.mtx.Lock()
+	defer li.mtx.Unlock()
+	li.pending = append(li.pending, log)
+}
+
+// Writes the logs added since the last commit to the index. Logs are indexed
+// against the height they carry. The index is written in a single batch once
+// it has all been read, so on error nothing is written.
+func (li *LogIndex) Commit() error {
+	li.mtx.Lock()
+	defer li.mtx.Unlock()
+	logsByHeight := make(map[int64][]txs.EventDataLog)
+	for _, log := range li.pending {
+		logsByHeight[log.Height] = append(logsByHeight[log.Height], log)
+	}
+	li.pending = nil
+	heights := make([]int64, 0, len(logsByHeight))
+	for height := range logsByHeight {
+		heights = append(heights, height)
+	}
+	// Heights must be recorded in order to keep them numbered in height order
+	sort.Sort(int64s(heights))
+	batch := li.db.NewBatch()
+	// The heights recorded under each prefix in this batch, which are not
+	// visible to reads until it is written
+	recorded := make(map[string]*heightsRecord)
+	for _, height := range heights {
+		logs := logsByHeight[height]
+		existing, err := li.logsAt(height)
+		if err != nil {
+			return err
+		}
+		batch.Set(logsKey(height), wire.BinaryBytes(append(existing, logs...)))
+		for _, log := range logs {
+			prefixes := [][]byte{heightsKey(log.Address.Bytes(), nil)}
+			for _, topic := range log.Topics {
+				prefixes = append(prefixes, heightsKey(nil, topic.Bytes()),
+					heightsKey(log.Address.Bytes(), topic.Bytes()))
+			}
+			for _, prefix := range prefixes {
+				record, ok := recorded[string(prefix)]
+				if !ok {
+					record, err = li.heightsRecord(prefix)
+					if err != nil {
+						return err
+					}
+					recorded[string(prefix)] = record
+				}
+				if record.count > 0 && record.last >= height {
+					continue
+				}
+				batch.Set(heightKey(prefix, record.count), wire.BinaryBytes(height))
+				record.count++
+				record.last = height
+				batch.Set(heightsCountKey(prefix), wire.BinaryBytes(record.count))
+			}
+		}
+	}
+	batch.Write()
+	return nil
+}
+
+// Returns the logs emitted by address with topic among their topics at heights
+// from minHeight to maxHeight inclusive in height order. Either address or
+// topic may be nil to match any, but not both. A maxHeight of zero means there
+// is no upper bound.
+func (li *LogIndex) Logs(address, topic []byte, minHeight,
+	maxHeight int64) ([]txs.EventDataLog, error) {
+	if len(address) == 0 && len(topic) == 0 {
+		return nil, fmt.Errorf("An address or topic is required to look up logs")
+	}
+	var addressWord, topicWord Word256
+	var addressKey, topicKey []byte
+	if len(address) > 0 {
+		addressWord = LeftPadWord256(address)
+		addressKey = addressWord.Bytes()
+	}
+	if len(topic) > 0 {
+		topicWord = LeftPadWord256(topic)
+		topicKey = topicWord.Bytes()
+	}
+	li.mtx.Lock()
+	defer li.mtx.Unlock()
+	prefix := heightsKey(addressKey, topicKey)
+	count, err := li.heightsCount(prefix)
+	if err != nil {
+		return nil, err
+	}
+	// The heights are numbered in order so we can skip straight to the start
+	// of the range
+	var searchErr error
+	i := sort.Search(int(count), func(i int) bool {
+		height, err := li.heightAt(prefix, int64(i))
+		if err != nil && searchErr == nil {
+			searchErr = err
+		}
+		return height >= minHeight
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	var logs []txs.EventDataLog
+	for n := int64(i); n < count; n++ {
+		height, err := li.heightAt(prefix, n)
+		if err != nil {
+			return nil, err
+		}
+		if maxHeight != 0 && height > maxHeight {
+			break
+		}
+		logsAtHeight, err := li.logsAt(height)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logsAtHeight {
+			if len(address) > 0 && log.Address != addressWord {
+				continue
+			}
+			if len(topic) > 0 && !hasTopic(log, topicWord) {
+				continue
+			}
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (li *LogIndex) logsAt(height int64) ([]txs.EventDataLog, error) {
+	var logs []txs.EventDataLog
+	err := readBinary(li.db.Get(logsKey(height)), &logs)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read logs at height %v from log "+
+			"index: %v", height, err)
+	}
+	return logs, nil
+}
+
+// The number of heights recorded under a prefix and the last of them
+type heightsRecord struct {
+	count int64
+	last  int64
+}
+
+func (li *LogIndex) heightsRecord(prefix []byte) (*heightsRecord, error) {
+	count, err := li.heightsCount(prefix)
+	if err != nil {
+		return nil, err
+	}
+	record := &heightsRecord{count: count}
+	if count > 0 {
+		record.last, err = li.heightAt(prefix, count-1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func (li *LogIndex) heightsCount(prefix []byte) (int64, error) {
+	var count int64
+	err := readBinary(li.db.Get(heightsCountKey(prefix)), &count)
+	if err != nil {
+		return 0, fmt.Errorf("Could not read number of heights from log "+
+			"index: %v", err)
+	}
+	return count, nil
+}
+
+func (li *LogIndex) heightAt(prefix []byte, n int64) (int64, error) {
+	bs := li.db.Get(heightKey(prefix, n))
+	if len(bs) == 0 {
+		return 0, fmt.Errorf("Height %v of %s missing from log index", n, prefix)
+	}
+	var height int64
+	err := readBinary(bs, &height)
+	if err != nil {
+		return 0, fmt.Errorf("Could not read height %v of %s from log index: %v",
+			n, prefix, err)
+	}
+	return height, nil
+}
+
+func logsKey(height int64) []byte {
+	return []byte(fmt.Sprintf("%s%016X", logsKeyPrefix, height))
+}
+
+// The prefix of the keys holding the heights at which address and topic appear
+func heightsKey(address, topic []byte) []byte {
+	return []byte(fmt.Sprintf("%s%X/%X/", heightsKeyPrefix, address, topic))
+}
+
+// The key of the nth height under prefix, numbered in hex so that they sort in
+// order and never collide with the count
+func heightKey(prefix []byte, n int64) []byte {
+	return []byte(fmt.Sprintf("%s%016X", prefix, n))
+}
+
+func heightsCountKey(prefix []byte) []byte {
+	return []byte(fmt.Sprintf("%s%s", prefix, heightsCountSuffix))
+}
+
+func hasTopic(log txs.EventDataLog, topic Word256) bool {
+	for _, t := range log.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+type int64s []int64
+
+func (is int64s) Len() int           { return len(is) }
+func (is int64s) Less(i, j int) bool { return is[i] < is[j] }
+func (is int64s) Swap(i, j int)      { is[i], is[j] = is[j], is[i] }
+
+// Reads o from bs, leaving it untouched if bs is empty
+func readBinary(bs []byte, o interface{}) error {
+	if len(bs) == 0 {
+		return nil
+	}
+	n, err := new(int), new(error)
+	wire.ReadBinaryPtr(o, bytes.NewReader(bs), len(bs), n, err)
+	return *err
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	dbm "github.com/tendermint/go-db"
+)
+
+func TestLogIndex(t *testing.T) {
+	addressA := []byte{0x0A}
+	addressB := []byte{0x0B}
+	transfer := RightPadWord256([]byte("Transfer"))
+	approval := RightPadWord256([]byte("Approval"))
+	newLog := func(address []byte, topic Word256, height int64) txs.EventDataLog {
+		return txs.EventDataLog{
+			Address: LeftPadWord256(address),
+			Topics:  []Word256{topic},
+			Data:    []byte{0x01},
+			Height:  height,
+		}
+	}
+	db := dbm.NewMemDB()
+	logIndex := NewLogIndex(db)
+	logs := []txs.EventDataLog{
+		newLog(addressA, transfer, 1),
+		newLog(addressB, transfer, 1),
+		newLog(addressA, approval, 2),
+		newLog(addressA, transfer, 3),
+	}
+	for _, log := range logs {
+		logIndex.Add(log)
+	}
+	// Nothing is indexed until committed
+	found, err := logIndex.Logs(addressA, nil, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, found, 0)
+	assert.NoError(t, logIndex.Commit())
+
+	found, err = logIndex.Logs(addressA, transfer.Bytes(), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []txs.EventDataLog{logs[0], logs[3]}, found)
+
+	found, err = logIndex.Logs(addressA, transfer.Bytes(), 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []txs.EventDataLog{logs[3]}, found)
+
+	found, err = logIndex.Logs(nil, transfer.Bytes(), 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []txs.EventDataLog{logs[0], logs[1]}, found)
+
+	found, err = logIndex.Logs(addressA, nil, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []txs.EventDataLog{logs[0], logs[2], logs[3]}, found)
+
+	// The index persists in the db
+	logIndex.Add(newLog(addressB, approval, 4))
+	assert.NoError(t, logIndex.Commit())
+	found, err = NewLogIndex(db).Logs(addressB, nil, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	_, err = logIndex.Logs(nil, nil, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestLogIndexManyHeights(t *testing.T) {
+	address := []byte{0x0A}
+	transfer := RightPadWord256([]byte("Transfer"))
+	db := dbm.NewMemDB()
+	logIndex := NewLogIndex(db)
+	for height := int64(1); height <= 100; height++ {
+		// Two logs at a height record it once
+		for i := 0; i < 2; i++ {
+			logIndex.Add(txs.EventDataLog{
+				Address: LeftPadWord256(address),
+				Topics:  []Word256{transfer},
+				Height:  height,
+			})
+		}
+		assert.NoError(t, logIndex.Commit())
+	}
+	prefix := heightsKey(LeftPadWord256(address).Bytes(), nil)
+	count, err := logIndex.heightsCount(prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), count)
+	// Each height is its own key rather than growing a single value
+	height, err := logIndex.heightAt(prefix, 41)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), height)
+
+	found, err := logIndex.Logs(address, transfer.Bytes(), 42, 44)
+	assert.NoError(t, err)
+	assert.Len(t, found, 6)
+	for i, log := range found {
+		assert.Equal(t, int64(42+i/2), log.Height)
+	}
+	found, err = logIndex.Logs(address, nil, 99, 0)
+	assert.NoError(t, err)
+	assert.Len(t, found, 4)
+	found, err = logIndex.Logs(address, nil, 101, 0)
+	assert.NoError(t, err)
+	assert.Len(t, found, 0)
+}
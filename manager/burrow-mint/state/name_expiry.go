@@ -0,0 +1,119 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"sort"
+
+	"github.com/hyperledger/burrow/txs"
+)
+
+// The number of blocks before a name registry entry expires that its owner is
+// warned of the expiry with a NameReg/<name>/Expiring event
+const NameRegExpiryWarningPeriod = 1000
+
+// NameRegExpiries keeps the heights at which name registry entries expire so
+// that events can be fired as each entry approaches and reaches its expiry
+// without going through the whole registry every block. It is built from
+// state when the node starts and kept up to date with the names of the NameTxs
+// in each block, so it is not part of state itself.
+type NameRegExpiries struct {
+	// The expiry of each entry that has not yet expired
+	expires map[string]int
+	// The names to check at each height, since they may warn or expire then.
+	// Checks are made against expires so those of renewed entries are ignored.
+	checks map[int][]string
+	// The names of the NameTxs in the block being executed
+	pending map[string]bool
+}
+
+func NewNameRegExpiries(s *State) *NameRegExpiries {
+	nre := &NameRegExpiries{
+		expires: make(map[string]int),
+		checks:  make(map[int][]string),
+		pending: make(map[string]bool),
+	}
+	s.nameReg.Iterate(func(key, value []byte) bool {
+		entry := DecodeNameRegEntry(value)
+		nre.schedule(entry.Name, entry.Expires, s.LastBlockHeight+1)
+		return false
+	})
+	return nre
+}
+
+// Adds the name of tx, if it is a NameTx, to be looked up in state on the next
+// call to Commit
+func (nre *NameRegExpiries) Add(tx txs.Tx) {
+	if nameTx, ok := tx.(*txs.NameTx); ok {
+		nre.pending[nameTx.Name] = true
+	}
+}
+
+// Updates the expiries of the names added since the last commit from state s,
+// committed at s.LastBlockHeight, and gets the events of the entries that
+// warn of or reach their expiry at that height
+func (nre *NameRegExpiries) Commit(s *State) []txs.EventDataNameRegExpiry {
+	height := s.LastBlockHeight
+	for name := range nre.pending {
+		delete(nre.expires, name)
+		if entry := s.GetNameRegEntry(name); entry != nil {
+			nre.schedule(name, entry.Expires, height)
+		}
+	}
+	nre.pending = make(map[string]bool)
+
+	names := nre.checks[height]
+	delete(nre.checks, height)
+	sort.Strings(names)
+	var expiries []txs.EventDataNameRegExpiry
+	for i, name := range names {
+		expires, ok := nre.expires[name]
+		if !ok || (i > 0 && names[i-1] == name) || expires-NameRegExpiryWarningPeriod > height {
+			continue
+		}
+		entry := s.GetNameRegEntry(name)
+		if entry == nil {
+			continue
+		}
+		if expires <= height {
+			delete(nre.expires, name)
+		}
+		expiries = append(expiries, txs.EventDataNameRegExpiry{
+			Name:    name,
+			Owner:   entry.Owner,
+			Expires: expires,
+			Height:  int64(height),
+			Expired: expires <= height,
+		})
+	}
+	return expiries
+}
+
+// Schedules the checks of an entry expiring at expires, which is warned of no
+// earlier than height
+func (nre *NameRegExpiries) schedule(name string, expires, height int) {
+	if expires < height {
+		return
+	}
+	nre.expires[name] = expires
+	warnAt := expires - NameRegExpiryWarningPeriod
+	if warnAt < height {
+		warnAt = height
+	}
+	if warnAt < expires {
+		nre.checks[warnAt] = append(nre.checks[warnAt], name)
+	}
+	nre.checks[expires] = append(nre.checks[expires], name)
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameRegExpiries(t *testing.T) {
+	state, _, _ := RandGenesisState(1, true, 1000, 1, true, 1000)
+	owner := []byte("owner_______________")
+	state.UpdateNameRegEntry(&core_types.NameRegEntry{Name: "long", Owner: owner,
+		Expires: 1500})
+	state.UpdateNameRegEntry(&core_types.NameRegEntry{Name: "short", Owner: owner,
+		Expires: 10})
+	nameRegExpiries := NewNameRegExpiries(state)
+
+	expiries := make(map[int][]txs.EventDataNameRegExpiry)
+	commitUpTo := func(height int) {
+		for state.LastBlockHeight < height {
+			state.LastBlockHeight++
+			if found := nameRegExpiries.Commit(state); len(found) > 0 {
+				expiries[state.LastBlockHeight] = found
+			}
+		}
+	}
+
+	// An entry already within the warning period is warned of at once
+	commitUpTo(20)
+	assert.Equal(t, map[int][]txs.EventDataNameRegExpiry{
+		1:  {{Name: "short", Owner: owner, Expires: 10, Height: 1}},
+		10: {{Name: "short", Owner: owner, Expires: 10, Height: 10, Expired: true}},
+	}, expiries)
+
+	// Renewing an entry moves its warning and expiry
+	state.UpdateNameRegEntry(&core_types.NameRegEntry{Name: "long", Owner: owner,
+		Expires: 3000})
+	nameRegExpiries.Add(&txs.NameTx{Name: "long"})
+	expiries = make(map[int][]txs.EventDataNameRegExpiry)
+	commitUpTo(3000)
+	assert.Equal(t, map[int][]txs.EventDataNameRegExpiry{
+		2000: {{Name: "long", Owner: owner, Expires: 3000, Height: 2000}},
+		3000: {{Name: "long", Owner: owner, Expires: 3000, Height: 3000, Expired: true}},
+	}, expiries)
+
+	// Removed entries do not expire
+	state.UpdateNameRegEntry(&core_types.NameRegEntry{Name: "removed", Owner: owner,
+		Expires: 3005})
+	nameRegExpiries.Add(&txs.NameTx{Name: "removed"})
+	commitUpTo(3001)
+	assert.Len(t, expiries[3001], 1)
+	state.RemoveNameRegEntry("removed")
+	nameRegExpiries.Add(&txs.NameTx{Name: "removed"})
+	expiries = make(map[int][]txs.EventDataNameRegExpiry)
+	commitUpTo(3010)
+	assert.Len(t, expiries, 0)
+}
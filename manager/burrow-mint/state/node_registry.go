@@ -0,0 +1,119 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-merkle"
+	"github.com/tendermint/go-wire"
+)
+
+//-------------------------------------
+// State.nodeRegistry
+
+// Get the network identity the validator with address registered for its node
+func (s *State) GetNodeRegEntry(address []byte) *core_types.NodeRegEntry {
+	_, valueBytes, _ := s.nodeRegistry.Get(address)
+	if valueBytes == nil {
+		return nil
+	}
+	return DecodeNodeRegEntry(valueBytes)
+}
+
+func DecodeNodeRegEntry(entryBytes []byte) *core_types.NodeRegEntry {
+	entry := new(core_types.NodeRegEntry)
+	readBinary(entryBytes, entry)
+	return entry
+}
+
+func (s *State) UpdateNodeRegEntry(entry *core_types.NodeRegEntry) bool {
+	return s.nodeRegistry.Set(entry.Address, wire.BinaryBytes(entry))
+}
+
+// The node registry entries by validator address
+func (s *State) GetNodeRegistry() merkle.Tree {
+	return s.nodeRegistry.Copy()
+}
+
+// State.nodeRegistry
+//-------------------------------------
+
+// Registers the network identity of the node of a validator of the next block,
+// or of the block after, which has signed tx with its key. The entry is kept
+// by the address of the key, so a validator that rotates its key registers its
+// node again for the new key.
+func execIdentifyTx(blockCache *BlockCache, tx *txs.IdentifyTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	address := tx.PubKey.Address()
+	if err := tx.ValidateBasic(); err != nil {
+		logging.InfoMsg(logger, "Invalid node identity",
+			"address", address,
+			"error", err)
+		return err
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if !tx.PubKey.VerifyBytes(signBytes, tx.Signature) {
+		logging.InfoMsg(logger, "Node identity is not signed by the validator key",
+			"address", address)
+		return txs.ErrTxInvalidSignature
+	}
+	validators := ValidatorsAt(_s.GenesisValidators(), blockCache.GetValidatorRotations(),
+		blockCache.GetValidatorPowerChanges(), _s.LastBlockHeight+1)
+	if findValidator(validators, tx.PubKey) < 0 &&
+		findValidator(nextValidators(blockCache), tx.PubKey) < 0 {
+		return fmt.Errorf("%X is not a validator so cannot register a node", address)
+	}
+	entry := blockCache.GetNodeRegEntry(address)
+	sequence := 1
+	if entry != nil {
+		sequence = entry.Sequence + 1
+	}
+	if tx.Sequence != sequence {
+		return txs.ErrTxInvalidSequence{
+			Got:      tx.Sequence,
+			Expected: sequence,
+		}
+	}
+
+	// Good!
+	logging.TraceMsg(logger, "Registering validator node",
+		"address", address,
+		"node_id", tx.NodeID,
+		"net_address", tx.NetAddress)
+	blockCache.UpdateNodeRegEntry(&core_types.NodeRegEntry{
+		Address:            address,
+		NodeID:             tx.NodeID,
+		NetAddress:         tx.NetAddress,
+		TLSCertFingerprint: tx.TLSCertFingerprint,
+		Moniker:            tx.Moniker,
+		Sequence:           tx.Sequence,
+		Height:             _s.LastBlockHeight + 1,
+	})
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(address), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringIdentify(), txs.EventDataTx{tx, nil, ""})
+	}
+	return nil
+}
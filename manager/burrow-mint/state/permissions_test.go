@@ -1077,6 +1077,123 @@ func TestSNativeTx(t *testing.T) {
 	}
 }
 
+func TestPermissionGroups(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true) // give the 0 account permission
+	genDoc.Accounts[3].Permissions.Base.Set(ptypes.CreateContract, false)
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+	hasCreate := func(u *acm.PrivAccount) bool {
+		return HasPermission(blockCache, blockCache.GetAccount(u.Address),
+			ptypes.CreateContract, logger)
+	}
+	members := [][]byte{user[1].Address, user[2].Address, user[3].Address}
+
+	// The group must exist before it has members
+	moderator := blockCache.GetAccount(user[0].Address)
+	moderator.Permissions.Base.Set(ptypes.AddRole, true)
+	testSNativeTxExpectFail(t, blockCache, &ptypes.AddGroupMembersArgs{"deployers", members})
+	moderator.Permissions.Base.Unset(ptypes.AddRole)
+
+	testSNativeTxExpectFail(t, blockCache, &ptypes.SetGroupArgs{"deployers", ptypes.CreateContract, true})
+	testSNativeTxExpectPass(t, blockCache, ptypes.SetBase,
+		&ptypes.SetGroupArgs{"deployers", ptypes.CreateContract, true})
+	if hasCreate(user[1]) {
+		t.Fatal("expected an account not in the group not to have its permissions")
+	}
+
+	testSNativeTxExpectFail(t, blockCache, &ptypes.AddGroupMembersArgs{"deployers", members})
+	testSNativeTxExpectPass(t, blockCache, ptypes.AddRole,
+		&ptypes.AddGroupMembersArgs{"deployers", members})
+	if !hasCreate(user[1]) || !hasCreate(user[2]) {
+		t.Fatal("expected the members of the group to have its permissions")
+	}
+	if hasCreate(user[3]) {
+		t.Fatal("expected the permissions of an account to take precedence over its groups")
+	}
+	if hasCreate(user[4]) {
+		t.Fatal("expected an account not in the group not to have its permissions")
+	}
+
+	// No members change if any cannot
+	testSNativeTxExpectFail(t, blockCache, &ptypes.AddGroupMembersArgs{"deployers",
+		[][]byte{user[4].Address, user[1].Address}})
+	if hasCreate(user[4]) {
+		t.Fatal("expected a failed tx not to add members")
+	}
+
+	testSNativeTxExpectFail(t, blockCache, &ptypes.RmGroupMembersArgs{"deployers", members[:1]})
+	testSNativeTxExpectPass(t, blockCache, ptypes.RmRole,
+		&ptypes.RmGroupMembersArgs{"deployers", members[:1]})
+	if hasCreate(user[1]) || !hasCreate(user[2]) {
+		t.Fatal("expected only the removed member to lose the group's permissions")
+	}
+
+	testSNativeTxExpectFail(t, blockCache, &ptypes.UnsetGroupArgs{"deployers", ptypes.CreateContract})
+	testSNativeTxExpectPass(t, blockCache, ptypes.UnsetBase,
+		&ptypes.UnsetGroupArgs{"deployers", ptypes.CreateContract})
+	if hasCreate(user[2]) {
+		t.Fatal("expected members to fall back to the global permissions")
+	}
+}
+
+func TestPermissionChangeEvents(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true) // give the 0 account permission
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.SetBase, true)
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	// From a PermissionsTx
+	tx, _ := txs.NewPermissionsTx(blockCache, user[0].PubKey,
+		&ptypes.SetBaseArgs{user[3].Address, ptypes.CreateContract, true})
+	tx.Sign(chainID, user[0])
+	ev, exception := execTxWaitEvent(t, blockCache, tx,
+		txs.EventStringAccPermissionChange(user[3].Address))
+	if exception != "" {
+		t.Fatal("Unexpected exception", exception)
+	}
+	change, ok := ev.(txs.EventDataPermission)
+	if !ok {
+		t.Fatalf("Expected EventDataPermission, got %v", ev)
+	}
+	if change.Function != "setBase" || change.Permission != ptypes.CreateContract ||
+		!change.Value || change.Height != 1 ||
+		!bytes.Equal(change.Granter, user[0].Address) ||
+		!bytes.Equal(change.TxHash, txs.TxHash(chainID, tx)) {
+		t.Errorf("Unexpected permission change %v", change)
+	}
+
+	// From a contract calling the Permissions SNative
+	doug := &acm.Account{
+		Address:     DougAddress,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	doug.Permissions.Base.Set(ptypes.Call, true)
+	doug.Permissions.Base.Set(ptypes.SetBase, true)
+	snativeAddress, _, data := snativePermTestInputCALL("setBase", user[4], ptypes.Bond, true)
+	doug.Code = callContractCode(snativeAddress)
+	blockCache.UpdateAccount(doug)
+	callTx, _ := txs.NewCallTx(blockCache, user[0].PubKey, doug.Address, data, 100, 10000, 100)
+	callTx.Sign(chainID, user[0])
+	ev, exception = execTxWaitEvent(t, blockCache, callTx,
+		txs.EventStringAccPermissionChange(user[4].Address))
+	if exception != "" {
+		t.Fatal("Unexpected exception", exception)
+	}
+	change, ok = ev.(txs.EventDataPermission)
+	if !ok {
+		t.Fatalf("Expected EventDataPermission, got %v", ev)
+	}
+	if change.Function != "setBase" || change.Permission != ptypes.Bond ||
+		!change.Value || !bytes.Equal(change.Granter, doug.Address) {
+		t.Errorf("Unexpected permission change %v", change)
+	}
+}
+
 //-------------------------------------------------------------------------------------
 // helpers
 
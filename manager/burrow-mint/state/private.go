@@ -0,0 +1,324 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	dbm "github.com/tendermint/go-db"
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+)
+
+//-------------------------------------
+// State.privateTxs
+
+func (s *State) getPrivateTxEntry(txHash []byte) []byte {
+	_, value, _ := s.privateTxs.Get(txHash)
+	return value
+}
+
+func (s *State) setPrivateTxEntry(txHash, value []byte) bool {
+	return s.privateTxs.Set(txHash, value)
+}
+
+// Get the record of the PrivateTx with txHash, nil if there is none
+func (s *State) GetPrivateTxRecord(txHash []byte) *core_types.PrivateTxRecord {
+	return decodePrivateTxRecord(s.getPrivateTxEntry(txHash))
+}
+
+func (s *State) UpdatePrivateTxRecord(record *core_types.PrivateTxRecord) bool {
+	return s.setPrivateTxEntry(record.TxHash, wire.BinaryBytes(record))
+}
+
+func decodePrivateTxRecord(recordBytes []byte) *core_types.PrivateTxRecord {
+	if recordBytes == nil {
+		return nil
+	}
+	record := new(core_types.PrivateTxRecord)
+	readBinary(recordBytes, record)
+	return record
+}
+
+// State.privateTxs
+//-------------------------------------
+
+// The private states of the groups whose keys the node holds, each kept in a
+// database of its own outside the state of the chain. The private state of a
+// group starts from the genesis doc and is only changed by the payloads of
+// the PrivateTxs sent to the group, which the node decrypts and executes as
+// it delivers them. It is saved whenever the state of the chain is saved.
+type PrivateStates struct {
+	mtx    sync.Mutex
+	groups map[string]*privateGroup
+}
+
+type privateGroup struct {
+	key      []byte
+	state    *State
+	receipts *TxReceipts
+}
+
+func NewPrivateStates() *PrivateStates {
+	return &PrivateStates{groups: make(map[string]*privateGroup)}
+}
+
+// Adds the group sharing key, returning its ID. The private state of the
+// group is loaded from db, or made from genDoc when db holds none.
+func (ps *PrivateStates) AddGroup(key []byte, db dbm.DB,
+	genDoc *genesis.GenesisDoc) ([]byte, error) {
+	if len(key) != txs.PrivateGroupKeyLength {
+		return nil, fmt.Errorf("Key of private group is %v bytes but must be %v "+
+			"bytes", len(key), txs.PrivateGroupKeyLength)
+	}
+	group := txs.PrivateGroupID(key)
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if _, ok := ps.groups[string(group)]; ok {
+		return nil, fmt.Errorf("Private group %X is added twice", group)
+	}
+	privState := LoadState(db)
+	if privState == nil {
+		privState = MakeGenesisState(db, genDoc)
+		// The fees of PrivateTxs are paid on the chain
+		privState.BaseFee = 0
+		privState.Save()
+	} else if privState.ChainID != genDoc.ChainID {
+		return nil, fmt.Errorf("Private state of group %X is of chain %s not %s",
+			group, privState.ChainID, genDoc.ChainID)
+	}
+	ps.groups[string(group)] = &privateGroup{
+		key:      key,
+		state:    privState,
+		receipts: NewTxReceipts(db),
+	}
+	return group, nil
+}
+
+// The IDs of the groups, in order
+func (ps *PrivateStates) Groups() [][]byte {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	groups := make([][]byte, 0, len(ps.groups))
+	for group := range ps.groups {
+		groups = append(groups, []byte(group))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return bytes.Compare(groups[i], groups[j]) < 0
+	})
+	return groups
+}
+
+// Get the account at address in the private state of group, nil if there is
+// none
+func (ps *PrivateStates) GetAccount(group, address []byte) (*acm.Account, error) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	privGroup, err := ps.group(group)
+	if err != nil {
+		return nil, err
+	}
+	return privGroup.state.GetAccount(address), nil
+}
+
+// Get the storage at key of the account at address in the private state of
+// group, zero if there is none
+func (ps *PrivateStates) GetStorage(group, address []byte,
+	key Word256) (Word256, error) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	privGroup, err := ps.group(group)
+	if err != nil {
+		return Zero256, err
+	}
+	account := privGroup.state.GetAccount(address)
+	if account == nil {
+		return Zero256, nil
+	}
+	storage := privGroup.state.LoadStorage(account.StorageRoot)
+	_, value, _ := storage.Get(key.Bytes())
+	return LeftPadWord256(value), nil
+}
+
+// Get the receipt of the payload of the PrivateTx with txHash executed by
+// group, decoding its logs with abiJSON if given
+func (ps *PrivateStates) TxReceipt(group, txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	privGroup, err := ps.group(group)
+	if err != nil {
+		return nil, err
+	}
+	return privGroup.receipts.TxReceipt(txHash, abiJSON, privGroup.state)
+}
+
+// Saves the private state of each group with the receipts of the payloads it
+// has executed
+func (ps *PrivateStates) Save() {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	for _, privGroup := range ps.groups {
+		privGroup.state.Save()
+		privGroup.receipts.Commit()
+	}
+}
+
+func (ps *PrivateStates) group(group []byte) (*privateGroup, error) {
+	privGroup, ok := ps.groups[string(group)]
+	if !ok {
+		return nil, fmt.Errorf("Node does not hold the key of private group %X",
+			group)
+	}
+	return privGroup, nil
+}
+
+// Decrypts and executes the payload of tx, whose input has been taken on the
+// chain, against the private state of its group, returning whether the node
+// holds the key of the group. The account sending tx and the global
+// permissions are those on the chain, so the payload runs with the
+// permissions the sender has there. Its logs are only kept in its receipt.
+func (ps *PrivateStates) execute(blockCache *BlockCache, tx *txs.PrivateTx,
+	logger logging_types.InfoTraceLogger) (bool, error) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	privGroup, ok := ps.groups[string(tx.Group)]
+	if !ok {
+		return false, nil
+	}
+	payload, err := tx.Decrypt(privGroup.key)
+	if err != nil {
+		return true, err
+	}
+	_s := blockCache.State()
+	privState := privGroup.state
+	privState.LastBlockHeight = _s.LastBlockHeight
+	privState.LastBlockHash = _s.LastBlockHash
+	privState.LastBlockTime = _s.LastBlockTime
+	privState.txReceipts = privGroup.receipts
+
+	privCache := NewBlockCache(privState)
+	inAcc := blockCache.GetAccount(tx.Input.Address).Copy()
+	if privAcc := privCache.GetAccount(inAcc.Address); privAcc != nil {
+		// The sender keeps the sequence of its private contract creations
+		inAcc.Sequence = privAcc.Sequence
+		inAcc.Code = privAcc.Code
+		inAcc.StorageRoot = privAcc.StorageRoot
+	}
+	privCache.UpdateAccount(inAcc)
+	if globalAcc := blockCache.GetAccount(ptypes.GlobalPermissionsAddress); globalAcc != nil {
+		privCache.UpdateAccount(globalAcc.Copy())
+	}
+	callTx := &txs.CallTx{
+		Input: &txs.TxInput{
+			Address:  inAcc.Address,
+			Sequence: inAcc.Sequence + 1,
+		},
+		Address:  payload.Address,
+		GasLimit: payload.GasLimit,
+		Data:     payload.Data,
+	}
+	err = execCallTx(privCache, callTx, tx, true, nil, logger)
+	// Whatever the payload did the input of tx was taken
+	privCache.Sync()
+	return true, err
+}
+
+// Takes the fee of a PrivateTx, the input amount, and records the hash of its
+// encrypted payload. When the node holds the key of the group of tx it then
+// executes the payload against the private state of the group. What the
+// payload does cannot change the outcome of tx on the chain, as the nodes
+// outside the group do not know it.
+func execPrivateTx(blockCache *BlockCache, tx *txs.PrivateTx, runCall bool,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	inAcc := blockCache.GetAccount(tx.Input.Address)
+	if inAcc == nil {
+		logging.InfoMsg(logger, "Cannot find input account",
+			"tx_input", tx.Input)
+		return txs.ErrTxInvalidAddress
+	}
+	// pubKey should be present in either "inAcc" or "tx.Input"
+	if err := checkInputPubKey(inAcc, tx.Input); err != nil {
+		logging.InfoMsg(logger, "Cannot find public key for input account",
+			"tx_input", tx.Input)
+		return err
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if err := validateInput(inAcc, signBytes, tx.Input); err != nil {
+		logging.InfoMsg(logger, "validateInput failed",
+			"tx_input", tx.Input, "error", err)
+		return err
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if !hasCallPermission(blockCache, inAcc, logger) {
+		return fmt.Errorf("Account %X does not have Call permission", tx.Input.Address)
+	}
+	if err := validateFee(_s, tx.Input.Amount, 0); err != nil {
+		logging.InfoMsg(logger, "Fee does not cover the base fee",
+			"base_fee", _s.BaseFee, "error", err)
+		return err
+	}
+
+	// Good!
+	txHash := txs.TxHash(_s.ChainID, tx)
+	inAcc.Sequence += 1
+	inAcc.Balance -= tx.Input.Amount
+	blockCache.UpdateAccount(inAcc)
+	payFee(blockCache, tx.Input.Amount, 0)
+	blockCache.UpdatePrivateTxRecord(&core_types.PrivateTxRecord{
+		TxHash:      txHash,
+		Group:       tx.Group,
+		Sender:      tx.Input.Address,
+		PayloadHash: tx.PayloadHash(),
+		Height:      _s.LastBlockHeight + 1,
+	})
+
+	if runCall && _s.privateStates != nil {
+		member, err := _s.privateStates.execute(blockCache, tx, logger)
+		if member {
+			logging.TraceMsg(logger, "Executed private payload",
+				"group", tx.Group,
+				"tx_hash", txHash,
+				"error", err)
+		}
+		if err != nil {
+			logging.InfoMsg(logger, "Private payload could not be executed",
+				"group", tx.Group,
+				"tx_hash", txHash,
+				"error", err)
+		}
+	}
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringPrivate(tx.Group), txs.EventDataTx{tx, nil, ""})
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/tendermint/go-merkle"
+)
+
+// The names the trees of state are hashed with in Hash
+const (
+	accountsTreeName           = "Accounts"
+	nameRegTreeName            = "NameRegistry"
+	abiRegistryTreeName        = "ABIRegistry"
+	validatorRotationsTreeName = "ValidatorRotations"
+	proposalsTreeName          = "Proposals"
+	validatorPowersTreeName    = "ValidatorPowers"
+	bondsTreeName              = "Bonds"
+	unbondingsTreeName         = "Unbondings"
+	jailsTreeName              = "Jails"
+	missedBlocksTreeName       = "MissedBlocks"
+	nodeRegistryTreeName       = "NodeRegistry"
+	relaysTreeName             = "Relays"
+	bridgeTreeName             = "Bridge"
+	privateTxsTreeName         = "PrivateTxs"
+	confidentialTreeName       = "Confidential"
+)
+
+// Returns the account at address with a proof of it against Hash. IAVL trees
+// cannot prove absence so it is an error for the account not to exist.
+func (s *State) GetAccountWithProof(address []byte) (*core_types.AccountWithProof, error) {
+	accBytes, treeProof, exists := s.accounts.Proof(address)
+	if !exists {
+		return nil, fmt.Errorf("Account %X does not exist so cannot be proved", address)
+	}
+	return &core_types.AccountWithProof{
+		Height:  s.LastBlockHeight,
+		Account: acm.DecodeAccount(accBytes),
+		Proof:   s.stateProof(accountsTreeName, treeProof),
+	}, nil
+}
+
+// Returns the storage at key of the account at address, with proofs of it
+// against the account's storage root and of the account against Hash. As for
+// accounts, it is an error for the key to have no (that is, zero) value.
+func (s *State) GetStorageWithProof(address []byte, key Word256) (*core_types.StorageItemWithProof,
+	error) {
+	accountWithProof, err := s.GetAccountWithProof(address)
+	if err != nil {
+		return nil, err
+	}
+	storage := s.LoadStorage(accountWithProof.Account.StorageRoot)
+	value, storageProof, exists := storage.Proof(key.Bytes())
+	if !exists {
+		return nil, fmt.Errorf("Storage key %X of account %X is not set so "+
+			"cannot be proved", key.Bytes(), address)
+	}
+	return &core_types.StorageItemWithProof{
+		Height:       s.LastBlockHeight,
+		StorageItem:  core_types.StorageItem{Key: key.Bytes(), Value: value},
+		StorageProof: storageProof,
+		Account:      accountWithProof.Account,
+		AccountProof: accountWithProof.Proof,
+	}, nil
+}
+
+// Extends a proof against the root of the named tree to one against Hash
+func (s *State) stateProof(treeName string, treeProof []byte) *core_types.StateProof {
+	trees := s.hashedTrees()
+	kvPairs := merkle.MakeSortedKVPairs(trees)
+	appHash, simpleProofs := merkle.SimpleProofsFromHashables(kvPairs)
+	proof := &core_types.StateProof{
+		AppHash:   appHash,
+		TreeName:  treeName,
+		TreeRoot:  trees[treeName].(merkle.Tree).Hash(),
+		TreeProof: treeProof,
+		Total:     len(kvPairs),
+	}
+	for i, kvPair := range kvPairs {
+		if kvPair.(merkle.KVPair).Key == treeName {
+			proof.Index = i
+			proof.Aunts = simpleProofs[i].Aunts
+		}
+	}
+	return proof
+}
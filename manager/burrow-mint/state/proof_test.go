@@ -0,0 +1,58 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAccountWithProof(t *testing.T) {
+	st, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	address := privAccounts[1].Address
+	accountWithProof, err := st.GetAccountWithProof(address)
+	assert.NoError(t, err)
+	assert.Equal(t, st.GetAccount(address), accountWithProof.Account)
+	assert.True(t, accountWithProof.Verify(st.Hash()))
+
+	// A tampered account does not verify
+	accountWithProof.Account.Balance += 1
+	assert.False(t, accountWithProof.Verify(st.Hash()))
+
+	_, err = st.GetAccountWithProof([]byte("not an account"))
+	assert.Error(t, err)
+}
+
+func TestGetStorageWithProof(t *testing.T) {
+	st, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	address := privAccounts[0].Address
+	cache := NewBlockCache(st)
+	cache.SetStorage(LeftPadWord256(address), Int64ToWord256(1), Int64ToWord256(42))
+	cache.Sync()
+
+	itemWithProof, err := st.GetStorageWithProof(address, Int64ToWord256(1))
+	assert.NoError(t, err)
+	assert.Equal(t, Int64ToWord256(42).Bytes(), itemWithProof.StorageItem.Value)
+	assert.True(t, itemWithProof.Verify(st.Hash()))
+
+	itemWithProof.StorageItem.Value = Int64ToWord256(43).Bytes()
+	assert.False(t, itemWithProof.Verify(st.Hash()))
+
+	_, err = st.GetStorageWithProof(address, Int64ToWord256(2))
+	assert.Error(t, err)
+}
@@ -0,0 +1,329 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+)
+
+type ProposalState byte
+
+const (
+	// The proposal is waiting for votes
+	ProposalStateVoting ProposalState = iota
+	// The batch of txs of the proposal has been executed
+	ProposalStateExecuted
+	// The batch of txs of the proposal failed, so none of it was applied
+	ProposalStateFailed
+)
+
+func (ps ProposalState) String() string {
+	switch ps {
+	case ProposalStateVoting:
+		return "voting"
+	case ProposalStateExecuted:
+		return "executed"
+	case ProposalStateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// A proposal made on-chain and the accounts that have voted for it
+type Ballot struct {
+	Proposal *txs.Proposal `json:"proposal"`
+	// The height of the block the proposal was made in
+	Height int           `json:"height"`
+	State  ProposalState `json:"state"`
+	// The addresses of the voters in the order they voted, starting with the
+	// proposer
+	Votes [][]byte `json:"votes"`
+	// Why the batch of txs failed
+	Error string `json:"error"`
+}
+
+// Copies the ballot so votes can be added to the copy, the proposal is shared
+func (ballot *Ballot) Copy() *Ballot {
+	ballotCopy := *ballot
+	ballotCopy.Votes = append([][]byte(nil), ballot.Votes...)
+	return &ballotCopy
+}
+
+func (ballot *Ballot) HasVoted(address []byte) bool {
+	for _, voter := range ballot.Votes {
+		if bytes.Equal(voter, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the proposal has threshold votes and the vote of every input of its
+// batch of txs, so the batch can be executed
+func (ballot *Ballot) Passed(threshold int) bool {
+	if len(ballot.Votes) < threshold {
+		return false
+	}
+	for _, address := range ballot.Proposal.InputAddresses() {
+		if !ballot.HasVoted(address) {
+			return false
+		}
+	}
+	return true
+}
+
+func DecodeBallot(ballotBytes []byte) *Ballot {
+	ballot := new(Ballot)
+	readBinary(ballotBytes, ballot)
+	return ballot
+}
+
+//-------------------------------------
+// State.proposals
+
+// Get the ballot of the proposal with proposalHash, or nil if it has not been
+// made
+func (s *State) GetBallot(proposalHash []byte) *Ballot {
+	_, valueBytes, _ := s.proposals.Get(proposalHash)
+	if valueBytes == nil {
+		return nil
+	}
+	return DecodeBallot(valueBytes)
+}
+
+func (s *State) UpdateBallot(proposalHash []byte, ballot *Ballot) bool {
+	return s.proposals.Set(proposalHash, wire.BinaryBytes(ballot))
+}
+
+// State.proposals
+//-------------------------------------
+
+// Makes the proposal of tx or adds the vote of its input to the proposal it
+// gives. Once a vote passes the proposal its batch of txs is executed against
+// a copy of blockCache that replaces blockCache only if every tx succeeds.
+// Either way the vote stands and the outcome is recorded in the ballot.
+func execProposalTx(blockCache *BlockCache, tx *txs.ProposalTx, runCall bool,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if _s.ProposalThreshold < 1 {
+		return fmt.Errorf("Proposals cannot be made on this chain since its " +
+			"proposal threshold is 0")
+	}
+
+	// Validate input
+	inAcc := blockCache.GetAccount(tx.Input.Address)
+	if inAcc == nil {
+		logging.InfoMsg(logger, "Cannot find input account",
+			"tx_input", tx.Input)
+		return txs.ErrTxInvalidAddress
+	}
+	if !HasPermission(blockCache, inAcc, ptypes.Vote, logger) {
+		return fmt.Errorf("Account %X does not have Vote permission", tx.Input.Address)
+	}
+	// pubKey should be present in either "inAcc" or "tx.Input"
+	if err := checkInputPubKey(inAcc, tx.Input); err != nil {
+		logging.InfoMsg(logger, "Cannot find public key for input account",
+			"tx_input", tx.Input)
+		return err
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if err := validateInput(inAcc, signBytes, tx.Input); err != nil {
+		logging.InfoMsg(logger, "validateInput failed",
+			"tx_input", tx.Input, "error", err)
+		return err
+	}
+	if err := validateFee(_s, tx.Input.Amount, 0); err != nil {
+		return err
+	}
+
+	proposalHash := tx.GetProposalHash(_s.ChainID)
+	ballot := blockCache.GetBallot(proposalHash)
+	if tx.Proposal != nil {
+		if ballot != nil {
+			return fmt.Errorf("Proposal %X has already been made", proposalHash)
+		}
+		ballot = &Ballot{
+			Proposal: tx.Proposal,
+			Height:   _s.LastBlockHeight + 1,
+			State:    ProposalStateVoting,
+		}
+	} else {
+		if ballot == nil {
+			return fmt.Errorf("There is no proposal %X to vote for", proposalHash)
+		}
+		if ballot.State != ProposalStateVoting {
+			return fmt.Errorf("Proposal %X is closed since it has %v",
+				proposalHash, ballot.State)
+		}
+		if ballot.HasVoted(tx.Input.Address) {
+			return fmt.Errorf("Account %X has already voted for proposal %X",
+				tx.Input.Address, proposalHash)
+		}
+		ballot = ballot.Copy()
+	}
+
+	// Good!
+	inAcc.Sequence += 1
+	inAcc.Balance -= tx.Input.Amount
+	blockCache.UpdateAccount(inAcc)
+	payFee(blockCache, tx.Input.Amount, 0)
+	ballot.Votes = append(ballot.Votes, tx.Input.Address)
+
+	if ballot.Passed(_s.ProposalThreshold) {
+		batchCache := blockCache.Copy()
+		// The events of the batch only fire if all of it is applied
+		var batchEvc events.Fireable
+		var eventCache *events.EventCache
+		if evc != nil {
+			eventCache = events.NewEventCache(evc)
+			batchEvc = eventCache
+		}
+		if err := execProposal(batchCache, ballot.Proposal, tx, runCall, batchEvc,
+			logger); err != nil {
+			logging.InfoMsg(logger, "Proposal failed",
+				"proposal_hash", proposalHash, "error", err)
+			ballot.State = ProposalStateFailed
+			ballot.Error = err.Error()
+		} else {
+			ballot.State = ProposalStateExecuted
+			*blockCache = *batchCache
+			if eventCache != nil {
+				eventCache.Flush()
+			}
+		}
+	}
+	blockCache.UpdateBallot(proposalHash, ballot)
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringProposal(proposalHash), txs.EventDataProposal{
+			ProposalHash: proposalHash,
+			Name:         ballot.Proposal.Name,
+			Height:       int64(_s.LastBlockHeight + 1),
+			Voter:        tx.Input.Address,
+			Votes:        len(ballot.Votes),
+			State:        ballot.State.String(),
+			Error:        ballot.Error,
+		})
+	}
+	return nil
+}
+
+// Executes the batch of txs of proposal, which has passed with the vote of
+// proposalTx, in order. The inputs of the batched txs have voted for the
+// proposal so are taken as signed by proposalTx, which is the tx of their
+// events, and their sequences are set to follow those of their accounts.
+func execProposal(blockCache *BlockCache, proposal *txs.Proposal,
+	proposalTx *txs.ProposalTx, runCall bool, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	for i, batchTx := range proposal.Txs {
+		// Copied so that the proposal is not changed by setting sequences
+		txBytes, err := txs.EncodeTx(batchTx)
+		if err != nil {
+			return err
+		}
+		tx, err := txs.DecodeTx(txBytes)
+		if err != nil {
+			return err
+		}
+		for _, input := range txs.ProposalTxInputs(tx) {
+			acc := blockCache.GetAccount(input.Address)
+			if acc == nil {
+				return fmt.Errorf("Tx %v of proposal has unknown input %X", i,
+					input.Address)
+			}
+			input.Sequence = acc.Sequence + 1
+		}
+		switch tx := tx.(type) {
+		case *txs.SendTx:
+			err = execSendTx(blockCache, tx, proposalTx, evc, logger)
+		case *txs.CallTx:
+			err = execCallTx(blockCache, tx, proposalTx, runCall, evc, logger)
+		case *txs.PermissionsTx:
+			err = execPermissionsTx(blockCache, tx, proposalTx, evc, logger)
+		case *txs.GovTx:
+			err = execGovTx(blockCache, tx, proposalTx, evc, logger)
+		default:
+			err = fmt.Errorf("Proposal cannot execute %T", tx)
+		}
+		if err != nil {
+			return fmt.Errorf("Tx %v of proposal failed: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Changes the chain parameters as tx, batched by the proposal of proposalTx,
+// sets. The global permissions are changed in blockCache straight away, and the
+// rest when blockCache is synced.
+func execGovTx(blockCache *BlockCache, tx *txs.GovTx, proposalTx *txs.ProposalTx,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) error {
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if tx.Params != nil {
+		if err := blockCache.State().ValidateChainParams(tx.Params); err != nil {
+			return err
+		}
+		// Nodes could not halt before the block executing
+		height := blockCache.State().LastBlockHeight + 1
+		if upgrade := tx.Params.Upgrade; upgrade != nil && upgrade.Height > 0 &&
+			upgrade.Height <= height {
+			return fmt.Errorf("Upgrade height %v must be above the height of "+
+				"the block executing %v", upgrade.Height, height)
+		}
+	}
+
+	logging.TraceMsg(logger, "New GovTx", "params", tx.Params, "unjail", tx.Unjail)
+
+	// The batch of the proposal is discarded if an unjailing fails
+	for _, address := range tx.Unjail {
+		if err := unjailValidator(blockCache, address); err != nil {
+			return err
+		}
+	}
+	if tx.Params != nil {
+		if tx.Params.GlobalPermissions != nil {
+			globalAcc := blockCache.GetAccount(ptypes.GlobalPermissionsAddress)
+			if globalAcc == nil {
+				return fmt.Errorf("Cannot find the global permissions account")
+			}
+			globalAcc.Permissions.Base = *tx.Params.GlobalPermissions
+			blockCache.UpdateAccount(globalAcc)
+		}
+		if tx.Params.Bridge != nil {
+			setBridgeParams(blockCache, tx.Params.Bridge)
+		}
+		blockCache.AddChainParams(tx.Params)
+	}
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringGov(), txs.EventDataTx{proposalTx, nil, ""})
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+
+	dbm "github.com/tendermint/go-db"
+	wire "github.com/tendermint/go-wire"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	pruningPrefix = "pruning/"
+	// Holds the heights of the versions of state recorded for pruning
+	pruningHeightsKey = pruningPrefix + "heights"
+	// Prefix of the root hashes of the version at a height
+	pruningVersionKeyPrefix = pruningPrefix + "versions/"
+)
+
+// Every version of state is kept unless pruning is configured. The zero value
+// of PruningOptions disables pruning.
+type PruningOptions struct {
+	// How many of the most recent versions to keep, 0 keeps every version
+	KeepRecent int
+	// Also keep every version at a height that is a multiple of KeepEvery,
+	// for example so snapshots can be taken from them, 0 keeps none
+	KeepEvery int
+	// How many blocks to wait between pruning runs
+	Interval int
+}
+
+func (opts PruningOptions) Enabled() bool {
+	return opts.KeepRecent > 0
+}
+
+func (opts PruningOptions) keep(height, lastHeight int) bool {
+	return height > lastHeight-opts.KeepRecent ||
+		(opts.KeepEvery > 0 && height%opts.KeepEvery == 0)
+}
+
+// The root hashes of the trees making up a version of state
+type stateVersion struct {
+	AccountsRoot    []byte
+	NameRegRoot     []byte
+	ABIRegistryRoot []byte
+}
+
+// Versions recorded before the ABI registry lack its root
+type legacyStateVersion struct {
+	AccountsRoot []byte
+	NameRegRoot  []byte
+}
+
+func readStateVersion(bs []byte, version *stateVersion) error {
+	if err := readBinary(bs, version); err != nil {
+		legacy := new(legacyStateVersion)
+		if readBinary(bs, legacy) != nil {
+			return err
+		}
+		*version = stateVersion{
+			AccountsRoot: legacy.AccountsRoot,
+			NameRegRoot:  legacy.NameRegRoot,
+		}
+	}
+	return nil
+}
+
+// The Pruner saves state, recording the root of each version saved so that
+// past versions can be read with StateAt, and when pruning is enabled deletes
+// the IAVL nodes of versions that fall outside the retention window in the
+// background. Only versions saved through the Pruner are ever pruned.
+//
+// Nodes are shared between versions (and may reappear in a later version
+// once deleted from an earlier one) so a node is only deleted when no kept
+// version reaches it. A pruning run therefore walks every kept version, which
+// costs time and memory in the number of distinct nodes they hold. The walk
+// is made from the roots of the versions as they were when the run started,
+// without blocking saves, and only the versions saved since are walked again
+// before the nodes are deleted.
+//
+// A version being read (see StateAt and HoldLatest) is not pruned until the
+// read is done, since the IAVL trees panic on reaching a node that has been
+// deleted from under them.
+type Pruner struct {
+	// Held while saving state and while deleting nodes, so that nodes being
+	// written by a save are never deleted
+	mtx     sync.Mutex
+	db      dbm.DB
+	options PruningOptions
+	heights []int
+	running bool
+	// The height of the last version saved
+	lastSaved int
+	// The number of reads of each version in progress
+	reading map[int]int
+	// The versions the pruning run in progress is deleting, which can no
+	// longer be read
+	pruning map[int]bool
+	logger  logging_types.InfoTraceLogger
+}
+
+func NewPruner(db dbm.DB, options PruningOptions,
+	logger logging_types.InfoTraceLogger) (*Pruner, error) {
+	pruner := &Pruner{
+		db:      db,
+		options: options,
+		reading: make(map[int]int),
+		logger:  logging.WithScope(logger, "Pruner"),
+	}
+	if err := readBinary(db.Get([]byte(pruningHeightsKey)), &pruner.heights); err != nil {
+		return nil, fmt.Errorf("Could not load pruning heights: %v", err)
+	}
+	if pruner.options.Interval < 1 {
+		pruner.options.Interval = 1
+	}
+	return pruner, nil
+}
+
+// Saves s to its database, as s.Save does, and records the saved version so
+// it can be read and pruned later. If a pruning run is due and none is in
+// progress one is started in the background.
+func (p *Pruner) Save(s *State) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	s.Save()
+	height := s.LastBlockHeight
+	version := &stateVersion{
+		AccountsRoot:    s.accounts.Hash(),
+		NameRegRoot:     s.nameReg.Hash(),
+		ABIRegistryRoot: s.abiRegistry.Hash(),
+	}
+	p.db.Set(pruningVersionKey(height), wire.BinaryBytes(version))
+	p.lastSaved = height
+	if !p.options.Enabled() {
+		return
+	}
+	p.heights = append(p.heights, height)
+	p.db.Set([]byte(pruningHeightsKey), wire.BinaryBytes(p.heights))
+	if height%p.options.Interval == 0 && !p.running {
+		p.running = true
+		go func() {
+			if err := p.Prune(height); err != nil {
+				logging.InfoMsg(p.logger, "Failed to prune state", "error", err)
+			}
+		}()
+	}
+}
+
+// Returns a copy of s with the version of state saved at height, which must
+// not have been pruned, and a func to call once done reading it. The version
+// is not pruned until then.
+func (p *Pruner) StateAt(s *State, height int) (*State, func(), error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.pruning[height] {
+		return nil, nil, fmt.Errorf("The state at height %v is not available", height)
+	}
+	versionState, err := stateAt(p.db, s, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	return versionState, p.hold(height), nil
+}
+
+// Keeps the last version saved from being pruned until the returned func is
+// called, so that a copy of the latest state, which reaches no other saved
+// nodes, can be read. The state must not be saved between copying it and
+// holding its version.
+func (p *Pruner) HoldLatest() func() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.hold(p.lastSaved)
+}
+
+// p.mtx must be held
+func (p *Pruner) hold(height int) func() {
+	p.reading[height]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mtx.Lock()
+			defer p.mtx.Unlock()
+			if p.reading[height]--; p.reading[height] == 0 {
+				delete(p.reading, height)
+			}
+		})
+	}
+}
+
+// Returns a copy of s with the version of state at height recorded in its
+// database by a Pruner, as Pruner.StateAt does, for reading past state from a
+// database no node is running on
+func LoadStateAt(s *State, height int) (*State, error) {
+	return stateAt(s.DB, s, height)
+}
+
+func stateAt(db dbm.DB, s *State, height int) (*State, error) {
+	versionBytes := db.Get(pruningVersionKey(height))
+	if len(versionBytes) == 0 {
+		return nil, fmt.Errorf("The state at height %v is not available", height)
+	}
+	version := new(stateVersion)
+	if err := readStateVersion(versionBytes, version); err != nil {
+		return nil, fmt.Errorf("Could not load version of state at height %v: %v",
+			height, err)
+	}
+	versionState := s.Copy()
+	versionState.LastBlockHeight = height
+	versionState.accounts.Load(version.AccountsRoot)
+	versionState.nameReg.Load(version.NameRegRoot)
+	versionState.abiRegistry.Load(version.ABIRegistryRoot)
+	return versionState, nil
+}
+
+// Deletes the versions of state recorded before lastHeight that are outside
+// the retention window. Versions saved while pruning, and those being read,
+// are kept.
+func (p *Pruner) Prune(lastHeight int) error {
+	p.mtx.Lock()
+	// Snapshot the roots so that the versions can be walked without the lock
+	versions := make(map[int]*stateVersion)
+	var prune []int
+	for _, height := range p.heights {
+		version, err := p.loadVersion(height)
+		if err != nil {
+			p.mtx.Unlock()
+			return err
+		}
+		versions[height] = version
+		if height <= lastHeight && !p.options.keep(height, lastHeight) &&
+			p.reading[height] == 0 {
+			prune = append(prune, height)
+		}
+	}
+	pruning := make(map[int]bool)
+	for _, height := range prune {
+		pruning[height] = true
+	}
+	p.pruning = pruning
+	p.mtx.Unlock()
+	defer func() {
+		p.mtx.Lock()
+		p.pruning = nil
+		p.running = false
+		p.mtx.Unlock()
+	}()
+	if len(prune) == 0 {
+		return nil
+	}
+
+	kept := make(map[string]bool)
+	keep := func(hash []byte) bool {
+		if kept[string(hash)] {
+			return false
+		}
+		kept[string(hash)] = true
+		return true
+	}
+	for height, version := range versions {
+		if !pruning[height] {
+			if err := p.walkVersion(version, keep); err != nil {
+				return err
+			}
+		}
+	}
+	deleted := make(map[string]bool)
+	for _, height := range prune {
+		err := p.walkVersion(versions[height], func(hash []byte) bool {
+			if kept[string(hash)] || deleted[string(hash)] {
+				return false
+			}
+			deleted[string(hash)] = true
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	// Versions saved since the snapshot may have brought back nodes of the
+	// pruned versions, and share the rest with the versions already walked
+	var remaining []int
+	for _, height := range p.heights {
+		if pruning[height] {
+			continue
+		}
+		remaining = append(remaining, height)
+		if _, ok := versions[height]; !ok {
+			version, err := p.loadVersion(height)
+			if err != nil {
+				return err
+			}
+			if err := p.walkVersion(version, keep); err != nil {
+				return err
+			}
+		}
+	}
+	// Delete once walked since children are found through their parents
+	batch := p.db.NewBatch()
+	for hash := range deleted {
+		if !kept[hash] {
+			batch.Delete([]byte(hash))
+		}
+	}
+	for _, height := range prune {
+		batch.Delete(pruningVersionKey(height))
+	}
+	p.heights = remaining
+	batch.Set([]byte(pruningHeightsKey), wire.BinaryBytes(p.heights))
+	batch.Write()
+	logging.InfoMsg(p.logger, "Pruned state", "versions", len(prune),
+		"last_block_height", lastHeight)
+	return nil
+}
+
+func (p *Pruner) loadVersion(height int) (*stateVersion, error) {
+	version := new(stateVersion)
+	if err := readStateVersion(p.db.Get(pruningVersionKey(height)), version); err != nil {
+		return nil, fmt.Errorf("Could not load version of state at height %v: %v",
+			height, err)
+	}
+	return version, nil
+}
+
+// Calls visit with the hash of each node of version, including the nodes of
+// account storage, only descending into a node's children when visit returns
+// true
+func (p *Pruner) walkVersion(version *stateVersion, visit func(hash []byte) bool) error {
+	err := p.walkTree(version.AccountsRoot, visit, func(value []byte) error {
+		account := acm.DecodeAccount(value)
+		return p.walkTree(account.StorageRoot, visit, nil)
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.walkTree(version.NameRegRoot, visit, nil); err != nil {
+		return err
+	}
+	return p.walkTree(version.ABIRegistryRoot, visit, nil)
+}
+
+// Walks the persisted IAVL nodes under root, calling leafValue (if not nil)
+// with the value of each leaf visited. Nodes that are missing from the
+// database (already pruned or never saved) are skipped.
+func (p *Pruner) walkTree(root []byte, visit func(hash []byte) bool,
+	leafValue func(value []byte) error) error {
+	if len(root) == 0 || !visit(root) {
+		return nil
+	}
+	nodeBytes := p.db.Get(root)
+	if len(nodeBytes) == 0 {
+		return nil
+	}
+	node, err := readIAVLNode(nodeBytes)
+	if err != nil {
+		return fmt.Errorf("Could not decode node %X: %v", root, err)
+	}
+	if node.height == 0 {
+		if leafValue != nil {
+			return leafValue(node.value)
+		}
+		return nil
+	}
+	if err := p.walkTree(node.leftHash, visit, leafValue); err != nil {
+		return err
+	}
+	return p.walkTree(node.rightHash, visit, leafValue)
+}
+
+// The persisted form of a go-merkle IAVLNode, which has a value if it is a
+// leaf and the hashes of its children otherwise
+type iavlNode struct {
+	height    int8
+	size      int
+	key       []byte
+	value     []byte
+	leftHash  []byte
+	rightHash []byte
+}
+
+func readIAVLNode(nodeBytes []byte) (*iavlNode, error) {
+	r, n, err := bytes.NewReader(nodeBytes), new(int), new(error)
+	node := &iavlNode{
+		height: wire.ReadInt8(r, n, err),
+		size:   wire.ReadVarint(r, n, err),
+		key:    wire.ReadByteSlice(r, 0, n, err),
+	}
+	if node.height == 0 {
+		node.value = wire.ReadByteSlice(r, 0, n, err)
+	} else {
+		node.leftHash = wire.ReadByteSlice(r, 0, n, err)
+		node.rightHash = wire.ReadByteSlice(r, 0, n, err)
+	}
+	return node, *err
+}
+
+// The hash the node is stored under, which unlike its persisted form does not
+// cover the key of an inner node
+func (node *iavlNode) hash() []byte {
+	hasher, n, err := ripemd160.New(), new(int), new(error)
+	wire.WriteInt8(node.height, hasher, n, err)
+	wire.WriteVarint(node.size, hasher, n, err)
+	if node.height == 0 {
+		wire.WriteByteSlice(node.key, hasher, n, err)
+		wire.WriteByteSlice(node.value, hasher, n, err)
+	} else {
+		wire.WriteByteSlice(node.leftHash, hasher, n, err)
+		wire.WriteByteSlice(node.rightHash, hasher, n, err)
+	}
+	return hasher.Sum(nil)
+}
+
+func pruningVersionKey(height int) []byte {
+	return []byte(fmt.Sprintf("%s%016X", pruningVersionKeyPrefix, height))
+}
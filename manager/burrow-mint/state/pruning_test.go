@@ -0,0 +1,80 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/logging/loggers"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruner(t *testing.T) {
+	st, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	address := privAccounts[0].Address
+	// Prune manually, only after saving every version
+	options := PruningOptions{KeepRecent: 2, KeepEvery: 3, Interval: 1000}
+	pruner, err := NewPruner(st.DB, options, loggers.NewNoopInfoTraceLogger())
+	assert.NoError(t, err)
+
+	versions := make(map[int]*stateVersion)
+	for height := 1; height <= 7; height++ {
+		cache := NewBlockCache(st)
+		account := cache.GetAccount(address)
+		account.Balance = int64(height)
+		cache.UpdateAccount(account)
+		cache.SetStorage(LeftPadWord256(address), Int64ToWord256(1),
+			Int64ToWord256(int64(height)))
+		cache.Sync()
+		st.LastBlockHeight = height
+		pruner.Save(st)
+		versions[height] = &stateVersion{
+			AccountsRoot: st.accounts.Hash(),
+			NameRegRoot:  st.nameReg.Hash(),
+		}
+	}
+	// A version being read is not pruned until the read is done
+	held, release, err := pruner.StateAt(st, 4)
+	assert.NoError(t, err)
+	assert.NoError(t, pruner.Prune(7))
+	assert.Equal(t, []int{3, 4, 6, 7}, pruner.heights)
+	assert.Equal(t, int64(4), held.GetAccount(address).Balance)
+	release()
+	assert.NoError(t, pruner.Prune(7))
+	_, _, err = pruner.StateAt(st, 4)
+	assert.Error(t, err)
+
+	// Recent versions and every third version are kept
+	assert.Equal(t, []int{3, 6, 7}, pruner.heights)
+	for height, version := range versions {
+		copied := st.Copy()
+		copied.accounts.Load(version.AccountsRoot)
+		if height == 3 || height >= 6 {
+			assert.Equal(t, int64(height), copied.GetAccount(address).Balance)
+			assert.Equal(t, Int64ToWord256(int64(height)),
+				NewBlockCache(copied).GetStorage(LeftPadWord256(address), Int64ToWord256(1)))
+		} else {
+			assert.Nil(t, st.DB.Get(version.AccountsRoot),
+				"root of version %v should be pruned", height)
+		}
+	}
+
+	// The heights kept are reloaded from the db
+	pruner, err = NewPruner(st.DB, options, loggers.NewNoopInfoTraceLogger())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 6, 7}, pruner.heights)
+}
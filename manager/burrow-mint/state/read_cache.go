@@ -0,0 +1,159 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/hyperledger/burrow/metrics"
+
+	"github.com/tendermint/go-merkle"
+)
+
+var stateCacheLookups = metrics.NewCounter("burrow_state_cache_lookups_total",
+	"The reads of accounts and storage through the state read cache, by cache "+
+		"and whether they hit", "cache", "result")
+
+// The zero value of ReadCacheOptions caches nothing
+type ReadCacheOptions struct {
+	// The number of accounts to keep cached, 0 caches none
+	Accounts int
+	// The number of storage slots to keep cached, 0 caches none
+	Storage int
+}
+
+func (opts ReadCacheOptions) Enabled() bool {
+	return opts.Accounts > 0 || opts.Storage > 0
+}
+
+// Caches the encoded accounts and the storage values most recently read from
+// the trees of state. Entries are keyed by the root hash of the tree they were
+// read from as well as their key, so they never go stale and the cache can be
+// shared by every copy and version of state. A nil ReadCache reads through to
+// the trees.
+type ReadCache struct {
+	accounts *lruCache
+	storage  *lruCache
+}
+
+func NewReadCache(options ReadCacheOptions) *ReadCache {
+	return &ReadCache{
+		accounts: newLRUCache("accounts", options.Accounts),
+		storage:  newLRUCache("storage", options.Storage),
+	}
+}
+
+// Gets the account at address of accounts
+func (rc *ReadCache) account(accounts merkle.Tree, address []byte) []byte {
+	load := func() []byte {
+		_, accBytes, _ := accounts.Get(address)
+		return accBytes
+	}
+	if rc == nil || rc.accounts == nil {
+		return load()
+	}
+	return rc.accounts.getOrLoad(string(accounts.Hash())+string(address), load)
+}
+
+// Gets the value of key in storage
+func (rc *ReadCache) storageValue(storage merkle.Tree, key []byte) []byte {
+	load := func() []byte {
+		_, value, _ := storage.Get(key)
+		return value
+	}
+	if rc == nil || rc.storage == nil {
+		return load()
+	}
+	return rc.storage.getOrLoad(string(storage.Hash())+string(key), load)
+}
+
+// Gets the value of key in the storage tree with root, which load reads from
+// the tree on a miss so that the tree is only loaded when need be
+func (rc *ReadCache) storageValueAt(root, key []byte, load func() []byte) []byte {
+	if rc == nil {
+		return load()
+	}
+	return rc.storage.getOrLoad(string(root)+string(key), load)
+}
+
+// A least recently used cache of byte slices. A nil lruCache caches nothing.
+type lruCache struct {
+	name     string
+	capacity int
+	mtx      sync.Mutex
+	// Most recently used first
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(name string, capacity int) *lruCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &lruCache{
+		name:     name,
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// Values are cached whether or not they are nil, so absent keys are cached too.
+// The value returned must not be mutated.
+func (lru *lruCache) getOrLoad(key string, load func() []byte) []byte {
+	if lru == nil {
+		return load()
+	}
+	lru.mtx.Lock()
+	if element, ok := lru.elements[key]; ok {
+		lru.order.MoveToFront(element)
+		lru.mtx.Unlock()
+		stateCacheLookups.Inc(lru.name, "hit")
+		return element.Value.(*lruEntry).value
+	}
+	lru.mtx.Unlock()
+	stateCacheLookups.Inc(lru.name, "miss")
+	// Loaded outside the lock so reads of the trees are not serialised
+	value := load()
+	lru.mtx.Lock()
+	defer lru.mtx.Unlock()
+	if element, ok := lru.elements[key]; ok {
+		lru.order.MoveToFront(element)
+		return value
+	}
+	lru.elements[key] = lru.order.PushFront(&lruEntry{key: key, value: value})
+	if lru.order.Len() > lru.capacity {
+		oldest := lru.order.Back()
+		lru.order.Remove(oldest)
+		delete(lru.elements, oldest.Value.(*lruEntry).key)
+	}
+	return value
+}
+
+// The number of entries cached
+func (lru *lruCache) len() int {
+	if lru == nil {
+		return 0
+	}
+	lru.mtx.Lock()
+	defer lru.mtx.Unlock()
+	return lru.order.Len()
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	tdb "github.com/tendermint/go-db"
+)
+
+func TestLRUCache(t *testing.T) {
+	lru := newLRUCache("test", 2)
+	loads := 0
+	load := func(value string) func() []byte {
+		return func() []byte {
+			loads++
+			return []byte(value)
+		}
+	}
+	assert.Equal(t, []byte("a"), lru.getOrLoad("a", load("a")))
+	assert.Equal(t, []byte("b"), lru.getOrLoad("b", load("b")))
+	assert.Equal(t, []byte("a"), lru.getOrLoad("a", load("not a")))
+	assert.Equal(t, 2, loads)
+	// b is the least recently used
+	lru.getOrLoad("c", load("c"))
+	assert.Equal(t, 2, lru.len())
+	assert.Equal(t, []byte("a"), lru.getOrLoad("a", load("not a")))
+	assert.Equal(t, []byte("new b"), lru.getOrLoad("b", load("new b")))
+	assert.Equal(t, 4, loads)
+
+	// Nothing is cached by a nil cache
+	var disabled *lruCache
+	assert.Equal(t, []byte("a"), disabled.getOrLoad("a", load("a")))
+	assert.Equal(t, 0, disabled.len())
+}
+
+func TestReadCache(t *testing.T) {
+	genDoc, privAccounts, _ := RandGenesisDoc(2, true, 1000, 1, true, 1000)
+	st := MakeGenesisState(tdb.NewMemDB(), genDoc)
+	st.Save()
+	st.SetReadCache(NewReadCache(ReadCacheOptions{Accounts: 10, Storage: 10}))
+	address := privAccounts[0].Address
+	hits := func(cache string) float64 {
+		return stateCacheLookups.Value(cache, "hit")
+	}
+
+	accountHits := hits("accounts")
+	balance := st.GetAccount(address).Balance
+	// Accounts read are copies, so the cached account is unchanged
+	st.GetAccount(address).Balance = 0
+	assert.Equal(t, balance, st.GetAccount(address).Balance)
+	assert.Equal(t, accountHits+2, hits("accounts"))
+	// Copies of state share its cache
+	assert.Equal(t, balance, st.Copy().GetAccount(address).Balance)
+	assert.Equal(t, accountHits+3, hits("accounts"))
+
+	cache := NewBlockCache(st)
+	// Read through the cache
+	account := cache.GetAccount(address)
+	account.Balance = balance + 1
+	cache.UpdateAccount(account)
+	cache.SetStorage(LeftPadWord256(address), Int64ToWord256(1), Int64ToWord256(2))
+	cache.Sync()
+	st.Save()
+	// The accounts tree has a new root, whose accounts have not been cached
+	assert.Equal(t, balance+1, st.GetAccount(address).Balance)
+	assert.Equal(t, accountHits+4, hits("accounts"))
+
+	storageRoot := st.GetAccount(address).StorageRoot
+	storageHits := hits("storage")
+	assert.Equal(t, Int64ToWord256(2).Bytes(), st.GetStorage(storageRoot,
+		Int64ToWord256(1).Bytes()))
+	assert.Equal(t, Int64ToWord256(2), NewBlockCache(st).GetStorage(
+		LeftPadWord256(address), Int64ToWord256(1)))
+	assert.Nil(t, st.GetStorage(storageRoot, Int64ToWord256(3).Bytes()))
+	assert.Equal(t, storageHits+1, hits("storage"))
+}
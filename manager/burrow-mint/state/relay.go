@@ -0,0 +1,308 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/lite"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The relays tree holds the chains relayed, the headers relayed of each and
+// the storage proved against each header, under keys with these prefixes.
+// Chains sort before headers and storage, so they can be listed by iterating
+// from the start of the tree.
+const (
+	relayedChainPrefix   = byte('c')
+	relayedHeaderPrefix  = byte('h')
+	relayedStoragePrefix = byte('s')
+)
+
+func relayedChainKey(chainID string) []byte {
+	return append([]byte{relayedChainPrefix}, chainID...)
+}
+
+// The chain ID is length prefixed so that the keys of one chain are not a
+// prefix of those of another
+func relayedHeaderKey(chainID string, height int) []byte {
+	key := append([]byte{relayedHeaderPrefix}, wire.BinaryBytes(chainID)...)
+	return appendHeight(key, height)
+}
+
+func relayedStorageKey(chainID string, height int, address []byte,
+	key Word256) []byte {
+	storageKey := append([]byte{relayedStoragePrefix}, wire.BinaryBytes(chainID)...)
+	storageKey = appendHeight(storageKey, height)
+	storageKey = append(storageKey, LeftPadBytes(address, 20)...)
+	return append(storageKey, key.Bytes()...)
+}
+
+func appendHeight(key []byte, height int) []byte {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(height))
+	return append(key, heightBytes...)
+}
+
+//-------------------------------------
+// State.relays
+
+func (s *State) getRelayEntry(key []byte) []byte {
+	_, value, _ := s.relays.Get(key)
+	return value
+}
+
+func (s *State) setRelayEntry(key, value []byte) bool {
+	return s.relays.Set(key, value)
+}
+
+// Get the chain relayed with chainID, nil if none has been
+func (s *State) GetRelayedChain(chainID string) *core_types.RelayedChain {
+	return decodeRelayedChain(s.getRelayEntry(relayedChainKey(chainID)))
+}
+
+func (s *State) UpdateRelayedChain(chain *core_types.RelayedChain) bool {
+	return s.setRelayEntry(relayedChainKey(chain.ChainID), wire.BinaryBytes(chain))
+}
+
+// Get the chains relayed in order of chain ID
+func (s *State) GetRelayedChains() []*core_types.RelayedChain {
+	var chains []*core_types.RelayedChain
+	s.relays.Iterate(func(key, value []byte) bool {
+		if key[0] != relayedChainPrefix {
+			return true
+		}
+		chains = append(chains, decodeRelayedChain(value))
+		return false
+	})
+	return chains
+}
+
+// Get the header of chainID relayed at height, nil if none has been
+func (s *State) GetRelayedHeader(chainID string, height int) *core_types.RelayedHeader {
+	return decodeRelayedHeader(s.getRelayEntry(relayedHeaderKey(chainID, height)))
+}
+
+func (s *State) UpdateRelayedHeader(header *core_types.RelayedHeader) bool {
+	return s.setRelayEntry(relayedHeaderKey(header.ChainID, header.Height),
+		wire.BinaryBytes(header))
+}
+
+// Get the storage of address at key on chainID proved against the header at
+// height, and whether it has been proved
+func (s *State) GetRelayedStorage(chainID string, height int, address []byte,
+	key Word256) (Word256, bool) {
+	value := s.getRelayEntry(relayedStorageKey(chainID, height, address, key))
+	return LeftPadWord256(value), value != nil
+}
+
+func (s *State) SetRelayedStorage(chainID string, height int, address []byte,
+	key, value Word256) bool {
+	return s.setRelayEntry(relayedStorageKey(chainID, height, address, key),
+		value.Bytes())
+}
+
+func decodeRelayedChain(chainBytes []byte) *core_types.RelayedChain {
+	if chainBytes == nil {
+		return nil
+	}
+	chain := new(core_types.RelayedChain)
+	readBinary(chainBytes, chain)
+	return chain
+}
+
+func decodeRelayedHeader(headerBytes []byte) *core_types.RelayedHeader {
+	if headerBytes == nil {
+		return nil
+	}
+	header := new(core_types.RelayedHeader)
+	readBinary(headerBytes, header)
+	return header
+}
+
+// State.relays
+//-------------------------------------
+
+// Verifies the header of the other chain relayed by tx against the commit of
+// the validators trusted for that chain and records it, with the storage
+// proved against its AppHash. A header already relayed may be relayed again to
+// prove more storage against it, without its commit.
+func execRelayTx(blockCache *BlockCache, tx *txs.RelayTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	inAcc := blockCache.GetAccount(tx.Input.Address)
+	if inAcc == nil {
+		logging.InfoMsg(logger, "Cannot find input account",
+			"tx_input", tx.Input)
+		return txs.ErrTxInvalidAddress
+	}
+	// pubKey should be present in either "inAcc" or "tx.Input"
+	if err := checkInputPubKey(inAcc, tx.Input); err != nil {
+		logging.InfoMsg(logger, "Cannot find public key for input account",
+			"tx_input", tx.Input)
+		return err
+	}
+	signBytes := acm.SignBytes(_s.ChainID, tx)
+	if err := validateInput(inAcc, signBytes, tx.Input); err != nil {
+		logging.InfoMsg(logger, "validateInput failed",
+			"tx_input", tx.Input, "error", err)
+		return err
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if tx.ChainID == _s.ChainID {
+		return fmt.Errorf("Chain %s cannot relay its own headers", tx.ChainID)
+	}
+
+	header := tx.Header
+	chain := blockCache.GetRelayedChain(tx.ChainID)
+	relayed := blockCache.GetRelayedHeader(tx.ChainID, header.Height)
+	if relayed != nil {
+		if !bytes.Equal(relayed.BlockHash, tx.BlockID.Hash) {
+			return fmt.Errorf("Header of chain %s at height %v was relayed with "+
+				"block hash %X", tx.ChainID, header.Height, relayed.BlockHash)
+		}
+	} else {
+		var err error
+		if chain, err = verifyRelayedCommit(blockCache, inAcc, chain, tx, logger); err != nil {
+			return err
+		}
+		relayed = &core_types.RelayedHeader{
+			ChainID:        tx.ChainID,
+			Height:         header.Height,
+			Time:           header.Time,
+			BlockHash:      tx.BlockID.Hash,
+			AppHash:        header.AppHash,
+			ValidatorsHash: header.ValidatorsHash,
+			RelayedAt:      _s.LastBlockHeight + 1,
+		}
+	}
+	for _, item := range tx.Storage {
+		if !item.Verify(relayed.AppHash) {
+			return fmt.Errorf("Storage of %X at key %X is not proved against the "+
+				"AppHash %X of chain %s at height %v", item.Account.Address,
+				item.StorageItem.Key, relayed.AppHash, tx.ChainID, relayed.Height)
+		}
+	}
+
+	// Good!
+	logging.TraceMsg(logger, "Relaying header",
+		"chain_id", tx.ChainID,
+		"height", header.Height,
+		"block_hash", tx.BlockID.Hash,
+		"storage_items", len(tx.Storage))
+	blockCache.UpdateRelayedChain(chain)
+	blockCache.UpdateRelayedHeader(relayed)
+	for _, item := range tx.Storage {
+		blockCache.SetRelayedStorage(tx.ChainID, relayed.Height,
+			item.Account.Address, LeftPadWord256(item.StorageItem.Key),
+			LeftPadWord256(item.StorageItem.Value))
+	}
+	// The input amount is burnt as the fee
+	inAcc.Sequence += 1
+	inAcc.Balance -= tx.Input.Amount
+	blockCache.UpdateAccount(inAcc)
+	payFee(blockCache, tx.Input.Amount, 0)
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccInput(tx.Input.Address), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringRelay(tx.ChainID), txs.EventDataTx{tx, nil, ""})
+	}
+	return nil
+}
+
+// Checks that the commit of tx is signed by the validators trusted for the
+// relayed chain, which is nil if it has not been relayed before, returning the
+// chain with the header of tx as its latest. The first header of a chain is
+// relayed by an account with the root permission and its validators are
+// trusted as given. When the validators change at a later header, the new
+// validators are trusted only if the previously trusted validators hand over
+// to them.
+func verifyRelayedCommit(blockCache *BlockCache, inAcc *acm.Account,
+	chain *core_types.RelayedChain, tx *txs.RelayTx,
+	logger logging_types.InfoTraceLogger) (*core_types.RelayedChain, error) {
+	header := tx.Header
+	if tx.Commit == nil {
+		return nil, fmt.Errorf("Header of chain %s at height %v is relayed "+
+			"without its commit", tx.ChainID, header.Height)
+	}
+	if chain != nil {
+		// The chain is only changed in the cache once the tx has succeeded
+		chainCopy := *chain
+		chain = &chainCopy
+	}
+	var validators *tm_types.ValidatorSet
+	if chain == nil {
+		if !HasPermission(blockCache, inAcc, ptypes.Root, logger) {
+			return nil, fmt.Errorf("Account %X does not have Root permission to "+
+				"relay the new chain %s", inAcc.Address, tx.ChainID)
+		}
+		validators = tm_types.NewValidatorSet(tx.Validators)
+		if len(tx.Validators) == 0 ||
+			!bytes.Equal(validators.Hash(), header.ValidatorsHash) {
+			return nil, fmt.Errorf("The first header of chain %s must be relayed "+
+				"with the validators it names", tx.ChainID)
+		}
+		chain = &core_types.RelayedChain{
+			ChainID:      tx.ChainID,
+			Validators:   tx.Validators,
+			Registrar:    inAcc.Address,
+			RegisteredAt: blockCache.State().LastBlockHeight + 1,
+		}
+	} else {
+		if header.Height <= chain.Height {
+			return nil, fmt.Errorf("Header of chain %s at height %v is not above "+
+				"the latest relayed at height %v", tx.ChainID, header.Height,
+				chain.Height)
+		}
+		trusted := tm_types.NewValidatorSet(chain.Validators)
+		validators = trusted
+		if !bytes.Equal(header.ValidatorsHash, trusted.Hash()) {
+			validators = tm_types.NewValidatorSet(tx.Validators)
+			if len(tx.Validators) == 0 ||
+				!bytes.Equal(validators.Hash(), header.ValidatorsHash) {
+				return nil, fmt.Errorf("Validators of chain %s changed at height %v "+
+					"but the validators it names were not relayed", tx.ChainID,
+					header.Height)
+			}
+			err := lite.VerifyHandover(tx.ChainID, trusted, tx.BlockID,
+				header.Height, tx.Commit)
+			if err != nil {
+				return nil, err
+			}
+			chain.Validators = tx.Validators
+		}
+	}
+	err := validators.VerifyCommit(tx.ChainID, tx.BlockID, header.Height, tx.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("Could not verify commit of chain %s at height "+
+			"%v: %v", tx.ChainID, header.Height, err)
+	}
+	chain.Height = header.Height
+	return chain, nil
+}
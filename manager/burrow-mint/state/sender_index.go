@@ -0,0 +1,164 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/txs"
+
+	dbm "github.com/tendermint/go-db"
+	wire "github.com/tendermint/go-wire"
+)
+
+const senderIndexPrefix = "senderindex/"
+
+// SenderIndex records the txs of committed blocks against the address of each
+// of their inputs so that the txs an account has signed can be listed without
+// replaying the chain. The txs of an address are numbered in the order they
+// were committed and stored one per key, with the count kept alongside, so
+// that adding a tx and reading the most recent ones touch only the keys
+// involved.
+type SenderIndex struct {
+	mtx     sync.Mutex
+	db      dbm.DB
+	pending []senderTx
+}
+
+type senderTx struct {
+	address []byte
+	tx      *core_types.SenderTx
+}
+
+func NewSenderIndex(db dbm.DB) *SenderIndex {
+	return &SenderIndex{db: db}
+}
+
+// Adds tx, executed as the index-th tx of the block at height, to be indexed
+// against each of its inputs on the next call to Commit
+func (si *SenderIndex) Add(chainID string, tx txs.Tx, height, index int) {
+	inputs := txInputs(chainID, tx)
+	if len(inputs) == 0 {
+		return
+	}
+	txHash := txs.TxHash(chainID, tx)
+	si.mtx.Lock()
+	defer si.mtx.Unlock()
+	for _, input := range inputs {
+		si.pending = append(si.pending, senderTx{
+			address: input.Address,
+			tx: &core_types.SenderTx{
+				TxHash:   txHash,
+				Height:   height,
+				Index:    index,
+				Sequence: input.Sequence,
+			},
+		})
+	}
+}
+
+// Writes the txs added since the last commit to the index
+func (si *SenderIndex) Commit() error {
+	si.mtx.Lock()
+	defer si.mtx.Unlock()
+	for _, pending := range si.pending {
+		count, err := si.count(pending.address)
+		if err != nil {
+			return err
+		}
+		si.db.Set(senderTxKey(pending.address, count), wire.BinaryBytes(pending.tx))
+		si.db.Set(senderCountKey(pending.address), wire.BinaryBytes(count+1))
+	}
+	si.pending = nil
+	return nil
+}
+
+// Returns up to limit of the most recent txs with an input from address,
+// newest first
+func (si *SenderIndex) SenderTxs(address []byte, limit int) ([]*core_types.SenderTx, error) {
+	si.mtx.Lock()
+	defer si.mtx.Unlock()
+	count, err := si.count(address)
+	if err != nil {
+		return nil, err
+	}
+	senderTxs := []*core_types.SenderTx{}
+	for n := count - 1; n >= 0 && len(senderTxs) < limit; n-- {
+		senderTx := new(core_types.SenderTx)
+		if err := readBinary(si.db.Get(senderTxKey(address, n)), senderTx); err != nil {
+			return nil, fmt.Errorf("Could not read tx %v of %X from sender "+
+				"index: %v", n, address, err)
+		}
+		senderTxs = append(senderTxs, senderTx)
+	}
+	return senderTxs, nil
+}
+
+// The number of txs indexed against address
+func (si *SenderIndex) count(address []byte) (int64, error) {
+	var count int64
+	if err := readBinary(si.db.Get(senderCountKey(address)), &count); err != nil {
+		return 0, fmt.Errorf("Could not read count of txs of %X from sender "+
+			"index: %v", address, err)
+	}
+	return count, nil
+}
+
+// The inputs of tx, through which it is signed by their accounts
+func txInputs(chainID string, tx txs.Tx) []*txs.TxInput {
+	switch tx := tx.(type) {
+	case *txs.SendTx:
+		return tx.Inputs
+	case *txs.CallTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.NameTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.ABITx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.RelayTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.PrivateTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.BondTx:
+		return tx.Inputs
+	case *txs.PermissionsTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.ProposalTx:
+		return []*txs.TxInput{tx.Input}
+	case *txs.EthTx:
+		callTx, err := tx.CallTx(chainID)
+		if err != nil {
+			return nil
+		}
+		return []*txs.TxInput{callTx.Input}
+	case *txs.MultisigTx:
+		return txInputs(chainID, tx.Tx)
+	case *txs.PriorityFeeTx:
+		return txInputs(chainID, tx.Tx)
+	case *txs.ExpiringTx:
+		return txInputs(chainID, tx.Tx)
+	}
+	return nil
+}
+
+func senderCountKey(address []byte) []byte {
+	return []byte(fmt.Sprintf("%s%X/count", senderIndexPrefix, address))
+}
+
+func senderTxKey(address []byte, n int64) []byte {
+	return []byte(fmt.Sprintf("%s%X/txs/%016X", senderIndexPrefix, address, n))
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/stretchr/testify/assert"
+	dbm "github.com/tendermint/go-db"
+)
+
+func TestSenderIndex(t *testing.T) {
+	chainID := "test_chain"
+	addressA := []byte("addressA____________")
+	addressB := []byte("addressB____________")
+	sendTx := &txs.SendTx{
+		Inputs: []*txs.TxInput{
+			{Address: addressA, Amount: 1, Sequence: 1},
+			{Address: addressB, Amount: 1, Sequence: 4},
+		},
+		Outputs: []*txs.TxOutput{{Address: addressB, Amount: 2}},
+	}
+	callTx := &txs.CallTx{
+		Input:    &txs.TxInput{Address: addressA, Amount: 1, Sequence: 2},
+		Address:  addressB,
+		GasLimit: 10,
+	}
+	db := dbm.NewMemDB()
+	senderIndex := NewSenderIndex(db)
+	senderIndex.Add(chainID, sendTx, 1, 0)
+	senderIndex.Add(chainID, callTx, 2, 3)
+
+	// Nothing is indexed until committed
+	found, err := senderIndex.SenderTxs(addressA, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 0)
+	assert.NoError(t, senderIndex.Commit())
+
+	sendTxOfA := &core_types.SenderTx{TxHash: txs.TxHash(chainID, sendTx),
+		Height: 1, Index: 0, Sequence: 1}
+	callTxOfA := &core_types.SenderTx{TxHash: txs.TxHash(chainID, callTx),
+		Height: 2, Index: 3, Sequence: 2}
+	found, err = senderIndex.SenderTxs(addressA, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*core_types.SenderTx{callTxOfA, sendTxOfA}, found)
+
+	found, err = senderIndex.SenderTxs(addressA, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*core_types.SenderTx{callTxOfA}, found)
+
+	// The index persists in the db
+	found, err = NewSenderIndex(db).SenderTxs(addressB, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*core_types.SenderTx{{TxHash: sendTxOfA.TxHash,
+		Height: 1, Index: 0, Sequence: 4}}, found)
+
+	found, err = senderIndex.SenderTxs([]byte("addressC____________"), 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 0)
+}
@@ -0,0 +1,399 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	acm "github.com/hyperledger/burrow/account"
+	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/txs"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-events"
+	"github.com/tendermint/go-wire"
+)
+
+// The reasons a validator is slashed and jailed
+const (
+	JailReasonDoubleSign = "double_sign"
+	JailReasonDowntime   = "downtime"
+)
+
+// The record of the last time the validator with PubKey was jailed for
+// misbehaving at OffenceHeight. While Jailed it is out of the validator set
+// and cannot bond until ReleaseHeight, after which bonding gives it back
+// Power, what it had less what it was slashed, on top of the coins bonded.
+// The record is kept once it leaves jail, with the height of the last
+// double-signing punished, so the same offence cannot be punished twice.
+type ValidatorJail struct {
+	PubKey           crypto.PubKeyEd25519 `json:"pub_key"`
+	Reason           string               `json:"reason"`
+	OffenceHeight    int                  `json:"offence_height"`
+	ReleaseHeight    int                  `json:"release_height"`
+	Power            int64                `json:"power"`
+	Jailed           bool                 `json:"jailed"`
+	DoubleSignHeight int                  `json:"double_sign_height"`
+}
+
+// The blocks the validator with Address has missed signing in the downtime
+// window starting at WindowStart. The count starts again with each window.
+type MissedBlocks struct {
+	Address     []byte `json:"address"`
+	WindowStart int    `json:"window_start"`
+	Missed      int    `json:"missed"`
+}
+
+func DecodeMissedBlocks(missedBytes []byte) *MissedBlocks {
+	missed := new(MissedBlocks)
+	readBinary(missedBytes, missed)
+	return missed
+}
+
+func DecodeValidatorJail(jailBytes []byte) *ValidatorJail {
+	jail := new(ValidatorJail)
+	readBinary(jailBytes, jail)
+	return jail
+}
+
+//-------------------------------------
+// State.jails
+
+func (s *State) GetValidatorJail(address []byte) *ValidatorJail {
+	_, valueBytes, _ := s.jails.Get(address)
+	if valueBytes == nil {
+		return nil
+	}
+	return DecodeValidatorJail(valueBytes)
+}
+
+func (s *State) UpdateValidatorJail(jail *ValidatorJail) bool {
+	return s.jails.Set(jail.PubKey.Address(), wire.BinaryBytes(jail))
+}
+
+// State.jails
+//-------------------------------------
+// State.missedBlocks
+
+func (s *State) GetMissedBlocks(address []byte) *MissedBlocks {
+	_, valueBytes, _ := s.missedBlocks.Get(address)
+	if valueBytes == nil {
+		return nil
+	}
+	return DecodeMissedBlocks(valueBytes)
+}
+
+func (s *State) UpdateMissedBlocks(missed *MissedBlocks) bool {
+	return s.missedBlocks.Set(missed.Address, wire.BinaryBytes(missed))
+}
+
+// State.missedBlocks
+//-------------------------------------
+
+// Gets the unbondings of the validator with address yet to be released
+func (s *State) GetUnbondings(address []byte) []*Unbonding {
+	var unbondings []*Unbonding
+	s.unbondings.Iterate(func(key, value []byte) bool {
+		unbonding := DecodeUnbonding(value)
+		if bytes.Equal(unbonding.Address, address) {
+			unbondings = append(unbondings, unbonding)
+		}
+		return false
+	})
+	return unbondings
+}
+
+// Burns percent of each of outputs, returning what is left of them and the
+// total burnt
+func slashOutputs(outputs []*txs.TxOutput, percent int) ([]*txs.TxOutput, int64) {
+	slashed := make([]*txs.TxOutput, len(outputs))
+	total := int64(0)
+	for i, out := range outputs {
+		cut := out.Amount * int64(percent) / 100
+		slashed[i] = &txs.TxOutput{Address: out.Address, Amount: out.Amount - cut}
+		total += cut
+	}
+	return slashed, total
+}
+
+// Punishes the validator that signed both votes of tx, which must conflict:
+// the coins it has bonded, and those it has unbonded but which are yet to be
+// released, are slashed, and it is removed from the validator set from the
+// block after the next and jailed. The votes must be recent enough that its
+// unbonded coins are still held.
+func execDupeoutTx(blockCache *BlockCache, tx *txs.DupeoutTx, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	genesisValidators := _s.GenesisValidators()
+	if genesisValidators == nil {
+		return fmt.Errorf("The genesis validators are not known so validators " +
+			"cannot be slashed")
+	}
+	voteA, voteB := &tx.VoteA, &tx.VoteB
+	if !bytes.Equal(voteA.ValidatorAddress, tx.Address) ||
+		!bytes.Equal(voteB.ValidatorAddress, tx.Address) {
+		return txs.ErrTxInvalidAddress
+	}
+	// Verify equivocation
+	if voteA.Height != voteB.Height {
+		return errors.New("DupeoutTx heights don't match")
+	}
+	if voteA.Round != voteB.Round {
+		return errors.New("DupeoutTx rounds don't match")
+	}
+	if voteA.Type != voteB.Type {
+		return errors.New("DupeoutTx types don't match")
+	}
+	if voteA.BlockID.Equals(voteB.BlockID) {
+		return errors.New("DupeoutTx blockhashes shouldn't match")
+	}
+	height := _s.LastBlockHeight + 1
+	if voteA.Height > height || voteA.Height+unbondingPeriodBlocks <= height {
+		return fmt.Errorf("DupeoutTx votes must be from the last %v blocks, "+
+			"not height %v", unbondingPeriodBlocks, voteA.Height)
+	}
+
+	// Verify the signatures
+	rotations := blockCache.GetValidatorRotations()
+	validators := ValidatorsAt(genesisValidators, rotations,
+		blockCache.GetValidatorPowerChanges(), voteA.Height)
+	i := findValidatorByAddress(validators, tx.Address)
+	if i < 0 {
+		return txs.ErrTxInvalidAddress
+	}
+	accused, ok := validators[i].PubKey.(crypto.PubKeyEd25519)
+	if !ok {
+		return fmt.Errorf("Validator %X does not have an ed25519 key", tx.Address)
+	}
+	if !accused.VerifyBytes(acm.SignBytes(_s.ChainID, voteA), voteA.Signature) ||
+		!accused.VerifyBytes(acm.SignBytes(_s.ChainID, voteB), voteB.Signature) {
+		return txs.ErrTxInvalidSignature
+	}
+
+	// The validator may have rotated to a new key since
+	pubKey := accused
+	for _, rotation := range rotations {
+		if rotation.Height > voteA.Height && rotation.Height <= height+1 &&
+			rotation.PubKey == pubKey {
+			pubKey = rotation.NewPubKey
+		}
+	}
+	// A validator jailed for downtime can still be punished for double-signing
+	jail := blockCache.GetValidatorJail(pubKey.Address())
+	if jail != nil && (voteA.Height <= jail.DoubleSignHeight ||
+		(jail.Jailed && jail.Reason == JailReasonDoubleSign)) {
+		return fmt.Errorf("Validator %X has already been punished for "+
+			"double-signing at height %v", pubKey.Address(), jail.OffenceHeight)
+	}
+
+	validators = nextValidators(blockCache)
+	i = findValidator(validators, pubKey)
+	if i >= 0 && len(validators) == 1 {
+		return fmt.Errorf("Validator %X cannot be jailed since it is the "+
+			"last validator", pubKey.Address())
+	}
+
+	// Good! (Bad validator!)
+	params := _s.GetSlashingParams()
+	slashed := int64(0)
+	if bond := blockCache.GetValidatorBond(tx.Address); bond != nil {
+		bondSlashed := int64(0)
+		bond.UnbondTo, bondSlashed = slashOutputs(bond.UnbondTo, params.SlashPercent)
+		bond.Amount -= bondSlashed
+		slashed += bondSlashed
+		blockCache.UpdateValidatorBond(bond)
+	}
+	power := int64(0)
+	if i >= 0 {
+		power = validators[i].VotingPower
+		blockCache.SetValidatorPowerChange(&ValidatorPowerChange{
+			Height: height + 1,
+			PubKey: pubKey,
+			Power:  0,
+		})
+	} else if jail != nil && jail.Jailed {
+		power = jail.Power
+	}
+	power -= slashed
+	if power < 0 {
+		power = 0
+	}
+	// Unbondings are replaced by adding them again
+	for _, unbonding := range blockCache.GetUnbondings(tx.Address) {
+		slashedUnbonding := *unbonding
+		unbondingSlashed := int64(0)
+		slashedUnbonding.UnbondTo, unbondingSlashed = slashOutputs(unbonding.UnbondTo,
+			params.SlashPercent)
+		slashed += unbondingSlashed
+		blockCache.AddUnbonding(&slashedUnbonding)
+	}
+	jail = &ValidatorJail{
+		PubKey:           pubKey,
+		Reason:           JailReasonDoubleSign,
+		OffenceHeight:    voteA.Height,
+		ReleaseHeight:    height + params.JailBlocks,
+		Power:            power,
+		Jailed:           true,
+		DoubleSignHeight: voteA.Height,
+	}
+	blockCache.UpdateValidatorJail(jail)
+	logging.InfoMsg(logger, "Slashed validator for double-signing",
+		"address", pubKey.Address(),
+		"offence_height", voteA.Height,
+		"slashed", slashed,
+		"release_height", jail.ReleaseHeight)
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringDupeout(), txs.EventDataTx{tx, nil, ""})
+		evc.FireEvent(txs.EventStringSlash(), txs.EventDataSlash{
+			Address:       pubKey.Address(),
+			Height:        int64(height),
+			Reason:        jail.Reason,
+			OffenceHeight: jail.OffenceHeight,
+			Slashed:       slashed,
+			ReleaseHeight: jail.ReleaseHeight,
+		})
+	}
+	return nil
+}
+
+// Counts the blocks missed by the validators at height that are not among the
+// signers of its commit, jailing those that have missed more than the
+// downtime limit of the current window from the block after the next. Jailing
+// is put off to a later block when the power removed would be over the limit
+// on changes of power.
+func RecordCommitSigners(blockCache *BlockCache, height int, signers [][]byte,
+	evc events.Fireable, logger logging_types.InfoTraceLogger) {
+	_s := blockCache.State()
+	params := _s.GetSlashingParams()
+	genesisValidators := _s.GenesisValidators()
+	if params.DowntimeWindow <= 0 || genesisValidators == nil {
+		return
+	}
+	signed := make(map[string]bool, len(signers))
+	for _, signer := range signers {
+		signed[string(signer)] = true
+	}
+	windowStart := height - height%params.DowntimeWindow
+	for _, validator := range ValidatorsAt(genesisValidators,
+		blockCache.GetValidatorRotations(), blockCache.GetValidatorPowerChanges(),
+		height) {
+		if signed[string(validator.Address)] {
+			continue
+		}
+		missed := blockCache.GetMissedBlocks(validator.Address)
+		if missed == nil || missed.WindowStart != windowStart {
+			missed = &MissedBlocks{
+				Address:     validator.Address,
+				WindowStart: windowStart,
+			}
+		}
+		missed.Missed++
+		if missed.Missed > params.MaxMissedBlocks {
+			err := jailForDowntime(blockCache, validator.Address, params, evc, logger)
+			if err != nil {
+				logging.InfoMsg(logger, "Could not jail validator for downtime",
+					"address", validator.Address,
+					"missed", missed.Missed,
+					"error", err)
+			} else {
+				missed.Missed = 0
+			}
+		}
+		blockCache.UpdateMissedBlocks(missed)
+	}
+}
+
+func jailForDowntime(blockCache *BlockCache, address []byte,
+	params genesis.SlashingParams, evc events.Fireable,
+	logger logging_types.InfoTraceLogger) error {
+	_s := blockCache.State()
+	validators := nextValidators(blockCache)
+	i := findValidatorByAddress(validators, address)
+	if i < 0 {
+		// Already on its way out of the validator set
+		return nil
+	}
+	pubKey, ok := validators[i].PubKey.(crypto.PubKeyEd25519)
+	if !ok {
+		return fmt.Errorf("Validator %X does not have an ed25519 key", address)
+	}
+	height := _s.LastBlockHeight + 1
+	change := &ValidatorPowerChange{
+		Height: height + 1,
+		PubKey: pubKey,
+		Power:  0,
+	}
+	if err := validatePowerChange(blockCache, change); err != nil {
+		return err
+	}
+	blockCache.SetValidatorPowerChange(change)
+	jail := &ValidatorJail{
+		PubKey:        pubKey,
+		Reason:        JailReasonDowntime,
+		OffenceHeight: height,
+		ReleaseHeight: height + params.DowntimeJailBlocks,
+		Power:         validators[i].VotingPower,
+		Jailed:        true,
+	}
+	if previous := blockCache.GetValidatorJail(address); previous != nil {
+		jail.DoubleSignHeight = previous.DoubleSignHeight
+	}
+	blockCache.UpdateValidatorJail(jail)
+	logging.InfoMsg(logger, "Jailed validator for downtime",
+		"address", address,
+		"release_height", jail.ReleaseHeight)
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringSlash(), txs.EventDataSlash{
+			Address:       address,
+			Height:        int64(height),
+			Reason:        jail.Reason,
+			OffenceHeight: jail.OffenceHeight,
+			ReleaseHeight: jail.ReleaseHeight,
+		})
+	}
+	return nil
+}
+
+// Releases the jailed validator with address before its release height,
+// giving it back the power it had left from the block after the next, as a
+// GovTx does
+func unjailValidator(blockCache *BlockCache, address []byte) error {
+	_s := blockCache.State()
+	jail := blockCache.GetValidatorJail(address)
+	if jail == nil || !jail.Jailed {
+		return fmt.Errorf("Validator %X is not jailed", address)
+	}
+	if jail.Power > 0 {
+		change := &ValidatorPowerChange{
+			Height: _s.LastBlockHeight + 2,
+			PubKey: jail.PubKey,
+			Power:  jail.Power,
+		}
+		if err := validatePowerChange(blockCache, change); err != nil {
+			return err
+		}
+		blockCache.SetValidatorPowerChange(change)
+	}
+	jail.Jailed = false
+	jail.Power = 0
+	blockCache.UpdateValidatorJail(jail)
+	return nil
+}
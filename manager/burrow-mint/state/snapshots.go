@@ -0,0 +1,527 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	manager_types "github.com/hyperledger/burrow/manager/types"
+	"github.com/hyperledger/burrow/txs"
+
+	dbm "github.com/tendermint/go-db"
+	"github.com/tendermint/go-merkle"
+	wire "github.com/tendermint/go-wire"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// The format of the chunks of the snapshots taken, each of which holds
+	// IAVL nodes of state as they are persisted
+	SnapshotFormat = uint32(1)
+
+	snapshotsPrefix = "snapshots/"
+	// Holds the heights of the snapshots kept
+	snapshotHeightsKey = snapshotsPrefix + "heights"
+	// A chunk is cut once it holds this many bytes of nodes
+	snapshotChunkSize = 1 << 20
+)
+
+// No snapshots are taken unless an interval is configured. The zero value of
+// SnapshotOptions disables snapshots.
+type SnapshotOptions struct {
+	// Take a snapshot of each version of state at a height that is a multiple
+	// of Interval, 0 takes none
+	Interval int
+	// How many of the most recent snapshots to keep, 0 keeps all
+	KeepRecent int
+}
+
+func (opts SnapshotOptions) Enabled() bool {
+	return opts.Interval > 0
+}
+
+// What a snapshot holds besides the nodes of its trees: the fields of the
+// state saved alongside their roots, the roots themselves and the hashes of
+// the chunks
+type snapshotMetadata struct {
+	ChainID           string                `json:"chain_id"`
+	LastBlockHeight   int                   `json:"last_block_height"`
+	LastBlockHash     []byte                `json:"last_block_hash"`
+	LastBlockParts    types.PartSetHeader   `json:"last_block_parts"`
+	LastBlockTime     time.Time             `json:"last_block_time"`
+	BaseFee           int64                 `json:"base_fee"`
+	ProposalThreshold int                   `json:"proposal_threshold"`
+	GasLimit          int64                 `json:"gas_limit"`
+	MaxTxSize         int                   `json:"max_tx_size"`
+	MaxTxGas          int64                 `json:"max_tx_gas"`
+	MaxBlockGas       int64                 `json:"max_block_gas"`
+	FeeParams         *genesis.FeeParams    `json:"fee_params"`
+	RewardParams      *genesis.RewardParams `json:"reward_params"`
+	Upgrade           *txs.UpgradePlan      `json:"upgrade"`
+	GasSchedule       *genesis.GasSchedule  `json:"gas_schedule"`
+	// The roots of the trees Hash covers, by name in order
+	Roots       []snapshotRoot `json:"roots"`
+	ChunkHashes [][]byte       `json:"chunk_hashes"`
+}
+
+type snapshotRoot struct {
+	Name string `json:"name"`
+	Root []byte `json:"root"`
+}
+
+// A chunk is a list of IAVL nodes by their hash
+type snapshotNode struct {
+	Hash []byte
+	Node []byte
+}
+
+// Snapshots takes snapshots of state at regular heights so that nodes joining
+// the chain can restore state from them rather than replaying every block,
+// and keeps the most recent of them. A snapshot copies every IAVL node of the
+// version of state it was taken of (including those of account storage) into
+// chunks stored alongside state.
+type Snapshots struct {
+	mtx     sync.Mutex
+	db      dbm.DB
+	options SnapshotOptions
+	heights []int
+	running bool
+	logger  logging_types.InfoTraceLogger
+}
+
+func NewSnapshots(db dbm.DB, options SnapshotOptions,
+	logger logging_types.InfoTraceLogger) (*Snapshots, error) {
+	snapshots := &Snapshots{
+		db:      db,
+		options: options,
+		logger:  logging.WithScope(logger, "Snapshots"),
+	}
+	if err := readBinary(db.Get([]byte(snapshotHeightsKey)), &snapshots.heights); err != nil {
+		return nil, fmt.Errorf("Could not load snapshot heights: %v", err)
+	}
+	return snapshots, nil
+}
+
+// Takes a snapshot of s, which must have been saved, in the background if one
+// is due at its height and none is being taken already. The nodes of the
+// version of s must not be pruned while the snapshot is taken, so when
+// pruning the snapshot interval should be a multiple of the heights pruning
+// keeps.
+func (ss *Snapshots) Take(s *State) {
+	if !ss.options.Enabled() || s.LastBlockHeight%ss.options.Interval != 0 {
+		return
+	}
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	if ss.running {
+		logging.InfoMsg(ss.logger, "Skipping snapshot since one is being taken",
+			"height", s.LastBlockHeight)
+		return
+	}
+	ss.running = true
+	metadata := newSnapshotMetadata(s)
+	go func() {
+		defer func() {
+			ss.mtx.Lock()
+			ss.running = false
+			ss.mtx.Unlock()
+		}()
+		snapshot, err := ss.take(metadata)
+		if err != nil {
+			logging.InfoMsg(ss.logger, "Failed to take snapshot",
+				"height", metadata.LastBlockHeight,
+				"error", err)
+			return
+		}
+		logging.InfoMsg(ss.logger, "Took snapshot",
+			"height", snapshot.Height,
+			"chunks", snapshot.Chunks)
+	}()
+}
+
+// Takes a snapshot of s, which must have been saved, whatever its height
+func (ss *Snapshots) Snapshot(s *State) (*manager_types.Snapshot, error) {
+	return ss.take(newSnapshotMetadata(s))
+}
+
+// The snapshots kept, most recent first
+func (ss *Snapshots) List() []*manager_types.Snapshot {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	var snapshots []*manager_types.Snapshot
+	for i := len(ss.heights) - 1; i >= 0; i-- {
+		snapshot := new(manager_types.Snapshot)
+		if err := readBinary(ss.db.Get(snapshotKey(ss.heights[i])), snapshot); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// Returns a chunk of the snapshot at height, or nil if it is not kept
+func (ss *Snapshots) LoadChunk(height uint64, format uint32, chunk uint32) []byte {
+	if format != SnapshotFormat {
+		return nil
+	}
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	for _, h := range ss.heights {
+		if uint64(h) == height {
+			return ss.db.Get(snapshotChunkKey(h, chunk))
+		}
+	}
+	return nil
+}
+
+func (ss *Snapshots) take(metadata *snapshotMetadata) (*manager_types.Snapshot, error) {
+	height := metadata.LastBlockHeight
+	var chunk []snapshotNode
+	chunkSize := 0
+	writeChunk := func() {
+		chunkBytes := wire.BinaryBytes(chunk)
+		chunkHash := sha256.Sum256(chunkBytes)
+		ss.db.Set(snapshotChunkKey(height, uint32(len(metadata.ChunkHashes))), chunkBytes)
+		metadata.ChunkHashes = append(metadata.ChunkHashes, chunkHash[:])
+		chunk = nil
+		chunkSize = 0
+	}
+	seen := make(map[string]bool)
+	for _, root := range metadata.Roots {
+		err := walkNodes(ss.db, root.Root, root.Name == accountsTreeName, seen,
+			func(hash, nodeBytes []byte) {
+				chunk = append(chunk, snapshotNode{Hash: hash, Node: nodeBytes})
+				chunkSize += len(hash) + len(nodeBytes)
+				if chunkSize >= snapshotChunkSize {
+					writeChunk()
+				}
+			})
+		if err != nil {
+			return nil, fmt.Errorf("Could not take snapshot at height %v: %v",
+				height, err)
+		}
+	}
+	// Every snapshot has a chunk, even if it is empty, so it can be finished
+	if len(chunk) > 0 || len(metadata.ChunkHashes) == 0 {
+		writeChunk()
+	}
+	metadataBytes := wire.JSONBytes(metadata)
+	hash := sha256.Sum256(metadataBytes)
+	snapshot := &manager_types.Snapshot{
+		Height:   uint64(height),
+		Format:   SnapshotFormat,
+		Chunks:   uint32(len(metadata.ChunkHashes)),
+		Hash:     hash[:],
+		Metadata: metadataBytes,
+	}
+
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	ss.db.Set(snapshotKey(height), wire.BinaryBytes(snapshot))
+	heights := []int{}
+	for _, h := range ss.heights {
+		if h != height {
+			heights = append(heights, h)
+		}
+	}
+	heights = append(heights, height)
+	sort.Ints(heights)
+	for ss.options.KeepRecent > 0 && len(heights) > ss.options.KeepRecent {
+		ss.delete(heights[0])
+		heights = heights[1:]
+	}
+	ss.heights = heights
+	ss.db.Set([]byte(snapshotHeightsKey), wire.BinaryBytes(ss.heights))
+	return snapshot, nil
+}
+
+func (ss *Snapshots) delete(height int) {
+	snapshot := new(manager_types.Snapshot)
+	if readBinary(ss.db.Get(snapshotKey(height)), snapshot) == nil {
+		for i := uint32(0); i < snapshot.Chunks; i++ {
+			ss.db.Delete(snapshotChunkKey(height, i))
+		}
+	}
+	ss.db.Delete(snapshotKey(height))
+}
+
+// A SnapshotRestorer restores state from the chunks of a snapshot taken by
+// another node. Each node of a chunk is checked against its hash as it is
+// applied, and once every chunk is applied the trees of the snapshot are
+// checked to be complete and to have the trusted app hash. The fields of
+// state saved alongside the roots of its trees are not covered by the app
+// hash so they are trusted to be those of the snapshot.
+type SnapshotRestorer struct {
+	state     *State
+	snapshot  *manager_types.Snapshot
+	metadata  *snapshotMetadata
+	appHash   []byte
+	applied   []bool
+	remaining int
+}
+
+// Prepares to restore the snapshot onto a copy of s, a state of the same
+// chain, checking that the trees of the snapshot have appHash
+func NewSnapshotRestorer(s *State, snapshot *manager_types.Snapshot,
+	appHash []byte) (*SnapshotRestorer, error) {
+	if snapshot.Format != SnapshotFormat {
+		return nil, fmt.Errorf("Snapshot format %v is not %v", snapshot.Format,
+			SnapshotFormat)
+	}
+	hash := sha256.Sum256(snapshot.Metadata)
+	if !bytes.Equal(hash[:], snapshot.Hash) {
+		return nil, fmt.Errorf("Snapshot hash %X is not that of its metadata",
+			snapshot.Hash)
+	}
+	metadata := new(snapshotMetadata)
+	errR := new(error)
+	wire.ReadJSONPtr(metadata, snapshot.Metadata, errR)
+	if *errR != nil {
+		return nil, fmt.Errorf("Could not read snapshot metadata: %v", *errR)
+	}
+	if metadata.ChainID != s.ChainID {
+		return nil, fmt.Errorf("Snapshot is of chain %s rather than %s",
+			metadata.ChainID, s.ChainID)
+	}
+	if uint64(metadata.LastBlockHeight) != snapshot.Height {
+		return nil, fmt.Errorf("Snapshot at height %v holds state at height %v",
+			snapshot.Height, metadata.LastBlockHeight)
+	}
+	if snapshot.Chunks == 0 || int(snapshot.Chunks) != len(metadata.ChunkHashes) {
+		return nil, fmt.Errorf("Snapshot has %v chunks but the hashes of %v",
+			snapshot.Chunks, len(metadata.ChunkHashes))
+	}
+	if !bytes.Equal(metadata.appHash(), appHash) {
+		return nil, fmt.Errorf("Snapshot app hash %X is not %X",
+			metadata.appHash(), appHash)
+	}
+	return &SnapshotRestorer{
+		state:     s.Copy(),
+		snapshot:  snapshot,
+		metadata:  metadata,
+		appHash:   appHash,
+		applied:   make([]bool, snapshot.Chunks),
+		remaining: int(snapshot.Chunks),
+	}, nil
+}
+
+// Writes the nodes of the chunk at index to the database of state, returning
+// an error if the chunk is not the snapshot's
+func (sr *SnapshotRestorer) Apply(index uint32, chunk []byte) error {
+	if index >= sr.snapshot.Chunks {
+		return fmt.Errorf("Snapshot has no chunk %v", index)
+	}
+	hash := sha256.Sum256(chunk)
+	if !bytes.Equal(hash[:], sr.metadata.ChunkHashes[index]) {
+		return fmt.Errorf("Chunk %v does not have its hash %X", index,
+			sr.metadata.ChunkHashes[index])
+	}
+	var nodes []snapshotNode
+	if err := readBinary(chunk, &nodes); err != nil {
+		return fmt.Errorf("Could not read chunk %v: %v", index, err)
+	}
+	for _, node := range nodes {
+		iavlNode, err := readIAVLNode(node.Node)
+		if err != nil {
+			return fmt.Errorf("Could not decode node %X of chunk %v: %v", node.Hash,
+				index, err)
+		}
+		if !bytes.Equal(iavlNode.hash(), node.Hash) {
+			return fmt.Errorf("Node %X of chunk %v does not have its hash",
+				node.Hash, index)
+		}
+	}
+	for _, node := range nodes {
+		sr.state.DB.Set(node.Hash, node.Node)
+	}
+	if !sr.applied[index] {
+		sr.applied[index] = true
+		sr.remaining--
+	}
+	return nil
+}
+
+// Whether every chunk has been applied
+func (sr *SnapshotRestorer) Complete() bool {
+	return sr.remaining == 0
+}
+
+// Returns the restored state once every chunk has been applied, after
+// checking it has every node of its trees and the trusted app hash. The
+// caller is responsible for saving the returned state.
+func (sr *SnapshotRestorer) Finish() (*State, error) {
+	if !sr.Complete() {
+		return nil, fmt.Errorf("%v chunks of the snapshot have not been applied",
+			sr.remaining)
+	}
+	seen := make(map[string]bool)
+	for _, root := range sr.metadata.Roots {
+		err := walkNodes(sr.state.DB, root.Root, root.Name == accountsTreeName, seen,
+			func(hash, nodeBytes []byte) {})
+		if err != nil {
+			return nil, fmt.Errorf("Snapshot is incomplete: %v", err)
+		}
+	}
+	metadata := sr.metadata
+	s := sr.state
+	s.LastBlockHeight = metadata.LastBlockHeight
+	s.LastBlockHash = metadata.LastBlockHash
+	s.LastBlockParts = metadata.LastBlockParts
+	s.LastBlockTime = metadata.LastBlockTime
+	s.BaseFee = metadata.BaseFee
+	s.ProposalThreshold = metadata.ProposalThreshold
+	s.GasLimit = metadata.GasLimit
+	s.MaxTxSize = metadata.MaxTxSize
+	s.MaxTxGas = metadata.MaxTxGas
+	s.MaxBlockGas = metadata.MaxBlockGas
+	s.FeeParams = metadata.FeeParams
+	s.RewardParams = metadata.RewardParams
+	s.Upgrade = metadata.Upgrade
+	if err := s.SetGasSchedule(metadata.GasSchedule); err != nil {
+		return nil, err
+	}
+	roots := make(map[string][]byte)
+	for _, root := range metadata.Roots {
+		roots[root.Name] = root.Root
+	}
+	for name, tree := range s.namedTrees() {
+		tree.Load(roots[name])
+	}
+	if !bytes.Equal(s.Hash(), sr.appHash) {
+		return nil, fmt.Errorf("Restored state has app hash %X rather than %X",
+			s.Hash(), sr.appHash)
+	}
+	return s, nil
+}
+
+func newSnapshotMetadata(s *State) *snapshotMetadata {
+	metadata := &snapshotMetadata{
+		ChainID:           s.ChainID,
+		LastBlockHeight:   s.LastBlockHeight,
+		LastBlockHash:     s.LastBlockHash,
+		LastBlockParts:    s.LastBlockParts,
+		LastBlockTime:     s.LastBlockTime,
+		BaseFee:           s.BaseFee,
+		ProposalThreshold: s.ProposalThreshold,
+		GasLimit:          s.GasLimit,
+		MaxTxSize:         s.MaxTxSize,
+		MaxTxGas:          s.MaxTxGas,
+		MaxBlockGas:       s.MaxBlockGas,
+		FeeParams:         s.FeeParams,
+		RewardParams:      s.RewardParams,
+		Upgrade:           s.Upgrade,
+		GasSchedule:       s.GasSchedule,
+	}
+	trees := s.hashedTrees()
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metadata.Roots = append(metadata.Roots, snapshotRoot{
+			Name: name,
+			Root: trees[name].(merkle.Tree).Hash(),
+		})
+	}
+	return metadata
+}
+
+// The hash of state with the roots of the snapshot's trees, as Hash
+func (metadata *snapshotMetadata) appHash() []byte {
+	trees := make(map[string]interface{})
+	for _, root := range metadata.Roots {
+		trees[root.Name] = rootHash(root.Root)
+	}
+	return merkle.SimpleHashFromMap(trees)
+}
+
+// Hashes as a tree with the root
+type rootHash []byte
+
+func (root rootHash) Hash() []byte {
+	return root
+}
+
+// Every tree of state by the name it is hashed with, whether or not Hash
+// covers it
+func (s *State) namedTrees() map[string]merkle.Tree {
+	return map[string]merkle.Tree{
+		accountsTreeName:           s.accounts,
+		nameRegTreeName:            s.nameReg,
+		abiRegistryTreeName:        s.abiRegistry,
+		validatorRotationsTreeName: s.validatorRotations,
+		proposalsTreeName:          s.proposals,
+		validatorPowersTreeName:    s.validatorPowers,
+		bondsTreeName:              s.bonds,
+		unbondingsTreeName:         s.unbondings,
+		jailsTreeName:              s.jails,
+		missedBlocksTreeName:       s.missedBlocks,
+		nodeRegistryTreeName:       s.nodeRegistry,
+		relaysTreeName:             s.relays,
+		bridgeTreeName:             s.bridge,
+		privateTxsTreeName:         s.privateTxs,
+		confidentialTreeName:       s.confidential,
+	}
+}
+
+// Calls visit with the hash and persisted form of each node under root that
+// is not in seen, parents before their children, adding them to seen. When
+// accounts is set the nodes of the storage of each account follow its leaf.
+// It is an error for a node to be missing.
+func walkNodes(db dbm.DB, root []byte, accounts bool, seen map[string]bool,
+	visit func(hash, nodeBytes []byte)) error {
+	if len(root) == 0 || seen[string(root)] {
+		return nil
+	}
+	nodeBytes := db.Get(root)
+	if len(nodeBytes) == 0 {
+		return fmt.Errorf("Node %X is missing", root)
+	}
+	node, err := readIAVLNode(nodeBytes)
+	if err != nil {
+		return fmt.Errorf("Could not decode node %X: %v", root, err)
+	}
+	seen[string(root)] = true
+	visit(root, nodeBytes)
+	if node.height == 0 {
+		if accounts {
+			account := acm.DecodeAccount(node.value)
+			return walkNodes(db, account.StorageRoot, false, seen, visit)
+		}
+		return nil
+	}
+	if err := walkNodes(db, node.leftHash, accounts, seen, visit); err != nil {
+		return err
+	}
+	return walkNodes(db, node.rightHash, accounts, seen, visit)
+}
+
+func snapshotKey(height int) []byte {
+	return []byte(fmt.Sprintf("%s%016X", snapshotsPrefix, height))
+}
+
+func snapshotChunkKey(height int, chunk uint32) []byte {
+	return []byte(fmt.Sprintf("%s%016X/%08X", snapshotsPrefix, height, chunk))
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/logging/loggers"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tdb "github.com/tendermint/go-db"
+)
+
+func TestSnapshots(t *testing.T) {
+	genDoc, privAccounts, _ := RandGenesisDoc(2, true, 1000, 1, true, 1000)
+	st := MakeGenesisState(tdb.NewMemDB(), genDoc)
+	st.Save()
+	address := privAccounts[0].Address
+	options := SnapshotOptions{Interval: 2, KeepRecent: 2}
+	snapshots, err := NewSnapshots(st.DB, options, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+	for height := 1; height <= 6; height++ {
+		cache := NewBlockCache(st)
+		account := cache.GetAccount(address)
+		account.Balance = int64(height)
+		cache.UpdateAccount(account)
+		for i := int64(0); i < 100; i++ {
+			cache.SetStorage(LeftPadWord256(address), Int64ToWord256(i),
+				Int64ToWord256(int64(height)))
+		}
+		cache.Sync()
+		st.LastBlockHeight = height
+		st.Save()
+		if height%options.Interval == 0 {
+			_, err := snapshots.Snapshot(st)
+			require.NoError(t, err)
+		}
+	}
+
+	// Only the most recent snapshots are kept
+	list := snapshots.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, uint64(6), list[0].Height)
+	assert.Equal(t, uint64(4), list[1].Height)
+	assert.Nil(t, snapshots.LoadChunk(2, SnapshotFormat, 0))
+	snapshot := list[0]
+
+	// A new node of the chain restores the state from the snapshot's chunks
+	fresh := MakeGenesisState(tdb.NewMemDB(), genDoc)
+	fresh.Save()
+	_, err = NewSnapshotRestorer(fresh, snapshot, []byte("not the app hash"))
+	assert.Error(t, err)
+	restorer, err := NewSnapshotRestorer(fresh, snapshot, st.Hash())
+	require.NoError(t, err)
+	chunk := snapshots.LoadChunk(snapshot.Height, snapshot.Format, 0)
+	require.NotNil(t, chunk)
+	corrupt := append([]byte{}, chunk...)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	assert.Error(t, restorer.Apply(0, corrupt))
+	for i := uint32(0); i < snapshot.Chunks; i++ {
+		assert.False(t, restorer.Complete())
+		require.NoError(t, restorer.Apply(i, snapshots.LoadChunk(snapshot.Height,
+			snapshot.Format, i)))
+	}
+	assert.True(t, restorer.Complete())
+	restored, err := restorer.Finish()
+	require.NoError(t, err)
+	assert.Equal(t, st.Hash(), restored.Hash())
+	assert.Equal(t, 6, restored.LastBlockHeight)
+	assert.Equal(t, int64(6), restored.GetAccount(address).Balance)
+	assert.Equal(t, Int64ToWord256(6), NewBlockCache(restored).GetStorage(
+		LeftPadWord256(address), Int64ToWord256(99)))
+
+	// The snapshots kept are reloaded from the db
+	snapshots, err = NewSnapshots(st.DB, options, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+	assert.Len(t, snapshots.List(), 2)
+}
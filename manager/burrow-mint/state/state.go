@@ -23,6 +23,7 @@ import (
 
 	acm "github.com/hyperledger/burrow/account"
 	genesis "github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm"
 	ptypes "github.com/hyperledger/burrow/permission/types"
 	"github.com/hyperledger/burrow/txs"
 
@@ -43,6 +44,8 @@ var (
 	unbondingPeriodBlocks        = int(60 * 24 * 365) // TODO probably better to make it time based.
 	validatorTimeoutBlocks       = int(10)            // TODO adjust
 	maxLoadStateElementSize      = 0                  // no max
+	defaultGasLimit              = genesis.DefaultGasLimit
+	defaultSlashingParams        = genesis.DefaultSlashingParams
 )
 
 //-----------------------------------------------------------------------------
@@ -55,12 +58,77 @@ type State struct {
 	LastBlockHash   []byte
 	LastBlockParts  types.PartSetHeader
 	LastBlockTime   time.Time
+	// The fee burnt from each CallTx and SendTx in the next block
+	BaseFee int64
+	// The votes a proposal needs before it is executed, none can be made when 0
+	ProposalThreshold int
+	// The gas each CallTx is given, defaultGasLimit when 0
+	GasLimit int64
+	// The longest tx in bytes the chain accepts, no limit when 0
+	MaxTxSize int
+	// The most gas a CallTx may be given, no limit when 0
+	MaxTxGas int64
+	// The most gas the CallTxs of a block may be given in all, no limit when 0
+	MaxBlockGas int64
+	// The parameters the base fee is adjusted by, it is not charged when nil
+	FeeParams *genesis.FeeParams
+	// The rewards of validators, there are none when nil
+	RewardParams *genesis.RewardParams
+	// The upgrade the chain is to halt for, if any
+	Upgrade *txs.UpgradePlan
+	// The gas schedule of genesis, replaced with SetGasSchedule
+	GasSchedule   *genesis.GasSchedule
+	vmGasSchedule *vm.GasSchedule
+	// The account credited with priority fees in the current block, they are
+	// burnt if nil. Not saved.
+	BlockProposer []byte
+	// Where the traces of executed CallTxs are kept, if anywhere. Not saved
+	// or copied.
+	txTraces *TxTraces
+	// Where the receipts of executed CallTxs are added, if anywhere. Not saved
+	// or copied.
+	txReceipts *TxReceipts
+	// Caches the accounts and storage read from the trees, if anything. Shared
+	// with copies.
+	readCache *ReadCache
+	// The private states of the groups the node executes PrivateTxs for, if
+	// any. Shared with copies.
+	privateStates *PrivateStates
 	//	BondedValidators     *types.ValidatorSet
 	//	LastBondedValidators *types.ValidatorSet
 	//	UnbondingValidators  *types.ValidatorSet
 	accounts       merkle.Tree // Shouldn't be accessed directly.
 	validatorInfos merkle.Tree // Shouldn't be accessed directly.
 	nameReg        merkle.Tree // Shouldn't be accessed directly.
+	abiRegistry    merkle.Tree // Shouldn't be accessed directly.
+	// The rotations of validator keys, applied to the genesis validators
+	validatorRotations merkle.Tree // Shouldn't be accessed directly.
+	// The proposals made, and their votes, by hash
+	proposals merkle.Tree // Shouldn't be accessed directly.
+	// The changes to the power of validators, the coins bonded to them and
+	// the coins of unbonded validators waiting to be released
+	validatorPowers merkle.Tree // Shouldn't be accessed directly.
+	bonds           merkle.Tree // Shouldn't be accessed directly.
+	unbondings      merkle.Tree // Shouldn't be accessed directly.
+	// The validators removed from the validator set for misbehaving, and the
+	// blocks validators have missed signing in the current downtime window
+	jails        merkle.Tree // Shouldn't be accessed directly.
+	missedBlocks merkle.Tree // Shouldn't be accessed directly.
+	// The network identities of the nodes of validators, by validator address
+	nodeRegistry merkle.Tree // Shouldn't be accessed directly.
+	// The chains relayed, their headers and the storage proved against them
+	relays merkle.Tree // Shouldn't be accessed directly.
+	// The attestations of events for the bridge to Ethereum, the events
+	// designated for it and the Ethereum addresses bound by validators
+	bridge merkle.Tree // Shouldn't be accessed directly.
+	// The records of private txs, by tx hash, whose payloads only the nodes of
+	// their private groups execute
+	privateTxs merkle.Tree // Shouldn't be accessed directly.
+	// The encrypted blobs of the confidential store and the grants to read them
+	confidential merkle.Tree // Shouldn't be accessed directly.
+	// The validators and slashing parameters of the genesis doc, not saved
+	genesisValidators []genesis.GenesisValidator
+	slashingParams    *genesis.SlashingParams
 
 	evc events.Fireable // typically an events.EventCache
 }
@@ -89,6 +157,132 @@ func LoadState(db dbm.DB) *State {
 		nameRegHash := wire.ReadByteSlice(r, maxLoadStateElementSize, n, err)
 		s.nameReg = merkle.NewIAVLTree(0, db)
 		s.nameReg.Load(nameRegHash)
+		// Absent from state saved before base fees
+		if r.Len() > 0 {
+			s.BaseFee = wire.ReadInt64(r, n, err)
+		}
+		// Absent from state saved before gas schedules
+		if r.Len() > 0 {
+			gasScheduleJSON := wire.ReadByteSlice(r, maxLoadStateElementSize, n, err)
+			wire.ReadJSONPtr(&s.GasSchedule, gasScheduleJSON, err)
+		}
+		s.abiRegistry = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before the ABI registry
+		if r.Len() > 0 {
+			s.abiRegistry.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.validatorRotations = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before validator rotations
+		if r.Len() > 0 {
+			s.validatorRotations.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.proposals = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before proposals
+		if r.Len() > 0 {
+			s.proposals.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+			s.ProposalThreshold = wire.ReadVarint(r, n, err)
+		}
+		// Absent from state saved before chain parameters, whose fee
+		// parameters were read from the genesis doc
+		hasChainParams := r.Len() > 0
+		if hasChainParams {
+			s.GasLimit = wire.ReadInt64(r, n, err)
+			s.MaxTxSize = wire.ReadVarint(r, n, err)
+			feeParamsJSON := wire.ReadByteSlice(r, maxLoadStateElementSize, n, err)
+			wire.ReadJSONPtr(&s.FeeParams, feeParamsJSON, err)
+		}
+		s.validatorPowers = merkle.NewIAVLTree(0, db)
+		s.bonds = merkle.NewIAVLTree(0, db)
+		s.unbondings = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before bonding
+		if r.Len() > 0 {
+			s.validatorPowers.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+			s.bonds.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+			s.unbondings.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.jails = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before slashing
+		if r.Len() > 0 {
+			s.jails.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.missedBlocks = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before downtime jailing
+		if r.Len() > 0 {
+			s.missedBlocks.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		// Absent from state saved before rewards, which were read from the
+		// genesis doc
+		hasRewardParams := r.Len() > 0
+		if hasRewardParams {
+			rewardParamsJSON := wire.ReadByteSlice(r, maxLoadStateElementSize, n, err)
+			wire.ReadJSONPtr(&s.RewardParams, rewardParamsJSON, err)
+		}
+		s.nodeRegistry = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before the node registry
+		if r.Len() > 0 {
+			s.nodeRegistry.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		// Absent from state saved before the gas ceiling, which was read from
+		// the genesis doc
+		hasMaxTxGas := r.Len() > 0
+		if hasMaxTxGas {
+			s.MaxTxGas = wire.ReadInt64(r, n, err)
+		}
+		// Absent from state saved before the block gas limit, which was read
+		// from the genesis doc
+		hasMaxBlockGas := r.Len() > 0
+		if hasMaxBlockGas {
+			s.MaxBlockGas = wire.ReadInt64(r, n, err)
+		}
+		// Absent from state saved before upgrades
+		if r.Len() > 0 {
+			upgradeJSON := wire.ReadByteSlice(r, maxLoadStateElementSize, n, err)
+			wire.ReadJSONPtr(&s.Upgrade, upgradeJSON, err)
+		}
+		s.relays = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before relaying
+		if r.Len() > 0 {
+			s.relays.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.bridge = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before the bridge
+		if r.Len() > 0 {
+			s.bridge.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.privateTxs = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before private txs
+		if r.Len() > 0 {
+			s.privateTxs.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		s.confidential = merkle.NewIAVLTree(0, db)
+		// Absent from state saved before the confidential store
+		if r.Len() > 0 {
+			s.confidential.Load(wire.ReadByteSlice(r, maxLoadStateElementSize, n, err))
+		}
+		// The genesis doc is saved alongside state by the pipe
+		if genDoc, genErr := s.GetGenesisDoc(); genErr == nil && genDoc != nil {
+			s.genesisValidators = genDoc.Validators
+			if genDoc.Params != nil {
+				s.slashingParams = genDoc.Params.Slashing
+			}
+			if !hasChainParams && genDoc.Params != nil {
+				s.FeeParams = genDoc.Params.Fees
+			}
+			if !hasRewardParams && genDoc.Params != nil {
+				s.RewardParams = genDoc.Params.Rewards
+			}
+			if !hasMaxTxGas && genDoc.Params != nil {
+				s.MaxTxGas = genDoc.Params.MaxTxGas
+			}
+			if !hasMaxBlockGas && genDoc.Params != nil {
+				s.MaxBlockGas = genDoc.Params.MaxBlockGas
+			}
+		}
+		if *err == nil {
+			if setErr := s.SetGasSchedule(s.GasSchedule); setErr != nil {
+				*err = setErr
+			}
+		}
 		if *err != nil {
 			// DATA HAS BEEN CORRUPTED OR THE SPEC HAS CHANGED
 			util.Fatalf("Data has been corrupted or its spec has changed: %v\n", *err)
@@ -102,6 +296,19 @@ func (s *State) Save() {
 	s.accounts.Save()
 	//s.validatorInfos.Save()
 	s.nameReg.Save()
+	s.abiRegistry.Save()
+	s.validatorRotations.Save()
+	s.proposals.Save()
+	s.validatorPowers.Save()
+	s.bonds.Save()
+	s.unbondings.Save()
+	s.jails.Save()
+	s.missedBlocks.Save()
+	s.nodeRegistry.Save()
+	s.relays.Save()
+	s.bridge.Save()
+	s.privateTxs.Save()
+	s.confidential.Save()
 	buf, n, err := new(bytes.Buffer), new(int), new(error)
 	wire.WriteString(s.ChainID, buf, n, err)
 	wire.WriteVarint(s.LastBlockHeight, buf, n, err)
@@ -114,6 +321,29 @@ func (s *State) Save() {
 	wire.WriteByteSlice(s.accounts.Hash(), buf, n, err)
 	//wire.WriteByteSlice(s.validatorInfos.Hash(), buf, n, err)
 	wire.WriteByteSlice(s.nameReg.Hash(), buf, n, err)
+	wire.WriteInt64(s.BaseFee, buf, n, err)
+	wire.WriteByteSlice(wire.JSONBytes(s.GasSchedule), buf, n, err)
+	wire.WriteByteSlice(s.abiRegistry.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.validatorRotations.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.proposals.Hash(), buf, n, err)
+	wire.WriteVarint(s.ProposalThreshold, buf, n, err)
+	wire.WriteInt64(s.GasLimit, buf, n, err)
+	wire.WriteVarint(s.MaxTxSize, buf, n, err)
+	wire.WriteByteSlice(wire.JSONBytes(s.FeeParams), buf, n, err)
+	wire.WriteByteSlice(s.validatorPowers.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.bonds.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.unbondings.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.jails.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.missedBlocks.Hash(), buf, n, err)
+	wire.WriteByteSlice(wire.JSONBytes(s.RewardParams), buf, n, err)
+	wire.WriteByteSlice(s.nodeRegistry.Hash(), buf, n, err)
+	wire.WriteInt64(s.MaxTxGas, buf, n, err)
+	wire.WriteInt64(s.MaxBlockGas, buf, n, err)
+	wire.WriteByteSlice(wire.JSONBytes(s.Upgrade), buf, n, err)
+	wire.WriteByteSlice(s.relays.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.bridge.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.privateTxs.Hash(), buf, n, err)
+	wire.WriteByteSlice(s.confidential.Hash(), buf, n, err)
 	if *err != nil {
 		// TODO: [Silas] Do something better than this, really serialising ought to
 		// be error-free
@@ -128,31 +358,108 @@ func (s *State) Save() {
 // as if State were copied by value.
 func (s *State) Copy() *State {
 	return &State{
-		DB:              s.DB,
-		ChainID:         s.ChainID,
-		LastBlockHeight: s.LastBlockHeight,
-		LastBlockHash:   s.LastBlockHash,
-		LastBlockParts:  s.LastBlockParts,
-		LastBlockTime:   s.LastBlockTime,
+		DB:                s.DB,
+		ChainID:           s.ChainID,
+		LastBlockHeight:   s.LastBlockHeight,
+		LastBlockHash:     s.LastBlockHash,
+		LastBlockParts:    s.LastBlockParts,
+		LastBlockTime:     s.LastBlockTime,
+		BaseFee:           s.BaseFee,
+		ProposalThreshold: s.ProposalThreshold,
+		GasLimit:          s.GasLimit,
+		MaxTxSize:         s.MaxTxSize,
+		MaxTxGas:          s.MaxTxGas,
+		MaxBlockGas:       s.MaxBlockGas,
+		FeeParams:         s.FeeParams,
+		RewardParams:      s.RewardParams,
+		Upgrade:           s.Upgrade,
+		GasSchedule:       s.GasSchedule,
+		vmGasSchedule:     s.vmGasSchedule,
+		BlockProposer:     s.BlockProposer,
 		// BondedValidators:     s.BondedValidators.Copy(),     // TODO remove need for Copy() here.
 		// LastBondedValidators: s.LastBondedValidators.Copy(), // That is, make updates to the validator set
 		// UnbondingValidators: s.UnbondingValidators.Copy(), // copy the valSet lazily.
 		accounts: s.accounts.Copy(),
 		//validatorInfos:       s.validatorInfos.Copy(),
-		nameReg: s.nameReg.Copy(),
-		evc:     nil,
+		nameReg:            s.nameReg.Copy(),
+		abiRegistry:        s.abiRegistry.Copy(),
+		validatorRotations: s.validatorRotations.Copy(),
+		proposals:          s.proposals.Copy(),
+		validatorPowers:    s.validatorPowers.Copy(),
+		bonds:              s.bonds.Copy(),
+		unbondings:         s.unbondings.Copy(),
+		jails:              s.jails.Copy(),
+		missedBlocks:       s.missedBlocks.Copy(),
+		nodeRegistry:       s.nodeRegistry.Copy(),
+		relays:             s.relays.Copy(),
+		bridge:             s.bridge.Copy(),
+		privateTxs:         s.privateTxs.Copy(),
+		confidential:       s.confidential.Copy(),
+		genesisValidators:  s.genesisValidators,
+		slashingParams:     s.slashingParams,
+		readCache:          s.readCache,
+		privateStates:      s.privateStates,
+		evc:                nil,
 	}
 }
 
 // Returns a hash that represents the state data, excluding Last*
 func (s *State) Hash() []byte {
-	return merkle.SimpleHashFromMap(map[string]interface{}{
+	return merkle.SimpleHashFromMap(s.hashedTrees())
+}
+
+// The trees of state hashed by Hash, by the name they are hashed with. The
+// trees added since the accounts and name registry are only hashed once they
+// have an entry so that the hash of state from before they existed is
+// unchanged.
+func (s *State) hashedTrees() map[string]interface{} {
+	trees := map[string]interface{}{
 		//"BondedValidators":    s.BondedValidators,
 		//"UnbondingValidators": s.UnbondingValidators,
-		"Accounts": s.accounts,
+		accountsTreeName: s.accounts,
 		//"ValidatorInfos":      s.validatorInfos,
-		"NameRegistry": s.nameReg,
-	})
+		nameRegTreeName: s.nameReg,
+	}
+	if s.abiRegistry.Size() > 0 {
+		trees[abiRegistryTreeName] = s.abiRegistry
+	}
+	if s.validatorRotations.Size() > 0 {
+		trees[validatorRotationsTreeName] = s.validatorRotations
+	}
+	if s.proposals.Size() > 0 {
+		trees[proposalsTreeName] = s.proposals
+	}
+	if s.validatorPowers.Size() > 0 {
+		trees[validatorPowersTreeName] = s.validatorPowers
+	}
+	if s.bonds.Size() > 0 {
+		trees[bondsTreeName] = s.bonds
+	}
+	if s.unbondings.Size() > 0 {
+		trees[unbondingsTreeName] = s.unbondings
+	}
+	if s.jails.Size() > 0 {
+		trees[jailsTreeName] = s.jails
+	}
+	if s.missedBlocks.Size() > 0 {
+		trees[missedBlocksTreeName] = s.missedBlocks
+	}
+	if s.nodeRegistry.Size() > 0 {
+		trees[nodeRegistryTreeName] = s.nodeRegistry
+	}
+	if s.relays.Size() > 0 {
+		trees[relaysTreeName] = s.relays
+	}
+	if s.bridge.Size() > 0 {
+		trees[bridgeTreeName] = s.bridge
+	}
+	if s.privateTxs.Size() > 0 {
+		trees[privateTxsTreeName] = s.privateTxs
+	}
+	if s.confidential.Size() > 0 {
+		trees[confidentialTreeName] = s.confidential
+	}
+	return trees
 }
 
 /* //XXX Done by tendermint core
@@ -189,7 +496,141 @@ func (s *State) SetDB(db dbm.DB) {
 // State.params
 
 func (s *State) GetGasLimit() int64 {
-	return 1000000 // TODO
+	if s.GasLimit == 0 {
+		return defaultGasLimit
+	}
+	return s.GasLimit
+}
+
+// The punishment for double-signing and downtime, defaultSlashingParams when
+// the genesis doc does not set it
+func (s *State) GetSlashingParams() genesis.SlashingParams {
+	if s.slashingParams == nil {
+		return defaultSlashingParams
+	}
+	return *s.slashingParams
+}
+
+// Checks that the new parameters of a GovTx can be set
+func (s *State) ValidateChainParams(params *txs.ChainParams) error {
+	if params.GasSchedule != nil {
+		if _, err := NewVMGasSchedule(params.GasSchedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sets the parameters a GovTx changes, other than the global permissions
+// which are kept in the global permissions account
+func (s *State) SetChainParams(params *txs.ChainParams) error {
+	if err := s.ValidateChainParams(params); err != nil {
+		return err
+	}
+	if params.GasSchedule != nil {
+		if err := s.SetGasSchedule(params.GasSchedule); err != nil {
+			return err
+		}
+	}
+	if params.GasLimit != nil {
+		s.GasLimit = *params.GasLimit
+	}
+	if params.MaxTxSize != nil {
+		s.MaxTxSize = *params.MaxTxSize
+	}
+	if params.Fees != nil {
+		s.FeeParams = params.Fees
+		s.BaseFee = params.Fees.InitialBaseFee
+	}
+	if params.ProposalThreshold != nil {
+		s.ProposalThreshold = *params.ProposalThreshold
+	}
+	if params.Rewards != nil {
+		s.RewardParams = params.Rewards
+	}
+	if params.Upgrade != nil {
+		if params.Upgrade.Height == 0 {
+			s.Upgrade = nil
+		} else {
+			s.Upgrade = params.Upgrade
+		}
+	}
+	return nil
+}
+
+// Whether the next block is the height of the upgrade of the chain, which only
+// the binary of the upgrade may execute
+func (s *State) UpgradeDue() bool {
+	return s.Upgrade != nil && s.LastBlockHeight+1 == s.Upgrade.Height
+}
+
+// Checks that a binary of version, a semantic version such as 0.17.1, may
+// execute the next block, as it may unless the upgrade of the chain is due and
+// it is not the version the upgrade names
+func (s *State) CheckUpgrade(version string) error {
+	if s.UpgradeDue() && s.Upgrade.Name != version {
+		return fmt.Errorf("Chain halted after height %v for upgrade '%s', "+
+			"which cannot be executed by version %s", s.LastBlockHeight,
+			s.Upgrade.Name, version)
+	}
+	return nil
+}
+
+// Keeps the traces of CallTxs executed against the state in txTraces, or
+// stops tracing if it is nil
+func (s *State) SetTxTraces(txTraces *TxTraces) {
+	s.txTraces = txTraces
+}
+
+// Adds the receipts of CallTxs executed against the state to txReceipts, or
+// stops adding them if it is nil
+func (s *State) SetTxReceipts(txReceipts *TxReceipts) {
+	s.txReceipts = txReceipts
+}
+
+// Executes the payloads of the PrivateTxs to the groups of privateStates
+// against their private states, or stops executing them if it is nil
+func (s *State) SetPrivateStates(privateStates *PrivateStates) {
+	s.privateStates = privateStates
+}
+
+// The private states of the groups the node executes PrivateTxs for, nil if
+// there are none
+func (s *State) PrivateStates() *PrivateStates {
+	return s.privateStates
+}
+
+// Replaces the gas schedule the VM charges by, nil for Burrow's own
+func (s *State) SetGasSchedule(gasSchedule *genesis.GasSchedule) error {
+	vmGasSchedule, err := NewVMGasSchedule(gasSchedule)
+	if err != nil {
+		return err
+	}
+	s.GasSchedule = gasSchedule
+	s.vmGasSchedule = vmGasSchedule
+	return nil
+}
+
+func (s *State) GetVMGasSchedule() *vm.GasSchedule {
+	if s.vmGasSchedule == nil {
+		return vm.DefaultGasSchedule()
+	}
+	return s.vmGasSchedule
+}
+
+// Gets the VM gas schedule a genesis gas schedule describes
+func NewVMGasSchedule(gasSchedule *genesis.GasSchedule) (*vm.GasSchedule, error) {
+	if gasSchedule == nil {
+		return vm.DefaultGasSchedule(), nil
+	}
+	costs := make(map[string]int64, len(gasSchedule.Costs))
+	for _, cost := range gasSchedule.Costs {
+		if _, ok := costs[cost.Name]; ok {
+			return nil, fmt.Errorf("Gas cost %s is given more than once", cost.Name)
+		}
+		costs[cost.Name] = cost.Gas
+	}
+	return vm.NewGasSchedule(gasSchedule.Base, costs)
 }
 
 // State.params
@@ -199,7 +640,7 @@ func (s *State) GetGasLimit() int64 {
 // Returns nil if account does not exist with given address.
 // Implements Statelike
 func (s *State) GetAccount(address []byte) *acm.Account {
-	_, accBytes, _ := s.accounts.Get(address)
+	accBytes := s.readCache.account(s.accounts, address)
 	if accBytes == nil {
 		return nil
 	}
@@ -349,6 +790,21 @@ func (s *State) LoadStorage(hash []byte) (storage merkle.Tree) {
 	return storage
 }
 
+// Gets the value of the (left padded) key in the storage tree with root
+// storageRoot, or nil if it has none, through the read cache
+func (s *State) GetStorage(storageRoot []byte, key []byte) []byte {
+	return s.readCache.storageValueAt(storageRoot, key, func() []byte {
+		_, value, _ := s.LoadStorage(storageRoot).Get(key)
+		return value
+	})
+}
+
+// Sets the cache accounts and storage are read through, nil reads them
+// straight from the trees
+func (s *State) SetReadCache(readCache *ReadCache) {
+	s.readCache = readCache
+}
+
 // State.storage
 //-------------------------------------
 // State.nameReg
@@ -406,6 +862,30 @@ var NameRegCodec = wire.Codec{
 
 // State.nameReg
 //-------------------------------------
+// State.abiRegistry
+
+// Get the ABI entry of the contract at address
+func (s *State) GetABIEntry(address []byte) *core_types.ABIEntry {
+	_, valueBytes, _ := s.abiRegistry.Get(address)
+	if valueBytes == nil {
+		return nil
+	}
+
+	return DecodeABIEntry(valueBytes)
+}
+
+func DecodeABIEntry(entryBytes []byte) *core_types.ABIEntry {
+	entry := new(core_types.ABIEntry)
+	readBinary(entryBytes, entry)
+	return entry
+}
+
+func (s *State) UpdateABIEntry(entry *core_types.ABIEntry) bool {
+	return s.abiRegistry.Set(entry.Address, wire.BinaryBytes(entry))
+}
+
+// State.abiRegistry
+//-------------------------------------
 
 // Implements events.Eventable. Typically uses events.EventCache
 func (s *State) SetFireable(evc events.Fireable) {
@@ -435,7 +915,7 @@ func MakeGenesisState(db dbm.DB, genDoc *genesis.GenesisDoc) *State {
 		// and should be required in the genesis file;
 		// the requirement is not yet enforced when lacking set
 		// time to 11/18/2016 @ 4:09am (UTC)
-		genDoc.GenesisTime = time.Unix(1479442162, 0)
+		genDoc.GenesisTime = genesis.DefaultGenesisTime
 	}
 
 	// Make accounts state tree
@@ -511,23 +991,105 @@ func MakeGenesisState(db dbm.DB, genDoc *genesis.GenesisDoc) *State {
 	nameReg := merkle.NewIAVLTree(0, db)
 	// TODO: add names, contracts to genesis.json
 
+	abiRegistry := merkle.NewIAVLTree(0, db)
+
+	validatorRotations := merkle.NewIAVLTree(0, db)
+
+	proposals := merkle.NewIAVLTree(0, db)
+
+	validatorPowers := merkle.NewIAVLTree(0, db)
+	bonds := merkle.NewIAVLTree(0, db)
+	unbondings := merkle.NewIAVLTree(0, db)
+	jails := merkle.NewIAVLTree(0, db)
+	missedBlocks := merkle.NewIAVLTree(0, db)
+	nodeRegistry := merkle.NewIAVLTree(0, db)
+	relays := merkle.NewIAVLTree(0, db)
+	bridge := merkle.NewIAVLTree(0, db)
+	privateTxs := merkle.NewIAVLTree(0, db)
+	confidential := merkle.NewIAVLTree(0, db)
+
 	// IAVLTrees must be persisted before copy operations.
 	accounts.Save()
 	//validatorInfos.Save()
 	nameReg.Save()
+	abiRegistry.Save()
+	validatorRotations.Save()
+	proposals.Save()
+	validatorPowers.Save()
+	bonds.Save()
+	unbondings.Save()
+	jails.Save()
+	missedBlocks.Save()
+	nodeRegistry.Save()
+	relays.Save()
+	bridge.Save()
+	privateTxs.Save()
+	confidential.Save()
+
+	baseFee := int64(0)
+	if genDoc.Params != nil && genDoc.Params.Fees != nil {
+		baseFee = genDoc.Params.Fees.InitialBaseFee
+	}
+	proposalThreshold := 0
+	gasLimit := int64(0)
+	maxTxSize := 0
+	maxTxGas := int64(0)
+	maxBlockGas := int64(0)
+	var feeParams *genesis.FeeParams
+	var rewardParams *genesis.RewardParams
+	var slashingParams *genesis.SlashingParams
+	if genDoc.Params != nil {
+		proposalThreshold = genDoc.Params.ProposalThreshold
+		gasLimit = genDoc.Params.GasLimit
+		maxTxSize = genDoc.Params.MaxTxSize
+		maxTxGas = genDoc.Params.MaxTxGas
+		maxBlockGas = genDoc.Params.MaxBlockGas
+		feeParams = genDoc.Params.Fees
+		rewardParams = genDoc.Params.Rewards
+		slashingParams = genDoc.Params.Slashing
+	}
 
-	return &State{
-		DB:              db,
-		ChainID:         genDoc.ChainID,
-		LastBlockHeight: 0,
-		LastBlockHash:   nil,
-		LastBlockParts:  types.PartSetHeader{},
-		LastBlockTime:   genDoc.GenesisTime,
+	s := &State{
+		DB:                db,
+		ChainID:           genDoc.ChainID,
+		LastBlockHeight:   0,
+		LastBlockHash:     nil,
+		LastBlockParts:    types.PartSetHeader{},
+		LastBlockTime:     genDoc.GenesisTime,
+		BaseFee:           baseFee,
+		ProposalThreshold: proposalThreshold,
+		GasLimit:          gasLimit,
+		MaxTxSize:         maxTxSize,
+		MaxTxGas:          maxTxGas,
+		MaxBlockGas:       maxBlockGas,
+		FeeParams:         feeParams,
+		RewardParams:      rewardParams,
 		//BondedValidators:     types.NewValidatorSet(validators),
 		//LastBondedValidators: types.NewValidatorSet(nil),
 		//UnbondingValidators:  types.NewValidatorSet(nil),
 		accounts: accounts,
 		//validatorInfos:       validatorInfos,
-		nameReg: nameReg,
+		nameReg:            nameReg,
+		abiRegistry:        abiRegistry,
+		validatorRotations: validatorRotations,
+		proposals:          proposals,
+		validatorPowers:    validatorPowers,
+		bonds:              bonds,
+		unbondings:         unbondings,
+		jails:              jails,
+		missedBlocks:       missedBlocks,
+		nodeRegistry:       nodeRegistry,
+		relays:             relays,
+		bridge:             bridge,
+		privateTxs:         privateTxs,
+		confidential:       confidential,
+		genesisValidators:  genDoc.Validators,
+		slashingParams:     slashingParams,
 	}
+	if genDoc.Params != nil {
+		if err := s.SetGasSchedule(genDoc.Params.GasSchedule); err != nil {
+			util.Fatalf("The genesis gas schedule is invalid: %v", err)
+		}
+	}
+	return s
 }
@@ -18,13 +18,22 @@ import (
 	"bytes"
 	"encoding/hex"
 	"testing"
+	"time"
 
+	acm "github.com/hyperledger/burrow/account"
 	core_types "github.com/hyperledger/burrow/core/types"
+	genesis "github.com/hyperledger/burrow/genesis"
 	evm "github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	ptypes "github.com/hyperledger/burrow/permission/types"
 	"github.com/hyperledger/burrow/txs"
 	"github.com/hyperledger/burrow/word256"
 
+	"github.com/tendermint/go-crypto"
+	tdb "github.com/tendermint/go-db"
 	"github.com/tendermint/tendermint/config/tendermint_test"
+	tm_types "github.com/tendermint/tendermint/types"
 )
 
 func init() {
@@ -422,6 +431,93 @@ var preFactoryCode, _ = hex.DecodeString("60606040526000357C01000000000000000000
 var factoryCode, _ = hex.DecodeString("60606040526000357C010000000000000000000000000000000000000000000000000000000090048063EFC81A8C146037576035565B005B60426004805050606E565B604051808273FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF16815260200191505060405180910390F35B6000604051610153806100E0833901809050604051809103906000F0600060006101000A81548173FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF02191690830217905550600060009054906101000A900473FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF16905060DD565B90566060604052610141806100126000396000F360606040526000357C0100000000000000000000000000000000000000000000000000000000900480639ED933181461003957610037565B005B61004F600480803590602001909190505061007B565B604051808273FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF16815260200191505060405180910390F35B60008173FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF1663EFC81A8C604051817C01000000000000000000000000000000000000000000000000000000000281526004018090506020604051808303816000876161DA5A03F1156100025750505060405180519060200150600060006101000A81548173FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF02191690830217905550600060009054906101000A900473FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF16905061013C565B91905056")
 var createData, _ = hex.DecodeString("9ed93318")
 
+func TestABITxs(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	contractABI := `[{"type":"event","name":"Ping","inputs":[]}]`
+
+	// There is no contract at the address
+	tx, _ := txs.NewABITx(state, privAccounts[0].PubKey, privAccounts[2].PubKey.Address(),
+		contractABI, nil, 1)
+	tx.Sign(state.ChainID, privAccounts[0])
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Fatal("Expected error registering an ABI for an account without code")
+	}
+
+	// Creates a contract with the code 0x6000
+	createCode := []byte{0x61, 0x60, 0x00, 0x60, 0x00, 0x52, 0x60, 0x02, 0x60, 0x1e, 0xf3}
+	createTx, _ := txs.NewCallTx(state, privAccounts[0].PubKey, nil, createCode, 1, 1000, 1)
+	createTx.Sign(state.ChainID, privAccounts[0])
+	if err := execTxWithState(state, createTx, true); err != nil {
+		t.Fatal(err)
+	}
+	contractAddress := NewContractAddress(createTx.Input.Address, createTx.Input.Sequence)
+	if contract := state.GetAccount(contractAddress); contract == nil ||
+		!bytes.Equal(contract.Code, []byte{0x60, 0x00}) {
+		t.Fatalf("Expected a contract to be created at %X", contractAddress)
+	}
+	if entry := GetContractABIEntry(state, contractAddress); entry != nil {
+		t.Fatalf("Expected no ABI before one is registered: %v", entry)
+	}
+
+	// Not an ABI
+	tx, _ = txs.NewABITx(state, privAccounts[0].PubKey, contractAddress, "not an ABI", nil, 1)
+	tx.Sign(state.ChainID, privAccounts[0])
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Fatal("Expected error registering an invalid ABI")
+	}
+
+	// Only the creator can register the ABI
+	tx, _ = txs.NewABITx(state, privAccounts[1].PubKey, contractAddress, contractABI, nil, 1)
+	tx.Sign(state.ChainID, privAccounts[1])
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Fatal("Expected error registering an ABI for a contract another account created")
+	}
+
+	balance := state.GetAccount(privAccounts[0].PubKey.Address()).Balance
+	tx, _ = txs.NewABITx(state, privAccounts[0].PubKey, contractAddress, contractABI, nil, 1)
+	tx.Sign(state.ChainID, privAccounts[0])
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatal(err)
+	}
+	entry := GetContractABIEntry(state, contractAddress)
+	if entry == nil {
+		t.Fatal("Expected an ABI to be registered for the contract")
+	}
+	if entry.ABI != contractABI || !bytes.Equal(entry.Owner, privAccounts[0].PubKey.Address()) {
+		t.Errorf("Unexpected ABI entry: %v", entry)
+	}
+	if state.GetAccount(privAccounts[0].PubKey.Address()).Balance != balance-1 {
+		t.Errorf("Expected the input amount to be taken as the fee")
+	}
+
+	// Nor can another account replace it
+	tx, _ = txs.NewABITx(state, privAccounts[1].PubKey, contractAddress, "", make([]byte, 32), 1)
+	tx.Sign(state.ChainID, privAccounts[1])
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Fatal("Expected error replacing an ABI owned by another account")
+	}
+	tx, _ = txs.NewABITx(state, privAccounts[0].PubKey, contractAddress, "", make([]byte, 32), 1)
+	tx.Sign(state.ChainID, privAccounts[0])
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatal(err)
+	}
+	entry = GetContractABIEntry(state, contractAddress)
+	if entry.ABI != "" || !bytes.Equal(entry.ABIHash, make([]byte, 32)) {
+		t.Errorf("Expected the ABI to be replaced by an ABI hash: %v", entry)
+	}
+
+	// The ABI is not that of other contracts with the same code
+	createTx, _ = txs.NewCallTx(state, privAccounts[1].PubKey, nil, createCode, 1, 1000, 1)
+	createTx.Sign(state.ChainID, privAccounts[1])
+	if err := execTxWithState(state, createTx, true); err != nil {
+		t.Fatal(err)
+	}
+	otherAddress := NewContractAddress(createTx.Input.Address, createTx.Input.Sequence)
+	if entry := GetContractABIEntry(state, otherAddress); entry != nil {
+		t.Errorf("Expected no ABI for another contract with the same code: %v", entry)
+	}
+}
+
 func TestCreates(t *testing.T) {
 	//evm.SetDebug(true)
 	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
@@ -548,6 +644,137 @@ func TestContractSend(t *testing.T) {
 	}
 }
 
+func TestEthTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	acc0 := state.GetAccount(privAccounts[0].PubKey.Address())
+	acc1 := state.GetAccount(privAccounts[1].PubKey.Address())
+	acc2 := state.GetAccount(privAccounts[2].PubKey.Address())
+
+	state = state.Copy()
+	acc1.Code = callerCode
+	state.UpdateAccount(acc1)
+	// The account of an Ethereum key, which must exist before it can send
+	seckey := bytes.Repeat([]byte{0x46}, 32)
+	ethAddress, _ := hex.DecodeString("9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+	state.UpdateAccount(&acm.Account{
+		Address:     ethAddress,
+		Balance:     10000,
+		Permissions: acc0.Permissions,
+	})
+
+	// call the contract, triggering the send
+	ethTx := &txs.EthTx{
+		GasPrice: 1,
+		GasLimit: 1000,
+		To:       acc1.Address,
+		Value:    10,
+		Data:     append([]byte{0x3e, 0x58, 0xc5, 0x8c}, word256.LeftPadBytes(acc2.Address, 32)...),
+	}
+	if err := ethTx.Sign(state.ChainID, seckey); err != nil {
+		t.Fatal(err)
+	}
+	txBytes, _ := txs.EncodeTx(ethTx)
+	tx, err := txs.DecodeTx(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatalf("Got error in executing Ethereum transaction, %v", err)
+	}
+	ethAcc := state.GetAccount(ethAddress)
+	if ethAcc.Sequence != 1 || ethAcc.Balance != 10000-10-1000 {
+		t.Errorf("Expected the value and fee to be taken from the sender, got %v", ethAcc)
+	}
+	if state.GetAccount(acc2.Address).Balance != acc2.Balance+10 {
+		t.Errorf("Value transfer from contract failed")
+	}
+
+	// Replaying the tx fails on its nonce
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected error replaying Ethereum transaction")
+	}
+
+	// Keys without accounts cannot send
+	ethTx.Nonce = 1
+	if err := ethTx.Sign(state.ChainID, bytes.Repeat([]byte{0x47}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if err := execTxWithState(state, ethTx, true); err != txs.ErrTxInvalidAddress {
+		t.Errorf("Expected error sending from unknown account, got %v", err)
+	}
+}
+
+func TestMultisigTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	acc0 := state.GetAccount(privAccounts[0].PubKey.Address())
+	acc1 := state.GetAccount(privAccounts[1].PubKey.Address())
+	pubKeys := []crypto.PubKey{privAccounts[0].PubKey, privAccounts[1].PubKey,
+		privAccounts[2].PubKey}
+
+	state = state.Copy()
+	multisigAddress := txs.MultisigAddress(2, pubKeys)
+	state.UpdateAccount(&acm.Account{
+		Address:     multisigAddress,
+		Balance:     100,
+		Permissions: acc0.Permissions,
+	})
+
+	sendTx := txs.NewSendTx()
+	sendTx.Inputs = append(sendTx.Inputs, &txs.TxInput{
+		Address:  multisigAddress,
+		Amount:   10,
+		Sequence: 1,
+	})
+	sendTx.AddOutput(acc1.Address, 10)
+	tx, err := txs.NewMultisigTx(2, pubKeys, sendTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.AddSignature(state.ChainID, privAccounts[0].PubKey,
+		privAccounts[0].Sign(state.ChainID, tx)); err != nil {
+		t.Fatal(err)
+	}
+	if err := execTxWithState(state.Copy(), tx, true); err != txs.ErrTxMultisigThreshold {
+		t.Errorf("Expected a tx signed by one signatory to fail, got %v", err)
+	}
+
+	// The other signatory signs their own copy
+	other, err := txs.DecodeMultisigTxJSON(txs.MultisigTxJSON(tx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Signatures[0] = nil
+	if err := other.AddSignature(state.ChainID, privAccounts[2].PubKey,
+		privAccounts[2].Sign(state.ChainID, other)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Combine(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatalf("Got error in executing multisig transaction, %v", err)
+	}
+	multisigAcc := state.GetAccount(multisigAddress)
+	if multisigAcc.Sequence != 1 || multisigAcc.Balance != 90 {
+		t.Errorf("Expected the amount to be taken from the multisig account, got %v",
+			multisigAcc)
+	}
+	if state.GetAccount(acc1.Address).Balance != acc1.Balance+10 {
+		t.Errorf("Send from multisig account failed")
+	}
+
+	// Replaying the tx fails on its sequence
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected error replaying multisig transaction")
+	}
+
+	// A bad signature fails even when the threshold is met
+	tx.Signatures[1] = privAccounts[1].PrivKey.Sign([]byte("not the tx"))
+	if err := execTxWithState(state, tx, true); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected invalid signature error, got %v", err)
+	}
+}
+
 // TODO: test overflows.
 // TODO: test for unbonding validators.
 func TestTxs(t *testing.T) {
@@ -798,6 +1025,53 @@ func TestSelfDestruct(t *testing.T) {
 
 }
 
+func TestTxGasCeiling(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	acc0 := state.GetAccount(privAccounts[0].Address)
+	acc1 := state.GetAccount(privAccounts[1].Address)
+	// Loops until it runs out of gas
+	acc1.Code = []byte{0x5b, 0x60, 0x00, 0x56}
+	state.UpdateAccount(acc1)
+	state.MaxTxGas = 1 << 40
+
+	tx := txs.NewCallTxWithNonce(privAccounts[0].PubKey, acc1.Address, nil, 1,
+		1<<41, 0, acc0.Sequence+1)
+	tx.Input.Signature = privAccounts[0].Sign(state.ChainID, tx)
+	if err := ExecTx(NewBlockCache(state), tx, true, nil, logger); err != txs.ErrTxGasLimitTooHigh {
+		t.Errorf("Expected gas limit above the ceiling to be rejected, got %v", err)
+	}
+}
+
+func TestTxExpiry(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	acc0 := state.GetAccount(privAccounts[0].Address)
+	state.LastBlockHeight = 10
+
+	sendTx := txs.NewSendTx()
+	sendTx.AddInputWithNonce(privAccounts[0].PubKey, 1, acc0.Sequence+1)
+	sendTx.AddOutput(privAccounts[1].Address, 1)
+	tx := &txs.ExpiringTx{ExpiresAt: 10, Tx: sendTx}
+	sendTx.Inputs[0].PubKey = privAccounts[0].PubKey
+	sendTx.Inputs[0].Signature = privAccounts[0].Sign(state.ChainID, tx)
+	err := ExecTx(NewBlockCache(state), tx, true, nil, logger)
+	if _, ok := err.(txs.ErrTxExpired); !ok {
+		t.Errorf("Expected tx expired at the last block to be rejected, got %v", err)
+	}
+	// The input signs for the expiry, so it cannot be dropped or moved
+	if err := ExecTx(NewBlockCache(state), sendTx, true, nil, logger); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected the tx without its expiry to be rejected, got %v", err)
+	}
+	tx.ExpiresAt = 11
+	if err := ExecTx(NewBlockCache(state), tx, true, nil, logger); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected the tx with another expiry to be rejected, got %v", err)
+	}
+
+	sendTx.Inputs[0].Signature = privAccounts[0].Sign(state.ChainID, tx)
+	if err := ExecTx(NewBlockCache(state), tx, true, nil, logger); err != nil {
+		t.Errorf("Got error in executing tx expiring at the next block, %v", err)
+	}
+}
+
 /* TODO
 func TestAddValidator(t *testing.T) {
 
@@ -874,3 +1148,1136 @@ func TestAddValidator(t *testing.T) {
 	}
 }
 */
+
+func TestBlockCacheCopy(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	sender := privAccounts[0]
+	receiver := privAccounts[1].PubKey.Address()
+	cache := NewBlockCache(state)
+	// Load the sender into the cache
+	acc := cache.GetAccount(sender.Address)
+	balance := acc.Balance
+
+	cacheCopy := cache.Copy()
+	tx := txs.NewSendTx()
+	tx.AddInputWithNonce(sender.PubKey, 10, acc.Sequence+1)
+	tx.AddOutput(receiver, 10)
+	tx.SignInput(state.ChainID, 0, sender)
+	if err := ExecTx(cacheCopy, tx, false, nil, logger); err != nil {
+		t.Fatal(err)
+	}
+	// The copy sees the tx and the cache does not
+	if got := cacheCopy.GetAccount(sender.Address).Balance; got != balance-10 {
+		t.Errorf("Expected the copy to have balance %v but it has %v", balance-10, got)
+	}
+	if got := cache.GetAccount(sender.Address).Balance; got != balance {
+		t.Errorf("Expected the cache to keep balance %v but it has %v", balance, got)
+	}
+	// So the tx can be executed again against the cache
+	if err := ExecTx(cache, tx, false, nil, logger); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateTx(t *testing.T) {
+	state, privAccounts, privValidators := RandGenesisState(1, true, 1000, 2, true, 1000)
+	privVal := privValidators[0]
+	pubKey := privVal.PubKey.(crypto.PubKeyEd25519)
+	newPrivAccount := acm.GenPrivAccount()
+	newPubKey := newPrivAccount.PubKey.(crypto.PubKeyEd25519)
+	sign := func(tx *txs.RotateTx, privKey crypto.PrivKey) {
+		signBytes := acm.SignBytes(state.ChainID, tx)
+		tx.Signature = privKey.Sign(signBytes).(crypto.SignatureEd25519)
+		tx.NewSignature = newPrivAccount.PrivKey.Sign(signBytes).(crypto.SignatureEd25519)
+	}
+	hash := state.Hash()
+
+	// Only validator keys can be rotated
+	tx := &txs.RotateTx{
+		PubKey:    privAccounts[0].PubKey.(crypto.PubKeyEd25519),
+		NewPubKey: newPubKey,
+		Height:    5,
+	}
+	sign(tx, privAccounts[0].PrivKey)
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected rotating a key that is not a validator's to fail")
+	}
+
+	// The height must be after the block the tx is executed in
+	tx = &txs.RotateTx{PubKey: pubKey, NewPubKey: newPubKey, Height: 1}
+	sign(tx, privVal.PrivKey)
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected rotating at the current height to fail")
+	}
+
+	// Both keys must sign
+	tx = &txs.RotateTx{PubKey: pubKey, NewPubKey: newPubKey, Height: 5}
+	sign(tx, privVal.PrivKey)
+	unsigned := *tx
+	unsigned.NewSignature = crypto.SignatureEd25519{}
+	if err := execTxWithState(state, &unsigned, true); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected invalid signature error, got %v", err)
+	}
+	if !bytes.Equal(hash, state.Hash()) {
+		t.Errorf("Expected failed rotations not to change state")
+	}
+
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatalf("Got error in executing rotate transaction, %v", err)
+	}
+	rotations := state.GetValidatorRotations()
+	if len(rotations) != 1 || rotations[0].NewPubKey != newPubKey {
+		t.Fatalf("Expected the rotation to be saved, got %v", rotations)
+	}
+	if bytes.Equal(hash, state.Hash()) {
+		t.Errorf("Expected the rotation to change the state hash")
+	}
+
+	// The rotation is pending until its height
+	tx = &txs.RotateTx{PubKey: pubKey, NewPubKey: newPubKey, Height: 6}
+	sign(tx, privVal.PrivKey)
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected rotating a validator with a pending rotation to fail")
+	}
+
+	validators := ValidatorsAt(state.GenesisValidators(), rotations, nil, 4)
+	if findValidator(validators, pubKey) < 0 || findValidator(validators, newPubKey) >= 0 {
+		t.Errorf("Expected the old key to validate before the rotation")
+	}
+	validators = ValidatorsAt(state.GenesisValidators(), rotations, nil, 5)
+	i := findValidator(validators, newPubKey)
+	if findValidator(validators, pubKey) >= 0 || i < 0 {
+		t.Fatalf("Expected the new key to validate from the rotation")
+	}
+	if validators[i].VotingPower != state.GenesisValidators()[0].Amount &&
+		validators[i].VotingPower != state.GenesisValidators()[1].Amount {
+		t.Errorf("Expected the new key to keep the validator's power")
+	}
+
+	changes := ValidatorChangesAfter(state.GenesisValidators(), rotations, nil, 4)
+	if len(changes) != 2 || changes[0].VotingPower != 0 ||
+		!bytes.Equal(changes[0].Address, pubKey.Address()) ||
+		changes[1].VotingPower != validators[i].VotingPower {
+		t.Errorf("Expected changes removing the old key and adding the new, got %v",
+			changes)
+	}
+	if changes := ValidatorChangesAfter(state.GenesisValidators(), rotations, nil, 5); len(changes) != 0 {
+		t.Errorf("Expected no changes after the rotation, got %v", changes)
+	}
+}
+
+func TestBondTx(t *testing.T) {
+	state, _, privValidators := RandGenesisState(1, true, 1000, 3, false, 1000)
+	privVal := privValidators[0]
+	pubKey := privVal.PubKey.(crypto.PubKeyEd25519)
+	perms := ptypes.DefaultAccountPermissions
+	state.UpdateAccount(&acm.Account{
+		Address:     pubKey.Address(),
+		Balance:     5000,
+		Permissions: perms,
+	})
+	bond := func(amt, fee int64, sequence int) *txs.BondTx {
+		tx := &txs.BondTx{
+			PubKey: pubKey,
+			Inputs: []*txs.TxInput{{
+				Address:  pubKey.Address(),
+				Amount:   amt + fee,
+				Sequence: sequence,
+				PubKey:   pubKey,
+			}},
+			UnbondTo: []*txs.TxOutput{{Address: pubKey.Address(), Amount: amt}},
+		}
+		signBytes := acm.SignBytes(state.ChainID, tx)
+		tx.Signature = privVal.PrivKey.Sign(signBytes).(crypto.SignatureEd25519)
+		tx.Inputs[0].Signature = privVal.PrivKey.Sign(signBytes)
+		return tx
+	}
+
+	// No more than a third of the power can change at a height
+	if err := execTxWithState(state, bond(1001, 0, 1), true); err == nil {
+		t.Errorf("Expected bonding more than a third of the power to fail")
+	}
+	if err := execTxWithState(state, bond(600, 10, 1), true); err != nil {
+		t.Fatalf("Got error in executing bond transaction, %v", err)
+	}
+	if balance := state.GetAccount(pubKey.Address()).Balance; balance != 4390 {
+		t.Errorf("Expected the bond and fee to be taken, got balance %v", balance)
+	}
+	if err := execTxWithState(state, bond(600, 0, 2), true); err == nil {
+		t.Errorf("Expected a second bond over the limit at the same height to fail")
+	}
+
+	powerChanges := state.GetValidatorPowerChanges()
+	if len(powerChanges) != 1 || powerChanges[0].Height != state.LastBlockHeight+2 ||
+		powerChanges[0].Power != 1600 {
+		t.Fatalf("Expected the power change to be saved, got %v", powerChanges)
+	}
+	validators := ValidatorsAt(state.GenesisValidators(), nil, powerChanges,
+		state.LastBlockHeight+1)
+	if validators[findValidator(validators, pubKey)].VotingPower != 1000 {
+		t.Errorf("Expected the power to be unchanged until the block after next")
+	}
+	validators = ValidatorsAt(state.GenesisValidators(), nil, powerChanges,
+		state.LastBlockHeight+2)
+	if validators[findValidator(validators, pubKey)].VotingPower != 1600 {
+		t.Errorf("Expected the bond to add to the validator's power")
+	}
+	changes := ValidatorChangesAfter(state.GenesisValidators(), nil, powerChanges,
+		state.LastBlockHeight+1)
+	if len(changes) != 1 || changes[0].VotingPower != 1600 {
+		t.Errorf("Expected a change to the validator's power, got %v", changes)
+	}
+
+	// A later block can bond again
+	state.LastBlockHeight += 1
+	if err := execTxWithState(state, bond(500, 0, 2), true); err != nil {
+		t.Fatalf("Got error in executing bond transaction, %v", err)
+	}
+	if bond := state.GetValidatorBond(pubKey.Address()); bond == nil ||
+		bond.Amount != 1100 || len(bond.UnbondTo) != 2 {
+		t.Errorf("Expected the bonds to accumulate, got %v", bond)
+	}
+}
+
+func TestUnbondTx(t *testing.T) {
+	state, _, privValidators := RandGenesisState(1, true, 1000, 3, false, 1000)
+	privVal := privValidators[0]
+	pubKey := privVal.PubKey.(crypto.PubKeyEd25519)
+	state.LastBlockHeight = 2
+	state.UpdateValidatorBond(&ValidatorBond{
+		PubKey:   pubKey,
+		Amount:   200,
+		UnbondTo: []*txs.TxOutput{{Address: pubKey.Address(), Amount: 200}},
+		Height:   1,
+	})
+	unbond := func(height int) *txs.UnbondTx {
+		tx := &txs.UnbondTx{Address: pubKey.Address(), Height: height}
+		tx.Signature = privVal.PrivKey.Sign(acm.SignBytes(state.ChainID, tx)).(crypto.SignatureEd25519)
+		return tx
+	}
+
+	// The height must be after the bond
+	if err := execTxWithState(state, unbond(1), true); err == nil {
+		t.Errorf("Expected unbonding at the bond height to fail")
+	}
+	if err := execTxWithState(state, unbond(2), true); err != nil {
+		t.Fatalf("Got error in executing unbond transaction, %v", err)
+	}
+	if state.GetValidatorBond(pubKey.Address()) != nil {
+		t.Errorf("Expected the bond to be removed")
+	}
+	changes := ValidatorChangesAfter(state.GenesisValidators(), nil,
+		state.GetValidatorPowerChanges(), state.LastBlockHeight+1)
+	if len(changes) != 1 || changes[0].VotingPower != 0 ||
+		!bytes.Equal(changes[0].Address, pubKey.Address()) {
+		t.Errorf("Expected a change removing the validator, got %v", changes)
+	}
+	if err := execTxWithState(state, unbond(2), true); err != txs.ErrTxInvalidAddress {
+		t.Errorf("Expected unbonding an unbonded validator to fail, got %v", err)
+	}
+
+	// The coins are held for the unbonding period
+	releaseHeight := state.LastBlockHeight + 1 + unbondingPeriodBlocks
+	if released := state.ReleaseUnbondings(releaseHeight - 1); len(released) != 0 {
+		t.Errorf("Expected nothing to be released before the unbonding period")
+	}
+	released := state.ReleaseUnbondings(releaseHeight)
+	if len(released) != 1 {
+		t.Fatalf("Expected the unbonding to be released, got %v", released)
+	}
+	if acc := state.GetAccount(pubKey.Address()); acc == nil || acc.Balance != 200 {
+		t.Errorf("Expected the bonded coins to be returned, got %v", acc)
+	}
+	if released := state.ReleaseUnbondings(releaseHeight); len(released) != 0 {
+		t.Errorf("Expected the unbonding to be released once, got %v", released)
+	}
+}
+
+func TestDupeoutTx(t *testing.T) {
+	state, _, privValidators := RandGenesisState(1, true, 1000, 3, false, 1000)
+	state.LastBlockHeight = 1
+	privVal := privValidators[0]
+	pubKey := privVal.PubKey.(crypto.PubKeyEd25519)
+	address := pubKey.Address()
+	state.UpdateValidatorBond(&ValidatorBond{
+		PubKey:   pubKey,
+		Amount:   300,
+		UnbondTo: []*txs.TxOutput{{Address: address, Amount: 300}},
+		Height:   1,
+	})
+	state.AddUnbonding(&Unbonding{
+		ReleaseHeight: 100,
+		Address:       address,
+		UnbondTo:      []*txs.TxOutput{{Address: address, Amount: 200}},
+	})
+	vote := func(blockHash string, privKey crypto.PrivKey) tm_types.Vote {
+		vote := tm_types.Vote{
+			ValidatorAddress: address,
+			Height:           1,
+			Type:             tm_types.VoteTypePrecommit,
+			BlockID:          tm_types.BlockID{Hash: []byte(blockHash)},
+		}
+		vote.Signature = privKey.Sign(acm.SignBytes(state.ChainID, &vote))
+		return vote
+	}
+
+	// The votes must conflict
+	tx := &txs.DupeoutTx{
+		Address: address,
+		VoteA:   vote("blockA", privVal.PrivKey),
+		VoteB:   vote("blockA", privVal.PrivKey),
+	}
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected votes for the same block not to be evidence")
+	}
+	// and be signed by the validator
+	tx.VoteB = vote("blockB", privValidators[1].PrivKey)
+	if err := execTxWithState(state, tx, true); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected invalid signature error, got %v", err)
+	}
+
+	tx.VoteB = vote("blockB", privVal.PrivKey)
+	if err := execTxWithState(state, tx, true); err != nil {
+		t.Fatalf("Got error in executing dupeout transaction, %v", err)
+	}
+	if bond := state.GetValidatorBond(address); bond.Amount != 285 ||
+		bond.UnbondTo[0].Amount != 285 {
+		t.Errorf("Expected 5%% of the bond to be slashed, got %v", bond)
+	}
+	if unbondings := state.GetUnbondings(address); len(unbondings) != 1 ||
+		unbondings[0].UnbondTo[0].Amount != 190 {
+		t.Errorf("Expected 5%% of the unbonding to be slashed, got %v", unbondings)
+	}
+	jail := state.GetValidatorJail(address)
+	if jail == nil || !jail.Jailed || jail.Power != 985 ||
+		jail.ReleaseHeight != 2+defaultSlashingParams.JailBlocks {
+		t.Fatalf("Expected the validator to be jailed, got %v", jail)
+	}
+	validators := ValidatorsAt(state.GenesisValidators(), nil,
+		state.GetValidatorPowerChanges(), state.LastBlockHeight+2)
+	if len(validators) != 2 || findValidator(validators, pubKey) >= 0 {
+		t.Errorf("Expected the validator to be removed, got %v", validators)
+	}
+
+	// The same offence is only punished once
+	if err := execTxWithState(state, tx, true); err == nil {
+		t.Errorf("Expected the evidence not to be accepted twice")
+	}
+
+	// A jailed validator cannot bond, but can unbond
+	state.UpdateAccount(&acm.Account{
+		Address:     address,
+		Balance:     100,
+		Permissions: ptypes.DefaultAccountPermissions,
+	})
+	bondTx := &txs.BondTx{
+		PubKey: pubKey,
+		Inputs: []*txs.TxInput{{
+			Address:  address,
+			Amount:   1,
+			Sequence: 1,
+			PubKey:   pubKey,
+		}},
+		UnbondTo: []*txs.TxOutput{{Address: address, Amount: 1}},
+	}
+	signBytes := acm.SignBytes(state.ChainID, bondTx)
+	bondTx.Signature = privVal.PrivKey.Sign(signBytes).(crypto.SignatureEd25519)
+	bondTx.Inputs[0].Signature = privVal.PrivKey.Sign(signBytes)
+	if err := execTxWithState(state, bondTx, true); err == nil {
+		t.Errorf("Expected bonding while jailed to fail")
+	}
+	unbondTx := &txs.UnbondTx{Address: address, Height: 2}
+	unbondTx.Signature = privVal.PrivKey.Sign(acm.SignBytes(state.ChainID,
+		unbondTx)).(crypto.SignatureEd25519)
+	if err := execTxWithState(state, unbondTx, true); err != nil {
+		t.Fatalf("Got error in executing unbond transaction, %v", err)
+	}
+	if jail := state.GetValidatorJail(address); jail.Jailed {
+		t.Errorf("Expected unbonding to leave jail")
+	}
+	if state.GetValidatorBond(address) != nil || len(state.GetUnbondings(address)) != 2 {
+		t.Errorf("Expected the slashed bond to be unbonded")
+	}
+}
+
+func TestDowntimeJailing(t *testing.T) {
+	state, _, privValidators := RandGenesisState(1, true, 1000, 4, false, 1000)
+	state.slashingParams = &genesis.SlashingParams{
+		DowntimeWindow:     10,
+		MaxMissedBlocks:    2,
+		DowntimeJailBlocks: 5,
+	}
+	address := privValidators[0].PubKey.Address()
+	var signers [][]byte
+	for _, privVal := range privValidators[1:] {
+		signers = append(signers, privVal.PubKey.Address())
+	}
+	record := func(height int) {
+		cache := NewBlockCache(state)
+		RecordCommitSigners(cache, height, signers, nil, logger)
+		cache.Sync()
+	}
+
+	record(1)
+	record(2)
+	if missed := state.GetMissedBlocks(address); missed == nil || missed.Missed != 2 {
+		t.Fatalf("Expected 2 missed blocks, got %v", missed)
+	}
+	if missed := state.GetMissedBlocks(signers[0]); missed != nil {
+		t.Errorf("Expected a signer to have missed no blocks, got %v", missed)
+	}
+	if state.GetValidatorJail(address) != nil {
+		t.Errorf("Expected the validator not to be jailed at the limit")
+	}
+	record(3)
+	jail := state.GetValidatorJail(address)
+	if jail == nil || !jail.Jailed || jail.Reason != JailReasonDowntime ||
+		jail.Power != 1000 || jail.ReleaseHeight != state.LastBlockHeight+1+5 {
+		t.Fatalf("Expected the validator to be jailed, got %v", jail)
+	}
+	validators := ValidatorsAt(state.GenesisValidators(), nil,
+		state.GetValidatorPowerChanges(), state.LastBlockHeight+2)
+	if len(validators) != 3 {
+		t.Errorf("Expected the validator to be removed, got %v", validators)
+	}
+	if missed := state.GetMissedBlocks(address); missed.Missed != 0 {
+		t.Errorf("Expected the count to start again once jailed, got %v", missed)
+	}
+
+	// The count starts again with each window
+	state.UpdateMissedBlocks(&MissedBlocks{Address: signers[0], Missed: 2})
+	cache := NewBlockCache(state)
+	RecordCommitSigners(cache, 10, signers[1:], nil, logger)
+	cache.Sync()
+	if missed := state.GetMissedBlocks(signers[0]); missed.WindowStart != 10 ||
+		missed.Missed != 1 {
+		t.Errorf("Expected the count to start again in a new window, got %v", missed)
+	}
+
+	// Governance can release a validator early
+	tx := &txs.GovTx{Unjail: [][]byte{address}}
+	if err := tx.ValidateBasic(); err != nil {
+		t.Fatalf("Expected unjailing to be a valid GovTx, got %v", err)
+	}
+	cache = NewBlockCache(state)
+	if err := unjailValidator(cache, address); err != nil {
+		t.Fatalf("Got error unjailing validator, %v", err)
+	}
+	cache.Sync()
+	if jail := state.GetValidatorJail(address); jail.Jailed {
+		t.Errorf("Expected the validator to be released")
+	}
+	validators = ValidatorsAt(state.GenesisValidators(), nil,
+		state.GetValidatorPowerChanges(), state.LastBlockHeight+2)
+	if i := findValidatorByAddress(validators, address); i < 0 ||
+		validators[i].VotingPower != 1000 {
+		t.Errorf("Expected the validator's power to be restored, got %v", validators)
+	}
+	if err := unjailValidator(NewBlockCache(state), address); err == nil {
+		t.Errorf("Expected unjailing a validator that is not jailed to fail")
+	}
+}
+
+func TestProposalTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	addresses := make([][]byte, len(privAccounts))
+	for i, privAccount := range privAccounts {
+		addresses[i] = privAccount.PubKey.Address()
+	}
+	proposalTx := func(i int, proposal *txs.Proposal, proposalHash []byte) *txs.ProposalTx {
+		tx := &txs.ProposalTx{
+			Input: &txs.TxInput{
+				Address:  addresses[i],
+				Amount:   1,
+				Sequence: state.GetAccount(addresses[i]).Sequence + 1,
+				PubKey:   privAccounts[i].PubKey,
+			},
+			ProposalHash: proposalHash,
+			Proposal:     proposal,
+		}
+		tx.Input.Signature = privAccounts[i].Sign(state.ChainID, tx)
+		return tx
+	}
+	grantVote := func(i int) {
+		acc := state.GetAccount(addresses[i])
+		acc.Permissions.Base.Set(ptypes.Vote, true)
+		state.UpdateAccount(acc)
+	}
+	// A payment from the second account, whose sequence is set on execution
+	payment := func(amount int64) *txs.Proposal {
+		sendTx := txs.NewSendTx()
+		sendTx.Inputs = append(sendTx.Inputs, &txs.TxInput{
+			Address: addresses[1],
+			Amount:  amount,
+		})
+		sendTx.AddOutput(addresses[2], amount)
+		return &txs.Proposal{Name: "payment", Txs: []txs.Tx{sendTx}}
+	}
+	proposal := payment(10)
+	proposalHash := txs.ProposalHash(state.ChainID, proposal)
+
+	if err := execTxWithState(state, proposalTx(0, proposal, nil), true); err == nil {
+		t.Errorf("Expected proposing without a proposal threshold to fail")
+	}
+	state.ProposalThreshold = 2
+	if err := execTxWithState(state, proposalTx(0, proposal, nil), true); err == nil {
+		t.Errorf("Expected proposing without Vote permission to fail")
+	}
+	grantVote(0)
+	grantVote(1)
+	hash := state.Hash()
+
+	if err := execTxWithState(state, proposalTx(0, proposal, nil), true); err != nil {
+		t.Fatalf("Got error in executing proposal transaction, %v", err)
+	}
+	ballot := state.GetBallot(proposalHash)
+	if ballot == nil || ballot.State != ProposalStateVoting || len(ballot.Votes) != 1 {
+		t.Fatalf("Expected the proposal to be voting with the vote of its proposer, got %v", ballot)
+	}
+	if bytes.Equal(hash, state.Hash()) {
+		t.Errorf("Expected the proposal to change the state hash")
+	}
+	if err := execTxWithState(state, proposalTx(1, proposal, nil), true); err == nil {
+		t.Errorf("Expected making the same proposal again to fail")
+	}
+	if err := execTxWithState(state, proposalTx(0, nil, proposalHash), true); err == nil {
+		t.Errorf("Expected voting twice to fail")
+	}
+	if err := execTxWithState(state, proposalTx(2, nil, proposalHash), true); err == nil {
+		t.Errorf("Expected voting without Vote permission to fail")
+	}
+
+	// The second vote, of the input of the batch, passes the proposal
+	balance1 := state.GetAccount(addresses[1]).Balance
+	balance2 := state.GetAccount(addresses[2]).Balance
+	sequence1 := state.GetAccount(addresses[1]).Sequence
+	if err := execTxWithState(state, proposalTx(1, nil, proposalHash), true); err != nil {
+		t.Fatalf("Got error in executing vote transaction, %v", err)
+	}
+	if ballot = state.GetBallot(proposalHash); ballot.State != ProposalStateExecuted {
+		t.Fatalf("Expected the proposal to be executed, got %v: %s", ballot.State, ballot.Error)
+	}
+	acc1 := state.GetAccount(addresses[1])
+	if acc1.Balance != balance1-11 || acc1.Sequence != sequence1+2 {
+		t.Errorf("Expected the voter to pay the fee and the payment, got balance "+
+			"%v and sequence %v", acc1.Balance, acc1.Sequence)
+	}
+	if state.GetAccount(addresses[2]).Balance != balance2+10 {
+		t.Errorf("Expected the payment to be made")
+	}
+	grantVote(2)
+	if err := execTxWithState(state, proposalTx(2, nil, proposalHash), true); err == nil {
+		t.Errorf("Expected voting for an executed proposal to fail")
+	}
+
+	// A batch that fails is not applied but the vote stands
+	proposal = payment(1 << 40)
+	proposalHash = txs.ProposalHash(state.ChainID, proposal)
+	for _, i := range []int{0, 1} {
+		var tx *txs.ProposalTx
+		if i == 0 {
+			tx = proposalTx(i, proposal, nil)
+		} else {
+			tx = proposalTx(i, nil, proposalHash)
+		}
+		if err := execTxWithState(state, tx, true); err != nil {
+			t.Fatalf("Got error in executing proposal transaction, %v", err)
+		}
+	}
+	if ballot = state.GetBallot(proposalHash); ballot.State != ProposalStateFailed || ballot.Error == "" {
+		t.Errorf("Expected the proposal to fail, got %v", ballot.State)
+	}
+	if state.GetAccount(addresses[1]).Balance != acc1.Balance-1 {
+		t.Errorf("Expected the voter to pay only the fee for a failed proposal")
+	}
+}
+
+func TestGovTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	state.ProposalThreshold = 2
+	for _, privAccount := range privAccounts {
+		acc := state.GetAccount(privAccount.PubKey.Address())
+		acc.Permissions.Base.Set(ptypes.Vote, true)
+		state.UpdateAccount(acc)
+	}
+	vote := func(i int, proposal *txs.Proposal, proposalHash []byte) error {
+		address := privAccounts[i].PubKey.Address()
+		tx := &txs.ProposalTx{
+			Input: &txs.TxInput{
+				Address:  address,
+				Amount:   1,
+				Sequence: state.GetAccount(address).Sequence + 1,
+				PubKey:   privAccounts[i].PubKey,
+			},
+			ProposalHash: proposalHash,
+			Proposal:     proposal,
+		}
+		tx.Input.Signature = privAccounts[i].Sign(state.ChainID, tx)
+		return execTxWithState(state, tx, true)
+	}
+	gasLimit := int64(5000000)
+	maxTxSize := 1 << 16
+	globalPermissions := ptypes.BasePermissions{
+		Perms:  ptypes.Send | ptypes.Call,
+		SetBit: ptypes.AllPermFlags,
+	}
+	govTx := txs.NewGovTx(&txs.ChainParams{
+		GasLimit:          &gasLimit,
+		MaxTxSize:         &maxTxSize,
+		Fees:              &genesis.FeeParams{InitialBaseFee: 3, TargetTxsPerBlock: 10},
+		GlobalPermissions: &globalPermissions,
+	})
+
+	if err := execTxWithState(state, govTx, true); err == nil {
+		t.Errorf("Expected executing a GovTx outside a proposal to fail")
+	}
+	proposal := &txs.Proposal{Name: "params", Txs: []txs.Tx{govTx}}
+	proposalHash := txs.ProposalHash(state.ChainID, proposal)
+	if err := vote(0, proposal, nil); err != nil {
+		t.Fatalf("Got error in executing proposal transaction, %v", err)
+	}
+	if state.GetGasLimit() != defaultGasLimit {
+		t.Errorf("Expected the gas limit to be unchanged before the proposal passes")
+	}
+	if err := vote(1, nil, proposalHash); err != nil {
+		t.Fatalf("Got error in executing vote transaction, %v", err)
+	}
+	if ballot := state.GetBallot(proposalHash); ballot.State != ProposalStateExecuted {
+		t.Fatalf("Expected the proposal to be executed, got %v: %s", ballot.State, ballot.Error)
+	}
+	if state.GetGasLimit() != gasLimit || state.MaxTxSize != maxTxSize {
+		t.Errorf("Expected gas limit %v and max tx size %v, got %v and %v",
+			gasLimit, maxTxSize, state.GetGasLimit(), state.MaxTxSize)
+	}
+	if state.FeeParams == nil || state.FeeParams.TargetTxsPerBlock != 10 || state.BaseFee != 3 {
+		t.Errorf("Expected the fee parameters to be set and the base fee to restart, got %v and %v",
+			state.FeeParams, state.BaseFee)
+	}
+	if state.GetAccount(ptypes.GlobalPermissionsAddress).Permissions.Base != globalPermissions {
+		t.Errorf("Expected the global permissions to be replaced")
+	}
+
+	// Parameters that cannot be set fail the proposal
+	threshold := 0
+	proposal = &txs.Proposal{Name: "no governance", Txs: []txs.Tx{
+		txs.NewGovTx(&txs.ChainParams{ProposalThreshold: &threshold})}}
+	if err := vote(0, proposal, nil); err == nil {
+		t.Errorf("Expected proposing a proposal threshold of 0 to fail")
+	}
+	proposal = &txs.Proposal{Name: "bad gas", Txs: []txs.Tx{
+		txs.NewGovTx(&txs.ChainParams{GasSchedule: &genesis.GasSchedule{Base: "unknown"}})}}
+	proposalHash = txs.ProposalHash(state.ChainID, proposal)
+	if err := vote(0, proposal, nil); err != nil {
+		t.Fatalf("Got error in executing proposal transaction, %v", err)
+	}
+	if err := vote(1, nil, proposalHash); err != nil {
+		t.Fatalf("Got error in executing vote transaction, %v", err)
+	}
+	if ballot := state.GetBallot(proposalHash); ballot.State != ProposalStateFailed {
+		t.Errorf("Expected a proposal of an unknown gas schedule to fail, got %v", ballot.State)
+	}
+}
+
+func TestUpgradeGovTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(1, true, 1000, 1, true, 1000)
+	state.ProposalThreshold = 1
+	state.LastBlockHeight = 10
+	acc := state.GetAccount(privAccounts[0].PubKey.Address())
+	acc.Permissions.Base.Set(ptypes.Vote, true)
+	state.UpdateAccount(acc)
+	propose := func(upgrade *txs.UpgradePlan) *Ballot {
+		proposal := &txs.Proposal{Name: "upgrade", Txs: []txs.Tx{
+			txs.NewGovTx(&txs.ChainParams{Upgrade: upgrade})}}
+		tx := &txs.ProposalTx{
+			Input: &txs.TxInput{
+				Address:  acc.Address,
+				Amount:   1,
+				Sequence: state.GetAccount(acc.Address).Sequence + 1,
+				PubKey:   privAccounts[0].PubKey,
+			},
+			Proposal: proposal,
+		}
+		tx.Input.Signature = privAccounts[0].Sign(state.ChainID, tx)
+		if err := execTxWithState(state, tx, true); err != nil {
+			t.Fatalf("Got error in executing proposal transaction, %v", err)
+		}
+		return state.GetBallot(txs.ProposalHash(state.ChainID, proposal))
+	}
+
+	// Too late to halt before the block executing
+	if ballot := propose(&txs.UpgradePlan{Name: "0.18.0", Height: 11}); ballot.State != ProposalStateFailed {
+		t.Errorf("Expected an upgrade at the height executing to fail, got %v", ballot.State)
+	}
+	if ballot := propose(&txs.UpgradePlan{Name: "0.18.0", Height: 12}); ballot.State != ProposalStateExecuted {
+		t.Fatalf("Expected the upgrade to be planned, got %v: %s", ballot.State, ballot.Error)
+	}
+	if state.UpgradeDue() || state.CheckUpgrade("0.17.1") != nil {
+		t.Errorf("Expected the upgrade not to be due before the block before it")
+	}
+	state.LastBlockHeight = 11
+	state.Save()
+	state = LoadState(state.DB)
+	if !state.UpgradeDue() {
+		t.Fatalf("Expected the upgrade to be due after the block before it")
+	}
+	if state.CheckUpgrade("0.17.1") == nil {
+		t.Errorf("Expected a binary of another version to be stopped at the upgrade")
+	}
+	if err := state.CheckUpgrade("0.18.0"); err != nil {
+		t.Errorf("Expected the binary of the upgrade to take over, got %v", err)
+	}
+
+	state.LastBlockHeight = 10
+	propose(&txs.UpgradePlan{})
+	if state.Upgrade != nil {
+		t.Errorf("Expected an upgrade at height 0 to cancel the upgrade")
+	}
+}
+
+func TestIdentifyTx(t *testing.T) {
+	state, privAccounts, privValidators := RandGenesisState(1, true, 1000, 1, true, 1000)
+	privVal := privValidators[0]
+	address := privVal.PubKey.Address()
+	makeTx := func(privKey crypto.PrivKey, sequence int, netAddress string) *txs.IdentifyTx {
+		tx := &txs.IdentifyTx{
+			PubKey:     privKey.PubKey().(crypto.PubKeyEd25519),
+			Sequence:   sequence,
+			NodeID:     make([]byte, 32),
+			NetAddress: netAddress,
+			Moniker:    "validator0",
+		}
+		tx.Signature = privKey.Sign(acm.SignBytes(state.ChainID, tx)).(crypto.SignatureEd25519)
+		return tx
+	}
+
+	// Only validators can register nodes
+	if err := execTxWithState(state, makeTx(privAccounts[0].PrivKey, 1, "10.0.0.1:46656"),
+		true); err == nil {
+		t.Errorf("Expected registering a node for an account that is not a validator to fail")
+	}
+	tx := makeTx(privVal.PrivKey, 1, "10.0.0.1:46656")
+	tx.Moniker = "validator1"
+	if err := execTxWithState(state, tx, true); err != txs.ErrTxInvalidSignature {
+		t.Errorf("Expected invalid signature error, got %v", err)
+	}
+	if err := execTxWithState(state, makeTx(privVal.PrivKey, 1, "10.0.0.1"), true); err == nil {
+		t.Errorf("Expected registering a net address without a port to fail")
+	}
+
+	if err := execTxWithState(state, makeTx(privVal.PrivKey, 1, "10.0.0.1:46656"),
+		true); err != nil {
+		t.Fatalf("Unexpected error registering node: %v", err)
+	}
+	entry := state.GetNodeRegEntry(address)
+	if entry == nil || entry.NetAddress != "10.0.0.1:46656" || entry.Moniker != "validator0" ||
+		entry.Sequence != 1 || entry.Height != state.LastBlockHeight+1 {
+		t.Fatalf("Unexpected node registry entry %v", entry)
+	}
+
+	// The registered identity can only be replaced by an IdentifyTx with the
+	// next sequence, so it cannot be replayed
+	if err := execTxWithState(state, makeTx(privVal.PrivKey, 1, "10.0.0.2:46656"),
+		true); err == nil {
+		t.Errorf("Expected reusing the sequence of the registered node to fail")
+	}
+	if err := execTxWithState(state, makeTx(privVal.PrivKey, 2, "10.0.0.2:46656"),
+		true); err != nil {
+		t.Fatalf("Unexpected error replacing node: %v", err)
+	}
+	if entry = state.GetNodeRegEntry(address); entry.NetAddress != "10.0.0.2:46656" {
+		t.Errorf("Expected the node to be replaced, got %v", entry)
+	}
+	nodes := 0
+	state.GetNodeRegistry().Iterate(func(key, value []byte) bool {
+		nodes++
+		return false
+	})
+	if nodes != 1 {
+		t.Errorf("Expected one node in the registry, got %v", nodes)
+	}
+}
+
+func TestRelayTx(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(2, true, 1000, 1, true, 1000)
+	relayer := privAccounts[0]
+
+	// The other chain, with storage to relay
+	otherChainID := "other_chain"
+	other, otherAccounts, _ := RandGenesisState(1, true, 1000, 1, true, 1000)
+	other.ChainID = otherChainID
+	address := otherAccounts[0].PubKey.Address()
+	key, value := word256.Int64ToWord256(1), word256.Int64ToWord256(42)
+	otherCache := NewBlockCache(other)
+	otherCache.SetStorage(word256.LeftPadWord256(address), key, value)
+	otherCache.Sync()
+	other.Save()
+	item, err := other.GetStorageWithProof(address, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privKeys := make(map[string]crypto.PrivKey)
+	makeValidators := func(n int) []*tm_types.Validator {
+		validators := make([]*tm_types.Validator, n)
+		for i := range validators {
+			privKey := crypto.GenPrivKeyEd25519()
+			validators[i] = tm_types.NewValidator(privKey.PubKey(), 10)
+			privKeys[string(validators[i].Address)] = privKey
+		}
+		return validators
+	}
+	// Makes a RelayTx of the header at height signed by validators
+	makeTx := func(height int, validators []*tm_types.Validator,
+		storage ...*core_types.StorageItemWithProof) *txs.RelayTx {
+		set := tm_types.NewValidatorSet(validators)
+		header := &tm_types.Header{
+			ChainID:        otherChainID,
+			Height:         height,
+			Time:           time.Unix(int64(height), 0),
+			ValidatorsHash: set.Hash(),
+			AppHash:        other.Hash(),
+		}
+		blockID := tm_types.BlockID{Hash: header.Hash()}
+		commit := &tm_types.Commit{
+			BlockID:    blockID,
+			Precommits: make([]*tm_types.Vote, set.Size()),
+		}
+		for i, validator := range set.Validators {
+			vote := &tm_types.Vote{
+				ValidatorAddress: validator.Address,
+				ValidatorIndex:   i,
+				Height:           height,
+				Type:             tm_types.VoteTypePrecommit,
+				BlockID:          blockID,
+			}
+			vote.Signature = privKeys[string(validator.Address)].Sign(
+				tm_types.SignBytes(otherChainID, vote))
+			commit.Precommits[i] = vote
+		}
+		acc := state.GetAccount(relayer.PubKey.Address())
+		tx := &txs.RelayTx{
+			Input: &txs.TxInput{
+				Address:  acc.Address,
+				Amount:   1,
+				Sequence: acc.Sequence + 1,
+			},
+			ChainID:    otherChainID,
+			Header:     header,
+			BlockID:    blockID,
+			Commit:     commit,
+			Validators: validators,
+			Storage:    storage,
+		}
+		tx.Sign(state.ChainID, relayer)
+		return tx
+	}
+	validators := makeValidators(3)
+	height := item.Height + 1
+
+	// Only an account with the root permission relays a new chain
+	if err := execTxWithState(state, makeTx(height, validators, item), true); err == nil {
+		t.Fatal("Expected relaying a new chain without the root permission to fail")
+	}
+	acc := state.GetAccount(relayer.PubKey.Address())
+	acc.Permissions.Base.Set(ptypes.Root, true)
+	state.UpdateAccount(acc)
+
+	forged := *item
+	forged.StorageItem.Value = word256.Int64ToWord256(43).Bytes()
+	if err := execTxWithState(state, makeTx(height, validators, &forged), true); err == nil {
+		t.Fatal("Expected relaying storage that is not proved to fail")
+	}
+	if err := execTxWithState(state, makeTx(height, validators, item), true); err != nil {
+		t.Fatal(err)
+	}
+	chain := state.GetRelayedChain(otherChainID)
+	if chain == nil || chain.Height != height ||
+		!bytes.Equal(chain.Registrar, relayer.PubKey.Address()) {
+		t.Fatalf("Unexpected relayed chain %v", chain)
+	}
+	header := state.GetRelayedHeader(otherChainID, height)
+	if header == nil || !bytes.Equal(header.AppHash, other.Hash()) {
+		t.Fatalf("Unexpected relayed header %v", header)
+	}
+	if relayed, ok := state.GetRelayedStorage(otherChainID, height, address,
+		key); !ok || relayed != value {
+		t.Errorf("Expected storage %X to be relayed, got %X", value, relayed)
+	}
+
+	// Headers are relayed in order
+	if err := execTxWithState(state, makeTx(height-1, validators), true); err == nil {
+		t.Error("Expected relaying a header below the latest to fail")
+	}
+	// The trusted validators must hand over to new ones
+	if err := execTxWithState(state, makeTx(height+1, makeValidators(3)), true); err == nil {
+		t.Error("Expected relaying a header of validators not handed over to to fail")
+	}
+	handedOver := append(makeValidators(1), validators...)
+	if err := execTxWithState(state, makeTx(height+1, handedOver), true); err != nil {
+		t.Fatal(err)
+	}
+	if chain = state.GetRelayedChain(otherChainID); chain.Height != height+1 ||
+		len(chain.Validators) != 4 {
+		t.Errorf("Expected the validators of the chain to change, got %v", chain)
+	}
+
+	// The Relay SNative reads the relayed storage
+	relayContract := evm.SNativeContracts()["Relay"]
+	function, err := relayContract.FunctionByName("relayedStorage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := append(word256.RightPadWord256([]byte(otherChainID)).Bytes(),
+		word256.Int64ToWord256(int64(height)).Bytes()...)
+	args = append(args, word256.LeftPadWord256(address).Bytes()...)
+	args = append(args, key.Bytes()...)
+	gas := int64(1000)
+	output, err := function.F(NewTxCache(NewBlockCache(state)), nil, args, &gas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, value.Bytes()) {
+		t.Errorf("Expected the Relay SNative to return %X, got %X", value, output)
+	}
+}
+
+func TestAttestTx(t *testing.T) {
+	state, privAccounts, privValidators := RandGenesisState(1, true, 1000, 3, false, 1000)
+	state.LastBlockHeight = 5
+	contract := []byte("bridged_contract_abc")
+	topic := word256.LeftPadWord256([]byte("Transfer(address,uint256)"))
+	blockCache := NewBlockCache(state)
+	setBridgeParams(blockCache, &txs.BridgeParams{
+		Designate: []*txs.BridgeEventSpec{{Address: contract, Topic: topic}},
+	})
+	blockCache.Sync()
+
+	bridgeKeys := make([][]byte, 4)
+	for i := range bridgeKeys {
+		bridgeKeys[i] = sha3.Sha3([]byte{byte(i)})
+	}
+	event := core_types.BridgeEvent{
+		Height:  3,
+		Address: contract,
+		Topics:  []word256.Word256{topic, word256.Int64ToWord256(1)},
+		Data:    []byte{0xAB},
+	}
+	makeTx := func(privKey crypto.PrivKey, event core_types.BridgeEvent,
+		bridgeKey []byte) *txs.AttestTx {
+		tx := &txs.AttestTx{
+			PubKey: privKey.PubKey().(crypto.PubKeyEd25519),
+			Event:  event,
+		}
+		var err error
+		if tx.EthSignature, err = txs.SignAttestation(state.ChainID, event, bridgeKey); err != nil {
+			t.Fatal(err)
+		}
+		tx.Signature = privKey.Sign(acm.SignBytes(state.ChainID, tx)).(crypto.SignatureEd25519)
+		return tx
+	}
+
+	// Only validators attest, and only designated events that have been emitted
+	if err := execTxWithState(state, makeTx(privAccounts[0].PrivKey, event, bridgeKeys[0]),
+		true); err == nil {
+		t.Errorf("Expected an attestation by an account that is not a validator to fail")
+	}
+	undesignated := event
+	undesignated.Topics = []word256.Word256{word256.Int64ToWord256(2)}
+	if err := execTxWithState(state, makeTx(privValidators[0].PrivKey, undesignated,
+		bridgeKeys[0]), true); err == nil {
+		t.Errorf("Expected an attestation of an event that is not designated to fail")
+	}
+	future := event
+	future.Height = 6
+	if err := execTxWithState(state, makeTx(privValidators[0].PrivKey, future,
+		bridgeKeys[0]), true); err == nil {
+		t.Errorf("Expected an attestation of an event not yet emitted to fail")
+	}
+
+	id := txs.AttestationID(state.ChainID, event)
+	for i, privVal := range privValidators {
+		if err := execTxWithState(state, makeTx(privVal.PrivKey, event, bridgeKeys[i]),
+			true); err != nil {
+			t.Fatalf("Unexpected error attesting event: %v", err)
+		}
+		attestation := state.GetAttestation(id)
+		if attestation == nil || len(attestation.Signatures) != i+1 ||
+			attestation.Power != int64(1000*(i+1)) || attestation.TotalPower != 3000 {
+			t.Fatalf("Unexpected attestation %v", attestation)
+		}
+		// Two thirds of the power is not enough
+		if complete := attestation.CompletedAt != 0; complete != (i == 2) {
+			t.Errorf("Expected attestation by %v validators to be complete: %v, got "+
+				"completed at %v", i+1, i == 2, attestation.CompletedAt)
+		}
+		if i == 0 {
+			if err := execTxWithState(state, makeTx(privVal.PrivKey, event, bridgeKeys[i]),
+				true); err == nil {
+				t.Errorf("Expected attesting an event twice to fail")
+			}
+		}
+	}
+	attestation := state.GetAttestation(id)
+	if attestation.CompletedAt != state.LastBlockHeight+1 {
+		t.Errorf("Expected attestation to complete at %v, got %v",
+			state.LastBlockHeight+1, attestation.CompletedAt)
+	}
+	for i, signature := range attestation.Signatures {
+		pubkey, err := secp256k1.PubkeyFromSeckey(bridgeKeys[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(signature.EthAddress, sha3.Sha3(pubkey[1:])[12:]) ||
+			!bytes.Equal(state.GetBridgeKey(signature.Validator), signature.EthAddress) {
+			t.Errorf("Expected signature %v to be by bridge key %v", signature, i)
+		}
+	}
+	if n := len(state.GetAttestations(3)); n != 1 {
+		t.Errorf("Expected one attestation of events from height 3, got %v", n)
+	}
+	if n := len(state.GetAttestations(4)); n != 0 {
+		t.Errorf("Expected no attestations of events from height 4, got %v", n)
+	}
+
+	// A validator keeps the bridge key it first attested with
+	next := event
+	next.Height = 4
+	if err := execTxWithState(state, makeTx(privValidators[0].PrivKey, next,
+		bridgeKeys[3]), true); err == nil {
+		t.Errorf("Expected attesting with another bridge key to fail")
+	}
+
+	// Revoked events are no longer attested
+	blockCache = NewBlockCache(state)
+	setBridgeParams(blockCache, &txs.BridgeParams{
+		Revoke: []*txs.BridgeEventSpec{{Address: contract, Topic: topic}},
+	})
+	blockCache.Sync()
+	if events := state.GetDesignatedEvents(); len(events) != 1 ||
+		events[0].RevokedAt != state.LastBlockHeight+1 {
+		t.Fatalf("Unexpected designated events %v", events)
+	}
+	if err := execTxWithState(state, makeTx(privValidators[0].PrivKey, next,
+		bridgeKeys[0]), true); err == nil {
+		t.Errorf("Expected an attestation of a revoked event to fail")
+	}
+}
+
+/*
+A contract whose constructor stores 1 at slot 0 and whose code returns slot 0
+*/
+var privateStorageCode, _ = hex.DecodeString("6001600055600b6011600039600b6000f360005460005260206000f3")
+
+func TestPrivateTx(t *testing.T) {
+	genDoc, privAccounts, _ := RandGenesisDoc(1, true, 1000, 1, false, 1000)
+	memberState := MakeGenesisState(tdb.NewMemDB(), genDoc)
+	otherState := MakeGenesisState(tdb.NewMemDB(), genDoc)
+	key := sha3.Sha3([]byte("group key"))
+	privDB := tdb.NewMemDB()
+	privateStates := NewPrivateStates()
+	group, err := privateStates.AddGroup(key, privDB, genDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memberState.SetPrivateStates(privateStates)
+	// a node in another group does not execute the payload either
+	otherStates := NewPrivateStates()
+	if _, err := otherStates.AddGroup(sha3.Sha3([]byte("other key")),
+		tdb.NewMemDB(), genDoc); err != nil {
+		t.Fatal(err)
+	}
+	otherState.SetPrivateStates(otherStates)
+
+	acc0 := memberState.GetAccount(privAccounts[0].PubKey.Address())
+	tx, err := txs.NewPrivateTx(&txs.TxInput{
+		Address:  acc0.Address,
+		Amount:   10,
+		Sequence: acc0.Sequence + 1,
+	}, key, &txs.PrivatePayload{GasLimit: 100000, Data: privateStorageCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(genDoc.ChainID, privAccounts[0])
+	for _, s := range []*State{memberState, otherState} {
+		if err := execTxWithState(s, tx, true); err != nil {
+			t.Fatalf("Got error in executing PrivateTx, %v", err)
+		}
+	}
+	// Every node takes the fee and records the payload hash alike
+	if !bytes.Equal(memberState.Hash(), otherState.Hash()) {
+		t.Errorf("Nodes in and outside the private group differ on state")
+	}
+	if acc := otherState.GetAccount(acc0.Address); acc.Balance != acc0.Balance-10 ||
+		acc.Sequence != acc0.Sequence+1 {
+		t.Errorf("PrivateTx did not take its input, got %v", acc)
+	}
+	txHash := txs.TxHash(genDoc.ChainID, tx)
+	record := otherState.GetPrivateTxRecord(txHash)
+	if record == nil || !bytes.Equal(record.Group, group) ||
+		!bytes.Equal(record.PayloadHash, tx.PayloadHash()) {
+		t.Fatalf("Expected the PrivateTx to be recorded, got %v", record)
+	}
+
+	privateStates.Save()
+	receipt, err := privateStates.TxReceipt(group, txHash, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !receipt.Success || len(receipt.ContractAddress) != 20 {
+		t.Fatalf("Expected the payload to create a contract, got %v", receipt)
+	}
+	value, err := privateStates.GetStorage(group, receipt.ContractAddress,
+		word256.Zero256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != word256.Int64ToWord256(1) {
+		t.Errorf("Expected the private contract to store 1, got %X", value)
+	}
+	// The contract exists only in the private state of the group
+	if memberState.GetAccount(receipt.ContractAddress) != nil {
+		t.Errorf("Private contract was created on the chain")
+	}
+	if acc, _ := otherStates.GetAccount(group, receipt.ContractAddress); acc != nil {
+		t.Errorf("Private contract was created outside its group")
+	}
+	if _, err := otherStates.TxReceipt(otherStates.Groups()[0], txHash, ""); err == nil {
+		t.Errorf("Expected no receipt outside the group")
+	}
+
+	// The payload cannot be sent to another group without its key
+	forged := *tx
+	forged.Group = otherStates.Groups()[0]
+	forged.Input = &txs.TxInput{
+		Address:  acc0.Address,
+		Amount:   10,
+		Sequence: acc0.Sequence + 2,
+	}
+	forged.Sign(genDoc.ChainID, privAccounts[0])
+	if err := execTxWithState(otherState, &forged, true); err != nil {
+		t.Fatalf("Got error in executing PrivateTx, %v", err)
+	}
+	otherStates.Save()
+	if _, err := otherStates.TxReceipt(forged.Group,
+		txs.TxHash(genDoc.ChainID, &forged), ""); err == nil {
+		t.Errorf("Expected a payload of another group not to be executed")
+	}
+
+	// A restarted node loads the private state it saved
+	restarted := NewPrivateStates()
+	if _, err := restarted.AddGroup(key, privDB, genDoc); err != nil {
+		t.Fatal(err)
+	}
+	value, err = restarted.GetStorage(group, receipt.ContractAddress,
+		word256.Zero256)
+	if err != nil || value != word256.Int64ToWord256(1) {
+		t.Errorf("Expected the saved private state to be loaded, got %X, %v",
+			value, err)
+	}
+	if _, err := restarted.AddGroup(key, tdb.NewMemDB(), genDoc); err == nil {
+		t.Errorf("Expected a group added twice to fail")
+	}
+	if _, err := restarted.AddGroup(key[:16], tdb.NewMemDB(), genDoc); err == nil {
+		t.Errorf("Expected a short key to fail")
+	}
+}
@@ -31,15 +31,31 @@ type TxCache struct {
 	backend  *BlockCache
 	accounts map[Word256]vmAccountInfo
 	storages map[Tuple256]Word256
+	// Confidential entries set by the call, by key
+	confidential map[string][]byte
+	// Restore the entries of the maps above, while there are snapshots to
+	// revert to
+	journal   []func()
+	snapshots []txCacheSnapshot
+}
+
+type txCacheSnapshot struct {
+	// The length of the journal when the snapshot was taken
+	journal int
+	// The values of the accounts of the cache, which the VM changes in place
+	accounts map[*vm.Account]vm.Account
 }
 
 var _ vm.AppState = &TxCache{}
+var _ vm.RelayState = &TxCache{}
+var _ vm.ConfidentialState = &TxCache{}
 
 func NewTxCache(backend *BlockCache) *TxCache {
 	return &TxCache{
-		backend:  backend,
-		accounts: make(map[Word256]vmAccountInfo),
-		storages: make(map[Tuple256]Word256),
+		backend:      backend,
+		accounts:     make(map[Word256]vmAccountInfo),
+		storages:     make(map[Tuple256]Word256),
+		confidential: make(map[string][]byte),
 	}
 }
 
@@ -65,6 +81,7 @@ func (cache *TxCache) UpdateAccount(acc *vm.Account) {
 	if removed {
 		sanity.PanicSanity("UpdateAccount on a removed account")
 	}
+	cache.journalAccount(addr)
 	cache.accounts[addr] = vmAccountInfo{acc, false}
 }
 
@@ -74,6 +91,7 @@ func (cache *TxCache) RemoveAccount(acc *vm.Account) {
 	if removed {
 		sanity.PanicSanity("RemoveAccount on a removed account")
 	}
+	cache.journalAccount(addr)
 	cache.accounts[addr] = vmAccountInfo{acc, true}
 }
 
@@ -100,6 +118,7 @@ func (cache *TxCache) CreateAccount(creator *vm.Account) *vm.Account {
 				StorageRoot: nil,
 			},
 		}
+		cache.journalAccount(addr)
 		cache.accounts[addr] = vmAccountInfo{account, false}
 		return account
 	} else {
@@ -109,6 +128,24 @@ func (cache *TxCache) CreateAccount(creator *vm.Account) *vm.Account {
 	}
 }
 
+func (cache *TxCache) CreateAccountAt(creator *vm.Account, addr Word256) *vm.Account {
+	if cache.GetAccount(addr) != nil {
+		return nil
+	}
+	creator.Nonce += 1
+	account := &vm.Account{
+		Address:     addr,
+		Permissions: cache.GetAccount(ptypes.GlobalPermissionsAddress256).Permissions,
+		Other: vmAccountOther{
+			PubKey:      nil,
+			StorageRoot: nil,
+		},
+	}
+	cache.journalAccount(addr)
+	cache.accounts[addr] = vmAccountInfo{account, false}
+	return account
+}
+
 // TxCache.account
 //-------------------------------------
 // TxCache.storage
@@ -130,11 +167,144 @@ func (cache *TxCache) SetStorage(addr Word256, key Word256, value Word256) {
 	if removed {
 		sanity.PanicSanity("SetStorage() on a removed account")
 	}
-	cache.storages[Tuple256{addr, key}] = value
+	addrKey := Tuple256{addr, key}
+	if len(cache.snapshots) > 0 {
+		prev, ok := cache.storages[addrKey]
+		cache.journal = append(cache.journal, func() {
+			if ok {
+				cache.storages[addrKey] = prev
+			} else {
+				delete(cache.storages, addrKey)
+			}
+		})
+	}
+	cache.storages[addrKey] = value
 }
 
 // TxCache.storage
 //-------------------------------------
+// TxCache.relays
+
+// Relayed chains are only changed by RelayTxs, so they are read from the
+// backend
+
+func (cache *TxCache) GetRelayedHeight(chainID string) int {
+	chain := cache.backend.GetRelayedChain(chainID)
+	if chain == nil {
+		return 0
+	}
+	return chain.Height
+}
+
+func (cache *TxCache) GetRelayedAppHash(chainID string, height int) []byte {
+	header := cache.backend.GetRelayedHeader(chainID, height)
+	if header == nil {
+		return nil
+	}
+	return header.AppHash
+}
+
+func (cache *TxCache) GetRelayedStorage(chainID string, height int,
+	addr Word256, key Word256) (Word256, bool) {
+	return cache.backend.GetRelayedStorage(chainID, height, addr.Postfix(20), key)
+}
+
+// TxCache.relays
+//-------------------------------------
+// TxCache.confidential
+
+func (cache *TxCache) getConfidentialEntry(key []byte) []byte {
+	if value, ok := cache.confidential[string(key)]; ok {
+		return value
+	}
+	return cache.backend.getConfidentialEntry(key)
+}
+
+func (cache *TxCache) GetConfidentialBlob(owner, key Word256) []byte {
+	blob := cache.getConfidentialEntry(confidentialBlobKey(owner.Postfix(20), key))
+	if len(blob) == 0 {
+		return nil
+	}
+	return blob
+}
+
+func (cache *TxCache) SetConfidentialBlob(owner, key Word256, blob []byte) {
+	blobKey := string(confidentialBlobKey(owner.Postfix(20), key))
+	cache.journalConfidential(blobKey)
+	cache.confidential[blobKey] = append([]byte{}, blob...)
+}
+
+func (cache *TxCache) IsConfidentialGranted(owner, key, reader Word256) bool {
+	grantKey := confidentialGrantKey(owner.Postfix(20), key, reader.Postfix(20))
+	return len(cache.getConfidentialEntry(grantKey)) > 0
+}
+
+func (cache *TxCache) SetConfidentialGrant(owner, key, reader Word256, granted bool) {
+	grantKey := string(confidentialGrantKey(owner.Postfix(20), key, reader.Postfix(20)))
+	cache.journalConfidential(grantKey)
+	cache.confidential[grantKey] = confidentialGrantValue(granted)
+}
+
+func (cache *TxCache) journalConfidential(key string) {
+	if len(cache.snapshots) > 0 {
+		prev, ok := cache.confidential[key]
+		cache.journal = append(cache.journal, func() {
+			if ok {
+				cache.confidential[key] = prev
+			} else {
+				delete(cache.confidential, key)
+			}
+		})
+	}
+}
+
+// TxCache.confidential
+//-------------------------------------
+// TxCache.snapshots
+
+func (cache *TxCache) Snapshot() int {
+	accounts := make(map[*vm.Account]vm.Account, len(cache.accounts))
+	for _, accInfo := range cache.accounts {
+		accounts[accInfo.account] = *accInfo.account
+	}
+	cache.snapshots = append(cache.snapshots, txCacheSnapshot{
+		journal:  len(cache.journal),
+		accounts: accounts,
+	})
+	return len(cache.snapshots) - 1
+}
+
+func (cache *TxCache) RevertToSnapshot(snapshot int) {
+	if snapshot < 0 || snapshot >= len(cache.snapshots) {
+		sanity.PanicSanity(fmt.Sprintf("RevertToSnapshot() on an invalid snapshot: %v", snapshot))
+	}
+	snap := cache.snapshots[snapshot]
+	// Undo the changes to the maps last first
+	for i := len(cache.journal) - 1; i >= snap.journal; i-- {
+		cache.journal[i]()
+	}
+	cache.journal = cache.journal[:snap.journal]
+	for acc, value := range snap.accounts {
+		*acc = value
+	}
+	cache.snapshots = cache.snapshots[:snapshot]
+}
+
+func (cache *TxCache) journalAccount(addr Word256) {
+	if len(cache.snapshots) > 0 {
+		prev, ok := cache.accounts[addr]
+		cache.journal = append(cache.journal, func() {
+			if ok {
+				cache.accounts[addr] = prev
+			} else {
+				delete(cache.accounts, addr)
+			}
+		})
+	}
+}
+
+// TxCache.snapshots
+//-------------------------------------
 
 // These updates do not have to be in deterministic order,
 // the backend is responsible for ordering updates.
@@ -154,6 +324,11 @@ func (cache *TxCache) Sync() {
 			cache.backend.UpdateAccount(toStateAccount(acc))
 		}
 	}
+
+	// Put or remove confidential entries
+	for key, value := range cache.confidential {
+		cache.backend.setConfidentialEntry([]byte(key), value)
+	}
 }
 
 //-----------------------------------------------------------------------------
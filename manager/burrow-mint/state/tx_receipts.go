@@ -0,0 +1,197 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	dbm "github.com/tendermint/go-db"
+	"github.com/tendermint/go-events"
+	wire "github.com/tendermint/go-wire"
+)
+
+const txReceiptsKeyPrefix = "receipts/"
+
+// TxReceipts records the receipt of each CallTx in committed blocks by the
+// hash of the tx
+type TxReceipts struct {
+	mtx     sync.Mutex
+	db      dbm.DB
+	pending []*core_types.TxReceipt
+}
+
+func NewTxReceipts(db dbm.DB) *TxReceipts {
+	return &TxReceipts{db: db}
+}
+
+// Adds a receipt to be stored on the next call to Commit
+func (tr *TxReceipts) Add(receipt *core_types.TxReceipt) {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	tr.pending = append(tr.pending, receipt)
+}
+
+// Writes the receipts added since the last commit
+func (tr *TxReceipts) Commit() {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	for _, receipt := range tr.pending {
+		tr.db.Set(txReceiptKey(receipt.TxHash), wire.BinaryBytes(receipt))
+	}
+	tr.pending = nil
+}
+
+// Returns the receipt of the tx with txHash. When abiJSON is not empty the
+// logs of the receipt that are events of the ABI are decoded against it,
+// otherwise when registry is not nil each log is decoded against the ABI
+// registered for the code of the contract that emitted it, if there is one.
+func (tr *TxReceipts) TxReceipt(txHash []byte, abiJSON string,
+	registry ABIGetter) (*core_types.TxReceipt, error) {
+	var contractABI *abi.ABI
+	if abiJSON != "" {
+		var err error
+		contractABI, err = abi.ReadABI([]byte(abiJSON))
+		if err != nil {
+			return nil, err
+		}
+	}
+	tr.mtx.Lock()
+	bs := tr.db.Get(txReceiptKey(txHash))
+	tr.mtx.Unlock()
+	if len(bs) == 0 {
+		return nil, fmt.Errorf("No receipt for tx %X, it is not a committed CallTx",
+			txHash)
+	}
+	receipt := new(core_types.TxReceipt)
+	if err := readBinary(bs, receipt); err != nil {
+		return nil, fmt.Errorf("Could not read receipt of tx %X: %v", txHash, err)
+	}
+	if contractABI != nil {
+		for _, log := range receipt.Logs {
+			log.Event = decodeLog(contractABI, log)
+		}
+	} else if registry != nil {
+		registeredABIs := make(map[Word256]*abi.ABI)
+		for _, log := range receipt.Logs {
+			logABI, ok := registeredABIs[log.Address]
+			if !ok {
+				logABI = registeredABI(registry, log.Address.Postfix(20))
+				registeredABIs[log.Address] = logABI
+			}
+			if logABI != nil {
+				log.Event = decodeLog(logABI, log)
+			}
+		}
+	}
+	return receipt, nil
+}
+
+// Returns the ABI registered for the contract at address, or nil if there
+// is none on chain
+func registeredABI(registry ABIGetter, address []byte) *abi.ABI {
+	entry := GetContractABIEntry(registry, address)
+	if entry == nil || entry.ABI == "" {
+		return nil
+	}
+	contractABI, err := abi.ReadABI([]byte(entry.ABI))
+	if err != nil {
+		return nil
+	}
+	return contractABI
+}
+
+// Makes the receipt of a CallTx with hash txHash that left gas and returned
+// ret and err
+func txReceipt(s *State, tx *txs.CallTx, txHash []byte, createContract bool,
+	callee *vm.Account, gas int64, logs *logCollectingFireable, ret []byte,
+	err error) *core_types.TxReceipt {
+	receipt := &core_types.TxReceipt{
+		TxHash:  txHash,
+		Height:  s.LastBlockHeight + 1,
+		Success: err == nil,
+		GasUsed: tx.GasLimit - gas,
+	}
+	if err != nil {
+		receipt.Exception = vm.RevertError(err, ret).Error()
+		return receipt
+	}
+	if createContract {
+		receipt.ContractAddress = callee.Address.Postfix(20)
+	}
+	receipt.Logs = logs.logs
+	return receipt
+}
+
+// Decodes log as an event of contractABI, or returns nil if it is not one
+func decodeLog(contractABI *abi.ABI, log *core_types.ReceiptLog) *core_types.DecodedEvent {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+	event := contractABI.EventByID(log.Topics[0])
+	if event == nil {
+		return nil
+	}
+	return decodeEvent(event, log.Topics, log.Data)
+}
+
+// Decodes the topics and data of a log as event, or returns nil if they are
+// not one
+func decodeEvent(event *abi.Event, topics []Word256, data []byte) *core_types.DecodedEvent {
+	values, err := event.Decode(topics, data)
+	if err != nil {
+		return nil
+	}
+	decoded := &core_types.DecodedEvent{Name: event.Name}
+	for i, input := range event.Inputs {
+		decoded.Args = append(decoded.Args, &core_types.DecodedArg{
+			Name:    input.Name,
+			Type:    string(input.TypeName),
+			Indexed: input.Indexed,
+			Value:   values[i],
+		})
+	}
+	return decoded
+}
+
+// Passes events on to fireable, if there is one, collecting the logs among
+// them for a receipt
+type logCollectingFireable struct {
+	fireable events.Fireable
+	logs     []*core_types.ReceiptLog
+}
+
+func (lcf *logCollectingFireable) FireEvent(event string, data events.EventData) {
+	if log, ok := data.(txs.EventDataLog); ok {
+		lcf.logs = append(lcf.logs, &core_types.ReceiptLog{
+			Address: log.Address,
+			Topics:  log.Topics,
+			Data:    log.Data,
+		})
+	}
+	if lcf.fireable != nil {
+		lcf.fireable.FireEvent(event, data)
+	}
+}
+
+func txReceiptKey(txHash []byte) []byte {
+	return []byte(fmt.Sprintf("%s%X", txReceiptsKeyPrefix, txHash))
+}
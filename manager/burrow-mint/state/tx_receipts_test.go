@@ -0,0 +1,114 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	. "github.com/hyperledger/burrow/manager/burrow-mint/evm/opcodes"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storedABI = `[{"type": "event", "name": "Stored", "inputs": [
+	{"name": "value", "type": "uint256", "indexed": false}]}]`
+
+func TestTxReceipts(t *testing.T) {
+	state, privAccounts, _ := RandGenesisState(3, true, 1000, 1, true, 1000)
+	txReceipts := NewTxReceipts(state.DB)
+	state.SetTxReceipts(txReceipts)
+
+	contractABI, err := abi.ReadABI([]byte(storedABI))
+	require.NoError(t, err)
+	stored := contractABI.Events[0].ID()
+	contract := state.GetAccount(privAccounts[1].PubKey.Address())
+	contract.Code = Bytecode(PUSH1, 42, PUSH1, 0, MSTORE, PUSH32, stored, PUSH1, 32,
+		PUSH1, 0, LOG1, STOP)
+	state.UpdateAccount(contract)
+
+	callTx := func(address []byte) *txs.CallTx {
+		acc0 := state.GetAccount(privAccounts[0].PubKey.Address())
+		tx := &txs.CallTx{
+			Input: &txs.TxInput{
+				Address:  acc0.Address,
+				Amount:   1,
+				Sequence: acc0.Sequence + 1,
+				PubKey:   privAccounts[0].PubKey,
+			},
+			Address:  address,
+			GasLimit: 10000,
+		}
+		tx.Input.Signature = privAccounts[0].Sign(state.ChainID, tx)
+		require.NoError(t, execTxWithState(state, tx, true))
+		return tx
+	}
+	tx := callTx(contract.Address)
+	txHash := txs.TxHash(state.ChainID, tx)
+
+	// Nothing is stored until committed
+	_, err = txReceipts.TxReceipt(txHash, "", nil)
+	assert.Error(t, err)
+	txReceipts.Commit()
+
+	receipt, err := NewTxReceipts(state.DB).TxReceipt(txHash, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, txHash, receipt.TxHash)
+	assert.Equal(t, state.LastBlockHeight+1, receipt.Height)
+	assert.True(t, receipt.Success)
+	assert.True(t, receipt.GasUsed > 0)
+	assert.Empty(t, receipt.ContractAddress)
+	require.Len(t, receipt.Logs, 1)
+	assert.Equal(t, LeftPadWord256(contract.Address), receipt.Logs[0].Address)
+	assert.Equal(t, []Word256{stored}, receipt.Logs[0].Topics)
+	assert.Equal(t, Int64ToWord256(42).Bytes(), receipt.Logs[0].Data)
+	assert.Nil(t, receipt.Logs[0].Event)
+
+	receipt, err = txReceipts.TxReceipt(txHash, storedABI, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &core_types.DecodedEvent{
+		Name: "Stored",
+		Args: []*core_types.DecodedArg{{Name: "value", Type: "uint256", Value: "42"}},
+	}, receipt.Logs[0].Event)
+
+	_, err = txReceipts.TxReceipt(txHash, "not an ABI", nil)
+	assert.Error(t, err)
+
+	// Decoded against the ABI registered for the contract
+	receipt, err = txReceipts.TxReceipt(txHash, "", state)
+	require.NoError(t, err)
+	assert.Nil(t, receipt.Logs[0].Event)
+	state.UpdateABIEntry(&core_types.ABIEntry{
+		Address: contract.Address,
+		ABI:     storedABI,
+	})
+	receipt, err = txReceipts.TxReceipt(txHash, "", state)
+	require.NoError(t, err)
+	require.NotNil(t, receipt.Logs[0].Event)
+	assert.Equal(t, "Stored", receipt.Logs[0].Event.Name)
+
+	// A call to an account without code fails
+	tx = callTx(privAccounts[2].PubKey.Address())
+	txReceipts.Commit()
+	receipt, err = txReceipts.TxReceipt(txs.TxHash(state.ChainID, tx), "", nil)
+	require.NoError(t, err)
+	assert.False(t, receipt.Success)
+	assert.Equal(t, txs.ErrTxInvalidAddress.Error(), receipt.Exception)
+	assert.Empty(t, receipt.Logs)
+}
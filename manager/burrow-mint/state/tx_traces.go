@@ -0,0 +1,59 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"sync"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+)
+
+// The EVM traces of the most recently executed CallTxs by tx hash. Traces
+// are held in memory and the oldest are dropped beyond the capacity.
+type TxTraces struct {
+	mtx      sync.Mutex
+	capacity int
+	// Tx hashes in the order they were added, oldest first
+	order  []string
+	traces map[string]*core_types.Trace
+}
+
+func NewTxTraces(capacity int) *TxTraces {
+	return &TxTraces{
+		capacity: capacity,
+		traces:   make(map[string]*core_types.Trace),
+	}
+}
+
+func (tt *TxTraces) Add(txHash []byte, trace *core_types.Trace) {
+	tt.mtx.Lock()
+	defer tt.mtx.Unlock()
+	key := string(txHash)
+	if _, ok := tt.traces[key]; !ok {
+		tt.order = append(tt.order, key)
+	}
+	tt.traces[key] = trace
+	for len(tt.order) > tt.capacity {
+		delete(tt.traces, tt.order[0])
+		tt.order = tt.order[1:]
+	}
+}
+
+// Get the trace of a tx, or nil if it was not traced or has been dropped
+func (tt *TxTraces) Get(txHash []byte) *core_types.Trace {
+	tt.mtx.Lock()
+	defer tt.mtx.Unlock()
+	return tt.traces[string(txHash)]
+}
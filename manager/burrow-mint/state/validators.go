@@ -0,0 +1,210 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/burrow/genesis"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The handover of a validator's voting power to NewPubKey, which validates in
+// place of PubKey from Height on
+type ValidatorRotation struct {
+	Height    int                  `json:"height"`
+	PubKey    crypto.PubKeyEd25519 `json:"pub_key"`
+	NewPubKey crypto.PubKeyEd25519 `json:"new_pub_key"`
+}
+
+// Rotations are keyed by height then by the address handed over, so the tree
+// holds them in the order they take effect
+func validatorRotationKey(rotation *ValidatorRotation) []byte {
+	key := make([]byte, 8, 8+20)
+	binary.BigEndian.PutUint64(key, uint64(rotation.Height))
+	return append(key, rotation.PubKey.Address()...)
+}
+
+func DecodeValidatorRotation(rotationBytes []byte) *ValidatorRotation {
+	rotation := new(ValidatorRotation)
+	readBinary(rotationBytes, rotation)
+	return rotation
+}
+
+// Gets every rotation ever made, including those yet to take effect, in the
+// order they take effect
+func (s *State) GetValidatorRotations() []*ValidatorRotation {
+	var rotations []*ValidatorRotation
+	s.validatorRotations.Iterate(func(key, value []byte) bool {
+		rotations = append(rotations, DecodeValidatorRotation(value))
+		return false
+	})
+	return rotations
+}
+
+func (s *State) AddValidatorRotation(rotation *ValidatorRotation) bool {
+	return s.validatorRotations.Set(validatorRotationKey(rotation),
+		wire.BinaryBytes(rotation))
+}
+
+// The validators of the genesis doc, which are not saved with state. They are
+// nil if the genesis doc is not known.
+func (s *State) GenesisValidators() []genesis.GenesisValidator {
+	return s.genesisValidators
+}
+
+func (s *State) SetGenesisValidators(genesisValidators []genesis.GenesisValidator) {
+	s.genesisValidators = genesisValidators
+}
+
+// Gets the validators at height, which are the genesis validators with
+// their keys replaced by the rotations and their power changed by the power
+// changes that have taken effect by then. The rotations of a height are made
+// before its power changes.
+func ValidatorsAt(genesisValidators []genesis.GenesisValidator,
+	rotations []*ValidatorRotation, powerChanges []*ValidatorPowerChange,
+	height int) []*tm_types.Validator {
+	validators := make([]*tm_types.Validator, len(genesisValidators))
+	for i, genesisValidator := range genesisValidators {
+		validators[i] = tm_types.NewValidator(genesisValidator.PubKey,
+			genesisValidator.Amount)
+	}
+	r, p := 0, 0
+	for {
+		if r < len(rotations) && rotations[r].Height <= height &&
+			(p == len(powerChanges) || rotations[r].Height <= powerChanges[p].Height) {
+			rotation := rotations[r]
+			if i := findValidator(validators, rotation.PubKey); i >= 0 {
+				validators[i] = tm_types.NewValidator(rotation.NewPubKey,
+					validators[i].VotingPower)
+			}
+			r++
+		} else if p < len(powerChanges) && powerChanges[p].Height <= height {
+			change := powerChanges[p]
+			i := findValidator(validators, change.PubKey)
+			switch {
+			case i >= 0 && change.Power == 0:
+				validators = append(validators[:i], validators[i+1:]...)
+			case i >= 0:
+				validators[i] = tm_types.NewValidator(change.PubKey, change.Power)
+			case change.Power > 0:
+				validators = append(validators, tm_types.NewValidator(change.PubKey,
+					change.Power))
+			}
+			p++
+		} else {
+			return validators
+		}
+	}
+}
+
+// Gets the index of the validator with pubKey, or -1 if none has it
+func findValidator(validators []*tm_types.Validator, pubKey crypto.PubKey) int {
+	for i, validator := range validators {
+		if bytes.Equal(validator.PubKey.Bytes(), pubKey.Bytes()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Gets the index of the validator with address, or -1 if none has it
+func findValidatorByAddress(validators []*tm_types.Validator, address []byte) int {
+	for i, validator := range validators {
+		if bytes.Equal(validator.Address, address) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Checks that rotation can be made at height given the rotations and power
+// changes made so far. The key handed over must be a validator's, with no
+// rotation of its own yet to take effect, and the new key must be neither a
+// validator's nor the subject of a rotation yet to take effect. Neither key
+// may have a power change yet to take effect.
+func validateRotation(genesisValidators []genesis.GenesisValidator,
+	rotations []*ValidatorRotation, powerChanges []*ValidatorPowerChange,
+	rotation *ValidatorRotation, height int) error {
+	if genesisValidators == nil {
+		return fmt.Errorf("The genesis validators are not known so validator " +
+			"keys cannot be rotated")
+	}
+	if rotation.Height <= height {
+		return fmt.Errorf("Rotation must take effect after height %v, not at %v",
+			height, rotation.Height)
+	}
+	validators := ValidatorsAt(genesisValidators, rotations, powerChanges, height)
+	if findValidator(validators, rotation.PubKey) < 0 {
+		return fmt.Errorf("%X is not a validator", rotation.PubKey.Address())
+	}
+	if findValidator(validators, rotation.NewPubKey) >= 0 {
+		return fmt.Errorf("%X is already a validator", rotation.NewPubKey.Address())
+	}
+	for _, pending := range rotations {
+		if pending.Height <= height {
+			continue
+		}
+		if pending.PubKey == rotation.PubKey {
+			return fmt.Errorf("%X is already being rotated at height %v",
+				rotation.PubKey.Address(), pending.Height)
+		}
+		if pending.PubKey == rotation.NewPubKey || pending.NewPubKey == rotation.NewPubKey {
+			return fmt.Errorf("%X is in a rotation at height %v",
+				rotation.NewPubKey.Address(), pending.Height)
+		}
+	}
+	for _, pending := range powerChanges {
+		if pending.Height > height && (pending.PubKey == rotation.PubKey ||
+			pending.PubKey == rotation.NewPubKey) {
+			return fmt.Errorf("%X has its power changed at height %v",
+				pending.PubKey.Address(), pending.Height)
+		}
+	}
+	return nil
+}
+
+// Gets the changes to the validators that take effect after height, as
+// validators with the power they have from the next height: those handed over
+// or unbonded have none, the keys handed over to take their power and those
+// whose power changes have their new power. Tendermint makes the changes the
+// application returns from EndBlock in order, so the validators that leave
+// come first.
+func ValidatorChangesAfter(genesisValidators []genesis.GenesisValidator,
+	rotations []*ValidatorRotation, powerChanges []*ValidatorPowerChange,
+	height int) []*tm_types.Validator {
+	validators := ValidatorsAt(genesisValidators, rotations, powerChanges, height)
+	nextValidators := ValidatorsAt(genesisValidators, rotations, powerChanges,
+		height+1)
+	var changes []*tm_types.Validator
+	for _, validator := range validators {
+		if findValidator(nextValidators, validator.PubKey) < 0 {
+			changes = append(changes, tm_types.NewValidator(validator.PubKey, 0))
+		}
+	}
+	for _, validator := range nextValidators {
+		i := findValidator(validators, validator.PubKey)
+		if i < 0 || validators[i].VotingPower != validator.VotingPower {
+			changes = append(changes, tm_types.NewValidator(validator.PubKey,
+				validator.VotingPower))
+		}
+	}
+	return changes
+}
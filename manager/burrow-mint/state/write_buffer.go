@@ -0,0 +1,297 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	dbm "github.com/tendermint/go-db"
+	wire "github.com/tendermint/go-wire"
+)
+
+// Synced with the number of flushes when a flush is synced, so that the
+// flushes before it are too
+const writeBufferSyncedKey = "writebuffer/synced"
+
+// When the writes a WriteBuffer flushes are synced to disk
+type SyncPolicy string
+
+const (
+	// Sync every flush
+	SyncAlways SyncPolicy = "always"
+	// Sync every WriteBufferOptions.SyncInterval flushes
+	SyncInterval SyncPolicy = "interval"
+	// Leave syncing to the database backend and operating system
+	SyncNever SyncPolicy = "never"
+)
+
+type WriteBufferOptions struct {
+	// Write each flush in the background while the next block executes
+	// rather than before Flush returns
+	Pipeline bool
+	Sync     SyncPolicy
+	// The number of flushes between syncs when Sync is SyncInterval
+	SyncInterval int
+}
+
+func (opts WriteBufferOptions) Validate() error {
+	switch opts.Sync {
+	case SyncAlways, SyncNever:
+		return nil
+	case SyncInterval:
+		if opts.SyncInterval < 1 {
+			return fmt.Errorf("The sync interval of a write buffer must be at "+
+				"least 1, not %v", opts.SyncInterval)
+		}
+		return nil
+	}
+	return fmt.Errorf("Write buffer sync policy %q is not one of %q, %q, "+
+		"or %q", opts.Sync, SyncAlways, SyncInterval, SyncNever)
+}
+
+type bufferedWrite struct {
+	value  []byte
+	delete bool
+}
+
+// A dbm.DB that buffers the writes made to db between flushes, which are
+// written in a single batch sorted by key rather than key by key as the
+// merkle trees and indices of state make them. Reads see the writes buffered.
+// Each block's writes are flushed once it has been committed; should the node
+// stop before they are written (or synced) the blocks they belong to are
+// replayed from the block store on restart, since the state a flush writes
+// includes the height it was saved at.
+type WriteBuffer struct {
+	db      dbm.DB
+	options WriteBufferOptions
+	// Serialises flushes
+	flushMtx sync.Mutex
+	mtx      sync.RWMutex
+	// The writes since the last flush
+	pending map[string]bufferedWrite
+	// The writes of the flush being written in the background
+	flushing map[string]bufferedWrite
+	// Closed when the last flush has been written
+	flushed chan struct{}
+	flushes int
+}
+
+var _ dbm.DB = (*WriteBuffer)(nil)
+
+func NewWriteBuffer(db dbm.DB, options WriteBufferOptions) (*WriteBuffer, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	return &WriteBuffer{
+		db:      db,
+		options: options,
+		pending: make(map[string]bufferedWrite),
+	}, nil
+}
+
+func (wb *WriteBuffer) Get(key []byte) []byte {
+	wb.mtx.RLock()
+	write, ok := wb.pending[string(key)]
+	if !ok {
+		write, ok = wb.flushing[string(key)]
+	}
+	wb.mtx.RUnlock()
+	if ok {
+		return write.value
+	}
+	return wb.db.Get(key)
+}
+
+func (wb *WriteBuffer) Set(key []byte, value []byte) {
+	wb.mtx.Lock()
+	defer wb.mtx.Unlock()
+	wb.set(key, value)
+}
+
+// Buffered like Set, whether the write is synced is up to the sync policy
+func (wb *WriteBuffer) SetSync(key []byte, value []byte) {
+	wb.Set(key, value)
+}
+
+func (wb *WriteBuffer) Delete(key []byte) {
+	wb.mtx.Lock()
+	defer wb.mtx.Unlock()
+	wb.delete(key)
+}
+
+// Buffered like Delete, whether the write is synced is up to the sync policy
+func (wb *WriteBuffer) DeleteSync(key []byte) {
+	wb.Delete(key)
+}
+
+// Flushes and syncs the writes buffered before closing db
+func (wb *WriteBuffer) Close() {
+	wb.flushMtx.Lock()
+	defer wb.flushMtx.Unlock()
+	wb.flush(true)
+	wb.wait()
+	wb.db.Close()
+}
+
+func (wb *WriteBuffer) NewBatch() dbm.Batch {
+	return &writeBufferBatch{writeBuffer: wb}
+}
+
+func (wb *WriteBuffer) Print() {
+	wb.Flush()
+	wb.Wait()
+	wb.db.Print()
+}
+
+// Iterates over db once the writes buffered have been written to it, so
+// should only be used for debugging
+func (wb *WriteBuffer) Iterator() dbm.Iterator {
+	wb.Flush()
+	wb.Wait()
+	return wb.db.Iterator()
+}
+
+func (wb *WriteBuffer) Stats() map[string]string {
+	stats := wb.db.Stats()
+	wb.mtx.RLock()
+	defer wb.mtx.RUnlock()
+	stats["write_buffer.pending"] = fmt.Sprintf("%v", len(wb.pending))
+	stats["write_buffer.flushing"] = fmt.Sprintf("%v", len(wb.flushing))
+	stats["write_buffer.flushes"] = fmt.Sprintf("%v", wb.flushes)
+	return stats
+}
+
+// Writes the writes buffered since the last flush to db in one batch, in the
+// background when pipelining, once the last flush has been written
+func (wb *WriteBuffer) Flush() {
+	wb.flushMtx.Lock()
+	defer wb.flushMtx.Unlock()
+	wb.flush(false)
+}
+
+// Waits for the last flush to be written
+func (wb *WriteBuffer) Wait() {
+	wb.flushMtx.Lock()
+	defer wb.flushMtx.Unlock()
+	wb.wait()
+}
+
+// flushMtx must be held
+func (wb *WriteBuffer) flush(sync bool) {
+	wb.wait()
+	wb.mtx.Lock()
+	if len(wb.pending) == 0 && !sync {
+		wb.mtx.Unlock()
+		return
+	}
+	writes := wb.pending
+	wb.pending = make(map[string]bufferedWrite)
+	wb.flushes++
+	sync = sync || wb.options.Sync == SyncAlways ||
+		(wb.options.Sync == SyncInterval && wb.flushes%wb.options.SyncInterval == 0)
+	wb.flushing = writes
+	flushed := make(chan struct{})
+	wb.flushed = flushed
+	flushes := wb.flushes
+	wb.mtx.Unlock()
+	if wb.options.Pipeline {
+		go wb.write(writes, sync, flushes, flushed)
+	} else {
+		wb.write(writes, sync, flushes, flushed)
+	}
+}
+
+// flushMtx must be held
+func (wb *WriteBuffer) wait() {
+	wb.mtx.RLock()
+	flushed := wb.flushed
+	wb.mtx.RUnlock()
+	if flushed != nil {
+		<-flushed
+	}
+}
+
+// Like the dbm.DB methods it errors panic from db, so failing to write state
+// stops the node from committing blocks on top of state it does not have
+func (wb *WriteBuffer) write(writes map[string]bufferedWrite, sync bool,
+	flushes int, flushed chan struct{}) {
+	keys := make([]string, 0, len(writes))
+	for key := range writes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	batch := wb.db.NewBatch()
+	for _, key := range keys {
+		if write := writes[key]; write.delete {
+			batch.Delete([]byte(key))
+		} else {
+			batch.Set([]byte(key), write.value)
+		}
+	}
+	batch.Write()
+	if sync {
+		// Syncing a write syncs those before it
+		wb.db.SetSync([]byte(writeBufferSyncedKey), wire.BinaryBytes(flushes))
+	}
+	wb.mtx.Lock()
+	wb.flushing = nil
+	wb.mtx.Unlock()
+	close(flushed)
+}
+
+// mtx must be held
+func (wb *WriteBuffer) set(key []byte, value []byte) {
+	// Callers may reuse value once they have written it
+	wb.pending[string(key)] = bufferedWrite{value: append([]byte{}, value...)}
+}
+
+// mtx must be held
+func (wb *WriteBuffer) delete(key []byte) {
+	wb.pending[string(key)] = bufferedWrite{delete: true}
+}
+
+// Buffers its writes in the WriteBuffer when written
+type writeBufferBatch struct {
+	writeBuffer *WriteBuffer
+	writes      []bufferedWrite
+	keys        [][]byte
+}
+
+func (wbb *writeBufferBatch) Set(key, value []byte) {
+	wbb.keys = append(wbb.keys, key)
+	wbb.writes = append(wbb.writes, bufferedWrite{value: value})
+}
+
+func (wbb *writeBufferBatch) Delete(key []byte) {
+	wbb.keys = append(wbb.keys, key)
+	wbb.writes = append(wbb.writes, bufferedWrite{delete: true})
+}
+
+func (wbb *writeBufferBatch) Write() {
+	wb := wbb.writeBuffer
+	wb.mtx.Lock()
+	defer wb.mtx.Unlock()
+	for i, write := range wbb.writes {
+		if write.delete {
+			wb.delete(wbb.keys[i])
+		} else {
+			wb.set(wbb.keys[i], write.value)
+		}
+	}
+	wbb.keys = nil
+	wbb.writes = nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tdb "github.com/tendermint/go-db"
+)
+
+func TestWriteBuffer(t *testing.T) {
+	for _, pipeline := range []bool{false, true} {
+		db := tdb.NewMemDB()
+		wb, err := NewWriteBuffer(db, WriteBufferOptions{
+			Pipeline:     pipeline,
+			Sync:         SyncInterval,
+			SyncInterval: 2,
+		})
+		require.NoError(t, err)
+		db.Set([]byte("a"), []byte("0"))
+
+		wb.Set([]byte("a"), []byte("1"))
+		wb.Set([]byte("b"), []byte("2"))
+		batch := wb.NewBatch()
+		batch.Set([]byte("c"), []byte("3"))
+		batch.Delete([]byte("b"))
+		assert.Equal(t, []byte("2"), wb.Get([]byte("b")))
+		batch.Write()
+
+		// Reads see the writes buffered, which db does not until flushed
+		assert.Equal(t, []byte("1"), wb.Get([]byte("a")))
+		assert.Nil(t, wb.Get([]byte("b")))
+		assert.Equal(t, []byte("3"), wb.Get([]byte("c")))
+		assert.Equal(t, []byte("0"), db.Get([]byte("a")))
+		assert.Nil(t, db.Get([]byte("c")))
+
+		wb.Flush()
+		// Written to db in the background when pipelining
+		wb.Set([]byte("a"), []byte("4"))
+		assert.Equal(t, []byte("4"), wb.Get([]byte("a")))
+		assert.Equal(t, []byte("3"), wb.Get([]byte("c")))
+		wb.Wait()
+		assert.Equal(t, []byte("1"), db.Get([]byte("a")))
+		assert.Equal(t, []byte("3"), db.Get([]byte("c")))
+		assert.Nil(t, db.Get([]byte(writeBufferSyncedKey)))
+
+		// Every other flush is synced
+		wb.Flush()
+		wb.Wait()
+		assert.Equal(t, []byte("4"), db.Get([]byte("a")))
+		assert.NotNil(t, db.Get([]byte(writeBufferSyncedKey)))
+		assert.Equal(t, "2", wb.Stats()["write_buffer.flushes"])
+	}
+}
+
+func TestWriteBufferConcurrentReads(t *testing.T) {
+	wb, err := NewWriteBuffer(tdb.NewMemDB(), WriteBufferOptions{
+		Pipeline: true,
+		Sync:     SyncAlways,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		wb.Set([]byte(fmt.Sprintf("key%v", i)), []byte{0})
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Each key is in the buffer or db throughout
+		for n := 0; n < 100; n++ {
+			for i := 0; i < 100; i++ {
+				if wb.Get([]byte(fmt.Sprintf("key%v", i))) == nil {
+					t.Errorf("key%v missing", i)
+					return
+				}
+			}
+		}
+	}()
+	for n := 0; n < 100; n++ {
+		wb.Set([]byte(fmt.Sprintf("key%v", n)), []byte{byte(n)})
+		wb.Flush()
+	}
+	<-done
+	wb.Close()
+}
+
+func TestWriteBufferOptions(t *testing.T) {
+	_, err := NewWriteBuffer(tdb.NewMemDB(), WriteBufferOptions{Sync: "sometimes"})
+	assert.Error(t, err)
+	_, err = NewWriteBuffer(tdb.NewMemDB(), WriteBufferOptions{Sync: SyncInterval})
+	assert.Error(t, err)
+}
@@ -16,6 +16,7 @@ package burrowmint
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"sync"
@@ -26,6 +27,7 @@ import (
 	"github.com/hyperledger/burrow/event"
 	"github.com/hyperledger/burrow/manager/burrow-mint/evm"
 	"github.com/hyperledger/burrow/manager/burrow-mint/state"
+	ptypes "github.com/hyperledger/burrow/permission/types"
 	"github.com/hyperledger/burrow/txs"
 	"github.com/hyperledger/burrow/word256"
 
@@ -42,11 +44,13 @@ type transactor struct {
 	eventEmitter  event.EventEmitter
 	txMtx         *sync.Mutex
 	txBroadcaster func(tx txs.Tx) error
+	sequences     *sequenceTracker
+	calls         *callPool
 }
 
 func newTransactor(chainID string, eventSwitch tEvents.Fireable,
 	burrowMint *BurrowMint, eventEmitter event.EventEmitter,
-	txBroadcaster func(tx txs.Tx) error) *transactor {
+	txBroadcaster func(tx txs.Tx) error, calls *callPool) *transactor {
 	return &transactor{
 		chainID,
 		eventSwitch,
@@ -54,6 +58,8 @@ func newTransactor(chainID string, eventSwitch tEvents.Fireable,
 		eventEmitter,
 		&sync.Mutex{},
 		txBroadcaster,
+		newSequenceTracker(),
+		calls,
 	}
 }
 
@@ -62,7 +68,7 @@ func newTransactor(chainID string, eventSwitch tEvents.Fireable,
 // NOTE: this function is used from 1337 and has sibling on 46657
 // in pipe.go
 // TODO: [ben] resolve incompatibilities in byte representation for 0.12.0 release
-func (this *transactor) Call(fromAddress, toAddress, data []byte) (
+func (this *transactor) Call(fromAddress, toAddress, data []byte, trace bool) (
 	*core_types.Call, error) {
 
 	st := this.burrowMint.GetState()
@@ -77,59 +83,186 @@ func (this *transactor) Call(fromAddress, toAddress, data []byte) (
 	callee := toVMAccount(outAcc)
 	caller := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
 	txCache := state.NewTxCache(cache)
-	gasLimit := st.GetGasLimit()
-	params := vm.Params{
-		BlockHeight: int64(st.LastBlockHeight),
-		BlockHash:   word256.LeftPadWord256(st.LastBlockHash),
-		BlockTime:   st.LastBlockTime.Unix(),
-		GasLimit:    gasLimit,
-	}
-
-	vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
-		caller.Address, nil)
-	vmach.SetFireable(this.eventSwitch)
-	gas := gasLimit
-	ret, err := vmach.Call(caller, callee, callee.Code, data, 0, &gas)
-	if err != nil {
-		return nil, err
-	}
-	gasUsed := gasLimit - gas
-	// here return bytes are hex encoded; on the sibling function
-	// they are not
-	return &core_types.Call{Return: hex.EncodeToString(ret), GasUsed: gasUsed}, nil
+	gasLimit := this.calls.gasLimit(st.GetGasLimit())
+
+	var call *core_types.Call
+	err := this.calls.simulate(func(interrupt <-chan struct{}) error {
+		vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider,
+			callParams(st), caller.Address, nil)
+		vmach.SetFireable(this.eventSwitch)
+		vmach.SetInterrupt(interrupt)
+		gas := gasLimit
+		tracer := setTracer(vmach, trace)
+		ret, err := vmach.Call(caller, callee, callee.Code, data, 0, &gas)
+		call, err = callResult(ret, gasLimit-gas, tracer, err)
+		return err
+	})
+	return call, err
 }
 
 // Run the given code on an isolated and unpersisted state
 // Cannot be used to create new contracts.
-func (this *transactor) CallCode(fromAddress, code, data []byte) (
+func (this *transactor) CallCode(fromAddress, code, data []byte, trace bool) (
 	*core_types.Call, error) {
 	if fromAddress == nil {
 		fromAddress = []byte{}
 	}
-	cache := this.burrowMint.GetCheckCache() // XXX: DON'T MUTATE THIS CACHE (used internally for CheckTx)
+	st := this.burrowMint.GetState() // for block height, time
+	// A cache of its own rather than the check cache, which CheckTx mutates
+	cache := state.NewBlockCache(st)
 	callee := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
 	caller := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
 	txCache := state.NewTxCache(cache)
-	st := this.burrowMint.GetState() // for block height, time
-	gasLimit := st.GetGasLimit()
-	params := vm.Params{
+	gasLimit := this.calls.gasLimit(st.GetGasLimit())
+
+	var call *core_types.Call
+	err := this.calls.simulate(func(interrupt <-chan struct{}) error {
+		vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider,
+			callParams(st), caller.Address, nil)
+		vmach.SetInterrupt(interrupt)
+		gas := gasLimit
+		tracer := setTracer(vmach, trace)
+		ret, err := vmach.Call(caller, callee, code, data, 0, &gas)
+		call, err = callResult(ret, gasLimit-gas, tracer, err)
+		return err
+	})
+	return call, err
+}
+
+// Call toAddress, or run code if toAddress is empty, on an isolated and
+// unpersisted copy of the state as committed at height, or of the latest
+// state if height is 0, after applying overrides to it. Past heights can only
+// be used when their state is kept.
+func (this *transactor) CallWithOverrides(fromAddress, toAddress, code,
+	data []byte, height int, overrides []*core_types.AccountOverride,
+	trace bool) (*core_types.Call, error) {
+	var call *core_types.Call
+	err := this.burrowMint.ReadStateAt(height, func(st *state.State) error {
+		cache := state.NewBlockCache(st)
+		if err := applyOverrides(cache, overrides); err != nil {
+			return err
+		}
+		if fromAddress == nil {
+			fromAddress = []byte{}
+		}
+		caller := &vm.Account{Address: word256.LeftPadWord256(fromAddress)}
+		callee := &vm.Account{Address: caller.Address}
+		if len(toAddress) != 0 {
+			outAcc := cache.GetAccount(toAddress)
+			if outAcc == nil {
+				return fmt.Errorf("Account %X does not exist", toAddress)
+			}
+			callee = toVMAccount(outAcc)
+			code = callee.Code
+		}
+		txCache := state.NewTxCache(cache)
+		gasLimit := this.calls.gasLimit(st.GetGasLimit())
+
+		return this.calls.simulate(func(interrupt <-chan struct{}) error {
+			vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider,
+				callParams(st), caller.Address, nil)
+			vmach.SetInterrupt(interrupt)
+			gas := gasLimit
+			tracer := setTracer(vmach, trace)
+			ret, err := vmach.Call(caller, callee, code, data, 0, &gas)
+			call, err = callResult(ret, gasLimit-gas, tracer, err)
+			return err
+		})
+	})
+	return call, err
+}
+
+// Applies overrides to the accounts in cache, creating those that do not
+// exist
+func applyOverrides(cache *state.BlockCache,
+	overrides []*core_types.AccountOverride) error {
+	for _, override := range overrides {
+		if len(override.Address) != 20 {
+			return fmt.Errorf("Address of override is not of the right "+
+				"length: %d", len(override.Address))
+		}
+		acc := cache.GetAccount(override.Address)
+		if acc == nil {
+			acc = &account.Account{
+				Address:     override.Address,
+				Permissions: ptypes.ZeroAccountPermissions,
+			}
+		} else {
+			acc = acc.Copy()
+		}
+		if override.Balance != nil {
+			acc.Balance = *override.Balance
+		}
+		if override.Sequence != nil {
+			acc.Sequence = *override.Sequence
+		}
+		if len(override.Code) != 0 {
+			acc.Code = override.Code
+		}
+		cache.UpdateAccount(acc)
+		address := word256.LeftPadWord256(override.Address)
+		for _, item := range override.Storage {
+			if len(item.Key) > 32 || len(item.Value) > 32 {
+				return fmt.Errorf("Storage override of %X is longer than "+
+					"32 bytes", override.Address)
+			}
+			cache.SetStorage(address, word256.LeftPadWord256(item.Key),
+				word256.LeftPadWord256(item.Value))
+		}
+	}
+	return nil
+}
+
+// The parameters of the VM for calls on st
+func callParams(st *state.State) vm.Params {
+	return vm.Params{
 		BlockHeight: int64(st.LastBlockHeight),
 		BlockHash:   word256.LeftPadWord256(st.LastBlockHash),
 		BlockTime:   st.LastBlockTime.Unix(),
-		GasLimit:    gasLimit,
+		GasLimit:    st.GetGasLimit(),
+		ChainID:     vm.ChainIDWord256(st.ChainID),
+		GasSchedule: st.GetVMGasSchedule(),
 	}
+}
 
-	vmach := vm.NewVM(txCache, vm.DefaultDynamicMemoryProvider, params,
-		caller.Address, nil)
-	gas := gasLimit
-	ret, err := vmach.Call(caller, callee, code, data, 0, &gas)
-	if err != nil {
-		return nil, err
+// Get the trace of a CallTx executed by this node, if it kept one
+func (this *transactor) TraceTx(txHash []byte) (*core_types.Trace, error) {
+	txTraces := this.burrowMint.TxTraces()
+	if txTraces == nil {
+		return nil, fmt.Errorf("Tx tracing is disabled, set trace_txs in the " +
+			"burrowmint configuration to enable it")
+	}
+	trace := txTraces.Get(txHash)
+	if trace == nil {
+		return nil, fmt.Errorf("No trace of tx %X, it is not a recent CallTx", txHash)
+	}
+	return trace, nil
+}
+
+// Sets a tracer on vmach if trace is true, returning it
+func setTracer(vmach *vm.VM, trace bool) *vm.Tracer {
+	if !trace {
+		return nil
+	}
+	tracer := vm.NewTracer(vm.DefaultMaxTraceSteps)
+	vmach.SetTracer(tracer)
+	return tracer
+}
+
+// A traced call that fails still returns its trace, in which the last step of
+// each failed call carries its error
+func callResult(ret []byte, gasUsed int64, tracer *vm.Tracer,
+	err error) (*core_types.Call, error) {
+	if err != nil && tracer == nil {
+		return nil, vm.RevertError(err, ret)
 	}
-	gasUsed := gasLimit - gas
 	// here return bytes are hex encoded; on the sibling function
 	// they are not
-	return &core_types.Call{Return: hex.EncodeToString(ret), GasUsed: gasUsed}, nil
+	call := &core_types.Call{Return: hex.EncodeToString(ret), GasUsed: gasUsed}
+	if tracer != nil {
+		call.Trace = tracer.Trace()
+	}
+	return call, nil
 }
 
 // Broadcast a transaction.
@@ -140,17 +273,30 @@ func (this *transactor) BroadcastTx(tx txs.Tx) (*txs.Receipt, error) {
 		return nil, fmt.Errorf("Error broadcasting transaction: %v", err)
 	}
 
-	txHash := txs.TxHash(this.chainID, tx)
-	var createsContract uint8
-	var contractAddr []byte
-	// check if creates new contract
-	if callTx, ok := tx.(*txs.CallTx); ok {
-		if len(callTx.Address) == 0 {
-			createsContract = 1
-			contractAddr = state.NewContractAddress(callTx.Input.Address, callTx.Input.Sequence)
+	receipt := txs.GenerateReceipt(this.chainID, tx)
+	return &receipt, nil
+}
+
+// Broadcasts a batch of signed transactions in order. Nothing is broadcast
+// unless the whole batch passes CheckTx against the current mempool, and the
+// transactor broadcasts nothing else until the batch is done so no tx it signs
+// can come between those of the batch. Should a tx still fail to broadcast,
+// the receipts of those before it are returned with the error.
+func (this *transactor) BroadcastTxBatch(batch []txs.Tx) ([]*txs.Receipt, error) {
+	this.txMtx.Lock()
+	defer this.txMtx.Unlock()
+	if err := this.burrowMint.CheckTxBatch(batch); err != nil {
+		return nil, err
+	}
+	receipts := make([]*txs.Receipt, 0, len(batch))
+	for i, tx := range batch {
+		receipt, err := this.BroadcastTx(tx)
+		if err != nil {
+			return receipts, fmt.Errorf("Tx %v of batch: %v", i, err)
 		}
+		receipts = append(receipts, receipt)
 	}
-	return &txs.Receipt{txHash, createsContract, contractAddr}, nil
+	return receipts, nil
 }
 
 // Orders calls to BroadcastTx using lock (waits for response from core before releasing)
@@ -170,14 +316,7 @@ func (this *transactor) Transact(privKey, address, data []byte, gasLimit,
 	this.txMtx.Lock()
 	defer this.txMtx.Unlock()
 	pa := account.GenPrivAccountFromPrivKeyBytes(privKey)
-	cache := this.burrowMint.GetCheckCache() // XXX: DON'T MUTATE THIS CACHE (used internally for CheckTx)
-	acc := cache.GetAccount(pa.Address)
-	var sequence int
-	if acc == nil {
-		sequence = 1
-	} else {
-		sequence = acc.Sequence + 1
-	}
+	sequence := this.nextSequence(pa.Address)
 	// TODO: [Silas] we should consider revising this method and removing fee, or
 	// possibly adding an amount parameter. It is non-sensical to just be able to
 	// set the fee. Our support of fees in general is questionable since at the
@@ -204,11 +343,7 @@ func (this *transactor) Transact(privKey, address, data []byte, gasLimit,
 	}
 
 	// Got ourselves a tx.
-	txS, errS := this.SignTx(tx, []*account.PrivAccount{pa})
-	if errS != nil {
-		return nil, errS
-	}
-	return this.BroadcastTx(txS)
+	return this.signAndBroadcast(tx, pa)
 }
 
 func (this *transactor) TransactAndHold(privKey, address, data []byte, gasLimit, fee int64) (*txs.EventDataCall, error) {
@@ -226,7 +361,10 @@ func (this *transactor) TransactAndHold(privKey, address, data []byte, gasLimit,
 	// after which we want to block (and then discard the value - see below)
 	wc := make(chan *txs.EventDataCall, 1)
 	subId := fmt.Sprintf("%X", rec.TxHash)
-	this.eventEmitter.Subscribe(subId, txs.EventStringAccCall(addr),
+	// Cancelling the context tears down the subscription on every return path
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
+	defer cancel()
+	this.eventEmitter.Subscribe(ctx, subId, txs.EventStringAccCall(addr),
 		func(evt txs.EventData) {
 			eventDataCall := evt.(txs.EventDataCall)
 			if bytes.Equal(eventDataCall.TxID, rec.TxHash) {
@@ -243,24 +381,19 @@ func (this *transactor) TransactAndHold(privKey, address, data []byte, gasLimit,
 			}
 		})
 
-	timer := time.NewTimer(300 * time.Second)
-	toChan := timer.C
-
 	var ret *txs.EventDataCall
 	var rErr error
 
 	select {
-	case <-toChan:
+	case <-ctx.Done():
 		rErr = fmt.Errorf("Transaction timed out. Hash: " + subId)
 	case e := <-wc:
-		timer.Stop()
 		if e.Exception != "" {
 			rErr = fmt.Errorf("Error when transacting: " + e.Exception)
 		} else {
 			ret = e
 		}
 	}
-	this.eventEmitter.Unsubscribe(subId)
 	return ret, rErr
 }
 
@@ -287,19 +420,14 @@ func (this *transactor) Send(privKey, toAddress []byte,
 	defer this.txMtx.Unlock()
 	pa := account.GenPrivAccountFromPrivKeyBytes(privKey)
 	cache := this.burrowMint.GetState()
-	acc := cache.GetAccount(pa.Address)
-	var sequence int
-	if acc == nil {
-		sequence = 1
-	} else {
-		sequence = acc.Sequence + 1
-	}
+	sequence := this.nextSequence(pa.Address)
 
 	tx := txs.NewSendTx()
 
+	// The inputs must cover the base fee on top of the amount sent
 	txInput := &txs.TxInput{
 		Address:  pa.Address,
-		Amount:   amount,
+		Amount:   amount + cache.BaseFee,
 		Sequence: sequence,
 		PubKey:   pa.PubKey,
 	}
@@ -311,11 +439,7 @@ func (this *transactor) Send(privKey, toAddress []byte,
 	tx.Outputs = append(tx.Outputs, txOutput)
 
 	// Got ourselves a tx.
-	txS, errS := this.SignTx(tx, []*account.PrivAccount{pa})
-	if errS != nil {
-		return nil, errS
-	}
-	return this.BroadcastTx(txS)
+	return this.signAndBroadcast(tx, pa)
 }
 
 func (this *transactor) SendAndHold(privKey, toAddress []byte,
@@ -325,30 +449,31 @@ func (this *transactor) SendAndHold(privKey, toAddress []byte,
 		return nil, tErr
 	}
 
-	wc := make(chan *txs.SendTx)
+	wc := make(chan *txs.SendTx, 1)
 	subId := fmt.Sprintf("%X", rec.TxHash)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
+	defer cancel()
 
-	this.eventEmitter.Subscribe(subId, txs.EventStringAccOutput(toAddress),
+	this.eventEmitter.Subscribe(ctx, subId, txs.EventStringAccOutput(toAddress),
 		func(evt txs.EventData) {
 			event := evt.(txs.EventDataTx)
 			tx := event.Tx.(*txs.SendTx)
-			wc <- tx
+			// Non-blocking send for the same reason as in TransactAndHold
+			select {
+			case wc <- tx:
+			default:
+			}
 		})
 
-	timer := time.NewTimer(300 * time.Second)
-	toChan := timer.C
-
 	var rErr error
 
 	pa := account.GenPrivAccountFromPrivKeyBytes(privKey)
 
 	select {
-	case <-toChan:
+	case <-ctx.Done():
 		rErr = fmt.Errorf("Transaction timed out. Hash: " + subId)
 	case e := <-wc:
 		if bytes.Equal(e.Inputs[0].Address, pa.Address) && e.Inputs[0].Amount == amount {
-			timer.Stop()
-			this.eventEmitter.Unsubscribe(subId)
 			return rec, rErr
 		}
 	}
@@ -364,21 +489,64 @@ func (this *transactor) TransactNameReg(privKey []byte, name, data string,
 	this.txMtx.Lock()
 	defer this.txMtx.Unlock()
 	pa := account.GenPrivAccountFromPrivKeyBytes(privKey)
+	sequence := this.nextSequence(pa.Address)
+	tx := txs.NewNameTxWithNonce(pa.PubKey, name, data, amount, fee, sequence)
+	// Got ourselves a tx.
+	return this.signAndBroadcast(tx, pa)
+}
+
+func (this *transactor) RenewName(privKey []byte, name string,
+	amount, fee int64) (*txs.Receipt, error) {
+
+	if len(privKey) != 64 {
+		return nil, fmt.Errorf("Private key is not of the right length: %d\n", len(privKey))
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("Renewing a name must add some credit to it")
+	}
+	this.txMtx.Lock()
+	defer this.txMtx.Unlock()
+	pa := account.GenPrivAccountFromPrivKeyBytes(privKey)
 	cache := this.burrowMint.GetCheckCache() // XXX: DON'T MUTATE THIS CACHE (used internally for CheckTx)
-	acc := cache.GetAccount(pa.Address)
+	entry := cache.GetNameRegEntry(name)
+	if entry == nil {
+		return nil, fmt.Errorf("Entry %s not found", name)
+	}
+	if !bytes.Equal(entry.Owner, pa.Address) {
+		return nil, fmt.Errorf("Entry %s is owned by %X, not %X", name,
+			entry.Owner, pa.Address)
+	}
+	sequence := this.nextSequence(pa.Address)
+	tx := txs.NewNameTxWithNonce(pa.PubKey, name, entry.Data, amount, fee, sequence)
+	return this.signAndBroadcast(tx, pa)
+}
+
+// Reserves the sequence number of the next tx signed for address, which
+// follows both the check cache and the txs already signed for address
+func (this *transactor) nextSequence(address []byte) int {
 	var sequence int
-	if acc == nil {
-		sequence = 1
-	} else {
-		sequence = acc.Sequence + 1
+	cache := this.burrowMint.GetCheckCache() // XXX: DON'T MUTATE THIS CACHE (used internally for CheckTx)
+	if acc := cache.GetAccount(address); acc != nil {
+		sequence = acc.Sequence
 	}
-	tx := txs.NewNameTxWithNonce(pa.PubKey, name, data, amount, fee, sequence)
-	// Got ourselves a tx.
-	txS, errS := this.SignTx(tx, []*account.PrivAccount{pa})
-	if errS != nil {
-		return nil, errS
+	return this.sequences.reserve(address, sequence)
+}
+
+// Signs tx, whose only input is from pa, and broadcasts it. If the tx does not
+// make it into the mempool the sequence numbers of pa are resynced with state.
+func (this *transactor) signAndBroadcast(tx txs.Tx,
+	pa *account.PrivAccount) (*txs.Receipt, error) {
+	txS, err := this.SignTx(tx, []*account.PrivAccount{pa})
+	if err != nil {
+		this.sequences.resync(pa.Address)
+		return nil, err
 	}
-	return this.BroadcastTx(txS)
+	receipt, err := this.BroadcastTx(txS)
+	if err != nil {
+		this.sequences.resync(pa.Address)
+		return nil, err
+	}
+	return receipt, nil
 }
 
 // Sign a transaction
@@ -395,6 +563,18 @@ func (this *transactor) SignTx(tx txs.Tx, privAccounts []*account.PrivAccount) (
 		nameTx := tx.(*txs.NameTx)
 		nameTx.Input.PubKey = privAccounts[0].PubKey
 		nameTx.Input.Signature = privAccounts[0].Sign(this.chainID, nameTx)
+	case *txs.ABITx:
+		abiTx := tx.(*txs.ABITx)
+		abiTx.Input.PubKey = privAccounts[0].PubKey
+		abiTx.Input.Signature = privAccounts[0].Sign(this.chainID, abiTx)
+	case *txs.RelayTx:
+		relayTx := tx.(*txs.RelayTx)
+		relayTx.Input.PubKey = privAccounts[0].PubKey
+		relayTx.Input.Signature = privAccounts[0].Sign(this.chainID, relayTx)
+	case *txs.PrivateTx:
+		privateTx := tx.(*txs.PrivateTx)
+		privateTx.Input.PubKey = privAccounts[0].PubKey
+		privateTx.Input.Signature = privAccounts[0].Sign(this.chainID, privateTx)
 	case *txs.SendTx:
 		sendTx := tx.(*txs.SendTx)
 		for i, input := range sendTx.Inputs {
@@ -405,6 +585,32 @@ func (this *transactor) SignTx(tx txs.Tx, privAccounts []*account.PrivAccount) (
 		callTx := tx.(*txs.CallTx)
 		callTx.Input.PubKey = privAccounts[0].PubKey
 		callTx.Input.Signature = privAccounts[0].Sign(this.chainID, callTx)
+	case *txs.PriorityFeeTx:
+		priorityFeeTx := tx.(*txs.PriorityFeeTx)
+		for i, input := range priorityFeeTx.Inputs() {
+			input.PubKey = privAccounts[i].PubKey
+			input.Signature = privAccounts[i].Sign(this.chainID, priorityFeeTx)
+		}
+	case *txs.ExpiringTx:
+		expiringTx := tx.(*txs.ExpiringTx)
+		for i, input := range expiringTx.Inputs() {
+			input.PubKey = privAccounts[i].PubKey
+			input.Signature = privAccounts[i].Sign(this.chainID, expiringTx)
+		}
+	case *txs.ProposalTx:
+		proposalTx := tx.(*txs.ProposalTx)
+		proposalTx.Input.PubKey = privAccounts[0].PubKey
+		proposalTx.Input.Signature = privAccounts[0].Sign(this.chainID, proposalTx)
+	case *txs.MultisigTx:
+		multisigTx := tx.(*txs.MultisigTx)
+		// each privaccount signs as one of the signatories
+		for _, privAccount := range privAccounts {
+			err := multisigTx.AddSignature(this.chainID, privAccount.PubKey,
+				privAccount.Sign(this.chainID, multisigTx))
+			if err != nil {
+				return nil, err
+			}
+		}
 	case *txs.BondTx:
 		bondTx := tx.(*txs.BondTx)
 		// the first privaccount corresponds to the BondTx pub key.
@@ -420,12 +626,30 @@ func (this *transactor) SignTx(tx txs.Tx, privAccounts []*account.PrivAccount) (
 	case *txs.RebondTx:
 		rebondTx := tx.(*txs.RebondTx)
 		rebondTx.Signature = privAccounts[0].Sign(this.chainID, rebondTx).(crypto.SignatureEd25519)
+	case *txs.RotateTx:
+		rotateTx := tx.(*txs.RotateTx)
+		// the first privaccount holds the validator key, the second the new key
+		if len(privAccounts) != 2 {
+			return nil, fmt.Errorf("RotateTx must be signed by the validator key and the new key")
+		}
+		rotateTx.Signature = privAccounts[0].Sign(this.chainID, rotateTx).(crypto.SignatureEd25519)
+		rotateTx.NewSignature = privAccounts[1].Sign(this.chainID, rotateTx).(crypto.SignatureEd25519)
+	case *txs.IdentifyTx:
+		identifyTx := tx.(*txs.IdentifyTx)
+		identifyTx.Signature = privAccounts[0].Sign(this.chainID, identifyTx).(crypto.SignatureEd25519)
+	case *txs.AttestTx:
+		attestTx := tx.(*txs.AttestTx)
+		attestTx.Signature = privAccounts[0].Sign(this.chainID, attestTx).(crypto.SignatureEd25519)
 	default:
 		return nil, fmt.Errorf("Object is not a proper transaction: %v\n", tx)
 	}
 	return tx, nil
 }
 
+func (this *transactor) BaseFee() int64 {
+	return this.burrowMint.GetState().BaseFee
+}
+
 // No idea what this does.
 func toVMAccount(acc *account.Account) *vm.Account {
 	return &vm.Account{
@@ -0,0 +1,97 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burrowmint
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/hyperledger/burrow/logging"
+	manager_types "github.com/hyperledger/burrow/manager/types"
+)
+
+// Where the upgrade marker is written in the data directory
+const upgradeMarkerFile = "upgrade.json"
+
+var errHalted = errors.New("Node has halted for the upgrade of the chain")
+
+var _ manager_types.HaltingApplication = (*BurrowMint)(nil)
+
+// Written once the node halts for an upgrade, so that the operator (or a
+// process supervising the node) knows the binary of which version to start and
+// the state it takes over
+type UpgradeMarker struct {
+	ChainID string `json:"chain_id"`
+	// The upgrade, which the binary of version Name executes from Height
+	Name   string `json:"name"`
+	Height int    `json:"height"`
+	// The state halted at, that of the block before Height
+	LastBlockHeight int    `json:"last_block_height"`
+	AppHash         []byte `json:"app_hash"`
+}
+
+// Writes an UpgradeMarker to file once the node halts for an upgrade
+func (app *BurrowMint) SetUpgradeFile(file string) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.upgradeFile = file
+}
+
+// Implements manager/types.HaltingApplication
+func (app *BurrowMint) Halted() <-chan struct{} {
+	return app.halted
+}
+
+func (app *BurrowMint) isHalted() bool {
+	select {
+	case <-app.halted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Halts once the block before the upgrade height has been committed with
+// appHash, saving state and writing the upgrade marker, app.mtx must be held
+func (app *BurrowMint) haltForUpgrade(appHash []byte) {
+	if app.unsaved {
+		app.saveState()
+		app.flushWrites()
+	}
+	upgrade := app.state.Upgrade
+	logging.InfoMsg(app.logger, "Halting for upgrade",
+		"name", upgrade.Name,
+		"height", upgrade.Height,
+		"app_hash", appHash)
+	if app.upgradeFile != "" {
+		marker, err := json.MarshalIndent(&UpgradeMarker{
+			ChainID:         app.state.ChainID,
+			Name:            upgrade.Name,
+			Height:          upgrade.Height,
+			LastBlockHeight: app.state.LastBlockHeight,
+			AppHash:         appHash,
+		}, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(app.upgradeFile, marker, 0644)
+		}
+		if err != nil {
+			logging.InfoMsg(app.logger, "Failed to write upgrade marker",
+				"file", app.upgradeFile,
+				"error", err)
+		}
+	}
+	close(app.halted)
+}
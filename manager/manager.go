@@ -16,6 +16,7 @@ package manager
 
 import (
 	"fmt"
+	"io"
 
 	events "github.com/tendermint/go-events"
 
@@ -42,3 +43,24 @@ func NewApplicationPipe(moduleConfig *config.ModuleConfig,
 	}
 	return nil, fmt.Errorf("Failed to return Pipe for %s", moduleConfig.Name)
 }
+
+// DumpState writes a dump of the application state committed at height in
+// the module's data directory to w, or of the last committed state if height
+// is 0.
+func DumpState(moduleConfig *config.ModuleConfig, height int, w io.Writer) error {
+	switch moduleConfig.Name {
+	case "burrowmint":
+		return burrowmint.DumpState(moduleConfig, height, w)
+	}
+	return fmt.Errorf("Failed to dump state for %s", moduleConfig.Name)
+}
+
+// RestoreState initialises the application state in the module's data
+// directory from a dump read from r.
+func RestoreState(moduleConfig *config.ModuleConfig, r io.Reader) error {
+	switch moduleConfig.Name {
+	case "burrowmint":
+		return burrowmint.RestoreState(moduleConfig, r)
+	}
+	return fmt.Errorf("Failed to restore state for %s", moduleConfig.Name)
+}
@@ -18,6 +18,7 @@ import (
 	// TODO: [ben] this is currently only used for abci result type; but should
 	// be removed as abci dependencies shouldn't feature in the application
 	// manager
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
 	consensus_types "github.com/hyperledger/burrow/consensus/types"
 	abci "github.com/tendermint/abci/types"
 )
@@ -102,3 +103,41 @@ type Application interface {
 	// Is this the passed ConsensusEngine compatible with this manager
 	CompatibleConsensus(consensusEngine consensus_types.ConsensusEngine) bool
 }
+
+// An Application that keeps account of the txs in the mempool, which must be
+// told of the txs removed from the mempool other than by a block
+type MempoolApplication interface {
+	Application
+
+	// Forgets the txs with txHashes, which are being removed from the mempool,
+	// and resets the state the txs left are checked against to the committed
+	// state so that the consensus engine can recheck them
+	RemoveMempoolTxs(txHashes [][]byte)
+}
+
+// An Application that the blocks a node stored but did not execute before it
+// stopped can be replayed through before its consensus engine starts
+type ReplayApplication interface {
+	Application
+
+	// The height of the last block the application committed
+	LastBlockHeight() int
+
+	// Reads the commits of past blocks from blockStore
+	SetBlockStore(blockStore blockchain_types.BlockStore)
+
+	// Only save state every interval blocks that are committed instead of
+	// every block, as when interval is not above 1. Any state committed but
+	// not yet saved is saved when the interval is set.
+	SetSaveInterval(interval int)
+}
+
+// An Application that halts at a height, such as that of an upgrade of the
+// chain, past which it executes no more blocks, so that the node can be
+// stopped
+type HaltingApplication interface {
+	Application
+
+	// Closed once the application has halted
+	Halted() <-chan struct{}
+}
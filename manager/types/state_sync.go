@@ -0,0 +1,92 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// NOTE: these mirror the state sync methods and types of later versions of
+// the abci protocol, which the version of abci we build against predates, so
+// that a consensus engine that syncs state can drive them.
+
+// A snapshot of the application state at a height, which a new node can
+// restore from its chunks instead of replaying every block up to the height.
+type Snapshot struct {
+	Height uint64 `json:"height"`
+	// The format of the chunks, should it change
+	Format uint32 `json:"format"`
+	Chunks uint32 `json:"chunks"`
+	// Identifies the snapshot, it is not trusted on its own
+	Hash []byte `json:"hash"`
+	// Whatever the application needs to restore the snapshot
+	Metadata []byte `json:"metadata"`
+}
+
+type OfferSnapshotResult uint8
+
+const (
+	// Restore the snapshot, after which its chunks are applied
+	OfferSnapshotAccept OfferSnapshotResult = iota
+	// Abort state sync altogether
+	OfferSnapshotAbort
+	// Reject the snapshot and try another
+	OfferSnapshotReject
+	// Reject every snapshot of the snapshot's format
+	OfferSnapshotRejectFormat
+	// Reject every snapshot from the peer that offered the snapshot
+	OfferSnapshotRejectSender
+)
+
+type ApplySnapshotChunkResult uint8
+
+const (
+	// The chunk was applied, proceed with the next
+	ApplySnapshotChunkAccept ApplySnapshotChunkResult = iota
+	// Abort state sync altogether
+	ApplySnapshotChunkAbort
+	// Fetch the chunk again and retry applying it
+	ApplySnapshotChunkRetry
+	// Restart the snapshot from its first chunk
+	ApplySnapshotChunkRetrySnapshot
+	// Reject the snapshot and try another
+	ApplySnapshotChunkRejectSnapshot
+)
+
+type ResponseApplySnapshotChunk struct {
+	Result ApplySnapshotChunkResult
+	// Chunks to fetch again, whether or not they were already applied
+	RefetchChunks []uint32
+	// Peers to stop fetching chunks from, such as the sender of a bad chunk
+	RejectSenders []string
+}
+
+// An Application that takes snapshots of its state and can restore its state
+// from those of other nodes.
+type StateSyncApplication interface {
+	Application
+
+	// The snapshots the application can serve chunks of
+	ListSnapshots() []*Snapshot
+
+	// Offers a snapshot to restore state from to an application that has not
+	// executed any blocks. appHash is the trusted application hash at the
+	// height of the snapshot, which the restored state must have.
+	OfferSnapshot(snapshot *Snapshot, appHash []byte) OfferSnapshotResult
+
+	// Loads a chunk of one of the snapshots ListSnapshots returns, or nil if
+	// there is no such snapshot or chunk
+	LoadSnapshotChunk(height uint64, format uint32, chunk uint32) []byte
+
+	// Applies the chunk at index of the accepted snapshot, which sender
+	// served. State is restored once every chunk has been applied.
+	ApplySnapshotChunk(index uint32, chunk []byte, sender string) ResponseApplySnapshotChunk
+}
@@ -0,0 +1,372 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Metrics for monitoring a node, exported in the Prometheus text exposition
+// format so that they can be scraped by a standard Prometheus server
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ContentType = "text/plain; version=0.0.4"
+
+// The default buckets for histograms of durations in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Returns count buckets, the first at start and each factor times the last
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// A Registry holds metrics and writes out their current values
+type Registry struct {
+	mtx      sync.Mutex
+	families map[string]*family
+}
+
+// The registry of the metrics of the node served on the metrics endpoint
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// A Counter only goes up. labelNames give the labels whose values are passed,
+// in order, on each use of the counter.
+type Counter struct {
+	*family
+}
+
+// A Gauge can be set to any value
+type Gauge struct {
+	*family
+}
+
+// A GaugeFunc takes its value from a function called when metrics are written
+type GaugeFunc struct {
+	*family
+}
+
+// A Histogram counts observations in buckets of the values they are no more
+// than, and keeps the sum and count of all observations
+type Histogram struct {
+	*family
+}
+
+// A family of series of a metric, one series for each combination of label
+// values used
+type family struct {
+	mtx        sync.Mutex
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+	isFunc     bool
+	fn         func() float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+// Creates a counter in registry, panicking if the name is already taken
+func (registry *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{registry.register(name, help, "counter", nil, labelNames)}
+}
+
+func (registry *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{registry.register(name, help, "gauge", nil, labelNames)}
+}
+
+// Creates a gauge that is not written out until it is given a function
+func (registry *Registry) NewGaugeFunc(name, help string) *GaugeFunc {
+	f := registry.register(name, help, "gauge", nil, nil)
+	f.isFunc = true
+	return &GaugeFunc{f}
+}
+
+// Creates a histogram with buckets, which must be in increasing order
+func (registry *Registry) NewHistogram(name, help string, buckets []float64,
+	labelNames ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Errorf("Buckets of histogram %s are not in increasing order", name))
+	}
+	return &Histogram{registry.register(name, help, "histogram", buckets, labelNames)}
+}
+
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labelNames...)
+}
+
+func NewGaugeFunc(name, help string) *GaugeFunc {
+	return DefaultRegistry.NewGaugeFunc(name, help)
+}
+
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
+
+func (registry *Registry) register(name, help, kind string, buckets []float64,
+	labelNames []string) *family {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+	if _, ok := registry.families[name]; ok {
+		panic(fmt.Errorf("Metric %s is already registered", name))
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	registry.families[name] = f
+	return f
+}
+
+// Gets the series of labelValues, f.mtx must be held
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Errorf("Metric %s has labels %v but was given values %v",
+			f.name, f.labelNames, labelValues))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		if f.buckets != nil {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) add(delta float64, labelValues []string) {
+	f.mtx.Lock()
+	f.get(labelValues).value += delta
+	f.mtx.Unlock()
+}
+
+// The value of the series of labelValues, the sum of its observations for a
+// histogram
+func (f *family) Value(labelValues ...string) float64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.get(labelValues).value
+}
+
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.add(1, labelValues)
+}
+
+// Adds delta, which must not be negative, to the counter
+func (counter *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Errorf("Counter %s cannot decrease", counter.name))
+	}
+	counter.add(delta, labelValues)
+}
+
+func (gauge *Gauge) Set(value float64, labelValues ...string) {
+	gauge.mtx.Lock()
+	gauge.get(labelValues).value = value
+	gauge.mtx.Unlock()
+}
+
+func (gauge *Gauge) Add(delta float64, labelValues ...string) {
+	gauge.add(delta, labelValues)
+}
+
+func (gaugeFunc *GaugeFunc) SetFunc(fn func() float64) {
+	gaugeFunc.mtx.Lock()
+	gaugeFunc.fn = fn
+	gaugeFunc.mtx.Unlock()
+}
+
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	histogram.mtx.Lock()
+	defer histogram.mtx.Unlock()
+	s := histogram.get(labelValues)
+	for i, bound := range histogram.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.value += value
+	s.count++
+}
+
+// Observes the seconds since start
+func (histogram *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	histogram.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Writes out all the metrics of the registry in order of name
+func (registry *Registry) WriteTo(w io.Writer) (int64, error) {
+	registry.mtx.Lock()
+	families := make([]*family, 0, len(registry.families))
+	for _, f := range registry.families {
+		families = append(families, f)
+	}
+	registry.mtx.Unlock()
+	sort.Sort(familiesByName(families))
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		f.writeTo(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Serves the metrics of the registry to a Prometheus scrape
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	registry.WriteTo(w)
+}
+
+func (f *family) writeTo(w *bufio.Writer) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	// Nothing is known of a labelled metric until it is first used
+	if (f.isFunc && f.fn == nil) || (len(f.labelNames) > 0 && len(f.series) == 0) {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	if f.isFunc {
+		writeSample(w, f.name, nil, nil, f.fn())
+		return
+	}
+	if len(f.series) == 0 {
+		// Whereas one without labels starts at zero
+		f.get(nil)
+	}
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			writeSample(w, f.name, f.labelNames, s.labelValues, s.value)
+			continue
+		}
+		labelNames := append(append([]string{}, f.labelNames...), "le")
+		for i, bound := range f.buckets {
+			writeSample(w, f.name+"_bucket", labelNames,
+				append(append([]string{}, s.labelValues...), formatFloat(bound)),
+				float64(s.counts[i]))
+		}
+		writeSample(w, f.name+"_bucket", labelNames,
+			append(append([]string{}, s.labelValues...), "+Inf"), float64(s.count))
+		writeSample(w, f.name+"_sum", f.labelNames, s.labelValues, s.value)
+		writeSample(w, f.name+"_count", f.labelNames, s.labelValues, float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string,
+	value float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 {
+		w.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", labelName, escapeLabelValue(labelValues[i]))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper       = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	labelValueEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"")
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+type familiesByName []*family
+
+func (fs familiesByName) Len() int {
+	return len(fs)
+}
+
+func (fs familiesByName) Less(i, j int) bool {
+	return fs[i].name < fs[j].name
+}
+
+func (fs familiesByName) Swap(i, j int) {
+	fs[i], fs[j] = fs[j], fs[i]
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTo(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("requests_total", "Requests served", "method", "code")
+	gauge := registry.NewGauge("height", "The height")
+	registry.NewGauge("unused", "Never set", "label")
+	gaugeFunc := registry.NewGaugeFunc("queue_size", "The size of the queue")
+	histogram := registry.NewHistogram("latency_seconds", "Latency", []float64{0.1, 1})
+
+	counter.Inc("get", "200")
+	counter.Add(2, "get", "200")
+	counter.Inc("post", "500")
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	buf := new(bytes.Buffer)
+	_, err := registry.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP height The height
+# TYPE height gauge
+height 0
+# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+# HELP requests_total Requests served
+# TYPE requests_total counter
+requests_total{method="get",code="200"} 3
+requests_total{method="post",code="500"} 1
+`, buf.String())
+
+	gauge.Set(42)
+	gaugeFunc.SetFunc(func() float64 { return 7 })
+	buf.Reset()
+	registry.WriteTo(buf)
+	assert.Contains(t, buf.String(), "height 42\n")
+	assert.Contains(t, buf.String(), "# TYPE queue_size gauge\nqueue_size 7\n")
+	assert.Equal(t, float64(3), counter.Value("get", "200"))
+}
+
+func TestLabelEscaping(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("errors_total", "Errors\nby message", "message")
+	counter.Inc("a \"quoted\"\nmessage")
+	buf := new(bytes.Buffer)
+	registry.WriteTo(buf)
+	assert.Equal(t, `# HELP errors_total Errors\nby message
+# TYPE errors_total counter
+errors_total{message="a \"quoted\"\nmessage"} 1
+`, buf.String())
+}
+
+func TestRegisterPanics(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("total", "Total", "label")
+	assert.Panics(t, func() { registry.NewGauge("total", "Again") })
+	assert.Panics(t, func() { counter.Inc() })
+	assert.Panics(t, func() { counter.Add(-1, "label") })
+	assert.Panics(t, func() {
+		registry.NewHistogram("histogram", "Unsorted", []float64{1, 0.1})
+	})
+}
+
+func TestServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("total", "Total").Inc()
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP total Total\n# TYPE total counter\ntotal 1\n",
+		recorder.Body.String())
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	assert.Equal(t, []float64{1, 10, 100}, ExponentialBuckets(1, 10, 3))
+}
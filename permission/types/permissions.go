@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/hyperledger/burrow/word256"
+	"golang.org/x/crypto/ripemd160"
 )
 
 //------------------------------------------------------------------------------------------------
@@ -28,6 +29,15 @@ var (
 	GlobalPermissionsAddress256 = word256.Zero256
 )
 
+// The address of the account holding the permissions of a group. The
+// accounts that have the group as a role are its members.
+func GroupAddress(group string) []byte {
+	hasher := ripemd160.New()
+	hasher.Write([]byte("PermissionGroup/"))
+	hasher.Write(word256.RightPadBytes([]byte(group), 32))
+	return hasher.Sum(nil)
+}
+
 // A particular permission
 type PermFlag uint64
 
@@ -51,7 +61,11 @@ const (
 	AddRole
 	RmRole
 
-	NumPermissions uint = 14 // NOTE Adjust this too. We can support upto 64
+	// governance permissions, after the moderator permissions so the flags of
+	// existing chains are unchanged
+	Vote
+
+	NumPermissions uint = 15 // NOTE Adjust this too. We can support upto 64
 
 	TopPermFlag      PermFlag = 1 << (NumPermissions - 1)
 	AllPermFlags     PermFlag = TopPermFlag | (TopPermFlag - 1)
@@ -183,6 +197,32 @@ func (aP *AccountPermissions) RmRole(role string) bool {
 	return false
 }
 
+// Get a permission value from the groups that are roles of the account.
+// getGroup returns the permissions of a group, or nil if there is no such
+// group. The permission is true if any of the groups that set it has it, and
+// ErrValueNotSet is returned if none of them sets it.
+func (aP *AccountPermissions) GroupGet(getGroup func(group string) *BasePermissions,
+	ty PermFlag) (bool, error) {
+	if ty == 0 {
+		return false, ErrInvalidPermission(ty)
+	}
+	set := false
+	for _, role := range aP.Roles {
+		groupPerms := getGroup(role)
+		if groupPerms == nil || !groupPerms.IsSet(ty) {
+			continue
+		}
+		if groupPerms.Perms&ty > 0 {
+			return true, nil
+		}
+		set = true
+	}
+	if !set {
+		return false, ErrValueNotSet(ty)
+	}
+	return false, nil
+}
+
 // Clone clones the account permissions
 func (accountPermissions *AccountPermissions) Clone() AccountPermissions {
 	// clone base permissions
@@ -232,6 +272,8 @@ func PermFlagToString(pf PermFlag) (perm string) {
 		perm = "addRole"
 	case RmRole:
 		perm = "removeRole"
+	case Vote:
+		perm = "vote"
 	default:
 		perm = "#-UNKNOWN-#"
 	}
@@ -270,6 +312,8 @@ func PermStringToFlag(perm string) (pf PermFlag, err error) {
 		pf = AddRole
 	case "removerole", "rmrole", "rm_role":
 		pf = RmRole
+	case "vote":
+		pf = Vote
 	default:
 		err = fmt.Errorf("Unknown permission %s", perm)
 	}
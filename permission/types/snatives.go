@@ -37,6 +37,11 @@ const (
 	PermArgsTypeHasRole   = byte(0x05)
 	PermArgsTypeAddRole   = byte(0x06)
 	PermArgsTypeRmRole    = byte(0x07)
+
+	PermArgsTypeSetGroup        = byte(0x08)
+	PermArgsTypeUnsetGroup      = byte(0x09)
+	PermArgsTypeAddGroupMembers = byte(0x0A)
+	PermArgsTypeRmGroupMembers  = byte(0x0B)
 )
 
 // TODO: [ben] this registration needs to be lifted up
@@ -53,6 +58,10 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{&HasRoleArgs{}, PermArgsTypeHasRole},
 	wire.ConcreteType{&AddRoleArgs{}, PermArgsTypeAddRole},
 	wire.ConcreteType{&RmRoleArgs{}, PermArgsTypeRmRole},
+	wire.ConcreteType{&SetGroupArgs{}, PermArgsTypeSetGroup},
+	wire.ConcreteType{&UnsetGroupArgs{}, PermArgsTypeUnsetGroup},
+	wire.ConcreteType{&AddGroupMembersArgs{}, PermArgsTypeAddGroupMembers},
+	wire.ConcreteType{&RmGroupMembersArgs{}, PermArgsTypeRmGroupMembers},
 )
 
 type HasBaseArgs struct {
@@ -118,3 +127,46 @@ type RmRoleArgs struct {
 func (*RmRoleArgs) PermFlag() PermFlag {
 	return RmRole
 }
+
+// Groups are roles with permissions of their own, held by an account at
+// GroupAddress(group). Setting a group's permissions takes the same
+// permissions as setting an account's, and adding or removing members takes
+// the same permissions as adding or removing roles. A group is created the
+// first time one of its permissions is set.
+
+type SetGroupArgs struct {
+	Group      string   `json:"group"`
+	Permission PermFlag `json:"permission"`
+	Value      bool     `json:"value"`
+}
+
+func (*SetGroupArgs) PermFlag() PermFlag {
+	return SetBase
+}
+
+type UnsetGroupArgs struct {
+	Group      string   `json:"group"`
+	Permission PermFlag `json:"permission"`
+}
+
+func (*UnsetGroupArgs) PermFlag() PermFlag {
+	return UnsetBase
+}
+
+type AddGroupMembersArgs struct {
+	Group     string   `json:"group"`
+	Addresses [][]byte `json:"addresses"`
+}
+
+func (*AddGroupMembersArgs) PermFlag() PermFlag {
+	return AddRole
+}
+
+type RmGroupMembersArgs struct {
+	Group     string   `json:"group"`
+	Addresses [][]byte `json:"addresses"`
+}
+
+func (*RmGroupMembersArgs) PermFlag() PermFlag {
+	return RmRole
+}
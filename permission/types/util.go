@@ -47,3 +47,15 @@ func convertPermissionsMapStringIntToBasePermissions(permissions map[string]bool
 
 	return basePermissions, nil
 }
+
+// PermFlagToStrings gets the names of the permissions in a permission mask in
+// order of their flags
+func PermFlagToStrings(pf PermFlag) []string {
+	var perms []string
+	for flag := Root; flag != 0 && flag <= pf; flag <<= 1 {
+		if pf&flag != 0 {
+			perms = append(perms, PermFlagToString(flag))
+		}
+	}
+	return perms
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Recursive Length Prefix encoding, Ethereum's serialisation of nested byte
+// strings, as used by Ethereum transactions
+package rlp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrTrailingBytes = errors.New("RLP value is followed by trailing bytes")
+	ErrTruncated     = errors.New("RLP value is truncated")
+	ErrNonCanonical  = errors.New("RLP value is not canonically encoded")
+)
+
+// A decoded RLP value, which is either a byte string or a list of values
+type Value struct {
+	IsList bool
+	Bytes  []byte
+	List   []*Value
+}
+
+// Decodes exactly one value from bs. Only canonical encodings, those with the
+// shortest length prefixes, are accepted so each value has one encoding.
+func Decode(bs []byte) (*Value, error) {
+	value, rest, err := decode(bs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailingBytes
+	}
+	return value, nil
+}
+
+func decode(bs []byte) (*Value, []byte, error) {
+	if len(bs) == 0 {
+		return nil, nil, ErrTruncated
+	}
+	prefix := bs[0]
+	switch {
+	case prefix < 0x80:
+		return &Value{Bytes: bs[:1]}, bs[1:], nil
+	case prefix < 0xc0:
+		payload, rest, err := payload(bs, 0x80)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(payload) == 1 && payload[0] < 0x80 {
+			// Should have been encoded as itself
+			return nil, nil, ErrNonCanonical
+		}
+		return &Value{Bytes: payload}, rest, nil
+	}
+	payload, rest, err := payload(bs, 0xc0)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := &Value{IsList: true, List: []*Value{}}
+	for len(payload) > 0 {
+		var item *Value
+		item, payload, err = decode(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		value.List = append(value.List, item)
+	}
+	return value, rest, nil
+}
+
+// Splits the payload of the string or list at the start of bs from what
+// follows it, where offset is 0x80 for strings and 0xc0 for lists
+func payload(bs []byte, offset byte) ([]byte, []byte, error) {
+	prefix := bs[0] - offset
+	bs = bs[1:]
+	var length uint64
+	if prefix <= 55 {
+		length = uint64(prefix)
+	} else {
+		lengthSize := int(prefix - 55)
+		if len(bs) < lengthSize {
+			return nil, nil, ErrTruncated
+		}
+		if bs[0] == 0 {
+			return nil, nil, ErrNonCanonical
+		}
+		if lengthSize > 8 {
+			return nil, nil, fmt.Errorf("RLP length of %v bytes is too long",
+				lengthSize)
+		}
+		lengthBytes := make([]byte, 8)
+		copy(lengthBytes[8-lengthSize:], bs[:lengthSize])
+		length = binary.BigEndian.Uint64(lengthBytes)
+		if length <= 55 {
+			return nil, nil, ErrNonCanonical
+		}
+		bs = bs[lengthSize:]
+	}
+	if uint64(len(bs)) < length {
+		return nil, nil, ErrTruncated
+	}
+	return bs[:length], bs[length:], nil
+}
+
+// Reads the value as a canonically encoded unsigned integer, which has no
+// leading zeroes, that fits in 64 bits
+func (value *Value) Uint64() (uint64, error) {
+	n, err := value.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if n.BitLen() > 64 {
+		return 0, fmt.Errorf("RLP integer %v does not fit in 64 bits", n)
+	}
+	return n.Uint64(), nil
+}
+
+// Reads the value as a canonically encoded unsigned integer
+func (value *Value) BigInt() (*big.Int, error) {
+	if value.IsList {
+		return nil, fmt.Errorf("RLP list is not an integer")
+	}
+	if len(value.Bytes) > 0 && value.Bytes[0] == 0 {
+		return nil, ErrNonCanonical
+	}
+	return new(big.Int).SetBytes(value.Bytes), nil
+}
+
+// Encodes a byte string
+func EncodeBytes(bs []byte) []byte {
+	if len(bs) == 1 && bs[0] < 0x80 {
+		return []byte{bs[0]}
+	}
+	return append(lengthPrefix(len(bs), 0x80), bs...)
+}
+
+// Encodes an unsigned integer as the big-endian byte string with no leading
+// zeroes
+func EncodeUint64(n uint64) []byte {
+	return EncodeBigInt(new(big.Int).SetUint64(n))
+}
+
+// Encodes a non-negative integer
+func EncodeBigInt(n *big.Int) []byte {
+	return EncodeBytes(n.Bytes())
+}
+
+// Encodes a list of already encoded items
+func EncodeList(items ...[]byte) []byte {
+	payload := bytes.Join(items, nil)
+	return append(lengthPrefix(len(payload), 0xc0), payload...)
+}
+
+func lengthPrefix(length int, offset byte) []byte {
+	if length <= 55 {
+		return []byte{offset + byte(length)}
+	}
+	lengthBytes := new(big.Int).SetUint64(uint64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(lengthBytes))}, lengthBytes...)
+}
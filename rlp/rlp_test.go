@@ -0,0 +1,106 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, []byte{0x80}, EncodeBytes(nil))
+	assert.Equal(t, []byte{0x0f}, EncodeBytes([]byte{0x0f}))
+	assert.Equal(t, []byte{0x83, 'd', 'o', 'g'}, EncodeBytes([]byte("dog")))
+	assert.Equal(t, []byte{0x80}, EncodeUint64(0))
+	assert.Equal(t, []byte{0x82, 0x04, 0x00}, EncodeUint64(1024))
+	assert.Equal(t, []byte{0xc0}, EncodeList())
+	assert.Equal(t, []byte{0xc8, 0x83, 'c', 'a', 't', 0x83, 'd', 'o', 'g'},
+		EncodeList(EncodeBytes([]byte("cat")), EncodeBytes([]byte("dog"))))
+	// The set theoretical representation of three
+	assert.Equal(t, []byte{0xc7, 0xc0, 0xc1, 0xc0, 0xc3, 0xc0, 0xc1, 0xc0},
+		EncodeList(EncodeList(), EncodeList(EncodeList()),
+			EncodeList(EncodeList(), EncodeList(EncodeList()))))
+	long := bytes.Repeat([]byte{'a'}, 56)
+	assert.Equal(t, append([]byte{0xb8, 56}, long...), EncodeBytes(long))
+	long = bytes.Repeat([]byte{'a'}, 1024)
+	assert.Equal(t, append([]byte{0xb9, 0x04, 0x00}, long...), EncodeBytes(long))
+}
+
+func TestDecode(t *testing.T) {
+	value, err := Decode([]byte{0xc8, 0x83, 'c', 'a', 't', 0x83, 'd', 'o', 'g'})
+	require.NoError(t, err)
+	assert.True(t, value.IsList)
+	require.Len(t, value.List, 2)
+	assert.Equal(t, []byte("cat"), value.List[0].Bytes)
+	assert.Equal(t, []byte("dog"), value.List[1].Bytes)
+
+	value, err = Decode([]byte{0xc0})
+	require.NoError(t, err)
+	assert.True(t, value.IsList)
+	assert.Empty(t, value.List)
+
+	value, err = Decode([]byte{0x82, 0x04, 0x00})
+	require.NoError(t, err)
+	n, err := value.Uint64()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024), n)
+
+	long := bytes.Repeat([]byte{'a'}, 1024)
+	value, err = Decode(EncodeBytes(long))
+	require.NoError(t, err)
+	assert.Equal(t, long, value.Bytes)
+
+	big256, _ := new(big.Int).SetString("10000000000000000000000000000000000000000000000000000000000000000", 16)
+	value, err = Decode(EncodeBigInt(big256))
+	require.NoError(t, err)
+	decoded, err := value.BigInt()
+	require.NoError(t, err)
+	assert.Equal(t, big256, decoded)
+	_, err = value.Uint64()
+	assert.Error(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, bad := range []string{
+		"",
+		// Truncated
+		"83646f",
+		"c883636174",
+		"b90400",
+		// Trailing
+		"8000",
+		// A single small byte must be itself
+		"810f",
+		// Long form for a short length
+		"b80a",
+		// Leading zero in length
+		"b9000a",
+	} {
+		bs, err := hex.DecodeString(bad)
+		require.NoError(t, err)
+		_, err = Decode(bs)
+		assert.Error(t, err, bad)
+	}
+	// Integers have no leading zeroes
+	value, err := Decode([]byte{0x82, 0x00, 0x01})
+	require.NoError(t, err)
+	_, err = value.Uint64()
+	assert.Equal(t, ErrNonCanonical, err)
+}
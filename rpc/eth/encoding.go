@@ -0,0 +1,143 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// Ethereum JSON-RPC encodes byte strings as 0x prefixed hex, and numbers
+// ("quantities") as 0x prefixed hex with no leading zeroes
+
+func hexData(bs []byte) string {
+	return "0x" + hex.EncodeToString(bs)
+}
+
+func hexQuantity(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
+
+func hexBigQuantity(n *big.Int) string {
+	return "0x" + n.Text(16)
+}
+
+// Burrow's tx and block hashes are 20 bytes but Ethereum clients expect 32, so
+// they are left padded with zeroes
+func hexHash(hash []byte) string {
+	return hexData(LeftPadWord256(hash).Bytes())
+}
+
+// A byte string param
+type data []byte
+
+func (d *data) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("Hex data %s must start with 0x", s)
+	}
+	decoded, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return fmt.Errorf("Could not decode hex data %s: %v", s, err)
+	}
+	*d = decoded
+	return nil
+}
+
+// A quantity param
+type quantity big.Int
+
+func (q *quantity) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return err
+	}
+	n, err := parseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = quantity(*n)
+	return nil
+}
+
+func (q *quantity) Int() *big.Int {
+	return (*big.Int)(q)
+}
+
+func parseQuantity(s string) (*big.Int, error) {
+	if !strings.HasPrefix(s, "0x") || len(s) == 2 {
+		return nil, fmt.Errorf("Quantity %s must be 0x prefixed hex", s)
+	}
+	n, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok {
+		return nil, fmt.Errorf("Could not decode quantity %s", s)
+	}
+	return n, nil
+}
+
+// Checks that an address param is 20 bytes
+func address(d data) ([]byte, error) {
+	if len(d) != 20 {
+		return nil, invalidParams("Address %s must be 20 bytes", hexData(d))
+	}
+	return d, nil
+}
+
+// The burrow hash of a hash param, which must be the 32 byte padding of
+// burrow's 20 byte hash, the 20 byte hash itself, or the 32 byte hash of an
+// Ethereum tx
+func burrowHash(d data) ([]byte, error) {
+	switch {
+	case len(d) == 20:
+		return d, nil
+	case len(d) == 32 && bytes.Equal(d[:12], make([]byte, 12)):
+		return d[12:], nil
+	case len(d) == 32:
+		return d, nil
+	}
+	return nil, invalidParams("%s is not a tx hash", hexData(d))
+}
+
+// The Ethereum logs bloom filter of a set of logs: each address and topic
+// sets the 3 bits of the 2048 given by the low 11 bits of the first 3 pairs
+// of bytes of its hash
+func bloom(addresses []Word256, topics []Word256) []byte {
+	filter := make([]byte, 256)
+	add := func(bs []byte) {
+		hash := sha3.Sha3(bs)
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+			filter[255-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	for _, address := range addresses {
+		add(address.Postfix(20))
+	}
+	for _, topic := range topics {
+		add(topic.Bytes())
+	}
+	return filter
+}
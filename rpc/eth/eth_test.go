@@ -0,0 +1,349 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// A pipe serving the little the eth service needs from memory. Anything else
+// panics on the nil embedded interfaces.
+type testPipe struct {
+	definitions.Pipe
+	chain      *testChain
+	accounts   *testAccounts
+	logs       *testLogs
+	receipts   *testReceipts
+	transactor *testTransactor
+}
+
+type testChain struct {
+	blockchain_types.Blockchain
+	chainID string
+	blocks  []*tm_types.Block
+}
+
+// Interfaces to embed without their names clashing with their methods
+type (
+	accountsInterface interface {
+		definitions.Accounts
+	}
+	logsInterface interface {
+		definitions.Logs
+	}
+)
+
+type testAccounts struct {
+	accountsInterface
+	accounts map[string]*acm.Account
+}
+
+type testLogs struct {
+	logsInterface
+	logs []txs.EventDataLog
+}
+
+type testReceipts struct {
+	definitions.Receipts
+	receipts map[string]*core_types.TxReceipt
+}
+
+type testTransactor struct {
+	definitions.Transactor
+	broadcast []txs.Tx
+}
+
+func (tp *testPipe) Blockchain() blockchain_types.Blockchain { return tp.chain }
+func (tp *testPipe) Accounts() definitions.Accounts          { return tp.accounts }
+func (tp *testPipe) Logs() definitions.Logs                  { return tp.logs }
+func (tp *testPipe) Receipts() definitions.Receipts          { return tp.receipts }
+func (tp *testPipe) Transactor() definitions.Transactor      { return tp.transactor }
+
+func (tc *testChain) ChainId() string { return tc.chainID }
+func (tc *testChain) Height() int     { return len(tc.blocks) }
+
+func (tc *testChain) Block(height int) *tm_types.Block {
+	if height < 1 || height > len(tc.blocks) {
+		return nil
+	}
+	return tc.blocks[height-1]
+}
+
+func (ta *testAccounts) Account(address []byte) (*acm.Account, error) {
+	if account, ok := ta.accounts[string(address)]; ok {
+		return account, nil
+	}
+	return &acm.Account{Address: address}, nil
+}
+
+func (tl *testLogs) Logs(address, topic []byte, minHeight,
+	maxHeight int64) ([]txs.EventDataLog, error) {
+	var logs []txs.EventDataLog
+	for _, log := range tl.logs {
+		if log.Height < minHeight || log.Height > maxHeight ||
+			(address != nil && log.Address != LeftPadWord256(address)) {
+			continue
+		}
+		for _, t := range log.Topics {
+			if topic == nil || t == LeftPadWord256(topic) {
+				logs = append(logs, log)
+				break
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (tr *testReceipts) TxReceipt(txHash []byte, abiJSON string) (*core_types.TxReceipt, error) {
+	if receipt, ok := tr.receipts[string(txHash)]; ok {
+		return receipt, nil
+	}
+	return nil, fmt.Errorf("No receipt")
+}
+
+func (tt *testTransactor) Call(fromAddress, toAddress, data []byte,
+	trace bool) (*core_types.Call, error) {
+	return &core_types.Call{Return: hex.EncodeToString(append(toAddress, data...))}, nil
+}
+
+func (tt *testTransactor) BroadcastTx(tx txs.Tx) (*txs.Receipt, error) {
+	tt.broadcast = append(tt.broadcast, tx)
+	return &txs.Receipt{TxHash: txs.TxHash("1234", tx)}, nil
+}
+
+var (
+	sender   = []byte("sender______________")
+	contract = []byte("contract____________")
+	topicA   = LeftPadWord256([]byte("A"))
+	topicB   = LeftPadWord256([]byte("B"))
+)
+
+// A chain of two blocks, the second holding a CallTx emitting two logs
+func newTestPipe(t *testing.T) (*testPipe, []byte) {
+	callTx := &txs.CallTx{
+		Input:    &txs.TxInput{Address: sender, Amount: 1, Sequence: 1},
+		Address:  contract,
+		GasLimit: 100,
+	}
+	txBytes, err := txs.EncodeTx(callTx)
+	require.NoError(t, err)
+	txHash := txs.TxHash("1234", callTx)
+	logs := []*core_types.ReceiptLog{
+		{Address: LeftPadWord256(contract), Topics: []Word256{topicA}, Data: []byte{1}},
+		{Address: LeftPadWord256(contract), Topics: []Word256{topicB, topicA}},
+	}
+	return &testPipe{
+		chain: &testChain{
+			chainID: "1234",
+			blocks: []*tm_types.Block{
+				{Header: &tm_types.Header{Height: 1}, Data: &tm_types.Data{}},
+				{
+					Header: &tm_types.Header{Height: 2},
+					Data:   &tm_types.Data{Txs: []tm_types.Tx{txBytes}},
+				},
+			},
+		},
+		accounts: &testAccounts{accounts: map[string]*acm.Account{
+			string(sender): {Address: sender, Balance: 255, Sequence: 1},
+		}},
+		logs: &testLogs{logs: []txs.EventDataLog{
+			{Address: logs[0].Address, Topics: logs[0].Topics, Height: 2},
+			{Address: logs[1].Address, Topics: logs[1].Topics, Height: 2},
+		}},
+		receipts: &testReceipts{receipts: map[string]*core_types.TxReceipt{
+			string(txHash): {
+				TxHash:  txHash,
+				Height:  2,
+				Success: true,
+				GasUsed: 21,
+				Logs:    logs,
+			},
+		}},
+		transactor: &testTransactor{},
+	}, txHash
+}
+
+// Processes a JSON request returning the decoded response
+func process(t *testing.T, service *EthService, request string) interface{} {
+	w := httptest.NewRecorder()
+	service.Process(httptest.NewRequest("POST", "/eth", strings.NewReader(request)), w)
+	var response interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func call(t *testing.T, service *EthService, method string, params ...interface{}) (interface{}, map[string]interface{}) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	require.NoError(t, err)
+	response := process(t, service, fmt.Sprintf(`{"jsonrpc":"2.0","id":7,"method":%q,"params":%s}`,
+		method, paramsJSON)).(map[string]interface{})
+	assert.Equal(t, float64(7), response["id"])
+	if errorObject, ok := response["error"]; ok {
+		return nil, errorObject.(map[string]interface{})
+	}
+	return response["result"], nil
+}
+
+func result(t *testing.T, service *EthService, method string, params ...interface{}) interface{} {
+	result, errorObject := call(t, service, method, params...)
+	require.Nil(t, errorObject)
+	return result
+}
+
+func TestChain(t *testing.T) {
+	pipe, _ := newTestPipe(t)
+	service := NewEthService(pipe)
+	assert.Equal(t, "0x4d2", result(t, service, "eth_chainId"))
+	assert.Equal(t, "1234", result(t, service, "net_version"))
+	assert.Equal(t, "0x2", result(t, service, "eth_blockNumber"))
+
+	// Batches keep their ids
+	responses := process(t, service, `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},`+
+		`{"jsonrpc":"2.0","id":"two","method":"eth_mine"}]`).([]interface{})
+	require.Len(t, responses, 2)
+	assert.Equal(t, float64(1), responses[0].(map[string]interface{})["id"])
+	assert.Equal(t, "two", responses[1].(map[string]interface{})["id"])
+	assert.Contains(t, responses[1].(map[string]interface{}), "error")
+}
+
+func TestAccounts(t *testing.T) {
+	pipe, _ := newTestPipe(t)
+	service := NewEthService(pipe)
+	assert.Equal(t, "0xff", result(t, service, "eth_getBalance", hexData(sender), "latest"))
+	assert.Equal(t, "0x1", result(t, service, "eth_getTransactionCount", hexData(sender)))
+	assert.Equal(t, "0x0", result(t, service, "eth_getBalance", hexData(contract), "0x2"))
+	assert.Equal(t, "0x", result(t, service, "eth_getCode", hexData(contract), "latest"))
+
+	// Only the latest state
+	_, errorObject := call(t, service, "eth_getBalance", hexData(sender), "0x1")
+	assert.Equal(t, float64(-32602), errorObject["code"])
+	_, errorObject = call(t, service, "eth_getBalance", "0x12")
+	assert.Equal(t, float64(-32602), errorObject["code"])
+}
+
+func TestCall(t *testing.T) {
+	pipe, _ := newTestPipe(t)
+	service := NewEthService(pipe)
+	assert.Equal(t, hexData(append(contract, 0xAB)), result(t, service, "eth_call",
+		map[string]string{"to": hexData(contract), "data": "0xab"}, "latest"))
+	_, errorObject := call(t, service, "eth_call",
+		map[string]string{"to": hexData(contract), "value": "0x1"})
+	assert.NotNil(t, errorObject)
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	pipe, _ := newTestPipe(t)
+	service := NewEthService(pipe)
+	sendTx := txs.NewSendTx()
+	sendTx.AddOutput(contract, 1)
+	txBytes, err := txs.EncodeTx(sendTx)
+	require.NoError(t, err)
+	assert.Equal(t, hexHash(txs.TxHash("1234", sendTx)),
+		result(t, service, "eth_sendRawTransaction", hexData(txBytes)))
+	assert.Len(t, pipe.transactor.broadcast, 1)
+
+	// Ethereum txs are identified by their Ethereum hash
+	ethTx := &txs.EthTx{GasLimit: 21000, To: contract, Value: 1}
+	require.NoError(t, ethTx.Sign("1234", bytes.Repeat([]byte{0x46}, 32)))
+	assert.Equal(t, hexData(ethTx.Hash()),
+		result(t, service, "eth_sendRawTransaction", hexData(ethTx.RLP())))
+	require.Len(t, pipe.transactor.broadcast, 2)
+	assert.Equal(t, ethTx, pipe.transactor.broadcast[1])
+
+	_, errorObject := call(t, service, "eth_sendRawTransaction", "0x0102")
+	assert.Equal(t, float64(-32602), errorObject["code"])
+}
+
+func TestGetTransactionReceipt(t *testing.T) {
+	pipe, txHash := newTestPipe(t)
+	service := NewEthService(pipe)
+	receipt := result(t, service, "eth_getTransactionReceipt", hexHash(txHash)).(map[string]interface{})
+	assert.Equal(t, hexHash(txHash), receipt["transactionHash"])
+	assert.Equal(t, "0x2", receipt["blockNumber"])
+	assert.Equal(t, "0x0", receipt["transactionIndex"])
+	assert.Equal(t, hexData(sender), receipt["from"])
+	assert.Equal(t, hexData(contract), receipt["to"])
+	assert.Equal(t, "0x15", receipt["gasUsed"])
+	assert.Equal(t, "0x1", receipt["status"])
+	assert.Nil(t, receipt["contractAddress"])
+	logs := receipt["logs"].([]interface{})
+	require.Len(t, logs, 2)
+	assert.Equal(t, "0x1", logs[1].(map[string]interface{})["logIndex"])
+	assert.Equal(t, hexData(bloom(
+		[]Word256{LeftPadWord256(contract), LeftPadWord256(contract)},
+		[]Word256{topicA, topicB, topicA})), receipt["logsBloom"])
+
+	// Burrow's own hash works too
+	assert.NotNil(t, result(t, service, "eth_getTransactionReceipt", hexData(txHash)))
+	// Unknown txs have no receipt
+	assert.Nil(t, result(t, service, "eth_getTransactionReceipt", hexHash(sender)))
+}
+
+func TestGetLogs(t *testing.T) {
+	pipe, txHash := newTestPipe(t)
+	service := NewEthService(pipe)
+	logs := result(t, service, "eth_getLogs", map[string]interface{}{
+		"fromBlock": "0x1",
+		"address":   hexData(contract),
+	}).([]interface{})
+	require.Len(t, logs, 2)
+	log := logs[0].(map[string]interface{})
+	assert.Equal(t, hexHash(txHash), log["transactionHash"])
+	assert.Equal(t, "0x01", log["data"])
+	assert.Equal(t, []interface{}{hexData(topicA.Bytes())}, log["topics"])
+
+	// Topics are positional
+	logs = result(t, service, "eth_getLogs", map[string]interface{}{
+		"fromBlock": "earliest",
+		"topics":    []interface{}{nil, hexData(topicA.Bytes())},
+	}).([]interface{})
+	require.Len(t, logs, 1)
+	assert.Equal(t, "0x1", logs[0].(map[string]interface{})["logIndex"])
+	logs = result(t, service, "eth_getLogs", map[string]interface{}{
+		"fromBlock": "0x1",
+		"topics":    []interface{}{[]string{hexData(topicA.Bytes()), hexData(topicB.Bytes())}},
+	}).([]interface{})
+	assert.Len(t, logs, 2)
+
+	// Out of range
+	logs = result(t, service, "eth_getLogs", map[string]interface{}{
+		"toBlock": "0x1",
+		"address": hexData(contract),
+	}).([]interface{})
+	assert.Empty(t, logs)
+
+	_, errorObject := call(t, service, "eth_getLogs", map[string]interface{}{})
+	assert.Equal(t, float64(-32602), errorObject["code"])
+}
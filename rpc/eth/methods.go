@@ -0,0 +1,626 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/txs"
+	"github.com/hyperledger/burrow/version"
+	. "github.com/hyperledger/burrow/word256"
+
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+type (
+	// The params of eth_call
+	callObject struct {
+		From data `json:"from"`
+		To   data `json:"to"`
+		Data data `json:"data"`
+		// What newer clients call data
+		Input data      `json:"input"`
+		Value *quantity `json:"value"`
+	}
+
+	// The changes eth_call makes to an account before calling, as in geth's
+	// state override set. Only the slots of stateDiff can be set since the
+	// whole storage of an account cannot be replaced with state.
+	accountOverride struct {
+		Balance   *quantity       `json:"balance"`
+		Nonce     *quantity       `json:"nonce"`
+		Code      data            `json:"code"`
+		State     json.RawMessage `json:"state"`
+		StateDiff map[string]data `json:"stateDiff"`
+	}
+
+	// The params of eth_getLogs
+	logFilter struct {
+		FromBlock string `json:"fromBlock"`
+		ToBlock   string `json:"toBlock"`
+		BlockHash data   `json:"blockHash"`
+		// An address or a list of addresses
+		Address json.RawMessage `json:"address"`
+		// For each position a topic, a list of topics, or null for any
+		Topics []json.RawMessage `json:"topics"`
+	}
+
+	ethReceipt struct {
+		TransactionHash   string    `json:"transactionHash"`
+		TransactionIndex  string    `json:"transactionIndex"`
+		BlockHash         string    `json:"blockHash"`
+		BlockNumber       string    `json:"blockNumber"`
+		From              string    `json:"from"`
+		To                *string   `json:"to"`
+		CumulativeGasUsed string    `json:"cumulativeGasUsed"`
+		GasUsed           string    `json:"gasUsed"`
+		ContractAddress   *string   `json:"contractAddress"`
+		Logs              []*ethLog `json:"logs"`
+		LogsBloom         string    `json:"logsBloom"`
+		Status            string    `json:"status"`
+	}
+
+	ethLog struct {
+		Address          string   `json:"address"`
+		Topics           []string `json:"topics"`
+		Data             string   `json:"data"`
+		BlockNumber      string   `json:"blockNumber"`
+		BlockHash        string   `json:"blockHash"`
+		TransactionHash  string   `json:"transactionHash"`
+		TransactionIndex string   `json:"transactionIndex"`
+		LogIndex         string   `json:"logIndex"`
+		Removed          bool     `json:"removed"`
+	}
+)
+
+// A receipt of a tx in a block along with its Ethereum form
+type blockReceipt struct {
+	receipt *core_types.TxReceipt
+	eth     *ethReceipt
+}
+
+func (service *EthService) ClientVersion(params json.RawMessage) (interface{}, error) {
+	return "burrow/v" + version.GetSemanticVersionString(), nil
+}
+
+func (service *EthService) NetVersion(params json.RawMessage) (interface{}, error) {
+	return service.chainID().String(), nil
+}
+
+// The chain ID is the value of the CHAINID opcode, which is the burrow chain
+// ID itself when it is a number and its hash otherwise. Wallets generally
+// need a number small enough for JavaScript, so chains meant for them should
+// have numeric chain IDs.
+func (service *EthService) ChainId(params json.RawMessage) (interface{}, error) {
+	return hexBigQuantity(service.chainID()), nil
+}
+
+func (service *EthService) chainID() *big.Int {
+	return txs.EthChainID(service.pipe.Blockchain().ChainId())
+}
+
+func (service *EthService) BlockNumber(params json.RawMessage) (interface{}, error) {
+	return hexQuantity(uint64(service.pipe.Blockchain().Height())), nil
+}
+
+func (service *EthService) GetBalance(params json.RawMessage) (interface{}, error) {
+	account, err := service.account(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexQuantity(uint64(account.Balance)), nil
+}
+
+func (service *EthService) GetCode(params json.RawMessage) (interface{}, error) {
+	account, err := service.account(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexData(account.Code), nil
+}
+
+// The count is the sequence number of the account, which is the nonce of the
+// next Ethereum tx it sends
+func (service *EthService) GetTransactionCount(params json.RawMessage) (interface{}, error) {
+	account, err := service.account(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexQuantity(uint64(account.Sequence)), nil
+}
+
+// Reads the account of address and block params
+func (service *EthService) account(params json.RawMessage) (*acm.Account, error) {
+	var addressParam data
+	var block string
+	if err := readParams(params, 1, &addressParam, &block); err != nil {
+		return nil, err
+	}
+	address, err := address(addressParam)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.checkLatest(block); err != nil {
+		return nil, err
+	}
+	return service.pipe.Accounts().Account(address)
+}
+
+// Calls a contract, or runs the init code given as data when there is no to
+// address, against the state at a block, with any overrides given applied to
+// it, without committing anything. Calls cannot transfer value.
+func (service *EthService) Call(params json.RawMessage) (interface{}, error) {
+	call := new(callObject)
+	var block string
+	var overrideSet map[string]*accountOverride
+	if err := readParams(params, 1, call, &block, &overrideSet); err != nil {
+		return nil, err
+	}
+	height, err := service.blockHeight(block)
+	if err != nil {
+		return nil, err
+	}
+	if height == service.pipe.Blockchain().Height() {
+		height = 0
+	} else if height == 0 {
+		return nil, invalidParams("There is no state before the first block")
+	}
+	if call.Value != nil && call.Value.Int().Sign() != 0 {
+		return nil, invalidParams("Calls cannot transfer value")
+	}
+	overrides, err := accountOverrides(overrideSet)
+	if err != nil {
+		return nil, err
+	}
+	input := call.Data
+	if len(input) == 0 {
+		input = call.Input
+	}
+	var to []byte
+	if len(call.To) != 0 {
+		if to, err = address(call.To); err != nil {
+			return nil, err
+		}
+	}
+	var result *core_types.Call
+	switch {
+	case height != 0 || len(overrides) != 0:
+		result, err = service.pipe.Transactor().CallWithOverrides(call.From, to,
+			input, nil, height, overrides, false)
+	case len(to) == 0:
+		result, err = service.pipe.Transactor().CallCode(call.From, input, nil, false)
+	default:
+		result, err = service.pipe.Transactor().Call(call.From, to, input, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret, err := hex.DecodeString(result.Return)
+	if err != nil {
+		return nil, err
+	}
+	return hexData(ret), nil
+}
+
+// Broadcasts a signed Ethereum tx in its RLP encoding or a signed burrow tx
+// in its binary encoding, returning its hash
+func (service *EthService) SendRawTransaction(params json.RawMessage) (interface{}, error) {
+	var txBytes data
+	if err := readParams(params, 1, &txBytes); err != nil {
+		return nil, err
+	}
+	tx, err := txs.DecodeTx(txBytes)
+	if err != nil {
+		return nil, invalidParams("Could not decode tx: %v", err)
+	}
+	receipt, err := service.pipe.Transactor().BroadcastTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	return hexHash(receipt.TxHash), nil
+}
+
+// Gets the receipt of a committed CallTx or EthTx, or null for any other tx as
+// Ethereum clients expect of txs that are not yet committed
+func (service *EthService) GetTransactionReceipt(params json.RawMessage) (interface{}, error) {
+	var hashParam data
+	if err := readParams(params, 1, &hashParam); err != nil {
+		return nil, err
+	}
+	txHash, err := burrowHash(hashParam)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := service.pipe.Receipts().TxReceipt(txHash, "")
+	if err != nil {
+		return nil, nil
+	}
+	block := service.pipe.Blockchain().Block(receipt.Height)
+	if block == nil {
+		return nil, fmt.Errorf("Could not load block %v of tx %X", receipt.Height,
+			txHash)
+	}
+	blockReceipts, err := service.blockReceipts(block)
+	if err != nil {
+		return nil, err
+	}
+	for _, br := range blockReceipts {
+		if bytes.Equal(br.receipt.TxHash, txHash) {
+			return br.eth, nil
+		}
+	}
+	return nil, fmt.Errorf("Tx %X is not in block %v", txHash, receipt.Height)
+}
+
+// Gets the logs matching a filter. The filter must give an address or a
+// topic for the log index to look up, and cannot give a block hash.
+func (service *EthService) GetLogs(params json.RawMessage) (interface{}, error) {
+	filter := new(logFilter)
+	if err := readParams(params, 1, filter); err != nil {
+		return nil, err
+	}
+	if len(filter.BlockHash) > 0 {
+		return nil, invalidParams("Logs cannot be looked up by block hash, " +
+			"give fromBlock and toBlock instead")
+	}
+	addresses, err := readDataList(filter.Address, 20)
+	if err != nil {
+		return nil, err
+	}
+	topics := make([][]data, len(filter.Topics))
+	// The options of the first position with any are looked up in the index
+	var lookupTopics []data
+	for i, topic := range filter.Topics {
+		if topics[i], err = readDataList(topic, 32); err != nil {
+			return nil, err
+		}
+		if len(lookupTopics) == 0 {
+			lookupTopics = topics[i]
+		}
+	}
+	if len(addresses) == 0 && len(lookupTopics) == 0 {
+		return nil, invalidParams("The filter must give an address or a topic")
+	}
+	fromHeight, err := service.blockHeight(filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	toHeight, err := service.blockHeight(filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	logs := []*ethLog{}
+	if toHeight < 1 || fromHeight > toHeight {
+		return logs, nil
+	}
+	// The log index finds the heights with matching logs, which are read from
+	// the receipts of their blocks so they come with their txs
+	lookupAddresses := addresses
+	if len(lookupAddresses) == 0 {
+		lookupAddresses = []data{nil}
+	}
+	if len(lookupTopics) == 0 {
+		lookupTopics = []data{nil}
+	}
+	heightSet := make(map[int]bool)
+	for _, address := range lookupAddresses {
+		for _, topic := range lookupTopics {
+			indexed, err := service.pipe.Logs().Logs(address, topic,
+				int64(fromHeight), int64(toHeight))
+			if err != nil {
+				return nil, err
+			}
+			for _, log := range indexed {
+				heightSet[int(log.Height)] = true
+			}
+		}
+	}
+	heights := make([]int, 0, len(heightSet))
+	for height := range heightSet {
+		heights = append(heights, height)
+	}
+	sort.Ints(heights)
+	for _, height := range heights {
+		block := service.pipe.Blockchain().Block(height)
+		if block == nil {
+			return nil, fmt.Errorf("Could not load block %v", height)
+		}
+		blockReceipts, err := service.blockReceipts(block)
+		if err != nil {
+			return nil, err
+		}
+		for _, br := range blockReceipts {
+			for i, log := range br.receipt.Logs {
+				if matchLog(log, addresses, topics) {
+					logs = append(logs, br.eth.Logs[i])
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// The receipts of the CallTxs and EthTxs of a block, in order, numbering their logs
+// across the block
+func (service *EthService) blockReceipts(block *tm_types.Block) ([]*blockReceipt, error) {
+	chainID := service.pipe.Blockchain().ChainId()
+	height := block.Header.Height
+	blockHash := hexHash(block.Hash())
+	var blockReceipts []*blockReceipt
+	var cumulativeGasUsed int64
+	logIndex := 0
+	for i, txBytes := range block.Data.Txs {
+		tx, err := txs.DecodeTx(txBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode tx %v in block %v: %v", i,
+				height, err)
+		}
+		var callTx *txs.CallTx
+		switch tx := tx.(type) {
+		case *txs.CallTx:
+			callTx = tx
+		case *txs.EthTx:
+			if callTx, err = tx.CallTx(chainID); err != nil {
+				// The tx failed to execute
+				continue
+			}
+		case *txs.MultisigTx:
+			var ok bool
+			if callTx, ok = tx.Tx.(*txs.CallTx); !ok {
+				continue
+			}
+		case *txs.PriorityFeeTx, *txs.ExpiringTx:
+			var ok bool
+			if callTx, ok = txs.InnerTx(tx).(*txs.CallTx); !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		receipt, err := service.pipe.Receipts().TxReceipt(txs.TxHash(chainID, tx), "")
+		if err != nil {
+			// The tx was not executed
+			continue
+		}
+		cumulativeGasUsed += receipt.GasUsed
+		eth := &ethReceipt{
+			TransactionHash:   hexHash(receipt.TxHash),
+			TransactionIndex:  hexQuantity(uint64(i)),
+			BlockHash:         blockHash,
+			BlockNumber:       hexQuantity(uint64(height)),
+			From:              hexData(callTx.Input.Address),
+			CumulativeGasUsed: hexQuantity(uint64(cumulativeGasUsed)),
+			GasUsed:           hexQuantity(uint64(receipt.GasUsed)),
+			Logs:              []*ethLog{},
+			Status:            "0x0",
+		}
+		if len(callTx.Address) > 0 {
+			to := hexData(callTx.Address)
+			eth.To = &to
+		}
+		if len(receipt.ContractAddress) > 0 {
+			contractAddress := hexData(receipt.ContractAddress)
+			eth.ContractAddress = &contractAddress
+		}
+		if receipt.Success {
+			eth.Status = "0x1"
+		}
+		var addresses, topics []Word256
+		for _, log := range receipt.Logs {
+			ethTopics := make([]string, len(log.Topics))
+			for j, topic := range log.Topics {
+				ethTopics[j] = hexData(topic.Bytes())
+			}
+			eth.Logs = append(eth.Logs, &ethLog{
+				Address:          hexData(log.Address.Postfix(20)),
+				Topics:           ethTopics,
+				Data:             hexData(log.Data),
+				BlockNumber:      eth.BlockNumber,
+				BlockHash:        blockHash,
+				TransactionHash:  eth.TransactionHash,
+				TransactionIndex: eth.TransactionIndex,
+				LogIndex:         hexQuantity(uint64(logIndex)),
+			})
+			logIndex++
+			addresses = append(addresses, log.Address)
+			topics = append(topics, log.Topics...)
+		}
+		eth.LogsBloom = hexData(bloom(addresses, topics))
+		blockReceipts = append(blockReceipts, &blockReceipt{receipt, eth})
+	}
+	return blockReceipts, nil
+}
+
+// Whether a log was emitted by one of addresses, if any are given, and has
+// one of the topics given for each position
+func matchLog(log *core_types.ReceiptLog, addresses []data, topics [][]data) bool {
+	if len(addresses) > 0 && !containsWord(addresses, log.Address) {
+		return false
+	}
+	for i, options := range topics {
+		if len(options) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) || !containsWord(options, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsWord(ds []data, word Word256) bool {
+	for _, d := range ds {
+		if LeftPadWord256(d) == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Burrow only serves the latest state, so a block param of a state query must
+// be a tag meaning the latest block or be the latest height
+func (service *EthService) checkLatest(block string) error {
+	height, err := service.blockHeight(block)
+	if err != nil {
+		return err
+	}
+	if latest := service.pipe.Blockchain().Height(); height != latest {
+		return invalidParams("Only the state at the latest block, %v, can be "+
+			"queried", latest)
+	}
+	return nil
+}
+
+// Converts a state override set to the overrides of a call, in order of
+// address
+func accountOverrides(overrideSet map[string]*accountOverride) ([]*core_types.AccountOverride, error) {
+	addresses := make([]string, 0, len(overrideSet))
+	for addressHex := range overrideSet {
+		addresses = append(addresses, addressHex)
+	}
+	sort.Strings(addresses)
+	overrides := make([]*core_types.AccountOverride, 0, len(overrideSet))
+	for _, addressHex := range addresses {
+		var addressData data
+		if err := json.Unmarshal([]byte(fmt.Sprintf("%q", addressHex)), &addressData); err != nil {
+			return nil, invalidParams("State override of %s: %v", addressHex, err)
+		}
+		overrideAddress, err := address(addressData)
+		if err != nil {
+			return nil, err
+		}
+		accOverride := overrideSet[addressHex]
+		if accOverride == nil {
+			continue
+		}
+		if len(accOverride.State) != 0 {
+			return nil, invalidParams("State override of %s cannot replace "+
+				"state, set slots with stateDiff instead", addressHex)
+		}
+		override := &core_types.AccountOverride{
+			Address: overrideAddress,
+			Code:    accOverride.Code,
+		}
+		if accOverride.Balance != nil {
+			balance := accOverride.Balance.Int()
+			if balance.BitLen() > 63 {
+				return nil, invalidParams("Balance override of %s is too large",
+					addressHex)
+			}
+			override.Balance = new(int64)
+			*override.Balance = balance.Int64()
+		}
+		if accOverride.Nonce != nil {
+			// The nonce of the next tx is the sequence number of the last
+			nonce := accOverride.Nonce.Int()
+			if nonce.BitLen() > 31 {
+				return nil, invalidParams("Nonce override of %s is too large",
+					addressHex)
+			}
+			override.Sequence = new(int)
+			*override.Sequence = int(nonce.Int64())
+		}
+		slots := make([]string, 0, len(accOverride.StateDiff))
+		for slot := range accOverride.StateDiff {
+			slots = append(slots, slot)
+		}
+		sort.Strings(slots)
+		for _, slot := range slots {
+			var key data
+			if err := json.Unmarshal([]byte(fmt.Sprintf("%q", slot)), &key); err != nil {
+				return nil, invalidParams("Storage slot %s of %s: %v", slot,
+					addressHex, err)
+			}
+			value := accOverride.StateDiff[slot]
+			if len(key) > 32 || len(value) > 32 {
+				return nil, invalidParams("Storage slot %s of %s is longer "+
+					"than 32 bytes", slot, addressHex)
+			}
+			override.Storage = append(override.Storage,
+				&core_types.StorageItem{Key: key, Value: value})
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+// The height that a block param refers to, by default the latest
+func (service *EthService) blockHeight(block string) (int, error) {
+	switch block {
+	case "", "latest", "pending":
+		return service.pipe.Blockchain().Height(), nil
+	case "earliest":
+		return 0, nil
+	}
+	height, err := parseQuantity(block)
+	if err != nil || height.BitLen() > 62 {
+		return 0, invalidParams("Block %s is not a block number or tag", block)
+	}
+	return int(height.Int64()), nil
+}
+
+// Unmarshals the positional params of a request into args. Params beyond the
+// required ones may be omitted, leaving their args untouched.
+func readParams(params json.RawMessage, required int, args ...interface{}) error {
+	var positional []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return invalidParams("Params must be an array: %v", err)
+		}
+	}
+	if len(positional) < required || len(positional) > len(args) {
+		return invalidParams("Expected %v to %v params but got %v", required,
+			len(args), len(positional))
+	}
+	for i, param := range positional {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return invalidParams("Param %v: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Reads a filter field holding null, a byte string, or a list of byte
+// strings, each of length bytes
+func readDataList(raw json.RawMessage, length int) ([]data, error) {
+	var list []data
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+	} else {
+		var d data
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+		list = []data{d}
+	}
+	for _, d := range list {
+		if len(d) != length {
+			return nil, invalidParams("%s must be %v bytes", hexData(d), length)
+		}
+	}
+	return list, nil
+}
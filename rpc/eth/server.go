@@ -0,0 +1,237 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/rpc"
+	"github.com/hyperledger/burrow/server"
+	"github.com/hyperledger/burrow/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server used to handle Ethereum JSON-RPC requests so that web3 clients and
+// wallets can talk to burrow. Implements server.Server
+type EthJsonRpcServer struct {
+	service server.HttpService
+	running bool
+}
+
+// Create a new EthJsonRpcServer
+func NewEthJsonRpcServer(service server.HttpService) *EthJsonRpcServer {
+	return &EthJsonRpcServer{service: service}
+}
+
+// Start adds the eth rpc path to the router, unless it is not configured
+func (this *EthJsonRpcServer) Start(config *server.ServerConfig,
+	router *gin.Engine) {
+	if config.HTTP.EthJsonRpcEndpoint != "" {
+		router.POST(config.HTTP.EthJsonRpcEndpoint, this.handleFunc)
+	}
+	this.running = true
+}
+
+// Is the server currently running?
+func (this *EthJsonRpcServer) Running() bool {
+	return this.running
+}
+
+// Shut the server down. Does nothing.
+func (this *EthJsonRpcServer) ShutDown() {
+	this.running = false
+}
+
+func (this *EthJsonRpcServer) handleFunc(c *gin.Context) {
+	this.service.Process(c.Request, c.Writer)
+}
+
+// Unlike burrow's own JSON-RPC the ids of Ethereum clients are usually
+// numbers, and are echoed back exactly as they were sent
+type ethRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      json.RawMessage `json:"id"`
+}
+
+type ethResultResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+type ethErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Error   *rpc.RPCError   `json:"error"`
+}
+
+// Returned by methods given bad params
+type paramsError struct {
+	message string
+}
+
+func (pe *paramsError) Error() string {
+	return pe.message
+}
+
+func invalidParams(format string, args ...interface{}) error {
+	return &paramsError{fmt.Sprintf(format, args...)}
+}
+
+type ethMethod func(params json.RawMessage) (interface{}, error)
+
+// The Ethereum JSON-RPC service, implementing the eth_ methods that web3
+// clients need on top of burrow's transactions and state. Implements
+// server.HttpService
+type EthService struct {
+	pipe    definitions.Pipe
+	methods map[string]ethMethod
+}
+
+func NewEthService(pipe definitions.Pipe) *EthService {
+	service := &EthService{pipe: pipe}
+	service.methods = map[string]ethMethod{
+		"web3_clientVersion":        service.ClientVersion,
+		"net_version":               service.NetVersion,
+		"eth_chainId":               service.ChainId,
+		"eth_blockNumber":           service.BlockNumber,
+		"eth_getBalance":            service.GetBalance,
+		"eth_getCode":               service.GetCode,
+		"eth_getTransactionCount":   service.GetTransactionCount,
+		"eth_call":                  service.Call,
+		"eth_sendRawTransaction":    service.SendRawTransaction,
+		"eth_getTransactionReceipt": service.GetTransactionReceipt,
+		"eth_getLogs":               service.GetLogs,
+	}
+	return service
+}
+
+// Process a request, or a batch of requests
+func (service *EthService) Process(r *http.Request, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(errorResponse(nil, rpc.PARSE_ERROR, "Failed to read request: "+
+			err.Error()), w)
+		return
+	}
+	parent, _ := tracing.ParseTraceParent(r.Header.Get("traceparent"))
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []*ethRequest
+		if err := json.Unmarshal(body, &requests); err != nil {
+			writeJSON(errorResponse(nil, rpc.PARSE_ERROR, "Failed to parse request: "+
+				err.Error()), w)
+			return
+		}
+		if len(requests) == 0 {
+			writeJSON(errorResponse(nil, rpc.INVALID_REQUEST, "Empty batch"), w)
+			return
+		}
+		responses := make([]interface{}, len(requests))
+		for i, request := range requests {
+			responses[i] = service.handle(r.Context(), request, parent)
+		}
+		writeJSON(responses, w)
+		return
+	}
+	request := new(ethRequest)
+	if err := json.Unmarshal(body, request); err != nil {
+		writeJSON(errorResponse(nil, rpc.PARSE_ERROR, "Failed to parse request: "+
+			err.Error()), w)
+		return
+	}
+	writeJSON(service.handle(r.Context(), request, parent), w)
+}
+
+func (service *EthService) handle(ctx context.Context, request *ethRequest,
+	parent tracing.SpanContext) interface{} {
+	if request.JSONRPC != "2.0" {
+		return errorResponse(request.Id, rpc.INVALID_REQUEST,
+			"Wrong protocol version: "+request.JSONRPC)
+	}
+	method, ok := service.methods[request.Method]
+	if !ok {
+		return errorResponse(request.Id, rpc.METHOD_NOT_FOUND,
+			"Method not found: "+request.Method)
+	}
+	if err := server.Authorize(ctx, request.Method); err != nil {
+		return errorResponse(request.Id, rpc.UNAUTHORIZED, err.Error())
+	}
+	span := tracing.DefaultTracer.Start(request.Method, parent)
+	defer span.Finish()
+	start := time.Now()
+	result, err := method(request.Params)
+	rpc.RequestSeconds.ObserveSince(start, "eth", request.Method)
+	if err != nil {
+		span.SetTag("error", err.Error())
+		rpc.RequestErrors.Inc("eth", request.Method)
+		code := rpc.INTERNAL_ERROR
+		if _, ok := err.(*paramsError); ok {
+			code = rpc.INVALID_PARAMS
+		}
+		return errorResponse(request.Id, code, err.Error())
+	}
+	if request.Method == "eth_sendRawTransaction" && span != nil {
+		traceSentTx(result, span)
+	}
+	return &ethResultResponse{JSONRPC: "2.0", Id: request.Id, Result: result}
+}
+
+// Continues the trace of span with the tx whose hash was returned by
+// eth_sendRawTransaction, so that its execution is traced
+func traceSentTx(result interface{}, span *tracing.Span) {
+	hashHex, ok := result.(string)
+	if !ok {
+		return
+	}
+	var hash data
+	if err := hash.UnmarshalJSON([]byte(fmt.Sprintf("%q", hashHex))); err != nil {
+		return
+	}
+	if txHash, err := burrowHash(hash); err == nil {
+		tracing.DefaultTracer.TraceTx(txHash, span.Context())
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *ethErrorResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &ethErrorResponse{
+		JSONRPC: "2.0",
+		Id:      id,
+		Error:   &rpc.RPCError{Code: code, Message: message},
+	}
+}
+
+func writeJSON(o interface{}, w http.ResponseWriter) {
+	bs, err := json.Marshal(o)
+	if err != nil {
+		http.Error(w, "Failed to marshal response: "+err.Error(), 500)
+		return
+	}
+	w.Write(bs)
+}
@@ -0,0 +1,292 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/event"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// A pipe serving the little the GraphQL service needs from memory. Anything
+// else panics on the nil embedded interfaces.
+type testPipe struct {
+	definitions.Pipe
+	chain    *testChain
+	accounts *testAccounts
+	logs     *testLogs
+	receipts *testReceipts
+}
+
+type testChain struct {
+	blockchain_types.Blockchain
+	blocks []*tm_types.Block
+}
+
+// Interfaces to embed without their names clashing with their methods
+type (
+	accountsInterface interface {
+		definitions.Accounts
+	}
+	logsInterface interface {
+		definitions.Logs
+	}
+)
+
+type testAccounts struct {
+	accountsInterface
+	accounts []*acm.Account
+}
+
+type testLogs struct {
+	logsInterface
+	logs []txs.EventDataLog
+}
+
+type testReceipts struct {
+	definitions.Receipts
+	receipts map[string]*core_types.TxReceipt
+}
+
+func (tp *testPipe) Blockchain() blockchain_types.Blockchain { return tp.chain }
+func (tp *testPipe) Accounts() definitions.Accounts          { return tp.accounts }
+func (tp *testPipe) Logs() definitions.Logs                  { return tp.logs }
+func (tp *testPipe) Receipts() definitions.Receipts          { return tp.receipts }
+
+func (tc *testChain) ChainId() string { return "1234" }
+func (tc *testChain) Height() int     { return len(tc.blocks) }
+
+func (tc *testChain) Block(height int) *tm_types.Block {
+	if height < 1 || height > len(tc.blocks) {
+		return nil
+	}
+	return tc.blocks[height-1]
+}
+
+func (ta *testAccounts) Account(address []byte) (*acm.Account, error) {
+	for _, account := range ta.accounts {
+		if string(account.Address) == string(address) {
+			return account, nil
+		}
+	}
+	return &acm.Account{Address: address}, nil
+}
+
+// Ignores the filters
+func (ta *testAccounts) Accounts(filters []*event.FilterData) (*core_types.AccountList, error) {
+	return &core_types.AccountList{Accounts: ta.accounts}, nil
+}
+
+func (ta *testAccounts) ABI(address []byte) (*core_types.ABIEntry, error) {
+	if string(address) == string(contract) {
+		return &core_types.ABIEntry{ABI: "[]"}, nil
+	}
+	return nil, fmt.Errorf("No ABI")
+}
+
+func (tl *testLogs) Logs(address, topic []byte, minHeight,
+	maxHeight int64) ([]txs.EventDataLog, error) {
+	var logs []txs.EventDataLog
+	for _, log := range tl.logs {
+		if log.Height < minHeight || (maxHeight != 0 && log.Height > maxHeight) ||
+			(address != nil && log.Address != LeftPadWord256(address)) {
+			continue
+		}
+		for _, t := range log.Topics {
+			if topic == nil || t == LeftPadWord256(topic) {
+				logs = append(logs, log)
+				break
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (tr *testReceipts) TxReceipt(txHash []byte, abiJSON string) (*core_types.TxReceipt, error) {
+	receipt, ok := tr.receipts[string(txHash)]
+	if !ok {
+		return nil, fmt.Errorf("No receipt")
+	}
+	if abiJSON != "" {
+		// Pretend to decode against the ABI
+		decoded := *receipt
+		decoded.Logs = nil
+		for _, log := range receipt.Logs {
+			decodedLog := *log
+			decodedLog.Event = &core_types.DecodedEvent{Name: abiJSON}
+			decoded.Logs = append(decoded.Logs, &decodedLog)
+		}
+		return &decoded, nil
+	}
+	return receipt, nil
+}
+
+var (
+	sender   = []byte("sender______________")
+	contract = []byte("contract____________")
+	topicA   = LeftPadWord256([]byte("A"))
+	topicB   = LeftPadWord256([]byte("B"))
+)
+
+// A chain of two blocks, the second holding a SendTx and a CallTx emitting
+// two logs
+func newTestPipe(t *testing.T) (*testPipe, []byte) {
+	sendTx := txs.NewSendTx()
+	sendTx.AddOutput(contract, 1)
+	sendTxBytes, err := txs.EncodeTx(sendTx)
+	require.NoError(t, err)
+	callTx := &txs.CallTx{
+		Input:    &txs.TxInput{Address: sender, Amount: 1, Sequence: 1},
+		Address:  contract,
+		GasLimit: 100,
+		Data:     []byte{0xAB},
+	}
+	txBytes, err := txs.EncodeTx(callTx)
+	require.NoError(t, err)
+	txHash := txs.TxHash("1234", callTx)
+	logs := []*core_types.ReceiptLog{
+		{
+			Address: LeftPadWord256(contract),
+			Topics:  []Word256{topicA},
+			Data:    []byte{1},
+			Event: &core_types.DecodedEvent{Name: "Ping", Args: []*core_types.DecodedArg{
+				{Name: "n", Type: "uint256", Value: "1"},
+			}},
+		},
+		{Address: LeftPadWord256(contract), Topics: []Word256{topicB, topicA}},
+	}
+	return &testPipe{
+		chain: &testChain{
+			blocks: []*tm_types.Block{
+				{Header: &tm_types.Header{Height: 1}, Data: &tm_types.Data{}},
+				{
+					Header: &tm_types.Header{Height: 2, NumTxs: 2},
+					Data:   &tm_types.Data{Txs: []tm_types.Tx{sendTxBytes, txBytes}},
+				},
+			},
+		},
+		accounts: &testAccounts{accounts: []*acm.Account{
+			{Address: contract, Code: []byte{0x60}},
+			{Address: sender, Balance: 255, Sequence: 1},
+		}},
+		logs: &testLogs{logs: []txs.EventDataLog{
+			{Address: logs[0].Address, Topics: logs[0].Topics, Height: 2},
+			{Address: logs[1].Address, Topics: logs[1].Topics, Height: 2},
+		}},
+		receipts: &testReceipts{receipts: map[string]*core_types.TxReceipt{
+			string(txHash): {
+				TxHash:  txHash,
+				Height:  2,
+				Success: true,
+				GasUsed: 21,
+				Logs:    logs,
+			},
+		}},
+	}, txHash
+}
+
+func execute(t *testing.T, q string) string {
+	pipe, _ := newTestPipe(t)
+	bs, err := json.Marshal(NewGraphQLService(pipe).Execute(q, "", nil))
+	require.NoError(t, err)
+	return string(bs)
+}
+
+func hexOf(bs []byte) string {
+	return strings.ToUpper(hex.EncodeToString(bs))
+}
+
+func TestBlocks(t *testing.T) {
+	assert.Equal(t, `{"data":{"chainId":"1234","height":2,"blocks":[{"height":2},{"height":1}]}}`,
+		execute(t, `{ chainId height blocks { height } }`))
+	assert.Equal(t, `{"data":{"blocks":[{"height":1}]}}`,
+		execute(t, `{ blocks(maxHeight: 1, first: 1) { height } }`))
+	assert.Equal(t, `{"data":{"block":{"height":2,"numTxs":2,"transactions":[`+
+		`{"index":0,"type":"SendTx","from":null,"success":null,"events":null},`+
+		`{"index":1,"type":"CallTx","from":"`+hexOf(sender)+`","success":true,"events":[{"name":"Ping"},{"name":null}]}]}}}`,
+		execute(t, `{ block { height numTxs transactions { index type from success events { name } } } }`))
+	assert.Equal(t, `{"data":{"block":null}}`, execute(t, `{ block(height: 3) { height } }`))
+}
+
+func TestAccounts(t *testing.T) {
+	assert.Equal(t, `{"data":{"account":{"balance":255,"sequence":1,"isContract":false,"abi":null}}}`,
+		execute(t, `{ account(address: "`+hexOf(sender)+`") { balance sequence isContract abi } }`))
+	// In order of address, paged by address
+	assert.Equal(t, `{"data":{"accounts":[{"address":"`+hexOf(contract)+`","abi":"[]"}]}}`,
+		execute(t, `{ accounts(first: 1) { address abi } }`))
+	assert.Equal(t, `{"data":{"accounts":[{"address":"`+hexOf(sender)+`"}]}}`,
+		execute(t, `{ accounts(first: 1, after: "0x`+hexOf(contract)+`") { address } }`))
+
+	assert.Equal(t, `{"data":{"account":null},"errors":[{"message":"address must be 20 bytes but is 1","path":["account"]}]}`,
+		execute(t, `{ account(address: "01") { balance } }`))
+}
+
+func TestTransactionAndEvents(t *testing.T) {
+	_, txHash := newTestPipe(t)
+	assert.Equal(t, `{"data":{"transaction":{"height":2,"index":1,"to":"`+hexOf(contract)+
+		`","data":"AB","gasUsed":21,"exception":null,"events":[{"name":"Ping","args":[{"name":"n","value":"1"}]},{"name":null,"args":null}]}}}`,
+		execute(t, `{ transaction(hash: "`+hexOf(txHash)+`") { height index to data gasUsed exception events { name args { name value } } } }`))
+	assert.Equal(t, `{"data":{"transaction":null}}`,
+		execute(t, `{ transaction(hash: "`+hexOf(sender)+`") { height } }`))
+
+	// Paged by cursor
+	assert.Equal(t, `{"data":{"events":[{"cursor":"2/1/0","txHash":"`+hexOf(txHash)+`","logIndex":0,"data":"01"}]}}`,
+		execute(t, `{ events(address: "`+hexOf(contract)+`", first: 1) { cursor txHash logIndex data } }`))
+	assert.Equal(t, `{"data":{"events":[{"cursor":"2/1/1","topics":["`+hexOf(topicB.Bytes())+`","`+hexOf(topicA.Bytes())+`"]}]}}`,
+		execute(t, `{ events(topic: "`+hexOf(topicA.Bytes())+`", after: "2/1/0") { cursor topics } }`))
+	assert.Equal(t, `{"data":{"events":[{"name":"abi"}]}}`,
+		execute(t, `{ events(topic: "`+hexOf(topicB.Bytes())+`", abi: "abi") { name } }`))
+	assert.Equal(t, `{"data":{"events":[]}}`,
+		execute(t, `{ events(address: "`+hexOf(contract)+`", maxHeight: 1) { name } }`))
+
+	assert.Equal(t, `{"data":{"events":null},"errors":[{"message":"events needs an address or a topic","path":["events"]}]}`,
+		execute(t, `{ events { name } }`))
+}
+
+func TestProcess(t *testing.T) {
+	pipe, _ := newTestPipe(t)
+	service := NewGraphQLService(pipe)
+
+	w := httptest.NewRecorder()
+	service.Process(httptest.NewRequest("POST", "/graphql", strings.NewReader(
+		`{"query":"query H($h: Int) { block(height: $h) { height } }","variables":{"h":1}}`)), w)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"data":{"block":{"height":1}}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	service.Process(httptest.NewRequest("GET", "/graphql?query="+
+		url.QueryEscape("{ height }"), nil), w)
+	assert.Equal(t, `{"data":{"height":2}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	service.Process(httptest.NewRequest("POST", "/graphql", strings.NewReader(`{}`)), w)
+	assert.Equal(t, 400, w.Code)
+}
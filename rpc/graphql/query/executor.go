@@ -0,0 +1,371 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// The response to a query. Data is null when the query could not be
+// executed, and otherwise the fields whose resolvers failed are null with an
+// error giving their path.
+type Result struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// An error in the query rather than in resolving it, which stops execution
+type queryError struct {
+	message string
+}
+
+func (qe *queryError) Error() string {
+	return qe.message
+}
+
+func newQueryError(format string, args ...interface{}) error {
+	return &queryError{fmt.Sprintf(format, args...)}
+}
+
+// The fields of an object in the order they were selected
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (om *orderedMap) set(key string, value interface{}) {
+	if _, ok := om.values[key]; !ok {
+		om.keys = append(om.keys, key)
+	}
+	om.values[key] = value
+}
+
+func (om *orderedMap) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, key := range om.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		bs, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bs)
+		buf.WriteByte(':')
+		if bs, err = json.Marshal(om.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Write(bs)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// Executes the operation of query named operationName, which may be empty
+// when the query has only one operation, with variables decoded from JSON.
+// Only queries are supported, and of introspection only __typename.
+func (schema *Schema) Execute(query, operationName string,
+	variables map[string]interface{}) *Result {
+	doc, err := parse(query)
+	if err != nil {
+		return errorResult(err)
+	}
+	op, err := doc.operation(operationName)
+	if err != nil {
+		return errorResult(err)
+	}
+	if op.kind != "query" {
+		return errorResult(fmt.Errorf("Only queries are supported not %ss", op.kind))
+	}
+	ex := &executor{doc: doc, variables: make(map[string]interface{})}
+	for _, definition := range op.variables {
+		if value, ok := variables[definition.name]; ok {
+			ex.variables[definition.name] = value
+		} else if definition.hasDefault {
+			ex.variables[definition.name] = definition.defaultValue
+		}
+	}
+	data, err := ex.executeSelections(schema.Query, nil, op.selections, nil)
+	if err != nil {
+		return errorResult(err)
+	}
+	return &Result{Data: data, Errors: ex.errors}
+}
+
+func errorResult(err error) *Result {
+	return &Result{Errors: []*Error{{Message: err.Error()}}}
+}
+
+func (doc *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) != 1 {
+			return nil, fmt.Errorf("Query has %v operations so the one to "+
+				"execute must be named", len(doc.operations))
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("Query has no operation named %s", name)
+}
+
+func (ex *executor) executeSelections(object *Object, source interface{},
+	selections []selection, path []interface{}) (*orderedMap, error) {
+	result := &orderedMap{values: make(map[string]interface{})}
+	var keys []string
+	fields := make(map[string][]*field)
+	if err := ex.collectFields(object, selections, make(map[string]bool),
+		&keys, fields); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		f := fields[key][0]
+		if f.name == "__typename" {
+			result.set(key, object.Name)
+			continue
+		}
+		definition, ok := object.Fields[f.name]
+		if !ok {
+			return nil, newQueryError("Cannot query field %s on type %s", f.name,
+				object.Name)
+		}
+		args, err := ex.arguments(definition, f)
+		if err != nil {
+			return nil, err
+		}
+		// Fields selected more than once have all their selections merged
+		var subselections []selection
+		for _, f := range fields[key] {
+			subselections = append(subselections, f.selections...)
+		}
+		fieldPath := append(path[:len(path):len(path)], key)
+		value, err := definition.Resolve(source, args)
+		if err != nil {
+			ex.fieldError(err, fieldPath)
+			result.set(key, nil)
+			continue
+		}
+		completed, err := ex.completeOrNull(definition.Type, value, subselections,
+			fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		result.set(key, completed)
+	}
+	return result, nil
+}
+
+// Collects the fields selected on object by response key, in order
+func (ex *executor) collectFields(object *Object, selections []selection,
+	visited map[string]bool, keys *[]string, fields map[string][]*field) error {
+	for _, s := range selections {
+		include, err := ex.included(s)
+		if err != nil {
+			return err
+		}
+		if !include {
+			continue
+		}
+		switch s := s.(type) {
+		case *field:
+			key := s.responseKey()
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], s)
+		case *fragmentSpread:
+			if visited[s.name] {
+				continue
+			}
+			visited[s.name] = true
+			fragment, ok := ex.doc.fragments[s.name]
+			if !ok {
+				return newQueryError("Unknown fragment %s", s.name)
+			}
+			if fragment.typeCondition != object.Name {
+				continue
+			}
+			if err := ex.collectFields(object, fragment.selections, visited, keys,
+				fields); err != nil {
+				return err
+			}
+		case *inlineFragment:
+			if s.typeCondition != "" && s.typeCondition != object.Name {
+				continue
+			}
+			if err := ex.collectFields(object, s.selections, visited, keys,
+				fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluates the @skip and @include directives of a selection
+func (ex *executor) included(s selection) (bool, error) {
+	for _, d := range s.directives() {
+		if d.name != "skip" && d.name != "include" {
+			return false, newQueryError("Unknown directive @%s", d.name)
+		}
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			return false, newQueryError("Directive @%s takes one argument if", d.name)
+		}
+		condition, ok := ex.substitute(d.arguments[0].value).(bool)
+		if !ok {
+			return false, newQueryError("Argument if of @%s must be a Boolean", d.name)
+		}
+		if condition == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (ex *executor) arguments(definition *Field, f *field) (Args, error) {
+	args := make(Args)
+	for _, arg := range f.arguments {
+		typ, ok := definition.Args[arg.name]
+		if !ok {
+			return nil, newQueryError("Unknown argument %s of field %s", arg.name,
+				f.name)
+		}
+		value, err := coerce(typ, ex.substitute(arg.value))
+		if err != nil {
+			return nil, newQueryError("Argument %s of field %s: %v", arg.name,
+				f.name, err)
+		}
+		if value != nil {
+			args[arg.name] = value
+		}
+	}
+	return args, nil
+}
+
+// Replaces the variables in a value with their values, or null if they were
+// not given
+func (ex *executor) substitute(value interface{}) interface{} {
+	switch value := value.(type) {
+	case variable:
+		return ex.variables[string(value)]
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = ex.substitute(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for name, item := range value {
+			object[name] = ex.substitute(item)
+		}
+		return object
+	}
+	return value
+}
+
+// Completes value as typ, or records the error and returns null if that
+// fails for a reason other than the query
+func (ex *executor) completeOrNull(typ Type, value interface{},
+	selections []selection, path []interface{}) (interface{}, error) {
+	completed, err := ex.complete(typ, value, selections, path)
+	if err != nil {
+		if _, ok := err.(*queryError); ok {
+			return nil, err
+		}
+		ex.fieldError(err, path)
+		return nil, nil
+	}
+	return completed, nil
+}
+
+func (ex *executor) complete(typ Type, value interface{}, selections []selection,
+	path []interface{}) (interface{}, error) {
+	switch typ := typ.(type) {
+	case *Scalar:
+		if len(selections) > 0 {
+			return nil, newQueryError("Field %v of type %v cannot have a selection",
+				path[len(path)-1], typ)
+		}
+		if isNull(value) {
+			return nil, nil
+		}
+		return typ.Serialize(value)
+	case *Object:
+		if len(selections) == 0 {
+			return nil, newQueryError("Field %v of type %v must have a selection",
+				path[len(path)-1], typ)
+		}
+		if isNull(value) {
+			return nil, nil
+		}
+		return ex.executeSelections(typ, value, selections, path)
+	case *List:
+		if isNull(value) {
+			// Still check the selections
+			_, err := ex.complete(typ.Of, nil, selections, path)
+			return nil, err
+		}
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("Expected a list but got %T", value)
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			var err error
+			list[i], err = ex.completeOrNull(typ.Of, rv.Index(i).Interface(),
+				selections, append(path[:len(path):len(path)], i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("Unknown type %v", typ)
+}
+
+func (ex *executor) fieldError(err error, path []interface{}) {
+	ex.errors = append(ex.errors, &Error{Message: err.Error(), Path: path})
+}
+
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
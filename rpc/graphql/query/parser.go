@@ -0,0 +1,582 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parts of a GraphQL document needed to execute queries. Type references
+// in variable definitions are parsed but not kept since the arguments the
+// variables are used for say how to coerce them.
+type (
+	document struct {
+		operations []*operation
+		fragments  map[string]*fragment
+	}
+
+	operation struct {
+		kind       string
+		name       string
+		variables  []*variableDefinition
+		selections []selection
+	}
+
+	variableDefinition struct {
+		name         string
+		defaultValue interface{}
+		hasDefault   bool
+	}
+
+	fragment struct {
+		name          string
+		typeCondition string
+		selections    []selection
+	}
+
+	selection interface {
+		directives() []*directive
+	}
+
+	field struct {
+		alias      string
+		name       string
+		arguments  []*argument
+		directs    []*directive
+		selections []selection
+	}
+
+	fragmentSpread struct {
+		name    string
+		directs []*directive
+	}
+
+	inlineFragment struct {
+		typeCondition string
+		directs       []*directive
+		selections    []selection
+	}
+
+	directive struct {
+		name      string
+		arguments []*argument
+	}
+
+	argument struct {
+		name  string
+		value interface{}
+	}
+
+	// A reference to a variable in a value
+	variable string
+
+	// An enum value, which is coerced to a string
+	enumValue string
+)
+
+func (f *field) directives() []*directive            { return f.directs }
+func (fs *fragmentSpread) directives() []*directive  { return fs.directs }
+func (inf *inlineFragment) directives() []*directive { return inf.directs }
+
+// The name the field is given in the response
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.value)
+}
+
+type parser struct {
+	query string
+	pos   int
+	token token
+}
+
+// A syntax error in a query
+type SyntaxError struct {
+	Message string
+	Pos     int
+}
+
+func (se *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax error at %v: %s", se.Pos, se.Message)
+}
+
+func parse(query string) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if se, ok := r.(*SyntaxError); ok {
+				err = se
+				return
+			}
+			panic(r)
+		}
+	}()
+	p := &parser{query: query}
+	p.next()
+	doc = &document{fragments: make(map[string]*fragment)}
+	if p.token.kind == tokenEOF {
+		p.fail("Query has no operations")
+	}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{
+				kind:       "query",
+				selections: p.parseSelectionSet(),
+			})
+		case p.peek("query") || p.peek("mutation") || p.peek("subscription"):
+			doc.operations = append(doc.operations, p.parseOperation())
+		case p.peek("fragment"):
+			fragment := p.parseFragment()
+			if _, ok := doc.fragments[fragment.name]; ok {
+				p.fail("Fragment %s is defined more than once", fragment.name)
+			}
+			doc.fragments[fragment.name] = fragment
+		default:
+			p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	panic(&SyntaxError{Message: fmt.Sprintf(format, args...), Pos: p.token.pos})
+}
+
+func (p *parser) unexpected() {
+	p.fail("Unexpected %v", p.token)
+}
+
+// Whether the current token is the punctuator or name s
+func (p *parser) peek(s string) bool {
+	return (p.token.kind == tokenPunctuator || p.token.kind == tokenName) &&
+		p.token.value == s
+}
+
+// Consumes the current token if it is the punctuator or name s
+func (p *parser) skip(s string) bool {
+	if p.peek(s) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) {
+	if !p.skip(s) {
+		p.fail("Expected %q but got %v", s, p.token)
+	}
+}
+
+func (p *parser) expectName() string {
+	if p.token.kind != tokenName {
+		p.fail("Expected a name but got %v", p.token)
+	}
+	name := p.token.value
+	p.next()
+	return name
+}
+
+func (p *parser) parseOperation() *operation {
+	op := &operation{kind: p.expectName()}
+	if p.token.kind == tokenName {
+		op.name = p.expectName()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			p.expect("$")
+			definition := &variableDefinition{name: p.expectName()}
+			p.expect(":")
+			p.parseType()
+			if p.skip("=") {
+				definition.defaultValue = p.parseValue(true)
+				definition.hasDefault = true
+			}
+			op.variables = append(op.variables, definition)
+		}
+	}
+	p.parseDirectives()
+	op.selections = p.parseSelectionSet()
+	return op
+}
+
+func (p *parser) parseType() {
+	if p.skip("[") {
+		p.parseType()
+		p.expect("]")
+	} else {
+		p.expectName()
+	}
+	p.skip("!")
+}
+
+func (p *parser) parseFragment() *fragment {
+	p.expect("fragment")
+	f := &fragment{name: p.expectName()}
+	if f.name == "on" {
+		p.fail("Fragments cannot be named on")
+	}
+	p.expect("on")
+	f.typeCondition = p.expectName()
+	p.parseDirectives()
+	f.selections = p.parseSelectionSet()
+	return f
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expect("{")
+	var selections []selection
+	for !p.skip("}") {
+		selections = append(selections, p.parseSelection())
+	}
+	if len(selections) == 0 {
+		p.fail("Selection sets cannot be empty")
+	}
+	return selections
+}
+
+func (p *parser) parseSelection() selection {
+	if p.skip("...") {
+		if p.peek("on") || p.peek("@") || p.peek("{") {
+			inline := &inlineFragment{}
+			if p.skip("on") {
+				inline.typeCondition = p.expectName()
+			}
+			inline.directs = p.parseDirectives()
+			inline.selections = p.parseSelectionSet()
+			return inline
+		}
+		return &fragmentSpread{
+			name:    p.expectName(),
+			directs: p.parseDirectives(),
+		}
+	}
+	f := &field{name: p.expectName()}
+	if p.skip(":") {
+		f.alias = f.name
+		f.name = p.expectName()
+	}
+	f.arguments = p.parseArguments()
+	f.directs = p.parseDirectives()
+	if p.peek("{") {
+		f.selections = p.parseSelectionSet()
+	}
+	return f
+}
+
+func (p *parser) parseArguments() []*argument {
+	var arguments []*argument
+	if p.skip("(") {
+		for !p.skip(")") {
+			arg := &argument{name: p.expectName()}
+			p.expect(":")
+			arg.value = p.parseValue(false)
+			arguments = append(arguments, arg)
+		}
+	}
+	return arguments
+}
+
+func (p *parser) parseDirectives() []*directive {
+	var directives []*directive
+	for p.skip("@") {
+		directives = append(directives, &directive{
+			name:      p.expectName(),
+			arguments: p.parseArguments(),
+		})
+	}
+	return directives
+}
+
+// Parses a literal value, which can refer to variables unless it is constant
+func (p *parser) parseValue(constant bool) interface{} {
+	t := p.token
+	switch {
+	case t.kind == tokenInt:
+		p.next()
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			p.fail("Int %s is out of range", t.value)
+		}
+		return n
+	case t.kind == tokenFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			p.fail("Invalid float %s", t.value)
+		}
+		return f
+	case t.kind == tokenString:
+		p.next()
+		return t.value
+	case t.kind == tokenName:
+		p.next()
+		switch t.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(t.value)
+	case p.skip("$"):
+		if constant {
+			p.fail("Variables cannot be used in constant values")
+		}
+		return variable(p.expectName())
+	case p.skip("["):
+		list := []interface{}{}
+		for !p.skip("]") {
+			list = append(list, p.parseValue(constant))
+		}
+		return list
+	case p.skip("{"):
+		object := make(map[string]interface{})
+		for !p.skip("}") {
+			name := p.expectName()
+			p.expect(":")
+			object[name] = p.parseValue(constant)
+		}
+		return object
+	}
+	p.unexpected()
+	return nil
+}
+
+// Lexes the next token into p.token
+func (p *parser) next() {
+	p.skipIgnored()
+	start := p.pos
+	if p.pos >= len(p.query) {
+		p.token = token{kind: tokenEOF, pos: start}
+		return
+	}
+	c := p.query[p.pos]
+	switch {
+	case strings.HasPrefix(p.query[p.pos:], "..."):
+		p.pos += 3
+		p.token = token{tokenPunctuator, "...", start}
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		p.pos++
+		p.token = token{tokenPunctuator, string(c), start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.query) && (p.query[p.pos] == '_' ||
+			isLetter(p.query[p.pos]) || isDigit(p.query[p.pos])) {
+			p.pos++
+		}
+		p.token = token{tokenName, p.query[start:p.pos], start}
+	case c == '-' || isDigit(c):
+		p.lexNumber()
+	case c == '"':
+		p.lexString()
+	default:
+		p.token = token{tokenPunctuator, string(c), start}
+		p.fail("Unexpected character %q", c)
+	}
+}
+
+// Skips whitespace, commas and comments
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.query) {
+		switch p.query[p.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			p.pos++
+		case '#':
+			for p.pos < len(p.query) && p.query[p.pos] != '\n' &&
+				p.query[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			if strings.HasPrefix(p.query[p.pos:], "\ufeff") {
+				p.pos += len("\ufeff")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (p *parser) lexNumber() {
+	start := p.pos
+	kind := tokenInt
+	if p.query[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		digitsStart := p.pos
+		for p.pos < len(p.query) && isDigit(p.query[p.pos]) {
+			p.pos++
+		}
+		if p.pos == digitsStart {
+			p.token = token{tokenInt, p.query[start:p.pos], start}
+			p.fail("Invalid number %s", p.query[start:p.pos])
+		}
+	}
+	digits()
+	if p.pos < len(p.query) && p.query[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.query) && (p.query[p.pos] == 'e' || p.query[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.query) && (p.query[p.pos] == '+' || p.query[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.token = token{kind, p.query[start:p.pos], start}
+}
+
+func (p *parser) lexString() {
+	start := p.pos
+	if strings.HasPrefix(p.query[p.pos:], `"""`) {
+		p.lexBlockString()
+		return
+	}
+	p.pos++
+	var value []byte
+	for {
+		if p.pos >= len(p.query) || p.query[p.pos] == '\n' || p.query[p.pos] == '\r' {
+			p.token = token{tokenString, "", start}
+			p.fail("Unterminated string")
+		}
+		c := p.query[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			value = append(value, c)
+			p.pos++
+			continue
+		}
+		if p.pos+1 >= len(p.query) {
+			p.fail("Unterminated string")
+		}
+		escape := p.query[p.pos+1]
+		p.pos += 2
+		switch escape {
+		case '"', '\\', '/':
+			value = append(value, escape)
+		case 'b':
+			value = append(value, '\b')
+		case 'f':
+			value = append(value, '\f')
+		case 'n':
+			value = append(value, '\n')
+		case 'r':
+			value = append(value, '\r')
+		case 't':
+			value = append(value, '\t')
+		case 'u':
+			if p.pos+4 > len(p.query) {
+				p.fail("Invalid unicode escape")
+			}
+			r, err := strconv.ParseUint(p.query[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				p.fail("Invalid unicode escape")
+			}
+			p.pos += 4
+			buf := make([]byte, utf8.UTFMax)
+			value = append(value, buf[:utf8.EncodeRune(buf, rune(r))]...)
+		default:
+			p.fail("Invalid escape \\%c", escape)
+		}
+	}
+	p.token = token{tokenString, string(value), start}
+}
+
+// Lexes a """ string, removing the common indentation of its lines and its
+// blank first and last lines
+func (p *parser) lexBlockString() {
+	start := p.pos
+	p.pos += 3
+	end := strings.Index(p.query[p.pos:], `"""`)
+	for end >= 0 && strings.HasSuffix(p.query[p.pos:p.pos+end], `\`) {
+		next := strings.Index(p.query[p.pos+end+3:], `"""`)
+		if next < 0 {
+			end = -1
+			break
+		}
+		end += 3 + next
+	}
+	if end < 0 {
+		p.token = token{tokenString, "", start}
+		p.fail("Unterminated string")
+	}
+	raw := strings.Replace(p.query[p.pos:p.pos+end], `\"""`, `"""`, -1)
+	p.pos += end + 3
+	lines := strings.Split(strings.Replace(raw, "\r\n", "\n", -1), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	p.token = token{tokenString, strings.Join(lines, "\n"), start}
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPerson struct {
+	name    string
+	age     int
+	friends []*testPerson
+}
+
+func testSchema() *Schema {
+	alice := &testPerson{name: "Alice", age: 30}
+	bob := &testPerson{name: "Bob", age: 25}
+	alice.friends = []*testPerson{bob}
+	bob.friends = []*testPerson{alice, nil}
+	people := map[string]*testPerson{"Alice": alice, "Bob": bob}
+
+	person := &Object{Name: "Person"}
+	person.Fields = map[string]*Field{
+		"name": {
+			Type: String,
+			Resolve: func(source interface{}, args Args) (interface{}, error) {
+				return source.(*testPerson).name, nil
+			},
+		},
+		"age": {
+			Type: Int,
+			Resolve: func(source interface{}, args Args) (interface{}, error) {
+				return source.(*testPerson).age, nil
+			},
+		},
+		"friends": {
+			Type: &List{person},
+			Args: map[string]Type{"first": Int},
+			Resolve: func(source interface{}, args Args) (interface{}, error) {
+				friends := source.(*testPerson).friends
+				if first, ok := args.Int("first"); ok && int(first) < len(friends) {
+					friends = friends[:first]
+				}
+				return friends, nil
+			},
+		},
+		"secret": {
+			Type: String,
+			Resolve: func(source interface{}, args Args) (interface{}, error) {
+				return nil, fmt.Errorf("%s will not say", source.(*testPerson).name)
+			},
+		},
+	}
+	return &Schema{Query: &Object{
+		Name: "Query",
+		Fields: map[string]*Field{
+			"person": {
+				Type: person,
+				Args: map[string]Type{"name": String},
+				Resolve: func(source interface{}, args Args) (interface{}, error) {
+					name, _ := args.String("name")
+					return people[name], nil
+				},
+			},
+			"names": {
+				Type: &List{String},
+				Args: map[string]Type{"names": &List{String}},
+				Resolve: func(source interface{}, args Args) (interface{}, error) {
+					names, _ := args.List("names")
+					return names, nil
+				},
+			},
+		},
+	}}
+}
+
+func execute(t *testing.T, query string, variables map[string]interface{}) string {
+	bs, err := json.Marshal(testSchema().Execute(query, "", variables))
+	require.NoError(t, err)
+	return string(bs)
+}
+
+func TestExecute(t *testing.T) {
+	assert.Equal(t, `{"data":{"person":{"name":"Alice","age":30}}}`,
+		execute(t, `{ person(name: "Alice") { name age } }`, nil))
+	// Fields keep the order they are selected in
+	assert.Equal(t, `{"data":{"person":{"age":30,"name":"Alice"}}}`,
+		execute(t, `{ person(name: "Alice") { age name } }`, nil))
+	assert.Equal(t, `{"data":{"a":{"n":"Alice"},"b":{"n":"Bob"}}}`,
+		execute(t, `query { a: person(name: "Alice") { n: name } b: person(name: "Bob") { n: name } }`, nil))
+	assert.Equal(t, `{"data":{"person":null}}`,
+		execute(t, `{ person(name: "Carol") { name } }`, nil))
+	assert.Equal(t, `{"data":{"person":{"__typename":"Person","friends":[{"name":"Alice"},null]}}}`,
+		execute(t, `{ person(name: "Bob") { __typename friends { name } } }`, nil))
+	assert.Equal(t, `{"data":{"person":{"friends":[{"name":"Alice"}]}}}`,
+		execute(t, `{ person(name: "Bob") { friends(first: 1) { name } } }`, nil))
+	// A single value is coerced to a list
+	assert.Equal(t, `{"data":{"names":["a"]}}`, execute(t, `{ names(names: "a") }`, nil))
+	assert.Equal(t, `{"data":{"names":["a","b"]}}`,
+		execute(t, `{ names(names: ["a", "b"]) }`, nil))
+}
+
+func TestVariablesAndFragments(t *testing.T) {
+	query := `
+		# Comments are ignored
+		query Person($name: String!, $withAge: Boolean = false, $first: Int) {
+			person(name: $name) {
+				...details
+				age @include(if: $withAge)
+				friends(first: $first) { ... on Person { name } }
+			}
+		}
+		fragment details on Person { name }`
+	assert.Equal(t, `{"data":{"person":{"name":"Bob","friends":[{"name":"Alice"}]}}}`,
+		execute(t, query, map[string]interface{}{"name": "Bob", "first": float64(1)}))
+	assert.Equal(t, `{"data":{"person":{"name":"Bob","age":25,"friends":[{"name":"Alice"},null]}}}`,
+		execute(t, query, map[string]interface{}{"name": "Bob", "withAge": true}))
+	assert.Equal(t, `{"data":{"person":{"age":30}}}`,
+		execute(t, `{ person(name: """
+			Alice
+		""") { name @skip(if: true) age } }`, nil))
+}
+
+func TestErrors(t *testing.T) {
+	// Resolver errors null their field
+	assert.Equal(t, `{"data":{"person":{"name":"Alice","secret":null}},`+
+		`"errors":[{"message":"Alice will not say","path":["person","secret"]}]}`,
+		execute(t, `{ person(name: "Alice") { name secret } }`, nil))
+	assert.Equal(t, `{"data":{"person":{"friends":[{"secret":null}]}},`+
+		`"errors":[{"message":"Bob will not say","path":["person","friends",0,"secret"]}]}`,
+		execute(t, `{ person(name: "Alice") { friends { secret } } }`, nil))
+
+	// Errors in the query stop it
+	for _, query := range []string{
+		`{ person(name: "Alice") { height } }`,
+		`{ person(name: "Alice") }`,
+		`{ person(name: "Alice") { name { first } } }`,
+		`{ person(nickname: "Al") { name } }`,
+		`{ person(name: 1) { name } }`,
+		`{ person(name: "Alice") { ...missing } }`,
+		`{ person(name: "Alice") { name @defer } }`,
+		`mutation { person(name: "Alice") { name } }`,
+		`query A { names } query B { names }`,
+		`{ person(name: "Alice" { name } }`,
+		`{ }`,
+		`{ names(names: "unterminated) }`,
+	} {
+		result := testSchema().Execute(query, "", nil)
+		assert.Nil(t, result.Data, query)
+		assert.Len(t, result.Errors, 1, query)
+	}
+
+	result := testSchema().Execute(`query A { names } query B { person(name: "Bob") { age } }`, "B", nil)
+	bs, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"person":{"age":25}}}`, string(bs))
+}
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`query Q($a: [Int!]! = [1, 2]) { f(s: "\"é\n", n: -1.5e3, e: ENUM, o: {k: null}) }`)
+	require.NoError(t, err)
+	require.Len(t, doc.operations, 1)
+	op := doc.operations[0]
+	assert.Equal(t, "Q", op.name)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, op.variables[0].defaultValue)
+	args := op.selections[0].(*field).arguments
+	assert.Equal(t, "\"é\n", args[0].value)
+	assert.Equal(t, -1500.0, args[1].value)
+	assert.Equal(t, enumValue("ENUM"), args[2].value)
+	assert.Equal(t, map[string]interface{}{"k": nil}, args[3].value)
+
+	_, err = parse(`query Q($a: Int = $b) { f }`)
+	assert.Error(t, err)
+	_, err = parse(`{ f } fragment x on T { f } fragment x on T { f }`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// An executor of GraphQL queries against schemas of Go resolvers. It supports
+// the query language, with variables, fragments and the @skip and @include
+// directives, but not mutations, subscriptions, interfaces or introspection.
+package query
+
+import (
+	"fmt"
+	"math"
+)
+
+// The output and input types of a schema: *Scalar, *Object and *List
+type Type interface {
+	String() string
+}
+
+// A leaf type, whose values are serialised by Serialize and whose argument
+// values, either literals or JSON variables, are coerced by Coerce
+type Scalar struct {
+	Name      string
+	Serialize func(value interface{}) (interface{}, error)
+	Coerce    func(value interface{}) (interface{}, error)
+}
+
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+type List struct {
+	Of Type
+}
+
+// A field of an object, resolved from the value of the object being
+// resolved as source
+type Field struct {
+	Type Type
+	// The types of the arguments the field takes
+	Args map[string]Type
+	// Returns the value of the field, where a nil interface, pointer or slice
+	// is null
+	Resolve func(source interface{}, args Args) (interface{}, error)
+}
+
+// The arguments given to a field, coerced to the types of Field.Args. Only
+// the arguments that were given and are not null are present.
+type Args map[string]interface{}
+
+type Schema struct {
+	Query *Object
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+func (l *List) String() string {
+	return "[" + l.Of.String() + "]"
+}
+
+func (args Args) Int(name string) (int64, bool) {
+	n, ok := args[name].(int64)
+	return n, ok
+}
+
+func (args Args) String(name string) (string, bool) {
+	s, ok := args[name].(string)
+	return s, ok
+}
+
+func (args Args) Boolean(name string) (bool, bool) {
+	b, ok := args[name].(bool)
+	return b, ok
+}
+
+func (args Args) List(name string) ([]interface{}, bool) {
+	l, ok := args[name].([]interface{})
+	return l, ok
+}
+
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch n := value.(type) {
+			case int:
+				return int64(n), nil
+			case int64:
+				return n, nil
+			case uint64:
+				if n > math.MaxInt64 {
+					return nil, fmt.Errorf("Int %v is too large", n)
+				}
+				return int64(n), nil
+			}
+			return nil, fmt.Errorf("Cannot serialise %T as an Int", value)
+		},
+		Coerce: func(value interface{}) (interface{}, error) {
+			switch n := value.(type) {
+			case int64:
+				return n, nil
+			case float64:
+				// From JSON variables
+				if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+					return int64(n), nil
+				}
+			}
+			return nil, fmt.Errorf("Expected an Int but got %v", value)
+		},
+	}
+
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch s := value.(type) {
+			case string:
+				return s, nil
+			case fmt.Stringer:
+				return s.String(), nil
+			}
+			return nil, fmt.Errorf("Cannot serialise %T as a String", value)
+		},
+		Coerce: func(value interface{}) (interface{}, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("Expected a String but got %v", value)
+		},
+	}
+
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Cannot serialise %T as a Boolean", value)
+		},
+		Coerce: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Expected a Boolean but got %v", value)
+		},
+	}
+)
+
+// Coerces an argument value, with its variables already substituted, to typ
+func coerce(typ Type, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch typ := typ.(type) {
+	case *Scalar:
+		if enum, ok := value.(enumValue); ok {
+			value = string(enum)
+		}
+		return typ.Coerce(value)
+	case *List:
+		list, ok := value.([]interface{})
+		if !ok {
+			// A single value is coerced to a list of it
+			list = []interface{}{value}
+		}
+		coerced := make([]interface{}, len(list))
+		for i, item := range list {
+			var err error
+			if coerced[i], err = coerce(typ.Of, item); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	}
+	return nil, fmt.Errorf("Arguments cannot be of type %v", typ)
+}
@@ -0,0 +1,665 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/event"
+	"github.com/hyperledger/burrow/rpc/graphql/query"
+	"github.com/hyperledger/burrow/txs"
+	. "github.com/hyperledger/burrow/word256"
+
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type (
+	// A tx of a block with the CallTx it executed as, if any, and its receipt
+	// if it has been executed
+	txSource struct {
+		tx      txs.Tx
+		callTx  *txs.CallTx
+		hash    []byte
+		height  int
+		index   int
+		receipt *core_types.TxReceipt
+	}
+
+	eventSource struct {
+		log      *core_types.ReceiptLog
+		height   int
+		txHash   []byte
+		txIndex  int
+		logIndex int
+	}
+)
+
+type resolver func(source interface{}, args query.Args) (interface{}, error)
+
+func (service *GraphQLService) newSchema() *query.Schema {
+	eventArgType := &query.Object{
+		Name: "EventArg",
+		Fields: map[string]*query.Field{
+			"name":    {Type: query.String, Resolve: eventArgField(func(arg *core_types.DecodedArg) interface{} { return arg.Name })},
+			"type":    {Type: query.String, Resolve: eventArgField(func(arg *core_types.DecodedArg) interface{} { return arg.Type })},
+			"indexed": {Type: query.Boolean, Resolve: eventArgField(func(arg *core_types.DecodedArg) interface{} { return arg.Indexed })},
+			"value":   {Type: query.String, Resolve: eventArgField(func(arg *core_types.DecodedArg) interface{} { return arg.Value })},
+		},
+	}
+	eventType := &query.Object{
+		Name: "Event",
+		Fields: map[string]*query.Field{
+			"cursor":   {Type: query.String, Resolve: eventField(func(e *eventSource) interface{} { return e.cursor().String() })},
+			"height":   {Type: query.Int, Resolve: eventField(func(e *eventSource) interface{} { return e.height })},
+			"txHash":   {Type: query.String, Resolve: eventField(func(e *eventSource) interface{} { return hexString(e.txHash) })},
+			"txIndex":  {Type: query.Int, Resolve: eventField(func(e *eventSource) interface{} { return e.txIndex })},
+			"logIndex": {Type: query.Int, Resolve: eventField(func(e *eventSource) interface{} { return e.logIndex })},
+			"address":  {Type: query.String, Resolve: eventField(func(e *eventSource) interface{} { return hexString(e.log.Address.Postfix(20)) })},
+			"topics": {Type: &query.List{query.String}, Resolve: eventField(func(e *eventSource) interface{} {
+				topics := make([]string, len(e.log.Topics))
+				for i, topic := range e.log.Topics {
+					topics[i] = hexString(topic.Bytes())
+				}
+				return topics
+			})},
+			"data": {Type: query.String, Resolve: eventField(func(e *eventSource) interface{} { return hexString(e.log.Data) })},
+			"name": {Type: query.String, Resolve: eventField(func(e *eventSource) interface{} {
+				if e.log.Event == nil {
+					return nil
+				}
+				return e.log.Event.Name
+			})},
+			"args": {Type: &query.List{eventArgType}, Resolve: eventField(func(e *eventSource) interface{} {
+				if e.log.Event == nil {
+					return nil
+				}
+				return e.log.Event.Args
+			})},
+		},
+	}
+	transactionType := &query.Object{
+		Name: "Transaction",
+		Fields: map[string]*query.Field{
+			"hash":   {Type: query.String, Resolve: txField(func(tx *txSource) interface{} { return hexString(tx.hash) })},
+			"height": {Type: query.Int, Resolve: txField(func(tx *txSource) interface{} { return tx.height })},
+			"index":  {Type: query.Int, Resolve: txField(func(tx *txSource) interface{} { return tx.index })},
+			"type": {Type: query.String, Resolve: txField(func(tx *txSource) interface{} {
+				return reflect.TypeOf(tx.tx).Elem().Name()
+			})},
+			"from": {Type: query.String, Resolve: callTxField(func(tx *txSource) interface{} { return hexString(tx.callTx.Input.Address) })},
+			"to": {Type: query.String, Resolve: callTxField(func(tx *txSource) interface{} {
+				if len(tx.callTx.Address) == 0 {
+					return nil
+				}
+				return hexString(tx.callTx.Address)
+			})},
+			"data":     {Type: query.String, Resolve: callTxField(func(tx *txSource) interface{} { return hexString(tx.callTx.Data) })},
+			"gasLimit": {Type: query.Int, Resolve: callTxField(func(tx *txSource) interface{} { return tx.callTx.GasLimit })},
+			"fee":      {Type: query.Int, Resolve: callTxField(func(tx *txSource) interface{} { return tx.callTx.Fee })},
+			"success":  {Type: query.Boolean, Resolve: receiptField(func(r *core_types.TxReceipt) interface{} { return r.Success })},
+			"exception": {Type: query.String, Resolve: receiptField(func(r *core_types.TxReceipt) interface{} {
+				if r.Exception == "" {
+					return nil
+				}
+				return r.Exception
+			})},
+			"gasUsed": {Type: query.Int, Resolve: receiptField(func(r *core_types.TxReceipt) interface{} { return r.GasUsed })},
+			"contractAddress": {Type: query.String, Resolve: receiptField(func(r *core_types.TxReceipt) interface{} {
+				if len(r.ContractAddress) == 0 {
+					return nil
+				}
+				return hexString(r.ContractAddress)
+			})},
+			"events": {
+				Type:    &query.List{eventType},
+				Args:    map[string]query.Type{"abi": query.String},
+				Resolve: service.transactionEvents,
+			},
+		},
+	}
+	blockType := &query.Object{
+		Name: "Block",
+		Fields: map[string]*query.Field{
+			"height":        {Type: query.Int, Resolve: blockField(func(b *tm_types.Block) interface{} { return b.Header.Height })},
+			"hash":          {Type: query.String, Resolve: blockField(func(b *tm_types.Block) interface{} { return hexString(b.Hash()) })},
+			"time":          {Type: query.String, Resolve: blockField(func(b *tm_types.Block) interface{} { return b.Header.Time.Format(time.RFC3339Nano) })},
+			"numTxs":        {Type: query.Int, Resolve: blockField(func(b *tm_types.Block) interface{} { return b.Header.NumTxs })},
+			"appHash":       {Type: query.String, Resolve: blockField(func(b *tm_types.Block) interface{} { return hexString(b.Header.AppHash) })},
+			"lastBlockHash": {Type: query.String, Resolve: blockField(func(b *tm_types.Block) interface{} { return hexString(b.Header.LastBlockID.Hash) })},
+			"transactions": {Type: &query.List{transactionType}, Resolve: func(source interface{}, args query.Args) (interface{}, error) {
+				return service.blockTxs(source.(*tm_types.Block))
+			}},
+		},
+	}
+	accountType := &query.Object{
+		Name: "Account",
+		Fields: map[string]*query.Field{
+			"address":    {Type: query.String, Resolve: accountField(func(acc *acm.Account) interface{} { return hexString(acc.Address) })},
+			"balance":    {Type: query.Int, Resolve: accountField(func(acc *acm.Account) interface{} { return acc.Balance })},
+			"sequence":   {Type: query.Int, Resolve: accountField(func(acc *acm.Account) interface{} { return acc.Sequence })},
+			"code":       {Type: query.String, Resolve: accountField(func(acc *acm.Account) interface{} { return hexString(acc.Code) })},
+			"isContract": {Type: query.Boolean, Resolve: accountField(func(acc *acm.Account) interface{} { return len(acc.Code) > 0 })},
+			"abi": {Type: query.String, Resolve: func(source interface{}, args query.Args) (interface{}, error) {
+				entry, err := service.pipe.Accounts().ABI(source.(*acm.Account).Address)
+				if err != nil || entry.ABI == "" {
+					// Nothing registered
+					return nil, nil
+				}
+				return entry.ABI, nil
+			}},
+			"storage": {
+				Type: query.String,
+				Args: map[string]query.Type{"key": query.String},
+				Resolve: func(source interface{}, args query.Args) (interface{}, error) {
+					key, err := hexArg(args, "key", 0)
+					if err != nil {
+						return nil, err
+					}
+					if key == nil || len(key) > 32 {
+						return nil, fmt.Errorf("storage needs a key of up to 32 bytes")
+					}
+					item, err := service.pipe.Accounts().StorageAt(source.(*acm.Account).Address,
+						LeftPadBytes(key, 32))
+					if err != nil {
+						return nil, err
+					}
+					return hexString(item.Value), nil
+				},
+			},
+		},
+	}
+	return &query.Schema{Query: &query.Object{
+		Name: "Query",
+		Fields: map[string]*query.Field{
+			"chainId": {Type: query.String, Resolve: func(source interface{}, args query.Args) (interface{}, error) {
+				return service.pipe.Blockchain().ChainId(), nil
+			}},
+			"height": {Type: query.Int, Resolve: func(source interface{}, args query.Args) (interface{}, error) {
+				return service.pipe.Blockchain().Height(), nil
+			}},
+			"account": {
+				Type:    accountType,
+				Args:    map[string]query.Type{"address": query.String},
+				Resolve: service.account,
+			},
+			"accounts": {
+				Type: &query.List{accountType},
+				Args: map[string]query.Type{
+					"first":      query.Int,
+					"after":      query.String,
+					"minBalance": query.Int,
+					"maxBalance": query.Int,
+					"isContract": query.Boolean,
+				},
+				Resolve: service.accounts,
+			},
+			"block": {
+				Type:    blockType,
+				Args:    map[string]query.Type{"height": query.Int},
+				Resolve: service.block,
+			},
+			"blocks": {
+				Type: &query.List{blockType},
+				Args: map[string]query.Type{
+					"first":     query.Int,
+					"minHeight": query.Int,
+					"maxHeight": query.Int,
+				},
+				Resolve: service.blocks,
+			},
+			"transaction": {
+				Type:    transactionType,
+				Args:    map[string]query.Type{"hash": query.String},
+				Resolve: service.transaction,
+			},
+			"events": {
+				Type: &query.List{eventType},
+				Args: map[string]query.Type{
+					"address":   query.String,
+					"topic":     query.String,
+					"minHeight": query.Int,
+					"maxHeight": query.Int,
+					"abi":       query.String,
+					"first":     query.Int,
+					"after":     query.String,
+				},
+				Resolve: service.events,
+			},
+		},
+	}}
+}
+
+func accountField(f func(*acm.Account) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		return f(source.(*acm.Account)), nil
+	}
+}
+
+func blockField(f func(*tm_types.Block) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		return f(source.(*tm_types.Block)), nil
+	}
+}
+
+func txField(f func(*txSource) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		return f(source.(*txSource)), nil
+	}
+}
+
+// Resolves fields that only CallTxs have as null for other txs
+func callTxField(f func(*txSource) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		if source.(*txSource).callTx == nil {
+			return nil, nil
+		}
+		return f(source.(*txSource)), nil
+	}
+}
+
+// Resolves fields of receipts as null for txs without one
+func receiptField(f func(*core_types.TxReceipt) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		if source.(*txSource).receipt == nil {
+			return nil, nil
+		}
+		return f(source.(*txSource).receipt), nil
+	}
+}
+
+func eventField(f func(*eventSource) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		return f(source.(*eventSource)), nil
+	}
+}
+
+func eventArgField(f func(*core_types.DecodedArg) interface{}) resolver {
+	return func(source interface{}, args query.Args) (interface{}, error) {
+		return f(source.(*core_types.DecodedArg)), nil
+	}
+}
+
+func (service *GraphQLService) account(source interface{}, args query.Args) (interface{}, error) {
+	address, err := hexArg(args, "address", 20)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, fmt.Errorf("account needs an address")
+	}
+	return service.pipe.Accounts().Account(address)
+}
+
+// Gets accounts in order of address, starting after the address after
+func (service *GraphQLService) accounts(source interface{}, args query.Args) (interface{}, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	after, err := hexArg(args, "after", 20)
+	if err != nil {
+		return nil, err
+	}
+	var filters []*event.FilterData
+	if minBalance, ok := args.Int("minBalance"); ok {
+		filters = append(filters, &event.FilterData{"balance", ">=", strconv.FormatInt(minBalance, 10)})
+	}
+	if maxBalance, ok := args.Int("maxBalance"); ok {
+		filters = append(filters, &event.FilterData{"balance", "<=", strconv.FormatInt(maxBalance, 10)})
+	}
+	if isContract, ok := args.Boolean("isContract"); ok {
+		op := "=="
+		if isContract {
+			op = "!="
+		}
+		filters = append(filters, &event.FilterData{"code", op, ""})
+	}
+	list, err := service.pipe.Accounts().Accounts(filters)
+	if err != nil {
+		return nil, err
+	}
+	accounts := list.Accounts
+	sort.Sort(accountsByAddress(accounts))
+	start := sort.Search(len(accounts), func(i int) bool {
+		return bytes.Compare(accounts[i].Address, after) > 0
+	})
+	accounts = accounts[start:]
+	if len(accounts) > first {
+		accounts = accounts[:first]
+	}
+	return accounts, nil
+}
+
+// Gets the block at height, or the latest block
+func (service *GraphQLService) block(source interface{}, args query.Args) (interface{}, error) {
+	height, ok := args.Int("height")
+	if !ok {
+		height = int64(service.pipe.Blockchain().Height())
+	}
+	return service.pipe.Blockchain().Block(int(height)), nil
+}
+
+// Gets blocks from the latest down, so the next page is below the height of
+// the last block
+func (service *GraphQLService) blocks(source interface{}, args query.Args) (interface{}, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	latest := int64(service.pipe.Blockchain().Height())
+	maxHeight, ok := args.Int("maxHeight")
+	if !ok || maxHeight > latest {
+		maxHeight = latest
+	}
+	minHeight, ok := args.Int("minHeight")
+	if !ok || minHeight < 1 {
+		minHeight = 1
+	}
+	blocks := []*tm_types.Block{}
+	for height := maxHeight; height >= minHeight && len(blocks) < first; height-- {
+		block := service.pipe.Blockchain().Block(int(height))
+		if block == nil {
+			return nil, fmt.Errorf("Could not load block %v", height)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// Gets an executed tx, given its hash as a 20 byte burrow hash or a 32 byte
+// Ethereum hash
+func (service *GraphQLService) transaction(source interface{}, args query.Args) (interface{}, error) {
+	txHash, err := hexArg(args, "hash", 0)
+	if err != nil || (len(txHash) != 20 && len(txHash) != 32) {
+		return nil, fmt.Errorf("transaction needs a 20 or 32 byte hex hash")
+	}
+	receipt, err := service.pipe.Receipts().TxReceipt(txHash, "")
+	if err != nil {
+		// Not executed
+		return nil, nil
+	}
+	block := service.pipe.Blockchain().Block(receipt.Height)
+	if block == nil {
+		return nil, fmt.Errorf("Could not load block %v of tx %X", receipt.Height,
+			txHash)
+	}
+	sources, err := service.blockTxs(block)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range sources {
+		if bytes.Equal(tx.hash, txHash) {
+			return tx, nil
+		}
+	}
+	return nil, fmt.Errorf("Tx %X is not in block %v", txHash, receipt.Height)
+}
+
+// Gets the events of a tx, decoding them against abi if it is given or else
+// the ABIs registered for the contracts that emitted them
+func (service *GraphQLService) transactionEvents(source interface{}, args query.Args) (interface{}, error) {
+	tx := source.(*txSource)
+	receipt := tx.receipt
+	if receipt == nil {
+		return nil, nil
+	}
+	if abiJSON, ok := args.String("abi"); ok {
+		var err error
+		if receipt, err = service.pipe.Receipts().TxReceipt(tx.hash, abiJSON); err != nil {
+			return nil, err
+		}
+	}
+	events := make([]*eventSource, len(receipt.Logs))
+	for i, log := range receipt.Logs {
+		events[i] = &eventSource{log, tx.height, tx.hash, tx.index, i}
+	}
+	return events, nil
+}
+
+// Gets the events emitted by address with topic among their topics, at
+// least one of which must be given for the log index to look up, in order
+// and starting after the cursor after
+func (service *GraphQLService) events(source interface{}, args query.Args) (interface{}, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	address, err := hexArg(args, "address", 20)
+	if err != nil {
+		return nil, err
+	}
+	topic, err := hexArg(args, "topic", 32)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil && topic == nil {
+		return nil, fmt.Errorf("events needs an address or a topic")
+	}
+	minHeight, _ := args.Int("minHeight")
+	maxHeight, _ := args.Int("maxHeight")
+	var after eventCursor
+	if afterString, ok := args.String("after"); ok {
+		if after, err = parseEventCursor(afterString); err != nil {
+			return nil, err
+		}
+		if int64(after.height) > minHeight {
+			minHeight = int64(after.height)
+		}
+	}
+	abiJSON, _ := args.String("abi")
+	indexed, err := service.pipe.Logs().Logs(address, topic, minHeight, maxHeight)
+	if err != nil {
+		return nil, err
+	}
+	heightSet := make(map[int]bool)
+	for _, log := range indexed {
+		heightSet[int(log.Height)] = true
+	}
+	heights := make([]int, 0, len(heightSet))
+	for height := range heightSet {
+		heights = append(heights, height)
+	}
+	sort.Ints(heights)
+	events := []*eventSource{}
+	for _, height := range heights {
+		block := service.pipe.Blockchain().Block(height)
+		if block == nil {
+			return nil, fmt.Errorf("Could not load block %v", height)
+		}
+		sources, err := service.blockTxs(block)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range sources {
+			receipt := tx.receipt
+			if receipt == nil {
+				continue
+			}
+			if abiJSON != "" {
+				if receipt, err = service.pipe.Receipts().TxReceipt(tx.hash, abiJSON); err != nil {
+					return nil, err
+				}
+			}
+			for i, log := range receipt.Logs {
+				e := &eventSource{log, tx.height, tx.hash, tx.index, i}
+				if !e.cursor().after(after) || !matchLog(log, address, topic) {
+					continue
+				}
+				events = append(events, e)
+				if len(events) == first {
+					return events, nil
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// The txs of a block with the receipts of those that have them
+func (service *GraphQLService) blockTxs(block *tm_types.Block) ([]*txSource, error) {
+	chainID := service.pipe.Blockchain().ChainId()
+	sources := make([]*txSource, len(block.Data.Txs))
+	for i, txBytes := range block.Data.Txs {
+		tx, err := txs.DecodeTx(txBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode tx %v in block %v: %v", i,
+				block.Header.Height, err)
+		}
+		source := &txSource{
+			tx:     tx,
+			hash:   txs.TxHash(chainID, tx),
+			height: block.Header.Height,
+			index:  i,
+		}
+		switch tx := tx.(type) {
+		case *txs.CallTx:
+			source.callTx = tx
+		case *txs.EthTx:
+			// Without a sender the tx failed to execute
+			source.callTx, _ = tx.CallTx(chainID)
+		case *txs.MultisigTx:
+			source.callTx, _ = tx.Tx.(*txs.CallTx)
+		case *txs.PriorityFeeTx, *txs.ExpiringTx:
+			source.callTx, _ = txs.InnerTx(tx).(*txs.CallTx)
+		}
+		if source.callTx != nil {
+			if receipt, err := service.pipe.Receipts().TxReceipt(source.hash, ""); err == nil {
+				source.receipt = receipt
+			}
+		}
+		sources[i] = source
+	}
+	return sources, nil
+}
+
+func matchLog(log *core_types.ReceiptLog, address, topic []byte) bool {
+	if address != nil && !bytes.Equal(log.Address.Postfix(20), address) {
+		return false
+	}
+	if topic == nil {
+		return true
+	}
+	for _, logTopic := range log.Topics {
+		if bytes.Equal(logTopic.Bytes(), topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// The position of an event in the chain
+type eventCursor struct {
+	height, txIndex, logIndex int
+}
+
+func (e *eventSource) cursor() eventCursor {
+	return eventCursor{e.height, e.txIndex, e.logIndex}
+}
+
+func (ec eventCursor) String() string {
+	return fmt.Sprintf("%v/%v/%v", ec.height, ec.txIndex, ec.logIndex)
+}
+
+func (ec eventCursor) after(other eventCursor) bool {
+	if ec.height != other.height {
+		return ec.height > other.height
+	}
+	if ec.txIndex != other.txIndex {
+		return ec.txIndex > other.txIndex
+	}
+	return ec.logIndex > other.logIndex
+}
+
+func parseEventCursor(s string) (eventCursor, error) {
+	var ec eventCursor
+	parts := strings.Split(s, "/")
+	if len(parts) == 3 {
+		var err error
+		for i, n := range []*int{&ec.height, &ec.txIndex, &ec.logIndex} {
+			if *n, err = strconv.Atoi(parts[i]); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return ec, nil
+		}
+	}
+	return ec, fmt.Errorf("%s is not the cursor of an event", s)
+}
+
+// The number of items a page of a list holds, given by first
+func pageSize(args query.Args) (int, error) {
+	first, ok := args.Int("first")
+	if !ok {
+		return defaultPageSize, nil
+	}
+	if first < 1 || first > maxPageSize {
+		return 0, fmt.Errorf("first must be between 1 and %v", maxPageSize)
+	}
+	return int(first), nil
+}
+
+// Reads an argument of hex, optionally prefixed with 0x, that must be length
+// bytes long unless length is 0. Returns nil if the argument was not given.
+func hexArg(args query.Args, name string, length int) ([]byte, error) {
+	s, ok := args.String(name)
+	if !ok {
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be hex: %v", name, err)
+	}
+	if length != 0 && len(bs) != length {
+		return nil, fmt.Errorf("%s must be %v bytes but is %v", name, length, len(bs))
+	}
+	return bs, nil
+}
+
+// Bytes are given in upper case hex like the rest of burrow's APIs
+func hexString(bs []byte) string {
+	return fmt.Sprintf("%X", bs)
+}
+
+type accountsByAddress []*acm.Account
+
+func (as accountsByAddress) Len() int {
+	return len(as)
+}
+
+func (as accountsByAddress) Less(i, j int) bool {
+	return bytes.Compare(as[i].Address, as[j].Address) < 0
+}
+
+func (as accountsByAddress) Swap(i, j int) {
+	as[i], as[j] = as[j], as[i]
+}
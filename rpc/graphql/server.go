@@ -0,0 +1,146 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// GraphQL queries over accounts, blocks, transactions and the events decoded
+// from their logs
+package graphql
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/rpc/graphql/query"
+	"github.com/hyperledger/burrow/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The method GraphQL queries are authorized as, since they are not calls of
+// RPC methods
+const AuthMethod = "graphql"
+
+// Server used to handle GraphQL queries. Implements server.Server
+type GraphQLServer struct {
+	service server.HttpService
+	running bool
+}
+
+// Create a new GraphQLServer
+func NewGraphQLServer(service server.HttpService) *GraphQLServer {
+	return &GraphQLServer{service: service}
+}
+
+// Start adds the GraphQL path to the router, unless it is not configured
+func (this *GraphQLServer) Start(config *server.ServerConfig, router *gin.Engine) {
+	if config.HTTP.GraphQLEndpoint != "" {
+		router.GET(config.HTTP.GraphQLEndpoint, this.handleFunc)
+		router.POST(config.HTTP.GraphQLEndpoint, this.handleFunc)
+	}
+	this.running = true
+}
+
+// Is the server currently running?
+func (this *GraphQLServer) Running() bool {
+	return this.running
+}
+
+// Shut the server down. Does nothing.
+func (this *GraphQLServer) ShutDown() {
+	this.running = false
+}
+
+func (this *GraphQLServer) handleFunc(c *gin.Context) {
+	if err := server.Authorize(c.Request.Context(), AuthMethod); err != nil {
+		c.AbortWithError(http.StatusForbidden, err)
+		return
+	}
+	this.service.Process(c.Request, c.Writer)
+}
+
+// A query as GraphQL clients send it, either as the JSON body of a POST or
+// as the params of a GET where variables are JSON
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// The GraphQL query service. Implements server.HttpService
+type GraphQLService struct {
+	pipe   definitions.Pipe
+	schema *query.Schema
+}
+
+func NewGraphQLService(pipe definitions.Pipe) *GraphQLService {
+	service := &GraphQLService{pipe: pipe}
+	service.schema = service.newSchema()
+	return service
+}
+
+// Execute a query against the latest state
+func (service *GraphQLService) Execute(q, operationName string,
+	variables map[string]interface{}) *query.Result {
+	return service.schema.Execute(q, operationName, variables)
+}
+
+// Process a request
+func (service *GraphQLService) Process(r *http.Request, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	request := new(graphQLRequest)
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		request.Query = params.Get("query")
+		request.OperationName = params.Get("operationName")
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				writeError(w, "Failed to parse variables: "+err.Error())
+				return
+			}
+		}
+	} else {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, "Failed to read request: "+err.Error())
+			return
+		}
+		if err := json.Unmarshal(body, request); err != nil {
+			writeError(w, "Failed to parse request: "+err.Error())
+			return
+		}
+	}
+	if request.Query == "" {
+		writeError(w, "Request has no query")
+		return
+	}
+	writeJSON(w, http.StatusOK, service.Execute(request.Query,
+		request.OperationName, request.Variables))
+}
+
+func writeError(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusBadRequest, &query.Result{
+		Errors: []*query.Error{{Message: message}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, o interface{}) {
+	bs, err := json.Marshal(o)
+	if err != nil {
+		http.Error(w, "Failed to marshal response: "+err.Error(), 500)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(bs)
+}
@@ -0,0 +1,188 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Liveness and readiness probes for Kubernetes and load balancers, reporting
+// whether the node has caught up with its chain, its peers and whether its
+// databases can be read
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A node whose latest block is older than this is taken to be catching up
+const DefaultMaxBlockAge = time.Minute
+
+// The status of the node. It is healthy if its databases can be read, and
+// ready to serve if it is also caught up and has enough peers.
+type Status struct {
+	Healthy           bool       `json:"healthy"`
+	Ready             bool       `json:"ready"`
+	CatchingUp        bool       `json:"catching_up"`
+	LatestBlockHeight int        `json:"latest_block_height"`
+	LatestBlockTime   *time.Time `json:"latest_block_time,omitempty"`
+	// Seconds since the latest block
+	LatestBlockAge float64 `json:"latest_block_age"`
+	Peers          int     `json:"peers"`
+	DBAccessible   bool    `json:"db_accessible"`
+	// Why the node is not healthy or not ready
+	Errors []string `json:"errors,omitempty"`
+}
+
+type HealthService struct {
+	pipe        definitions.Pipe
+	maxBlockAge time.Duration
+	minPeers    int
+	now         func() time.Time
+}
+
+// Create a HealthService that is ready once the latest block is no older than
+// maxBlockAge (DefaultMaxBlockAge if 0) and the node has at least minPeers
+func NewHealthService(pipe definitions.Pipe, maxBlockAge time.Duration,
+	minPeers int) *HealthService {
+	if maxBlockAge <= 0 {
+		maxBlockAge = DefaultMaxBlockAge
+	}
+	return &HealthService{
+		pipe:        pipe,
+		maxBlockAge: maxBlockAge,
+		minPeers:    minPeers,
+		now:         time.Now,
+	}
+}
+
+func (hs *HealthService) Status() *Status {
+	status := &Status{DBAccessible: true}
+	err := hs.readDBs(status)
+	if err != nil {
+		status.DBAccessible = false
+		status.Errors = append(status.Errors, err.Error())
+	}
+	if status.LatestBlockTime == nil {
+		status.CatchingUp = true
+		status.Errors = append(status.Errors, "No blocks have been committed yet")
+	} else {
+		age := hs.now().Sub(*status.LatestBlockTime)
+		status.LatestBlockAge = age.Seconds()
+		if age > hs.maxBlockAge {
+			status.CatchingUp = true
+			status.Errors = append(status.Errors, fmt.Sprintf("The latest block is %s "+
+				"old, more than the %s it may be while caught up", age, hs.maxBlockAge))
+		}
+	}
+	consensusEngine := hs.pipe.GetConsensusEngine()
+	if consensusEngine == nil {
+		status.Errors = append(status.Errors, "The consensus engine is not running")
+	} else {
+		status.Peers = len(consensusEngine.Peers())
+	}
+	status.Healthy = status.DBAccessible && consensusEngine != nil
+	peered := status.Peers >= hs.minPeers
+	if !peered {
+		status.Errors = append(status.Errors, fmt.Sprintf("The node has %v peers, "+
+			"fewer than the %v it needs", status.Peers, hs.minPeers))
+	}
+	status.Ready = status.Healthy && !status.CatchingUp && peered
+	return status
+}
+
+// Read the latest block and an account, recovering from the panics of
+// databases that cannot be read
+func (hs *HealthService) readDBs(status *Status) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Could not read the databases of the node: %v", r)
+		}
+	}()
+	blockchain := hs.pipe.Blockchain()
+	status.LatestBlockHeight = blockchain.Height()
+	if status.LatestBlockHeight > 0 {
+		blockMeta := blockchain.BlockMeta(status.LatestBlockHeight)
+		if blockMeta == nil || blockMeta.Header == nil {
+			return fmt.Errorf("Could not load the latest block %v from the block store",
+				status.LatestBlockHeight)
+		}
+		blockTime := blockMeta.Header.Time
+		status.LatestBlockTime = &blockTime
+	}
+	_, err = hs.pipe.Accounts().Account(make([]byte, 20))
+	if err != nil {
+		return fmt.Errorf("Could not read the state: %v", err)
+	}
+	return nil
+}
+
+// Serves the status of the node at the health and readiness endpoints, with
+// status 200 if it is healthy or ready respectively and 503 otherwise.
+// Implements server.Server
+type HealthServer struct {
+	service *HealthService
+	running bool
+}
+
+func NewHealthServer(service *HealthService) *HealthServer {
+	return &HealthServer{service: service}
+}
+
+// Start adds the health and readiness paths to the router, unless they are not
+// configured
+func (this *HealthServer) Start(config *server.ServerConfig, router *gin.Engine) {
+	if config.HTTP.HealthEndpoint != "" {
+		router.GET(config.HTTP.HealthEndpoint, this.handleHealth)
+	}
+	if config.HTTP.ReadinessEndpoint != "" {
+		router.GET(config.HTTP.ReadinessEndpoint, this.handleReadiness)
+	}
+	this.running = true
+}
+
+// Is the server currently running?
+func (this *HealthServer) Running() bool {
+	return this.running
+}
+
+// Shut the server down. Does nothing.
+func (this *HealthServer) ShutDown() {
+	this.running = false
+}
+
+func (this *HealthServer) handleHealth(c *gin.Context) {
+	status := this.service.Status()
+	writeStatus(c.Writer, status, status.Healthy)
+}
+
+func (this *HealthServer) handleReadiness(c *gin.Context) {
+	status := this.service.Status()
+	writeStatus(c.Writer, status, status.Ready)
+}
+
+func writeStatus(w http.ResponseWriter, status *Status, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	// Probes must not see a cached answer
+	w.Header().Set("Cache-Control", "no-cache")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
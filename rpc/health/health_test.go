@@ -0,0 +1,192 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	consensus_types "github.com/hyperledger/burrow/consensus/types"
+	"github.com/hyperledger/burrow/definitions"
+	"github.com/hyperledger/burrow/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// A pipe with a chain of blocks at the given times, a consensus engine with
+// some peers and accounts that fail to be read if err is set
+type testPipe struct {
+	definitions.Pipe
+	chain     *testChain
+	consensus *testConsensus
+	accounts  *testAccounts
+}
+
+type testChain struct {
+	blockchain_types.Blockchain
+	blockTimes []time.Time
+	// Whether the block store has lost its blocks
+	missing bool
+}
+
+type testConsensus struct {
+	consensus_types.ConsensusEngine
+	peers int
+}
+
+type accountsInterface interface {
+	definitions.Accounts
+}
+
+type testAccounts struct {
+	accountsInterface
+	err error
+}
+
+func (tp *testPipe) Blockchain() blockchain_types.Blockchain { return tp.chain }
+func (tp *testPipe) Accounts() definitions.Accounts          { return tp.accounts }
+
+func (tp *testPipe) GetConsensusEngine() consensus_types.ConsensusEngine {
+	if tp.consensus == nil {
+		return nil
+	}
+	return tp.consensus
+}
+
+func (tc *testChain) Height() int { return len(tc.blockTimes) }
+
+func (tc *testChain) BlockMeta(height int) *tm_types.BlockMeta {
+	if tc.missing {
+		return nil
+	}
+	return &tm_types.BlockMeta{Header: &tm_types.Header{Height: height,
+		Time: tc.blockTimes[height-1]}}
+}
+
+func (tc *testConsensus) Peers() []*consensus_types.Peer {
+	return make([]*consensus_types.Peer, tc.peers)
+}
+
+func (ta *testAccounts) Account(address []byte) (*acm.Account, error) {
+	return nil, ta.err
+}
+
+var now = time.Unix(1500000000, 0)
+
+func newTestService(pipe *testPipe, minPeers int) *HealthService {
+	service := NewHealthService(pipe, 0, minPeers)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestStatus(t *testing.T) {
+	pipe := &testPipe{
+		chain:     &testChain{blockTimes: []time.Time{now.Add(-time.Hour), now.Add(-time.Second)}},
+		consensus: &testConsensus{peers: 3},
+		accounts:  &testAccounts{},
+	}
+	status := newTestService(pipe, 2).Status()
+	assert.True(t, status.Healthy)
+	assert.True(t, status.Ready)
+	assert.False(t, status.CatchingUp)
+	assert.Equal(t, 2, status.LatestBlockHeight)
+	assert.Equal(t, 1.0, status.LatestBlockAge)
+	assert.Equal(t, 3, status.Peers)
+	assert.Empty(t, status.Errors)
+
+	// Too few peers
+	status = newTestService(pipe, 4).Status()
+	assert.True(t, status.Healthy)
+	assert.False(t, status.Ready)
+	assert.Len(t, status.Errors, 1)
+
+	// Catching up
+	pipe.chain.blockTimes = pipe.chain.blockTimes[:1]
+	status = newTestService(pipe, 0).Status()
+	assert.True(t, status.Healthy)
+	assert.True(t, status.CatchingUp)
+	assert.False(t, status.Ready)
+
+	// No blocks yet
+	pipe.chain.blockTimes = nil
+	status = newTestService(pipe, 0).Status()
+	assert.True(t, status.CatchingUp)
+	assert.Nil(t, status.LatestBlockTime)
+
+	// The state cannot be read
+	pipe.chain.blockTimes = []time.Time{now}
+	pipe.accounts.err = fmt.Errorf("leveldb: closed")
+	status = newTestService(pipe, 0).Status()
+	assert.False(t, status.DBAccessible)
+	assert.False(t, status.Healthy)
+	assert.False(t, status.Ready)
+
+	// Nor the blocks
+	pipe.accounts.err = nil
+	pipe.chain.missing = true
+	status = newTestService(pipe, 0).Status()
+	assert.False(t, status.DBAccessible)
+	assert.False(t, status.Healthy)
+
+	// Nor is consensus running
+	pipe.chain.missing = false
+	pipe.consensus = nil
+	status = newTestService(pipe, 0).Status()
+	assert.True(t, status.DBAccessible)
+	assert.False(t, status.Healthy)
+}
+
+func TestEndpoints(t *testing.T) {
+	pipe := &testPipe{
+		chain:     &testChain{blockTimes: []time.Time{now.Add(-time.Hour)}},
+		consensus: &testConsensus{peers: 1},
+		accounts:  &testAccounts{},
+	}
+	config := server.DefaultServerConfig()
+	config.HTTP.HealthEndpoint = "/healthz"
+	config.HTTP.ReadinessEndpoint = "/readyz"
+	router := gin.New()
+	NewHealthServer(newTestService(pipe, 0)).Start(config, router)
+
+	get := func(path string) (int, *Status) {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(recorder, request)
+		status := new(Status)
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), status))
+		return recorder.Code, status
+	}
+	code, status := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.CatchingUp)
+	code, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	pipe.chain.blockTimes = append(pipe.chain.blockTimes, now)
+	code, status = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.Ready)
+	assert.Equal(t, now.Unix(), status.LatestBlockTime.Unix())
+}
@@ -25,6 +25,9 @@ const (
 	INVALID_PARAMS   = -32602
 	INTERNAL_ERROR   = -32603
 	PARSE_ERROR      = -32700
+	// In the range reserved for implementation-defined server errors
+	UNAUTHORIZED = -32001
+	RATE_LIMITED = -32005
 )
 
 // Request and Response objects. Id is a string. Error data not used.
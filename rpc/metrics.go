@@ -0,0 +1,29 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"github.com/hyperledger/burrow/metrics"
+)
+
+// The metrics of the JSON-RPC services, labelled by the service and the
+// method called. Calls to methods that do not exist are not recorded.
+var (
+	RequestSeconds = metrics.NewHistogram("burrow_rpc_request_seconds",
+		"The time taken to handle RPC requests", metrics.DefBuckets,
+		"service", "method")
+	RequestErrors = metrics.NewCounter("burrow_rpc_errors_total",
+		"The RPC requests that returned an error", "service", "method")
+)
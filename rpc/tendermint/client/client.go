@@ -140,6 +140,156 @@ func GetName(client RPCClient, name string) (*core_types.NameRegEntry, error) {
 	return res.(*rpc_types.ResultGetName).Entry, nil
 }
 
+func GetNode(client RPCClient, address []byte) (*core_types.NodeRegEntry, error) {
+	res, err := call(client, "get_node",
+		"address", address)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetNode).Entry, nil
+}
+
+func GetABI(client RPCClient, address []byte) (*core_types.ABIEntry, error) {
+	res, err := call(client, "get_abi",
+		"address", address)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetABI).Entry, nil
+}
+
+func GetTxReceipt(client RPCClient, txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	res, err := call(client, "get_tx_receipt",
+		"txHash", txHash,
+		"abi", abiJSON)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetTxReceipt).Receipt, nil
+}
+
+func GetPrivateTx(client RPCClient, txHash []byte,
+	abiJSON string) (*rpc_types.ResultGetPrivateTx, error) {
+	res, err := call(client, "get_private_tx",
+		"txHash", txHash,
+		"abi", abiJSON)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetPrivateTx), nil
+}
+
+func GetPrivateAccount(client RPCClient, group,
+	address []byte) (*acm.Account, error) {
+	res, err := call(client, "get_private_account",
+		"group", group,
+		"address", address)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetAccount).Account, nil
+}
+
+func GetPrivateStorage(client RPCClient, group, address,
+	key []byte) ([]byte, error) {
+	res, err := call(client, "get_private_storage",
+		"group", group,
+		"address", address,
+		"key", key)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetStorage).Value, nil
+}
+
+func SealConfidential(client RPCClient, owner, key, data []byte) ([]byte, error) {
+	res, err := call(client, "seal_confidential",
+		"owner", owner,
+		"key", key,
+		"data", data)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultSealConfidential).Blob, nil
+}
+
+func ReadConfidential(client RPCClient, read *txs.ConfidentialRead, pubKey,
+	signature []byte) ([]byte, error) {
+	res, err := call(client, "read_confidential",
+		"owner", read.Owner,
+		"key", read.Key,
+		"height", read.Height,
+		"pubKey", pubKey,
+		"signature", signature)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultReadConfidential).Data, nil
+}
+
+func ListNodes(client RPCClient) (*rpc_types.ResultListNodes, error) {
+	res, err := call(client, "list_nodes")
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultListNodes), nil
+}
+
+func GetRelayedChain(client RPCClient, chainID string) (*core_types.RelayedChain, error) {
+	res, err := call(client, "get_relayed_chain",
+		"chainId", chainID)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetRelayedChain).Chain, nil
+}
+
+func GetRelayedHeader(client RPCClient, chainID string,
+	height int) (*core_types.RelayedHeader, error) {
+	res, err := call(client, "get_relayed_header",
+		"chainId", chainID,
+		"height", height)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetRelayedHeader).Header, nil
+}
+
+func ListRelayedChains(client RPCClient) (*rpc_types.ResultListRelayedChains, error) {
+	res, err := call(client, "list_relayed_chains")
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultListRelayedChains), nil
+}
+
+func GetAttestation(client RPCClient, id []byte) (*core_types.Attestation, error) {
+	res, err := call(client, "get_attestation",
+		"id", id)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultGetAttestation).Attestation, nil
+}
+
+func ListAttestations(client RPCClient, minHeight int64) (*rpc_types.ResultListAttestations, error) {
+	res, err := call(client, "list_attestations",
+		"minHeight", minHeight)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultListAttestations), nil
+}
+
+func ListDesignatedEvents(client RPCClient) (*rpc_types.ResultListDesignatedEvents, error) {
+	res, err := call(client, "list_designated_events")
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rpc_types.ResultListDesignatedEvents), nil
+}
+
 func BlockchainInfo(client RPCClient, minHeight,
 	maxHeight int) (*rpc_types.ResultBlockchainInfo, error) {
 	res, err := call(client, "blockchain",
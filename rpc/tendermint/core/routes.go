@@ -52,6 +52,21 @@ func (tmRoutes *TendermintRoutes) GetRoutes() map[string]*rpc.RPCFunc {
 		"list_accounts":           rpc.NewRPCFunc(tmRoutes.ListAccountsResult, ""),
 		"get_name":                rpc.NewRPCFunc(tmRoutes.GetNameResult, "name"),
 		"list_names":              rpc.NewRPCFunc(tmRoutes.ListNamesResult, ""),
+		"get_node":                rpc.NewRPCFunc(tmRoutes.GetNodeResult, "address"),
+		"list_nodes":              rpc.NewRPCFunc(tmRoutes.ListNodesResult, ""),
+		"get_relayed_chain":       rpc.NewRPCFunc(tmRoutes.GetRelayedChainResult, "chainId"),
+		"get_relayed_header":      rpc.NewRPCFunc(tmRoutes.GetRelayedHeaderResult, "chainId,height"),
+		"list_relayed_chains":     rpc.NewRPCFunc(tmRoutes.ListRelayedChainsResult, ""),
+		"get_attestation":         rpc.NewRPCFunc(tmRoutes.GetAttestationResult, "id"),
+		"list_attestations":       rpc.NewRPCFunc(tmRoutes.ListAttestationsResult, "minHeight"),
+		"list_designated_events":  rpc.NewRPCFunc(tmRoutes.ListDesignatedEventsResult, ""),
+		"get_private_tx":          rpc.NewRPCFunc(tmRoutes.GetPrivateTxResult, "txHash,abi"),
+		"get_private_account":     rpc.NewRPCFunc(tmRoutes.GetPrivateAccountResult, "group,address"),
+		"get_private_storage":     rpc.NewRPCFunc(tmRoutes.GetPrivateStorageResult, "group,address,key"),
+		"seal_confidential":       rpc.NewRPCFunc(tmRoutes.SealConfidentialResult, "owner,key,data"),
+		"read_confidential":       rpc.NewRPCFunc(tmRoutes.ReadConfidentialResult, "owner,key,height,pubKey,signature"),
+		"get_abi":                 rpc.NewRPCFunc(tmRoutes.GetABIResult, "address"),
+		"get_tx_receipt":          rpc.NewRPCFunc(tmRoutes.GetTxReceiptResult, "txHash,abi"),
 		"broadcast_tx":            rpc.NewRPCFunc(tmRoutes.BroadcastTxResult, "tx"),
 		"blockchain":              rpc.NewRPCFunc(tmRoutes.BlockchainInfo, "minHeight,maxHeight"),
 		"get_block":               rpc.NewRPCFunc(tmRoutes.GetBlock, "height"),
@@ -196,6 +211,138 @@ func (tmRoutes *TendermintRoutes) ListNamesResult() (ctypes.BurrowResult, error)
 	}
 }
 
+func (tmRoutes *TendermintRoutes) GetNodeResult(address []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetNode(address); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) ListNodesResult() (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.ListNodes(); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetRelayedChainResult(chainID string) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetRelayedChain(chainID); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetRelayedHeaderResult(chainID string,
+	height int) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetRelayedHeader(chainID, height); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) ListRelayedChainsResult() (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.ListRelayedChains(); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetAttestationResult(id []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetAttestation(id); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) ListAttestationsResult(minHeight int64) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.ListAttestations(minHeight); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) ListDesignatedEventsResult() (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.ListDesignatedEvents(); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetABIResult(address []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetABI(address); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetPrivateTxResult(txHash []byte,
+	abiJSON string) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetPrivateTx(txHash, abiJSON); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetPrivateAccountResult(group,
+	address []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetPrivateAccount(group, address); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetPrivateStorageResult(group, address,
+	key []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetPrivateStorage(group, address, key); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) SealConfidentialResult(owner, key,
+	data []byte) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.SealConfidential(owner, key, data); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) ReadConfidentialResult(owner, key []byte,
+	height int, pubKey, signature []byte) (ctypes.BurrowResult, error) {
+	read := &txs.ConfidentialRead{
+		Owner:  owner,
+		Key:    key,
+		Height: height,
+	}
+	if r, err := tmRoutes.tendermintPipe.ReadConfidential(read, pubKey, signature); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
+func (tmRoutes *TendermintRoutes) GetTxReceiptResult(txHash []byte,
+	abiJSON string) (ctypes.BurrowResult, error) {
+	if r, err := tmRoutes.tendermintPipe.GetTxReceipt(txHash, abiJSON); err != nil {
+		return nil, err
+	} else {
+		return r, nil
+	}
+}
+
 func (tmRoutes *TendermintRoutes) GenPrivAccountResult() (ctypes.BurrowResult, error) {
 	//if r, err := tmRoutes.tendermintPipe.GenPrivAccount(); err != nil {
 	//	return nil, err
@@ -139,6 +139,69 @@ type ResultGetName struct {
 	Entry *core_types.NameRegEntry `json:"entry"`
 }
 
+type ResultGetNode struct {
+	Entry *core_types.NodeRegEntry `json:"entry"`
+}
+
+type ResultListNodes struct {
+	BlockHeight int                        `json:"block_height"`
+	Nodes       []*core_types.NodeRegEntry `json:"nodes"`
+}
+
+type ResultGetRelayedChain struct {
+	Chain *core_types.RelayedChain `json:"chain"`
+}
+
+type ResultGetRelayedHeader struct {
+	Header *core_types.RelayedHeader `json:"header"`
+}
+
+type ResultListRelayedChains struct {
+	BlockHeight int                        `json:"block_height"`
+	Chains      []*core_types.RelayedChain `json:"chains"`
+}
+
+type ResultGetAttestation struct {
+	Attestation *core_types.Attestation `json:"attestation"`
+}
+
+type ResultListAttestations struct {
+	BlockHeight  int                       `json:"block_height"`
+	Attestations []*core_types.Attestation `json:"attestations"`
+}
+
+type ResultListDesignatedEvents struct {
+	BlockHeight int                           `json:"block_height"`
+	Events      []*core_types.DesignatedEvent `json:"events"`
+}
+
+// The record of a PrivateTx, with the receipt of its payload when the node is
+// in its private group
+type ResultGetPrivateTx struct {
+	Record  *core_types.PrivateTxRecord `json:"record"`
+	Receipt *core_types.TxReceipt       `json:"receipt"`
+}
+
+// A blob sealed by the node for the confidential store
+type ResultSealConfidential struct {
+	Blob []byte `json:"blob"`
+}
+
+// The blob owner put at key in the confidential store, opened by the node
+type ResultReadConfidential struct {
+	Owner []byte `json:"owner"`
+	Key   []byte `json:"key"`
+	Data  []byte `json:"data"`
+}
+
+type ResultGetABI struct {
+	Entry *core_types.ABIEntry `json:"entry"`
+}
+
+type ResultGetTxReceipt struct {
+	Receipt *core_types.TxReceipt `json:"receipt"`
+}
+
 type ResultGenesis struct {
 	Genesis *genesis.GenesisDoc `json:"genesis"`
 }
@@ -156,29 +219,42 @@ type ResultEvent struct {
 // result types
 
 const (
-	ResultTypeGetStorage         = byte(0x01)
-	ResultTypeCall               = byte(0x02)
-	ResultTypeListAccounts       = byte(0x03)
-	ResultTypeDumpStorage        = byte(0x04)
-	ResultTypeBlockchainInfo     = byte(0x05)
-	ResultTypeGetBlock           = byte(0x06)
-	ResultTypeStatus             = byte(0x07)
-	ResultTypeNetInfo            = byte(0x08)
-	ResultTypeListValidators     = byte(0x09)
-	ResultTypeDumpConsensusState = byte(0x0A)
-	ResultTypeListNames          = byte(0x0B)
-	ResultTypeGenPrivAccount     = byte(0x0C)
-	ResultTypeGetAccount         = byte(0x0D)
-	ResultTypeBroadcastTx        = byte(0x0E)
-	ResultTypeListUnconfirmedTxs = byte(0x0F)
-	ResultTypeGetName            = byte(0x10)
-	ResultTypeGenesis            = byte(0x11)
-	ResultTypeSignTx             = byte(0x12)
-	ResultTypeEvent              = byte(0x13) // so websockets can respond to rpc functions
-	ResultTypeSubscribe          = byte(0x14)
-	ResultTypeUnsubscribe        = byte(0x15)
-	ResultTypePeerConsensusState = byte(0x16)
-	ResultTypeChainId            = byte(0x17)
+	ResultTypeGetStorage           = byte(0x01)
+	ResultTypeCall                 = byte(0x02)
+	ResultTypeListAccounts         = byte(0x03)
+	ResultTypeDumpStorage          = byte(0x04)
+	ResultTypeBlockchainInfo       = byte(0x05)
+	ResultTypeGetBlock             = byte(0x06)
+	ResultTypeStatus               = byte(0x07)
+	ResultTypeNetInfo              = byte(0x08)
+	ResultTypeListValidators       = byte(0x09)
+	ResultTypeDumpConsensusState   = byte(0x0A)
+	ResultTypeListNames            = byte(0x0B)
+	ResultTypeGenPrivAccount       = byte(0x0C)
+	ResultTypeGetAccount           = byte(0x0D)
+	ResultTypeBroadcastTx          = byte(0x0E)
+	ResultTypeListUnconfirmedTxs   = byte(0x0F)
+	ResultTypeGetName              = byte(0x10)
+	ResultTypeGenesis              = byte(0x11)
+	ResultTypeSignTx               = byte(0x12)
+	ResultTypeEvent                = byte(0x13) // so websockets can respond to rpc functions
+	ResultTypeSubscribe            = byte(0x14)
+	ResultTypeUnsubscribe          = byte(0x15)
+	ResultTypePeerConsensusState   = byte(0x16)
+	ResultTypeChainId              = byte(0x17)
+	ResultTypeGetNode              = byte(0x18)
+	ResultTypeListNodes            = byte(0x19)
+	ResultTypeGetABI               = byte(0x1A)
+	ResultTypeGetTxReceipt         = byte(0x1B)
+	ResultTypeGetRelayedChain      = byte(0x1C)
+	ResultTypeGetRelayedHeader     = byte(0x1D)
+	ResultTypeListRelayedChains    = byte(0x1E)
+	ResultTypeGetAttestation       = byte(0x1F)
+	ResultTypeListAttestations     = byte(0x20)
+	ResultTypeListDesignatedEvents = byte(0x21)
+	ResultTypeGetPrivateTx         = byte(0x22)
+	ResultTypeSealConfidential     = byte(0x23)
+	ResultTypeReadConfidential     = byte(0x24)
 )
 
 type BurrowResult interface {
@@ -210,6 +286,19 @@ func ConcreteTypes() []wire.ConcreteType {
 		{&ResultSubscribe{}, ResultTypeSubscribe},
 		{&ResultUnsubscribe{}, ResultTypeUnsubscribe},
 		{&ResultChainId{}, ResultTypeChainId},
+		{&ResultGetNode{}, ResultTypeGetNode},
+		{&ResultListNodes{}, ResultTypeListNodes},
+		{&ResultGetABI{}, ResultTypeGetABI},
+		{&ResultGetTxReceipt{}, ResultTypeGetTxReceipt},
+		{&ResultGetRelayedChain{}, ResultTypeGetRelayedChain},
+		{&ResultGetRelayedHeader{}, ResultTypeGetRelayedHeader},
+		{&ResultListRelayedChains{}, ResultTypeListRelayedChains},
+		{&ResultGetAttestation{}, ResultTypeGetAttestation},
+		{&ResultListAttestations{}, ResultTypeListAttestations},
+		{&ResultListDesignatedEvents{}, ResultTypeListDesignatedEvents},
+		{&ResultGetPrivateTx{}, ResultTypeGetPrivateTx},
+		{&ResultSealConfidential{}, ResultTypeSealConfidential},
+		{&ResultReadConfidential{}, ResultTypeReadConfidential},
 	}
 }
 
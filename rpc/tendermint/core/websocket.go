@@ -19,6 +19,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 
 	events "github.com/tendermint/go-events"
 	rpcserver "github.com/tendermint/go-rpc/server"
@@ -27,6 +28,10 @@ import (
 	server "github.com/hyperledger/burrow/server"
 )
 
+// The prefix of the routes as the methods roles authorize, as in
+// tendermint.broadcast_tx
+const TendermintMethodPrefix = "tendermint."
+
 type TendermintWebsocketServer struct {
 	routes    TendermintRoutes
 	listeners []net.Listener
@@ -48,13 +53,22 @@ func NewTendermintWebsocketServer(config *server.ServerConfig,
 		return nil, fmt.Errorf("No RPC listening addresses provided in [servers.tendermint.rpc_local_address] in configuration file: %s",
 			listenerAddresses)
 	}
+	var handler http.Handler = &authorizedRoutes{
+		routes:   routes,
+		evsw:     evsw,
+		endpoint: config.Tendermint.Endpoint,
+		handlers: make(map[*server.Identity]http.Handler),
+	}
+	if config.Auth.Enable {
+		authenticator, err := server.NewAuthenticator(config.Auth)
+		if err != nil {
+			return nil, err
+		}
+		handler = authenticator.Handler(handler)
+	}
 	listeners := make([]net.Listener, len(listenerAddresses))
 	for i, listenerAddress := range listenerAddresses {
-		mux := http.NewServeMux()
-		wm := rpcserver.NewWebsocketManager(routes, evsw)
-		mux.HandleFunc(config.Tendermint.Endpoint, wm.WebsocketHandler)
-		rpcserver.RegisterRPCFuncs(mux, routes)
-		listener, err := rpcserver.StartHTTPServer(listenerAddress, mux)
+		listener, err := rpcserver.StartHTTPServer(listenerAddress, handler)
 		if err != nil {
 			return nil, err
 		}
@@ -66,6 +80,40 @@ func NewTendermintWebsocketServer(config *server.ServerConfig,
 	}, nil
 }
 
+// Serves each request with only the routes its identity may call, since the
+// routes are called without the request to authorize against. The routes
+// left out are unknown to the client, over websockets too.
+type authorizedRoutes struct {
+	routes   map[string]*rpcserver.RPCFunc
+	evsw     events.EventSwitch
+	endpoint string
+	mtx      sync.Mutex
+	// By identity, which is nil when authentication is not enabled
+	handlers map[*server.Identity]http.Handler
+}
+
+func (ar *authorizedRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	identity := server.IdentityFromContext(r.Context())
+	ar.mtx.Lock()
+	handler, ok := ar.handlers[identity]
+	if !ok {
+		routes := make(map[string]*rpcserver.RPCFunc)
+		for name, route := range ar.routes {
+			if server.Authorize(r.Context(), TendermintMethodPrefix+name) == nil {
+				routes[name] = route
+			}
+		}
+		mux := http.NewServeMux()
+		wm := rpcserver.NewWebsocketManager(routes, ar.evsw)
+		mux.HandleFunc(ar.endpoint, wm.WebsocketHandler)
+		rpcserver.RegisterRPCFuncs(mux, routes)
+		handler = mux
+		ar.handlers[identity] = handler
+	}
+	ar.mtx.Unlock()
+	handler.ServeHTTP(w, r)
+}
+
 func (tmServer *TendermintWebsocketServer) Shutdown() {
 	for _, listener := range tmServer.listeners {
 		listener.Close()
@@ -0,0 +1,244 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"context"
+	"fmt"
+
+	blockchain_types "github.com/hyperledger/burrow/blockchain/types"
+	"github.com/hyperledger/burrow/event"
+	rpc "github.com/hyperledger/burrow/rpc"
+	server "github.com/hyperledger/burrow/server"
+	"github.com/hyperledger/burrow/txs"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The execution event streams push records to a websocket session under the
+// subscription id returned by the request, in height order, until the
+// requested range is exhausted, the stream is unsubscribed, or the session
+// closes.
+
+// A committed block, pushed when streaming blocks
+type BlockExecution struct {
+	Height int             `json:"height"`
+	Block  *tm_types.Block `json:"block"`
+}
+
+// A transaction from a committed block, pushed when streaming transactions.
+// Index is the position of the transaction within its block.
+type TxExecution struct {
+	Height int    `json:"height"`
+	Index  int    `json:"index"`
+	TxHash []byte `json:"tx_hash"`
+	Tx     txs.Tx `json:"tx"`
+}
+
+// Pushed once the last height of a bounded stream has been sent
+type StreamEnd struct {
+	Height int `json:"height"`
+}
+
+func (this *BurrowWsService) StreamBlocks(request *rpc.RPCRequest,
+	requester interface{}) (interface{}, int, error) {
+	return this.streamBlocks(request, requester,
+		func(subId string, session *server.WSSession, block *tm_types.Block) error {
+			return this.writeResponse(subId,
+				&BlockExecution{block.Header.Height, block}, session)
+		})
+}
+
+func (this *BurrowWsService) StreamTxs(request *rpc.RPCRequest,
+	requester interface{}) (interface{}, int, error) {
+	chainId := this.pipe.Blockchain().ChainId()
+	return this.streamBlocks(request, requester,
+		func(subId string, session *server.WSSession, block *tm_types.Block) error {
+			for i, txBytes := range block.Data.Txs {
+				tx, err := txs.DecodeTx(txBytes)
+				if err != nil {
+					return fmt.Errorf("Could not decode tx %v in block %v: %v", i,
+						block.Header.Height, err)
+				}
+				err = this.writeResponse(subId, &TxExecution{
+					Height: block.Header.Height,
+					Index:  i,
+					TxHash: txs.TxHash(chainId, tx),
+					Tx:     tx,
+				}, session)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// Events are not retained once they have been emitted so the stream starts
+// from the current height and min_height may not be given.
+func (this *BurrowWsService) StreamEvents(request *rpc.RPCRequest,
+	requester interface{}) (interface{}, int, error) {
+	session, ok := requester.(*server.WSSession)
+	if !ok {
+		return 0, rpc.INTERNAL_ERROR,
+			fmt.Errorf("Passing wrong object to websocket events")
+	}
+	param := &StreamParam{}
+	err := this.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	blockchain := this.pipe.Blockchain()
+	height := blockchain.Height()
+	if param.MinHeight != 0 {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("Events are not retained "+
+			"so cannot be streamed from a given height, omit min_height to "+
+			"stream from the current height (%v)", height)
+	}
+	if param.MaxHeight != 0 && param.MaxHeight <= height {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("Events up to max_height %v "+
+			"have already been emitted, the chain is at height %v",
+			param.MaxHeight, height)
+	}
+	subId, errSID := event.GenerateSubId()
+	if errSID != nil {
+		return nil, rpc.INTERNAL_ERROR, errSID
+	}
+	ctx, err := addSubscription(session, subId)
+	if err != nil {
+		return nil, rpc.RATE_LIMITED, err
+	}
+	callback := event.BufferedCallback(ctx, this.eventBufferConfig,
+		func(eventData txs.EventData) {
+			this.writeResponse(subId, eventData, session)
+		})
+	err = event.SubscribeQuery(ctx, this.pipe.Events(), subId, param.EventId,
+		callback)
+	if err != nil {
+		session.RemoveSubscription(subId)
+		return nil, rpc.INTERNAL_ERROR, err
+	}
+	go func() {
+		// Wait for the last block in range to be committed
+		err := streamBlocks(ctx, blockchain, this.pipe.Events(), subId,
+			height+1, param.MaxHeight,
+			func(*tm_types.Block) error { return nil })
+		this.endStream(ctx, subId, param.MaxHeight, err, session)
+	}()
+	return &event.EventSub{subId}, 0, nil
+}
+
+// Decodes the StreamParam of request and starts a goroutine calling send for
+// each block in its range, returning the subscription id the blocks are
+// pushed under
+func (this *BurrowWsService) streamBlocks(request *rpc.RPCRequest,
+	requester interface{},
+	send func(subId string, session *server.WSSession, block *tm_types.Block) error) (interface{}, int, error) {
+	session, ok := requester.(*server.WSSession)
+	if !ok {
+		return 0, rpc.INTERNAL_ERROR,
+			fmt.Errorf("Passing wrong object to websocket events")
+	}
+	param := &StreamParam{}
+	err := this.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	minHeight := param.MinHeight
+	if minHeight < 1 {
+		minHeight = 1
+	}
+	if param.MaxHeight < 0 || (param.MaxHeight != 0 && param.MaxHeight < minHeight) {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("max_height %v is less "+
+			"than min_height %v", param.MaxHeight, minHeight)
+	}
+	subId, errSID := event.GenerateSubId()
+	if errSID != nil {
+		return nil, rpc.INTERNAL_ERROR, errSID
+	}
+	ctx, err := addSubscription(session, subId)
+	if err != nil {
+		return nil, rpc.RATE_LIMITED, err
+	}
+	go func() {
+		err := streamBlocks(ctx, this.pipe.Blockchain(), this.pipe.Events(),
+			subId, minHeight, param.MaxHeight,
+			func(block *tm_types.Block) error {
+				return send(subId, session, block)
+			})
+		this.endStream(ctx, subId, param.MaxHeight, err, session)
+	}()
+	return &event.EventSub{subId}, 0, nil
+}
+
+// Removes a finished stream from the session and tells the client, either
+// with an error or, for a bounded stream, with StreamEnd. Nothing is written
+// if the stream was unsubscribed or the session has gone.
+func (this *BurrowWsService) endStream(ctx context.Context, subId string,
+	maxHeight int, err error, session *server.WSSession) {
+	if ctx.Err() != nil {
+		return
+	}
+	session.RemoveSubscription(subId)
+	if err != nil {
+		this.writeError(err.Error(), subId, rpc.INTERNAL_ERROR, session)
+		return
+	}
+	this.writeResponse(subId, &StreamEnd{maxHeight}, session)
+}
+
+// Calls send with each block from minHeight to maxHeight in turn, waiting for
+// blocks to be committed as needed. A maxHeight of zero follows the chain tip
+// until ctx is done.
+func streamBlocks(ctx context.Context, blockchain blockchain_types.Blockchain,
+	eventEmitter event.EventEmitter, subId string, minHeight, maxHeight int,
+	send func(*tm_types.Block) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var newBlock chan struct{}
+	if maxHeight == 0 || maxHeight > blockchain.Height() {
+		newBlock = make(chan struct{}, 1)
+		// Use our own id so as not to clobber other subscriptions made with subId
+		err := eventEmitter.Subscribe(ctx, subId+"/"+txs.EventStringNewBlock(),
+			txs.EventStringNewBlock(), func(txs.EventData) {
+				select {
+				case newBlock <- struct{}{}:
+				default:
+				}
+			})
+		if err != nil {
+			return err
+		}
+	}
+	height := minHeight
+	for {
+		for ; height <= blockchain.Height() && (maxHeight == 0 || height <= maxHeight); height++ {
+			block := blockchain.Block(height)
+			if block == nil {
+				return fmt.Errorf("Could not find block at height %v", height)
+			}
+			if err := send(block); err != nil {
+				return err
+			}
+		}
+		if maxHeight != 0 && height > maxHeight {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-newBlock:
+		}
+	}
+}
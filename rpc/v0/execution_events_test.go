@@ -0,0 +1,112 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v0
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/burrow/txs"
+	"github.com/stretchr/testify/assert"
+	mintTypes "github.com/tendermint/tendermint/types"
+)
+
+// A blockchain that grows by one block, firing NewBlock, on each commit
+type growingChain struct {
+	mtx      sync.Mutex
+	height   int
+	callback func(txs.EventData)
+}
+
+func (this *growingChain) ChainId() string {
+	return "growing"
+}
+
+func (this *growingChain) Height() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.height
+}
+
+func (this *growingChain) Block(height int) *mintTypes.Block {
+	return &mintTypes.Block{Header: &mintTypes.Header{Height: height}}
+}
+
+func (this *growingChain) BlockMeta(height int) *mintTypes.BlockMeta {
+	return &mintTypes.BlockMeta{}
+}
+
+func (this *growingChain) Subscribe(ctx context.Context, subId, event string,
+	callback func(txs.EventData)) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.callback = callback
+	return nil
+}
+
+func (this *growingChain) Unsubscribe(subId string) error {
+	return nil
+}
+
+func (this *growingChain) commit() {
+	this.mtx.Lock()
+	this.height++
+	callback := this.callback
+	this.mtx.Unlock()
+	if callback != nil {
+		callback(txs.EventDataNewBlock{})
+	}
+}
+
+func TestStreamBlocksBounded(t *testing.T) {
+	chain := &growingChain{height: 5}
+	var heights []int
+	err := streamBlocks(context.Background(), chain, chain, "sub", 2, 4,
+		func(block *mintTypes.Block) error {
+			heights = append(heights, block.Header.Height)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4}, heights)
+	assert.Nil(t, chain.callback, "should not follow the tip for a past range")
+}
+
+func TestStreamBlocksFollow(t *testing.T) {
+	chain := &growingChain{height: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	heightCh := make(chan int, 10)
+	errCh := make(chan error)
+	go func() {
+		errCh <- streamBlocks(ctx, chain, chain, "sub", 1, 0,
+			func(block *mintTypes.Block) error {
+				heightCh <- block.Header.Height
+				return nil
+			})
+	}()
+	assert.Equal(t, 1, <-heightCh)
+	chain.commit()
+	assert.Equal(t, 2, <-heightCh)
+	chain.commit()
+	assert.Equal(t, 3, <-heightCh)
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("stream should end when its context is done")
+	}
+}
@@ -15,13 +15,17 @@
 package v0
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	definitions "github.com/hyperledger/burrow/definitions"
 	event "github.com/hyperledger/burrow/event"
 	"github.com/hyperledger/burrow/rpc"
 	server "github.com/hyperledger/burrow/server"
+	"github.com/hyperledger/burrow/tracing"
+	"github.com/hyperledger/burrow/txs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -112,8 +116,20 @@ func (this *BurrowJsonService) Process(r *http.Request, w http.ResponseWriter) {
 	mName := req.Method
 
 	if handler, ok := this.defaultHandlers[mName]; ok {
+		if err := server.Authorize(r.Context(), mName); err != nil {
+			this.writeError(err.Error(), req.Id, rpc.UNAUTHORIZED, w)
+			return
+		}
+		parent, _ := tracing.ParseTraceParent(r.Header.Get("traceparent"))
+		span := tracing.DefaultTracer.Start(mName, parent)
+		defer span.Finish()
+		start := time.Now()
 		resp, errCode, err := handler(req, w)
+		rpc.RequestSeconds.ObserveSince(start, SERVICE_NAME, mName)
+		traceTxs(resp, span)
 		if err != nil {
+			span.SetTag("error", err.Error())
+			rpc.RequestErrors.Inc(SERVICE_NAME, mName)
 			this.writeError(err.Error(), req.Id, errCode, w)
 		} else {
 			this.writeResponse(req.Id, resp, w)
@@ -123,6 +139,22 @@ func (this *BurrowJsonService) Process(r *http.Request, w http.ResponseWriter) {
 	}
 }
 
+// Continues the trace of span with the txs that were sent, if resp holds their
+// receipts, so that their execution is traced
+func traceTxs(resp interface{}, span *tracing.Span) {
+	if span == nil {
+		return
+	}
+	switch receipts := resp.(type) {
+	case *txs.Receipt:
+		tracing.DefaultTracer.TraceTx(receipts.TxHash, span.Context())
+	case []*txs.Receipt:
+		for _, receipt := range receipts {
+			tracing.DefaultTracer.TraceTx(receipt.TxHash, span.Context())
+		}
+	}
+}
+
 // Helper for writing error responses.
 func (this *BurrowJsonService) writeError(msg, id string, code int, w http.ResponseWriter) {
 	response := rpc.NewRPCErrorResponse(id, code, msg)
@@ -157,7 +189,7 @@ func (this *BurrowJsonService) EventSubscribe(request *rpc.RPCRequest,
 		return nil, rpc.INVALID_PARAMS, err
 	}
 	eventId := param.EventId
-	subId, errC := this.eventSubs.Add(eventId)
+	subId, errC := this.eventSubs.Add(context.Background(), eventId)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -174,7 +206,7 @@ func (this *BurrowJsonService) EventUnsubscribe(request *rpc.RPCRequest,
 	}
 	subId := param.SubId
 
-	errC := this.pipe.Events().Unsubscribe(subId)
+	errC := this.eventSubs.Remove(subId)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -184,16 +216,16 @@ func (this *BurrowJsonService) EventUnsubscribe(request *rpc.RPCRequest,
 // Check subscription event cache for new data.
 func (this *BurrowJsonService) EventPoll(request *rpc.RPCRequest,
 	requester interface{}) (interface{}, int, error) {
-	param := &SubIdParam{}
+	param := &EventPollParam{}
 	err := json.Unmarshal(request.Params, param)
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
 	subId := param.SubId
 
-	result, errC := this.eventSubs.Poll(subId)
+	result, cursor, errC := this.eventSubs.Poll(subId, param.AfterCursor)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
-	return &event.PollResponse{result}, 0, nil
+	return &event.PollResponse{result, cursor}, 0, nil
 }
@@ -15,10 +15,13 @@
 package v0
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/burrow/blockchain"
 	core_types "github.com/hyperledger/burrow/core/types"
 	definitions "github.com/hyperledger/burrow/definitions"
 	"github.com/hyperledger/burrow/event"
+	"github.com/hyperledger/burrow/logging/lifecycle"
 	"github.com/hyperledger/burrow/rpc"
 	"github.com/hyperledger/burrow/rpc/v0/shared"
 	"github.com/hyperledger/burrow/txs"
@@ -30,8 +33,14 @@ const (
 
 	GET_ACCOUNTS              = SERVICE_NAME + ".getAccounts" // Accounts
 	GET_ACCOUNT               = SERVICE_NAME + ".getAccount"
+	LIST_ACCOUNTS             = SERVICE_NAME + ".listAccounts"
 	GET_STORAGE               = SERVICE_NAME + ".getStorage"
 	GET_STORAGE_AT            = SERVICE_NAME + ".getStorageAt"
+	GET_ACCOUNT_WITH_PROOF    = SERVICE_NAME + ".getAccountWithProof"
+	GET_STORAGE_AT_WITH_PROOF = SERVICE_NAME + ".getStorageAtWithProof"
+	LIST_STORAGE              = SERVICE_NAME + ".listStorage"
+	GET_ABI                   = SERVICE_NAME + ".getABI"
+	DUMP_STATE                = SERVICE_NAME + ".dumpState" // REST only, streamed
 	GEN_PRIV_ACCOUNT          = SERVICE_NAME + ".genPrivAccount"
 	GEN_PRIV_ACCOUNT_FROM_KEY = SERVICE_NAME + ".genPrivAccountFromKey"
 	GET_BLOCKCHAIN_INFO       = SERVICE_NAME + ".getBlockchainInfo" // Blockchain
@@ -53,18 +62,40 @@ const (
 	CALL                      = SERVICE_NAME + ".call" // Tx
 	CALL_CODE                 = SERVICE_NAME + ".callCode"
 	BROADCAST_TX              = SERVICE_NAME + ".broadcastTx"
+	BROADCAST_TX_BATCH        = SERVICE_NAME + ".broadcastTxBatch"
 	GET_UNCONFIRMED_TXS       = SERVICE_NAME + ".getUnconfirmedTxs"
+	LIST_UNCONFIRMED_TXS      = SERVICE_NAME + ".listUnconfirmedTxs"
+	REMOVE_UNCONFIRMED_TX     = SERVICE_NAME + ".removeUnconfirmedTx"
+	FLUSH_UNCONFIRMED_TXS     = SERVICE_NAME + ".flushUnconfirmedTxs"
+	GET_BASE_FEE              = SERVICE_NAME + ".getBaseFee"
+	TRACE_TX                  = SERVICE_NAME + ".traceTx"
 	SIGN_TX                   = SERVICE_NAME + ".signTx"
+	SIGN_MULTISIG_TX          = SERVICE_NAME + ".signMultisigTx"
+	COMBINE_MULTISIG_TXS      = SERVICE_NAME + ".combineMultisigTxs"
 	TRANSACT                  = SERVICE_NAME + ".transact"
 	TRANSACT_AND_HOLD         = SERVICE_NAME + ".transactAndHold"
 	SEND                      = SERVICE_NAME + ".send"
 	SEND_AND_HOLD             = SERVICE_NAME + ".sendAndHold"
 	TRANSACT_NAMEREG          = SERVICE_NAME + ".transactNameReg"
+	RENEW_NAME                = SERVICE_NAME + ".renewName"
 	EVENT_SUBSCRIBE           = SERVICE_NAME + ".eventSubscribe" // Events
 	EVENT_UNSUBSCRIBE         = SERVICE_NAME + ".eventUnsubscribe"
 	EVENT_POLL                = SERVICE_NAME + ".eventPoll"
+	STREAM_BLOCKS             = SERVICE_NAME + ".streamBlocks" // Execution events
+	STREAM_TXS                = SERVICE_NAME + ".streamTxs"
+	STREAM_EVENTS             = SERVICE_NAME + ".streamEvents"
+	GET_LOGS                  = SERVICE_NAME + ".getLogs"
+	GET_TX_RECEIPT            = SERVICE_NAME + ".getTxReceipt"
+	GET_SENDER_TXS            = SERVICE_NAME + ".getSenderTxs"
 	GET_NAMEREG_ENTRY         = SERVICE_NAME + ".getNameRegEntry" // Namereg
 	GET_NAMEREG_ENTRIES       = SERVICE_NAME + ".getNameRegEntries"
+	RELOAD_LOGGING            = SERVICE_NAME + ".reloadLogging" // Logging
+)
+
+// The number of txs listed by getSenderTxs by default, and at most
+const (
+	defaultSenderTxsLimit = 100
+	maxSenderTxsLimit     = 1000
 )
 
 // The rpc method handlers.
@@ -93,8 +124,13 @@ func (burrowMethods *BurrowMethods) getMethods() map[string]RequestHandlerFunc {
 	// Accounts
 	dhMap[GET_ACCOUNTS] = burrowMethods.Accounts
 	dhMap[GET_ACCOUNT] = burrowMethods.Account
+	dhMap[LIST_ACCOUNTS] = burrowMethods.ListAccounts
 	dhMap[GET_STORAGE] = burrowMethods.AccountStorage
 	dhMap[GET_STORAGE_AT] = burrowMethods.AccountStorageAt
+	dhMap[GET_ACCOUNT_WITH_PROOF] = burrowMethods.AccountWithProof
+	dhMap[GET_STORAGE_AT_WITH_PROOF] = burrowMethods.AccountStorageAtWithProof
+	dhMap[LIST_STORAGE] = burrowMethods.ListStorage
+	dhMap[GET_ABI] = burrowMethods.AccountABI
 	dhMap[GEN_PRIV_ACCOUNT] = burrowMethods.GenPrivAccount
 	dhMap[GEN_PRIV_ACCOUNT_FROM_KEY] = burrowMethods.GenPrivAccountFromKey
 	// Blockchain
@@ -120,16 +156,33 @@ func (burrowMethods *BurrowMethods) getMethods() map[string]RequestHandlerFunc {
 	dhMap[CALL] = burrowMethods.Call
 	dhMap[CALL_CODE] = burrowMethods.CallCode
 	dhMap[BROADCAST_TX] = burrowMethods.BroadcastTx
+	dhMap[BROADCAST_TX_BATCH] = burrowMethods.BroadcastTxBatch
 	dhMap[GET_UNCONFIRMED_TXS] = burrowMethods.UnconfirmedTxs
+	dhMap[LIST_UNCONFIRMED_TXS] = burrowMethods.ListUnconfirmedTxs
+	dhMap[REMOVE_UNCONFIRMED_TX] = burrowMethods.RemoveUnconfirmedTx
+	dhMap[FLUSH_UNCONFIRMED_TXS] = burrowMethods.FlushUnconfirmedTxs
+	dhMap[GET_BASE_FEE] = burrowMethods.BaseFee
+	dhMap[TRACE_TX] = burrowMethods.TraceTx
 	dhMap[SIGN_TX] = burrowMethods.SignTx
+	dhMap[SIGN_MULTISIG_TX] = burrowMethods.SignMultisigTx
+	dhMap[COMBINE_MULTISIG_TXS] = burrowMethods.CombineMultisigTxs
 	dhMap[TRANSACT] = burrowMethods.Transact
 	dhMap[TRANSACT_AND_HOLD] = burrowMethods.TransactAndHold
 	dhMap[SEND] = burrowMethods.Send
 	dhMap[SEND_AND_HOLD] = burrowMethods.SendAndHold
 	dhMap[TRANSACT_NAMEREG] = burrowMethods.TransactNameReg
+	dhMap[RENEW_NAME] = burrowMethods.RenewName
+	// Logs
+	dhMap[GET_LOGS] = burrowMethods.Logs
+	// Receipts
+	dhMap[GET_TX_RECEIPT] = burrowMethods.TxReceipt
+	// Tx index
+	dhMap[GET_SENDER_TXS] = burrowMethods.SenderTxs
 	// Namereg
 	dhMap[GET_NAMEREG_ENTRY] = burrowMethods.NameRegEntry
 	dhMap[GET_NAMEREG_ENTRIES] = burrowMethods.NameRegEntries
+	// Logging
+	dhMap[RELOAD_LOGGING] = burrowMethods.ReloadLogging
 
 	return dhMap
 }
@@ -164,14 +217,15 @@ func (burrowMethods *BurrowMethods) GenPrivAccountFromKey(request *rpc.RPCReques
 }
 
 func (burrowMethods *BurrowMethods) Account(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
-	param := &AddressParam{}
+	param := &AddressAtHeightParam{}
 	err := burrowMethods.codec.DecodeBytes(param, request.Params)
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
 	address := param.Address
 	// TODO is address check?
-	account, errC := burrowMethods.pipe.Accounts().Account(address)
+	account, errC := burrowMethods.pipe.Accounts().HistoricalAccount(address,
+		param.Height)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -191,14 +245,29 @@ func (burrowMethods *BurrowMethods) Accounts(request *rpc.RPCRequest, requester
 	return list, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) ListAccounts(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &ListAccountsParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	page, errC := burrowMethods.pipe.Accounts().ListAccounts(param.Filters,
+		param.PageToken, param.PageSize)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return page, 0, nil
+}
+
 func (burrowMethods *BurrowMethods) AccountStorage(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
-	param := &AddressParam{}
+	param := &AddressAtHeightParam{}
 	err := burrowMethods.codec.DecodeBytes(param, request.Params)
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
 	address := param.Address
-	storage, errC := burrowMethods.pipe.Accounts().Storage(address)
+	storage, errC := burrowMethods.pipe.Accounts().HistoricalStorage(address,
+		param.Height)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -206,20 +275,76 @@ func (burrowMethods *BurrowMethods) AccountStorage(request *rpc.RPCRequest, requ
 }
 
 func (burrowMethods *BurrowMethods) AccountStorageAt(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
-	param := &StorageAtParam{}
+	param := &StorageAtHeightParam{}
 	err := burrowMethods.codec.DecodeBytes(param, request.Params)
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
 	address := param.Address
 	key := param.Key
-	storageItem, errC := burrowMethods.pipe.Accounts().StorageAt(address, key)
+	storageItem, errC := burrowMethods.pipe.Accounts().HistoricalStorageAt(address,
+		key, param.Height)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
 	return storageItem, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) ListStorage(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &ListStorageParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	page, errC := burrowMethods.pipe.Accounts().ListStorage(param.Address,
+		param.PageToken, param.PageSize)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return page, 0, nil
+}
+
+func (burrowMethods *BurrowMethods) AccountWithProof(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &AddressAtHeightParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	accountWithProof, errC := burrowMethods.pipe.Accounts().AccountWithProof(param.Address,
+		param.Height)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return accountWithProof, 0, nil
+}
+
+func (burrowMethods *BurrowMethods) AccountStorageAtWithProof(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &StorageAtHeightParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	itemWithProof, errC := burrowMethods.pipe.Accounts().StorageAtWithProof(param.Address,
+		param.Key, param.Height)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return itemWithProof, 0, nil
+}
+
+func (burrowMethods *BurrowMethods) AccountABI(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &AddressParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	entry, errC := burrowMethods.pipe.Accounts().ABI(param.Address)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return entry, 0, nil
+}
+
 // *************************************** Blockchain ************************************
 
 func (burrowMethods *BurrowMethods) BlockchainInfo(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
@@ -332,10 +457,7 @@ func (burrowMethods *BurrowMethods) Call(request *rpc.RPCRequest, requester inte
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
-	from := param.From
-	to := param.Address
-	data := param.Data
-	call, errC := burrowMethods.pipe.Transactor().Call(from, to, data)
+	call, errC := param.call(burrowMethods.pipe.Transactor())
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -348,16 +470,31 @@ func (burrowMethods *BurrowMethods) CallCode(request *rpc.RPCRequest, requester
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
-	from := param.From
-	code := param.Code
-	data := param.Data
-	call, errC := burrowMethods.pipe.Transactor().CallCode(from, code, data)
+	call, errC := param.call(burrowMethods.pipe.Transactor())
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
 	return call, 0, nil
 }
 
+// Makes the call, against past or overridden state only when a height or
+// overrides are given
+func (param *CallParam) call(transactor definitions.Transactor) (*core_types.Call, error) {
+	if param.Height == 0 && len(param.Overrides) == 0 {
+		return transactor.Call(param.From, param.Address, param.Data, param.Trace)
+	}
+	return transactor.CallWithOverrides(param.From, param.Address, nil,
+		param.Data, param.Height, param.Overrides, param.Trace)
+}
+
+func (param *CallCodeParam) call(transactor definitions.Transactor) (*core_types.Call, error) {
+	if param.Height == 0 && len(param.Overrides) == 0 {
+		return transactor.CallCode(param.From, param.Code, param.Data, param.Trace)
+	}
+	return transactor.CallWithOverrides(param.From, nil, param.Code,
+		param.Data, param.Height, param.Overrides, param.Trace)
+}
+
 func (burrowMethods *BurrowMethods) BroadcastTx(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
 	// Accept all transaction types as parameter for broadcast.
 	param := new(txs.Tx)
@@ -372,6 +509,19 @@ func (burrowMethods *BurrowMethods) BroadcastTx(request *rpc.RPCRequest, request
 	return receipt, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) BroadcastTxBatch(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &BroadcastTxBatchParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	receipts, errC := burrowMethods.pipe.Transactor().BroadcastTxBatch(param.Txs)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return receipts, 0, nil
+}
+
 func (burrowMethods *BurrowMethods) Transact(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
 	param := &TransactParam{}
 	err := burrowMethods.codec.DecodeBytes(param, request.Params)
@@ -437,6 +587,19 @@ func (burrowMethods *BurrowMethods) TransactNameReg(request *rpc.RPCRequest, req
 	return receipt, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) RenewName(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &RenewNameParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	receipt, errC := burrowMethods.pipe.Transactor().RenewName(param.PrivKey, param.Name, param.Amount, param.Fee)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return receipt, 0, nil
+}
+
 func (burrowMethods *BurrowMethods) UnconfirmedTxs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
 	trans, errC := burrowMethods.pipe.GetConsensusEngine().ListUnconfirmedTxs(-1)
 	if errC != nil {
@@ -445,6 +608,55 @@ func (burrowMethods *BurrowMethods) UnconfirmedTxs(request *rpc.RPCRequest, requ
 	return txs.UnconfirmedTxs{trans}, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) ListUnconfirmedTxs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	trans, errC := burrowMethods.pipe.GetConsensusEngine().ListUnconfirmedTxs(-1)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return mempoolTxs(burrowMethods.pipe.Blockchain().ChainId(), trans), 0, nil
+}
+
+func (burrowMethods *BurrowMethods) RemoveUnconfirmedTx(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &TxHashParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	removed := burrowMethods.pipe.GetConsensusEngine().RemoveUnconfirmedTx(param.TxHash)
+	return &core_types.MempoolRemoval{Removed: removed}, 0, nil
+}
+
+func (burrowMethods *BurrowMethods) FlushUnconfirmedTxs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	removed := burrowMethods.pipe.GetConsensusEngine().FlushUnconfirmedTxs()
+	return &core_types.MempoolFlush{Removed: removed}, 0, nil
+}
+
+// Lists the txs in the mempool of the chain with chainID by their hashes
+func mempoolTxs(chainID string, unconfirmed []txs.Tx) *txs.MempoolTxs {
+	mempoolTxs := &txs.MempoolTxs{Txs: make([]*txs.MempoolTx, len(unconfirmed))}
+	for i, tx := range unconfirmed {
+		mempoolTxs.Txs[i] = &txs.MempoolTx{TxHash: txs.TxHash(chainID, tx), Tx: tx}
+	}
+	return mempoolTxs
+}
+
+func (burrowMethods *BurrowMethods) BaseFee(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	return &core_types.BaseFee{burrowMethods.pipe.Transactor().BaseFee()}, 0, nil
+}
+
+func (burrowMethods *BurrowMethods) TraceTx(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &TxHashParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	trace, errC := burrowMethods.pipe.Transactor().TraceTx(param.TxHash)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return trace, 0, nil
+}
+
 func (burrowMethods *BurrowMethods) SignTx(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
 	param := &SignTxParam{}
 	err := burrowMethods.codec.DecodeBytes(param, request.Params)
@@ -460,6 +672,108 @@ func (burrowMethods *BurrowMethods) SignTx(request *rpc.RPCRequest, requester in
 	return txRet, 0, nil
 }
 
+func (burrowMethods *BurrowMethods) SignMultisigTx(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &SignMultisigTxParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	if param.Tx == nil {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("No multisig tx to sign")
+	}
+	txRet, errC := burrowMethods.pipe.Transactor().SignTx(param.Tx, param.PrivAccounts)
+	if errC != nil {
+		return nil, rpc.INVALID_PARAMS, errC
+	}
+	return txRet, 0, nil
+}
+
+// Combines the signatures of copies of a multisig tx signed by different
+// signatories
+func (burrowMethods *BurrowMethods) CombineMultisigTxs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &CombineMultisigTxsParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	if len(param.Txs) == 0 || param.Txs[0] == nil {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("No multisig txs to combine")
+	}
+	combined := param.Txs[0]
+	for i, tx := range param.Txs[1:] {
+		if tx == nil {
+			return nil, rpc.INVALID_PARAMS, fmt.Errorf("Multisig tx %v is empty", i+1)
+		}
+		if err := combined.Combine(tx); err != nil {
+			return nil, rpc.INVALID_PARAMS, fmt.Errorf("Multisig tx %v: %v", i+1, err)
+		}
+	}
+	return combined, 0, nil
+}
+
+// *************************************** Logs ***************************************
+
+func (burrowMethods *BurrowMethods) Logs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &LogsParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	logs, errC := burrowMethods.pipe.Logs().Logs(param.Address, param.Topic,
+		param.MinHeight, param.MaxHeight)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return &event.LogList{logs}, 0, nil
+}
+
+// *************************************** Receipts ***************************************
+
+func (burrowMethods *BurrowMethods) TxReceipt(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &TxReceiptParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	receipt, errC := burrowMethods.pipe.Receipts().TxReceipt(param.TxHash, param.ABI)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return receipt, 0, nil
+}
+
+// *************************************** Tx index ***************************************
+
+func (burrowMethods *BurrowMethods) SenderTxs(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	param := &SenderTxsParam{}
+	err := burrowMethods.codec.DecodeBytes(param, request.Params)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	limit, err := senderTxsLimit(param.Limit)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
+	}
+	senderTxs, errC := burrowMethods.pipe.TxIndex().SenderTxs(param.Address, limit)
+	if errC != nil {
+		return nil, rpc.INTERNAL_ERROR, errC
+	}
+	return senderTxs, 0, nil
+}
+
+// The number of txs to list for a requested limit of them, where zero means
+// the default
+func senderTxsLimit(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return defaultSenderTxsLimit, nil
+	case limit < 0 || limit > maxSenderTxsLimit:
+		return 0, fmt.Errorf("The limit of txs must be between 1 and %v",
+			maxSenderTxsLimit)
+	}
+	return limit, nil
+}
+
 // *************************************** Name Registry ***************************************
 
 func (burrowMethods *BurrowMethods) NameRegEntry(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
@@ -470,7 +784,7 @@ func (burrowMethods *BurrowMethods) NameRegEntry(request *rpc.RPCRequest, reques
 	}
 	name := param.Name
 	// TODO is address check?
-	entry, errC := burrowMethods.pipe.NameReg().Entry(name)
+	entry, errC := burrowMethods.pipe.NameReg().HistoricalEntry(name, param.Height)
 	if errC != nil {
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
@@ -489,3 +803,14 @@ func (burrowMethods *BurrowMethods) NameRegEntries(request *rpc.RPCRequest, requ
 	}
 	return list, 0, nil
 }
+
+// *************************************** Logging ***************************************
+
+// Rereads the logging config from the node's config file, as on SIGHUP
+func (burrowMethods *BurrowMethods) ReloadLogging(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
+	err := lifecycle.ReloadLogging()
+	if err != nil {
+		return nil, rpc.INTERNAL_ERROR, err
+	}
+	return &core_types.LoggingReload{Result: true}, 0, nil
+}
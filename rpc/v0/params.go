@@ -16,6 +16,7 @@ package v0
 
 import (
 	"github.com/hyperledger/burrow/account"
+	core_types "github.com/hyperledger/burrow/core/types"
 	event "github.com/hyperledger/burrow/event"
 	"github.com/hyperledger/burrow/txs"
 )
@@ -49,6 +50,34 @@ type (
 		Key     []byte `json:"key"`
 	}
 
+	// Get an account as committed at a height, 0 for the latest
+	AddressAtHeightParam struct {
+		Address []byte `json:"address"`
+		Height  int    `json:"height"`
+	}
+
+	// StorageAt as committed at a height, 0 for the latest
+	StorageAtHeightParam struct {
+		Address []byte `json:"address"`
+		Key     []byte `json:"key"`
+		Height  int    `json:"height"`
+	}
+
+	// Get a page of the accounts that match the filters, starting after
+	// PageToken if it is not empty
+	ListAccountsParam struct {
+		Filters   []*event.FilterData `json:"filters"`
+		PageToken []byte              `json:"page_token"`
+		PageSize  int                 `json:"page_size"`
+	}
+
+	// Get a page of storage, starting after PageToken if it is not empty
+	ListStorageParam struct {
+		Address   []byte `json:"address"`
+		PageToken []byte `json:"page_token"`
+		PageSize  int    `json:"page_size"`
+	}
+
 	// Get a block
 	HeightParam struct {
 		Height int `json:"height"`
@@ -63,6 +92,10 @@ type (
 	// Event Id
 	EventIdParam struct {
 		EventId string `json:"event_id"`
+		// Optional per-subscription overrides of the server's event buffering,
+		// where a buffer size of zero asks for synchronous delivery
+		BufferSize     *int   `json:"buffer_size,omitempty"`
+		OverflowPolicy string `json:"overflow_policy,omitempty"`
 	}
 
 	// Event Id
@@ -70,22 +103,79 @@ type (
 		SubId string `json:"sub_id"`
 	}
 
+	// Used to stream execution records over the heights MinHeight to MaxHeight
+	// inclusive, a MaxHeight of zero follows the chain tip indefinitely. EventId
+	// is only used when streaming events and may be a query.
+	StreamParam struct {
+		MinHeight int    `json:"min_height"`
+		MaxHeight int    `json:"max_height"`
+		EventId   string `json:"event_id,omitempty"`
+	}
+
+	// Used to look up logs by emitting address and/or topic between MinHeight
+	// and MaxHeight inclusive, a MaxHeight of zero means up to the latest block
+	LogsParam struct {
+		Address   []byte `json:"address"`
+		Topic     []byte `json:"topic"`
+		MinHeight int64  `json:"min_height"`
+		MaxHeight int64  `json:"max_height"`
+	}
+
+	// Used to list the most recent txs signed by Address, up to Limit of them
+	// or a default number if it is zero
+	SenderTxsParam struct {
+		Address []byte `json:"address"`
+		Limit   int    `json:"limit"`
+	}
+
+	// Used when polling a subscription, events after AfterCursor are returned,
+	// or those since the last poll when it is omitted
+	EventPollParam struct {
+		SubId       string  `json:"sub_id"`
+		AfterCursor *uint64 `json:"after_cursor,omitempty"`
+	}
+
 	PeerParam struct {
 		Address string `json:"address"`
 	}
 
 	// Used when doing calls
+	// Calls are made against the state as committed at Height, or the latest
+	// state if it is zero, with Overrides applied to it
 	CallParam struct {
-		Address []byte `json:"address"`
-		From    []byte `json:"from"`
-		Data    []byte `json:"data"`
+		Address   []byte                        `json:"address"`
+		From      []byte                        `json:"from"`
+		Data      []byte                        `json:"data"`
+		Trace     bool                          `json:"trace"`
+		Height    int                           `json:"height"`
+		Overrides []*core_types.AccountOverride `json:"overrides"`
 	}
 
-	// Used when doing code calls
+	// Used when doing code calls, with Height and Overrides as for CallParam
 	CallCodeParam struct {
-		From []byte `json:"from"`
-		Code []byte `json:"code"`
-		Data []byte `json:"data"`
+		From      []byte                        `json:"from"`
+		Code      []byte                        `json:"code"`
+		Data      []byte                        `json:"data"`
+		Trace     bool                          `json:"trace"`
+		Height    int                           `json:"height"`
+		Overrides []*core_types.AccountOverride `json:"overrides"`
+	}
+
+	// Used when getting the trace of a tx
+	TxHashParam struct {
+		TxHash []byte `json:"tx_hash"`
+	}
+
+	// Used when getting the receipt of a tx, with the JSON ABI of the contracts
+	// its logs are decoded against, if any
+	TxReceiptParam struct {
+		TxHash []byte `json:"tx_hash"`
+		ABI    string `json:"abi"`
+	}
+
+	// Used when broadcasting signed txs in order
+	BroadcastTxBatchParam struct {
+		Txs []txs.Tx `json:"txs"`
 	}
 
 	// Used when signing a tx. Uses placeholders just like TxParam
@@ -94,6 +184,17 @@ type (
 		PrivAccounts []*account.PrivAccount `json:"priv_accounts"`
 	}
 
+	// Used when signing a multisig tx as some of its signatories
+	SignMultisigTxParam struct {
+		Tx           *txs.MultisigTx        `json:"tx"`
+		PrivAccounts []*account.PrivAccount `json:"priv_accounts"`
+	}
+
+	// Used when combining the signatures of copies of a multisig tx
+	CombineMultisigTxsParam struct {
+		Txs []*txs.MultisigTx `json:"txs"`
+	}
+
 	// Used when sending a transaction to be created and signed on the server
 	// (using the private key). This only uses the standard key type for now.
 	TransactParam struct {
@@ -111,8 +212,10 @@ type (
 		Amount    int64  `json:"amount"`
 	}
 
+	// Used for getting a name entry as committed at a height, 0 for the latest
 	NameRegEntryParam struct {
-		Name string `json:"name"`
+		Name   string `json:"name"`
+		Height int    `json:"height"`
 	}
 
 	// Used when sending a namereg transaction to be created and signed on the server
@@ -124,4 +227,13 @@ type (
 		Fee     int64  `json:"fee"`
 		Amount  int64  `json:"amount"`
 	}
+
+	// Used when renewing a namereg entry with a tx created and signed on the
+	// server (using the private key of its owner)
+	RenewNameParam struct {
+		PrivKey []byte `json:"priv_key"`
+		Name    string `json:"name"`
+		Fee     int64  `json:"fee"`
+		Amount  int64  `json:"amount"`
+	}
 )
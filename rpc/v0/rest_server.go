@@ -15,6 +15,7 @@
 package v0
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"strconv"
@@ -58,47 +59,107 @@ func NewRestServer(codec rpc.Codec, pipe definitions.Pipe,
 // Starting the server means registering all the handlers with the router.
 func (restServer *RestServer) Start(config *server.ServerConfig, router *gin.Engine) {
 	// Accounts
-	router.GET("/accounts", parseSearchQuery, restServer.handleAccounts)
-	router.GET("/accounts/:address", addressParam, restServer.handleAccount)
-	router.GET("/accounts/:address/storage", addressParam, restServer.handleStorage)
-	router.GET("/accounts/:address/storage/:key", addressParam, keyParam, restServer.handleStorageAt)
+	router.GET("/accounts", authorize(GET_ACCOUNTS), parseSearchQuery,
+		restServer.handleAccounts)
+	router.GET("/accounts_list", authorize(LIST_ACCOUNTS), parseSearchQuery,
+		parsePageQuery, restServer.handleListAccounts)
+	router.GET("/accounts/:address", authorize(GET_ACCOUNT), addressParam,
+		parseHeightQuery, restServer.handleAccount)
+	router.GET("/accounts/:address/storage", authorize(GET_STORAGE), addressParam,
+		parseHeightQuery, restServer.handleStorage)
+	router.GET("/accounts/:address/storage/:key", authorize(GET_STORAGE_AT),
+		addressParam, keyParam, parseHeightQuery, restServer.handleStorageAt)
+	router.GET("/accounts/:address/storage_list", authorize(LIST_STORAGE),
+		addressParam, parsePageQuery, restServer.handleListStorage)
+	router.GET("/accounts/:address/proof", authorize(GET_ACCOUNT_WITH_PROOF),
+		addressParam, parseHeightQuery, restServer.handleAccountWithProof)
+	router.GET("/accounts/:address/storage/:key/proof",
+		authorize(GET_STORAGE_AT_WITH_PROOF), addressParam, keyParam,
+		parseHeightQuery, restServer.handleStorageAtWithProof)
+	router.GET("/accounts/:address/abi", authorize(GET_ABI), addressParam,
+		restServer.handleABI)
+	router.GET("/accounts/:address/txs", authorize(GET_SENDER_TXS), addressParam,
+		restServer.handleSenderTxs)
+	// State
+	router.GET("/state/dump", authorize(DUMP_STATE), parseHeightQuery,
+		restServer.handleDumpState)
 	// Blockchain
-	router.GET("/blockchain", restServer.handleBlockchainInfo)
-	router.GET("/blockchain/chain_id", restServer.handleChainId)
-	router.GET("/blockchain/genesis_hash", restServer.handleGenesisHash)
-	router.GET("/blockchain/latest_block_height", restServer.handleLatestBlockHeight)
-	router.GET("/blockchain/latest_block", restServer.handleLatestBlock)
-	router.GET("/blockchain/blocks", parseSearchQuery, restServer.handleBlocks)
-	router.GET("/blockchain/block/:height", heightParam, restServer.handleBlock)
+	router.GET("/blockchain", authorize(GET_BLOCKCHAIN_INFO),
+		restServer.handleBlockchainInfo)
+	router.GET("/blockchain/chain_id", authorize(GET_CHAIN_ID),
+		restServer.handleChainId)
+	router.GET("/blockchain/genesis_hash", authorize(GET_GENESIS_HASH),
+		restServer.handleGenesisHash)
+	router.GET("/blockchain/latest_block_height",
+		authorize(GET_LATEST_BLOCK_HEIGHT), restServer.handleLatestBlockHeight)
+	router.GET("/blockchain/latest_block", authorize(GET_LATEST_BLOCK),
+		restServer.handleLatestBlock)
+	router.GET("/blockchain/blocks", authorize(GET_BLOCKS), parseSearchQuery,
+		restServer.handleBlocks)
+	router.GET("/blockchain/block/:height", authorize(GET_BLOCK), heightParam,
+		restServer.handleBlock)
 	// Consensus
-	router.GET("/consensus", restServer.handleConsensusState)
-	router.GET("/consensus/validators", restServer.handleValidatorList)
+	router.GET("/consensus", authorize(GET_CONSENSUS_STATE),
+		restServer.handleConsensusState)
+	router.GET("/consensus/validators", authorize(GET_VALIDATORS),
+		restServer.handleValidatorList)
 	// Events
-	router.POST("/event_subs", restServer.handleEventSubscribe)
-	router.GET("/event_subs/:id", restServer.handleEventPoll)
-	router.DELETE("/event_subs/:id", restServer.handleEventUnsubscribe)
+	router.POST("/event_subs", authorize(EVENT_SUBSCRIBE),
+		restServer.handleEventSubscribe)
+	router.GET("/event_subs/:id", authorize(EVENT_POLL), subIdParam,
+		parseAfterCursor, restServer.handleEventPoll)
+	router.DELETE("/event_subs/:id", authorize(EVENT_UNSUBSCRIBE), subIdParam,
+		restServer.handleEventUnsubscribe)
+	// Logs
+	router.GET("/logs", authorize(GET_LOGS), parseLogsQuery, restServer.handleLogs)
+	// Receipts
+	router.GET("/receipts/:hash", authorize(GET_TX_RECEIPT), txHashParam,
+		restServer.handleTxReceipt)
 	// NameReg
-	router.GET("/namereg", parseSearchQuery, restServer.handleNameRegEntries)
-	router.GET("/namereg/:key", nameParam, restServer.handleNameRegEntry)
+	router.GET("/namereg", authorize(GET_NAMEREG_ENTRIES), parseSearchQuery,
+		restServer.handleNameRegEntries)
+	router.GET("/namereg/:key", authorize(GET_NAMEREG_ENTRY), nameParam,
+		parseHeightQuery, restServer.handleNameRegEntry)
 	// Network
-	router.GET("/network", restServer.handleNetworkInfo)
-	router.GET("/network/client_version", restServer.handleClientVersion)
-	router.GET("/network/moniker", restServer.handleMoniker)
-	router.GET("/network/listening", restServer.handleListening)
-	router.GET("/network/listeners", restServer.handleListeners)
-	router.GET("/network/peers", restServer.handlePeers)
-	router.GET("/network/peers/:address", peerAddressParam, restServer.handlePeer)
+	router.GET("/network", authorize(GET_NETWORK_INFO),
+		restServer.handleNetworkInfo)
+	router.GET("/network/client_version", authorize(GET_CLIENT_VERSION),
+		restServer.handleClientVersion)
+	router.GET("/network/moniker", authorize(GET_MONIKER), restServer.handleMoniker)
+	router.GET("/network/listening", authorize(IS_LISTENING),
+		restServer.handleListening)
+	router.GET("/network/listeners", authorize(GET_LISTENERS),
+		restServer.handleListeners)
+	router.GET("/network/peers", authorize(GET_PEERS), restServer.handlePeers)
+	router.GET("/network/peers/:address", authorize(GET_PEER), peerAddressParam,
+		restServer.handlePeer)
 	// Tx related (TODO get txs has still not been implemented)
-	router.POST("/txpool", restServer.handleBroadcastTx)
-	router.GET("/txpool", restServer.handleUnconfirmedTxs)
+	router.POST("/txpool", authorize(BROADCAST_TX), restServer.handleBroadcastTx)
+	router.GET("/txpool", authorize(GET_UNCONFIRMED_TXS),
+		restServer.handleUnconfirmedTxs)
+	router.GET("/txpool/base_fee", authorize(GET_BASE_FEE),
+		restServer.handleBaseFee)
+	router.GET("/mempool", authorize(LIST_UNCONFIRMED_TXS),
+		restServer.handleListUnconfirmedTxs)
+	router.DELETE("/mempool/:hash", authorize(REMOVE_UNCONFIRMED_TX),
+		txHashParam, restServer.handleRemoveUnconfirmedTx)
+	router.DELETE("/mempool", authorize(FLUSH_UNCONFIRMED_TXS),
+		restServer.handleFlushUnconfirmedTxs)
 	// Code execution
-	router.POST("/calls", restServer.handleCall)
-	router.POST("/codecalls", restServer.handleCallCode)
+	router.POST("/calls", authorize(CALL), restServer.handleCall)
+	router.POST("/codecalls", authorize(CALL_CODE), restServer.handleCallCode)
+	router.GET("/traces/:hash", authorize(TRACE_TX), txHashParam,
+		restServer.handleTraceTx)
 	// Unsafe
-	router.GET("/unsafe/pa_generator", restServer.handleGenPrivAcc)
-	router.POST("/unsafe/txpool", parseTxModifier, restServer.handleTransact)
-	router.POST("/unsafe/namereg/txpool", restServer.handleTransactNameReg)
-	router.POST("/unsafe/tx_signer", restServer.handleSignTx)
+	router.GET("/unsafe/pa_generator", authorize(GEN_PRIV_ACCOUNT),
+		restServer.handleGenPrivAcc)
+	router.POST("/unsafe/txpool", authorize(TRANSACT), parseTxModifier,
+		restServer.handleTransact)
+	router.POST("/unsafe/namereg/txpool", authorize(TRANSACT_NAMEREG),
+		restServer.handleTransactNameReg)
+	router.POST("/unsafe/namereg/renew", authorize(RENEW_NAME),
+		restServer.handleRenewName)
+	router.POST("/unsafe/tx_signer", authorize(SIGN_TX), restServer.handleSignTx)
 	restServer.running = true
 }
 
@@ -145,11 +206,30 @@ func (restServer *RestServer) handleAccounts(c *gin.Context) {
 	restServer.codec.Encode(accs, c.Writer)
 }
 
+func (restServer *RestServer) handleListAccounts(c *gin.Context) {
+	var filters []*event.FilterData
+	fs, exists := c.Get("filters")
+	if exists {
+		filters = fs.([]*event.FilterData)
+	}
+	pageToken := c.MustGet("pageToken").([]byte)
+	pageSize := c.MustGet("pageSize").(int)
+	page, err := restServer.pipe.Accounts().ListAccounts(filters, pageToken, pageSize)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(page, c.Writer)
+}
+
 func (restServer *RestServer) handleAccount(c *gin.Context) {
 	addr := c.MustGet("addrBts").([]byte)
-	acc, err := restServer.pipe.Accounts().Account(addr)
+	height := c.MustGet("height").(int)
+	acc, err := restServer.pipe.Accounts().HistoricalAccount(addr, height)
 	if err != nil {
 		c.AbortWithError(500, err)
+		return
 	}
 	c.Writer.WriteHeader(200)
 	restServer.codec.Encode(acc, c.Writer)
@@ -157,25 +237,110 @@ func (restServer *RestServer) handleAccount(c *gin.Context) {
 
 func (restServer *RestServer) handleStorage(c *gin.Context) {
 	addr := c.MustGet("addrBts").([]byte)
-	s, err := restServer.pipe.Accounts().Storage(addr)
+	height := c.MustGet("height").(int)
+	s, err := restServer.pipe.Accounts().HistoricalStorage(addr, height)
 	if err != nil {
 		c.AbortWithError(500, err)
+		return
 	}
 	c.Writer.WriteHeader(200)
 	restServer.codec.Encode(s, c.Writer)
 }
 
+func (restServer *RestServer) handleABI(c *gin.Context) {
+	addr := c.MustGet("addrBts").([]byte)
+	entry, err := restServer.pipe.Accounts().ABI(addr)
+	if err != nil {
+		c.AbortWithError(404, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(entry, c.Writer)
+}
+
 func (restServer *RestServer) handleStorageAt(c *gin.Context) {
 	addr := c.MustGet("addrBts").([]byte)
 	key := c.MustGet("keyBts").([]byte)
-	sa, err := restServer.pipe.Accounts().StorageAt(addr, key)
+	height := c.MustGet("height").(int)
+	sa, err := restServer.pipe.Accounts().HistoricalStorageAt(addr, key, height)
 	if err != nil {
 		c.AbortWithError(500, err)
+		return
 	}
 	c.Writer.WriteHeader(200)
 	restServer.codec.Encode(sa, c.Writer)
 }
 
+func (restServer *RestServer) handleListStorage(c *gin.Context) {
+	addr := c.MustGet("addrBts").([]byte)
+	pageToken := c.MustGet("pageToken").([]byte)
+	pageSize := c.MustGet("pageSize").(int)
+	page, err := restServer.pipe.Accounts().ListStorage(addr, pageToken, pageSize)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(page, c.Writer)
+}
+
+func (restServer *RestServer) handleAccountWithProof(c *gin.Context) {
+	addr := c.MustGet("addrBts").([]byte)
+	height := c.MustGet("height").(int)
+	accountWithProof, err := restServer.pipe.Accounts().AccountWithProof(addr, height)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(accountWithProof, c.Writer)
+}
+
+func (restServer *RestServer) handleStorageAtWithProof(c *gin.Context) {
+	addr := c.MustGet("addrBts").([]byte)
+	key := c.MustGet("keyBts").([]byte)
+	height := c.MustGet("height").(int)
+	itemWithProof, err := restServer.pipe.Accounts().StorageAtWithProof(addr, key, height)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(itemWithProof, c.Writer)
+}
+
+// ********************************* State *********************************
+
+// Streams the dump as it is written. The status goes out with the first
+// record, so an error after that can only cut the dump short.
+func (restServer *RestServer) handleDumpState(c *gin.Context) {
+	height := c.MustGet("height").(int)
+	w := &dumpWriter{c: c}
+	if err := restServer.pipe.Accounts().Dump(height, w); err != nil {
+		if !w.written {
+			c.AbortWithError(500, err)
+			return
+		}
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// Writes the header of a dump with its first record
+type dumpWriter struct {
+	c       *gin.Context
+	written bool
+}
+
+func (w *dumpWriter) Write(bs []byte) (int, error) {
+	if !w.written {
+		w.written = true
+		w.c.Header("Content-Type", "application/x-ndjson")
+		w.c.Writer.WriteHeader(200)
+	}
+	return w.c.Writer.Write(bs)
+}
+
 // ********************************* Blockchain *********************************
 
 func (restServer *RestServer) handleBlockchainInfo(c *gin.Context) {
@@ -254,7 +419,7 @@ func (restServer *RestServer) handleEventSubscribe(c *gin.Context) {
 	if errD != nil {
 		c.AbortWithError(500, errD)
 	}
-	subId, err := restServer.eventSubs.Add(param.EventId)
+	subId, err := restServer.eventSubs.Add(context.Background(), param.EventId)
 	if err != nil {
 		c.AbortWithError(500, err)
 	}
@@ -264,12 +429,14 @@ func (restServer *RestServer) handleEventSubscribe(c *gin.Context) {
 
 func (restServer *RestServer) handleEventPoll(c *gin.Context) {
 	subId := c.MustGet("id").(string)
-	data, err := restServer.eventSubs.Poll(subId)
+	afterCursor := c.MustGet("after_cursor").(*uint64)
+	data, cursor, err := restServer.eventSubs.Poll(subId, afterCursor)
 	if err != nil {
 		c.AbortWithError(500, err)
+		return
 	}
 	c.Writer.WriteHeader(200)
-	restServer.codec.Encode(&event.PollResponse{data}, c.Writer)
+	restServer.codec.Encode(&event.PollResponse{data, cursor}, c.Writer)
 }
 
 func (restServer *RestServer) handleEventUnsubscribe(c *gin.Context) {
@@ -298,11 +465,66 @@ func (restServer *RestServer) handleNameRegEntries(c *gin.Context) {
 	restServer.codec.Encode(entries, c.Writer)
 }
 
+// ********************************* Logs *********************************
+
+func (restServer *RestServer) handleLogs(c *gin.Context) {
+	param := c.MustGet("logsParam").(*LogsParam)
+	logs, err := restServer.pipe.Logs().Logs(param.Address, param.Topic,
+		param.MinHeight, param.MaxHeight)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(&event.LogList{logs}, c.Writer)
+}
+
+// ********************************* Receipts *********************************
+
+func (restServer *RestServer) handleTxReceipt(c *gin.Context) {
+	txHash := c.MustGet("txHash").([]byte)
+	receipt, err := restServer.pipe.Receipts().TxReceipt(txHash, c.Query("abi"))
+	if err != nil {
+		c.AbortWithError(404, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(receipt, c.Writer)
+}
+
+// ********************************* Tx index *********************************
+
+func (restServer *RestServer) handleSenderTxs(c *gin.Context) {
+	address := c.MustGet("addrBts").([]byte)
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.AbortWithError(400, err)
+			return
+		}
+	}
+	limit, err := senderTxsLimit(limit)
+	if err != nil {
+		c.AbortWithError(400, err)
+		return
+	}
+	senderTxs, err := restServer.pipe.TxIndex().SenderTxs(address, limit)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(senderTxs, c.Writer)
+}
+
 func (restServer *RestServer) handleNameRegEntry(c *gin.Context) {
 	name := c.MustGet("name").(string)
-	entry, err := restServer.pipe.NameReg().Entry(name)
+	height := c.MustGet("height").(int)
+	entry, err := restServer.pipe.NameReg().HistoricalEntry(name, height)
 	if err != nil {
 		c.AbortWithError(500, err)
+		return
 	}
 	c.Writer.WriteHeader(200)
 	restServer.codec.Encode(entry, c.Writer)
@@ -378,13 +600,42 @@ func (restServer *RestServer) handleUnconfirmedTxs(c *gin.Context) {
 	restServer.codec.Encode(txs.UnconfirmedTxs{trans}, c.Writer)
 }
 
+func (restServer *RestServer) handleListUnconfirmedTxs(c *gin.Context) {
+	trans, err := restServer.pipe.GetConsensusEngine().ListUnconfirmedTxs(-1)
+	if err != nil {
+		c.AbortWithError(500, err)
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(mempoolTxs(restServer.pipe.Blockchain().ChainId(),
+		trans), c.Writer)
+}
+
+func (restServer *RestServer) handleRemoveUnconfirmedTx(c *gin.Context) {
+	txHash := c.MustGet("txHash").([]byte)
+	removed := restServer.pipe.GetConsensusEngine().RemoveUnconfirmedTx(txHash)
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(&core_types.MempoolRemoval{Removed: removed}, c.Writer)
+}
+
+func (restServer *RestServer) handleFlushUnconfirmedTxs(c *gin.Context) {
+	removed := restServer.pipe.GetConsensusEngine().FlushUnconfirmedTxs()
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(&core_types.MempoolFlush{Removed: removed}, c.Writer)
+}
+
+func (restServer *RestServer) handleBaseFee(c *gin.Context) {
+	baseFee := restServer.pipe.Transactor().BaseFee()
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(&core_types.BaseFee{baseFee}, c.Writer)
+}
+
 func (restServer *RestServer) handleCall(c *gin.Context) {
 	param := &CallParam{}
 	errD := restServer.codec.Decode(param, c.Request.Body)
 	if errD != nil {
 		c.AbortWithError(500, errD)
 	}
-	call, err := restServer.pipe.Transactor().Call(param.From, param.Address, param.Data)
+	call, err := param.call(restServer.pipe.Transactor())
 	if err != nil {
 		c.AbortWithError(500, err)
 	}
@@ -398,7 +649,7 @@ func (restServer *RestServer) handleCallCode(c *gin.Context) {
 	if errD != nil {
 		c.AbortWithError(500, errD)
 	}
-	call, err := restServer.pipe.Transactor().CallCode(param.From, param.Code, param.Data)
+	call, err := param.call(restServer.pipe.Transactor())
 	if err != nil {
 		c.AbortWithError(500, err)
 	}
@@ -406,6 +657,16 @@ func (restServer *RestServer) handleCallCode(c *gin.Context) {
 	restServer.codec.Encode(call, c.Writer)
 }
 
+func (restServer *RestServer) handleTraceTx(c *gin.Context) {
+	txHash := c.MustGet("txHash").([]byte)
+	trace, err := restServer.pipe.Transactor().TraceTx(txHash)
+	if err != nil {
+		c.AbortWithError(404, err)
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(trace, c.Writer)
+}
+
 func (restServer *RestServer) handleTransact(c *gin.Context) {
 
 	_, hold := c.Get("hold")
@@ -446,6 +707,20 @@ func (restServer *RestServer) handleTransactNameReg(c *gin.Context) {
 	restServer.codec.Encode(receipt, c.Writer)
 }
 
+func (restServer *RestServer) handleRenewName(c *gin.Context) {
+	param := &RenewNameParam{}
+	errD := restServer.codec.Decode(param, c.Request.Body)
+	if errD != nil {
+		c.AbortWithError(500, errD)
+	}
+	receipt, err := restServer.pipe.Transactor().RenewName(param.PrivKey, param.Name, param.Amount, param.Fee)
+	if err != nil {
+		c.AbortWithError(500, err)
+	}
+	c.Writer.WriteHeader(200)
+	restServer.codec.Encode(receipt, c.Writer)
+}
+
 func (restServer *RestServer) handleSignTx(c *gin.Context) {
 	param := &SignTxParam{}
 	errD := restServer.codec.Decode(param, c.Request.Body)
@@ -462,6 +737,15 @@ func (restServer *RestServer) handleSignTx(c *gin.Context) {
 
 // ********************************* Middleware *********************************
 
+// Checks the client may call the JSON-RPC method equivalent to the route
+func authorize(method string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := server.Authorize(c.Request.Context(), method); err != nil {
+			c.AbortWithError(403, err)
+		}
+	}
+}
+
 func addressParam(c *gin.Context) {
 	addr := c.Param("address")
 	if !util.IsAddress(addr) {
@@ -488,6 +772,15 @@ func keyParam(c *gin.Context) {
 	c.Next()
 }
 
+func txHashParam(c *gin.Context) {
+	txHash, err := hex.DecodeString(c.Param("hash"))
+	if err != nil {
+		c.AbortWithError(400, err)
+	}
+	c.Set("txHash", txHash)
+	c.Next()
+}
+
 func heightParam(c *gin.Context) {
 	h, err := strconv.Atoi(c.Param("height"))
 	if err != nil {
@@ -500,6 +793,38 @@ func heightParam(c *gin.Context) {
 	c.Next()
 }
 
+// Reads an optional height from the query, defaulting to 0 for the latest
+func parseHeightQuery(c *gin.Context) {
+	height := 0
+	if value := c.Query("height"); value != "" {
+		var err error
+		if height, err = strconv.Atoi(value); err != nil || height < 0 {
+			c.AbortWithError(400, fmt.Errorf("Malformed height: %s", value))
+			return
+		}
+	}
+	c.Set("height", height)
+	c.Next()
+}
+
+func parsePageQuery(c *gin.Context) {
+	pageToken, err := hex.DecodeString(c.Query("page_token"))
+	if err != nil {
+		c.AbortWithError(400, fmt.Errorf("Malformed page token: %v", err))
+		return
+	}
+	pageSize := 0
+	if value := c.Query("page_size"); value != "" {
+		if pageSize, err = strconv.Atoi(value); err != nil || pageSize < 1 {
+			c.AbortWithError(400, fmt.Errorf("Malformed page size: %s", value))
+			return
+		}
+	}
+	c.Set("pageToken", pageToken)
+	c.Set("pageSize", pageSize)
+	c.Next()
+}
+
 // TODO
 func peerAddressParam(c *gin.Context) {
 	subId := c.Param("address")
@@ -520,6 +845,52 @@ func parseTxModifier(c *gin.Context) {
 	}
 }
 
+func subIdParam(c *gin.Context) {
+	subId := c.Param("id")
+	c.Set("id", subId)
+	c.Next()
+}
+
+func parseAfterCursor(c *gin.Context) {
+	afterCursor := c.Query("after_cursor")
+	if afterCursor == "" {
+		c.Set("after_cursor", (*uint64)(nil))
+		return
+	}
+	cursor, err := strconv.ParseUint(afterCursor, 10, 64)
+	if err != nil {
+		c.AbortWithError(400, fmt.Errorf("after_cursor must be a non-negative "+
+			"integer, found: %s", afterCursor))
+		return
+	}
+	c.Set("after_cursor", &cursor)
+}
+
+func parseLogsQuery(c *gin.Context) {
+	param := &LogsParam{}
+	var err error
+	if param.Address, err = hex.DecodeString(c.Query("address")); err != nil {
+		c.AbortWithError(400, fmt.Errorf("Malformed address: %v", err))
+		return
+	}
+	if param.Topic, err = hex.DecodeString(c.Query("topic")); err != nil {
+		c.AbortWithError(400, fmt.Errorf("Malformed topic: %v", err))
+		return
+	}
+	for name, height := range map[string]*int64{
+		"min_height": &param.MinHeight,
+		"max_height": &param.MaxHeight,
+	} {
+		if value := c.Query(name); value != "" {
+			if *height, err = strconv.ParseInt(value, 10, 64); err != nil {
+				c.AbortWithError(400, fmt.Errorf("Malformed %s: %v", name, err))
+				return
+			}
+		}
+	}
+	c.Set("logsParam", param)
+}
+
 func parseSearchQuery(c *gin.Context) {
 	q := c.Query("q")
 	if q != "" {
@@ -15,7 +15,9 @@
 package v0
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	account "github.com/hyperledger/burrow/account"
 	core_types "github.com/hyperledger/burrow/core/types"
@@ -42,6 +44,9 @@ type MockPipe struct {
 	blockchain      blockchain_types.Blockchain
 	consensusEngine consensus_types.ConsensusEngine
 	events          event.EventEmitter
+	logs            definitions.Logs
+	receipts        definitions.Receipts
+	txIndex         definitions.TxIndex
 	namereg         definitions.NameReg
 	transactor      definitions.Transactor
 	logger          logging_types.InfoTraceLogger
@@ -55,6 +60,9 @@ func NewMockPipe(td *TestData) definitions.Pipe {
 		blockchain:      &chain{td},
 		consensusEngine: &consensusEngine{td},
 		events:          &eventer{td},
+		logs:            &logs{td},
+		receipts:        &receipts{td},
+		txIndex:         &txIndex{td},
 		namereg:         &namereg{td},
 		transactor:      &transactor{td},
 		logger:          loggers.NewNoopInfoTraceLogger(),
@@ -78,6 +86,18 @@ func (pipe *MockPipe) Events() event.EventEmitter {
 	return pipe.events
 }
 
+func (pipe *MockPipe) Logs() definitions.Logs {
+	return pipe.logs
+}
+
+func (pipe *MockPipe) Receipts() definitions.Receipts {
+	return pipe.receipts
+}
+
+func (pipe *MockPipe) TxIndex() definitions.TxIndex {
+	return pipe.txIndex
+}
+
 func (pipe *MockPipe) NameReg() definitions.NameReg {
 	return pipe.namereg
 }
@@ -140,6 +160,11 @@ func (acc *accounts) Accounts([]*event.FilterData) (*core_types.AccountList, err
 	return acc.testData.GetAccounts.Output, nil
 }
 
+func (acc *accounts) ListAccounts(filters []*event.FilterData, pageToken []byte, pageSize int) (*core_types.AccountPage, error) {
+	return &core_types.AccountPage{
+		Accounts: acc.testData.GetAccounts.Output.Accounts}, nil
+}
+
 func (acc *accounts) Account(address []byte) (*account.Account, error) {
 	return acc.testData.GetAccount.Output, nil
 }
@@ -152,6 +177,42 @@ func (acc *accounts) StorageAt(address, key []byte) (*core_types.StorageItem, er
 	return acc.testData.GetStorageAt.Output, nil
 }
 
+func (acc *accounts) HistoricalAccount(address []byte, height int) (*account.Account, error) {
+	return acc.testData.GetAccount.Output, nil
+}
+
+func (acc *accounts) HistoricalStorage(address []byte, height int) (*core_types.Storage, error) {
+	return acc.testData.GetStorage.Output, nil
+}
+
+func (acc *accounts) HistoricalStorageAt(address, key []byte, height int) (*core_types.StorageItem, error) {
+	return acc.testData.GetStorageAt.Output, nil
+}
+
+func (acc *accounts) AccountWithProof(address []byte, height int) (*core_types.AccountWithProof, error) {
+	return &core_types.AccountWithProof{Height: height,
+		Account: acc.testData.GetAccount.Output}, nil
+}
+
+func (acc *accounts) StorageAtWithProof(address, key []byte, height int) (*core_types.StorageItemWithProof, error) {
+	return &core_types.StorageItemWithProof{Height: height,
+		StorageItem: *acc.testData.GetStorageAt.Output}, nil
+}
+
+func (acc *accounts) ListStorage(address, pageToken []byte, pageSize int) (*core_types.StoragePage, error) {
+	storage := acc.testData.GetStorage.Output
+	return &core_types.StoragePage{StorageRoot: storage.StorageRoot,
+		StorageItems: storage.StorageItems}, nil
+}
+
+func (acc *accounts) ABI(address []byte) (*core_types.ABIEntry, error) {
+	return nil, fmt.Errorf("No ABI is registered for the contract at %X", address)
+}
+
+func (acc *accounts) Dump(height int, w io.Writer) error {
+	return fmt.Errorf("The state at height %v is not available", height)
+}
+
 // Blockchain
 type chain struct {
 	testData *TestData
@@ -225,6 +286,14 @@ func (cons *consensusEngine) ListUnconfirmedTxs(maxTxs int) ([]txs.Tx, error) {
 	return cons.testData.GetUnconfirmedTxs.Output.Txs, nil
 }
 
+func (cons *consensusEngine) RemoveUnconfirmedTx(txHash []byte) bool {
+	return false
+}
+
+func (cons *consensusEngine) FlushUnconfirmedTxs() int {
+	return 0
+}
+
 func (cons *consensusEngine) ListValidators() []consensus_types.Validator {
 	return nil
 }
@@ -246,7 +315,8 @@ type eventer struct {
 	testData *TestData
 }
 
-func (evntr *eventer) Subscribe(subId, event string, callback func(txs.EventData)) error {
+func (evntr *eventer) Subscribe(ctx context.Context, subId, event string,
+	callback func(txs.EventData)) error {
 	return nil
 }
 
@@ -263,20 +333,58 @@ func (nmreg *namereg) Entry(key string) (*core_types.NameRegEntry, error) {
 	return nmreg.testData.GetNameRegEntry.Output, nil
 }
 
+func (nmreg *namereg) HistoricalEntry(key string, height int) (*core_types.NameRegEntry, error) {
+	return nmreg.testData.GetNameRegEntry.Output, nil
+}
+
 func (nmreg *namereg) Entries(filters []*event.FilterData) (*core_types.ResultListNames, error) {
 	return nmreg.testData.GetNameRegEntries.Output, nil
 }
 
+// Logs
+type logs struct {
+	testData *TestData
+}
+
+func (lgs *logs) Logs(address, topic []byte, minHeight,
+	maxHeight int64) ([]txs.EventDataLog, error) {
+	return nil, nil
+}
+
+// Receipts
+type receipts struct {
+	testData *TestData
+}
+
+func (rcpts *receipts) TxReceipt(txHash []byte,
+	abiJSON string) (*core_types.TxReceipt, error) {
+	return nil, nil
+}
+
+// Tx index
+type txIndex struct {
+	testData *TestData
+}
+
+func (ti *txIndex) SenderTxs(address []byte, limit int) (*core_types.SenderTxs, error) {
+	return nil, nil
+}
+
 // Txs
 type transactor struct {
 	testData *TestData
 }
 
-func (trans *transactor) Call(fromAddress, toAddress, data []byte) (*core_types.Call, error) {
+func (trans *transactor) Call(fromAddress, toAddress, data []byte, trace bool) (*core_types.Call, error) {
+	return trans.testData.Call.Output, nil
+}
+
+func (trans *transactor) CallWithOverrides(from, to, code, data []byte, height int,
+	overrides []*core_types.AccountOverride, trace bool) (*core_types.Call, error) {
 	return trans.testData.Call.Output, nil
 }
 
-func (trans *transactor) CallCode(from, code, data []byte) (*core_types.Call, error) {
+func (trans *transactor) CallCode(from, code, data []byte, trace bool) (*core_types.Call, error) {
 	return trans.testData.CallCode.Output, nil
 }
 
@@ -285,6 +393,14 @@ func (trans *transactor) BroadcastTx(tx txs.Tx) (*txs.Receipt, error) {
 	return &receipt, nil
 }
 
+func (trans *transactor) BroadcastTxBatch(batch []txs.Tx) ([]*txs.Receipt, error) {
+	receipts := make([]*txs.Receipt, len(batch))
+	for i, tx := range batch {
+		receipts[i], _ = trans.BroadcastTx(tx)
+	}
+	return receipts, nil
+}
+
 func (trans *transactor) Transact(privKey, address, data []byte, gasLimit, fee int64) (*txs.Receipt, error) {
 	if len(address) == 0 {
 		return trans.testData.TransactCreate.Output, nil
@@ -308,6 +424,18 @@ func (trans *transactor) TransactNameReg(privKey []byte, name, data string, amou
 	return trans.testData.TransactNameReg.Output, nil
 }
 
+func (trans *transactor) RenewName(privKey []byte, name string, amount, fee int64) (*txs.Receipt, error) {
+	return trans.testData.TransactNameReg.Output, nil
+}
+
 func (trans *transactor) SignTx(tx txs.Tx, privAccounts []*account.PrivAccount) (txs.Tx, error) {
 	return nil, nil
 }
+
+func (trans *transactor) BaseFee() int64 {
+	return 0
+}
+
+func (trans *transactor) TraceTx(txHash []byte) (*core_types.Trace, error) {
+	return nil, nil
+}
@@ -96,6 +96,15 @@ func (mockSuite *MockSuite) TestGetAccounts() {
 	mockSuite.Equal(mockSuite.testData.GetAccounts.Output, ret)
 }
 
+func (mockSuite *MockSuite) TestListAccounts() {
+	resp := mockSuite.get("/accounts_list?q=balance:%3E0&page_size=10")
+	ret := &core_types.AccountPage{}
+	errD := mockSuite.codec.Decode(ret, resp.Body)
+	mockSuite.NoError(errD)
+	mockSuite.Equal(mockSuite.testData.GetAccounts.Output.Accounts, ret.Accounts)
+	mockSuite.Empty(ret.NextPageToken)
+}
+
 func (mockSuite *MockSuite) TestGetAccount() {
 	addr := hex.EncodeToString(mockSuite.testData.GetAccount.Input.Address)
 	resp := mockSuite.get("/accounts/" + addr)
@@ -114,6 +123,17 @@ func (mockSuite *MockSuite) TestGetStorage() {
 	mockSuite.Equal(mockSuite.testData.GetStorage.Output, ret)
 }
 
+func (mockSuite *MockSuite) TestListStorage() {
+	addr := hex.EncodeToString(mockSuite.testData.GetStorage.Input.Address)
+	resp := mockSuite.get("/accounts/" + addr + "/storage_list?page_size=10")
+	ret := &core_types.StoragePage{}
+	errD := mockSuite.codec.Decode(ret, resp.Body)
+	mockSuite.NoError(errD)
+	mockSuite.Equal(mockSuite.testData.GetStorage.Output.StorageItems,
+		ret.StorageItems)
+	mockSuite.Empty(ret.NextPageToken)
+}
+
 func (mockSuite *MockSuite) TestGetStorageAt() {
 	addr := hex.EncodeToString(mockSuite.testData.GetStorageAt.Input.Address)
 	key := hex.EncodeToString(mockSuite.testData.GetStorageAt.Input.Key)
@@ -291,6 +311,14 @@ func (mockSuite *MockSuite) TestTransactNameReg() {
 	mockSuite.Equal(mockSuite.testData.TransactNameReg.Output, ret)
 }
 
+func (mockSuite *MockSuite) TestRenewName() {
+	resp := mockSuite.postJson("/unsafe/namereg/renew", mockSuite.testData.TransactNameReg.Input)
+	ret := &txs.Receipt{}
+	errD := mockSuite.codec.Decode(ret, resp.Body)
+	mockSuite.NoError(errD)
+	mockSuite.Equal(mockSuite.testData.TransactNameReg.Output, ret)
+}
+
 func (mockSuite *MockSuite) TestGetUnconfirmedTxs() {
 	resp := mockSuite.get("/txpool")
 	ret := &txs.UnconfirmedTxs{}
@@ -15,6 +15,7 @@
 package v0
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -27,21 +28,28 @@ import (
 
 // Used for Burrow. Implements WebSocketService.
 type BurrowWsService struct {
-	codec           rpc.Codec
-	pipe            definitions.Pipe
-	defaultHandlers map[string]RequestHandlerFunc
+	codec             rpc.Codec
+	pipe              definitions.Pipe
+	eventBufferConfig event.BufferConfig
+	defaultHandlers   map[string]RequestHandlerFunc
 }
 
-// Create a new websocket service.
-func NewBurrowWsService(codec rpc.Codec,
-	pipe definitions.Pipe) server.WebSocketService {
-	tmwss := &BurrowWsService{codec: codec, pipe: pipe}
+// Create a new websocket service. Event subscriptions are buffered according
+// to eventBufferConfig unless the subscriber asks otherwise.
+func NewBurrowWsService(codec rpc.Codec, pipe definitions.Pipe,
+	eventBufferConfig event.BufferConfig) server.WebSocketService {
+	tmwss := &BurrowWsService{codec: codec, pipe: pipe,
+		eventBufferConfig: eventBufferConfig}
 	mtds := NewBurrowMethods(codec, pipe)
 
 	dhMap := mtds.getMethods()
 	// Events
 	dhMap[EVENT_SUBSCRIBE] = tmwss.EventSubscribe
 	dhMap[EVENT_UNSUBSCRIBE] = tmwss.EventUnsubscribe
+	// Execution events
+	dhMap[STREAM_BLOCKS] = tmwss.StreamBlocks
+	dhMap[STREAM_TXS] = tmwss.StreamTxs
+	dhMap[STREAM_EVENTS] = tmwss.StreamEvents
 	tmwss.defaultHandlers = dhMap
 	return tmwss
 }
@@ -68,7 +76,16 @@ func (this *BurrowWsService) Process(msg []byte, session *server.WSSession) {
 
 	mName := req.Method
 
+	if err := server.AllowRequest(session.Context()); err != nil {
+		this.writeError(err.Error(), req.Id, rpc.RATE_LIMITED, session)
+		return
+	}
+
 	if handler, ok := this.defaultHandlers[mName]; ok {
+		if err := server.Authorize(session.Context(), mName); err != nil {
+			this.writeError(err.Error(), req.Id, rpc.UNAUTHORIZED, session)
+			return
+		}
 		resp, errCode, err := handler(req, session)
 		if err != nil {
 			this.writeError(err.Error(), req.Id, errCode, session)
@@ -125,31 +142,63 @@ func (this *BurrowWsService) EventSubscribe(request *rpc.RPCRequest,
 		return nil, rpc.INTERNAL_ERROR, errSID
 	}
 
-	callback := func(ret txs.EventData) {
-		this.writeResponse(subId, ret, session)
+	bufferConfig, err := event.ClientBufferConfig(this.eventBufferConfig,
+		param.BufferSize, param.OverflowPolicy)
+	if err != nil {
+		return nil, rpc.INVALID_PARAMS, err
 	}
-	errC := this.pipe.Events().Subscribe(subId, eventId, callback)
+
+	// The subscription is torn down when it is unsubscribed or the session closes
+	ctx, err := addSubscription(session, subId)
+	if err != nil {
+		return nil, rpc.RATE_LIMITED, err
+	}
+	callback := event.BufferedCallback(ctx, bufferConfig, func(ret txs.EventData) {
+		this.writeResponse(subId, ret, session)
+	})
+	errC := event.SubscribeQuery(ctx, this.pipe.Events(), subId, eventId, callback)
 	if errC != nil {
+		session.RemoveSubscription(subId)
 		return nil, rpc.INTERNAL_ERROR, errC
 	}
 	return &event.EventSub{subId}, 0, nil
 }
 
+// Unsubscribes from one of the subscriptions carried by the session, including
+// execution event streams, leaving any others in place.
 func (this *BurrowWsService) EventUnsubscribe(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
-	param := &EventIdParam{}
+	session, ok := requester.(*server.WSSession)
+	if !ok {
+		return 0, rpc.INTERNAL_ERROR,
+			fmt.Errorf("Passing wrong object to websocket events")
+	}
+	param := &SubIdParam{}
 	err := this.codec.DecodeBytes(param, request.Params)
 	if err != nil {
 		return nil, rpc.INVALID_PARAMS, err
 	}
-	eventId := param.EventId
-
-	errC := this.pipe.Events().Unsubscribe(eventId)
-	if errC != nil {
-		return nil, rpc.INTERNAL_ERROR, errC
+	if !session.RemoveSubscription(param.SubId) {
+		return nil, rpc.INVALID_PARAMS, fmt.Errorf("Subscription not active "+
+			"on this connection. ID: %s", param.SubId)
 	}
 	return &event.EventUnsub{true}, 0, nil
 }
 
+// Adds a subscription to the session if the client has not reached its limit
+// of subscriptions, which the subscription counts against until it is removed
+func addSubscription(session *server.WSSession, subId string) (context.Context, error) {
+	release, err := server.AcquireSubscription(session.Context())
+	if err != nil {
+		return nil, err
+	}
+	ctx := session.AddSubscription(subId)
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+	return ctx, nil
+}
+
 func (this *BurrowWsService) EventPoll(request *rpc.RPCRequest, requester interface{}) (interface{}, int, error) {
 	return nil, rpc.INTERNAL_ERROR, fmt.Errorf("Cannot poll with websockets")
 }
@@ -0,0 +1,207 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The name of the identity of requests that present no credentials
+const AnonymousIdentity = "anonymous"
+
+type identityKey struct{}
+
+// The client of a request and the RPC methods it may call
+type Identity struct {
+	Name string
+	// Patterns of method names, such as burrow.get*
+	methods []string
+}
+
+// Whether the identity may call method
+func (identity *Identity) Allowed(method string) bool {
+	for _, pattern := range identity.methods {
+		if matched, _ := path.Match(pattern, method); matched {
+			return true
+		}
+	}
+	return false
+}
+
+type ErrUnauthorized struct {
+	Identity string
+	Method   string
+}
+
+func (e ErrUnauthorized) Error() string {
+	return fmt.Sprintf("%s is not authorized to call %s", e.Identity, e.Method)
+}
+
+// Adds the identity a request has been authenticated as to ctx
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	if identity == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Gets the identity a request has been authenticated as, or nil when
+// authentication is not enabled
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// Checks the identity in ctx may call method. Everything is allowed when
+// authentication is not enabled.
+func Authorize(ctx context.Context, method string) error {
+	identity := IdentityFromContext(ctx)
+	if identity == nil || identity.Allowed(method) {
+		return nil
+	}
+	return ErrUnauthorized{Identity: identity.Name, Method: method}
+}
+
+// Works out who is making requests from their bearer tokens or client
+// certificates
+type Authenticator struct {
+	identities []*authIdentity
+	anonymous  *Identity
+}
+
+type authIdentity struct {
+	AuthIdentity
+	identity *Identity
+}
+
+func NewAuthenticator(config Auth) (*Authenticator, error) {
+	for role, patterns := range config.Roles {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("Role %s allows bad method pattern %s: %v",
+					role, pattern, err)
+			}
+		}
+	}
+	anonymous, err := newIdentity(AnonymousIdentity, config.AnonymousRoles, config.Roles)
+	if err != nil {
+		return nil, err
+	}
+	authenticator := &Authenticator{anonymous: anonymous}
+	// In order of name so that the first identity matching a certificate
+	// does not change from run to run
+	names := make([]string, 0, len(config.Identities))
+	for name := range config.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		identityConfig := config.Identities[name]
+		if identityConfig.Token == "" && identityConfig.ClientCertName == "" {
+			return nil, fmt.Errorf("Identity %s has neither a token nor a "+
+				"client certificate name", name)
+		}
+		identity, err := newIdentity(name, identityConfig.Roles, config.Roles)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.identities = append(authenticator.identities,
+			&authIdentity{AuthIdentity: identityConfig, identity: identity})
+	}
+	return authenticator, nil
+}
+
+func newIdentity(name string, roles []string, rolePatterns map[string][]string) (*Identity, error) {
+	identity := &Identity{Name: name}
+	for _, role := range roles {
+		patterns, ok := rolePatterns[role]
+		if !ok {
+			return nil, fmt.Errorf("Identity %s has role %s, which is not defined",
+				name, role)
+		}
+		identity.methods = append(identity.methods, patterns...)
+	}
+	return identity, nil
+}
+
+// Gets the identity of the client of request. A bearer token that is not
+// configured is an error, whereas requests without a token are identified by
+// their (verified) client certificate, or else are anonymous.
+func (authenticator *Authenticator) Authenticate(request *http.Request) (*Identity, error) {
+	if authorization := request.Header.Get("Authorization"); authorization != "" {
+		if !strings.HasPrefix(authorization, "Bearer ") {
+			return nil, fmt.Errorf("Authorization must be a bearer token")
+		}
+		token := []byte(strings.TrimPrefix(authorization, "Bearer "))
+		var identity *Identity
+		// Compare against every token so as not to give away which matched
+		for _, ai := range authenticator.identities {
+			if ai.Token != "" && subtle.ConstantTimeCompare([]byte(ai.Token), token) == 1 {
+				identity = ai.identity
+			}
+		}
+		if identity == nil {
+			return nil, fmt.Errorf("Unknown bearer token")
+		}
+		return identity, nil
+	}
+	if request.TLS != nil && len(request.TLS.PeerCertificates) > 0 {
+		name := request.TLS.PeerCertificates[0].Subject.CommonName
+		for _, ai := range authenticator.identities {
+			if ai.ClientCertName != "" && ai.ClientCertName == name {
+				return ai.identity, nil
+			}
+		}
+	}
+	return authenticator.anonymous, nil
+}
+
+// Authenticates each request, rejecting those with bad credentials, and adds
+// the identity to the context of the request for the RPC services to authorize
+// the methods called against
+func (authenticator *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithError(http.StatusUnauthorized, err)
+			return
+		}
+		c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+// Authenticates each request to handler like the middleware, for the servers
+// that are not served by gin
+func (authenticator *Authenticator) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAuth() Auth {
+	return Auth{
+		Enable:         true,
+		AnonymousRoles: []string{"read"},
+		Roles: map[string][]string{
+			"read":     {"burrow.get*", "eth_call"},
+			"transact": {"burrow.broadcastTx*"},
+			"admin":    {"*"},
+		},
+		Identities: map[string]AuthIdentity{
+			"deployer": {Token: "deployer-secret", Roles: []string{"read", "transact"}},
+			"operator": {ClientCertName: "operator.example.com", Roles: []string{"admin"}},
+		},
+	}
+}
+
+func request(authorization, certName string) *http.Request {
+	request, _ := http.NewRequest("POST", "/rpc", nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	if certName != "" {
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: certName}},
+		}}
+	}
+	return request
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticator, err := NewAuthenticator(testAuth())
+	require.NoError(t, err)
+
+	identity, err := authenticator.Authenticate(request("Bearer deployer-secret", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "deployer", identity.Name)
+	assert.True(t, identity.Allowed("burrow.getAccount"))
+	assert.True(t, identity.Allowed("burrow.broadcastTxBatch"))
+	assert.False(t, identity.Allowed("burrow.transact"))
+
+	identity, err = authenticator.Authenticate(request("", "operator.example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "operator", identity.Name)
+	assert.True(t, identity.Allowed("burrow.reloadLogging"))
+
+	// Neither credentials nor a known certificate
+	for _, r := range []*http.Request{request("", ""), request("", "stranger")} {
+		identity, err = authenticator.Authenticate(r)
+		require.NoError(t, err)
+		assert.Equal(t, AnonymousIdentity, identity.Name)
+		assert.True(t, identity.Allowed("eth_call"))
+		assert.False(t, identity.Allowed("eth_sendRawTransaction"))
+	}
+
+	_, err = authenticator.Authenticate(request("Bearer wrong", ""))
+	assert.Error(t, err)
+	_, err = authenticator.Authenticate(request("Basic deployer-secret", ""))
+	assert.Error(t, err)
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	auth := testAuth()
+	auth.AnonymousRoles = []string{"nobody"}
+	_, err := NewAuthenticator(auth)
+	assert.Error(t, err)
+
+	auth = testAuth()
+	auth.Roles["bad"] = []string{"burrow.[get"}
+	_, err = NewAuthenticator(auth)
+	assert.Error(t, err)
+
+	auth = testAuth()
+	auth.Identities["nameless"] = AuthIdentity{Roles: []string{"read"}}
+	_, err = NewAuthenticator(auth)
+	assert.Error(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	// Everything is allowed without authentication
+	assert.NoError(t, Authorize(context.Background(), "burrow.transact"))
+
+	authenticator, err := NewAuthenticator(testAuth())
+	require.NoError(t, err)
+	identity, err := authenticator.Authenticate(request("", ""))
+	require.NoError(t, err)
+	ctx := WithIdentity(context.Background(), identity)
+	assert.NoError(t, Authorize(ctx, "burrow.getAccount"))
+	assert.Equal(t, ErrUnauthorized{Identity: AnonymousIdentity, Method: "burrow.transact"},
+		Authorize(ctx, "burrow.transact"))
+}
+
+func TestAuthenticatorHandler(t *testing.T) {
+	authenticator, err := NewAuthenticator(testAuth())
+	require.NoError(t, err)
+	handler := authenticator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Authorize(r.Context(), "burrow.broadcastTx"); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request("Bearer deployer-secret", ""))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request("", ""))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request("Bearer wrong", ""))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+}
@@ -17,6 +17,7 @@ package server
 import (
 	"fmt"
 	"math"
+	"time"
 
 	viper "github.com/spf13/viper"
 )
@@ -29,6 +30,10 @@ type (
 		CORS       CORS      `toml:"CORS"`
 		HTTP       HTTP      `toml:"HTTP"`
 		WebSocket  WebSocket `toml:"web_socket"`
+		Tracing    Tracing   `toml:"tracing"`
+		Health     Health    `toml:"health"`
+		Auth       Auth      `toml:"auth"`
+		RateLimit  RateLimit `toml:"rate_limit"`
 		Tendermint Tendermint
 	}
 
@@ -41,6 +46,20 @@ type (
 		TLS      bool   `toml:"tls"`
 		CertPath string `toml:"cert_path"`
 		KeyPath  string `toml:"key_path"`
+		// The CAs client certificates are verified against, or empty to not
+		// ask clients for certificates
+		ClientCAPath string `toml:"client_ca_path"`
+		// Certificates served instead of the one above to clients asking for
+		// a server name (SNI) they are valid for
+		Certificates map[string]TLSCertificate `toml:"certificates"`
+		// How often to check whether the certificate files have changed and
+		// reload them, or 0 to never
+		ReloadInterval time.Duration `toml:"reload_interval"`
+	}
+
+	TLSCertificate struct {
+		CertPath string `toml:"cert_path"`
+		KeyPath  string `toml:"key_path"`
 	}
 
 	// Options stores configurations
@@ -56,6 +75,18 @@ type (
 
 	HTTP struct {
 		JsonRpcEndpoint string `toml:"json_rpc_endpoint"`
+		// The endpoint of the Ethereum (eth_ namespace) JSON-RPC service, or
+		// empty to not serve it
+		EthJsonRpcEndpoint string `toml:"eth_json_rpc_endpoint"`
+		// The endpoint of the GraphQL query service, or empty to not serve it
+		GraphQLEndpoint string `toml:"graphql_endpoint"`
+		// The endpoint Prometheus scrapes the metrics of the node from, or
+		// empty to not serve them
+		MetricsEndpoint string `toml:"metrics_endpoint"`
+		// The endpoints of the liveness and readiness probes, or empty to not
+		// serve them
+		HealthEndpoint    string `toml:"health_endpoint"`
+		ReadinessEndpoint string `toml:"readiness_endpoint"`
 	}
 
 	WebSocket struct {
@@ -63,6 +94,65 @@ type (
 		MaxWebSocketSessions uint16 `toml:"max_websocket_sessions"`
 		ReadBufferSize       uint64 `toml:"read_buffer_size"`
 		WriteBufferSize      uint64 `toml:"write_buffer_size"`
+		// Default number of events buffered per subscription and what to do
+		// when a slow client lets the buffer fill up
+		EventBufferSize     uint64 `toml:"event_buffer_size"`
+		EventOverflowPolicy string `toml:"event_overflow_policy"`
+		// The origins of the browser pages that may open websockets, in the
+		// same form as the CORS allow_origins, which are used instead when
+		// these are empty. With neither any origin may.
+		AllowedOrigins []string `toml:"allowed_origins"`
+	}
+
+	Tracing struct {
+		// Where spans are POSTed in the Zipkin v2 JSON format, or empty to
+		// not trace requests
+		ZipkinEndpoint string `toml:"zipkin_endpoint"`
+		ServiceName    string `toml:"service_name"`
+	}
+
+	Health struct {
+		// The node is not ready while its latest block is older than this
+		MaxBlockAge time.Duration `toml:"max_block_age"`
+		// Nor while it has fewer peers than this
+		MinPeers int `toml:"min_peers"`
+	}
+
+	Auth struct {
+		// Whether to authenticate requests and authorize the RPC methods they
+		// call, otherwise anyone may call any method
+		Enable bool `toml:"enable"`
+		// The roles of requests that present no credentials
+		AnonymousRoles []string `toml:"anonymous_roles"`
+		// The names of roles to patterns of the RPC methods they may call,
+		// such as burrow.get*
+		Roles map[string][]string `toml:"roles"`
+		// The names of the clients that may present credentials to theirs
+		Identities map[string]AuthIdentity `toml:"identities"`
+	}
+
+	// A client is identified by its bearer token, or by the common name of its
+	// client certificate
+	AuthIdentity struct {
+		Token          string   `toml:"token"`
+		ClientCertName string   `toml:"client_cert_name"`
+		Roles          []string `toml:"roles"`
+	}
+
+	// Limits of each client, or 0 for none
+	RateLimit struct {
+		// The sustained rate of HTTP requests and websocket messages
+		RequestsPerSecond float64 `toml:"requests_per_second"`
+		// How many requests may be made at once above the sustained rate,
+		// which defaults to a second's worth
+		Burst int `toml:"burst"`
+		// Websocket connections held at once
+		MaxConnections int `toml:"max_connections"`
+		// Event subscriptions and streams held at once over websockets
+		MaxSubscriptions int `toml:"max_subscriptions"`
+		// Whether to take the IP of clients from the X-Forwarded-For and
+		// X-Real-IP headers set by a reverse proxy, rather than the connection
+		TrustProxyHeaders bool `toml:"trust_proxy_headers"`
 	}
 
 	Tendermint struct {
@@ -121,15 +211,28 @@ func ReadServerConfig(viper *viper.Viper) (*ServerConfig, error) {
 			writeBufferSize)
 	}
 
+	// check domain range for websocket.event_buffer_size
+	eventBufferSize := viper.GetInt("websocket.event_buffer_size")
+	var eventBufferSizeUint64 uint64 = 0
+	if eventBufferSize >= 0 {
+		eventBufferSizeUint64 = uint64(eventBufferSize)
+	} else {
+		return nil, fmt.Errorf("Failed to read websocket event buffer size: %v",
+			eventBufferSize)
+	}
+
 	return &ServerConfig{
 		Bind: Bind{
 			Address: viper.GetString("bind.address"),
 			Port:    bindPortUint16,
 		},
 		TLS: TLS{
-			TLS:      viper.GetBool("tls.tls"),
-			CertPath: viper.GetString("tls.cert_path"),
-			KeyPath:  viper.GetString("tls.key_path"),
+			TLS:            viper.GetBool("tls.tls"),
+			CertPath:       viper.GetString("tls.cert_path"),
+			KeyPath:        viper.GetString("tls.key_path"),
+			ClientCAPath:   viper.GetString("tls.client_ca_path"),
+			Certificates:   readTLSCertificates(viper),
+			ReloadInterval: viper.GetDuration("tls.reload_interval"),
 		},
 		CORS: CORS{
 			Enable:           viper.GetBool("cors.enable"),
@@ -141,13 +244,37 @@ func ReadServerConfig(viper *viper.Viper) (*ServerConfig, error) {
 			MaxAge:           maxAgeUint64,
 		},
 		HTTP: HTTP{
-			JsonRpcEndpoint: viper.GetString("http.json_rpc_endpoint"),
+			JsonRpcEndpoint:    viper.GetString("http.json_rpc_endpoint"),
+			EthJsonRpcEndpoint: viper.GetString("http.eth_json_rpc_endpoint"),
+			GraphQLEndpoint:    viper.GetString("http.graphql_endpoint"),
+			MetricsEndpoint:    viper.GetString("http.metrics_endpoint"),
+			HealthEndpoint:     viper.GetString("http.health_endpoint"),
+			ReadinessEndpoint:  viper.GetString("http.readiness_endpoint"),
 		},
 		WebSocket: WebSocket{
 			WebSocketEndpoint:    viper.GetString("websocket.endpoint"),
 			MaxWebSocketSessions: maxWebsocketSessionsUint16,
 			ReadBufferSize:       readBufferSizeUint64,
 			WriteBufferSize:      writeBufferSizeUint64,
+			EventBufferSize:      eventBufferSizeUint64,
+			EventOverflowPolicy:  viper.GetString("websocket.event_overflow_policy"),
+			AllowedOrigins:       viper.GetStringSlice("websocket.allowed_origins"),
+		},
+		Tracing: Tracing{
+			ZipkinEndpoint: viper.GetString("tracing.zipkin_endpoint"),
+			ServiceName:    viper.GetString("tracing.service_name"),
+		},
+		Health: Health{
+			MaxBlockAge: viper.GetDuration("health.max_block_age"),
+			MinPeers:    viper.GetInt("health.min_peers"),
+		},
+		Auth: readAuth(viper),
+		RateLimit: RateLimit{
+			RequestsPerSecond: viper.GetFloat64("rate_limit.requests_per_second"),
+			Burst:             viper.GetInt("rate_limit.burst"),
+			MaxConnections:    viper.GetInt("rate_limit.max_connections"),
+			MaxSubscriptions:  viper.GetInt("rate_limit.max_subscriptions"),
+			TrustProxyHeaders: viper.GetBool("rate_limit.trust_proxy_headers"),
 		},
 		Tendermint: Tendermint{
 			RpcLocalAddress: viper.GetString("tendermint.rpc_local_address"),
@@ -156,6 +283,39 @@ func ReadServerConfig(viper *viper.Viper) (*ServerConfig, error) {
 	}, nil
 }
 
+func readTLSCertificates(viper *viper.Viper) map[string]TLSCertificate {
+	certificates := make(map[string]TLSCertificate)
+	for name := range viper.GetStringMap("tls.certificates") {
+		key := "tls.certificates." + name
+		certificates[name] = TLSCertificate{
+			CertPath: viper.GetString(key + ".cert_path"),
+			KeyPath:  viper.GetString(key + ".key_path"),
+		}
+	}
+	return certificates
+}
+
+func readAuth(viper *viper.Viper) Auth {
+	auth := Auth{
+		Enable:         viper.GetBool("auth.enable"),
+		AnonymousRoles: viper.GetStringSlice("auth.anonymous_roles"),
+		Roles:          make(map[string][]string),
+		Identities:     make(map[string]AuthIdentity),
+	}
+	for role := range viper.GetStringMap("auth.roles") {
+		auth.Roles[role] = viper.GetStringSlice("auth.roles." + role)
+	}
+	for name := range viper.GetStringMap("auth.identities") {
+		key := "auth.identities." + name
+		auth.Identities[name] = AuthIdentity{
+			Token:          viper.GetString(key + ".token"),
+			ClientCertName: viper.GetString(key + ".client_cert_name"),
+			Roles:          viper.GetStringSlice(key + ".roles"),
+		}
+	}
+	return auth
+}
+
 // NOTE: [ben] only preserved for /test/server tests; but should not be used and
 // will be deprecated.
 func DefaultServerConfig() *ServerConfig {
@@ -171,15 +331,17 @@ func DefaultServerConfig() *ServerConfig {
 			KeyPath:  kp,
 		},
 		CORS: CORS{},
-		HTTP: HTTP{JsonRpcEndpoint: "/rpc"},
+		HTTP: HTTP{JsonRpcEndpoint: "/rpc", EthJsonRpcEndpoint: "/eth"},
 		WebSocket: WebSocket{
 			WebSocketEndpoint:    "/socketrpc",
 			MaxWebSocketSessions: 50,
 			ReadBufferSize:       4096,
 			WriteBufferSize:      4096,
+			EventBufferSize:      100,
+			EventOverflowPolicy:  "latest_wins",
 		},
 		Tendermint: Tendermint{
-			RpcLocalAddress: "0.0.0.0:46657",
+			RpcLocalAddress: "127.0.0.1:46657",
 			Endpoint:        "/websocket",
 		},
 	}
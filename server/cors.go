@@ -0,0 +1,141 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Methods and headers browsers may use in cross-origin requests when the CORS
+// config does not list them
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "DELETE"}
+	DefaultCORSHeaders = []string{"Content-Type", "Authorization", "traceparent"}
+)
+
+// An allow-list of the origins of browser pages, each entry of which is "*"
+// for any origin, an origin such as https://dapp.example.com, or an origin
+// with a wildcard subdomain such as https://*.example.com
+type OriginPolicy struct {
+	allowAll bool
+	origins  []string
+}
+
+func NewOriginPolicy(origins []string) *OriginPolicy {
+	policy := &OriginPolicy{}
+	for _, origin := range origins {
+		if origin == "*" {
+			policy.allowAll = true
+		}
+		policy.origins = append(policy.origins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+	}
+	return policy
+}
+
+func (policy *OriginPolicy) Allowed(origin string) bool {
+	if policy.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, allowed := range policy.origins {
+		if allowed == origin {
+			return true
+		}
+		// The wildcard matches one or more labels
+		if i := strings.Index(allowed, "://*."); i >= 0 {
+			scheme, domain := allowed[:i+3], allowed[i+4:]
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, domain) &&
+				len(origin) > len(scheme)+len(domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Answers preflight requests and adds the headers that let browser pages from
+// the allowed origins read the responses to their requests
+func NewCORSMiddleware(options CORS) gin.HandlerFunc {
+	policy := NewOriginPolicy(options.AllowOrigins)
+	methods := options.AllowMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSMethods
+	}
+	headers := options.AllowHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	return func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		preflight := c.Request.Method == "OPTIONS" &&
+			c.Request.Header.Get("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+		if !policy.Allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// The browser will not let the page read the response
+			c.Next()
+			return
+		}
+		if policy.allowAll && !options.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if options.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if len(options.ExposeHeaders) > 0 {
+				c.Header("Access-Control-Expose-Headers", strings.Join(options.ExposeHeaders, ", "))
+			}
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		if options.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.FormatUint(options.MaxAge, 10))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Whether a websocket may be opened by request. Clients other than browsers
+// send no Origin, and pages may always connect back to the host they were
+// served from. Other origins must be allowed by policy, unless it is nil.
+func checkOrigin(policy *OriginPolicy, request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" || policy == nil {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, request.Host) {
+		return true
+	}
+	return policy.Allowed(origin)
+}
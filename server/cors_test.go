@@ -0,0 +1,56 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginPolicy(t *testing.T) {
+	policy := NewOriginPolicy([]string{"https://dapp.example.com/", "https://*.Example.org"})
+	assert.True(t, policy.Allowed("https://dapp.example.com"))
+	assert.True(t, policy.Allowed("https://DAPP.example.com"))
+	assert.False(t, policy.Allowed("http://dapp.example.com"))
+	assert.False(t, policy.Allowed("https://other.example.com"))
+	assert.True(t, policy.Allowed("https://a.example.org"))
+	assert.True(t, policy.Allowed("https://a.b.example.org"))
+	assert.False(t, policy.Allowed("https://example.org"))
+	assert.False(t, policy.Allowed("https://evilexample.org"))
+	assert.False(t, policy.Allowed("http://a.example.org"))
+
+	assert.True(t, NewOriginPolicy([]string{"*"}).Allowed("https://anywhere.net"))
+	assert.False(t, NewOriginPolicy(nil).Allowed("https://anywhere.net"))
+}
+
+func TestCheckOrigin(t *testing.T) {
+	request := func(origin string) *http.Request {
+		request, _ := http.NewRequest("GET", "http://node.example.com:1337/socketrpc", nil)
+		if origin != "" {
+			request.Header.Set("Origin", origin)
+		}
+		return request
+	}
+	policy := NewOriginPolicy([]string{"https://dapp.example.com"})
+	assert.True(t, checkOrigin(policy, request("")))
+	assert.True(t, checkOrigin(policy, request("http://node.example.com:1337")))
+	assert.True(t, checkOrigin(policy, request("https://dapp.example.com")))
+	assert.False(t, checkOrigin(policy, request("https://evil.example.com")))
+	// Without a policy any page may connect
+	assert.True(t, checkOrigin(nil, request("https://evil.example.com")))
+	assert.False(t, checkOrigin(NewOriginPolicy(nil), request("https://evil.example.com")))
+}
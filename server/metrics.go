@@ -0,0 +1,52 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/burrow/metrics"
+)
+
+// Serves the metrics of the node for Prometheus. Implements Server
+type MetricsServer struct {
+	registry *metrics.Registry
+	running  bool
+}
+
+func NewMetricsServer(registry *metrics.Registry) *MetricsServer {
+	return &MetricsServer{registry: registry}
+}
+
+// Start adds the metrics path to the router, unless it is not configured
+func (this *MetricsServer) Start(config *ServerConfig, router *gin.Engine) {
+	if config.HTTP.MetricsEndpoint != "" {
+		router.GET(config.HTTP.MetricsEndpoint, this.handleFunc)
+	}
+	this.running = true
+}
+
+// Is the server currently running?
+func (this *MetricsServer) Running() bool {
+	return this.running
+}
+
+// Shut the server down. Does nothing.
+func (this *MetricsServer) ShutDown() {
+	this.running = false
+}
+
+func (this *MetricsServer) handleFunc(c *gin.Context) {
+	this.registry.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,244 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How often clients that are within all their limits are forgotten
+const rateLimitSweepInterval = time.Minute
+
+type quotaKey struct{}
+
+type ErrRateLimited struct {
+	Client string
+	// What the limit is on, such as requests per second
+	Limit string
+	Max   float64
+}
+
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("%s has exceeded its limit of %v %s", e.Client, e.Max, e.Limit)
+}
+
+// Limits the rate of requests each client makes, and the websocket connections
+// and event subscriptions it holds at once. Clients that have authenticated are
+// limited by identity and others by IP address.
+type RateLimiter struct {
+	config    RateLimit
+	burst     float64
+	mtx       sync.Mutex
+	clients   map[string]*clientUsage
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type clientUsage struct {
+	// A token bucket of requests
+	tokens        float64
+	updated       time.Time
+	connections   int
+	subscriptions int
+}
+
+// The quota of the client of a request, carried in its context
+type quota struct {
+	limiter *RateLimiter
+	client  string
+}
+
+func NewRateLimiter(config RateLimit) *RateLimiter {
+	burst := float64(config.Burst)
+	if burst == 0 {
+		burst = math.Max(1, math.Ceil(config.RequestsPerSecond))
+	}
+	return &RateLimiter{
+		config:  config,
+		burst:   burst,
+		clients: make(map[string]*clientUsage),
+		now:     time.Now,
+	}
+}
+
+// Whether any of the limits are set
+func (config RateLimit) Enabled() bool {
+	return config.RequestsPerSecond > 0 || config.MaxConnections > 0 ||
+		config.MaxSubscriptions > 0
+}
+
+// Counts each request against the quota of its client, responding 429 to those
+// over it, and adds the quota to the context of the request so that websocket
+// messages, connections and subscriptions can be counted against it too. Must
+// come after the authentication middleware, if any, to limit by identity.
+func (limiter *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := limiter.client(c)
+		if retryAfter, err := limiter.request(client); err != nil {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithError(429, err)
+			return
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(),
+			quotaKey{}, &quota{limiter: limiter, client: client}))
+		c.Next()
+	}
+}
+
+func (limiter *RateLimiter) client(c *gin.Context) string {
+	if identity := IdentityFromContext(c.Request.Context()); identity != nil &&
+		identity.Name != AnonymousIdentity {
+		return "identity " + identity.Name
+	}
+	if limiter.config.TrustProxyHeaders {
+		return "IP " + c.ClientIP()
+	}
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+	return "IP " + host
+}
+
+// Takes a request from the client's bucket, or if it is empty returns how long
+// until it will not be
+func (limiter *RateLimiter) request(client string) (time.Duration, error) {
+	if limiter.config.RequestsPerSecond <= 0 {
+		return 0, nil
+	}
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+	usage := limiter.usage(client)
+	if usage.tokens < 1 {
+		return time.Duration((1 - usage.tokens) / limiter.config.RequestsPerSecond *
+				float64(time.Second)),
+			ErrRateLimited{Client: client, Limit: "requests per second",
+				Max: limiter.config.RequestsPerSecond}
+	}
+	usage.tokens--
+	return 0, nil
+}
+
+// Counts something the client holds, such as a connection, against max and
+// returns a function to release it again
+func (limiter *RateLimiter) acquire(client string, count func(*clientUsage) *int,
+	max int, limit string) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+	held := count(limiter.usage(client))
+	if *held >= max {
+		return nil, ErrRateLimited{Client: client, Limit: limit, Max: float64(max)}
+	}
+	*held++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			limiter.mtx.Lock()
+			defer limiter.mtx.Unlock()
+			*count(limiter.usage(client))--
+		})
+	}, nil
+}
+
+// Gets the usage of the client with its bucket refilled to now. Must be called
+// with the lock held.
+func (limiter *RateLimiter) usage(client string) *clientUsage {
+	now := limiter.now()
+	if now.Sub(limiter.lastSweep) > rateLimitSweepInterval {
+		limiter.sweep(now)
+	}
+	usage, ok := limiter.clients[client]
+	if !ok {
+		usage = &clientUsage{tokens: limiter.burst, updated: now}
+		limiter.clients[client] = usage
+		return usage
+	}
+	usage.tokens = math.Min(limiter.burst,
+		usage.tokens+now.Sub(usage.updated).Seconds()*limiter.config.RequestsPerSecond)
+	usage.updated = now
+	return usage
+}
+
+// Forgets the clients whose buckets would be full and that hold nothing, since
+// they are no different from clients that have not been seen
+func (limiter *RateLimiter) sweep(now time.Time) {
+	limiter.lastSweep = now
+	for client, usage := range limiter.clients {
+		tokens := usage.tokens + now.Sub(usage.updated).Seconds()*limiter.config.RequestsPerSecond
+		if tokens >= limiter.burst && usage.connections == 0 && usage.subscriptions == 0 {
+			delete(limiter.clients, client)
+		}
+	}
+}
+
+func quotaFromContext(ctx context.Context) *quota {
+	q, _ := ctx.Value(quotaKey{}).(*quota)
+	return q
+}
+
+// Counts a message, such as one received over a websocket, against the request
+// rate of the client of ctx
+func AllowRequest(ctx context.Context) error {
+	q := quotaFromContext(ctx)
+	if q == nil {
+		return nil
+	}
+	_, err := q.limiter.request(q.client)
+	return err
+}
+
+// Counts a websocket connection against the limit of the client of ctx. The
+// returned function must be called once the connection closes.
+func AcquireConnection(ctx context.Context) (func(), error) {
+	q := quotaFromContext(ctx)
+	if q == nil {
+		return func() {}, nil
+	}
+	return q.limiter.acquire(q.client,
+		func(usage *clientUsage) *int { return &usage.connections },
+		q.limiter.config.MaxConnections, "concurrent connections")
+}
+
+// Counts an event subscription against the limit of the client of ctx. The
+// returned function must be called once the subscription ends.
+func AcquireSubscription(ctx context.Context) (func(), error) {
+	q := quotaFromContext(ctx)
+	if q == nil {
+		return func() {}, nil
+	}
+	return q.limiter.acquire(q.client,
+		func(usage *clientUsage) *int { return &usage.subscriptions },
+		q.limiter.config.MaxSubscriptions, "subscriptions")
+}
+
+// Adds the quota of the client of from to ctx, so that a context that outlives
+// a request, like that of a websocket session, is still counted against it
+func withQuotaOf(ctx, from context.Context) context.Context {
+	if q := quotaFromContext(from); q != nil {
+		return context.WithValue(ctx, quotaKey{}, q)
+	}
+	return ctx
+}
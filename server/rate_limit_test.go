@@ -0,0 +1,104 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRateLimiter(config RateLimit) (*RateLimiter, *time.Time) {
+	limiter := NewRateLimiter(config)
+	now := time.Unix(1500000000, 0)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestRateLimiterRequests(t *testing.T) {
+	limiter, now := testRateLimiter(RateLimit{RequestsPerSecond: 2, Burst: 3})
+	for i := 0; i < 3; i++ {
+		_, err := limiter.request("IP 10.0.0.1")
+		require.NoError(t, err)
+	}
+	retryAfter, err := limiter.request("IP 10.0.0.1")
+	assert.Equal(t, ErrRateLimited{Client: "IP 10.0.0.1", Limit: "requests per second",
+		Max: 2}, err)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+	// Other clients have their own buckets
+	_, err = limiter.request("IP 10.0.0.2")
+	assert.NoError(t, err)
+
+	*now = now.Add(500 * time.Millisecond)
+	_, err = limiter.request("IP 10.0.0.1")
+	assert.NoError(t, err)
+	_, err = limiter.request("IP 10.0.0.1")
+	assert.Error(t, err)
+
+	// Idle clients are forgotten
+	*now = now.Add(2 * rateLimitSweepInterval)
+	_, err = limiter.request("IP 10.0.0.3")
+	assert.NoError(t, err)
+	assert.Len(t, limiter.clients, 1)
+}
+
+func TestRateLimiterConnectionsAndSubscriptions(t *testing.T) {
+	limiter, now := testRateLimiter(RateLimit{MaxConnections: 1, MaxSubscriptions: 2})
+	ctx := context.WithValue(context.Background(), quotaKey{},
+		&quota{limiter: limiter, client: "identity deployer"})
+	// Requests are not limited
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, AllowRequest(ctx))
+	}
+
+	release, err := AcquireConnection(ctx)
+	require.NoError(t, err)
+	_, err = AcquireConnection(ctx)
+	assert.Error(t, err)
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		releaseSub, err := AcquireSubscription(ctx)
+		require.NoError(t, err)
+		releases = append(releases, releaseSub)
+	}
+	_, err = AcquireSubscription(ctx)
+	assert.Error(t, err)
+	// Releasing twice only frees one
+	releases[0]()
+	releases[0]()
+	_, err = AcquireSubscription(ctx)
+	assert.NoError(t, err)
+	_, err = AcquireSubscription(ctx)
+	assert.Error(t, err)
+
+	// Clients holding connections are remembered
+	*now = now.Add(2 * rateLimitSweepInterval)
+	assert.NoError(t, AllowRequest(ctx))
+	assert.Len(t, limiter.clients, 1)
+	release()
+	_, err = AcquireConnection(ctx)
+	assert.NoError(t, err)
+}
+
+func TestNoRateLimiter(t *testing.T) {
+	assert.NoError(t, AllowRequest(context.Background()))
+	release, err := AcquireSubscription(context.Background())
+	assert.NoError(t, err)
+	release()
+}
@@ -16,15 +16,17 @@ package server
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
 	logging_types "github.com/hyperledger/burrow/logging/types"
-	cors "github.com/tommy351/gin-cors"
 	"gopkg.in/tylerb/graceful.v1"
 )
 
@@ -69,8 +71,20 @@ func (serveProcess *ServeProcess) Start() error {
 
 	config := serveProcess.config
 
-	ch := NewCORSMiddleware(config.CORS)
-	router.Use(gin.Recovery(), logHandler(serveProcess.logger), contentTypeMW, ch)
+	router.Use(gin.Recovery(), logHandler(serveProcess.logger), contentTypeMW)
+	if config.CORS.Enable {
+		router.Use(NewCORSMiddleware(config.CORS))
+	}
+	if config.Auth.Enable {
+		authenticator, err := NewAuthenticator(config.Auth)
+		if err != nil {
+			return err
+		}
+		router.Use(authenticator.Middleware())
+	}
+	if config.RateLimit.Enabled() {
+		router.Use(NewRateLimiter(config.RateLimit).Middleware())
+	}
 
 	address := config.Bind.Address
 	port := config.Bind.Port
@@ -109,12 +123,27 @@ func (serveProcess *ServeProcess) Start() error {
 			tConfig.NextProtos = []string{"http/1.1"}
 		}
 
-		var tErr error
-		tConfig.Certificates = make([]tls.Certificate, 1)
-		tConfig.Certificates[0], tErr = tls.LoadX509KeyPair(config.TLS.CertPath, config.TLS.KeyPath)
+		certificates, tErr := NewCertificateStore(config.TLS, serveProcess.logger)
 		if tErr != nil {
 			return tErr
 		}
+		tConfig.GetCertificate = certificates.GetCertificate
+		if config.TLS.ReloadInterval > 0 {
+			go certificates.Watch(config.TLS.ReloadInterval, srv.StopChan())
+		}
+
+		if config.TLS.ClientCAPath != "" {
+			caPEM, err := ioutil.ReadFile(config.TLS.ClientCAPath)
+			if err != nil {
+				return err
+			}
+			tConfig.ClientCAs = x509.NewCertPool()
+			if !tConfig.ClientCAs.AppendCertsFromPEM(caPEM) {
+				return fmt.Errorf("No certificates found in %s", config.TLS.ClientCAPath)
+			}
+			// Clients may still authenticate with bearer tokens instead
+			tConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
 
 		lst = tls.NewListener(l, tConfig)
 	} else {
@@ -222,7 +251,8 @@ func NewServeProcess(config *ServerConfig, logger logging_types.InfoTraceLogger,
 		startListenChans: startListeners,
 		stopListenChans:  stopListeners,
 		srv:              nil,
-		logger:           logging.WithScope(logger, "ServeProcess"),
+		logger: logging.WithScope(logging.WithSubsystem(logger,
+			structure.RPCSubsystem), "ServeProcess"),
 	}
 	return sp, nil
 }
@@ -250,18 +280,6 @@ func logHandler(logger logging_types.InfoTraceLogger) gin.HandlerFunc {
 	}
 }
 
-func NewCORSMiddleware(options CORS) gin.HandlerFunc {
-	o := cors.Options{
-		AllowCredentials: options.AllowCredentials,
-		AllowHeaders:     options.AllowHeaders,
-		AllowMethods:     options.AllowMethods,
-		AllowOrigins:     options.AllowOrigins,
-		ExposeHeaders:    options.ExposeHeaders,
-		MaxAge:           time.Duration(options.MaxAge),
-	}
-	return cors.Middleware(o)
-}
-
 // Just a catch-all for POST requests right now. Only allow default charset (utf8).
 func contentTypeMW(c *gin.Context) {
 	if c.Request.Method == "POST" && c.ContentType() != "application/json" {
@@ -16,6 +16,9 @@ package server
 
 import (
 	//"fmt"
+	"context"
+	"sort"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,3 +56,29 @@ func TestIdFull(t *testing.T) {
 	_, err := idPool.GetId()
 	assert.Error(t, err)
 }
+
+func TestSessionSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &WSSession{
+		ctx:           ctx,
+		cancel:        cancel,
+		subsMtx:       &sync.Mutex{},
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+	ctx1 := session.AddSubscription("sub1")
+	ctx2 := session.AddSubscription("sub2")
+	subIds := session.Subscriptions()
+	sort.Strings(subIds)
+	assert.Equal(t, []string{"sub1", "sub2"}, subIds)
+
+	// Removing one subscription leaves the other alone
+	assert.True(t, session.RemoveSubscription("sub1"))
+	assert.False(t, session.RemoveSubscription("sub1"))
+	assert.Error(t, ctx1.Err())
+	assert.NoError(t, ctx2.Err())
+	assert.Equal(t, []string{"sub2"}, session.Subscriptions())
+
+	// Closing the session ends the rest
+	cancel()
+	assert.Error(t, ctx2.Err())
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+)
+
+// Holds the certificates the RPC listener serves, choosing between them by
+// the server name clients ask for (SNI), and reloads them when their files
+// change so that renewed certificates are picked up without a restart
+type CertificateStore struct {
+	paths        []TLSCertificate
+	mtx          sync.RWMutex
+	certificates []*tls.Certificate
+	modified     []time.Time
+	logger       logging_types.InfoTraceLogger
+}
+
+// Loads the default certificate of config followed by any others it names
+func NewCertificateStore(config TLS, logger logging_types.InfoTraceLogger) (*CertificateStore, error) {
+	paths := []TLSCertificate{{CertPath: config.CertPath, KeyPath: config.KeyPath}}
+	names := make([]string, 0, len(config.Certificates))
+	for name := range config.Certificates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		paths = append(paths, config.Certificates[name])
+	}
+	store := &CertificateStore{
+		paths:  paths,
+		logger: logging.WithScope(logger, "CertificateStore"),
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Picks the first certificate valid for the server name the client asked for,
+// or the default certificate if none is or the client did not ask
+func (store *CertificateStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	store.mtx.RLock()
+	defer store.mtx.RUnlock()
+	if hello.ServerName != "" {
+		for _, certificate := range store.certificates {
+			if certificate.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return certificate, nil
+			}
+		}
+	}
+	return store.certificates[0], nil
+}
+
+// Reloads the certificates if any of their files have changed since they were
+// loaded, returning whether they were. If they cannot be loaded the ones
+// loaded before are kept.
+func (store *CertificateStore) Reload() (bool, error) {
+	modified, err := store.modTimes()
+	if err != nil {
+		return false, err
+	}
+	store.mtx.RLock()
+	changed := false
+	for i := range modified {
+		changed = changed || !modified[i].Equal(store.modified[i])
+	}
+	store.mtx.RUnlock()
+	if !changed {
+		return false, nil
+	}
+	return true, store.load()
+}
+
+// Checks for changed certificate files every interval until stop is closed
+func (store *CertificateStore) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := store.Reload()
+			if err != nil {
+				logging.InfoMsg(store.logger, "Could not reload TLS certificates, "+
+					"keeping those already loaded", "error", err)
+			} else if reloaded {
+				logging.InfoMsg(store.logger, "Reloaded TLS certificates")
+			}
+		}
+	}
+}
+
+func (store *CertificateStore) load() error {
+	// Read the times first so that a change made while loading is not missed
+	modified, err := store.modTimes()
+	if err != nil {
+		return err
+	}
+	certificates := make([]*tls.Certificate, len(store.paths))
+	for i, path := range store.paths {
+		certificate, err := tls.LoadX509KeyPair(path.CertPath, path.KeyPath)
+		if err != nil {
+			return fmt.Errorf("Could not load TLS certificate %s: %v", path.CertPath, err)
+		}
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("Could not parse TLS certificate %s: %v", path.CertPath, err)
+		}
+		certificates[i] = &certificate
+	}
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+	store.certificates = certificates
+	store.modified = modified
+	return nil
+}
+
+func (store *CertificateStore) modTimes() ([]time.Time, error) {
+	modified := make([]time.Time, 0, 2*len(store.paths))
+	for _, path := range store.paths {
+		for _, file := range []string{path.CertPath, path.KeyPath} {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, err
+			}
+			modified = append(modified, info.ModTime())
+		}
+	}
+	return modified, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes a self-signed certificate for names and its key to dir
+func writeCertificate(t *testing.T, dir, file string, serial int64,
+	names ...string) TLSCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certificate := TLSCertificate{
+		CertPath: filepath.Join(dir, file+".crt"),
+		KeyPath:  filepath.Join(dir, file+".key"),
+	}
+	require.NoError(t, ioutil.WriteFile(certificate.CertPath,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(certificate.KeyPath,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certificate
+}
+
+func serial(t *testing.T, store *CertificateStore, serverName string) int64 {
+	certificate, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	require.NoError(t, err)
+	return certificate.Leaf.SerialNumber.Int64()
+}
+
+func TestCertificateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "burrow-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defaultCertificate := writeCertificate(t, dir, "default", 1, "node.example.com")
+	store, err := NewCertificateStore(TLS{
+		CertPath: defaultCertificate.CertPath,
+		KeyPath:  defaultCertificate.KeyPath,
+		Certificates: map[string]TLSCertificate{
+			"other": writeCertificate(t, dir, "other", 2, "rpc.example.org", "*.example.org"),
+		},
+	}, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), serial(t, store, ""))
+	assert.Equal(t, int64(1), serial(t, store, "node.example.com"))
+	assert.Equal(t, int64(2), serial(t, store, "rpc.example.org"))
+	assert.Equal(t, int64(2), serial(t, store, "eu.example.org"))
+	assert.Equal(t, int64(1), serial(t, store, "unknown.example.net"))
+
+	reloaded, err := store.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// A renewed certificate
+	writeCertificate(t, dir, "default", 3, "node.example.com")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(defaultCertificate.CertPath, later, later))
+	reloaded, err = store.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, int64(3), serial(t, store, ""))
+
+	// A broken certificate is not served
+	require.NoError(t, ioutil.WriteFile(defaultCertificate.CertPath, []byte("garbage"), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(defaultCertificate.CertPath, later, later))
+	_, err = store.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, int64(3), serial(t, store, ""))
+}
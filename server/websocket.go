@@ -15,6 +15,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -23,6 +24,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/hyperledger/burrow/logging"
+	"github.com/hyperledger/burrow/logging/structure"
 	logging_types "github.com/hyperledger/burrow/logging/types"
 )
 
@@ -68,6 +70,7 @@ type WebSocketServer struct {
 // the server is at capacity.
 func NewWebSocketServer(maxSessions uint16, service WebSocketService,
 	logger logging_types.InfoTraceLogger) *WebSocketServer {
+	logger = logging.WithSubsystem(logger, structure.RPCSubsystem)
 	return &WebSocketServer{
 		maxSessions:    maxSessions,
 		sessionManager: NewSessionManager(maxSessions, service, logger),
@@ -85,7 +88,15 @@ func (wsServer *WebSocketServer) Start(config *ServerConfig, router *gin.Engine)
 		// TODO Will this be enough for massive "get blockchain" requests?
 		WriteBufferSize: int(config.WebSocket.WriteBufferSize),
 	}
-	wsServer.upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	var origins *OriginPolicy
+	if len(config.WebSocket.AllowedOrigins) > 0 {
+		origins = NewOriginPolicy(config.WebSocket.AllowedOrigins)
+	} else if config.CORS.Enable {
+		origins = NewOriginPolicy(config.CORS.AllowOrigins)
+	}
+	wsServer.upgrader.CheckOrigin = func(r *http.Request) bool {
+		return checkOrigin(origins, r)
+	}
 	router.GET(config.WebSocket.WebSocketEndpoint, wsServer.handleFunc)
 	wsServer.running = true
 }
@@ -110,25 +121,37 @@ func (wsServer *WebSocketServer) SessionManager() *SessionManager {
 func (wsServer *WebSocketServer) handleFunc(c *gin.Context) {
 	r := c.Request
 	w := c.Writer
+	release, qErr := AcquireConnection(r.Context())
+	if qErr != nil {
+		http.Error(w, qErr.Error(), 429)
+		return
+	}
 	// Upgrade to websocket.
 	wsConn, uErr := wsServer.upgrader.Upgrade(w, r, nil)
 
 	if uErr != nil {
+		release()
 		errMsg := "Failed to upgrade to websocket connection"
 		http.Error(w, fmt.Sprintf("%s: %s", errMsg, uErr.Error()), 400)
 		logging.InfoMsg(wsServer.logger, errMsg, "error", uErr)
 		return
 	}
 
-	session, cErr := wsServer.sessionManager.createSession(wsConn)
+	session, cErr := wsServer.sessionManager.createSession(wsConn, r.Context())
 
 	if cErr != nil {
+		release()
 		errMsg := "Failed to establish websocket connection"
 		http.Error(w, fmt.Sprintf("%s: %s", errMsg, cErr.Error()), 503)
 		logging.InfoMsg(wsServer.logger, errMsg, "error", cErr)
 		return
 	}
 
+	go func() {
+		<-session.Context().Done()
+		release()
+	}()
+
 	// Start the connection.
 	logging.InfoMsg(wsServer.logger, "New websocket connection",
 		"session_id", session.id)
@@ -154,6 +177,10 @@ type WSSession struct {
 	service        WebSocketService
 	opened         bool
 	closed         bool
+	ctx            context.Context
+	cancel         context.CancelFunc
+	subsMtx        *sync.Mutex
+	subscriptions  map[string]context.CancelFunc
 	logger         logging_types.InfoTraceLogger
 }
 
@@ -178,6 +205,53 @@ func (wsSession *WSSession) Id() uint {
 	return wsSession.id
 }
 
+// Get a context that is done once the session has been closed. Anything
+// scoped to the lifetime of the session (such as event subscriptions) should
+// be bound to it.
+func (wsSession *WSSession) Context() context.Context {
+	return wsSession.ctx
+}
+
+// Registers a subscription made over the session so that it can be removed
+// independently of any other subscriptions the session carries. The returned
+// context, which is derived from the session's, is done once the subscription
+// has been removed and should be used to make the subscription.
+func (wsSession *WSSession) AddSubscription(subId string) context.Context {
+	ctx, cancel := context.WithCancel(wsSession.ctx)
+	wsSession.subsMtx.Lock()
+	defer wsSession.subsMtx.Unlock()
+	if oldCancel, ok := wsSession.subscriptions[subId]; ok {
+		oldCancel()
+	}
+	wsSession.subscriptions[subId] = cancel
+	return ctx
+}
+
+// Removes a subscription made over this session, returning false if the
+// session does not carry a subscription with subId.
+func (wsSession *WSSession) RemoveSubscription(subId string) bool {
+	wsSession.subsMtx.Lock()
+	defer wsSession.subsMtx.Unlock()
+	cancel, ok := wsSession.subscriptions[subId]
+	if !ok {
+		return false
+	}
+	delete(wsSession.subscriptions, subId)
+	cancel()
+	return true
+}
+
+// Get the ids of the subscriptions currently carried by the session.
+func (wsSession *WSSession) Subscriptions() []string {
+	wsSession.subsMtx.Lock()
+	defer wsSession.subsMtx.Unlock()
+	subIds := make([]string, 0, len(wsSession.subscriptions))
+	for subId := range wsSession.subscriptions {
+		subIds = append(subIds, subId)
+	}
+	return subIds
+}
+
 // Starts the read and write pumps. Blocks on the former.
 // Notifies all the observers.
 func (wsSession *WSSession) Open() {
@@ -191,6 +265,7 @@ func (wsSession *WSSession) Open() {
 func (wsSession *WSSession) Close() {
 	if !wsSession.closed {
 		wsSession.closed = true
+		wsSession.cancel()
 		wsSession.wsConn.Close()
 		wsSession.sessionManager.removeSession(wsSession.id)
 		logging.InfoMsg(wsSession.logger, "Closing websocket connection.",
@@ -401,8 +476,10 @@ func (sessionManager *SessionManager) notifyClosed(session *WSSession) {
 	}
 }
 
-// Creates a new session and adds it to the manager.
-func (sessionManager *SessionManager) createSession(wsConn *websocket.Conn) (*WSSession, error) {
+// Creates a new session, which carries the identity and quota of the request
+// that opened it, and adds it to the manager.
+func (sessionManager *SessionManager) createSession(wsConn *websocket.Conn,
+	requestCtx context.Context) (*WSSession, error) {
 	// Check that the capacity hasn't been exceeded.
 	sessionManager.mtx.Lock()
 	defer sessionManager.mtx.Unlock()
@@ -412,6 +489,8 @@ func (sessionManager *SessionManager) createSession(wsConn *websocket.Conn) (*WS
 
 	// Create and start
 	newId, _ := sessionManager.idPool.GetId()
+	ctx, cancel := context.WithCancel(withQuotaOf(WithIdentity(context.Background(),
+		IdentityFromContext(requestCtx)), requestCtx))
 	conn := &WSSession{
 		sessionManager: sessionManager,
 		id:             newId,
@@ -419,6 +498,10 @@ func (sessionManager *SessionManager) createSession(wsConn *websocket.Conn) (*WS
 		writeChan:      make(chan []byte, maxMessageSize),
 		writeCloseChan: make(chan struct{}),
 		service:        sessionManager.service,
+		ctx:            ctx,
+		cancel:         cancel,
+		subsMtx:        &sync.Mutex{},
+		subscriptions:  make(map[string]context.CancelFunc),
 		logger: logging.WithScope(sessionManager.logger, "WSSession").
 			With("session_id", newId),
 	}
@@ -0,0 +1,233 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hyperledger/burrow/common/sanity"
+	dbm "github.com/tendermint/go-db"
+)
+
+type badgerDBBackend struct{}
+
+func (badgerDBBackend) Open(name, dir string) (dbm.DB, error) {
+	return NewBadgerDB(name, dir)
+}
+
+func (badgerDBBackend) Persistent() bool {
+	return true
+}
+
+// A dbm.DB kept in a badger key-value store, which keeps its keys in an LSM
+// tree apart from the log of values so that writing the nodes of the state's
+// merkle trees costs less than with leveldb. As with the other backends
+// errors from the store panic since the dbm.DB methods do not return them.
+type BadgerDB struct {
+	db *badger.DB
+}
+
+var _ dbm.DB = (*BadgerDB)(nil)
+
+// Opens (creating if need be) the badger store called name in dir
+func NewBadgerDB(name, dir string) (*BadgerDB, error) {
+	path := filepath.Join(dir, name+".badger")
+	options := badger.DefaultOptions
+	options.Dir = path
+	options.ValueDir = path
+	// Writes are batched where it matters, so each can be synced
+	options.SyncWrites = true
+	db, err := badger.Open(options)
+	if err != nil {
+		return nil, err
+	}
+	return &BadgerDB{db: db}, nil
+}
+
+func (bdb *BadgerDB) Get(key []byte) []byte {
+	var value []byte
+	err := bdb.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// The item's value is only valid within the transaction
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+	return value
+}
+
+func (bdb *BadgerDB) Set(key []byte, value []byte) {
+	bdb.SetSync(key, value)
+}
+
+func (bdb *BadgerDB) SetSync(key []byte, value []byte) {
+	err := bdb.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BadgerDB) Delete(key []byte) {
+	bdb.DeleteSync(key)
+}
+
+func (bdb *BadgerDB) DeleteSync(key []byte) {
+	err := bdb.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BadgerDB) Close() {
+	if err := bdb.db.Close(); err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BadgerDB) NewBatch() dbm.Batch {
+	return &badgerBatch{db: bdb.db}
+}
+
+func (bdb *BadgerDB) Print() {
+	iterator := bdb.Iterator()
+	defer iterator.Release()
+	for iterator.Next() {
+		fmt.Printf("[%X]:\t[%X]\n", iterator.Key(), iterator.Value())
+	}
+}
+
+// Iterates over the keys in order as of a read-only transaction, which is
+// discarded when the iterator is released
+func (bdb *BadgerDB) Iterator() dbm.Iterator {
+	txn := bdb.db.NewTransaction(false)
+	return &badgerIterator{
+		txn:      txn,
+		iterator: txn.NewIterator(badger.DefaultIteratorOptions),
+	}
+}
+
+func (bdb *BadgerDB) Stats() map[string]string {
+	lsmSize, valueLogSize := bdb.db.Size()
+	return map[string]string{
+		"database.type":           "badger",
+		"database.lsm_size":       fmt.Sprintf("%v", lsmSize),
+		"database.value_log_size": fmt.Sprintf("%v", valueLogSize),
+	}
+}
+
+type badgerIterator struct {
+	txn      *badger.Txn
+	iterator *badger.Iterator
+	started  bool
+	err      error
+}
+
+func (bi *badgerIterator) Next() bool {
+	if !bi.started {
+		bi.started = true
+		bi.iterator.Rewind()
+	} else {
+		bi.iterator.Next()
+	}
+	return bi.iterator.Valid()
+}
+
+func (bi *badgerIterator) Key() []byte {
+	return bi.iterator.Item().KeyCopy(nil)
+}
+
+func (bi *badgerIterator) Value() []byte {
+	value, err := bi.iterator.Item().ValueCopy(nil)
+	if err != nil {
+		bi.err = err
+	}
+	return value
+}
+
+func (bi *badgerIterator) Release() {
+	bi.iterator.Close()
+	bi.txn.Discard()
+}
+
+func (bi *badgerIterator) Error() error {
+	return bi.err
+}
+
+type badgerOperation struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// Writes its operations in as few transactions as badger allows
+type badgerBatch struct {
+	db         *badger.DB
+	operations []badgerOperation
+}
+
+func (bb *badgerBatch) Set(key, value []byte) {
+	bb.operations = append(bb.operations, badgerOperation{key: key, value: value})
+}
+
+func (bb *badgerBatch) Delete(key []byte) {
+	bb.operations = append(bb.operations, badgerOperation{key: key, delete: true})
+}
+
+func (bb *badgerBatch) Write() {
+	txn := bb.db.NewTransaction(true)
+	// The operations applied to txn
+	pending := 0
+	for i := 0; i < len(bb.operations); {
+		err := bb.operations[i].apply(txn)
+		if err == badger.ErrTxnTooBig && pending > 0 {
+			// Commit what fits and carry on in a new transaction
+			if err = txn.Commit(nil); err != nil {
+				sanity.PanicCrisis(err)
+			}
+			txn = bb.db.NewTransaction(true)
+			pending = 0
+			continue
+		} else if err != nil {
+			txn.Discard()
+			sanity.PanicCrisis(err)
+		}
+		pending++
+		i++
+	}
+	if err := txn.Commit(nil); err != nil {
+		sanity.PanicCrisis(err)
+	}
+	bb.operations = nil
+}
+
+func (operation badgerOperation) apply(txn *badger.Txn) error {
+	if operation.delete {
+		return txn.Delete(operation.key)
+	}
+	return txn.Set(operation.key, operation.value)
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+	"github.com/hyperledger/burrow/common/sanity"
+	dbm "github.com/tendermint/go-db"
+)
+
+// Every key is kept in the one bucket of the bolt file
+var boltBucket = []byte("burrow")
+
+type boltDBBackend struct{}
+
+func (boltDBBackend) Open(name, dir string) (dbm.DB, error) {
+	return NewBoltDB(name, dir)
+}
+
+func (boltDBBackend) Persistent() bool {
+	return true
+}
+
+// A dbm.DB kept in a bolt file, a B+tree written through copy-on-write
+// transactions that is slower to write than leveldb but never needs
+// compacting or recovering after a crash. As with the other backends errors
+// from the store panic since the dbm.DB methods do not return them.
+type BoltDB struct {
+	db *bolt.DB
+}
+
+var _ dbm.DB = (*BoltDB)(nil)
+
+// Opens (creating if need be) the bolt file called name in dir
+func NewBoltDB(name, dir string) (*BoltDB, error) {
+	db, err := bolt.Open(filepath.Join(dir, name+".bolt"), 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDB{db: db}, nil
+}
+
+func (bdb *BoltDB) Get(key []byte) []byte {
+	var value []byte
+	bdb.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid within the transaction
+		if v := tx.Bucket(boltBucket).Get(key); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return value
+}
+
+func (bdb *BoltDB) Set(key []byte, value []byte) {
+	bdb.SetSync(key, value)
+}
+
+func (bdb *BoltDB) SetSync(key []byte, value []byte) {
+	err := bdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BoltDB) Delete(key []byte) {
+	bdb.DeleteSync(key)
+}
+
+func (bdb *BoltDB) DeleteSync(key []byte) {
+	err := bdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BoltDB) Close() {
+	if err := bdb.db.Close(); err != nil {
+		sanity.PanicCrisis(err)
+	}
+}
+
+func (bdb *BoltDB) NewBatch() dbm.Batch {
+	return &boltBatch{db: bdb.db}
+}
+
+func (bdb *BoltDB) Print() {
+	iterator := bdb.Iterator()
+	defer iterator.Release()
+	for iterator.Next() {
+		fmt.Printf("[%X]:\t[%X]\n", iterator.Key(), iterator.Value())
+	}
+}
+
+// Iterates over the keys in order as of a read-only transaction, which is
+// rolled back when the iterator is released. Bolt cannot commit a write while
+// a read-only transaction is open in the same goroutine, so the iterator must
+// be released before writing.
+func (bdb *BoltDB) Iterator() dbm.Iterator {
+	tx, err := bdb.db.Begin(false)
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+	return &boltIterator{
+		tx:     tx,
+		cursor: tx.Bucket(boltBucket).Cursor(),
+	}
+}
+
+func (bdb *BoltDB) Stats() map[string]string {
+	stats := bdb.db.Stats()
+	return map[string]string{
+		"database.type":          "boltdb",
+		"database.free_pages":    fmt.Sprintf("%v", stats.FreePageN),
+		"database.pending_pages": fmt.Sprintf("%v", stats.PendingPageN),
+		"database.tx_count":      fmt.Sprintf("%v", stats.TxN),
+	}
+}
+
+type boltIterator struct {
+	tx      *bolt.Tx
+	cursor  *bolt.Cursor
+	started bool
+	key     []byte
+	value   []byte
+}
+
+func (bi *boltIterator) Next() bool {
+	if !bi.started {
+		bi.started = true
+		bi.key, bi.value = bi.cursor.First()
+	} else {
+		bi.key, bi.value = bi.cursor.Next()
+	}
+	return bi.key != nil
+}
+
+func (bi *boltIterator) Key() []byte {
+	return append([]byte{}, bi.key...)
+}
+
+func (bi *boltIterator) Value() []byte {
+	return append([]byte{}, bi.value...)
+}
+
+func (bi *boltIterator) Release() {
+	bi.tx.Rollback()
+}
+
+func (bi *boltIterator) Error() error {
+	return nil
+}
+
+// Writes its operations in a single transaction
+type boltBatch struct {
+	db         *bolt.DB
+	operations []func(bucket *bolt.Bucket) error
+}
+
+func (bb *boltBatch) Set(key, value []byte) {
+	bb.operations = append(bb.operations, func(bucket *bolt.Bucket) error {
+		return bucket.Put(key, value)
+	})
+}
+
+func (bb *boltBatch) Delete(key []byte) {
+	bb.operations = append(bb.operations, func(bucket *bolt.Bucket) error {
+		return bucket.Delete(key)
+	})
+}
+
+func (bb *boltBatch) Write() {
+	err := bb.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, operation := range bb.operations {
+			if err := operation(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		sanity.PanicCrisis(err)
+	}
+	bb.operations = nil
+}
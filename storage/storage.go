@@ -0,0 +1,123 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage opens the databases burrow keeps its state in with one of a
+// number of backends, all of which provide the Tendermint go-db interface the
+// merkle trees of the state are stored through.
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	dbm "github.com/tendermint/go-db"
+)
+
+const (
+	GoLevelDBBackend = "goleveldb"
+	// The name Tendermint gives goleveldb, kept so existing configuration works
+	LevelDBBackend  = dbm.LevelDBBackendStr
+	MemDBBackend    = dbm.MemDBBackendStr
+	BadgerDBBackend = "badger"
+	BoltDBBackend   = "boltdb"
+)
+
+// A database backend, which opens the named databases of a data directory
+type Backend interface {
+	// Opens the database called name in dir, creating it if need be
+	Open(name, dir string) (dbm.DB, error)
+	// Whether what is written to the databases the backend opens outlives
+	// the process
+	Persistent() bool
+}
+
+var backends = map[string]Backend{
+	GoLevelDBBackend: levelDBBackend{},
+	LevelDBBackend:   levelDBBackend{},
+	MemDBBackend:     memDBBackend{},
+	BadgerDBBackend:  badgerDBBackend{},
+	BoltDBBackend:    boltDBBackend{},
+}
+
+// Registers a backend under name, replacing any backend of that name
+func RegisterBackend(name string, backend Backend) {
+	backends[name] = backend
+}
+
+// Gets the backend registered under name
+func GetBackend(name string) (Backend, error) {
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("Database backend %s is not supported, "+
+			"supported backends are: %s", name, strings.Join(Backends(), ", "))
+	}
+	return backend, nil
+}
+
+// The names of the backends registered, in order
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Opens the database called name in dir with the named backend
+func NewDB(name, backend, dir string) (dbm.DB, error) {
+	b, err := GetBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	if b.Persistent() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("Could not create database directory %s: %v",
+				dir, err)
+		}
+	}
+	db, err := b.Open(name, dir)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open %s database %s in %s: %v",
+			backend, name, dir, err)
+	}
+	return db, nil
+}
+
+type levelDBBackend struct{}
+
+func (levelDBBackend) Open(name, dir string) (dbm.DB, error) {
+	// Unlike dbm.NewDB this returns rather than panics on errors
+	db, err := dbm.NewGoLevelDB(name, dir)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (levelDBBackend) Persistent() bool {
+	return true
+}
+
+type memDBBackend struct{}
+
+func (memDBBackend) Open(name, dir string) (dbm.DB, error) {
+	return dbm.NewMemDB(), nil
+}
+
+func (memDBBackend) Persistent() bool {
+	return false
+}
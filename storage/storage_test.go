@@ -0,0 +1,74 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackends(t *testing.T) {
+	for _, backend := range Backends() {
+		dir, err := ioutil.TempDir("", "burrow-storage")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		db, err := NewDB("test", backend, dir)
+		require.NoError(t, err, backend)
+		db.Set([]byte("b"), []byte("2"))
+		db.SetSync([]byte("a"), []byte("1"))
+		assert.Equal(t, []byte("1"), db.Get([]byte("a")), backend)
+		assert.Nil(t, db.Get([]byte("c")), backend)
+		db.Delete([]byte("b"))
+		assert.Nil(t, db.Get([]byte("b")), backend)
+
+		batch := db.NewBatch()
+		batch.Set([]byte("c"), []byte("3"))
+		batch.Set([]byte("d"), []byte("4"))
+		batch.Delete([]byte("a"))
+		// Nothing is written until the batch is
+		assert.Nil(t, db.Get([]byte("c")), backend)
+		batch.Write()
+
+		iterator := db.Iterator()
+		var keys, values []string
+		for iterator.Next() {
+			keys = append(keys, string(iterator.Key()))
+			values = append(values, string(iterator.Value()))
+		}
+		assert.NoError(t, iterator.Error(), backend)
+		iterator.Release()
+		assert.Equal(t, []string{"c", "d"}, keys, backend)
+		assert.Equal(t, []string{"3", "4"}, values, backend)
+		db.Close()
+
+		if b, _ := GetBackend(backend); b.Persistent() {
+			// What was written outlives the database being closed
+			db, err = NewDB("test", backend, dir)
+			require.NoError(t, err, backend)
+			assert.Equal(t, []byte("4"), db.Get([]byte("d")), backend)
+			db.Close()
+		}
+	}
+}
+
+func TestUnsupportedBackend(t *testing.T) {
+	_, err := NewDB("test", "rocksdb", os.TempDir())
+	assert.Error(t, err)
+}
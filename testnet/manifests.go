@@ -0,0 +1,171 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testnet
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/tendermint/go-wire"
+)
+
+// Each node runs in its own container with its working directory mounted at
+// the working directory of the burrow image
+const dockerComposeTemplate = `version: "3"
+services:
+{{- range .Nodes}}
+  {{.Name}}:
+    image: "{{$.Spec.DockerImage}}"
+    volumes:
+      - ./{{.Name}}:/home/monax/.monax
+    ports:
+      - "{{.APIPort}}:{{.APIPort}}"
+      - "{{.PeerPort}}:{{.PeerPort}}"
+      - "{{.RPCPort}}:{{.RPCPort}}"
+{{- end}}
+`
+
+// Each node has a Secret holding its configuration, genesis file and key, which
+// an init container copies to the working directory, since the key file is
+// written to as the node signs, and a Service by which its peers dial it. The
+// state of a node is lost when its pod is deleted.
+const kubernetesTemplate = `{{- range .Nodes}}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.Name}}
+  labels:
+    app: burrow
+    chain_id: "{{$.Spec.ChainId}}"
+stringData:
+  config.toml: |
+{{indent 4 (printf "%s" .Config)}}
+  genesis.json: |
+{{indent 4 (printf "%s" $.Genesis)}}
+  priv_validator.json: |
+{{indent 4 (privValidatorJSON .)}}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+  labels:
+    app: burrow
+    chain_id: "{{$.Spec.ChainId}}"
+spec:
+  selector:
+    app: burrow
+    node: {{.Name}}
+  ports:
+    - name: api
+      port: {{.APIPort}}
+    - name: peer
+      port: {{.PeerPort}}
+    - name: rpc
+      port: {{.RPCPort}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+  labels:
+    app: burrow
+    chain_id: "{{$.Spec.ChainId}}"
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: burrow
+      node: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app: burrow
+        node: {{.Name}}
+    spec:
+      initContainers:
+        - name: config
+          image: "{{$.Spec.DockerImage}}"
+          command: ["sh", "-c", "cp /config/* /home/monax/.monax/"]
+          volumeMounts:
+            - name: config
+              mountPath: /config
+            - name: work
+              mountPath: /home/monax/.monax
+      containers:
+        - name: burrow
+          image: "{{$.Spec.DockerImage}}"
+          ports:
+            - containerPort: {{.APIPort}}
+            - containerPort: {{.PeerPort}}
+            - containerPort: {{.RPCPort}}
+          volumeMounts:
+            - name: work
+              mountPath: /home/monax/.monax
+      volumes:
+        - name: config
+          secret:
+            secretName: {{.Name}}
+        - name: work
+          emptyDir: {}
+{{- end}}
+`
+
+var manifestFuncs = template.FuncMap{
+	"indent": indent,
+	"privValidatorJSON": func(node *Node) string {
+		return string(wire.JSONBytesPretty(node.PrivValidator))
+	},
+}
+
+var dockerComposeManifest = template.Must(template.New("dockerCompose").
+	Funcs(manifestFuncs).Parse(dockerComposeTemplate))
+
+var kubernetesManifest = template.Must(template.New("kubernetes").
+	Funcs(manifestFuncs).Parse(kubernetesTemplate))
+
+// A docker-compose file running each node in its own container
+func (testnet *Testnet) DockerCompose() ([]byte, error) {
+	return executeManifest(dockerComposeManifest, testnet)
+}
+
+// Kubernetes resources running each node in its own pod
+func (testnet *Testnet) Kubernetes() ([]byte, error) {
+	return executeManifest(kubernetesManifest, testnet)
+}
+
+func executeManifest(manifest *template.Template, testnet *Testnet) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := manifest.Execute(buffer, testnet); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Indents each line of s by n spaces for a YAML block scalar
+func indent(n int, s string) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,258 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testnet generates the configuration of every node of a test network
+// of validators, and manifests to run them with docker-compose or Kubernetes.
+package testnet
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/files"
+	"github.com/hyperledger/burrow/genesis"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The ports of the first node. Each next node listens on these plus PortStride
+// so that all the nodes can share a host.
+const (
+	DefaultAPIPort  = config.DefaultBindPort
+	DefaultPeerPort = 46656
+	DefaultRPCPort  = 46657
+	PortStride      = 10
+)
+
+const DefaultDockerImage = "hyperledger/burrow:latest"
+
+type Spec struct {
+	ChainId    string
+	Validators int
+	// Accounts in addition to those of the validators, the first of which has
+	// all the permissions
+	Accounts int
+	// The balance of each account, of which validators bond Bonded
+	Balance int64
+	Bonded  int64
+	// The hosts the nodes dial each other at. If empty each node is dialled at
+	// its NodeName, which is the name of its service in the manifests.
+	Hosts       []string
+	DockerImage string
+}
+
+type Node struct {
+	Name          string
+	Host          string
+	APIPort       int
+	PeerPort      int
+	RPCPort       int
+	PrivValidator *tm_types.PrivValidator
+	Config        []byte
+}
+
+type Testnet struct {
+	Spec     Spec
+	Genesis  []byte
+	Nodes    []*Node
+	Accounts []*acm.PrivAccount
+}
+
+func NodeName(index int) string {
+	return fmt.Sprintf("node%d", index)
+}
+
+// Generates new keys for the validators and accounts of spec, and the genesis
+// file and the configuration of each node
+func Generate(spec Spec) (*Testnet, error) {
+	if spec.ChainId == "" {
+		return nil, fmt.Errorf("A testnet needs a chain id")
+	}
+	if spec.Validators < 1 {
+		return nil, fmt.Errorf("A testnet needs at least one validator")
+	}
+	if spec.Accounts < 0 {
+		return nil, fmt.Errorf("Cannot have %d accounts", spec.Accounts)
+	}
+	if spec.Bonded <= 0 || spec.Balance < spec.Bonded {
+		return nil, fmt.Errorf("Validators must bond a positive amount that " +
+			"is no more than the balance of their accounts")
+	}
+	if len(spec.Hosts) > 0 && len(spec.Hosts) != spec.Validators {
+		return nil, fmt.Errorf("Have %d hosts for %d validators",
+			len(spec.Hosts), spec.Validators)
+	}
+	if spec.DockerImage == "" {
+		spec.DockerImage = DefaultDockerImage
+	}
+	testnet := &Testnet{
+		Spec:     spec,
+		Nodes:    make([]*Node, spec.Validators),
+		Accounts: make([]*acm.PrivAccount, spec.Accounts),
+	}
+	var genesisAccounts []*genesis.GenesisAccount
+	genesisValidators := make([]*genesis.GenesisValidator, spec.Validators)
+	for i := range testnet.Nodes {
+		privAccount := acm.GenPrivAccount()
+		node := &Node{
+			Name:     NodeName(i),
+			Host:     NodeName(i),
+			APIPort:  DefaultAPIPort + i*PortStride,
+			PeerPort: DefaultPeerPort + i*PortStride,
+			RPCPort:  DefaultRPCPort + i*PortStride,
+			PrivValidator: &tm_types.PrivValidator{
+				Address: privAccount.Address,
+				PubKey:  privAccount.PubKey,
+				PrivKey: privAccount.PrivKey,
+			},
+		}
+		if len(spec.Hosts) > 0 {
+			node.Host = spec.Hosts[i]
+		}
+		pubKey := privAccount.PubKey.(crypto.PubKeyEd25519)
+		validator, err := genesis.NewGenesisValidator(spec.Bonded, node.Name,
+			privAccount.Address, spec.Bonded, "ed25519", pubKey[:])
+		if err != nil {
+			return nil, err
+		}
+		genesisValidators[i] = validator
+		genesisAccounts = append(genesisAccounts, genesis.NewGenesisAccount(
+			privAccount.Address, spec.Balance-spec.Bonded, node.Name,
+			&ptypes.DefaultAccountPermissions))
+		testnet.Nodes[i] = node
+	}
+	rootPermissions := ptypes.AccountPermissions{
+		Base: ptypes.BasePermissions{
+			Perms:  ptypes.AllPermFlags,
+			SetBit: ptypes.AllPermFlags,
+		},
+		Roles: []string{},
+	}
+	for i := range testnet.Accounts {
+		testnet.Accounts[i] = acm.GenPrivAccount()
+		name := fmt.Sprintf("participant_%d", i)
+		permissions := &ptypes.DefaultAccountPermissions
+		if i == 0 {
+			name = "root"
+			permissions = &rootPermissions
+		}
+		genesisAccounts = append(genesisAccounts, genesis.NewGenesisAccount(
+			testnet.Accounts[i].Address, spec.Balance, name, permissions))
+	}
+	genesisDoc, err := genesis.MakeGenesisDocFromAccounts(spec.ChainId,
+		genesisAccounts, genesisValidators)
+	if err != nil {
+		return nil, err
+	}
+	if testnet.Genesis, err = genesis.GetGenesisFileBytes(&genesisDoc); err != nil {
+		return nil, err
+	}
+	for i, node := range testnet.Nodes {
+		tendermintModule := &config.ConfigTendermint{
+			Moniker:     fmt.Sprintf("%s_%s", spec.ChainId, node.Name),
+			Seeds:       testnet.Seeds(i),
+			NodeAddress: listenAddress(node.PeerPort),
+			DBBackend:   config.DefaultDBBackend,
+		}
+		servers := &config.ConfigServers{
+			BindPort:             uint16(node.APIPort),
+			TendermintRPCAddress: listenAddress(node.RPCPort),
+		}
+		burrowMint := &config.ConfigBurrowMint{
+			DBBackend:      config.DefaultDBBackend,
+			TendermintHost: servers.TendermintRPCAddress,
+		}
+		node.Config, err = config.GetNodeConfigurationFileBytes(spec.ChainId,
+			tendermintModule, servers, burrowMint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return testnet, nil
+}
+
+// The addresses of the peers of the node at index, as the seeds of its
+// configuration
+func (testnet *Testnet) Seeds(index int) string {
+	var seeds []string
+	for i, node := range testnet.Nodes {
+		if i != index {
+			seeds = append(seeds, net.JoinHostPort(node.Host,
+				strconv.Itoa(node.PeerPort)))
+		}
+	}
+	return strings.Join(seeds, ",")
+}
+
+// Writes a working directory for each node, named by the node, plain key files
+// of the accounts to accounts, and the manifests to dir
+func (testnet *Testnet) Write(dir string) error {
+	for _, node := range testnet.Nodes {
+		nodeDir := path.Join(dir, node.Name)
+		if err := os.MkdirAll(nodeDir, 0700); err != nil {
+			return err
+		}
+		if err := files.WriteFileRW(path.Join(nodeDir, "config.toml"),
+			node.Config); err != nil {
+			return err
+		}
+		if err := files.WriteFileRW(path.Join(nodeDir, "genesis.json"),
+			testnet.Genesis); err != nil {
+			return err
+		}
+		if err := files.WriteFile(path.Join(nodeDir, "priv_validator.json"),
+			wire.JSONBytesPretty(node.PrivValidator), 0600); err != nil {
+			return err
+		}
+	}
+	if len(testnet.Accounts) > 0 {
+		accountsDir := path.Join(dir, "accounts")
+		if err := os.MkdirAll(accountsDir, 0700); err != nil {
+			return err
+		}
+		for _, privAccount := range testnet.Accounts {
+			if err := files.WriteFile(path.Join(accountsDir,
+				fmt.Sprintf("%X.json", privAccount.Address)),
+				wire.JSONBytesPretty(privAccount), 0600); err != nil {
+				return err
+			}
+		}
+	}
+	dockerCompose, err := testnet.DockerCompose()
+	if err != nil {
+		return err
+	}
+	if err := files.WriteFileRW(path.Join(dir, "docker-compose.yaml"),
+		dockerCompose); err != nil {
+		return err
+	}
+	kubernetes, err := testnet.Kubernetes()
+	if err != nil {
+		return err
+	}
+	return files.WriteFileRW(path.Join(dir, "kubernetes.yaml"), kubernetes)
+}
+
+func listenAddress(port int) string {
+	return net.JoinHostPort("0.0.0.0", strconv.Itoa(port))
+}
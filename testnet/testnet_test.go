@@ -0,0 +1,109 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/burrow/config"
+	"github.com/hyperledger/burrow/genesis"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec() Spec {
+	return Spec{
+		ChainId:    "testnet",
+		Validators: 3,
+		Accounts:   2,
+		Balance:    1000,
+		Bonded:     100,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tn, err := Generate(testSpec())
+	require.NoError(t, err)
+	require.Len(t, tn.Nodes, 3)
+
+	genDoc := genesis.GenesisDocFromJSON(tn.Genesis)
+	assert.Equal(t, "testnet", genDoc.ChainID)
+	require.Len(t, genDoc.Validators, 3)
+	assert.Len(t, genDoc.Accounts, 5)
+	for i, node := range tn.Nodes {
+		assert.Equal(t, node.PrivValidator.PubKey, genDoc.Validators[i].PubKey)
+	}
+
+	ports := make(map[int]bool)
+	for i, node := range tn.Nodes {
+		for _, port := range []int{node.APIPort, node.PeerPort, node.RPCPort} {
+			assert.False(t, ports[port], "port %d is used twice", port)
+			ports[port] = true
+		}
+		conf, err := config.ReadViperConfig(node.Config)
+		require.NoError(t, err)
+		assert.Equal(t, node.APIPort, conf.GetInt("servers.bind.port"))
+		assert.Equal(t, tn.Seeds(i), conf.GetString("tendermint.configuration.seeds"))
+	}
+	assert.Equal(t, "node1:46666,node2:46676", tn.Seeds(0))
+}
+
+func TestGenerateHosts(t *testing.T) {
+	spec := testSpec()
+	spec.Hosts = []string{"10.0.0.1", "10.0.0.2"}
+	_, err := Generate(spec)
+	assert.Error(t, err, "Should need a host for each validator")
+
+	spec.Hosts = append(spec.Hosts, "10.0.0.3")
+	tn, err := Generate(spec)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:46656,10.0.0.2:46666", tn.Seeds(2))
+
+	spec.Bonded = spec.Balance + 1
+	_, err = Generate(spec)
+	assert.Error(t, err, "Should not bond more than the balance")
+}
+
+func TestWrite(t *testing.T) {
+	tn, err := Generate(testSpec())
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "testnet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, tn.Write(dir))
+
+	for _, node := range tn.Nodes {
+		for _, file := range []string{"config.toml", "genesis.json", "priv_validator.json"} {
+			_, err := os.Stat(path.Join(dir, node.Name, file))
+			assert.NoError(t, err)
+		}
+	}
+	accounts, err := ioutil.ReadDir(path.Join(dir, "accounts"))
+	require.NoError(t, err)
+	assert.Len(t, accounts, 2)
+
+	dockerCompose, err := ioutil.ReadFile(path.Join(dir, "docker-compose.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerCompose), `"46676:46676"`)
+	kubernetes, err := ioutil.ReadFile(path.Join(dir, "kubernetes.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(kubernetes), "kind: Deployment"))
+	assert.Contains(t, string(kubernetes), `      seeds = "node0:46656,node1:46666"`)
+}
@@ -0,0 +1,257 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tracing of the time spent handling RPC requests and executing the txs they
+// send, recorded as spans that are exported to a tracing backend such as
+// Jaeger or Zipkin. Traces are continued from the W3C traceparent header of
+// requests and follow txs through the mempool and execution by their hashes.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The most txs whose trace is remembered until they are executed, after which
+// the oldest are forgotten
+const MaxTracedTxs = 10000
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+// Identifies a span, the parent of the spans started from it
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// Whether the context identifies a span, which the zero context does not
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// The context as a W3C traceparent header
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%x-%x-01", sc.TraceID[:], sc.SpanID[:])
+}
+
+// Parses a W3C traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceParent(header string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("Malformed traceparent '%s'", header)
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, fmt.Errorf("Malformed trace id in traceparent '%s'", header)
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, fmt.Errorf("Malformed span id in traceparent '%s'", header)
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("Traceparent '%s' has a zero id", header)
+	}
+	return sc, nil
+}
+
+// A finished span as it is exported
+type SpanData struct {
+	Context  SpanContext
+	ParentID SpanID
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Tags     map[string]string
+}
+
+// Exporters send finished spans to a tracing backend. Export must not block.
+type Exporter interface {
+	Export(span *SpanData)
+}
+
+// A span of time spent on an operation. The methods of a nil span do nothing
+// so that code can be instrumented whether or not tracing is enabled.
+type Span struct {
+	tracer *Tracer
+	data   SpanData
+	// The tx whose trace continues from this span until it is finished
+	txKey string
+}
+
+func (span *Span) Context() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+	return span.data.Context
+}
+
+func (span *Span) SetTag(key, value string) {
+	if span == nil {
+		return
+	}
+	if span.data.Tags == nil {
+		span.data.Tags = make(map[string]string)
+	}
+	span.data.Tags[key] = value
+}
+
+// Ends the span and exports it
+func (span *Span) Finish() {
+	if span == nil {
+		return
+	}
+	span.data.Duration = time.Since(span.data.Start)
+	span.tracer.finish(span)
+}
+
+// Starts spans and remembers the traces of txs. A Tracer without an exporter
+// starts no spans.
+type Tracer struct {
+	mtx      sync.Mutex
+	exporter Exporter
+	// The span the trace of each traced tx continues from, keyed by tx hash,
+	// and the hashes in the order they were traced
+	txContexts map[string]SpanContext
+	txKeys     []string
+}
+
+// The tracer of the node, which is enabled by giving it an exporter
+var DefaultTracer = NewTracer()
+
+func NewTracer() *Tracer {
+	return &Tracer{txContexts: make(map[string]SpanContext)}
+}
+
+// Sets the exporter of finished spans, or disables tracing when it is nil
+func (tracer *Tracer) SetExporter(exporter Exporter) {
+	tracer.mtx.Lock()
+	tracer.exporter = exporter
+	tracer.mtx.Unlock()
+}
+
+func (tracer *Tracer) Enabled() bool {
+	tracer.mtx.Lock()
+	defer tracer.mtx.Unlock()
+	return tracer.exporter != nil
+}
+
+// Starts a span that is a child of parent, or the root of a new trace when
+// parent is not valid. Returns nil when tracing is not enabled.
+func (tracer *Tracer) Start(name string, parent SpanContext) *Span {
+	if !tracer.Enabled() {
+		return nil
+	}
+	return tracer.start(name, parent)
+}
+
+func (tracer *Tracer) start(name string, parent SpanContext) *Span {
+	span := &Span{
+		tracer: tracer,
+		data: SpanData{
+			Name:  name,
+			Start: time.Now(),
+		},
+	}
+	if parent.IsValid() {
+		span.data.Context.TraceID = parent.TraceID
+		span.data.ParentID = parent.SpanID
+	} else {
+		rand.Read(span.data.Context.TraceID[:])
+	}
+	rand.Read(span.data.Context.SpanID[:])
+	return span
+}
+
+// Continues the trace of parent with the tx of txHash, so that the spans of
+// its execution are children of parent
+func (tracer *Tracer) TraceTx(txHash []byte, parent SpanContext) {
+	if !parent.IsValid() {
+		return
+	}
+	tracer.mtx.Lock()
+	defer tracer.mtx.Unlock()
+	if tracer.exporter == nil {
+		return
+	}
+	key := string(txHash)
+	if _, ok := tracer.txContexts[key]; !ok {
+		if len(tracer.txKeys) >= MaxTracedTxs {
+			delete(tracer.txContexts, tracer.txKeys[0])
+			tracer.txKeys = tracer.txKeys[1:]
+		}
+		tracer.txKeys = append(tracer.txKeys, key)
+	}
+	tracer.txContexts[key] = parent
+}
+
+// Starts a span of the tx of txHash if it is traced, otherwise returns nil.
+// Until the span is finished the spans started for the tx are its children.
+func (tracer *Tracer) StartTxSpan(txHash []byte, name string) *Span {
+	tracer.mtx.Lock()
+	defer tracer.mtx.Unlock()
+	key := string(txHash)
+	parent, ok := tracer.txContexts[key]
+	if !ok || tracer.exporter == nil {
+		return nil
+	}
+	span := tracer.start(name, parent)
+	span.txKey = key
+	tracer.txContexts[key] = span.Context()
+	return span
+}
+
+// Forgets the trace of the tx of txHash once it has been executed
+func (tracer *Tracer) ForgetTx(txHash []byte) {
+	tracer.mtx.Lock()
+	defer tracer.mtx.Unlock()
+	key := string(txHash)
+	if _, ok := tracer.txContexts[key]; !ok {
+		return
+	}
+	delete(tracer.txContexts, key)
+	for i, txKey := range tracer.txKeys {
+		if txKey == key {
+			tracer.txKeys = append(tracer.txKeys[:i], tracer.txKeys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (tracer *Tracer) finish(span *Span) {
+	tracer.mtx.Lock()
+	defer tracer.mtx.Unlock()
+	if span.txKey != "" {
+		// The trace of the tx continues from the parent of the span again
+		if sc, ok := tracer.txContexts[span.txKey]; ok && sc == span.Context() {
+			tracer.txContexts[span.txKey] = SpanContext{
+				TraceID: sc.TraceID,
+				SpanID:  span.data.ParentID,
+			}
+		}
+	}
+	if tracer.exporter != nil {
+		tracer.exporter.Export(&span.data)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collectingExporter struct {
+	sync.Mutex
+	spans []*SpanData
+}
+
+func (ce *collectingExporter) Export(span *SpanData) {
+	ce.Lock()
+	ce.spans = append(ce.spans, span)
+	ce.Unlock()
+}
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		sc.TraceParent())
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(header)
+		assert.Error(t, err, header)
+	}
+	// Later versions may add fields
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.NoError(t, err)
+}
+
+func TestDisabled(t *testing.T) {
+	tracer := NewTracer()
+	span := tracer.Start("disabled", SpanContext{})
+	assert.Nil(t, span)
+	// A nil span can be used
+	span.SetTag("key", "value")
+	span.Finish()
+	assert.False(t, span.Context().IsValid())
+	tracer.TraceTx([]byte("tx"), SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}})
+	assert.Nil(t, tracer.StartTxSpan([]byte("tx"), "tx"))
+}
+
+func TestTxSpans(t *testing.T) {
+	tracer := NewTracer()
+	exporter := new(collectingExporter)
+	tracer.SetExporter(exporter)
+
+	rpcSpan := tracer.Start("rpc", SpanContext{})
+	txHash := []byte("tx")
+	assert.Nil(t, tracer.StartTxSpan(txHash, "untraced"))
+	tracer.TraceTx(txHash, rpcSpan.Context())
+	rpcSpan.Finish()
+
+	deliverSpan := tracer.StartTxSpan(txHash, "DeliverTx")
+	evmSpan := tracer.StartTxSpan(txHash, "EVM")
+	evmSpan.SetTag("gas", "21000")
+	evmSpan.Finish()
+	deliverSpan.Finish()
+	// Later spans of the tx are children of the rpc span again
+	recheckSpan := tracer.StartTxSpan(txHash, "CheckTx")
+	recheckSpan.Finish()
+	tracer.ForgetTx(txHash)
+	assert.Nil(t, tracer.StartTxSpan(txHash, "forgotten"))
+
+	require.Len(t, exporter.spans, 4)
+	rpc, evm, deliver, recheck := exporter.spans[0], exporter.spans[1],
+		exporter.spans[2], exporter.spans[3]
+	for _, span := range exporter.spans {
+		assert.Equal(t, rpc.Context.TraceID, span.Context.TraceID)
+	}
+	assert.Equal(t, SpanID{}, rpc.ParentID)
+	assert.Equal(t, rpc.Context.SpanID, deliver.ParentID)
+	assert.Equal(t, deliver.Context.SpanID, evm.ParentID)
+	assert.Equal(t, rpc.Context.SpanID, recheck.ParentID)
+	assert.Equal(t, map[string]string{"gas": "21000"}, evm.Tags)
+}
+
+func TestTraceTxForgetsOldest(t *testing.T) {
+	tracer := NewTracer()
+	tracer.SetExporter(new(collectingExporter))
+	parent := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}}
+	for i := 0; i <= MaxTracedTxs; i++ {
+		tracer.TraceTx([]byte{byte(i >> 8), byte(i)}, parent)
+	}
+	assert.Nil(t, tracer.StartTxSpan([]byte{0, 0}, "oldest"))
+	assert.NotNil(t, tracer.StartTxSpan([]byte{0, 1}, "kept"))
+	assert.Len(t, tracer.txKeys, MaxTracedTxs)
+}
+
+func TestZipkinExporter(t *testing.T) {
+	received := make(chan []map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var spans []map[string]interface{}
+		json.Unmarshal(body, &spans)
+		received <- spans
+		w.WriteHeader(202)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer()
+	tracer.SetExporter(NewZipkinExporter(server.URL, "burrow"))
+	parent, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	span := tracer.Start("burrow.getAccount", parent)
+	span.SetTag("status", "ok")
+	span.Finish()
+
+	select {
+	case spans := <-received:
+		require.Len(t, spans, 1)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0]["traceId"])
+		assert.Equal(t, "00f067aa0ba902b7", spans[0]["parentId"])
+		assert.Equal(t, "burrow.getAccount", spans[0]["name"])
+		assert.Equal(t, map[string]interface{}{"serviceName": "burrow"},
+			spans[0]["localEndpoint"])
+		assert.Equal(t, map[string]interface{}{"status": "ok"}, spans[0]["tags"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for spans")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// Spans are sent in batches of at most this many, or after this interval
+	zipkinBatchSize     = 100
+	zipkinFlushInterval = time.Second
+	// Spans finished while this many are waiting to be sent are dropped
+	zipkinQueueSize = 10000
+)
+
+// Exports spans in the Zipkin v2 JSON format, which Jaeger collectors also
+// accept, by POSTing them to endpoint, for example
+// http://localhost:9411/api/v2/spans
+type ZipkinExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+	spans       chan *SpanData
+}
+
+func NewZipkinExporter(endpoint, serviceName string) *ZipkinExporter {
+	exporter := &ZipkinExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		spans:       make(chan *SpanData, zipkinQueueSize),
+	}
+	go exporter.run()
+	return exporter
+}
+
+func (exporter *ZipkinExporter) Export(span *SpanData) {
+	select {
+	case exporter.spans <- span:
+	default:
+	}
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func (exporter *ZipkinExporter) zipkinSpan(span *SpanData) *zipkinSpan {
+	zs := &zipkinSpan{
+		TraceID:       hex.EncodeToString(span.Context.TraceID[:]),
+		ID:            hex.EncodeToString(span.Context.SpanID[:]),
+		Name:          span.Name,
+		Timestamp:     span.Start.UnixNano() / int64(time.Microsecond),
+		Duration:      int64(span.Duration / time.Microsecond),
+		LocalEndpoint: zipkinEndpoint{ServiceName: exporter.serviceName},
+		Tags:          span.Tags,
+	}
+	if span.ParentID != (SpanID{}) {
+		zs.ParentID = hex.EncodeToString(span.ParentID[:])
+	}
+	// Zipkin drops spans that last no time at all
+	if zs.Duration == 0 {
+		zs.Duration = 1
+	}
+	return zs
+}
+
+func (exporter *ZipkinExporter) run() {
+	ticker := time.NewTicker(zipkinFlushInterval)
+	defer ticker.Stop()
+	var batch []*zipkinSpan
+	for {
+		select {
+		case span := <-exporter.spans:
+			batch = append(batch, exporter.zipkinSpan(span))
+			if len(batch) < zipkinBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		// Spans that cannot be sent are dropped rather than backing up
+		exporter.send(batch)
+		batch = nil
+	}
+}
+
+func (exporter *ZipkinExporter) send(batch []*zipkinSpan) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	response, err := exporter.client.Post(exporter.endpoint, "application/json",
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("Zipkin endpoint %s returned status %v",
+			exporter.endpoint, response.StatusCode)
+	}
+	return nil
+}
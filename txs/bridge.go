@@ -0,0 +1,143 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/hyperledger/burrow/word256"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+)
+
+// The most data of an event that can be attested, as the contract on Ethereum
+// pays for every byte it checks
+const MaxBridgeEventDataLength = 1024
+
+// Attests that the designated event Event was emitted on this chain. It is
+// signed by the validator key, and EthSignature signs the ID of the
+// attestation with the Ethereum key the validator bridges with, which is bound
+// to the validator by its first AttestTx. An AttestTx pays no fee, and each
+// validator attests an event once.
+type AttestTx struct {
+	PubKey crypto.PubKeyEd25519   `json:"pub_key"`
+	Event  core_types.BridgeEvent `json:"event"`
+	// r || s || v with v 27 or 28
+	EthSignature []byte                  `json:"eth_signature"`
+	Signature    crypto.SignatureEd25519 `json:"signature"`
+}
+
+// The event of the bridge log is, which is the log of a designated event
+func (log EventDataLog) BridgeEvent() core_types.BridgeEvent {
+	return core_types.BridgeEvent{
+		Height:  log.Height,
+		Address: log.Address.Postfix(20),
+		Topics:  log.Topics,
+		Data:    log.Data,
+	}
+}
+
+// The ID of the attestation of event on chainID, which the Ethereum keys of
+// validators sign, as keccak256(abi.encodePacked(keccak256(chainID), height,
+// address, topics, data)) with the height a uint256 and the address and topics
+// 32 bytes each, so that a contract on Ethereum computes the same ID
+func AttestationID(chainID string, event core_types.BridgeEvent) []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(sha3.Sha3([]byte(chainID)))
+	buf.Write(word256.Int64ToWord256(event.Height).Bytes())
+	buf.Write(word256.LeftPadBytes(event.Address, 32))
+	for _, topic := range event.Topics {
+		buf.Write(topic.Bytes())
+	}
+	buf.Write(event.Data)
+	return sha3.Sha3(buf.Bytes())
+}
+
+// Signs the ID of the attestation of event on chainID with the secp256k1
+// secret key seckey, giving the signature as ecrecover takes it
+func SignAttestation(chainID string, event core_types.BridgeEvent,
+	seckey []byte) ([]byte, error) {
+	sig, err := secp256k1.Sign(AttestationID(chainID, event), seckey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// Recovers the Ethereum address that signed the ID of the attestation of
+// tx.Event on chainID
+func (tx *AttestTx) EthAddress(chainID string) ([]byte, error) {
+	sig := make([]byte, secp256k1.SignatureLength)
+	copy(sig, tx.EthSignature)
+	sig[64] -= 27
+	pubkey, err := secp256k1.RecoverPubkey(AttestationID(chainID, tx.Event), sig)
+	if err != nil {
+		return nil, err
+	}
+	return sha3.Sha3(pubkey[1:])[12:], nil
+}
+
+// Checks the event is one that can be attested and that the Ethereum
+// signature is well formed, without checking whose it is
+func (tx *AttestTx) ValidateBasic() error {
+	event := tx.Event
+	if event.Height < 1 {
+		return fmt.Errorf("Event has height %v", event.Height)
+	}
+	if len(event.Address) != 20 {
+		return ErrTxInvalidAddress
+	}
+	// The first topic is the signature of the event, and a log has at most four
+	if len(event.Topics) < 1 || len(event.Topics) > 4 {
+		return fmt.Errorf("Event has %v topics but must have between 1 and 4",
+			len(event.Topics))
+	}
+	if len(event.Data) > MaxBridgeEventDataLength {
+		return fmt.Errorf("Event has %v bytes of data but at most %v can be "+
+			"attested", len(event.Data), MaxBridgeEventDataLength)
+	}
+	if len(tx.EthSignature) != secp256k1.SignatureLength ||
+		(tx.EthSignature[64] != 27 && tx.EthSignature[64] != 28) {
+		return fmt.Errorf("Ethereum signature must be r || s || v with v 27 or 28")
+	}
+	// Otherwise the same key could sign the attestation twice
+	if new(big.Int).SetBytes(tx.EthSignature[32:64]).Cmp(secp256k1.HalfN) > 0 {
+		return fmt.Errorf("Ethereum signature has the high s value")
+	}
+	return nil
+}
+
+func (tx *AttestTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"eth_signature":"%X","id":"%X"`,
+		TxTypeAttest, tx.EthSignature, AttestationID(chainID, tx.Event))), w, n, err)
+	wire.WriteTo([]byte(`,"pub_key":`), w, n, err)
+	wire.WriteTo(wire.JSONBytes(tx.PubKey), w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *AttestTx) String() string {
+	return Fmt("AttestTx{%X,%v,%X,%X}", tx.PubKey.Address(), tx.Event.Height,
+		tx.Event.Address, tx.EthSignature)
+}
@@ -0,0 +1,46 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"io"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+// The number of blocks after its height that a ConfidentialRead is accepted
+const ConfidentialReadWindow = 10
+
+// A request to a node to decrypt the blob Owner put at Key in the
+// confidential store, signed by the account reading it. The node decrypts the
+// blob only for the owner and the accounts it granted to read it, and only
+// until the chain is ConfidentialReadWindow blocks past Height, so that a
+// request seen by others cannot be replayed later.
+type ConfidentialRead struct {
+	Owner  []byte `json:"owner"`
+	Key    []byte `json:"key"`
+	Height int    `json:"height"`
+}
+
+func (read *ConfidentialRead) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"confidential_read":{"height":%v,"key":"%X","owner":"%X"}}`,
+		read.Height, read.Key, read.Owner)), w, n, err)
+}
+
+func (read *ConfidentialRead) String() string {
+	return Fmt("ConfidentialRead{%X/%X at %v}", read.Owner, read.Key, read.Height)
+}
@@ -0,0 +1,252 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	"github.com/hyperledger/burrow/rlp"
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+// An Ethereum transaction signed with secp256k1, as produced by Ethereum
+// wallets. It is executed as the CallTx returned by CallTx, from the account
+// whose address is that of the recovered key, but is identified by its
+// Ethereum hash. An EthTx is encoded as its RLP rather than with go-wire.
+type EthTx struct {
+	Nonce    uint64 `json:"nonce"`
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	// Empty to create a contract
+	To    []byte `json:"to"`
+	Value uint64 `json:"value"`
+	Data  []byte `json:"data"`
+	// Either 27 or 28, or chain ID * 2 + 35 or 36 when the signature is bound
+	// to a chain as described by EIP-155. Like R and S it is a big-endian
+	// integer, as the chain IDs of chains whose IDs are not numbers do not fit
+	// in 64 bits.
+	V []byte `json:"v"`
+	R []byte `json:"r"`
+	S []byte `json:"s"`
+}
+
+// Whether txBytes is an Ethereum transaction rather than a go-wire one, which
+// starts with a tx type
+func IsEthTx(txBytes []byte) bool {
+	// An RLP list
+	return len(txBytes) > 0 && txBytes[0] >= 0xc0
+}
+
+func DecodeEthTx(txBytes []byte) (*EthTx, error) {
+	value, err := rlp.Decode(txBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !value.IsList || len(value.List) != 9 {
+		return nil, fmt.Errorf("Ethereum transaction must be an RLP list of 9 items")
+	}
+	items := value.List
+	for _, item := range items {
+		if item.IsList {
+			return nil, fmt.Errorf("Ethereum transaction items must be RLP strings")
+		}
+	}
+	tx := &EthTx{
+		To:   items[3].Bytes,
+		Data: items[5].Bytes,
+		V:    items[6].Bytes,
+		R:    items[7].Bytes,
+		S:    items[8].Bytes,
+	}
+	for i, field := range []*uint64{&tx.Nonce, &tx.GasPrice, &tx.GasLimit} {
+		if *field, err = items[i].Uint64(); err != nil {
+			return nil, err
+		}
+	}
+	if tx.Value, err = items[4].Uint64(); err != nil {
+		return nil, err
+	}
+	for _, n := range items[6:] {
+		if _, err := n.BigInt(); err != nil {
+			return nil, err
+		}
+	}
+	if len(tx.To) != 0 && len(tx.To) != 20 {
+		return nil, ErrTxInvalidAddress
+	}
+	return tx, nil
+}
+
+// The RLP of the signed transaction
+func (tx *EthTx) RLP() []byte {
+	return rlp.EncodeList(tx.unsignedRLP(
+		rlp.EncodeBytes(tx.V), rlp.EncodeBytes(tx.R), rlp.EncodeBytes(tx.S))...)
+}
+
+// The Ethereum transaction hash
+func (tx *EthTx) Hash() []byte {
+	return sha3.Sha3(tx.RLP())
+}
+
+func (tx *EthTx) unsignedRLP(extra ...[]byte) [][]byte {
+	return append([][]byte{
+		rlp.EncodeUint64(tx.Nonce),
+		rlp.EncodeUint64(tx.GasPrice),
+		rlp.EncodeUint64(tx.GasLimit),
+		rlp.EncodeBytes(tx.To),
+		rlp.EncodeUint64(tx.Value),
+		rlp.EncodeBytes(tx.Data),
+	}, extra...)
+}
+
+// Writes the RLP that is hashed for the signature, which includes the chain
+// ID for EIP-155 signatures
+func (tx *EthTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	var signed []byte
+	if tx.legacy() {
+		signed = rlp.EncodeList(tx.unsignedRLP()...)
+	} else {
+		signed = rlp.EncodeList(tx.unsignedRLP(rlp.EncodeBigInt(EthChainID(chainID)),
+			rlp.EncodeBytes(nil), rlp.EncodeBytes(nil))...)
+	}
+	wire.WriteTo(signed, w, n, err)
+}
+
+func (tx *EthTx) signHash(chainID string) []byte {
+	var n int
+	var err error
+	buf := new(bytes.Buffer)
+	// Writing to a buffer does not error
+	tx.WriteSignBytes(chainID, buf, &n, &err)
+	return sha3.Sha3(buf.Bytes())
+}
+
+// Whether the signature is one from before EIP-155, which is not bound to a
+// chain
+func (tx *EthTx) legacy() bool {
+	return len(tx.V) == 1 && (tx.V[0] == 27 || tx.V[0] == 28)
+}
+
+// Signs the transaction with the secp256k1 private key seckey, binding the
+// signature to the chain as described by EIP-155
+func (tx *EthTx) Sign(chainID string, seckey []byte) error {
+	v := new(big.Int).Lsh(EthChainID(chainID), 1)
+	v.Add(v, big.NewInt(35))
+	tx.V = v.Bytes()
+	sig, err := secp256k1.Sign(tx.signHash(chainID), seckey)
+	if err != nil {
+		return err
+	}
+	if sig[64] > 1 {
+		// R overflowed the group order, which Ethereum cannot represent
+		return fmt.Errorf("Signature of Ethereum transaction has a recovery ID " +
+			"that V cannot represent")
+	}
+	tx.V = v.Add(v, big.NewInt(int64(sig[64]))).Bytes()
+	tx.R = new(big.Int).SetBytes(sig[:32]).Bytes()
+	tx.S = new(big.Int).SetBytes(sig[32:64]).Bytes()
+	return nil
+}
+
+// Recovers the address of the account that signed the transaction. As on
+// Ethereum since EIP-2, signatures whose s is in the upper half of the group
+// order are rejected, so that a transaction has a single valid signature.
+func (tx *EthTx) Sender(chainID string) ([]byte, error) {
+	var recoveryID uint64
+	v := new(big.Int).SetBytes(tx.V)
+	switch {
+	case tx.legacy():
+		recoveryID = uint64(tx.V[0] - 27)
+	case v.Cmp(big.NewInt(35)) >= 0:
+		signedChainID, parity := new(big.Int).DivMod(v.Sub(v, big.NewInt(35)),
+			big.NewInt(2), new(big.Int))
+		if signedChainID.Cmp(EthChainID(chainID)) != 0 {
+			return nil, fmt.Errorf("Ethereum transaction is signed for chain ID "+
+				"%v but the chain ID of %s is %v", signedChainID, chainID,
+				EthChainID(chainID))
+		}
+		recoveryID = parity.Uint64()
+	default:
+		return nil, ErrTxInvalidSignature
+	}
+	if len(tx.R) > 32 || len(tx.S) > 32 ||
+		new(big.Int).SetBytes(tx.S).Cmp(secp256k1.HalfN) > 0 {
+		return nil, ErrTxInvalidSignature
+	}
+	sig := make([]byte, secp256k1.SignatureLength)
+	copy(sig[32-len(tx.R):32], tx.R)
+	copy(sig[64-len(tx.S):64], tx.S)
+	sig[64] = byte(recoveryID)
+	pubkey, err := secp256k1.RecoverPubkey(tx.signHash(chainID), sig)
+	if err != nil {
+		return nil, ErrTxInvalidSignature
+	}
+	return sha3.Sha3(pubkey[1:])[12:], nil
+}
+
+// The CallTx with which the transaction is executed. It spends value plus the
+// gas price times the gas limit from the sender, paying the latter as the
+// fee, and its sequence is one more than the nonce since Ethereum nonces
+// start from zero.
+func (tx *EthTx) CallTx(chainID string) (*CallTx, error) {
+	sender, err := tx.Sender(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.GasLimit > math.MaxInt64 || tx.Value > math.MaxInt64 ||
+		tx.Nonce >= math.MaxInt32 {
+		return nil, fmt.Errorf("Ethereum transaction gas limit, value or nonce " +
+			"is too large")
+	}
+	if tx.GasPrice != 0 && tx.GasLimit > math.MaxInt64/tx.GasPrice {
+		return nil, fmt.Errorf("Ethereum transaction fee overflows")
+	}
+	fee := int64(tx.GasPrice * tx.GasLimit)
+	if int64(tx.Value) > math.MaxInt64-fee {
+		return nil, fmt.Errorf("Ethereum transaction value and fee overflow")
+	}
+	return &CallTx{
+		Input: &TxInput{
+			Address:  sender,
+			Amount:   int64(tx.Value) + fee,
+			Sequence: int(tx.Nonce) + 1,
+		},
+		Address:  tx.To,
+		GasLimit: int64(tx.GasLimit),
+		Fee:      fee,
+		Data:     tx.Data,
+	}, nil
+}
+
+func (tx *EthTx) String() string {
+	return Fmt("EthTx{%v -> %x: %x}", tx.Nonce, tx.To, tx.Data)
+}
+
+// The chain ID used by Ethereum to identify a chain, which is the Burrow
+// chain ID itself when it is a number and its hash otherwise
+func EthChainID(chainID string) *big.Int {
+	if n, err := strconv.ParseUint(chainID, 10, 64); err == nil {
+		return new(big.Int).SetUint64(n)
+	}
+	return new(big.Int).SetBytes(sha3.Sha3([]byte(chainID)))
+}
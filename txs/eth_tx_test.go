@@ -0,0 +1,150 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/secp256k1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The example transaction from EIP-155
+var (
+	ethTxHex = "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7" +
+		"6400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8" +
+		"997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	ethSeckey = bytes.Repeat([]byte{0x46}, 32)
+	ethSender = "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"
+)
+
+func TestEthTx(t *testing.T) {
+	txBytes, _ := hex.DecodeString(ethTxHex)
+	assert.True(t, IsEthTx(txBytes))
+	tx, err := DecodeTx(txBytes)
+	require.NoError(t, err)
+	ethTx, ok := tx.(*EthTx)
+	require.True(t, ok)
+	assert.Equal(t, uint64(9), ethTx.Nonce)
+	assert.Equal(t, uint64(20000000000), ethTx.GasPrice)
+	assert.Equal(t, uint64(21000), ethTx.GasLimit)
+	assert.Equal(t, bytes.Repeat([]byte{0x35}, 20), ethTx.To)
+	assert.Equal(t, uint64(1000000000000000000), ethTx.Value)
+	assert.Equal(t, []byte{37}, ethTx.V)
+
+	encoded, err := EncodeTx(ethTx)
+	require.NoError(t, err)
+	assert.Equal(t, txBytes, encoded)
+
+	sender, err := ethTx.Sender("1")
+	require.NoError(t, err)
+	assert.Equal(t, ethSender, hex.EncodeToString(sender))
+	// Signed for another chain
+	_, err = ethTx.Sender("2")
+	assert.Error(t, err)
+
+	// Signing gives the same signature
+	signed := *ethTx
+	signed.V, signed.R, signed.S = nil, nil, nil
+	require.NoError(t, signed.Sign("1", ethSeckey))
+	assert.Equal(t, ethTx, &signed)
+
+	// The sequence of the CallTx is one more than the nonce
+	callTx, err := ethTx.CallTx("1")
+	require.NoError(t, err)
+	assert.Equal(t, sender, callTx.Input.Address)
+	assert.Equal(t, 10, callTx.Input.Sequence)
+	assert.Equal(t, int64(20000000000*21000), callTx.Fee)
+	assert.Equal(t, int64(1000000000000000000+20000000000*21000), callTx.Input.Amount)
+	assert.Equal(t, ethTx.To, callTx.Address)
+	assert.Equal(t, int64(21000), callTx.GasLimit)
+
+	assert.Equal(t, "33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788",
+		hex.EncodeToString(TxHash("1", ethTx)))
+}
+
+func TestEthTxLargeChainID(t *testing.T) {
+	// Chain IDs that are not numbers are hashed to one too large for 64 bits
+	ethTx := &EthTx{GasLimit: 100000, Data: []byte{0x60, 0x00}}
+	require.NoError(t, ethTx.Sign(chainID, ethSeckey))
+	v := new(big.Int).SetBytes(ethTx.V)
+	v.Sub(v, big.NewInt(35)).Rsh(v, 1)
+	assert.Equal(t, EthChainID(chainID), v)
+	decoded, err := DecodeEthTx(ethTx.RLP())
+	require.NoError(t, err)
+	sender, err := decoded.Sender(chainID)
+	require.NoError(t, err)
+	assert.Equal(t, ethSender, hex.EncodeToString(sender))
+	_, err = decoded.Sender(chainID + "-fork")
+	assert.Error(t, err)
+
+	receipt := GenerateReceipt(chainID, decoded)
+	assert.Equal(t, uint8(1), receipt.CreatesContract)
+	assert.Equal(t, NewContractAddress(sender, 1), receipt.ContractAddr)
+}
+
+func TestEthTxLegacySignature(t *testing.T) {
+	// Signed before EIP-155, so valid on any chain
+	ethTx := &EthTx{GasLimit: 100000, Data: []byte{0x60, 0x00}, V: []byte{27}}
+	sig, err := secp256k1.Sign(ethTx.signHash(chainID), ethSeckey)
+	require.NoError(t, err)
+	ethTx.V = []byte{27 + sig[64]}
+	ethTx.R = new(big.Int).SetBytes(sig[:32]).Bytes()
+	ethTx.S = new(big.Int).SetBytes(sig[32:64]).Bytes()
+	decoded, err := DecodeEthTx(ethTx.RLP())
+	require.NoError(t, err)
+	for _, chainID := range []string{chainID, "1"} {
+		sender, err := decoded.Sender(chainID)
+		require.NoError(t, err)
+		assert.Equal(t, ethSender, hex.EncodeToString(sender))
+	}
+}
+
+func TestEthTxMalleableSignature(t *testing.T) {
+	txBytes, _ := hex.DecodeString(ethTxHex)
+	ethTx, err := DecodeEthTx(txBytes)
+	require.NoError(t, err)
+	// The same signature with s in the upper half of the group order recovers
+	// the same key but is rejected
+	ethTx.S = new(big.Int).Sub(secp256k1.N, new(big.Int).SetBytes(ethTx.S)).Bytes()
+	ethTx.V = []byte{38}
+	_, err = ethTx.Sender("1")
+	assert.Equal(t, ErrTxInvalidSignature, err)
+}
+
+func TestEthTxInvalid(t *testing.T) {
+	txBytes, _ := hex.DecodeString(ethTxHex)
+	ethTx, err := DecodeEthTx(txBytes)
+	require.NoError(t, err)
+	ethTx.V = []byte{29}
+	_, err = ethTx.Sender("1")
+	assert.Equal(t, ErrTxInvalidSignature, err)
+
+	ethTx.To = []byte{1, 2, 3}
+	_, err = DecodeEthTx(ethTx.RLP())
+	assert.Equal(t, ErrTxInvalidAddress, err)
+
+	ethTx.To = nil
+	ethTx.GasPrice = 1 << 40
+	ethTx.GasLimit = 1 << 40
+	require.NoError(t, ethTx.Sign("1", ethSeckey))
+	_, err = ethTx.CallTx("1")
+	assert.Error(t, err)
+}
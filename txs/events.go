@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"time"
 
+	core_types "github.com/hyperledger/burrow/core/types"
+	ptypes "github.com/hyperledger/burrow/permission/types"
 	. "github.com/hyperledger/burrow/word256"
 
 	"github.com/tendermint/go-wire"
@@ -36,8 +38,17 @@ func EventStringBond() string                   { return "Bond" }
 func EventStringUnbond() string                 { return "Unbond" }
 func EventStringRebond() string                 { return "Rebond" }
 func EventStringDupeout() string                { return "Dupeout" }
+func EventStringRotate() string                 { return "Rotate" }
+func EventStringIdentify() string               { return "Identify" }
+func EventStringRelay(chainID string) string    { return fmt.Sprintf("Relay/%s", chainID) }
+func EventStringPrivate(group []byte) string    { return fmt.Sprintf("Private/%X", group) }
+func EventStringAttest() string                 { return "Attest" }
+func EventStringAttestation(id []byte) string   { return fmt.Sprintf("Attestation/%X", id) }
+func EventStringGov() string                    { return "Gov" }
+func EventStringSlash() string                  { return "Slash" }
 func EventStringNewBlock() string               { return "NewBlock" }
 func EventStringFork() string                   { return "Fork" }
+func EventStringPendingTx() string              { return "PendingTx" }
 
 func EventStringNewRound() string         { return fmt.Sprintf("NewRound") }
 func EventStringTimeoutPropose() string   { return fmt.Sprintf("TimeoutPropose") }
@@ -49,6 +60,21 @@ func EventStringRelock() string           { return fmt.Sprintf("Relock") }
 func EventStringTimeoutWait() string      { return fmt.Sprintf("TimeoutWait") }
 func EventStringVote() string             { return fmt.Sprintf("Vote") }
 
+func EventStringNameRegExpiring(name string) string {
+	return fmt.Sprintf("NameReg/%s/Expiring", name)
+}
+func EventStringNameRegExpired(name string) string { return fmt.Sprintf("NameReg/%s/Expired", name) }
+func EventStringNameRegExpiry() string             { return "NameRegExpiry" }
+
+func EventStringPermissionChange() string { return "PermissionChange" }
+func EventStringAccPermissionChange(addr []byte) string {
+	return fmt.Sprintf("Acc/%X/PermissionChange", addr)
+}
+
+func EventStringProposal(proposalHash []byte) string {
+	return fmt.Sprintf("Proposal/%X", proposalHash)
+}
+
 //----------------------------------------
 
 const (
@@ -58,6 +84,12 @@ const (
 	EventDataTypeCall           = byte(0x04)
 	EventDataTypeLog            = byte(0x05)
 	EventDataTypeNewBlockHeader = byte(0x06)
+	EventDataTypePendingTx      = byte(0x07)
+	EventDataTypePermission     = byte(0x08)
+	EventDataTypeNameRegExpiry  = byte(0x09)
+	EventDataTypeProposal       = byte(0x0A)
+	EventDataTypeSlash          = byte(0x0B)
+	EventDataTypeDecodedLog     = byte(0x0C)
 
 	EventDataTypeRoundState = byte(0x11)
 	EventDataTypeVote       = byte(0x12)
@@ -75,6 +107,12 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{EventDataTx{}, EventDataTypeTx},
 	wire.ConcreteType{EventDataCall{}, EventDataTypeCall},
 	wire.ConcreteType{EventDataLog{}, EventDataTypeLog},
+	wire.ConcreteType{EventDataPendingTx{}, EventDataTypePendingTx},
+	wire.ConcreteType{EventDataPermission{}, EventDataTypePermission},
+	wire.ConcreteType{EventDataNameRegExpiry{}, EventDataTypeNameRegExpiry},
+	wire.ConcreteType{EventDataProposal{}, EventDataTypeProposal},
+	wire.ConcreteType{EventDataSlash{}, EventDataTypeSlash},
+	wire.ConcreteType{EventDataDecodedLog{}, EventDataTypeDecodedLog},
 	wire.ConcreteType{EventDataRoundState{}, EventDataTypeRoundState},
 	wire.ConcreteType{EventDataVote{}, EventDataTypeVote},
 )
@@ -122,6 +160,96 @@ type EventDataLog struct {
 	Height  int64     `json:"height"`
 }
 
+// EventDataDecodedLog is delivered to subscribers to log events in place of an
+// EventDataLog when the node knows the event of a contract the log is, from
+// the ABI registered for the contract or its database of event signatures
+type EventDataDecodedLog struct {
+	Address Word256                  `json:"address"`
+	Topics  []Word256                `json:"topics"`
+	Data    []byte                   `json:"data"`
+	Height  int64                    `json:"height"`
+	Event   *core_types.DecodedEvent `json:"event"`
+}
+
+// The log without its event
+func (log EventDataDecodedLog) Log() EventDataLog {
+	return EventDataLog{
+		Address: log.Address,
+		Topics:  log.Topics,
+		Data:    log.Data,
+		Height:  log.Height,
+	}
+}
+
+// EventDataPendingTx fires when a tx is checked for the mempool, both when it
+// arrives and when it is rechecked after each block that does not include it.
+// Exception is empty if the tx is in the mempool, otherwise it is why the tx
+// was rejected or dropped. ContractAddr is only set for a CallTx that would
+// create a contract.
+type EventDataPendingTx struct {
+	TxHash       []byte `json:"tx_hash"`
+	Tx           Tx     `json:"tx"`
+	ContractAddr []byte `json:"contract_addr"`
+	Exception    string `json:"exception"`
+}
+
+// EventDataPermission fires for each account whose permissions or roles are
+// changed, by a PermissionsTx or by a contract calling the Permissions
+// SNative. Granter made the change to the account at Address, which may be a
+// group or the global permissions account. Function is the PermArgs or SNative
+// function making the change, such as setBase. Permission and Value are the
+// permissions set and the value they are set to (false when they are unset),
+// and Role is the role or group given or taken away.
+type EventDataPermission struct {
+	Height     int64           `json:"height"`
+	TxHash     []byte          `json:"tx_hash"`
+	Granter    []byte          `json:"granter"`
+	Address    []byte          `json:"address"`
+	Function   string          `json:"function"`
+	Permission ptypes.PermFlag `json:"permission"`
+	Value      bool            `json:"value"`
+	Role       string          `json:"role"`
+}
+
+// EventDataNameRegExpiry fires when the block at Height is committed for a
+// name registry entry that expires at Expires, once when it comes within
+// NameRegExpiryWarningPeriod blocks of expiring and again when it expires,
+// when Expired is true. The owner can extend the entry with RenewName.
+type EventDataNameRegExpiry struct {
+	Name    string `json:"name"`
+	Owner   []byte `json:"owner"`
+	Expires int    `json:"expires"`
+	Height  int64  `json:"height"`
+	Expired bool   `json:"expired"`
+}
+
+// EventDataProposal fires for each vote for the proposal with ProposalHash,
+// including that of the proposer, with the number of Votes it has and its
+// State after the vote: "voting", "executed", or "failed" with the Error that
+// stopped its batch of txs.
+type EventDataProposal struct {
+	ProposalHash []byte `json:"proposal_hash"`
+	Name         string `json:"name"`
+	Height       int64  `json:"height"`
+	Voter        []byte `json:"voter"`
+	Votes        int    `json:"votes"`
+	State        string `json:"state"`
+	Error        string `json:"error"`
+}
+
+// EventDataSlash fires when the validator with Address is punished in the
+// block at Height for its offence at OffenceHeight, for the Reason given, such
+// as "double_sign". Slashed coins of its bond and unbondings are burnt and it
+// is jailed, out of the validator set, until ReleaseHeight.
+type EventDataSlash struct {
+	Address       []byte `json:"address"`
+	Height        int64  `json:"height"`
+	Reason        string `json:"reason"`
+	OffenceHeight int    `json:"offence_height"`
+	Slashed       int64  `json:"slashed"`
+	ReleaseHeight int    `json:"release_height"`
+}
+
 // We fire the most recent round state that led to the event
 // (ie. NewRound will have the previous rounds state)
 type EventDataRoundState struct {
@@ -150,5 +278,11 @@ func (_ EventDataNewBlockHeader) AssertIsEventData() {}
 func (_ EventDataTx) AssertIsEventData()             {}
 func (_ EventDataCall) AssertIsEventData()           {}
 func (_ EventDataLog) AssertIsEventData()            {}
+func (_ EventDataPendingTx) AssertIsEventData()      {}
+func (_ EventDataPermission) AssertIsEventData()     {}
+func (_ EventDataNameRegExpiry) AssertIsEventData()  {}
+func (_ EventDataProposal) AssertIsEventData()       {}
+func (_ EventDataSlash) AssertIsEventData()          {}
+func (_ EventDataDecodedLog) AssertIsEventData()     {}
 func (_ EventDataRoundState) AssertIsEventData()     {}
 func (_ EventDataVote) AssertIsEventData()           {}
@@ -0,0 +1,70 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"fmt"
+	"io"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+// A SendTx or CallTx, or a PriorityFeeTx for one, that cannot be included in
+// a block above ExpiresAt, so that once that block is committed a client can
+// sign the tx again without the risk of both being executed. As with a
+// PriorityFeeTx the inputs of the inner tx sign the sign bytes of the
+// ExpiringTx, which hold its expiry, and the expiry is carried by a tx of its
+// own type so that the binary encoding of the txs and inputs committed before
+// expiry was added is unchanged.
+type ExpiringTx struct {
+	ExpiresAt int `json:"expires_at"`
+	Tx        Tx  `json:"tx"`
+}
+
+// The inputs of the inner tx, which sign the ExpiringTx
+func (tx *ExpiringTx) Inputs() []*TxInput {
+	return innerInputs(tx)
+}
+
+func (tx *ExpiringTx) ValidateBasic() error {
+	if tx.ExpiresAt <= 0 {
+		return fmt.Errorf("Expiring tx must expire above height 0")
+	}
+	switch inner := tx.Tx.(type) {
+	case *SendTx, *CallTx:
+	case *PriorityFeeTx:
+		return inner.ValidateBasic()
+	default:
+		return fmt.Errorf("Expiring tx can only expire a SendTx, CallTx or "+
+			"PriorityFeeTx, not %T", tx.Tx)
+	}
+	return nil
+}
+
+func (tx *ExpiringTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	if tx.Tx == nil {
+		*err = fmt.Errorf("Expiring tx has no tx to expire")
+		return
+	}
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"expires_at":%v,"tx":`, TxTypeExpiring, tx.ExpiresAt)), w, n, err)
+	tx.Tx.WriteSignBytes(chainID, w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *ExpiringTx) String() string {
+	return Fmt("ExpiringTx{%v: %v}", tx.ExpiresAt, tx.Tx)
+}
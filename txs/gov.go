@@ -0,0 +1,170 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/burrow/genesis"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	"github.com/hyperledger/burrow/word256"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+// Changes to the parameters of a chain that are kept in its state. The fields
+// left nil are unchanged.
+type ChainParams struct {
+	// The gas each CallTx is given, which contracts see as the block gas limit
+	GasLimit *int64 `json:"gas_limit"`
+	// The longest tx in bytes that the chain accepts, 0 for no limit
+	MaxTxSize *int `json:"max_tx_size"`
+	// Replaces the base fee parameters, the base fee starts again from their
+	// initial base fee
+	Fees        *genesis.FeeParams   `json:"fees"`
+	GasSchedule *genesis.GasSchedule `json:"gas_schedule"`
+	// Must stay at least 1 so that the chain can still be governed
+	ProposalThreshold *int `json:"proposal_threshold"`
+	// Replaces the base permissions of the global permissions account, which
+	// are the defaults of accounts that do not set a permission
+	GlobalPermissions *ptypes.BasePermissions `json:"global_permissions"`
+	// Replaces the rewards of validators from the next block
+	Rewards *genesis.RewardParams `json:"rewards"`
+	// Replaces the upgrade the chain is planned to halt for, or cancels it when
+	// its height is 0
+	Upgrade *UpgradePlan `json:"upgrade"`
+	// Designates events for validators to attest for the bridge to Ethereum,
+	// or revokes events designated before
+	Bridge *BridgeParams `json:"bridge"`
+}
+
+// Nodes halt once they have committed the block before Height, and only a
+// binary whose version is Name executes Height and the blocks after it, so
+// that every node of a chain switches to a new version at the same block
+type UpgradePlan struct {
+	// The semantic version of the binary that takes over, such as 0.18.0
+	Name   string `json:"name"`
+	Height int    `json:"height"`
+}
+
+// The events designated for the bridge, each by the address of its contract and
+// the topic of its signature, which is the sha3 of a signature such as
+// Transfer(address,uint256)
+type BridgeParams struct {
+	Designate []*BridgeEventSpec `json:"designate"`
+	Revoke    []*BridgeEventSpec `json:"revoke"`
+}
+
+type BridgeEventSpec struct {
+	Address []byte          `json:"address"`
+	Topic   word256.Word256 `json:"topic"`
+}
+
+// Changes the parameters of the chain, and releases the jailed validators with
+// the addresses in Unjail. A GovTx has no input, so it can only be executed as
+// part of the batch of a proposal that has passed. The parameters in state
+// take effect from the next block, the global permissions and the events
+// designated for the bridge at once.
+type GovTx struct {
+	Params *ChainParams `json:"params"`
+	Unjail [][]byte     `json:"unjail"`
+}
+
+func NewGovTx(params *ChainParams) *GovTx {
+	return &GovTx{
+		Params: params,
+	}
+}
+
+// Checks the tx changes at least one parameter or unjails a validator, and
+// that the new values are in range. The gas schedule can only be checked
+// against the VM when executed.
+func (tx *GovTx) ValidateBasic() error {
+	for _, address := range tx.Unjail {
+		if len(address) != 20 {
+			return ErrTxInvalidAddress
+		}
+	}
+	params := tx.Params
+	if params == nil {
+		if len(tx.Unjail) == 0 {
+			return fmt.Errorf("GovTx changes no parameters")
+		}
+		return nil
+	}
+	if params.GasLimit == nil && params.MaxTxSize == nil &&
+		params.Fees == nil && params.GasSchedule == nil &&
+		params.ProposalThreshold == nil && params.GlobalPermissions == nil &&
+		params.Rewards == nil && params.Upgrade == nil && params.Bridge == nil {
+		return fmt.Errorf("GovTx changes no parameters")
+	}
+	if params.GasLimit != nil && *params.GasLimit < 1 {
+		return fmt.Errorf("Gas limit must be positive")
+	}
+	if params.MaxTxSize != nil && *params.MaxTxSize < 0 {
+		return fmt.Errorf("Max tx size must not be negative")
+	}
+	if params.Fees != nil && (params.Fees.InitialBaseFee < 0 ||
+		params.Fees.TargetTxsPerBlock < 0 || params.Fees.BaseFeeChangeDenominator < 0) {
+		return fmt.Errorf("Fee parameters must not be negative")
+	}
+	if params.ProposalThreshold != nil && *params.ProposalThreshold < 1 {
+		return fmt.Errorf("Proposal threshold must be at least 1")
+	}
+	if params.Rewards != nil && (params.Rewards.BlockReward < 0 ||
+		params.Rewards.FeePercent < 0 || params.Rewards.FeePercent > 100 ||
+		params.Rewards.ProposerPercent < 0 || params.Rewards.ProposerPercent > 100) {
+		return fmt.Errorf("Reward parameters must not be negative and their " +
+			"percentages must be at most 100")
+	}
+	if params.Upgrade != nil && (params.Upgrade.Height < 0 ||
+		(params.Upgrade.Height > 0 && params.Upgrade.Name == "")) {
+		return fmt.Errorf("Upgrade must be named and its height must not be " +
+			"negative")
+	}
+	if params.Bridge != nil {
+		if len(params.Bridge.Designate) == 0 && len(params.Bridge.Revoke) == 0 {
+			return fmt.Errorf("Bridge parameters designate and revoke no events")
+		}
+		for _, specs := range [][]*BridgeEventSpec{params.Bridge.Designate,
+			params.Bridge.Revoke} {
+			for _, spec := range specs {
+				if spec == nil || len(spec.Address) != 20 {
+					return ErrTxInvalidAddress
+				}
+			}
+		}
+	}
+	if params.GlobalPermissions != nil &&
+		(params.GlobalPermissions.Perms|params.GlobalPermissions.SetBit)&^ptypes.AllPermFlags != 0 {
+		return fmt.Errorf("Global permissions set unknown permissions")
+	}
+	return nil
+}
+
+func (tx *GovTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"params":`, TxTypeGov)), w, n, err)
+	wire.WriteJSON(tx.Params, w, n, err)
+	wire.WriteTo([]byte(`,"unjail":`), w, n, err)
+	wire.WriteJSON(tx.Unjail, w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *GovTx) String() string {
+	return Fmt("GovTx{%s,%X}", string(wire.JSONBytes(tx.Params)), tx.Unjail)
+}
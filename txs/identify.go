@@ -0,0 +1,89 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+)
+
+// The longest moniker a validator can register for its node
+const MaxMonikerLength = 64
+
+// Registers the network identity of the node of the validator with PubKey in
+// the node registry, so that peers can discover and authenticate each other
+// from chain state. It is signed by the validator key, and replaces the
+// identity the validator last registered, which had the sequence before
+// Sequence.
+type IdentifyTx struct {
+	PubKey   crypto.PubKeyEd25519 `json:"pub_key"`
+	Sequence int                  `json:"sequence"`
+	// The public key the node identifies itself with to its peers
+	NodeID []byte `json:"node_id"`
+	// Where peers can dial the node, as host:port
+	NetAddress string `json:"net_address"`
+	// The sha256 hash of the certificate the node serves TLS with, if any
+	TLSCertFingerprint []byte                  `json:"tls_cert_fingerprint"`
+	Moniker            string                  `json:"moniker"`
+	Signature          crypto.SignatureEd25519 `json:"signature"`
+}
+
+// Checks the identity is well formed, without checking that the node can be
+// reached at its address
+func (tx *IdentifyTx) ValidateBasic() error {
+	if tx.Sequence < 1 {
+		return fmt.Errorf("Sequence of IdentifyTx must be at least 1")
+	}
+	if len(tx.NodeID) != 32 {
+		return fmt.Errorf("Node ID must be a 32 byte public key")
+	}
+	host, port, err := net.SplitHostPort(tx.NetAddress)
+	if err != nil {
+		return fmt.Errorf("Net address %s is not host:port: %v", tx.NetAddress, err)
+	}
+	if portNumber, errP := strconv.ParseUint(port, 10, 16); host == "" ||
+		errP != nil || portNumber == 0 {
+		return fmt.Errorf("Net address %s does not have a host and a port",
+			tx.NetAddress)
+	}
+	if len(tx.TLSCertFingerprint) != 0 && len(tx.TLSCertFingerprint) != 32 {
+		return fmt.Errorf("TLS certificate fingerprint must be a 32 byte sha256 hash")
+	}
+	if len(tx.Moniker) > MaxMonikerLength {
+		return fmt.Errorf("Moniker is longer than %v bytes", MaxMonikerLength)
+	}
+	return nil
+}
+
+func (tx *IdentifyTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"moniker":%s,"net_address":%s,"node_id":"%X"`,
+		TxTypeIdentify, jsonEscape(tx.Moniker), jsonEscape(tx.NetAddress), tx.NodeID)), w, n, err)
+	wire.WriteTo([]byte(`,"pub_key":`), w, n, err)
+	wire.WriteTo(wire.JSONBytes(tx.PubKey), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"sequence":%v,"tls_cert_fingerprint":"%X"}]}`,
+		tx.Sequence, tx.TLSCertFingerprint)), w, n, err)
+}
+
+func (tx *IdentifyTx) String() string {
+	return Fmt("IdentifyTx{%X,%v,%X,%s,%s}", tx.PubKey.Address(), tx.Sequence,
+		tx.NodeID, tx.NetAddress, tx.Moniker)
+}
@@ -0,0 +1,240 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ripemd160"
+
+	acm "github.com/hyperledger/burrow/account"
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+)
+
+// The most signatories a multisig account may have
+const MaxMultisigPubKeys = 32
+
+var ErrTxMultisigThreshold = errors.New("Error multisig threshold not met")
+
+// A SendTx or CallTx whose input is a multisig account, signed by at least
+// Threshold of the PubKeys that may sign for it rather than by the input. The
+// address of a multisig account is the hash of its threshold and public keys,
+// so it is not recorded in the state and is given again with each tx.
+//
+// Each signatory signs the sign bytes of Tx, which are also the sign bytes of
+// the MultisigTx, so signatures can be collected offline in any order and
+// combined before the tx is broadcast. Signatures[i] is the signature of
+// PubKeys[i], or nil while it has not signed.
+type MultisigTx struct {
+	Threshold  int                `json:"threshold"`
+	PubKeys    []crypto.PubKey    `json:"pub_keys"`
+	Tx         Tx                 `json:"tx"`
+	Signatures []crypto.Signature `json:"signatures"`
+}
+
+// The address of the multisig account that threshold of pubKeys sign for
+func MultisigAddress(threshold int, pubKeys []crypto.PubKey) []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `{"pub_keys":[`)
+	for i, pubKey := range pubKeys {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(buf, `"%X"`, pubKey.Bytes())
+	}
+	fmt.Fprintf(buf, `],"threshold":%v}`, threshold)
+	hasher := ripemd160.New()
+	hasher.Write(buf.Bytes())
+	return hasher.Sum(nil)
+}
+
+// Makes a tx for threshold of pubKeys to sign in place of the input of tx at
+// their multisig address, which must be a SendTx or CallTx
+func NewMultisigTx(threshold int, pubKeys []crypto.PubKey, tx Tx) (*MultisigTx, error) {
+	multisigTx := &MultisigTx{
+		Threshold:  threshold,
+		PubKeys:    pubKeys,
+		Tx:         tx,
+		Signatures: make([]crypto.Signature, len(pubKeys)),
+	}
+	if err := multisigTx.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	return multisigTx, nil
+}
+
+// The address of the multisig account
+func (tx *MultisigTx) Address() []byte {
+	return MultisigAddress(tx.Threshold, tx.PubKeys)
+}
+
+// The input of the inner tx that is signed by the signatories
+func (tx *MultisigTx) Input() *TxInput {
+	address := tx.Address()
+	var inputs []*TxInput
+	switch inner := tx.Tx.(type) {
+	case *SendTx:
+		inputs = inner.Inputs
+	case *CallTx:
+		inputs = []*TxInput{inner.Input}
+	}
+	for _, input := range inputs {
+		if input != nil && bytes.Equal(input.Address, address) {
+			return input
+		}
+	}
+	return nil
+}
+
+// Checks that the signatories and inner tx are well formed, but not their
+// signatures
+func (tx *MultisigTx) ValidateBasic() error {
+	if len(tx.PubKeys) == 0 || len(tx.PubKeys) > MaxMultisigPubKeys {
+		return fmt.Errorf("Multisig account must have between 1 and %v "+
+			"signatories", MaxMultisigPubKeys)
+	}
+	if tx.Threshold < 1 || tx.Threshold > len(tx.PubKeys) {
+		return fmt.Errorf("Multisig threshold %v must be between 1 and the "+
+			"number of signatories", tx.Threshold)
+	}
+	if len(tx.Signatures) != len(tx.PubKeys) {
+		return fmt.Errorf("Multisig tx has %v signatures for %v signatories",
+			len(tx.Signatures), len(tx.PubKeys))
+	}
+	seen := make(map[string]bool, len(tx.PubKeys))
+	for _, pubKey := range tx.PubKeys {
+		if pubKey == nil {
+			return ErrTxUnknownPubKey
+		}
+		if seen[string(pubKey.Bytes())] {
+			return fmt.Errorf("Multisig signatory %X is given twice",
+				pubKey.Address())
+		}
+		seen[string(pubKey.Bytes())] = true
+	}
+	switch tx.Tx.(type) {
+	case *SendTx, *CallTx:
+	default:
+		return fmt.Errorf("Multisig tx can only sign for a SendTx or CallTx, "+
+			"not %T", tx.Tx)
+	}
+	if tx.Input() == nil {
+		return fmt.Errorf("Multisig tx has no input from %X", tx.Address())
+	}
+	return nil
+}
+
+// Adds the signature of pubKey, which must be one of the signatories
+func (tx *MultisigTx) AddSignature(chainID string, pubKey crypto.PubKey,
+	signature crypto.Signature) error {
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	for i, signatory := range tx.PubKeys {
+		if bytes.Equal(signatory.Bytes(), pubKey.Bytes()) {
+			if !pubKey.VerifyBytes(acm.SignBytes(chainID, tx), signature) {
+				return ErrTxInvalidSignature
+			}
+			tx.Signatures[i] = signature
+			return nil
+		}
+	}
+	return fmt.Errorf("%X is not a signatory of multisig account %X",
+		pubKey.Address(), tx.Address())
+}
+
+// Adds the signatures of other, which must be a copy of the same tx signed by
+// other signatories. The signatures are checked when the tx is verified.
+func (tx *MultisigTx) Combine(other *MultisigTx) error {
+	for _, multisigTx := range []*MultisigTx{tx, other} {
+		if err := multisigTx.ValidateBasic(); err != nil {
+			return err
+		}
+	}
+	// The same address has the same signatories in the same order
+	if !bytes.Equal(tx.Address(), other.Address()) ||
+		!bytes.Equal(acm.SignBytes("", tx.Tx), acm.SignBytes("", other.Tx)) {
+		return fmt.Errorf("Cannot combine the signatures of different multisig txs")
+	}
+	for i, signature := range other.Signatures {
+		if signature != nil && tx.Signatures[i] == nil {
+			tx.Signatures[i] = signature
+		}
+	}
+	return nil
+}
+
+// The number of signatories that have signed, whether or not their
+// signatures are valid
+func (tx *MultisigTx) SignatureCount() int {
+	count := 0
+	for _, signature := range tx.Signatures {
+		if signature != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Checks that at least Threshold signatories have signed and that all of the
+// signatures given are valid
+func (tx *MultisigTx) Verify(chainID string) error {
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	signBytes := acm.SignBytes(chainID, tx)
+	for i, signature := range tx.Signatures {
+		if signature != nil && !tx.PubKeys[i].VerifyBytes(signBytes, signature) {
+			return ErrTxInvalidSignature
+		}
+	}
+	if tx.SignatureCount() < tx.Threshold {
+		return ErrTxMultisigThreshold
+	}
+	return nil
+}
+
+func (tx *MultisigTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	if tx.Tx == nil {
+		*err = fmt.Errorf("Multisig tx has no tx to sign")
+		return
+	}
+	tx.Tx.WriteSignBytes(chainID, w, n, err)
+}
+
+func (tx *MultisigTx) String() string {
+	return Fmt("MultisigTx{%v of %v: %v}", tx.SignatureCount(), tx.Threshold, tx.Tx)
+}
+
+// Encodes a multisig tx as JSON to pass between signatories
+func MultisigTxJSON(tx *MultisigTx) []byte {
+	return wire.JSONBytesPretty(tx)
+}
+
+// Decodes a multisig tx encoded by MultisigTxJSON
+func DecodeMultisigTxJSON(txJSON []byte) (*MultisigTx, error) {
+	var err error
+	tx := new(MultisigTx)
+	wire.ReadJSON(tx, txJSON, &err)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
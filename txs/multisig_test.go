@@ -0,0 +1,89 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestMultisigTx(t *testing.T) {
+	var privAccounts []*acm.PrivAccount
+	var pubKeys []crypto.PubKey
+	for i := byte(1); i <= 3; i++ {
+		privKeyBytes := make([]byte, 64)
+		privKeyBytes[0] = i
+		privAccount := acm.GenPrivAccountFromPrivKeyBytes(privKeyBytes)
+		privAccounts = append(privAccounts, privAccount)
+		pubKeys = append(pubKeys, privAccount.PubKey)
+	}
+	address := MultisigAddress(2, pubKeys)
+	assert.Len(t, address, 20)
+	assert.NotEqual(t, address, MultisigAddress(1, pubKeys))
+	assert.NotEqual(t, address, MultisigAddress(2, pubKeys[:2]))
+
+	callTx := &CallTx{
+		Input:    &TxInput{Address: address, Amount: 10, Sequence: 1},
+		Address:  []byte("contract address...."),
+		GasLimit: 100,
+	}
+	_, err := NewMultisigTx(3, pubKeys[:2], callTx)
+	assert.Error(t, err, "threshold above the number of signatories")
+	_, err = NewMultisigTx(2, pubKeys[1:], callTx)
+	assert.Error(t, err, "input from another address")
+	tx, err := NewMultisigTx(2, pubKeys, callTx)
+	require.NoError(t, err)
+	assert.Equal(t, acm.SignBytes(chainID, callTx), acm.SignBytes(chainID, tx))
+
+	// Each signatory signs a copy
+	copies := make([]*MultisigTx, 3)
+	for i, privAccount := range privAccounts {
+		copies[i], err = DecodeMultisigTxJSON(MultisigTxJSON(tx))
+		require.NoError(t, err)
+		require.NoError(t, copies[i].AddSignature(chainID, privAccount.PubKey,
+			privAccount.Sign(chainID, copies[i])))
+		assert.Equal(t, ErrTxMultisigThreshold, copies[i].Verify(chainID))
+	}
+	assert.Equal(t, ErrTxInvalidSignature, copies[0].AddSignature(chainID,
+		privAccounts[0].PubKey, privAccounts[1].Sign(chainID, copies[0])))
+
+	require.NoError(t, copies[0].Combine(copies[2]))
+	assert.Equal(t, 2, copies[0].SignatureCount())
+	assert.NoError(t, copies[0].Verify(chainID))
+	assert.Error(t, copies[0].Verify("another chain"))
+
+	// Copies of another tx cannot be combined
+	other, err := NewMultisigTx(2, pubKeys, &CallTx{
+		Input:   &TxInput{Address: address, Amount: 10, Sequence: 2},
+		Address: callTx.Address,
+	})
+	require.NoError(t, err)
+	assert.Error(t, other.Combine(copies[1]))
+
+	// Multisig txs only sign for SendTxs and CallTxs
+	_, err = NewMultisigTx(2, pubKeys, &NameTx{Input: callTx.Input})
+	assert.Error(t, err)
+
+	txBytes, err := EncodeTx(copies[0])
+	require.NoError(t, err)
+	decoded, err := DecodeTx(txBytes)
+	require.NoError(t, err)
+	assert.Equal(t, TxHash(chainID, callTx), TxHash(chainID, decoded))
+	assert.NoError(t, decoded.(*MultisigTx).Verify(chainID))
+}
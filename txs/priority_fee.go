@@ -0,0 +1,107 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"fmt"
+	"io"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+// A SendTx or CallTx that pays PriorityFee of its fee to the proposer of the
+// block, burning the rest as usual. The inputs of Tx sign the sign bytes of
+// the PriorityFeeTx, which hold the priority fee and the sign bytes of Tx, so
+// neither the tx nor its priority fee can be executed without the other.
+//
+// The priority fee is carried by a tx of its own type, rather than a field of
+// SendTx and CallTx, so that the binary encoding of those is unchanged and
+// the txs in the blocks committed before priority fees can still be decoded.
+type PriorityFeeTx struct {
+	PriorityFee int64 `json:"priority_fee"`
+	Tx          Tx    `json:"tx"`
+}
+
+// The priority fee tx pays, which is 0 unless it is a PriorityFeeTx or an
+// ExpiringTx for one
+func PriorityFee(tx Tx) int64 {
+	switch tx := tx.(type) {
+	case *PriorityFeeTx:
+		return tx.PriorityFee
+	case *ExpiringTx:
+		return PriorityFee(tx.Tx)
+	}
+	return 0
+}
+
+// The SendTx or CallTx that tx pays a priority fee for or sets the expiry of,
+// whose inputs sign tx, and tx itself when it is neither a PriorityFeeTx nor an
+// ExpiringTx
+func InnerTx(tx Tx) Tx {
+	for {
+		switch wrapper := tx.(type) {
+		case *PriorityFeeTx:
+			tx = wrapper.Tx
+		case *ExpiringTx:
+			tx = wrapper.Tx
+		default:
+			return tx
+		}
+	}
+}
+
+// The inputs of the inner tx, which sign the PriorityFeeTx
+func (tx *PriorityFeeTx) Inputs() []*TxInput {
+	return innerInputs(tx)
+}
+
+func innerInputs(tx Tx) []*TxInput {
+	switch inner := InnerTx(tx).(type) {
+	case *SendTx:
+		return inner.Inputs
+	case *CallTx:
+		return []*TxInput{inner.Input}
+	}
+	return nil
+}
+
+func (tx *PriorityFeeTx) ValidateBasic() error {
+	if tx.PriorityFee < 0 {
+		return ErrTxInvalidAmount
+	}
+	switch tx.Tx.(type) {
+	case *SendTx, *CallTx:
+	default:
+		return fmt.Errorf("Priority fee tx can only pay for a SendTx or CallTx, "+
+			"not %T", tx.Tx)
+	}
+	return nil
+}
+
+func (tx *PriorityFeeTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	if tx.Tx == nil {
+		*err = fmt.Errorf("Priority fee tx has no tx to pay for")
+		return
+	}
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"priority_fee":%v,"tx":`, TxTypePriorityFee, tx.PriorityFee)), w, n, err)
+	tx.Tx.WriteSignBytes(chainID, w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *PriorityFeeTx) String() string {
+	return Fmt("PriorityFeeTx{%v: %v}", tx.PriorityFee, tx.Tx)
+}
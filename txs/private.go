@@ -0,0 +1,162 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+const (
+	// The length of the key a private group shares, an AES-256 key
+	PrivateGroupKeyLength = 32
+	// The length of the nonce a payload is encrypted under with AES-GCM
+	PrivateNonceLength = 12
+)
+
+// A CallTx whose payload, the contract called and the data it is called with,
+// is encrypted to a private group with the key the group shares. Every node
+// takes the fee, the input amount, and records the hash of the payload, but
+// only the nodes holding the key of the group decrypt the payload and execute
+// it against the private state they keep for the group. The contract called
+// is one created by a PrivateTx to the same group, or a PrivateTx without an
+// address creates one.
+type PrivateTx struct {
+	Input *TxInput `json:"input"`
+	// The ID of the group, from the key it shares
+	Group      []byte `json:"group"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// The payload of a PrivateTx, executed as a CallTx by the private group
+type PrivatePayload struct {
+	Address  []byte `json:"address"`
+	GasLimit int64  `json:"gas_limit"`
+	Data     []byte `json:"data"`
+}
+
+// The ID of the private group that shares key, which names the group in its
+// PrivateTxs without giving away the key
+func PrivateGroupID(key []byte) []byte {
+	return sha3.Sha3(key)
+}
+
+// Makes a PrivateTx from input that encrypts payload with the key of a
+// private group under a random nonce
+func NewPrivateTx(input *TxInput, key []byte,
+	payload *PrivatePayload) (*PrivateTx, error) {
+	aead, err := privateAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, PrivateNonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("Could not make nonce: %v", err)
+	}
+	group := PrivateGroupID(key)
+	return &PrivateTx{
+		Input: input,
+		Group: group,
+		Nonce: nonce,
+		// The group is authenticated so the payload cannot be moved to another
+		Ciphertext: aead.Seal(nil, nonce, wire.BinaryBytes(payload), group),
+	}, nil
+}
+
+// Decrypts the payload of tx with the key of its group
+func (tx *PrivateTx) Decrypt(key []byte) (*PrivatePayload, error) {
+	if !bytes.Equal(PrivateGroupID(key), tx.Group) {
+		return nil, fmt.Errorf("Key is not that of private group %X", tx.Group)
+	}
+	aead, err := privateAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, tx.Nonce, tx.Ciphertext, tx.Group)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decrypt payload of PrivateTx: %v", err)
+	}
+	payload := new(PrivatePayload)
+	if err := wire.ReadBinaryBytes(plaintext, payload); err != nil {
+		return nil, fmt.Errorf("Could not read payload of PrivateTx: %v", err)
+	}
+	if len(payload.Address) != 0 && len(payload.Address) != 20 {
+		return nil, fmt.Errorf("Payload of PrivateTx calls address %X, which "+
+			"is not 20 bytes", payload.Address)
+	}
+	return payload, nil
+}
+
+// The hash of the encrypted payload of tx, which nodes outside its group
+// record in place of the private state it changes
+func (tx *PrivateTx) PayloadHash() []byte {
+	return sha3.Sha3(append(append([]byte{}, tx.Nonce...), tx.Ciphertext...))
+}
+
+func (tx *PrivateTx) ValidateBasic() error {
+	if tx.Input == nil {
+		return fmt.Errorf("PrivateTx must have an input")
+	}
+	if err := tx.Input.ValidateBasic(); err != nil {
+		return err
+	}
+	if len(tx.Group) != 32 {
+		return fmt.Errorf("Private group is %v bytes but group IDs are 32 bytes",
+			len(tx.Group))
+	}
+	if len(tx.Nonce) != PrivateNonceLength {
+		return fmt.Errorf("Nonce is %v bytes but must be %v bytes", len(tx.Nonce),
+			PrivateNonceLength)
+	}
+	if len(tx.Ciphertext) == 0 {
+		return fmt.Errorf("PrivateTx has no payload")
+	}
+	return nil
+}
+
+func (tx *PrivateTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"ciphertext":"%X","group":"%X","input":`,
+		TxTypePrivate, tx.Ciphertext, tx.Group)), w, n, err)
+	tx.Input.WriteSignBytes(w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"nonce":"%X"}]}`, tx.Nonce)), w, n, err)
+}
+
+func (tx *PrivateTx) String() string {
+	return Fmt("PrivateTx{%v -> %X: %v bytes}", tx.Input, tx.Group,
+		len(tx.Ciphertext))
+}
+
+func privateAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != PrivateGroupKeyLength {
+		return nil, fmt.Errorf("Key of private group is %v bytes but must be %v "+
+			"bytes", len(key), PrivateGroupKeyLength)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,223 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ripemd160"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+)
+
+const (
+	// The most txs a proposal may batch
+	MaxProposalTxs = 32
+	// The longest the name and description of a proposal may be
+	MaxProposalNameLength        = 64
+	MaxProposalDescriptionLength = 1 << 12
+)
+
+// A batch of txs registered on-chain to be executed, in order and all or none,
+// once enough accounts with the Vote permission have voted for it. The
+// batched txs are not signed. Each of their inputs must vote for the proposal
+// instead, and the sequences of the inputs are set when the batch is executed.
+type Proposal struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Txs         []Tx   `json:"txs"`
+}
+
+// Checks the proposal is well formed and batches only txs that can be
+// executed by a proposal
+func (p *Proposal) ValidateBasic() error {
+	if len(p.Name) == 0 {
+		return ErrTxInvalidString{"Proposal name must not be empty"}
+	}
+	if len(p.Name) > MaxProposalNameLength {
+		return ErrTxInvalidString{Fmt("Proposal name is too long. Max %d bytes",
+			MaxProposalNameLength)}
+	}
+	if len(p.Description) > MaxProposalDescriptionLength {
+		return ErrTxInvalidString{Fmt("Proposal description is too long. Max %d bytes",
+			MaxProposalDescriptionLength)}
+	}
+	if len(p.Txs) == 0 || len(p.Txs) > MaxProposalTxs {
+		return fmt.Errorf("Proposal must batch between 1 and %v txs", MaxProposalTxs)
+	}
+	for i, tx := range p.Txs {
+		switch tx := tx.(type) {
+		case *SendTx:
+			if len(tx.Inputs) == 0 {
+				return fmt.Errorf("SendTx %v of proposal has no inputs", i)
+			}
+		case *CallTx, *PermissionsTx:
+		case *GovTx:
+			if err := tx.ValidateBasic(); err != nil {
+				return fmt.Errorf("GovTx %v of proposal is invalid: %v", i, err)
+			}
+		default:
+			return fmt.Errorf("Proposal can only batch SendTxs, CallTxs, "+
+				"PermissionsTxs and GovTxs, not %T", tx)
+		}
+		for _, input := range ProposalTxInputs(tx) {
+			if input == nil {
+				return fmt.Errorf("Tx %v of proposal has no input", i)
+			}
+			if err := input.ValidateBasic(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The addresses of the inputs of the batched txs, each of which must vote for
+// the proposal before it is executed, in the order they first appear
+func (p *Proposal) InputAddresses() [][]byte {
+	var addresses [][]byte
+	seen := make(map[string]bool)
+	for _, tx := range p.Txs {
+		for _, input := range ProposalTxInputs(tx) {
+			if input != nil && !seen[string(input.Address)] {
+				seen[string(input.Address)] = true
+				addresses = append(addresses, input.Address)
+			}
+		}
+	}
+	return addresses
+}
+
+// The inputs of a tx batched by a proposal, a GovTx has none
+func ProposalTxInputs(tx Tx) []*TxInput {
+	switch tx := tx.(type) {
+	case *SendTx:
+		return tx.Inputs
+	case *CallTx:
+		return []*TxInput{tx.Input}
+	case *PermissionsTx:
+		return []*TxInput{tx.Input}
+	}
+	return nil
+}
+
+func (p *Proposal) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"description":%s,"name":%s,"txs":[`,
+		jsonEscape(p.Description), jsonEscape(p.Name))), w, n, err)
+	for i, tx := range p.Txs {
+		if tx == nil {
+			*err = fmt.Errorf("Tx %v of proposal is nil", i)
+			return
+		}
+		if i > 0 {
+			wire.WriteTo([]byte(","), w, n, err)
+		}
+		tx.WriteSignBytes(chainID, w, n, err)
+	}
+	wire.WriteTo([]byte(`]}`), w, n, err)
+}
+
+// The hash a proposal is registered and voted for by on the chain chainID
+func ProposalHash(chainID string, p *Proposal) []byte {
+	buf, n, err := new(bytes.Buffer), new(int), new(error)
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s,"proposal":`, jsonEscape(chainID))), buf, n, err)
+	p.WriteSignBytes(chainID, buf, n, err)
+	wire.WriteTo([]byte(`}`), buf, n, err)
+	if *err != nil {
+		PanicCrisis(*err)
+	}
+	hasher := ripemd160.New()
+	hasher.Write(buf.Bytes())
+	return hasher.Sum(nil)
+}
+
+//-----------------------------------------------------------------------------
+
+// Makes Proposal, when it is set, which counts as the vote of the input for
+// it. Otherwise votes for the proposal already made with ProposalHash. The
+// amount of the input is taken as the fee.
+type ProposalTx struct {
+	Input        *TxInput  `json:"input"`
+	ProposalHash []byte    `json:"proposal_hash"`
+	Proposal     *Proposal `json:"proposal"`
+}
+
+func NewProposalTx(input *TxInput, proposal *Proposal) *ProposalTx {
+	return &ProposalTx{
+		Input:    input,
+		Proposal: proposal,
+	}
+}
+
+func NewVoteTx(input *TxInput, proposalHash []byte) *ProposalTx {
+	return &ProposalTx{
+		Input:        input,
+		ProposalHash: proposalHash,
+	}
+}
+
+// Checks that the tx either makes a well formed proposal or votes for one
+func (tx *ProposalTx) ValidateBasic() error {
+	if tx.Input == nil {
+		return fmt.Errorf("ProposalTx has no input")
+	}
+	if err := tx.Input.ValidateBasic(); err != nil {
+		return err
+	}
+	if tx.Proposal == nil {
+		if len(tx.ProposalHash) == 0 {
+			return fmt.Errorf("ProposalTx must make a proposal or give the " +
+				"hash of the proposal it votes for")
+		}
+		return nil
+	}
+	if len(tx.ProposalHash) != 0 {
+		return fmt.Errorf("ProposalTx that makes a proposal must not also " +
+			"give a proposal hash")
+	}
+	return tx.Proposal.ValidateBasic()
+}
+
+// The hash of the proposal made or voted for on the chain chainID
+func (tx *ProposalTx) GetProposalHash(chainID string) []byte {
+	if tx.Proposal != nil {
+		return ProposalHash(chainID, tx.Proposal)
+	}
+	return tx.ProposalHash
+}
+
+func (tx *ProposalTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"input":`, TxTypeProposal)), w, n, err)
+	tx.Input.WriteSignBytes(w, n, err)
+	if tx.Proposal != nil {
+		wire.WriteTo([]byte(`,"proposal":`), w, n, err)
+		tx.Proposal.WriteSignBytes(chainID, w, n, err)
+	} else {
+		wire.WriteTo([]byte(Fmt(`,"proposal_hash":"%X"`, tx.ProposalHash)), w, n, err)
+	}
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *ProposalTx) String() string {
+	if tx.Proposal != nil {
+		return Fmt("ProposalTx{%v proposes %s: %v txs}", tx.Input, tx.Proposal.Name,
+			len(tx.Proposal.Txs))
+	}
+	return Fmt("ProposalTx{%v votes for %X}", tx.Input, tx.ProposalHash)
+}
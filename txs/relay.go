@@ -0,0 +1,124 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	core_types "github.com/hyperledger/burrow/core/types"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-wire"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// The most storage items a RelayTx can prove
+const MaxRelayedStorageItems = 64
+
+// Relays the header of a block of another Burrow (or Tendermint) chain,
+// with the commit of its validators, so that its AppHash is known on this
+// chain, along with storage of accounts on that chain proved against it.
+// The first header of a chain is relayed by an account with the root
+// permission, with the validators named by the header, which are trusted
+// from then on. Later headers are relayed by any account, and must be
+// higher than the last. When the validators change, Validators is the new
+// set named by the header and more than two thirds of the voting power of
+// the trusted validators must have signed Commit. The input amount is burnt
+// as the fee.
+type RelayTx struct {
+	Input   *TxInput         `json:"input"`
+	ChainID string           `json:"chain_id"`
+	Header  *tm_types.Header `json:"header"`
+	// The ID of the block of Header, which Commit is for
+	BlockID tm_types.BlockID `json:"block_id"`
+	// The commit of the block, which is the LastCommit of its successor. It
+	// may be left out when the header has been relayed before.
+	Commit *tm_types.Commit `json:"commit"`
+	// The validators named by Header, needed only when they change
+	Validators []*tm_types.Validator `json:"validators"`
+	// Storage of the other chain at the height before Header, whose state
+	// the AppHash of Header is the root of
+	Storage []*core_types.StorageItemWithProof `json:"storage"`
+}
+
+// Checks the relayed header is well formed, without checking the commit
+func (tx *RelayTx) ValidateBasic() error {
+	if tx.ChainID == "" {
+		return fmt.Errorf("RelayTx must name the chain it relays")
+	}
+	if tx.Header == nil {
+		return fmt.Errorf("RelayTx must carry a header")
+	}
+	if tx.Header.ChainID != tx.ChainID {
+		return fmt.Errorf("Header is for chain %s not %s", tx.Header.ChainID,
+			tx.ChainID)
+	}
+	if tx.Header.Height < 1 {
+		return fmt.Errorf("Header has height %v", tx.Header.Height)
+	}
+	if !bytes.Equal(tx.BlockID.Hash, tx.Header.Hash()) {
+		return fmt.Errorf("Block ID %X is not that of the header", tx.BlockID.Hash)
+	}
+	if len(tx.Storage) > MaxRelayedStorageItems {
+		return fmt.Errorf("RelayTx proves %v storage items but at most %v can be "+
+			"proved", len(tx.Storage), MaxRelayedStorageItems)
+	}
+	for _, item := range tx.Storage {
+		if item == nil || item.Account == nil ||
+			item.Height != tx.Header.Height-1 {
+			return fmt.Errorf("Storage items must be proved with their account "+
+				"at height %v", tx.Header.Height-1)
+		}
+		if len(item.StorageItem.Key) > 32 || len(item.StorageItem.Value) > 32 {
+			return fmt.Errorf("Storage keys and values are at most 32 bytes")
+		}
+	}
+	return nil
+}
+
+// The block hash commits to the rest of the header, and the commit and
+// validators are checked against it, so only the hash and the storage
+// relayed are signed
+func (tx *RelayTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"block_hash":"%X","chain_id":%s`,
+		TxTypeRelay, tx.BlockID.Hash, jsonEscape(tx.ChainID))), w, n, err)
+	wire.WriteTo([]byte(`,"input":`), w, n, err)
+	tx.Input.WriteSignBytes(w, n, err)
+	wire.WriteTo([]byte(`,"storage":[`), w, n, err)
+	for i, item := range tx.Storage {
+		if i > 0 {
+			wire.WriteTo([]byte(`,`), w, n, err)
+		}
+		var address []byte
+		if item.Account != nil {
+			address = item.Account.Address
+		}
+		wire.WriteTo([]byte(Fmt(`{"address":"%X","key":"%X","value":"%X"}`,
+			address, item.StorageItem.Key, item.StorageItem.Value)), w, n, err)
+	}
+	wire.WriteTo([]byte(`]}]}`), w, n, err)
+}
+
+func (tx *RelayTx) String() string {
+	height := 0
+	if tx.Header != nil {
+		height = tx.Header.Height
+	}
+	return Fmt("RelayTx{%v -> %s/%v %X: %v storage}", tx.Input, tx.ChainID,
+		height, tx.BlockID.Hash, len(tx.Storage))
+}
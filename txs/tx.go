@@ -41,6 +41,8 @@ var (
 	ErrTxInvalidPubKey        = errors.New("Error invalid pubkey")
 	ErrTxInvalidSignature     = errors.New("Error invalid signature")
 	ErrTxPermissionDenied     = errors.New("Error permission denied")
+	ErrTxGasLimitTooHigh      = errors.New("Error gas limit too high")
+	ErrTxBlockGasExceeded     = errors.New("Error block gas limit exceeded")
 )
 
 type ErrTxInvalidString struct {
@@ -51,6 +53,15 @@ func (e ErrTxInvalidString) Error() string {
 	return e.Msg
 }
 
+type ErrTxExpired struct {
+	ExpiresAt int
+	Height    int
+}
+
+func (e ErrTxExpired) Error() string {
+	return Fmt("Error tx expired at height %d, cannot be included at height %d", e.ExpiresAt, e.Height)
+}
+
 type ErrTxInvalidSequence struct {
 	Got      int
 	Expected int
@@ -67,31 +78,55 @@ Account Txs:
  - SendTx         Send coins to address
  - CallTx         Send a msg to a contract that runs in the vm
  - NameTx	  Store some value under a name in the global namereg
+ - ABITx          Register the ABI of a contract's code in the ABI registry
+ - EthTx          An Ethereum transaction executed as a CallTx
+ - MultisigTx     A SendTx or CallTx signed for a multisig account
+ - RelayTx        Relay a header of another chain and storage proved against it
+ - PrivateTx      A CallTx encrypted to a private group, executed only by its nodes
+ - PriorityFeeTx  A SendTx or CallTx that pays part of its fee to the proposer
+ - ExpiringTx     A SendTx or CallTx that cannot be included after a block height
 
 Validation Txs:
  - BondTx         New validator posts a bond
  - UnbondTx       Validator leaves
  - DupeoutTx      Validator dupes out (equivocates)
+ - RotateTx       Validator hands over to a new consensus key
+ - IdentifyTx     Validator registers the network identity of its node
+ - AttestTx       Validator attests an event designated for the bridge to Ethereum
 
 Admin Txs:
  - PermissionsTx
+ - ProposalTx     Propose a batch of txs for accounts to vote on, or vote for one
+ - GovTx          Change the parameters of the chain from a proposal
 */
 
 // Types of Tx implementations
 const (
 	// Account transactions
-	TxTypeSend = byte(0x01)
-	TxTypeCall = byte(0x02)
-	TxTypeName = byte(0x03)
+	TxTypeSend        = byte(0x01)
+	TxTypeCall        = byte(0x02)
+	TxTypeName        = byte(0x03)
+	TxTypeABI         = byte(0x04)
+	TxTypeEth         = byte(0x05)
+	TxTypeMultisig    = byte(0x06)
+	TxTypeRelay       = byte(0x07)
+	TxTypePrivate     = byte(0x08)
+	TxTypePriorityFee = byte(0x09)
+	TxTypeExpiring    = byte(0x0A)
 
 	// Validation transactions
-	TxTypeBond    = byte(0x11)
-	TxTypeUnbond  = byte(0x12)
-	TxTypeRebond  = byte(0x13)
-	TxTypeDupeout = byte(0x14)
+	TxTypeBond     = byte(0x11)
+	TxTypeUnbond   = byte(0x12)
+	TxTypeRebond   = byte(0x13)
+	TxTypeDupeout  = byte(0x14)
+	TxTypeRotate   = byte(0x15)
+	TxTypeIdentify = byte(0x16)
+	TxTypeAttest   = byte(0x17)
 
 	// Admin transactions
 	TxTypePermissions = byte(0x20)
+	TxTypeProposal    = byte(0x21)
+	TxTypeGov         = byte(0x22)
 )
 
 // for wire.readReflect
@@ -100,11 +135,23 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{&SendTx{}, TxTypeSend},
 	wire.ConcreteType{&CallTx{}, TxTypeCall},
 	wire.ConcreteType{&NameTx{}, TxTypeName},
+	wire.ConcreteType{&ABITx{}, TxTypeABI},
+	wire.ConcreteType{&EthTx{}, TxTypeEth},
+	wire.ConcreteType{&MultisigTx{}, TxTypeMultisig},
+	wire.ConcreteType{&RelayTx{}, TxTypeRelay},
+	wire.ConcreteType{&PrivateTx{}, TxTypePrivate},
+	wire.ConcreteType{&PriorityFeeTx{}, TxTypePriorityFee},
+	wire.ConcreteType{&ExpiringTx{}, TxTypeExpiring},
 	wire.ConcreteType{&BondTx{}, TxTypeBond},
 	wire.ConcreteType{&UnbondTx{}, TxTypeUnbond},
 	wire.ConcreteType{&RebondTx{}, TxTypeRebond},
 	wire.ConcreteType{&DupeoutTx{}, TxTypeDupeout},
+	wire.ConcreteType{&RotateTx{}, TxTypeRotate},
+	wire.ConcreteType{&IdentifyTx{}, TxTypeIdentify},
+	wire.ConcreteType{&AttestTx{}, TxTypeAttest},
 	wire.ConcreteType{&PermissionsTx{}, TxTypePermissions},
+	wire.ConcreteType{&ProposalTx{}, TxTypeProposal},
+	wire.ConcreteType{&GovTx{}, TxTypeGov},
 )
 
 //-----------------------------------------------------------------------------
@@ -119,6 +166,17 @@ type (
 		Txs []Tx `json:"txs"`
 	}
 
+	// ListUnconfirmedTxs
+	MempoolTxs struct {
+		Txs []*MempoolTx `json:"txs"`
+	}
+
+	// A tx waiting in the mempool, by its hash
+	MempoolTx struct {
+		TxHash []byte `json:"tx_hash"`
+		Tx     Tx     `json:"tx"`
+	}
+
 	SendTx struct {
 		Inputs  []*TxInput  `json:"inputs"`
 		Outputs []*TxOutput `json:"outputs"`
@@ -285,6 +343,51 @@ func (tx *NameTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+// The longest ABI an ABITx can register
+const MaxABILength = 1 << 16
+
+// Registers an ABI for the contract at Address, which only the account that
+// created it with a CallTx may do. Exactly one of ABI,
+// the JSON ABI, and ABIHash, the sha3 of a JSON ABI kept off-chain, is given.
+// The input amount is burnt as the fee.
+type ABITx struct {
+	Input   *TxInput `json:"input"`
+	Address []byte   `json:"address"`
+	ABI     string   `json:"abi"`
+	ABIHash []byte   `json:"abi_hash"`
+}
+
+func (tx *ABITx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"abi":%s,"abi_hash":"%X","address":"%X"`,
+		TxTypeABI, jsonEscape(tx.ABI), tx.ABIHash, tx.Address)), w, n, err)
+	wire.WriteTo([]byte(`,"input":`), w, n, err)
+	tx.Input.WriteSignBytes(w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *ABITx) ValidateABI() error {
+	if (tx.ABI == "") == (len(tx.ABIHash) == 0) {
+		return ErrTxInvalidString{"Exactly one of an ABI and an ABI hash must be given"}
+	}
+	if len(tx.ABI) > MaxABILength {
+		return ErrTxInvalidString{Fmt("ABI is too long. Max %d bytes", MaxABILength)}
+	}
+	if len(tx.ABIHash) != 0 && len(tx.ABIHash) != 32 {
+		return ErrTxInvalidString{Fmt("ABI hash must be 32 bytes not %d", len(tx.ABIHash))}
+	}
+	return nil
+}
+
+func (tx *ABITx) String() string {
+	if tx.ABI == "" {
+		return Fmt("ABITx{%v -> %X: hash %X}", tx.Input, tx.Address, tx.ABIHash)
+	}
+	return Fmt("ABITx{%v -> %X: %s}", tx.Input, tx.Address, tx.ABI)
+}
+
+//-----------------------------------------------------------------------------
+
 type BondTx struct {
 	PubKey    crypto.PubKeyEd25519    `json:"pub_key"` // NOTE: these don't have type byte
 	Signature crypto.SignatureEd25519 `json:"signature"`
@@ -359,10 +462,15 @@ type DupeoutTx struct {
 	VoteB   tendermint_types.Vote `json:"vote_b"`
 }
 
+// A DupeoutTx is not signed, its sign bytes, which include the signed votes,
+// only give it its hash
 func (tx *DupeoutTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
-	// PanicSanity("DupeoutTx has no sign bytes")
-	// TODO
-	// return
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"address":"%X","vote_a":`, TxTypeDupeout, tx.Address)), w, n, err)
+	wire.WriteJSON(&tx.VoteA, w, n, err)
+	wire.WriteTo([]byte(`,"vote_b":`), w, n, err)
+	wire.WriteJSON(&tx.VoteB, w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
 }
 
 func (tx *DupeoutTx) String() string {
@@ -371,6 +479,32 @@ func (tx *DupeoutTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+// Hands the voting power of the validator with PubKey over to NewPubKey from
+// Height on. It is signed by both keys, so the validator authorises the
+// handover and shows it holds the new key.
+type RotateTx struct {
+	PubKey       crypto.PubKeyEd25519    `json:"pub_key"`
+	NewPubKey    crypto.PubKeyEd25519    `json:"new_pub_key"`
+	Height       int                     `json:"height"`
+	Signature    crypto.SignatureEd25519 `json:"signature"`
+	NewSignature crypto.SignatureEd25519 `json:"new_signature"`
+}
+
+func (tx *RotateTx) WriteSignBytes(chainID string, w io.Writer, n *int, err *error) {
+	wire.WriteTo([]byte(Fmt(`{"chain_id":%s`, jsonEscape(chainID))), w, n, err)
+	wire.WriteTo([]byte(Fmt(`,"tx":[%v,{"height":%v,"new_pub_key":`, TxTypeRotate, tx.Height)), w, n, err)
+	wire.WriteTo(wire.JSONBytes(tx.NewPubKey), w, n, err)
+	wire.WriteTo([]byte(`,"pub_key":`), w, n, err)
+	wire.WriteTo(wire.JSONBytes(tx.PubKey), w, n, err)
+	wire.WriteTo([]byte(`}]}`), w, n, err)
+}
+
+func (tx *RotateTx) String() string {
+	return Fmt("RotateTx{%X -> %X,%v}", tx.PubKey.Address(), tx.NewPubKey.Address(), tx.Height)
+}
+
+//-----------------------------------------------------------------------------
+
 type PermissionsTx struct {
 	Input    *TxInput        `json:"input"`
 	PermArgs ptypes.PermArgs `json:"args"`
@@ -392,6 +526,9 @@ func (tx *PermissionsTx) String() string {
 //-----------------------------------------------------------------------------
 
 func TxHash(chainID string, tx Tx) []byte {
+	if ethTx, ok := tx.(*EthTx); ok {
+		return ethTx.Hash()
+	}
 	signBytes := acm.SignBytes(chainID, tx)
 	hasher := ripemd160.New()
 	hasher.Write(signBytes)
@@ -402,6 +539,9 @@ func TxHash(chainID string, tx Tx) []byte {
 //-----------------------------------------------------------------------------
 
 func EncodeTx(tx Tx) ([]byte, error) {
+	if ethTx, ok := tx.(*EthTx); ok {
+		return ethTx.RLP(), nil
+	}
 	var n int
 	var err error
 	buf := new(bytes.Buffer)
@@ -412,8 +552,31 @@ func EncodeTx(tx Tx) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// Encodes a tx as JSON in the form [type, {tx}], signed or not, to pass
+// between the machines that form, sign and broadcast it
+func TxJSON(tx Tx) []byte {
+	return wire.JSONBytesPretty(&tx)
+}
+
+// Decodes a tx encoded by TxJSON
+func DecodeTxJSON(txJSON []byte) (Tx, error) {
+	var err error
+	var tx Tx
+	wire.ReadJSON(&tx, txJSON, &err)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errors.New("Error JSON holds no tx")
+	}
+	return tx, nil
+}
+
 // panic on err
 func DecodeTx(txBytes []byte) (Tx, error) {
+	if IsEthTx(txBytes) {
+		return DecodeEthTx(txBytes)
+	}
 	var n int
 	var err error
 	tx := new(Tx)
@@ -431,7 +594,16 @@ func GenerateReceipt(chainId string, tx Tx) Receipt {
 		CreatesContract: 0,
 		ContractAddr:    nil,
 	}
-	if callTx, ok := tx.(*CallTx); ok {
+	switch signedTx := tx.(type) {
+	case *EthTx:
+		// An invalid signature fails execution so there is no contract
+		tx, _ = signedTx.CallTx(chainId)
+	case *MultisigTx:
+		tx = signedTx.Tx
+	case *PriorityFeeTx, *ExpiringTx:
+		tx = InnerTx(signedTx)
+	}
+	if callTx, ok := tx.(*CallTx); ok && callTx != nil {
 		if len(callTx.Address) == 0 {
 			receipt.CreatesContract = 1
 			receipt.ContractAddr = NewContractAddress(callTx.Input.Address,
@@ -150,6 +150,61 @@ func (tx *NameTx) Sign(chainID string, privAccount *acm.PrivAccount) {
 	tx.Input.Signature = privAccount.Sign(chainID, tx)
 }
 
+//----------------------------------------------------------------------------
+// ABITx interface for creating tx
+
+func NewABITx(st AccountGetter, from crypto.PubKey, address []byte, abi string,
+	abiHash []byte, amt int64) (*ABITx, error) {
+	addr := from.Address()
+	acc := st.GetAccount(addr)
+	if acc == nil {
+		return nil, fmt.Errorf("Invalid address %X from pubkey %X", addr, from)
+	}
+
+	nonce := acc.Sequence + 1
+	return NewABITxWithNonce(from, address, abi, abiHash, amt, nonce), nil
+}
+
+func NewABITxWithNonce(from crypto.PubKey, address []byte, abi string,
+	abiHash []byte, amt int64, nonce int) *ABITx {
+	addr := from.Address()
+	input := &TxInput{
+		Address:   addr,
+		Amount:    amt,
+		Sequence:  nonce,
+		Signature: crypto.SignatureEd25519{},
+		PubKey:    from,
+	}
+
+	return &ABITx{
+		Input:   input,
+		Address: address,
+		ABI:     abi,
+		ABIHash: abiHash,
+	}
+}
+
+func (tx *ABITx) Sign(chainID string, privAccount *acm.PrivAccount) {
+	tx.Input.PubKey = privAccount.PubKey
+	tx.Input.Signature = privAccount.Sign(chainID, tx)
+}
+
+//----------------------------------------------------------------------------
+// RelayTx interface for creating tx
+
+func (tx *RelayTx) Sign(chainID string, privAccount *acm.PrivAccount) {
+	tx.Input.PubKey = privAccount.PubKey
+	tx.Input.Signature = privAccount.Sign(chainID, tx)
+}
+
+//----------------------------------------------------------------------------
+// PrivateTx interface for creating tx
+
+func (tx *PrivateTx) Sign(chainID string, privAccount *acm.PrivAccount) {
+	tx.Input.PubKey = privAccount.PubKey
+	tx.Input.Signature = privAccount.Sign(chainID, tx)
+}
+
 //----------------------------------------------------------------------------
 // BondTx interface for adding inputs/outputs and adding signatures
 
@@ -0,0 +1,203 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The changes to a database that bring its tables in line with a projection
+// spec
+type Migration struct {
+	// Creates the tables that do not exist yet
+	Create []string
+	// Alters the existing tables whose columns differ from the spec
+	Alter []string
+}
+
+// The column of a table as it is in the database
+type dbColumn struct {
+	sqlType  string
+	nullable bool
+}
+
+func (migration *Migration) Statements() []string {
+	return append(append([]string{}, migration.Create...), migration.Alter...)
+}
+
+// The DDL creating vent's own table and the tables of the spec
+func (vent *Vent) SchemaSQL() []string {
+	statements := stateTableSQL()
+	for _, table := range vent.tables {
+		statements = append(statements, table.CreateSQL())
+	}
+	return statements
+}
+
+// The DDL creating the table of vent's own state if it does not exist
+func stateTableSQL() []string {
+	return []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (height BIGINT NOT NULL)",
+			stateTableName),
+		fmt.Sprintf("INSERT INTO %s (height) SELECT 0 WHERE NOT EXISTS "+
+			"(SELECT 1 FROM %s)", stateTableName, stateTableName),
+	}
+}
+
+// Compares the tables of the database with those of the spec, returning the
+// migration that would bring the database in line with the spec. Columns the
+// spec adds to a table can be added, and columns it no longer fills are made
+// nullable but kept. A change to the type of a column or to the primary key
+// of a table cannot be migrated, so the table must be dropped and the chain
+// projected again.
+func (vent *Vent) Migration() (*Migration, error) {
+	migration := &Migration{Create: stateTableSQL()}
+	for _, table := range vent.tables {
+		columns, err := vent.dbColumns(table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			migration.Create = append(migration.Create, table.CreateSQL())
+			continue
+		}
+		if _, ok := columns[heightColumnName]; !ok {
+			return nil, fmt.Errorf("Table %s has no %s column so was not "+
+				"created by vent", table.Name, heightColumnName)
+		}
+		primaryKey, err := vent.dbPrimaryKey(table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Join(primaryKey, ", ") != strings.Join(table.primaryKey(), ", ") {
+			return nil, fmt.Errorf("Table %s has primary key (%s) but the "+
+				"spec gives it (%s), drop the table to project it again",
+				table.Name, strings.Join(primaryKey, ", "),
+				strings.Join(table.primaryKey(), ", "))
+		}
+		for _, column := range table.Columns {
+			dbColumn, ok := columns[column.Name]
+			if !ok {
+				migration.Alter = append(migration.Alter, fmt.Sprintf(
+					"ALTER TABLE %s ADD COLUMN %s %s", table.Name, column.Name,
+					column.SQLType))
+			} else if dbColumn.sqlType != column.SQLType {
+				return nil, fmt.Errorf("Column %s of %s has type %s but the "+
+					"spec gives it type %s, drop the table to project it again",
+					column.Name, table.Name, dbColumn.sqlType, column.SQLType)
+			}
+			delete(columns, column.Name)
+		}
+		delete(columns, heightColumnName)
+		for _, name := range sortedColumnNames(columns) {
+			if !columns[name].nullable {
+				migration.Alter = append(migration.Alter, fmt.Sprintf(
+					"ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table.Name,
+					name))
+			}
+		}
+	}
+	return migration, nil
+}
+
+// Applies a migration in one transaction
+func (vent *Vent) Migrate(migration *Migration) error {
+	tx, err := vent.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range migration.Statements() {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Could not migrate database: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// The columns of a table in the current schema of the database, or none if
+// there is no such table
+func (vent *Vent) dbColumns(tableName string) (map[string]*dbColumn, error) {
+	rows, err := vent.db.Query("SELECT column_name, data_type, "+
+		"character_maximum_length, numeric_precision, is_nullable "+
+		"FROM information_schema.columns "+
+		"WHERE table_schema = current_schema() AND table_name = $1", tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read columns of %s: %v", tableName, err)
+	}
+	defer rows.Close()
+	columns := make(map[string]*dbColumn)
+	for rows.Next() {
+		var name, dataType, nullable string
+		var length, precision sql.NullInt64
+		if err := rows.Scan(&name, &dataType, &length, &precision, &nullable); err != nil {
+			return nil, fmt.Errorf("Could not read columns of %s: %v", tableName, err)
+		}
+		columns[name] = &dbColumn{
+			sqlType:  columnSQLType(dataType, length, precision),
+			nullable: nullable == "YES",
+		}
+	}
+	return columns, rows.Err()
+}
+
+// The names of the primary columns of a table in the order of the key
+func (vent *Vent) dbPrimaryKey(tableName string) ([]string, error) {
+	rows, err := vent.db.Query("SELECT kcu.column_name "+
+		"FROM information_schema.table_constraints tc "+
+		"JOIN information_schema.key_column_usage kcu "+
+		"ON tc.constraint_name = kcu.constraint_name "+
+		"AND tc.table_schema = kcu.table_schema "+
+		"WHERE tc.constraint_type = 'PRIMARY KEY' "+
+		"AND tc.table_schema = current_schema() AND tc.table_name = $1 "+
+		"ORDER BY kcu.ordinal_position", tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read primary key of %s: %v", tableName, err)
+	}
+	defer rows.Close()
+	var primaryKey []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Could not read primary key of %s: %v", tableName, err)
+		}
+		primaryKey = append(primaryKey, name)
+	}
+	return primaryKey, rows.Err()
+}
+
+// The type of a column described by the information schema written as vent
+// writes it in DDL
+func columnSQLType(dataType string, length, precision sql.NullInt64) string {
+	switch dataType {
+	case "character varying":
+		return fmt.Sprintf("VARCHAR(%v)", length.Int64)
+	case "numeric":
+		return fmt.Sprintf("NUMERIC(%v)", precision.Int64)
+	}
+	return strings.ToUpper(dataType)
+}
+
+func sortedColumnNames(columns map[string]*dbColumn) []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
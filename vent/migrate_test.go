@@ -0,0 +1,97 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/hyperledger/burrow/logging/loggers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func column(name, dataType string, length, precision int64, nullable bool) []driver.Value {
+	row := []driver.Value{name, dataType, nil, nil, "NO"}
+	if length > 0 {
+		row[2] = length
+	}
+	if precision > 0 {
+		row[3] = precision
+	}
+	if nullable {
+		row[4] = "YES"
+	}
+	return row
+}
+
+func TestMigration(t *testing.T) {
+	spec, err := ReadProjectionSpec([]byte(itemsSpec))
+	require.NoError(t, err)
+	db, err := sql.Open("venttest", "")
+	require.NoError(t, err)
+	vent, err := NewVent(db, &testSource{abi: itemsABI}, spec, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+	defer func() {
+		testDB.columns = nil
+		testDB.primaryKeys = nil
+	}()
+
+	// No tables yet
+	migration, err := vent.Migration()
+	require.NoError(t, err)
+	assert.Equal(t, vent.SchemaSQL(), migration.Create)
+	assert.Empty(t, migration.Alter)
+
+	// A table projected from an older spec without the name column and with
+	// a since removed column
+	testDB.columns = map[string][][]driver.Value{
+		"items": {
+			column("id", "numeric", 0, 78, false),
+			column("owner", "character varying", 40, 0, true),
+			column("colour", "text", 0, 0, false),
+			column("_height", "bigint", 0, 0, false),
+		},
+	}
+	testDB.primaryKeys = map[string][]string{"items": {"id"}}
+	migration, err = vent.Migration()
+	require.NoError(t, err)
+	assert.Equal(t, stateTableSQL(), migration.Create)
+	assert.Equal(t, []string{
+		"ALTER TABLE items ADD COLUMN name TEXT",
+		"ALTER TABLE items ALTER COLUMN colour DROP NOT NULL",
+	}, migration.Alter)
+	// Vent will not run against tables needing migration
+	assert.Error(t, vent.Init())
+
+	testDB.committed = nil
+	require.NoError(t, vent.Migrate(migration))
+	assert.Equal(t, migration.Statements(), testDB.committed)
+
+	// Changes that cannot be migrated
+	testDB.columns["items"][1] = column("owner", "text", 0, 0, true)
+	_, err = vent.Migration()
+	assert.Error(t, err)
+	testDB.columns["items"][1] = column("owner", "character varying", 40, 0, true)
+	testDB.primaryKeys["items"] = []string{"id", "owner"}
+	_, err = vent.Migration()
+	assert.Error(t, err)
+	testDB.primaryKeys["items"] = []string{"id"}
+	testDB.columns["items"] = testDB.columns["items"][:3]
+	_, err = vent.Migration()
+	assert.Error(t, err)
+}
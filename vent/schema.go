@@ -0,0 +1,259 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// A table that projections are made onto
+type Table struct {
+	Name    string
+	Columns []*TableColumn
+}
+
+type TableColumn struct {
+	Name    string
+	SQLType string
+	Primary bool
+}
+
+// A projection bound to its event in an ABI
+type boundProjection struct {
+	*Projection
+	// Zero for any contract
+	address Word256
+	event   *abi.Event
+	// The index among the inputs of the event of the input of each column
+	inputs []int
+	// The statement applying a log of the event to the table
+	statement string
+}
+
+func bindProjection(projection *Projection, contractABI *abi.ABI) (*boundProjection, error) {
+	address, err := projection.address()
+	if err != nil {
+		return nil, err
+	}
+	bound := &boundProjection{Projection: projection}
+	if len(address) > 0 {
+		bound.address = LeftPadWord256(address)
+	}
+	for _, event := range contractABI.Events {
+		if event.Name != projection.Event && event.Signature() != projection.Event {
+			continue
+		}
+		if bound.event != nil {
+			return nil, fmt.Errorf("Event %s is overloaded in its ABI, give "+
+				"its signature, such as %s, instead", projection.Event,
+				event.Signature())
+		}
+		bound.event = event
+	}
+	if bound.event == nil {
+		return nil, fmt.Errorf("There is no event %s in the ABI of the "+
+			"projection onto %s", projection.Event, projection.Table)
+	}
+	if bound.event.Anonymous {
+		return nil, fmt.Errorf("Event %s is anonymous so its logs cannot be "+
+			"identified", projection.Event)
+	}
+	for _, column := range projection.Columns {
+		index := -1
+		for i, input := range bound.event.Inputs {
+			if input.Name == column.input() {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("Event %s has no input %s for column %s of %s",
+				projection.Event, column.input(), column.Name, projection.Table)
+		}
+		bound.inputs = append(bound.inputs, index)
+	}
+	return bound, nil
+}
+
+// The SQL type of the column holding the values of an event input
+func sqlType(input *abi.EventInput) (string, error) {
+	typeName := string(input.TypeName)
+	switch {
+	case strings.HasSuffix(typeName, "]"):
+		return "", fmt.Errorf("Input %s has array type %s which cannot be "+
+			"projected", input.Name, typeName)
+	case typeName == "address":
+		return "VARCHAR(40)", nil
+	case typeName == "bool":
+		return "BOOLEAN", nil
+	case strings.HasPrefix(typeName, "uint"), strings.HasPrefix(typeName, "int"):
+		return "NUMERIC(78)", nil
+	case typeName == "string", strings.HasPrefix(typeName, "bytes"):
+		// Only the hash of indexed strings and bytes is logged, which is
+		// projected as hex
+		return "TEXT", nil
+	}
+	return "", fmt.Errorf("Input %s has type %s which cannot be projected",
+		input.Name, typeName)
+}
+
+// Makes the tables that projections are made onto, in the order the tables
+// first appear. The columns of a table are the union of the columns of the
+// projections onto it, which must agree on the type of each column and on
+// which columns are primary.
+func buildTables(projections []*boundProjection) ([]*Table, error) {
+	var tables []*Table
+	tablesByName := make(map[string]*Table)
+	primaryKeys := make(map[string]string)
+	for _, projection := range projections {
+		table := tablesByName[projection.Table]
+		if table == nil {
+			table = &Table{Name: projection.Table}
+			tablesByName[table.Name] = table
+			tables = append(tables, table)
+		}
+		var primaryKey []string
+		for i, column := range projection.Columns {
+			columnType, err := sqlType(projection.event.Inputs[projection.inputs[i]])
+			if err != nil {
+				return nil, fmt.Errorf("Column %s of %s: %v", column.Name,
+					table.Name, err)
+			}
+			if column.Primary {
+				primaryKey = append(primaryKey, column.Name)
+			}
+			tableColumn := table.column(column.Name)
+			if tableColumn == nil {
+				table.Columns = append(table.Columns, &TableColumn{
+					Name:    column.Name,
+					SQLType: columnType,
+					Primary: column.Primary,
+				})
+			} else if tableColumn.SQLType != columnType {
+				return nil, fmt.Errorf("Column %s of %s is projected from "+
+					"inputs of types %s and %s", column.Name, table.Name,
+					tableColumn.SQLType, columnType)
+			}
+		}
+		key := strings.Join(primaryKey, ", ")
+		if previousKey, ok := primaryKeys[table.Name]; ok && previousKey != key {
+			return nil, fmt.Errorf("Projections onto %s have primary columns "+
+				"(%s) and (%s) but must all have the same", table.Name,
+				previousKey, key)
+		}
+		primaryKeys[table.Name] = key
+	}
+	for _, projection := range projections {
+		projection.statement = projection.sql()
+	}
+	return tables, nil
+}
+
+func (table *Table) column(name string) *TableColumn {
+	for _, column := range table.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+func (table *Table) primaryKey() []string {
+	var primaryKey []string
+	for _, column := range table.Columns {
+		if column.Primary {
+			primaryKey = append(primaryKey, column.Name)
+		}
+	}
+	return primaryKey
+}
+
+// The DDL creating the table if it does not exist
+func (table *Table) CreateSQL() string {
+	var definitions []string
+	for _, column := range table.Columns {
+		definition := column.Name + " " + column.SQLType
+		if column.Primary {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+	}
+	definitions = append(definitions, heightColumnName+" BIGINT NOT NULL")
+	if primaryKey := table.primaryKey(); len(primaryKey) > 0 {
+		definitions = append(definitions,
+			"PRIMARY KEY ("+strings.Join(primaryKey, ", ")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table.Name,
+		strings.Join(definitions, ", "))
+}
+
+// How a projection changes its table
+func (projection *boundProjection) operation() string {
+	switch {
+	case projection.Delete:
+		return "delete"
+	case len(projection.primaryKey()) > 0:
+		return "upsert"
+	}
+	return "insert"
+}
+
+func (projection *boundProjection) primaryKey() []string {
+	var primaryKey []string
+	for _, column := range projection.Columns {
+		if column.Primary {
+			primaryKey = append(primaryKey, column.Name)
+		}
+	}
+	return primaryKey
+}
+
+// The statement applying a log to the table, taking the values of the columns
+// of the projection in order followed by the height of the log, or only the
+// values of the primary columns when the projection deletes
+func (projection *boundProjection) sql() string {
+	if projection.Delete {
+		var conditions []string
+		for i, name := range projection.primaryKey() {
+			conditions = append(conditions, fmt.Sprintf("%s = $%v", name, i+1))
+		}
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", projection.Table,
+			strings.Join(conditions, " AND "))
+	}
+	var names, params, updates []string
+	for i, column := range projection.Columns {
+		names = append(names, column.Name)
+		params = append(params, fmt.Sprintf("$%v", i+1))
+		if !column.Primary {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s",
+				column.Name, column.Name))
+		}
+	}
+	names = append(names, heightColumnName)
+	params = append(params, fmt.Sprintf("$%v", len(params)+1))
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", projection.Table,
+		strings.Join(names, ", "), strings.Join(params, ", "))
+	primaryKey := projection.primaryKey()
+	if len(primaryKey) == 0 {
+		return insert
+	}
+	updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", heightColumnName,
+		heightColumnName))
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s", insert,
+		strings.Join(primaryKey, ", "), strings.Join(updates, ", "))
+}
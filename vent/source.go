@@ -0,0 +1,151 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// A log emitted by a contract in a committed block
+type Log struct {
+	Address Word256
+	Topics  []Word256
+	Data    []byte
+	Height  int64
+}
+
+// Where vent reads the chain from
+type Source interface {
+	// The height of the latest committed block
+	Height() (int64, error)
+	// The logs emitted by address having topic between minHeight and
+	// maxHeight inclusive, in the order they were emitted. Either of address
+	// and topic may be nil to match any.
+	Logs(address, topic []byte, minHeight, maxHeight int64) ([]*Log, error)
+	// The ABI registered on chain for the contract at address,
+	// which is either the JSON ABI or the hash of an ABI kept off-chain
+	ABI(address []byte) (abiJSON string, abiHash []byte, err error)
+}
+
+// Reads the chain from the REST API of a burrow node at url, such as
+// http://localhost:1337
+type restSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewRESTSource(url string) Source {
+	return &restSource{
+		url:    strings.TrimSuffix(url, "/"),
+		client: http.DefaultClient,
+	}
+}
+
+func (rs *restSource) Height() (int64, error) {
+	latest := new(struct {
+		Height int64 `json:"height"`
+	})
+	if err := rs.get("/blockchain/latest_block_height", nil, latest); err != nil {
+		return 0, err
+	}
+	return latest.Height, nil
+}
+
+func (rs *restSource) Logs(address, topic []byte, minHeight,
+	maxHeight int64) ([]*Log, error) {
+	query := url.Values{}
+	query.Set("address", hex.EncodeToString(address))
+	query.Set("topic", hex.EncodeToString(topic))
+	query.Set("min_height", strconv.FormatInt(minHeight, 10))
+	query.Set("max_height", strconv.FormatInt(maxHeight, 10))
+	logList := new(struct {
+		Logs []struct {
+			Address hexBytes   `json:"address"`
+			Topics  []hexBytes `json:"topics"`
+			Data    hexBytes   `json:"data"`
+			Height  int64      `json:"height"`
+		} `json:"logs"`
+	})
+	if err := rs.get("/logs", query, logList); err != nil {
+		return nil, err
+	}
+	logs := make([]*Log, len(logList.Logs))
+	for i, l := range logList.Logs {
+		logs[i] = &Log{
+			Address: LeftPadWord256(l.Address),
+			Data:    l.Data,
+			Height:  l.Height,
+		}
+		for _, topic := range l.Topics {
+			logs[i].Topics = append(logs[i].Topics, LeftPadWord256(topic))
+		}
+	}
+	return logs, nil
+}
+
+func (rs *restSource) ABI(address []byte) (string, []byte, error) {
+	entry := new(struct {
+		ABI     string   `json:"abi"`
+		ABIHash hexBytes `json:"abi_hash"`
+	})
+	if err := rs.get(fmt.Sprintf("/accounts/%X/abi", address), nil, entry); err != nil {
+		return "", nil, err
+	}
+	return entry.ABI, entry.ABIHash, nil
+}
+
+func (rs *restSource) get(path string, query url.Values, o interface{}) error {
+	u := rs.url + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	response, err := rs.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("Request for %s failed with status %s", u,
+			response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(o); err != nil {
+		return fmt.Errorf("Could not read response to %s: %v", u, err)
+	}
+	return nil
+}
+
+// Bytes encoded in JSON as a hex string, as the node's codec does
+type hexBytes []byte
+
+func (hb *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*hb = bs
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// The table in which vent records the height it has projected the chain up to
+const stateTableName = "vent_state"
+
+// The column added to each table holding the height of the log that last
+// changed the row
+const heightColumnName = "_height"
+
+var identifierRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// A projection spec maps the events of contracts onto the rows of SQL tables
+type ProjectionSpec struct {
+	// The channel notified of each change made to a table, if not empty
+	NotifyChannel string        `json:"notify_channel"`
+	Projections   []*Projection `json:"projections"`
+}
+
+// Projects an event onto a table. Each log of the event inserts a row, or
+// when the projection has primary columns inserts or updates the row with the
+// same primary key, or deletes it when Delete is set.
+type Projection struct {
+	Table string `json:"table"`
+	// The name of the event, or its signature if the ABI overloads the name
+	Event string `json:"event"`
+	// The hex address of the contract emitting the event, or empty for any
+	// contract
+	Address string `json:"address"`
+	// A file holding the JSON ABI of the event, needed unless an ABI is
+	// registered on chain for the code of the contract at Address
+	ABIFile string    `json:"abi_file"`
+	Delete  bool      `json:"delete"`
+	Columns []*Column `json:"columns"`
+}
+
+// A column of a table filled from an input of an event
+type Column struct {
+	Name string `json:"name"`
+	// The name of the event input, by default the name of the column
+	Input   string `json:"input"`
+	Primary bool   `json:"primary"`
+}
+
+// Reads a JSON projection spec, checking that it is well formed
+func ReadProjectionSpec(specJSON []byte) (*ProjectionSpec, error) {
+	spec := new(ProjectionSpec)
+	if err := json.Unmarshal(specJSON, spec); err != nil {
+		return nil, fmt.Errorf("Could not read projection spec: %v", err)
+	}
+	if len(spec.Projections) == 0 {
+		return nil, fmt.Errorf("Projection spec has no projections")
+	}
+	for i, projection := range spec.Projections {
+		if err := projection.validate(); err != nil {
+			return nil, fmt.Errorf("Projection %v of spec: %v", i, err)
+		}
+	}
+	return spec, nil
+}
+
+func (projection *Projection) validate() error {
+	if !identifierRegexp.MatchString(projection.Table) {
+		return fmt.Errorf("Table name '%s' must be lower case letters, digits "+
+			"and underscores starting with a letter", projection.Table)
+	}
+	if projection.Table == stateTableName {
+		return fmt.Errorf("Table name '%s' is reserved", stateTableName)
+	}
+	if projection.Event == "" {
+		return fmt.Errorf("Projection onto %s has no event", projection.Table)
+	}
+	if _, err := projection.address(); err != nil {
+		return err
+	}
+	if projection.Address == "" && projection.ABIFile == "" {
+		return fmt.Errorf("Projection of %s needs an ABI file when it has no "+
+			"address", projection.Event)
+	}
+	if len(projection.Columns) == 0 {
+		return fmt.Errorf("Projection of %s has no columns", projection.Event)
+	}
+	names := make(map[string]bool)
+	primary := false
+	for _, column := range projection.Columns {
+		if !identifierRegexp.MatchString(column.Name) {
+			return fmt.Errorf("Column name '%s' must be lower case letters, "+
+				"digits and underscores starting with a letter", column.Name)
+		}
+		if names[column.Name] {
+			return fmt.Errorf("Column %s of %s appears twice", column.Name,
+				projection.Table)
+		}
+		names[column.Name] = true
+		primary = primary || column.Primary
+	}
+	if projection.Delete && !primary {
+		return fmt.Errorf("Projection of %s deletes rows so it needs a "+
+			"primary column", projection.Event)
+	}
+	return nil
+}
+
+func (projection *Projection) address() ([]byte, error) {
+	address, err := hex.DecodeString(projection.Address)
+	if err != nil || (len(address) != 0 && len(address) != 20) {
+		return nil, fmt.Errorf("Address '%s' of projection of %s is not 20 "+
+			"hex bytes", projection.Address, projection.Event)
+	}
+	return address, nil
+}
+
+func (column *Column) input() string {
+	if column.Input == "" {
+		return column.Name
+	}
+	return column.Input
+}
@@ -0,0 +1,376 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/burrow/logging"
+	logging_types "github.com/hyperledger/burrow/logging/types"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+)
+
+// The most blocks whose logs are projected in one database transaction
+const maxSyncBlocks = 1000
+
+// The payload of the notification sent on the notify channel of a spec for
+// each change to a table. Row holds the values of the columns of the
+// projection, or only of the primary columns when a row is deleted.
+type Notification struct {
+	Table     string            `json:"table"`
+	Operation string            `json:"operation"`
+	Height    int64             `json:"height"`
+	Row       map[string]string `json:"row"`
+}
+
+// Vent projects the events logged by contracts on a chain onto Postgres
+// tables as directed by a projection spec. It records the height it has
+// projected up to in the database, in the same transaction as the changes to
+// the tables, so it resumes where it left off.
+type Vent struct {
+	db          *sql.DB
+	source      Source
+	spec        *ProjectionSpec
+	projections []*boundProjection
+	tables      []*Table
+	logger      logging_types.InfoTraceLogger
+}
+
+// Makes a Vent projecting the chain read from source onto the tables of db.
+// The ABIs of the events of spec are read from the ABI files of its
+// projections or else fetched from the ABI registry of the chain.
+func NewVent(db *sql.DB, source Source, spec *ProjectionSpec,
+	logger logging_types.InfoTraceLogger) (*Vent, error) {
+	vent := &Vent{
+		db:     db,
+		source: source,
+		spec:   spec,
+		logger: logging.WithScope(logger, "Vent"),
+	}
+	for _, projection := range spec.Projections {
+		contractABI, abiJSON, err := projectionABI(projection, source)
+		if err != nil {
+			return nil, err
+		}
+		bound, err := bindProjection(projection, contractABI)
+		if err != nil {
+			return nil, err
+		}
+		if projection.ABIFile != "" && bound.address != Zero256 {
+			if err := checkRegisteredABI(bound, abiJSON, source); err != nil {
+				return nil, err
+			}
+		}
+		vent.projections = append(vent.projections, bound)
+	}
+	var err error
+	if vent.tables, err = buildTables(vent.projections); err != nil {
+		return nil, err
+	}
+	return vent, nil
+}
+
+// Reads the ABI of a projection, returning it along with its JSON
+func projectionABI(projection *Projection, source Source) (*abi.ABI, []byte, error) {
+	abiJSON, err := projectionABIJSON(projection, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	contractABI, err := abi.ReadABI(abiJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contractABI, abiJSON, nil
+}
+
+func projectionABIJSON(projection *Projection, source Source) ([]byte, error) {
+	if projection.ABIFile != "" {
+		abiJSON, err := ioutil.ReadFile(projection.ABIFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read ABI file of projection of "+
+				"%s: %v", projection.Event, err)
+		}
+		return abiJSON, nil
+	}
+	address, err := projection.address()
+	if err != nil {
+		return nil, err
+	}
+	abiJSON, _, err := source.ABI(address)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the ABI registered for contract "+
+			"%X: %v", address, err)
+	}
+	if abiJSON == "" {
+		return nil, fmt.Errorf("Only the hash of an ABI is registered for "+
+			"contract %X so the projection of %s needs an ABI file", address,
+			projection.Event)
+	}
+	return []byte(abiJSON), nil
+}
+
+// Checks that the ABI file of a projection of the events of a contract agrees
+// with the ABI registered on chain for the contract, if any. A registered hash
+// must be the hash of the file, and a registered JSON ABI must have the event
+// with the same inputs indexed.
+func checkRegisteredABI(projection *boundProjection, abiJSON []byte,
+	source Source) error {
+	address := projection.address.Postfix(20)
+	registeredJSON, registeredHash, err := source.ABI(address)
+	if err != nil {
+		// Nothing is registered for the contract
+		return nil
+	}
+	if len(registeredHash) > 0 {
+		if !bytes.Equal(registeredHash, sha3.Sha3(abiJSON)) {
+			return fmt.Errorf("ABI file %s does not have the hash %X "+
+				"registered for contract %X", projection.ABIFile,
+				registeredHash, address)
+		}
+		return nil
+	}
+	registered, err := abi.ReadABI([]byte(registeredJSON))
+	if err != nil {
+		return fmt.Errorf("ABI registered for contract %X: %v", address, err)
+	}
+	event := registered.EventByID(projection.event.ID())
+	if event == nil {
+		return fmt.Errorf("The ABI registered for contract %X has no event %s",
+			address, projection.event.Signature())
+	}
+	for i, input := range event.Inputs {
+		if input.Indexed != projection.event.Inputs[i].Indexed {
+			return fmt.Errorf("Input %v of event %s is indexed differently "+
+				"in ABI file %s than in the ABI registered for contract %X", i,
+				event.Signature(), projection.ABIFile, address)
+		}
+	}
+	return nil
+}
+
+// The tables that the spec projects onto
+func (vent *Vent) Tables() []*Table {
+	return vent.tables
+}
+
+// Creates the tables of the spec, and the table of vent's own state, if they
+// do not already exist. Fails if existing tables differ from those of the
+// spec, which must first be migrated.
+func (vent *Vent) Init() error {
+	migration, err := vent.Migration()
+	if err != nil {
+		return err
+	}
+	if len(migration.Alter) > 0 {
+		return fmt.Errorf("The tables of the database differ from those of "+
+			"the projection spec so must be migrated with: %s",
+			strings.Join(migration.Alter, "; "))
+	}
+	if err := vent.Migrate(migration); err != nil {
+		return fmt.Errorf("Could not initialise database: %v", err)
+	}
+	return nil
+}
+
+// The height of the last block whose logs have been projected
+func (vent *Vent) Height() (int64, error) {
+	var height int64
+	row := vent.db.QueryRow(fmt.Sprintf("SELECT height FROM %s", stateTableName))
+	if err := row.Scan(&height); err != nil {
+		return 0, fmt.Errorf("Could not read projected height: %v", err)
+	}
+	return height, nil
+}
+
+// Projects the logs of the blocks committed since the last sync, returning
+// the height projected up to
+func (vent *Vent) Sync() (int64, error) {
+	height, err := vent.Height()
+	if err != nil {
+		return 0, err
+	}
+	latestHeight, err := vent.source.Height()
+	if err != nil {
+		return height, err
+	}
+	for height < latestHeight {
+		toHeight := height + maxSyncBlocks
+		if toHeight > latestHeight {
+			toHeight = latestHeight
+		}
+		logs, err := vent.logs(height+1, toHeight)
+		if err != nil {
+			return height, err
+		}
+		if err := vent.project(logs, toHeight); err != nil {
+			return height, err
+		}
+		height = toHeight
+	}
+	return height, nil
+}
+
+// Syncs every interval until stop is closed. Failures are logged and the sync
+// retried on the next interval.
+func (vent *Vent) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		height, err := vent.Sync()
+		if err != nil {
+			logging.InfoMsg(vent.logger, "Failed to sync", "error", err)
+		} else {
+			logging.TraceMsg(vent.logger, "Synced", "height", height)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// A log to apply to the table of a projection
+type projectedLog struct {
+	projection *boundProjection
+	log        *Log
+}
+
+// Gets the logs of the events of the projections between minHeight and
+// maxHeight in order of height. The logs of a contract keep the order they
+// were emitted in, as do the logs of an event projected from any contract.
+func (vent *Vent) logs(minHeight, maxHeight int64) ([]*projectedLog, error) {
+	var logs []*projectedLog
+	byAddress := make(map[Word256][]*boundProjection)
+	var addresses []Word256
+	for _, projection := range vent.projections {
+		if projection.address == Zero256 {
+			eventID := projection.event.ID()
+			eventLogs, err := vent.source.Logs(nil, eventID.Bytes(), minHeight,
+				maxHeight)
+			if err != nil {
+				return nil, err
+			}
+			for _, log := range eventLogs {
+				logs = append(logs, &projectedLog{projection, log})
+			}
+			continue
+		}
+		if _, ok := byAddress[projection.address]; !ok {
+			addresses = append(addresses, projection.address)
+		}
+		byAddress[projection.address] = append(byAddress[projection.address],
+			projection)
+	}
+	for _, address := range addresses {
+		addressLogs, err := vent.source.Logs(address.Postfix(20), nil, minHeight,
+			maxHeight)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range addressLogs {
+			if len(log.Topics) == 0 {
+				continue
+			}
+			for _, projection := range byAddress[address] {
+				if projection.event.ID() == log.Topics[0] {
+					logs = append(logs, &projectedLog{projection, log})
+				}
+			}
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].log.Height < logs[j].log.Height
+	})
+	return logs, nil
+}
+
+// Applies logs to the tables and records height as projected, all in one
+// transaction
+func (vent *Vent) project(logs []*projectedLog, height int64) error {
+	tx, err := vent.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, pl := range logs {
+		if err := vent.apply(tx, pl.projection, pl.log); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET height = $1", stateTableName),
+		height)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Could not record projected height: %v", err)
+	}
+	return tx.Commit()
+}
+
+func (vent *Vent) apply(tx *sql.Tx, projection *boundProjection, log *Log) error {
+	values, err := projection.event.Decode(log.Topics, log.Data)
+	if err != nil {
+		// The contract logged something malformed, which cannot be projected
+		logging.InfoMsg(vent.logger, "Skipping log that cannot be decoded",
+			"event", projection.Event,
+			"address", fmt.Sprintf("%X", log.Address.Postfix(20)),
+			"height", log.Height,
+			"error", err)
+		return nil
+	}
+	row := make(map[string]string)
+	var args []interface{}
+	for i, column := range projection.Columns {
+		if projection.Delete && !column.Primary {
+			continue
+		}
+		value := values[projection.inputs[i]]
+		row[column.Name] = value
+		args = append(args, value)
+	}
+	if !projection.Delete {
+		args = append(args, log.Height)
+	}
+	if _, err := tx.Exec(projection.statement, args...); err != nil {
+		return fmt.Errorf("Could not project %s log at height %v onto %s: %v",
+			projection.Event, log.Height, projection.Table, err)
+	}
+	if vent.spec.NotifyChannel == "" {
+		return nil
+	}
+	payload, err := json.Marshal(&Notification{
+		Table:     projection.Table,
+		Operation: projection.operation(),
+		Height:    log.Height,
+		Row:       row,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("SELECT pg_notify($1, $2)", vent.spec.NotifyChannel,
+		string(payload)); err != nil {
+		return fmt.Errorf("Could not notify %s: %v", vent.spec.NotifyChannel, err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vent
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/abi"
+	"github.com/hyperledger/burrow/manager/burrow-mint/evm/sha3"
+	. "github.com/hyperledger/burrow/word256"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const itemsABI = `[
+	{"type": "event", "name": "ItemSet", "inputs": [
+		{"name": "id", "type": "uint256", "indexed": true},
+		{"name": "owner", "type": "address", "indexed": false},
+		{"name": "label", "type": "string", "indexed": false}]},
+	{"type": "event", "name": "ItemRemoved", "inputs": [
+		{"name": "id", "type": "uint256", "indexed": true}]}]`
+
+const itemsSpec = `{
+	"notify_channel": "items",
+	"projections": [
+		{"table": "items", "event": "ItemSet", "address": "00000000000000000000000000000000000000AB",
+		 "columns": [{"name": "id", "primary": true}, {"name": "owner"}, {"name": "name", "input": "label"}]},
+		{"table": "items", "event": "ItemRemoved", "address": "00000000000000000000000000000000000000AB",
+		 "delete": true, "columns": [{"name": "id", "primary": true}]}]}`
+
+var contractAddress = LeftPadWord256([]byte{0xAB})
+
+// A source serving logs and ABIs from memory
+type testSource struct {
+	height  int64
+	logs    []*Log
+	abi     string
+	abiHash []byte
+}
+
+func (ts *testSource) Height() (int64, error) {
+	return ts.height, nil
+}
+
+func (ts *testSource) Logs(address, topic []byte, minHeight, maxHeight int64) ([]*Log, error) {
+	var logs []*Log
+	for _, log := range ts.logs {
+		if log.Height >= minHeight && log.Height <= maxHeight &&
+			(address == nil || log.Address == LeftPadWord256(address)) &&
+			(topic == nil || log.Topics[0] == LeftPadWord256(topic)) {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (ts *testSource) ABI(address []byte) (string, []byte, error) {
+	if ts.abi == "" && ts.abiHash == nil {
+		return "", nil, fmt.Errorf("No ABI")
+	}
+	return ts.abi, ts.abiHash, nil
+}
+
+// A database driver recording the statements executed and committed, and
+// answering queries for the height and the information schema
+type testDriver struct {
+	committed []string
+	height    int64
+	// The rows of information_schema.columns of each table
+	columns map[string][][]driver.Value
+	// The primary columns of each table
+	primaryKeys map[string][]string
+}
+
+var testDB = &testDriver{}
+
+func init() {
+	sql.Register("venttest", testDB)
+}
+
+type testConn struct {
+	driver  *testDriver
+	pending []string
+}
+
+type testStmt struct {
+	conn  *testConn
+	query string
+}
+
+type testRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (td *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{driver: td}, nil
+}
+
+func (tc *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{conn: tc, query: query}, nil
+}
+
+func (tc *testConn) Close() error {
+	return nil
+}
+
+func (tc *testConn) Begin() (driver.Tx, error) {
+	tc.pending = nil
+	return tc, nil
+}
+
+func (tc *testConn) Commit() error {
+	tc.driver.committed = append(tc.driver.committed, tc.pending...)
+	tc.pending = nil
+	return nil
+}
+
+func (tc *testConn) Rollback() error {
+	tc.pending = nil
+	return nil
+}
+
+func (ts *testStmt) Close() error {
+	return nil
+}
+
+func (ts *testStmt) NumInput() int {
+	return -1
+}
+
+func (ts *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	statement := ts.query
+	for _, arg := range args {
+		statement += fmt.Sprintf(" [%v]", arg)
+	}
+	if strings.HasPrefix(ts.query, "UPDATE "+stateTableName) {
+		ts.conn.driver.height = args[0].(int64)
+	}
+	ts.conn.pending = append(ts.conn.pending, statement)
+	return driver.RowsAffected(1), nil
+}
+
+func (ts *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	td := ts.conn.driver
+	switch {
+	case strings.Contains(ts.query, "information_schema.columns"):
+		return &testRows{
+			columns: []string{"column_name", "data_type", "character_maximum_length",
+				"numeric_precision", "is_nullable"},
+			rows: td.columns[args[0].(string)],
+		}, nil
+	case strings.Contains(ts.query, "PRIMARY KEY"):
+		rows := &testRows{columns: []string{"column_name"}}
+		for _, name := range td.primaryKeys[args[0].(string)] {
+			rows.rows = append(rows.rows, []driver.Value{name})
+		}
+		return rows, nil
+	}
+	return &testRows{
+		columns: []string{"height"},
+		rows:    [][]driver.Value{{td.height}},
+	}, nil
+}
+
+func (tr *testRows) Columns() []string {
+	return tr.columns
+}
+
+func (tr *testRows) Close() error {
+	return nil
+}
+
+func (tr *testRows) Next(dest []driver.Value) error {
+	if len(tr.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, tr.rows[0])
+	tr.rows = tr.rows[1:]
+	return nil
+}
+
+func itemLog(t *testing.T, height int64, event string, id int64, data []byte) *Log {
+	contractABI, err := abi.ReadABI([]byte(itemsABI))
+	require.NoError(t, err)
+	for _, e := range contractABI.Events {
+		if e.Name == event {
+			return &Log{
+				Address: contractAddress,
+				Topics:  []Word256{e.ID(), Int64ToWord256(id)},
+				Data:    data,
+				Height:  height,
+			}
+		}
+	}
+	t.Fatalf("No event %s", event)
+	return nil
+}
+
+func stringData(owner byte, s string) []byte {
+	data := append(LeftPadWord256([]byte{owner}).Bytes(), Int64ToWord256(64).Bytes()...)
+	data = append(data, Int64ToWord256(int64(len(s))).Bytes()...)
+	return append(data, RightPadWord256([]byte(s)).Bytes()...)
+}
+
+func TestReadProjectionSpec(t *testing.T) {
+	spec, err := ReadProjectionSpec([]byte(itemsSpec))
+	require.NoError(t, err)
+	assert.Equal(t, "items", spec.NotifyChannel)
+	assert.Len(t, spec.Projections, 2)
+
+	for _, bad := range []string{
+		`{"projections": []}`,
+		`{"projections": [{"table": "Items", "event": "E", "abi_file": "f", "columns": [{"name": "a"}]}]}`,
+		`{"projections": [{"table": "vent_state", "event": "E", "abi_file": "f", "columns": [{"name": "a"}]}]}`,
+		`{"projections": [{"table": "t", "event": "E", "columns": [{"name": "a"}]}]}`,
+		`{"projections": [{"table": "t", "event": "E", "address": "AB", "columns": [{"name": "a"}]}]}`,
+		`{"projections": [{"table": "t", "event": "E", "abi_file": "f", "columns": [{"name": "_a"}]}]}`,
+		`{"projections": [{"table": "t", "event": "E", "abi_file": "f", "columns": [{"name": "a"}, {"name": "a"}]}]}`,
+		`{"projections": [{"table": "t", "event": "E", "abi_file": "f", "delete": true, "columns": [{"name": "a"}]}]}`,
+	} {
+		_, err := ReadProjectionSpec([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTables(t *testing.T) {
+	spec, err := ReadProjectionSpec([]byte(itemsSpec))
+	require.NoError(t, err)
+	vent, err := NewVent(nil, &testSource{abi: itemsABI}, spec, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+	require.Len(t, vent.Tables(), 1)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS items (id NUMERIC(78) NOT NULL, "+
+		"owner VARCHAR(40), name TEXT, _height BIGINT NOT NULL, PRIMARY KEY (id))",
+		vent.Tables()[0].CreateSQL())
+	assert.Equal(t, "INSERT INTO items (id, owner, name, _height) VALUES ($1, $2, $3, $4) "+
+		"ON CONFLICT (id) DO UPDATE SET owner = EXCLUDED.owner, name = EXCLUDED.name, "+
+		"_height = EXCLUDED._height", vent.projections[0].statement)
+	assert.Equal(t, "DELETE FROM items WHERE id = $1", vent.projections[1].statement)
+
+	// No ABI
+	_, err = NewVent(nil, &testSource{}, spec, loggers.NewNoopInfoTraceLogger())
+	assert.Error(t, err)
+
+	// Projections disagreeing on the primary key
+	spec.Projections[1].Columns[0].Primary = false
+	spec.Projections[1].Delete = false
+	_, err = NewVent(nil, &testSource{abi: itemsABI}, spec, loggers.NewNoopInfoTraceLogger())
+	assert.Error(t, err)
+}
+
+func TestSync(t *testing.T) {
+	spec, err := ReadProjectionSpec([]byte(itemsSpec))
+	require.NoError(t, err)
+	source := &testSource{
+		height: 3,
+		abi:    itemsABI,
+		logs: []*Log{
+			itemLog(t, 1, "ItemSet", 7, stringData(0xCD, "first")),
+			itemLog(t, 3, "ItemRemoved", 7, nil),
+			// Malformed
+			itemLog(t, 3, "ItemSet", 8, nil),
+		},
+	}
+	db, err := sql.Open("venttest", "")
+	require.NoError(t, err)
+	vent, err := NewVent(db, source, spec, loggers.NewNoopInfoTraceLogger())
+	require.NoError(t, err)
+	testDB.committed = nil
+	require.NoError(t, vent.Init())
+	assert.Len(t, testDB.committed, 3)
+
+	testDB.committed = nil
+	height, err := vent.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), height)
+	assert.Equal(t, []string{
+		vent.projections[0].statement + " [7] [00000000000000000000000000000000000000CD] [first] [1]",
+		`SELECT pg_notify($1, $2) [items] [{"table":"items","operation":"upsert","height":1,` +
+			`"row":{"id":"7","name":"first","owner":"00000000000000000000000000000000000000CD"}}]`,
+		vent.projections[1].statement + " [7]",
+		`SELECT pg_notify($1, $2) [items] [{"table":"items","operation":"delete","height":3,` +
+			`"row":{"id":"7"}}]`,
+		"UPDATE vent_state SET height = $1 [3]",
+	}, testDB.committed)
+
+	// Nothing new to project
+	testDB.committed = nil
+	height, err = vent.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), height)
+	assert.Empty(t, testDB.committed)
+}
+
+func TestRegisteredABI(t *testing.T) {
+	abiFile, err := ioutil.TempFile("", "vent")
+	require.NoError(t, err)
+	defer os.Remove(abiFile.Name())
+	_, err = abiFile.WriteString(itemsABI)
+	require.NoError(t, err)
+	require.NoError(t, abiFile.Close())
+
+	spec, err := ReadProjectionSpec([]byte(itemsSpec))
+	require.NoError(t, err)
+	for _, projection := range spec.Projections {
+		projection.ABIFile = abiFile.Name()
+	}
+	newVent := func(source Source) error {
+		_, err := NewVent(nil, source, spec, loggers.NewNoopInfoTraceLogger())
+		return err
+	}
+	// Nothing registered
+	assert.NoError(t, newVent(&testSource{}))
+	assert.NoError(t, newVent(&testSource{abiHash: sha3.Sha3([]byte(itemsABI))}))
+	assert.Error(t, newVent(&testSource{abiHash: sha3.Sha3([]byte("[]"))}))
+	assert.NoError(t, newVent(&testSource{abi: itemsABI}))
+	// The registered ABI does not index the id of ItemSet
+	assert.Error(t, newVent(&testSource{abi: strings.Replace(itemsABI,
+		`"indexed": true`, `"indexed": false`, 1)}))
+	assert.Error(t, newVent(&testSource{abi: "[]"}))
+}